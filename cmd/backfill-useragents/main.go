@@ -0,0 +1,57 @@
+// Command backfill-useragents fills in the browser, OS and device type of
+// clicks recorded before migration 015 added those columns.
+//
+// Usage:
+//
+//	go run ./cmd/backfill-useragents -batch 1000
+//
+// It reads the same DB_* environment variables as the server. Stopping it
+// (Ctrl+C) is safe: the next run continues with the rows still missing.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"url-shortener/internal/config"
+	"url-shortener/internal/repository/postgres"
+	"url-shortener/internal/service"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 1000, "Clicks updated per statement")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := postgres.InitDB(
+		ctx,
+		cfg.Database.DatabaseDSN(),
+		cfg.Database.MaxOpenConns,
+		cfg.Database.MaxIdleConns,
+		cfg.Database.ConnMaxLifetime,
+	)
+	if err != nil {
+		log.Fatalf("Database connection failed: %v", err)
+	}
+	defer db.Close()
+
+	backfill := service.NewUserAgentBackfill(postgres.NewClickBackfillRepository(db), *batchSize)
+	updated, err := backfill.Run(ctx, func(updated int64) {
+		log.Printf("Updated %d clicks", updated)
+	})
+	if err != nil {
+		log.Fatalf("Backfill stopped after %d clicks: %v", updated, err)
+	}
+	log.Printf("Done: %d clicks updated", updated)
+}
@@ -0,0 +1,300 @@
+// Command loadtest drives a running URL shortener with a mix of creates and
+// redirects and reports latency percentiles and error rates.
+//
+// Usage:
+//
+//	go run ./cmd/loadtest -target http://localhost:8080 -seed 1000 -duration 30s -concurrency 50 -create-ratio 0.1
+//
+// WHY A SEPARATE TOOL?
+// Unit tests use mocks, so they can't tell us whether a change made the CACHE
+// or the REPOSITORY slower. Running the same load before and after a change and
+// comparing p95/p99 makes performance regressions measurable.
+//
+// NOTE: disable rate limiting on the server under test (RATE_LIMIT_ENABLED=false),
+// otherwise every request after the first ~100 per minute is a 429.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+)
+
+// config holds the command-line options
+type config struct {
+	target       string
+	seed         int
+	duration     time.Duration
+	concurrency  int
+	rate         int // Total requests per second (0 = as fast as possible)
+	createRatio  float64
+	distribution string // "uniform" or "zipf" (a few hot links get most traffic)
+	timeout      time.Duration
+	maxErrorRate float64 // Exit with status 1 above this error rate (for CI)
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.target, "target", "http://localhost:8080", "Base URL of the server under test")
+	flag.IntVar(&cfg.seed, "seed", 1000, "Number of URLs to create before the test")
+	flag.DurationVar(&cfg.duration, "duration", 30*time.Second, "How long to generate load")
+	flag.IntVar(&cfg.concurrency, "concurrency", 20, "Number of concurrent workers")
+	flag.IntVar(&cfg.rate, "rate", 0, "Total requests per second (0 = unlimited)")
+	flag.Float64Var(&cfg.createRatio, "create-ratio", 0.1, "Fraction of requests that create a URL (0-1); the rest are redirects")
+	flag.StringVar(&cfg.distribution, "distribution", "zipf", "How redirects pick a code: uniform or zipf")
+	flag.DurationVar(&cfg.timeout, "timeout", 5*time.Second, "Per-request timeout")
+	flag.Float64Var(&cfg.maxErrorRate, "max-error-rate", 1, "Exit with status 1 if the overall error rate is above this (0-1)")
+	flag.Parse()
+
+	if cfg.createRatio < 0 || cfg.createRatio > 1 {
+		log.Fatalf("-create-ratio must be between 0 and 1")
+	}
+	if cfg.distribution != "uniform" && cfg.distribution != "zipf" {
+		log.Fatalf("-distribution must be uniform or zipf")
+	}
+	if cfg.seed < 1 && cfg.createRatio < 1 {
+		log.Fatalf("-seed must be at least 1 when the mix contains redirects")
+	}
+
+	// Ctrl+C stops the test early but still prints the report
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client := &http.Client{
+		Timeout: cfg.timeout,
+		// Measure the shortener itself, not the destination sites
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Transport: &http.Transport{
+			MaxIdleConns:        cfg.concurrency,
+			MaxIdleConnsPerHost: cfg.concurrency,
+		},
+	}
+	lt := &loadTester{cfg: cfg, client: client}
+
+	fmt.Printf("Seeding %d URLs on %s...\n", cfg.seed, cfg.target)
+	codes, err := lt.seedURLs(ctx)
+	if err != nil {
+		log.Fatalf("Seeding failed: %v", err)
+	}
+
+	fmt.Printf("Running for %s: %d workers, %.0f%% creates, %s redirects\n",
+		cfg.duration, cfg.concurrency, cfg.createRatio*100, cfg.distribution)
+	report := lt.run(ctx, codes)
+	report.print(os.Stdout)
+
+	if report.errorRate() > cfg.maxErrorRate {
+		fmt.Printf("\nFAIL: error rate %.2f%% is above the limit of %.2f%%\n",
+			report.errorRate()*100, cfg.maxErrorRate*100)
+		os.Exit(1)
+	}
+}
+
+// loadTester sends the requests
+type loadTester struct {
+	cfg    config
+	client *http.Client
+}
+
+// seedURLs creates cfg.seed URLs in parallel and returns their short codes
+func (lt *loadTester) seedURLs(ctx context.Context) ([]string, error) {
+	jobs := make(chan int)
+	results := make(chan string)
+	errs := make(chan error, lt.cfg.concurrency)
+
+	var wg sync.WaitGroup
+	for w := 0; w < lt.cfg.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				code, _, err := lt.createURL(ctx, i)
+				if err != nil {
+					errs <- err
+					return
+				}
+				results <- code
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := 0; i < lt.cfg.seed; i++ {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	codes := make([]string, 0, lt.cfg.seed)
+	for code := range results {
+		codes = append(codes, code)
+	}
+
+	// Fail fast: a seeding error usually means the server is down or rate limited
+	select {
+	case err := <-errs:
+		return nil, err
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// run generates load until cfg.duration has passed or ctx is canceled
+func (lt *loadTester) run(ctx context.Context, codes []string) *report {
+	ctx, cancel := context.WithTimeout(ctx, lt.cfg.duration)
+	defer cancel()
+
+	// Optional pacing: one token per request, shared by all workers
+	var tokens <-chan time.Time
+	if lt.cfg.rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(lt.cfg.rate))
+		defer ticker.Stop()
+		tokens = ticker.C
+	}
+
+	rep := newReport()
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	for w := 0; w < lt.cfg.concurrency; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			// math/rand sources are not safe for concurrent use: one per worker
+			rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(worker)))
+			pick := lt.picker(rng, len(codes))
+			seq := worker * 1_000_000 // Unique destination URLs across workers
+
+			for {
+				if tokens != nil {
+					select {
+					case <-tokens:
+					case <-ctx.Done():
+						return
+					}
+				}
+				if ctx.Err() != nil {
+					return
+				}
+
+				op := opRedirect
+				if rng.Float64() < lt.cfg.createRatio {
+					op = opCreate
+				}
+
+				reqStart := time.Now()
+				var status int
+				var err error
+				if op == opCreate {
+					seq++
+					_, status, err = lt.createURL(ctx, lt.cfg.seed+seq)
+				} else {
+					status, err = lt.redirect(ctx, codes[pick()])
+				}
+
+				// Requests cut off by the end of the test are not failures
+				if ctx.Err() != nil {
+					return
+				}
+				rep.record(op, status, err, time.Since(reqStart))
+			}
+		}(w)
+	}
+
+	wg.Wait()
+	rep.elapsed = time.Since(start)
+	return rep
+}
+
+// picker returns a function that chooses the index of the next code to redirect
+//
+// ZIPF vs UNIFORM:
+// Real traffic is skewed - a handful of viral links get most clicks. A Zipf
+// distribution reproduces that and mostly exercises the CACHE. Uniform spreads
+// requests over every code and pushes more of them to the DATABASE.
+func (lt *loadTester) picker(rng *rand.Rand, n int) func() int {
+	if n == 0 {
+		return func() int { return 0 }
+	}
+	if lt.cfg.distribution == "zipf" && n > 1 {
+		zipf := rand.NewZipf(rng, 1.1, 1, uint64(n-1))
+		return func() int { return int(zipf.Uint64()) }
+	}
+	return func() int { return rng.Intn(n) }
+}
+
+// createURL creates a short URL and returns its code and the HTTP status
+func (lt *loadTester) createURL(ctx context.Context, n int) (string, int, error) {
+	body, err := json.Marshal(v1.CreateURLRequest{
+		URL: fmt.Sprintf("https://example.com/loadtest/%d?r=%d", n, rand.Int63()),
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, lt.cfg.target+"/api/v1/urls", bytes.NewReader(body))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := lt.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", resp.StatusCode, fmt.Errorf("create: unexpected status %d", resp.StatusCode)
+	}
+
+	var created v1.CreateURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", resp.StatusCode, fmt.Errorf("create: invalid response: %w", err)
+	}
+	return created.ShortCode, resp.StatusCode, nil
+}
+
+// redirect requests a short code without following the redirect
+func (lt *loadTester) redirect(ctx context.Context, code string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lt.cfg.target+"/"+code, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := lt.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMovedPermanently && resp.StatusCode != http.StatusFound {
+		return resp.StatusCode, fmt.Errorf("redirect: unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
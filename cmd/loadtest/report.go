@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Operations measured separately (creates hit the database, redirects mostly the cache)
+const (
+	opCreate   = "create"
+	opRedirect = "redirect"
+)
+
+// report collects the outcome of every request
+type report struct {
+	mu      sync.Mutex
+	ops     map[string]*opStats
+	elapsed time.Duration
+}
+
+// opStats holds the results of one operation
+type opStats struct {
+	latencies []time.Duration // Successful requests only
+	errors    int
+	statuses  map[int]int // Status code -> count (0 = transport error or timeout)
+}
+
+func newReport() *report {
+	return &report{ops: map[string]*opStats{
+		opCreate:   {statuses: make(map[int]int)},
+		opRedirect: {statuses: make(map[int]int)},
+	}}
+}
+
+// record adds one request to the report
+func (r *report) record(op string, status int, err error, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.ops[op]
+	stats.statuses[status]++
+	if err != nil {
+		stats.errors++
+		return
+	}
+	stats.latencies = append(stats.latencies, latency)
+}
+
+// errorRate returns failed requests / all requests across operations
+func (r *report) errorRate() float64 {
+	total, errors := 0, 0
+	for _, stats := range r.ops {
+		total += len(stats.latencies) + stats.errors
+		errors += stats.errors
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(errors) / float64(total)
+}
+
+// print writes a summary table
+//
+// WHY PERCENTILES AND NOT THE AVERAGE?
+// An average hides the slow tail: 99 requests at 1ms and one at 1s average
+// out to ~11ms. p99 says "1 in 100 users waited at least this long".
+func (r *report) print(w io.Writer) {
+	fmt.Fprintf(w, "\nCompleted in %s\n\n", r.elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "%-10s %9s %8s %9s %10s %10s %10s %10s\n",
+		"op", "requests", "errors", "req/s", "p50", "p95", "p99", "max")
+
+	for _, op := range []string{opCreate, opRedirect} {
+		stats := r.ops[op]
+		total := len(stats.latencies) + stats.errors
+		if total == 0 {
+			continue
+		}
+
+		sort.Slice(stats.latencies, func(i, j int) bool { return stats.latencies[i] < stats.latencies[j] })
+		fmt.Fprintf(w, "%-10s %9d %7.2f%% %9.1f %10s %10s %10s %10s\n",
+			op,
+			total,
+			float64(stats.errors)/float64(total)*100,
+			float64(total)/r.elapsed.Seconds(),
+			percentile(stats.latencies, 0.50),
+			percentile(stats.latencies, 0.95),
+			percentile(stats.latencies, 0.99),
+			percentile(stats.latencies, 1),
+		)
+	}
+
+	// Show which status codes caused the errors (e.g. 429 = rate limited)
+	for _, op := range []string{opCreate, opRedirect} {
+		stats := r.ops[op]
+		if stats.errors == 0 {
+			continue
+		}
+		codes := make([]int, 0, len(stats.statuses))
+		for code := range stats.statuses {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		fmt.Fprintf(w, "\n%s status codes:", op)
+		for _, code := range codes {
+			label := fmt.Sprint(code)
+			if code == 0 {
+				label = "network"
+			}
+			fmt.Fprintf(w, " %s=%d", label, stats.statuses[code])
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "\nOverall error rate: %.2f%%\n", r.errorRate()*100)
+}
+
+// percentile returns the p-th percentile (0-1) of sorted latencies
+// Uses the nearest-rank method
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(sorted)) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1].Round(10 * time.Microsecond)
+}
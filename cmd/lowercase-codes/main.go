@@ -0,0 +1,94 @@
+// Command lowercase-codes lowercases the short codes and aliases of links
+// created before SHORT_CODE_CASE=insensitive.
+//
+// Usage:
+//
+//	go run ./cmd/lowercase-codes          # dry run: report what would change
+//	go run ./cmd/lowercase-codes -apply   # lowercase the links
+//
+// Switch the server to SHORT_CODE_CASE=insensitive BEFORE applying: old
+// spellings then still find their link (cached copies and printed links
+// included), they just no longer need to be typed exactly.
+//
+// It reads the same DB_* and SHARD_* environment variables as the server and
+// migrates every shard. Links whose lowercase code is taken, or would belong
+// on another shard, are listed and left alone. Stopping it (Ctrl+C) is safe:
+// the next run continues with the links still in mixed case.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"url-shortener/internal/config"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository/postgres"
+	"url-shortener/internal/service"
+	"url-shortener/internal/shard"
+)
+
+func main() {
+	apply := flag.Bool("apply", false, "Lowercase the links (default: dry run)")
+	batchSize := flag.Int("batch", 1000, "Links read per query")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	shardMap, err := shard.NewMap(slices.Collect(maps.Keys(cfg.Database.Shards)), cfg.Database.ShardPrefixes)
+	if err != nil {
+		log.Fatalf("Invalid shard configuration: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for i, name := range shardMap.Names() {
+		dsn := cfg.Database.DatabaseDSN()
+		if i > 0 {
+			dsn = cfg.Database.Shards[name]
+		}
+
+		db, err := postgres.InitDB(
+			ctx,
+			dsn,
+			cfg.Database.MaxOpenConns,
+			cfg.Database.MaxIdleConns,
+			cfg.Database.ConnMaxLifetime,
+		)
+		if err != nil {
+			log.Fatalf("Database connection failed (shard %s): %v", name, err)
+		}
+
+		migration := service.NewCodeCaseMigration(postgres.NewCodeCaseRepository(db), *batchSize).
+			WithRouting(shardMap.For)
+		report, err := migration.Run(ctx, *apply, func(report *domain.CodeCaseReport) {
+			log.Printf("Shard %s: %d of %d links lowercased", name, report.Lowercased, report.Scanned)
+		})
+		db.Close()
+		if err != nil {
+			log.Fatalf("Migration stopped on shard %s: %v", name, err)
+		}
+
+		for _, code := range report.Conflicts {
+			log.Printf("Shard %s: %s left alone, its lowercase code is taken", name, code)
+		}
+		for _, code := range report.Moved {
+			log.Printf("Shard %s: %s left alone, its lowercase code belongs on another shard", name, code)
+		}
+		log.Printf("Shard %s done: %d lowercased, %d conflicts, %d on the wrong shard",
+			name, report.Lowercased, len(report.Conflicts), len(report.Moved))
+	}
+
+	if !*apply {
+		log.Printf("Dry run: nothing was changed (run with -apply)")
+	}
+}
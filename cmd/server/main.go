@@ -2,23 +2,66 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"html/template"
 	"log"
+	"maps"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"syscall"
 	"time"
 
+	"url-shortener/internal/abuse"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/billing"
+	urlcache "url-shortener/internal/cache"
+	"url-shortener/internal/captcha"
+	"url-shortener/internal/cdn"
 	"url-shortener/internal/config"
+	"url-shortener/internal/crypto/aesgcm"
+	"url-shortener/internal/crypto/envelope"
+	"url-shortener/internal/crypto/keys"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/encoders"
+	"url-shortener/internal/errreport"
+	"url-shortener/internal/faults"
+	"url-shortener/internal/featureflags"
 	httpHandler "url-shortener/internal/handler/http"
+	"url-shortener/internal/i18n"
+	"url-shortener/internal/jobs"
+	"url-shortener/internal/leader"
+	"url-shortener/internal/metadata"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/notify"
 	"url-shortener/internal/ratelimit"
+	"url-shortener/internal/referrer"
+	"url-shortener/internal/region"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/repository/memcached"
 	"url-shortener/internal/repository/postgres"
 	redisrepo "url-shortener/internal/repository/redis"
+	"url-shortener/internal/resilience"
+	"url-shortener/internal/resolver"
+	"url-shortener/internal/scheduler"
 	"url-shortener/internal/service"
+	"url-shortener/internal/shard"
+	"url-shortener/internal/shortcode"
+	"url-shortener/internal/startup"
+	"url-shortener/internal/storage/blob"
+	"url-shortener/internal/warehouse"
 	"url-shortener/pkg/logger"
 
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -35,15 +78,82 @@ func main() {
 		"port", cfg.Server.Port,
 	)
 
-	// Initialize database connection
 	ctx := context.Background()
-	db, err := postgres.InitDB(
-		ctx,
-		cfg.Database.DatabaseDSN(),
-		cfg.Database.MaxOpenConns,
-		cfg.Database.MaxIdleConns,
-		cfg.Database.ConnMaxLifetime,
-	)
+
+	// Error tracker: panics and 5xx responses of the HTTP servers, panics of
+	// the background workers (errreport.Nop when SENTRY_DSN is empty)
+	reporter := buildErrorReporter(cfg)
+	if _, ok := reporter.(errreport.Nop); !ok {
+		appLogger.Info("Error reporting enabled", "environment", cfg.Errors.Environment, "sample_rate", cfg.Errors.SampleRate)
+	}
+
+	// Key manager (local keys, AWS KMS or GCP KMS): decrypts the kv1:...
+	// secrets of the configuration first, before anything uses them
+	keyManager, err := keys.New(cfg.KMS)
+	if err != nil {
+		log.Fatalf("Invalid key manager settings: %v", err)
+	}
+	if err := decryptSecrets(ctx, keyManager, cfg); err != nil {
+		log.Fatalf("Failed to decrypt secrets: %v", err)
+	}
+	if keyManager != nil {
+		version, err := keyManager.Current(ctx)
+		if err != nil {
+			log.Fatalf("Key manager unavailable: %v", err)
+		}
+		provider := cfg.KMS.Provider
+		if provider == "" {
+			provider = "local"
+		}
+		appLogger.Info("Key manager enabled", "provider", provider, "key_version", version)
+	}
+
+	// STARTUP ORDERING:
+	// In docker compose or on Kubernetes, PostgreSQL and Redis may come up
+	// after us. Each dependency is retried with backoff until STARTUP_TIMEOUT
+	// instead of exiting at the first refused connection. With STARTUP_PARTIAL
+	// the listeners start right away behind a gate: /health/live says alive,
+	// /health/ready says "starting" (and what we wait for), the rest gets 503.
+	readiness := startup.NewTracker(appLogger.Logger)
+	startupCtx, cancelStartup := context.WithTimeout(ctx, cfg.App.StartupTimeout)
+	defer cancelStartup()
+
+	publicGate := httpHandler.NewStartupGate(readiness)
+	adminGate := httpHandler.NewStartupGate(readiness)
+	server := &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      publicGate,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	var adminServer *http.Server
+	if cfg.Server.AdminPort != "" {
+		adminServer = &http.Server{
+			Addr:        ":" + cfg.Server.AdminPort,
+			Handler:     adminGate,
+			ReadTimeout: cfg.Server.ReadTimeout,
+			// No WriteTimeout: CPU profiles and traces stream for ?seconds=N
+			IdleTimeout: cfg.Server.IdleTimeout,
+		}
+	}
+	if cfg.App.StartupPartial {
+		listen(appLogger, "Server", server)
+		if adminServer != nil {
+			listen(appLogger, "Admin server", adminServer)
+		}
+	}
+
+	// Initialize database connection
+	db, err := startup.Connect(startupCtx, readiness, "postgres", func(ctx context.Context) (*pgxpool.Pool, error) {
+		return postgres.InitDB(
+			ctx,
+			cfg.Database.DatabaseDSN(),
+			cfg.Database.MaxOpenConns,
+			cfg.Database.MaxIdleConns,
+			cfg.Database.ConnMaxLifetime,
+		)
+	})
 	if err != nil {
 		appLogger.Error("Failed to connect to database", "error", err)
 		log.Fatalf("Database connection failed: %v", err)
@@ -52,11 +162,13 @@ func main() {
 	appLogger.Info("Database connection established")
 
 	// Initialize Redis connection
-	redisClient, err := redisrepo.InitRedis(
-		cfg.Redis.RedisAddr(),
-		cfg.Redis.Password,
-		cfg.Redis.DB,
-	)
+	redisClient, err := startup.Connect(startupCtx, readiness, "redis", func(context.Context) (*redis.Client, error) {
+		return redisrepo.InitRedis(
+			cfg.Redis.RedisAddr(),
+			cfg.Redis.Password,
+			cfg.Redis.DB,
+		)
+	})
 	if err != nil {
 		appLogger.Error("Failed to connect to Redis", "error", err)
 		log.Fatalf("Redis connection failed: %v", err)
@@ -64,19 +176,732 @@ func main() {
 	defer redisClient.Close()
 	appLogger.Info("Redis connection established")
 
-	// Initialize cache
-	cache := redisrepo.NewCache(redisClient, cfg.Redis.CacheTTL)
+	// Export connection pool health on /metrics-raw (read at scrape time)
+	prometheus.MustRegister(
+		metrics.NewPgxPoolCollector(db),
+		metrics.NewRedisPoolCollector(redisClient),
+	)
+
+	// Dependencies checked by /health/ready
+	readinessChecks := []httpHandler.DependencyCheck{
+		{Name: "postgres", Check: db.Ping},
+		{Name: "redis", Check: func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}},
+	}
+
+	// Sharding (optional): links and their clicks spread over several
+	// databases, picked by the first character of the short code
+	shardMap, err := shard.NewMap(slices.Collect(maps.Keys(cfg.Database.Shards)), cfg.Database.ShardPrefixes)
+	if err != nil {
+		log.Fatalf("Invalid shard configuration: %v", err)
+	}
+	pools := []*pgxpool.Pool{db} // Indexed like shardMap: the main database is shard 0
+	for _, name := range shardMap.Names()[1:] {
+		pool, err := startup.Connect(startupCtx, readiness, "postgres-"+name, func(ctx context.Context) (*pgxpool.Pool, error) {
+			return postgres.InitDB(
+				ctx,
+				cfg.Database.Shards[name],
+				cfg.Database.MaxOpenConns,
+				cfg.Database.MaxIdleConns,
+				cfg.Database.ConnMaxLifetime,
+			)
+		})
+		if err != nil {
+			log.Fatalf("Database connection failed (shard %s): %v", name, err)
+		}
+		defer pool.Close()
+		pools = append(pools, pool)
+		readinessChecks = append(readinessChecks, httpHandler.DependencyCheck{Name: "postgres-" + name, Check: pool.Ping})
+	}
+	if shardMap.Len() > 1 {
+		appLogger.Info("Sharding enabled", "shards", shardMap.Names())
+	}
+
+	// Edge snapshot: every shard numbers its own link changes
+	edgeShards := make([]repository.EdgeSnapshotRepository, len(pools))
+	for i, pool := range pools {
+		edgeShards[i] = postgres.NewEdgeSnapshotRepository(pool)
+	}
+	edgeSnapshots := service.NewEdgeSnapshotService(edgeShards...)
+
+	// Multi-region (optional): a secondary region serves redirects from its
+	// read replica and sends writes to the primary region
+	regionCfg := region.Config{Name: cfg.Region.Name, Primary: cfg.Region.Primary}
+	var primaryRegionURL *url.URL
+	var primaryDB *pgxpool.Pool
+	if !regionCfg.IsPrimary() {
+		if shardMap.Len() > 1 {
+			log.Fatalf("Sharding is not supported in a secondary region (REGION=%s)", regionCfg.Name)
+		}
+		primaryRegionURL, err = url.Parse(cfg.Region.PrimaryURL)
+		if err != nil || primaryRegionURL.Scheme == "" || primaryRegionURL.Host == "" {
+			log.Fatalf("Invalid PRIMARY_REGION_URL %q: a secondary region needs the primary region's base URL", cfg.Region.PrimaryURL)
+		}
+		if cfg.Region.PrimaryDatabaseDSN == "" {
+			log.Fatalf("PRIMARY_DATABASE_DSN is required in a secondary region (clicks are written there)")
+		}
+		primaryDB, err = startup.Connect(startupCtx, readiness, "postgres-primary-region", func(ctx context.Context) (*pgxpool.Pool, error) {
+			return postgres.InitDB(
+				ctx,
+				cfg.Region.PrimaryDatabaseDSN,
+				cfg.Database.MaxOpenConns,
+				cfg.Database.MaxIdleConns,
+				cfg.Database.ConnMaxLifetime,
+			)
+		})
+		if err != nil {
+			log.Fatalf("Database connection failed (primary region): %v", err)
+		}
+		defer primaryDB.Close()
+		readinessChecks = append(readinessChecks, httpHandler.DependencyCheck{Name: "postgres-primary-region", Check: primaryDB.Ping})
+		appLogger.Info("Running as a secondary region",
+			"region", regionCfg.Name,
+			"primary_region", regionCfg.Primary,
+			"primary_url", primaryRegionURL.String(),
+		)
+	}
+
+	// Initialize cache (Redis or Memcached - both store the same entries)
+	cacheCodec := urlcache.Codec(cfg.Redis.Codec)
+	if !cacheCodec.Valid() {
+		log.Fatalf("Invalid CACHE_CODEC %q (use binary or json)", cfg.Redis.Codec)
+	}
+	var cacheBackend urlcache.Cache
+	var enableStaleWhileRevalidate func(staleTTL time.Duration, refresh urlcache.RefreshFunc)
+	switch cfg.Redis.CacheDriver {
+	case "redis":
+		redisCache := redisrepo.NewCache(redisClient, cfg.Redis.CacheTTL).WithCodec(cacheCodec)
+		cacheBackend = redisCache
+		enableStaleWhileRevalidate = func(staleTTL time.Duration, refresh urlcache.RefreshFunc) {
+			redisCache.WithStaleWhileRevalidate(staleTTL, refresh)
+		}
+	case "memcached":
+		memcachedClient, err := startup.Connect(startupCtx, readiness, "memcached", func(context.Context) (*memcache.Client, error) {
+			return memcached.InitMemcached(cfg.Redis.MemcachedServers)
+		})
+		if err != nil {
+			appLogger.Error("Failed to connect to Memcached", "error", err)
+			log.Fatalf("Memcached connection failed: %v", err)
+		}
+		defer memcachedClient.Close()
+		appLogger.Info("Memcached connection established", "servers", cfg.Redis.MemcachedServers)
+
+		readinessChecks = append(readinessChecks, httpHandler.DependencyCheck{
+			Name:  "memcached",
+			Check: func(context.Context) error { return memcachedClient.Ping() },
+		})
+
+		memcachedCache := memcached.NewCache(memcachedClient, cfg.Redis.CacheTTL).WithCodec(cacheCodec)
+		cacheBackend = memcachedCache
+		enableStaleWhileRevalidate = func(staleTTL time.Duration, refresh urlcache.RefreshFunc) {
+			memcachedCache.WithStaleWhileRevalidate(staleTTL, refresh)
+		}
+	default:
+		log.Fatalf("Invalid CACHE_DRIVER %q (use redis or memcached)", cfg.Redis.CacheDriver)
+	}
+	cacheBackend = urlcache.NewInstrumented(cacheBackend) // Same cache metrics for every backend
 
 	// Initialize repositories (Data Access Layer)
-	urlRepo := postgres.NewURLRepository(db)
-	clickRepo := postgres.NewClickRepository(db)
+	var urlRepo repository.URLRepository = postgres.NewStripedURLRepository(db, cfg.App.ClickStripes)
+	var clickRepo repository.ClickRepository = postgres.NewClickRepository(db)
+	var exportRepo repository.ExportRepository = postgres.NewExportRepository(db)
+	var erasureRepo repository.ErasureRepository = postgres.NewErasureRepository(db)
+	var searchRepo repository.SearchRepository = postgres.NewSearchRepository(db)
+	var popularAliasRepo repository.PopularAliasRepository = postgres.NewPopularAliasRepository(db)
+	var approvalRepo repository.ApprovalRepository = postgres.NewApprovalRepository(db)
+	if shardMap.Len() > 1 {
+		urlShards := make([]repository.URLRepository, len(pools))
+		clickShards := make([]repository.ClickRepository, len(pools))
+		exportShards := make([]repository.ExportRepository, len(pools))
+		erasureShards := make([]repository.ErasureRepository, len(pools))
+		searchShards := make([]repository.SearchRepository, len(pools))
+		popularAliasShards := make([]repository.PopularAliasRepository, len(pools))
+		approvalShards := make([]repository.ApprovalRepository, len(pools))
+		for i, pool := range pools {
+			urlShards[i] = postgres.NewStripedURLRepository(pool, cfg.App.ClickStripes)
+			clickShards[i] = postgres.NewClickRepository(pool)
+			exportShards[i] = postgres.NewExportRepository(pool)
+			erasureShards[i] = postgres.NewErasureRepository(pool)
+			searchShards[i] = postgres.NewSearchRepository(pool)
+			popularAliasShards[i] = postgres.NewPopularAliasRepository(pool)
+			approvalShards[i] = postgres.NewApprovalRepository(pool)
+		}
+		urlRepo = shard.NewURLRepository(shardMap, urlShards)
+		clickRepo = shard.NewClickRepository(shardMap, clickShards)
+		exportRepo = shard.NewExportRepository(exportShards)
+		erasureRepo = shard.NewErasureRepository(erasureShards)
+		searchRepo = shard.NewSearchRepository(searchShards)
+		popularAliasRepo = shard.NewPopularAliasRepository(popularAliasShards)
+		approvalRepo = shard.NewApprovalRepository(approvalShards)
+	}
+	if primaryDB != nil {
+		urlRepo = region.NewURLRepository(urlRepo, postgres.NewStripedURLRepository(primaryDB, cfg.App.ClickStripes))
+		clickRepo = region.NewClickRepository(clickRepo, postgres.NewClickRepository(primaryDB))
+	}
+
+	// Chaos testing: make Postgres/Redis fail or slow down on purpose
+	if cfg.Faults.Enabled {
+		if cfg.App.Environment == "production" {
+			appLogger.Warn("FAULT_INJECTION_ENABLED is ignored in production")
+		} else {
+			dbFaults := faults.NewInjector(cfg.Faults.DBErrorRate, cfg.Faults.DBLatency)
+			urlRepo = faults.NewURLRepository(urlRepo, dbFaults)
+			clickRepo = faults.NewClickRepository(clickRepo, dbFaults)
+
+			redisFaults := faults.NewInjector(cfg.Faults.RedisErrorRate, cfg.Faults.RedisLatency)
+			redisClient.AddHook(faults.RedisHook(redisFaults)) // Covers the rate limiter (and the cache when it is Redis)
+
+			appLogger.Warn("Fault injection enabled",
+				"db_error_rate", cfg.Faults.DBErrorRate,
+				"db_latency", cfg.Faults.DBLatency,
+				"redis_error_rate", cfg.Faults.RedisErrorRate,
+				"redis_latency", cfg.Faults.RedisLatency,
+			)
+		}
+	}
+
+	// Timeouts and retries for transient failures (wraps the fault injection
+	// above, so injected failures exercise the retry logic too)
+	dbReads := resilience.Policy{
+		Timeout:    cfg.Database.ReadTimeout,
+		MaxRetries: cfg.Database.MaxRetries,
+		BaseDelay:  cfg.Database.RetryBaseDelay,
+		MaxDelay:   cfg.Database.RetryMaxDelay,
+	}
+	dbWrites := dbReads
+	dbWrites.Timeout = cfg.Database.WriteTimeout
+	urlRepo = resilience.NewURLRepository(urlRepo, dbReads, dbWrites)
+	clickRepo = resilience.NewClickRepository(clickRepo, dbReads, dbWrites)
+	cache := resilience.NewCache(cacheBackend, resilience.Policy{
+		Timeout:    cfg.Redis.OpTimeout,
+		MaxRetries: cfg.Redis.MaxRetries,
+		BaseDelay:  5 * time.Millisecond,
+		MaxDelay:   50 * time.Millisecond,
+	})
 
 	// Initialize services (Business Logic Layer)
-	urlService := service.NewURLService(urlRepo, clickRepo, cache)
+	codeCase, err := domain.ParseCodeCase(cfg.App.ShortCodeCase)
+	if err != nil {
+		log.Fatalf("Invalid SHORT_CODE_CASE %q: %v", cfg.App.ShortCodeCase, err)
+	}
+	alphabet := cfg.App.ShortCodeAlphabet
+	if codeCase == domain.CodeCaseInsensitive {
+		alphabet = shortcode.FoldAlphabet(alphabet) // Codes are stored lowercase, so only generate lowercase
+	}
+	codeGenerator, err := shortcode.NewGenerator(
+		alphabet,
+		cfg.App.ShortCodeLength,
+		cfg.App.ShortCodeDomains,
+	)
+	if err != nil {
+		log.Fatalf("Invalid short code configuration: %v", err)
+	}
+
+	// Per-workspace defaults, e.g. the timezone analytics are bucketed in
+	workspaceSettings := postgres.NewWorkspaceSettingsRepository(db)
+
+	// Read-through cache in front of the links: the service never talks to
+	// the cache itself, the decorator serves lookups and forgets changed links
+	cachedURLs := urlcache.NewCachedURLRepository(urlRepo, cache).WithBackend(cfg.Redis.CacheDriver)
+
+	urlService := service.NewURLService(cachedURLs, clickRepo).
+		WithCodeGenerator(codeGenerator).
+		WithCodePool(cfg.App.ShortCodeBatchSize).
+		WithCodeCase(codeCase).
+		WithAliasLocks(redisrepo.NewLocker(redisClient)). // Two requests for the same alias: the second gets 409 right away
+		WithClickDedup(redisrepo.NewClickDeduplicator(redisClient), cfg.App.ClickDedupWindow).
+		WithReferrerClassifier(referrer.NewClassifier(cfg.App.InternalHosts...)).
+		WithRegion(regionCfg.Name).
+		WithAnalytics(cfg.App.EnableAnalytics). // Off: clicks are counted, no click events stored
+		WithConsentSignals(cfg.App.HonorDoNotTrack).
+		WithAnalyticsTimezone(cfg.App.AnalyticsTimezone).
+		WithWorkspaceSettings(workspaceSettings)
+
+	// Plan quotas: monthly link limits for authenticated callers
+	var quotaService *service.QuotaService
+	if cfg.App.QuotasEnabled {
+		plans := map[string]domain.Plan{
+			domain.PlanFree: {Name: domain.PlanFree, MonthlyLinks: cfg.App.QuotaFreeLinks},
+			domain.PlanPro:  {Name: domain.PlanPro, MonthlyLinks: cfg.App.QuotaProLinks},
+		}
+		quotaService, err = service.NewQuotaService(postgres.NewUsageRepository(db), plans, cfg.App.QuotaDefaultPlan)
+		if err != nil {
+			log.Fatalf("Invalid quota configuration: %v", err)
+		}
+		quotaService.WithCounter(redisrepo.NewUsageCounter(redisClient))
+		urlService.WithQuotas(quotaService)
+	}
+
+	// Stripe billing: webhooks keep subscriptions in sync, and the quota
+	// layer picks each owner's plan from their subscription
+	var billingService *service.BillingService
+	if cfg.Billing.Enabled() {
+		billingService = service.NewBillingService(
+			postgres.NewSubscriptionRepository(db),
+			billing.NewClient(cfg.Billing.StripeSecretKey, cfg.Billing.APITimeout),
+			cfg.Billing.ProductPlans,
+			cfg.Billing.PortalReturnURL,
+		)
+		if quotaService != nil {
+			quotaService.WithPlanResolver(billingService)
+		}
+		appLogger.Info("Stripe billing enabled", "products", len(cfg.Billing.ProductPlans))
+	}
+
+	// CDN purging: edited and deleted links leave the edge cache right away
+	edgePurger := buildEdgePurger(cfg.CDN)
+	if edgePurger != nil {
+		urlService.WithEdgePurger(edgePurger)
+		appLogger.Info("CDN purging enabled", "provider", cfg.CDN.Provider, "edge_ttl", cfg.CDN.EdgeTTL)
+	}
+
+	// Stale-while-revalidate: serve expired entries once more while the
+	// links are reloaded from the database in the background
+	if cfg.Redis.StaleTTL > 0 {
+		enableStaleWhileRevalidate(cfg.Redis.StaleTTL, cachedURLs.Refresh)
+	}
+
+	// Optional: stop cache misses from piling up on an overloaded database
+	if cfg.Database.BreakerFailures > 0 {
+		cachedURLs.WithBreaker(resilience.NewCircuitBreaker(
+			"postgres_redirect",
+			cfg.Database.BreakerFailures,
+			cfg.Database.BreakerOpenTimeout,
+		))
+	}
+
+	// Optional: unwrap destinations to detect links hidden behind other shorteners
+	if cfg.App.ResolveDestinations {
+		urlService.WithResolver(
+			resolver.New(cfg.App.ResolveMaxHops, cfg.App.ResolveTimeout),
+			cfg.App.RejectRedirectors,
+		)
+		appLogger.Info("Destination resolution enabled",
+			"max_hops", cfg.App.ResolveMaxHops,
+			"reject_redirectors", cfg.App.RejectRedirectors,
+		)
+	}
+
+	// Redirect chain preview (GET /api/v1/urls/{code}/resolve) - same bounds
+	// as creation-time resolution, but always on so owners can debug links
+	urlService.WithRedirectTracer(resolver.New(cfg.App.ResolveMaxHops, cfg.App.ResolveTimeout))
+
+	// Optional: show links by the title of their destination page
+	if cfg.App.FetchMetadata {
+		urlService.WithMetadata(
+			metadata.New(cfg.App.MetadataTimeout, int64(cfg.App.MetadataMaxBytes)),
+			cfg.App.MetadataConcurrency,
+		)
+		appLogger.Info("Destination metadata fetching enabled", "concurrency", cfg.App.MetadataConcurrency)
+	}
+
+	// Destination domain rules: allow/deny lists checked at creation, on
+	// updates and on restore. Every instance keeps the rules in memory.
+	domainPolicy := service.NewDomainPolicyService(postgres.NewDomainRuleRepository(db))
+	if err := domainPolicy.Reload(ctx); err != nil {
+		appLogger.Warn("Failed to load domain rules, retrying in the background", "error", err)
+	}
+	urlService.WithDestinationPolicy(domainPolicy)
+
+	// Confusable aliases: "paypa1" next to a popular "paypal" is refused or
+	// flagged. Every instance keeps the most clicked aliases in memory.
+	confusablePolicy, err := domain.ParseConfusablePolicy(cfg.App.ConfusableAliases)
+	if err != nil {
+		log.Fatalf("Invalid CONFUSABLE_ALIASES %q: %v", cfg.App.ConfusableAliases, err)
+	}
+	confusableAliases := service.NewConfusableAliasService(popularAliasRepo, cfg.App.ConfusableTopN)
+	urlService.WithConfusableAliases(confusableAliases, confusablePolicy)
+
+	// Encrypted destinations: sensitive links keep theirs encrypted at rest,
+	// with data keys wrapped by the key manager
+	var destinationSealer *envelope.Sealer
+	if keyManager != nil {
+		destinationSealer = envelope.New(keyManager)
+		urlService.WithDestinationSealing(destinationSealer)
+	}
+
+	// Background workers stop when this context is canceled during shutdown
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+
+	// Rules changed on other instances apply here after the next reload
+	go domainPolicy.Run(workerCtx, cfg.App.DomainPolicyRefresh)
+	if confusablePolicy != domain.ConfusableOff {
+		go confusableAliases.Run(workerCtx, cfg.App.ConfusableRefresh)
+	}
+
+	// Link warnings: notify owners before links hit their click limit or expire
+	notifier := buildNotifier(cfg.Notify)
+
+	// Side effects of link events (see package events); the service publishes
+	// them without knowing who listens. Cache invalidation is built in.
+	auditRepo := postgres.NewAuditRepository(db)
+	linkEvents := urlService.Events()
+	service.SubscribeMetrics(linkEvents)
+	service.SubscribeAudit(linkEvents, auditRepo)
+	if cfg.Notify.LinkEvents {
+		service.SubscribeNotifications(linkEvents, notifier)
+	}
+
+	// Account deletion (GDPR erasure): requests are processed in the background
+	erasureService := service.NewErasureService(erasureRepo, cache)
+	if edgePurger != nil {
+		erasureService.WithEdgePurger(edgePurger)
+	}
+
+	// Background jobs: long operations answer 202 with a job to poll
+	// Job kinds are registered on jobQueue before the workers start below
+	jobQueue := jobs.New(postgres.NewJobRepository(db)).
+		WithRetries(cfg.App.JobMaxAttempts, 30*time.Second, 30*time.Minute).
+		WithErrorReporter(reporter)
+
+	// Custom domains: links are only served on (and created for) domains
+	// whose owner published our TXT record. Every instance keeps the
+	// domains in memory; the TXT lookups run as background jobs.
+	customDomains := service.NewCustomDomainService(postgres.NewCustomDomainRepository(db), net.DefaultResolver).
+		WithJobs(jobQueue).
+		WithTrustedHosts(cfg.App.TrustedDomains...).
+		WithRecheckInterval(cfg.App.DomainRecheck)
+	jobQueue.Register(service.DomainVerifyJob, customDomains.VerifyJob)
+	if err := customDomains.Reload(ctx); err != nil {
+		appLogger.Warn("Failed to load custom domains, retrying in the background", "error", err)
+	}
+	urlService.WithDomainVerification(customDomains)
+	go customDomains.Run(workerCtx, cfg.App.DomainRefresh)
+
+	// Bulk imports: large files are stored as jobs and imported by the workers
+	importService := service.NewImportService(urlService).WithJobs(jobQueue)
+	jobQueue.Register(service.ImportJobKind, importService.RunJob)
+
+	// Automatic HTTPS for our hosts and verified custom domains
+	// Certificates live in the writable database so every replica, in
+	// every region, serves the same ones
+	var certificates *service.CertificateService
+	var tlsServer *http.Server
+	if cfg.TLS.Autocert {
+		certDB := db
+		if primaryDB != nil {
+			certDB = primaryDB
+		}
+		certificates = service.NewCertificateService(postgres.NewCertificateRepository(certDB), customDomains).
+			WithACME(cfg.TLS.DirectoryURL, cfg.TLS.Email).
+			WithRenewBefore(cfg.TLS.RenewBefore)
+		customDomains.WithCertificates(certificates)
+		tlsServer = &http.Server{
+			Addr:         ":" + cfg.TLS.Port,
+			Handler:      publicGate,
+			TLSConfig:    certificates.TLSConfig(),
+			ReadTimeout:  cfg.Server.ReadTimeout,
+			WriteTimeout: cfg.Server.WriteTimeout,
+			IdleTimeout:  cfg.Server.IdleTimeout,
+		}
+		appLogger.Info("Automatic TLS enabled", "port", cfg.TLS.Port, "trusted_hosts", cfg.App.TrustedDomains)
+	}
+
+	// Scoped API keys, created by workspace owners at /api/v1/keys
+	// (expired ones are deleted by the "api-keys" task below)
+	apiKeyService := service.NewAPIKeyService(postgres.NewAPIKeyRepository(db)).
+		WithHashPeppers(cfg.App.APIKeyPeppers...).
+		WithRotationGrace(cfg.App.APIKeyRotationGrace).
+		WithExpiryWarning(cfg.App.APIKeyExpiryWarning)
+	if len(cfg.App.APIKeyPeppers) > 0 {
+		appLogger.Info("API key secrets hashed with a pepper", "hash_version", apiKeyService.HashVersion())
+	}
+
+	// The workers below write, so they only run in the primary region
+	// (a secondary region's database is a read replica)
+	if regionCfg.IsPrimary() {
+		// Singleton workers run on the elected leader only, so replicas
+		// don't duplicate their work (SKIP LOCKED queues run everywhere)
+		leaderWork := leader.New(postgres.NewAdvisoryLocker(db), "background-workers").
+			WithInterval(cfg.App.LeaderCheckInterval).
+			WithErrorReporter(reporter)
+
+		// One worker per shard: each only scans its own database
+		for _, pool := range pools {
+			warningService := service.NewWarningService(
+				postgres.NewWarningRepository(pool),
+				notifier,
+				cfg.Notify.ExpiryWarning,
+			).WithEvents(linkEvents)
+			leaderWork.Go(func(ctx context.Context) { warningService.Run(ctx, cfg.Notify.WarningInterval) })
+		}
+
+		// Click spike alerts: each shard checks the rollups of its own links
+		if cfg.Notify.AnomalyInterval > 0 {
+			detector := domain.DefaultSpikeDetector
+			detector.Threshold = cfg.Notify.AnomalyThreshold
+			detector.MinClicks = cfg.Notify.AnomalyMinClicks
+			for _, pool := range pools {
+				anomalyService := service.NewAnomalyService(postgres.NewAnomalyRepository(pool), notifier, detector)
+				leaderWork.Go(func(ctx context.Context) { anomalyService.Run(ctx, cfg.Notify.AnomalyInterval) })
+			}
+		}
+
+		go erasureService.Run(workerCtx, cfg.App.ErasureInterval)
+
+		if cfg.App.JobWorkers > 0 {
+			go jobQueue.Run(workerCtx, cfg.App.JobWorkers, cfg.App.JobPollInterval)
+		}
+
+		// Scheduled maintenance: cron times in UTC, each task on one replica
+		// at a time (advisory locks on the main database)
+		probeTargets := make([]service.ProbeTarget, 0, len(readinessChecks))
+		for _, check := range readinessChecks {
+			probeTargets = append(probeTargets, service.ProbeTarget{Name: check.Name, Check: check.Check})
+		}
+		prober := service.NewProber(5*time.Second, probeTargets...)
+
+		rollupServices := make([]*service.RollupService, 0, len(pools))
+		for _, pool := range pools {
+			rollupServices = append(rollupServices, service.NewRollupService(postgres.NewRollupRepository(pool), notifier))
+		}
+		// forEachShard runs a rollup task on every shard, even if one fails
+		forEachShard := func(run func(ctx context.Context, s *service.RollupService) error) scheduler.Task {
+			return func(ctx context.Context) error {
+				var errs []error
+				for _, s := range rollupServices {
+					errs = append(errs, run(ctx, s))
+				}
+				return errors.Join(errs...)
+			}
+		}
+
+		jitter := cfg.App.SchedulerJitter
+		tasks := scheduler.New(postgres.NewAdvisoryLocker(db)).
+			WithErrorReporter(reporter).
+			Register("janitor", "17 * * * *", jitter, func(ctx context.Context) error {
+				_, err := jobQueue.Prune(ctx, cfg.App.JobRetention)
+				return err
+			}).
+			Register("rollup", "5 * * * *", jitter, forEachShard(func(ctx context.Context, s *service.RollupService) error {
+				_, err := s.Prune(ctx)
+				return err
+			})).
+			Register("prober", "* * * * *", min(jitter, 10*time.Second), prober.Probe).
+			Register("edge-tombstones", "23 3 * * *", jitter, func(ctx context.Context) error {
+				_, err := edgeSnapshots.PruneTombstones(ctx, cfg.App.EdgeTombstoneTTL)
+				return err
+			}).
+			Register("domain-verify", "*/10 * * * *", jitter, func(ctx context.Context) error {
+				_, err := customDomains.RecheckDue(ctx)
+				return err
+			}).
+			Register("api-keys", "41 * * * *", jitter, func(ctx context.Context) error {
+				revoked, err := apiKeyService.RevokeExpired(ctx)
+				if revoked > 0 {
+					appLogger.Info("Expired API keys revoked", "count", revoked)
+				}
+				return err
+			})
+		if destinationSealer != nil {
+			// Re-encryption: moves every shard's links to the current key and
+			// encrypts sensitive links from before encryption was turned on
+			resealers := make([]*service.ResealService, 0, len(pools))
+			for _, pool := range pools {
+				resealers = append(resealers, service.NewResealService(postgres.NewSealedDestinationRepository(pool), destinationSealer, cache))
+			}
+			tasks.Register("destination-keys", "29 * * * *", jitter, func(ctx context.Context) error {
+				var errs []error
+				for _, resealer := range resealers {
+					report, err := resealer.Reseal(ctx)
+					if report.Sealed+report.Rewrapped+report.Skipped > 0 {
+						appLogger.Info("Destinations re-encrypted", "sealed", report.Sealed, "rewrapped", report.Rewrapped, "skipped", report.Skipped)
+					}
+					errs = append(errs, err)
+				}
+				return errors.Join(errs...)
+			})
+		}
+		if cfg.App.DigestSchedule != "" {
+			tasks.Register("digest", cfg.App.DigestSchedule, jitter, forEachShard(func(ctx context.Context, s *service.RollupService) error {
+				_, err := s.SendDigests(ctx)
+				return err
+			}))
+		}
+		go tasks.Run(workerCtx)
+
+		// Domain policy sweep: after a rule change, each shard switches off
+		// its links to destinations that are no longer allowed
+		for _, pool := range pools {
+			sweeper := service.NewPolicySweeper(postgres.NewPolicySweepRepository(pool), domainPolicy, cache, notifier)
+			if edgePurger != nil {
+				sweeper.WithEdgePurger(edgePurger)
+			}
+			leaderWork.Go(func(ctx context.Context) { sweeper.Run(ctx, cfg.App.PolicySweepInterval) })
+		}
+
+		// Striped click counters: each shard folds its own into the links
+		// (runs even with CLICK_COUNTER_STRIPES=1, for stripes left from before)
+		for _, pool := range pools {
+			folder := service.NewClickFoldService(postgres.NewClickStripeRepository(pool))
+			leaderWork.Go(func(ctx context.Context) { folder.Run(ctx, cfg.App.ClickFoldInterval) })
+		}
+
+		// Archive tier: cold links leave the hot table, and come back when visited
+		if cfg.App.ArchiveAfter > 0 {
+			for _, pool := range pools {
+				archiveService := service.NewArchiveService(postgres.NewArchiveRepository(pool), cache, cfg.App.ArchiveAfter)
+				go archiveService.Run(workerCtx, cfg.App.ArchiveInterval)
+			}
+			appLogger.Info("Link archiving enabled", "after", cfg.App.ArchiveAfter)
+		}
+
+		// Data warehouse export: every shard ships its own clicks, checkpointed in its own database
+		if cfg.ETL.Bucket != "" {
+			store, format := buildWarehouseStore(cfg.ETL)
+			for i, pool := range pools {
+				exporter := service.NewWarehouseService(
+					postgres.NewWarehouseRepository(pool),
+					store,
+					shardMap.Names()[i],
+					cfg.ETL.Prefix,
+					format,
+				).WithBatchSize(cfg.ETL.BatchSize).WithLag(cfg.ETL.Lag)
+				leaderWork.Go(func(ctx context.Context) { exporter.Run(ctx, cfg.ETL.Interval) })
+			}
+			appLogger.Info("Warehouse export enabled", "bucket", cfg.ETL.Bucket, "prefix", cfg.ETL.Prefix, "interval", cfg.ETL.Interval)
+		}
+
+		go leaderWork.Run(workerCtx)
+	}
+
+	// Feature flags: stored in PostgreSQL, changes announced over Redis
+	// Every region reloads them (reading works on a replica too)
+	featureFlags := featureflags.New(postgres.NewFeatureFlagRepository(db), featureflags.Defaults).
+		WithNotifier(redisrepo.NewFlagNotifier(redisClient))
+	go featureFlags.Run(workerCtx, cfg.App.FeatureFlagRefresh)
+
+	// Translations are compiled in; a broken catalog is a build mistake
+	catalog, err := i18n.Load()
+	if err != nil {
+		log.Fatalf("Failed to load translations: %v", err)
+	}
+
+	// CAPTCHA provider (hCaptcha or Turnstile), nil when not configured
+	var captchaVerifier *captcha.Verifier
+	if cfg.Captcha.Enabled() {
+		captchaVerifier, err = captcha.New(cfg.Captcha.Provider, cfg.Captcha.SiteKey, cfg.Captcha.Secret, cfg.Captcha.Timeout)
+		if err != nil {
+			log.Fatalf("Invalid CAPTCHA configuration: %v", err)
+		}
+		appLogger.Info("CAPTCHA enabled", "provider", cfg.Captcha.Provider)
+	}
 
 	// Initialize HTTP handler (Presentation Layer)
 	baseURL := fmt.Sprintf("http://localhost:%s", cfg.Server.Port)
 	handler := httpHandler.NewHandler(urlService, appLogger.Logger, baseURL)
+	if quotaService != nil {
+		handler.WithQuotas(quotaService)
+	}
+	errorTemplate, err := template.ParseFiles(filepath.Join("web", "templates", "error.html"))
+	if err != nil {
+		log.Fatalf("Failed to parse error page template: %v", err)
+	}
+	handler.WithErrorPages(buildErrorPages(cfg.Pages, errorTemplate))
+	previewTemplate, err := template.ParseFiles(filepath.Join("web", "templates", "preview.html"))
+	if err != nil {
+		log.Fatalf("Failed to parse preview card template: %v", err)
+	}
+	handler.WithPreviewCards(previewTemplate)
+	uiTemplate, err := template.ParseFiles(filepath.Join("web", "templates", "index.html"))
+	if err != nil {
+		log.Fatalf("Failed to parse UI template: %v", err)
+	}
+	handler.WithUI(uiTemplate)
+	handler.WithScheduleTimezone(cfg.App.ScheduleTimezone)
+	handler.WithAnalytics(cfg.App.EnableAnalytics)
+	handler.WithEdgeCaching(cfg.CDN.EdgeTTL)
+	handler.WithDomainGate(customDomains)
+	handler.WithFeatureFlags(featureFlags)
+	if captchaVerifier != nil {
+		// Anonymous creates need a solved CAPTCHA; API key holders never do
+		handler.WithCaptcha(captchaVerifier)
+	}
+	importHandler := httpHandler.NewImportHandler(importService, appLogger.Logger)
+	erasureHandler := httpHandler.NewErasureHandler(erasureService, appLogger.Logger)
+	jobHandler := httpHandler.NewJobHandler(jobQueue, appLogger.Logger)
+	exportHandler := httpHandler.NewExportHandler(
+		service.NewExportService(exportRepo),
+		appLogger.Logger,
+	)
+	searchHandler := httpHandler.NewSearchHandler(service.NewSearchService(searchRepo), appLogger.Logger, baseURL)
+
+	// Link-in-bio pages: managed through the API, rendered at /@{handle}
+	pageTemplate, err := template.ParseFiles(filepath.Join("web", "templates", "page.html"))
+	if err != nil {
+		log.Fatalf("Failed to parse page template: %v", err)
+	}
+	pageHandler := httpHandler.NewPageHandler(
+		service.NewPageService(postgres.NewPageRepository(db)),
+		pageTemplate,
+		appLogger.Logger,
+		baseURL,
+	)
+
+	// Burn-after-reading links: only with an encryption key for their secrets
+	secretsEnabled := cfg.App.PayloadEncryptionKey != ""
+	if secretsEnabled {
+		key, err := aesgcm.ParseKey(cfg.App.PayloadEncryptionKey)
+		if err != nil {
+			log.Fatalf("Invalid PAYLOAD_ENCRYPTION_KEY: %v", err)
+		}
+		payloadCipher, err := aesgcm.New(key)
+		if err != nil {
+			log.Fatalf("Failed to create payload cipher: %v", err)
+		}
+		secretService := service.NewSecretService(urlService, urlRepo, auditRepo, payloadCipher, cache).
+			WithDestinationPolicy(domainPolicy)
+		if edgePurger != nil {
+			secretService.WithEdgePurger(edgePurger)
+		}
+		secretTemplate, err := template.ParseFiles(filepath.Join("web", "templates", "secret.html"))
+		if err != nil {
+			log.Fatalf("Failed to parse secret page template: %v", err)
+		}
+		handler.WithSecrets(secretService, secretTemplate)
+		appLogger.Info("Burn-after-reading links enabled")
+	}
+
+	// Paste links: the snippet page for browsers
+	pasteTemplate, err := template.ParseFiles(filepath.Join("web", "templates", "paste.html"))
+	if err != nil {
+		log.Fatalf("Failed to parse paste page template: %v", err)
+	}
+	handler.WithPastes(pasteTemplate)
+
+	// File links: only with a bucket to keep the files in
+	if cfg.Files.Enabled() {
+		handler.WithFiles(service.NewFileService(urlService, buildFileStore(cfg.Files), cfg.Files.MaxSize()))
+		appLogger.Info("File links enabled", "bucket", cfg.Files.Bucket, "max_size_mb", cfg.Files.MaxSizeMB)
+	}
+
+	// Link templates: named settings for consistently configured links
+	templateHandler := httpHandler.NewTemplateHandler(
+		service.NewTemplateService(postgres.NewTemplateRepository(db), urlService),
+		appLogger.Logger,
+		baseURL,
+	)
+
+	// SAML single sign-on: members sign in at their workspace's IdP and get
+	// a session token that authenticates like an API key
+	memberRepo := postgres.NewMemberRepository(db)
+	ssoService := service.NewSSOService(
+		postgres.NewSAMLConnectionRepository(db),
+		postgres.NewSessionRepository(db),
+		memberRepo,
+		workspaceSettings,
+		baseURL,
+	).WithSessionTTL(cfg.App.SAMLSessionTTL)
+
+	// Turns API keys and SSO session tokens into principals (header
+	// everywhere, ?key= on /quick)
+	authenticator := auth.NewChainAuthenticator(
+		auth.NewStaticKeyAuthenticator(cfg.App.AdminAPIKey),
+		apiKeyService,
+		ssoService,
+	)
 
 	// Set up HTTP routes
 	mux := http.NewServeMux()
@@ -84,24 +909,286 @@ func main() {
 	// Serve static files (CSS, JS, images)
 	httpHandler.SetupStaticFiles(mux)
 
-	// API routes
-	mux.HandleFunc("/api/v1/urls", handler.CreateURL)
-	mux.HandleFunc("/api/v1/urls/", handler.GetURLStats) // Note: trailing slash for path matching
+	// Alias availability checks are cheap to spam, so they get their own,
+	// stricter limit on top of the global one (stops alias enumeration).
+	// Dry-run creates and alias suggestions share it: they answer the same
+	// question, and more
+	aliasCheck := func(next http.Handler) http.Handler { return next }
+	if cfg.App.RateLimitEnabled {
+		aliasLimiter := ratelimit.NewTokenBucketLimiter(
+			redisClient,
+			cfg.App.AliasCheckPerMinute,
+			time.Minute,
+			cfg.App.AliasCheckPerMinute,
+		).WithNamespace("alias-check")
+		aliasCheck = httpHandler.RateLimitMiddleware(aliasLimiter)
+	}
+
+	// API v1 routes (frozen - may announce deprecation via Deprecation/Sunset headers)
+	apiV1 := httpHandler.NewAPIVersion(mux, "v1", httpHandler.VersionLifecycle{
+		DeprecatedAt: cfg.Server.APIV1DeprecatedAt,
+		Sunset:       cfg.Server.APIV1Sunset,
+		Successor:    "/api/v2",
+	})
+	apiV1.HandleFunc("/urls", handler.CreateURL)
+	apiV1.HandleFunc("/urls/", handler.GetURLStats) // Note: trailing slash for path matching
+	// Dry run of POST /urls: same checks, nothing created
+	apiV1.Handle("POST /urls/validate", aliasCheck(http.HandlerFunc(handler.ValidateURL)))
+	apiV1.HandleFunc("GET /urls/{code}/summary", handler.GetURLSummary)
+	apiV1.HandleFunc("GET /urls/{code}/timeseries", handler.GetURLTimeseries)
+	apiV1.HandleFunc("GET /urls/{code}/stats/heatmap", handler.GetURLHeatmap)
+	// Authenticated: each call makes outbound requests from our servers
+	apiV1.HandleFunc("GET /urls/{code}/resolve", httpHandler.RequireAuth(handler.ResolveURL))
+	// Owner or admin - the service checks ownership
+	apiV1.HandleFunc("DELETE /urls/{id}", httpHandler.RequireAuth(handler.DeleteURL))
+	apiV1.HandleFunc("PUT /urls/{alias}", httpHandler.RequireAuth(handler.UpsertURL)) // Idempotent upsert for IaC tools
+	apiV1.HandleFunc("POST /urls/{id}/restore", httpHandler.RequireAuth(handler.RestoreURL))
+	apiV1.HandleFunc("POST /urls/{id}/sign", httpHandler.RequireAuth(handler.SignURL))
+	apiV1.HandleFunc("POST /urls/{id}/clone", httpHandler.RequireAuth(handler.CloneURL))
+	apiV1.HandleFunc("PUT /urls/{id}/preview", httpHandler.RequireAuth(handler.SetPreview))
+	apiV1.HandleFunc("DELETE /urls/{id}/preview", httpHandler.RequireAuth(handler.DeletePreview))
+	apiV1.HandleFunc("PUT /urls/{id}/notes", httpHandler.RequireAuth(handler.SetNotes))
+	apiV1.HandleFunc("PUT /urls/{id}/visibility", httpHandler.RequireAuth(handler.SetVisibility))
+	// Plain-text quick create for bookmarklets and browser extensions
+	apiV1.HandleFunc("GET /quick", httpHandler.QuickKeyAuth(authenticator, handler.QuickCreate))
+	apiV1.HandleFunc("POST /quick", httpHandler.QuickKeyAuth(authenticator, handler.QuickCreate))
+	apiV1.Handle("GET /aliases/suggest", aliasCheck(http.HandlerFunc(handler.SuggestAliases)))
+	apiV1.Handle("GET /aliases/{alias}/availability", aliasCheck(http.HandlerFunc(handler.CheckAliasAvailability)))
+	// Bulk creation needs a key: anonymous callers are limited to one
+	// CAPTCHA-checked link at a time
+	apiV1.HandleFunc("POST /import", httpHandler.RequireAuth(importHandler.Import))
+	apiV1.HandleFunc("GET /import/{id}", httpHandler.RequireAuth(importHandler.GetImportJob))
+	apiV1.HandleFunc("GET /export", httpHandler.RequireAuth(exportHandler.Export))
+	apiV1.HandleFunc("GET /urls/stream", httpHandler.RequireAuth(exportHandler.StreamURLs))
+	apiV1.HandleFunc("GET /search", httpHandler.RequireAuth(searchHandler.Search))
+	if secretsEnabled {
+		apiV1.HandleFunc("POST /secrets", httpHandler.RequireAuth(handler.CreateSecret))
+		apiV1.HandleFunc("GET /urls/{id}/audit", httpHandler.RequireAuth(handler.ListAudit))
+	}
+	apiV1.HandleFunc("POST /pastes", httpHandler.RequireAuth(handler.CreatePaste))
+	if cfg.Files.Enabled() {
+		apiV1.HandleFunc("POST /files", httpHandler.RequireAuth(handler.UploadFile))
+	}
+	if quotaService != nil {
+		usageHandler := httpHandler.NewUsageHandler(quotaService, appLogger.Logger)
+		apiV1.HandleFunc("GET /usage", httpHandler.RequireAuth(usageHandler.GetUsage))
+	}
+
+	// Client libraries read their settings from here (same limits as the
+	// global limiter below; a zero policy means "not rate limited")
+	var sdkLimits httpHandler.RateLimitPolicy
+	if cfg.App.RateLimitEnabled {
+		sdkLimits = httpHandler.RateLimitPolicy{
+			RequestsPerMinute: cfg.App.RateLimitPerMinute,
+			Burst:             cfg.App.RateLimitPerMinute + 20,
+		}
+	}
+	sdkHandler := httpHandler.NewSDKHandler(baseURL, sdkLimits, appLogger.Logger)
+	if quotaService != nil {
+		sdkHandler.WithQuotas(quotaService)
+	}
+	apiV1.HandleFunc("GET /sdk/config", httpHandler.RequireAuth(sdkHandler.GetConfig))
+	if billingService != nil {
+		billingHandler := httpHandler.NewBillingHandler(billingService, cfg.Billing.StripeWebhookSecret, appLogger.Logger)
+		// No auth: Stripe signs the request instead
+		apiV1.HandleFunc("POST /billing/webhook", billingHandler.Webhook)
+		apiV1.HandleFunc("POST /billing/portal", httpHandler.RequireAuth(billingHandler.Portal))
+	}
+	flagHandler := httpHandler.NewFeatureFlagHandler(featureFlags, appLogger.Logger)
+	apiV1.HandleFunc("GET /flags", httpHandler.RequireAdmin(flagHandler.ListFlags))
+	apiV1.HandleFunc("PUT /flags/{name}", httpHandler.RequireAdmin(flagHandler.PutFlag))
+	apiV1.HandleFunc("DELETE /flags/{name}", httpHandler.RequireAdmin(flagHandler.DeleteFlag))
+
+	apiV1.HandleFunc("DELETE /me", httpHandler.RequireAuth(erasureHandler.DeleteMe))
+	apiV1.HandleFunc("DELETE /users/{owner}", httpHandler.RequireAdmin(erasureHandler.DeleteUser))
+	apiV1.HandleFunc("GET /erasures/{id}", httpHandler.RequireAuth(erasureHandler.GetErasure))
+	apiV1.HandleFunc("GET /jobs/{id}", httpHandler.RequireAuth(jobHandler.GetJob))
+
+	apiV1.HandleFunc("POST /pages", httpHandler.RequireAuth(pageHandler.CreatePage))
+	apiV1.HandleFunc("GET /pages", httpHandler.RequireAuth(pageHandler.ListPages))
+	apiV1.HandleFunc("GET /pages/{id}", httpHandler.RequireAuth(pageHandler.GetPage))
+	apiV1.HandleFunc("PUT /pages/{id}", httpHandler.RequireAuth(pageHandler.UpdatePage))
+	apiV1.HandleFunc("DELETE /pages/{id}", httpHandler.RequireAuth(pageHandler.DeletePage))
+
+	apiV1.HandleFunc("POST /templates", httpHandler.RequireAuth(templateHandler.CreateTemplate))
+	apiV1.HandleFunc("GET /templates", httpHandler.RequireAuth(templateHandler.ListTemplates))
+	apiV1.HandleFunc("GET /templates/{id}", httpHandler.RequireAuth(templateHandler.GetTemplate))
+	apiV1.HandleFunc("PUT /templates/{id}", httpHandler.RequireAuth(templateHandler.UpdateTemplate))
+	apiV1.HandleFunc("DELETE /templates/{id}", httpHandler.RequireAuth(templateHandler.DeleteTemplate))
+	apiV1.HandleFunc("POST /templates/{id}/urls", httpHandler.RequireAuth(templateHandler.CreateURL))
+
+	// Clicks served by external edge redirectors (e.g. Cloudflare Workers)
+	clickIngest := service.NewClickIngestService(urlService, redisrepo.NewEventClaims(redisClient), cfg.App.ClickIngestTTL)
+	clickIngestHandler := httpHandler.NewClickIngestHandler(clickIngest, appLogger.Logger)
+	apiV1.HandleFunc("POST /clicks", httpHandler.RequireAdmin(clickIngestHandler.IngestClicks))
+
+	// Links for edge workers and nginx maps to redirect without asking us
+	edgeSnapshotHandler := httpHandler.NewEdgeSnapshotHandler(edgeSnapshots, appLogger.Logger)
+	apiV1.HandleFunc("GET /edge/snapshot", httpHandler.RequireAdmin(edgeSnapshotHandler.Snapshot))
+
+	domainHandler := httpHandler.NewDomainHandler(customDomains, appLogger.Logger)
+	apiV1.HandleFunc("GET /domains", httpHandler.RequireAuth(domainHandler.ListDomains))
+	apiV1.HandleFunc("POST /domains", httpHandler.RequireAuth(domainHandler.AddDomain))
+	apiV1.HandleFunc("GET /domains/{id}", httpHandler.RequireAuth(domainHandler.GetDomain))
+	apiV1.HandleFunc("DELETE /domains/{id}", httpHandler.RequireAuth(domainHandler.DeleteDomain))
+	apiV1.HandleFunc("GET /domains/{id}/verify", httpHandler.RequireAuth(domainHandler.VerifyDomain))
+
+	domainRuleHandler := httpHandler.NewDomainRuleHandler(domainPolicy, appLogger.Logger)
+	apiV1.HandleFunc("GET /domain-rules", httpHandler.RequireAuth(domainRuleHandler.ListRules))
+	apiV1.HandleFunc("POST /domain-rules", httpHandler.RequireAuth(domainRuleHandler.CreateRule))
+	apiV1.HandleFunc("DELETE /domain-rules/{id}", httpHandler.RequireAuth(domainRuleHandler.DeleteRule))
+
+	settingsHandler := httpHandler.NewSettingsHandler(service.NewWorkspaceService(workspaceSettings), appLogger.Logger)
+	apiV1.HandleFunc("GET /settings", httpHandler.RequireAuth(settingsHandler.GetSettings))
+	apiV1.HandleFunc("PUT /settings", httpHandler.RequireAuth(settingsHandler.PutSettings))
+	// Operators put workspaces on a quota plan without Stripe
+	apiV1.HandleFunc("PUT /workspaces/{workspace}/plan", httpHandler.RequireAdmin(settingsHandler.PutPlan))
+
+	// Approval queue: contributors' links wait here when the workspace
+	// requires approval (PUT /settings {"require_approval": true})
+	approvalService := service.NewApprovalService(cachedURLs, approvalRepo, linkEvents)
+	approvalHandler := httpHandler.NewApprovalHandler(approvalService, appLogger.Logger, baseURL)
+	apiV1.HandleFunc("GET /approvals", httpHandler.RequireAuth(approvalHandler.ListPending))
+	apiV1.HandleFunc("POST /approvals/{id}/approve", httpHandler.RequireAuth(approvalHandler.Approve))
+	apiV1.HandleFunc("POST /approvals/{id}/reject", httpHandler.RequireAuth(approvalHandler.Reject))
+
+	// SCIM provisioning: identity providers create, group and deactivate
+	// workspace members; group names map to roles in PUT /settings
+	// {"group_roles": {...}}. The owner issues the SCIM token here
+	scimService := service.NewSCIMService(
+		memberRepo,
+		postgres.NewSCIMTokenRepository(db),
+		workspaceSettings,
+	)
+	scimHandler := httpHandler.NewSCIMHandler(scimService, appLogger.Logger, baseURL)
+	apiV1.HandleFunc("POST /scim/token", httpHandler.RequireAuth(scimHandler.IssueToken))
+	apiV1.HandleFunc("DELETE /scim/token", httpHandler.RequireAuth(scimHandler.RevokeToken))
+
+	// The SCIM API itself authenticates the SCIM token, not API keys
+	mux.HandleFunc("GET /scim/v2/ServiceProviderConfig", scimHandler.Authenticate(scimHandler.ServiceProviderConfig))
+	mux.HandleFunc("GET /scim/v2/Users", scimHandler.Authenticate(scimHandler.ListUsers))
+	mux.HandleFunc("POST /scim/v2/Users", scimHandler.Authenticate(scimHandler.CreateUser))
+	mux.HandleFunc("GET /scim/v2/Users/{id}", scimHandler.Authenticate(scimHandler.GetUser))
+	mux.HandleFunc("PUT /scim/v2/Users/{id}", scimHandler.Authenticate(scimHandler.ReplaceUser))
+	mux.HandleFunc("PATCH /scim/v2/Users/{id}", scimHandler.Authenticate(scimHandler.PatchUser))
+	mux.HandleFunc("DELETE /scim/v2/Users/{id}", scimHandler.Authenticate(scimHandler.DeleteUser))
+	mux.HandleFunc("GET /scim/v2/Groups", scimHandler.Authenticate(scimHandler.ListGroups))
+	mux.HandleFunc("POST /scim/v2/Groups", scimHandler.Authenticate(scimHandler.CreateGroup))
+	mux.HandleFunc("GET /scim/v2/Groups/{id}", scimHandler.Authenticate(scimHandler.GetGroup))
+	mux.HandleFunc("PUT /scim/v2/Groups/{id}", scimHandler.Authenticate(scimHandler.ReplaceGroup))
+	mux.HandleFunc("PATCH /scim/v2/Groups/{id}", scimHandler.Authenticate(scimHandler.PatchGroup))
+	mux.HandleFunc("DELETE /scim/v2/Groups/{id}", scimHandler.Authenticate(scimHandler.DeleteGroup))
+
+	// SAML single sign-on: owners connect their IdP here and enter the
+	// sp_entity_id / acs_url it answers with on the IdP side
+	ssoHandler := httpHandler.NewSSOHandler(ssoService, appLogger.Logger)
+	apiV1.HandleFunc("GET /sso/saml", httpHandler.RequireAuth(ssoHandler.GetConnection))
+	apiV1.HandleFunc("PUT /sso/saml", httpHandler.RequireAuth(ssoHandler.PutConnection))
+	apiV1.HandleFunc("DELETE /sso/saml", httpHandler.RequireAuth(ssoHandler.DeleteConnection))
+	apiV1.HandleFunc("DELETE /sso/session", httpHandler.RequireAuth(ssoHandler.SignOut))
+
+	// The SAML endpoints the IdP and the member's browser use are public
+	mux.HandleFunc("GET /saml/{workspace}/metadata", ssoHandler.Metadata)
+	mux.HandleFunc("GET /saml/{workspace}/login", ssoHandler.Login)
+	mux.HandleFunc("POST /saml/{workspace}/acs", ssoHandler.ACS)
+
+	// API keys: each with the scopes its job needs (see the Scopes
+	// middleware below); rotation replaces the secret, keeping the key
+	apiKeyHandler := httpHandler.NewAPIKeyHandler(apiKeyService, appLogger.Logger)
+	apiV1.HandleFunc("GET /keys", httpHandler.RequireAuth(apiKeyHandler.ListKeys))
+	apiV1.HandleFunc("POST /keys", httpHandler.RequireAuth(apiKeyHandler.CreateKey))
+	apiV1.HandleFunc("POST /keys/{id}/rotate", httpHandler.RequireAuth(apiKeyHandler.RotateKey))
+	apiV1.HandleFunc("DELETE /keys/{id}", httpHandler.RequireAuth(apiKeyHandler.RevokeKey))
+
+	// Dashboard home page: the caller's top links, cached briefly in Redis
+	leaderboardService := service.NewLeaderboardService(clickRepo).
+		WithCache(redisrepo.NewLeaderboardCache(redisClient), cfg.App.LeaderboardCacheTTL)
+	leaderboardHandler := httpHandler.NewLeaderboardHandler(leaderboardService, baseURL, appLogger.Logger)
+	apiV1.HandleFunc("GET /stats/top", httpHandler.RequireAuth(leaderboardHandler.TopLinks))
+
+	// Slack /shorten slash command; workspaces are registered by an admin
+	if cfg.App.SlackEnabled {
+		slackHandler := httpHandler.NewSlackHandler(
+			service.NewSlackService(postgres.NewSlackWorkspaceRepository(db), urlService).WithSecretEncryption(keyManager),
+			appLogger.Logger,
+			baseURL,
+		)
+		// No auth: each request is signed with the workspace's signing secret
+		mux.HandleFunc("POST /integrations/slack", slackHandler.Command)
+		apiV1.HandleFunc("PUT /integrations/slack/workspaces/{team}", httpHandler.RequireAdmin(slackHandler.PutWorkspace))
+		apiV1.HandleFunc("DELETE /integrations/slack/workspaces/{team}", httpHandler.RequireAdmin(slackHandler.DeleteWorkspace))
+	}
 
-	// Health check
-	mux.HandleFunc("/health/live", handler.HealthCheck)
+	// API v2 routes (consistent {data, error, meta} envelope)
+	apiV2 := httpHandler.NewAPIVersion(mux, "v2", httpHandler.VersionLifecycle{})
+	apiV2.HandleFunc("POST /urls", handler.CreateURLV2)
+	apiV2.HandleFunc("GET /urls/{code}/stats", handler.GetURLStatsV2)
+
+	// Operational endpoints (health, readiness, metrics)
+	// With ADMIN_PORT set they move to a SEPARATE listener: the public port then
+	// exposes only redirects and the API, and can sit behind a CDN without
+	// leaking metrics. With ADMIN_PORT empty they stay on the public port.
+	opsMux := mux
+	var adminMux *http.ServeMux
+	if cfg.Server.AdminPort != "" {
+		adminMux = http.NewServeMux()
+		opsMux = adminMux
+	}
+
+	// Health checks
+	opsMux.HandleFunc("/health/live", handler.HealthCheck)
+	opsMux.HandleFunc("/health/ready", httpHandler.ReadinessCheck(2*time.Second, readinessChecks...))
 
 	// Metrics endpoints (must be before catch-all)
-	mux.HandleFunc("/metrics", httpHandler.ServeMetricsPage) // Styled page for viewing
-	mux.Handle("/metrics-raw", promhttp.Handler())           // Raw metrics for Prometheus
+	opsMux.HandleFunc("/metrics", httpHandler.ServeMetricsPage) // Styled page for viewing
+	// Raw metrics for Prometheus; with REGION set every series gets a region label
+	metricsHandler := promhttp.Handler()
+	if regionCfg.Name != "" {
+		metricsHandler = promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, promhttp.HandlerFor(
+			metrics.WithRegion(prometheus.DefaultGatherer, regionCfg.Name),
+			promhttp.HandlerOpts{},
+		))
+	}
+	opsMux.Handle("/metrics-raw", metricsHandler)
 
 	// API Documentation (must be before catch-all)
 	mux.HandleFunc("/api/docs", httpHandler.ServeSwagger)
 	mux.HandleFunc("/api/openapi.json", httpHandler.ServeOpenAPISpec)
 
-	// UI and redirect routes
+	// UI, public pages (/@handle) and redirect routes
 	// This must be last because it matches everything
-	mux.HandleFunc("/", handler.ServeUI)
+	redirects := pageHandler.Public(handler.ServeUI)
+	if cfg.Abuse.Enabled {
+		// Floods get banned (longer each time); with a CAPTCHA configured,
+		// suspicious browsers are asked to solve one first
+		guard := abuse.NewGuard(redisClient, abuse.Policy{
+			Window:         cfg.Abuse.Window,
+			ChallengeLimit: cfg.Abuse.ChallengeLimit,
+			BanLimit:       cfg.Abuse.BanLimit,
+			CodeLimit:      cfg.Abuse.CodeLimit,
+			BanDurations:   cfg.Abuse.BanDurations,
+			StrikeMemory:   cfg.Abuse.StrikeMemory,
+		})
+		protection := httpHandler.NewAbuseProtection(guard, appLogger.Logger)
+		if captchaVerifier != nil {
+			challengeTemplate, err := template.ParseFiles(filepath.Join("web", "templates", "challenge.html"))
+			if err != nil {
+				log.Fatalf("Failed to parse challenge page template: %v", err)
+			}
+			// The CAPTCHA secret also signs the pass cookies: one less secret to manage
+			protection.WithChallenge(captchaVerifier, challengeTemplate, cfg.Captcha.Secret, cfg.Abuse.PassTTL)
+			mux.HandleFunc("POST "+httpHandler.ChallengePath, protection.VerifyChallenge)
+		}
+		apiV1.HandleFunc("GET /bans", httpHandler.RequireAdmin(protection.ListBans))
+		apiV1.HandleFunc("DELETE /bans/{ip}", httpHandler.RequireAdmin(protection.LiftBan))
+		redirects = protection.Protect(redirects)
+		appLogger.Info("Abuse protection enabled",
+			"ban_limit", cfg.Abuse.BanLimit,
+			"window", cfg.Abuse.Window,
+			"captcha", captchaVerifier != nil,
+		)
+	}
+	mux.HandleFunc("/", redirects)
 
 	// Initialize rate limiter
 	rateLimiter := ratelimit.NewTokenBucketLimiter(
@@ -115,37 +1202,134 @@ func main() {
 	// Middleware is applied in reverse order (last middleware wraps first)
 	var finalHandler http.Handler = mux
 
+	// Scoped API keys only reach the routes their scopes open; unlisted
+	// routes need urls:read (GET, HEAD) or urls:write. Operator routes
+	// (RequireAdmin) need no entry: keys are only admins with the admin scope.
+	// Wrapped first so it runs after AuthMiddleware has found the key
+	finalHandler = httpHandler.Scopes(mux, httpHandler.RouteScopes{
+		"/api/v1/urls/":                         auth.ScopeStatsRead,
+		"GET /api/v1/urls/{code}/summary":       auth.ScopeStatsRead,
+		"GET /api/v1/urls/{code}/timeseries":    auth.ScopeStatsRead,
+		"GET /api/v1/urls/{code}/stats/heatmap": auth.ScopeStatsRead,
+		"GET /api/v1/stats/top":                 auth.ScopeStatsRead,
+		"GET /api/v1/usage":                     auth.ScopeStatsRead,
+		"GET /api/v2/urls/{code}/stats":         auth.ScopeStatsRead,
+		"GET /api/v1/quick":                     auth.ScopeURLsWrite, // Creates links
+		"POST /api/v1/import":                   auth.ScopeURLsWrite, // Creates links in bulk
+		"GET /api/v1/keys":                      auth.ScopeAdmin,
+		"POST /api/v1/keys":                     auth.ScopeAdmin,
+		"POST /api/v1/keys/{id}/rotate":         auth.ScopeAdmin,
+		"DELETE /api/v1/keys/{id}":              auth.ScopeAdmin,
+		"GET /api/v1/settings":                  auth.ScopeAdmin,
+		"PUT /api/v1/settings":                  auth.ScopeAdmin,
+		"POST /api/v1/scim/token":               auth.ScopeAdmin,
+		"DELETE /api/v1/scim/token":             auth.ScopeAdmin,
+		"GET /api/v1/sso/saml":                  auth.ScopeAdmin,
+		"PUT /api/v1/sso/saml":                  auth.ScopeAdmin,
+		"DELETE /api/v1/sso/saml":               auth.ScopeAdmin,
+		"POST /api/v1/domains":                  auth.ScopeAdmin,
+		"DELETE /api/v1/domains/{id}":           auth.ScopeAdmin,
+		"GET /api/v1/domains/{id}/verify":       auth.ScopeAdmin,
+		"POST /api/v1/domain-rules":             auth.ScopeAdmin,
+		"DELETE /api/v1/domain-rules/{id}":      auth.ScopeAdmin,
+		"POST /api/v1/billing/portal":           auth.ScopeAdmin,
+		"DELETE /api/v1/me":                     auth.ScopeAdmin,
+	})(finalHandler)
+
+	// Authenticate callers (anonymous requests pass through); SCIM routes
+	// are skipped because they check their own tokens
+	// Wrapped before rate limiting so the rate limiter runs FIRST and
+	// also throttles credential guessing
+	finalHandler = httpHandler.ExceptPrefix(httpHandler.SCIMPrefix, httpHandler.AuthMiddleware(authenticator))(finalHandler)
+
 	// Only apply rate limiting if enabled in config
 	if cfg.App.RateLimitEnabled {
 		finalHandler = httpHandler.RateLimitMiddleware(rateLimiter)(finalHandler)
 		appLogger.Info("Rate limiting enabled", "requests_per_minute", cfg.App.RateLimitPerMinute)
 	}
 
+	// Deadlines per route: short for redirects, long for bulk transfers
+	// Outside auth and rate limiting, whose Redis calls count too
+	finalHandler = httpHandler.Timeouts(mux, httpHandler.RouteTimeouts{
+		Default: cfg.Server.APITimeout,
+		Routes: map[string]time.Duration{
+			"/":                         cfg.Server.RedirectTimeout,
+			"GET /api/v1/export":        cfg.Server.LongRequestTimeout,
+			"GET /api/v1/urls/stream":   cfg.Server.LongRequestTimeout,
+			"GET /api/v1/edge/snapshot": cfg.Server.LongRequestTimeout,
+			"POST /api/v1/import":       cfg.Server.LongRequestTimeout,
+			"POST /api/v1/files":        cfg.Server.LongRequestTimeout,
+		},
+		// A slow cache is given up on for the database; a slow database
+		// fails the redirect fast instead of holding it until the deadline
+		Budgets: map[string]resilience.Budget{
+			"/": {Cache: cfg.Server.RedirectCacheBudget, Database: cfg.Server.RedirectDatabaseBudget},
+		},
+	})(finalHandler)
+
 	// Apply other middleware
 	finalHandler = httpHandler.Chain(
-		httpHandler.RecoveryMiddleware(appLogger.Logger),
+		httpHandler.RecoveryMiddleware(appLogger.Logger, reporter),
 		httpHandler.LoggingMiddleware(appLogger.Logger),
+		// Outside request IDs and CORS: forwarded requests get those headers
+		// from the primary region, not twice
+		httpHandler.RegionMiddleware(regionCfg.Name, primaryRegionURL, appLogger.Logger),
+		// Who the client is, for rate limits, bans, audit entries and clicks:
+		// X-Forwarded-For only counts when TRUSTED_PROXIES sent it
+		httpHandler.ClientIPMiddleware(cfg.Server.TrustedProxies),
 		httpHandler.RequestIDMiddleware,
 		httpHandler.CORSMiddleware,
+		// JSON, XML or MessagePack, as the Accept header asks
+		httpHandler.ContentNegotiation(encoders.Default()),
+		// Error messages and pages in the Accept-Language language
+		httpHandler.Localize(catalog),
 	)(finalHandler)
 
-	// Create HTTP server
-	server := &http.Server{
-		Addr:         ":" + cfg.Server.Port,
-		Handler:      finalHandler,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
-		IdleTimeout:  cfg.Server.IdleTimeout,
+	// The CA fetches HTTP-01 tokens on the plain port; outside rate
+	// limiting and region forwarding so a validation is never refused
+	if certificates != nil {
+		finalHandler = certificates.HTTPHandler(finalHandler)
 	}
 
-	// Start server in a goroutine
-	go func() {
-		appLogger.Info("Server starting", "address", server.Addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			appLogger.Error("Server failed", "error", err)
-			log.Fatalf("Server failed: %v", err)
+	// Startup is done: the gate forwards to the real handler from now on
+	publicGate.Open(finalHandler)
+
+	// Admin listener for operational endpoints
+	// The port must stay PRIVATE (no CDN, no public ingress): health and
+	// metrics are served without credentials so probes and Prometheus can
+	// reach them. Profiling exposes much more, so it still needs the admin token.
+	if adminMux != nil {
+		if cfg.App.EnableProfiling {
+			debugMux := http.NewServeMux()
+			httpHandler.RegisterDebugRoutes(debugMux)
+			adminMux.Handle("/debug/", httpHandler.AuthMiddleware(authenticator)(
+				httpHandler.RequireAdmin(debugMux.ServeHTTP),
+			))
+			appLogger.Info("Profiling endpoints enabled", "path", "/debug/")
+			if cfg.App.AdminAPIKey == "" {
+				appLogger.Warn("ADMIN_API_KEY is empty - profiling endpoints will reject every request")
+			}
 		}
-	}()
+
+		adminGate.Open(httpHandler.Chain(
+			httpHandler.RecoveryMiddleware(appLogger.Logger, reporter),
+			httpHandler.LoggingMiddleware(appLogger.Logger),
+		)(adminMux))
+	} else if cfg.App.EnableProfiling {
+		appLogger.Warn("ENABLE_PROFILING is set but ADMIN_PORT is empty - profiling endpoints are disabled")
+	}
+
+	// Without partial start, nothing listened until now
+	if !cfg.App.StartupPartial {
+		listen(appLogger, "Server", server)
+		if adminServer != nil {
+			listen(appLogger, "Admin server", adminServer)
+		}
+	}
+	if tlsServer != nil {
+		listen(appLogger, "HTTPS server", tlsServer)
+	}
+	readiness.SetPhase(startup.PhaseReady)
 
 	// Wait for interrupt signal for graceful shutdown
 	// This is GRACEFUL SHUTDOWN - we wait for existing requests to complete
@@ -155,16 +1339,244 @@ func main() {
 	<-quit
 
 	appLogger.Info("Shutting down server...")
+	readiness.SetPhase(startup.PhaseStopping)
+
+	// Stop background workers first so they don't start new work
+	stopWorkers()
 
 	// Create a deadline for shutdown (30 seconds)
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	// Attempt graceful shutdown
+	// A server that misses the deadline doesn't stop the sequence: exiting
+	// right away would also lose the clicks and error reports still pending
+	// below. The process exits non-zero at the end instead.
+	forced := false
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		appLogger.Error("Server forced to shutdown", "error", err)
-		log.Fatalf("Server forced to shutdown: %v", err)
+		forced = true
+	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(shutdownCtx); err != nil {
+			appLogger.Error("Admin server forced to shutdown", "error", err)
+		}
+	}
+	if tlsServer != nil {
+		if err := tlsServer.Shutdown(shutdownCtx); err != nil {
+			appLogger.Error("HTTPS server forced to shutdown", "error", err)
+			forced = true
+		}
 	}
 
+	// No request can start a click anymore: wait for the ones still being
+	// recorded (the click row and its counter increment) with what is left
+	// of the deadline - or a few seconds more if a server used it all up
+	drainCtx := shutdownCtx
+	if forced {
+		var cancelDrain context.CancelFunc
+		drainCtx, cancelDrain = context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelDrain()
+	}
+	drained := handler.DrainClicks(drainCtx)
+	if drained.Dropped > 0 {
+		appLogger.Warn("Clicks still being recorded at the shutdown deadline were dropped",
+			"flushed", drained.Flushed, "dropped", drained.Dropped)
+	} else {
+		appLogger.Info("Click queue drained", "flushed", drained.Flushed)
+	}
+
+	// Send what is still on its way to the error tracker
+	if !reporter.Flush(5 * time.Second) {
+		appLogger.Warn("Some error reports were not sent before exiting")
+	}
+
+	if forced {
+		log.Fatalf("Server forced to shutdown")
+	}
 	appLogger.Info("Server exited gracefully")
 }
+
+// listen starts srv in the background, with TLS if it has a TLSConfig
+// A listener that can't start (port taken) stops the process
+func listen(appLogger *logger.Logger, name string, srv *http.Server) {
+	go func() {
+		appLogger.Info(name+" starting", "address", srv.Addr)
+		serve := srv.ListenAndServe
+		if srv.TLSConfig != nil {
+			// Certificates come from TLSConfig.GetCertificate
+			serve = func() error { return srv.ListenAndServeTLS("", "") }
+		}
+		if err := serve(); err != nil && err != http.ErrServerClosed {
+			appLogger.Error(name+" failed", "error", err)
+			log.Fatalf("%s failed: %v", name, err)
+		}
+	}()
+}
+
+// decryptSecrets replaces the kv1:... values of cfg with their plain text
+// Plain values are left as they are (see keys.DecryptSecret)
+func decryptSecrets(ctx context.Context, m keys.Manager, cfg *config.Config) error {
+	secrets := map[string]*string{
+		"NOTIFY_WEBHOOK_SECRET": &cfg.Notify.WebhookSecret,
+		"STRIPE_WEBHOOK_SECRET": &cfg.Billing.StripeWebhookSecret,
+	}
+	for i := range cfg.App.APIKeyPeppers {
+		secrets[fmt.Sprintf("API_KEY_PEPPER (value %d)", i+1)] = &cfg.App.APIKeyPeppers[i]
+	}
+
+	for name, secret := range secrets {
+		plaintext, err := keys.DecryptSecret(ctx, m, *secret)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*secret = plaintext
+	}
+	for _, pepper := range cfg.App.APIKeyPeppers {
+		if len(pepper) < 32 {
+			return errors.New("API_KEY_PEPPER values must be at least 32 characters (generate one with: openssl rand -hex 32)")
+		}
+	}
+	return nil
+}
+
+// buildNotifier combines every configured notification channel
+// Returns a no-op notifier when nothing is configured
+func buildNotifier(cfg config.NotifyConfig) notify.Notifier {
+	var notifiers notify.Multi
+
+	if cfg.WebhookURL != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(cfg.WebhookURL, cfg.WebhookSecret, cfg.WebhookTimeout))
+	}
+	if cfg.SMTPHost != "" && len(cfg.EmailTo) > 0 {
+		notifiers = append(notifiers, notify.NewEmailNotifier(
+			cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPassword, cfg.EmailFrom, cfg.EmailTo,
+		))
+	}
+
+	if len(notifiers) == 0 {
+		return notify.Nop{}
+	}
+	return notifiers
+}
+
+// buildErrorReporter creates the error tracker client
+// Returns errreport.Nop when no tracker is configured (errors are only logged)
+func buildErrorReporter(cfg *config.Config) errreport.Reporter {
+	if cfg.Errors.SentryDSN == "" {
+		return errreport.Nop{}
+	}
+	if cfg.Errors.SampleRate < 0 || cfg.Errors.SampleRate > 1 {
+		log.Fatalf("Invalid SENTRY_SAMPLE_RATE %v (use 0 to 1)", cfg.Errors.SampleRate)
+	}
+	sentry, err := errreport.NewSentry(cfg.Errors.SentryDSN, cfg.Errors.Timeout)
+	if err != nil {
+		log.Fatalf("Invalid SENTRY_DSN: %v", err)
+	}
+	release := cfg.Errors.Release
+	if release == "" {
+		release = errreport.DefaultRelease()
+	}
+	sentry.WithRelease(release).
+		WithEnvironment(cfg.Errors.Environment).
+		WithSampleRate(cfg.Errors.SampleRate)
+	if cfg.Region.Name != "" {
+		sentry.WithTag("region", cfg.Region.Name)
+	}
+	return sentry
+}
+
+// buildEdgePurger picks the CDN purge client
+// Returns nil when no provider is configured (edge copies then expire on their own)
+func buildEdgePurger(cfg config.CDNConfig) service.EdgePurger {
+	switch cfg.Provider {
+	case "":
+		return nil
+	case "cloudflare":
+		if cfg.CloudflareZoneID == "" || cfg.CloudflareAPIToken == "" || len(cfg.Hosts) == 0 {
+			log.Fatalf("CDN_PROVIDER=cloudflare needs CLOUDFLARE_ZONE_ID, CLOUDFLARE_API_TOKEN and CDN_HOSTS")
+		}
+		return cdn.NewCloudflare(cfg.CloudflareZoneID, cfg.CloudflareAPIToken, cfg.Hosts, cfg.PurgeTimeout)
+	case "fastly":
+		if cfg.FastlyServiceID == "" || cfg.FastlyAPIKey == "" {
+			log.Fatalf("CDN_PROVIDER=fastly needs FASTLY_SERVICE_ID and FASTLY_API_KEY")
+		}
+		return cdn.NewFastly(cfg.FastlyServiceID, cfg.FastlyAPIKey, cfg.PurgeTimeout)
+	default:
+		log.Fatalf("Invalid CDN_PROVIDER %q (use cloudflare or fastly)", cfg.Provider)
+		return nil
+	}
+}
+
+// buildWarehouseStore creates the object store client of the warehouse export
+func buildWarehouseStore(cfg config.ETLConfig) (service.ObjectStore, warehouse.Format) {
+	format := warehouse.Format{Encoding: cfg.Format, Compression: cfg.Compression}
+	if err := format.Validate(); err != nil {
+		log.Fatalf("Invalid warehouse export settings: %v", err)
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		log.Fatalf("ETL_BUCKET needs ETL_ACCESS_KEY_ID and ETL_SECRET_ACCESS_KEY")
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+	return warehouse.NewS3(endpoint, cfg.Region, cfg.Bucket, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.Timeout), format
+}
+
+// buildFileStore creates the blob store of file links
+func buildFileStore(cfg config.FilesConfig) blob.Store {
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		log.Fatalf("FILES_BUCKET needs FILES_ACCESS_KEY_ID and FILES_SECRET_ACCESS_KEY")
+	}
+	if cfg.MaxSizeMB <= 0 {
+		log.Fatalf("FILES_MAX_SIZE_MB must be positive")
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+	return blob.NewS3(endpoint, cfg.Region, cfg.Bucket, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.Timeout)
+}
+
+// buildErrorPages sets up the branded error pages
+// A custom domain gets its own branding as soon as any ERROR_PAGE_DOMAIN_*
+// setting names it; the values it doesn't set come from the global ones
+func buildErrorPages(cfg config.ErrorPagesConfig, tmpl *template.Template) *httpHandler.ErrorPages {
+	fallback := httpHandler.ErrorPageBranding{
+		Brand:    cfg.Brand,
+		HomeURL:  cfg.HomeURL,
+		Redirect: cfg.Redirect,
+	}
+	pages := httpHandler.NewErrorPages(tmpl, fallback)
+
+	domains := make(map[string]httpHandler.ErrorPageBranding)
+	branding := func(host string) httpHandler.ErrorPageBranding {
+		if b, ok := domains[host]; ok {
+			return b
+		}
+		return fallback
+	}
+	for host, brand := range cfg.DomainBrands {
+		b := branding(host)
+		b.Brand = brand
+		domains[host] = b
+	}
+	for host, home := range cfg.DomainHomes {
+		b := branding(host)
+		b.HomeURL = home
+		domains[host] = b
+	}
+	for _, host := range cfg.RedirectDomains {
+		b := branding(host)
+		b.Redirect = true
+		domains[host] = b
+	}
+
+	for host, b := range domains {
+		pages.WithDomain(host, b)
+	}
+	return pages
+}
@@ -0,0 +1,262 @@
+package abuse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Action is what to do with a request on the redirect path
+type Action int
+
+const (
+	Allow     Action = iota // Normal traffic
+	Challenge               // Suspicious: browsers may be asked to solve a CAPTCHA
+	Block                   // The IP is banned
+)
+
+// Verdict is the guard's decision about one request
+type Verdict struct {
+	Action     Action
+	RetryAfter time.Duration // Block: how long the ban still lasts
+	Reason     string        // Why the request isn't plain Allow ("" for Allow)
+}
+
+// Ban is one banned IP address
+type Ban struct {
+	IP       string    `json:"ip"`
+	Reason   string    `json:"reason"`
+	Strikes  int       `json:"strikes"` // Bans of this IP within the strike memory, this one included
+	BannedAt time.Time `json:"banned_at"`
+	Until    time.Time `json:"until"`
+}
+
+// Policy sets the thresholds of the guard
+//
+// All counts are per Window. Example with the defaults (10s window):
+//
+//	> 50 requests from one IP     -> suspicious (CAPTCHA for browsers)
+//	> 200 requests from one IP    -> banned for BanDurations[strikes-1]
+//	> 1000 requests to one code   -> every visitor of that code is suspicious
+type Policy struct {
+	Window         time.Duration
+	ChallengeLimit int64           // Requests per IP that make it suspicious (0 = never)
+	BanLimit       int64           // Requests per IP that get it banned (0 = never)
+	CodeLimit      int64           // Requests per short code that make ALL its visitors suspicious (0 = never)
+	BanDurations   []time.Duration // Ban length by strike: 1st ban, 2nd ban, ... (the last one repeats)
+	StrikeMemory   time.Duration   // How long past bans count towards the next one
+}
+
+// DefaultPolicy is aggressive enough for floods, far above what people click
+var DefaultPolicy = Policy{
+	Window:         10 * time.Second,
+	ChallengeLimit: 50,
+	BanLimit:       200,
+	CodeLimit:      1000,
+	BanDurations:   []time.Duration{time.Minute, 10 * time.Minute, time.Hour, 24 * time.Hour},
+	StrikeMemory:   7 * 24 * time.Hour,
+}
+
+// BanDuration returns how long the strikes-th ban of an IP lasts
+// ESCALATION: a scraper that comes back right after its ban ends gets a
+// longer one each time, up to the last duration in the list
+func (p Policy) BanDuration(strikes int) time.Duration {
+	if len(p.BanDurations) == 0 {
+		return 0
+	}
+	if strikes < 1 {
+		strikes = 1
+	}
+	if strikes > len(p.BanDurations) {
+		strikes = len(p.BanDurations)
+	}
+	return p.BanDurations[strikes-1]
+}
+
+// decide turns the counters of one request into a verdict
+// Banning is done by the caller, so this stays a pure function
+func (p Policy) decide(ipCount, codeCount int64) Verdict {
+	switch {
+	case p.BanLimit > 0 && ipCount > p.BanLimit:
+		return Verdict{Action: Block, Reason: "ip_rate"}
+	case p.ChallengeLimit > 0 && ipCount > p.ChallengeLimit:
+		return Verdict{Action: Challenge, Reason: "ip_rate"}
+	case p.CodeLimit > 0 && codeCount > p.CodeLimit:
+		return Verdict{Action: Challenge, Reason: "code_rate"}
+	default:
+		return Verdict{Action: Allow}
+	}
+}
+
+// Guard tracks request rates on the redirect path and bans abusive IPs
+//
+// WHY NOT JUST THE RATE LIMITER?
+// The rate limiter resets every minute: a flood is slowed down but comes
+// back at full speed each window. The guard REMEMBERS offenders - a banned
+// IP gets nothing until the ban ends, and every new ban lasts longer.
+//
+// WHY REDIS?
+// Like the rate limiter: every instance sees the same counters and bans,
+// so spreading a flood over instances doesn't help the attacker.
+type Guard struct {
+	client *redis.Client
+	policy Policy
+	now    func() time.Time
+}
+
+// NewGuard creates a Redis-backed abuse guard
+func NewGuard(client *redis.Client, policy Policy) *Guard {
+	return &Guard{client: client, policy: policy, now: time.Now}
+}
+
+// Redis keys
+//
+//	abuse:ban:{ip}           ban details (JSON), expires when the ban ends
+//	abuse:strikes:{ip}       number of bans, expires after StrikeMemory
+//	abuse:ip:{ip}            requests in the current window
+//	abuse:code:{code}        requests in the current window
+const (
+	banPrefix    = "abuse:ban:"
+	strikePrefix = "abuse:strikes:"
+	ipPrefix     = "abuse:ip:"
+	codePrefix   = "abuse:code:"
+)
+
+// checkScript counts a request and returns the ban TTL (ms, or -2 when not
+// banned), the IP's count and the code's count
+// ONE round trip per redirect, and atomic: two requests arriving at the
+// same moment can't both see the old count
+var checkScript = redis.NewScript(`
+	local ban_ttl = redis.call('PTTL', KEYS[1])
+	if ban_ttl > 0 then
+		return {ban_ttl, 0, 0}
+	end
+
+	local window = tonumber(ARGV[1])
+	local ip_count = redis.call('INCR', KEYS[2])
+	if ip_count == 1 then
+		redis.call('PEXPIRE', KEYS[2], window)
+	end
+
+	local code_count = 0
+	if KEYS[3] ~= '' then
+		code_count = redis.call('INCR', KEYS[3])
+		if code_count == 1 then
+			redis.call('PEXPIRE', KEYS[3], window)
+		end
+	end
+
+	return {-2, ip_count, code_count}
+`)
+
+// Check counts a request from ip to code ("" for pages that aren't links)
+// and decides what to do with it. An IP over the ban limit is banned here.
+func (g *Guard) Check(ctx context.Context, ip, code string) (Verdict, error) {
+	codeKey := ""
+	if code != "" {
+		codeKey = codePrefix + code
+	}
+
+	result, err := checkScript.Run(ctx, g.client,
+		[]string{banPrefix + ip, ipPrefix + ip, codeKey},
+		g.policy.Window.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Verdict{Action: Allow}, fmt.Errorf("abuse check failed: %w", err)
+	}
+
+	if banTTL := result[0]; banTTL > 0 {
+		return Verdict{Action: Block, RetryAfter: time.Duration(banTTL) * time.Millisecond, Reason: "banned"}, nil
+	}
+
+	ipCount := result[1]
+	verdict := g.policy.decide(ipCount, result[2])
+	if verdict.Action != Block {
+		return verdict, nil
+	}
+
+	// Only the request that CROSSES the limit bans: requests already in
+	// flight don't each add a strike (which would escalate the ban at once)
+	verdict.RetryAfter = g.policy.Window
+	if ipCount == g.policy.BanLimit+1 {
+		ban, err := g.Ban(ctx, ip, fmt.Sprintf("more than %d requests in %s", g.policy.BanLimit, g.policy.Window))
+		if err != nil {
+			return verdict, err
+		}
+		verdict.RetryAfter = ban.Until.Sub(ban.BannedAt)
+	}
+	return verdict, nil
+}
+
+// Ban bans ip for the duration its strike count earns
+func (g *Guard) Ban(ctx context.Context, ip, reason string) (*Ban, error) {
+	strikeKey := strikePrefix + ip
+	pipe := g.client.TxPipeline()
+	strikes := pipe.Incr(ctx, strikeKey)
+	pipe.Expire(ctx, strikeKey, g.policy.StrikeMemory)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count strike: %w", err)
+	}
+
+	now := g.now().UTC()
+	duration := g.policy.BanDuration(int(strikes.Val()))
+	ban := &Ban{
+		IP:       ip,
+		Reason:   reason,
+		Strikes:  int(strikes.Val()),
+		BannedAt: now,
+		Until:    now.Add(duration),
+	}
+
+	data, err := json.Marshal(ban)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ban: %w", err)
+	}
+	if err := g.client.Set(ctx, banPrefix+ip, data, duration).Err(); err != nil {
+		return nil, fmt.Errorf("failed to store ban: %w", err)
+	}
+	return ban, nil
+}
+
+// Bans lists the IPs banned right now
+// SCAN (not KEYS) so a long ban list doesn't block Redis
+func (g *Guard) Bans(ctx context.Context) ([]Ban, error) {
+	var bans []Ban
+	iter := g.client.Scan(ctx, 0, banPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		data, err := g.client.Get(ctx, iter.Val()).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue // Expired between SCAN and GET
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ban: %w", err)
+		}
+
+		var ban Ban
+		if err := json.Unmarshal(data, &ban); err != nil {
+			return nil, fmt.Errorf("failed to decode ban %s: %w", strings.TrimPrefix(iter.Val(), banPrefix), err)
+		}
+		bans = append(bans, ban)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list bans: %w", err)
+	}
+	return bans, nil
+}
+
+// Lift ends the ban of ip and forgets its strikes
+// Returns false when the IP wasn't banned
+func (g *Guard) Lift(ctx context.Context, ip string) (bool, error) {
+	pipe := g.client.TxPipeline()
+	banned := pipe.Del(ctx, banPrefix+ip)
+	pipe.Del(ctx, strikePrefix+ip)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to lift ban: %w", err)
+	}
+	return banned.Val() > 0, nil
+}
@@ -0,0 +1,50 @@
+package abuse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_BanDuration(t *testing.T) {
+	policy := DefaultPolicy
+
+	assert.Equal(t, time.Minute, policy.BanDuration(0))
+	assert.Equal(t, time.Minute, policy.BanDuration(1))
+	assert.Equal(t, 10*time.Minute, policy.BanDuration(2))
+	assert.Equal(t, time.Hour, policy.BanDuration(3))
+	assert.Equal(t, 24*time.Hour, policy.BanDuration(4))
+	assert.Equal(t, 24*time.Hour, policy.BanDuration(12), "the last duration repeats")
+	assert.Equal(t, time.Duration(0), Policy{}.BanDuration(1))
+}
+
+func TestPolicy_Decide(t *testing.T) {
+	policy := Policy{ChallengeLimit: 50, BanLimit: 200, CodeLimit: 1000}
+
+	tests := []struct {
+		name       string
+		ipCount    int64
+		codeCount  int64
+		wantAction Action
+		wantReason string
+	}{
+		{name: "normal", ipCount: 3, codeCount: 40, wantAction: Allow},
+		{name: "at the challenge limit", ipCount: 50, codeCount: 40, wantAction: Allow},
+		{name: "busy IP", ipCount: 51, codeCount: 40, wantAction: Challenge, wantReason: "ip_rate"},
+		{name: "flooding IP", ipCount: 201, codeCount: 40, wantAction: Block, wantReason: "ip_rate"},
+		{name: "hot code", ipCount: 1, codeCount: 1001, wantAction: Challenge, wantReason: "code_rate"},
+		{name: "flood beats hot code", ipCount: 500, codeCount: 5000, wantAction: Block, wantReason: "ip_rate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := policy.decide(tt.ipCount, tt.codeCount)
+			assert.Equal(t, tt.wantAction, verdict.Action)
+			assert.Equal(t, tt.wantReason, verdict.Reason)
+		})
+	}
+
+	// Zero limits turn a check off
+	assert.Equal(t, Allow, Policy{}.decide(1_000_000, 1_000_000).Action)
+}
@@ -0,0 +1,158 @@
+package scim
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SCIM 2.0 DTOs (RFC 7643 resources, RFC 7644 protocol messages)
+//
+// WHY NOT THE v1 ENVELOPE?
+// SCIM clients are identity providers (Okta, Entra ID, OneLogin) that speak
+// the standard and nothing else: resources go out bare, lists and errors
+// have their own shapes, and attribute names are camelCase.
+
+// ContentType is the media type of every SCIM response
+const ContentType = "application/scim+json"
+
+// Schema URNs
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+)
+
+// Error types (scimType) of 400 and 409 errors
+const (
+	ErrorInvalidFilter = "invalidFilter"
+	ErrorInvalidSyntax = "invalidSyntax"
+	ErrorInvalidValue  = "invalidValue"
+	ErrorInvalidPath   = "invalidPath"
+	ErrorUniqueness    = "uniqueness"
+)
+
+// User is a workspace member
+type User struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	UserName    string   `json:"userName"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Name        *Name    `json:"name,omitempty"`   // Requests only: used when displayName is missing
+	Emails      []Email  `json:"emails,omitempty"` // Only the primary (or first) one is kept
+	Active      *bool    `json:"active,omitempty"` // Absent in a request = true
+	Groups      []Ref    `json:"groups,omitempty"` // Read-only
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+// Name is the structured name of a user
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// Email is one email address of a user
+type Email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// Group is a set of members; its displayName decides their role
+type Group struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	DisplayName string   `json:"displayName"`
+	Members     []Ref    `json:"members,omitempty"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+// Ref points at another resource: a user's group or a group's member
+type Ref struct {
+	Value   string `json:"value"`
+	Ref     string `json:"$ref,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// Meta describes a resource
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+// ListResponse is a page of resources (startIndex is 1-based)
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    any      `json:"Resources"`
+}
+
+// PatchRequest is the body of PATCH /Users/{id} and /Groups/{id}
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation is one change: op is add, replace or remove (any case)
+// Value is raw because its type depends on the path: identity providers
+// even send booleans as strings ("False")
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Error is the body of every SCIM error; Status is a string, per the RFC
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// ServiceProviderConfig tells identity providers what this server supports
+type ServiceProviderConfig struct {
+	Schemas               []string               `json:"schemas"`
+	Patch                 Supported              `json:"patch"`
+	Bulk                  BulkSupport            `json:"bulk"`
+	Filter                FilterSupport          `json:"filter"`
+	ChangePassword        Supported              `json:"changePassword"`
+	Sort                  Supported              `json:"sort"`
+	ETag                  Supported              `json:"etag"`
+	AuthenticationSchemes []AuthenticationScheme `json:"authenticationSchemes"`
+}
+
+// Supported says whether a feature is available
+type Supported struct {
+	Supported bool `json:"supported"`
+}
+
+// BulkSupport describes bulk operations
+type BulkSupport struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+// FilterSupport describes filtering
+type FilterSupport struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+// AuthenticationScheme describes how to authenticate
+type AuthenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary"`
+}
@@ -0,0 +1,993 @@
+package v1
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Request/Response DTOs (Data Transfer Objects)
+// These are separate from domain models because:
+// 1. API contracts should be stable even if domain models change
+// 2. We might want to expose/hide certain fields
+// 3. We can add API-specific validation (validate tags, checked by internal/validation)
+//
+// Each API version has its own DTO package, so v1 shapes stay frozen for
+// existing clients while newer versions are free to fix them
+
+type CreateURLRequest struct {
+	URL            string `json:"url" validate:"required,httpurl" label:"URL"`
+	CustomAlias    string `json:"custom_alias,omitempty" validate:"alias"`
+	ExpiresInHours int    `json:"expires_in_hours,omitempty" validate:"min=0"`
+	MaxClicks      int64  `json:"max_clicks,omitempty" validate:"min=1"`
+	Domain         string `json:"domain,omitempty" validate:"host"`
+
+	// Destination per visitor language, e.g. {"fr": "https://example.com/fr"}
+	LanguageTargets map[string]string `json:"language_targets,omitempty" validate:"max=20,dive,httpurl"`
+	Schedule        *Schedule         `json:"schedule,omitempty"`
+
+	// Only redirect with a valid ?sig= (see SigningSecret in the response)
+	Signed bool `json:"signed,omitempty"`
+
+	// Stop redirecting after the first visit (e.g. password reset links)
+	SingleUse bool `json:"single_use,omitempty"`
+
+	// false = only count clicks, don't record click events (default: true)
+	Analytics *bool `json:"analytics,omitempty"`
+
+	// Notes for the owner and integrations; they never change the redirect
+	Description    string            `json:"description,omitempty"`
+	CustomMetadata map[string]string `json:"custom_metadata,omitempty"` // e.g. {"ticket": "MKT-142"}
+
+	// "sensitive" = workspace viewers see the stats but not the destinations
+	Visibility string `json:"visibility,omitempty" validate:"oneof=standard sensitive"`
+
+	// Solved hCaptcha/Turnstile token; required without an API key when
+	// the server has CAPTCHAs enabled
+	CaptchaToken string `json:"captcha_token,omitempty"`
+}
+
+type CreateURLResponse struct {
+	ID          string     `json:"id"`
+	ShortCode   string     `json:"short_code"`
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	ResolvedURL *string    `json:"resolved_url,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	MaxClicks   *int64     `json:"max_clicks,omitempty"`
+
+	LanguageTargets map[string]string `json:"language_targets,omitempty"`
+	Schedule        *Schedule         `json:"schedule,omitempty"`
+	SingleUse       bool              `json:"single_use,omitempty"`
+	Analytics       *bool             `json:"analytics,omitempty"` // false when the link opted out
+
+	Description    string            `json:"description,omitempty"`
+	CustomMetadata map[string]string `json:"custom_metadata,omitempty"`
+
+	// Links held for approval: "pending" until an editor or owner decides,
+	// then "approved" or "rejected"; submitted_by is the contributor
+	Approval    string `json:"approval,omitempty"`
+	SubmittedBy string `json:"submitted_by,omitempty"`
+
+	// "sensitive" links reach viewers without original_url and the other
+	// destinations; absent for standard links
+	Visibility string `json:"visibility,omitempty"`
+
+	// The destination is stored encrypted: original_url is empty, only the
+	// redirect decrypts it
+	DestinationEncrypted bool `json:"destination_encrypted,omitempty"`
+
+	// Signed links only: the secret to sign the link yourself, and a URL
+	// with a signature that never expires
+	Signed        bool    `json:"signed,omitempty"`
+	SigningSecret *string `json:"signing_secret,omitempty"`
+	SignedURL     string  `json:"signed_url,omitempty"`
+}
+
+// ValidateURLResponse is what POST /api/v1/urls would create, answered by
+// POST /api/v1/urls/validate (same body as the create request)
+// Failed checks answer with the status and error creating would return.
+type ValidateURLResponse struct {
+	Valid       bool       `json:"valid"`                // Always true: invalid links get an error response
+	ShortCode   string     `json:"short_code,omitempty"` // The custom alias; generated codes are drawn at creation
+	ShortURL    string     `json:"short_url,omitempty"`
+	OriginalURL string     `json:"original_url"`
+	ResolvedURL *string    `json:"resolved_url,omitempty"`
+	Domain      string     `json:"domain,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // If created now
+
+	// "pending" when the link would wait for an editor's approval
+	Approval string `json:"approval,omitempty"`
+
+	// The popular alias custom_alias looks like: the link would be created,
+	// but flagged for review
+	ConfusableWith string `json:"confusable_with,omitempty"`
+
+	// Links the caller can still create this month, this one included
+	// (-1 = unlimited; absent for callers without a quota)
+	QuotaRemaining *int64 `json:"quota_remaining,omitempty"`
+}
+
+// ApprovalDecisionRequest is the body of POST /api/v1/approvals/{id}/approve
+// and POST /api/v1/approvals/{id}/reject
+type ApprovalDecisionRequest struct {
+	Reason string `json:"reason,omitempty" validate:"max=500"` // Sent to the contributor and kept in the notification
+}
+
+// SignURLRequest is the body of POST /api/v1/urls/{id}/sign
+type SignURLRequest struct {
+	ExpiresInHours int `json:"expires_in_hours,omitempty" validate:"min=0"` // 0 = the signature never expires
+}
+
+// SignedURLResponse is a URL that opens a signed link
+type SignedURLResponse struct {
+	SignedURL string     `json:"signed_url"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CloneURLRequest is the body of POST /api/v1/urls/{id}/clone and
+// POST /api/v1/templates/{id}/urls: everything else comes from the source
+type CloneURLRequest struct {
+	URL         string `json:"url" validate:"required,httpurl" label:"URL"`
+	CustomAlias string `json:"custom_alias,omitempty" validate:"alias"`
+}
+
+// UpsertURLRequest is the desired state for PUT /api/v1/urls/{alias}
+// Leaving language_targets out removes them: the body is the WHOLE desired state
+type UpsertURLRequest struct {
+	URL             string            `json:"url" validate:"required,httpurl" label:"URL"`
+	LanguageTargets map[string]string `json:"language_targets,omitempty" validate:"max=20,dive,httpurl"`
+	Schedule        *Schedule         `json:"schedule,omitempty"`
+	Description     string            `json:"description,omitempty"`
+	CustomMetadata  map[string]string `json:"custom_metadata,omitempty"`
+}
+
+// URLVisibility is the body (and response) of PUT /api/v1/urls/{id}/visibility
+type URLVisibility struct {
+	Visibility string `json:"visibility" validate:"required,oneof=standard sensitive"`
+}
+
+// URLNotes is the body (and response) of PUT /api/v1/urls/{id}/notes
+// It replaces both: leaving custom_metadata out removes every key
+type URLNotes struct {
+	Description    string            `json:"description"`
+	CustomMetadata map[string]string `json:"custom_metadata,omitempty"`
+}
+
+// UpsertURLResponse is the URL after the upsert
+type UpsertURLResponse struct {
+	CreateURLResponse
+	Result string `json:"result"` // "created", "updated" or "unchanged"
+}
+
+type URLStatsResponse struct {
+	ID                   string            `json:"id"`
+	ShortCode            string            `json:"short_code"`
+	OriginalURL          string            `json:"original_url"`
+	ResolvedURL          *string           `json:"resolved_url,omitempty"`
+	Clicks               int64             `json:"clicks"`
+	CreatedAt            time.Time         `json:"created_at"`
+	ExpiresAt            *time.Time        `json:"expires_at,omitempty"`
+	MaxClicks            *int64            `json:"max_clicks,omitempty"`
+	Metadata             *LinkMetadata     `json:"metadata,omitempty"` // Absent until fetched
+	Preview              *PreviewCard      `json:"preview,omitempty"`  // Absent unless the owner set one
+	LanguageTargets      map[string]string `json:"language_targets,omitempty"`
+	Schedule             *Schedule         `json:"schedule,omitempty"`
+	SingleUse            bool              `json:"single_use,omitempty"`
+	UsedAt               *time.Time        `json:"used_at,omitempty"` // Single-use links: when the one visit happened
+	Description          string            `json:"description,omitempty"`
+	CustomMetadata       map[string]string `json:"custom_metadata,omitempty"`
+	Approval             string            `json:"approval,omitempty"` // "pending", "approved" or "rejected"; absent if never held
+	SubmittedBy          string            `json:"submitted_by,omitempty"`
+	Visibility           string            `json:"visibility,omitempty"`            // "sensitive"; absent for standard links
+	DestinationEncrypted bool              `json:"destination_encrypted,omitempty"` // original_url is stored encrypted (and empty here)
+	Analytics            AnalyticsStatus   `json:"analytics"`
+	RecentClicks         []ClickInfo       `json:"recent_clicks"` // Always empty while analytics is off
+}
+
+// AnalyticsStatus tells whether click events are recorded for a link
+// While they aren't, the click counter still counts but recent_clicks stays
+// empty - reason and message say why
+type AnalyticsStatus struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`  // "disabled_globally" or "disabled_for_link"
+	Message string `json:"message,omitempty"` // Human-readable explanation (localized)
+}
+
+// LinkMetadata describes the destination page, so UIs can show links by name
+type LinkMetadata struct {
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	FaviconURL  string    `json:"favicon_url,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// PreviewCard is the owner's social preview card
+// Used by PUT /api/v1/urls/{id}/preview and in stats responses
+type PreviewCard struct {
+	Title       string `json:"title,omitempty" validate:"max=200"`
+	Description string `json:"description,omitempty" validate:"max=500"`
+	ImageURL    string `json:"image_url,omitempty" validate:"httpurl"`
+}
+
+type ClickInfo struct {
+	ClickedAt   time.Time `json:"clicked_at"`
+	CountryCode string    `json:"country_code,omitempty"`
+	City        string    `json:"city,omitempty"`
+	Channel     string    `json:"channel,omitempty"` // search, social, email, direct, internal or referral
+	Browser     string    `json:"browser,omitempty"`
+	OS          string    `json:"os,omitempty"`
+	DeviceType  string    `json:"device_type,omitempty"` // desktop, mobile, tablet, bot or unknown
+	Region      string    `json:"region,omitempty"`      // Deployment region that served the redirect
+}
+
+// URLSummaryResponse is the body of GET /api/v1/urls/{code}/summary
+// Every breakdown maps a value to its number of clicks
+type URLSummaryResponse struct {
+	ID               string           `json:"id"`
+	ShortCode        string           `json:"short_code"`
+	Clicks           int64            `json:"clicks"`
+	Channels         map[string]int64 `json:"channels"`
+	Devices          map[string]int64 `json:"devices"`
+	Browsers         map[string]int64 `json:"browsers"`
+	OperatingSystems map[string]int64 `json:"operating_systems"`
+	Regions          map[string]int64 `json:"regions"`  // Deployment region that served the clicks
+	Timezone         string           `json:"timezone"` // Timezone the daily buckets are aligned to
+	Daily            []ClickBucket    `json:"daily"`    // Clicks per day of the last 30 days, oldest first
+	Comparison       *ClickComparison `json:"comparison,omitempty"`
+}
+
+// ClickComparison compares the last 7 days with the 7 days before
+type ClickComparison struct {
+	Current       ClickPeriod `json:"current"`
+	Previous      ClickPeriod `json:"previous"`
+	ChangePercent *float64    `json:"change_percent"` // e.g. 12.5 or -40; null when the previous period had no clicks
+	Trend         string      `json:"trend"`          // rising, falling, steady, new or inactive
+}
+
+// ClickPeriod is the number of clicks from From (inclusive) to To (exclusive)
+type ClickPeriod struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Clicks int64     `json:"clicks"`
+}
+
+// ClickBucket is the number of clicks in one interval
+// Start carries the timezone's offset, e.g. "2026-03-29T00:00:00+01:00"
+type ClickBucket struct {
+	Start  time.Time `json:"start"`
+	Clicks int64     `json:"clicks"`
+}
+
+// TimeseriesResponse is the body of GET /api/v1/urls/{code}/timeseries
+type TimeseriesResponse struct {
+	ID        string        `json:"id"`
+	ShortCode string        `json:"short_code"`
+	Interval  string        `json:"interval"` // hour, day, week or month
+	Timezone  string        `json:"timezone"`
+	From      time.Time     `json:"from"` // Start of the first bucket
+	To        time.Time     `json:"to"`   // End of the last bucket (exclusive)
+	Buckets   []ClickBucket `json:"buckets"`
+}
+
+// HeatmapResponse is the body of GET /api/v1/urls/{code}/stats/heatmap
+// Clicks[day][hour]: 7 rows (Days, Monday first) of 24 hours in Timezone
+type HeatmapResponse struct {
+	ID        string       `json:"id"`
+	ShortCode string       `json:"short_code"`
+	Timezone  string       `json:"timezone"`
+	From      time.Time    `json:"from"` // A Monday midnight
+	To        time.Time    `json:"to"`   // A later Monday midnight (exclusive)
+	Days      []string     `json:"days"` // Row labels: "monday" ... "sunday"
+	Clicks    [][]int64    `json:"clicks"`
+	Total     int64        `json:"total"`
+	Peak      *HeatmapCell `json:"peak,omitempty"` // Busiest hour; absent without clicks
+}
+
+// HeatmapCell points at one hour of the week
+type HeatmapCell struct {
+	Day    string `json:"day"`
+	Hour   int    `json:"hour"`
+	Clicks int64  `json:"clicks"`
+}
+
+// TopLinksResponse is the body of GET /api/v1/stats/top
+type TopLinksResponse struct {
+	Period string            `json:"period"` // 24h, 7d or 30d
+	From   time.Time         `json:"from"`   // Start of the first counted hour
+	To     time.Time         `json:"to"`     // When the ranking was computed (cached up to a minute)
+	Links  []TopLinkResponse `json:"links"`
+}
+
+// TopLinkResponse is one link of the leaderboard
+type TopLinkResponse struct {
+	Rank           int    `json:"rank"`
+	ID             string `json:"id"`
+	ShortCode      string `json:"short_code"`
+	ShortURL       string `json:"short_url"`
+	OriginalURL    string `json:"original_url"`
+	Clicks         int64  `json:"clicks"`
+	UniqueVisitors int64  `json:"unique_visitors"`
+}
+
+// WorkspaceSettingsRequest is the body of PUT /api/v1/settings
+type WorkspaceSettingsRequest struct {
+	// Analytics timezone; "" goes back to the server default
+	Timezone string `json:"timezone" validate:"timezone"`
+
+	// Contributors' links wait for an editor or owner to approve them
+	RequireApproval bool `json:"require_approval"`
+
+	// SCIM group name -> workspace role of its members
+	GroupRoles map[string]string `json:"group_roles,omitempty" validate:"dive,oneof=owner editor contributor viewer"`
+}
+
+// WorkspaceSettingsResponse is the body of GET/PUT /api/v1/settings
+type WorkspaceSettingsResponse struct {
+	Workspace       string            `json:"workspace"`
+	Timezone        string            `json:"timezone"`
+	RequireApproval bool              `json:"require_approval,omitempty"`
+	GroupRoles      map[string]string `json:"group_roles,omitempty"`
+	Plan            string            `json:"plan,omitempty"`       // Set by operators; empty = the default plan
+	UpdatedAt       *time.Time        `json:"updated_at,omitempty"` // Not set until the first save
+}
+
+// WorkspacePlanRequest is the body of PUT /api/v1/workspaces/{workspace}/plan
+type WorkspacePlanRequest struct {
+	Plan string `json:"plan" validate:"oneof=free pro"` // "" = the default plan
+}
+
+// WorkspacePlanResponse is the body of PUT /api/v1/workspaces/{workspace}/plan
+type WorkspacePlanResponse struct {
+	Workspace string `json:"workspace"`
+	Plan      string `json:"plan"`
+}
+
+// SCIMTokenResponse is the body of POST /api/v1/scim/token
+// The identity provider needs both: where to send users, and how
+type SCIMTokenResponse struct {
+	Token    string `json:"token"`
+	Endpoint string `json:"endpoint"` // SCIM base URL, e.g. https://sho.rt/scim/v2
+}
+
+// APIKeyRequest is the body of POST /api/v1/keys
+type APIKeyRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,dive,oneof=urls:read urls:write stats:read admin"`
+	// When the key stops working (omitted = never); expired keys are deleted
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Operators only: the workspace the key is for (omitted = the caller's
+	// own). This is how a new workspace gets its first owner key.
+	Workspace string `json:"workspace,omitempty" validate:"max=100"`
+}
+
+// RotateAPIKeyRequest is the optional body of POST /api/v1/keys/{id}/rotate
+type RotateAPIKeyRequest struct {
+	// How long the old secret keeps working (omitted = the server default,
+	// 0 = it stops at once)
+	GracePeriodHours *int       `json:"grace_period_hours,omitempty" validate:"min=0,max=720"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"` // New expiry (omitted = unchanged)
+}
+
+// APIKeyResponse describes an API key
+// Token is only set when a key is created or rotated: it is not stored
+type APIKeyResponse struct {
+	ID         string     `json:"id"`
+	Workspace  string     `json:"workspace"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Start of the secret, e.g. "sk_Ab3dE9xY"
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // Accurate to a minute
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	// Until when the secret replaced by the last rotation still works
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+	Token                   string     `json:"token,omitempty"`
+}
+
+// SAMLConnectionRequest is the body of PUT /api/v1/sso/saml
+// The three IdP values come from the app the owner created in their IdP
+type SAMLConnectionRequest struct {
+	IdPEntityID   string `json:"idp_entity_id" validate:"required"`
+	SSOURL        string `json:"sso_url" validate:"required,httpurl"`
+	Certificate   string `json:"certificate" validate:"required"` // PEM or base64 signing certificate
+	RoleAttribute string `json:"role_attribute"`                  // e.g. "groups"; values map through group_roles
+}
+
+// SAMLConnectionResponse is the body of GET/PUT /api/v1/sso/saml
+// The sp_* fields are what the owner enters in their IdP
+type SAMLConnectionResponse struct {
+	IdPEntityID          string     `json:"idp_entity_id"`
+	SSOURL               string     `json:"sso_url"`
+	RoleAttribute        string     `json:"role_attribute,omitempty"`
+	CertificateExpiresAt *time.Time `json:"certificate_expires_at,omitempty"`
+	SPEntityID           string     `json:"sp_entity_id"`
+	ACSURL               string     `json:"acs_url"`
+	MetadataURL          string     `json:"metadata_url"`
+	LoginURL             string     `json:"login_url"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// SessionResponse is the body of a successful SAML sign-in
+// The token is sent as "Authorization: Bearer <token>" until it expires
+type SessionResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"` // Always "Bearer"
+	ExpiresAt time.Time `json:"expires_at"`
+	Workspace string    `json:"workspace"`
+	Member    string    `json:"member"`
+	Role      string    `json:"role"`
+
+	// RelayState the IdP posted back: where the app wanted to go after sign-in
+	RelayState string `json:"relay_state,omitempty"`
+}
+
+// ResolveResponse is the body of GET /api/v1/urls/{code}/resolve
+// Chain starts with the short link itself, then every request made from the
+// destination on; the final_* fields describe the last hop
+type ResolveResponse struct {
+	ShortCode        string        `json:"short_code"`
+	Chain            []RedirectHop `json:"chain"`
+	FinalURL         string        `json:"final_url,omitempty"`
+	FinalStatusCode  int           `json:"final_status_code,omitempty"`
+	FinalContentType string        `json:"final_content_type,omitempty"`
+	Redirects        int           `json:"redirects"`           // Hops after the short link that redirected
+	Truncated        bool          `json:"truncated,omitempty"` // More redirects than we follow
+	ViaShortener     bool          `json:"via_shortener,omitempty"`
+	Error            string        `json:"error,omitempty"` // Why following stopped early
+}
+
+// RedirectHop is one request in a redirect chain
+type RedirectHop struct {
+	URL         string `json:"url"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+type AliasSuggestionsResponse struct {
+	Suggestions []string `json:"suggestions"`
+}
+
+type AliasAvailabilityResponse struct {
+	Alias     string `json:"alias"`
+	Available bool   `json:"available"`
+	Status    string `json:"status"` // "free", "reserved", or "taken"
+}
+
+type ImportJobResponse struct {
+	ID         string            `json:"id"`
+	State      string            `json:"state"` // "pending", "running", "completed", or "failed"
+	DryRun     bool              `json:"dry_run"`
+	Total      int               `json:"total"`
+	Processed  int               `json:"processed"`
+	Created    int               `json:"created"`
+	Failed     int               `json:"failed"`
+	Progress   float64           `json:"progress"` // 0.0 - 1.0
+	CreatedAt  time.Time         `json:"created_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+	Results    []ImportRowResult `json:"results"`
+	Error      string            `json:"error,omitempty"` // Why a failed import stopped
+}
+
+type ImportRowResult struct {
+	Line           int    `json:"line"`
+	Alias          string `json:"alias,omitempty"`
+	URL            string `json:"url"`
+	ShortCode      string `json:"short_code,omitempty"`
+	AliasPreserved bool   `json:"alias_preserved"`
+	Status         string `json:"status"` // "created", "would_create", or "failed"
+	Error          string `json:"error,omitempty"`
+}
+
+type ExportRecord struct {
+	ID             string        `json:"id"`
+	ShortCode      string        `json:"short_code"`
+	OriginalURL    string        `json:"original_url"`
+	CustomAlias    *string       `json:"custom_alias,omitempty"`
+	Domain         string        `json:"domain,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	ExpiresAt      *time.Time    `json:"expires_at,omitempty"`
+	IsActive       bool          `json:"is_active"`
+	Clicks         int64         `json:"clicks"`
+	MaxClicks      *int64        `json:"max_clicks,omitempty"`
+	UniqueVisitors int64         `json:"unique_visitors"`
+	LastClickedAt  *time.Time    `json:"last_clicked_at,omitempty"`
+	Metadata       *LinkMetadata `json:"metadata,omitempty"` // NDJSON only; CSV columns stay stable
+
+	Description    string            `json:"description,omitempty"`     // NDJSON only
+	CustomMetadata map[string]string `json:"custom_metadata,omitempty"` // NDJSON only
+}
+
+// InventoryURL is one line of GET /api/v1/urls/stream
+// Keep the cursor of the last line processed: ?after=<cursor> resumes there
+type InventoryURL struct {
+	Cursor      string        `json:"cursor"`
+	ID          string        `json:"id"`
+	ShortCode   string        `json:"short_code"`
+	OriginalURL string        `json:"original_url"`
+	CustomAlias *string       `json:"custom_alias,omitempty"`
+	Domain      string        `json:"domain,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	ExpiresAt   *time.Time    `json:"expires_at,omitempty"`
+	IsActive    bool          `json:"is_active"`
+	Clicks      int64         `json:"clicks"`
+	MaxClicks   *int64        `json:"max_clicks,omitempty"`
+	Metadata    *LinkMetadata `json:"metadata,omitempty"`
+
+	Description    string            `json:"description,omitempty"`
+	CustomMetadata map[string]string `json:"custom_metadata,omitempty"`
+}
+
+// SearchHit is one result of GET /api/v1/search, best match first
+// Highlights holds the fields that matched as HTML (escaped, matches in
+// <mark>), keyed "alias", "title", "original_url" and "description"
+type SearchHit struct {
+	ID          string            `json:"id"`
+	ShortCode   string            `json:"short_code"`
+	ShortURL    string            `json:"short_url"`
+	OriginalURL string            `json:"original_url"`
+	CustomAlias *string           `json:"custom_alias,omitempty"`
+	Domain      string            `json:"domain,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	IsActive    bool              `json:"is_active"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Clicks      int64             `json:"clicks"`
+	Score       float64           `json:"score"`
+	Highlights  map[string]string `json:"highlights"`
+}
+
+type ErasureResponse struct {
+	ID          string          `json:"id"`
+	Status      string          `json:"status"` // "pending", "running", "completed", or "failed"
+	RequestedAt time.Time       `json:"requested_at"`
+	Error       string          `json:"error,omitempty"`
+	Receipt     *ErasureReceipt `json:"receipt,omitempty"` // Only once completed
+}
+
+// ErasureReceipt proves what was deleted without naming whose data it was
+// subject_hash is the SHA-256 of the owner ID
+type ErasureReceipt struct {
+	RequestID     string    `json:"request_id"`
+	SubjectHash   string    `json:"subject_hash"`
+	URLsDeleted   int64     `json:"urls_deleted"`
+	ClicksDeleted int64     `json:"clicks_deleted"`
+	Verified      bool      `json:"verified"`
+	RequestedAt   time.Time `json:"requested_at"`
+	CompletedAt   time.Time `json:"completed_at"`
+}
+
+type UsageResponse struct {
+	Plan        string    `json:"plan"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"` // When the counter resets
+	Used        int64     `json:"used"`
+	Limit       *int64    `json:"limit"`     // null = unlimited
+	Remaining   *int64    `json:"remaining"` // null = unlimited
+}
+
+// BillingPortalResponse carries the Stripe customer portal link
+// The link is single-use and expires after a few minutes
+type BillingPortalResponse struct {
+	URL string `json:"url"`
+}
+
+// SlackWorkspaceRequest configures the /shorten command for one Slack team
+type SlackWorkspaceRequest struct {
+	TeamName      string `json:"team_name,omitempty"`
+	SigningSecret string `json:"signing_secret" validate:"required"` // From the Slack app settings
+	Owner         string `json:"owner,omitempty"`                    // Defaults to "slack:<team_id>"
+	Domain        string `json:"domain,omitempty" validate:"host"`   // Custom domain for new links
+	InChannel     bool   `json:"in_channel,omitempty"`               // Replies visible to the channel
+}
+
+// SlackWorkspaceResponse never includes the signing secret
+type SlackWorkspaceResponse struct {
+	TeamID    string    `json:"team_id"`
+	TeamName  string    `json:"team_name,omitempty"`
+	Owner     string    `json:"owner"`
+	Domain    string    `json:"domain,omitempty"`
+	InChannel bool      `json:"in_channel"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PageRequest creates or replaces a link-in-bio page
+// Blocks are shown in the order given; send a block's id to keep its click count
+type PageRequest struct {
+	Handle string             `json:"handle" validate:"required"` // Public at /@handle
+	Title  string             `json:"title,omitempty" validate:"max=100"`
+	Bio    string             `json:"bio,omitempty" validate:"max=500"`
+	Theme  *PageThemeRequest  `json:"theme,omitempty"` // Missing values use the default theme
+	Blocks []PageBlockRequest `json:"blocks" validate:"max=50"`
+}
+
+type PageThemeRequest struct {
+	Background  string `json:"background,omitempty"`
+	Text        string `json:"text,omitempty"`
+	Button      string `json:"button,omitempty"`
+	ButtonText  string `json:"button_text,omitempty"`
+	ButtonShape string `json:"button_shape,omitempty" validate:"oneof=rounded pill square"`
+}
+
+type PageBlockRequest struct {
+	ID    string `json:"id,omitempty"` // Existing block to update
+	Title string `json:"title" validate:"required,max=80"`
+	URL   string `json:"url" validate:"required,httpurl" label:"URL"`
+}
+
+// PageResponse is a page with per-block click analytics
+type PageResponse struct {
+	ID        string              `json:"id"`
+	Handle    string              `json:"handle"`
+	PageURL   string              `json:"page_url"`
+	Title     string              `json:"title"`
+	Bio       string              `json:"bio"`
+	Theme     PageThemeRequest    `json:"theme"`
+	Blocks    []PageBlockResponse `json:"blocks"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+type PageBlockResponse struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	Position int    `json:"position"`
+	Clicks   int64  `json:"clicks"`
+}
+
+// Schedule rotates the destination by time of day / day of week
+// The first rule covering the current time wins; no match = the usual URL
+type Schedule struct {
+	Timezone string         `json:"timezone,omitempty"` // IANA name, e.g. "Europe/Berlin" (default: the server's)
+	Rules    []ScheduleRule `json:"rules" validate:"required,max=20"`
+}
+
+// ScheduleRule sends visitors to URL on Days between Start and End ("HH:MM")
+// End before Start runs past midnight; no times = the whole day
+type ScheduleRule struct {
+	Days  []string `json:"days,omitempty"` // "mon".."sun" (default: every day)
+	Start string   `json:"start,omitempty"`
+	End   string   `json:"end,omitempty"`
+	URL   string   `json:"url" validate:"required,httpurl" label:"URL"`
+}
+
+// TemplateRequest is the body of POST and PUT /api/v1/templates
+// The settings fields mean the same as in CreateURLRequest
+type TemplateRequest struct {
+	Name            string            `json:"name" validate:"required,max=100"`
+	ExpiresInHours  int               `json:"expires_in_hours,omitempty" validate:"min=0"`
+	MaxClicks       *int64            `json:"max_clicks,omitempty" validate:"min=1"`
+	Domain          string            `json:"domain,omitempty" validate:"host"`
+	LanguageTargets map[string]string `json:"language_targets,omitempty" validate:"max=20,dive,httpurl"`
+	Schedule        *Schedule         `json:"schedule,omitempty"`
+	Preview         *PreviewCard      `json:"preview,omitempty"`
+	Signed          bool              `json:"signed,omitempty"` // Every link gets its own secret
+	SingleUse       bool              `json:"single_use,omitempty"`
+	Analytics       *bool             `json:"analytics,omitempty"`
+}
+
+// TemplateResponse is a link template
+type TemplateResponse struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	ExpiresInHours  int               `json:"expires_in_hours,omitempty"`
+	MaxClicks       *int64            `json:"max_clicks,omitempty"`
+	Domain          string            `json:"domain,omitempty"`
+	LanguageTargets map[string]string `json:"language_targets,omitempty"`
+	Schedule        *Schedule         `json:"schedule,omitempty"`
+	Preview         *PreviewCard      `json:"preview,omitempty"`
+	Signed          bool              `json:"signed,omitempty"`
+	SingleUse       bool              `json:"single_use,omitempty"`
+	Analytics       *bool             `json:"analytics,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// FeatureFlagRequest is the body of PUT /api/v1/flags/{name}
+type FeatureFlagRequest struct {
+	Description string          `json:"description,omitempty" validate:"max=500"`
+	Enabled     bool            `json:"enabled"`              // For every workspace without an override
+	Workspaces  map[string]bool `json:"workspaces,omitempty"` // Per-workspace overrides
+}
+
+// FeatureFlagResponse is the state of one feature flag
+type FeatureFlagResponse struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Enabled     bool            `json:"enabled"`
+	Workspaces  map[string]bool `json:"workspaces,omitempty"`
+	UpdatedAt   *time.Time      `json:"updated_at,omitempty"` // Unset for flags still at their default
+}
+
+// BanResponse is one IP banned by the abuse guard
+type BanResponse struct {
+	IP       string    `json:"ip"`
+	Reason   string    `json:"reason"`
+	Strikes  int       `json:"strikes"` // Bans of this IP in the last week (each lasts longer)
+	BannedAt time.Time `json:"banned_at"`
+	Until    time.Time `json:"until"`
+}
+
+// DomainRuleRequest is the body of POST /api/v1/domain-rules
+// Pattern syntax: "example.com" (and subdomains), "*.example.com",
+// ".tk" (a whole TLD) or "regex:<Go regular expression>"
+type DomainRuleRequest struct {
+	Pattern string `json:"pattern" validate:"required,max=500"`
+	Action  string `json:"action" validate:"required,oneof=allow deny"`
+	Reason  string `json:"reason,omitempty" validate:"max=255"`
+	Global  bool   `json:"global,omitempty"` // For every workspace (admins only)
+}
+
+// DomainRuleResponse is one destination domain rule
+type DomainRuleResponse struct {
+	ID        string    `json:"id"`
+	Workspace string    `json:"workspace,omitempty"` // Empty for global rules
+	Global    bool      `json:"global"`
+	Pattern   string    `json:"pattern"`
+	Kind      string    `json:"kind"` // domain, wildcard, tld or regex
+	Action    string    `json:"action"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IngestClicksRequest is the body of POST /api/v1/clicks
+// Edge redirectors report the clicks they served, up to 500 per request
+type IngestClicksRequest struct {
+	Clicks []IngestClick `json:"clicks" validate:"required,max=500"`
+}
+
+// IngestClick is one click served at the edge
+type IngestClick struct {
+	ID        string    `json:"id" validate:"required,max=128" label:"ID"` // Idempotency key, unique per click (e.g. a UUID)
+	ShortCode string    `json:"short_code" validate:"required,max=64"`
+	ClickedAt time.Time `json:"clicked_at" validate:"required"`
+	IPAddress string    `json:"ip_address,omitempty" validate:"max=64,ip" label:"IP address"` // Stored as INET: no port
+	UserAgent string    `json:"user_agent,omitempty" validate:"max=1024"`
+	Referer   string    `json:"referer,omitempty" validate:"max=2048"`
+	Region    string    `json:"region,omitempty" validate:"max=64"`         // Edge location that served it, e.g. "fra"
+	Consent   string    `json:"consent,omitempty" validate:"oneof=dnt gpc"` // The visitor sent DNT: 1 or Sec-GPC: 1
+}
+
+// IngestClicksResponse has one result per reported click, in request order
+type IngestClicksResponse struct {
+	Accepted   int                 `json:"accepted"`
+	Duplicates int                 `json:"duplicates"`
+	Rejected   int                 `json:"rejected"`
+	Failed     int                 `json:"failed"` // Retry these with the same IDs
+	Results    []IngestClickResult `json:"results"`
+}
+
+// IngestClickResult is what happened to one reported click
+type IngestClickResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"` // accepted, duplicate, rejected or failed
+	Error  string `json:"error,omitempty"`
+}
+
+// CustomDomainRequest is the body of POST /api/v1/domains
+type CustomDomainRequest struct {
+	Host string `json:"host" validate:"required,host"`
+}
+
+// CustomDomainResponse is a custom domain and how to verify it
+type CustomDomainResponse struct {
+	ID         string          `json:"id"`
+	Host       string          `json:"host"`
+	Status     string          `json:"status"` // pending, verified or failed
+	Challenge  DomainChallenge `json:"challenge"`
+	CreatedAt  time.Time       `json:"created_at"`
+	VerifiedAt *time.Time      `json:"verified_at,omitempty"`
+	CheckedAt  *time.Time      `json:"checked_at,omitempty"`
+	LastError  string          `json:"last_error,omitempty"`
+	JobID      string          `json:"job_id,omitempty"` // Verification check to poll at /api/v1/jobs/{id}
+
+	Certificate *DomainCertificate `json:"certificate,omitempty"` // Only with automatic TLS
+}
+
+// DomainCertificate is the HTTPS certificate of a custom domain
+type DomainCertificate struct {
+	Status    string     `json:"status"` // none, issued, expired or failed
+	IssuedAt  *time.Time `json:"issued_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	FailedAt  *time.Time `json:"failed_at,omitempty"`
+}
+
+// DomainChallenge is the DNS record that proves control of a domain
+type DomainChallenge struct {
+	Type  string `json:"type"` // Always "TXT"
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// EdgeEntry is one line of the NDJSON edge snapshot (GET /api/v1/edge/snapshot)
+// A link with a custom alias has an entry for each code. Removed entries
+// (deltas only) carry just the code: the edge forgets it and passes
+// requests for it through to us.
+type EdgeEntry struct {
+	Code            string            `json:"code"`
+	Domain          string            `json:"domain,omitempty"` // Host the link is shared on ("" = default domain)
+	URL             string            `json:"url,omitempty"`
+	ExpiresAt       *time.Time        `json:"expires_at,omitempty"`       // Stop redirecting from then on
+	LanguageTargets map[string]string `json:"language_targets,omitempty"` // Same rules as a redirect here
+	Schedule        *Schedule         `json:"schedule,omitempty"`
+	Removed         bool              `json:"removed,omitempty"`
+}
+
+// CreateSecretRequest is the body of POST /api/v1/secrets
+// Exactly one of text and url: the secret shown once, then destroyed
+type CreateSecretRequest struct {
+	Text           string `json:"text,omitempty" validate:"max=10000"`
+	URL            string `json:"url,omitempty" validate:"httpurl" label:"URL"` // Shown as a link, never redirected to
+	ExpiresInHours int    `json:"expires_in_hours,omitempty" validate:"min=0"`
+}
+
+// CreateSecretResponse is a new burn-after-reading link
+// It never contains the secret: share short_url, and it reveals it once
+type CreateSecretResponse struct {
+	ID        string     `json:"id"`
+	ShortCode string     `json:"short_code"`
+	ShortURL  string     `json:"short_url"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// SecretResponse is what a burn-after-reading link answers API clients:
+// before the reveal (GET) only the short code, after it (POST) the secret
+type SecretResponse struct {
+	ShortCode string `json:"short_code"`
+	Revealed  bool   `json:"revealed"`
+	Text      string `json:"text,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// AuditEntryResponse is one entry of GET /api/v1/urls/{id}/audit
+type AuditEntryResponse struct {
+	ID         string    `json:"id"`
+	Action     string    `json:"action"` // e.g. link.revealed
+	Actor      string    `json:"actor"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// CreateFileResponse is a new file link
+// Opening short_url downloads the file; downloads count as clicks
+type CreateFileResponse struct {
+	ID          string     `json:"id"`
+	ShortCode   string     `json:"short_code"`
+	ShortURL    string     `json:"short_url"`
+	Name        string     `json:"name"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"` // Bytes
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreatePasteRequest is the body of POST /api/v1/pastes
+type CreatePasteRequest struct {
+	Content        string `json:"content" validate:"required"`
+	Language       string `json:"language,omitempty" validate:"max=32"` // Highlighting hint, e.g. "go"
+	CustomAlias    string `json:"custom_alias,omitempty" validate:"alias"`
+	ExpiresInHours int    `json:"expires_in_hours,omitempty" validate:"min=0"`
+	MaxClicks      int64  `json:"max_clicks,omitempty" validate:"min=1"` // Views before the paste is gone
+
+	// Only show the paste with a valid ?sig= (see SignedURL in the response)
+	Signed bool `json:"signed,omitempty"`
+}
+
+// CreatePasteResponse is a new paste link
+type CreatePasteResponse struct {
+	ID        string     `json:"id"`
+	ShortCode string     `json:"short_code"`
+	ShortURL  string     `json:"short_url"`
+	RawURL    string     `json:"raw_url"` // The snippet as text/plain
+	Language  string     `json:"language,omitempty"`
+	Size      int        `json:"size"` // Bytes
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxClicks *int64     `json:"max_clicks,omitempty"`
+
+	// Signed pastes only open with these (short_url and raw_url don't)
+	Signed        bool    `json:"signed,omitempty"`
+	SigningSecret *string `json:"signing_secret,omitempty"`
+	SignedURL     string  `json:"signed_url,omitempty"`
+}
+
+// PasteResponse is what a paste link answers API clients
+type PasteResponse struct {
+	ShortCode string `json:"short_code"`
+	Language  string `json:"language,omitempty"`
+	Content   string `json:"content"`
+}
+
+// JobResponse is the status of a background job (GET /api/v1/jobs/{id})
+// Poll it until status is "succeeded" or "failed"
+type JobResponse struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Status      string          `json:"status"` // "pending", "running", "succeeded" or "failed"
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	Result      json.RawMessage `json:"result,omitempty"` // Succeeded jobs only; shape depends on kind
+	Error       string          `json:"error,omitempty"`  // Last failure (also set while a retry is pending)
+	CreatedAt   time.Time       `json:"created_at"`
+	RetryAt     *time.Time      `json:"retry_at,omitempty"` // Pending retries: when the next attempt starts
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// SDKConfigResponse is the body of GET /api/v1/sdk/config
+// Everything a client library needs to talk to this server with the
+// caller's key, in one machine-readable document
+type SDKConfigResponse struct {
+	BaseURL    string         `json:"base_url"`     // Short links are served here
+	APIBaseURL string         `json:"api_base_url"` // base_url + "/api/v1"
+	OpenAPIURL string         `json:"openapi_url"`  // Feed this to openapi-generator
+	Auth       SDKAuth        `json:"auth"`
+	RateLimit  *SDKRateLimit  `json:"rate_limit"`      // null = not rate limited
+	Quota      *UsageResponse `json:"quota,omitempty"` // Absent when no plan applies
+	Retry      SDKRetryPolicy `json:"retry"`
+}
+
+// SDKAuth says how to authenticate, and as whom the key does
+type SDKAuth struct {
+	Scheme    string `json:"scheme"` // "bearer"
+	Header    string `json:"header"` // "Authorization"
+	Prefix    string `json:"prefix"` // Put before the key: "Bearer "
+	Principal string `json:"principal"`
+	Admin     bool   `json:"admin"`
+	Plan      string `json:"plan,omitempty"`
+}
+
+// SDKRateLimit describes the request rate limit and its response headers
+type SDKRateLimit struct {
+	RequestsPerMinute int    `json:"requests_per_minute"`
+	Burst             int    `json:"burst"`
+	Scope             string `json:"scope"` // "ip": counted per client IP address, not per key
+	LimitHeader       string `json:"limit_header"`
+	RemainingHeader   string `json:"remaining_header"`
+	ResetHeader       string `json:"reset_header"` // Unix time the bucket is full again
+}
+
+// SDKRetryPolicy tells clients which failures are worth retrying
+type SDKRetryPolicy struct {
+	Statuses         []int  `json:"statuses"`           // Retry these with backoff
+	RetryAfterHeader string `json:"retry_after_header"` // Seconds to wait, when present
+}
+
+// Envelope wraps every v1 JSON response body
+//
+// WHY ONE TYPE?
+// Health checks, creates and errors used to return different shapes, so
+// clients had to special-case each endpoint. Now every body has the same
+// top-level keys and always carries meta.request_id for support tickets.
+//
+// Error stays a plain string (not an object like v2) so existing v1 clients
+// that read body.error keep working
+type Envelope struct {
+	Data    interface{}       `json:"data,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Error   string            `json:"error,omitempty"`
+	Code    string            `json:"code,omitempty"`    // Machine-readable error code, e.g. "validation_failed"
+	Details map[string]string `json:"details,omitempty"` // Problem per field (validation_failed only)
+	Meta    *Meta             `json:"meta,omitempty"`
+}
+
+// Meta holds information about the response itself
+type Meta struct {
+	RequestID  string      `json:"request_id,omitempty"` // Same value as the X-Request-ID header
+	Pagination *Pagination `json:"pagination,omitempty"` // Only set by list endpoints
+}
+
+// Pagination describes which slice of a list was returned
+// Request the next page with ?offset=<offset+limit>&limit=<limit>
+type Pagination struct {
+	Limit   int  `json:"limit,omitempty"` // 0 means "no limit" (everything from offset)
+	Offset  int  `json:"offset"`
+	Total   int  `json:"total"`
+	HasMore bool `json:"has_more"`
+}
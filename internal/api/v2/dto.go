@@ -0,0 +1,160 @@
+package v2
+
+import "time"
+
+// API v2 DTOs
+//
+// WHAT CHANGED FROM v1?
+// 1. Every response uses the same Envelope: {data, error, meta}
+//    (v1 create returns {data, message}, stats returns {data}, errors return {error: "..."})
+// 2. Errors are objects with a stable machine-readable code, not bare strings
+// 3. A link has ONE shape (Link) whether it comes from create or stats
+
+// Envelope wraps every v2 response body
+// Exactly one of Data or Error is set
+type Envelope struct {
+	Data  interface{} `json:"data,omitempty"`
+	Error *Error      `json:"error,omitempty"`
+	Meta  *Meta       `json:"meta,omitempty"`
+}
+
+// Error describes why a request failed
+type Error struct {
+	Code    string            `json:"code"`              // Stable identifier, e.g. "not_found"
+	Message string            `json:"message"`           // Human-readable explanation
+	Details map[string]string `json:"details,omitempty"` // Problem per field (validation_failed only)
+}
+
+// Meta holds information about the response itself
+type Meta struct {
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Error codes returned by v2 endpoints
+const (
+	CodeInvalidRequest  = "invalid_request"
+	CodeValidation      = "validation_failed"
+	CodeForbidden       = "forbidden"
+	CodeNotFound        = "not_found"
+	CodeGone            = "gone"
+	CodeConflict        = "conflict"
+	CodeQuotaExceeded   = "quota_exceeded"
+	CodeCaptchaRequired = "captcha_required" // Missing or rejected CAPTCHA token (anonymous creates)
+	CodeUnavailable     = "unavailable"
+	CodeInternal        = "internal_error"
+)
+
+// CreateLinkRequest is the body of POST /api/v2/urls
+type CreateLinkRequest struct {
+	URL         string `json:"url" validate:"required,httpurl" label:"URL"`
+	CustomAlias string `json:"custom_alias,omitempty" validate:"alias"`
+	ExpiresIn   string `json:"expires_in,omitempty" validate:"duration"` // Go duration, e.g. "24h" or "90m"
+	MaxClicks   int64  `json:"max_clicks,omitempty" validate:"min=1"`
+	Domain      string `json:"domain,omitempty" validate:"host"` // Host to serve the link on (default: the API host)
+
+	// Destination per visitor language, e.g. {"fr": "https://example.com/fr"}
+	LanguageTargets map[string]string `json:"language_targets,omitempty" validate:"max=20,dive,httpurl"`
+	Schedule        *Schedule         `json:"schedule,omitempty"`
+
+	// Only redirect with a valid ?sig= made with the returned signing_secret
+	Signed bool `json:"signed,omitempty"`
+
+	// Stop redirecting after the first visit (e.g. password reset links)
+	SingleUse bool `json:"single_use,omitempty"`
+
+	// false = only count clicks, don't record click events (default: true)
+	Analytics *bool `json:"analytics,omitempty"`
+
+	// Notes for the owner and integrations; they never change the redirect
+	Description    string            `json:"description,omitempty"`
+	CustomMetadata map[string]string `json:"custom_metadata,omitempty"` // e.g. {"ticket": "MKT-142"}
+
+	// "sensitive" = workspace viewers see the stats but not the destinations
+	Visibility string `json:"visibility,omitempty" validate:"oneof=standard sensitive"`
+
+	// Solved hCaptcha/Turnstile token; required without an API key when
+	// the server has CAPTCHAs enabled
+	CaptchaToken string `json:"captcha_token,omitempty"`
+}
+
+// Link is the single representation of a short link in v2
+type Link struct {
+	ID          string        `json:"id"`
+	ShortCode   string        `json:"short_code"`
+	ShortURL    string        `json:"short_url"`
+	OriginalURL string        `json:"original_url"`
+	ResolvedURL *string       `json:"resolved_url,omitempty"`
+	Clicks      int64         `json:"clicks"`
+	MaxClicks   *int64        `json:"max_clicks,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	ExpiresAt   *time.Time    `json:"expires_at,omitempty"`
+	Metadata    *LinkMetadata `json:"metadata,omitempty"` // Absent until fetched
+
+	LanguageTargets map[string]string `json:"language_targets,omitempty"`
+	Schedule        *Schedule         `json:"schedule,omitempty"`
+
+	Signed        bool    `json:"signed,omitempty"`
+	SigningSecret *string `json:"signing_secret,omitempty"` // Only in the response that creates the link
+
+	SingleUse bool       `json:"single_use,omitempty"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+
+	Analytics *bool `json:"analytics,omitempty"` // false when the link opted out of analytics
+
+	Description    string            `json:"description,omitempty"`
+	CustomMetadata map[string]string `json:"custom_metadata,omitempty"`
+
+	Visibility           string `json:"visibility,omitempty"`            // "sensitive"; viewers then get no destinations
+	DestinationEncrypted bool   `json:"destination_encrypted,omitempty"` // original_url is stored encrypted (and empty here)
+}
+
+// LinkMetadata describes the destination page, so UIs can show links by name
+type LinkMetadata struct {
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	FaviconURL  string    `json:"favicon_url,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// Click is a single analytics event
+type Click struct {
+	ClickedAt   time.Time `json:"clicked_at"`
+	CountryCode string    `json:"country_code,omitempty"`
+	City        string    `json:"city,omitempty"`
+	Channel     string    `json:"channel,omitempty"`
+	Browser     string    `json:"browser,omitempty"`
+	OS          string    `json:"os,omitempty"`
+	DeviceType  string    `json:"device_type,omitempty"`
+	Region      string    `json:"region,omitempty"`
+}
+
+// LinkStats is the body of GET /api/v2/urls/{code}/stats
+// The link is nested instead of flattened, so it's identical to the create response
+type LinkStats struct {
+	Link         Link            `json:"link"`
+	Analytics    AnalyticsStatus `json:"analytics"`
+	RecentClicks []Click         `json:"recent_clicks"` // Always empty while analytics is off
+}
+
+// AnalyticsStatus tells whether click events are recorded for a link
+type AnalyticsStatus struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`  // "disabled_globally" or "disabled_for_link"
+	Message string `json:"message,omitempty"` // Human-readable explanation (localized)
+}
+
+// Schedule rotates the destination by time of day / day of week
+// The first rule covering the current time wins; no match = the usual URL
+type Schedule struct {
+	Timezone string         `json:"timezone,omitempty"` // IANA name, e.g. "Europe/Berlin" (default: the server's)
+	Rules    []ScheduleRule `json:"rules" validate:"required,max=20"`
+}
+
+// ScheduleRule sends visitors to URL on Days between Start and End ("HH:MM")
+// End before Start runs past midnight; no times = the whole day
+type ScheduleRule struct {
+	Days  []string `json:"days,omitempty"` // "mon".."sun" (default: every day)
+	Start string   `json:"start,omitempty"`
+	End   string   `json:"end,omitempty"`
+	URL   string   `json:"url" validate:"required,httpurl" label:"URL"`
+}
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+)
+
+// Principal is the authenticated caller of a request
+// Handlers and services use it to decide what the caller is allowed to do
+type Principal struct {
+	ID    string // Stable identifier of the caller (stored as created_by)
+	Admin bool   // Admins can manage every URL and use operational endpoints
+	Plan  string // Quota plan, e.g. "free" or "pro" (empty = the default plan)
+
+	// Set when a person acts for a shared workspace: ID is then the
+	// workspace (it still owns the links), Member the person and Role what
+	// they may do in it. Empty for the workspace's own credentials.
+	Member string
+	Role   Role
+
+	// What an API key may do (see Scope); nil = everything the principal
+	// may do anyway (the admin key, SSO sessions)
+	Scopes []Scope
+}
+
+// Role is what a member may do in a workspace
+type Role string
+
+const (
+	RoleOwner       Role = "owner"       // Everything, including workspace settings
+	RoleEditor      Role = "editor"      // Manage every link, approve contributors' links
+	RoleContributor Role = "contributor" // Create links; they may need approval (see WorkspaceSettings)
+	RoleViewer      Role = "viewer"      // Read-only: stats, but not the destinations of sensitive links
+)
+
+// ErrInvalidRole is returned for an unknown role
+var ErrInvalidRole = errors.New("role must be owner, editor, contributor or viewer")
+
+// ParseRole reads a role name ("" = owner)
+func ParseRole(s string) (Role, error) {
+	switch r := Role(s); r {
+	case "":
+		return RoleOwner, nil
+	case RoleOwner, RoleEditor, RoleContributor, RoleViewer:
+		return r, nil
+	default:
+		return "", ErrInvalidRole
+	}
+}
+
+// roleRank orders the roles from the least to the most privileged
+var roleRank = map[Role]int{RoleViewer: 1, RoleContributor: 2, RoleEditor: 3, RoleOwner: 4}
+
+// HighestRole returns the most privileged of roles, "" if there is none
+// Members in several groups get the best role any of them grants
+func HighestRole(roles ...Role) Role {
+	var highest Role
+	for _, role := range roles {
+		if roleRank[role] > roleRank[highest] {
+			highest = role
+		}
+	}
+	return highest
+}
+
+// Scope limits what an API key can be used for
+// Scopes narrow what the key's creator may do; they never add to it
+type Scope string
+
+const (
+	ScopeURLsRead  Scope = "urls:read"  // List, search and export links
+	ScopeURLsWrite Scope = "urls:write" // Create, change and delete links
+	ScopeStatsRead Scope = "stats:read" // Click statistics and usage
+	ScopeAdmin     Scope = "admin"      // Workspace administration (keys, settings, SSO) and operator endpoints; implies the others
+)
+
+// ErrInvalidScope is returned for an unknown scope
+var ErrInvalidScope = errors.New("scope must be urls:read, urls:write, stats:read or admin")
+
+// ParseScope reads a scope name
+func ParseScope(s string) (Scope, error) {
+	switch scope := Scope(s); scope {
+	case ScopeURLsRead, ScopeURLsWrite, ScopeStatsRead, ScopeAdmin:
+		return scope, nil
+	default:
+		return "", ErrInvalidScope
+	}
+}
+
+// HasScope reports whether the principal may be used for scope
+// Principals without scopes are not limited, and admin covers every scope
+func (p *Principal) HasScope(scope Scope) bool {
+	if p.Scopes == nil {
+		return true
+	}
+	for _, granted := range p.Scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectiveRole is the principal's role in its workspace
+// The workspace's own credentials (no Role) act as its owner
+func (p *Principal) EffectiveRole() Role {
+	if p.Role == "" {
+		return RoleOwner
+	}
+	return p.Role
+}
+
+// Actor identifies who acted, for audit entries and events: the member if
+// there is one, otherwise the principal itself
+func (p *Principal) Actor() string {
+	if p.Member != "" {
+		return p.Member
+	}
+	return p.ID
+}
+
+// Anonymous is the principal used when no credentials are presented
+var Anonymous = &Principal{ID: "anonymous"}
+
+var (
+	// ErrInvalidCredentials is returned when a presented token is not valid
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator turns a bearer token into a Principal
+// Implementations return ErrInvalidCredentials for unknown tokens
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+// StaticKeyAuthenticator authenticates a single pre-shared admin key from config
+// Useful for operators before API keys exist, and as a break-glass credential
+type StaticKeyAuthenticator struct {
+	key string
+}
+
+// NewStaticKeyAuthenticator creates an authenticator for the admin key
+// An empty key disables it (every token is rejected)
+func NewStaticKeyAuthenticator(key string) *StaticKeyAuthenticator {
+	return &StaticKeyAuthenticator{key: key}
+}
+
+// Authenticate checks the token against the admin key
+// subtle.ConstantTimeCompare prevents TIMING ATTACKS: a normal == returns early
+// on the first different byte, which leaks how much of the key was guessed right
+func (a *StaticKeyAuthenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	if a.key == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.key)) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	return &Principal{ID: "admin", Admin: true}, nil
+}
+
+// ChainAuthenticator tries several authenticators in order
+// Each kind of token has its own prefix, so at most one of them knows it
+type ChainAuthenticator struct {
+	authenticators []Authenticator
+}
+
+// NewChainAuthenticator combines authenticators; the first to accept wins
+func NewChainAuthenticator(authenticators ...Authenticator) *ChainAuthenticator {
+	return &ChainAuthenticator{authenticators: authenticators}
+}
+
+// Authenticate returns the principal of the first authenticator that knows
+// the token. Errors other than ErrInvalidCredentials (a database down)
+// stop the chain: the token might be valid, so it must not look unknown.
+func (c *ChainAuthenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	for _, authenticator := range c.authenticators {
+		principal, err := authenticator.Authenticate(ctx, token)
+		if err == nil {
+			return principal, nil
+		}
+		if !errors.Is(err, ErrInvalidCredentials) {
+			return nil, err
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// contextKey is a private type so no other package can collide with our keys
+type contextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal of the request, or Anonymous if there is none
+func FromContext(ctx context.Context) *Principal {
+	if p, ok := ctx.Value(contextKey{}).(*Principal); ok && p != nil {
+		return p
+	}
+	return Anonymous
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// authenticatorFunc adapts a function to Authenticator
+type authenticatorFunc func(ctx context.Context, token string) (*Principal, error)
+
+func (f authenticatorFunc) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	return f(ctx, token)
+}
+
+func TestChainAuthenticator(t *testing.T) {
+	errDown := errors.New("database down")
+	sessions := authenticatorFunc(func(ctx context.Context, token string) (*Principal, error) {
+		switch token {
+		case "sso_valid":
+			return &Principal{ID: "team1", Member: "ada@example.com", Role: RoleEditor}, nil
+		case "sso_down":
+			return nil, errDown
+		}
+		return nil, ErrInvalidCredentials
+	})
+	chain := NewChainAuthenticator(NewStaticKeyAuthenticator("secret"), sessions)
+
+	tests := []struct {
+		name      string
+		token     string
+		expected  string
+		expectErr error
+	}{
+		{name: "first authenticator", token: "secret", expected: "admin"},
+		{name: "second authenticator", token: "sso_valid", expected: "team1"},
+		{name: "nobody knows it", token: "nope", expectErr: ErrInvalidCredentials},
+		{name: "failure is not an unknown token", token: "sso_down", expectErr: errDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			principal, err := chain.Authenticate(context.Background(), tt.token)
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				assert.Nil(t, principal)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, principal.ID)
+		})
+	}
+}
+
+func TestHighestRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		roles    []Role
+		expected Role
+	}{
+		{name: "none", expected: ""},
+		{name: "one", roles: []Role{RoleViewer}, expected: RoleViewer},
+		{name: "best wins", roles: []Role{RoleViewer, RoleEditor, RoleContributor}, expected: RoleEditor},
+		{name: "unknown roles rank below everything", roles: []Role{"admin", RoleViewer}, expected: RoleViewer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, HighestRole(tt.roles...))
+		})
+	}
+}
+
+func TestPrincipal_HasScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []Scope
+		scope    Scope
+		expected bool
+	}{
+		{name: "unscoped", scopes: nil, scope: ScopeAdmin, expected: true},
+		{name: "granted", scopes: []Scope{ScopeURLsRead, ScopeStatsRead}, scope: ScopeStatsRead, expected: true},
+		{name: "not granted", scopes: []Scope{ScopeURLsRead}, scope: ScopeURLsWrite, expected: false},
+		{name: "read does not imply write", scopes: []Scope{ScopeURLsRead}, scope: ScopeStatsRead, expected: false},
+		{name: "admin implies everything", scopes: []Scope{ScopeAdmin}, scope: ScopeURLsWrite, expected: true},
+		{name: "empty scope list allows nothing", scopes: []Scope{}, scope: ScopeURLsRead, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal := &Principal{ID: "team1", Scopes: tt.scopes}
+			assert.Equal(t, tt.expected, principal.HasScope(tt.scope))
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is Stripe's REST API
+const DefaultAPIURL = "https://api.stripe.com"
+
+// Client calls the Stripe REST API
+type Client struct {
+	secretKey string
+	baseURL   string
+	client    *http.Client
+}
+
+// NewClient creates a Stripe client authenticating with secretKey (sk_...)
+func NewClient(secretKey string, timeout time.Duration) *Client {
+	return &Client{
+		secretKey: secretKey,
+		baseURL:   DefaultAPIURL,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+// WithBaseURL points the client at another API host (stripe-mock, tests)
+func (c *Client) WithBaseURL(baseURL string) *Client {
+	c.baseURL = strings.TrimRight(baseURL, "/")
+	return c
+}
+
+// CreatePortalSession opens a customer portal session and returns its URL
+// The portal is hosted by Stripe: customers update cards, switch plans and
+// cancel there, and we learn about the result through the webhook.
+func (c *Client) CreatePortalSession(ctx context.Context, customerID, returnURL string) (string, error) {
+	form := url.Values{}
+	form.Set("customer", customerID)
+	if returnURL != "" {
+		form.Set("return_url", returnURL)
+	}
+
+	var session struct {
+		URL string `json:"url"`
+	}
+	if err := c.post(ctx, "/v1/billing_portal/sessions", form, &session); err != nil {
+		return "", fmt.Errorf("failed to create portal session: %w", err)
+	}
+	return session.URL, nil
+}
+
+// post sends a form-encoded request and decodes the JSON response into out
+func (c *Client) post(ctx context.Context, path string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read stripe response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		return fmt.Errorf("stripe returned status %d: %s", resp.StatusCode, apiErr.Error.Message)
+	}
+
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,186 @@
+// Package billing talks to Stripe: it verifies webhook signatures, decodes
+// the few event payloads the shortener cares about, and opens customer
+// portal sessions.
+//
+// WHY NO STRIPE SDK?
+// We use two API features (webhooks and the portal). Both are plain HTTP
+// with form-encoded requests and JSON responses, so a small client keeps the
+// dependency tree - and the binary - much smaller.
+package billing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event types handled by the shortener
+const (
+	EventCheckoutCompleted   = "checkout.session.completed"
+	EventSubscriptionCreated = "customer.subscription.created"
+	EventSubscriptionUpdated = "customer.subscription.updated"
+	EventSubscriptionDeleted = "customer.subscription.deleted"
+)
+
+// DefaultTolerance is how old a signed webhook may be
+// Older deliveries are rejected so a captured request can't be replayed later
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	ErrInvalidSignature = errors.New("invalid stripe signature")
+	ErrSignatureExpired = errors.New("stripe signature timestamp outside tolerance")
+)
+
+// Event is the envelope of every Stripe webhook
+// Data.Object holds the changed object; decode it with the helpers below
+type Event struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"` // Unix seconds
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// CreatedAt returns when Stripe created the event
+func (e *Event) CreatedAt() time.Time {
+	return time.Unix(e.Created, 0).UTC()
+}
+
+// CheckoutSession is the part of a Checkout Session we use
+// ClientReferenceID is set by our pricing page to the owner ID
+type CheckoutSession struct {
+	ID                string `json:"id"`
+	Customer          string `json:"customer"`
+	ClientReferenceID string `json:"client_reference_id"`
+	Subscription      string `json:"subscription"`
+}
+
+// Subscription is the part of a Stripe subscription we use
+type Subscription struct {
+	ID               string            `json:"id"`
+	Customer         string            `json:"customer"`
+	Status           string            `json:"status"`
+	CurrentPeriodEnd int64             `json:"current_period_end"`
+	Metadata         map[string]string `json:"metadata"`
+	Items            struct {
+		Data []struct {
+			CurrentPeriodEnd int64 `json:"current_period_end"` // Newer API versions moved it here
+			Price            struct {
+				Product string `json:"product"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+// Products returns the product IDs of all subscription items
+func (s *Subscription) Products() []string {
+	products := make([]string, 0, len(s.Items.Data))
+	for _, item := range s.Items.Data {
+		products = append(products, item.Price.Product)
+	}
+	return products
+}
+
+// PeriodEnd returns the end of the current billing period (zero if unknown)
+func (s *Subscription) PeriodEnd() time.Time {
+	end := s.CurrentPeriodEnd
+	for _, item := range s.Items.Data {
+		if end == 0 || (item.CurrentPeriodEnd > 0 && item.CurrentPeriodEnd < end) {
+			end = item.CurrentPeriodEnd
+		}
+	}
+	if end == 0 {
+		return time.Time{}
+	}
+	return time.Unix(end, 0).UTC()
+}
+
+// ParseEvent verifies the Stripe-Signature header and decodes the event
+func ParseEvent(payload []byte, header, secret string, tolerance time.Duration, now time.Time) (*Event, error) {
+	if err := VerifySignature(payload, header, secret, tolerance, now); err != nil {
+		return nil, err
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("invalid stripe event: %w", err)
+	}
+	return &event, nil
+}
+
+// DecodeObject unmarshals the event's data.object into v
+func (e *Event) DecodeObject(v any) error {
+	if err := json.Unmarshal(e.Data.Object, v); err != nil {
+		return fmt.Errorf("invalid %s payload: %w", e.Type, err)
+	}
+	return nil
+}
+
+// VerifySignature checks a Stripe-Signature header
+//
+// HOW STRIPE SIGNS WEBHOOKS:
+// The header looks like "t=1700000000,v1=<hex>,v1=<hex>". Each v1 value is
+// HMAC-SHA256(secret, "<t>.<raw body>"). There can be several v1 values
+// while a secret is being rolled, so ANY match is accepted. The timestamp
+// is part of the signed data, which makes the tolerance check trustworthy.
+func VerifySignature(payload []byte, header, secret string, tolerance time.Duration, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	expected := Sign(payload, secret, seconds)
+	valid := false
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		age := now.Sub(time.Unix(seconds, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrSignatureExpired
+		}
+	}
+	return nil
+}
+
+// Sign computes the v1 signature for payload sent at timestamp
+// Exported for tests and for replaying events locally
+func Sign(payload []byte, secret string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,72 @@
+package billing
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySignature(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"evt_1","type":"customer.subscription.updated"}`)
+	now := time.Unix(1700000000, 0)
+	valid := Sign(payload, secret, now.Unix())
+
+	tests := []struct {
+		name    string
+		header  string
+		wantErr error
+	}{
+		{name: "valid", header: fmt.Sprintf("t=%d,v1=%s", now.Unix(), valid)},
+		{name: "any of several v1 signatures (secret rotation)", header: fmt.Sprintf("t=%d,v1=deadbeef,v1=%s,v0=old", now.Unix(), valid)},
+		{name: "wrong signature", header: fmt.Sprintf("t=%d,v1=deadbeef", now.Unix()), wantErr: ErrInvalidSignature},
+		{name: "signed with another secret", header: fmt.Sprintf("t=%d,v1=%s", now.Unix(), Sign(payload, "whsec_other", now.Unix())), wantErr: ErrInvalidSignature},
+		{name: "tampered timestamp", header: fmt.Sprintf("t=%d,v1=%s", now.Unix()+1, valid), wantErr: ErrInvalidSignature},
+		{name: "missing header", header: "", wantErr: ErrInvalidSignature},
+		{
+			name:    "too old",
+			header:  fmt.Sprintf("t=%d,v1=%s", now.Add(-10*time.Minute).Unix(), Sign(payload, secret, now.Add(-10*time.Minute).Unix())),
+			wantErr: ErrSignatureExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(payload, tt.header, secret, DefaultTolerance, now)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseEvent_Subscription(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{
+		"id": "evt_1",
+		"type": "customer.subscription.updated",
+		"created": 1700000000,
+		"data": {"object": {
+			"id": "sub_1",
+			"customer": "cus_1",
+			"status": "active",
+			"metadata": {"owner": "alice"},
+			"items": {"data": [{"current_period_end": 1702592000, "price": {"product": "prod_pro"}}]}
+		}}
+	}`)
+	now := time.Unix(1700000010, 0)
+	header := fmt.Sprintf("t=%d,v1=%s", now.Unix(), Sign(payload, secret, now.Unix()))
+
+	event, err := ParseEvent(payload, header, secret, DefaultTolerance, now)
+	require.NoError(t, err)
+	assert.Equal(t, EventSubscriptionUpdated, event.Type)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), event.CreatedAt())
+
+	var sub Subscription
+	require.NoError(t, event.DecodeObject(&sub))
+	assert.Equal(t, "cus_1", sub.Customer)
+	assert.Equal(t, "alice", sub.Metadata["owner"])
+	assert.Equal(t, []string{"prod_pro"}, sub.Products())
+	assert.Equal(t, time.Unix(1702592000, 0).UTC(), sub.PeriodEnd())
+}
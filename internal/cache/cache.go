@@ -0,0 +1,46 @@
+// Package cache defines the URL cache contract shared by every backend
+//
+// WHY A SEPARATE PACKAGE?
+// The Redis and Memcached backends, the decorators around them (metrics,
+// fault injection, retries) and the service layer all talk about the same
+// thing. Defining the contract once here means a new backend only has to
+// satisfy these interfaces, and swapping backends can't break a caller.
+package cache
+
+import (
+	"context"
+
+	"url-shortener/internal/domain"
+)
+
+// ContractVersion is the version of the Cache interface below
+// Bump it when a method is added or changes meaning. Backends report it in
+// GetStats, so /health-style diagnostics show which contract they implement.
+//
+// Every backend also asserts conformance at compile time:
+//
+//	var _ cache.Cache = (*Cache)(nil)
+const ContractVersion = 1
+
+// URLCache is the part of the contract the redirect path needs
+// Decorators only have to wrap these three methods
+type URLCache interface {
+	// GetURL returns the cached URL, or nil (and no error) on a miss
+	GetURL(ctx context.Context, shortCode string) (*domain.URL, error)
+	// SetURL stores url for the configured TTL
+	SetURL(ctx context.Context, shortCode string, url *domain.URL) error
+	// DeleteURL removes an entry; deleting a missing entry is not an error
+	DeleteURL(ctx context.Context, shortCode string) error
+}
+
+// Cache is the full contract every backend implements
+type Cache interface {
+	URLCache
+
+	// Exists reports whether shortCode is cached (fresh or stale)
+	Exists(ctx context.Context, shortCode string) (bool, error)
+	// Clear removes every cached URL
+	Clear(ctx context.Context) error
+	// GetStats returns backend-specific statistics for diagnostics
+	GetStats(ctx context.Context) (map[string]interface{}, error)
+}
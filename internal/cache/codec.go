@@ -0,0 +1,543 @@
+package cache
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"url-shortener/internal/domain"
+)
+
+// Entry is what a cache backend stores: the URL plus when it stops being fresh
+type Entry struct {
+	URL        *domain.URL `json:"url"`
+	FreshUntil time.Time   `json:"fresh_until"`
+}
+
+// Codec selects how cache entries are written (CACHE_CODEC)
+//
+// WHY NOT JUST JSON?
+// Every redirect served from the cache decodes one entry. JSON has to scan
+// field names and quotes and parse timestamps from text; the binary format
+// stores values in a fixed order with varints, so it is smaller and several
+// times faster to decode (see BenchmarkDecode in codec_test.go).
+//
+// Reading always accepts BOTH formats - the first byte tells them apart - so
+// switching codecs (or a rolling deploy with mixed settings) never breaks
+// existing entries.
+type Codec string
+
+const (
+	CodecJSON   Codec = "json"
+	CodecBinary Codec = "binary"
+)
+
+// Valid reports whether c is a known codec
+func (c Codec) Valid() bool {
+	return c == CodecJSON || c == CodecBinary
+}
+
+// ErrUnsupportedFormat means the entry was written in a format or version
+// this build can't read (e.g. by a newer deploy). Backends treat it as a
+// miss, so the request falls back to the database and rewrites the entry.
+var ErrUnsupportedFormat = errors.New("unsupported cache entry format")
+
+// Binary format
+//
+//	byte     magic (0xCB)
+//	byte     version
+//	time     fresh until
+//	uvarint  flags (which optional fields are present, IsActive)
+//	string   ID, ShortCode, OriginalURL, CreatedBy, Domain
+//	string   CustomAlias, ResolvedURL     (if present)
+//	time     CreatedAt, ExpiresAt         (ExpiresAt if present)
+//	varint   Clicks, MaxClicks            (MaxClicks if present)
+//	varint   Version
+//	string   Title, Description, FaviconURL (if Metadata present)
+//	time     FetchedAt                    (if Metadata present)
+//	string   Title, Description, ImageURL (if Preview present)
+//	uvarint  count, then string tag + string URL pairs sorted by tag
+//	         (if LanguageTargets present)
+//	string   Timezone                     (if Schedule present)
+//	uvarint  rule count, then per rule: uvarint day count + day strings,
+//	         string Start, End, URL       (if Schedule present)
+//	string   SigningSecret                (if present)
+//	time     UsedAt                       (if present)
+//	string   Payload                      (if present; sealed)
+//	time     BurnedAt                     (if present)
+//	string   Key, Name, ContentType       (if File present)
+//	varint   Size                         (if File present)
+//	string   Content, Language            (if Paste present)
+//	string   Description                  (if present)
+//	uvarint  count, then string key + string value pairs sorted by key
+//	         (if CustomMetadata present)
+//	string   Approval, SubmittedBy        (if Approval present)
+//	string   Visibility                   (if not standard)
+//	string   SealedDestination            (if present; encrypted)
+//
+// string = uvarint length + bytes, time = varint seconds + uvarint nanoseconds
+//
+// Adding a field to domain.URL? Encode it here AND bump binaryVersion, so
+// entries written without it are re-read from the database.
+const (
+	binaryMagic   = 0xCB
+	binaryVersion = 16
+)
+
+const (
+	flagCustomAlias = 1 << iota
+	flagExpiresAt
+	flagIsActive
+	flagResolvedURL
+	flagMaxClicks
+	flagMetadata
+	flagPreview
+	flagLanguageTargets
+	flagSchedule
+	flagSigningSecret
+	flagSingleUse
+	flagUsedAt
+	flagBurnAfterReading
+	flagPayload
+	flagBurnedAt
+	flagFile
+	flagPaste
+	flagAnalyticsDisabled
+	flagDescription
+	flagCustomMetadata
+	flagApproval
+	flagVisibility
+	flagSealedDestination
+)
+
+// Encode serializes a URL and its soft expiry with the given codec
+func Encode(codec Codec, url *domain.URL, freshUntil time.Time) ([]byte, error) {
+	if codec == CodecBinary {
+		return encodeBinary(url, freshUntil), nil
+	}
+	return json.Marshal(Entry{URL: url, FreshUntil: freshUntil})
+}
+
+// Decode reads an entry written by either codec
+func Decode(data []byte) (Entry, error) {
+	if len(data) == 0 {
+		return Entry{}, ErrUnsupportedFormat
+	}
+
+	switch data[0] {
+	case '{':
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return Entry{}, err
+		}
+		if entry.URL == nil {
+			// Plain URL JSON from before entries had a soft TTL
+			return Entry{}, ErrUnsupportedFormat
+		}
+		return entry, nil
+	case binaryMagic:
+		return decodeBinary(data)
+	default:
+		return Entry{}, ErrUnsupportedFormat
+	}
+}
+
+func encodeBinary(url *domain.URL, freshUntil time.Time) []byte {
+	var flags uint64
+	if url.CustomAlias != nil {
+		flags |= flagCustomAlias
+	}
+	if url.ExpiresAt != nil {
+		flags |= flagExpiresAt
+	}
+	if url.IsActive {
+		flags |= flagIsActive
+	}
+	if url.ResolvedURL != nil {
+		flags |= flagResolvedURL
+	}
+	if url.MaxClicks != nil {
+		flags |= flagMaxClicks
+	}
+	if url.Metadata != nil {
+		flags |= flagMetadata
+	}
+	if url.Preview != nil {
+		flags |= flagPreview
+	}
+	if len(url.LanguageTargets) > 0 {
+		flags |= flagLanguageTargets
+	}
+	if url.Schedule != nil {
+		flags |= flagSchedule
+	}
+	if url.SigningSecret != nil {
+		flags |= flagSigningSecret
+	}
+	if url.SingleUse {
+		flags |= flagSingleUse
+	}
+	if url.UsedAt != nil {
+		flags |= flagUsedAt
+	}
+	if url.BurnAfterReading {
+		flags |= flagBurnAfterReading
+	}
+	if url.Payload != nil {
+		flags |= flagPayload
+	}
+	if url.BurnedAt != nil {
+		flags |= flagBurnedAt
+	}
+	if url.File != nil {
+		flags |= flagFile
+	}
+	if url.Paste != nil {
+		flags |= flagPaste
+	}
+	if url.AnalyticsDisabled {
+		flags |= flagAnalyticsDisabled
+	}
+	if url.Description != "" {
+		flags |= flagDescription
+	}
+	if len(url.CustomMetadata) > 0 {
+		flags |= flagCustomMetadata
+	}
+	if url.Approval != domain.ApprovalNone {
+		flags |= flagApproval
+	}
+	if url.Visibility != domain.VisibilityStandard {
+		flags |= flagVisibility
+	}
+	if url.SealedDestination != "" {
+		flags |= flagSealedDestination
+	}
+
+	// Pre-size the buffer so appends don't reallocate
+	size := 64 + len(url.ID) + len(url.ShortCode) + len(url.OriginalURL) + len(url.CreatedBy) + len(url.Domain)
+	if url.Paste != nil {
+		size += len(url.Paste.Content)
+	}
+	buf := make([]byte, 0, size)
+
+	buf = append(buf, binaryMagic, binaryVersion)
+	buf = appendTime(buf, freshUntil)
+	buf = binary.AppendUvarint(buf, flags)
+	buf = appendString(buf, url.ID)
+	buf = appendString(buf, url.ShortCode)
+	buf = appendString(buf, url.OriginalURL)
+	buf = appendString(buf, url.CreatedBy)
+	buf = appendString(buf, url.Domain)
+	if url.CustomAlias != nil {
+		buf = appendString(buf, *url.CustomAlias)
+	}
+	if url.ResolvedURL != nil {
+		buf = appendString(buf, *url.ResolvedURL)
+	}
+	buf = appendTime(buf, url.CreatedAt)
+	if url.ExpiresAt != nil {
+		buf = appendTime(buf, *url.ExpiresAt)
+	}
+	buf = binary.AppendVarint(buf, url.Clicks)
+	if url.MaxClicks != nil {
+		buf = binary.AppendVarint(buf, *url.MaxClicks)
+	}
+	buf = binary.AppendVarint(buf, url.Version)
+	if url.Metadata != nil {
+		buf = appendString(buf, url.Metadata.Title)
+		buf = appendString(buf, url.Metadata.Description)
+		buf = appendString(buf, url.Metadata.FaviconURL)
+		buf = appendTime(buf, url.Metadata.FetchedAt)
+	}
+	if url.Preview != nil {
+		buf = appendString(buf, url.Preview.Title)
+		buf = appendString(buf, url.Preview.Description)
+		buf = appendString(buf, url.Preview.ImageURL)
+	}
+	if len(url.LanguageTargets) > 0 {
+		// Sorted so the same URL always encodes to the same bytes
+		buf = binary.AppendUvarint(buf, uint64(len(url.LanguageTargets)))
+		for _, tag := range slices.Sorted(maps.Keys(url.LanguageTargets)) {
+			buf = appendString(buf, tag)
+			buf = appendString(buf, url.LanguageTargets[tag])
+		}
+	}
+	if url.Schedule != nil {
+		buf = appendString(buf, url.Schedule.Timezone)
+		buf = binary.AppendUvarint(buf, uint64(len(url.Schedule.Rules)))
+		for _, rule := range url.Schedule.Rules {
+			buf = binary.AppendUvarint(buf, uint64(len(rule.Days)))
+			for _, day := range rule.Days {
+				buf = appendString(buf, day)
+			}
+			buf = appendString(buf, rule.Start)
+			buf = appendString(buf, rule.End)
+			buf = appendString(buf, rule.URL)
+		}
+	}
+	if url.SigningSecret != nil {
+		buf = appendString(buf, *url.SigningSecret)
+	}
+	if url.UsedAt != nil {
+		buf = appendTime(buf, *url.UsedAt)
+	}
+	if url.Payload != nil {
+		buf = appendString(buf, *url.Payload)
+	}
+	if url.BurnedAt != nil {
+		buf = appendTime(buf, *url.BurnedAt)
+	}
+	if url.File != nil {
+		buf = appendString(buf, url.File.Key)
+		buf = appendString(buf, url.File.Name)
+		buf = appendString(buf, url.File.ContentType)
+		buf = binary.AppendVarint(buf, url.File.Size)
+	}
+	if url.Paste != nil {
+		buf = appendString(buf, url.Paste.Content)
+		buf = appendString(buf, url.Paste.Language)
+	}
+	if url.Description != "" {
+		buf = appendString(buf, url.Description)
+	}
+	if len(url.CustomMetadata) > 0 {
+		buf = binary.AppendUvarint(buf, uint64(len(url.CustomMetadata)))
+		for _, key := range slices.Sorted(maps.Keys(url.CustomMetadata)) {
+			buf = appendString(buf, key)
+			buf = appendString(buf, url.CustomMetadata[key])
+		}
+	}
+	if url.Approval != domain.ApprovalNone {
+		buf = appendString(buf, string(url.Approval))
+		buf = appendString(buf, url.SubmittedBy)
+	}
+	if url.Visibility != domain.VisibilityStandard {
+		buf = appendString(buf, string(url.Visibility))
+	}
+	if url.SealedDestination != "" {
+		buf = appendString(buf, url.SealedDestination)
+	}
+	return buf
+}
+
+func decodeBinary(data []byte) (Entry, error) {
+	if len(data) < 2 || data[1] != binaryVersion {
+		return Entry{}, ErrUnsupportedFormat
+	}
+
+	r := &byteReader{data: data[2:]}
+	freshUntil := r.time()
+	flags := r.uvarint()
+
+	url := &domain.URL{
+		ID:          r.string(),
+		ShortCode:   r.string(),
+		OriginalURL: r.string(),
+		CreatedBy:   r.string(),
+		Domain:      r.string(),
+		IsActive:    flags&flagIsActive != 0,
+		SingleUse:   flags&flagSingleUse != 0,
+
+		BurnAfterReading:  flags&flagBurnAfterReading != 0,
+		AnalyticsDisabled: flags&flagAnalyticsDisabled != 0,
+	}
+	if flags&flagCustomAlias != 0 {
+		alias := r.string()
+		url.CustomAlias = &alias
+	}
+	if flags&flagResolvedURL != 0 {
+		resolved := r.string()
+		url.ResolvedURL = &resolved
+	}
+	url.CreatedAt = r.time()
+	if flags&flagExpiresAt != 0 {
+		expiresAt := r.time()
+		url.ExpiresAt = &expiresAt
+	}
+	url.Clicks = r.varint()
+	if flags&flagMaxClicks != 0 {
+		maxClicks := r.varint()
+		url.MaxClicks = &maxClicks
+	}
+	url.Version = r.varint()
+	if flags&flagMetadata != 0 {
+		url.Metadata = &domain.LinkMetadata{
+			Title:       r.string(),
+			Description: r.string(),
+			FaviconURL:  r.string(),
+			FetchedAt:   r.time(),
+		}
+	}
+	if flags&flagPreview != 0 {
+		url.Preview = &domain.PreviewCard{
+			Title:       r.string(),
+			Description: r.string(),
+			ImageURL:    r.string(),
+		}
+	}
+	if flags&flagLanguageTargets != 0 {
+		count := r.count()
+		url.LanguageTargets = make(map[string]string, count)
+		for i := uint64(0); i < count; i++ {
+			tag := r.string()
+			url.LanguageTargets[tag] = r.string()
+		}
+	}
+	if flags&flagSchedule != 0 {
+		url.Schedule = &domain.Schedule{Timezone: r.string()}
+		for rules := r.count(); rules > 0; rules-- {
+			var rule domain.ScheduleRule
+			for days := r.count(); days > 0; days-- {
+				rule.Days = append(rule.Days, r.string())
+			}
+			rule.Start = r.string()
+			rule.End = r.string()
+			rule.URL = r.string()
+			url.Schedule.Rules = append(url.Schedule.Rules, rule)
+		}
+	}
+	if flags&flagSigningSecret != 0 {
+		secret := r.string()
+		url.SigningSecret = &secret
+	}
+	if flags&flagUsedAt != 0 {
+		usedAt := r.time()
+		url.UsedAt = &usedAt
+	}
+	if flags&flagPayload != 0 {
+		payload := r.string()
+		url.Payload = &payload
+	}
+	if flags&flagBurnedAt != 0 {
+		burnedAt := r.time()
+		url.BurnedAt = &burnedAt
+	}
+	if flags&flagFile != 0 {
+		url.File = &domain.FileAttachment{
+			Key:         r.string(),
+			Name:        r.string(),
+			ContentType: r.string(),
+			Size:        r.varint(),
+		}
+	}
+	if flags&flagPaste != 0 {
+		url.Paste = &domain.Paste{
+			Content:  r.string(),
+			Language: r.string(),
+		}
+	}
+	if flags&flagDescription != 0 {
+		url.Description = r.string()
+	}
+	if flags&flagCustomMetadata != 0 {
+		count := r.count()
+		url.CustomMetadata = make(map[string]string, count)
+		for i := uint64(0); i < count; i++ {
+			key := r.string()
+			url.CustomMetadata[key] = r.string()
+		}
+	}
+	if flags&flagApproval != 0 {
+		url.Approval = domain.ApprovalStatus(r.string())
+		url.SubmittedBy = r.string()
+	}
+	if flags&flagVisibility != 0 {
+		url.Visibility = domain.LinkVisibility(r.string())
+	}
+	if flags&flagSealedDestination != 0 {
+		url.SealedDestination = r.string()
+	}
+
+	if r.err != nil {
+		return Entry{}, fmt.Errorf("corrupt cache entry: %w", r.err)
+	}
+	return Entry{URL: url, FreshUntil: freshUntil}, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func appendTime(buf []byte, t time.Time) []byte {
+	buf = binary.AppendVarint(buf, t.Unix())
+	return binary.AppendUvarint(buf, uint64(t.Nanosecond()))
+}
+
+// byteReader decodes the binary format
+// The first error sticks, so callers check it once at the end
+type byteReader struct {
+	data []byte
+	err  error
+}
+
+var errTruncated = errors.New("truncated data")
+
+func (r *byteReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *byteReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+// count reads the length of a list
+// Every element takes at least a byte, so a count larger than what's left
+// is corrupt - failing here keeps it from allocating a huge map or slice
+func (r *byteReader) count() uint64 {
+	count := r.uvarint()
+	if count > uint64(len(r.data)) {
+		r.fail()
+		return 0
+	}
+	return count
+}
+
+func (r *byteReader) string() string {
+	length := r.uvarint()
+	if r.err != nil || uint64(len(r.data)) < length {
+		r.fail()
+		return ""
+	}
+	s := string(r.data[:length])
+	r.data = r.data[length:]
+	return s
+}
+
+func (r *byteReader) time() time.Time {
+	sec := r.varint()
+	nsec := r.uvarint()
+	if r.err != nil {
+		return time.Time{}
+	}
+	return time.Unix(sec, int64(nsec)).UTC()
+}
+
+func (r *byteReader) fail() {
+	if r.err == nil {
+		r.err = errTruncated
+	}
+}
@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleURL returns a URL with every field set
+func sampleURL() *domain.URL {
+	alias := "my-link"
+	resolved := "https://example.com/final"
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 6, time.UTC)
+	maxClicks := int64(100)
+	secret := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	used := time.Date(2026, 1, 5, 8, 30, 0, 0, time.UTC)
+	payload := "v1.bm9uY2UgYW5kIGNpcGhlcnRleHQ"
+
+	return &domain.URL{
+		ID:          "6f1c2d4e-8a2b-4c1d-9e0f-123456789abc",
+		ShortCode:   "abc123",
+		OriginalURL: "https://example.com/some/long/path?utm_source=newsletter&utm_medium=email",
+		CustomAlias: &alias,
+		CreatedAt:   time.Date(2025, 12, 25, 14, 55, 29, 123456789, time.UTC),
+		ExpiresAt:   &expires,
+		Clicks:      4242,
+		CreatedBy:   "api-key-1",
+		IsActive:    true,
+		ResolvedURL: &resolved,
+		MaxClicks:   &maxClicks,
+		Domain:      "go.example.com",
+		Version:     3,
+		Metadata: &domain.LinkMetadata{
+			Title:       "Spring Sale - Example Shop",
+			Description: "Everything 20% off until Sunday",
+			FaviconURL:  "https://example.com/favicon.ico",
+			FetchedAt:   time.Date(2025, 12, 25, 14, 55, 31, 0, time.UTC),
+		},
+		Preview: &domain.PreviewCard{
+			Title:       "Spring Sale",
+			Description: "Only this weekend",
+			ImageURL:    "https://cdn.example.com/sale.png",
+		},
+		LanguageTargets: map[string]string{
+			"fr":    "https://example.com/fr/sale",
+			"pt-br": "https://example.com/br/sale",
+		},
+		Schedule: &domain.Schedule{
+			Timezone: "Europe/Berlin",
+			Rules: []domain.ScheduleRule{
+				{Days: []string{"mon", "fri"}, Start: "09:00", End: "17:00", URL: "https://example.com/chat"},
+				{URL: "https://example.com/help"},
+			},
+		},
+		SigningSecret: &secret,
+		SingleUse:     true,
+		UsedAt:        &used,
+
+		BurnAfterReading: true,
+		Payload:          &payload,
+		BurnedAt:         &used,
+
+		File:  &domain.FileAttachment{Key: "files/ab12/report.pdf", Name: "report.pdf", ContentType: "application/pdf", Size: 48213},
+		Paste: &domain.Paste{Content: "package main\n", Language: "go"},
+
+		AnalyticsDisabled: true,
+
+		Description:    "Spring sale banner, homepage",
+		CustomMetadata: map[string]string{"ticket": "MKT-142", "owner": "growth-team"},
+
+		Approval:    domain.ApprovalApproved,
+		SubmittedBy: "dana@example.com",
+
+		Visibility:        domain.VisibilitySensitive,
+		SealedDestination: "env1:k1:v1.d3JhcHBlZA:v1.c2VhbGVk",
+	}
+}
+
+func TestBinaryCodec_RoundTrip(t *testing.T) {
+	freshUntil := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		url  *domain.URL
+	}{
+		{name: "all fields set", url: sampleURL()},
+		{name: "optional fields empty", url: &domain.URL{ID: "1", ShortCode: "abc123", OriginalURL: "https://example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Encode(CodecBinary, tt.url, freshUntil)
+			require.NoError(t, err)
+
+			entry, err := Decode(data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.url, entry.URL)
+			assert.True(t, freshUntil.Equal(entry.FreshUntil))
+		})
+	}
+}
+
+// TestBinaryCodec_CoversEveryField fails when a field is added to domain.URL
+// without being added to the binary codec
+func TestBinaryCodec_CoversEveryField(t *testing.T) {
+	url := sampleURL()
+	value := reflect.ValueOf(url).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		require.False(t, value.Field(i).IsZero(),
+			"sampleURL must set %s - and the binary codec must encode it", value.Type().Field(i).Name)
+	}
+
+	data, err := Encode(CodecBinary, url, time.Now())
+	require.NoError(t, err)
+	entry, err := Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, url, entry.URL)
+}
+
+func TestDecodeEntry_ReadsBothFormats(t *testing.T) {
+	freshUntil := time.Now().Truncate(time.Second)
+
+	for _, codec := range []Codec{CodecJSON, CodecBinary} {
+		data, err := Encode(codec, sampleURL(), freshUntil)
+		require.NoError(t, err)
+
+		entry, err := Decode(data)
+		require.NoError(t, err, string(codec))
+		assert.Equal(t, "abc123", entry.URL.ShortCode)
+	}
+}
+
+func TestDecodeEntry_UnsupportedFormats(t *testing.T) {
+	current, err := Encode(CodecBinary, sampleURL(), time.Now())
+	require.NoError(t, err)
+	future := append([]byte{}, current...)
+	future[1] = binaryVersion + 1
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "newer binary version", data: future},
+		{name: "plain URL JSON (pre soft TTL)", data: []byte(`{"ID":"1","ShortCode":"abc123"}`)},
+		{name: "unknown format", data: []byte("garbage")},
+		{name: "empty", data: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.data)
+			assert.ErrorIs(t, err, ErrUnsupportedFormat)
+		})
+	}
+}
+
+func TestDecodeEntry_Truncated(t *testing.T) {
+	data, err := Encode(CodecBinary, sampleURL(), time.Now())
+	require.NoError(t, err)
+
+	_, err = Decode(data[:len(data)/2])
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+// Run with: go test -bench=. -benchmem ./internal/cache/
+func BenchmarkEncode(b *testing.B) {
+	url := sampleURL()
+	freshUntil := time.Now()
+
+	for _, codec := range []Codec{CodecJSON, CodecBinary} {
+		b.Run(string(codec), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Encode(codec, url, freshUntil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	for _, codec := range []Codec{CodecJSON, CodecBinary} {
+		data, err := Encode(codec, sampleURL(), time.Now())
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(string(codec), func(b *testing.B) {
+			b.ReportAllocs()
+			b.ReportMetric(float64(len(data)), "bytes/entry")
+			for i := 0; i < b.N; i++ {
+				if _, err := Decode(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
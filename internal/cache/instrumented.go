@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/metrics"
+)
+
+// Instrumented wraps a Cache and records the cache metrics
+//
+// WHY A DECORATOR?
+// Every backend used to time its own calls and count its own hits, and each
+// new backend had to copy that code exactly or the dashboards would drift.
+// Now backends only talk to their server; this wrapper produces the same
+// cache_hits_total, cache_misses_total and cache_operation_duration_seconds
+// for all of them.
+type Instrumented struct {
+	next Cache
+}
+
+// NewInstrumented wraps next with metrics
+func NewInstrumented(next Cache) *Instrumented {
+	return &Instrumented{next: next}
+}
+
+var _ Cache = (*Instrumented)(nil)
+
+func (c *Instrumented) GetURL(ctx context.Context, shortCode string) (*domain.URL, error) {
+	defer observe("get", time.Now())
+
+	url, err := c.next.GetURL(ctx, shortCode)
+	if err != nil {
+		return nil, err // Neither a hit nor a miss - the backend failed
+	}
+	if url == nil {
+		metrics.RecordCacheMiss()
+	} else {
+		metrics.RecordCacheHit()
+	}
+	return url, nil
+}
+
+func (c *Instrumented) SetURL(ctx context.Context, shortCode string, url *domain.URL) error {
+	defer observe("set", time.Now())
+	return c.next.SetURL(ctx, shortCode, url)
+}
+
+func (c *Instrumented) DeleteURL(ctx context.Context, shortCode string) error {
+	defer observe("delete", time.Now())
+	return c.next.DeleteURL(ctx, shortCode)
+}
+
+func (c *Instrumented) Exists(ctx context.Context, shortCode string) (bool, error) {
+	defer observe("exists", time.Now())
+	return c.next.Exists(ctx, shortCode)
+}
+
+func (c *Instrumented) Clear(ctx context.Context) error {
+	defer observe("clear", time.Now())
+	return c.next.Clear(ctx)
+}
+
+func (c *Instrumented) GetStats(ctx context.Context) (map[string]interface{}, error) {
+	return c.next.GetStats(ctx)
+}
+
+// observe records how long an operation took since start
+func observe(operation string, start time.Time) {
+	metrics.CacheOperationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCache is a minimal in-memory backend for contract tests
+type memoryCache struct {
+	urls   map[string]*domain.URL
+	getErr error
+}
+
+var _ Cache = (*memoryCache)(nil)
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{urls: make(map[string]*domain.URL)}
+}
+
+func (m *memoryCache) GetURL(ctx context.Context, shortCode string) (*domain.URL, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	return m.urls[shortCode], nil
+}
+
+func (m *memoryCache) SetURL(ctx context.Context, shortCode string, url *domain.URL) error {
+	m.urls[shortCode] = url
+	return nil
+}
+
+func (m *memoryCache) DeleteURL(ctx context.Context, shortCode string) error {
+	delete(m.urls, shortCode)
+	return nil
+}
+
+func (m *memoryCache) Exists(ctx context.Context, shortCode string) (bool, error) {
+	_, ok := m.urls[shortCode]
+	return ok, nil
+}
+
+func (m *memoryCache) Clear(ctx context.Context) error {
+	m.urls = make(map[string]*domain.URL)
+	return nil
+}
+
+func (m *memoryCache) GetStats(ctx context.Context) (map[string]interface{}, error) {
+	return map[string]interface{}{"contract_version": ContractVersion}, nil
+}
+
+func TestInstrumented_CountsHitsAndMisses(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryCache()
+	c := NewInstrumented(backend)
+
+	hits := testutil.ToFloat64(metrics.CacheHitsTotal)
+	misses := testutil.ToFloat64(metrics.CacheMissesTotal)
+
+	require.NoError(t, c.SetURL(ctx, "abc123", sampleURL()))
+
+	got, err := c.GetURL(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", got.ShortCode)
+
+	got, err = c.GetURL(ctx, "missing")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	// A backend error is neither a hit nor a miss
+	backend.getErr = errors.New("connection refused")
+	_, err = c.GetURL(ctx, "abc123")
+	assert.Error(t, err)
+
+	assert.Equal(t, hits+1, testutil.ToFloat64(metrics.CacheHitsTotal))
+	assert.Equal(t, misses+1, testutil.ToFloat64(metrics.CacheMissesTotal))
+}
+
+func TestInstrumented_PassesThroughFullContract(t *testing.T) {
+	ctx := context.Background()
+	c := NewInstrumented(newMemoryCache())
+
+	require.NoError(t, c.SetURL(ctx, "abc123", sampleURL()))
+
+	exists, err := c.Exists(ctx, "abc123")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, c.DeleteURL(ctx, "abc123"))
+	exists, err = c.Exists(ctx, "abc123")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, c.SetURL(ctx, "abc123", sampleURL()))
+	require.NoError(t, c.Clear(ctx))
+	exists, err = c.Exists(ctx, "abc123")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	stats, err := c.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ContractVersion, stats["contract_version"])
+}
@@ -0,0 +1,313 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/repository"
+)
+
+// Breaker guards the database on cache misses
+// Implemented by resilience.CircuitBreaker; optional (nil disables it)
+type Breaker interface {
+	Allow() error        // Returns an error while the breaker rejects calls
+	Record(success bool) // Reports the outcome of an allowed call
+}
+
+// CachedURLRepository puts the URL cache in front of a URLRepository
+//
+// WHY A DECORATOR?
+// The service used to talk to the cache itself: check it, fall back to the
+// database, store the result, and remember to delete the entry after every
+// write. Each caller had to know that GetURL returns (nil, nil) on a miss,
+// and a forgotten invalidation meant stale redirects. Now the cache is just
+// another URLRepository: the service reads and writes links, and this
+// wrapper decides what is served from the cache and what must be forgotten.
+//
+// READS (READ-THROUGH):
+// GetByShortCode and GetByCustomAlias answer from the cache when they can.
+// On a miss they load the link from the database and cache it. A broken
+// cache costs speed, not correctness: its errors are logged and the read
+// goes to the database. Contexts marked with repository.WithConsistentRead
+// skip the cache (stats, conditional updates).
+//
+// WRITES:
+// Create caches the new link right away (write-through). Every other write
+// deletes the cached copies, under the short code AND the custom alias,
+// because links are cached under the code they were looked up with.
+type CachedURLRepository struct {
+	next    repository.URLRepository
+	cache   URLCache
+	breaker Breaker // Optional: stops cache misses from piling up on a struggling database
+	backend string  // Labels cache hits in the lookup latency metric
+}
+
+// NewCachedURLRepository wraps next with cache
+func NewCachedURLRepository(next repository.URLRepository, cache URLCache) *CachedURLRepository {
+	return &CachedURLRepository{next: next, cache: cache, backend: metrics.SourceCache}
+}
+
+var _ repository.URLRepository = (*CachedURLRepository)(nil)
+
+// WithBackend names the cache backend ("redis", "memcached") in the
+// redirect lookup metric, so hits can be told apart after a CACHE_DRIVER switch
+func (r *CachedURLRepository) WithBackend(name string) *CachedURLRepository {
+	r.backend = name
+	return r
+}
+
+// WithBreaker protects the database behind cache misses with a circuit breaker
+// While it is open, cached links keep working and misses fail fast with the
+// breaker's error
+func (r *CachedURLRepository) WithBreaker(b Breaker) *CachedURLRepository {
+	r.breaker = b
+	return r
+}
+
+func (r *CachedURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*domain.URL, error) {
+	return r.read(ctx, shortCode, r.next.GetByShortCode)
+}
+
+func (r *CachedURLRepository) GetByCustomAlias(ctx context.Context, alias string) (*domain.URL, error) {
+	return r.read(ctx, alias, r.next.GetByCustomAlias)
+}
+
+// GetByID is not cached: links are cached by the code visitors use
+func (r *CachedURLRepository) GetByID(ctx context.Context, id string) (*domain.URL, error) {
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *CachedURLRepository) Create(ctx context.Context, url *domain.URL) error {
+	if err := r.next.Create(ctx, url); err != nil {
+		return err
+	}
+
+	// New links are often opened right after they are shared
+	// We don't fail if caching fails - the link is stored
+	if err := r.cache.SetURL(ctx, url.ShortCode, url); err != nil {
+		fmt.Printf("Warning: failed to cache URL: %v\n", err)
+	}
+	return nil
+}
+
+func (r *CachedURLRepository) Update(ctx context.Context, url *domain.URL) error {
+	if err := r.next.Update(ctx, url); err != nil {
+		return err
+	}
+	r.forget(ctx, url)
+	return nil
+}
+
+func (r *CachedURLRepository) SetMetadata(ctx context.Context, id string, meta *domain.LinkMetadata) error {
+	if err := r.next.SetMetadata(ctx, id, meta); err != nil {
+		return err
+	}
+	r.forgetID(ctx, id)
+	return nil
+}
+
+func (r *CachedURLRepository) Delete(ctx context.Context, id string) error {
+	if err := r.next.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.forgetID(ctx, id) // The row stays (soft delete), so it still tells us the codes
+	return nil
+}
+
+func (r *CachedURLRepository) Restore(ctx context.Context, id string) error {
+	if err := r.next.Restore(ctx, id); err != nil {
+		return err
+	}
+	r.forgetID(ctx, id) // A stale "inactive" copy may still be cached
+	return nil
+}
+
+func (r *CachedURLRepository) Purge(ctx context.Context, id string) (int64, error) {
+	// Load the codes first: after the purge there is no row to read them from
+	url, err := r.next.GetByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	clicks, err := r.next.Purge(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	r.forget(ctx, url)
+	return clicks, nil
+}
+
+// IncrementClicks drops the cached copy of links with a click limit
+// The cached counter is not updated on clicks. For most links that only
+// makes stats lag, but for a limited link the counter decides whether it
+// still redirects - the next lookup must read the real count.
+func (r *CachedURLRepository) IncrementClicks(ctx context.Context, shortCode string) error {
+	if err := r.next.IncrementClicks(ctx, shortCode); err != nil {
+		return err
+	}
+
+	cached, err := r.cache.GetURL(ctx, shortCode)
+	if err != nil {
+		fmt.Printf("Warning: failed to read cached URL: %v\n", err)
+		return nil
+	}
+	if cached != nil && cached.MaxClicks != nil {
+		r.forget(ctx, cached)
+	}
+	return nil
+}
+
+// MarkUsed forgets the link whether or not this call used it up:
+// either way it is used now
+func (r *CachedURLRepository) MarkUsed(ctx context.Context, shortCode string) (bool, error) {
+	won, err := r.next.MarkUsed(ctx, shortCode)
+	if err != nil {
+		return false, err
+	}
+	r.forgetCode(ctx, shortCode)
+	return won, nil
+}
+
+func (r *CachedURLRepository) Burn(ctx context.Context, shortCode string) (*string, error) {
+	payload, err := r.next.Burn(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	r.forgetCode(ctx, shortCode)
+	return payload, nil
+}
+
+// The existence checks guard uniqueness - only the database can answer them
+
+func (r *CachedURLRepository) ExistsShortCode(ctx context.Context, shortCode string) (bool, error) {
+	return r.next.ExistsShortCode(ctx, shortCode)
+}
+
+func (r *CachedURLRepository) ExistsCustomAlias(ctx context.Context, alias string) (bool, error) {
+	return r.next.ExistsCustomAlias(ctx, alias)
+}
+
+func (r *CachedURLRepository) FindTakenCodes(ctx context.Context, codes []string) (map[string]bool, error) {
+	return r.next.FindTakenCodes(ctx, codes)
+}
+
+// Refresh reloads a cached link from the database
+// The cache calls it in the background when it serves a stale entry
+// (stale-while-revalidate, see RefreshFunc). Links that are gone or no
+// longer accessible are evicted instead of refreshed.
+func (r *CachedURLRepository) Refresh(ctx context.Context, shortCode string) error {
+	url, err := r.load(ctx, shortCode, r.next.GetByShortCode)
+	if errors.Is(err, domain.ErrURLNotFound) {
+		url, err = r.load(ctx, shortCode, r.next.GetByCustomAlias)
+	}
+	if errors.Is(err, domain.ErrURLNotFound) {
+		return r.cache.DeleteURL(ctx, shortCode)
+	}
+	if err != nil {
+		// Keep serving the stale copy; the next stale read retries
+		return err
+	}
+
+	if url.CanBeAccessed() != nil {
+		return r.cache.DeleteURL(ctx, shortCode)
+	}
+	return r.cache.SetURL(ctx, shortCode, url)
+}
+
+// read answers a lookup by code from the cache, or from the database on a miss
+//
+// GetURL returns (nil, nil) on a miss and an error when the cache is down.
+// This is the one place that has to know that: both end up reading the
+// database, the error is only logged.
+func (r *CachedURLRepository) read(ctx context.Context, code string, get func(ctx context.Context, code string) (*domain.URL, error)) (*domain.URL, error) {
+	if repository.IsConsistentRead(ctx) {
+		return get(ctx, code)
+	}
+
+	// Record the latency of redirects by the tier that answered (not-found
+	// counts too); other reads would skew the histogram
+	start := time.Now()
+	source := r.backend
+	if repository.IsRedirectLookup(ctx) {
+		defer func() {
+			metrics.ObserveRedirectLookup(source, time.Since(start))
+		}()
+	}
+
+	cached, err := r.cache.GetURL(ctx, code)
+	if err != nil {
+		fmt.Printf("Warning: failed to read cached URL, using the database: %v\n", err)
+	} else if cached != nil {
+		return cached, nil
+	}
+
+	source = metrics.SourceDB
+	url, err := r.load(ctx, code, get)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cached under the code it was looked up with - that is what the next
+	// visitor asks for. We don't fail if caching fails.
+	if err := r.cache.SetURL(ctx, code, url); err != nil {
+		fmt.Printf("Warning: failed to cache URL: %v\n", err)
+	}
+	return url, nil
+}
+
+// load reads the database through the circuit breaker
+// If the breaker is open, it fails fast instead of adding load to a
+// database that is already struggling
+func (r *CachedURLRepository) load(ctx context.Context, code string, get func(ctx context.Context, code string) (*domain.URL, error)) (*domain.URL, error) {
+	if r.breaker != nil {
+		if err := r.breaker.Allow(); err != nil {
+			return nil, err
+		}
+	}
+
+	url, err := get(ctx, code)
+
+	if r.breaker != nil {
+		// "Not found" is a healthy answer - only real failures count
+		r.breaker.Record(err == nil || errors.Is(err, domain.ErrURLNotFound))
+	}
+	return url, err
+}
+
+// forget removes every cache entry that may hold url
+func (r *CachedURLRepository) forget(ctx context.Context, url *domain.URL) {
+	keys := []string{url.ShortCode}
+	if url.CustomAlias != nil && *url.CustomAlias != url.ShortCode {
+		keys = append(keys, *url.CustomAlias)
+	}
+
+	for _, key := range keys {
+		if err := r.cache.DeleteURL(ctx, key); err != nil {
+			fmt.Printf("Warning: failed to invalidate cached URL: %v\n", err)
+		}
+	}
+}
+
+// forgetID forgets the link with id; its codes are read from the database
+func (r *CachedURLRepository) forgetID(ctx context.Context, id string) {
+	url, err := r.next.GetByID(ctx, id)
+	if err != nil {
+		fmt.Printf("Warning: failed to invalidate cached URL %s: %v\n", id, err)
+		return
+	}
+	r.forget(ctx, url)
+}
+
+// forgetCode forgets the link with shortCode, including its custom alias entry
+func (r *CachedURLRepository) forgetCode(ctx context.Context, shortCode string) {
+	url, err := r.next.GetByShortCode(ctx, shortCode)
+	if err != nil {
+		// At least the entry under the code itself must go
+		url = &domain.URL{ShortCode: shortCode}
+	}
+	r.forget(ctx, url)
+}
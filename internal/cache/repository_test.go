@@ -0,0 +1,559 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/repository"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockURLRepository is a mock implementation of repository.URLRepository
+type MockURLRepository struct {
+	mock.Mock
+}
+
+var _ repository.URLRepository = (*MockURLRepository)(nil)
+
+func (m *MockURLRepository) Create(ctx context.Context, url *domain.URL) error {
+	return m.Called(ctx, url).Error(0)
+}
+
+func (m *MockURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*domain.URL, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) GetByID(ctx context.Context, id string) (*domain.URL, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) GetByCustomAlias(ctx context.Context, alias string) (*domain.URL, error) {
+	args := m.Called(ctx, alias)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) Update(ctx context.Context, url *domain.URL) error {
+	return m.Called(ctx, url).Error(0)
+}
+
+func (m *MockURLRepository) SetMetadata(ctx context.Context, id string, meta *domain.LinkMetadata) error {
+	return m.Called(ctx, id, meta).Error(0)
+}
+
+func (m *MockURLRepository) Delete(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockURLRepository) Restore(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockURLRepository) Purge(ctx context.Context, id string) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockURLRepository) IncrementClicks(ctx context.Context, shortCode string) error {
+	return m.Called(ctx, shortCode).Error(0)
+}
+
+func (m *MockURLRepository) MarkUsed(ctx context.Context, shortCode string) (bool, error) {
+	args := m.Called(ctx, shortCode)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockURLRepository) Burn(ctx context.Context, shortCode string) (*string, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*string), args.Error(1)
+}
+
+func (m *MockURLRepository) ExistsShortCode(ctx context.Context, shortCode string) (bool, error) {
+	args := m.Called(ctx, shortCode)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockURLRepository) ExistsCustomAlias(ctx context.Context, alias string) (bool, error) {
+	args := m.Called(ctx, alias)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockURLRepository) FindTakenCodes(ctx context.Context, codes []string) (map[string]bool, error) {
+	args := m.Called(ctx, codes)
+	return args.Get(0).(map[string]bool), args.Error(1)
+}
+
+// countingBreaker opens after failures consecutive failures
+// (resilience.CircuitBreaker imports this package, so tests can't use it)
+type countingBreaker struct {
+	failures int
+	limit    int
+}
+
+var errBreakerOpen = errors.New("circuit breaker is open")
+
+func (b *countingBreaker) Allow() error {
+	if b.failures >= b.limit {
+		return errBreakerOpen
+	}
+	return nil
+}
+
+func (b *countingBreaker) Record(success bool) {
+	if success {
+		b.failures = 0
+	} else {
+		b.failures++
+	}
+}
+
+// lookupCount returns how many lookups were observed for a source
+func lookupCount(t *testing.T, source string) uint64 {
+	t.Helper()
+	var metric dto.Metric
+	observer := metrics.RedirectLookupDuration.WithLabelValues(source).(prometheus.Metric)
+	require.NoError(t, observer.Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestCachedURLRepository_Hit(t *testing.T) {
+	// Arrange
+	ctx := repository.WithRedirectLookup(context.Background())
+	db := new(MockURLRepository)
+	cache := newMemoryCache()
+	cached := &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com", IsActive: true}
+	cache.urls["abc123"] = cached
+	repo := NewCachedURLRepository(db, cache).WithBackend("memcached")
+	before := lookupCount(t, "memcached")
+
+	// Act
+	url, err := repo.GetByShortCode(ctx, "abc123")
+
+	// Assert: the database is not asked
+	require.NoError(t, err)
+	assert.Equal(t, cached, url)
+	db.AssertNotCalled(t, "GetByShortCode", mock.Anything, mock.Anything)
+	assert.Equal(t, before+1, lookupCount(t, "memcached"))
+}
+
+func TestCachedURLRepository_Miss(t *testing.T) {
+	// Arrange
+	ctx := repository.WithRedirectLookup(context.Background())
+	db := new(MockURLRepository)
+	cache := newMemoryCache()
+	stored := &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com", IsActive: true}
+	db.On("GetByShortCode", ctx, "abc123").Return(stored, nil).Once()
+	repo := NewCachedURLRepository(db, cache)
+	before := lookupCount(t, metrics.SourceDB)
+
+	// Act: the second read is served by the entry the first one stored
+	first, err := repo.GetByShortCode(ctx, "abc123")
+	require.NoError(t, err)
+	second, err := repo.GetByShortCode(ctx, "abc123")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, stored, first)
+	assert.Equal(t, stored, second)
+	assert.Equal(t, stored, cache.urls["abc123"])
+	db.AssertNumberOfCalls(t, "GetByShortCode", 1)
+	assert.Equal(t, before+1, lookupCount(t, metrics.SourceDB))
+}
+
+func TestCachedURLRepository_OnlyRedirectsAreTimed(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db := new(MockURLRepository)
+	cache := newMemoryCache()
+	cache.urls["abc123"] = &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com", IsActive: true}
+	stored := &domain.URL{ID: "456", ShortCode: "def456", OriginalURL: "https://example.com/other", IsActive: true}
+	db.On("GetByShortCode", ctx, "def456").Return(stored, nil)
+	repo := NewCachedURLRepository(db, cache).WithBackend("memcached")
+	beforeCache, beforeDB := lookupCount(t, "memcached"), lookupCount(t, metrics.SourceDB)
+
+	// Act: a hit and a miss, neither for a redirect (stats, imports, updates...)
+	_, err := repo.GetByShortCode(ctx, "abc123")
+	require.NoError(t, err)
+	_, err = repo.GetByShortCode(ctx, "def456")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, beforeCache, lookupCount(t, "memcached"))
+	assert.Equal(t, beforeDB, lookupCount(t, metrics.SourceDB))
+}
+
+func TestCachedURLRepository_MissIsCachedUnderTheAlias(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db := new(MockURLRepository)
+	cache := newMemoryCache()
+	stored := (&domain.URL{ID: "123", ShortCode: "abc123", IsActive: true}).WithCustomAlias("promo")
+	db.On("GetByCustomAlias", ctx, "promo").Return(stored, nil)
+	repo := NewCachedURLRepository(db, cache)
+
+	// Act
+	_, err := repo.GetByCustomAlias(ctx, "promo")
+
+	// Assert: cached under the code visitors used
+	require.NoError(t, err)
+	assert.Equal(t, stored, cache.urls["promo"])
+	assert.NotContains(t, cache.urls, "abc123")
+}
+
+func TestCachedURLRepository_NotFoundIsNotCached(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db := new(MockURLRepository)
+	cache := newMemoryCache()
+	db.On("GetByShortCode", ctx, "nope").Return(nil, domain.ErrURLNotFound)
+	repo := NewCachedURLRepository(db, cache)
+
+	// Act
+	url, err := repo.GetByShortCode(ctx, "nope")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+	assert.Nil(t, url)
+	assert.Empty(t, cache.urls)
+}
+
+func TestCachedURLRepository_CacheErrorFallsBackToDatabase(t *testing.T) {
+	// Arrange: the cache is down
+	ctx := context.Background()
+	db := new(MockURLRepository)
+	cache := newMemoryCache()
+	cache.getErr = errors.New("connection refused")
+	stored := &domain.URL{ID: "123", ShortCode: "abc123", IsActive: true}
+	db.On("GetByShortCode", ctx, "abc123").Return(stored, nil)
+	repo := NewCachedURLRepository(db, cache)
+
+	// Act
+	url, err := repo.GetByShortCode(ctx, "abc123")
+
+	// Assert: a broken cache costs speed, not correctness
+	require.NoError(t, err)
+	assert.Equal(t, stored, url)
+	db.AssertExpectations(t)
+}
+
+func TestCachedURLRepository_DatabaseErrorIsReturned(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db := new(MockURLRepository)
+	cache := newMemoryCache()
+	dbDown := errors.New("connection refused")
+	db.On("GetByShortCode", ctx, "abc123").Return(nil, dbDown)
+	repo := NewCachedURLRepository(db, cache)
+
+	// Act
+	url, err := repo.GetByShortCode(ctx, "abc123")
+
+	// Assert
+	assert.ErrorIs(t, err, dbDown)
+	assert.Nil(t, url)
+	assert.Empty(t, cache.urls)
+}
+
+func TestCachedURLRepository_ConsistentReadSkipsCache(t *testing.T) {
+	// Arrange: the cached copy has an old click count
+	ctx := repository.WithConsistentRead(context.Background())
+	db := new(MockURLRepository)
+	cache := newMemoryCache()
+	cache.urls["abc123"] = &domain.URL{ID: "123", ShortCode: "abc123", Clicks: 3}
+	stored := &domain.URL{ID: "123", ShortCode: "abc123", Clicks: 7}
+	db.On("GetByShortCode", ctx, "abc123").Return(stored, nil)
+	repo := NewCachedURLRepository(db, cache)
+
+	// Act
+	url, err := repo.GetByShortCode(ctx, "abc123")
+
+	// Assert: the database answers, and the cache is left alone
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), url.Clicks)
+	assert.Equal(t, int64(3), cache.urls["abc123"].Clicks)
+}
+
+func TestCachedURLRepository_CreateCachesTheLink(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db := new(MockURLRepository)
+	cache := newMemoryCache()
+	url := &domain.URL{ShortCode: "abc123", OriginalURL: "https://example.com"}
+	db.On("Create", ctx, url).Return(nil)
+	repo := NewCachedURLRepository(db, cache)
+
+	// Act
+	err := repo.Create(ctx, url)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, url, cache.urls["abc123"])
+}
+
+func TestCachedURLRepository_FailedCreateIsNotCached(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db := new(MockURLRepository)
+	cache := newMemoryCache()
+	url := &domain.URL{ShortCode: "abc123"}
+	db.On("Create", ctx, url).Return(domain.ErrShortCodeTaken)
+	repo := NewCachedURLRepository(db, cache)
+
+	// Act
+	err := repo.Create(ctx, url)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrShortCodeTaken)
+	assert.Empty(t, cache.urls)
+}
+
+func TestCachedURLRepository_WritesInvalidate(t *testing.T) {
+	link := func() *domain.URL {
+		return (&domain.URL{ID: "123", ShortCode: "abc123", IsActive: true}).WithCustomAlias("promo")
+	}
+
+	tests := []struct {
+		name  string
+		setup func(db *MockURLRepository)
+		write func(ctx context.Context, repo *CachedURLRepository) error
+	}{
+		{
+			name:  "update",
+			setup: func(db *MockURLRepository) { db.On("Update", mock.Anything, mock.Anything).Return(nil) },
+			write: func(ctx context.Context, repo *CachedURLRepository) error { return repo.Update(ctx, link()) },
+		},
+		{
+			name:  "metadata",
+			setup: func(db *MockURLRepository) { db.On("SetMetadata", mock.Anything, "123", mock.Anything).Return(nil) },
+			write: func(ctx context.Context, repo *CachedURLRepository) error {
+				return repo.SetMetadata(ctx, "123", &domain.LinkMetadata{Title: "Example"})
+			},
+		},
+		{
+			name:  "delete",
+			setup: func(db *MockURLRepository) { db.On("Delete", mock.Anything, "123").Return(nil) },
+			write: func(ctx context.Context, repo *CachedURLRepository) error { return repo.Delete(ctx, "123") },
+		},
+		{
+			name:  "restore",
+			setup: func(db *MockURLRepository) { db.On("Restore", mock.Anything, "123").Return(nil) },
+			write: func(ctx context.Context, repo *CachedURLRepository) error { return repo.Restore(ctx, "123") },
+		},
+		{
+			name:  "purge",
+			setup: func(db *MockURLRepository) { db.On("Purge", mock.Anything, "123").Return(int64(5), nil) },
+			write: func(ctx context.Context, repo *CachedURLRepository) error {
+				_, err := repo.Purge(ctx, "123")
+				return err
+			},
+		},
+		{
+			name:  "mark used",
+			setup: func(db *MockURLRepository) { db.On("MarkUsed", mock.Anything, "abc123").Return(true, nil) },
+			write: func(ctx context.Context, repo *CachedURLRepository) error {
+				_, err := repo.MarkUsed(ctx, "abc123")
+				return err
+			},
+		},
+		{
+			name: "burn",
+			setup: func(db *MockURLRepository) {
+				payload := "v1.sealed"
+				db.On("Burn", mock.Anything, "abc123").Return(&payload, nil)
+			},
+			write: func(ctx context.Context, repo *CachedURLRepository) error {
+				_, err := repo.Burn(ctx, "abc123")
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: the link is cached under its code and its alias
+			ctx := context.Background()
+			db := new(MockURLRepository)
+			cache := newMemoryCache()
+			cache.urls["abc123"] = link()
+			cache.urls["promo"] = link()
+			cache.urls["other"] = &domain.URL{ShortCode: "other"}
+			db.On("GetByID", mock.Anything, "123").Return(link(), nil).Maybe()
+			db.On("GetByShortCode", mock.Anything, "abc123").Return(link(), nil).Maybe()
+			tt.setup(db)
+			repo := NewCachedURLRepository(db, cache)
+
+			// Act
+			err := tt.write(ctx, repo)
+
+			// Assert: both entries are gone, other links stay cached
+			require.NoError(t, err)
+			assert.NotContains(t, cache.urls, "abc123")
+			assert.NotContains(t, cache.urls, "promo")
+			assert.Contains(t, cache.urls, "other")
+		})
+	}
+}
+
+func TestCachedURLRepository_FailedWriteKeepsCache(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db := new(MockURLRepository)
+	cache := newMemoryCache()
+	url := &domain.URL{ID: "123", ShortCode: "abc123"}
+	cache.urls["abc123"] = url
+	db.On("Update", ctx, url).Return(domain.ErrVersionConflict)
+	repo := NewCachedURLRepository(db, cache)
+
+	// Act
+	err := repo.Update(ctx, url)
+
+	// Assert: nothing changed, so nothing is forgotten
+	assert.ErrorIs(t, err, domain.ErrVersionConflict)
+	assert.Contains(t, cache.urls, "abc123")
+}
+
+func TestCachedURLRepository_IncrementClicks(t *testing.T) {
+	tests := []struct {
+		name        string
+		cached      *domain.URL
+		expectEvict bool
+	}{
+		{name: "unlimited link stays cached", cached: &domain.URL{ShortCode: "abc123"}},
+		{name: "limited link is reloaded", cached: (&domain.URL{ShortCode: "abc123"}).WithMaxClicks(10), expectEvict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			db := new(MockURLRepository)
+			cache := newMemoryCache()
+			cache.urls["abc123"] = tt.cached
+			db.On("IncrementClicks", ctx, "abc123").Return(nil)
+			repo := NewCachedURLRepository(db, cache)
+
+			// Act
+			err := repo.IncrementClicks(ctx, "abc123")
+
+			// Assert
+			require.NoError(t, err)
+			if tt.expectEvict {
+				assert.NotContains(t, cache.urls, "abc123")
+			} else {
+				assert.Contains(t, cache.urls, "abc123")
+			}
+		})
+	}
+}
+
+func TestCachedURLRepository_Breaker(t *testing.T) {
+	// Arrange: the database is down and the breaker opens after 2 failures
+	ctx := context.Background()
+	db := new(MockURLRepository)
+	cache := newMemoryCache()
+	cached := &domain.URL{ShortCode: "cached", IsActive: true}
+	cache.urls["cached"] = cached
+	breaker := &countingBreaker{limit: 2}
+	repo := NewCachedURLRepository(db, cache).WithBreaker(breaker)
+	db.On("GetByShortCode", ctx, mock.Anything).Return(nil, errors.New("connection refused"))
+
+	// Act: two failing lookups trip the breaker
+	for i := 0; i < 2; i++ {
+		_, err := repo.GetByShortCode(ctx, "missing")
+		require.Error(t, err)
+	}
+
+	// Assert: misses fail fast without touching the database...
+	_, err := repo.GetByShortCode(ctx, "missing")
+	assert.ErrorIs(t, err, errBreakerOpen)
+	db.AssertNumberOfCalls(t, "GetByShortCode", 2)
+
+	// ...while cached links keep working
+	url, err := repo.GetByShortCode(ctx, "cached")
+	require.NoError(t, err)
+	assert.Equal(t, cached, url)
+}
+
+func TestCachedURLRepository_BreakerIgnoresNotFound(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db := new(MockURLRepository)
+	breaker := &countingBreaker{limit: 1}
+	repo := NewCachedURLRepository(db, newMemoryCache()).WithBreaker(breaker)
+	db.On("GetByShortCode", ctx, "nope").Return(nil, domain.ErrURLNotFound)
+
+	// Act
+	_, err := repo.GetByShortCode(ctx, "nope")
+
+	// Assert: a missing link is a healthy answer from the database
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+	assert.NoError(t, breaker.Allow())
+}
+
+func TestCachedURLRepository_Refresh(t *testing.T) {
+	activeURL := &domain.URL{ID: "1", ShortCode: "abc123", OriginalURL: "https://example.com", IsActive: true}
+	deletedURL := &domain.URL{ID: "2", ShortCode: "abc123", OriginalURL: "https://example.com", IsActive: false}
+	stale := &domain.URL{ID: "1", ShortCode: "abc123", OriginalURL: "https://old.example.com", IsActive: true}
+
+	tests := []struct {
+		name       string
+		dbURL      *domain.URL
+		dbErr      error
+		wantCached *domain.URL
+		expectErr  bool
+	}{
+		{name: "still active - re-cached", dbURL: activeURL, wantCached: activeURL},
+		{name: "deactivated - evicted", dbURL: deletedURL},
+		{name: "gone - evicted", dbErr: domain.ErrURLNotFound},
+		{name: "database error - stale copy kept", dbErr: errors.New("connection refused"), wantCached: stale, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			db := new(MockURLRepository)
+			cache := newMemoryCache()
+			cache.urls["abc123"] = stale
+			if tt.dbErr != nil {
+				db.On("GetByShortCode", ctx, "abc123").Return(nil, tt.dbErr)
+				db.On("GetByCustomAlias", ctx, "abc123").Return(nil, tt.dbErr)
+			} else {
+				db.On("GetByShortCode", ctx, "abc123").Return(tt.dbURL, nil)
+			}
+			repo := NewCachedURLRepository(db, cache)
+
+			// Act
+			err := repo.Refresh(ctx, "abc123")
+
+			// Assert
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCached, cache.urls["abc123"])
+		})
+	}
+}
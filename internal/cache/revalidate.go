@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"url-shortener/internal/metrics"
+)
+
+// RefreshFunc reloads a short code from the source of truth and re-caches it
+type RefreshFunc func(ctx context.Context, shortCode string) error
+
+// Revalidator runs background refreshes for STALE-WHILE-REVALIDATE
+//
+// Each entry has a SOFT TTL and a HARD TTL (soft + stale window). Until the
+// soft TTL it is fresh. Between the two it is STALE: backends still return it
+// immediately and call Trigger, which refreshes it from the database in the
+// background. Only after the hard TTL does the backend drop it and the next
+// request pays for a database lookup. Hot links therefore never see a cache
+// miss just because their TTL lapsed.
+//
+// A nil *Revalidator does nothing, so backends can call it unconditionally.
+type Revalidator struct {
+	refresh RefreshFunc
+	timeout time.Duration
+
+	mu         sync.Mutex
+	refreshing map[string]bool // Short codes with a refresh in flight (one refresh per key)
+}
+
+// NewRevalidator creates a revalidator that calls refresh for stale entries
+func NewRevalidator(refresh RefreshFunc) *Revalidator {
+	return &Revalidator{
+		refresh:    refresh,
+		timeout:    5 * time.Second,
+		refreshing: make(map[string]bool),
+	}
+}
+
+// Trigger refreshes shortCode in the background
+// Backends call it for every stale hit (counted in cache_stale_hits_total);
+// concurrent readers of the same hot key trigger only ONE refresh
+func (r *Revalidator) Trigger(ctx context.Context, shortCode string) {
+	if r == nil {
+		return
+	}
+	metrics.RecordCacheStaleHit()
+
+	r.mu.Lock()
+	if r.refreshing[shortCode] {
+		r.mu.Unlock()
+		return
+	}
+	r.refreshing[shortCode] = true
+	r.mu.Unlock()
+
+	// The request context ends with the response - keep its values, drop its cancellation
+	refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.timeout)
+	go func() {
+		defer cancel()
+		defer func() {
+			r.mu.Lock()
+			delete(r.refreshing, shortCode)
+			r.mu.Unlock()
+		}()
+
+		if err := r.refresh(refreshCtx, shortCode); err != nil {
+			fmt.Printf("Warning: failed to refresh stale cached URL: %v\n", err)
+		}
+	}()
+}
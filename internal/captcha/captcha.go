@@ -0,0 +1,158 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned when the provider rejects a token
+// (solved wrong, expired, already used, or made for another site)
+var ErrInvalidToken = errors.New("captcha verification failed")
+
+// ErrMissingToken is returned when no token was sent at all
+var ErrMissingToken = errors.New("captcha token is required")
+
+// Supported providers
+const (
+	ProviderHCaptcha  = "hcaptcha"
+	ProviderTurnstile = "turnstile"
+)
+
+// Widget is what a page needs to show the CAPTCHA
+//
+//	<script src="{{.ScriptURL}}" async defer></script>
+//	<div class="{{.Class}}" data-sitekey="{{.SiteKey}}"></div>
+//
+// The widget adds a hidden form field named ResponseField holding the token
+type Widget struct {
+	ScriptURL     string
+	Class         string
+	SiteKey       string
+	ResponseField string
+}
+
+// provider is where a CAPTCHA service lives
+// hCaptcha and Turnstile speak the SAME siteverify protocol, so only the
+// URLs and names differ
+type provider struct {
+	verifyURL     string
+	scriptURL     string
+	class         string
+	responseField string
+}
+
+var providers = map[string]provider{
+	ProviderHCaptcha: {
+		verifyURL:     "https://api.hcaptcha.com/siteverify",
+		scriptURL:     "https://js.hcaptcha.com/1/api.js",
+		class:         "h-captcha",
+		responseField: "h-captcha-response",
+	},
+	ProviderTurnstile: {
+		verifyURL:     "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+		scriptURL:     "https://challenges.cloudflare.com/turnstile/v0/api.js",
+		class:         "cf-turnstile",
+		responseField: "cf-turnstile-response",
+	},
+}
+
+// Verifier checks CAPTCHA tokens server-side
+//
+// WHY SERVER-SIDE?
+// The widget only proves something to the BROWSER. A bot can skip the
+// widget and post any string, so the token has to be sent to the provider
+// (with our secret key) before we trust it.
+type Verifier struct {
+	provider provider
+	siteKey  string
+	secret   string
+	client   *http.Client
+}
+
+// New creates a verifier for "hcaptcha" or "turnstile"
+func New(name, siteKey, secret string, timeout time.Duration) (*Verifier, error) {
+	p, ok := providers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown captcha provider %q (use %q or %q)", name, ProviderHCaptcha, ProviderTurnstile)
+	}
+	if siteKey == "" || secret == "" {
+		return nil, fmt.Errorf("captcha provider %q needs a site key and a secret key", name)
+	}
+	return &Verifier{
+		provider: p,
+		siteKey:  siteKey,
+		secret:   secret,
+		client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Widget returns what a page needs to show the CAPTCHA
+func (v *Verifier) Widget() Widget {
+	return Widget{
+		ScriptURL:     v.provider.scriptURL,
+		Class:         v.provider.class,
+		SiteKey:       v.siteKey,
+		ResponseField: v.provider.responseField,
+	}
+}
+
+// ResponseField is the form field (and the field API clients send) holding the token
+func (v *Verifier) ResponseField() string {
+	return v.provider.responseField
+}
+
+// verifyResponse is the siteverify answer (both providers)
+type verifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Verify asks the provider whether token is a freshly solved CAPTCHA
+// remoteIP is optional; providers use it as one more signal
+//
+// Returns ErrMissingToken or ErrInvalidToken for bad tokens, any other
+// error means the provider couldn't be asked
+func (v *Verifier) Verify(ctx context.Context, token, remoteIP string) error {
+	if token == "" {
+		return ErrMissingToken
+	}
+
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.provider.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build captcha request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("captcha provider unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha provider returned status %d", resp.StatusCode)
+	}
+
+	var result verifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode captcha response: %w", err)
+	}
+	if !result.Success {
+		if len(result.ErrorCodes) > 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidToken, strings.Join(result.ErrorCodes, ", "))
+		}
+		return ErrInvalidToken
+	}
+	return nil
+}
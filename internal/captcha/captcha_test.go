@@ -0,0 +1,91 @@
+package captcha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// siteverify answers like hCaptcha/Turnstile and records what it was sent
+func siteverify(t *testing.T, status int, body string, sent *url.Values) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		*sent = r.PostForm
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNew(t *testing.T) {
+	hcaptcha, err := New("hcaptcha", "site", "secret", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, Widget{
+		ScriptURL:     "https://js.hcaptcha.com/1/api.js",
+		Class:         "h-captcha",
+		SiteKey:       "site",
+		ResponseField: "h-captcha-response",
+	}, hcaptcha.Widget())
+
+	turnstile, err := New("Turnstile", "site", "secret", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "cf-turnstile-response", turnstile.ResponseField())
+
+	_, err = New("recaptcha", "site", "secret", time.Second)
+	assert.Error(t, err)
+
+	_, err = New("hcaptcha", "", "secret", time.Second)
+	assert.Error(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		status  int
+		body    string
+		wantErr error
+		failed  bool // Error other than the sentinel ones
+	}{
+		{name: "solved", token: "tok", status: http.StatusOK, body: `{"success":true}`},
+		{name: "rejected", token: "tok", status: http.StatusOK, body: `{"success":false,"error-codes":["invalid-input-response"]}`, wantErr: ErrInvalidToken},
+		{name: "missing token", token: "", wantErr: ErrMissingToken},
+		{name: "provider down", token: "tok", status: http.StatusBadGateway, failed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var sent url.Values
+			server := siteverify(t, tt.status, tt.body, &sent)
+			verifier, err := New("turnstile", "site", "secret", time.Second)
+			require.NoError(t, err)
+			verifier.provider.verifyURL = server.URL
+
+			// Act
+			err = verifier.Verify(context.Background(), tt.token, "203.0.113.7")
+
+			// Assert
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.failed:
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, ErrInvalidToken)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, "secret", sent.Get("secret"))
+				assert.Equal(t, "tok", sent.Get("response"))
+				assert.Equal(t, "203.0.113.7", sent.Get("remoteip"))
+			}
+		})
+	}
+}
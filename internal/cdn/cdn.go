@@ -0,0 +1,70 @@
+// Package cdn keeps redirects cached at the edge fresh
+//
+// HOW EDGE CACHING WORKS HERE:
+// With CDN_EDGE_TTL set, redirects that are the same for every visitor are
+// sent with "Cache-Control: public, max-age=0, s-maxage=N": browsers ask us
+// again every time, but the CDN answers from its cache for N seconds - the
+// redirect then never reaches our servers, let alone the database.
+//
+// PURGING:
+// An edited or deleted link must not keep redirecting from the edge. The
+// service calls a Purger whenever it drops a link from its own cache, and
+// the CDN forgets the link right away:
+//   - Cloudflare purges by URL (every plan supports it)
+//   - Fastly purges by surrogate key: every cached redirect carries
+//     "Surrogate-Key: link-<code>" (see Keys)
+package cdn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"url-shortener/internal/domain"
+)
+
+var ErrPurgeFailed = errors.New("CDN purge failed")
+
+// Purger removes links from the CDN cache
+type Purger interface {
+	PurgeLinks(ctx context.Context, urls []*domain.URL) error
+}
+
+// Keys returns the surrogate keys of a link: one per code it is served under
+// (short code and custom alias)
+func Keys(url *domain.URL) []string {
+	var keys []string
+	for _, code := range codes(url) {
+		keys = append(keys, "link-"+code)
+	}
+	return keys
+}
+
+// codes returns the codes a link is served under
+func codes(url *domain.URL) []string {
+	if url.CustomAlias != nil && *url.CustomAlias != url.ShortCode {
+		return []string{url.ShortCode, *url.CustomAlias}
+	}
+	return []string{url.ShortCode}
+}
+
+// batches splits items into chunks of at most size (APIs limit purge requests)
+func batches(items []string, size int) [][]string {
+	var chunks [][]string
+	for len(items) > size {
+		chunks = append(chunks, items[:size])
+		items = items[size:]
+	}
+	if len(items) > 0 {
+		chunks = append(chunks, items)
+	}
+	return chunks
+}
+
+// checkStatus turns a non-2xx API answer into ErrPurgeFailed
+func checkStatus(provider string, status int) error {
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("%w: %s returned status %d", ErrPurgeFailed, provider, status)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// purgeAPI records the purge requests sent to it
+type purgeAPI struct {
+	requests []*http.Request
+	bodies   []map[string][]string
+	status   int
+}
+
+func (a *purgeAPI) start(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string][]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		a.requests = append(a.requests, r)
+		a.bodies = append(a.bodies, body)
+		w.WriteHeader(a.status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestKeys(t *testing.T) {
+	alias := "spring-sale"
+	same := "abc123"
+
+	assert.Equal(t, []string{"link-abc123"}, Keys(&domain.URL{ShortCode: "abc123"}))
+	assert.Equal(t, []string{"link-abc123", "link-spring-sale"}, Keys(&domain.URL{ShortCode: "abc123", CustomAlias: &alias}))
+	assert.Equal(t, []string{"link-abc123"}, Keys(&domain.URL{ShortCode: "abc123", CustomAlias: &same}))
+}
+
+func TestCloudflare_PurgeLinks(t *testing.T) {
+	// Arrange
+	api := &purgeAPI{status: http.StatusOK}
+	server := api.start(t)
+	cloudflare := NewCloudflare("zone1", "token", []string{"sho.rt"}, time.Second)
+	cloudflare.apiURL = server.URL
+
+	urls := []*domain.URL{
+		{ShortCode: "abc123"},
+		{ShortCode: "xyz789", Domain: "go.example.com"},
+	}
+
+	// Act
+	err := cloudflare.PurgeLinks(context.Background(), urls)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, api.requests, 1)
+	assert.Equal(t, "/zones/zone1/purge_cache", api.requests[0].URL.Path)
+	assert.Equal(t, "Bearer token", api.requests[0].Header.Get("Authorization"))
+	assert.Equal(t, []string{
+		"https://sho.rt/abc123", "http://sho.rt/abc123",
+		"https://go.example.com/xyz789", "http://go.example.com/xyz789",
+	}, api.bodies[0]["files"])
+}
+
+func TestCloudflare_PurgeLinks_Batches(t *testing.T) {
+	// Arrange: 20 links on one host = 40 URLs, more than one request may list
+	api := &purgeAPI{status: http.StatusOK}
+	server := api.start(t)
+	cloudflare := NewCloudflare("zone1", "token", []string{"sho.rt"}, time.Second)
+	cloudflare.apiURL = server.URL
+
+	var urls []*domain.URL
+	for i := range 20 {
+		urls = append(urls, &domain.URL{ShortCode: fmt.Sprintf("code%d", i)})
+	}
+
+	// Act
+	err := cloudflare.PurgeLinks(context.Background(), urls)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, api.bodies, 2)
+	assert.Len(t, api.bodies[0]["files"], cloudflareMaxFiles)
+	assert.Len(t, api.bodies[1]["files"], 40-cloudflareMaxFiles)
+}
+
+func TestFastly_PurgeLinks(t *testing.T) {
+	// Arrange
+	api := &purgeAPI{status: http.StatusOK}
+	server := api.start(t)
+	fastly := NewFastly("svc1", "key", time.Second)
+	fastly.apiURL = server.URL
+	alias := "spring-sale"
+
+	// Act
+	err := fastly.PurgeLinks(context.Background(), []*domain.URL{{ShortCode: "abc123", CustomAlias: &alias}})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, api.requests, 1)
+	assert.Equal(t, "/service/svc1/purge", api.requests[0].URL.Path)
+	assert.Equal(t, "key", api.requests[0].Header.Get("Fastly-Key"))
+	assert.Equal(t, []string{"link-abc123", "link-spring-sale"}, api.bodies[0]["surrogate_keys"])
+}
+
+func TestPurgeLinks_APIError(t *testing.T) {
+	// Arrange
+	api := &purgeAPI{status: http.StatusForbidden}
+	server := api.start(t)
+	cloudflare := NewCloudflare("zone1", "bad-token", []string{"sho.rt"}, time.Second)
+	cloudflare.apiURL = server.URL
+	fastly := NewFastly("svc1", "bad-key", time.Second)
+	fastly.apiURL = server.URL
+	urls := []*domain.URL{{ShortCode: "abc123"}}
+
+	// Act
+	cloudflareErr := cloudflare.PurgeLinks(context.Background(), urls)
+	fastlyErr := fastly.PurgeLinks(context.Background(), urls)
+
+	// Assert
+	assert.ErrorIs(t, cloudflareErr, ErrPurgeFailed)
+	assert.ErrorIs(t, fastlyErr, ErrPurgeFailed)
+}
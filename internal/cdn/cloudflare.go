@@ -0,0 +1,83 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"url-shortener/internal/domain"
+)
+
+// cloudflareMaxFiles is how many URLs one purge request may list
+const cloudflareMaxFiles = 30
+
+// Cloudflare purges cached redirects by URL through the Cloudflare API
+type Cloudflare struct {
+	apiURL string
+	zoneID string
+	token  string
+	hosts  []string // Public hosts serving links without their own domain
+	client *http.Client
+}
+
+// NewCloudflare creates a Cloudflare purger for one zone
+// hosts are the public hosts (e.g. "sho.rt") links are served on; links
+// with a custom domain are purged on that domain only
+func NewCloudflare(zoneID, token string, hosts []string, timeout time.Duration) *Cloudflare {
+	return &Cloudflare{
+		apiURL: "https://api.cloudflare.com/client/v4",
+		zoneID: zoneID,
+		token:  token,
+		hosts:  hosts,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// PurgeLinks purges every URL the links are reachable at (http and https)
+func (c *Cloudflare) PurgeLinks(ctx context.Context, urls []*domain.URL) error {
+	var files []string
+	for _, url := range urls {
+		hosts := c.hosts
+		if url.Domain != "" {
+			hosts = []string{url.Domain}
+		}
+		for _, host := range hosts {
+			for _, code := range codes(url) {
+				files = append(files, "https://"+host+"/"+code, "http://"+host+"/"+code)
+			}
+		}
+	}
+
+	for _, batch := range batches(files, cloudflareMaxFiles) {
+		if err := c.purge(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purge sends one purge_cache request
+func (c *Cloudflare) purge(ctx context.Context, files []string) error {
+	body, err := json.Marshal(map[string][]string{"files": files})
+	if err != nil {
+		return fmt.Errorf("failed to marshal purge request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/zones/%s/purge_cache", c.apiURL, c.zoneID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create purge request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPurgeFailed, err)
+	}
+	defer resp.Body.Close()
+	return checkStatus("Cloudflare", resp.StatusCode)
+}
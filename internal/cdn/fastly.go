@@ -0,0 +1,72 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"url-shortener/internal/domain"
+)
+
+// fastlyMaxKeys is how many surrogate keys one batch purge may list
+const fastlyMaxKeys = 256
+
+// Fastly purges cached redirects by surrogate key through the Fastly API
+type Fastly struct {
+	apiURL    string
+	serviceID string
+	apiKey    string
+	client    *http.Client
+}
+
+// NewFastly creates a Fastly purger for one service
+func NewFastly(serviceID, apiKey string, timeout time.Duration) *Fastly {
+	return &Fastly{
+		apiURL:    "https://api.fastly.com",
+		serviceID: serviceID,
+		apiKey:    apiKey,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+// PurgeLinks purges the surrogate keys of the links (see Keys)
+// One key covers the link on every host and scheme
+func (f *Fastly) PurgeLinks(ctx context.Context, urls []*domain.URL) error {
+	var keys []string
+	for _, url := range urls {
+		keys = append(keys, Keys(url)...)
+	}
+
+	for _, batch := range batches(keys, fastlyMaxKeys) {
+		if err := f.purge(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purge sends one batch purge request
+func (f *Fastly) purge(ctx context.Context, keys []string) error {
+	body, err := json.Marshal(map[string][]string{"surrogate_keys": keys})
+	if err != nil {
+		return fmt.Errorf("failed to marshal purge request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/service/%s/purge", f.apiURL, f.serviceID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create purge request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Fastly-Key", f.apiKey)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPurgeFailed, err)
+	}
+	defer resp.Body.Close()
+	return checkStatus("Fastly", resp.StatusCode)
+}
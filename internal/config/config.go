@@ -2,9 +2,14 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+
+	"url-shortener/internal/crypto/keys"
+	"url-shortener/internal/shortcode"
 )
 
 // Config holds all application configuration
@@ -14,14 +19,49 @@ type Config struct {
 	Database DatabaseConfig
 	Redis    RedisConfig
 	App      AppConfig
+	Notify   NotifyConfig
+	Billing  BillingConfig
+	Pages    ErrorPagesConfig
+	Faults   FaultConfig
+	Region   RegionConfig
+	CDN      CDNConfig
+	ETL      ETLConfig
+	Files    FilesConfig
+	Abuse    AbuseConfig
+	Captcha  CaptchaConfig
+	TLS      TLSConfig
+	Errors   ErrorReportConfig
+
+	// Key manager for secret material (see internal/crypto/keys): encrypted
+	// destinations, kv1:... secrets in this configuration, the API key pepper
+	KMS keys.Config
 }
 
 // ServerConfig holds HTTP server settings
 type ServerConfig struct {
 	Port         string
+	AdminPort    string // Separate listener for operational endpoints ("" = serve them on Port)
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+
+	// Request deadlines per kind of route (0 = none), see handler.Timeouts
+	RedirectTimeout    time.Duration // Redirects, link pages and the UI
+	APITimeout         time.Duration // Every other route
+	LongRequestTimeout time.Duration // Exports, snapshots, imports and uploads
+
+	// How long a redirect may wait on each dependency in total (0 = up to
+	// the deadline), see resilience.Budget
+	RedirectCacheBudget    time.Duration
+	RedirectDatabaseBudget time.Duration
+
+	// API v1 lifecycle (zero time = not announced)
+	APIV1DeprecatedAt time.Time
+	APIV1Sunset       time.Time
+
+	// Reverse proxies and load balancers whose X-Forwarded-For is believed
+	// (none = the connection's address is the client), see handler.ClientIPMiddleware
+	TrustedProxies []netip.Prefix
 }
 
 // DatabaseConfig holds PostgreSQL connection settings
@@ -35,26 +75,304 @@ type DatabaseConfig struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+
+	// Retry and timeout policy (see internal/resilience)
+	ReadTimeout    time.Duration // Per-attempt timeout for queries
+	WriteTimeout   time.Duration // Per-attempt timeout for inserts/updates/deletes
+	MaxRetries     int           // Retries after the first attempt (0 = disabled)
+	RetryBaseDelay time.Duration // First backoff delay (doubles per retry, with jitter)
+	RetryMaxDelay  time.Duration // Longest single backoff delay
+
+	// Circuit breaker for redirect lookups
+	BreakerFailures    int           // Consecutive failures that open the breaker (0 = disabled)
+	BreakerOpenTimeout time.Duration // How long the breaker stays open before a trial call
+
+	// Sharding (see internal/shard): extra databases for links and clicks
+	Shards        map[string]string // Shard name -> DSN (empty = everything on the main database)
+	ShardPrefixes map[string]string // Shard name -> first characters of the codes it stores
 }
 
-// RedisConfig holds Redis connection settings
+// RedisConfig holds Redis connection settings and the URL cache settings
+// Redis is always required (rate limiting); the cache can move to Memcached
 type RedisConfig struct {
 	Host     string
 	Port     string
 	Password string
 	DB       int
-	CacheTTL time.Duration
+	CacheTTL time.Duration // Soft TTL: entries are fresh for this long
+	StaleTTL time.Duration // Extra time stale entries are served while refreshed (0 = disabled)
+	Codec    string        // Format for new cache entries: "binary" or "json"
+
+	// Cache backend: "redis" (default) or "memcached"
+	CacheDriver      string
+	MemcachedServers []string // host:port list, used when CacheDriver is "memcached"
+
+	// Retry and timeout policy - keep it tight, the database is the fallback
+	OpTimeout  time.Duration // Per-attempt timeout for cache operations
+	MaxRetries int           // Retries after the first attempt (0 = disabled)
+}
+
+// NotifyConfig holds settings for owner notifications (webhook and email)
+// A channel is enabled only when its destination is configured
+type NotifyConfig struct {
+	WebhookURL     string
+	WebhookSecret  string
+	WebhookTimeout time.Duration
+	SMTPHost       string
+	SMTPPort       string
+	SMTPUser       string
+	SMTPPassword   string
+	EmailFrom      string
+	EmailTo        []string
+
+	// Link warnings (click limit / expiration)
+	WarningInterval time.Duration // How often the evaluator runs
+	ExpiryWarning   time.Duration // How long before expiration to warn
+	LinkEvents      bool          // Also send url.created / url.deleted / url.expired events
+
+	// Click spike alerts (see domain.SpikeDetector)
+	AnomalyInterval  time.Duration // How often click rates are checked (0 = off)
+	AnomalyThreshold float64       // Standard deviations above the usual hourly clicks
+	AnomalyMinClicks int64         // Hours with fewer clicks are never spikes
+}
+
+// AbuseConfig holds the redirect path abuse guard settings (see internal/abuse)
+// All limits count requests per Window; 0 turns a limit off
+type AbuseConfig struct {
+	Enabled        bool
+	Window         time.Duration
+	ChallengeLimit int64           // Requests per IP that make it suspicious (CAPTCHA for browsers)
+	BanLimit       int64           // Requests per IP that get it banned
+	CodeLimit      int64           // Requests per short code that make all its visitors suspicious
+	BanDurations   []time.Duration // Ban length of the 1st, 2nd, ... ban of an IP
+	StrikeMemory   time.Duration   // How long past bans make the next one longer
+	PassTTL        time.Duration   // How long a solved CAPTCHA lets a browser through
+}
+
+// TLSConfig holds automatic HTTPS settings (see service.CertificateService)
+// When enabled, an HTTPS listener on Port gets certificates from the ACME CA
+// for TRUSTED_DOMAINS and verified custom domains. SERVER_PORT must be
+// reachable on port 80 of those hosts for the HTTP-01 challenge.
+type TLSConfig struct {
+	Autocert     bool
+	Port         string        // HTTPS listener
+	Email        string        // Contact for the CA account (expiry notices)
+	DirectoryURL string        // ACME directory ("" = Let's Encrypt production)
+	RenewBefore  time.Duration // How long before expiry certificates are renewed
+}
+
+// ErrorReportConfig holds the error tracker (see internal/errreport)
+// Panics and 5xx responses are reported only when SentryDSN is set
+type ErrorReportConfig struct {
+	SentryDSN   string
+	Release     string  // "" = the git revision the binary was built from
+	Environment string  // Defaults to APP_ENV
+	SampleRate  float64 // Share of 5xx responses reported (panics always are)
+	Timeout     time.Duration
+}
+
+// CaptchaConfig holds the CAPTCHA provider (see internal/captcha)
+// CAPTCHAs are enabled only when the provider and both keys are set
+type CaptchaConfig struct {
+	Provider string // "hcaptcha" or "turnstile"
+	SiteKey  string // Public key, embedded in pages
+	Secret   string // Private key, sent to the provider when verifying
+	Timeout  time.Duration
+}
+
+// Enabled reports whether a CAPTCHA provider is configured
+func (c *CaptchaConfig) Enabled() bool {
+	return c.Provider != "" && c.SiteKey != "" && c.Secret != ""
+}
+
+// BillingConfig holds Stripe settings (see internal/billing)
+// Billing is enabled only when both the API key and the webhook secret are set
+type BillingConfig struct {
+	StripeSecretKey     string            // API key (sk_...) used for portal sessions
+	StripeWebhookSecret string            // Webhook signing secret (whsec_...)
+	ProductPlans        map[string]string // Stripe product ID -> plan name ("pro")
+	PortalReturnURL     string            // Where the customer portal links back to
+	APITimeout          time.Duration     // Timeout for calls to the Stripe API
+}
+
+// Enabled reports whether Stripe billing is configured
+func (c BillingConfig) Enabled() bool {
+	return c.StripeSecretKey != "" && c.StripeWebhookSecret != ""
+}
+
+// ErrorPagesConfig controls what BROWSERS see for unknown or dead links
+// API clients (no text/html in Accept) keep getting JSON errors.
+// Custom domains can have their own brand, homepage and redirect behavior;
+// anything not set for a domain falls back to the global values.
+type ErrorPagesConfig struct {
+	Brand           string            // Name shown on the page
+	HomeURL         string            // Target of the "go to homepage" link
+	Redirect        bool              // Send browsers to HomeURL instead of showing the page
+	DomainBrands    map[string]string // Custom domain -> brand
+	DomainHomes     map[string]string // Custom domain -> homepage
+	RedirectDomains []string          // Custom domains that redirect to their homepage
+}
+
+// FaultConfig holds chaos-testing settings (see internal/faults)
+// Injection is ignored when APP_ENV=production
+type FaultConfig struct {
+	Enabled        bool
+	DBErrorRate    float64       // Share of PostgreSQL calls that fail (0-1)
+	DBLatency      time.Duration // Delay added to every PostgreSQL call
+	RedisErrorRate float64       // Share of Redis calls that fail (0-1)
+	RedisLatency   time.Duration // Delay added to every Redis call
+}
+
+// RegionConfig holds multi-region settings (see internal/region)
+// Leave REGION empty for a single-region deployment. In a secondary region
+// DB_* point at the local read replica, PRIMARY_DATABASE_DSN at the
+// writable database and PRIMARY_REGION_URL at the primary region's API.
+type RegionConfig struct {
+	Name               string // This region, added to metrics and click events
+	Primary            string // Region that owns the writable database (default: this one)
+	PrimaryURL         string // Public base URL of the primary region (API writes are forwarded there)
+	PrimaryDatabaseDSN string // Writable database for click counters and events
+}
+
+// CDNConfig holds edge caching settings (see internal/cdn)
+// Redirects are only marked cacheable when EdgeTTL > 0; set a provider so
+// edited and deleted links are purged from the edge right away
+type CDNConfig struct {
+	EdgeTTL      time.Duration // s-maxage of cacheable redirects (0 = no edge caching)
+	Provider     string        // "cloudflare", "fastly" or "" (no purging)
+	Hosts        []string      // Public hosts links are served on (Cloudflare purges by URL)
+	PurgeTimeout time.Duration // Timeout for one purge API call
+
+	CloudflareZoneID   string
+	CloudflareAPIToken string
+	FastlyServiceID    string
+	FastlyAPIKey       string
+}
+
+// ETLConfig holds data warehouse export settings (see internal/warehouse)
+// The export runs when Bucket is set. For GCS use
+// ETL_ENDPOINT=https://storage.googleapis.com with an HMAC key.
+type ETLConfig struct {
+	Bucket      string
+	Endpoint    string        // S3-compatible endpoint (default: AWS S3 in Region)
+	Region      string        // Signing region ("auto" for GCS)
+	Prefix      string        // Key prefix of all files
+	Format      string        // "ndjson"
+	Compression string        // "gzip" or "none"
+	Interval    time.Duration // How often new clicks are exported
+	BatchSize   int           // Clicks read per query
+	Lag         time.Duration // Only clicks older than this are exported
+	Timeout     time.Duration // Timeout for one upload
+
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// FilesConfig holds file link settings (see internal/storage/blob)
+// File uploads are enabled when Bucket is set. Any S3-compatible store
+// works: FILES_ENDPOINT=https://<account>.r2.cloudflarestorage.com for R2,
+// http://localhost:9000 for a local MinIO.
+type FilesConfig struct {
+	Bucket    string
+	Endpoint  string        // S3-compatible endpoint (default: AWS S3 in Region)
+	Region    string        // Signing region ("auto" for R2 and GCS)
+	MaxSizeMB int           // Largest accepted upload
+	Timeout   time.Duration // Timeout for storing or deleting one file
+
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// Enabled reports whether file uploads are configured
+func (c FilesConfig) Enabled() bool {
+	return c.Bucket != ""
+}
+
+// MaxSize returns the upload limit in bytes
+func (c FilesConfig) MaxSize() int64 {
+	return int64(c.MaxSizeMB) << 20
 }
 
 // AppConfig holds application-specific settings
 type AppConfig struct {
-	Environment        string
-	LogLevel           string
-	ShortCodeLength    int
-	RateLimitEnabled   bool
-	RateLimitPerMinute int
-	EnableAnalytics    bool
-	EnableMetrics      bool
+	Environment         string
+	LogLevel            string
+	ShortCodeLength     int
+	ShortCodeAlphabet   string
+	ShortCodeCase       string         // "sensitive" (default) or "insensitive": codes and aliases stored lowercase, found in any case
+	ShortCodeDomains    map[string]int // Per-domain code length (host -> length)
+	ShortCodeBatchSize  int            // Generated codes checked per query (0 or 1 = one query per link)
+	RateLimitEnabled    bool
+	RateLimitPerMinute  int
+	AliasCheckPerMinute int // Stricter limit for alias availability checks (prevents enumeration)
+	EnableAnalytics     bool
+	EnableMetrics       bool
+	EnableProfiling     bool           // Serve pprof/expvar under /debug/ on the admin port
+	AdminAPIKey         string         // Bearer token for admin-only endpoints (empty = disabled)
+	SAMLSessionTTL      time.Duration  // How long a SAML single sign-on session token is valid
+	APIKeyRotationGrace time.Duration  // How long a rotated API key's old secret keeps working by default
+	APIKeyExpiryWarning time.Duration  // Requests with a key expiring within it are logged and counted
+	ErasureInterval     time.Duration  // How often pending account deletions are processed
+	SlackEnabled        bool           // Serve the /shorten slash command at /integrations/slack
+	ClickDedupWindow    time.Duration  // Repeat clicks (same IP, User-Agent and link) within it count once (0 = off)
+	ClickStripes        int            // Click counter rows per link (1 = count on the link row; more for viral links)
+	ClickFoldInterval   time.Duration  // How often striped click counters are folded into the links
+	HonorDoNotTrack     bool           // Clicks with DNT: 1 or Sec-GPC: 1 are stored without IP, User-Agent and referrer
+	ClickIngestTTL      time.Duration  // How long IDs of clicks reported by edge workers are remembered (idempotency)
+	EdgeTombstoneTTL    time.Duration  // How long deleted links stay in edge snapshot deltas (older versions need a full snapshot)
+	InternalHosts       []string       // Our own hosts: clicks referred from them count as "internal"
+	ScheduleTimezone    *time.Location // Timezone of link schedules that don't set their own
+	AnalyticsTimezone   *time.Location // Timezone of click timeseries without ?tz= or a workspace default
+	LeaderboardCacheTTL time.Duration  // How long top links leaderboards are cached in Redis (0 = off)
+	ArchiveAfter        time.Duration  // Links unused for this long move to the archive tier (0 = off)
+	ArchiveInterval     time.Duration  // How often cold links are archived
+	FeatureFlagRefresh  time.Duration  // How often feature flags are reloaded (changes made here apply at once)
+	DomainPolicyRefresh time.Duration  // How often destination domain rules are reloaded (changes made here apply at once)
+	ConfusableAliases   string         // New aliases that look like popular or reserved ones: "reject" (default), "flag" or "off"
+	ConfusableTopN      int            // How many of the most clicked aliases are protected from imitation
+	ConfusableRefresh   time.Duration  // How often the most clicked aliases are reloaded
+	PolicySweepInterval time.Duration  // How often the sweeper checks for rule changes to apply to existing links
+	DomainRefresh       time.Duration  // How often custom domains are reloaded (changes made here apply at once)
+	DomainRecheck       time.Duration  // How often verified custom domains have their TXT record checked again
+	TrustedDomains      []string       // Our own hosts: links may use them without a verification
+	JobWorkers          int            // Background job workers per instance (0 = this instance runs no jobs)
+	JobPollInterval     time.Duration  // How often idle job workers look for new jobs
+	JobMaxAttempts      int            // Runs of a failing job before it is marked failed
+	JobRetention        time.Duration  // Finished jobs are deleted (by the janitor task) after this long
+	SchedulerJitter     time.Duration  // Random delay added to each scheduled task run (spreads tasks due at once)
+	DigestSchedule      string         // Cron spec of the weekly click digest (empty = off)
+	LeaderCheckInterval time.Duration  // How often leadership of the singleton workers is checked (failover time)
+	StartupTimeout      time.Duration  // How long dependencies are retried at startup before giving up (0 = try once)
+	StartupPartial      bool           // Serve health endpoints while the dependencies are still connecting
+
+	// Burn-after-reading links: 32-byte AES key (hex or base64) their secrets
+	// are encrypted with. Empty disables them; changing it makes the secrets
+	// of existing links unreadable.
+	PayloadEncryptionKey string
+
+	// Peppers API key secrets are hashed with (HMAC-SHA256), plain or as
+	// kv1:... values of the key manager. The first one hashes new secrets;
+	// the others only find keys hashed before it was added. Empty = plain
+	// SHA-256.
+	APIKeyPeppers []string
+
+	// Destination resolution (link cloaking detection)
+	ResolveDestinations bool          // Follow redirects of destinations at creation time
+	ResolveMaxHops      int           // Maximum redirects to follow
+	ResolveTimeout      time.Duration // Timeout for the whole redirect chain
+	RejectRedirectors   bool          // Reject destinations that redirect through other shorteners
+
+	// Destination metadata (page title, description, favicon)
+	FetchMetadata       bool          // Fetch it in the background after creation
+	MetadataTimeout     time.Duration // Timeout for each request of a fetch
+	MetadataMaxBytes    int           // How much of the page is read
+	MetadataConcurrency int           // Fetches running at the same time
+
+	// Plan quotas (authenticated, non-admin callers)
+	QuotasEnabled    bool
+	QuotaDefaultPlan string // Plan for principals without one: "free" or "pro"
+	QuotaFreeLinks   int64  // Links per month on the free plan
+	QuotaProLinks    int64  // Links per month on the pro plan (0 = unlimited)
 }
 
 // Load reads configuration from environment variables
@@ -64,9 +382,22 @@ func Load() (*Config, error) {
 	cfg := &Config{
 		Server: ServerConfig{
 			Port:         getEnv("SERVER_PORT", "8080"),
+			AdminPort:    parsePort("ADMIN_PORT", "9091"),
 			ReadTimeout:  parseDuration("SERVER_READ_TIMEOUT", "10s"),
 			WriteTimeout: parseDuration("SERVER_WRITE_TIMEOUT", "10s"),
 			IdleTimeout:  parseDuration("SERVER_IDLE_TIMEOUT", "120s"),
+
+			RedirectTimeout:    parseDuration("REDIRECT_TIMEOUT", "3s"),
+			APITimeout:         parseDuration("API_TIMEOUT", "10s"),
+			LongRequestTimeout: parseDuration("LONG_REQUEST_TIMEOUT", "30m"),
+
+			RedirectCacheBudget:    parseDuration("REDIRECT_CACHE_BUDGET", "50ms"),
+			RedirectDatabaseBudget: parseDuration("REDIRECT_DB_BUDGET", "200ms"),
+
+			APIV1DeprecatedAt: parseTime("API_V1_DEPRECATED_AT"),
+			APIV1Sunset:       parseTime("API_V1_SUNSET"),
+
+			TrustedProxies: parsePrefixes("TRUSTED_PROXIES"),
 		},
 		Database: DatabaseConfig{
 			Host:            getEnv("DB_HOST", "localhost"),
@@ -78,6 +409,17 @@ func Load() (*Config, error) {
 			MaxOpenConns:    parseInt("DB_MAX_OPEN_CONNS", 25),
 			MaxIdleConns:    parseInt("DB_MAX_IDLE_CONNS", 5),
 			ConnMaxLifetime: parseDuration("DB_CONN_MAX_LIFETIME", "5m"),
+			ReadTimeout:     parseDuration("DB_READ_TIMEOUT", "2s"),
+			WriteTimeout:    parseDuration("DB_WRITE_TIMEOUT", "5s"),
+			MaxRetries:      parseInt("DB_MAX_RETRIES", 2),
+			RetryBaseDelay:  parseDuration("DB_RETRY_BASE_DELAY", "25ms"),
+			RetryMaxDelay:   parseDuration("DB_RETRY_MAX_DELAY", "500ms"),
+
+			BreakerFailures:    parseInt("DB_BREAKER_FAILURES", 5),
+			BreakerOpenTimeout: parseDuration("DB_BREAKER_OPEN_TIMEOUT", "10s"),
+
+			Shards:        parseStringMap("SHARD_DATABASES"),
+			ShardPrefixes: parseStringMap("SHARD_PREFIXES"),
 		},
 		Redis: RedisConfig{
 			Host:     getEnv("REDIS_HOST", "localhost"),
@@ -85,16 +427,214 @@ func Load() (*Config, error) {
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       parseInt("REDIS_DB", 0),
 			CacheTTL: parseDuration("REDIS_CACHE_TTL", "1h"),
+			StaleTTL: parseDuration("REDIS_CACHE_STALE_TTL", "10m"),
+			Codec:    getEnv("CACHE_CODEC", "binary"),
+
+			CacheDriver:      getEnv("CACHE_DRIVER", "redis"),
+			MemcachedServers: parseList("MEMCACHED_SERVERS"),
+
+			OpTimeout:  parseDuration("REDIS_OP_TIMEOUT", "200ms"),
+			MaxRetries: parseInt("REDIS_MAX_RETRIES", 1),
 		},
 		App: AppConfig{
-			Environment:        getEnv("APP_ENV", "development"),
-			LogLevel:           getEnv("LOG_LEVEL", "info"),
-			ShortCodeLength:    parseInt("SHORT_CODE_LENGTH", 6),
-			RateLimitEnabled:   parseBool("RATE_LIMIT_ENABLED", true),
-			RateLimitPerMinute: parseInt("RATE_LIMIT_REQUESTS_PER_MINUTE", 100),
-			EnableAnalytics:    parseBool("ENABLE_ANALYTICS", true),
-			EnableMetrics:      parseBool("ENABLE_METRICS", true),
+			Environment:         getEnv("APP_ENV", "development"),
+			LogLevel:            getEnv("LOG_LEVEL", "info"),
+			ShortCodeLength:     parseInt("SHORT_CODE_LENGTH", 6),
+			ShortCodeAlphabet:   parseAlphabet("SHORT_CODE_ALPHABET"),
+			ShortCodeCase:       getEnv("SHORT_CODE_CASE", "sensitive"),
+			ShortCodeDomains:    parseIntMap("SHORT_CODE_DOMAIN_LENGTHS"),
+			ShortCodeBatchSize:  parseInt("SHORT_CODE_BATCH_SIZE", 32),
+			RateLimitEnabled:    parseBool("RATE_LIMIT_ENABLED", true),
+			RateLimitPerMinute:  parseInt("RATE_LIMIT_REQUESTS_PER_MINUTE", 100),
+			AliasCheckPerMinute: parseInt("ALIAS_CHECK_REQUESTS_PER_MINUTE", 30),
+			EnableAnalytics:     parseBool("ENABLE_ANALYTICS", true),
+			EnableMetrics:       parseBool("ENABLE_METRICS", true),
+			EnableProfiling:     parseBool("ENABLE_PROFILING", false),
+			AdminAPIKey:         getEnv("ADMIN_API_KEY", ""),
+			SAMLSessionTTL:      parseDuration("SAML_SESSION_TTL", "8h"),
+			APIKeyRotationGrace: parseDuration("API_KEY_ROTATION_GRACE", "24h"),
+			APIKeyExpiryWarning: parseDuration("API_KEY_EXPIRY_WARNING", "168h"),
+			ErasureInterval:     parseDuration("ERASURE_INTERVAL", "30s"),
+			SlackEnabled:        parseBool("SLACK_INTEGRATION_ENABLED", false),
+			ClickDedupWindow:    parseDuration("CLICK_DEDUP_WINDOW", "0s"),
+			ClickStripes:        parseInt("CLICK_COUNTER_STRIPES", 1),
+			ClickFoldInterval:   parseDuration("CLICK_FOLD_INTERVAL", "10s"),
+			HonorDoNotTrack:     parseBool("HONOR_DO_NOT_TRACK", true),
+			ClickIngestTTL:      parseDuration("CLICK_INGEST_IDEMPOTENCY_TTL", "24h"),
+			EdgeTombstoneTTL:    parseDuration("EDGE_TOMBSTONE_RETENTION", "168h"),
+			InternalHosts:       parseList("REFERRER_INTERNAL_HOSTS"),
+			ScheduleTimezone:    parseLocation("SCHEDULE_TIMEZONE"),
+			AnalyticsTimezone:   parseIANALocation("ANALYTICS_TIMEZONE"),
+			LeaderboardCacheTTL: parseDuration("LEADERBOARD_CACHE_TTL", "1m"),
+			ArchiveAfter:        time.Duration(parseInt("ARCHIVE_AFTER_MONTHS", 0)) * 30 * 24 * time.Hour,
+			ArchiveInterval:     parseDuration("ARCHIVE_INTERVAL", "1h"),
+			FeatureFlagRefresh:  parseDuration("FEATURE_FLAG_REFRESH_INTERVAL", "30s"),
+			DomainPolicyRefresh: parseDuration("DOMAIN_POLICY_REFRESH_INTERVAL", "30s"),
+			ConfusableAliases:   getEnv("CONFUSABLE_ALIASES", "reject"),
+			ConfusableTopN:      parseInt("CONFUSABLE_ALIAS_TOP_N", 1000),
+			ConfusableRefresh:   parseDuration("CONFUSABLE_ALIAS_REFRESH_INTERVAL", "5m"),
+			PolicySweepInterval: parseDuration("POLICY_SWEEP_INTERVAL", "1m"),
+			DomainRefresh:       parseDuration("CUSTOM_DOMAIN_REFRESH_INTERVAL", "30s"),
+			DomainRecheck:       parseDuration("CUSTOM_DOMAIN_RECHECK_INTERVAL", "24h"),
+			TrustedDomains:      parseList("TRUSTED_DOMAINS"),
+			JobWorkers:          parseInt("JOB_WORKERS", 4),
+			JobPollInterval:     parseDuration("JOB_POLL_INTERVAL", "1s"),
+			JobMaxAttempts:      parseInt("JOB_MAX_ATTEMPTS", 3),
+			JobRetention:        parseDuration("JOB_RETENTION", "168h"),
+			SchedulerJitter:     parseDuration("SCHEDULER_JITTER", "30s"),
+			DigestSchedule:      getEnv("DIGEST_SCHEDULE", "0 8 * * 1"),
+			LeaderCheckInterval: parseDuration("LEADER_CHECK_INTERVAL", "10s"),
+			StartupTimeout:      parseDuration("STARTUP_TIMEOUT", "60s"),
+			StartupPartial:      parseBool("STARTUP_PARTIAL", true),
+
+			PayloadEncryptionKey: getEnv("PAYLOAD_ENCRYPTION_KEY", ""),
+			APIKeyPeppers:        parseList("API_KEY_PEPPER"),
+
+			ResolveDestinations: parseBool("RESOLVE_DESTINATIONS", false),
+			ResolveMaxHops:      parseInt("RESOLVE_MAX_HOPS", 5),
+			ResolveTimeout:      parseDuration("RESOLVE_TIMEOUT", "3s"),
+			RejectRedirectors:   parseBool("REJECT_REDIRECTORS", false),
+
+			FetchMetadata:       parseBool("FETCH_METADATA", false),
+			MetadataTimeout:     parseDuration("METADATA_TIMEOUT", "5s"),
+			MetadataMaxBytes:    parseInt("METADATA_MAX_BYTES", 512*1024),
+			MetadataConcurrency: parseInt("METADATA_CONCURRENCY", 4),
+
+			QuotasEnabled:    parseBool("QUOTAS_ENABLED", true),
+			QuotaDefaultPlan: getEnv("QUOTA_DEFAULT_PLAN", "free"),
+			QuotaFreeLinks:   int64(parseInt("QUOTA_FREE_LINKS_PER_MONTH", 100)),
+			QuotaProLinks:    int64(parseInt("QUOTA_PRO_LINKS_PER_MONTH", 0)),
+		},
+		Notify: NotifyConfig{
+			WebhookURL:      getEnv("NOTIFY_WEBHOOK_URL", ""),
+			WebhookSecret:   getEnv("NOTIFY_WEBHOOK_SECRET", ""),
+			WebhookTimeout:  parseDuration("NOTIFY_WEBHOOK_TIMEOUT", "5s"),
+			SMTPHost:        getEnv("SMTP_HOST", ""),
+			SMTPPort:        getEnv("SMTP_PORT", "587"),
+			SMTPUser:        getEnv("SMTP_USER", ""),
+			SMTPPassword:    getEnv("SMTP_PASSWORD", ""),
+			EmailFrom:       getEnv("NOTIFY_EMAIL_FROM", "noreply@localhost"),
+			EmailTo:         parseList("NOTIFY_EMAIL_TO"),
+			WarningInterval: parseDuration("LINK_WARNING_INTERVAL", "5m"),
+			ExpiryWarning:   time.Duration(parseInt("LINK_EXPIRY_WARNING_DAYS", 3)) * 24 * time.Hour,
+			LinkEvents:      parseBool("NOTIFY_LINK_EVENTS", false),
+
+			AnomalyInterval:  parseDuration("ANOMALY_CHECK_INTERVAL", "5m"),
+			AnomalyThreshold: parseFloat("ANOMALY_THRESHOLD", 4),
+			AnomalyMinClicks: int64(parseInt("ANOMALY_MIN_CLICKS", 50)),
+		},
+		Billing: BillingConfig{
+			StripeSecretKey:     getEnv("STRIPE_SECRET_KEY", ""),
+			StripeWebhookSecret: getEnv("STRIPE_WEBHOOK_SECRET", ""),
+			ProductPlans:        parseStringMap("STRIPE_PRODUCT_PLANS"),
+			PortalReturnURL:     getEnv("STRIPE_PORTAL_RETURN_URL", ""),
+			APITimeout:          parseDuration("STRIPE_API_TIMEOUT", "10s"),
 		},
+		Pages: ErrorPagesConfig{
+			Brand:           getEnv("ERROR_PAGE_BRAND", "URL Shortener"),
+			HomeURL:         getEnv("ERROR_PAGE_HOME_URL", "/"),
+			Redirect:        parseBool("ERROR_PAGE_REDIRECT", false),
+			DomainBrands:    parseStringMap("ERROR_PAGE_DOMAIN_BRANDS"),
+			DomainHomes:     parseStringMap("ERROR_PAGE_DOMAIN_HOMES"),
+			RedirectDomains: parseList("ERROR_PAGE_REDIRECT_DOMAINS"),
+		},
+		Faults: FaultConfig{
+			Enabled:        parseBool("FAULT_INJECTION_ENABLED", false),
+			DBErrorRate:    parseFloat("FAULT_DB_ERROR_RATE", 0),
+			DBLatency:      parseDuration("FAULT_DB_LATENCY", "0s"),
+			RedisErrorRate: parseFloat("FAULT_REDIS_ERROR_RATE", 0),
+			RedisLatency:   parseDuration("FAULT_REDIS_LATENCY", "0s"),
+		},
+		Region: RegionConfig{
+			Name:               getEnv("REGION", ""),
+			Primary:            getEnv("PRIMARY_REGION", ""),
+			PrimaryURL:         getEnv("PRIMARY_REGION_URL", ""),
+			PrimaryDatabaseDSN: getEnv("PRIMARY_DATABASE_DSN", ""),
+		},
+		CDN: CDNConfig{
+			EdgeTTL:      parseDuration("CDN_EDGE_TTL", "0s"),
+			Provider:     getEnv("CDN_PROVIDER", ""),
+			Hosts:        parseList("CDN_HOSTS"),
+			PurgeTimeout: parseDuration("CDN_PURGE_TIMEOUT", "5s"),
+
+			CloudflareZoneID:   getEnv("CLOUDFLARE_ZONE_ID", ""),
+			CloudflareAPIToken: getEnv("CLOUDFLARE_API_TOKEN", ""),
+			FastlyServiceID:    getEnv("FASTLY_SERVICE_ID", ""),
+			FastlyAPIKey:       getEnv("FASTLY_API_KEY", ""),
+		},
+		ETL: ETLConfig{
+			Bucket:      getEnv("ETL_BUCKET", ""),
+			Endpoint:    getEnv("ETL_ENDPOINT", ""),
+			Region:      getEnv("ETL_REGION", "us-east-1"),
+			Prefix:      getEnv("ETL_PREFIX", "clicks"),
+			Format:      getEnv("ETL_FORMAT", "ndjson"),
+			Compression: getEnv("ETL_COMPRESSION", "gzip"),
+			Interval:    parseDuration("ETL_INTERVAL", "1h"),
+			BatchSize:   parseInt("ETL_BATCH_SIZE", 10000),
+			Lag:         parseDuration("ETL_LAG", "1m"),
+			Timeout:     parseDuration("ETL_TIMEOUT", "30s"),
+
+			AccessKeyID:     getEnv("ETL_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getEnv("ETL_SECRET_ACCESS_KEY", ""),
+		},
+		Files: FilesConfig{
+			Bucket:    getEnv("FILES_BUCKET", ""),
+			Endpoint:  getEnv("FILES_ENDPOINT", ""),
+			Region:    getEnv("FILES_REGION", "us-east-1"),
+			MaxSizeMB: parseInt("FILES_MAX_SIZE_MB", 25),
+			Timeout:   parseDuration("FILES_TIMEOUT", "5m"),
+
+			AccessKeyID:     getEnv("FILES_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getEnv("FILES_SECRET_ACCESS_KEY", ""),
+		},
+		Abuse: AbuseConfig{
+			Enabled:        parseBool("ABUSE_PROTECTION_ENABLED", true),
+			Window:         parseDuration("ABUSE_WINDOW", "10s"),
+			ChallengeLimit: int64(parseInt("ABUSE_CHALLENGE_LIMIT", 50)),
+			BanLimit:       int64(parseInt("ABUSE_BAN_LIMIT", 200)),
+			CodeLimit:      int64(parseInt("ABUSE_CODE_LIMIT", 1000)),
+			BanDurations:   parseDurationList("ABUSE_BAN_DURATIONS", "1m,10m,1h,24h"),
+			StrikeMemory:   parseDuration("ABUSE_STRIKE_MEMORY", "168h"),
+			PassTTL:        parseDuration("ABUSE_CHALLENGE_PASS_TTL", "30m"),
+		},
+		Captcha: CaptchaConfig{
+			Provider: getEnv("CAPTCHA_PROVIDER", ""),
+			SiteKey:  getEnv("CAPTCHA_SITE_KEY", ""),
+			Secret:   getEnv("CAPTCHA_SECRET_KEY", ""),
+			Timeout:  parseDuration("CAPTCHA_TIMEOUT", "5s"),
+		},
+		TLS: TLSConfig{
+			Autocert:     parseBool("AUTOCERT_ENABLED", false),
+			Port:         getEnv("TLS_PORT", "8443"),
+			Email:        getEnv("ACME_EMAIL", ""),
+			DirectoryURL: getEnv("ACME_DIRECTORY_URL", ""),
+			RenewBefore:  parseDuration("AUTOCERT_RENEW_BEFORE", "720h"),
+		},
+		Errors: ErrorReportConfig{
+			SentryDSN:   getEnv("SENTRY_DSN", ""),
+			Release:     getEnv("SENTRY_RELEASE", ""),
+			Environment: getEnv("SENTRY_ENVIRONMENT", getEnv("APP_ENV", "development")),
+			SampleRate:  parseFloat("SENTRY_SAMPLE_RATE", 1),
+			Timeout:     parseDuration("SENTRY_TIMEOUT", "5s"),
+		},
+		KMS: keys.Config{
+			Provider: getEnv("KMS_PROVIDER", ""),
+			// DESTINATION_ENCRYPTION_KEYS is the name from before KMS_PROVIDER
+			Keys:     getEnv("KMS_LOCAL_KEYS", getEnv("DESTINATION_ENCRYPTION_KEYS", "")),
+			KeyFile:  getEnv("KMS_KEY_FILE", ""),
+			KeyID:    getEnv("KMS_KEY_ID", ""),
+			Region:   getEnv("KMS_REGION", ""),
+			Endpoint: getEnv("KMS_ENDPOINT", ""),
+			Timeout:  parseDuration("KMS_TIMEOUT", "5s"),
+
+			AccessKeyID:     getEnv("KMS_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getEnv("KMS_SECRET_ACCESS_KEY", ""),
+			SessionToken:    getEnv("KMS_SESSION_TOKEN", ""),
+		},
+	}
+
+	if len(cfg.Redis.MemcachedServers) == 0 {
+		cfg.Redis.MemcachedServers = []string{"localhost:11211"}
 	}
 
 	return cfg, nil
@@ -133,6 +673,15 @@ func parseInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func parseFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
 func parseBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolVal, err := strconv.ParseBool(value); err == nil {
@@ -142,6 +691,122 @@ func parseBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// parsePort reads an optional listener port
+// "off" disables the listener (an empty value means "use the default")
+func parsePort(key, defaultValue string) string {
+	if value := getEnv(key, defaultValue); value != "off" {
+		return value
+	}
+	return ""
+}
+
+// parseList parses a comma-separated list, skipping empty entries
+func parseList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parsePrefixes parses a comma-separated list of CIDR ranges, skipping
+// malformed entries; a bare address is a range of one ("10.0.0.5" = 10.0.0.5/32)
+func parsePrefixes(key string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, item := range parseList(key) {
+		if prefix, err := netip.ParsePrefix(item); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(item); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	return prefixes
+}
+
+// parseAlphabet reads the short code alphabet
+// Accepts the preset names "alphanumeric" and "unambiguous", or a literal set of characters
+func parseAlphabet(key string) string {
+	switch value := getEnv(key, "alphanumeric"); value {
+	case "alphanumeric":
+		return shortcode.AlphabetAlphanumeric
+	case "unambiguous":
+		return shortcode.AlphabetUnambiguous
+	default:
+		return value
+	}
+}
+
+// parseIntMap parses "key=1,other=2" into a map, skipping malformed entries
+func parseIntMap(key string) map[string]int {
+	result := make(map[string]int)
+	for _, item := range parseList(key) {
+		name, value, found := strings.Cut(item, "=")
+		if !found {
+			continue
+		}
+		if intVal, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			result[strings.TrimSpace(name)] = intVal
+		}
+	}
+	return result
+}
+
+// parseStringMap parses a comma-separated list of name=value pairs
+// Example: "prod_A=pro,prod_B=pro". Entries without "=" are skipped
+func parseStringMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, item := range parseList(key) {
+		name, value, found := strings.Cut(item, "=")
+		if !found {
+			continue
+		}
+		result[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return result
+}
+
+// parseTime parses an RFC 3339 timestamp or a plain date (2006-01-02)
+// Returns the zero time if the variable is unset or invalid
+func parseTime(key string) time.Time {
+	value := os.Getenv(key)
+	if value == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// parseLocation loads an IANA timezone such as "Europe/Berlin"
+// Unset or unknown names fall back to the server's local timezone
+func parseLocation(key string) *time.Location {
+	value := os.Getenv(key)
+	if value == "" {
+		return time.Local // LoadLocation("") would mean UTC
+	}
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
+
+// parseIANALocation is parseLocation for values that go to the database:
+// PostgreSQL doesn't know the server's "Local", so the default is UTC
+func parseIANALocation(key string) *time.Location {
+	location, err := time.LoadLocation(getEnv(key, "UTC"))
+	if err != nil || location == time.Local {
+		return time.UTC
+	}
+	return location
+}
+
 func parseDuration(key string, defaultValue string) time.Duration {
 	value := getEnv(key, defaultValue)
 	duration, err := time.ParseDuration(value)
@@ -151,3 +816,26 @@ func parseDuration(key string, defaultValue string) time.Duration {
 	}
 	return duration
 }
+
+// parseDurationList parses a comma-separated list like "1m,10m,1h"
+// Any invalid entry makes the whole list fall back to the default
+func parseDurationList(key string, defaultValue string) []time.Duration {
+	if durations, ok := splitDurations(getEnv(key, defaultValue)); ok {
+		return durations
+	}
+	durations, _ := splitDurations(defaultValue)
+	return durations
+}
+
+// splitDurations parses a comma-separated list of positive durations
+func splitDurations(value string) ([]time.Duration, bool) {
+	var durations []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		duration, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || duration <= 0 {
+			return nil, false
+		}
+		durations = append(durations, duration)
+	}
+	return durations, true
+}
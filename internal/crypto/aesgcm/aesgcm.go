@@ -0,0 +1,100 @@
+// Package aesgcm encrypts small secrets for storage (AES-256-GCM)
+//
+// WHY GCM?
+// GCM is "authenticated" encryption: besides hiding the plaintext, it
+// detects any change to the ciphertext. A row edited in the database
+// doesn't decrypt to garbage - it fails to decrypt at all.
+//
+// Sealed values are text, so they fit a TEXT column:
+//
+//	v1.<base64(nonce | ciphertext | tag)>
+//
+// The "v1." prefix names the format, so a later format (or key version)
+// can be told apart from the values already stored.
+package aesgcm
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the key length in bytes (AES-256)
+const KeySize = 32
+
+const prefix = "v1."
+
+// ErrMalformed is returned for values that were not sealed by this package,
+// were sealed with another key, or were modified
+var ErrMalformed = errors.New("sealed value is malformed or was modified")
+
+// Cipher seals and opens values with one key
+// Safe for concurrent use
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// ParseKey decodes a key given as 64 hex characters or as base64
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, err := hex.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("key must be %d bytes, as hex or base64 (generate one with: openssl rand -hex %d)", KeySize, KeySize)
+}
+
+// New creates a cipher for a 32-byte key
+func New(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Seal encrypts plaintext
+// Every call uses a fresh random nonce, so sealing the same value twice
+// gives different results - nobody can tell that two rows hold the same secret.
+func (c *Cipher) Seal(plaintext []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	// Seal appends to its first argument: nonce | ciphertext | tag
+	sealed := c.aead.Seal(nonce, nonce, plaintext, nil)
+	return prefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value made by Seal
+func (c *Cipher) Open(sealed string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(sealed, prefix)
+	if !ok {
+		return nil, ErrMalformed
+	}
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,98 @@
+package aesgcm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCipher(t *testing.T, fill byte) *Cipher {
+	c, err := New(bytes.Repeat([]byte{fill}, KeySize))
+	require.NoError(t, err)
+	return c
+}
+
+func TestSealOpen(t *testing.T) {
+	// Arrange
+	c := testCipher(t, 1)
+
+	// Act
+	first, err := c.Seal([]byte("the wifi password is hunter2"))
+	require.NoError(t, err)
+	second, err := c.Seal([]byte("the wifi password is hunter2"))
+	require.NoError(t, err)
+	opened, err := c.Open(first)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "the wifi password is hunter2", string(opened))
+	assert.True(t, strings.HasPrefix(first, "v1."))
+	assert.NotContains(t, first, "hunter2")
+	assert.NotEqual(t, first, second, "every seal uses a fresh nonce")
+}
+
+func TestOpen_Rejects(t *testing.T) {
+	c := testCipher(t, 1)
+	sealed, err := c.Seal([]byte("secret"))
+	require.NoError(t, err)
+
+	// Flip one character of the ciphertext
+	tampered := []byte(sealed)
+	tampered[len(tampered)-3] ^= 1
+
+	tests := []struct {
+		name   string
+		cipher *Cipher
+		sealed string
+	}{
+		{name: "other key", cipher: testCipher(t, 2), sealed: sealed},
+		{name: "modified", cipher: c, sealed: string(tampered)},
+		{name: "unknown format", cipher: c, sealed: strings.TrimPrefix(sealed, "v1.")},
+		{name: "not base64", cipher: c, sealed: "v1.!!!"},
+		{name: "too short", cipher: c, sealed: "v1.AAAA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := tt.cipher.Open(tt.sealed)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrMalformed)
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	hexKey := strings.Repeat("ab", KeySize)
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "hex", input: hexKey},
+		{name: "base64", input: "q6urq6urq6urq6urq6urq6urq6urq6urq6urq6urq6s="},
+		{name: "surrounding whitespace", input: " " + hexKey + "\n"},
+		{name: "too short", input: "abcd", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			key, err := ParseKey(tt.input)
+
+			// Assert
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, key, KeySize)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Package envelope encrypts values with envelope encryption (AES-256-GCM)
+//
+// WHAT IS ENVELOPE ENCRYPTION?
+// Every value gets its own random data key, which encrypts it. The data key
+// is then encrypted ("wrapped") with a master key and stored next to the
+// value. The master key itself never touches the stored data.
+//
+// WHY BOTHER?
+// Rotating the master key only means re-wrapping the small data keys
+// (Rewrap) - the values themselves are not encrypted again. And the master
+// key only ever encrypts random data keys, never guessable plaintext. With
+// a cloud KMS (see package keys) it also means the master key never leaves
+// the KMS: only the data keys travel.
+//
+// Sealed values are text, so they fit a TEXT column:
+//
+//	env1:<master key version>:<wrapped data key>:<ciphertext>
+//
+// The key version tells which master key wrapped the data key, so values
+// sealed before a rotation still open as long as the manager has it.
+// (Versions are URL-escaped: an AWS key ARN contains ':'.)
+package envelope
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shortener/internal/crypto/aesgcm"
+	"url-shortener/internal/crypto/keys"
+)
+
+const prefix = "env1"
+
+// Unwrapped data keys are kept in memory for a while: with a cloud KMS,
+// unwrapping is a network call, and the same link is opened over and over
+const (
+	dataKeyTTL  = 5 * time.Minute
+	maxDataKeys = 10000
+)
+
+var (
+	// ErrMalformed is returned for values that were not sealed by this
+	// package or were modified
+	ErrMalformed = keys.ErrMalformed
+
+	// ErrUnknownKey is returned for values wrapped by a master key version
+	// the manager doesn't have (removed too early, or from another deployment)
+	ErrUnknownKey = keys.ErrUnknownVersion
+)
+
+// Sealer seals values with data keys wrapped by a keys.Manager
+// Safe for concurrent use
+type Sealer struct {
+	keys keys.Manager
+	now  func() time.Time
+
+	mu       sync.Mutex
+	dataKeys map[string]cachedDataKey // By wrapped data key
+}
+
+// cachedDataKey is an unwrapped data key and when it is forgotten
+type cachedDataKey struct {
+	cipher    *aesgcm.Cipher
+	expiresAt time.Time
+}
+
+// New creates a sealer whose data keys are wrapped by m
+func New(m keys.Manager) *Sealer {
+	return &Sealer{
+		keys:     m,
+		now:      time.Now,
+		dataKeys: make(map[string]cachedDataKey),
+	}
+}
+
+// Seal encrypts plaintext with a fresh data key wrapped by the current
+// master key version
+func (s *Sealer) Seal(ctx context.Context, plaintext []byte) (string, error) {
+	dataKey := make([]byte, aesgcm.KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	data, err := aesgcm.New(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := data.Seal(plaintext)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := s.keys.Encrypt(ctx, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return join(wrapped, ciphertext), nil
+}
+
+// Open decrypts a value made by Seal (with any version of the master key)
+func (s *Sealer) Open(ctx context.Context, sealed string) ([]byte, error) {
+	wrapped, ciphertext, err := split(sealed)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := data.Open(ciphertext)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return plaintext, nil
+}
+
+// Rewrap wraps the data key of sealed with the current master key version
+// The ciphertext stays as it is. Returns false (and sealed unchanged) when
+// the current version already wraps it.
+func (s *Sealer) Rewrap(ctx context.Context, sealed string) (string, bool, error) {
+	wrapped, ciphertext, err := split(sealed)
+	if err != nil {
+		return "", false, err
+	}
+	current, err := s.keys.Current(ctx)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get current key version: %w", err)
+	}
+	if wrapped.Version == current {
+		return sealed, false, nil
+	}
+
+	dataKey, err := s.keys.Decrypt(ctx, wrapped)
+	if err != nil {
+		return "", false, err
+	}
+	rewrapped, err := s.keys.Encrypt(ctx, dataKey)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return join(rewrapped, ciphertext), true, nil
+}
+
+// unwrap returns the cipher of a wrapped data key, from memory if possible
+func (s *Sealer) unwrap(ctx context.Context, wrapped keys.Ciphertext) (*aesgcm.Cipher, error) {
+	cacheKey := wrapped.Version + ":" + wrapped.Data
+	now := s.now()
+
+	s.mu.Lock()
+	cached, ok := s.dataKeys[cacheKey]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.cipher, nil
+	}
+
+	dataKey, err := s.keys.Decrypt(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	cipher, err := aesgcm.New(dataKey)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.dataKeys) >= maxDataKeys {
+		for key, entry := range s.dataKeys {
+			if !now.Before(entry.expiresAt) {
+				delete(s.dataKeys, key)
+			}
+		}
+		// Still full of live keys: start over rather than grow without bound
+		if len(s.dataKeys) >= maxDataKeys {
+			clear(s.dataKeys)
+		}
+	}
+	s.dataKeys[cacheKey] = cachedDataKey{cipher: cipher, expiresAt: now.Add(dataKeyTTL)}
+	return cipher, nil
+}
+
+// join puts a sealed value together
+func join(wrapped keys.Ciphertext, ciphertext string) string {
+	return strings.Join([]string{prefix, url.QueryEscape(wrapped.Version), wrapped.Data, ciphertext}, ":")
+}
+
+// split takes a sealed value apart
+func split(sealed string) (keys.Ciphertext, string, error) {
+	parts := strings.Split(sealed, ":")
+	if len(parts) != 4 || parts[0] != prefix || parts[2] == "" {
+		return keys.Ciphertext{}, "", ErrMalformed
+	}
+	version, err := url.QueryUnescape(parts[1])
+	if err != nil || version == "" {
+		return keys.Ciphertext{}, "", ErrMalformed
+	}
+	return keys.Ciphertext{Version: version, Data: parts[2]}, parts[3], nil
+}
@@ -0,0 +1,186 @@
+package envelope
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"url-shortener/internal/crypto/keys"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	oldKey = strings.Repeat("01", 32)
+	newKey = strings.Repeat("02", 32)
+)
+
+// testRing returns a sealer for local master keys given as "id=key,..."
+func testRing(t *testing.T, spec string) *Sealer {
+	local, err := keys.ParseLocal(spec)
+	require.NoError(t, err)
+	return New(local)
+}
+
+// countingManager counts the data keys a manager unwraps
+type countingManager struct {
+	keys.Manager
+	decrypts int
+}
+
+func (m *countingManager) Decrypt(ctx context.Context, ciphertext keys.Ciphertext) ([]byte, error) {
+	m.decrypts++
+	return m.Manager.Decrypt(ctx, ciphertext)
+}
+
+// versionedManager gives a local key an ARN-like version (with ':')
+type versionedManager struct {
+	keys.Manager
+	version string
+}
+
+func (m versionedManager) Encrypt(ctx context.Context, plaintext []byte) (keys.Ciphertext, error) {
+	ciphertext, err := m.Manager.Encrypt(ctx, plaintext)
+	ciphertext.Version = m.version
+	return ciphertext, err
+}
+
+func (m versionedManager) Decrypt(ctx context.Context, ciphertext keys.Ciphertext) ([]byte, error) {
+	if ciphertext.Version != m.version {
+		return nil, keys.ErrUnknownVersion
+	}
+	current, _ := m.Manager.Current(ctx)
+	return m.Manager.Decrypt(ctx, keys.Ciphertext{Version: current, Data: ciphertext.Data})
+}
+
+func (m versionedManager) Current(context.Context) (string, error) {
+	return m.version, nil
+}
+
+func TestSealOpen(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	ring := testRing(t, "2026-10="+newKey+",2026-01="+oldKey)
+
+	// Act
+	first, err := ring.Seal(ctx, []byte("https://example.com/launch"))
+	require.NoError(t, err)
+	second, err := ring.Seal(ctx, []byte("https://example.com/launch"))
+	require.NoError(t, err)
+	opened, err := ring.Open(ctx, first)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/launch", string(opened))
+	assert.True(t, strings.HasPrefix(first, "env1:2026-10:"), "the current key wraps new data keys")
+	assert.NotContains(t, first, "example.com")
+	assert.NotEqual(t, first, second, "every value gets its own data key")
+}
+
+func TestRewrap(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	before := testRing(t, "2026-01="+oldKey)
+	sealed, err := before.Seal(ctx, []byte("https://example.com/launch"))
+	require.NoError(t, err)
+	after := testRing(t, "2026-10="+newKey+",2026-01="+oldKey)
+
+	// Act
+	rewrapped, changed, err := after.Rewrap(ctx, sealed)
+	require.NoError(t, err)
+	again, changedAgain, err := after.Rewrap(ctx, rewrapped)
+	require.NoError(t, err)
+
+	// Assert
+	assert.True(t, changed)
+	assert.True(t, strings.HasPrefix(rewrapped, "env1:2026-10:"))
+	assert.Equal(t, sealed[strings.LastIndex(sealed, ":"):], rewrapped[strings.LastIndex(rewrapped, ":"):], "the ciphertext is not touched")
+	assert.False(t, changedAgain)
+	assert.Equal(t, rewrapped, again)
+
+	// The old key can go once everything is rewrapped
+	opened, err := testRing(t, "2026-10="+newKey).Open(ctx, rewrapped)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/launch", string(opened))
+}
+
+func TestOpen_Rejects(t *testing.T) {
+	ctx := context.Background()
+	ring := testRing(t, "k1="+newKey)
+	sealed, err := ring.Seal(ctx, []byte("https://example.com"))
+	require.NoError(t, err)
+
+	// Flip one character of the ciphertext
+	tampered := []byte(sealed)
+	tampered[len(tampered)-3] ^= 1
+
+	tests := []struct {
+		name     string
+		ring     *Sealer
+		sealed   string
+		expected error
+	}{
+		{name: "key not in the ring", ring: testRing(t, "k2="+oldKey), sealed: sealed, expected: ErrUnknownKey},
+		{name: "same ID, other key", ring: testRing(t, "k1="+oldKey), sealed: sealed, expected: ErrMalformed},
+		{name: "modified", ring: ring, sealed: string(tampered), expected: ErrMalformed},
+		{name: "unknown format", ring: ring, sealed: strings.Replace(sealed, "env1", "env9", 1), expected: ErrMalformed},
+		{name: "plain aesgcm value", ring: ring, sealed: "v1.AAAA", expected: ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := tt.ring.Open(ctx, tt.sealed)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestOpen_RemembersDataKeys(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	local, err := keys.ParseLocal("k1=" + newKey)
+	require.NoError(t, err)
+	manager := &countingManager{Manager: local}
+	sealer := New(manager)
+	first, err := sealer.Seal(ctx, []byte("https://example.com/a"))
+	require.NoError(t, err)
+	second, err := sealer.Seal(ctx, []byte("https://example.com/b"))
+	require.NoError(t, err)
+
+	// Act
+	for range 3 {
+		_, err = sealer.Open(ctx, first)
+		require.NoError(t, err)
+	}
+	_, err = sealer.Open(ctx, second)
+	require.NoError(t, err)
+
+	// Assert: one KMS call per data key, not per redirect
+	assert.Equal(t, 2, manager.decrypts)
+}
+
+func TestSeal_VersionWithColons(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	local, err := keys.ParseLocal("k1=" + newKey)
+	require.NoError(t, err)
+	arn := "arn:aws:kms:eu-west-1:111122223333:key/1234abcd"
+	sealer := New(versionedManager{Manager: local, version: arn})
+
+	// Act
+	sealed, err := sealer.Seal(ctx, []byte("https://example.com/launch"))
+	require.NoError(t, err)
+	opened, err := sealer.Open(ctx, sealed)
+	require.NoError(t, err)
+	_, changed, err := sealer.Rewrap(ctx, sealed)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "https://example.com/launch", string(opened))
+	assert.Len(t, strings.Split(sealed, ":"), 4, "the version is escaped")
+	assert.False(t, changed)
+}
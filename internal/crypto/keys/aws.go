@@ -0,0 +1,174 @@
+package keys
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/storage/blob"
+)
+
+// currentTTL is how long the current key version is remembered
+// An alias can be pointed at another key, or a new GCP version made primary;
+// new values pick that up within this time.
+const currentTTL = 5 * time.Minute
+
+// AWS encrypts with a key in AWS KMS
+// https://docs.aws.amazon.com/kms/latest/APIReference/
+//
+// The version of a value is the ARN of the key that encrypted it. KMS's own
+// automatic rotation keeps the ARN (old key material stays in the key), so a
+// new version only appears when KMS_KEY_ID points at another key - e.g. an
+// alias moved to a new key.
+type AWS struct {
+	endpoint string
+	keyID    string
+	token    string // Session token of temporary credentials
+	signer   blob.Signer
+	client   *http.Client
+	now      func() time.Time
+
+	mu        sync.Mutex
+	current   string
+	checkedAt time.Time
+}
+
+// Compile-time check that AWS implements Manager
+var _ Manager = (*AWS)(nil)
+
+// NewAWS creates a manager for keyID (key ID, ARN or alias) in region
+func NewAWS(keyID, region, accessKey, secretKey, sessionToken string, timeout time.Duration) *AWS {
+	return &AWS{
+		endpoint: "https://kms." + region + ".amazonaws.com",
+		keyID:    keyID,
+		token:    sessionToken,
+		signer:   blob.Signer{Region: region, AccessKey: accessKey, SecretKey: secretKey, Service: "kms"},
+		client:   &http.Client{Timeout: timeout},
+		now:      time.Now,
+	}
+}
+
+// Encrypt encrypts plaintext with the configured key
+func (a *AWS) Encrypt(ctx context.Context, plaintext []byte) (Ciphertext, error) {
+	var out struct {
+		CiphertextBlob string
+		KeyId          string
+	}
+	err := a.call(ctx, "Encrypt", map[string]string{
+		"KeyId":     a.keyID,
+		"Plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	}, &out)
+	if err != nil {
+		return Ciphertext{}, err
+	}
+	return Ciphertext{Version: out.KeyId, Data: out.CiphertextBlob}, nil
+}
+
+// Decrypt decrypts a value made by Encrypt
+// The version is sent along: KMS refuses a value made by any other key.
+func (a *AWS) Decrypt(ctx context.Context, ciphertext Ciphertext) ([]byte, error) {
+	var out struct {
+		Plaintext string
+	}
+	err := a.call(ctx, "Decrypt", map[string]string{
+		"KeyId":          ciphertext.Version,
+		"CiphertextBlob": ciphertext.Data,
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(out.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("aws kms: invalid plaintext in response: %w", err)
+	}
+	return plaintext, nil
+}
+
+// Current returns the ARN of the configured key (resolving an alias)
+func (a *AWS) Current(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.current != "" && a.now().Sub(a.checkedAt) < currentTTL {
+		return a.current, nil
+	}
+
+	var out struct {
+		KeyMetadata struct {
+			Arn string
+		}
+	}
+	if err := a.call(ctx, "DescribeKey", map[string]string{"KeyId": a.keyID}, &out); err != nil {
+		return "", err
+	}
+	a.current = out.KeyMetadata.Arn
+	a.checkedAt = a.now()
+	return a.current, nil
+}
+
+// call sends one request of the KMS JSON API and decodes its response into out
+func (a *AWS) call(ctx context.Context, operation string, in any, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s request: %w", operation, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", operation, err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+operation)
+	if a.token != "" {
+		req.Header.Set("X-Amz-Security-Token", a.token)
+	}
+	a.signer.Sign(req, blob.PayloadHash(body), a.now())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		metrics.RecordKMSRequest("aws", operation, "error")
+		return fmt.Errorf("aws kms %s failed: %w", operation, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		metrics.RecordKMSRequest("aws", operation, "error")
+		return fmt.Errorf("aws kms %s failed: %w", operation, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		metrics.RecordKMSRequest("aws", operation, "error")
+		return awsError(operation, resp.StatusCode, data)
+	}
+	metrics.RecordKMSRequest("aws", operation, "ok")
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("aws kms %s: invalid response: %w", operation, err)
+	}
+	return nil
+}
+
+// awsError turns a KMS error response into an error
+// Errors that mean "this value can't be decrypted here" wrap ErrMalformed or
+// ErrUnknownVersion, so callers can tell them from an outage.
+func awsError(operation string, status int, body []byte) error {
+	var kmsErr struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &kmsErr)
+
+	// Some endpoints qualify the type: "com.amazonaws.kms#NotFoundException"
+	switch kmsErr.Type[strings.LastIndex(kmsErr.Type, "#")+1:] {
+	case "InvalidCiphertextException", "IncorrectKeyException":
+		return fmt.Errorf("%w: aws kms %s: %s", ErrMalformed, operation, kmsErr.Message)
+	case "NotFoundException":
+		return fmt.Errorf("%w: aws kms %s: %s", ErrUnknownVersion, operation, kmsErr.Message)
+	}
+	return fmt.Errorf("aws kms %s failed with status %d: %s %s", operation, status, kmsErr.Type, kmsErr.Message)
+}
@@ -0,0 +1,126 @@
+package keys
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKeyARN = "arn:aws:kms:eu-west-1:111122223333:key/1234abcd"
+
+// fakeAWSKMS answers like KMS; its "ciphertext" is the plaintext reversed
+func fakeAWSKMS(t *testing.T, calls map[string]int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-amz-json-1.1", r.Header.Get("Content-Type"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/kms/aws4_request")
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+
+		var in map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "TrentService.")
+		calls[operation]++
+
+		var out any
+		switch operation {
+		case "Encrypt":
+			assert.Equal(t, "alias/app", in["KeyId"])
+			plaintext, _ := base64.StdEncoding.DecodeString(in["Plaintext"])
+			out = map[string]string{"KeyId": testKeyARN, "CiphertextBlob": base64.StdEncoding.EncodeToString(reverse(plaintext))}
+		case "Decrypt":
+			blob, _ := base64.StdEncoding.DecodeString(in["CiphertextBlob"])
+			if in["KeyId"] != testKeyARN || len(blob) == 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"__type":"InvalidCiphertextException","message":"bad"}`))
+				return
+			}
+			out = map[string]string{"KeyId": testKeyARN, "Plaintext": base64.StdEncoding.EncodeToString(reverse(blob))}
+		case "DescribeKey":
+			out = map[string]any{"KeyMetadata": map[string]string{"Arn": testKeyARN}}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+}
+
+func reverse(b []byte) []byte {
+	reversed := make([]byte, len(b))
+	for i := range b {
+		reversed[len(b)-1-i] = b[i]
+	}
+	return reversed
+}
+
+func newTestAWS(endpoint string) *AWS {
+	m := NewAWS("alias/app", "eu-west-1", "AKID", "secret", "session", time.Second)
+	m.endpoint = endpoint
+	return m
+}
+
+func TestAWS_EncryptDecrypt(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	calls := map[string]int{}
+	server := fakeAWSKMS(t, calls)
+	defer server.Close()
+	m := newTestAWS(server.URL)
+
+	// Act
+	ciphertext, err := m.Encrypt(ctx, []byte("whsec_abc"))
+	require.NoError(t, err)
+	plaintext, err := m.Decrypt(ctx, ciphertext)
+	require.NoError(t, err)
+	parsed, err := ParseCiphertext(ciphertext.String())
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "whsec_abc", string(plaintext))
+	assert.Equal(t, testKeyARN, ciphertext.Version, "the alias is resolved to the key")
+	assert.Equal(t, ciphertext, parsed)
+}
+
+func TestAWS_Decrypt_Rejects(t *testing.T) {
+	// Arrange
+	server := fakeAWSKMS(t, map[string]int{})
+	defer server.Close()
+	m := newTestAWS(server.URL)
+
+	// Act
+	_, err := m.Decrypt(context.Background(), Ciphertext{Version: "arn:aws:kms:eu-west-1:111122223333:key/other", Data: "AAAA"})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestAWS_Current(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	calls := map[string]int{}
+	server := fakeAWSKMS(t, calls)
+	defer server.Close()
+	m := newTestAWS(server.URL)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	// Act
+	first, err := m.Current(ctx)
+	require.NoError(t, err)
+	_, err = m.Current(ctx)
+	require.NoError(t, err)
+	now = now.Add(currentTTL)
+	_, err = m.Current(ctx)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, testKeyARN, first)
+	assert.Equal(t, 2, calls["DescribeKey"], "looked up again once it may have changed")
+}
@@ -0,0 +1,224 @@
+package keys
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shortener/internal/metrics"
+)
+
+// gcpTokenURL is where the metadata server hands out the access tokens of
+// the machine's service account (GCE, GKE, Cloud Run)
+const gcpTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCP encrypts with a key in Google Cloud KMS
+// https://cloud.google.com/kms/docs/reference/rest
+//
+// The version of a value is the key version that encrypted it
+// (".../cryptoKeys/k/cryptoKeyVersions/3"). Rotating the key in Cloud KMS
+// makes a new primary version: new values use it, older ones keep
+// decrypting until their version is disabled.
+type GCP struct {
+	endpoint string
+	key      string // projects/p/locations/l/keyRings/r/cryptoKeys/k
+	tokenURL string
+	client   *http.Client
+	now      func() time.Time
+
+	mu             sync.Mutex
+	token          string
+	tokenExpiresAt time.Time
+	current        string
+	checkedAt      time.Time
+}
+
+// Compile-time check that GCP implements Manager
+var _ Manager = (*GCP)(nil)
+
+// NewGCP creates a manager for the key resource name key
+// Requests are authorized as the machine's service account, which needs
+// the "Cloud KMS CryptoKey Encrypter/Decrypter" and "Viewer" roles on it.
+func NewGCP(key string, timeout time.Duration) *GCP {
+	return &GCP{
+		endpoint: "https://cloudkms.googleapis.com/v1",
+		key:      strings.Trim(key, "/"),
+		tokenURL: gcpTokenURL,
+		client:   &http.Client{Timeout: timeout},
+		now:      time.Now,
+	}
+}
+
+// Encrypt encrypts plaintext with the primary version of the key
+func (g *GCP) Encrypt(ctx context.Context, plaintext []byte) (Ciphertext, error) {
+	var out struct {
+		Name       string `json:"name"`
+		Ciphertext string `json:"ciphertext"`
+	}
+	err := g.call(ctx, "encrypt", http.MethodPost, g.key+":encrypt", map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	}, &out)
+	if err != nil {
+		return Ciphertext{}, err
+	}
+	return Ciphertext{Version: out.Name, Data: out.Ciphertext}, nil
+}
+
+// Decrypt decrypts a value made by Encrypt
+// Cloud KMS finds the version in the ciphertext; it only needs the key,
+// which is the version's parent (so values of a previous key still open).
+func (g *GCP) Decrypt(ctx context.Context, ciphertext Ciphertext) ([]byte, error) {
+	key, _, ok := strings.Cut(ciphertext.Version, "/cryptoKeyVersions/")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownVersion, ciphertext.Version)
+	}
+
+	var out struct {
+		Plaintext string `json:"plaintext"`
+	}
+	err := g.call(ctx, "decrypt", http.MethodPost, key+":decrypt", map[string]string{
+		"ciphertext": ciphertext.Data,
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(out.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("gcp kms: invalid plaintext in response: %w", err)
+	}
+	return plaintext, nil
+}
+
+// Current returns the primary version of the key
+func (g *GCP) Current(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	current, checkedAt := g.current, g.checkedAt
+	g.mu.Unlock()
+	if current != "" && g.now().Sub(checkedAt) < currentTTL {
+		return current, nil
+	}
+
+	var out struct {
+		Primary struct {
+			Name string `json:"name"`
+		} `json:"primary"`
+	}
+	if err := g.call(ctx, "get", http.MethodGet, g.key, nil, &out); err != nil {
+		return "", err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.current = out.Primary.Name
+	g.checkedAt = g.now()
+	return g.current, nil
+}
+
+// call sends one request of the Cloud KMS REST API and decodes its response into out
+func (g *GCP) call(ctx context.Context, operation, method, path string, in any, out any) error {
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		metrics.RecordKMSRequest("gcp", operation, "error")
+		return err
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s request: %w", operation, err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, g.endpoint+"/"+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", operation, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		metrics.RecordKMSRequest("gcp", operation, "error")
+		return fmt.Errorf("gcp kms %s failed: %w", operation, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		metrics.RecordKMSRequest("gcp", operation, "error")
+		return fmt.Errorf("gcp kms %s failed: %w", operation, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		metrics.RecordKMSRequest("gcp", operation, "error")
+		return gcpError(operation, resp.StatusCode, data)
+	}
+	metrics.RecordKMSRequest("gcp", operation, "ok")
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("gcp kms %s: invalid response: %w", operation, err)
+	}
+	return nil
+}
+
+// accessToken returns a token of the machine's service account
+// Tokens live about an hour; a new one is fetched a minute before expiry.
+func (g *GCP) accessToken(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.token != "" && g.now().Before(g.tokenExpiresAt) {
+		return g.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.tokenURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gcp kms: failed to get an access token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("gcp kms: metadata server returned status %d for the access token", resp.StatusCode)
+	}
+
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"` // Seconds
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || out.AccessToken == "" {
+		return "", fmt.Errorf("gcp kms: invalid access token response: %v", err)
+	}
+	g.token = out.AccessToken
+	g.tokenExpiresAt = g.now().Add(time.Duration(out.ExpiresIn)*time.Second - time.Minute)
+	return g.token, nil
+}
+
+// gcpError turns a Cloud KMS error response into an error
+// A ciphertext Cloud KMS can't decrypt is INVALID_ARGUMENT; a version or
+// key that doesn't exist (or was destroyed) is NOT_FOUND.
+func gcpError(operation string, status int, body []byte) error {
+	var kmsErr struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(body, &kmsErr)
+
+	switch {
+	case operation == "decrypt" && kmsErr.Error.Status == "INVALID_ARGUMENT":
+		return fmt.Errorf("%w: gcp kms %s: %s", ErrMalformed, operation, kmsErr.Error.Message)
+	case kmsErr.Error.Status == "NOT_FOUND":
+		return fmt.Errorf("%w: gcp kms %s: %s", ErrUnknownVersion, operation, kmsErr.Error.Message)
+	}
+	return fmt.Errorf("gcp kms %s failed with status %d: %s %s", operation, status, kmsErr.Error.Status, kmsErr.Error.Message)
+}
@@ -0,0 +1,112 @@
+package keys
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testGCPKey     = "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+	testGCPVersion = testGCPKey + "/cryptoKeyVersions/3"
+)
+
+// fakeGCPKMS answers like Cloud KMS and the metadata server; its
+// "ciphertext" is the plaintext reversed
+func fakeGCPKMS(t *testing.T, calls map[string]int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.Method+" "+r.URL.Path]++
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "ya29.token", "expires_in": 3600, "token_type": "Bearer"})
+			return
+		}
+		assert.Equal(t, "Bearer ya29.token", r.Header.Get("Authorization"))
+
+		var in map[string]string
+		if r.Method == http.MethodPost {
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/" + testGCPKey + ":encrypt":
+			plaintext, _ := base64.StdEncoding.DecodeString(in["plaintext"])
+			_ = json.NewEncoder(w).Encode(map[string]string{"name": testGCPVersion, "ciphertext": base64.StdEncoding.EncodeToString(reverse(plaintext))})
+		case "POST /v1/" + testGCPKey + ":decrypt":
+			ciphertext, _ := base64.StdEncoding.DecodeString(in["ciphertext"])
+			if len(ciphertext) == 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":{"code":400,"message":"Decryption failed","status":"INVALID_ARGUMENT"}}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"plaintext": base64.StdEncoding.EncodeToString(reverse(ciphertext))})
+		case "GET /v1/" + testGCPKey:
+			_ = json.NewEncoder(w).Encode(map[string]any{"name": testGCPKey, "primary": map[string]string{"name": testGCPVersion}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"not found","status":"NOT_FOUND"}}`))
+		}
+	}))
+}
+
+func newTestGCP(server *httptest.Server) *GCP {
+	m := NewGCP(testGCPKey, time.Second)
+	m.endpoint = server.URL + "/v1"
+	m.tokenURL = server.URL + "/token"
+	return m
+}
+
+func TestGCP_EncryptDecrypt(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	calls := map[string]int{}
+	server := fakeGCPKMS(t, calls)
+	defer server.Close()
+	m := newTestGCP(server)
+
+	// Act
+	ciphertext, err := m.Encrypt(ctx, []byte("whsec_abc"))
+	require.NoError(t, err)
+	plaintext, err := m.Decrypt(ctx, ciphertext)
+	require.NoError(t, err)
+	current, err := m.Current(ctx)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "whsec_abc", string(plaintext))
+	assert.Equal(t, testGCPVersion, ciphertext.Version)
+	assert.Equal(t, testGCPVersion, current)
+	assert.Equal(t, 1, calls["GET /token"], "the access token is reused")
+}
+
+func TestGCP_Decrypt_Rejects(t *testing.T) {
+	server := fakeGCPKMS(t, map[string]int{})
+	defer server.Close()
+	m := newTestGCP(server)
+
+	tests := []struct {
+		name       string
+		ciphertext Ciphertext
+		expected   error
+	}{
+		{name: "modified", ciphertext: Ciphertext{Version: testGCPVersion, Data: ""}, expected: ErrMalformed},
+		{name: "key of another ring", ciphertext: Ciphertext{Version: "projects/p/locations/global/keyRings/old/cryptoKeys/k/cryptoKeyVersions/1", Data: "AAAA"}, expected: ErrUnknownVersion},
+		{name: "not a key version", ciphertext: Ciphertext{Version: "2026-10", Data: "AAAA"}, expected: ErrUnknownVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := m.Decrypt(context.Background(), tt.ciphertext)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Package keys hides where master keys live behind one interface
+//
+// WHY AN ABSTRACTION?
+// Secret material (the data keys of encrypted destinations, webhook signing
+// secrets, the API key pepper) is encrypted with a master key. Where that
+// key lives is an operational choice:
+//   - Local: keys in a file or an environment variable. Simple, but anyone
+//     who can read the server's environment has them.
+//   - AWS KMS / GCP Cloud KMS: the key never leaves the provider's HSMs.
+//     The server only asks it to encrypt or decrypt small values, every
+//     use is audited, and access can be revoked without redeploying.
+//
+// The rest of the code only sees Manager, so switching providers is a
+// configuration change (KMS_PROVIDER).
+//
+// KEY VERSIONS:
+// Every Ciphertext records the key version that made it - the ID of a local
+// key, the ARN of an AWS key, the version resource of a GCP key. Values
+// encrypted before a rotation keep decrypting with their own version, and
+// Current tells which values still need to be moved to the new one.
+//
+// Ciphertexts are text, so they fit a TEXT column or an environment variable:
+//
+//	kv1:<key version (URL-escaped)>:<provider's ciphertext>
+package keys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const prefix = "kv1"
+
+var (
+	// ErrMalformed is returned for values that were not encrypted by a
+	// Manager, were encrypted with another key, or were modified
+	ErrMalformed = errors.New("ciphertext is malformed or was modified")
+
+	// ErrUnknownVersion is returned for values encrypted with a key version
+	// the manager doesn't have (removed too early, or from another deployment)
+	ErrUnknownVersion = errors.New("encrypted with a key version that is not configured")
+)
+
+// Manager encrypts small values (up to 4 KB) with a master key
+// Implemented by Local, AWS and GCP. Safe for concurrent use.
+type Manager interface {
+	// Encrypt encrypts plaintext with the current key version
+	Encrypt(ctx context.Context, plaintext []byte) (Ciphertext, error)
+
+	// Decrypt decrypts a value made by Encrypt, with any version of the key
+	Decrypt(ctx context.Context, ciphertext Ciphertext) ([]byte, error)
+
+	// Current returns the version Encrypt uses right now
+	Current(ctx context.Context) (string, error)
+}
+
+// Ciphertext is an encrypted value and the key version that made it
+type Ciphertext struct {
+	Version string // Key version (provider-specific, see Manager)
+	Data    string // Provider's ciphertext, as text without ':'
+}
+
+// String returns the text form, kv1:<version>:<data>
+func (c Ciphertext) String() string {
+	return strings.Join([]string{prefix, url.QueryEscape(c.Version), c.Data}, ":")
+}
+
+// ParseCiphertext reads the text form made by Ciphertext.String
+func ParseCiphertext(s string) (Ciphertext, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || parts[0] != prefix || parts[2] == "" {
+		return Ciphertext{}, ErrMalformed
+	}
+	version, err := url.QueryUnescape(parts[1])
+	if err != nil || version == "" {
+		return Ciphertext{}, ErrMalformed
+	}
+	return Ciphertext{Version: version, Data: parts[2]}, nil
+}
+
+// IsCiphertext reports whether s looks like the text form of a Ciphertext
+func IsCiphertext(s string) bool {
+	return strings.HasPrefix(s, prefix+":")
+}
+
+// DecryptSecret returns a configuration value in plain text
+// Values in the kv1 form are decrypted with m; anything else is returned
+// as it is, so a secret can be moved into the KMS one setting at a time.
+func DecryptSecret(ctx context.Context, m Manager, value string) (string, error) {
+	if !IsCiphertext(value) {
+		return value, nil
+	}
+	if m == nil {
+		return "", errors.New("value is encrypted, but no key manager is configured (KMS_PROVIDER)")
+	}
+	ciphertext, err := ParseCiphertext(value)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := m.Decrypt(ctx, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Config selects and configures a Manager (see New)
+type Config struct {
+	Provider string // "local", "aws" or "gcp" ("" = local if Keys or KeyFile is set)
+
+	// Local: "id=key,id=key", or a file with one id=key per line (see ParseLocal)
+	Keys    string
+	KeyFile string
+
+	// AWS: key ID, ARN or alias ("alias/url-shortener")
+	// GCP: key resource name ("projects/p/locations/l/keyRings/r/cryptoKeys/k")
+	KeyID    string
+	Region   string // AWS region of the key
+	Endpoint string // API endpoint (default: the provider's public one)
+	Timeout  time.Duration
+
+	// AWS credentials (GCP uses the service account of the machine)
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Temporary credentials only
+}
+
+// New creates the Manager cfg describes
+// Returns nil (and no error) when no provider is configured at all.
+func New(cfg Config) (Manager, error) {
+	provider := cfg.Provider
+	if provider == "" && (cfg.Keys != "" || cfg.KeyFile != "") {
+		provider = "local"
+	}
+
+	switch provider {
+	case "":
+		return nil, nil
+	case "local":
+		if cfg.KeyFile != "" {
+			return LoadLocal(cfg.KeyFile)
+		}
+		if cfg.Keys == "" {
+			return nil, errors.New("KMS_PROVIDER=local needs KMS_KEY_FILE or KMS_LOCAL_KEYS")
+		}
+		return ParseLocal(cfg.Keys)
+	case "aws":
+		if cfg.KeyID == "" || cfg.Region == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+			return nil, errors.New("KMS_PROVIDER=aws needs KMS_KEY_ID, KMS_REGION, KMS_ACCESS_KEY_ID and KMS_SECRET_ACCESS_KEY")
+		}
+		m := NewAWS(cfg.KeyID, cfg.Region, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken, cfg.Timeout)
+		if cfg.Endpoint != "" {
+			m.endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+		}
+		return m, nil
+	case "gcp":
+		if cfg.KeyID == "" {
+			return nil, errors.New("KMS_PROVIDER=gcp needs KMS_KEY_ID")
+		}
+		m := NewGCP(cfg.KeyID, cfg.Timeout)
+		if cfg.Endpoint != "" {
+			m.endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("invalid KMS_PROVIDER %q (use local, aws or gcp)", cfg.Provider)
+	}
+}
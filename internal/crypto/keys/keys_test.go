@@ -0,0 +1,121 @@
+package keys
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCiphertext_String(t *testing.T) {
+	tests := []struct {
+		name       string
+		ciphertext Ciphertext
+		expected   string
+	}{
+		{name: "local key", ciphertext: Ciphertext{Version: "2026-10", Data: "v1.AAAA"}, expected: "kv1:2026-10:v1.AAAA"},
+		{
+			name:       "aws key",
+			ciphertext: Ciphertext{Version: "arn:aws:kms:eu-west-1:111122223333:key/1234", Data: "AQIDAHg+/w=="},
+			expected:   "kv1:arn%3Aaws%3Akms%3Aeu-west-1%3A111122223333%3Akey%2F1234:AQIDAHg+/w==",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			text := tt.ciphertext.String()
+			parsed, err := ParseCiphertext(text)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, text)
+			assert.Equal(t, tt.ciphertext, parsed)
+		})
+	}
+}
+
+func TestParseCiphertext_Rejects(t *testing.T) {
+	for _, text := range []string{"", "whsec_abc", "kv1:k1", "kv1::v1.AAAA", "kv1:k1:", "kv2:k1:v1.AAAA", "kv1:%zz:v1.AAAA"} {
+		t.Run(text, func(t *testing.T) {
+			_, err := ParseCiphertext(text)
+			assert.ErrorIs(t, err, ErrMalformed)
+		})
+	}
+}
+
+func TestDecryptSecret(t *testing.T) {
+	ctx := context.Background()
+	local, err := ParseLocal("k1=" + newKey)
+	require.NoError(t, err)
+	encrypted, err := local.Encrypt(ctx, []byte("whsec_abc"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		manager  Manager
+		value    string
+		expected string
+		wantErr  bool
+	}{
+		{name: "encrypted", manager: local, value: encrypted.String(), expected: "whsec_abc"},
+		{name: "plain text", manager: local, value: "whsec_abc", expected: "whsec_abc"},
+		{name: "plain text without a manager", value: "whsec_abc", expected: "whsec_abc"},
+		{name: "empty", manager: local, value: ""},
+		{name: "encrypted without a manager", value: encrypted.String(), wantErr: true},
+		{name: "malformed", manager: local, value: "kv1:k1:", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			secret, err := DecryptSecret(ctx, tt.manager, tt.value)
+
+			// Assert
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, secret)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		expected Manager
+		wantErr  bool
+	}{
+		{name: "nothing configured", cfg: Config{}},
+		{name: "local keys without a provider", cfg: Config{Keys: "k1=" + newKey}, expected: &Local{}},
+		{name: "aws", cfg: Config{Provider: "aws", KeyID: "alias/app", Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret"}, expected: &AWS{}},
+		{name: "gcp", cfg: Config{Provider: "gcp", KeyID: "projects/p/locations/global/keyRings/r/cryptoKeys/k"}, expected: &GCP{}},
+		{name: "local without keys", cfg: Config{Provider: "local"}, wantErr: true},
+		{name: "aws without credentials", cfg: Config{Provider: "aws", KeyID: "alias/app", Region: "eu-west-1"}, wantErr: true},
+		{name: "gcp without a key", cfg: Config{Provider: "gcp"}, wantErr: true},
+		{name: "unknown provider", cfg: Config{Provider: "vault"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			manager, err := New(tt.cfg)
+
+			// Assert
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expected == nil {
+				assert.Nil(t, manager)
+				return
+			}
+			assert.IsType(t, tt.expected, manager)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"url-shortener/internal/crypto/aesgcm"
+)
+
+// Local keeps the master keys in memory, read from a file or a variable
+// The first key is current; the others only decrypt values made before it.
+// The version of a value is the ID of its key.
+type Local struct {
+	current string
+	keys    map[string]*aesgcm.Cipher
+}
+
+// LoadLocal reads the keys of a key file (see ParseLocal)
+// Mount it from a secret store rather than baking it into the image.
+func LoadLocal(path string) (*Local, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	return ParseLocal(string(data))
+}
+
+// ParseLocal reads keys given as "id=key", separated by commas or newlines
+// Lines starting with '#' are comments. The first key is current. IDs are
+// 1-32 letters, digits, '-' or '_' (e.g. "2026-10"); keys are 32 bytes as
+// hex or base64 (aesgcm.ParseKey).
+func ParseLocal(spec string) (*Local, error) {
+	local := &Local{keys: make(map[string]*aesgcm.Cipher)}
+	for _, line := range strings.Split(spec, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		for _, entry := range strings.Split(line, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			if err := local.add(entry); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if local.current == "" {
+		return nil, errors.New("no master keys given")
+	}
+	return local, nil
+}
+
+// add parses one "id=key" entry
+func (l *Local) add(entry string) error {
+	id, encoded, ok := strings.Cut(entry, "=")
+	id = strings.TrimSpace(id)
+	if !ok || !validKeyID(id) {
+		return fmt.Errorf("key %q: expected id=key with an id of 1-32 letters, digits, '-' or '_'", id)
+	}
+	if _, taken := l.keys[id]; taken {
+		return fmt.Errorf("key %q is listed twice", id)
+	}
+	key, err := aesgcm.ParseKey(encoded)
+	if err != nil {
+		return fmt.Errorf("key %q: %w", id, err)
+	}
+	cipher, err := aesgcm.New(key)
+	if err != nil {
+		return fmt.Errorf("key %q: %w", id, err)
+	}
+	l.keys[id] = cipher
+	if l.current == "" {
+		l.current = id
+	}
+	return nil
+}
+
+// Encrypt encrypts plaintext with the current key
+func (l *Local) Encrypt(_ context.Context, plaintext []byte) (Ciphertext, error) {
+	data, err := l.keys[l.current].Seal(plaintext)
+	if err != nil {
+		return Ciphertext{}, err
+	}
+	return Ciphertext{Version: l.current, Data: data}, nil
+}
+
+// Decrypt decrypts a value made by Encrypt with any of the keys
+func (l *Local) Decrypt(_ context.Context, ciphertext Ciphertext) ([]byte, error) {
+	cipher, ok := l.keys[ciphertext.Version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownVersion, ciphertext.Version)
+	}
+	plaintext, err := cipher.Open(ciphertext.Data)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return plaintext, nil
+}
+
+// Current returns the ID of the key that encrypts
+func (l *Local) Current(context.Context) (string, error) {
+	return l.current, nil
+}
+
+// validKeyID reports whether id can name a local key
+// Nothing that needs escaping, so values stay readable
+func validKeyID(id string) bool {
+	if id == "" || len(id) > 32 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package keys
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	oldKey = strings.Repeat("01", 32)
+	newKey = strings.Repeat("02", 32)
+)
+
+func TestParseLocal(t *testing.T) {
+	tests := []struct {
+		name            string
+		spec            string
+		expectedCurrent string
+		wantErr         bool
+	}{
+		{name: "one key", spec: "k1=" + newKey, expectedCurrent: "k1"},
+		{name: "first is current", spec: "2026-10=" + newKey + ", 2026-01=" + oldKey, expectedCurrent: "2026-10"},
+		{name: "key file", spec: "# rotated 2026-10\n2026-10=" + newKey + "\n\n2026-01=" + oldKey + "\n", expectedCurrent: "2026-10"},
+		{name: "base64 key", spec: "k1=q6urq6urq6urq6urq6urq6urq6urq6urq6urq6urq6s=", expectedCurrent: "k1"},
+		{name: "no id", spec: newKey, wantErr: true},
+		{name: "id with a colon", spec: "k:1=" + newKey, wantErr: true},
+		{name: "duplicate id", spec: "k1=" + newKey + "\nk1=" + oldKey, wantErr: true},
+		{name: "short key", spec: "k1=abcd", wantErr: true},
+		{name: "only comments", spec: "# no keys yet\n , ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			local, err := ParseLocal(tt.spec)
+
+			// Assert
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			current, err := local.Current(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCurrent, current)
+		})
+	}
+}
+
+func TestLocal_EncryptDecrypt(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	before, err := ParseLocal("2026-01=" + oldKey)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "master.keys")
+	require.NoError(t, os.WriteFile(path, []byte("2026-10="+newKey+"\n2026-01="+oldKey+"\n"), 0o600))
+	after, err := LoadLocal(path)
+	require.NoError(t, err)
+
+	// Act
+	old, err := before.Encrypt(ctx, []byte("whsec_abc"))
+	require.NoError(t, err)
+	fresh, err := after.Encrypt(ctx, []byte("whsec_abc"))
+	require.NoError(t, err)
+	opened, err := after.Decrypt(ctx, old)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "whsec_abc", string(opened))
+	assert.Equal(t, "2026-01", old.Version)
+	assert.Equal(t, "2026-10", fresh.Version, "the current key encrypts")
+
+	_, err = before.Decrypt(ctx, fresh)
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+	_, err = after.Decrypt(ctx, Ciphertext{Version: "2026-10", Data: old.Data})
+	assert.ErrorIs(t, err, ErrMalformed)
+}
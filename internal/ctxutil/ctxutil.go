@@ -0,0 +1,109 @@
+// Package ctxutil holds the request metadata carried on a context.Context
+//
+// WHY TYPED KEYS?
+// context.WithValue(ctx, "request_id", id) works until another package
+// picks the same string for something else - then one silently overwrites
+// the other. A key of an unexported type can't collide: no other package
+// can even create one. go vet warns about string keys for the same reason.
+//
+// WHY ONE PACKAGE?
+// The middleware sets these values, and handlers, services and the logger
+// read them. With the keys and accessors in one place, every reader agrees
+// on what is stored and how, and a missing value is always "" instead of a
+// failed type assertion.
+package ctxutil
+
+import (
+	"context"
+	"log/slog"
+
+	"url-shortener/internal/auth"
+)
+
+// key is unexported, so only this package can read or write these values
+type key int
+
+const (
+	requestIDKey key = iota
+	clientIPKey
+	traceIDKey
+)
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the ID of the request ("" outside a request)
+func RequestID(ctx context.Context) string {
+	return stringValue(ctx, requestIDKey)
+}
+
+// WithClientIP returns a copy of ctx carrying the client's IP address
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// ClientIP returns the IP address of the client, without port
+// ("" outside a request)
+func ClientIP(ctx context.Context) string {
+	return stringValue(ctx, clientIPKey)
+}
+
+// WithTraceID returns a copy of ctx carrying the distributed trace ID
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceIDKey, id)
+}
+
+// TraceID returns the trace the request belongs to ("" if the caller sent none)
+func TraceID(ctx context.Context) string {
+	return stringValue(ctx, traceIDKey)
+}
+
+// Principal returns the authenticated caller, or auth.Anonymous
+// The principal itself is stored by package auth (see auth.WithPrincipal)
+func Principal(ctx context.Context) *auth.Principal {
+	return auth.FromContext(ctx)
+}
+
+// Metadata is everything known about the request behind a context
+type Metadata struct {
+	RequestID string
+	ClientIP  string
+	TraceID   string
+	Principal *auth.Principal
+}
+
+// MetadataFrom collects the request metadata of ctx
+func MetadataFrom(ctx context.Context) Metadata {
+	return Metadata{
+		RequestID: RequestID(ctx),
+		ClientIP:  ClientIP(ctx),
+		TraceID:   TraceID(ctx),
+		Principal: Principal(ctx),
+	}
+}
+
+// LogAttrs returns the metadata as slog key/value pairs
+// Empty values are left out, so logs outside a request stay short
+func (m Metadata) LogAttrs() []any {
+	var attrs []any
+	add := func(name, value string) {
+		if value != "" {
+			attrs = append(attrs, slog.String(name, value))
+		}
+	}
+	add("request_id", m.RequestID)
+	add("client_ip", m.ClientIP)
+	add("trace_id", m.TraceID)
+	if m.Principal != nil && m.Principal != auth.Anonymous {
+		add("principal", m.Principal.ID)
+	}
+	return attrs
+}
+
+// stringValue reads a string value, "" if it is missing
+func stringValue(ctx context.Context, k key) string {
+	value, _ := ctx.Value(k).(string)
+	return value
+}
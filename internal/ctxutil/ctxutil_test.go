@@ -0,0 +1,66 @@
+package ctxutil
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"url-shortener/internal/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessors_RoundTrip(t *testing.T) {
+	// Arrange
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = WithClientIP(ctx, "203.0.113.7")
+	ctx = WithTraceID(ctx, "4bf92f3577b34da6a3ce929d0e0e4736")
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{ID: "alice"})
+
+	// Act
+	meta := MetadataFrom(ctx)
+
+	// Assert
+	assert.Equal(t, "req-1", meta.RequestID)
+	assert.Equal(t, "203.0.113.7", meta.ClientIP)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", meta.TraceID)
+	assert.Equal(t, "alice", meta.Principal.ID)
+}
+
+// foreignKey stands for another package's key that happens to use the same name
+type foreignKey string
+
+func TestAccessors_OtherPackagesKeysDoNotCollide(t *testing.T) {
+	// Arrange: someone else stores a "request_id" key
+	ctx := context.WithValue(context.Background(), foreignKey("request_id"), "not ours")
+
+	// Act & Assert
+	assert.Empty(t, RequestID(ctx))
+	assert.Equal(t, "mine", RequestID(WithRequestID(ctx, "mine")))
+}
+
+func TestMetadata_LogAttrs(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want []any
+	}{
+		{name: "outside a request", ctx: context.Background(), want: nil},
+		{
+			name: "anonymous request",
+			ctx:  WithClientIP(WithRequestID(context.Background(), "req-1"), "203.0.113.7"),
+			want: []any{slog.String("request_id", "req-1"), slog.String("client_ip", "203.0.113.7")},
+		},
+		{
+			name: "authenticated request",
+			ctx:  auth.WithPrincipal(WithRequestID(context.Background(), "req-2"), &auth.Principal{ID: "alice"}),
+			want: []any{slog.String("request_id", "req-2"), slog.String("principal", "alice")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MetadataFrom(tt.ctx).LogAttrs())
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "strings"
+
+// AliasStatus describes whether a custom alias can still be claimed
+type AliasStatus string
+
+const (
+	AliasFree       AliasStatus = "free"       // Nobody uses it yet
+	AliasReserved   AliasStatus = "reserved"   // Blocked by the service, can never be claimed
+	AliasTaken      AliasStatus = "taken"      // Already used by another link
+	AliasConfusable AliasStatus = "confusable" // Looks like a popular or reserved alias (see ConfusableIndex)
+)
+
+// ValidateAlias checks an alias on its own, without building a URL
+// Returns the same errors Validate would return for the alias
+func ValidateAlias(alias string) error {
+	if !isValidAlias(alias) {
+		return ErrCustomAliasInvalid
+	}
+	if IsReservedAlias(alias) {
+		return ErrCustomAliasReserved
+	}
+	return nil
+}
+
+// reservedAliases can never be used as custom aliases
+// Most of them collide with the server's own routes (/metrics, /static/...),
+// the rest would make convincing phishing links
+var reservedAliases = map[string]bool{
+	"api": true, "admin": true, "static": true, "metrics": true, "metrics-raw": true,
+	"health": true, "docs": true, "login": true, "logout": true, "signup": true,
+	"account": true, "settings": true, "dashboard": true, "support": true, "help": true,
+	"www": true, "mail": true, "status": true, "favicon": true, "robots": true,
+}
+
+// IsReservedAlias reports whether an alias is reserved (case-insensitive)
+func IsReservedAlias(alias string) bool {
+	return reservedAliases[strings.ToLower(alias)]
+}
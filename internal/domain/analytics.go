@@ -0,0 +1,364 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Analytics errors
+var (
+	ErrInvalidTimezone  = errors.New("timezone must be an IANA name like Europe/Berlin")
+	ErrInvalidInterval  = errors.New("interval must be hour, day, week or month")
+	ErrInvalidTimeRange = errors.New("invalid time range")
+)
+
+// Channel is the kind of place a click came from (see referrer.Classifier)
+type Channel string
+
+const (
+	ChannelDirect   Channel = "direct"   // No referrer: typed in, bookmarks, apps, privacy settings
+	ChannelSearch   Channel = "search"   // Search engines
+	ChannelSocial   Channel = "social"   // Social networks and chat apps
+	ChannelEmail    Channel = "email"    // Webmail and mail apps
+	ChannelInternal Channel = "internal" // Our own pages
+	ChannelReferral Channel = "referral" // Any other website
+)
+
+// AnalyticsReason explains why a link's clicks are not recorded one by one
+type AnalyticsReason string
+
+const (
+	AnalyticsDisabledGlobally AnalyticsReason = "disabled_globally" // ENABLE_ANALYTICS=false on the server
+	AnalyticsDisabledForLink  AnalyticsReason = "disabled_for_link" // Created with analytics: false
+)
+
+// AnalyticsStatus says whether click events are recorded for a link
+//
+// WHY TWO SWITCHES?
+// The operator can turn analytics off for the whole server (ENABLE_ANALYTICS),
+// and a link owner for a single link. Either way the click counter keeps
+// counting - only the individual click events (time, referrer, device) are
+// not stored. Stats responses carry the status, so an empty click list can
+// be told apart from a link nobody opened.
+type AnalyticsStatus struct {
+	Enabled bool
+	Reason  AnalyticsReason // Why it is off ("" when enabled)
+}
+
+// Analytics returns the analytics status of u, given the server-wide setting
+// The server-wide switch wins: it is reported even if the link opted out too
+func (u *URL) Analytics(enabledGlobally bool) AnalyticsStatus {
+	switch {
+	case !enabledGlobally:
+		return AnalyticsStatus{Reason: AnalyticsDisabledGlobally}
+	case u.AnalyticsDisabled:
+		return AnalyticsStatus{Reason: AnalyticsDisabledForLink}
+	default:
+		return AnalyticsStatus{Enabled: true}
+	}
+}
+
+// ClickDimension is a click attribute analytics can be broken down by
+type ClickDimension string
+
+const (
+	DimensionChannel ClickDimension = "channel"
+	DimensionBrowser ClickDimension = "browser"
+	DimensionOS      ClickDimension = "os"
+	DimensionDevice  ClickDimension = "device"
+	DimensionRegion  ClickDimension = "region"
+)
+
+// ClickSummary aggregates the clicks of one URL
+// Breakdowns map a value (e.g. "social") to its number of clicks; clicks
+// recorded before a dimension existed are counted as "unknown"
+type ClickSummary struct {
+	URL              *URL
+	Channels         map[string]int64
+	Devices          map[string]int64
+	Browsers         map[string]int64
+	OperatingSystems map[string]int64
+	Regions          map[string]int64 // Deployment region that served the clicks
+	Daily            *ClickTimeseries // Clicks per day of the last SummaryDays days
+	Comparison       *ClickComparison // This week vs the week before
+}
+
+// SummaryDays is how many days of daily clicks a ClickSummary includes
+const SummaryDays = 30
+
+// Trend classifies where a link's clicks are heading
+type Trend string
+
+const (
+	TrendRising   Trend = "rising"
+	TrendFalling  Trend = "falling"
+	TrendSteady   Trend = "steady"
+	TrendNew      Trend = "new"      // Clicks now, none the period before
+	TrendInactive Trend = "inactive" // No clicks in either period
+)
+
+// Trend thresholds
+// A change counts as rising/falling when it is at least TrendThreshold of
+// the previous period AND at least TrendMinClicks clicks: 2 -> 3 clicks is
+// +50%, but it's noise, not a trend
+const (
+	ComparisonPeriod = 7 * 24 * time.Hour
+	TrendThreshold   = 0.10
+	TrendMinClicks   = 5
+)
+
+// ClickComparison compares the clicks of two back-to-back periods
+//
+//	PreviousFrom ---- Previous ---- CurrentFrom ---- Current ---- To
+type ClickComparison struct {
+	PreviousFrom time.Time
+	CurrentFrom  time.Time
+	To           time.Time
+	Previous     int64
+	Current      int64
+	Change       *float64 // (Current - Previous) / Previous; nil when Previous is 0
+	Trend        Trend
+}
+
+// CompareClicks fills in Change and Trend from the two counts
+func CompareClicks(previousFrom, currentFrom, to time.Time, previous, current int64) *ClickComparison {
+	comparison := &ClickComparison{
+		PreviousFrom: previousFrom,
+		CurrentFrom:  currentFrom,
+		To:           to,
+		Previous:     previous,
+		Current:      current,
+	}
+
+	switch {
+	case previous == 0 && current == 0:
+		comparison.Trend = TrendInactive
+		return comparison
+	case previous == 0:
+		comparison.Trend = TrendNew
+		return comparison
+	}
+
+	change := float64(current-previous) / float64(previous)
+	comparison.Change = &change
+
+	difference := current - previous
+	switch {
+	case change >= TrendThreshold && difference >= TrendMinClicks:
+		comparison.Trend = TrendRising
+	case change <= -TrendThreshold && -difference >= TrendMinClicks:
+		comparison.Trend = TrendFalling
+	default:
+		comparison.Trend = TrendSteady
+	}
+	return comparison
+}
+
+// Interval is the size of one bucket of a click timeseries
+type Interval string
+
+const (
+	IntervalHour  Interval = "hour"
+	IntervalDay   Interval = "day"
+	IntervalWeek  Interval = "week" // Starts on Monday, like PostgreSQL's date_trunc
+	IntervalMonth Interval = "month"
+)
+
+// MaxTimeseriesBuckets bounds one timeseries (e.g. 1000 hours = ~6 weeks)
+const MaxTimeseriesBuckets = 1000
+
+// defaultBuckets is how far back a timeseries goes when no start is given
+var defaultBuckets = map[Interval]int{
+	IntervalHour:  48,
+	IntervalDay:   SummaryDays,
+	IntervalWeek:  12,
+	IntervalMonth: 12,
+}
+
+// Valid reports whether i is a known interval
+func (i Interval) Valid() bool {
+	_, ok := defaultBuckets[i]
+	return ok
+}
+
+// Truncate returns the start of the bucket containing t, in t's location
+//
+// WHY NOT t.Truncate(24 * time.Hour)?
+// time.Truncate works on absolute time since year 1, so days would start
+// at midnight UTC. We want midnight on the wall clock of the viewer's
+// timezone - the same thing date_trunc does after AT TIME ZONE.
+func (i Interval) Truncate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	switch i {
+	case IntervalHour:
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, t.Location())
+	case IntervalWeek:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-daysSinceMonday, 0, 0, 0, 0, t.Location())
+	case IntervalMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// Next returns the start of the bucket after the one starting at start
+// Calendar arithmetic, so days stay days across DST changes (23 or 25 hours)
+func (i Interval) Next(start time.Time) time.Time {
+	year, month, day := start.Date()
+	switch i {
+	case IntervalHour:
+		return time.Date(year, month, day, start.Hour()+1, 0, 0, 0, start.Location())
+	case IntervalWeek:
+		return time.Date(year, month, day+7, 0, 0, 0, 0, start.Location())
+	case IntervalMonth:
+		return time.Date(year, month+1, 1, 0, 0, 0, 0, start.Location())
+	default:
+		return time.Date(year, month, day+1, 0, 0, 0, 0, start.Location())
+	}
+}
+
+// ClickBucket is the number of clicks in one interval
+type ClickBucket struct {
+	Start  time.Time // Start of the interval, in the timeseries' location
+	Clicks int64
+}
+
+// ClickTimeseries is the clicks of one URL per interval
+// Buckets cover From (inclusive) to To (exclusive) without gaps: intervals
+// without clicks are present with Clicks = 0, so charts need no filling
+type ClickTimeseries struct {
+	URL      *URL
+	Interval Interval
+	Location *time.Location // Timezone the buckets are aligned to
+	From     time.Time
+	To       time.Time
+	Buckets  []ClickBucket
+}
+
+// TimeseriesQuery describes the timeseries a client asked for
+// Every field is optional: the defaults are daily buckets for the last
+// 30 days in the link's workspace timezone
+type TimeseriesQuery struct {
+	Interval Interval
+	Timezone string // IANA name; "" = workspace default
+	From     string // RFC 3339 or YYYY-MM-DD (midnight in Timezone)
+	To       string // Same formats; the bucket containing To is included
+}
+
+// LoadTimezone returns the location of an IANA timezone name
+// "Local" is rejected: it would silently mean "whatever the server runs in",
+// and the database doesn't know it either
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return location, nil
+}
+
+// TimeRange turns the query into the buckets to count, in location
+// Returns the start of the first bucket and the end of the last one
+func (q TimeseriesQuery) TimeRange(now time.Time, location *time.Location) (from, to time.Time, err error) {
+	interval := q.Interval
+	if interval == "" {
+		interval = IntervalDay
+	}
+	if !interval.Valid() {
+		return time.Time{}, time.Time{}, ErrInvalidInterval
+	}
+
+	last := interval.Truncate(now.In(location))
+	if q.To != "" {
+		end, err := parseTimeBound(q.To, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		last = interval.Truncate(end)
+	}
+
+	first := last
+	if q.From != "" {
+		start, err := parseTimeBound(q.From, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		first = interval.Truncate(start)
+	} else {
+		for n := 1; n < defaultBuckets[interval]; n++ {
+			first = interval.Truncate(first.Add(-time.Nanosecond))
+		}
+	}
+
+	if first.After(last) {
+		return time.Time{}, time.Time{}, ErrInvalidTimeRange
+	}
+	buckets := 0
+	for start := first; !start.After(last); start = interval.Next(start) {
+		if buckets++; buckets > MaxTimeseriesBuckets {
+			return time.Time{}, time.Time{}, ErrInvalidTimeRange
+		}
+	}
+	return first, interval.Next(last), nil
+}
+
+// parseTimeBound reads an RFC 3339 timestamp or a YYYY-MM-DD date
+func parseTimeBound(value string, location *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(location), nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, location); err == nil {
+		return t, nil
+	}
+	return time.Time{}, ErrInvalidTimeRange
+}
+
+// FillBuckets returns one bucket per interval from from to to, taking the
+// counts from counted (sparse, as the database returns them)
+func FillBuckets(interval Interval, from, to time.Time, counted []ClickBucket) []ClickBucket {
+	// Keyed by wall clock: when clocks go back, the repeated hour is ONE
+	// bucket in PostgreSQL too (date_trunc works on the local timestamp)
+	const key = "2006-01-02T15"
+	counts := make(map[string]int64, len(counted))
+	for _, bucket := range counted {
+		counts[bucket.Start.In(from.Location()).Format(key)] += bucket.Clicks
+	}
+
+	var buckets []ClickBucket
+	for start := from; start.Before(to); start = interval.Next(start) {
+		buckets = append(buckets, ClickBucket{Start: start, Clicks: counts[start.Format(key)]})
+	}
+	return buckets
+}
+
+// HourOfWeek is one cell of the click heatmap
+type HourOfWeek struct {
+	Day    int // 0 = Monday ... 6 = Sunday (ISO 8601, like IntervalWeek)
+	Hour   int // 0-23, local wall clock
+	Clicks int64
+}
+
+// ClickHeatmap is the clicks of one URL per weekday and hour of the day
+// Shows WHEN a link's audience is active: "Tuesdays at 9" stands out even if
+// the link is months old, which a timeseries can't show
+type ClickHeatmap struct {
+	URL      *URL
+	Location *time.Location // Timezone the hours are counted in
+	From     time.Time      // Always a Monday midnight...
+	To       time.Time      // ...to a Monday midnight, so every weekday weighs the same
+	Clicks   [7][24]int64   // Clicks[day][hour], day 0 = Monday
+}
+
+// AddCells adds sparse counts (as the database returns them) to the matrix
+// Cells outside 7x24 are ignored; cells from several shards add up
+func (h *ClickHeatmap) AddCells(cells []HourOfWeek) {
+	for _, cell := range cells {
+		if cell.Day < 0 || cell.Day > 6 || cell.Hour < 0 || cell.Hour > 23 {
+			continue
+		}
+		h.Clicks[cell.Day][cell.Hour] += cell.Clicks
+	}
+}
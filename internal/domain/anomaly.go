@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// AnomalyKind identifies what was unusual about a link's clicks
+type AnomalyKind string
+
+// AnomalyClickSpike fires when a link gets far more clicks in an hour than
+// it usually does: a bot hammering it, or the link going viral
+const AnomalyClickSpike AnomalyKind = "click.spike"
+
+// ClickAnomaly is an unusual hour in a link's clicks
+// Recorded at most once per link and hour
+type ClickAnomaly struct {
+	Kind       AnomalyKind
+	URL        *URL
+	Hour       time.Time // Start of the unusual hour (UTC)
+	Clicks     int64     // Clicks in that hour when it was detected
+	Mean       float64   // Average clicks per hour in the baseline
+	StdDev     float64   // Standard deviation of the baseline
+	Score      float64   // How many standard deviations above the mean
+	DetectedAt time.Time
+}
+
+// HourlyClicks is the number of clicks a link got in one hour
+type HourlyClicks struct {
+	URL    *URL
+	Clicks int64
+}
+
+// SpikeDetector flags hours with far more clicks than the hours before
+//
+// HOW IT WORKS (a rolling z-score):
+//
+//	mean, stddev = statistics of the last BaselineHours hourly counts
+//	score        = (clicks this hour - mean) / stddev
+//	spike        = score >= Threshold AND clicks >= MinClicks
+//
+// A link with a flat baseline has stddev 0, which would make ANY extra click
+// infinitely unusual. Click counts are roughly Poisson distributed, where
+// the stddev is sqrt(mean), so that (at least 1) is used as the floor.
+type SpikeDetector struct {
+	BaselineHours int     // Hours before the checked one that make up "usual"
+	MinHistory    int     // Hours a link must have existed; younger links aren't checked
+	Threshold     float64 // Standard deviations above the mean that count as a spike
+	MinClicks     int64   // Quieter hours are never spikes (2 clicks instead of 0 isn't news)
+}
+
+// DefaultSpikeDetector compares an hour with the week before it
+var DefaultSpikeDetector = SpikeDetector{
+	BaselineHours: 7 * 24,
+	MinHistory:    24,
+	Threshold:     4,
+	MinClicks:     50,
+}
+
+// Check scores clicks against baseline (one count per hour, zeros included)
+func (d SpikeDetector) Check(clicks int64, baseline []int64) (mean, stddev, score float64, spike bool) {
+	if len(baseline) == 0 {
+		return 0, 0, 0, false
+	}
+
+	for _, count := range baseline {
+		mean += float64(count)
+	}
+	mean /= float64(len(baseline))
+
+	for _, count := range baseline {
+		diff := float64(count) - mean
+		stddev += diff * diff
+	}
+	stddev = math.Sqrt(stddev / float64(len(baseline)))
+
+	scale := math.Max(stddev, math.Max(math.Sqrt(mean), 1))
+	score = (float64(clicks) - mean) / scale
+	return mean, stddev, score, score >= d.Threshold && clicks >= d.MinClicks
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("api key needs a name (up to 100 characters) and at least one scope")
+	ErrAPIKeyExpiry   = errors.New("api key expiry must be in the future")
+)
+
+// API KEYS
+// Scripts, CI jobs and integrations authenticate with API keys. Each key
+// gets only the scopes its job needs (a dashboard that reads stats can't
+// delete links), so a leaked key does limited damage. Keys act for the
+// workspace that created them, and their scopes only ever narrow that.
+//
+// Keys can expire, and a rotated key's previous secret keeps working for a
+// grace period, so deployments can switch to the new one without downtime.
+
+// APIKey is a workspace's key; the secret itself is never stored
+type APIKey struct {
+	ID        string
+	Workspace string
+	Name      string   // What it is for, e.g. "CI deploy links"
+	Prefix    string   // Start of the secret, to tell keys apart in lists and logs
+	Scopes    []string // auth.Scope names
+	Admin     bool     // Created by an operator: with the admin scope it may use operator endpoints
+	CreatedBy string   // Who created it (Principal.Actor)
+	Plan      string   // Quota plan of its workspace, read with the key (see WorkspaceSettings.Plan)
+
+	CreatedAt  time.Time
+	LastUsedAt *time.Time // Updated at most once a minute
+	RotatedAt  *time.Time // When the secret was last replaced
+	ExpiresAt  *time.Time // nil = never expires
+
+	// PreviousExpiresAt is when the secret replaced by the last rotation
+	// stops working (nil = it already has)
+	PreviousExpiresAt *time.Time
+
+	// HashVersion is the pepper the current secret is hashed with
+	// ("" = plain SHA-256, from before API_KEY_PEPPER)
+	HashVersion string
+}
+
+// APIKeyRotation is how a key's secret is replaced
+type APIKeyRotation struct {
+	Grace     *time.Duration // How long the old secret keeps working (nil = the default)
+	ExpiresAt *time.Time     // New expiry (nil = unchanged)
+}
+
+// Expired reports whether the key no longer works at now
+func (k *APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// PreviousSecretValid reports whether the secret replaced by the last
+// rotation still works at now
+func (k *APIKey) PreviousSecretValid(now time.Time) bool {
+	return k.PreviousExpiresAt != nil && now.Before(*k.PreviousExpiresAt)
+}
+
+// Validate checks a key before it is created
+// Scope names themselves are checked by the service (auth.ParseScope)
+func (k *APIKey) Validate() error {
+	k.Name = strings.TrimSpace(k.Name)
+	if k.Name == "" || len(k.Name) > 100 || len(k.Scopes) == 0 {
+		return ErrInvalidAPIKey
+	}
+
+	// Duplicates are harmless, but they'd show up in every listing
+	seen := make(map[string]bool, len(k.Scopes))
+	scopes := k.Scopes[:0]
+	for _, scope := range k.Scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	k.Scopes = scopes
+	return nil
+}
@@ -0,0 +1,54 @@
+package domain
+
+import "errors"
+
+// ApprovalStatus is where a link stands in the approval workflow
+type ApprovalStatus string
+
+const (
+	ApprovalNone     ApprovalStatus = ""         // Never needed approval
+	ApprovalPending  ApprovalStatus = "pending"  // Waiting for an editor or owner
+	ApprovalApproved ApprovalStatus = "approved" // Approved; redirects like any link
+	ApprovalRejected ApprovalStatus = "rejected" // Turned down; never redirects
+)
+
+var (
+	// ErrNotPendingApproval is returned when deciding on a link that isn't waiting for approval
+	ErrNotPendingApproval = errors.New("link is not waiting for approval")
+
+	// ErrAwaitingApproval is returned when restoring a link that was never approved
+	ErrAwaitingApproval = errors.New("link has not been approved")
+)
+
+// MaxApprovalReason is the longest reason a reviewer may give (in characters)
+const MaxApprovalReason = 500
+
+// ErrInvalidApprovalReason is returned for a reason over MaxApprovalReason
+var ErrInvalidApprovalReason = errors.New("reason must be at most 500 characters")
+
+// LINK APPROVAL
+// Workspaces can require that links created by contributors are approved
+// before they go live (see WorkspaceSettings.RequireApproval):
+//
+//	contributor creates ──> pending ──approve──> approved (redirects)
+//	                           └────reject────> rejected (never redirects)
+//
+// A pending link is stored INACTIVE. Everything that serves links - the
+// redirect, the cache, the edge snapshot - already skips inactive links, so
+// a link can't go live by a path that forgot about approvals. Approving
+// activates it.
+
+// WithPendingApproval returns a URLOption that holds a new link for approval
+// member is the contributor who submitted it
+func WithPendingApproval(member string) URLOption {
+	return func(u *URL) {
+		u.Approval = ApprovalPending
+		u.SubmittedBy = member
+		u.IsActive = false
+	}
+}
+
+// AwaitsApproval reports whether the link was held for approval and never approved
+func (u *URL) AwaitsApproval() bool {
+	return u.Approval == ApprovalPending || u.Approval == ApprovalRejected
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPendingApproval(t *testing.T) {
+	// Arrange
+	url := NewURL("https://example.com", "abc123", "team1")
+
+	// Act
+	WithPendingApproval("dana@example.com")(url)
+
+	// Assert: held back until someone approves it
+	assert.Equal(t, ApprovalPending, url.Approval)
+	assert.Equal(t, "dana@example.com", url.SubmittedBy)
+	assert.False(t, url.IsActive)
+}
+
+func TestURL_AwaitsApproval(t *testing.T) {
+	tests := []struct {
+		status ApprovalStatus
+		want   bool
+	}{
+		{status: ApprovalNone, want: false},
+		{status: ApprovalPending, want: true},
+		{status: ApprovalApproved, want: false},
+		{status: ApprovalRejected, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			assert.Equal(t, tt.want, (&URL{Approval: tt.status}).AwaitsApproval())
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// AuditAction is what an audit entry records
+type AuditAction string
+
+const (
+	AuditLinkRevealed AuditAction = "link.revealed" // A burn-after-reading link was revealed
+	AuditLinkCreated  AuditAction = "link.created"
+	AuditLinkDeleted  AuditAction = "link.deleted" // Soft delete, can be restored
+	AuditLinkPurged   AuditAction = "link.purged"  // Deleted for good, with its analytics
+	AuditLinkExpired  AuditAction = "link.expired"
+	AuditAliasFlagged AuditAction = "alias.flagged" // The alias looks like a popular or reserved one
+
+	AuditApprovalRequested AuditAction = "approval.requested" // A contributor's link waits for approval
+	AuditLinkApproved      AuditAction = "link.approved"
+	AuditLinkRejected      AuditAction = "link.rejected"
+)
+
+// AuditActorSystem is the actor of entries nobody caused directly (expiration)
+const AuditActorSystem = "system"
+
+// AuditEntry records something that happened to a link, for its owner to
+// review later: who did it, from where, and when
+//
+// Unlike clicks, entries are never rolled up or archived - they are kept
+// until the link's owner is erased.
+type AuditEntry struct {
+	ID         string
+	Action     AuditAction
+	Workspace  string // Owner of the link (created_by)
+	URLID      string
+	ShortCode  string
+	Actor      string // Principal ID, or "anonymous" for visitors
+	IPAddress  string
+	UserAgent  string
+	OccurredAt time.Time
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrCertificateNotFound is returned when nothing is stored under a key
+var ErrCertificateNotFound = errors.New("certificate not found")
+
+// CertificateStatus is the HTTPS state of a host
+type CertificateStatus string
+
+const (
+	CertificateNone    CertificateStatus = "none"    // Not issued yet: the first HTTPS request asks for one
+	CertificateIssued  CertificateStatus = "issued"  // Valid; renewed before it expires
+	CertificateExpired CertificateStatus = "expired" // Renewals kept failing
+	CertificateFailed  CertificateStatus = "failed"  // Never issued; the next HTTPS request tries again
+)
+
+// Certificate is what we know about the TLS certificate of a host
+type Certificate struct {
+	Host      string
+	IssuedAt  *time.Time // When the current certificate was stored
+	NotAfter  *time.Time // When it expires (nil = no certificate)
+	LastError string     // Why the last issuance or renewal failed ("" after a success)
+	FailedAt  *time.Time
+}
+
+// Status summarizes the certificate at now
+// A certificate stays issued until it expires, even if an issuance failed
+// since: it is still served.
+func (c *Certificate) Status(now time.Time) CertificateStatus {
+	switch {
+	case c.NotAfter != nil && now.After(*c.NotAfter):
+		return CertificateExpired
+	case c.NotAfter != nil:
+		return CertificateIssued
+	case c.LastError != "":
+		return CertificateFailed
+	default:
+		return CertificateNone
+	}
+}
@@ -8,14 +8,75 @@ import "time"
 type URLClick struct {
 	ID          int64     // Auto-incrementing ID
 	URLID       string    // Foreign key to URL
+	ShortCode   string    // Short code of the URL; routes the click to the URL's shard (not stored)
 	ClickedAt   time.Time // When the click occurred
 	IPAddress   string    // IP address of the visitor
 	UserAgent   string    // Browser/client information
 	Referer     string    // Where the visitor came from
+	Channel     Channel   // Kind of referrer (search, social, ...)
+	Browser     string    // Browser family parsed from UserAgent (e.g. "Chrome")
+	OS          string    // Operating system family (e.g. "iOS")
+	DeviceType  string    // "desktop", "mobile", "tablet", "bot" or "unknown"
 	CountryCode string    // Geolocation: country (e.g., "US")
 	City        string    // Geolocation: city
+	Region      string    // Deployment region that served the redirect (e.g. "eu-west")
 }
 
+// ClickContext is what the redirect knows about a visit
+// The handler fills it from the request and the service turns it into a
+// URLClick. It is a struct so analytics can add fields (country, bot flag, ...)
+// without changing every signature and mock between the two.
+type ClickContext struct {
+	ShortCode string // Code the visitor opened
+	IPAddress string // Remote address, may include the port
+	UserAgent string
+	Referer   string
+	Consent   ConsentSignal // The visitor asked not to be tracked ("" = no signal)
+
+	// Set for clicks reported by edge redirectors (see ClickEvent), which
+	// arrive after the fact and were served elsewhere
+	ClickedAt time.Time // When the click happened (zero = now)
+	Region    string    // Where the redirect was served ("" = this instance's region)
+}
+
+// ClickEvent is a click reported by an external edge redirector
+// The redirect already happened at the edge; the event only feeds analytics
+type ClickEvent struct {
+	ID    string // Idempotency key chosen by the reporter (e.g. a UUID)
+	Click ClickContext
+}
+
+// IngestStatus is what happened to one reported click event
+type IngestStatus string
+
+const (
+	IngestAccepted  IngestStatus = "accepted"  // Recorded
+	IngestDuplicate IngestStatus = "duplicate" // Already recorded earlier - not counted again
+	IngestRejected  IngestStatus = "rejected"  // Can never be recorded (unknown link) - don't retry
+	IngestFailed    IngestStatus = "failed"    // Temporary problem - safe to retry with the same ID
+)
+
+// IngestOutcome is the result for one event of a batch
+type IngestOutcome struct {
+	ID     string
+	Status IngestStatus
+	Error  string // Why it was rejected or failed
+}
+
+// ConsentSignal is a browser's request not to be tracked
+//
+// WHY BOTH?
+// DNT (Do Not Track) is old and widely sent, but was never standardized.
+// Sec-GPC (Global Privacy Control) is its successor, and some privacy laws
+// (e.g. the CCPA) treat it as a legally valid opt-out. Both mean the same
+// thing to us: count the click, but don't keep who made it.
+type ConsentSignal string
+
+const (
+	ConsentDoNotTrack ConsentSignal = "dnt" // DNT: 1
+	ConsentGPC        ConsentSignal = "gpc" // Sec-GPC: 1
+)
+
 // NewURLClick creates a new click event
 func NewURLClick(urlID, ipAddress, userAgent, referer string) *URLClick {
 	return &URLClick{
@@ -27,6 +88,14 @@ func NewURLClick(urlID, ipAddress, userAgent, referer string) *URLClick {
 	}
 }
 
+// WithDevice adds the parsed User-Agent dimensions to the click event
+func (c *URLClick) WithDevice(browser, os, deviceType string) *URLClick {
+	c.Browser = browser
+	c.OS = os
+	c.DeviceType = deviceType
+	return c
+}
+
 // WithGeolocation adds geolocation data to the click event
 func (c *URLClick) WithGeolocation(countryCode, city string) *URLClick {
 	c.CountryCode = countryCode
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// CodeCase is how a deployment treats upper and lower case in short codes
+//
+// WHY NOT ALWAYS IGNORE CASE?
+// Existing links may differ only in case ("abc123" and "ABC123" can be two
+// links), and printed links must keep working. So ignoring case is a
+// choice per deployment, with a migration for the links created before.
+type CodeCase string
+
+const (
+	// CodeCaseSensitive keeps codes exactly as created (the default)
+	CodeCaseSensitive CodeCase = "sensitive"
+
+	// CodeCaseInsensitive stores new codes and aliases in lower case and
+	// finds a link however its code is typed: "ABC123" opens "abc123"
+	CodeCaseInsensitive CodeCase = "insensitive"
+)
+
+// ErrInvalidCodeCase is returned for an unknown case policy
+var ErrInvalidCodeCase = errors.New("short code case must be sensitive or insensitive")
+
+// ParseCodeCase reads a case policy ("" = sensitive)
+func ParseCodeCase(s string) (CodeCase, error) {
+	switch c := CodeCase(strings.ToLower(strings.TrimSpace(s))); c {
+	case "", CodeCaseSensitive:
+		return CodeCaseSensitive, nil
+	case CodeCaseInsensitive:
+		return c, nil
+	default:
+		return "", ErrInvalidCodeCase
+	}
+}
+
+// Normalize returns code the way the policy stores it
+func (c CodeCase) Normalize(code string) string {
+	if c == CodeCaseInsensitive {
+		return strings.ToLower(code)
+	}
+	return code
+}
+
+// CodeCaseReport is the outcome of lowercasing the codes of existing links
+// (see service.CodeCaseMigration)
+type CodeCaseReport struct {
+	Scanned    int      // Links with capitals in their short code or alias
+	Lowercased int      // Links lowercased (or that would be, in a dry run)
+	Conflicts  []string // Codes whose lowercase form another link already uses
+	Moved      []string // Codes whose lowercase form belongs on another shard
+}
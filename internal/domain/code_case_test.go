@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCodeCase(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    CodeCase
+		wantErr error
+	}{
+		{input: "", want: CodeCaseSensitive},
+		{input: "sensitive", want: CodeCaseSensitive},
+		{input: " Insensitive ", want: CodeCaseInsensitive},
+		{input: "lower", wantErr: ErrInvalidCodeCase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			// Act
+			got, err := ParseCodeCase(tt.input)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCodeCase_Normalize(t *testing.T) {
+	assert.Equal(t, "AbC123", CodeCaseSensitive.Normalize("AbC123"))
+	assert.Equal(t, "abc123", CodeCaseInsensitive.Normalize("AbC123"))
+	assert.Equal(t, "AbC123", CodeCase("").Normalize("AbC123"), "the zero value is case-sensitive")
+}
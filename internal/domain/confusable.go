@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrConfusableAlias is returned for a custom alias that looks like a popular
+// or reserved one (e.g. "paypa1" next to "paypal")
+var ErrConfusableAlias = errors.New("custom alias looks too much like an existing link")
+
+// ConfusablePolicy is what happens to a new alias that imitates another
+type ConfusablePolicy string
+
+const (
+	ConfusableOff    ConfusablePolicy = "off"    // No check
+	ConfusableFlag   ConfusablePolicy = "flag"   // Created, and reported for review
+	ConfusableReject ConfusablePolicy = "reject" // Refused with ErrConfusableAlias
+)
+
+// ErrInvalidConfusablePolicy is returned for an unknown policy
+var ErrInvalidConfusablePolicy = errors.New("confusable alias policy must be off, flag or reject")
+
+// ParseConfusablePolicy reads a confusable alias policy ("" = reject)
+func ParseConfusablePolicy(s string) (ConfusablePolicy, error) {
+	switch p := ConfusablePolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return ConfusableReject, nil
+	case ConfusableOff, ConfusableFlag, ConfusableReject:
+		return p, nil
+	default:
+		return "", ErrInvalidConfusablePolicy
+	}
+}
+
+// PopularAlias is a custom alias and how often its link was clicked
+type PopularAlias struct {
+	Alias  string
+	Clicks int64
+}
+
+// lookalikes maps characters to the ASCII letter they pass for
+// Cyrillic and Greek letters that look Latin, plus digits and symbols that
+// stand in for letters. Uppercase is folded before the lookup (except I,
+// see AliasSkeleton).
+var lookalikes = map[rune]rune{
+	'0': 'o', '1': 'l', '|': 'l',
+	// Cyrillic
+	'а': 'a', 'е': 'e', 'о': 'o', 'р': 'p', 'с': 'c', 'у': 'y', 'х': 'x',
+	'і': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd', 'һ': 'h', 'ԛ': 'q', 'ԝ': 'w', 'ӏ': 'l',
+	// Greek
+	'α': 'a', 'ο': 'o', 'ρ': 'p', 'ν': 'v', 'ι': 'i', 'κ': 'k', 'τ': 't', 'υ': 'u',
+	// Latin look-alikes
+	'ɡ': 'g', 'ı': 'i', 'ℓ': 'l',
+}
+
+// lookalikePairs are letter pairs that pass for one letter in most fonts
+var lookalikePairs = strings.NewReplacer("rn", "m", "vv", "w")
+
+// AliasSkeleton reduces an alias to what it LOOKS like
+//
+// WHY?
+// Phishing links imitate a trusted short link on the same instance:
+// "paypa1", "g00gle" or "аpple" with a Cyrillic "а" read as the real thing
+// at a glance. Two aliases with the same skeleton are confusable:
+//
+//	AliasSkeleton("PayPa1") == AliasSkeleton("paypal") == "paypal"
+//
+// Uppercase I becomes l (they look the same), fullwidth letters become ASCII,
+// "rn" becomes "m" and "vv" becomes "w". "_" and "-" count as the same.
+func AliasSkeleton(alias string) string {
+	var b strings.Builder
+	for _, r := range alias {
+		if r >= '！' && r <= '～' {
+			r -= 0xFEE0 // Fullwidth forms ("ａｂｃ") to ASCII
+		}
+		if r == 'I' {
+			r = 'l'
+		}
+		r = unicode.ToLower(r)
+		if l, ok := lookalikes[r]; ok {
+			r = l
+		}
+		if r == '_' {
+			r = '-'
+		}
+		b.WriteRune(r)
+	}
+	return lookalikePairs.Replace(b.String())
+}
+
+// ConfusableIndex finds the protected alias a new alias imitates
+// Protected are the reserved aliases and the popular ones it was built with
+type ConfusableIndex struct {
+	skeletons map[string]string // Skeleton -> protected alias
+}
+
+// NewConfusableIndex builds an index of the reserved aliases and popular
+func NewConfusableIndex(popular []string) *ConfusableIndex {
+	idx := &ConfusableIndex{skeletons: make(map[string]string, len(popular)+len(reservedAliases))}
+	for alias := range reservedAliases {
+		idx.skeletons[AliasSkeleton(alias)] = alias
+	}
+	for _, alias := range popular {
+		skeleton := AliasSkeleton(alias)
+		if _, ok := idx.skeletons[skeleton]; !ok {
+			idx.skeletons[skeleton] = alias
+		}
+	}
+	return idx
+}
+
+// Imitated returns the protected alias that alias looks like, or "" if none
+// The alias itself doesn't count: "paypal" doesn't imitate "paypal"
+func (idx *ConfusableIndex) Imitated(alias string) string {
+	protected, ok := idx.skeletons[AliasSkeleton(alias)]
+	if !ok || protected == alias {
+		return ""
+	}
+	return protected
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAliasSkeleton(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		match bool
+	}{
+		{name: "digit one for l", a: "paypa1", b: "paypal", match: true},
+		{name: "zero for o", a: "g00gle", b: "google", match: true},
+		{name: "capital I for l", a: "heIlo", b: "hello", match: true},
+		{name: "case", a: "PayPal", b: "paypal", match: true},
+		{name: "cyrillic a", a: "аpple", b: "apple", match: true},
+		{name: "greek omicron", a: "gοogle", b: "google", match: true},
+		{name: "fullwidth", a: "ｐａｙｐａｌ", b: "paypal", match: true},
+		{name: "rn for m", a: "rnicrosoft", b: "microsoft", match: true},
+		{name: "vv for w", a: "vvikipedia", b: "wikipedia", match: true},
+		{name: "underscore for dash", a: "black_friday", b: "black-friday", match: true},
+		{name: "different words", a: "paypal", b: "payday", match: false},
+		{name: "extra letter", a: "paypall", b: "paypal", match: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.match, AliasSkeleton(tt.a) == AliasSkeleton(tt.b))
+		})
+	}
+}
+
+func TestConfusableIndex_Imitated(t *testing.T) {
+	// Arrange
+	idx := NewConfusableIndex([]string{"paypal", "launch"})
+
+	// Act & Assert
+	assert.Equal(t, "paypal", idx.Imitated("paypa1"))
+	assert.Equal(t, "admin", idx.Imitated("adrnin"), "reserved aliases are always protected")
+	assert.Equal(t, "", idx.Imitated("paypal"), "an alias doesn't imitate itself")
+	assert.Equal(t, "", idx.Imitated("payday"))
+}
+
+func TestParseConfusablePolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ConfusablePolicy
+		wantErr error
+	}{
+		{input: "", want: ConfusableReject},
+		{input: "flag", want: ConfusableFlag},
+		{input: " OFF ", want: ConfusableOff},
+		{input: "warn", wantErr: ErrInvalidConfusablePolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			// Act
+			got, err := ParseConfusablePolicy(tt.input)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
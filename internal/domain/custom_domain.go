@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Custom domain errors
+var (
+	ErrCustomDomainNotFound    = errors.New("custom domain not found")
+	ErrCustomDomainTaken       = errors.New("custom domain is already registered")
+	ErrCustomDomainUnverified  = errors.New("custom domain is not verified; add its TXT record and verify it first")
+	ErrDomainChallengeNotFound = errors.New("verification TXT record not found")
+)
+
+// DomainStatus is where a custom domain is in the verification workflow
+type DomainStatus string
+
+const (
+	DomainPending  DomainStatus = "pending"  // Waiting for its TXT record to show up
+	DomainVerified DomainStatus = "verified" // Proven: links are served on it
+	DomainFailed   DomainStatus = "failed"   // Was verified, but its TXT record disappeared
+)
+
+// DomainChallengeLabel is prepended to the host for the TXT record name
+const DomainChallengeLabel = "_shortener-challenge"
+
+// CustomDomain is a customer's own host for their short links
+//
+// HOW VERIFICATION WORKS:
+// Anyone can point a domain at us, and anyone can type a domain into a
+// create request. Before we serve links on a host, its owner proves they
+// control its DNS: they publish a TXT record with a random token at
+// ChallengeName, and we look it up (see service.CustomDomainService).
+//
+// Verified domains are checked again now and then. A record that is gone
+// means the domain may have changed hands - it goes back to failed and
+// stops serving until it is verified again.
+type CustomDomain struct {
+	ID         string
+	Host       string // Lowercase, e.g. "go.example.com"
+	Owner      string // Principal that registered it
+	Token      string // Random challenge; the TXT record must contain ChallengeValue
+	Status     DomainStatus
+	CreatedAt  time.Time
+	VerifiedAt *time.Time // First successful check since it was (re)verified
+	CheckedAt  *time.Time // Last lookup, successful or not (nil = never checked)
+	LastError  string     // Why the last check failed ("" after a success)
+
+	Certificate *Certificate // HTTPS certificate (nil when automatic TLS is off)
+}
+
+// NewCustomDomain registers host for owner with a fresh challenge token
+func NewCustomDomain(host, owner string) (*CustomDomain, error) {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if !IsValidHost(host) {
+		return nil, ErrInvalidDomain
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	return &CustomDomain{
+		Host:      host,
+		Owner:     owner,
+		Token:     hex.EncodeToString(token),
+		Status:    DomainPending,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// ChallengeName is the DNS name of the TXT record to publish
+func (d *CustomDomain) ChallengeName() string {
+	return DomainChallengeLabel + "." + d.Host
+}
+
+// ChallengeValue is the text the TXT record must contain
+func (d *CustomDomain) ChallengeValue() string {
+	return "shortener-verification=" + d.Token
+}
+
+// IsVerified reports whether links may be served on the domain
+func (d *CustomDomain) IsVerified() bool {
+	return d.Status == DomainVerified
+}
+
+// RecordCheck updates the status with the TXT records found at now
+// records == nil with a nil lookupErr means the name has no TXT records.
+// A failed lookup (timeout, SERVFAIL) says nothing about the domain: the
+// status stays, only LastError changes, so a DNS hiccup never takes a
+// verified domain offline.
+func (d *CustomDomain) RecordCheck(records []string, lookupErr error, now time.Time) {
+	d.CheckedAt = &now
+	if lookupErr != nil {
+		d.LastError = lookupErr.Error()
+		return
+	}
+
+	if slices.Contains(records, d.ChallengeValue()) {
+		if d.Status != DomainVerified {
+			d.VerifiedAt = &now
+		}
+		d.Status = DomainVerified
+		d.LastError = ""
+		return
+	}
+
+	// Not published yet (pending) or removed since (verified -> failed)
+	if d.Status == DomainVerified {
+		d.Status = DomainFailed
+	}
+	d.VerifiedAt = nil
+	d.LastError = ErrDomainChallengeNotFound.Error()
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// RollupRetention is how long the hourly click rollups are kept
+// The longest leaderboard period (Period30Days) plus a day, so a period
+// that starts mid-hour still finds its first hour
+const RollupRetention = 31 * 24 * time.Hour
+
+// DigestPeriod is how far back the click digest looks
+const DigestPeriod = 7 * 24 * time.Hour
+
+// EventClickDigest is the notification type of the weekly click digest
+const EventClickDigest = "digest.weekly"
+
+// ClickDigest sums up one owner's clicks over the digest period
+type ClickDigest struct {
+	Owner     string
+	Links     int64  // Links that were clicked in the period
+	Clicks    int64  // Clicks on all of them
+	TopLink   string // Short code of the most clicked link
+	TopClicks int64  // Clicks on TopLink
+}
@@ -0,0 +1,12 @@
+package domain
+
+// CreationPreview is what creating a link would produce, from a dry run
+// that ran every check of the creation without storing anything
+//
+// A dry run is a snapshot: the alias may be taken and the quota used up
+// between the check and the real request.
+type CreationPreview struct {
+	URL      *URL   // The link as it would be stored; ShortCode is "" when it would be generated
+	Imitates string // Popular alias the custom alias looks like (the link would be flagged for review)
+	Usage    *Usage // The caller's quota before this link (nil = no quota)
+}
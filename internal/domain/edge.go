@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidSnapshotVersion = errors.New("snapshot version must be the X-Snapshot-Version of an earlier snapshot")
+	ErrSnapshotExpired        = errors.New("snapshot version is too old for a delta; download a full snapshot")
+)
+
+// ServableAtEdge reports whether an edge worker can answer the link on its own
+//
+// The edge gets the destination and the rules that pick it (schedule,
+// language targets), so it can redirect without asking us. It can't:
+//   - count clicks toward a limit or use up a single-use link
+//   - check a signature (it doesn't get the secret)
+//   - tell crawlers from visitors for the preview card
+//   - show burn-after-reading secrets, files or pastes (no redirect at all)
+//   - decrypt an encrypted destination (it doesn't get the keys)
+//
+// Links like these stay on the Go service - the edge passes them through.
+// Whether the link is active and not expired is checked separately: that
+// changes over time, the kind of link doesn't.
+func (u *URL) ServableAtEdge() bool {
+	return u.MaxClicks == nil &&
+		u.Preview == nil &&
+		u.SigningSecret == nil &&
+		!u.SingleUse &&
+		!u.DestinationSealed() &&
+		u.Redirects()
+}
+
+// EdgeChange is one entry of the edge change log
+// URL is the link as it is now; for a deleted link (Removed) only its codes
+// and domain are set
+type EdgeChange struct {
+	Seq     int64 // Position in the change log of the link's shard
+	URL     *URL
+	Removed bool
+}
+
+// SnapshotVersion identifies the state of every shard an edge snapshot was
+// taken from: one change log position per shard, in shard order
+//
+// Clients treat it as an opaque string ("42" or "42.17.9" with shards) and
+// send it back as ?since= to get only what changed after it.
+type SnapshotVersion []int64
+
+// ParseSnapshotVersion parses the String form of a version taken from
+// shards shards
+// A version from a different number of shards is ErrSnapshotExpired: the
+// positions can't be matched to the shards any more
+func ParseSnapshotVersion(s string, shards int) (SnapshotVersion, error) {
+	parts := strings.Split(s, ".")
+	version := make(SnapshotVersion, len(parts))
+	for i, part := range parts {
+		seq, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || seq < 0 {
+			return nil, ErrInvalidSnapshotVersion
+		}
+		version[i] = seq
+	}
+	if len(version) != shards {
+		return nil, ErrSnapshotExpired
+	}
+	return version, nil
+}
+
+// String returns the positions joined by dots
+func (v SnapshotVersion) String() string {
+	parts := make([]string, len(v))
+	for i, seq := range v {
+		parts[i] = strconv.FormatInt(seq, 10)
+	}
+	return strings.Join(parts, ".")
+}
+
+// EdgeSnapshot describes one download of the edge snapshot
+// Since is nil for a full snapshot; a delta holds the changes after Since up
+// to and including Version
+type EdgeSnapshot struct {
+	Since   SnapshotVersion
+	Version SnapshotVersion
+}
+
+// IsDelta reports whether only the changes after Since are sent
+func (s *EdgeSnapshot) IsDelta() bool {
+	return s.Since != nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErasureStatus is the lifecycle of an account deletion request
+type ErasureStatus string
+
+const (
+	ErasurePending   ErasureStatus = "pending"   // Waiting for the background worker
+	ErasureRunning   ErasureStatus = "running"   // Data is being deleted
+	ErasureCompleted ErasureStatus = "completed" // Everything deleted and verified
+	ErasureFailed    ErasureStatus = "failed"    // Verification failed or the worker hit an error
+)
+
+var ErrErasureNotFound = errors.New("erasure request not found")
+
+// ErasureRequest is a GDPR "right to erasure" request for everything an owner created
+//
+// Once completed, Owner is replaced by SubjectHash so the request itself no
+// longer identifies the person - the receipt proves WHAT was deleted without
+// keeping WHO it belonged to
+type ErasureRequest struct {
+	ID            string
+	Owner         string // created_by value whose data is deleted (cleared on completion)
+	SubjectHash   string // SHA-256 of Owner, kept for the receipt
+	RequestedBy   string // Principal that asked (the owner, or an admin)
+	Status        ErasureStatus
+	URLsDeleted   int64
+	ClicksDeleted int64
+	Error         string
+	RequestedAt   time.Time
+	CompletedAt   *time.Time
+}
+
+// NewErasureRequest creates a pending erasure request
+func NewErasureRequest(owner, requestedBy string) *ErasureRequest {
+	return &ErasureRequest{
+		ID:          uuid.New().String(),
+		Owner:       owner,
+		SubjectHash: SubjectHash(owner),
+		RequestedBy: requestedBy,
+		Status:      ErasurePending,
+		RequestedAt: time.Now(),
+	}
+}
+
+// IsFinished reports whether the worker is done with the request
+func (e *ErasureRequest) IsFinished() bool {
+	return e.Status == ErasureCompleted || e.Status == ErasureFailed
+}
+
+// SubjectHash returns the hex SHA-256 of an owner ID
+// The owner can prove a receipt is theirs by hashing their own ID
+func SubjectHash(owner string) string {
+	sum := sha256.Sum256([]byte(owner))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a cursor we didn't hand out
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ExportRecord is one URL in a data export, with its aggregate statistics
+type ExportRecord struct {
+	URL            *URL
+	UniqueVisitors int64      // Distinct IP addresses that clicked the URL
+	LastClickedAt  *time.Time // nil if the URL was never clicked
+}
+
+// ExportCursor marks where the previous export page ended
+// Pages are ordered by (created_at, id), so the cursor is the last row's pair
+type ExportCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// CursorAfter returns the cursor pointing just past this record
+func (r *ExportRecord) CursorAfter() *ExportCursor {
+	return CursorAfterURL(r.URL)
+}
+
+// CursorAfterURL returns the cursor pointing just past url
+func CursorAfterURL(url *URL) *ExportCursor {
+	return &ExportCursor{CreatedAt: url.CreatedAt, ID: url.ID}
+}
+
+// String encodes the cursor for clients: opaque, URL-safe
+func (c *ExportCursor) String() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "," + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseExportCursor decodes a cursor made by String
+func ParseExportCursor(s string) (*ExportCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	createdAt, id, found := strings.Cut(string(raw), ",")
+	if !found || id == "" {
+		return nil, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &ExportCursor{CreatedAt: t, ID: id}, nil
+}
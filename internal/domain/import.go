@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrImportJobNotFound is returned for unknown (or already pruned) import jobs
+var ErrImportJobNotFound = errors.New("import job not found")
+
+// ImportRow is one link read from an import file (Bitly export, TinyURL, generic CSV)
+type ImportRow struct {
+	Line  int    `json:"line"`  // Line number in the file (for error reporting)
+	Alias string `json:"alias"` // Alias to preserve, empty = generate a code
+	URL   string `json:"url"`   // Destination
+}
+
+// ImportRowStatus is the outcome of importing a single row
+type ImportRowStatus string
+
+const (
+	ImportRowCreated     ImportRowStatus = "created"
+	ImportRowWouldCreate ImportRowStatus = "would_create" // Dry run: the row is valid
+	ImportRowFailed      ImportRowStatus = "failed"
+)
+
+// ImportRowResult reports what happened to one row
+type ImportRowResult struct {
+	Line           int             `json:"line"`
+	Alias          string          `json:"alias,omitempty"`
+	URL            string          `json:"url"`
+	ShortCode      string          `json:"short_code,omitempty"` // Code the link got (or would get, for kept aliases in a dry run)
+	AliasPreserved bool            `json:"alias_preserved"`      // False when the alias was taken/reserved/invalid and a code was generated
+	Status         ImportRowStatus `json:"status"`
+	Error          string          `json:"error,omitempty"`
+}
+
+// ImportState is the lifecycle of an import job
+type ImportState string
+
+const (
+	ImportPending   ImportState = "pending"
+	ImportRunning   ImportState = "running"
+	ImportCompleted ImportState = "completed"
+	ImportFailed    ImportState = "failed" // The background job gave up; Error says why
+)
+
+// ImportJob tracks a bulk import
+// Large files run in the background, so clients poll the job for progress
+type ImportJob struct {
+	ID         string
+	CreatedBy  string
+	DryRun     bool
+	State      ImportState
+	Total      int // Rows in the file
+	Processed  int // Rows handled so far
+	Created    int // Rows created (or valid, in a dry run)
+	Failed     int
+	Results    []ImportRowResult
+	Error      string // Why a failed job stopped
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// NewImportJob creates a pending job for the given rows
+func NewImportJob(createdBy string, total int, dryRun bool) *ImportJob {
+	return &ImportJob{
+		ID:        uuid.New().String(),
+		CreatedBy: createdBy,
+		DryRun:    dryRun,
+		State:     ImportPending,
+		Total:     total,
+		Results:   make([]ImportRowResult, 0, total),
+		CreatedAt: time.Now(),
+	}
+}
+
+// Progress returns the fraction of rows processed (0.0 - 1.0)
+func (j *ImportJob) Progress() float64 {
+	if j.Total == 0 {
+		return 1
+	}
+	return float64(j.Processed) / float64(j.Total)
+}
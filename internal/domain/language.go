@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// MaxLanguageTargets caps how many language overrides one link can have
+const MaxLanguageTargets = 20
+
+var ErrInvalidLanguageTargets = errors.New("language targets must map language tags (e.g. \"fr\", \"pt-br\") to http(s) URLs, at most 20")
+
+// Language targets send visitors to a destination in their own language
+//
+// The browser tells us which languages the visitor reads (the
+// Accept-Language header), and the owner maps languages to landing pages:
+//
+//	{"fr": "https://example.com/fr", "pt-br": "https://example.com/br"}
+//
+// A visitor who prefers French lands on /fr; anyone without a match gets
+// OriginalURL - it is ALWAYS the fallback, so a link never dead-ends.
+
+// WithLanguageTargets returns a URLOption that sets the language overrides
+// Tags are case-insensitive, so they are stored lowercase ("pt-BR" -> "pt-br")
+// An empty map removes every override
+func WithLanguageTargets(targets map[string]string) URLOption {
+	return func(u *URL) {
+		u.LanguageTargets = normalizeLanguageTargets(targets)
+	}
+}
+
+// normalizeLanguageTargets lowercases and trims tags and URLs (nil when empty)
+func normalizeLanguageTargets(targets map[string]string) map[string]string {
+	if len(targets) == 0 {
+		return nil
+	}
+	normalized := make(map[string]string, len(targets))
+	for tag, destination := range targets {
+		normalized[strings.ToLower(strings.TrimSpace(tag))] = strings.TrimSpace(destination)
+	}
+	return normalized
+}
+
+// validateLanguageTargets checks every tag and destination
+func validateLanguageTargets(targets map[string]string) error {
+	if len(targets) > MaxLanguageTargets {
+		return ErrInvalidLanguageTargets
+	}
+	for tag, destination := range targets {
+		if !isValidLanguageTag(tag) {
+			return ErrInvalidLanguageTargets
+		}
+		if !IsHTTPURL(destination) {
+			return ErrInvalidLanguageTargets
+		}
+	}
+	return nil
+}
+
+// isValidLanguageTag accepts a primary language with an optional region or
+// script ("fr", "pt-br", "es-419", "zh-hant")
+// The language itself is a 2-3 letter ISO 639 code, so "french" is rejected
+// "*" is not a valid key either - the fallback is OriginalURL
+func isValidLanguageTag(tag string) bool {
+	primary, subtag, hasSubtag := strings.Cut(tag, "-")
+	if len(primary) < 2 || len(primary) > 3 {
+		return false
+	}
+	for _, char := range primary {
+		if char < 'a' || char > 'z' {
+			return false
+		}
+	}
+	if !hasSubtag {
+		return true
+	}
+
+	if len(subtag) < 2 || len(subtag) > 8 {
+		return false
+	}
+	for _, char := range subtag {
+		if !(char >= 'a' && char <= 'z') && !(char >= '0' && char <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// DestinationFor picks where to send a visitor who reads languages, most
+// preferred first (see the Accept-Language parsing in the HTTP handler)
+//
+// HOW MATCHING WORKS, for each language in order:
+//  1. Exact tag: "pt-br" matches the "pt-br" target
+//  2. Primary language: "pt-br" also matches a plain "pt" target
+//
+// The first match wins, so a visitor preferring "de, fr" gets the French page
+// only when there is no German one. No match at all -> OriginalURL.
+func (u *URL) DestinationFor(languages []string) string {
+	if len(u.LanguageTargets) == 0 {
+		return u.OriginalURL
+	}
+	for _, language := range languages {
+		language = strings.ToLower(language)
+		if destination, ok := u.LanguageTargets[language]; ok {
+			return destination
+		}
+		if primary, _, found := strings.Cut(language, "-"); found {
+			if destination, ok := u.LanguageTargets[primary]; ok {
+				return destination
+			}
+		}
+	}
+	return u.OriginalURL
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrInvalidPeriod is returned for a leaderboard period we don't offer
+var ErrInvalidPeriod = errors.New("period must be 24h, 7d or 30d")
+
+// LeaderboardPeriod is how far back the top links leaderboard looks
+type LeaderboardPeriod string
+
+const (
+	Period24Hours LeaderboardPeriod = "24h"
+	Period7Days   LeaderboardPeriod = "7d"
+	Period30Days  LeaderboardPeriod = "30d" // Longest: the click rollups are backfilled this far
+)
+
+// Leaderboard sizes
+const (
+	DefaultLeaderboardLimit = 10
+	MaxLeaderboardLimit     = 100
+)
+
+// Duration returns the length of the period, ok=false for unknown periods
+func (p LeaderboardPeriod) Duration() (time.Duration, bool) {
+	switch p {
+	case Period24Hours:
+		return 24 * time.Hour, true
+	case Period7Days:
+		return 7 * 24 * time.Hour, true
+	case Period30Days:
+		return 30 * 24 * time.Hour, true
+	default:
+		return 0, false
+	}
+}
+
+// TopLink is one row of the leaderboard
+// Just what the dashboard shows, so cached leaderboards stay small
+type TopLink struct {
+	URLID          string
+	ShortCode      string
+	Domain         string // Custom short link domain; "" = the default one
+	OriginalURL    string
+	Clicks         int64
+	UniqueVisitors int64 // Distinct IP addresses in the period
+	Sensitive      bool  // VisibilitySensitive: viewers don't get OriginalURL
+}
+
+// Leaderboard is a workspace's most clicked links in a period
+type Leaderboard struct {
+	Period LeaderboardPeriod
+	From   time.Time // Start of the first counted hour
+	To     time.Time // When it was computed (cached leaderboards lag behind)
+	Links  []TopLink
+}
+
+// RankTopLinks sorts links by clicks (then unique visitors, then short
+// code, so equal links keep a stable order) and keeps the first limit
+func RankTopLinks(links []TopLink, limit int) []TopLink {
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Clicks != links[j].Clicks {
+			return links[i].Clicks > links[j].Clicks
+		}
+		if links[i].UniqueVisitors != links[j].UniqueVisitors {
+			return links[i].UniqueVisitors > links[j].UniqueVisitors
+		}
+		return links[i].ShortCode < links[j].ShortCode
+	})
+	if len(links) > limit {
+		links = links[:limit]
+	}
+	return links
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMemberNotFound  = errors.New("member not found")
+	ErrMemberExists    = errors.New("a member with this user name already exists")
+	ErrInvalidMember   = errors.New("member needs a user name (up to 255 characters)")
+	ErrMemberInactive  = errors.New("member is deactivated")
+	ErrNoWorkspaceRole = errors.New("member is in no group with a workspace role")
+
+	ErrGroupNotFound = errors.New("group not found")
+	ErrGroupExists   = errors.New("a group with this name already exists")
+	ErrInvalidGroup  = errors.New("group needs a display name (up to 255 characters)")
+
+	ErrSCIMTokenNotFound = errors.New("scim token not found")
+)
+
+// WORKSPACE MEMBERS
+// A shared workspace is one principal (it owns the links), used by many
+// people. Enterprise customers don't add those people by hand: their
+// identity provider provisions them over SCIM, puts them in groups, and
+// deactivates them when they leave the company.
+//
+// Members have no role of their own. The workspace maps group names to
+// roles (WorkspaceSettings.GroupRoles), and a member gets the highest role
+// of their groups - so access is managed where the customer manages
+// everything else: in the identity provider.
+
+// Member is a person provisioned into a workspace
+type Member struct {
+	ID          string
+	Workspace   string
+	UserName    string // Login, usually an email; unique per workspace, case-insensitive
+	ExternalID  string // The identity provider's ID for the person
+	DisplayName string
+	Email       string
+	Active      bool       // false = deprovisioned, locked out but kept
+	Groups      []GroupRef // Read-only: filled by the repository
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Group is a set of members; its name decides their role (see GroupRoles)
+type Group struct {
+	ID          string
+	Workspace   string
+	DisplayName string // Unique per workspace, case-insensitive
+	ExternalID  string
+	MemberIDs   []string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// GroupRef names one group of a member
+type GroupRef struct {
+	ID          string
+	DisplayName string
+}
+
+// MemberFilter narrows a member listing; empty fields match everything
+// Identity providers look members up before creating them
+type MemberFilter struct {
+	UserName   string // Case-insensitive
+	ExternalID string
+}
+
+// GroupFilter narrows a group listing; empty fields match everything
+type GroupFilter struct {
+	DisplayName string // Case-insensitive
+	ExternalID  string
+}
+
+// Validate checks a member before it is saved
+func (m *Member) Validate() error {
+	m.UserName = strings.TrimSpace(m.UserName)
+	if m.UserName == "" || len(m.UserName) > 255 || len(m.DisplayName) > 255 ||
+		len(m.ExternalID) > 255 || len(m.Email) > 255 {
+		return ErrInvalidMember
+	}
+	return nil
+}
+
+// Validate checks a group before it is saved
+func (g *Group) Validate() error {
+	g.DisplayName = strings.TrimSpace(g.DisplayName)
+	if g.DisplayName == "" || len(g.DisplayName) > 255 || len(g.ExternalID) > 255 {
+		return ErrInvalidGroup
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// LinkMetadata describes the destination page of a link
+// It is fetched in the background after a link is created, so UIs can show
+// "Spring Sale - Example Shop" instead of a long raw URL
+type LinkMetadata struct {
+	Title       string    // og:title, or the <title> of the page
+	Description string    // og:description, or the meta description
+	FaviconURL  string    // Absolute URL of the site icon ("" if unknown)
+	FetchedAt   time.Time // When the page was read
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits of a link's notes
+const (
+	MaxDescriptionLength     = 1000 // Characters
+	MaxCustomMetadataKeys    = 20
+	MaxCustomMetadataKeyLen  = 40   // Characters
+	MaxCustomMetadataValue   = 500  // Characters
+	MaxCustomMetadataPayload = 4096 // Bytes of all keys and values together
+)
+
+var (
+	ErrInvalidDescription    = errors.New("description must be at most 1000 characters")
+	ErrInvalidCustomMetadata = errors.New("custom metadata takes at most 20 keys (letters, digits, _ . -, up to 40 characters) with values up to 500 characters, 4 KB in all")
+)
+
+// Notes are what an owner or integrator writes about a link
+//
+//	description:     "Spring sale banner, homepage"
+//	custom_metadata: {"ticket": "MKT-142", "owner": "growth-team"}
+//
+// WHY ON THE LINK?
+// Integrations otherwise keep their own table mapping link IDs to ticket
+// numbers or campaign names, and it drifts. Notes never change how a link
+// redirects: the edge snapshot and the CDN don't see them.
+//
+// Custom metadata is for short keys and values, not documents - the limits
+// keep every row (and every list response) small.
+
+// WithDescription returns a URLOption that sets the description ("" removes it)
+func WithDescription(description string) URLOption {
+	return func(u *URL) {
+		u.Description = strings.TrimSpace(description)
+	}
+}
+
+// WithCustomMetadata returns a URLOption that replaces the custom metadata
+// An empty map removes every key
+func WithCustomMetadata(metadata map[string]string) URLOption {
+	return func(u *URL) {
+		if len(metadata) == 0 {
+			u.CustomMetadata = nil
+			return
+		}
+		u.CustomMetadata = make(map[string]string, len(metadata))
+		for key, value := range metadata {
+			u.CustomMetadata[strings.TrimSpace(key)] = value
+		}
+	}
+}
+
+// ValidateNotes checks the description and custom metadata against the limits
+func (u *URL) ValidateNotes() error {
+	if utf8.RuneCountInString(u.Description) > MaxDescriptionLength {
+		return ErrInvalidDescription
+	}
+	if len(u.CustomMetadata) > MaxCustomMetadataKeys {
+		return ErrInvalidCustomMetadata
+	}
+	payload := 0
+	for key, value := range u.CustomMetadata {
+		if !isValidMetadataKey(key) || utf8.RuneCountInString(value) > MaxCustomMetadataValue {
+			return ErrInvalidCustomMetadata
+		}
+		payload += len(key) + len(value)
+	}
+	if payload > MaxCustomMetadataPayload {
+		return ErrInvalidCustomMetadata
+	}
+	return nil
+}
+
+// isValidMetadataKey accepts 1-40 letters, digits, "_", "." and "-"
+func isValidMetadataKey(key string) bool {
+	if key == "" || len(key) > MaxCustomMetadataKeyLen {
+		return false
+	}
+	for _, char := range key {
+		if !((char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') ||
+			char == '_' || char == '.' || char == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURL_ValidateNotes(t *testing.T) {
+	tooManyKeys := make(map[string]string, MaxCustomMetadataKeys+1)
+	for i := range MaxCustomMetadataKeys + 1 {
+		tooManyKeys[fmt.Sprintf("key%d", i)] = "x"
+	}
+	tooLarge := make(map[string]string, 10)
+	for i := range 10 {
+		tooLarge[fmt.Sprintf("key%d", i)] = strings.Repeat("a", MaxCustomMetadataValue)
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		metadata    map[string]string
+		wantErr     error
+	}{
+		{name: "no notes"},
+		{name: "description and metadata", description: "Spring sale banner", metadata: map[string]string{"ticket": "MKT-142", "team.name": "growth-team"}},
+		{name: "longest description (in characters)", description: strings.Repeat("é", MaxDescriptionLength)},
+		{name: "description too long", description: strings.Repeat("a", MaxDescriptionLength+1), wantErr: ErrInvalidDescription},
+		{name: "too many keys", metadata: tooManyKeys, wantErr: ErrInvalidCustomMetadata},
+		{name: "empty key", metadata: map[string]string{"": "x"}, wantErr: ErrInvalidCustomMetadata},
+		{name: "key with spaces", metadata: map[string]string{"cost center": "x"}, wantErr: ErrInvalidCustomMetadata},
+		{name: "key too long", metadata: map[string]string{strings.Repeat("k", MaxCustomMetadataKeyLen+1): "x"}, wantErr: ErrInvalidCustomMetadata},
+		{name: "value too long", metadata: map[string]string{"note": strings.Repeat("a", MaxCustomMetadataValue+1)}, wantErr: ErrInvalidCustomMetadata},
+		{name: "payload too large", metadata: tooLarge, wantErr: ErrInvalidCustomMetadata},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			url := &URL{Description: tt.description, CustomMetadata: tt.metadata}
+
+			// Act
+			err := url.ValidateNotes()
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNotesOptions(t *testing.T) {
+	// Arrange
+	url := &URL{}
+
+	// Act
+	WithDescription("  Spring sale banner \n")(url)
+	WithCustomMetadata(map[string]string{" ticket ": "MKT-142"})(url)
+
+	// Assert
+	assert.Equal(t, "Spring sale banner", url.Description)
+	assert.Equal(t, map[string]string{"ticket": "MKT-142"}, url.CustomMetadata)
+
+	// An empty map removes every key
+	WithCustomMetadata(map[string]string{})(url)
+	assert.Nil(t, url.CustomMetadata)
+}
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrPageNotFound  = errors.New("page not found")
+	ErrHandleTaken   = errors.New("handle is already taken")
+	ErrInvalidHandle = errors.New("handle must be 3-30 characters: lowercase letters, digits and underscores")
+	ErrInvalidTheme  = errors.New("theme colors must be hex colors like #1a2b3c and the button shape rounded, pill or square")
+	ErrInvalidBlock  = errors.New("every block needs a title (up to 80 characters) and an http(s) URL")
+	ErrTooManyBlocks = errors.New("a page can have at most 50 blocks")
+	ErrBlockNotFound = errors.New("block not found")
+	ErrPageTooLong   = errors.New("title must be at most 100 characters and bio at most 500")
+)
+
+// MaxPageBlocks limits how many links one page can hold
+const MaxPageBlocks = 50
+
+var (
+	handlePattern   = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+	hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	buttonShapes    = map[string]bool{"rounded": true, "pill": true, "square": true}
+	reservedHandles = map[string]bool{"admin": true, "api": true, "static": true, "help": true}
+)
+
+// DefaultPageTheme is used for every theme value a page doesn't set
+var DefaultPageTheme = PageTheme{
+	Background:  "#f5f5f5",
+	Text:        "#222222",
+	Button:      "#ffffff",
+	ButtonText:  "#222222",
+	ButtonShape: "rounded",
+}
+
+// Page is a link-in-bio profile page served at /@{handle}
+// It shows a title, a short bio and an ordered list of link blocks
+type Page struct {
+	ID        string
+	Handle    string // Public name in the URL (/@handle), unique, lowercase
+	Owner     string // Principal that manages the page
+	Title     string
+	Bio       string
+	Theme     PageTheme
+	Blocks    []PageBlock // In display order
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PageTheme holds the look of a page
+// Colors are CSS hex colors - they end up in a <style> block, so only a
+// strict format is accepted
+type PageTheme struct {
+	Background  string `json:"background"`
+	Text        string `json:"text"`
+	Button      string `json:"button"`
+	ButtonText  string `json:"button_text"`
+	ButtonShape string `json:"button_shape"` // "rounded", "pill" or "square"
+}
+
+// PageBlock is one link on a page
+// Clicks counts click-throughs from the page (block analytics)
+type PageBlock struct {
+	ID       string // Stable across edits, so click counts survive reordering
+	Title    string
+	URL      string
+	Position int
+	Clicks   int64
+}
+
+// Normalize fills in defaults and canonical forms before validation
+// Missing theme values take the default theme, the handle is lowercased and
+// block positions follow the slice order
+func (p *Page) Normalize() {
+	p.Handle = strings.ToLower(strings.TrimSpace(p.Handle))
+	p.Title = strings.TrimSpace(p.Title)
+	p.Bio = strings.TrimSpace(p.Bio)
+
+	theme := &p.Theme
+	if theme.Background == "" {
+		theme.Background = DefaultPageTheme.Background
+	}
+	if theme.Text == "" {
+		theme.Text = DefaultPageTheme.Text
+	}
+	if theme.Button == "" {
+		theme.Button = DefaultPageTheme.Button
+	}
+	if theme.ButtonText == "" {
+		theme.ButtonText = DefaultPageTheme.ButtonText
+	}
+	if theme.ButtonShape == "" {
+		theme.ButtonShape = DefaultPageTheme.ButtonShape
+	}
+
+	for i := range p.Blocks {
+		p.Blocks[i].Title = strings.TrimSpace(p.Blocks[i].Title)
+		p.Blocks[i].Position = i
+	}
+}
+
+// Validate checks the page before it is saved
+func (p *Page) Validate() error {
+	if !handlePattern.MatchString(p.Handle) || reservedHandles[p.Handle] {
+		return ErrInvalidHandle
+	}
+	if len(p.Title) > 100 || len(p.Bio) > 500 {
+		return ErrPageTooLong
+	}
+
+	theme := p.Theme
+	for _, color := range []string{theme.Background, theme.Text, theme.Button, theme.ButtonText} {
+		if !hexColorPattern.MatchString(color) {
+			return ErrInvalidTheme
+		}
+	}
+	if !buttonShapes[theme.ButtonShape] {
+		return ErrInvalidTheme
+	}
+
+	if len(p.Blocks) > MaxPageBlocks {
+		return ErrTooManyBlocks
+	}
+	for _, block := range p.Blocks {
+		if block.Title == "" || len(block.Title) > 80 {
+			return ErrInvalidBlock
+		}
+		if !IsHTTPURL(block.URL) {
+			return ErrInvalidBlock
+		}
+	}
+	return nil
+}
+
+// Block returns the block with the given ID
+func (p *Page) Block(id string) (*PageBlock, bool) {
+	for i := range p.Blocks {
+		if p.Blocks[i].ID == id {
+			return &p.Blocks[i], true
+		}
+	}
+	return nil, false
+}
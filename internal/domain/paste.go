@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxPasteSize is the largest paste in bytes
+// Pastes live in the link's row (and so in the cache): keep them small,
+// bigger things are files (see WithFile)
+const MaxPasteSize = 128 << 10
+
+// Paste errors
+var (
+	ErrInvalidPaste  = errors.New("a paste needs some UTF-8 text and an optional language like \"go\" or \"c++\"")
+	ErrPasteTooLarge = errors.New("paste is too large")
+)
+
+// pasteLanguagePattern is what a highlight language looks like
+// Lower-case names as used by highlighters: go, python, c++, c#, objective-c
+var pasteLanguagePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9+#.-]{0,31}$`)
+
+// Paste is a text or code snippet a link shows instead of redirecting
+type Paste struct {
+	Content  string `json:"content"`
+	Language string `json:"language,omitempty"` // Syntax highlighting hint ("" = plain text)
+}
+
+// Validate checks the paste's content and language
+func (p *Paste) Validate() error {
+	if len(p.Content) > MaxPasteSize {
+		return ErrPasteTooLarge
+	}
+	if strings.TrimSpace(p.Content) == "" || !utf8.ValidString(p.Content) {
+		return ErrInvalidPaste
+	}
+	if p.Language != "" && !pasteLanguagePattern.MatchString(p.Language) {
+		return ErrInvalidPaste
+	}
+	return nil
+}
+
+// WithPaste returns a URLOption for a link that shows a snippet
+// Like a file link, a paste is a link in every other way: it expires,
+// counts its views as clicks and can be signed. OriginalURL stays empty.
+func WithPaste(paste Paste) URLOption {
+	return func(u *URL) {
+		u.Paste = &paste
+	}
+}
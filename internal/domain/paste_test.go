@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaste_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		paste   Paste
+		wantErr error
+	}{
+		{name: "plain text", paste: Paste{Content: "hello"}},
+		{name: "code", paste: Paste{Content: "package main\n", Language: "go"}},
+		{name: "language with symbols", paste: Paste{Content: "int x;", Language: "c++"}},
+		{name: "largest", paste: Paste{Content: strings.Repeat("a", MaxPasteSize)}},
+		{name: "too large", paste: Paste{Content: strings.Repeat("a", MaxPasteSize+1)}, wantErr: ErrPasteTooLarge},
+		{name: "blank", paste: Paste{Content: " \n\t"}, wantErr: ErrInvalidPaste},
+		{name: "not UTF-8", paste: Paste{Content: "\xff\xfe"}, wantErr: ErrInvalidPaste},
+		{name: "markup as language", paste: Paste{Content: "x", Language: `go"><script>`}, wantErr: ErrInvalidPaste},
+		{name: "upper-case language", paste: Paste{Content: "x", Language: "Go"}, wantErr: ErrInvalidPaste},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := tt.paste.Validate()
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWithPaste(t *testing.T) {
+	// Arrange: pastes have no destination of their own
+	link := NewURL("", "abc123", "user1")
+
+	// Act
+	WithPaste(Paste{Content: "package main\n", Language: "go"})(link)
+
+	// Assert
+	assert.NoError(t, link.Validate())
+	assert.False(t, link.Redirects())
+	assert.Equal(t, "go", link.Paste.Language)
+}
@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDomainRule(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		wantKind PatternKind
+		wantErr  bool
+	}{
+		{pattern: "Example.com", wantKind: PatternDomain},
+		{pattern: "*.example.com", wantKind: PatternWildcard},
+		{pattern: ".tk", wantKind: PatternTLD},
+		{pattern: ".co.uk", wantKind: PatternTLD},
+		{pattern: `regex:^[a-z0-9]{20}\.com$`, wantKind: PatternRegex},
+		{pattern: "", wantErr: true},
+		{pattern: "exa mple.com", wantErr: true},
+		{pattern: "https://example.com/path", wantErr: true},
+		{pattern: ".*", wantErr: true},
+		{pattern: "regex:", wantErr: true},
+		{pattern: "regex:([", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			rule, err := NewDomainRule("", tt.pattern, PolicyDeny, "", "admin")
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidDomainRule)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantKind, rule.Kind)
+		})
+	}
+
+	_, err := NewDomainRule("", "example.com", "block", "", "admin")
+	assert.ErrorIs(t, err, ErrInvalidDomainRule)
+}
+
+func TestDomainRule_Matches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		want    bool
+	}{
+		{"example.com", "example.com", true},
+		{"example.com", "www.example.com", true},
+		{"example.com", "notexample.com", false},
+		{"*.example.com", "www.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"cdn-*.example.net", "cdn-eu1.example.net", true},
+		{".tk", "free-prizes.tk", true},
+		{".tk", "tk", false},
+		{".tk", "example.tkx", false},
+		{`regex:^[a-z0-9]{20}\.com$`, "abcdefghij0123456789.com", true},
+		{`regex:^[a-z0-9]{20}\.com$`, "short.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.host, func(t *testing.T) {
+			rule, err := NewDomainRule("", tt.pattern, PolicyDeny, "", "admin")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rule.Matches(tt.host))
+		})
+	}
+}
+
+func TestDomainPolicy_CheckHost(t *testing.T) {
+	rule := func(workspace, pattern string, action PolicyAction) *DomainRule {
+		r, err := NewDomainRule(workspace, pattern, action, "", "admin")
+		require.NoError(t, err)
+		r.ID = workspace + "/" + pattern
+		return r
+	}
+
+	tests := []struct {
+		name      string
+		rules     []*DomainRule
+		workspace string
+		host      string
+		wantErr   bool
+	}{
+		{name: "no rules", host: "example.com"},
+		{name: "global deny", rules: []*DomainRule{rule("", ".tk", PolicyDeny)}, workspace: "alice", host: "win.tk", wantErr: true},
+		{name: "workspace deny", rules: []*DomainRule{rule("alice", "competitor.com", PolicyDeny)}, workspace: "alice", host: "competitor.com", wantErr: true},
+		{name: "another workspace's deny", rules: []*DomainRule{rule("bob", "competitor.com", PolicyDeny)}, workspace: "alice", host: "competitor.com"},
+		{name: "workspace allowlist", rules: []*DomainRule{rule("alice", "alice.com", PolicyAllow)}, workspace: "alice", host: "other.com", wantErr: true},
+		{name: "on the workspace allowlist", rules: []*DomainRule{rule("alice", "alice.com", PolicyAllow)}, workspace: "alice", host: "docs.alice.com"},
+		{name: "global allowlist binds workspaces", rules: []*DomainRule{rule("", "corp.example", PolicyAllow), rule("alice", "other.com", PolicyAllow)}, workspace: "alice", host: "other.com", wantErr: true},
+		{name: "deny beats allow", rules: []*DomainRule{rule("", "evil.com", PolicyDeny), rule("alice", "evil.com", PolicyAllow)}, workspace: "alice", host: "evil.com", wantErr: true},
+		{name: "case and trailing dot", rules: []*DomainRule{rule("", "evil.com", PolicyDeny)}, host: "EVIL.com.", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewDomainPolicy(tt.rules).CheckHost(tt.workspace, tt.host)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrDestinationBlocked)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDomainPolicy_CheckURL(t *testing.T) {
+	deny, err := NewDomainRule("", "evil.com", PolicyDeny, "phishing", "admin")
+	require.NoError(t, err)
+	policy := NewDomainPolicy([]*DomainRule{deny})
+
+	resolved := "https://login.evil.com/"
+	tests := []struct {
+		name    string
+		link    *URL
+		wantErr bool
+	}{
+		{name: "clean", link: &URL{OriginalURL: "https://example.com"}},
+		{name: "original", link: &URL{OriginalURL: "https://evil.com/x"}, wantErr: true},
+		{name: "resolved", link: &URL{OriginalURL: "https://example.com", ResolvedURL: &resolved}, wantErr: true},
+		{name: "language target", link: &URL{OriginalURL: "https://example.com", LanguageTargets: map[string]string{"fr": "https://evil.com/fr"}}, wantErr: true},
+		{name: "schedule", link: &URL{OriginalURL: "https://example.com", Schedule: &Schedule{Rules: []ScheduleRule{{URL: "https://evil.com/night"}}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.CheckURL(tt.link)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrDestinationBlocked)
+				assert.Contains(t, err.Error(), "phishing")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDomainPolicy_Revision(t *testing.T) {
+	a := &DomainRule{ID: "a"}
+	b := &DomainRule{ID: "b"}
+
+	assert.Equal(t, NewDomainPolicy([]*DomainRule{a, b}).Revision(), NewDomainPolicy([]*DomainRule{b, a}).Revision())
+	assert.NotEqual(t, NewDomainPolicy([]*DomainRule{a}).Revision(), NewDomainPolicy([]*DomainRule{a, b}).Revision())
+}
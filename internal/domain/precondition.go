@@ -0,0 +1,61 @@
+package domain
+
+import "strings"
+
+// UpsertOutcome tells what an idempotent PUT did
+type UpsertOutcome string
+
+const (
+	UpsertCreated   UpsertOutcome = "created"
+	UpsertUpdated   UpsertOutcome = "updated"
+	UpsertUnchanged UpsertOutcome = "unchanged"
+)
+
+// Precondition holds the conditional request headers of a write
+// (If-Match / If-None-Match, RFC 9110 section 13)
+//
+// OPTIMISTIC CONCURRENCY:
+// A client reads a URL, remembers its ETag and sends it back in If-Match
+// with the update. If somebody changed the URL in between, the ETag no
+// longer matches and the write is refused instead of silently overwriting
+// their change. If-None-Match: * means "only create, never overwrite".
+type Precondition struct {
+	IfMatch     string
+	IfNoneMatch string
+}
+
+// Check evaluates the precondition against the current URL (nil = missing)
+// Returns ErrPreconditionFailed when the write must not happen
+func (p Precondition) Check(current *URL) error {
+	if p.IfMatch != "" {
+		if current == nil || !matchesETag(p.IfMatch, current.ETag(), false) {
+			return ErrPreconditionFailed
+		}
+	}
+	if p.IfNoneMatch != "" && current != nil && matchesETag(p.IfNoneMatch, current.ETag(), true) {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// matchesETag reports whether header ("*" or a list of entity tags) matches etag
+// If-Match uses STRONG comparison: weak tags (W/"...") never match.
+// If-None-Match uses weak comparison: the W/ prefix is ignored.
+func matchesETag(header, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidPreview = errors.New("preview card needs a title (up to 200 characters), a description (up to 500) or an http(s) image URL")
+
+// PreviewCard overrides what social networks show when the short link is shared
+//
+// Twitter, Facebook, Slack and friends fetch a shared link with a bot and
+// read its og:/twitter: meta tags. Normally that's the destination page,
+// so the owner has no say in the card. With a PreviewCard set, the bots get
+// a small page with THESE tags instead (people still get the redirect).
+//
+// Empty fields fall back to the destination's own metadata (see LinkMetadata).
+type PreviewCard struct {
+	Title       string
+	Description string
+	ImageURL    string
+}
+
+// Normalize trims whitespace before validation
+func (p *PreviewCard) Normalize() {
+	p.Title = strings.TrimSpace(p.Title)
+	p.Description = strings.TrimSpace(p.Description)
+	p.ImageURL = strings.TrimSpace(p.ImageURL)
+}
+
+// Validate checks the card before it is saved
+// At least one field must be set - an empty card overrides nothing
+func (p *PreviewCard) Validate() error {
+	if p.Title == "" && p.Description == "" && p.ImageURL == "" {
+		return ErrInvalidPreview
+	}
+	if len(p.Title) > 200 || len(p.Description) > 500 {
+		return ErrInvalidPreview
+	}
+	if p.ImageURL != "" {
+		// Crawlers only load absolute http(s) images
+		if !IsHTTPURL(p.ImageURL) {
+			return ErrInvalidPreview
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrQuotaExceeded = errors.New("monthly link quota exceeded")
+	ErrUnknownPlan   = errors.New("plan must be free, pro, or empty for the default plan")
+)
+
+// Plan names
+const (
+	PlanFree = "free"
+	PlanPro  = "pro"
+)
+
+// ValidPlanName reports whether name is a plan (or "" for the default plan)
+func ValidPlanName(name string) bool {
+	return name == "" || name == PlanFree || name == PlanPro
+}
+
+// Plan limits how many links an owner can create per calendar month
+type Plan struct {
+	Name         string
+	MonthlyLinks int64 // 0 = unlimited
+}
+
+// Unlimited reports whether the plan has no monthly cap
+func (p Plan) Unlimited() bool {
+	return p.MonthlyLinks <= 0
+}
+
+// Usage is how much of their plan an owner used in the current period
+type Usage struct {
+	Owner       string
+	Plan        Plan
+	PeriodStart time.Time
+	PeriodEnd   time.Time // Exclusive: the counter resets at this instant
+	Used        int64
+}
+
+// Remaining returns how many links can still be created this period
+// Returns -1 for unlimited plans
+func (u *Usage) Remaining() int64 {
+	if u.Plan.Unlimited() {
+		return -1
+	}
+	if u.Used >= u.Plan.MonthlyLinks {
+		return 0
+	}
+	return u.Plan.MonthlyLinks - u.Used
+}
+
+// UsagePeriod returns the calendar month (UTC) containing t
+// Quotas reset on the first of every month at 00:00 UTC
+func UsagePeriod(t time.Time) (start, end time.Time) {
+	t = t.UTC()
+	start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
@@ -0,0 +1,213 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxScheduleRules caps how many rules one link's schedule can have
+const MaxScheduleRules = 20
+
+var ErrInvalidSchedule = errors.New("schedule needs a valid IANA timezone and 1-20 rules with days (mon-sun), HH:MM start/end times and an http(s) URL")
+
+// Schedule rotates a link's destination by time of day and day of week
+//
+// Example: business hours go to the support chat, the rest of the time to
+// the help center:
+//
+//	{
+//	  "timezone": "Europe/Berlin",
+//	  "rules": [
+//	    {"days": ["mon","tue","wed","thu","fri"], "start": "09:00", "end": "17:00", "url": "https://example.com/chat"}
+//	  ]
+//	}
+//
+// HOW A REDIRECT PICKS THE DESTINATION:
+//  1. The current time is converted to the schedule's timezone (or the
+//     server's timezone when none is set)
+//  2. Rules are checked in order - the FIRST rule that covers that moment wins
+//  3. No rule matches -> the link's usual destination (OriginalURL)
+//
+// Schedules are stored as JSON, hence the json tags.
+type Schedule struct {
+	Timezone string         `json:"timezone,omitempty"` // IANA name, e.g. "America/New_York" ("" = server's timezone)
+	Rules    []ScheduleRule `json:"rules"`
+}
+
+// ScheduleRule sends visitors to URL on Days between Start and End
+//
+// Times are "HH:MM" in 24-hour format. Start is inclusive and End exclusive,
+// so 09:00-17:00 ends at 16:59. An End before Start runs past midnight
+// (22:00-06:00 is a night rule; the early hours belong to the day it
+// started on). Leaving both empty covers the whole day - handy for
+// day-of-week rotations.
+type ScheduleRule struct {
+	Days  []string `json:"days,omitempty"` // "mon".."sun" (empty = every day)
+	Start string   `json:"start,omitempty"`
+	End   string   `json:"end,omitempty"`
+	URL   string   `json:"url"`
+}
+
+// scheduleDays maps the day names of a rule to Go's weekdays
+var scheduleDays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// WithSchedule returns a URLOption that sets the destination schedule
+// nil (or a schedule without rules) removes it
+func WithSchedule(schedule *Schedule) URLOption {
+	return func(u *URL) {
+		if schedule == nil || len(schedule.Rules) == 0 {
+			u.Schedule = nil
+			return
+		}
+		normalized := *schedule
+		normalized.Normalize()
+		u.Schedule = &normalized
+	}
+}
+
+// Normalize trims values and lowercases day names before validation
+// It copies the rules, so the caller's slices are never modified
+func (s *Schedule) Normalize() {
+	s.Timezone = strings.TrimSpace(s.Timezone)
+	rules := make([]ScheduleRule, len(s.Rules))
+	for i, rule := range s.Rules {
+		var days []string
+		for _, day := range rule.Days {
+			days = append(days, strings.ToLower(strings.TrimSpace(day)))
+		}
+		rules[i] = ScheduleRule{
+			Days:  days,
+			Start: strings.TrimSpace(rule.Start),
+			End:   strings.TrimSpace(rule.End),
+			URL:   strings.TrimSpace(rule.URL),
+		}
+	}
+	s.Rules = rules
+}
+
+// Validate checks the rule syntax before the schedule is saved
+func (s *Schedule) Validate() error {
+	if len(s.Rules) == 0 || len(s.Rules) > MaxScheduleRules {
+		return ErrInvalidSchedule
+	}
+	if s.Timezone != "" {
+		if _, err := LoadTimezone(s.Timezone); err != nil {
+			return ErrInvalidSchedule
+		}
+	}
+
+	for _, rule := range s.Rules {
+		for _, day := range rule.Days {
+			if _, ok := scheduleDays[day]; !ok {
+				return ErrInvalidSchedule
+			}
+		}
+
+		// Both times or neither (= whole day)
+		if (rule.Start == "") != (rule.End == "") {
+			return ErrInvalidSchedule
+		}
+		if rule.Start != "" {
+			start, okStart := parseClock(rule.Start)
+			end, okEnd := parseClock(rule.End)
+			if !okStart || !okEnd || start == end {
+				return ErrInvalidSchedule
+			}
+		}
+
+		if !IsHTTPURL(rule.URL) {
+			return ErrInvalidSchedule
+		}
+	}
+	return nil
+}
+
+// Equal reports whether two schedules route the same way (nil-safe)
+func (s *Schedule) Equal(other *Schedule) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	return s.Timezone == other.Timezone && slices.EqualFunc(s.Rules, other.Rules, func(a, b ScheduleRule) bool {
+		return slices.Equal(a.Days, b.Days) && a.Start == b.Start && a.End == b.End && a.URL == b.URL
+	})
+}
+
+// DestinationAt returns the URL of the first rule covering now
+// serverTZ is used when the schedule has no timezone of its own
+// Returns false when no rule matches (or there is no schedule)
+func (s *Schedule) DestinationAt(now time.Time, serverTZ *time.Location) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+
+	location := serverTZ
+	if s.Timezone != "" {
+		// Validated on save; a timezone dropped from the tz database later
+		// falls back to the server's instead of breaking the link
+		if loaded, err := time.LoadLocation(s.Timezone); err == nil {
+			location = loaded
+		}
+	}
+	if location != nil {
+		now = now.In(location)
+	}
+
+	for _, rule := range s.Rules {
+		if rule.covers(now) {
+			return rule.URL, true
+		}
+	}
+	return "", false
+}
+
+// covers reports whether the rule applies at local time t
+func (r ScheduleRule) covers(t time.Time) bool {
+	if r.Start == "" {
+		return r.onDay(t.Weekday())
+	}
+
+	start, _ := parseClock(r.Start)
+	end, _ := parseClock(r.End)
+	minute := t.Hour()*60 + t.Minute()
+
+	if start < end {
+		return r.onDay(t.Weekday()) && minute >= start && minute < end
+	}
+
+	// Past midnight: the evening part is on a listed day, the early
+	// hours on the day after one
+	yesterday := (t.Weekday() + 6) % 7
+	return (r.onDay(t.Weekday()) && minute >= start) || (r.onDay(yesterday) && minute < end)
+}
+
+// onDay reports whether the rule runs on weekday (no days = every day)
+func (r ScheduleRule) onDay(weekday time.Weekday) bool {
+	if len(r.Days) == 0 {
+		return true
+	}
+	for _, day := range r.Days {
+		if scheduleDays[day] == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock parses "HH:MM" into minutes after midnight
+func parseClock(value string) (int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "errors"
+
+// Encrypted destination errors
+var (
+	ErrSealedDestinationTargets = errors.New("links with encrypted destinations can't have language targets or a schedule")
+	ErrSealedDestination        = errors.New("encrypted destination can't be decrypted")
+)
+
+// ENCRYPTED DESTINATIONS
+// Visibility keeps a sensitive link's destination from workspace viewers -
+// but not from anyone who reads the database, a backup or the cache. With a
+// key manager configured (KMS_PROVIDER, see package keys), the destination
+// of a sensitive link is stored encrypted in SealedDestination and
+// OriginalURL stays empty.
+//
+// Only the redirect decrypts it (URLService.GetURL). Everything else -
+// stats, exports, search, the edge snapshot - sees a link without a
+// destination, like a file or paste link.
+//
+// Language targets and schedules are destinations too, so a link can't
+// have both: they would sit in the database in plain text.
+
+// DestinationSealed reports whether the destination is stored encrypted
+func (u *URL) DestinationSealed() bool {
+	return u.SealedDestination != ""
+}
+
+// ResealReport is the outcome of one run of the re-encryption job
+type ResealReport struct {
+	Scanned   int // Sensitive or encrypted links looked at
+	Sealed    int // Plain text destinations that got encrypted
+	Rewrapped int // Encrypted destinations moved to the current key version
+	Skipped   int // Sensitive links left in plain text (language targets or a schedule)
+}
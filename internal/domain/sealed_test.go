@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURL_Validate_SealedDestination(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []URLOption
+		expected error
+	}{
+		{name: "no destination in plain text", expected: nil},
+		{name: "language targets", opts: []URLOption{WithLanguageTargets(map[string]string{"fr": "https://example.com/fr"})}, expected: ErrSealedDestinationTargets},
+		{name: "schedule", opts: []URLOption{WithSchedule(&Schedule{Rules: []ScheduleRule{{Start: "18:00", End: "09:00", URL: "https://example.com/closed"}}})}, expected: ErrSealedDestinationTargets},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			url := NewURL("", "launch", "team1")
+			url.Visibility = VisibilitySensitive
+			url.SealedDestination = "env1:k1:v1.d3JhcA:v1.c2VhbGVk"
+			for _, opt := range tt.opts {
+				opt(url)
+			}
+
+			// Act
+			err := url.Validate()
+
+			// Assert
+			if tt.expected == nil {
+				assert.NoError(t, err)
+				assert.True(t, url.DestinationSealed())
+				assert.False(t, url.ServableAtEdge(), "the edge can't decrypt it")
+				return
+			}
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"errors"
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// maxSearchTerms bounds the words of one search
+// Every word is another index lookup; nobody types ten words to find a link
+const maxSearchTerms = 8
+
+// ErrInvalidSearch is returned for a search without a single word
+var ErrInvalidSearch = errors.New("search needs at least one word (letters or digits)")
+
+// SearchQuery is one full-text search over links
+type SearchQuery struct {
+	Terms  []string // Lowercase words; each matches any word starting with it
+	Owner  string   // Only this owner's links ("" = every owner, admins only)
+	Domain string   // Only links on this host ("" = any host)
+	Active *bool    // Only active (true) or deactivated (false) links (nil = both)
+	Limit  int
+	Offset int
+
+	// Leave sensitive links out (for viewers: a hit on a word of the
+	// destination would give the destination away, see VisibilitySensitive)
+	HideSensitive bool
+}
+
+// SearchHit is a link matching a search
+type SearchHit struct {
+	URL  *URL
+	Rank float64 // Higher is better; only comparable within one search
+}
+
+// SearchResult is one page of hits, best first
+type SearchResult struct {
+	Hits  []*SearchHit
+	Total int // Hits on every page
+}
+
+// ParseSearchTerms splits what the user typed into search words
+//
+// Only letters and digits make words: "summer-sale.com" searches for
+// "summer", "sale" and "com". That is also how the search index splits
+// links, and it keeps the words safe to put in a tsquery.
+func ParseSearchTerms(q string) ([]string, error) {
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(q), isNotWordRune) {
+		if len(terms) == maxSearchTerms {
+			break
+		}
+		if !slices.Contains(terms, word) {
+			terms = append(terms, word)
+		}
+	}
+	if len(terms) == 0 {
+		return nil, ErrInvalidSearch
+	}
+	return terms, nil
+}
+
+// TSQuery is the Postgres tsquery of the search: every word, as a prefix
+// e.g. "summer:* & sale:*"
+func (q SearchQuery) TSQuery() string {
+	parts := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		parts[i] = term + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// Highlight returns text as HTML with the matching words in <mark>, or ""
+// if no word matches
+//
+// WHY HTML?
+// The text comes from destination pages and link owners. Escaping it here
+// means a UI can show the snippet as it is, without stripping tags first.
+func Highlight(text string, terms []string) string {
+	var b strings.Builder
+	matched := false
+	word := []rune{}
+
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		s := string(word)
+		if matchesTerm(strings.ToLower(s), terms) {
+			matched = true
+			b.WriteString("<mark>" + html.EscapeString(s) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(s))
+		}
+		word = word[:0]
+	}
+
+	for _, r := range text {
+		if isNotWordRune(r) {
+			flush()
+			b.WriteString(html.EscapeString(string(r)))
+			continue
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	if !matched {
+		return ""
+	}
+	return b.String()
+}
+
+// matchesTerm reports whether word starts with one of the terms
+func matchesTerm(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
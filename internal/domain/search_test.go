@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSearchTerms(t *testing.T) {
+	tests := []struct {
+		name    string
+		q       string
+		want    []string
+		wantErr error
+	}{
+		{name: "words", q: "Summer Sale", want: []string{"summer", "sale"}},
+		{name: "punctuation splits words", q: "example.com/summer-sale", want: []string{"example", "com", "summer", "sale"}},
+		{name: "tsquery syntax is dropped", q: "sale:* | !x & (y)", want: []string{"sale", "x", "y"}},
+		{name: "duplicates", q: "sale SALE", want: []string{"sale"}},
+		{name: "non-latin letters", q: "Größe 東京", want: []string{"größe", "東京"}},
+		{name: "too many words", q: "a b c d e f g h i j", want: []string{"a", "b", "c", "d", "e", "f", "g", "h"}},
+		{name: "no words", q: " -/- ", wantErr: ErrInvalidSearch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			terms, err := ParseSearchTerms(tt.q)
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, terms)
+		})
+	}
+}
+
+func TestSearchQuery_TSQuery(t *testing.T) {
+	assert.Equal(t, "summer:* & sale:*", SearchQuery{Terms: []string{"summer", "sale"}}.TSQuery())
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{name: "prefix match", text: "Summer Sales 2026", terms: []string{"sale"}, want: "Summer <mark>Sales</mark> 2026"},
+		{name: "several terms", text: "https://shop.example.com/summer-sale", terms: []string{"summer", "shop"}, want: "https://<mark>shop</mark>.example.com/<mark>summer</mark>-sale"},
+		{name: "markup is escaped", text: "<b>Sale</b> & more", terms: []string{"sale"}, want: "&lt;b&gt;<mark>Sale</mark>&lt;/b&gt; &amp; more"},
+		{name: "no match", text: "Winter deals", terms: []string{"sale"}, want: ""},
+		{name: "only prefixes match", text: "Wholesale", terms: []string{"sale"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Highlight(tt.text, tt.terms))
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxSecretLength is the longest text a burn-after-reading link can hold
+const MaxSecretLength = 10000
+
+// Burn-after-reading errors
+var (
+	ErrLinkBurned         = errors.New("link has already been revealed")
+	ErrInvalidSecret      = errors.New("a secret needs either text (up to 10000 characters) or an http(s) URL")
+	ErrSecretsUnavailable = errors.New("secret links are not configured on this server")
+)
+
+// Secret is what a burn-after-reading link reveals: a text, or a destination
+// that is shown instead of redirected to
+type Secret struct {
+	Text string `json:"text,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// Validate checks that exactly one of Text and URL is set
+func (s Secret) Validate() error {
+	hasText := strings.TrimSpace(s.Text) != ""
+	switch {
+	case hasText == (s.URL != ""):
+		return ErrInvalidSecret
+	case hasText && utf8.RuneCountInString(s.Text) > MaxSecretLength:
+		return ErrInvalidSecret
+	case !hasText && validateDestination(s.URL) != nil:
+		return ErrInvalidSecret
+	}
+	return nil
+}
+
+// WithBurnAfterReading returns a URLOption for a link that reveals a secret
+// once and then destroys it. sealed is the ENCRYPTED secret.
+//
+// HOW IT WORKS:
+//  1. Visiting the link shows a "Reveal" button - nothing is used up yet,
+//     so chat apps and mail scanners that open links don't burn it
+//  2. Pressing it (a POST) clears Payload in the database, in the same
+//     statement that reads it: only one visitor ever sees the secret
+//  3. The reveal is written to the audit log for the owner
+//
+// The secret is only ever stored encrypted - in the database and in the
+// cache - so a dump of either doesn't leak it.
+func WithBurnAfterReading(sealed string) URLOption {
+	return func(u *URL) {
+		u.BurnAfterReading = true
+		u.Payload = &sealed
+	}
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecret_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  Secret
+		wantErr bool
+	}{
+		{name: "text", secret: Secret{Text: "wifi: hunter2"}},
+		{name: "url", secret: Secret{URL: "https://example.com/invite"}},
+		{name: "longest text", secret: Secret{Text: strings.Repeat("ü", MaxSecretLength)}},
+		{name: "text too long", secret: Secret{Text: strings.Repeat("a", MaxSecretLength+1)}, wantErr: true},
+		{name: "blank text", secret: Secret{Text: " \n "}, wantErr: true},
+		{name: "both", secret: Secret{Text: "x", URL: "https://example.com"}, wantErr: true},
+		{name: "not a web URL", secret: Secret{URL: "javascript:alert(1)"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := tt.secret.Validate()
+
+			// Assert
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidSecret)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWithBurnAfterReading(t *testing.T) {
+	// Arrange: burn links have no destination of their own
+	link := NewURL("", "abc123", "user1")
+
+	// Act
+	WithBurnAfterReading("v1.c2VhbGVk")(link)
+
+	// Assert
+	assert.NoError(t, link.Validate())
+	assert.True(t, link.BurnAfterReading)
+	assert.Equal(t, "v1.c2VhbGVk", *link.Payload)
+	assert.NoError(t, link.CanBeAccessed())
+
+	burned := time.Now()
+	link.BurnedAt = &burned
+	assert.ErrorIs(t, link.CanBeAccessed(), ErrLinkBurned)
+
+	// Without the option, an empty destination is still invalid
+	assert.Error(t, NewURL("", "def456", "user1").Validate())
+}
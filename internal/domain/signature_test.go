@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSigning_SecretPerLink(t *testing.T) {
+	// Act
+	first, second := NewURL("https://example.com", "abc123", "user1"), NewURL("https://example.com", "def456", "user1")
+	WithSigning()(first)
+	WithSigning()(second)
+
+	// Assert
+	require.NotNil(t, first.SigningSecret)
+	require.NotNil(t, second.SigningSecret)
+	assert.Len(t, *first.SigningSecret, 64)
+	assert.NotEqual(t, *first.SigningSecret, *second.SigningSecret)
+	assert.True(t, first.RequiresSignature())
+	assert.False(t, NewURL("https://example.com", "ghi789", "user1").RequiresSignature())
+}
+
+func TestVerifySignature(t *testing.T) {
+	secret := "s3cret"
+	link := &URL{ShortCode: "abc123", SigningSecret: &secret}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	nextWeek := now.Add(7 * 24 * time.Hour)
+
+	otherSecret := "other"
+	other := &URL{ShortCode: "def456", SigningSecret: &secret}
+	rotated := &URL{ShortCode: "abc123", SigningSecret: &otherSecret}
+
+	tests := []struct {
+		name     string
+		link     *URL
+		query    url.Values
+		now      time.Time
+		expected error
+	}{
+		{name: "never expires", link: link, query: link.SignedQuery(time.Time{}), now: now},
+		{name: "before expiry", link: link, query: link.SignedQuery(nextWeek), now: now},
+		{name: "after expiry", link: link, query: link.SignedQuery(nextWeek), now: nextWeek.Add(time.Second), expected: ErrSignatureExpired},
+		{name: "no signature", link: link, query: url.Values{}, now: now, expected: ErrSignatureRequired},
+		{name: "wrong signature", link: link, query: url.Values{"sig": {"AAAAAAAAAAAAAAAAAAAAAA"}}, now: now, expected: ErrSignatureRequired},
+		{
+			name: "expiry extended by hand",
+			link: link,
+			query: url.Values{
+				"sig": {link.Signature(now)},
+				"exp": {"4102444800"},
+			},
+			now:      now,
+			expected: ErrSignatureRequired,
+		},
+		{name: "expiry removed by hand", link: link, query: url.Values{"sig": {link.Signature(nextWeek)}}, now: now, expected: ErrSignatureRequired},
+		{name: "garbage expiry", link: link, query: url.Values{"sig": {link.Signature(time.Time{})}, "exp": {"soon"}}, now: now, expected: ErrSignatureRequired},
+		{name: "signature of another link", link: other, query: link.SignedQuery(time.Time{}), now: now, expected: ErrSignatureRequired},
+		{name: "secret changed", link: rotated, query: link.SignedQuery(time.Time{}), now: now, expected: ErrSignatureRequired},
+		{name: "unsigned link", link: &URL{ShortCode: "abc123"}, query: url.Values{}, now: now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := tt.link.VerifySignature(tt.query, tt.now)
+
+			// Assert
+			if tt.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSignedLink_SurvivesTemplates(t *testing.T) {
+	// Arrange
+	secret := "s3cret"
+	source := &URL{ShortCode: "abc123", SigningSecret: &secret}
+
+	// Act: templates and clones copy the settings, not the secret
+	copied := NewURL("https://example.com", "def456", "user1")
+	for _, opt := range source.Settings().Options() {
+		opt(copied)
+	}
+
+	// Assert
+	require.NotNil(t, copied.SigningSecret)
+	assert.NotEqual(t, secret, *copied.SigningSecret)
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrSlackWorkspaceNotFound = errors.New("slack workspace not found")
+	ErrInvalidSlackWorkspace  = errors.New("slack workspace needs a team ID and a signing secret")
+	ErrSlackUsage             = errors.New("usage: /shorten <url> [alias]") // Command text isn't "<url> [alias]"
+)
+
+// SlackWorkspace is the configuration of one Slack team using /shorten
+type SlackWorkspace struct {
+	TeamID        string // Slack team ID (T0123...)
+	TeamName      string
+	SigningSecret string // From the Slack app's "Basic Information" page
+	Owner         string // Principal the created links belong to
+	Domain        string // Optional custom domain for new links
+	InChannel     bool   // Replies visible to the whole channel
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// DefaultSlackOwner is the owner for a workspace registered without one
+func DefaultSlackOwner(teamID string) string {
+	return "slack:" + teamID
+}
+
+// Validate checks the settings before they are saved
+func (w *SlackWorkspace) Validate() error {
+	if w.TeamID == "" || w.SigningSecret == "" {
+		return ErrInvalidSlackWorkspace
+	}
+	if w.Domain != "" && !IsValidHost(w.Domain) {
+		return ErrInvalidDomain
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSAMLConnectionNotFound = errors.New("saml connection not found")
+	ErrInvalidSAMLConnection  = errors.New("saml connection needs the IdP entity ID, an http(s) sign-in URL and its signing certificate")
+	ErrAssertionReplayed      = errors.New("saml assertion was already used")
+	ErrSessionNotFound        = errors.New("session not found")
+)
+
+// SINGLE SIGN-ON
+// Enterprise customers want their people to sign in with the company login
+// (Okta, Entra ID) instead of sharing API keys. A workspace connects its
+// identity provider once; after a verified SAML sign-in the person gets a
+// short-lived session token that acts for the workspace with the role their
+// IdP groups map to (WorkspaceSettings.GroupRoles), like a SCIM member.
+
+// SAMLConnection is a workspace's SAML identity provider
+type SAMLConnection struct {
+	Workspace   string
+	IdPEntityID string // Issuer of the IdP's assertions
+	SSOURL      string // Where to send people to sign in (HTTP-Redirect binding)
+	Certificate string // PEM of the IdP's signing certificate
+
+	// Assertion attribute listing the person's groups (e.g. "groups");
+	// its values are looked up in GroupRoles. "" = only SCIM groups count
+	RoleAttribute string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Validate checks a connection before it is saved
+// The certificate itself is parsed by the service
+func (c *SAMLConnection) Validate() error {
+	c.IdPEntityID = strings.TrimSpace(c.IdPEntityID)
+	c.RoleAttribute = strings.TrimSpace(c.RoleAttribute)
+	if c.IdPEntityID == "" || len(c.IdPEntityID) > 1024 || len(c.RoleAttribute) > 255 ||
+		strings.TrimSpace(c.Certificate) == "" || len(c.SSOURL) > 2048 {
+		return ErrInvalidSAMLConnection
+	}
+	u, err := url.Parse(c.SSOURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return ErrInvalidSAMLConnection
+	}
+	return nil
+}
+
+// Session is a signed-in member's access to a workspace
+type Session struct {
+	Workspace string
+	Member    string // The NameID the IdP vouched for
+	Role      string
+	Plan      string // Quota plan of the workspace, read with the session (see WorkspaceSettings.Plan)
+	ExpiresAt time.Time
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+// Subscription links an owner to a paid plan managed by Stripe
+// Rows are written by the billing webhook; Stripe is the source of truth.
+type Subscription struct {
+	Owner            string
+	CustomerID       string // Stripe customer (cus_...)
+	SubscriptionID   string // Stripe subscription (sub_...), empty until checkout finishes
+	Plan             string // Plan tier mapped from the Stripe product
+	Status           string // Stripe status: active, trialing, past_due, canceled, ...
+	CurrentPeriodEnd time.Time
+	LastEventAt      time.Time // Creation time of the newest Stripe event applied
+}
+
+// Active reports whether the subscription currently grants its plan
+//
+// past_due still counts: Stripe keeps retrying the card for a while, and
+// downgrading on the first failed payment would punish a typo in the
+// expiry date. Stripe cancels the subscription if the retries fail.
+func (s *Subscription) Active() bool {
+	switch s.Status {
+	case "active", "trialing", "past_due":
+		return true
+	default:
+		return false
+	}
+}
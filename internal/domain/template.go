@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"errors"
+	"maps"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTemplateNotFound  = errors.New("template not found")
+	ErrTemplateNameTaken = errors.New("a template with this name already exists")
+	ErrInvalidTemplate   = errors.New("template needs a name (up to 100 characters) and a non-negative expiration")
+)
+
+// LinkSettings are the reusable settings of a link: everything except its
+// destination and short code
+//
+// Cloning a link copies them, and a LinkTemplate stores them so every link
+// of a campaign is created the same way. Stored as JSON, hence the tags.
+type LinkSettings struct {
+	ExpiresIn       time.Duration     `json:"expires_in,omitempty"` // Lifetime of new links (0 = never expire)
+	MaxClicks       *int64            `json:"max_clicks,omitempty"`
+	Domain          string            `json:"domain,omitempty"`
+	LanguageTargets map[string]string `json:"language_targets,omitempty"`
+	Schedule        *Schedule         `json:"schedule,omitempty"`
+	Preview         *PreviewCard      `json:"preview,omitempty"`
+	Signed          bool              `json:"signed,omitempty"` // New links get their OWN secret
+	SingleUse       bool              `json:"single_use,omitempty"`
+	NoAnalytics     bool              `json:"no_analytics,omitempty"`
+}
+
+// Settings returns the reusable settings of u
+//
+// EXPIRATION IS A POLICY, NOT A DATE:
+// A link created on the 1st that expires on the 8th has a lifetime of one
+// week. Its clone gets one week from ITS creation - copying the date would
+// create links that expire early, or are already expired.
+func (u *URL) Settings() LinkSettings {
+	settings := LinkSettings{
+		Domain:          u.Domain,
+		LanguageTargets: maps.Clone(u.LanguageTargets),
+	}
+	if u.ExpiresAt != nil {
+		settings.ExpiresIn = u.ExpiresAt.Sub(u.CreatedAt).Round(time.Second)
+	}
+	if u.MaxClicks != nil {
+		maxClicks := *u.MaxClicks
+		settings.MaxClicks = &maxClicks
+	}
+	if u.Schedule != nil {
+		schedule := *u.Schedule
+		schedule.Normalize() // Normalize copies the rules
+		settings.Schedule = &schedule
+	}
+	if u.Preview != nil {
+		preview := *u.Preview
+		settings.Preview = &preview
+	}
+	settings.Signed = u.RequiresSignature()
+	settings.SingleUse = u.SingleUse
+	settings.NoAnalytics = u.AnalyticsDisabled
+	return settings
+}
+
+// Options turns the settings into creation options
+// ExpiresIn is not among them: CreateShortURL takes the lifetime directly
+func (s LinkSettings) Options() []URLOption {
+	var opts []URLOption
+	if s.MaxClicks != nil {
+		opts = append(opts, WithClickLimit(*s.MaxClicks))
+	}
+	if s.Domain != "" {
+		opts = append(opts, WithDomain(s.Domain))
+	}
+	if len(s.LanguageTargets) > 0 {
+		opts = append(opts, WithLanguageTargets(s.LanguageTargets))
+	}
+	if s.Schedule != nil {
+		opts = append(opts, WithSchedule(s.Schedule))
+	}
+	if s.Preview != nil {
+		opts = append(opts, WithPreview(s.Preview))
+	}
+	if s.Signed {
+		opts = append(opts, WithSigning())
+	}
+	if s.SingleUse {
+		opts = append(opts, WithSingleUse())
+	}
+	if s.NoAnalytics {
+		opts = append(opts, WithoutAnalytics())
+	}
+	return opts
+}
+
+// Normalize brings the settings into the form the URL options store
+// (lowercase language tags and domain, sorted schedule days, ...)
+func (s *LinkSettings) Normalize() {
+	probe := &URL{}
+	for _, opt := range s.Options() {
+		opt(probe)
+	}
+	s.Domain = probe.Domain
+	s.LanguageTargets = probe.LanguageTargets
+	s.Schedule = probe.Schedule
+	s.Preview = probe.Preview
+}
+
+// Validate checks the settings with the same rules as a link
+func (s LinkSettings) Validate() error {
+	if s.ExpiresIn < 0 {
+		return ErrInvalidTemplate
+	}
+
+	// Borrow URL.Validate for the shared rules; the destination and code
+	// are placeholders that always pass
+	probe := NewURL("https://example.com", "template", "")
+	for _, opt := range s.Options() {
+		opt(probe)
+	}
+	if err := probe.Validate(); err != nil {
+		return err
+	}
+	if probe.Preview != nil {
+		return probe.Preview.Validate()
+	}
+	return nil
+}
+
+// WithPreview returns a URLOption that sets the social preview card
+func WithPreview(card *PreviewCard) URLOption {
+	return func(u *URL) {
+		if card == nil {
+			u.Preview = nil
+			return
+		}
+		preview := *card
+		preview.Normalize()
+		u.Preview = &preview
+	}
+}
+
+// LinkTemplate is a named set of LinkSettings for creating links quickly
+// Example: a "spring-campaign" template with a 30 day lifetime, the
+// go.example.com domain and a preview card - each new campaign link then
+// only needs its destination.
+type LinkTemplate struct {
+	ID        string
+	Owner     string // Only the owner (and admins) can see and use it
+	Name      string // Unique per owner
+	Settings  LinkSettings
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Normalize trims the name and normalizes the settings before validation
+func (t *LinkTemplate) Normalize() {
+	t.Name = strings.TrimSpace(t.Name)
+	t.Settings.Normalize()
+}
+
+// Validate checks the template before it is saved
+func (t *LinkTemplate) Validate() error {
+	if t.Name == "" || len(t.Name) > 100 {
+		return ErrInvalidTemplate
+	}
+	return t.Settings.Validate()
+}
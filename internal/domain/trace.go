@@ -0,0 +1,34 @@
+package domain
+
+import "errors"
+
+var ErrRedirectTraceUnavailable = errors.New("redirect tracing is not available")
+
+// RedirectTrace is where a short link's destination leads when followed
+//
+// Destinations break in ways the shortener can't see at creation time: the
+// page moves, a campaign URL starts redirecting to a login page, a domain
+// expires. Following the chain server-side shows the owner every hop and
+// where it ends, without leaving the dashboard.
+type RedirectTrace struct {
+	URL          *URL
+	Hops         []RedirectHop // Requests made from the destination on, in order
+	Truncated    bool          // The chain had more redirects than we follow
+	ViaShortener bool          // Some hop went through another URL shortener
+	Error        string        // Why following stopped early ("" = it reached the end)
+}
+
+// RedirectHop is one request in a redirect chain
+type RedirectHop struct {
+	URL         string
+	StatusCode  int
+	ContentType string
+}
+
+// Final returns the last hop (nil when not even the destination answered)
+func (t *RedirectTrace) Final() *RedirectHop {
+	if len(t.Hops) == 0 {
+		return nil
+	}
+	return &t.Hops[len(t.Hops)-1]
+}
@@ -1,7 +1,10 @@
 package domain
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/url"
 	"strings"
 	"time"
@@ -11,26 +14,96 @@ import (
 // This is our "domain model" - it contains both data AND behavior (methods)
 // In Go, we use structs to define data structures
 type URL struct {
-	ID          string     // UUID for internal identification
-	ShortCode   string     // The short identifier (e.g., "abc123")
-	OriginalURL string     // The full URL to redirect to
-	CustomAlias *string    // Optional custom alias (pointer = nullable)
-	CreatedAt   time.Time  // When the URL was created
-	ExpiresAt   *time.Time // Optional expiration time (pointer = nullable)
-	Clicks      int64      // Number of times this URL was accessed
-	CreatedBy   string     // User/API key that created it
-	IsActive    bool       // Soft delete flag
+	ID          string        // UUID for internal identification
+	ShortCode   string        // The short identifier (e.g., "abc123")
+	OriginalURL string        // The full URL to redirect to
+	CustomAlias *string       // Optional custom alias (pointer = nullable)
+	CreatedAt   time.Time     // When the URL was created
+	ExpiresAt   *time.Time    // Optional expiration time (pointer = nullable)
+	Clicks      int64         // Number of times this URL was accessed
+	CreatedBy   string        // User/API key that created it
+	IsActive    bool          // Soft delete flag
+	ResolvedURL *string       // Final destination after following redirects (nil if not resolved)
+	MaxClicks   *int64        // Optional click limit; the URL stops redirecting once reached
+	Domain      string        // Host the short link is served on ("" = default domain)
+	Version     int64         // Bumped on every update; the basis of the ETag
+	Metadata    *LinkMetadata // Title etc. of the destination page (nil until fetched)
+	Preview     *PreviewCard  // Owner's social preview card (nil = crawlers see the destination)
+
+	// Destination overrides per visitor language, e.g. "fr" -> French page
+	// (nil = everyone goes to OriginalURL, see DestinationFor)
+	LanguageTargets map[string]string
+
+	// Time-based destination rotation, e.g. business hours vs after hours
+	// (nil = no schedule, see Schedule)
+	Schedule *Schedule
+
+	// Secret that signs the link's ?sig= parameter
+	// (nil = the link opens without a signature, see WithSigning)
+	SigningSecret *string
+
+	SingleUse bool       // Stops redirecting after its first redirect (see WithSingleUse)
+	UsedAt    *time.Time // When a single-use link was used up (nil = not yet)
+
+	// Burn-after-reading: shows its sealed Payload once, then destroys it
+	// (see WithBurnAfterReading). OriginalURL is empty for these links.
+	BurnAfterReading bool
+	Payload          *string    // Encrypted secret (nil once revealed)
+	BurnedAt         *time.Time // When it was revealed (nil = not yet)
+
+	// File served instead of a redirect (nil = a regular link, see WithFile)
+	// OriginalURL is empty for these links.
+	File *FileAttachment
+
+	// Snippet shown instead of a redirect (nil = a regular link, see WithPaste)
+	// OriginalURL is empty for these links.
+	Paste *Paste
+
+	// Only the click counter is kept - no click events (see WithoutAnalytics)
+	AnalyticsDisabled bool
+
+	// Notes about the link, never shown to visitors (see WithDescription)
+	Description    string
+	CustomMetadata map[string]string // Small key/value pairs, e.g. {"ticket": "MKT-142"}
+
+	// Approval workflow (see WithPendingApproval); ApprovalNone for most links
+	Approval    ApprovalStatus
+	SubmittedBy string // Member who submitted the link for approval
+
+	// Who in the workspace sees the destinations (see WithVisibility)
+	Visibility LinkVisibility
+
+	// Encrypted destination of a sensitive link ("" = stored in plain text,
+	// see DestinationSealed). OriginalURL is empty while it is set.
+	SealedDestination string
 }
 
+// URLOption customizes a URL at creation time
+// This is the "functional options" pattern - new creation settings can be added
+// without changing the signature of every function that creates URLs
+type URLOption func(*URL)
+
 // Domain errors - defining errors as constants makes them testable
 // and allows callers to check for specific error types
 var (
-	ErrInvalidURL         = errors.New("invalid URL format")
-	ErrEmptyURL           = errors.New("URL cannot be empty")
-	ErrShortCodeTooShort  = errors.New("short code must be at least 3 characters")
-	ErrURLExpired         = errors.New("URL has expired")
-	ErrURLNotActive       = errors.New("URL is not active")
-	ErrCustomAliasInvalid = errors.New("custom alias must be alphanumeric and 3-20 characters")
+	ErrURLNotFound         = errors.New("URL not found")
+	ErrInvalidURL          = errors.New("invalid URL format")
+	ErrEmptyURL            = errors.New("URL cannot be empty")
+	ErrShortCodeTooShort   = errors.New("short code must be at least 3 characters")
+	ErrURLExpired          = errors.New("URL has expired")
+	ErrURLNotActive        = errors.New("URL is not active")
+	ErrCustomAliasInvalid  = errors.New("custom alias must be alphanumeric and 3-20 characters")
+	ErrCustomAliasReserved = errors.New("custom alias is reserved")
+	ErrRedirectorURL       = errors.New("destination redirects through another URL shortener")
+	ErrClickLimitReached   = errors.New("URL has reached its click limit")
+	ErrLinkUsed            = errors.New("link has already been used")
+	ErrInvalidClickLimit   = errors.New("click limit must be a positive number")
+	ErrInvalidDomain       = errors.New("domain must be a valid host name")
+	ErrCustomAliasTaken    = errors.New("custom alias already exists")
+	ErrShortCodeTaken      = errors.New("short code already exists")
+	ErrForbidden           = errors.New("not allowed to access this URL")
+	ErrVersionConflict     = errors.New("URL was modified by another request")
+	ErrPreconditionFailed  = errors.New("URL does not match the precondition")
 )
 
 // IsExpired checks if the URL has passed its expiration time
@@ -54,43 +127,104 @@ func (u *URL) CanBeAccessed() error {
 	if u.IsExpired() {
 		return ErrURLExpired
 	}
+	if u.ClickLimitReached() {
+		return ErrClickLimitReached
+	}
+	if u.SingleUse && u.UsedAt != nil {
+		return ErrLinkUsed
+	}
+	if u.BurnAfterReading && u.BurnedAt != nil {
+		return ErrLinkBurned
+	}
 	return nil
 }
 
+// Redirects reports whether the link redirects to OriginalURL
+// Burn-after-reading, file and paste links show their content instead and
+// have no destination at all
+func (u *URL) Redirects() bool {
+	return !u.BurnAfterReading && u.File == nil && u.Paste == nil
+}
+
+// ClickLimitReached checks if the URL has used up its click limit
+func (u *URL) ClickLimitReached() bool {
+	return u.MaxClicks != nil && u.Clicks >= *u.MaxClicks
+}
+
+// ClickLimitUsage returns the fraction of the click limit used (0 if unlimited)
+func (u *URL) ClickLimitUsage() float64 {
+	if u.MaxClicks == nil || *u.MaxClicks <= 0 {
+		return 0
+	}
+	return float64(u.Clicks) / float64(*u.MaxClicks)
+}
+
 // Validate checks if the URL fields are valid
 // This is called before saving to the database
 func (u *URL) Validate() error {
-	// Check if original URL is empty
-	if strings.TrimSpace(u.OriginalURL) == "" {
-		return ErrEmptyURL
+	// Burn-after-reading, file and paste links have no destination: what
+	// they show is sealed in Payload (see WithBurnAfterReading), stored in
+	// File or kept in Paste
+	switch {
+	case u.BurnAfterReading:
+		if u.Payload == nil {
+			return ErrInvalidSecret
+		}
+	case u.File != nil:
+		if err := u.File.Validate(); err != nil {
+			return err
+		}
+	case u.Paste != nil:
+		if err := u.Paste.Validate(); err != nil {
+			return err
+		}
+	case u.DestinationSealed():
+		// The destination was checked before it was encrypted
+		if u.LanguageTargets != nil || u.Schedule != nil {
+			return ErrSealedDestinationTargets
+		}
+	default:
+		if err := validateDestination(u.OriginalURL); err != nil {
+			return err
+		}
 	}
 
-	// Parse and validate URL format
-	// url.Parse is from Go's standard library
-	parsedURL, err := url.Parse(u.OriginalURL)
-	if err != nil {
-		return ErrInvalidURL
+	// Validate short code length
+	if len(u.ShortCode) < 3 {
+		return ErrShortCodeTooShort
 	}
 
-	// Ensure URL has a scheme (http:// or https://)
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return ErrInvalidURL
+	// Validate click limit if provided
+	if u.MaxClicks != nil && *u.MaxClicks <= 0 {
+		return ErrInvalidClickLimit
 	}
 
-	// Ensure URL has a host (domain)
-	if parsedURL.Host == "" {
-		return ErrInvalidURL
+	// Validate domain if provided
+	if u.Domain != "" && !IsValidHost(u.Domain) {
+		return ErrInvalidDomain
 	}
 
-	// Validate short code length
-	if len(u.ShortCode) < 3 {
-		return ErrShortCodeTooShort
+	// Validate language overrides if provided
+	if err := validateLanguageTargets(u.LanguageTargets); err != nil {
+		return err
+	}
+
+	// Validate the notes
+	if err := u.ValidateNotes(); err != nil {
+		return err
+	}
+
+	// Validate the schedule if provided
+	if u.Schedule != nil {
+		if err := u.Schedule.Validate(); err != nil {
+			return err
+		}
 	}
 
 	// Validate custom alias if provided
 	if u.CustomAlias != nil && *u.CustomAlias != "" {
-		if !isValidAlias(*u.CustomAlias) {
-			return ErrCustomAliasInvalid
+		if err := ValidateAlias(*u.CustomAlias); err != nil {
+			return err
 		}
 	}
 
@@ -125,6 +259,62 @@ func isValidAlias(alias string) bool {
 	return true
 }
 
+// validateDestination checks the URL a link redirects to
+func validateDestination(destination string) error {
+	// Check if original URL is empty
+	if strings.TrimSpace(destination) == "" {
+		return ErrEmptyURL
+	}
+
+	// Parse and validate URL format
+	// url.Parse is from Go's standard library
+	parsedURL, err := url.Parse(destination)
+	if err != nil {
+		return ErrInvalidURL
+	}
+
+	// Ensure URL has a scheme (http:// or https://)
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return ErrInvalidURL
+	}
+
+	// Ensure URL has a host (domain)
+	if parsedURL.Host == "" {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// IsHTTPURL reports whether raw is an absolute http(s) URL with a host
+// The one definition of "a link we can redirect to", shared by every check
+func IsHTTPURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// IsValidHost checks a host name such as "go.example.com" (no scheme, port, or path)
+func IsValidHost(host string) bool {
+	if len(host) > 253 || !strings.Contains(host, ".") {
+		return false
+	}
+
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, char := range label {
+			if !((char >= 'a' && char <= 'z') ||
+				(char >= 'A' && char <= 'Z') ||
+				(char >= '0' && char <= '9') ||
+				char == '-') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
 // NewURL is a constructor function that creates a new URL with sensible defaults
 // In Go, we use constructor functions instead of class constructors
 func NewURL(originalURL, shortCode, createdBy string) *URL {
@@ -135,9 +325,20 @@ func NewURL(originalURL, shortCode, createdBy string) *URL {
 		CreatedBy:   createdBy,
 		IsActive:    true,
 		Clicks:      0,
+		Version:     1,
 	}
 }
 
+// ETag identifies this version of the URL for HTTP caching and concurrency
+//
+// It is a STRONG validator ("..." without W/): any change to the settings
+// bumps Version and therefore the ETag. Clicks are deliberately NOT part of
+// it - a redirect must not make a client's If-Match fail.
+func (u *URL) ETag() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", u.ID, u.Version)))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
 // WithCustomAlias is a builder method that sets a custom alias
 // This is the "builder pattern" - allows for fluent API design
 func (u *URL) WithCustomAlias(alias string) *URL {
@@ -145,9 +346,62 @@ func (u *URL) WithCustomAlias(alias string) *URL {
 	return u
 }
 
+// WithResolvedURL records where the destination ultimately leads
+// Only stored when it differs from the original URL
+func (u *URL) WithResolvedURL(resolved string) *URL {
+	if resolved != "" && resolved != u.OriginalURL {
+		u.ResolvedURL = &resolved
+	}
+	return u
+}
+
 // WithExpiration sets an expiration time for the URL
 func (u *URL) WithExpiration(duration time.Duration) *URL {
 	expiresAt := time.Now().Add(duration)
 	u.ExpiresAt = &expiresAt
 	return u
 }
+
+// WithMaxClicks limits how many times the URL can be accessed
+func (u *URL) WithMaxClicks(maxClicks int64) *URL {
+	u.MaxClicks = &maxClicks
+	return u
+}
+
+// WithClickLimit returns a URLOption that sets a click limit
+func WithClickLimit(maxClicks int64) URLOption {
+	return func(u *URL) {
+		u.WithMaxClicks(maxClicks)
+	}
+}
+
+// WithSingleUse returns a URLOption that makes the link work exactly once
+//
+// WHY NOT A CLICK LIMIT OF 1?
+// Click limits are counted after the redirect, in the background - two
+// visitors arriving at the same moment both get through. A single-use link
+// is claimed BEFORE it redirects, with a conditional UPDATE that only one
+// request can win (see repository.URLRepository.MarkUsed). That is what
+// password-reset style links need.
+func WithSingleUse() URLOption {
+	return func(u *URL) {
+		u.SingleUse = true
+	}
+}
+
+// WithoutAnalytics returns a URLOption that turns off click analytics for the link
+// Clicks are still counted (click limits depend on it), but no click event
+// with IP address, User-Agent and referrer is stored. Useful for links in
+// privacy-sensitive places, or when the owner simply doesn't need the data.
+func WithoutAnalytics() URLOption {
+	return func(u *URL) {
+		u.AnalyticsDisabled = true
+	}
+}
+
+// WithDomain returns a URLOption that serves the short link on a specific host
+func WithDomain(host string) URLOption {
+	return func(u *URL) {
+		u.Domain = strings.ToLower(host)
+	}
+}
@@ -0,0 +1,58 @@
+package domain
+
+import "errors"
+
+// LinkVisibility controls which workspace members may see where a link goes
+type LinkVisibility string
+
+const (
+	VisibilityStandard  LinkVisibility = ""          // Every member sees everything
+	VisibilitySensitive LinkVisibility = "sensitive" // Viewers see the stats, not the destinations
+)
+
+// ErrInvalidVisibility is returned for an unknown visibility
+var ErrInvalidVisibility = errors.New(`visibility must be "standard" or "sensitive"`)
+
+// ParseLinkVisibility reads a visibility from the API ("" or "standard" = standard)
+func ParseLinkVisibility(s string) (LinkVisibility, error) {
+	switch s {
+	case "", "standard":
+		return VisibilityStandard, nil
+	case string(VisibilitySensitive):
+		return VisibilitySensitive, nil
+	default:
+		return "", ErrInvalidVisibility
+	}
+}
+
+// SENSITIVE LINKS
+// Some destinations are confidential - an unannounced product page, a
+// partner's private deal - while their click numbers are not. Marking such
+// a link sensitive lets the workspace's viewers (analysts, the marketing
+// agency) follow its stats without learning where it points.
+//
+// The link still redirects for everyone: visibility is about what the API
+// tells workspace members, not about who may open the link.
+
+// WithVisibility returns a URLOption that sets who sees the link's destinations
+func WithVisibility(v LinkVisibility) URLOption {
+	return func(u *URL) {
+		u.Visibility = v
+	}
+}
+
+// WithoutDestinations returns a copy of the link without anything that
+// reveals where it goes: the destination, the resolved URL, the language
+// and schedule destinations, and the metadata of the destination page
+//
+// It's a copy because url may be shared (the cache hands out the same
+// link to other requests). The stats stay.
+func (u *URL) WithoutDestinations() *URL {
+	redacted := *u
+	redacted.OriginalURL = ""
+	redacted.ResolvedURL = nil
+	redacted.LanguageTargets = nil
+	redacted.Schedule = nil
+	redacted.Metadata = nil
+	return &redacted
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLinkVisibility(t *testing.T) {
+	tests := []struct {
+		input     string
+		want      LinkVisibility
+		expectErr bool
+	}{
+		{input: "", want: VisibilityStandard},
+		{input: "standard", want: VisibilityStandard},
+		{input: "sensitive", want: VisibilitySensitive},
+		{input: "Sensitive", expectErr: true},
+		{input: "private", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLinkVisibility(tt.input)
+			if tt.expectErr {
+				assert.ErrorIs(t, err, ErrInvalidVisibility)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestURL_WithoutDestinations(t *testing.T) {
+	// Arrange
+	resolved := "https://example.com/launch?utm_source=go"
+	url := NewURL("https://example.com/launch", "launch", "team1")
+	for _, opt := range []URLOption{
+		WithVisibility(VisibilitySensitive),
+		WithLanguageTargets(map[string]string{"fr": "https://example.com/fr/launch"}),
+		WithDescription("Spring launch"),
+	} {
+		opt(url)
+	}
+	url.ResolvedURL = &resolved
+	url.Metadata = &LinkMetadata{Title: "Launch"}
+	url.Clicks = 42
+
+	// Act
+	redacted := url.WithoutDestinations()
+
+	// Assert: the destinations are gone, the stats and notes stay
+	assert.Empty(t, redacted.OriginalURL)
+	assert.Nil(t, redacted.ResolvedURL)
+	assert.Nil(t, redacted.LanguageTargets)
+	assert.Nil(t, redacted.Metadata)
+	assert.Equal(t, int64(42), redacted.Clicks)
+	assert.Equal(t, "Spring launch", redacted.Description)
+	assert.Equal(t, VisibilitySensitive, redacted.Visibility)
+
+	// ...and the original is untouched (it may be the cache's copy)
+	assert.Equal(t, "https://example.com/launch", url.OriginalURL)
+	assert.NotNil(t, url.ResolvedURL)
+	assert.NotNil(t, url.Metadata)
+}
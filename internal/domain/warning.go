@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// WarningKind identifies why an owner is being warned about a URL
+type WarningKind string
+
+const (
+	// WarningClickLimitNear fires when a URL has used 80% of its click limit
+	WarningClickLimitNear WarningKind = "click_limit.near"
+
+	// WarningClickLimitReached fires when a URL has used its whole click limit
+	WarningClickLimitReached WarningKind = "click_limit.reached"
+
+	// WarningExpiringSoon fires when a URL is close to its expiration time
+	WarningExpiringSoon WarningKind = "url.expiring_soon"
+
+	// WarningExpired records that a URL's expiration was announced (events.URLExpired)
+	WarningExpired WarningKind = "url.expired"
+)
+
+// ClickLimitWarningRatio is the fraction of the click limit that triggers WarningClickLimitNear
+const ClickLimitWarningRatio = 0.8
+
+// LinkWarning is a warning about a URL that is about to stop working
+// Each kind is sent at most once per URL
+type LinkWarning struct {
+	Kind        WarningKind
+	URL         *URL
+	TriggeredAt time.Time
+}
+
+// NewLinkWarning creates a warning for a URL
+func NewLinkWarning(kind WarningKind, url *URL) *LinkWarning {
+	return &LinkWarning{
+		Kind:        kind,
+		URL:         url,
+		TriggeredAt: time.Now(),
+	}
+}
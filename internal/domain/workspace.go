@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrWorkspaceSettingsNotFound means a workspace never saved its settings
+	ErrWorkspaceSettingsNotFound = errors.New("workspace settings not found")
+
+	// ErrInvalidGroupRoles is returned for a group_roles mapping that names
+	// no group or an unknown role
+	ErrInvalidGroupRoles = errors.New("group_roles maps up to 100 group names to owner, editor, contributor or viewer")
+)
+
+// MaxGroupRoles is how many groups a workspace may map to roles
+const MaxGroupRoles = 100
+
+// WorkspaceSettings are the defaults of one workspace
+// A workspace is the account that owns links: the principal ID stored as
+// created_by (see featureflags)
+type WorkspaceSettings struct {
+	Workspace string
+	Timezone  string // IANA name analytics are bucketed in; "" = server default
+	UpdatedAt time.Time
+
+	// Links created by contributors wait for an editor or owner to approve
+	// them before they redirect (see WithPendingApproval)
+	RequireApproval bool
+
+	// Role of the members of each group, by group name (see Member); the
+	// role names are checked by the service, which knows them
+	GroupRoles map[string]string
+
+	// Quota plan of the workspace ("" = the default plan). Set by operators
+	// only (see WorkspaceService.SetPlan); owners saving their settings
+	// can't change it
+	Plan string
+}
+
+// Validate checks the settings before they are saved
+func (s *WorkspaceSettings) Validate() error {
+	if s.Timezone != "" {
+		if _, err := LoadTimezone(s.Timezone); err != nil {
+			return err
+		}
+	}
+	if len(s.GroupRoles) > MaxGroupRoles {
+		return ErrInvalidGroupRoles
+	}
+	for group := range s.GroupRoles {
+		if strings.TrimSpace(group) == "" {
+			return ErrInvalidGroupRoles
+		}
+	}
+	return nil
+}
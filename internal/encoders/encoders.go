@@ -0,0 +1,94 @@
+// Package encoders writes API responses as JSON, XML or MessagePack
+//
+// CONTENT NEGOTIATION:
+// The client says which formats it understands in the Accept header, with
+// optional preferences ("q" values):
+//
+//	Accept: application/xml                      -> XML
+//	Accept: application/msgpack, */*;q=0.1      -> MessagePack
+//	Accept: text/html,*/*  (or no header)       -> JSON, the default
+//
+// WHY NOT encoding/xml DIRECTLY?
+// Our DTOs are built for JSON: maps (click breakdowns, error details) and
+// interface{} fields that encoding/xml can't handle, plus json tags that
+// name every field. So every format starts from the JSON document: XML and
+// MessagePack are translations of it, with the same field names and order.
+// A field added to a DTO shows up in every format without extra work.
+package encoders
+
+import (
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// Encoder writes a value in one format
+type Encoder interface {
+	ContentType() string // Sent as the Content-Type header
+	Encode(w io.Writer, v interface{}) error
+}
+
+// Registry maps media types to encoders
+type Registry struct {
+	fallback Encoder
+	byType   map[string]Encoder
+}
+
+// NewRegistry creates a registry answering with fallback when nothing else matches
+// The fallback is registered under its own content type
+func NewRegistry(fallback Encoder) *Registry {
+	r := &Registry{fallback: fallback, byType: map[string]Encoder{}}
+	r.Register(fallback.ContentType(), fallback)
+	return r
+}
+
+// Register makes an encoder available under a media type
+// One encoder can be registered under several names (text/xml, application/xml)
+func (r *Registry) Register(mediaType string, encoder Encoder) *Registry {
+	r.byType[strings.ToLower(mediaType)] = encoder
+	return r
+}
+
+// Default returns the registry used by the API: JSON (default), XML and MessagePack
+func Default() *Registry {
+	xml := XML{}
+	msgpack := MsgPack{}
+	return NewRegistry(JSON{}).
+		Register("application/xml", xml).
+		Register("text/xml", xml).
+		Register("application/msgpack", msgpack).
+		Register("application/x-msgpack", msgpack).
+		Register("application/vnd.msgpack", msgpack)
+}
+
+// Negotiate picks the encoder for an Accept header
+// The highest q value wins; on a tie the client's order decides. Wildcards
+// and unknown or missing types get the fallback - a client asking only for
+// something we can't produce still gets a readable JSON answer instead of 406.
+func (r *Registry) Negotiate(accept string) Encoder {
+	best, bestQ := r.fallback, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(raw, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+
+		if encoder, ok := r.byType[mediaType]; ok {
+			best, bestQ = encoder, q
+		} else if mediaType == "*/*" || mediaType == "application/*" {
+			best, bestQ = r.fallback, q
+		}
+	}
+	return best
+}
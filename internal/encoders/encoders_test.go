@@ -0,0 +1,120 @@
+package encoders
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	registry := Default()
+
+	tests := []struct {
+		accept   string
+		expected Encoder
+	}{
+		{accept: "", expected: JSON{}},
+		{accept: "application/json", expected: JSON{}},
+		{accept: "application/xml", expected: XML{}},
+		{accept: "text/xml; charset=utf-8", expected: XML{}},
+		{accept: "application/msgpack", expected: MsgPack{}},
+		{accept: "application/x-msgpack", expected: MsgPack{}},
+		{accept: "text/html,application/xhtml+xml,*/*;q=0.8", expected: JSON{}},
+		{accept: "application/json;q=0.5, application/xml", expected: XML{}},
+		{accept: "application/xml;q=0.9, application/msgpack;q=0.9", expected: XML{}}, // Tie: client's order
+		{accept: "*/*;q=0.1, application/msgpack", expected: MsgPack{}},
+		{accept: "text/csv", expected: JSON{}},
+		{accept: "not a media type", expected: JSON{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			assert.Equal(t, tt.expected, registry.Negotiate(tt.accept))
+		})
+	}
+}
+
+type testPayload struct {
+	Code    string            `json:"short_code"`
+	Clicks  int64             `json:"clicks"`
+	Expires *time.Time        `json:"expires_at"`
+	Tags    []string          `json:"tags,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+	Active  bool              `json:"is_active"`
+}
+
+func TestXML_Encode(t *testing.T) {
+	// Arrange
+	payload := map[string]interface{}{
+		"data": testPayload{
+			Code:    "abc<123>",
+			Clicks:  42,
+			Tags:    []string{"a", "b"},
+			Details: map[string]string{"rules[0].url": "URL is required"},
+			Active:  true,
+		},
+	}
+	var buf bytes.Buffer
+
+	// Act
+	err := XML{}.Encode(&buf, payload)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<response><data>`+
+		`<short_code>abc&lt;123&gt;</short_code><clicks>42</clicks><expires_at></expires_at>`+
+		`<tags><item>a</item><item>b</item></tags>`+
+		`<details><entry key="rules[0].url">URL is required</entry></details>`+
+		`<is_active>true</is_active>`+
+		`</data></response>`+"\n", buf.String())
+}
+
+func TestMsgPack_Encode(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected []byte
+	}{
+		{name: "nil", value: nil, expected: []byte{0xc0}},
+		{name: "true", value: true, expected: []byte{0xc3}},
+		{name: "fixint", value: 7, expected: []byte{0x07}},
+		{name: "negative fixint", value: -3, expected: []byte{0xfd}},
+		{name: "int16", value: 300, expected: []byte{0xd1, 0x01, 0x2c}},
+		{name: "int64", value: int64(1) << 40, expected: []byte{0xd3, 0, 0, 1, 0, 0, 0, 0, 0}},
+		{name: "float", value: 1.5, expected: []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{name: "fixstr", value: "abc", expected: []byte{0xa3, 'a', 'b', 'c'}},
+		{name: "fixarray", value: []int{1, 2}, expected: []byte{0x92, 0x01, 0x02}},
+		{
+			name: "fixmap keeps field order",
+			value: struct {
+				B int    `json:"b"`
+				A string `json:"a"`
+			}{B: 1, A: "x"},
+			expected: []byte{0x82, 0xa1, 'b', 0x01, 0xa1, 'a', 0xa1, 'x'},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			var buf bytes.Buffer
+			err := MsgPack{}.Encode(&buf, tt.value)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, buf.Bytes())
+		})
+	}
+}
+
+func TestMsgPack_LongString(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, MsgPack{}.Encode(&buf, string(bytes.Repeat([]byte("x"), 40))))
+
+	assert.Equal(t, []byte{0xd9, 40}, buf.Bytes()[:2]) // str8
+	assert.Len(t, buf.Bytes(), 42)
+}
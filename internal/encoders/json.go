@@ -0,0 +1,85 @@
+package encoders
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSON writes application/json, the format every endpoint was built for
+type JSON struct{}
+
+// ContentType returns application/json
+func (JSON) ContentType() string { return "application/json" }
+
+// Encode writes v as JSON followed by a newline
+func (JSON) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// member is one key of a JSON object, kept in document order
+type member struct {
+	key   string
+	value interface{}
+}
+
+// document converts v to its JSON document: nil, bool, json.Number,
+// string, []interface{} or []member (an object)
+// Goes through encoding/json so json tags, omitempty and MarshalJSON apply
+func document(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep integers exact (no float64 round trip)
+	return readValue(decoder)
+}
+
+// readValue reads one JSON value token by token, keeping object key order
+func readValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			var object []member
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := readValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				object = append(object, member{key: key.(string), value: value})
+			}
+			_, err := decoder.Token() // Closing }
+			if object == nil {
+				object = []member{}
+			}
+			return object, err
+		case '[':
+			array := []interface{}{}
+			for decoder.More() {
+				value, err := readValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			}
+			_, err := decoder.Token() // Closing ]
+			return array, err
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", t)
+	default:
+		return t, nil // nil, bool, json.Number or string
+	}
+}
@@ -0,0 +1,133 @@
+package encoders
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// MsgPack writes application/msgpack (https://msgpack.org), a binary JSON
+//
+// Same structure as JSON, but smaller and faster to parse - popular with
+// embedded and high-volume clients. Only the types a JSON document can
+// hold are needed: nil, bool, integers, floats, strings, arrays and maps.
+// Times stay RFC 3339 strings, exactly as in JSON.
+type MsgPack struct{}
+
+// ContentType returns application/msgpack
+func (MsgPack) ContentType() string { return "application/msgpack" }
+
+// Encode writes v as one MessagePack value
+func (MsgPack) Encode(w io.Writer, v interface{}) error {
+	doc, err := document(v)
+	if err != nil {
+		return err
+	}
+
+	buf := bufio.NewWriter(w)
+	if err := writeMsgPack(buf, doc); err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// writeMsgPack writes one value of a JSON document
+func writeMsgPack(w *bufio.Writer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return w.WriteByte(0xc0)
+	case bool:
+		if v {
+			return w.WriteByte(0xc3)
+		}
+		return w.WriteByte(0xc2)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return writeMsgPackInt(w, n)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf("invalid number %q: %w", v, err)
+		}
+		return writeMsgPackHeader(w, 0xcb, math.Float64bits(f), 8)
+	case string:
+		if err := writeMsgPackLength(w, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb); err != nil {
+			return err
+		}
+		_, err := w.WriteString(v)
+		return err
+	case []interface{}:
+		if err := writeMsgPackLength(w, len(v), 0x90, 16, 0, 0xdc, 0xdd); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := writeMsgPack(w, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []member:
+		if err := writeMsgPackLength(w, len(v), 0x80, 16, 0, 0xde, 0xdf); err != nil {
+			return err
+		}
+		for _, m := range v {
+			if err := writeMsgPack(w, m.key); err != nil {
+				return err
+			}
+			if err := writeMsgPack(w, m.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("msgpack: unsupported type %T", value)
+	}
+}
+
+// writeMsgPackInt uses the smallest integer encoding that fits
+func writeMsgPackInt(w *bufio.Writer, n int64) error {
+	switch {
+	case n >= 0 && n <= 127:
+		return w.WriteByte(byte(n)) // positive fixint
+	case n < 0 && n >= -32:
+		return w.WriteByte(byte(int8(n))) // negative fixint
+	case n >= math.MinInt8 && n <= math.MaxInt8:
+		return writeMsgPackHeader(w, 0xd0, uint64(uint8(int8(n))), 1)
+	case n >= math.MinInt16 && n <= math.MaxInt16:
+		return writeMsgPackHeader(w, 0xd1, uint64(uint16(int16(n))), 2)
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		return writeMsgPackHeader(w, 0xd2, uint64(uint32(int32(n))), 4)
+	default:
+		return writeMsgPackHeader(w, 0xd3, uint64(n), 8)
+	}
+}
+
+// writeMsgPackLength writes the header of a string, array or map
+// fix is the "fix" prefix for lengths below fixLimit; len8 (0 = none),
+// len16 and len32 are the prefixes for longer values
+func writeMsgPackLength(w *bufio.Writer, n int, fix byte, fixLimit int, len8, len16, len32 byte) error {
+	switch {
+	case n < fixLimit:
+		return w.WriteByte(fix | byte(n))
+	case len8 != 0 && n <= math.MaxUint8:
+		return writeMsgPackHeader(w, len8, uint64(n), 1)
+	case n <= math.MaxUint16:
+		return writeMsgPackHeader(w, len16, uint64(n), 2)
+	default:
+		return writeMsgPackHeader(w, len32, uint64(n), 4)
+	}
+}
+
+// writeMsgPackHeader writes a type byte followed by size big-endian bytes of value
+func writeMsgPackHeader(w *bufio.Writer, typ byte, value uint64, size int) error {
+	if err := w.WriteByte(typ); err != nil {
+		return err
+	}
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], value)
+	_, err := w.Write(b[8-size:])
+	return err
+}
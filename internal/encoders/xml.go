@@ -0,0 +1,107 @@
+package encoders
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// XML writes application/xml for clients that can't read JSON
+//
+// The JSON document maps to elements one to one:
+//
+//	{"data": {"short_code": "abc", "tags": ["a", "b"]}}
+//
+//	<response>
+//	  <data>
+//	    <short_code>abc</short_code>
+//	    <tags><item>a</item><item>b</item></tags>
+//	  </data>
+//	</response>
+//
+// Keys that aren't valid element names (e.g. "rules[0].url" in error
+// details) become <entry key="rules[0].url">. null becomes an empty element.
+type XML struct{}
+
+// xmlName matches keys usable as element names as they are
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// ContentType returns application/xml
+func (XML) ContentType() string { return "application/xml; charset=utf-8" }
+
+// Encode writes v as an XML document with a <response> root
+func (XML) Encode(w io.Writer, v interface{}) error {
+	doc, err := document(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	if err := writeXMLElement(encoder, xml.StartElement{Name: xml.Name{Local: "response"}}, doc); err != nil {
+		return err
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// writeXMLElement writes value wrapped in start
+func writeXMLElement(encoder *xml.Encoder, start xml.StartElement, value interface{}) error {
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case []member:
+		for _, m := range v {
+			if err := writeXMLElement(encoder, xmlStart(m.key), m.value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := writeXMLElement(encoder, xml.StartElement{Name: xml.Name{Local: "item"}}, item); err != nil {
+				return err
+			}
+		}
+	case nil:
+		// Empty element
+	case bool:
+		text := "false"
+		if v {
+			text = "true"
+		}
+		if err := encoder.EncodeToken(xml.CharData(text)); err != nil {
+			return err
+		}
+	case json.Number:
+		if err := encoder.EncodeToken(xml.CharData(v.String())); err != nil {
+			return err
+		}
+	case string:
+		if err := encoder.EncodeToken(xml.CharData(v)); err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+// xmlStart returns the element for an object key
+// "xml..." names are reserved by the XML spec, so they get an entry too
+func xmlStart(key string) xml.StartElement {
+	if xmlName.MatchString(key) && !strings.HasPrefix(strings.ToLower(key), "xml") {
+		return xml.StartElement{Name: xml.Name{Local: key}}
+	}
+	return xml.StartElement{
+		Name: xml.Name{Local: "entry"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key}},
+	}
+}
@@ -0,0 +1,165 @@
+// Package errreport sends crashes and server errors to an error tracker
+//
+// WHY?
+// A panic recovered by the HTTP middleware or a background worker used to
+// be one log line among millions. An error tracker (Sentry) groups the same
+// crash across replicas, keeps its stack trace and the request it happened
+// in, tells which release introduced it and alerts someone.
+//
+// HOW:
+// Code that recovers a panic or answers 5xx builds an Event (Panic does it
+// for a recovered value, stack trace included) and hands it to a Reporter.
+// Reporters send in the background: a slow or unreachable tracker never
+// delays a request. Nop is used when no tracker is configured.
+package errreport
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Levels of an event
+const (
+	LevelError = "error" // A request or job failed (5xx)
+	LevelFatal = "fatal" // A panic
+)
+
+// appModule is the module path: frames inside it are our code ("in app")
+const appModule = "url-shortener/"
+
+// Event is one error to report
+type Event struct {
+	Level   string // LevelError or LevelFatal
+	Type    string // Kind of error, e.g. "runtime.boundsError" or "HTTP 503"
+	Message string // What happened
+	Panic   bool   // Recovered from a panic (reported even when sampled out)
+	Stack   []Frame
+	Request *Request          // The HTTP request it happened in (nil in background work)
+	Tags    map[string]string // Searchable context: component, task, job kind, request ID
+	Time    time.Time
+
+	// Fingerprint groups events in the tracker (nil = by type and stack)
+	// 5xx responses have no stack: they are grouped by method and status,
+	// not by path, or every short code would be its own issue
+	Fingerprint []string
+}
+
+// Frame is one function call of a stack trace
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+	InApp    bool // Our code, not the standard library or a dependency
+}
+
+// Request is the HTTP request an event happened in
+// Only what helps debugging and is safe to store outside our
+// infrastructure: no query string, cookies or credentials
+type Request struct {
+	Method    string
+	URL       string // Scheme, host and path
+	Status    int
+	UserAgent string
+}
+
+// Reporter sends events to an error tracker
+// Implementations must be safe for concurrent use and must not block:
+// Report is called while a request or a worker waits
+type Reporter interface {
+	Report(event Event)
+	// Flush waits until the events reported so far are sent, at most timeout
+	// Returns false if some were still pending
+	Flush(timeout time.Duration) bool
+}
+
+// Nop discards every event
+// Used when no error tracker is configured
+type Nop struct{}
+
+// Report does nothing
+func (Nop) Report(event Event) {}
+
+// Flush has nothing to wait for
+func (Nop) Flush(timeout time.Duration) bool {
+	return true
+}
+
+// Panic describes a value returned by recover()
+// Call it from the deferred function that recovered: the stack of the
+// panicking goroutine is still there, and becomes the event's stack trace
+func Panic(recovered any) Event {
+	return Event{
+		Level:   LevelFatal,
+		Type:    fmt.Sprintf("%T", recovered),
+		Message: fmt.Sprintf("panic: %v", recovered),
+		Panic:   true,
+		Stack:   Stack(),
+		Tags:    map[string]string{},
+		Time:    time.Now(),
+	}
+}
+
+// Stack returns the stack trace of the calling goroutine, innermost call
+// first, without the runtime's and this package's frames
+// Inside a recovering deferred function, the trace starts where the panic
+// was raised: the recovery code itself is left out.
+func Stack() []Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []Frame
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.gopanic" {
+			stack = stack[:0] // Everything so far is the recovery, not the crash
+		}
+		if !skipFrame(frame.Function) {
+			stack = append(stack, Frame{
+				Function: frame.Function,
+				File:     frame.File,
+				Line:     frame.Line,
+				InApp:    strings.HasPrefix(frame.Function, appModule),
+			})
+		}
+		if !more {
+			return stack
+		}
+	}
+}
+
+// skipFrame reports whether a frame is machinery rather than the crash
+func skipFrame(function string) bool {
+	return strings.HasPrefix(function, "runtime.") ||
+		function == appModule+"internal/errreport.Stack" ||
+		function == appModule+"internal/errreport.Panic"
+}
+
+// DefaultRelease is the release of this binary when none is configured:
+// the VCS revision Go records at build time ("" if it wasn't recorded)
+func DefaultRelease() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	var revision string
+	var modified bool
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if revision != "" && modified {
+		revision += "-dirty"
+	}
+	return revision
+}
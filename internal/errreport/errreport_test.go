@@ -0,0 +1,36 @@
+package errreport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func crash() {
+	var codes []string
+	_ = codes[3] // Index out of range
+}
+
+func TestPanic(t *testing.T) {
+	// Arrange
+	var event Event
+
+	// Act
+	func() {
+		defer func() { event = Panic(recover()) }()
+		crash()
+	}()
+
+	// Assert
+	assert.Equal(t, LevelFatal, event.Level)
+	assert.True(t, event.Panic)
+	assert.Equal(t, "runtime.boundsError", event.Type)
+	assert.Contains(t, event.Message, "panic: runtime error: index out of range")
+	require.NotEmpty(t, event.Stack)
+	assert.Equal(t, "url-shortener/internal/errreport.crash", event.Stack[0].Function, "starts where the panic was raised")
+	assert.True(t, event.Stack[0].InApp)
+	for _, frame := range event.Stack {
+		assert.NotContains(t, frame.Function, "runtime.", "no panic machinery")
+	}
+}
@@ -0,0 +1,362 @@
+package errreport
+
+import (
+	"bytes"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/internal/metrics"
+)
+
+// maxPending is how many events may be on their way at once
+// More are dropped: during an outage every request fails, and the tracker
+// doesn't need ten thousand copies of the same error
+const maxPending = 20
+
+// defaultRetryAfter is how long to wait after a 429 without Retry-After
+const defaultRetryAfter = time.Minute
+
+// Sentry sends events to Sentry (or a tracker speaking its protocol, such as
+// GlitchTip or a self-hosted Sentry) through the envelope API
+//
+// SAMPLING:
+// Panics are always sent. Other events (5xx responses) are sent with the
+// probability of the sample rate: enough to see that something is wrong
+// without sending every failed request of an incident.
+type Sentry struct {
+	dsn        string
+	endpoint   string // <scheme>://<host>[/<path>]/api/<project>/envelope/
+	publicKey  string
+	client     *http.Client
+	release    string
+	env        string
+	serverName string
+	tags       map[string]string
+	sampleRate float64
+
+	random func() float64
+	now    func() time.Time
+
+	pending atomic.Int64
+
+	mu           sync.Mutex
+	limitedUntil time.Time // The tracker asked us to wait (429)
+}
+
+// NewSentry creates a Sentry reporter for a DSN
+// ("https://<public key>@<host>/<project ID>", shown in the project settings)
+func NewSentry(dsn string, timeout time.Duration) (*Sentry, error) {
+	endpoint, publicKey, err := parseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	serverName, _ := os.Hostname()
+	return &Sentry{
+		dsn:        dsn,
+		endpoint:   endpoint,
+		publicKey:  publicKey,
+		client:     &http.Client{Timeout: timeout},
+		serverName: serverName,
+		tags:       map[string]string{},
+		sampleRate: 1,
+		random:     rand.Float64,
+		now:        time.Now,
+	}, nil
+}
+
+// WithRelease sets the release events belong to (e.g. a git revision)
+// The tracker shows in which release an error first appeared
+func (s *Sentry) WithRelease(release string) *Sentry {
+	s.release = release
+	return s
+}
+
+// WithEnvironment sets the environment of events ("production", "staging")
+func (s *Sentry) WithEnvironment(env string) *Sentry {
+	s.env = env
+	return s
+}
+
+// WithSampleRate sets the share of non-panic events that are sent (0 to 1)
+func (s *Sentry) WithSampleRate(rate float64) *Sentry {
+	s.sampleRate = rate
+	return s
+}
+
+// WithTag adds a tag to every event (e.g. the region of this instance)
+func (s *Sentry) WithTag(key, value string) *Sentry {
+	s.tags[key] = value
+	return s
+}
+
+// Report sends the event in the background
+func (s *Sentry) Report(event Event) {
+	if !event.Panic && s.random() >= s.sampleRate {
+		metrics.RecordErrorReport(event.Level, "sampled_out")
+		return
+	}
+	if s.rateLimited() {
+		metrics.RecordErrorReport(event.Level, "rate_limited")
+		return
+	}
+	if s.pending.Add(1) > maxPending {
+		s.pending.Add(-1)
+		metrics.RecordErrorReport(event.Level, "dropped")
+		return
+	}
+
+	go func() {
+		defer s.pending.Add(-1)
+		if err := s.send(event); err != nil {
+			fmt.Printf("Warning: failed to report error to Sentry: %v\n", err)
+		}
+	}()
+}
+
+// Flush waits until the events reported so far are sent, at most timeout
+func (s *Sentry) Flush(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for s.pending.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
+// send posts one event as an envelope
+func (s *Sentry) send(event Event) error {
+	body, err := s.envelope(event)
+	if err != nil {
+		metrics.RecordErrorReport(event.Level, "failed")
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		metrics.RecordErrorReport(event.Level, "failed")
+		return fmt.Errorf("failed to create Sentry request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", "Sentry sentry_version=7, sentry_client=url-shortener/1.0, sentry_key="+s.publicKey)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		metrics.RecordErrorReport(event.Level, "failed")
+		return fmt.Errorf("sentry request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		s.limit(resp.Header.Get("Retry-After"))
+		metrics.RecordErrorReport(event.Level, "rate_limited")
+		return nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		metrics.RecordErrorReport(event.Level, "failed")
+		return fmt.Errorf("sentry returned status %d", resp.StatusCode)
+	}
+	metrics.RecordErrorReport(event.Level, "sent")
+	return nil
+}
+
+// rateLimited reports whether the tracker asked us to wait
+func (s *Sentry) rateLimited() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now().Before(s.limitedUntil)
+}
+
+// limit stops sending for the Retry-After of a 429 (seconds)
+func (s *Sentry) limit(retryAfter string) {
+	wait := defaultRetryAfter
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds > 0 {
+		wait = time.Duration(seconds) * time.Second
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limitedUntil = s.now().Add(wait)
+}
+
+// envelope encodes an event the way the envelope API expects it:
+// an envelope header line, an item header line, then the event JSON
+func (s *Sentry) envelope(event Event) ([]byte, error) {
+	payload := s.payload(event)
+	item, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	var buf bytes.Buffer
+	header, _ := json.Marshal(map[string]string{
+		"event_id": payload.EventID,
+		"sent_at":  s.now().UTC().Format(time.RFC3339),
+		"dsn":      s.dsn,
+	})
+	buf.Write(header)
+	buf.WriteByte('\n')
+	itemHeader, _ := json.Marshal(map[string]any{"type": "event", "length": len(item)})
+	buf.Write(itemHeader)
+	buf.WriteByte('\n')
+	buf.Write(item)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// sentryEvent is the event payload of the envelope API
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Fingerprint []string          `json:"fingerprint,omitempty"`
+	Exception   struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+	Request *sentryRequest `json:"request,omitempty"`
+}
+
+type sentryException struct {
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	Mechanism struct {
+		Type    string `json:"type"`
+		Handled bool   `json:"handled"`
+	} `json:"mechanism"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type sentryRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// payload converts an event to the payload Sentry expects
+func (s *Sentry) payload(event Event) sentryEvent {
+	out := sentryEvent{
+		EventID:     newEventID(),
+		Timestamp:   event.Time.UTC().Format(time.RFC3339Nano),
+		Platform:    "go",
+		Level:       event.Level,
+		Release:     s.release,
+		Environment: s.env,
+		ServerName:  s.serverName,
+		Tags:        make(map[string]string, len(s.tags)+len(event.Tags)),
+		Fingerprint: event.Fingerprint,
+	}
+	if event.Time.IsZero() {
+		out.Timestamp = s.now().UTC().Format(time.RFC3339Nano)
+	}
+	for k, v := range s.tags {
+		out.Tags[k] = v
+	}
+	for k, v := range event.Tags {
+		out.Tags[k] = v
+	}
+
+	exception := sentryException{Type: event.Type, Value: event.Message}
+	// Recovered panics are "handled" (the process lives on), 5xx are too
+	exception.Mechanism.Type = "generic"
+	exception.Mechanism.Handled = true
+	if event.Panic {
+		exception.Mechanism.Type = "panic"
+	}
+	if len(event.Stack) > 0 {
+		// Sentry lists frames oldest first, the crash last
+		frames := make([]sentryFrame, 0, len(event.Stack))
+		for i := len(event.Stack) - 1; i >= 0; i-- {
+			frame := event.Stack[i]
+			module, function := splitFunction(frame.Function)
+			frames = append(frames, sentryFrame{
+				Function: function,
+				Module:   module,
+				AbsPath:  frame.File,
+				Lineno:   frame.Line,
+				InApp:    frame.InApp,
+			})
+		}
+		exception.Stacktrace = &sentryStacktrace{Frames: frames}
+	}
+	out.Exception.Values = []sentryException{exception}
+
+	if r := event.Request; r != nil {
+		out.Request = &sentryRequest{Method: r.Method, URL: r.URL}
+		if r.UserAgent != "" {
+			out.Request.Headers = map[string]string{"User-Agent": r.UserAgent}
+		}
+		if r.Status != 0 {
+			out.Tags["status_code"] = strconv.Itoa(r.Status)
+		}
+	}
+	return out
+}
+
+// splitFunction splits "url-shortener/internal/jobs.(*Queue).run" into the
+// package ("url-shortener/internal/jobs") and the function ("(*Queue).run")
+func splitFunction(name string) (module, function string) {
+	slash := strings.LastIndex(name, "/") + 1
+	dot := strings.Index(name[slash:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	return name[:slash+dot], name[slash+dot+1:]
+}
+
+// newEventID returns a random event ID (32 hex characters)
+func newEventID() string {
+	id := make([]byte, 16)
+	_, _ = cryptorand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// parseDSN returns the envelope endpoint and public key of a DSN
+func parseDSN(dsn string) (endpoint, publicKey string, err error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return "", "", fmt.Errorf("invalid Sentry DSN: scheme must be https or http")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return "", "", fmt.Errorf("invalid Sentry DSN: missing public key")
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project := path[slash+1:]
+	if u.Host == "" || project == "" {
+		return "", "", fmt.Errorf("invalid Sentry DSN: missing host or project ID")
+	}
+	// Self-hosted Sentry can live under a path: https://key@host/sentry/42
+	endpoint = u.Scheme + "://" + u.Host + path[:slash] + "/api/" + project + "/envelope/"
+	return endpoint, u.User.Username(), nil
+}
@@ -0,0 +1,185 @@
+package errreport
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDSN(t *testing.T) {
+	tests := []struct {
+		name             string
+		dsn              string
+		expectedEndpoint string
+		wantErr          bool
+	}{
+		{name: "sentry.io", dsn: "https://abc123@o42.ingest.sentry.io/4501", expectedEndpoint: "https://o42.ingest.sentry.io/api/4501/envelope/"},
+		{name: "self-hosted under a path", dsn: "http://abc123@sentry.internal:9000/sentry/7", expectedEndpoint: "http://sentry.internal:9000/sentry/api/7/envelope/"},
+		{name: "no public key", dsn: "https://o42.ingest.sentry.io/4501", wantErr: true},
+		{name: "no project", dsn: "https://abc123@o42.ingest.sentry.io/", wantErr: true},
+		{name: "not a URL", dsn: "abc123", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			endpoint, publicKey, err := parseDSN(tt.dsn)
+
+			// Assert
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedEndpoint, endpoint)
+			assert.Equal(t, "abc123", publicKey)
+		})
+	}
+}
+
+// fakeSentry records the events it receives and answers with status
+func fakeSentry(t *testing.T, status int) (*httptest.Server, *[]map[string]any) {
+	var mu sync.Mutex
+	var events []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/42/envelope/", r.URL.Path)
+		assert.Contains(t, r.Header.Get("X-Sentry-Auth"), "sentry_key=public")
+
+		// Envelope header, item header, event
+		scanner := bufio.NewScanner(r.Body)
+		var lines []string
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		if assert.Len(t, lines, 3) {
+			var item struct {
+				Type   string `json:"type"`
+				Length int    `json:"length"`
+			}
+			assert.NoError(t, json.Unmarshal([]byte(lines[1]), &item))
+			assert.Equal(t, "event", item.Type)
+			assert.Equal(t, len(lines[2]), item.Length)
+
+			var event map[string]any
+			assert.NoError(t, json.Unmarshal([]byte(lines[2]), &event))
+			mu.Lock()
+			events = append(events, event)
+			mu.Unlock()
+		}
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "30")
+		}
+		w.WriteHeader(status)
+	}))
+	return server, &events
+}
+
+func newTestSentry(t *testing.T, server *httptest.Server) *Sentry {
+	s, err := NewSentry(strings.Replace(server.URL, "://", "://public@", 1)+"/42", time.Second)
+	require.NoError(t, err)
+	return s.WithRelease("1a2b3c4d5e6f").WithEnvironment("production").WithTag("region", "eu")
+}
+
+func TestSentry_Report(t *testing.T) {
+	// Arrange
+	server, events := fakeSentry(t, http.StatusOK)
+	defer server.Close()
+	s := newTestSentry(t, server)
+	var event Event
+	func() {
+		defer func() { event = Panic(recover()) }()
+		crash()
+	}()
+	event.Tags["component"] = "http"
+	event.Request = &Request{Method: "GET", URL: "https://sho.rt/abc123", Status: 500, UserAgent: "curl/8.0"}
+
+	// Act
+	s.Report(event)
+	require.True(t, s.Flush(time.Second))
+
+	// Assert
+	require.Len(t, *events, 1)
+	sent := (*events)[0]
+	assert.Len(t, sent["event_id"], 32)
+	assert.Equal(t, "fatal", sent["level"])
+	assert.Equal(t, "1a2b3c4d5e6f", sent["release"])
+	assert.Equal(t, "production", sent["environment"])
+	assert.Equal(t, map[string]any{"region": "eu", "component": "http", "status_code": "500"}, sent["tags"])
+	assert.Equal(t, map[string]any{"method": "GET", "url": "https://sho.rt/abc123", "headers": map[string]any{"User-Agent": "curl/8.0"}}, sent["request"])
+
+	exception := sent["exception"].(map[string]any)["values"].([]any)[0].(map[string]any)
+	assert.Equal(t, "runtime.boundsError", exception["type"])
+	assert.Equal(t, "panic", exception["mechanism"].(map[string]any)["type"])
+	frames := exception["stacktrace"].(map[string]any)["frames"].([]any)
+	last := frames[len(frames)-1].(map[string]any)
+	assert.Equal(t, "url-shortener/internal/errreport", last["module"])
+	assert.Equal(t, "crash", last["function"], "the crash is the last frame")
+}
+
+func TestSentry_Report_Sampling(t *testing.T) {
+	// Arrange
+	server, events := fakeSentry(t, http.StatusOK)
+	defer server.Close()
+	s := newTestSentry(t, server).WithSampleRate(0.25)
+	s.random = func() float64 { return 0.5 }
+
+	// Act
+	s.Report(Event{Level: LevelError, Type: "HTTP 503", Message: "GET / returned 503"})
+	s.Report(Event{Level: LevelFatal, Type: "string", Message: "panic: boom", Panic: true})
+	require.True(t, s.Flush(time.Second))
+
+	// Assert
+	require.Len(t, *events, 1, "the 5xx was sampled out, the panic is always sent")
+	assert.Equal(t, "fatal", (*events)[0]["level"])
+}
+
+func TestSentry_Report_RateLimited(t *testing.T) {
+	// Arrange
+	server, events := fakeSentry(t, http.StatusTooManyRequests)
+	defer server.Close()
+	s := newTestSentry(t, server)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	event := Event{Level: LevelFatal, Type: "string", Message: "panic: boom", Panic: true}
+
+	// Act
+	s.Report(event)
+	require.True(t, s.Flush(time.Second))
+	s.Report(event)
+	require.True(t, s.Flush(time.Second))
+	now = now.Add(30 * time.Second)
+	s.Report(event)
+	require.True(t, s.Flush(time.Second))
+
+	// Assert
+	assert.Len(t, *events, 2, "nothing is sent for the Retry-After of a 429")
+}
+
+func TestSplitFunction(t *testing.T) {
+	tests := []struct {
+		name             string
+		expectedModule   string
+		expectedFunction string
+	}{
+		{name: "url-shortener/internal/jobs.(*Queue).run", expectedModule: "url-shortener/internal/jobs", expectedFunction: "(*Queue).run"},
+		{name: "net/http.HandlerFunc.ServeHTTP", expectedModule: "net/http", expectedFunction: "HandlerFunc.ServeHTTP"},
+		{name: "main.main", expectedModule: "main", expectedFunction: "main"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module, function := splitFunction(tt.name)
+
+			assert.Equal(t, tt.expectedModule, module)
+			assert.Equal(t, tt.expectedFunction, function)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// Package events is an in-process event bus for things that happen to links
+//
+// WHY?
+// Creating or deleting a link used to trigger its side effects directly:
+// the service removed cache entries, every handler bumped a metric, and
+// adding webhooks or an audit trail meant touching all of those places
+// again. Now the service only PUBLISHES what happened ("URL created") and
+// doesn't know who listens. Metrics, CDN purges, webhooks and the audit
+// log SUBSCRIBE to the events they care about.
+//
+// DELIVERY:
+// Publish calls the subscribers one after another, in the order they
+// subscribed, before it returns. That keeps the order of side effects
+// predictable (the CDN is purged before the call returns) and needs
+// no queue. Subscribers that talk to slow systems (webhooks) hand the work
+// to a goroutine themselves. A panicking subscriber is logged and skipped:
+// it never fails the request that published the event.
+//
+// Events live in memory only: a crash between the database write and a
+// subscriber loses that side effect. Work that must not be lost belongs in
+// the job queue (see package jobs).
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"url-shortener/internal/domain"
+)
+
+// Event is something that happened; its name identifies the type
+type Event interface {
+	EventName() string
+}
+
+// URLCreated is published after a link is stored
+type URLCreated struct {
+	URL        *domain.URL
+	Actor      string // Principal that created it ("anonymous" for visitors)
+	OccurredAt time.Time
+}
+
+// URLDeleted is published after a link is deleted
+type URLDeleted struct {
+	URL        *domain.URL
+	Actor      string
+	Permanent  bool // Purged with its analytics (false = soft delete, can be restored)
+	OccurredAt time.Time
+}
+
+// ClickRecorded is published after a click is counted
+// URL carries the counter including this click
+type ClickRecorded struct {
+	URL   *domain.URL
+	Click *domain.URLClick // Built even when analytics is off - it just isn't stored
+}
+
+// URLExpired is published once per link, after its expiration time passed
+// It is found by a background worker, so it may arrive minutes late
+type URLExpired struct {
+	URL        *domain.URL
+	OccurredAt time.Time
+}
+
+// AliasFlagged is published after a link is created whose alias looks like
+// a popular or reserved one, for someone to review (see domain.ConfusableFlag)
+type AliasFlagged struct {
+	URL        *domain.URL
+	Imitates   string // The alias it looks like
+	Actor      string
+	OccurredAt time.Time
+}
+
+// ApprovalRequested is published after a contributor's link is created that
+// must be approved before it redirects (see domain.WithPendingApproval)
+type ApprovalRequested struct {
+	URL        *domain.URL
+	Actor      string // The contributor
+	OccurredAt time.Time
+}
+
+// ApprovalDecided is published after an editor or owner approved or
+// rejected a pending link
+type ApprovalDecided struct {
+	URL        *domain.URL
+	Actor      string // Who decided
+	Approved   bool
+	Reason     string // Optional, e.g. why it was rejected
+	OccurredAt time.Time
+}
+
+func (URLCreated) EventName() string    { return "url.created" }
+func (URLDeleted) EventName() string    { return "url.deleted" }
+func (ClickRecorded) EventName() string { return "click.recorded" }
+func (URLExpired) EventName() string    { return "url.expired" }
+func (AliasFlagged) EventName() string  { return "alias.flagged" }
+
+func (ApprovalRequested) EventName() string { return "approval.requested" }
+func (ApprovalDecided) EventName() string   { return "approval.decided" }
+
+// Bus delivers published events to their subscribers
+// Safe for concurrent use. A nil *Bus drops every event.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]func(ctx context.Context, event Event)
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]func(ctx context.Context, event Event))}
+}
+
+// Subscribe calls handle for every published event of type E
+//
+//	events.Subscribe(bus, func(ctx context.Context, e events.URLCreated) { ... })
+func Subscribe[E Event](b *Bus, handle func(ctx context.Context, event E)) {
+	var zero E
+	name := zero.EventName()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], func(ctx context.Context, event Event) {
+		handle(ctx, event.(E))
+	})
+}
+
+// Publish delivers event to its subscribers and returns when all have run
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers[event.EventName()]
+	b.mu.RUnlock()
+
+	for _, handle := range handlers {
+		deliver(ctx, event, handle)
+	}
+}
+
+// deliver runs one subscriber; a panic is logged instead of reaching the publisher
+func deliver(ctx context.Context, event Event, handle func(ctx context.Context, event Event)) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Warning: subscriber of %s panicked: %v\n", event.EventName(), r)
+		}
+	}()
+	handle(ctx, event)
+}
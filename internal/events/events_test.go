@@ -0,0 +1,47 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus_DeliversToSubscribersOfTheType(t *testing.T) {
+	// Arrange
+	bus := NewBus()
+	var got []string
+	Subscribe(bus, func(ctx context.Context, e URLCreated) { got = append(got, "first:"+e.URL.ShortCode) })
+	Subscribe(bus, func(ctx context.Context, e URLCreated) { got = append(got, "second:"+e.URL.ShortCode) })
+	Subscribe(bus, func(ctx context.Context, e URLDeleted) { got = append(got, "deleted:"+e.URL.ShortCode) })
+
+	// Act
+	bus.Publish(context.Background(), URLCreated{URL: &domain.URL{ShortCode: "abc"}})
+
+	// Assert
+	assert.Equal(t, []string{"first:abc", "second:abc"}, got, "in subscription order, other types untouched")
+}
+
+func TestBus_PanickingSubscriberDoesNotStopOthers(t *testing.T) {
+	// Arrange
+	bus := NewBus()
+	delivered := false
+	Subscribe(bus, func(ctx context.Context, e URLExpired) { panic("broken subscriber") })
+	Subscribe(bus, func(ctx context.Context, e URLExpired) { delivered = true })
+
+	// Act & Assert
+	assert.NotPanics(t, func() {
+		bus.Publish(context.Background(), URLExpired{URL: &domain.URL{ShortCode: "old"}})
+	})
+	assert.True(t, delivered)
+}
+
+func TestBus_NilBusDropsEvents(t *testing.T) {
+	var bus *Bus
+
+	assert.NotPanics(t, func() {
+		bus.Publish(context.Background(), ClickRecorded{})
+	})
+}
@@ -0,0 +1,87 @@
+package faults
+
+import (
+	"context"
+	"net"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache wraps a cache.URLCache and injects faults before every call
+// Use it to check that a broken cache only costs speed, never correctness
+type Cache struct {
+	next     cache.URLCache
+	injector *Injector
+}
+
+// NewCache wraps next with fault injection
+func NewCache(next cache.URLCache, injector *Injector) *Cache {
+	return &Cache{next: next, injector: injector}
+}
+
+func (c *Cache) GetURL(ctx context.Context, shortCode string) (*domain.URL, error) {
+	if err := c.injector.Inject(ctx, "redis.GetURL"); err != nil {
+		return nil, err
+	}
+	return c.next.GetURL(ctx, shortCode)
+}
+
+func (c *Cache) SetURL(ctx context.Context, shortCode string, url *domain.URL) error {
+	if err := c.injector.Inject(ctx, "redis.SetURL"); err != nil {
+		return err
+	}
+	return c.next.SetURL(ctx, shortCode, url)
+}
+
+func (c *Cache) DeleteURL(ctx context.Context, shortCode string) error {
+	if err := c.injector.Inject(ctx, "redis.DeleteURL"); err != nil {
+		return err
+	}
+	return c.next.DeleteURL(ctx, shortCode)
+}
+
+// RedisHook injects faults into EVERY command sent by a go-redis client
+//
+// The Cache decorator only covers URL caching. Other Redis users - the rate
+// limiter, for example - talk to the client directly, so they are covered
+// with a client hook instead:
+//
+//	redisClient.AddHook(faults.RedisHook(injector))
+func RedisHook(injector *Injector) redis.Hook {
+	return redisHook{injector: injector}
+}
+
+type redisHook struct {
+	injector *Injector
+}
+
+func (h redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.injector.Inject(ctx, "redis."+cmd.Name()); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.injector.Inject(ctx, "redis.pipeline"); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		return next(ctx, cmds)
+	}
+}
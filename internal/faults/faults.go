@@ -0,0 +1,92 @@
+// Package faults injects simulated failures into dependencies (chaos testing)
+//
+// WHY BREAK THINGS ON PURPOSE?
+// The service claims to survive Redis and PostgreSQL problems: rate limiting
+// fails open, a broken cache falls back to the database, and so on. Those code
+// paths almost never run in development, so they rot. Wrapping the real
+// dependencies with decorators that fail (or slow down) a configurable share of
+// calls lets us watch those behaviors on purpose - in tests, load tests, or a
+// staging environment.
+//
+// NEVER enable this in production.
+package faults
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Error is returned by injected failures
+// It reports itself as Temporary so retry logic treats it like a network blip
+type Error struct {
+	Op string // The operation that "failed", e.g. "postgres.GetByShortCode"
+}
+
+func (e *Error) Error() string {
+	return "injected fault: " + e.Op
+}
+
+// Temporary marks injected failures as transient
+func (e *Error) Temporary() bool {
+	return true
+}
+
+// Injector decides when a call fails and how long it is delayed
+// A zero Injector (or nil) never injects anything
+type Injector struct {
+	errorRate float64       // Share of calls that fail (0-1)
+	latency   time.Duration // Extra delay added to every call
+
+	mu  sync.Mutex // rand.Rand is not safe for concurrent use
+	rng *rand.Rand
+}
+
+// NewInjector creates an injector
+// errorRate is clamped to 0-1
+func NewInjector(errorRate float64, latency time.Duration) *Injector {
+	if errorRate < 0 {
+		errorRate = 0
+	}
+	if errorRate > 1 {
+		errorRate = 1
+	}
+
+	return &Injector{
+		errorRate: errorRate,
+		latency:   latency,
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Inject runs before a real call: it sleeps for the configured latency and
+// then returns an *Error for errorRate of the calls
+// A canceled context stops the sleep early and returns ctx.Err(), just like
+// a real driver would
+func (i *Injector) Inject(ctx context.Context, op string) error {
+	if i == nil {
+		return nil
+	}
+
+	if i.latency > 0 {
+		timer := time.NewTimer(i.latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if i.errorRate > 0 && i.roll() < i.errorRate {
+		return &Error{Op: op}
+	}
+	return nil
+}
+
+func (i *Injector) roll() float64 {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rng.Float64()
+}
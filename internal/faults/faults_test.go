@@ -0,0 +1,313 @@
+package faults
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjector_ErrorRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		injector  *Injector
+		expectErr bool
+	}{
+		{name: "nil never injects", injector: nil},
+		{name: "zero value never injects", injector: &Injector{}},
+		{name: "rate 0 never injects", injector: NewInjector(0, 0)},
+		{name: "negative rate is clamped to 0", injector: NewInjector(-1, 0)},
+		{name: "rate 1 always injects", injector: NewInjector(1, 0), expectErr: true},
+		{name: "rate above 1 is clamped to 1", injector: NewInjector(5, 0), expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				// Act
+				err := tt.injector.Inject(context.Background(), "postgres.Create")
+
+				// Assert
+				if !tt.expectErr {
+					require.NoError(t, err)
+					continue
+				}
+				var injected *Error
+				require.ErrorAs(t, err, &injected)
+				assert.Equal(t, "postgres.Create", injected.Op)
+				assert.True(t, injected.Temporary(), "retry logic treats it as a network blip")
+			}
+		})
+	}
+}
+
+func TestInjector_PartialRate(t *testing.T) {
+	// Arrange
+	injector := NewInjector(0.5, 0)
+
+	// Act
+	failed := 0
+	for range 1000 {
+		if injector.Inject(context.Background(), "redis.GetURL") != nil {
+			failed++
+		}
+	}
+
+	// Assert: some calls fail, some don't
+	assert.Greater(t, failed, 0)
+	assert.Less(t, failed, 1000)
+}
+
+func TestInjector_Latency(t *testing.T) {
+	// Arrange
+	injector := NewInjector(0, 20*time.Millisecond)
+
+	// Act
+	start := time.Now()
+	err := injector.Inject(context.Background(), "postgres.GetByID")
+
+	// Assert
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestInjector_CanceledContextStopsTheDelay(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	injector := NewInjector(1, time.Minute)
+
+	// Act
+	start := time.Now()
+	err := injector.Inject(ctx, "postgres.GetByID")
+
+	// Assert: the context's error, like a real driver, not an injected fault
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// stubURLRepository answers GetByShortCode; any other call panics on the
+// nil embedded interface, which proves a failed call never reaches it
+type stubURLRepository struct {
+	repository.URLRepository
+	calls int
+}
+
+func (s *stubURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*domain.URL, error) {
+	s.calls++
+	return &domain.URL{ShortCode: shortCode}, nil
+}
+
+func TestURLRepository(t *testing.T) {
+	t.Run("passes calls through when nothing is injected", func(t *testing.T) {
+		// Arrange
+		next := &stubURLRepository{}
+		repo := NewURLRepository(next, NewInjector(0, 0))
+
+		// Act
+		url, err := repo.GetByShortCode(context.Background(), "abc123")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "abc123", url.ShortCode)
+		assert.Equal(t, 1, next.calls)
+	})
+
+	// Every method fails before reaching the real repository
+	repo := NewURLRepository(&stubURLRepository{}, NewInjector(1, 0))
+	ctx := context.Background()
+	calls := map[string]func() error{
+		"postgres.Create":            func() error { return repo.Create(ctx, &domain.URL{}) },
+		"postgres.GetByShortCode":    func() error { _, err := repo.GetByShortCode(ctx, "abc123"); return err },
+		"postgres.GetByID":           func() error { _, err := repo.GetByID(ctx, "1"); return err },
+		"postgres.GetByCustomAlias":  func() error { _, err := repo.GetByCustomAlias(ctx, "alias"); return err },
+		"postgres.Update":            func() error { return repo.Update(ctx, &domain.URL{}) },
+		"postgres.SetMetadata":       func() error { return repo.SetMetadata(ctx, "1", &domain.LinkMetadata{}) },
+		"postgres.Delete":            func() error { return repo.Delete(ctx, "1") },
+		"postgres.Restore":           func() error { return repo.Restore(ctx, "1") },
+		"postgres.Purge":             func() error { _, err := repo.Purge(ctx, "1"); return err },
+		"postgres.IncrementClicks":   func() error { return repo.IncrementClicks(ctx, "abc123") },
+		"postgres.MarkUsed":          func() error { _, err := repo.MarkUsed(ctx, "abc123"); return err },
+		"postgres.Burn":              func() error { _, err := repo.Burn(ctx, "abc123"); return err },
+		"postgres.ExistsShortCode":   func() error { _, err := repo.ExistsShortCode(ctx, "abc123"); return err },
+		"postgres.ExistsCustomAlias": func() error { _, err := repo.ExistsCustomAlias(ctx, "alias"); return err },
+		"postgres.FindTakenCodes":    func() error { _, err := repo.FindTakenCodes(ctx, []string{"abc123"}); return err },
+	}
+	for op, call := range calls {
+		t.Run(op, func(t *testing.T) {
+			assertInjected(t, op, call())
+		})
+	}
+}
+
+func TestClickRepository(t *testing.T) {
+	// Arrange: a nil next panics if a failed call reaches it
+	repo := NewClickRepository(nil, NewInjector(1, 0))
+	ctx := context.Background()
+	now := time.Now()
+
+	calls := map[string]func() error{
+		"postgres.CreateClick":   func() error { return repo.Create(ctx, &domain.URLClick{}) },
+		"postgres.GetClicks":     func() error { _, err := repo.GetByURLID(ctx, "1", 10, 0); return err },
+		"postgres.GetClickCount": func() error { _, err := repo.GetClickCount(ctx, "1"); return err },
+		"postgres.CountClicksBy": func() error { _, err := repo.CountBy(ctx, "1", domain.DimensionBrowser); return err },
+		"postgres.CountClicksByPeriod": func() error {
+			_, err := repo.CountByPeriod(ctx, "1", domain.IntervalDay, time.UTC, now.Add(-time.Hour), now)
+			return err
+		},
+		"postgres.CountClicksByHourOfWeek": func() error {
+			_, err := repo.CountByHourOfWeek(ctx, "1", time.UTC, now.Add(-time.Hour), now)
+			return err
+		},
+		"postgres.TopLinks": func() error { _, err := repo.TopLinks(ctx, "team1", now.Add(-time.Hour), 10); return err },
+	}
+
+	for op, call := range calls {
+		t.Run(op, func(t *testing.T) {
+			assertInjected(t, op, call())
+		})
+	}
+}
+
+// stubCache is an in-memory cache.URLCache
+type stubCache struct {
+	urls map[string]*domain.URL
+}
+
+func (s *stubCache) GetURL(ctx context.Context, shortCode string) (*domain.URL, error) {
+	return s.urls[shortCode], nil
+}
+
+func (s *stubCache) SetURL(ctx context.Context, shortCode string, url *domain.URL) error {
+	s.urls[shortCode] = url
+	return nil
+}
+
+func (s *stubCache) DeleteURL(ctx context.Context, shortCode string) error {
+	delete(s.urls, shortCode)
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	url := &domain.URL{ShortCode: "abc123"}
+
+	t.Run("passes calls through when nothing is injected", func(t *testing.T) {
+		// Arrange
+		c := NewCache(&stubCache{urls: map[string]*domain.URL{}}, nil)
+
+		// Act & Assert
+		require.NoError(t, c.SetURL(ctx, "abc123", url))
+		got, err := c.GetURL(ctx, "abc123")
+		require.NoError(t, err)
+		assert.Equal(t, url, got)
+		require.NoError(t, c.DeleteURL(ctx, "abc123"))
+		got, err = c.GetURL(ctx, "abc123")
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("fails every call before the real cache", func(t *testing.T) {
+		// Arrange
+		next := &stubCache{urls: map[string]*domain.URL{"abc123": url}}
+		c := NewCache(next, NewInjector(1, 0))
+
+		// Act
+		got, getErr := c.GetURL(ctx, "abc123")
+		setErr := c.SetURL(ctx, "xyz789", url)
+		deleteErr := c.DeleteURL(ctx, "abc123")
+
+		// Assert
+		assert.Nil(t, got)
+		assertInjected(t, "redis.GetURL", getErr)
+		assertInjected(t, "redis.SetURL", setErr)
+		assertInjected(t, "redis.DeleteURL", deleteErr)
+		assert.Equal(t, map[string]*domain.URL{"abc123": url}, next.urls, "the real cache is untouched")
+	})
+}
+
+func TestRedisHook_Process(t *testing.T) {
+	tests := []struct {
+		name       string
+		injector   *Injector
+		expectNext bool
+	}{
+		{name: "nothing injected", injector: NewInjector(0, 0), expectNext: true},
+		{name: "fault injected", injector: NewInjector(1, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			called := false
+			process := RedisHook(tt.injector).ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+				called = true
+				return nil
+			})
+			cmd := redis.NewStringCmd(ctx, "get", "ratelimit:1.2.3.4")
+
+			// Act
+			err := process(ctx, cmd)
+
+			// Assert
+			assert.Equal(t, tt.expectNext, called)
+			if tt.expectNext {
+				assert.NoError(t, err)
+				return
+			}
+			assertInjected(t, "redis.get", err)
+			assert.Equal(t, err, cmd.Err(), "the command carries the error too")
+		})
+	}
+}
+
+func TestRedisHook_Pipeline(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	called := false
+	process := RedisHook(NewInjector(1, 0)).ProcessPipelineHook(func(ctx context.Context, cmds []redis.Cmder) error {
+		called = true
+		return nil
+	})
+	cmds := []redis.Cmder{redis.NewIntCmd(ctx, "incr", "k"), redis.NewBoolCmd(ctx, "expire", "k", 60)}
+
+	// Act
+	err := process(ctx, cmds)
+
+	// Assert
+	assert.False(t, called)
+	assertInjected(t, "redis.pipeline", err)
+	for _, cmd := range cmds {
+		assert.Equal(t, err, cmd.Err())
+	}
+}
+
+func TestRedisHook_OnClient(t *testing.T) {
+	// Arrange: nothing listens there, so reaching the network would fail
+	// with a dial error instead
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer client.Close()
+	client.AddHook(RedisHook(NewInjector(1, 0)))
+
+	// Act
+	err := client.Incr(context.Background(), "ratelimit:1.2.3.4").Err()
+
+	// Assert
+	assertInjected(t, "redis.incr", err)
+}
+
+func assertInjected(t *testing.T, op string, err error) {
+	t.Helper()
+	var injected *Error
+	if assert.True(t, errors.As(err, &injected), "expected an injected fault, got %v", err) {
+		assert.Equal(t, op, injected.Op)
+	}
+}
@@ -0,0 +1,187 @@
+package faults
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// URLRepository wraps a URLRepository and injects faults before every call
+// This is the DECORATOR pattern: same interface, extra behavior, and the
+// service layer can't tell the difference
+type URLRepository struct {
+	next     repository.URLRepository
+	injector *Injector
+}
+
+// NewURLRepository wraps next with fault injection
+func NewURLRepository(next repository.URLRepository, injector *Injector) *URLRepository {
+	return &URLRepository{next: next, injector: injector}
+}
+
+func (r *URLRepository) Create(ctx context.Context, url *domain.URL) error {
+	if err := r.injector.Inject(ctx, "postgres.Create"); err != nil {
+		return err
+	}
+	return r.next.Create(ctx, url)
+}
+
+func (r *URLRepository) GetByShortCode(ctx context.Context, shortCode string) (*domain.URL, error) {
+	if err := r.injector.Inject(ctx, "postgres.GetByShortCode"); err != nil {
+		return nil, err
+	}
+	return r.next.GetByShortCode(ctx, shortCode)
+}
+
+func (r *URLRepository) GetByID(ctx context.Context, id string) (*domain.URL, error) {
+	if err := r.injector.Inject(ctx, "postgres.GetByID"); err != nil {
+		return nil, err
+	}
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *URLRepository) GetByCustomAlias(ctx context.Context, alias string) (*domain.URL, error) {
+	if err := r.injector.Inject(ctx, "postgres.GetByCustomAlias"); err != nil {
+		return nil, err
+	}
+	return r.next.GetByCustomAlias(ctx, alias)
+}
+
+func (r *URLRepository) Update(ctx context.Context, url *domain.URL) error {
+	if err := r.injector.Inject(ctx, "postgres.Update"); err != nil {
+		return err
+	}
+	return r.next.Update(ctx, url)
+}
+
+func (r *URLRepository) SetMetadata(ctx context.Context, id string, meta *domain.LinkMetadata) error {
+	if err := r.injector.Inject(ctx, "postgres.SetMetadata"); err != nil {
+		return err
+	}
+	return r.next.SetMetadata(ctx, id, meta)
+}
+
+func (r *URLRepository) Delete(ctx context.Context, id string) error {
+	if err := r.injector.Inject(ctx, "postgres.Delete"); err != nil {
+		return err
+	}
+	return r.next.Delete(ctx, id)
+}
+
+func (r *URLRepository) Restore(ctx context.Context, id string) error {
+	if err := r.injector.Inject(ctx, "postgres.Restore"); err != nil {
+		return err
+	}
+	return r.next.Restore(ctx, id)
+}
+
+func (r *URLRepository) Purge(ctx context.Context, id string) (int64, error) {
+	if err := r.injector.Inject(ctx, "postgres.Purge"); err != nil {
+		return 0, err
+	}
+	return r.next.Purge(ctx, id)
+}
+
+func (r *URLRepository) IncrementClicks(ctx context.Context, shortCode string) error {
+	if err := r.injector.Inject(ctx, "postgres.IncrementClicks"); err != nil {
+		return err
+	}
+	return r.next.IncrementClicks(ctx, shortCode)
+}
+
+func (r *URLRepository) MarkUsed(ctx context.Context, shortCode string) (bool, error) {
+	if err := r.injector.Inject(ctx, "postgres.MarkUsed"); err != nil {
+		return false, err
+	}
+	return r.next.MarkUsed(ctx, shortCode)
+}
+
+func (r *URLRepository) Burn(ctx context.Context, shortCode string) (*string, error) {
+	if err := r.injector.Inject(ctx, "postgres.Burn"); err != nil {
+		return nil, err
+	}
+	return r.next.Burn(ctx, shortCode)
+}
+
+func (r *URLRepository) ExistsShortCode(ctx context.Context, shortCode string) (bool, error) {
+	if err := r.injector.Inject(ctx, "postgres.ExistsShortCode"); err != nil {
+		return false, err
+	}
+	return r.next.ExistsShortCode(ctx, shortCode)
+}
+
+func (r *URLRepository) ExistsCustomAlias(ctx context.Context, alias string) (bool, error) {
+	if err := r.injector.Inject(ctx, "postgres.ExistsCustomAlias"); err != nil {
+		return false, err
+	}
+	return r.next.ExistsCustomAlias(ctx, alias)
+}
+
+func (r *URLRepository) FindTakenCodes(ctx context.Context, codes []string) (map[string]bool, error) {
+	if err := r.injector.Inject(ctx, "postgres.FindTakenCodes"); err != nil {
+		return nil, err
+	}
+	return r.next.FindTakenCodes(ctx, codes)
+}
+
+// ClickRepository wraps a ClickRepository and injects faults before every call
+type ClickRepository struct {
+	next     repository.ClickRepository
+	injector *Injector
+}
+
+// NewClickRepository wraps next with fault injection
+func NewClickRepository(next repository.ClickRepository, injector *Injector) *ClickRepository {
+	return &ClickRepository{next: next, injector: injector}
+}
+
+func (r *ClickRepository) Create(ctx context.Context, click *domain.URLClick) error {
+	if err := r.injector.Inject(ctx, "postgres.CreateClick"); err != nil {
+		return err
+	}
+	return r.next.Create(ctx, click)
+}
+
+func (r *ClickRepository) GetByURLID(ctx context.Context, urlID string, limit, offset int) ([]*domain.URLClick, error) {
+	if err := r.injector.Inject(ctx, "postgres.GetClicks"); err != nil {
+		return nil, err
+	}
+	return r.next.GetByURLID(ctx, urlID, limit, offset)
+}
+
+func (r *ClickRepository) GetClickCount(ctx context.Context, urlID string) (int64, error) {
+	if err := r.injector.Inject(ctx, "postgres.GetClickCount"); err != nil {
+		return 0, err
+	}
+	return r.next.GetClickCount(ctx, urlID)
+}
+
+func (r *ClickRepository) CountBy(ctx context.Context, urlID string, dimension domain.ClickDimension) (map[string]int64, error) {
+	if err := r.injector.Inject(ctx, "postgres.CountClicksBy"); err != nil {
+		return nil, err
+	}
+	return r.next.CountBy(ctx, urlID, dimension)
+}
+
+func (r *ClickRepository) CountByPeriod(ctx context.Context, urlID string, interval domain.Interval, location *time.Location, from, to time.Time) ([]domain.ClickBucket, error) {
+	if err := r.injector.Inject(ctx, "postgres.CountClicksByPeriod"); err != nil {
+		return nil, err
+	}
+	return r.next.CountByPeriod(ctx, urlID, interval, location, from, to)
+}
+
+func (r *ClickRepository) CountByHourOfWeek(ctx context.Context, urlID string, location *time.Location, from, to time.Time) ([]domain.HourOfWeek, error) {
+	if err := r.injector.Inject(ctx, "postgres.CountClicksByHourOfWeek"); err != nil {
+		return nil, err
+	}
+	return r.next.CountByHourOfWeek(ctx, urlID, location, from, to)
+}
+
+func (r *ClickRepository) TopLinks(ctx context.Context, owner string, since time.Time, limit int) ([]domain.TopLink, error) {
+	if err := r.injector.Inject(ctx, "postgres.TopLinks"); err != nil {
+		return nil, err
+	}
+	return r.next.TopLinks(ctx, owner, since, limit)
+}
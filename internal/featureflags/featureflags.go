@@ -0,0 +1,250 @@
+// Package featureflags turns features on and off at runtime
+//
+// WHY FLAGS?
+// Shipping code and releasing a feature become two separate steps: the code
+// is deployed switched off, then an admin flips the flag - for everyone or
+// for a few workspaces first - and flips it back if something goes wrong.
+// No restart, no redeploy.
+//
+// WHERE FLAGS LIVE:
+// PostgreSQL is the source of truth (feature_flags table). Every instance
+// keeps an in-memory SNAPSHOT, so checking a flag costs a map lookup, not a
+// query. The snapshot is reloaded periodically and, when a Notifier is set,
+// right after any instance changes a flag (Redis pub/sub).
+//
+// WORKSPACES:
+// A workspace is the account that owns links: the principal ID stored as
+// created_by. A flag can be overridden per workspace, e.g. off for
+// everyone but on for "acme" while it is being tried out.
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/internal/metrics"
+)
+
+// Known flags
+// Flags that aren't stored yet fall back to their entry in Defaults
+const (
+	// PreviewPages serves owner-defined preview cards to link preview bots
+	PreviewPages = "preview-pages"
+)
+
+// Defaults is the state of every known flag until an admin changes it
+var Defaults = map[string]bool{
+	PreviewPages: true,
+}
+
+var (
+	ErrInvalidName = errors.New("flag name must be 1-64 lowercase letters, digits or dashes")
+	ErrNotFound    = errors.New("feature flag not found")
+)
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// Flag is the stored state of one feature flag
+type Flag struct {
+	Name        string
+	Description string
+	Enabled     bool            // State for every workspace without an override
+	Workspaces  map[string]bool // Per-workspace overrides
+	UpdatedAt   time.Time
+}
+
+// EnabledFor returns the state of the flag for a workspace ("" = no workspace)
+func (f Flag) EnabledFor(workspace string) bool {
+	if enabled, ok := f.Workspaces[workspace]; ok && workspace != "" {
+		return enabled
+	}
+	return f.Enabled
+}
+
+// Validate checks the flag name
+func (f Flag) Validate() error {
+	if !validName.MatchString(f.Name) {
+		return ErrInvalidName
+	}
+	return nil
+}
+
+// Store persists flags (PostgreSQL in production)
+type Store interface {
+	List(ctx context.Context) ([]Flag, error)
+	Save(ctx context.Context, flag *Flag) error // Creates or replaces; sets UpdatedAt
+	Delete(ctx context.Context, name string) error
+}
+
+// Notifier tells the other instances that flags changed
+type Notifier interface {
+	Publish(ctx context.Context) error
+	// Subscribe calls onChange for every change until ctx is canceled
+	Subscribe(ctx context.Context, onChange func())
+}
+
+// Flags answers "is this feature on?" from an in-memory snapshot
+type Flags struct {
+	store    Store
+	notifier Notifier
+	defaults map[string]bool
+	snapshot atomic.Pointer[map[string]Flag]
+}
+
+// New creates the flag set
+// Until the first Refresh every flag has its default state
+func New(store Store, defaults map[string]bool) *Flags {
+	f := &Flags{store: store, defaults: defaults}
+	empty := map[string]Flag{}
+	f.snapshot.Store(&empty)
+	return f
+}
+
+// WithNotifier propagates changes to other instances right away
+// Without it they pick changes up on their next periodic refresh
+func (f *Flags) WithNotifier(notifier Notifier) *Flags {
+	f.notifier = notifier
+	return f
+}
+
+// Enabled reports whether a flag is on for a workspace
+// Unknown flags are off
+func (f *Flags) Enabled(name, workspace string) bool {
+	enabled := f.defaults[name]
+	if flag, ok := (*f.snapshot.Load())[name]; ok {
+		enabled = flag.EnabledFor(workspace)
+	}
+
+	metrics.RecordFeatureFlagEvaluation(name, enabled)
+	return enabled
+}
+
+// Refresh reloads the snapshot from the store
+func (f *Flags) Refresh(ctx context.Context) error {
+	flags, err := f.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	snapshot := make(map[string]Flag, len(flags))
+	for _, flag := range flags {
+		snapshot[flag.Name] = flag
+	}
+	f.snapshot.Store(&snapshot)
+	f.recordState()
+	return nil
+}
+
+// Run keeps the snapshot fresh until ctx is canceled: every interval, and
+// on every change announced by the notifier
+func (f *Flags) Run(ctx context.Context, interval time.Duration) {
+	refresh := func() {
+		if err := f.Refresh(ctx); err != nil && ctx.Err() == nil {
+			fmt.Printf("Warning: failed to refresh feature flags: %v\n", err)
+		}
+	}
+
+	if f.notifier != nil {
+		go f.notifier.Subscribe(ctx, refresh)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		refresh()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// List returns every flag: the stored ones plus known flags still at their
+// default, sorted by name
+func (f *Flags) List(ctx context.Context) ([]Flag, error) {
+	stored, err := f.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]Flag, len(stored)+len(f.defaults))
+	for name, enabled := range f.defaults {
+		byName[name] = Flag{Name: name, Enabled: enabled}
+	}
+	for _, flag := range stored {
+		byName[flag.Name] = flag
+	}
+
+	names := slices.Sorted(maps.Keys(byName))
+	flags := make([]Flag, 0, len(names))
+	for _, name := range names {
+		flags = append(flags, byName[name])
+	}
+	return flags, nil
+}
+
+// Set creates or replaces a flag and applies it everywhere
+func (f *Flags) Set(ctx context.Context, flag *Flag) error {
+	if err := flag.Validate(); err != nil {
+		return err
+	}
+	if err := f.store.Save(ctx, flag); err != nil {
+		return err
+	}
+	f.changed(ctx)
+	return nil
+}
+
+// Delete removes a flag; a known flag goes back to its default
+func (f *Flags) Delete(ctx context.Context, name string) error {
+	if err := f.store.Delete(ctx, name); err != nil {
+		return err
+	}
+	f.changed(ctx)
+	return nil
+}
+
+// changed applies a change locally and announces it
+// The change is already stored, so failures here only delay it: the
+// periodic refresh catches up
+func (f *Flags) changed(ctx context.Context) {
+	if err := f.Refresh(ctx); err != nil {
+		fmt.Printf("Warning: failed to refresh feature flags: %v\n", err)
+	}
+	if f.notifier != nil {
+		if err := f.notifier.Publish(ctx); err != nil {
+			fmt.Printf("Warning: failed to announce feature flag change: %v\n", err)
+		}
+	}
+}
+
+// recordState exports the snapshot as metrics
+// Reset first, so deleted flags disappear instead of keeping their last value
+func (f *Flags) recordState() {
+	metrics.FeatureFlagEnabled.Reset()
+	metrics.FeatureFlagOverrides.Reset()
+
+	for name, enabled := range f.defaults {
+		metrics.FeatureFlagEnabled.WithLabelValues(name).Set(boolToFloat(enabled))
+	}
+	for name, flag := range *f.snapshot.Load() {
+		metrics.FeatureFlagEnabled.WithLabelValues(name).Set(boolToFloat(flag.Enabled))
+		metrics.FeatureFlagOverrides.WithLabelValues(name).Set(float64(len(flag.Workspaces)))
+	}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
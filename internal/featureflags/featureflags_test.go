@@ -0,0 +1,155 @@
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockStore is a mock implementation of Store
+type MockStore struct {
+	mock.Mock
+}
+
+func (m *MockStore) List(ctx context.Context) ([]Flag, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Flag), args.Error(1)
+}
+
+func (m *MockStore) Save(ctx context.Context, flag *Flag) error {
+	args := m.Called(ctx, flag)
+	return args.Error(0)
+}
+
+func (m *MockStore) Delete(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+// MockNotifier is a mock implementation of Notifier
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) Publish(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockNotifier) Subscribe(ctx context.Context, onChange func()) {
+	m.Called(ctx, onChange)
+}
+
+func TestEnabled(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	store := new(MockStore)
+	store.On("List", ctx).Return([]Flag{
+		{Name: "new-generator", Enabled: false, Workspaces: map[string]bool{"acme": true}},
+		{Name: "preview-pages", Enabled: false},
+	}, nil)
+	flags := New(store, map[string]bool{"preview-pages": true, "kill-switch": true})
+
+	// Before the first refresh: defaults only
+	assert.True(t, flags.Enabled("preview-pages", "acme"))
+	require.NoError(t, flags.Refresh(ctx))
+
+	tests := []struct {
+		name      string
+		flag      string
+		workspace string
+		expected  bool
+	}{
+		{name: "override on", flag: "new-generator", workspace: "acme", expected: true},
+		{name: "no override", flag: "new-generator", workspace: "globex", expected: false},
+		{name: "no workspace", flag: "new-generator", workspace: "", expected: false},
+		{name: "stored beats default", flag: "preview-pages", workspace: "acme", expected: false},
+		{name: "default when not stored", flag: "kill-switch", workspace: "acme", expected: true},
+		{name: "unknown flag", flag: "nope", workspace: "acme", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, flags.Enabled(tt.flag, tt.workspace))
+		})
+	}
+}
+
+func TestRefresh_KeepsSnapshotOnError(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	store := new(MockStore)
+	store.On("List", ctx).Return([]Flag{{Name: "beta", Enabled: true}}, nil).Once()
+	store.On("List", ctx).Return(nil, errors.New("connection refused")).Once()
+	flags := New(store, nil)
+	require.NoError(t, flags.Refresh(ctx))
+
+	// Act
+	err := flags.Refresh(ctx)
+
+	// Assert: a failed reload doesn't switch features off
+	assert.Error(t, err)
+	assert.True(t, flags.Enabled("beta", ""))
+}
+
+func TestList_IncludesDefaults(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	store := new(MockStore)
+	store.On("List", ctx).Return([]Flag{{Name: "beta", Enabled: true}}, nil)
+	flags := New(store, map[string]bool{"preview-pages": true})
+
+	// Act
+	list, err := flags.List(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []Flag{
+		{Name: "beta", Enabled: true},
+		{Name: "preview-pages", Enabled: true},
+	}, list)
+}
+
+func TestSet(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	store := new(MockStore)
+	notifier := new(MockNotifier)
+	flag := &Flag{Name: "beta", Enabled: true}
+	store.On("Save", ctx, flag).Return(nil)
+	store.On("List", ctx).Return([]Flag{*flag}, nil)
+	notifier.On("Publish", ctx).Return(nil)
+	flags := New(store, nil).WithNotifier(notifier)
+
+	// Act
+	err := flags.Set(ctx, flag)
+
+	// Assert: applied locally right away and announced to the other instances
+	require.NoError(t, err)
+	assert.True(t, flags.Enabled("beta", ""))
+	notifier.AssertExpectations(t)
+}
+
+func TestSet_InvalidName(t *testing.T) {
+	for _, name := range []string{"", "Beta", "new_generator", "-beta", string(make([]byte, 65))} {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			store := new(MockStore)
+			flags := New(store, nil)
+
+			// Act
+			err := flags.Set(context.Background(), &Flag{Name: name})
+
+			// Assert
+			assert.ErrorIs(t, err, ErrInvalidName)
+			store.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/internal/abuse"
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/captcha"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/useragent"
+)
+
+// AbuseGuard decides about requests on the redirect path and manages bans
+// Implemented by abuse.Guard
+type AbuseGuard interface {
+	Check(ctx context.Context, ip, code string) (abuse.Verdict, error)
+	Bans(ctx context.Context) ([]abuse.Ban, error)
+	Lift(ctx context.Context, ip string) (bool, error)
+}
+
+// CaptchaVerifier checks CAPTCHA tokens with the provider
+// Implemented by captcha.Verifier
+type CaptchaVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+	Widget() captcha.Widget
+}
+
+// challengeCookie holds the proof that this browser solved a CAPTCHA
+const challengeCookie = "abuse_pass"
+
+// ChallengePath is where the challenge page posts the solved CAPTCHA
+const ChallengePath = "/_challenge"
+
+// challengePageData is what web/templates/challenge.html renders
+type challengePageData struct {
+	Widget captcha.Widget
+	Action string // ChallengePath
+	Return string // Where to go after solving (the link that was clicked)
+	Failed bool   // The last attempt was rejected
+	Lang   string
+	T      func(string, ...interface{}) string
+}
+
+// AbuseProtection guards the redirect path against floods
+//
+// WHAT HAPPENS TO A REQUEST:
+//
+//	banned IP                      -> 429 with Retry-After, nothing else runs
+//	suspicious browser, no pass    -> CAPTCHA page (when a CAPTCHA is configured)
+//	everything else                -> the redirect, as before
+//
+// "Suspicious" never blocks on its own: without a CAPTCHA (or for clients
+// that can't solve one, like curl) the request goes through, and only the
+// much higher ban limit stops it. A viral link must not lock people out.
+type AbuseProtection struct {
+	guard  AbuseGuard
+	logger *slog.Logger
+
+	// Optional CAPTCHA challenge (see WithChallenge)
+	verifier CaptchaVerifier
+	tmpl     *template.Template
+	secret   []byte        // Signs pass cookies
+	passTTL  time.Duration // How long a solved CAPTCHA is valid
+	now      func() time.Time
+}
+
+// NewAbuseProtection creates the redirect path guard
+func NewAbuseProtection(guard AbuseGuard, logger *slog.Logger) *AbuseProtection {
+	return &AbuseProtection{guard: guard, logger: logger, now: time.Now}
+}
+
+// WithChallenge shows suspicious browsers a CAPTCHA rendered from tmpl
+// A solved CAPTCHA is remembered in a cookie signed with secret for passTTL
+func (p *AbuseProtection) WithChallenge(verifier CaptchaVerifier, tmpl *template.Template, secret string, passTTL time.Duration) *AbuseProtection {
+	p.verifier = verifier
+	p.tmpl = tmpl
+	p.secret = []byte(secret)
+	p.passTTL = passTTL
+	return p
+}
+
+// Protect wraps the redirect handler
+func (p *AbuseProtection) Protect(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+		code := strings.TrimPrefix(r.URL.Path, "/")
+
+		verdict, err := p.guard.Check(r.Context(), ip, code)
+		if err != nil {
+			// Fail open like the rate limiter: a Redis hiccup must not take
+			// every link down with it
+			p.logger.Warn("Abuse check failed", "error", err)
+			next(w, r)
+			return
+		}
+
+		switch verdict.Action {
+		case abuse.Block:
+			metrics.RecordAbuseAction("blocked")
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(verdict.RetryAfter.Seconds()))))
+			respondError(w, http.StatusTooManyRequests, "Too many requests, please try again later")
+			return
+		case abuse.Challenge:
+			if p.shouldChallenge(r, ip) {
+				metrics.RecordAbuseAction("challenged")
+				p.renderChallenge(w, r, r.URL.RequestURI(), false, http.StatusForbidden)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// shouldChallenge reports whether to show the CAPTCHA instead of the link
+// Only browsers can solve it, and only once per pass
+func (p *AbuseProtection) shouldChallenge(r *http.Request, ip string) bool {
+	if p.verifier == nil || r.Method != http.MethodGet || !prefersHTML(r) {
+		return false
+	}
+	if useragent.Parse(r.UserAgent()).Device == useragent.DeviceBot {
+		return false
+	}
+	return !p.validPass(r, ip)
+}
+
+// VerifyChallenge handles POST /_challenge (the challenge page form)
+// A solved CAPTCHA sets the pass cookie and sends the visitor on to the link
+func (p *AbuseProtection) VerifyChallenge(w http.ResponseWriter, r *http.Request) {
+	if p.verifier == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	returnTo := safeReturnPath(r.PostFormValue("return"))
+	ip := clientIP(r)
+	err := p.verifier.Verify(r.Context(), r.PostFormValue(p.verifier.Widget().ResponseField), ip)
+	if err != nil {
+		if !errors.Is(err, captcha.ErrInvalidToken) && !errors.Is(err, captcha.ErrMissingToken) {
+			p.logger.Error("Failed to verify CAPTCHA", "error", err)
+		}
+		p.renderChallenge(w, r, returnTo, true, http.StatusForbidden)
+		return
+	}
+
+	metrics.RecordAbuseAction("solved")
+	expires := p.now().Add(p.passTTL)
+	http.SetCookie(w, &http.Cookie{
+		Name:     challengeCookie,
+		Value:    p.signPass(ip, expires),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, returnTo, http.StatusSeeOther)
+}
+
+// renderChallenge writes the CAPTCHA page
+func (p *AbuseProtection) renderChallenge(w http.ResponseWriter, r *http.Request, returnTo string, failed bool, status int) {
+	localizer, localized := localizerFor(w)
+
+	var buf bytes.Buffer
+	data := challengePageData{
+		Widget: p.verifier.Widget(),
+		Action: ChallengePath,
+		Return: returnTo,
+		Failed: failed,
+		Lang:   localizer.Language(),
+		T:      localizer.Translate,
+	}
+	if err := p.tmpl.Execute(&buf, data); err != nil {
+		p.logger.Error("Failed to render challenge page", "error", err)
+		respondError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if localized {
+		setLanguageHeaders(w, localizer)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store") // Never let a CDN serve the challenge for the link
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// signPass builds the cookie value "<expiry unix>.<hmac>"
+// The IP is part of the signature, so a pass can't be shared with a botnet
+func (p *AbuseProtection) signPass(ip string, expires time.Time) string {
+	expiry := strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(ip + "|" + expiry))
+	return expiry + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validPass reports whether the request carries an unexpired pass for ip
+func (p *AbuseProtection) validPass(r *http.Request, ip string) bool {
+	cookie, err := r.Cookie(challengeCookie)
+	if err != nil {
+		return false
+	}
+	expiry, _, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || p.now().After(time.Unix(unix, 0)) {
+		return false
+	}
+	return hmac.Equal([]byte(cookie.Value), []byte(p.signPass(ip, time.Unix(unix, 0))))
+}
+
+// safeReturnPath only allows paths on this site
+// Otherwise the challenge would be an open redirect: "/_challenge?return=//evil.com"
+func safeReturnPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+	return path
+}
+
+// ListBans handles GET /api/v1/bans?limit=&offset= (admin only)
+// Longest remaining ban first
+func (p *AbuseProtection) ListBans(w http.ResponseWriter, r *http.Request) {
+	window, err := parsePageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	bans, err := p.guard.Bans(r.Context())
+	if err != nil {
+		p.logger.Error("Failed to list bans", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to list bans")
+		return
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Until.After(bans[j].Until) })
+
+	response := make([]v1.BanResponse, 0, len(bans))
+	for _, ban := range bans {
+		response = append(response, v1.BanResponse{
+			IP:       ban.IP,
+			Reason:   ban.Reason,
+			Strikes:  ban.Strikes,
+			BannedAt: ban.BannedAt,
+			Until:    ban.Until,
+		})
+	}
+	items, pagination := paginate(response, window)
+	respondList(w, items, pagination)
+}
+
+// LiftBan handles DELETE /api/v1/bans/{ip} (admin only)
+// The IP's strikes are forgotten too, so its next ban starts short again
+func (p *AbuseProtection) LiftBan(w http.ResponseWriter, r *http.Request) {
+	ip := r.PathValue("ip")
+	lifted, err := p.guard.Lift(r.Context(), ip)
+	if err != nil {
+		p.logger.Error("Failed to lift ban", "ip", ip, "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to lift ban")
+		return
+	}
+	if !lifted {
+		respondError(w, http.StatusNotFound, "Ban not found")
+		return
+	}
+
+	p.logger.Info("Ban lifted", "ip", ip)
+	w.WriteHeader(http.StatusNoContent)
+}
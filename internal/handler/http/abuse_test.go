@@ -0,0 +1,367 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/abuse"
+	"url-shortener/internal/captcha"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAbuseGuard is a mock implementation of AbuseGuard
+type MockAbuseGuard struct {
+	mock.Mock
+}
+
+func (m *MockAbuseGuard) Check(ctx context.Context, ip, code string) (abuse.Verdict, error) {
+	args := m.Called(ctx, ip, code)
+	return args.Get(0).(abuse.Verdict), args.Error(1)
+}
+
+func (m *MockAbuseGuard) Bans(ctx context.Context) ([]abuse.Ban, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]abuse.Ban), args.Error(1)
+}
+
+func (m *MockAbuseGuard) Lift(ctx context.Context, ip string) (bool, error) {
+	args := m.Called(ctx, ip)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockCaptchaVerifier is a mock implementation of CaptchaVerifier
+type MockCaptchaVerifier struct {
+	mock.Mock
+}
+
+func (m *MockCaptchaVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	args := m.Called(ctx, token, remoteIP)
+	return args.Error(0)
+}
+
+func (m *MockCaptchaVerifier) Widget() captcha.Widget {
+	return captcha.Widget{
+		ScriptURL:     "https://challenges.cloudflare.com/turnstile/v0/api.js",
+		Class:         "cf-turnstile",
+		SiteKey:       "site-key",
+		ResponseField: "cf-turnstile-response",
+	}
+}
+
+const testBrowserUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+func newTestAbuseProtection(t *testing.T, withChallenge bool) (*AbuseProtection, *MockAbuseGuard, *MockCaptchaVerifier) {
+	guard := new(MockAbuseGuard)
+	verifier := new(MockCaptchaVerifier)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	protection := NewAbuseProtection(guard, logger)
+	if withChallenge {
+		tmpl, err := template.ParseFiles(filepath.Join("..", "..", "..", "web", "templates", "challenge.html"))
+		require.NoError(t, err)
+		protection.WithChallenge(verifier, tmpl, "pass-secret", 30*time.Minute)
+	}
+	return protection, guard, verifier
+}
+
+func TestAbuseProtection_Protect(t *testing.T) {
+	tests := []struct {
+		name          string
+		verdict       abuse.Verdict
+		checkErr      error
+		withChallenge bool
+		userAgent     string
+		accept        string
+		wantStatus    int
+		wantNext      bool
+	}{
+		{
+			name:       "normal traffic",
+			verdict:    abuse.Verdict{Action: abuse.Allow},
+			wantStatus: http.StatusFound,
+			wantNext:   true,
+		},
+		{
+			name:       "banned IP",
+			verdict:    abuse.Verdict{Action: abuse.Block, RetryAfter: 90 * time.Second, Reason: "banned"},
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:          "suspicious browser gets the CAPTCHA",
+			verdict:       abuse.Verdict{Action: abuse.Challenge, Reason: "ip_rate"},
+			withChallenge: true,
+			userAgent:     testBrowserUA,
+			accept:        "text/html",
+			wantStatus:    http.StatusForbidden,
+		},
+		{
+			name:       "suspicious browser without CAPTCHA configured",
+			verdict:    abuse.Verdict{Action: abuse.Challenge, Reason: "ip_rate"},
+			userAgent:  testBrowserUA,
+			accept:     "text/html",
+			wantStatus: http.StatusFound,
+			wantNext:   true,
+		},
+		{
+			name:          "suspicious curl can't solve a CAPTCHA",
+			verdict:       abuse.Verdict{Action: abuse.Challenge, Reason: "code_rate"},
+			withChallenge: true,
+			userAgent:     "curl/8.4.0",
+			accept:        "*/*",
+			wantStatus:    http.StatusFound,
+			wantNext:      true,
+		},
+		{
+			name:       "Redis down fails open",
+			verdict:    abuse.Verdict{Action: abuse.Allow},
+			checkErr:   errors.New("connection refused"),
+			wantStatus: http.StatusFound,
+			wantNext:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			protection, guard, _ := newTestAbuseProtection(t, tt.withChallenge)
+			guard.On("Check", mock.Anything, "203.0.113.7", "abc123").Return(tt.verdict, tt.checkErr)
+
+			nextCalled := false
+			next := func(w http.ResponseWriter, r *http.Request) {
+				nextCalled = true
+				w.WriteHeader(http.StatusFound)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			req.RemoteAddr = "203.0.113.7:54321"
+			req.Header.Set("User-Agent", tt.userAgent)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+
+			// Act
+			protection.Protect(next)(w, req)
+
+			// Assert
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantNext, nextCalled)
+			guard.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAbuseProtection_Protect_SpoofedForwardedFor(t *testing.T) {
+	// Arrange: the client sends a new X-Forwarded-For every time, to dodge
+	// its ban or to get someone else banned
+	protection, guard, _ := newTestAbuseProtection(t, false)
+	guard.On("Check", mock.Anything, "203.0.113.7", "abc123").
+		Return(abuse.Verdict{Action: abuse.Block, RetryAfter: time.Minute}, nil)
+	handler := ClientIPMiddleware(nil)(protection.Protect(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusFound)
+	}))
+
+	for _, spoofed := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.9, 10.0.0.1"} {
+		req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+		req.RemoteAddr = "203.0.113.7:54321"
+		req.Header.Set("X-Forwarded-For", spoofed)
+		req.Header.Set("X-Real-IP", spoofed)
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, req)
+
+		// Assert: still checked (and banned) as the connection's address
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, spoofed)
+	}
+	guard.AssertNotCalled(t, "Check", mock.Anything, "198.51.100.1", mock.Anything)
+	guard.AssertNumberOfCalls(t, "Check", 3)
+}
+
+func TestAbuseProtection_Protect_BanHeaders(t *testing.T) {
+	// Arrange
+	protection, guard, _ := newTestAbuseProtection(t, false)
+	guard.On("Check", mock.Anything, "203.0.113.7", "abc123").
+		Return(abuse.Verdict{Action: abuse.Block, RetryAfter: 1500 * time.Millisecond}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.RemoteAddr = "203.0.113.7:54321"
+	w := httptest.NewRecorder()
+
+	// Act
+	protection.Protect(func(w http.ResponseWriter, r *http.Request) {})(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"), "rounded up to whole seconds")
+}
+
+func TestAbuseProtection_ChallengePage(t *testing.T) {
+	// Arrange
+	protection, guard, _ := newTestAbuseProtection(t, true)
+	guard.On("Check", mock.Anything, "203.0.113.7", "abc123").
+		Return(abuse.Verdict{Action: abuse.Challenge, Reason: "ip_rate"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123?utm_source=mail", nil)
+	req.RemoteAddr = "203.0.113.7:54321"
+	req.Header.Set("User-Agent", testBrowserUA)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+
+	// Act
+	protection.Protect(func(w http.ResponseWriter, r *http.Request) {})(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	body := w.Body.String()
+	assert.Contains(t, body, `class="cf-turnstile" data-sitekey="site-key"`)
+	assert.Contains(t, body, `action="/_challenge"`)
+	assert.Contains(t, body, `value="/abc123?utm_source=mail"`, "the visitor returns to the link they clicked")
+}
+
+func TestAbuseProtection_VerifyChallenge(t *testing.T) {
+	// Arrange
+	protection, guard, verifier := newTestAbuseProtection(t, true)
+	verifier.On("Verify", mock.Anything, "solved-token", "203.0.113.7").Return(nil)
+
+	form := url.Values{"cf-turnstile-response": {"solved-token"}, "return": {"/abc123"}}
+	req := httptest.NewRequest(http.MethodPost, "/_challenge", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "203.0.113.7:54321"
+	w := httptest.NewRecorder()
+
+	// Act
+	protection.VerifyChallenge(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/abc123", w.Header().Get("Location"))
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+
+	// The pass lets the same IP through the next challenge...
+	guard.On("Check", mock.Anything, mock.Anything, "abc123").
+		Return(abuse.Verdict{Action: abuse.Challenge, Reason: "ip_rate"}, nil)
+	next := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusFound) }
+
+	again := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	again.RemoteAddr = "203.0.113.7:54321"
+	again.Header.Set("User-Agent", testBrowserUA)
+	again.Header.Set("Accept", "text/html")
+	again.AddCookie(cookies[0])
+	passed := httptest.NewRecorder()
+	protection.Protect(next)(passed, again)
+	assert.Equal(t, http.StatusFound, passed.Code)
+
+	// ...but not another IP that copied the cookie
+	copied := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	copied.RemoteAddr = "198.51.100.9:54321"
+	copied.Header.Set("User-Agent", testBrowserUA)
+	copied.Header.Set("Accept", "text/html")
+	copied.AddCookie(cookies[0])
+	challenged := httptest.NewRecorder()
+	protection.Protect(next)(challenged, copied)
+	assert.Equal(t, http.StatusForbidden, challenged.Code)
+}
+
+func TestAbuseProtection_VerifyChallenge_Rejected(t *testing.T) {
+	// Arrange
+	protection, _, verifier := newTestAbuseProtection(t, true)
+	verifier.On("Verify", mock.Anything, "", "203.0.113.7").Return(captcha.ErrMissingToken)
+
+	form := url.Values{"return": {"//evil.example/phish"}}
+	req := httptest.NewRequest(http.MethodPost, "/_challenge", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "203.0.113.7:54321"
+	w := httptest.NewRecorder()
+
+	// Act
+	protection.VerifyChallenge(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+	assert.Contains(t, w.Body.String(), `value="/"`, "other sites are never a return target")
+}
+
+func TestSafeReturnPath(t *testing.T) {
+	assert.Equal(t, "/abc123?x=1", safeReturnPath("/abc123?x=1"))
+	assert.Equal(t, "/", safeReturnPath(""))
+	assert.Equal(t, "/", safeReturnPath("https://evil.example"))
+	assert.Equal(t, "/", safeReturnPath("//evil.example"))
+	assert.Equal(t, "/", safeReturnPath(`/\evil.example`))
+}
+
+func TestListBans(t *testing.T) {
+	// Arrange
+	protection, guard, _ := newTestAbuseProtection(t, false)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	guard.On("Bans", mock.Anything).Return([]abuse.Ban{
+		{IP: "203.0.113.7", Reason: "flood", Strikes: 1, BannedAt: now, Until: now.Add(time.Minute)},
+		{IP: "198.51.100.9", Reason: "flood", Strikes: 3, BannedAt: now, Until: now.Add(time.Hour)},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/bans", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	protection.ListBans(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data, 2)
+	assert.Equal(t, "198.51.100.9", response.Data[0]["ip"], "longest ban first")
+	assert.Equal(t, float64(3), response.Data[0]["strikes"])
+}
+
+func TestLiftBan(t *testing.T) {
+	tests := []struct {
+		name       string
+		lifted     bool
+		err        error
+		wantStatus int
+	}{
+		{name: "banned", lifted: true, wantStatus: http.StatusNoContent},
+		{name: "not banned", lifted: false, wantStatus: http.StatusNotFound},
+		{name: "Redis error", err: errors.New("connection refused"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			protection, guard, _ := newTestAbuseProtection(t, false)
+			guard.On("Lift", mock.Anything, "203.0.113.7").Return(tt.lifted, tt.err)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/bans/203.0.113.7", nil)
+			req.SetPathValue("ip", "203.0.113.7")
+			w := httptest.NewRecorder()
+
+			// Act
+			protection.LiftBan(w, req)
+
+			// Assert
+			assert.Equal(t, tt.wantStatus, w.Code)
+			guard.AssertExpectations(t)
+		})
+	}
+}
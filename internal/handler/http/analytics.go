@@ -0,0 +1,176 @@
+package http
+
+import (
+	"errors"
+	"math"
+	"net/http"
+
+	v1 "url-shortener/internal/api/v1"
+	v2 "url-shortener/internal/api/v2"
+	"url-shortener/internal/domain"
+)
+
+// WithAnalytics tells stats responses whether the server records click
+// events (ENABLE_ANALYTICS, default: true)
+// The service skips them on its own; the handler only explains it
+func (h *Handler) WithAnalytics(enabled bool) *Handler {
+	h.analyticsOff = !enabled
+	return h
+}
+
+// analyticsMessages explain why recent_clicks is empty
+var analyticsMessages = map[domain.AnalyticsReason]string{
+	domain.AnalyticsDisabledGlobally: "Analytics is turned off on this server: clicks are counted, but click details are not recorded",
+	domain.AnalyticsDisabledForLink:  "Analytics is turned off for this link: clicks are counted, but click details are not recorded",
+}
+
+// analyticsStatus is the analytics block of stats responses
+func (h *Handler) analyticsStatus(w http.ResponseWriter, url *domain.URL) v1.AnalyticsStatus {
+	status := url.Analytics(!h.analyticsOff)
+	if status.Enabled {
+		return v1.AnalyticsStatus{Enabled: true}
+	}
+	return v1.AnalyticsStatus{
+		Reason:  string(status.Reason),
+		Message: translate(w, analyticsMessages[status.Reason]),
+	}
+}
+
+// analyticsStatusV2 is analyticsStatus for v2 (same fields)
+func (h *Handler) analyticsStatusV2(w http.ResponseWriter, url *domain.URL) v2.AnalyticsStatus {
+	return v2.AnalyticsStatus(h.analyticsStatus(w, url))
+}
+
+// analyticsOptOut shows a link's opt-out in create responses
+// nil (left out) for links with analytics, so existing responses don't change
+func analyticsOptOut(disabled bool) *bool {
+	if !disabled {
+		return nil
+	}
+	enabled := false
+	return &enabled
+}
+
+// withoutAnalytics reports whether a create request opted out ("analytics": false)
+func withoutAnalytics(analytics *bool) bool {
+	return analytics != nil && !*analytics
+}
+
+// GetURLTimeseries handles GET /api/v1/urls/{code}/timeseries
+//
+//	?interval=hour|day|week|month   (default: day)
+//	&tz=America/New_York            (default: the link's workspace timezone)
+//	&from=2026-03-01&to=2026-03-31  (default: the last 48 hours / 30 days / 12 weeks / 12 months)
+//
+// Every interval in the range is returned, with 0 for intervals without
+// clicks, so a chart can plot the buckets as they come
+func (h *Handler) GetURLTimeseries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	series, err := h.urlService.GetClickTimeseries(r.Context(), r.PathValue("code"), domain.TimeseriesQuery{
+		Interval: domain.Interval(query.Get("interval")),
+		Timezone: query.Get("tz"),
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+	})
+	if err != nil {
+		h.respondAnalyticsError(w, err, "Failed to get click timeseries")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, v1.TimeseriesResponse{
+		ID:        series.URL.ID,
+		ShortCode: series.URL.ShortCode,
+		Interval:  string(series.Interval),
+		Timezone:  series.Location.String(),
+		From:      series.From,
+		To:        series.To,
+		Buckets:   clickBuckets(series.Buckets),
+	}, "")
+}
+
+// heatmapDays labels the heatmap rows (domain.ClickHeatmap starts on Monday)
+var heatmapDays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+// GetURLHeatmap handles GET /api/v1/urls/{code}/stats/heatmap
+//
+//	?tz=America/New_York            (default: the link's workspace timezone)
+//	&from=2026-03-01&to=2026-03-31  (default: the last 12 weeks; rounded out to whole weeks)
+//
+// Returns a 7x24 matrix of clicks: when in the week the audience clicks
+func (h *Handler) GetURLHeatmap(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	heatmap, err := h.urlService.GetClickHeatmap(r.Context(), r.PathValue("code"), domain.TimeseriesQuery{
+		Timezone: query.Get("tz"),
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+	})
+	if err != nil {
+		h.respondAnalyticsError(w, err, "Failed to get click heatmap")
+		return
+	}
+
+	response := v1.HeatmapResponse{
+		ID:        heatmap.URL.ID,
+		ShortCode: heatmap.URL.ShortCode,
+		Timezone:  heatmap.Location.String(),
+		From:      heatmap.From,
+		To:        heatmap.To,
+		Days:      heatmapDays,
+		Clicks:    make([][]int64, len(heatmap.Clicks)),
+	}
+	for day, hours := range heatmap.Clicks {
+		response.Clicks[day] = hours[:]
+		for hour, clicks := range hours {
+			response.Total += clicks
+			if clicks > 0 && (response.Peak == nil || clicks > response.Peak.Clicks) {
+				response.Peak = &v1.HeatmapCell{Day: heatmapDays[day], Hour: hour, Clicks: clicks}
+			}
+		}
+	}
+	respondSuccess(w, http.StatusOK, response, "")
+}
+
+// respondAnalyticsError maps the errors of the analytics endpoints
+func (h *Handler) respondAnalyticsError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		respondError(w, http.StatusForbidden, "You don't have access to this URL")
+	case errors.Is(err, domain.ErrURLNotFound):
+		respondError(w, http.StatusNotFound, "URL not found")
+	case errors.Is(err, domain.ErrInvalidTimezone),
+		errors.Is(err, domain.ErrInvalidInterval):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrInvalidTimeRange):
+		respondError(w, http.StatusBadRequest, "from and to must be RFC 3339 times or YYYY-MM-DD dates, from before to, at most 1000 intervals apart")
+	default:
+		h.logger.Error(fallback, "error", err)
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+// clickComparison converts a period-over-period comparison (nil stays nil)
+// The change becomes a percentage rounded to one decimal: 0.1234 -> 12.3
+func clickComparison(comparison *domain.ClickComparison) *v1.ClickComparison {
+	if comparison == nil {
+		return nil
+	}
+	response := &v1.ClickComparison{
+		Current:  v1.ClickPeriod{From: comparison.CurrentFrom, To: comparison.To, Clicks: comparison.Current},
+		Previous: v1.ClickPeriod{From: comparison.PreviousFrom, To: comparison.CurrentFrom, Clicks: comparison.Previous},
+		Trend:    string(comparison.Trend),
+	}
+	if comparison.Change != nil {
+		percent := math.Round(*comparison.Change*1000) / 10
+		response.ChangePercent = &percent
+	}
+	return response
+}
+
+// clickBuckets converts timeseries buckets (never nil, so JSON gets [])
+func clickBuckets(buckets []domain.ClickBucket) []v1.ClickBucket {
+	response := make([]v1.ClickBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		response = append(response, v1.ClickBucket{Start: bucket.Start, Clicks: bucket.Clicks})
+	}
+	return response
+}
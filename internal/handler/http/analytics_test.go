@@ -0,0 +1,265 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetURLTimeseries(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedQuery  domain.TimeseriesQuery
+		serviceErr     error
+		expectedStatus int
+	}{
+		{
+			name:           "daily in the viewer's timezone",
+			query:          "?interval=day&tz=America/New_York&from=2026-03-07&to=2026-03-08",
+			expectedQuery:  domain.TimeseriesQuery{Interval: domain.IntervalDay, Timezone: "America/New_York", From: "2026-03-07", To: "2026-03-08"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown timezone",
+			query:          "?tz=Mars/Olympus",
+			expectedQuery:  domain.TimeseriesQuery{Timezone: "Mars/Olympus"},
+			serviceErr:     domain.ErrInvalidTimezone,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "bad range",
+			query:          "?from=yesterday",
+			expectedQuery:  domain.TimeseriesQuery{From: "yesterday"},
+			serviceErr:     domain.ErrInvalidTimeRange,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{name: "not the owner", serviceErr: domain.ErrForbidden, expectedStatus: http.StatusForbidden},
+		{name: "unknown link", serviceErr: domain.ErrURLNotFound, expectedStatus: http.StatusNotFound},
+		{name: "database down", serviceErr: assert.AnError, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			series := &domain.ClickTimeseries{
+				URL:      &domain.URL{ID: "123", ShortCode: "abc123"},
+				Interval: domain.IntervalDay,
+				Location: newYork,
+				From:     time.Date(2026, 3, 7, 0, 0, 0, 0, newYork),
+				To:       time.Date(2026, 3, 9, 0, 0, 0, 0, newYork),
+				Buckets: []domain.ClickBucket{
+					{Start: time.Date(2026, 3, 7, 0, 0, 0, 0, newYork), Clicks: 4},
+					{Start: time.Date(2026, 3, 8, 0, 0, 0, 0, newYork), Clicks: 0},
+				},
+			}
+			if tt.serviceErr != nil {
+				mockService.On("GetClickTimeseries", mock.Anything, "abc123", tt.expectedQuery).Return(nil, tt.serviceErr)
+			} else {
+				mockService.On("GetClickTimeseries", mock.Anything, "abc123", tt.expectedQuery).Return(series, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc123/timeseries"+tt.query, nil)
+			req.SetPathValue("code", "abc123")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.GetURLTimeseries(w, req)
+
+			// Assert: bucket starts carry the viewer's offset (EST, then EDT)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"timezone":"America/New_York"`)
+				assert.Contains(t, w.Body.String(), `{"start":"2026-03-07T00:00:00-05:00","clicks":4}`)
+				assert.Contains(t, w.Body.String(), `{"start":"2026-03-08T00:00:00-05:00","clicks":0}`)
+				assert.Contains(t, w.Body.String(), `"to":"2026-03-09T00:00:00-04:00"`)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetURLSummary_Daily(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mockService.On("GetClickSummary", mock.Anything, "abc123", "UTC").Return(&domain.ClickSummary{
+		URL:        &domain.URL{ID: "123", ShortCode: "abc123"},
+		Daily:      &domain.ClickTimeseries{Location: time.UTC, Buckets: []domain.ClickBucket{{Start: day, Clicks: 2}}},
+		Comparison: domain.CompareClicks(day.AddDate(0, 0, -14), day.AddDate(0, 0, -7), day, 30, 20),
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc123/summary?tz=UTC", nil)
+	req.SetPathValue("code", "abc123")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.GetURLSummary(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"timezone":"UTC"`)
+	assert.Contains(t, w.Body.String(), `"daily":[{"start":"2026-03-01T00:00:00Z","clicks":2}]`)
+	assert.Contains(t, w.Body.String(), `"current":{"from":"2026-02-22T00:00:00Z","to":"2026-03-01T00:00:00Z","clicks":20}`)
+	assert.Contains(t, w.Body.String(), `"previous":{"from":"2026-02-15T00:00:00Z","to":"2026-02-22T00:00:00Z","clicks":30}`)
+	assert.Contains(t, w.Body.String(), `"change_percent":-33.3,"trend":"falling"`)
+}
+
+func TestGetURLHeatmap(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedQuery  domain.TimeseriesQuery
+		clicks         map[[2]int]int64
+		serviceErr     error
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:           "busiest hour",
+			query:          "?tz=UTC&from=2026-03-02&to=2026-03-15",
+			expectedQuery:  domain.TimeseriesQuery{Timezone: "UTC", From: "2026-03-02", To: "2026-03-15"},
+			clicks:         map[[2]int]int64{{1, 9}: 6, {6, 23}: 1},
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				`"days":["monday","tuesday","wednesday","thursday","friday","saturday","sunday"]`,
+				`"clicks":[[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0],[0,0,0,0,0,0,0,0,0,6,`,
+				`"total":7`,
+				`"peak":{"day":"tuesday","hour":9,"clicks":6}`,
+			},
+		},
+		{
+			name:           "no clicks has no peak",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"total":0}`},
+		},
+		{name: "unknown timezone", query: "?tz=Nowhere", expectedQuery: domain.TimeseriesQuery{Timezone: "Nowhere"}, serviceErr: domain.ErrInvalidTimezone, expectedStatus: http.StatusBadRequest},
+		{name: "not the owner", serviceErr: domain.ErrForbidden, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			if tt.serviceErr != nil {
+				mockService.On("GetClickHeatmap", mock.Anything, "abc123", tt.expectedQuery).Return(nil, tt.serviceErr)
+			} else {
+				heatmap := &domain.ClickHeatmap{
+					URL:      &domain.URL{ID: "123", ShortCode: "abc123"},
+					Location: time.UTC,
+					From:     time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+					To:       time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+				}
+				for cell, clicks := range tt.clicks {
+					heatmap.Clicks[cell[0]][cell[1]] = clicks
+				}
+				mockService.On("GetClickHeatmap", mock.Anything, "abc123", tt.expectedQuery).Return(heatmap, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc123/stats/heatmap"+tt.query, nil)
+			req.SetPathValue("code", "abc123")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.GetURLHeatmap(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			for _, expected := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), expected)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetURLStats_AnalyticsStatus(t *testing.T) {
+	tests := []struct {
+		name            string
+		globallyEnabled bool
+		linkOptedOut    bool
+		expected        map[string]interface{}
+	}{
+		{
+			name:            "enabled",
+			globallyEnabled: true,
+			expected:        map[string]interface{}{"enabled": true},
+		},
+		{
+			name:            "disabled globally",
+			globallyEnabled: false,
+			linkOptedOut:    true, // The server-wide switch is the one reported
+			expected: map[string]interface{}{
+				"enabled": false,
+				"reason":  "disabled_globally",
+				"message": "Analytics is turned off on this server: clicks are counted, but click details are not recorded",
+			},
+		},
+		{
+			name:            "disabled for the link",
+			globallyEnabled: true,
+			linkOptedOut:    true,
+			expected: map[string]interface{}{
+				"enabled": false,
+				"reason":  "disabled_for_link",
+				"message": "Analytics is turned off for this link: clicks are counted, but click details are not recorded",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			handler.WithAnalytics(tt.globallyEnabled)
+			url := &domain.URL{ID: "123", ShortCode: "abc123", Clicks: 42, AnalyticsDisabled: tt.linkOptedOut}
+			mockService.On("GetURLStats", mock.Anything, "abc123").Return(url, []*domain.URLClick(nil), nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc123/stats", nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.GetURLStats(w, req)
+
+			// Assert
+			require.Equal(t, http.StatusOK, w.Code)
+			var response struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expected, response.Data["analytics"])
+			assert.Equal(t, float64(42), response.Data["clicks"], "the counter is shown either way")
+			assert.Equal(t, []interface{}{}, response.Data["recent_clicks"])
+		})
+	}
+}
+
+func TestCreateURL_AnalyticsOptOut(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	url := &domain.URL{ID: "123", ShortCode: "quiet1", OriginalURL: "https://example.com", AnalyticsDisabled: true}
+	mockService.On("CreateShortURL", mock.Anything, "https://example.com", "", "anonymous", time.Duration(0)).Return(url, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/urls", bytes.NewBufferString(`{"url":"https://example.com","analytics":false}`))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.CreateURL(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"analytics":false`)
+}
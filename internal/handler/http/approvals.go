@@ -0,0 +1,102 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+// Approver is the service the approval endpoints need
+// Implemented by service.ApprovalService
+type Approver interface {
+	ListPending(ctx context.Context) ([]*domain.URL, error)
+	Approve(ctx context.Context, id, reason string) (*domain.URL, error)
+	Reject(ctx context.Context, id, reason string) (*domain.URL, error)
+}
+
+// ApprovalHandler serves the approval queue of a workspace
+type ApprovalHandler struct {
+	approver Approver
+	logger   *slog.Logger
+	baseURL  string
+}
+
+// NewApprovalHandler creates a new approval handler
+func NewApprovalHandler(approver Approver, logger *slog.Logger, baseURL string) *ApprovalHandler {
+	return &ApprovalHandler{approver: approver, logger: logger, baseURL: baseURL}
+}
+
+// ListPending handles GET /api/v1/approvals?limit=&offset= (editors and owners)
+// The workspace's links waiting for approval, oldest first
+func (h *ApprovalHandler) ListPending(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	urls, err := h.approver.ListPending(r.Context())
+	if err != nil {
+		h.respondApprovalError(w, err)
+		return
+	}
+
+	items := make([]v1.CreateURLResponse, 0, len(urls))
+	for _, url := range urls {
+		items = append(items, createURLResponse(h.baseURL, url))
+	}
+	pageItems, pagination := paginate(items, page)
+	respondList(w, pageItems, pagination)
+}
+
+// Approve handles POST /api/v1/approvals/{id}/approve (editors and owners)
+// The link starts redirecting
+func (h *ApprovalHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.approver.Approve, "Link approved")
+}
+
+// Reject handles POST /api/v1/approvals/{id}/reject (editors and owners)
+// The link never redirects; the contributor may delete it
+func (h *ApprovalHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.approver.Reject, "Link rejected")
+}
+
+// decide reads the reason ({} for none) and records the decision
+func (h *ApprovalHandler) decide(w http.ResponseWriter, r *http.Request, decision func(ctx context.Context, id, reason string) (*domain.URL, error), message string) {
+	var req v1.ApprovalDecisionRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	url, err := decision(r.Context(), r.PathValue("id"), req.Reason)
+	if err != nil {
+		h.respondApprovalError(w, err)
+		return
+	}
+	respondSuccess(w, http.StatusOK, createURLResponse(h.baseURL, url), message)
+}
+
+// respondApprovalError answers 400 for bad reasons, 403 for contributors and
+// other workspaces, 404 for unknown links, 409 for links already decided
+// (or decided by someone else just now) and 500 for everything else
+func (h *ApprovalHandler) respondApprovalError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidApprovalReason):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		respondError(w, http.StatusForbidden, "Only workspace editors and owners can review links")
+	case errors.Is(err, domain.ErrURLNotFound):
+		respondError(w, http.StatusNotFound, "URL not found")
+	case errors.Is(err, domain.ErrNotPendingApproval):
+		respondError(w, http.StatusConflict, "Link is not waiting for approval")
+	case errors.Is(err, domain.ErrVersionConflict):
+		respondError(w, http.StatusConflict, "URL was modified by another request, please retry")
+	default:
+		h.logger.Error("Failed to review link", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to review link")
+	}
+}
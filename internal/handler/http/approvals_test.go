@@ -0,0 +1,157 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockApprover is a mock implementation of Approver
+type MockApprover struct {
+	mock.Mock
+}
+
+func (m *MockApprover) ListPending(ctx context.Context) ([]*domain.URL, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URL), args.Error(1)
+}
+
+func (m *MockApprover) Approve(ctx context.Context, id, reason string) (*domain.URL, error) {
+	args := m.Called(ctx, id, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockApprover) Reject(ctx context.Context, id, reason string) (*domain.URL, error) {
+	args := m.Called(ctx, id, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func newTestApprovalHandler() (*ApprovalHandler, *MockApprover) {
+	approver := new(MockApprover)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewApprovalHandler(approver, logger, "http://localhost:8080"), approver
+}
+
+func TestListPendingApprovals(t *testing.T) {
+	pending := []*domain.URL{
+		{ID: "1", ShortCode: "launch", OriginalURL: "https://example.com/launch", Approval: domain.ApprovalPending, SubmittedBy: "dana@example.com"},
+		{ID: "2", ShortCode: "promo", OriginalURL: "https://example.com/promo", Approval: domain.ApprovalPending, SubmittedBy: "frank@example.com"},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		serviceErr     error
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:           "queue",
+			query:          "?limit=1",
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				`"approval":"pending","submitted_by":"dana@example.com"`,
+				`"total":2,"has_more":true`,
+			},
+		},
+		{name: "contributor", serviceErr: domain.ErrForbidden, expectedStatus: http.StatusForbidden},
+		{name: "bad limit", query: "?limit=0", expectedStatus: http.StatusBadRequest},
+		{name: "database down", serviceErr: assert.AnError, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, approver := newTestApprovalHandler()
+			if tt.serviceErr != nil {
+				approver.On("ListPending", mock.Anything).Return(nil, tt.serviceErr)
+			} else {
+				approver.On("ListPending", mock.Anything).Return(pending, nil)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/approvals"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ListPending(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			for _, want := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), want)
+			}
+		})
+	}
+}
+
+func TestDecideApproval(t *testing.T) {
+	tests := []struct {
+		name           string
+		action         string
+		body           string
+		reason         string
+		serviceErr     error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "approve", action: "approve", body: `{}`, expectedStatus: http.StatusOK, expectedBody: `"message":"Link approved"`},
+		{name: "reject with reason", action: "reject", body: `{"reason":"Wrong campaign"}`, reason: "Wrong campaign", expectedStatus: http.StatusOK, expectedBody: `"message":"Link rejected"`},
+		{name: "reason too long", action: "reject", body: `{"reason":"` + strings.Repeat("x", 501) + `"}`, expectedStatus: http.StatusBadRequest},
+		{name: "invalid body", action: "approve", body: `not json`, expectedStatus: http.StatusBadRequest},
+		{name: "contributor", action: "approve", body: `{}`, serviceErr: domain.ErrForbidden, expectedStatus: http.StatusForbidden},
+		{name: "unknown link", action: "approve", body: `{}`, serviceErr: domain.ErrURLNotFound, expectedStatus: http.StatusNotFound},
+		{name: "already decided", action: "reject", body: `{}`, serviceErr: domain.ErrNotPendingApproval, expectedStatus: http.StatusConflict},
+		{name: "decided concurrently", action: "approve", body: `{}`, serviceErr: domain.ErrVersionConflict, expectedStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, approver := newTestApprovalHandler()
+			method := "Approve"
+			status := domain.ApprovalApproved
+			if tt.action == "reject" {
+				method, status = "Reject", domain.ApprovalRejected
+			}
+			if tt.serviceErr != nil {
+				approver.On(method, mock.Anything, "123", tt.reason).Return(nil, tt.serviceErr)
+			} else {
+				approver.On(method, mock.Anything, "123", tt.reason).Return(&domain.URL{ID: "123", ShortCode: "launch", Approval: status}, nil)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/approvals/123/"+tt.action, strings.NewReader(tt.body))
+			req.SetPathValue("id", "123")
+			w := httptest.NewRecorder()
+
+			// Act
+			if tt.action == "reject" {
+				handler.Reject(w, req)
+			} else {
+				handler.Approve(w, req)
+			}
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			if tt.expectedStatus == http.StatusBadRequest {
+				approver.AssertNotCalled(t, method, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
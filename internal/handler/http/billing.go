@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/billing"
+	"url-shortener/internal/domain"
+)
+
+// maxWebhookBytes caps Stripe webhook bodies (real events are a few KB)
+const maxWebhookBytes = 256 << 10
+
+// Biller is the service the billing endpoints need
+// Implemented by service.BillingService
+type Biller interface {
+	HandleEvent(ctx context.Context, event *billing.Event) error
+	PortalURL(ctx context.Context, owner string) (string, error)
+}
+
+// BillingHandler serves the Stripe webhook and the customer portal link
+type BillingHandler struct {
+	billing       Biller
+	webhookSecret string
+	logger        *slog.Logger
+	now           func() time.Time
+}
+
+// NewBillingHandler creates a new billing handler
+// webhookSecret is the endpoint's signing secret (whsec_...)
+func NewBillingHandler(biller Biller, webhookSecret string, logger *slog.Logger) *BillingHandler {
+	return &BillingHandler{
+		billing:       biller,
+		webhookSecret: webhookSecret,
+		logger:        logger,
+		now:           time.Now,
+	}
+}
+
+// Webhook handles POST /api/v1/billing/webhook
+//
+// Stripe calls this without credentials - the Stripe-Signature header is
+// what proves the request is genuine, so it is checked BEFORE anything in
+// the body is trusted. Any non-2xx answer makes Stripe retry with backoff
+// for up to three days, which is how transient failures heal themselves.
+func (h *BillingHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	// The signature covers the RAW body, so read it before any decoding
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		respondError(w, http.StatusRequestEntityTooLarge, "Webhook body is too large")
+		return
+	}
+
+	event, err := billing.ParseEvent(payload, r.Header.Get("Stripe-Signature"), h.webhookSecret, billing.DefaultTolerance, h.now())
+	if err != nil {
+		h.logger.Warn("Rejected billing webhook", "error", err)
+		respondError(w, http.StatusBadRequest, "Invalid webhook signature")
+		return
+	}
+
+	if err := h.billing.HandleEvent(r.Context(), event); err != nil {
+		h.logger.Error("Failed to handle billing event", "event_id", event.ID, "type", event.Type, "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to handle event")
+		return
+	}
+
+	h.logger.Info("Billing event handled", "event_id", event.ID, "type", event.Type)
+	respondSuccess(w, http.StatusOK, nil, "Event received")
+}
+
+// Portal handles POST /api/v1/billing/portal
+// Returns a short-lived link to Stripe's customer portal, where the caller
+// manages their card, plan and invoices
+func (h *BillingHandler) Portal(w http.ResponseWriter, r *http.Request) {
+	principal := auth.FromContext(r.Context())
+
+	portalURL, err := h.billing.PortalURL(r.Context(), principal.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrSubscriptionNotFound) {
+			respondError(w, http.StatusNotFound, "No subscription for this account")
+			return
+		}
+		h.logger.Error("Failed to create billing portal session", "error", err)
+		respondError(w, http.StatusBadGateway, "Failed to create billing portal session")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, v1.BillingPortalResponse{URL: portalURL}, "")
+}
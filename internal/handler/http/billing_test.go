@@ -0,0 +1,114 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/billing"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockBiller is a mock implementation of Biller
+type MockBiller struct {
+	mock.Mock
+}
+
+func (m *MockBiller) HandleEvent(ctx context.Context, event *billing.Event) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockBiller) PortalURL(ctx context.Context, owner string) (string, error) {
+	args := m.Called(ctx, owner)
+	return args.String(0), args.Error(1)
+}
+
+func setupBillingHandler() (*BillingHandler, *MockBiller) {
+	biller := new(MockBiller)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := NewBillingHandler(biller, "whsec_test", logger)
+	handler.now = func() time.Time { return time.Unix(1700000000, 0) }
+	return handler, biller
+}
+
+func TestBillingWebhook(t *testing.T) {
+	payload := []byte(`{"id":"evt_1","type":"customer.subscription.updated","created":1700000000,"data":{"object":{}}}`)
+	signed := fmt.Sprintf("t=1700000000,v1=%s", billing.Sign(payload, "whsec_test", 1700000000))
+
+	tests := []struct {
+		name           string
+		signature      string
+		handleErr      error
+		expectedStatus int
+	}{
+		{name: "valid event", signature: signed, expectedStatus: http.StatusOK},
+		{name: "bad signature", signature: "t=1700000000,v1=deadbeef", expectedStatus: http.StatusBadRequest},
+		{name: "handler failure makes Stripe retry", signature: signed, handleErr: assert.AnError, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, biller := setupBillingHandler()
+			biller.On("HandleEvent", mock.Anything, mock.AnythingOfType("*billing.Event")).Return(tt.handleErr)
+
+			req := httptest.NewRequest("POST", "/api/v1/billing/webhook", bytes.NewReader(payload))
+			req.Header.Set("Stripe-Signature", tt.signature)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.Webhook(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusBadRequest {
+				biller.AssertNotCalled(t, "HandleEvent", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestBillingPortal(t *testing.T) {
+	tests := []struct {
+		name           string
+		portalURL      string
+		err            error
+		expectedStatus int
+	}{
+		{name: "portal link", portalURL: "https://billing.stripe.com/session/abc", expectedStatus: http.StatusOK},
+		{name: "never subscribed", err: domain.ErrSubscriptionNotFound, expectedStatus: http.StatusNotFound},
+		{name: "stripe unavailable", err: assert.AnError, expectedStatus: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, biller := setupBillingHandler()
+			biller.On("PortalURL", mock.Anything, "alice").Return(tt.portalURL, tt.err)
+
+			req := httptest.NewRequest("POST", "/api/v1/billing/portal", nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{ID: "alice"}))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.Portal(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.portalURL != "" {
+				assert.Contains(t, w.Body.String(), tt.portalURL)
+			}
+		})
+	}
+}
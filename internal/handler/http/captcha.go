@@ -0,0 +1,40 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/captcha"
+)
+
+// WithCaptcha requires a solved CAPTCHA to create links without an API key
+// WHY? A public instance is an easy target: spammers script the open
+// create endpoint to mass-produce links to phishing pages. API key holders
+// are known, so they are never asked.
+func (h *Handler) WithCaptcha(verifier CaptchaVerifier) *Handler {
+	h.captcha = verifier
+	return h
+}
+
+// checkCaptcha verifies the token of an anonymous create request
+// Returns ok=false with the status and message to answer with
+func (h *Handler) checkCaptcha(r *http.Request, token string) (status int, message string, ok bool) {
+	if h.captcha == nil || auth.FromContext(r.Context()) != auth.Anonymous {
+		return 0, "", true
+	}
+
+	err := h.captcha.Verify(r.Context(), token, clientIP(r))
+	switch {
+	case err == nil:
+		return 0, "", true
+	case errors.Is(err, captcha.ErrMissingToken):
+		return http.StatusBadRequest, "captcha_token is required without an API key", false
+	case errors.Is(err, captcha.ErrInvalidToken):
+		return http.StatusForbidden, "CAPTCHA verification failed, please try again", false
+	default:
+		// The provider is down: don't let everyone in, but say it's temporary
+		h.logger.Error("Failed to verify CAPTCHA", "error", err)
+		return http.StatusServiceUnavailable, "CAPTCHA verification unavailable, please retry", false
+	}
+}
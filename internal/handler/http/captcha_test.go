@@ -0,0 +1,153 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/captcha"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateURL_Captcha(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		body       string
+		verifyErr  error
+		verify     bool // Whether the token is sent to the provider
+		wantStatus int
+	}{
+		{
+			name:       "anonymous with a solved CAPTCHA",
+			body:       `{"url": "https://example.com", "captcha_token": "solved"}`,
+			verify:     true,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "anonymous without a token",
+			body:       `{"url": "https://example.com"}`,
+			verifyErr:  captcha.ErrMissingToken,
+			verify:     true,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "anonymous with a rejected token",
+			body:       `{"url": "https://example.com", "captcha_token": "solved"}`,
+			verifyErr:  captcha.ErrInvalidToken,
+			verify:     true,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "provider down",
+			body:       `{"url": "https://example.com", "captcha_token": "solved"}`,
+			verifyErr:  errors.New("captcha provider unreachable"),
+			verify:     true,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "API key holders are never asked",
+			principal:  &auth.Principal{ID: "alice"},
+			body:       `{"url": "https://example.com"}`,
+			wantStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			verifier := new(MockCaptchaVerifier)
+			handler.WithCaptcha(verifier)
+
+			token := ""
+			if tt.verifyErr != captcha.ErrMissingToken {
+				token = "solved"
+			}
+			if tt.verify {
+				verifier.On("Verify", mock.Anything, token, "203.0.113.7").Return(tt.verifyErr)
+			}
+			if tt.wantStatus == http.StatusCreated {
+				mockService.On("CreateShortURL", mock.Anything, "https://example.com", "", mock.Anything, time.Duration(0)).
+					Return(&domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com"}, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/urls", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = "203.0.113.7:54321"
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.CreateURL(w, req)
+
+			// Assert
+			assert.Equal(t, tt.wantStatus, w.Code)
+			verifier.AssertExpectations(t)
+			mockService.AssertExpectations(t)
+			if !tt.verify {
+				verifier.AssertNotCalled(t, "Verify", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestCreateURLV2_CaptchaRequired(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	verifier := new(MockCaptchaVerifier)
+	handler.WithCaptcha(verifier)
+	verifier.On("Verify", mock.Anything, "", mock.Anything).Return(captcha.ErrMissingToken)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/urls", bytes.NewBufferString(`{"url": "https://example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.CreateURLV2(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var response struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "captcha_required", response.Error.Code)
+	mockService.AssertNotCalled(t, "CreateShortURL")
+}
+
+func TestServeUI_CaptchaWidget(t *testing.T) {
+	// Arrange
+	tmpl, err := template.ParseFiles(filepath.Join("..", "..", "..", "web", "templates", "index.html"))
+	require.NoError(t, err)
+	handler, _ := setupTestHandler()
+	handler.WithUI(tmpl).WithCaptcha(new(MockCaptchaVerifier))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeUI(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `<script src="https://challenges.cloudflare.com/turnstile/v0/api.js" async defer></script>`)
+	assert.Contains(t, body, `data-captcha-field="cf-turnstile-response"`)
+	assert.Contains(t, body, `<div class="cf-turnstile" data-sitekey="site-key"></div>`)
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+// maxIngestBytes caps a click batch: 500 clicks with long User-Agents and referrers fit
+const maxIngestBytes = 4 << 20
+
+// ClickIngester records click events reported by edge redirectors
+// Implemented by service.ClickIngestService
+type ClickIngester interface {
+	Ingest(ctx context.Context, clickEvents []domain.ClickEvent) []domain.IngestOutcome
+}
+
+// ClickIngestHandler receives clicks from external edge redirectors
+type ClickIngestHandler struct {
+	clicks ClickIngester
+	logger *slog.Logger
+}
+
+// NewClickIngestHandler creates a new click ingest handler
+func NewClickIngestHandler(clicks ClickIngester, logger *slog.Logger) *ClickIngestHandler {
+	return &ClickIngestHandler{clicks: clicks, logger: logger}
+}
+
+// IngestClicks handles POST /api/v1/clicks (admin: edge workers use the admin key)
+//
+// The batch is validated as a whole (400 if any click is malformed), then
+// each click is recorded on its own. The response is 200 with a result per
+// click: "failed" ones may be retried with the same IDs, everything else
+// is final - retrying an accepted click returns "duplicate".
+func (h *ClickIngestHandler) IngestClicks(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxIngestBytes)
+	var req v1.IngestClicksRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	clickEvents := make([]domain.ClickEvent, 0, len(req.Clicks))
+	for _, click := range req.Clicks {
+		clickEvents = append(clickEvents, domain.ClickEvent{
+			ID: click.ID,
+			Click: domain.ClickContext{
+				ShortCode: click.ShortCode,
+				IPAddress: click.IPAddress,
+				UserAgent: click.UserAgent,
+				Referer:   click.Referer,
+				Consent:   domain.ConsentSignal(click.Consent),
+				ClickedAt: click.ClickedAt,
+				Region:    click.Region,
+			},
+		})
+	}
+
+	outcomes := h.clicks.Ingest(r.Context(), clickEvents)
+
+	response := v1.IngestClicksResponse{Results: make([]v1.IngestClickResult, 0, len(outcomes))}
+	for _, outcome := range outcomes {
+		switch outcome.Status {
+		case domain.IngestAccepted:
+			response.Accepted++
+		case domain.IngestDuplicate:
+			response.Duplicates++
+		case domain.IngestRejected:
+			response.Rejected++
+		case domain.IngestFailed:
+			response.Failed++
+		}
+		response.Results = append(response.Results, v1.IngestClickResult{
+			ID:     outcome.ID,
+			Status: string(outcome.Status),
+			Error:  outcome.Error,
+		})
+	}
+	if response.Failed > 0 {
+		h.logger.Warn("Some reported clicks failed", "failed", response.Failed, "total", len(outcomes))
+	}
+
+	respondSuccess(w, http.StatusOK, response, "")
+}
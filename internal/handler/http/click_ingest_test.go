@@ -0,0 +1,122 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockClickIngester is a mock implementation of ClickIngester
+type MockClickIngester struct {
+	mock.Mock
+}
+
+func (m *MockClickIngester) Ingest(ctx context.Context, clickEvents []domain.ClickEvent) []domain.IngestOutcome {
+	args := m.Called(ctx, clickEvents)
+	return args.Get(0).([]domain.IngestOutcome)
+}
+
+func newTestClickIngestHandler() (*ClickIngestHandler, *MockClickIngester) {
+	clicks := new(MockClickIngester)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewClickIngestHandler(clicks, logger), clicks
+}
+
+func TestIngestClicks(t *testing.T) {
+	// Arrange
+	handler, clicks := newTestClickIngestHandler()
+	clickedAt := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+	clicks.On("Ingest", mock.Anything, []domain.ClickEvent{
+		{ID: "e1", Click: domain.ClickContext{
+			ShortCode: "abc123",
+			IPAddress: "203.0.113.7",
+			UserAgent: "Mozilla/5.0",
+			Referer:   "https://news.example.com/",
+			ClickedAt: clickedAt,
+			Region:    "fra",
+		}},
+		{ID: "e2", Click: domain.ClickContext{ShortCode: "gone", ClickedAt: clickedAt, Consent: domain.ConsentGPC}},
+	}).Return([]domain.IngestOutcome{
+		{ID: "e1", Status: domain.IngestAccepted},
+		{ID: "e2", Status: domain.IngestRejected, Error: "unknown short code"},
+	})
+
+	body := `{"clicks": [
+		{"id": "e1", "short_code": "abc123", "clicked_at": "2026-03-07T12:00:00Z", "ip_address": "203.0.113.7",
+		 "user_agent": "Mozilla/5.0", "referer": "https://news.example.com/", "region": "fra"},
+		{"id": "e2", "short_code": "gone", "clicked_at": "2026-03-07T12:00:00Z", "consent": "gpc"}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/clicks", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.IngestClicks(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data struct {
+			Accepted int `json:"accepted"`
+			Rejected int `json:"rejected"`
+			Results  []struct {
+				ID     string `json:"id"`
+				Status string `json:"status"`
+				Error  string `json:"error"`
+			} `json:"results"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Data.Accepted)
+	assert.Equal(t, 1, response.Data.Rejected)
+	require.Len(t, response.Data.Results, 2)
+	assert.Equal(t, "rejected", response.Data.Results[1].Status)
+	assert.Equal(t, "unknown short code", response.Data.Results[1].Error)
+	clicks.AssertExpectations(t)
+}
+
+func TestIngestClicks_Validation(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectedField string
+	}{
+		{name: "no clicks", body: `{"clicks": []}`, expectedField: "clicks"},
+		{name: "missing ID", body: `{"clicks": [{"short_code": "abc123", "clicked_at": "2026-03-07T12:00:00Z"}]}`, expectedField: "clicks[0].id"},
+		{name: "missing time", body: `{"clicks": [{"id": "e1", "short_code": "abc123"}]}`, expectedField: "clicks[0].clicked_at"},
+		{name: "not an IP address", body: `{"clicks": [{"id": "e1", "short_code": "abc123", "clicked_at": "2026-03-07T12:00:00Z", "ip_address": "203.0.113.7:443"}]}`, expectedField: "clicks[0].ip_address"},
+		{name: "unknown consent", body: `{"clicks": [{"id": "e1", "short_code": "abc123", "clicked_at": "2026-03-07T12:00:00Z", "consent": "maybe"}]}`, expectedField: "clicks[0].consent"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, clicks := newTestClickIngestHandler()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/clicks", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.IngestClicks(w, req)
+
+			// Assert - nothing of a malformed batch is recorded
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var response struct {
+				Details map[string]string `json:"details"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Contains(t, response.Details, tt.expectedField)
+			clicks.AssertNotCalled(t, "Ingest", mock.Anything, mock.Anything)
+		})
+	}
+}
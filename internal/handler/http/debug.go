@@ -0,0 +1,30 @@
+package http
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+)
+
+// RegisterDebugRoutes mounts the Go runtime debugging endpoints under /debug/
+//
+//	/debug/pprof/          index of profiles (heap, goroutine, allocs, block, mutex, ...)
+//	/debug/pprof/profile   CPU profile (?seconds=30)
+//	/debug/pprof/trace     execution trace (?seconds=5)
+//	/debug/vars            expvar (memstats, cmdline, custom counters)
+//
+// Usage (go tool pprof can't send headers, so download first):
+//
+//	curl -H "Authorization: Bearer $ADMIN_API_KEY" localhost:9091/debug/pprof/heap > heap.out
+//	go tool pprof -http=: heap.out
+//
+// SECURITY: profiles expose memory contents and internals. Only mount these on
+// the admin listener, behind RequireAdmin - never on the public port
+func RegisterDebugRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index) // Also serves named profiles like /debug/pprof/heap
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+}
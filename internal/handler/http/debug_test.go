@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/internal/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugRoutes_RequireAdmin(t *testing.T) {
+	// Arrange
+	mux := http.NewServeMux()
+	RegisterDebugRoutes(mux)
+	handler := AuthMiddleware(auth.NewStaticKeyAuthenticator("secret"))(RequireAdmin(mux.ServeHTTP))
+
+	tests := []struct {
+		name           string
+		path           string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "expvar as admin", path: "/debug/vars", authorization: "Bearer secret", expectedStatus: http.StatusOK},
+		{name: "pprof index as admin", path: "/debug/pprof/", authorization: "Bearer secret", expectedStatus: http.StatusOK},
+		{name: "anonymous", path: "/debug/pprof/", expectedStatus: http.StatusUnauthorized},
+		{name: "wrong token", path: "/debug/vars", authorization: "Bearer nope", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
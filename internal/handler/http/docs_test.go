@@ -0,0 +1,165 @@
+package http
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadOpenAPISpec reads api/openapi.json from the repo root
+// WHY TEST A JSON FILE? Client generators (openapi-generator) choke on
+// missing operation IDs and dangling $refs; this catches them before users do
+func loadOpenAPISpec(t *testing.T) map[string]interface{} {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join("..", "..", "..", "api", "openapi.json"))
+	require.NoError(t, err)
+
+	var spec map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &spec))
+	return spec
+}
+
+// specOperations returns every operation as "METHOD path" => operation
+func specOperations(t *testing.T, spec map[string]interface{}) map[string]map[string]interface{} {
+	t.Helper()
+
+	operations := make(map[string]map[string]interface{})
+	paths, ok := spec["paths"].(map[string]interface{})
+	require.True(t, ok, "spec has no paths")
+
+	for path, item := range paths {
+		for method, op := range item.(map[string]interface{}) {
+			operations[strings.ToUpper(method)+" "+path] = op.(map[string]interface{})
+		}
+	}
+	return operations
+}
+
+func TestOpenAPISpec_OperationsAreGeneratorReady(t *testing.T) {
+	// Arrange
+	spec := loadOpenAPISpec(t)
+
+	declaredTags := make(map[string]bool)
+	for _, tag := range spec["tags"].([]interface{}) {
+		declaredTags[tag.(map[string]interface{})["name"].(string)] = true
+	}
+
+	// Act
+	operations := specOperations(t, spec)
+
+	// Assert
+	require.NotEmpty(t, operations)
+
+	seenIDs := make(map[string]string)
+	for name, op := range operations {
+		id, _ := op["operationId"].(string)
+		if assert.NotEmpty(t, id, "%s has no operationId", name) {
+			assert.NotContains(t, seenIDs, id, "%s reuses the operationId of %s", name, seenIDs[id])
+			seenIDs[id] = name
+		}
+
+		tags, _ := op["tags"].([]interface{})
+		assert.NotEmpty(t, tags, "%s has no tags", name)
+		for _, tag := range tags {
+			assert.True(t, declaredTags[tag.(string)], "%s uses undeclared tag %q", name, tag)
+		}
+
+		assert.Contains(t, op, "security", "%s doesn't say whether it needs an API key", name)
+	}
+}
+
+func TestOpenAPISpec_JSONBodiesHaveExamples(t *testing.T) {
+	// Arrange
+	spec := loadOpenAPISpec(t)
+	operations := specOperations(t, spec)
+
+	hasExample := func(media map[string]interface{}) bool {
+		_, one := media["example"]
+		_, many := media["examples"]
+		return one || many
+	}
+
+	// Act & Assert
+	for name, op := range operations {
+		if body, ok := op["requestBody"].(map[string]interface{}); ok {
+			for mediaType, media := range body["content"].(map[string]interface{}) {
+				assert.True(t, hasExample(media.(map[string]interface{})), "%s request %s has no example", name, mediaType)
+			}
+		}
+
+		for status, response := range op["responses"].(map[string]interface{}) {
+			content, ok := response.(map[string]interface{})["content"].(map[string]interface{})
+			if !ok {
+				continue // A $ref (checked below) or a body-less response
+			}
+			for mediaType, media := range content {
+				assert.True(t, hasExample(media.(map[string]interface{})), "%s %s response %s has no example", name, status, mediaType)
+			}
+		}
+	}
+}
+
+func TestOpenAPISpec_RefsResolve(t *testing.T) {
+	// Arrange
+	spec := loadOpenAPISpec(t)
+
+	var refs []string
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch v := node.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				refs = append(refs, ref)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+
+	// Act
+	walk(spec)
+
+	// Assert
+	require.NotEmpty(t, refs)
+	for _, ref := range refs {
+		require.True(t, strings.HasPrefix(ref, "#/"), "only local refs are supported: %s", ref)
+
+		var node interface{} = spec
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			m, ok := node.(map[string]interface{})
+			if !assert.True(t, ok, "%s doesn't resolve", ref) {
+				break
+			}
+			node, ok = m[part]
+			if !assert.True(t, ok, "%s doesn't resolve", ref) {
+				break
+			}
+		}
+	}
+}
+
+func TestOpenAPISpec_DeclaresBearerAuth(t *testing.T) {
+	// Arrange
+	spec := loadOpenAPISpec(t)
+
+	// Act
+	components := spec["components"].(map[string]interface{})
+	schemes, _ := components["securitySchemes"].(map[string]interface{})
+
+	// Assert
+	require.Contains(t, schemes, "bearerAuth")
+	scheme := schemes["bearerAuth"].(map[string]interface{})
+	assert.Equal(t, "http", scheme["type"])
+	assert.Equal(t, "bearer", scheme["scheme"])
+}
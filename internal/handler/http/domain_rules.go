@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+// DomainRuleManager is what the domain rule API needs
+// Implemented by service.DomainPolicyService
+type DomainRuleManager interface {
+	ListRules(ctx context.Context) ([]*domain.DomainRule, error)
+	CreateRule(ctx context.Context, pattern string, action domain.PolicyAction, reason string, global bool) (*domain.DomainRule, error)
+	DeleteRule(ctx context.Context, id string) error
+}
+
+// DomainRuleHandler serves the destination domain rules
+// Workspaces manage their own rules; admins also manage the global ones
+type DomainRuleHandler struct {
+	rules  DomainRuleManager
+	logger *slog.Logger
+}
+
+// NewDomainRuleHandler creates a new domain rule handler
+func NewDomainRuleHandler(rules DomainRuleManager, logger *slog.Logger) *DomainRuleHandler {
+	return &DomainRuleHandler{rules: rules, logger: logger}
+}
+
+// ListRules handles GET /api/v1/domain-rules?limit=&offset= (authenticated)
+// The global rules and the caller's own; admins see every workspace's
+func (h *DomainRuleHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	window, err := parsePageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rules, err := h.rules.ListRules(r.Context())
+	if err != nil {
+		h.respondRuleError(w, err, "Failed to list domain rules")
+		return
+	}
+
+	response := make([]v1.DomainRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		response = append(response, domainRuleResponse(rule))
+	}
+	items, pagination := paginate(response, window)
+	respondList(w, items, pagination)
+}
+
+// CreateRule handles POST /api/v1/domain-rules (authenticated)
+// A new deny rule also switches off existing links it matches (see
+// service.PolicySweeper) - within a sweep interval, not right away
+func (h *DomainRuleHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var req v1.DomainRuleRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	rule, err := h.rules.CreateRule(r.Context(), req.Pattern, domain.PolicyAction(req.Action), req.Reason, req.Global)
+	if err != nil {
+		h.respondRuleError(w, err, "Failed to create domain rule")
+		return
+	}
+
+	h.logger.Info("Domain rule created", "id", rule.ID, "workspace", rule.Workspace, "pattern", rule.Pattern, "action", rule.Action)
+	respondSuccess(w, http.StatusCreated, domainRuleResponse(rule), "Domain rule created")
+}
+
+// DeleteRule handles DELETE /api/v1/domain-rules/{id} (authenticated)
+func (h *DomainRuleHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.rules.DeleteRule(r.Context(), id); err != nil {
+		h.respondRuleError(w, err, "Failed to delete domain rule")
+		return
+	}
+
+	h.logger.Info("Domain rule deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondRuleError maps domain rule errors to HTTP statuses
+func (h *DomainRuleHandler) respondRuleError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		respondError(w, http.StatusForbidden, "You don't have access to this domain rule")
+	case errors.Is(err, domain.ErrDomainRuleNotFound):
+		respondError(w, http.StatusNotFound, "Domain rule not found")
+	case errors.Is(err, domain.ErrDomainRuleDuplicate):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInvalidDomainRule):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Error(message, "error", err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+// domainRuleResponse converts a rule to its API representation
+func domainRuleResponse(rule *domain.DomainRule) v1.DomainRuleResponse {
+	return v1.DomainRuleResponse{
+		ID:        rule.ID,
+		Workspace: rule.Workspace,
+		Global:    rule.Workspace == domain.GlobalWorkspace,
+		Pattern:   rule.Pattern,
+		Kind:      string(rule.Kind),
+		Action:    string(rule.Action),
+		Reason:    rule.Reason,
+		CreatedBy: rule.CreatedBy,
+		CreatedAt: rule.CreatedAt,
+	}
+}
@@ -0,0 +1,195 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDomainRuleManager is a mock implementation of DomainRuleManager
+type MockDomainRuleManager struct {
+	mock.Mock
+}
+
+func (m *MockDomainRuleManager) ListRules(ctx context.Context) ([]*domain.DomainRule, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.DomainRule), args.Error(1)
+}
+
+func (m *MockDomainRuleManager) CreateRule(ctx context.Context, pattern string, action domain.PolicyAction, reason string, global bool) (*domain.DomainRule, error) {
+	args := m.Called(ctx, pattern, action, reason, global)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.DomainRule), args.Error(1)
+}
+
+func (m *MockDomainRuleManager) DeleteRule(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func newTestDomainRuleHandler() (*DomainRuleHandler, *MockDomainRuleManager) {
+	rules := new(MockDomainRuleManager)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewDomainRuleHandler(rules, logger), rules
+}
+
+func TestListDomainRules(t *testing.T) {
+	// Arrange
+	handler, rules := newTestDomainRuleHandler()
+	rules.On("ListRules", mock.Anything).Return([]*domain.DomainRule{
+		{ID: "r1", Pattern: ".tk", Kind: domain.PatternTLD, Action: domain.PolicyDeny, Reason: "disposable", CreatedBy: "admin"},
+		{ID: "r2", Workspace: "user1", Pattern: "example.com", Kind: domain.PatternDomain, Action: domain.PolicyAllow, CreatedBy: "user1"},
+	}, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/domain-rules?limit=1", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ListRules(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `"id":"r1","global":true,"pattern":".tk","kind":"tld","action":"deny","reason":"disposable"`)
+	assert.NotContains(t, body, `"r2"`)
+	assert.Contains(t, body, `"total":2`)
+}
+
+func TestCreateDomainRule(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "created",
+			body:           `{"pattern":"*.evil.com","action":"deny","reason":"phishing"}`,
+			expectCall:     true,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"workspace":"user1","global":false,"pattern":"*.evil.com","kind":"wildcard","action":"deny"`,
+		},
+		{
+			name:           "unknown action",
+			body:           `{"pattern":"evil.com","action":"block"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"action"`,
+		},
+		{
+			name:           "missing pattern",
+			body:           `{"action":"deny"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"pattern"`,
+		},
+		{
+			name:           "invalid pattern",
+			body:           `{"pattern":"*.evil.com","action":"deny"}`,
+			serviceErr:     domain.ErrInvalidDomainRule,
+			expectCall:     true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "global rule without admin",
+			body:           `{"pattern":"*.evil.com","action":"deny","global":true}`,
+			serviceErr:     domain.ErrForbidden,
+			expectCall:     true,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "duplicate",
+			body:           `{"pattern":"*.evil.com","action":"deny"}`,
+			serviceErr:     fmt.Errorf("%w: *.evil.com", domain.ErrDomainRuleDuplicate),
+			expectCall:     true,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "database down",
+			body:           `{"pattern":"*.evil.com","action":"deny"}`,
+			serviceErr:     assert.AnError,
+			expectCall:     true,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, rules := newTestDomainRuleHandler()
+			if tt.expectCall {
+				var rule *domain.DomainRule
+				if tt.serviceErr == nil {
+					rule = &domain.DomainRule{
+						ID:        "r1",
+						Workspace: "user1",
+						Pattern:   "*.evil.com",
+						Kind:      domain.PatternWildcard,
+						Action:    domain.PolicyDeny,
+						Reason:    "phishing",
+						CreatedBy: "user1",
+						CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+					}
+				}
+				rules.On("CreateRule", mock.Anything, "*.evil.com", domain.PolicyDeny, mock.Anything, mock.Anything).
+					Return(rule, tt.serviceErr)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/domain-rules", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.CreateRule(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			rules.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteDomainRule(t *testing.T) {
+	tests := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{name: "deleted", expectedStatus: http.StatusNoContent},
+		{name: "not found", serviceErr: domain.ErrDomainRuleNotFound, expectedStatus: http.StatusNotFound},
+		{name: "someone else's rule", serviceErr: domain.ErrForbidden, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, rules := newTestDomainRuleHandler()
+			rules.On("DeleteRule", mock.Anything, "r1").Return(tt.serviceErr)
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/domain-rules/r1", nil)
+			req.SetPathValue("id", "r1")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.DeleteRule(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/jobs"
+)
+
+// DomainGate decides which hosts redirects are answered on
+// Implemented by service.CustomDomainService; optional (nil serves every host)
+type DomainGate interface {
+	Serves(host string) bool
+}
+
+// WithDomainGate refuses redirects on custom domains that aren't verified
+func (h *Handler) WithDomainGate(gate DomainGate) *Handler {
+	h.domains = gate
+	return h
+}
+
+// CustomDomainManager is what the custom domain API needs
+// Implemented by service.CustomDomainService
+type CustomDomainManager interface {
+	AddDomain(ctx context.Context, host string) (*domain.CustomDomain, error)
+	ListDomains(ctx context.Context) ([]*domain.CustomDomain, error)
+	GetDomain(ctx context.Context, id string) (*domain.CustomDomain, error)
+	DeleteDomain(ctx context.Context, id string) error
+	RequestVerification(ctx context.Context, id string) (*domain.CustomDomain, *jobs.Job, error)
+}
+
+// DomainHandler serves the custom domain API
+type DomainHandler struct {
+	domains CustomDomainManager
+	logger  *slog.Logger
+}
+
+// NewDomainHandler creates a new custom domain handler
+func NewDomainHandler(domains CustomDomainManager, logger *slog.Logger) *DomainHandler {
+	return &DomainHandler{domains: domains, logger: logger}
+}
+
+// ListDomains handles GET /api/v1/domains?limit=&offset= (authenticated)
+// The caller's domains; admins see every domain
+func (h *DomainHandler) ListDomains(w http.ResponseWriter, r *http.Request) {
+	window, err := parsePageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	domains, err := h.domains.ListDomains(r.Context())
+	if err != nil {
+		h.respondDomainError(w, err, "Failed to list domains")
+		return
+	}
+
+	response := make([]v1.CustomDomainResponse, 0, len(domains))
+	for _, d := range domains {
+		response = append(response, customDomainResponse(d))
+	}
+	items, pagination := paginate(response, window)
+	respondList(w, items, pagination)
+}
+
+// AddDomain handles POST /api/v1/domains (authenticated)
+// The response has the TXT record to publish; the domain is checked in the
+// background and serves links once it is verified
+func (h *DomainHandler) AddDomain(w http.ResponseWriter, r *http.Request) {
+	var req v1.CustomDomainRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	d, err := h.domains.AddDomain(r.Context(), req.Host)
+	if err != nil {
+		h.respondDomainError(w, err, "Failed to add domain")
+		return
+	}
+
+	h.logger.Info("Custom domain added", "id", d.ID, "host", d.Host, "owner", d.Owner)
+	respondSuccess(w, http.StatusCreated, customDomainResponse(d), "Domain added: publish its TXT record to verify it")
+}
+
+// GetDomain handles GET /api/v1/domains/{id} (authenticated)
+func (h *DomainHandler) GetDomain(w http.ResponseWriter, r *http.Request) {
+	d, err := h.domains.GetDomain(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.respondDomainError(w, err, "Failed to get domain")
+		return
+	}
+	respondSuccess(w, http.StatusOK, customDomainResponse(d), "")
+}
+
+// DeleteDomain handles DELETE /api/v1/domains/{id} (authenticated)
+func (h *DomainHandler) DeleteDomain(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.domains.DeleteDomain(r.Context(), id); err != nil {
+		h.respondDomainError(w, err, "Failed to delete domain")
+		return
+	}
+
+	h.logger.Info("Custom domain deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyDomain handles GET /api/v1/domains/{id}/verify (authenticated)
+// Starts a new check of the TXT record: 202 with the job to poll, or 200
+// with the outcome when checks run inline (no job queue)
+func (h *DomainHandler) VerifyDomain(w http.ResponseWriter, r *http.Request) {
+	d, job, err := h.domains.RequestVerification(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.respondDomainError(w, err, "Failed to verify domain")
+		return
+	}
+
+	response := customDomainResponse(d)
+	if job == nil {
+		respondSuccess(w, http.StatusOK, response, "")
+		return
+	}
+	response.JobID = job.ID
+	w.Header().Set("Location", "/api/v1/jobs/"+job.ID)
+	respondSuccess(w, http.StatusAccepted, response, "Verification started")
+}
+
+// respondDomainError maps custom domain errors to HTTP statuses
+func (h *DomainHandler) respondDomainError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		respondError(w, http.StatusForbidden, "You don't have access to this domain")
+	case errors.Is(err, domain.ErrCustomDomainNotFound):
+		respondError(w, http.StatusNotFound, "Domain not found")
+	case errors.Is(err, domain.ErrCustomDomainTaken):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInvalidDomain):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Error(message, "error", err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+// customDomainResponse converts a domain to its API representation
+func customDomainResponse(d *domain.CustomDomain) v1.CustomDomainResponse {
+	response := v1.CustomDomainResponse{
+		ID:     d.ID,
+		Host:   d.Host,
+		Status: string(d.Status),
+		Challenge: v1.DomainChallenge{
+			Type:  "TXT",
+			Name:  d.ChallengeName(),
+			Value: d.ChallengeValue(),
+		},
+		CreatedAt:  d.CreatedAt,
+		VerifiedAt: d.VerifiedAt,
+		CheckedAt:  d.CheckedAt,
+		LastError:  d.LastError,
+	}
+	if c := d.Certificate; c != nil {
+		response.Certificate = &v1.DomainCertificate{
+			Status:    string(c.Status(time.Now())),
+			IssuedAt:  c.IssuedAt,
+			ExpiresAt: c.NotAfter,
+			LastError: c.LastError,
+			FailedAt:  c.FailedAt,
+		}
+	}
+	return response
+}
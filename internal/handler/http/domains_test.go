@@ -0,0 +1,196 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/jobs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCustomDomainManager is a mock implementation of CustomDomainManager
+type MockCustomDomainManager struct {
+	mock.Mock
+}
+
+func (m *MockCustomDomainManager) AddDomain(ctx context.Context, host string) (*domain.CustomDomain, error) {
+	args := m.Called(ctx, host)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CustomDomain), args.Error(1)
+}
+
+func (m *MockCustomDomainManager) ListDomains(ctx context.Context) ([]*domain.CustomDomain, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.CustomDomain), args.Error(1)
+}
+
+func (m *MockCustomDomainManager) GetDomain(ctx context.Context, id string) (*domain.CustomDomain, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CustomDomain), args.Error(1)
+}
+
+func (m *MockCustomDomainManager) DeleteDomain(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCustomDomainManager) RequestVerification(ctx context.Context, id string) (*domain.CustomDomain, *jobs.Job, error) {
+	args := m.Called(ctx, id)
+	var d *domain.CustomDomain
+	if args.Get(0) != nil {
+		d = args.Get(0).(*domain.CustomDomain)
+	}
+	var job *jobs.Job
+	if args.Get(1) != nil {
+		job = args.Get(1).(*jobs.Job)
+	}
+	return d, job, args.Error(2)
+}
+
+// fixedGate serves every host except the listed ones
+type fixedGate map[string]bool
+
+func (g fixedGate) Serves(host string) bool { return !g[host] }
+
+func newTestDomainHandler() (*DomainHandler, *MockCustomDomainManager) {
+	domains := new(MockCustomDomainManager)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewDomainHandler(domains, logger), domains
+}
+
+func TestAddDomain(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "returns the TXT record to publish",
+			body:           `{"host":"go.example.com"}`,
+			expectCall:     true,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"challenge":{"type":"TXT","name":"_shortener-challenge.go.example.com","value":"shortener-verification=abc"}`,
+		},
+		{
+			name:           "invalid host",
+			body:           `{"host":"not a host"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"validation_failed"`,
+		},
+		{
+			name:           "already registered",
+			body:           `{"host":"go.example.com"}`,
+			serviceErr:     domain.ErrCustomDomainTaken,
+			expectCall:     true,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "already registered",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, domains := newTestDomainHandler()
+			if tt.expectCall {
+				d := &domain.CustomDomain{ID: "d1", Host: "go.example.com", Token: "abc", Status: domain.DomainPending}
+				if tt.serviceErr != nil {
+					domains.On("AddDomain", mock.Anything, "go.example.com").Return(nil, tt.serviceErr)
+				} else {
+					domains.On("AddDomain", mock.Anything, "go.example.com").Return(d, nil)
+				}
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/domains", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.AddDomain(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			domains.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetDomain_Certificate(t *testing.T) {
+	// Arrange
+	handler, domains := newTestDomainHandler()
+	notAfter := time.Now().Add(60 * 24 * time.Hour).UTC().Truncate(time.Second)
+	d := &domain.CustomDomain{
+		ID: "d1", Host: "go.example.com", Token: "abc", Status: domain.DomainVerified,
+		Certificate: &domain.Certificate{Host: "go.example.com", NotAfter: &notAfter},
+	}
+	domains.On("GetDomain", mock.Anything, "d1").Return(d, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/domains/d1", nil)
+	req.SetPathValue("id", "d1")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.GetDomain(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"certificate":{"status":"issued","expires_at":"`+notAfter.Format(time.RFC3339)+`"}`)
+}
+
+func TestVerifyDomain(t *testing.T) {
+	// Arrange
+	handler, domains := newTestDomainHandler()
+	d := &domain.CustomDomain{ID: "d1", Host: "go.example.com", Token: "abc", Status: domain.DomainPending}
+	domains.On("RequestVerification", mock.Anything, "d1").Return(d, &jobs.Job{ID: "job-1"}, nil)
+	domains.On("RequestVerification", mock.Anything, "other").Return(nil, nil, domain.ErrForbidden)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/domains/d1/verify", nil)
+	req.SetPathValue("id", "d1")
+	w := httptest.NewRecorder()
+	handler.VerifyDomain(w, req)
+
+	forbiddenReq := httptest.NewRequest(http.MethodGet, "/api/v1/domains/other/verify", nil)
+	forbiddenReq.SetPathValue("id", "other")
+	forbidden := httptest.NewRecorder()
+	handler.VerifyDomain(forbidden, forbiddenReq)
+
+	// Assert
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "/api/v1/jobs/job-1", w.Header().Get("Location"))
+	assert.Contains(t, w.Body.String(), `"job_id":"job-1"`)
+	assert.Equal(t, http.StatusForbidden, forbidden.Code)
+}
+
+func TestRedirect_UnverifiedDomain(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	handler.WithDomainGate(fixedGate{"new.example.com": true})
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Host = "new.example.com"
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.RedirectURL(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusMisdirectedRequest, w.Code)
+	mockService.AssertNotCalled(t, "GetURL", mock.Anything, mock.Anything)
+}
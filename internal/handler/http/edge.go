@@ -0,0 +1,61 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"url-shortener/internal/cdn"
+	"url-shortener/internal/domain"
+)
+
+// WithEdgeCaching lets a CDN cache redirects for up to ttl (see internal/cdn)
+// Browsers are still told not to cache them, so an edited link takes effect
+// as soon as the CDN has been purged
+func (h *Handler) WithEdgeCaching(ttl time.Duration) *Handler {
+	h.edgeTTL = ttl
+	return h
+}
+
+// setEdgeCacheHeaders marks a redirect as cacheable by shared caches
+//
+// ONLY redirects that are the same for every visitor and every moment are
+// cached at the edge. A link is answered here instead when:
+//   - it has a click limit (every click must be counted to enforce it)
+//   - it has a schedule, language targets or a preview card (the answer
+//     depends on the clock, Accept-Language or User-Agent - and not every
+//     CDN honors Vary)
+//   - it is signed (the edge would answer without checking the signature)
+//   - it is single-use (the edge would let everyone through)
+//   - it is burn-after-reading or a file (never a redirect; downloads
+//     must be counted and stop when the link expires)
+//
+// Links that expire are only cached until they expire.
+func (h *Handler) setEdgeCacheHeaders(w http.ResponseWriter, url *domain.URL) {
+	if h.edgeTTL <= 0 || !edgeCacheable(url) {
+		return
+	}
+
+	ttl := h.edgeTTL
+	if url.ExpiresAt != nil {
+		ttl = min(ttl, url.ExpiresAt.Sub(h.now()))
+	}
+	seconds := int(ttl.Seconds())
+	if seconds <= 0 {
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=0, s-maxage=%d", seconds))
+	w.Header().Set("Surrogate-Control", fmt.Sprintf("max-age=%d", seconds)) // Fastly; stripped before the browser
+	w.Header().Set("Surrogate-Key", strings.Join(cdn.Keys(url), " "))       // What the purge API targets
+}
+
+// edgeCacheable reports whether a link redirects the same way for everyone
+// A CDN cache only stores the answer, not the rules - unlike the edge
+// workers fed by the edge snapshot (see domain.URL.ServableAtEdge)
+func edgeCacheable(url *domain.URL) bool {
+	return url.ServableAtEdge() &&
+		url.Schedule == nil &&
+		len(url.LanguageTargets) == 0
+}
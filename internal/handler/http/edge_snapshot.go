@@ -0,0 +1,177 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+// EdgeSnapshotter reads the links edge workers can serve
+// Implemented by service.EdgeSnapshotService
+type EdgeSnapshotter interface {
+	Open(ctx context.Context, since string) (*domain.EdgeSnapshot, error)
+	Stream(ctx context.Context, snapshot *domain.EdgeSnapshot, fn func(*domain.EdgeChange) error) error
+}
+
+// EdgeSnapshotHandler serves the edge snapshot endpoint
+type EdgeSnapshotHandler struct {
+	snapshots EdgeSnapshotter
+	logger    *slog.Logger
+}
+
+// NewEdgeSnapshotHandler creates a new edge snapshot handler
+func NewEdgeSnapshotHandler(snapshots EdgeSnapshotter, logger *slog.Logger) *EdgeSnapshotHandler {
+	return &EdgeSnapshotHandler{snapshots: snapshots, logger: logger}
+}
+
+// Snapshot handles GET /api/v1/edge/snapshot?since=<version>&format=ndjson|nginx
+// (admin: edge workers use the admin key)
+//
+// HOW AN EDGE WORKER STAYS IN SYNC:
+//  1. Download a full snapshot and keep its X-Snapshot-Version
+//  2. Every now and then ask for ?since=<that version>: only the links
+//     that changed come back, then keep the new X-Snapshot-Version
+//  3. 410 Gone means the delta can't be told any more - start over at 1
+//
+// The ETag is the version too, so a plain If-None-Match gets a 304 when
+// nothing changed. Like the export, the status is sent before the body and
+// the X-Snapshot-Status trailer says "complete" or "failed": only apply a
+// complete snapshot.
+//
+// format=nginx writes a full snapshot as nginx map entries (see
+// nginxEdgeWriter) for `map $uri $short_link { include ...; }`.
+func (h *EdgeSnapshotHandler) Snapshot(w http.ResponseWriter, r *http.Request) {
+	since := r.URL.Query().Get("since")
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ndjson"
+	}
+	var write func(*domain.EdgeChange) error
+	switch format {
+	case "ndjson":
+		write = ndjsonEdgeWriter(w)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	case "nginx":
+		if since != "" {
+			respondError(w, http.StatusBadRequest, "format=nginx only supports full snapshots")
+			return
+		}
+		write = nginxEdgeWriter(w)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	default:
+		respondError(w, http.StatusBadRequest, "format must be ndjson or nginx")
+		return
+	}
+
+	snapshot, err := h.snapshots.Open(r.Context(), since)
+	switch {
+	case errors.Is(err, domain.ErrInvalidSnapshotVersion):
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, domain.ErrSnapshotExpired):
+		respondError(w, http.StatusGone, err.Error())
+		return
+	case err != nil:
+		h.logger.Error("Failed to open edge snapshot", "since", since, "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to read edge snapshot")
+		return
+	}
+
+	version := snapshot.Version.String()
+	etag := `"` + version + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("X-Snapshot-Version", version)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Trailer", "X-Snapshot-Status")
+	w.WriteHeader(http.StatusOK)
+
+	// Big snapshots outlive the server's WriteTimeout (see Export)
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Now().Add(exportWriteDeadline))
+
+	count := 0
+	err = h.snapshots.Stream(r.Context(), snapshot, func(change *domain.EdgeChange) error {
+		if err := write(change); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			_ = rc.Flush()
+			_ = rc.SetWriteDeadline(time.Now().Add(exportWriteDeadline))
+		}
+		return nil
+	})
+	if err != nil {
+		h.logger.Error("Edge snapshot failed", "since", since, "version", version, "links_written", count, "error", err)
+		w.Header().Set("X-Snapshot-Status", "failed")
+		return
+	}
+
+	h.logger.Info("Edge snapshot completed", "since", since, "version", version, "links", count, "format", format)
+	w.Header().Set("X-Snapshot-Status", "complete")
+}
+
+// edgeCodes returns every code a link is reachable under
+func edgeCodes(url *domain.URL) []string {
+	codes := []string{url.ShortCode}
+	if url.CustomAlias != nil && *url.CustomAlias != url.ShortCode {
+		codes = append(codes, *url.CustomAlias)
+	}
+	return codes
+}
+
+// ndjsonEdgeWriter writes one v1.EdgeEntry per line and code
+func ndjsonEdgeWriter(out io.Writer) func(*domain.EdgeChange) error {
+	encoder := json.NewEncoder(out)
+	return func(change *domain.EdgeChange) error {
+		for _, code := range edgeCodes(change.URL) {
+			entry := v1.EdgeEntry{Code: code, Domain: change.URL.Domain, Removed: change.Removed}
+			if !change.Removed {
+				entry.URL = change.URL.OriginalURL
+				entry.ExpiresAt = change.URL.ExpiresAt
+				entry.LanguageTargets = change.URL.LanguageTargets
+				entry.Schedule = scheduleV1(change.URL.Schedule)
+			}
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// nginxEdgeWriter writes `/code "destination";` per code
+//
+// An nginx map is a plain lookup table, so only links that redirect the
+// same way forever make it in: links with an expiry date, a schedule or
+// language targets are left out (nginx passes them through to us), and so
+// are destinations nginx would read as variables or syntax.
+func nginxEdgeWriter(out io.Writer) func(*domain.EdgeChange) error {
+	return func(change *domain.EdgeChange) error {
+		url := change.URL
+		if url.ExpiresAt != nil || url.Schedule != nil || len(url.LanguageTargets) > 0 ||
+			strings.ContainsAny(url.OriginalURL, "$\"\\;{} \t\r\n") {
+			return nil
+		}
+		for _, code := range edgeCodes(url) {
+			if _, err := fmt.Fprintf(out, "/%s \"%s\";\n", code, url.OriginalURL); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEdgeSnapshotter is a mock implementation of EdgeSnapshotter that replays fixed changes
+type MockEdgeSnapshotter struct {
+	mock.Mock
+	changes []*domain.EdgeChange
+}
+
+func (m *MockEdgeSnapshotter) Open(ctx context.Context, since string) (*domain.EdgeSnapshot, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.EdgeSnapshot), args.Error(1)
+}
+
+func (m *MockEdgeSnapshotter) Stream(ctx context.Context, snapshot *domain.EdgeSnapshot, fn func(*domain.EdgeChange) error) error {
+	for _, change := range m.changes {
+		if err := fn(change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setupEdgeSnapshotHandler(changes ...*domain.EdgeChange) (*EdgeSnapshotHandler, *MockEdgeSnapshotter) {
+	mockSnapshots := &MockEdgeSnapshotter{changes: changes}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewEdgeSnapshotHandler(mockSnapshots, logger), mockSnapshots
+}
+
+func TestEdgeSnapshot_Formats(t *testing.T) {
+	alias := "summer"
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	changes := []*domain.EdgeChange{
+		{URL: &domain.URL{ShortCode: "abc123", CustomAlias: &alias, OriginalURL: "https://example.com/sale"}},
+		{URL: &domain.URL{ShortCode: "later", OriginalURL: "https://example.com/later", ExpiresAt: &expires}},
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		wantBody string
+	}{
+		{
+			name:   "ndjson",
+			target: "/api/v1/edge/snapshot",
+			wantBody: `{"code":"abc123","url":"https://example.com/sale"}` + "\n" +
+				`{"code":"summer","url":"https://example.com/sale"}` + "\n" +
+				`{"code":"later","url":"https://example.com/later","expires_at":"2030-01-01T00:00:00Z"}` + "\n",
+		},
+		{
+			name:   "nginx leaves out links with rules",
+			target: "/api/v1/edge/snapshot?format=nginx",
+			wantBody: `/abc123 "https://example.com/sale";` + "\n" +
+				`/summer "https://example.com/sale";` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockSnapshots := setupEdgeSnapshotHandler(changes...)
+			mockSnapshots.On("Open", mock.Anything, "").
+				Return(&domain.EdgeSnapshot{Version: domain.SnapshotVersion{42, 7}}, nil)
+			rec := httptest.NewRecorder()
+
+			// Act
+			handler.Snapshot(rec, httptest.NewRequest("GET", tt.target, nil))
+
+			// Assert
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+			assert.Equal(t, "42.7", rec.Header().Get("X-Snapshot-Version"))
+			assert.Equal(t, `"42.7"`, rec.Header().Get("ETag"))
+			assert.Equal(t, "complete", rec.Header().Get("X-Snapshot-Status"))
+		})
+	}
+}
+
+func TestEdgeSnapshot_DeltaRemovals(t *testing.T) {
+	// Arrange
+	alias := "summer"
+	handler, mockSnapshots := setupEdgeSnapshotHandler(
+		&domain.EdgeChange{Seq: 43, URL: &domain.URL{ShortCode: "abc123", CustomAlias: &alias}, Removed: true},
+	)
+	mockSnapshots.On("Open", mock.Anything, "42").
+		Return(&domain.EdgeSnapshot{Since: domain.SnapshotVersion{42}, Version: domain.SnapshotVersion{43}}, nil)
+	rec := httptest.NewRecorder()
+
+	// Act
+	handler.Snapshot(rec, httptest.NewRequest("GET", "/api/v1/edge/snapshot?since=42", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"code":"abc123","removed":true}`+"\n"+`{"code":"summer","removed":true}`+"\n", rec.Body.String())
+	assert.Equal(t, "43", rec.Header().Get("X-Snapshot-Version"))
+}
+
+func TestEdgeSnapshot_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		ifNoneMatch string
+		openErr     error
+		wantStatus  int
+	}{
+		{name: "not modified", target: "/api/v1/edge/snapshot", ifNoneMatch: `"42"`, wantStatus: http.StatusNotModified},
+		{name: "unknown format", target: "/api/v1/edge/snapshot?format=yaml", wantStatus: http.StatusBadRequest},
+		{name: "nginx delta", target: "/api/v1/edge/snapshot?format=nginx&since=1", wantStatus: http.StatusBadRequest},
+		{name: "invalid version", target: "/api/v1/edge/snapshot?since=x", openErr: domain.ErrInvalidSnapshotVersion, wantStatus: http.StatusBadRequest},
+		{name: "expired version", target: "/api/v1/edge/snapshot?since=1", openErr: domain.ErrSnapshotExpired, wantStatus: http.StatusGone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockSnapshots := setupEdgeSnapshotHandler(
+				&domain.EdgeChange{URL: &domain.URL{ShortCode: "abc123", OriginalURL: "https://example.com"}},
+			)
+			if tt.openErr != nil {
+				mockSnapshots.On("Open", mock.Anything, mock.Anything).Return(nil, tt.openErr)
+			} else {
+				mockSnapshots.On("Open", mock.Anything, mock.Anything).
+					Return(&domain.EdgeSnapshot{Version: domain.SnapshotVersion{42}}, nil)
+			}
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			// Act
+			handler.Snapshot(rec, req)
+
+			// Assert
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusNotModified {
+				assert.Empty(t, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRedirectURL_EdgeCaching(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	inTenMinutes := now.Add(10 * time.Minute)
+	alreadyExpired := now.Add(-time.Second)
+	maxClicks := int64(10)
+	alias := "sale"
+
+	tests := []struct {
+		name                 string
+		url                  domain.URL
+		edgeTTL              time.Duration
+		expectedCacheControl string
+		expectedSurrogateKey string
+	}{
+		{
+			name:                 "plain link",
+			url:                  domain.URL{ShortCode: "abc123"},
+			edgeTTL:              time.Hour,
+			expectedCacheControl: "public, max-age=0, s-maxage=3600",
+			expectedSurrogateKey: "link-abc123",
+		},
+		{
+			name:                 "custom alias",
+			url:                  domain.URL{ShortCode: "abc123", CustomAlias: &alias},
+			edgeTTL:              time.Hour,
+			expectedCacheControl: "public, max-age=0, s-maxage=3600",
+			expectedSurrogateKey: "link-abc123 link-sale",
+		},
+		{
+			name:                 "expires before the TTL",
+			url:                  domain.URL{ShortCode: "abc123", ExpiresAt: &inTenMinutes},
+			edgeTTL:              time.Hour,
+			expectedCacheControl: "public, max-age=0, s-maxage=600",
+			expectedSurrogateKey: "link-abc123",
+		},
+		{name: "expiring right now", url: domain.URL{ShortCode: "abc123", ExpiresAt: &alreadyExpired}, edgeTTL: time.Hour},
+		{name: "click limit must be counted", url: domain.URL{ShortCode: "abc123", MaxClicks: &maxClicks}, edgeTTL: time.Hour},
+		{name: "language targets", url: domain.URL{ShortCode: "abc123", LanguageTargets: map[string]string{"fr": "https://example.com/fr"}}, edgeTTL: time.Hour},
+		{name: "preview card", url: domain.URL{ShortCode: "abc123", Preview: &domain.PreviewCard{Title: "Hi"}}, edgeTTL: time.Hour},
+		{name: "edge caching off", url: domain.URL{ShortCode: "abc123"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			handler.WithEdgeCaching(tt.edgeTTL)
+			handler.now = func() time.Time { return now }
+
+			url := tt.url
+			url.OriginalURL = "https://example.com"
+			mockService.On("GetURL", mock.Anything, "abc123").Return(&url, nil)
+			mockService.On("RecordClick", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.RedirectURL(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusFound, w.Code)
+			assert.Equal(t, tt.expectedCacheControl, w.Header().Get("Cache-Control"))
+			assert.Equal(t, tt.expectedSurrogateKey, w.Header().Get("Surrogate-Key"))
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+)
+
+// Eraser is the service the account deletion endpoints need
+// Implemented by service.ErasureService
+type Eraser interface {
+	RequestErasure(ctx context.Context, owner, requestedBy string) (*domain.ErasureRequest, error)
+	GetRequest(ctx context.Context, id string) (*domain.ErasureRequest, error)
+}
+
+// ErasureHandler serves the account deletion (GDPR erasure) endpoints
+type ErasureHandler struct {
+	eraser Eraser
+	logger *slog.Logger
+}
+
+// NewErasureHandler creates a new erasure handler
+func NewErasureHandler(eraser Eraser, logger *slog.Logger) *ErasureHandler {
+	return &ErasureHandler{
+		eraser: eraser,
+		logger: logger,
+	}
+}
+
+// DeleteMe handles DELETE /api/v1/me
+// Schedules deletion of everything the caller created
+// In a shared workspace that's every member's links, so only owners may
+func (h *ErasureHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	principal := auth.FromContext(r.Context())
+	if principal.EffectiveRole() != auth.RoleOwner {
+		respondError(w, http.StatusForbidden, "Only workspace owners can delete the workspace's data")
+		return
+	}
+	h.requestErasure(w, r, principal.ID, principal.ID)
+}
+
+// DeleteUser handles DELETE /api/v1/users/{owner} (admin only)
+func (h *ErasureHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	principal := auth.FromContext(r.Context())
+	h.requestErasure(w, r, r.PathValue("owner"), principal.ID)
+}
+
+// GetErasure handles GET /api/v1/erasures/{id}
+// Visible to the admin, to whoever asked, and to the data subject
+// (matched by hash, because the owner is cleared once the erasure completes)
+func (h *ErasureHandler) GetErasure(w http.ResponseWriter, r *http.Request) {
+	req, err := h.eraser.GetRequest(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, domain.ErrErasureNotFound) {
+			respondError(w, http.StatusNotFound, "Erasure request not found")
+			return
+		}
+		h.logger.Error("Failed to get erasure request", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to get erasure request")
+		return
+	}
+
+	principal := auth.FromContext(r.Context())
+	if !principal.Admin && req.RequestedBy != principal.ID && req.SubjectHash != domain.SubjectHash(principal.ID) {
+		respondError(w, http.StatusNotFound, "Erasure request not found")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, erasureResponse(req), "")
+}
+
+// requestErasure schedules the erasure and answers 202 with the request to poll
+func (h *ErasureHandler) requestErasure(w http.ResponseWriter, r *http.Request, owner, requestedBy string) {
+	if owner == "" {
+		respondError(w, http.StatusBadRequest, "Owner is required")
+		return
+	}
+
+	req, err := h.eraser.RequestErasure(r.Context(), owner, requestedBy)
+	if err != nil {
+		h.logger.Error("Failed to request erasure", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to request account deletion")
+		return
+	}
+
+	// Log the request ID only - the owner is exactly what we are erasing
+	h.logger.Info("Account erasure requested", "erasure_id", req.ID)
+
+	w.Header().Set("Location", "/api/v1/erasures/"+req.ID)
+	respondSuccess(w, http.StatusAccepted, erasureResponse(req), "Account deletion scheduled")
+}
+
+// erasureResponse converts a request to its API representation
+func erasureResponse(req *domain.ErasureRequest) v1.ErasureResponse {
+	response := v1.ErasureResponse{
+		ID:          req.ID,
+		Status:      string(req.Status),
+		RequestedAt: req.RequestedAt,
+		Error:       req.Error,
+	}
+
+	if req.Status == domain.ErasureCompleted && req.CompletedAt != nil {
+		response.Receipt = &v1.ErasureReceipt{
+			RequestID:     req.ID,
+			SubjectHash:   req.SubjectHash,
+			URLsDeleted:   req.URLsDeleted,
+			ClicksDeleted: req.ClicksDeleted,
+			Verified:      true, // Completion requires passing verification
+			RequestedAt:   req.RequestedAt,
+			CompletedAt:   *req.CompletedAt,
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEraser is a mock implementation of Eraser
+type MockEraser struct {
+	mock.Mock
+}
+
+func (m *MockEraser) RequestErasure(ctx context.Context, owner, requestedBy string) (*domain.ErasureRequest, error) {
+	args := m.Called(ctx, owner, requestedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ErasureRequest), args.Error(1)
+}
+
+func (m *MockEraser) GetRequest(ctx context.Context, id string) (*domain.ErasureRequest, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ErasureRequest), args.Error(1)
+}
+
+func setupErasureHandler() (*ErasureHandler, *MockEraser) {
+	mockEraser := new(MockEraser)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewErasureHandler(mockEraser, logger), mockEraser
+}
+
+func TestDeleteMe_SchedulesErasure(t *testing.T) {
+	// Arrange
+	handler, mockEraser := setupErasureHandler()
+	req := domain.NewErasureRequest("user1", "user1")
+	mockEraser.On("RequestErasure", mock.Anything, "user1", "user1").Return(req, nil)
+
+	httpReq := httptest.NewRequest("DELETE", "/api/v1/me", nil)
+	httpReq = httpReq.WithContext(auth.WithPrincipal(httpReq.Context(), &auth.Principal{ID: "user1"}))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.DeleteMe(w, httpReq)
+
+	// Assert
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "/api/v1/erasures/"+req.ID, w.Header().Get("Location"))
+	assert.Contains(t, w.Body.String(), `"status":"pending"`)
+	assert.NotContains(t, w.Body.String(), "receipt")
+}
+
+func TestDeleteMe_OwnersOnly(t *testing.T) {
+	for _, role := range []auth.Role{auth.RoleEditor, auth.RoleContributor, auth.RoleViewer} {
+		t.Run(string(role), func(t *testing.T) {
+			// Arrange
+			handler, mockEraser := setupErasureHandler()
+			httpReq := httptest.NewRequest("DELETE", "/api/v1/me", nil)
+			httpReq = httpReq.WithContext(auth.WithPrincipal(httpReq.Context(), &auth.Principal{ID: "team1", Member: "erin@example.com", Role: role}))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.DeleteMe(w, httpReq)
+
+			// Assert
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			mockEraser.AssertNotCalled(t, "RequestErasure", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestGetErasure_ReceiptForDataSubject(t *testing.T) {
+	// Arrange
+	handler, mockEraser := setupErasureHandler()
+
+	// Completed requests no longer store the owner, only its hash
+	completedAt := time.Now()
+	req := domain.NewErasureRequest("user1", "admin")
+	req.Owner = ""
+	req.Status = domain.ErasureCompleted
+	req.URLsDeleted = 3
+	req.CompletedAt = &completedAt
+	mockEraser.On("GetRequest", mock.Anything, req.ID).Return(req, nil)
+
+	tests := []struct {
+		name           string
+		principal      *auth.Principal
+		expectedStatus int
+	}{
+		{name: "data subject", principal: &auth.Principal{ID: "user1"}, expectedStatus: http.StatusOK},
+		{name: "admin", principal: &auth.Principal{ID: "admin", Admin: true}, expectedStatus: http.StatusOK},
+		{name: "someone else", principal: &auth.Principal{ID: "user2"}, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest("GET", "/api/v1/erasures/"+req.ID, nil)
+			httpReq = httpReq.WithContext(auth.WithPrincipal(httpReq.Context(), tt.principal))
+			httpReq.SetPathValue("id", req.ID)
+			w := httptest.NewRecorder()
+
+			handler.GetErasure(w, httpReq)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"subject_hash":"`+domain.SubjectHash("user1")+`"`)
+				assert.Contains(t, w.Body.String(), `"urls_deleted":3`)
+			}
+		})
+	}
+}
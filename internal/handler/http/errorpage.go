@@ -0,0 +1,136 @@
+package http
+
+import (
+	"bytes"
+	"html/template"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrorPageBranding is how the error page looks on one domain
+type ErrorPageBranding struct {
+	Brand    string // Name shown on the page
+	HomeURL  string // Target of the "go to homepage" link
+	Redirect bool   // Redirect to HomeURL instead of showing the page
+}
+
+// ErrorPages renders branded HTML pages for links that don't work
+// (unknown, expired or used up), for browsers only
+//
+// CONTENT NEGOTIATION:
+// Browsers send "Accept: text/html,..." and get a readable page. API
+// clients and tools like curl send "application/json" or "*/*" and keep
+// getting the JSON error they always got.
+type ErrorPages struct {
+	tmpl     *template.Template
+	fallback ErrorPageBranding
+	domains  map[string]ErrorPageBranding // Lowercase host -> branding
+}
+
+// errorPageData is what web/templates/error.html renders
+type errorPageData struct {
+	ErrorPageBranding
+	Status  int
+	Title   string
+	Message string
+	Lang    string                              // <html lang>
+	T       func(string, ...interface{}) string // {{call .T "Go to homepage"}}
+}
+
+// NewErrorPages creates error pages that use fallback on every domain
+// without its own branding
+func NewErrorPages(tmpl *template.Template, fallback ErrorPageBranding) *ErrorPages {
+	return &ErrorPages{
+		tmpl:     tmpl,
+		fallback: fallback,
+		domains:  make(map[string]ErrorPageBranding),
+	}
+}
+
+// WithDomain sets the branding used for requests to host
+func (p *ErrorPages) WithDomain(host string, branding ErrorPageBranding) *ErrorPages {
+	p.domains[strings.ToLower(host)] = branding
+	return p
+}
+
+// brandingFor picks the branding by the request's Host header
+func (p *ErrorPages) brandingFor(r *http.Request) ErrorPageBranding {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if branding, ok := p.domains[strings.ToLower(host)]; ok {
+		return branding
+	}
+	return p.fallback
+}
+
+// Serve writes the error page (or the homepage redirect) for status
+// Returns false when the client prefers another format - the caller then
+// writes its usual JSON error
+func (p *ErrorPages) Serve(w http.ResponseWriter, r *http.Request, status int, title, message string) bool {
+	if !prefersHTML(r) {
+		return false
+	}
+
+	branding := p.brandingFor(r)
+	if branding.Redirect && branding.HomeURL != "" {
+		// 302: the link might exist tomorrow, so browsers must not cache this
+		http.Redirect(w, r, branding.HomeURL, http.StatusFound)
+		return true
+	}
+
+	localizer, localized := localizerFor(w)
+
+	// Render into a buffer first so a template error can't leave a half
+	// written page behind the status line
+	var buf bytes.Buffer
+	data := errorPageData{
+		ErrorPageBranding: branding,
+		Status:            status,
+		Title:             localizer.Translate(title),
+		Message:           localizer.Translate(message),
+		Lang:              localizer.Language(),
+		T:                 localizer.Translate,
+	}
+	if err := p.tmpl.Execute(&buf, data); err != nil {
+		return false
+	}
+
+	if localized {
+		setLanguageHeaders(w, localizer)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+	return true
+}
+
+// prefersHTML reports whether the Accept header ranks text/html above JSON
+// Wildcards don't count: "*/*" means "anything", and the API answers JSON
+func prefersHTML(r *http.Request) bool {
+	var htmlQ, jsonQ float64
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		switch mediaType {
+		case "text/html", "application/xhtml+xml":
+			htmlQ = max(htmlQ, q)
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return htmlQ > 0 && htmlQ > jsonQ
+}
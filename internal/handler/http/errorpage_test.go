@@ -0,0 +1,138 @@
+package http
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const browserAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+func newTestErrorPages(t *testing.T) *ErrorPages {
+	tmpl, err := template.ParseFiles(filepath.Join("..", "..", "..", "web", "templates", "error.html"))
+	require.NoError(t, err)
+
+	return NewErrorPages(tmpl, ErrorPageBranding{Brand: "Shortener", HomeURL: "/"}).
+		WithDomain("go.acme.com", ErrorPageBranding{Brand: "Acme Links", HomeURL: "https://acme.com"}).
+		WithDomain("l.globex.io", ErrorPageBranding{Brand: "Globex", HomeURL: "https://globex.io", Redirect: true})
+}
+
+func TestPrefersHTML(t *testing.T) {
+	tests := []struct {
+		accept   string
+		expected bool
+	}{
+		{accept: browserAccept, expected: true},
+		{accept: "application/json", expected: false},
+		{accept: "*/*", expected: false},
+		{accept: "", expected: false},
+		{accept: "application/json, text/html;q=0.5", expected: false},
+		{accept: "text/html;q=0.9, application/json;q=0.5", expected: true},
+		{accept: "text/html;q=0", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			req.Header.Set("Accept", tt.accept)
+
+			assert.Equal(t, tt.expected, prefersHTML(req))
+		})
+	}
+}
+
+func TestRedirectURL_ErrorPages(t *testing.T) {
+	tests := []struct {
+		name             string
+		host             string
+		accept           string
+		serviceErr       error
+		expectedStatus   int
+		expectedType     string
+		expectedBody     string
+		expectedLocation string
+	}{
+		{
+			name:           "browser gets the default page",
+			host:           "localhost:8080",
+			accept:         browserAccept,
+			serviceErr:     domain.ErrURLNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedType:   "text/html; charset=utf-8",
+			expectedBody:   "Shortener",
+		},
+		{
+			name:           "custom domain branding",
+			host:           "GO.ACME.COM",
+			accept:         browserAccept,
+			serviceErr:     domain.ErrURLNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedType:   "text/html; charset=utf-8",
+			expectedBody:   `href="https://acme.com"`,
+		},
+		{
+			name:             "custom domain redirects home",
+			host:             "l.globex.io",
+			accept:           browserAccept,
+			serviceErr:       domain.ErrURLNotFound,
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://globex.io",
+		},
+		{
+			name:           "expired link",
+			host:           "localhost",
+			accept:         browserAccept,
+			serviceErr:     domain.ErrURLExpired,
+			expectedStatus: http.StatusGone,
+			expectedType:   "text/html; charset=utf-8",
+			expectedBody:   "URL has expired",
+		},
+		{
+			name:           "API clients keep JSON",
+			host:           "go.acme.com",
+			accept:         "application/json",
+			serviceErr:     domain.ErrURLNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedType:   "application/json",
+			expectedBody:   `"error":"URL not found"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			handler.WithErrorPages(newTestErrorPages(t))
+			mockService.On("GetURL", mock.Anything, "missing").Return(nil, tt.serviceErr)
+
+			req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+			req.Host = tt.host
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.RedirectURL(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedType != "" {
+				assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+			}
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			if tt.expectedLocation != "" {
+				assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+)
+
+// Exporter is the service the export endpoint needs
+// Implemented by service.ExportService
+type Exporter interface {
+	Export(ctx context.Context, owner string, fn func(*domain.ExportRecord) error) error
+	StreamInventory(ctx context.Context, owner string, after *domain.ExportCursor, fn func(*domain.URL) error) error
+}
+
+const (
+	exportFlushEvery    = 100              // Rows between pushes to the client
+	exportWriteDeadline = 60 * time.Second // Time allowed to write each batch
+)
+
+// exportCSVHeader lists the CSV columns, in order
+var exportCSVHeader = []string{
+	"id", "short_code", "original_url", "custom_alias", "domain", "created_at", "expires_at",
+	"is_active", "clicks", "max_clicks", "unique_visitors", "last_clicked_at",
+}
+
+// ExportHandler serves the data export endpoint
+type ExportHandler struct {
+	exporter Exporter
+	logger   *slog.Logger
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(exporter Exporter, logger *slog.Logger) *ExportHandler {
+	return &ExportHandler{
+		exporter: exporter,
+		logger:   logger,
+	}
+}
+
+// Export handles GET /api/v1/export?format=csv|ndjson
+//
+// Streams every URL the caller owns with its aggregate stats. Admins can
+// export another owner's data with ?owner=...
+//
+// The status code is sent before the first row, so a failure halfway through
+// cannot become a 500. Instead the X-Export-Status TRAILER (sent after the
+// body) says "complete" or "failed" - clients must check it
+func (h *ExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	owner, ok := exportOwner(w, r)
+	if !ok {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+
+	var write func(*v1.ExportRecord) error
+	var flush func() error
+	switch format {
+	case "csv":
+		write, flush = csvExportWriter(w)
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	case "ndjson":
+		write, flush = ndjsonExportWriter(w)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	default:
+		respondError(w, http.StatusBadRequest, "format must be csv or ndjson")
+		return
+	}
+
+	filename := fmt.Sprintf("urls-%s.%s", time.Now().UTC().Format("2006-01-02"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Trailer", "X-Export-Status")
+	w.WriteHeader(http.StatusOK)
+
+	// Big exports outlive the server's WriteTimeout, so the deadline is pushed
+	// forward after every batch; a stalled client still gets cut off
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Now().Add(exportWriteDeadline))
+
+	count := 0
+	err := h.exporter.Export(r.Context(), owner, func(record *domain.ExportRecord) error {
+		if err := write(exportRecord(record)); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			_ = rc.Flush()
+			_ = rc.SetWriteDeadline(time.Now().Add(exportWriteDeadline))
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+
+	if err != nil {
+		h.logger.Error("Export failed", "owner", owner, "rows_written", count, "error", err)
+		w.Header().Set("X-Export-Status", "failed")
+		return
+	}
+
+	h.logger.Info("Export completed", "owner", owner, "rows", count, "format", format)
+	w.Header().Set("X-Export-Status", "complete")
+}
+
+// StreamURLs handles GET /api/v1/urls/stream?after=<cursor>
+//
+// Streams the caller's links as NDJSON, one line per link, oldest first, so
+// sync tools can mirror an inventory of any size without paging. Every line
+// is flushed as soon as it is written and carries a cursor; after a dropped
+// connection, ?after= with the last cursor received picks up from there.
+// Admins can stream another owner's links with ?owner=...
+//
+// Like the export, the status is sent first and the X-Stream-Status trailer
+// says "complete" or "failed".
+func (h *ExportHandler) StreamURLs(w http.ResponseWriter, r *http.Request) {
+	owner, ok := exportOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var after *domain.ExportCursor
+	if raw := r.URL.Query().Get("after"); raw != "" {
+		cursor, err := domain.ParseExportCursor(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "after must be a cursor from a previous stream")
+			return
+		}
+		after = cursor
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Trailer", "X-Stream-Status")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+	count := 0
+	err := h.exporter.StreamInventory(r.Context(), owner, after, func(url *domain.URL) error {
+		_ = rc.SetWriteDeadline(time.Now().Add(exportWriteDeadline))
+		if err := encoder.Encode(inventoryURL(url)); err != nil {
+			return err
+		}
+		count++
+		// A flush per line: the client can process (and resume) as it reads
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	})
+
+	if err != nil {
+		h.logger.Error("URL stream failed", "owner", owner, "rows_written", count, "error", err)
+		w.Header().Set("X-Stream-Status", "failed")
+		return
+	}
+
+	h.logger.Info("URL stream completed", "owner", owner, "rows", count, "resumed", after != nil)
+	w.Header().Set("X-Stream-Status", "complete")
+}
+
+// exportOwner returns whose data the caller asked for: their own, or with
+// ?owner= another owner's (admins only)
+func exportOwner(w http.ResponseWriter, r *http.Request) (string, bool) {
+	principal := auth.FromContext(r.Context())
+	owner := principal.ID
+	if requested := r.URL.Query().Get("owner"); requested != "" && requested != owner {
+		if !principal.Admin {
+			respondError(w, http.StatusForbidden, "Only admins can export other owners")
+			return "", false
+		}
+		owner = requested
+	}
+	return owner, true
+}
+
+// csvExportWriter writes the header, then one CSV line per record
+func csvExportWriter(out io.Writer) (func(*v1.ExportRecord) error, func() error) {
+	writer := csv.NewWriter(out)
+	headerWritten := false
+
+	write := func(record *v1.ExportRecord) error {
+		if !headerWritten {
+			headerWritten = true
+			if err := writer.Write(exportCSVHeader); err != nil {
+				return err
+			}
+		}
+		return writer.Write([]string{
+			record.ID,
+			record.ShortCode,
+			record.OriginalURL,
+			stringOrEmpty(record.CustomAlias),
+			record.Domain,
+			record.CreatedAt.UTC().Format(time.RFC3339),
+			timeOrEmpty(record.ExpiresAt),
+			strconv.FormatBool(record.IsActive),
+			strconv.FormatInt(record.Clicks, 10),
+			intOrEmpty(record.MaxClicks),
+			strconv.FormatInt(record.UniqueVisitors, 10),
+			timeOrEmpty(record.LastClickedAt),
+		})
+	}
+	flush := func() error {
+		// An owner without URLs still gets a file with the header
+		if !headerWritten {
+			headerWritten = true
+			if err := writer.Write(exportCSVHeader); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+	return write, flush
+}
+
+// ndjsonExportWriter writes one JSON object per line
+func ndjsonExportWriter(out io.Writer) (func(*v1.ExportRecord) error, func() error) {
+	encoder := json.NewEncoder(out) // Encode appends the newline for us
+	write := func(record *v1.ExportRecord) error {
+		return encoder.Encode(record)
+	}
+	flush := func() error { return nil }
+	return write, flush
+}
+
+// exportRecord converts a record to its API representation
+func exportRecord(record *domain.ExportRecord) *v1.ExportRecord {
+	url := record.URL
+	return &v1.ExportRecord{
+		ID:             url.ID,
+		ShortCode:      url.ShortCode,
+		OriginalURL:    url.OriginalURL,
+		CustomAlias:    url.CustomAlias,
+		Domain:         url.Domain,
+		CreatedAt:      url.CreatedAt,
+		ExpiresAt:      url.ExpiresAt,
+		IsActive:       url.IsActive,
+		Clicks:         url.Clicks,
+		MaxClicks:      url.MaxClicks,
+		UniqueVisitors: record.UniqueVisitors,
+		LastClickedAt:  record.LastClickedAt,
+		Metadata:       linkMetadata(url.Metadata),
+		Description:    url.Description,
+		CustomMetadata: url.CustomMetadata,
+	}
+}
+
+// inventoryURL converts a link to a line of the URL stream
+func inventoryURL(url *domain.URL) *v1.InventoryURL {
+	return &v1.InventoryURL{
+		Cursor:      domain.CursorAfterURL(url).String(),
+		ID:          url.ID,
+		ShortCode:   url.ShortCode,
+		OriginalURL: url.OriginalURL,
+		CustomAlias: url.CustomAlias,
+		Domain:      url.Domain,
+		CreatedAt:   url.CreatedAt,
+		ExpiresAt:   url.ExpiresAt,
+		IsActive:    url.IsActive,
+		Clicks:      url.Clicks,
+		MaxClicks:   url.MaxClicks,
+		Metadata:    linkMetadata(url.Metadata),
+
+		Description:    url.Description,
+		CustomMetadata: url.CustomMetadata,
+	}
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func timeOrEmpty(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func intOrEmpty(n *int64) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.FormatInt(*n, 10)
+}
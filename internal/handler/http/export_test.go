@@ -0,0 +1,185 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockExporter is a mock implementation of Exporter that replays fixed records
+type MockExporter struct {
+	mock.Mock
+	records []*domain.ExportRecord
+}
+
+func (m *MockExporter) Export(ctx context.Context, owner string, fn func(*domain.ExportRecord) error) error {
+	args := m.Called(ctx, owner)
+	for _, record := range m.records {
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return args.Error(0)
+}
+
+func (m *MockExporter) StreamInventory(ctx context.Context, owner string, after *domain.ExportCursor, fn func(*domain.URL) error) error {
+	args := m.Called(ctx, owner, after)
+	for _, record := range m.records {
+		if err := fn(record.URL); err != nil {
+			return err
+		}
+	}
+	return args.Error(0)
+}
+
+func setupExportHandler(records ...*domain.ExportRecord) (*ExportHandler, *MockExporter) {
+	mockExporter := &MockExporter{records: records}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewExportHandler(mockExporter, logger), mockExporter
+}
+
+func exportRequest(target string, principal *auth.Principal) *http.Request {
+	req := httptest.NewRequest("GET", target, nil)
+	return req.WithContext(auth.WithPrincipal(req.Context(), principal))
+}
+
+func TestExport_Formats(t *testing.T) {
+	alias := "summer"
+	record := &domain.ExportRecord{
+		URL: &domain.URL{
+			ID:          "id-1",
+			ShortCode:   "summer",
+			OriginalURL: "https://example.com/sale",
+			CustomAlias: &alias,
+			CreatedAt:   time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+			Clicks:      42,
+			IsActive:    true,
+		},
+		UniqueVisitors: 30,
+	}
+
+	tests := []struct {
+		name         string
+		format       string
+		contentType  string
+		expectedBody string
+	}{
+		{
+			name:         "csv by default",
+			format:       "",
+			contentType:  "text/csv; charset=utf-8",
+			expectedBody: "id,short_code,original_url,custom_alias,domain,created_at,expires_at,is_active,clicks,max_clicks,unique_visitors,last_clicked_at\nid-1,summer,https://example.com/sale,summer,,2024-06-01T12:00:00Z,,true,42,,30,\n",
+		},
+		{
+			name:         "ndjson",
+			format:       "ndjson",
+			contentType:  "application/x-ndjson",
+			expectedBody: `{"id":"id-1","short_code":"summer","original_url":"https://example.com/sale","custom_alias":"summer","created_at":"2024-06-01T12:00:00Z","is_active":true,"clicks":42,"unique_visitors":30}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockExporter := setupExportHandler(record)
+			mockExporter.On("Export", mock.Anything, "user1").Return(nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.Export(w, exportRequest("/api/v1/export?format="+tt.format, &auth.Principal{ID: "user1"}))
+
+			// Assert
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, "complete", w.Result().Trailer.Get("X-Export-Status"))
+		})
+	}
+}
+
+func TestExport_OtherOwnerRequiresAdmin(t *testing.T) {
+	// Arrange
+	handler, mockExporter := setupExportHandler()
+	mockExporter.On("Export", mock.Anything, "user2").Return(nil)
+
+	// Act
+	forbidden := httptest.NewRecorder()
+	handler.Export(forbidden, exportRequest("/api/v1/export?owner=user2", &auth.Principal{ID: "user1"}))
+
+	allowed := httptest.NewRecorder()
+	handler.Export(allowed, exportRequest("/api/v1/export?owner=user2", &auth.Principal{ID: "admin", Admin: true}))
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, forbidden.Code)
+	assert.Equal(t, http.StatusOK, allowed.Code)
+	assert.True(t, strings.HasPrefix(allowed.Body.String(), "id,short_code"), "empty export still has a header")
+	mockExporter.AssertNumberOfCalls(t, "Export", 1)
+}
+
+func TestExport_RequiresAuthentication(t *testing.T) {
+	// Arrange
+	handler, _ := setupExportHandler()
+	protected := RequireAuth(handler.Export)
+	w := httptest.NewRecorder()
+
+	// Act
+	protected(w, httptest.NewRequest("GET", "/api/v1/export", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestStreamURLs(t *testing.T) {
+	// Arrange
+	created := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	records := []*domain.ExportRecord{
+		{URL: &domain.URL{ID: "id-1", ShortCode: "abc", OriginalURL: "https://example.com/a", CreatedAt: created, IsActive: true}},
+		{URL: &domain.URL{ID: "id-2", ShortCode: "def", OriginalURL: "https://example.com/b", CreatedAt: created, Clicks: 3}},
+	}
+	handler, mockExporter := setupExportHandler(records...)
+	resumeAt := domain.CursorAfterURL(records[0].URL)
+	mockExporter.On("StreamInventory", mock.Anything, "user1", (*domain.ExportCursor)(nil)).Return(nil).Once()
+	mockExporter.On("StreamInventory", mock.Anything, "user1", resumeAt).Return(nil).Once()
+
+	// Act
+	w := httptest.NewRecorder()
+	handler.StreamURLs(w, exportRequest("/api/v1/urls/stream", &auth.Principal{ID: "user1"}))
+	resumed := httptest.NewRecorder()
+	handler.StreamURLs(resumed, exportRequest("/api/v1/urls/stream?after="+resumeAt.String(), &auth.Principal{ID: "user1"}))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, `{"cursor":"`+resumeAt.String()+`","id":"id-1","short_code":"abc","original_url":"https://example.com/a","created_at":"2024-06-01T12:00:00Z","is_active":true,"clicks":0}`, lines[0])
+	assert.True(t, w.Flushed, "every line is flushed right away")
+	assert.Equal(t, "complete", w.Result().Trailer.Get("X-Stream-Status"))
+	assert.Equal(t, http.StatusOK, resumed.Code)
+	mockExporter.AssertExpectations(t)
+}
+
+func TestStreamURLs_InvalidCursor(t *testing.T) {
+	// Arrange
+	handler, mockExporter := setupExportHandler()
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.StreamURLs(w, exportRequest("/api/v1/urls/stream?after=not-a-cursor", &auth.Principal{ID: "user1"}))
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockExporter.AssertNotCalled(t, "StreamInventory", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/featureflags"
+)
+
+// FlagChecker answers whether a feature is on for a workspace
+// Implemented by featureflags.Flags
+type FlagChecker interface {
+	Enabled(name, workspace string) bool
+}
+
+// WithFeatureFlags lets features be switched at runtime
+func (h *Handler) WithFeatureFlags(flags FlagChecker) *Handler {
+	h.flags = flags
+	return h
+}
+
+// featureEnabled checks a flag for the workspace that owns a link
+// Without flags every known flag keeps its default
+func (h *Handler) featureEnabled(name, workspace string) bool {
+	if h.flags == nil {
+		return featureflags.Defaults[name]
+	}
+	return h.flags.Enabled(name, workspace)
+}
+
+// FlagManager is what the feature flag admin API needs
+// Implemented by featureflags.Flags
+type FlagManager interface {
+	List(ctx context.Context) ([]featureflags.Flag, error)
+	Set(ctx context.Context, flag *featureflags.Flag) error
+	Delete(ctx context.Context, name string) error
+}
+
+// FeatureFlagHandler serves the feature flag admin API
+type FeatureFlagHandler struct {
+	flags  FlagManager
+	logger *slog.Logger
+}
+
+// NewFeatureFlagHandler creates a new feature flag handler
+func NewFeatureFlagHandler(flags FlagManager, logger *slog.Logger) *FeatureFlagHandler {
+	return &FeatureFlagHandler{flags: flags, logger: logger}
+}
+
+// ListFlags handles GET /api/v1/flags?limit=&offset= (admin only)
+func (h *FeatureFlagHandler) ListFlags(w http.ResponseWriter, r *http.Request) {
+	window, err := parsePageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	flags, err := h.flags.List(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to list feature flags", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to list feature flags")
+		return
+	}
+
+	response := make([]v1.FeatureFlagResponse, 0, len(flags))
+	for _, flag := range flags {
+		response = append(response, featureFlagResponse(flag))
+	}
+	items, pagination := paginate(response, window)
+	respondList(w, items, pagination)
+}
+
+// PutFlag handles PUT /api/v1/flags/{name} (admin only)
+// The change applies to every instance within seconds, without a restart
+func (h *FeatureFlagHandler) PutFlag(w http.ResponseWriter, r *http.Request) {
+	var req v1.FeatureFlagRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	flag := &featureflags.Flag{
+		Name:        r.PathValue("name"),
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		Workspaces:  req.Workspaces,
+	}
+	if err := h.flags.Set(r.Context(), flag); err != nil {
+		if errors.Is(err, featureflags.ErrInvalidName) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.Error("Failed to save feature flag", "flag", flag.Name, "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to save feature flag")
+		return
+	}
+
+	h.logger.Info("Feature flag changed", "flag", flag.Name, "enabled", flag.Enabled, "overrides", len(flag.Workspaces))
+	respondSuccess(w, http.StatusOK, featureFlagResponse(*flag), "Feature flag saved")
+}
+
+// DeleteFlag handles DELETE /api/v1/flags/{name} (admin only)
+// A known flag goes back to its default state
+func (h *FeatureFlagHandler) DeleteFlag(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := h.flags.Delete(r.Context(), name); err != nil {
+		if errors.Is(err, featureflags.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "Feature flag not found")
+			return
+		}
+		h.logger.Error("Failed to delete feature flag", "flag", name, "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to delete feature flag")
+		return
+	}
+
+	h.logger.Info("Feature flag reset", "flag", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// featureFlagResponse converts a flag to its API representation
+func featureFlagResponse(flag featureflags.Flag) v1.FeatureFlagResponse {
+	response := v1.FeatureFlagResponse{
+		Name:        flag.Name,
+		Description: flag.Description,
+		Enabled:     flag.Enabled,
+		Workspaces:  flag.Workspaces,
+	}
+	if !flag.UpdatedAt.IsZero() {
+		response.UpdatedAt = &flag.UpdatedAt
+	}
+	return response
+}
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/featureflags"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockFlagManager is a mock implementation of FlagManager and FlagChecker
+type MockFlagManager struct {
+	mock.Mock
+}
+
+func (m *MockFlagManager) Enabled(name, workspace string) bool {
+	args := m.Called(name, workspace)
+	return args.Bool(0)
+}
+
+func (m *MockFlagManager) List(ctx context.Context) ([]featureflags.Flag, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]featureflags.Flag), args.Error(1)
+}
+
+func (m *MockFlagManager) Set(ctx context.Context, flag *featureflags.Flag) error {
+	args := m.Called(ctx, flag)
+	return args.Error(0)
+}
+
+func (m *MockFlagManager) Delete(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func newTestFlagHandler() (*FeatureFlagHandler, *MockFlagManager) {
+	flags := new(MockFlagManager)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewFeatureFlagHandler(flags, logger), flags
+}
+
+func TestListFlags(t *testing.T) {
+	// Arrange
+	handler, flags := newTestFlagHandler()
+	updated := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	flags.On("List", mock.Anything).Return([]featureflags.Flag{
+		{Name: "preview-pages", Enabled: true},
+		{Name: "unambiguous-codes", Workspaces: map[string]bool{"acme": true}, UpdatedAt: updated},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/flags", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ListFlags(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data, 2)
+	assert.Equal(t, true, response.Data[0]["enabled"])
+	assert.NotContains(t, response.Data[0], "updated_at", "flags at their default were never updated")
+	assert.Equal(t, map[string]interface{}{"acme": true}, response.Data[1]["workspaces"])
+}
+
+func TestPutFlag(t *testing.T) {
+	tests := []struct {
+		name           string
+		flag           string
+		body           string
+		setErr         error
+		expectedStatus int
+	}{
+		{name: "saved", flag: "preview-pages", body: `{"enabled":false,"workspaces":{"acme":true}}`, expectedStatus: http.StatusOK},
+		{name: "invalid body", flag: "preview-pages", body: `{`, expectedStatus: http.StatusBadRequest},
+		{name: "invalid name", flag: "Preview_Pages", body: `{"enabled":true}`, setErr: featureflags.ErrInvalidName, expectedStatus: http.StatusBadRequest},
+		{name: "store down", flag: "preview-pages", body: `{"enabled":true}`, setErr: assert.AnError, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, flags := newTestFlagHandler()
+			flags.On("Set", mock.Anything, mock.MatchedBy(func(f *featureflags.Flag) bool {
+				return f.Name == tt.flag
+			})).Return(tt.setErr)
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/flags/"+tt.flag, bytes.NewBufferString(tt.body))
+			req.SetPathValue("name", tt.flag)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.PutFlag(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				flags.AssertCalled(t, "Set", mock.Anything, &featureflags.Flag{
+					Name:       "preview-pages",
+					Workspaces: map[string]bool{"acme": true},
+				})
+			}
+		})
+	}
+}
+
+func TestDeleteFlag(t *testing.T) {
+	tests := []struct {
+		name           string
+		deleteErr      error
+		expectedStatus int
+	}{
+		{name: "reset", expectedStatus: http.StatusNoContent},
+		{name: "not stored", deleteErr: featureflags.ErrNotFound, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, flags := newTestFlagHandler()
+			flags.On("Delete", mock.Anything, "preview-pages").Return(tt.deleteErr)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/flags/preview-pages", nil)
+			req.SetPathValue("name", "preview-pages")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.DeleteFlag(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRedirectURL_PreviewPagesFlagOff(t *testing.T) {
+	// Arrange: the owner's workspace has preview pages switched off
+	handler, mockService := setupTestHandler()
+	flags := new(MockFlagManager)
+	handler.WithPreviewCards(newTestPreviewTemplate(t)).WithFeatureFlags(flags)
+	flags.On("Enabled", featureflags.PreviewPages, "acme").Return(false)
+
+	url := &domain.URL{
+		ID:          "123",
+		ShortCode:   "abc123",
+		OriginalURL: "https://example.com",
+		CreatedBy:   "acme",
+		IsActive:    true,
+		Preview:     &domain.PreviewCard{Title: "Spring Sale"},
+	}
+	mockService.On("GetURL", mock.Anything, "abc123").Return(url, nil)
+	mockService.On("RecordClick", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Header.Set("User-Agent", twitterbotUA)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.RedirectURL(w, req)
+
+	// Assert: the bot gets the plain redirect
+	assert.Equal(t, http.StatusFound, w.Code)
+	flags.AssertExpectations(t)
+}
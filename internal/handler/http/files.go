@@ -0,0 +1,194 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/storage/blob"
+)
+
+// File upload limits
+const (
+	// multipartMemory is how much of an upload is kept in memory while
+	// parsing; the rest spills to a temporary file
+	multipartMemory = 8 << 20
+
+	// multipartOverhead is what the form adds on top of the file itself
+	// (boundaries, part headers, the other fields)
+	multipartOverhead = 1 << 20
+)
+
+// inlineContentTypes are shown in the browser; everything else downloads
+// Only types a browser can't run: no HTML, no SVG (it may hold scripts)
+var inlineContentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/gif":       true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+	"text/plain":      true,
+	"audio/mpeg":      true,
+	"video/mp4":       true,
+}
+
+// FileManager is what file links need
+// Implemented by service.FileService
+type FileManager interface {
+	Upload(ctx context.Context, name, contentType string, body io.Reader, size int64, expiresIn time.Duration) (*domain.URL, error)
+	Open(ctx context.Context, url *domain.URL) (*blob.Object, error)
+	MaxSize() int64
+}
+
+// WithFiles enables file links
+func (h *Handler) WithFiles(files FileManager) *Handler {
+	h.files = files
+	return h
+}
+
+// UploadFile handles POST /api/v1/files (authenticated)
+//
+// The request is multipart/form-data with the file in the "file" field and
+// an optional "expires_in_hours":
+//
+//	curl -F file=@report.pdf -F expires_in_hours=24 .../api/v1/files
+func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
+	// Cut off oversized uploads while reading instead of after storing them
+	maxSize := h.files.MaxSize()
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartOverhead)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, fileTooLargeMessage(maxSize))
+			return
+		}
+		respondError(w, http.StatusBadRequest, `Expected a multipart/form-data upload with a "file" field`)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, `Expected a multipart/form-data upload with a "file" field`)
+		return
+	}
+	defer file.Close()
+
+	var expiresIn time.Duration
+	if value := r.FormValue("expires_in_hours"); value != "" {
+		hours, err := strconv.Atoi(value)
+		if err != nil || hours < 0 {
+			respondError(w, http.StatusBadRequest, "expires_in_hours must be a whole number of hours")
+			return
+		}
+		expiresIn = time.Duration(hours) * time.Hour
+	}
+
+	url, err := h.files.Upload(r.Context(), header.Filename, header.Header.Get("Content-Type"), file, header.Size, expiresIn)
+	if err != nil {
+		status := createErrorStatus(err)
+		switch status {
+		case http.StatusRequestEntityTooLarge:
+			respondError(w, status, fileTooLargeMessage(maxSize))
+		case http.StatusInternalServerError:
+			h.logger.Error("Failed to upload file", "error", err)
+			respondError(w, status, "Failed to upload file")
+		default:
+			respondError(w, status, err.Error())
+		}
+		return
+	}
+
+	respondSuccess(w, http.StatusCreated, v1.CreateFileResponse{
+		ID:          url.ID,
+		ShortCode:   url.ShortCode,
+		ShortURL:    h.shortURL(url),
+		Name:        url.File.Name,
+		ContentType: url.File.ContentType,
+		Size:        url.File.Size,
+		CreatedAt:   url.CreatedAt,
+		ExpiresAt:   url.ExpiresAt,
+	}, "File uploaded")
+}
+
+// serveFile answers the short link of a file link with the file itself
+//
+// WHY ALL THE HEADERS?
+// Anybody can upload anything, and it's served from OUR domain. A file the
+// browser runs (HTML, SVG, ...) could read cookies or fake a login page.
+// nosniff stops the browser from guessing a runnable type, the sandbox CSP
+// takes scripts away from whatever does get rendered, and only harmless
+// types are shown inline - the rest downloads.
+func (h *Handler) serveFile(w http.ResponseWriter, r *http.Request, url *domain.URL) {
+	if h.files == nil {
+		h.respondLinkError(w, r, http.StatusServiceUnavailable, "This link can't be opened", domain.ErrFilesUnavailable.Error())
+		return
+	}
+
+	object, err := h.files.Open(r.Context(), url)
+	if err != nil {
+		if errors.Is(err, domain.ErrURLNotFound) {
+			h.logger.Warn("File of link is missing", "short_code", url.ShortCode, "error", err)
+			h.respondLinkError(w, r, http.StatusNotFound, "Link not found", "URL not found")
+			return
+		}
+		h.logger.Error("Failed to open file", "short_code", url.ShortCode, "error", err)
+		respondError(w, http.StatusServiceUnavailable, "Service temporarily unavailable, please retry")
+		return
+	}
+	defer object.Body.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", url.File.ContentType)
+	header.Set("Content-Disposition", contentDisposition(url.File))
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Security-Policy", "sandbox")
+	// Every download must reach us: it's counted, and the link may expire
+	header.Set("Cache-Control", "no-store")
+	if object.Size >= 0 {
+		header.Set("Content-Length", strconv.FormatInt(object.Size, 10))
+	}
+
+	// HEAD (link checkers, download managers) only asks about the file
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	h.recordClick(r, url.ShortCode)
+	w.WriteHeader(http.StatusOK)
+
+	// Streamed: the file is never held in memory as a whole
+	if _, err := io.Copy(w, object.Body); err != nil {
+		// Usually the visitor went away; the status line is long gone anyway
+		h.logger.Warn("File download interrupted", "short_code", url.ShortCode, "error", err)
+	}
+}
+
+// contentDisposition returns "inline" or "attachment" with the file's name
+func contentDisposition(file *domain.FileAttachment) string {
+	disposition := "attachment"
+	if mediaType, _, err := mime.ParseMediaType(file.ContentType); err == nil && inlineContentTypes[strings.ToLower(mediaType)] {
+		disposition = "inline"
+	}
+
+	// FormatMediaType quotes the name (and encodes non-ASCII names); it
+	// returns "" for names it can't represent - the browser then picks one
+	if value := mime.FormatMediaType(disposition, map[string]string{"filename": file.Name}); value != "" {
+		return value
+	}
+	return disposition
+}
+
+// fileTooLargeMessage tells the client the upload limit
+func fileTooLargeMessage(maxSize int64) string {
+	return fmt.Sprintf("File is too large - the limit is %d MB", maxSize>>20)
+}
@@ -0,0 +1,272 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/storage/blob"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockFileManager is a mock implementation of FileManager
+type MockFileManager struct {
+	mock.Mock
+}
+
+func (m *MockFileManager) Upload(ctx context.Context, name, contentType string, body io.Reader, size int64, expiresIn time.Duration) (*domain.URL, error) {
+	args := m.Called(ctx, name, contentType, body, size, expiresIn)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockFileManager) Open(ctx context.Context, url *domain.URL) (*blob.Object, error) {
+	args := m.Called(ctx, url)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*blob.Object), args.Error(1)
+}
+
+func (m *MockFileManager) MaxSize() int64 {
+	return 25 << 20
+}
+
+// multipartUpload builds a multipart/form-data request body
+// An empty fileName leaves out the file field.
+func multipartUpload(t *testing.T, fileName, content string, fields map[string]string) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	if fileName != "" {
+		part, err := writer.CreateFormFile("file", fileName)
+		require.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return &body, writer.FormDataContentType()
+}
+
+func TestUploadFile(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileName       string
+		fields         map[string]string
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "uploaded",
+			fileName:       "report.pdf",
+			fields:         map[string]string{"expires_in_hours": "24"},
+			expectCall:     true,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"short_url":"http://localhost:8080/f1le","name":"report.pdf"`,
+		},
+		{
+			name:           "no file",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `\"file\" field`,
+		},
+		{
+			name:           "bad expiry",
+			fileName:       "report.pdf",
+			fields:         map[string]string{"expires_in_hours": "soon"},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "expires_in_hours",
+		},
+		{
+			name:           "too large",
+			fileName:       "report.pdf",
+			fields:         map[string]string{"expires_in_hours": "24"},
+			serviceErr:     domain.ErrFileTooLarge,
+			expectCall:     true,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   "the limit is 25 MB",
+		},
+		{
+			name:           "quota exceeded",
+			fileName:       "report.pdf",
+			fields:         map[string]string{"expires_in_hours": "24"},
+			serviceErr:     domain.ErrQuotaExceeded,
+			expectCall:     true,
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:           "storage down",
+			fileName:       "report.pdf",
+			fields:         map[string]string{"expires_in_hours": "24"},
+			serviceErr:     assert.AnError,
+			expectCall:     true,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Failed to upload file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, _ := setupTestHandler()
+			files := new(MockFileManager)
+			handler.WithFiles(files)
+			if tt.expectCall {
+				var url *domain.URL
+				if tt.serviceErr == nil {
+					url = &domain.URL{ID: "1", ShortCode: "f1le", File: &domain.FileAttachment{
+						Key: "files/ab12", Name: "report.pdf", ContentType: "application/octet-stream", Size: 8,
+					}}
+				}
+				files.On("Upload", mock.Anything, "report.pdf", "application/octet-stream", mock.Anything, int64(8), 24*time.Hour).
+					Return(url, tt.serviceErr)
+			}
+			body, contentType := multipartUpload(t, tt.fileName, "%PDF-1.7", tt.fields)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/files", body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.UploadFile(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			files.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRedirectURL_File(t *testing.T) {
+	tests := []struct {
+		name                string
+		method              string
+		contentType         string
+		expectedDisposition string
+		expectedBody        string
+		expectClick         bool
+	}{
+		{
+			name:                "pdf opens in the browser",
+			method:              http.MethodGet,
+			contentType:         "application/pdf",
+			expectedDisposition: `inline; filename=report.pdf`,
+			expectedBody:        "%PDF-1.7",
+			expectClick:         true,
+		},
+		{
+			name:                "html downloads",
+			method:              http.MethodGet,
+			contentType:         "text/html",
+			expectedDisposition: `attachment; filename=report.pdf`,
+			expectedBody:        "%PDF-1.7",
+			expectClick:         true,
+		},
+		{
+			name:                "HEAD is not a download",
+			method:              http.MethodHead,
+			contentType:         "application/pdf",
+			expectedDisposition: `inline; filename=report.pdf`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			files := new(MockFileManager)
+			handler.WithFiles(files)
+			url := &domain.URL{ShortCode: "f1le", IsActive: true, File: &domain.FileAttachment{
+				Key: "files/ab12", Name: "report.pdf", ContentType: tt.contentType, Size: 8,
+			}}
+			mockService.On("GetURL", mock.Anything, "f1le").Return(url, nil)
+			files.On("Open", mock.Anything, url).Return(&blob.Object{Body: io.NopCloser(strings.NewReader("%PDF-1.7")), Size: 8}, nil)
+
+			// RecordClick runs in a goroutine, so signal when it has been called
+			clickRecorded := make(chan struct{})
+			mockService.On("RecordClick", mock.Anything, clickOn("f1le")).
+				Run(func(args mock.Arguments) { close(clickRecorded) }).
+				Return(nil).Maybe()
+
+			req := httptest.NewRequest(tt.method, "/f1le", nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.RedirectURL(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedDisposition, w.Header().Get("Content-Disposition"))
+			assert.Equal(t, "8", w.Header().Get("Content-Length"))
+			assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+			assert.Equal(t, "sandbox", w.Header().Get("Content-Security-Policy"))
+			assert.Empty(t, w.Header().Get("Location"))
+
+			if tt.expectClick {
+				select {
+				case <-clickRecorded:
+				case <-time.After(time.Second):
+					t.Fatal("download was not counted")
+				}
+			} else {
+				mockService.AssertNotCalled(t, "RecordClick", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRedirectURL_FileUnavailable(t *testing.T) {
+	tests := []struct {
+		name           string
+		openErr        error
+		configured     bool
+		expectedStatus int
+	}{
+		{name: "file deleted from the bucket", openErr: domain.ErrURLNotFound, configured: true, expectedStatus: http.StatusNotFound},
+		{name: "storage down", openErr: assert.AnError, configured: true, expectedStatus: http.StatusServiceUnavailable},
+		{name: "files not configured", expectedStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			url := &domain.URL{ShortCode: "f1le", IsActive: true, File: &domain.FileAttachment{Key: "files/ab12", Name: "report.pdf", Size: 8}}
+			mockService.On("GetURL", mock.Anything, "f1le").Return(url, nil)
+			if tt.configured {
+				files := new(MockFileManager)
+				files.On("Open", mock.Anything, url).Return(nil, tt.openErr)
+				handler.WithFiles(files)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/f1le", nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.RedirectURL(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Empty(t, w.Header().Get("Location"))
+			mockService.AssertNotCalled(t, "RecordClick", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -2,33 +2,75 @@ package http
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/auth"
 	"url-shortener/internal/domain"
 	"url-shortener/internal/metrics"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/resilience"
+	"url-shortener/internal/startup"
 )
 
 // URLService interface defines the service methods needed by the handler
 // Using an interface instead of concrete type allows for easy mocking in tests
 type URLService interface {
-	CreateShortURL(ctx context.Context, originalURL, customAlias, createdBy string, expiresIn time.Duration) (*domain.URL, error)
+	CreateShortURL(ctx context.Context, originalURL, customAlias, createdBy string, expiresIn time.Duration, opts ...domain.URLOption) (*domain.URL, error)
+	ValidateShortURL(ctx context.Context, originalURL, customAlias, createdBy string, expiresIn time.Duration, opts ...domain.URLOption) (*domain.CreationPreview, error)
 	GetURL(ctx context.Context, shortCode string) (*domain.URL, error)
-	RecordClick(ctx context.Context, shortCode, ipAddress, userAgent, referer string) error
+	RecordClick(ctx context.Context, click domain.ClickContext) error
 	GetURLStats(ctx context.Context, shortCode string) (*domain.URL, []*domain.URLClick, error)
+	GetClickSummary(ctx context.Context, shortCode, timezone string) (*domain.ClickSummary, error)
+	GetClickTimeseries(ctx context.Context, shortCode string, query domain.TimeseriesQuery) (*domain.ClickTimeseries, error)
+	GetClickHeatmap(ctx context.Context, shortCode string, query domain.TimeseriesQuery) (*domain.ClickHeatmap, error)
+	TraceRedirects(ctx context.Context, shortCode string) (*domain.RedirectTrace, error)
 	DeleteURL(ctx context.Context, id string) error
+	RestoreURL(ctx context.Context, id string) (*domain.URL, error)
+	PurgeURL(ctx context.Context, id string) (int64, error)
+	SuggestAliases(ctx context.Context, destination, keyword string, limit int) ([]string, error)
+	CheckAliasAvailability(ctx context.Context, alias string) (domain.AliasStatus, error)
+	UpsertURL(ctx context.Context, alias, originalURL string, cond domain.Precondition, opts ...domain.URLOption) (*domain.URL, domain.UpsertOutcome, error)
+	SetPreview(ctx context.Context, id string, card *domain.PreviewCard) (*domain.URL, error)
+	SetNotes(ctx context.Context, id, description string, metadata map[string]string) (*domain.URL, error)
+	SetVisibility(ctx context.Context, id string, visibility domain.LinkVisibility) (*domain.URL, error)
+	CloneURL(ctx context.Context, id, originalURL, customAlias string) (*domain.URL, error)
+	SignURL(ctx context.Context, id string, expiresIn time.Duration) (*domain.SignedLink, error)
+	UseOnce(ctx context.Context, url *domain.URL) error
 }
 
 // Handler holds dependencies for HTTP handlers
 // This is DEPENDENCY INJECTION - we pass dependencies through the constructor
 // instead of using global variables or creating them inside handlers
 type Handler struct {
-	urlService URLService
-	logger     *slog.Logger
-	baseURL    string // Base URL for generating short URLs (e.g., "http://localhost:8080")
+	urlService  URLService
+	logger      *slog.Logger
+	baseURL     string             // Base URL for generating short URLs (e.g., "http://localhost:8080")
+	quotas      UsageReporter      // Optional: adds X-Quota-* headers to create responses
+	errorPages  *ErrorPages        // Optional: branded HTML instead of JSON errors for browsers
+	previewTmpl *template.Template // Optional: preview card page for link preview bots
+	uiTmpl      *template.Template // Optional: hosted UI rendered in the visitor's language
+	scheduleTZ  *time.Location     // Timezone of schedules that don't set their own
+	now         func() time.Time   // Clock for schedules (tests pin it)
+	edgeTTL     time.Duration      // Optional: how long a CDN may cache redirects (0 = not at all)
+	flags       FlagChecker        // Optional: runtime feature flags (nil = every flag at its default)
+	captcha     CaptchaVerifier    // Optional: anonymous creates need a solved CAPTCHA
+	secrets     SecretManager      // Optional: burn-after-reading links (see WithSecrets)
+	secretTmpl  *template.Template // Optional: reveal page of burn-after-reading links
+	files       FileManager        // Optional: file links (see WithFiles)
+	pasteTmpl   *template.Template // Optional: page showing paste links to browsers
+	domains     DomainGate         // Optional: custom domains only serve links once verified
+	clicks      *startup.Inflight  // Clicks still being recorded, drained at shutdown
+
+	analyticsOff bool // ENABLE_ANALYTICS=false: stats explain the empty click list (see WithAnalytics)
 }
 
 // NewHandler creates a new HTTP handler
@@ -37,44 +79,45 @@ func NewHandler(urlService URLService, logger *slog.Logger, baseURL string) *Han
 		urlService: urlService,
 		logger:     logger,
 		baseURL:    baseURL,
+		scheduleTZ: time.Local,
+		now:        time.Now,
+		clicks:     startup.NewInflight("clicks"),
 	}
 }
 
-// Request/Response DTOs (Data Transfer Objects)
-// These are separate from domain models because:
-// 1. API contracts should be stable even if domain models change
-// 2. We might want to expose/hide certain fields
-// 3. We can add API-specific validation
-
-type CreateURLRequest struct {
-	URL            string `json:"url"`
-	CustomAlias    string `json:"custom_alias,omitempty"`
-	ExpiresInHours int    `json:"expires_in_hours,omitempty"`
+// WithQuotas adds the caller's remaining quota to create responses
+func (h *Handler) WithQuotas(quotas UsageReporter) *Handler {
+	h.quotas = quotas
+	return h
 }
 
-type CreateURLResponse struct {
-	ID          string     `json:"id"`
-	ShortCode   string     `json:"short_code"`
-	ShortURL    string     `json:"short_url"`
-	OriginalURL string     `json:"original_url"`
-	CreatedAt   time.Time  `json:"created_at"`
-	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+// WithErrorPages shows browsers a branded page for unknown or dead links
+func (h *Handler) WithErrorPages(pages *ErrorPages) *Handler {
+	h.errorPages = pages
+	return h
 }
 
-type URLStatsResponse struct {
-	ID           string      `json:"id"`
-	ShortCode    string      `json:"short_code"`
-	OriginalURL  string      `json:"original_url"`
-	Clicks       int64       `json:"clicks"`
-	CreatedAt    time.Time   `json:"created_at"`
-	ExpiresAt    *time.Time  `json:"expires_at,omitempty"`
-	RecentClicks []ClickInfo `json:"recent_clicks"`
+// respondLinkError answers a redirect that can't happen
+// Browsers get the branded error page when one is configured, everybody
+// else the JSON error
+func (h *Handler) respondLinkError(w http.ResponseWriter, r *http.Request, status int, title, message string) {
+	if h.errorPages != nil && h.errorPages.Serve(w, r, status, title, message) {
+		return
+	}
+	respondError(w, status, message)
 }
 
-type ClickInfo struct {
-	ClickedAt   time.Time `json:"clicked_at"`
-	CountryCode string    `json:"country_code,omitempty"`
-	City        string    `json:"city,omitempty"`
+// writeQuotaHeaders adds the X-Quota-* headers for the caller (see writeQuotaHeaders)
+func (h *Handler) writeQuotaHeaders(w http.ResponseWriter, r *http.Request) {
+	if h.quotas == nil {
+		return
+	}
+	usage, err := h.quotas.GetUsage(r.Context(), auth.FromContext(r.Context()))
+	if err != nil {
+		h.logger.Warn("Failed to get quota usage", "error", err)
+		return
+	}
+	writeQuotaHeaders(w, usage)
 }
 
 // CreateURL handles POST /api/v1/urls
@@ -85,54 +128,163 @@ func (h *Handler) CreateURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse request body
-	var req CreateURLRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid JSON body")
+	// Parse and validate the request body (rules: validate tags on the DTO)
+	var req v1.CreateURLRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 	defer r.Body.Close()
 
-	// Validate required fields
-	if req.URL == "" {
-		respondError(w, http.StatusBadRequest, "URL is required")
+	if status, message, ok := h.checkCaptcha(r, req.CaptchaToken); !ok {
+		respondError(w, status, message)
 		return
 	}
 
-	// Calculate expiration duration
-	var expiresIn time.Duration
-	if req.ExpiresInHours > 0 {
-		expiresIn = time.Duration(req.ExpiresInHours) * time.Hour
-	}
+	expiresIn, opts := createOptions(req)
 
 	// Call service layer
 	url, err := h.urlService.CreateShortURL(
 		r.Context(),
 		req.URL,
 		req.CustomAlias,
-		"anonymous", // TODO: Get from authentication
+		auth.FromContext(r.Context()).ID, // The caller owns the new URL
 		expiresIn,
+		opts...,
 	)
+	h.writeQuotaHeaders(w, r)
 	if err != nil {
-		h.logger.Error("Failed to create URL", "error", err)
-		respondError(w, http.StatusInternalServerError, err.Error())
+		status := createErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to create URL", "error", err)
+		}
+		respondError(w, status, err.Error())
 		return
 	}
 
-	// Record business metric
-	metrics.RecordURLCreated()
+	respondSuccess(w, http.StatusCreated, createURLResponse(h.baseURL, url), "URL created successfully")
+}
 
-	// Build response
-	response := CreateURLResponse{
-		ID:          url.ID,
-		ShortCode:   url.ShortCode,
-		ShortURL:    fmt.Sprintf("%s/%s", h.baseURL, url.ShortCode),
-		OriginalURL: url.OriginalURL,
-		CreatedAt:   url.CreatedAt,
-		ExpiresAt:   url.ExpiresAt,
+// ValidateURL handles POST /api/v1/urls/validate
+// A dry run of CreateURL: same body, same checks, same errors, but nothing
+// is created (see URLService.ValidateShortURL). No CAPTCHA, so forms can
+// check as the user types: anonymous dry runs never fetch the destination,
+// and the alias-check rate limit applies.
+func (h *Handler) ValidateURL(w http.ResponseWriter, r *http.Request) {
+	var req v1.CreateURLRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	expiresIn, opts := createOptions(req)
+	preview, err := h.urlService.ValidateShortURL(
+		r.Context(),
+		req.URL,
+		req.CustomAlias,
+		auth.FromContext(r.Context()).ID,
+		expiresIn,
+		opts...,
+	)
+	h.writeQuotaHeaders(w, r)
+	if err != nil {
+		status := createErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to validate URL", "error", err)
+		}
+		respondError(w, status, err.Error())
+		return
+	}
+
+	url := preview.URL
+	response := v1.ValidateURLResponse{
+		Valid:          true,
+		ShortCode:      url.ShortCode,
+		OriginalURL:    url.OriginalURL,
+		ResolvedURL:    url.ResolvedURL,
+		Domain:         url.Domain,
+		ExpiresAt:      url.ExpiresAt,
+		Approval:       string(url.Approval),
+		ConfusableWith: preview.Imitates,
+	}
+	if url.ShortCode != "" {
+		response.ShortURL = h.shortURL(url)
+	}
+	if preview.Usage != nil {
+		remaining := preview.Usage.Remaining()
+		response.QuotaRemaining = &remaining
+	}
+	respondSuccess(w, http.StatusOK, response, "URL is valid")
+}
+
+// createOptions turns the optional settings of a create request into the
+// expiration and options CreateShortURL takes
+func createOptions(req v1.CreateURLRequest) (time.Duration, []domain.URLOption) {
+	// Calculate expiration duration
+	var expiresIn time.Duration
+	if req.ExpiresInHours > 0 {
+		expiresIn = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+
+	// Optional settings
+	var opts []domain.URLOption
+	if req.MaxClicks != 0 {
+		opts = append(opts, domain.WithClickLimit(req.MaxClicks))
+	}
+	if req.Domain != "" {
+		opts = append(opts, domain.WithDomain(req.Domain))
+	}
+	if len(req.LanguageTargets) > 0 {
+		opts = append(opts, domain.WithLanguageTargets(req.LanguageTargets))
+	}
+	if req.Schedule != nil {
+		opts = append(opts, domain.WithSchedule(scheduleFromV1(req.Schedule)))
+	}
+	if req.Signed {
+		opts = append(opts, domain.WithSigning())
+	}
+	if req.SingleUse {
+		opts = append(opts, domain.WithSingleUse())
+	}
+	if withoutAnalytics(req.Analytics) {
+		opts = append(opts, domain.WithoutAnalytics())
+	}
+	if req.Description != "" {
+		opts = append(opts, domain.WithDescription(req.Description))
+	}
+	if len(req.CustomMetadata) > 0 {
+		opts = append(opts, domain.WithCustomMetadata(req.CustomMetadata))
+	}
+	if req.Visibility != "" {
+		visibility, _ := domain.ParseLinkVisibility(req.Visibility) // Validated by decodeRequest
+		opts = append(opts, domain.WithVisibility(visibility))
+	}
+	return expiresIn, opts
+}
+
+// CloneURL handles POST /api/v1/urls/{id}/clone
+// The new link gets the source's settings and the destination from the body
+func (h *Handler) CloneURL(w http.ResponseWriter, r *http.Request) {
+	var req v1.CloneURLRequest
+	if !decodeRequest(w, r, &req) {
+		return
 	}
 
-	respondSuccess(w, http.StatusCreated, response, "URL created successfully")
+	url, err := h.urlService.CloneURL(r.Context(), r.PathValue("id"), req.URL, req.CustomAlias)
+	h.writeQuotaHeaders(w, r)
+	if err != nil {
+		if errors.Is(err, domain.ErrURLNotFound) || errors.Is(err, domain.ErrForbidden) {
+			h.respondLookupError(w, "Failed to clone URL", err)
+			return
+		}
+		status := createErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to clone URL", "error", err)
+		}
+		respondError(w, status, err.Error())
+		return
+	}
+
+	respondSuccess(w, http.StatusCreated, createURLResponse(h.baseURL, url), "URL cloned successfully")
 }
 
 // RedirectURL handles GET /{shortCode}
@@ -145,26 +297,88 @@ func (h *Handler) RedirectURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get URL from service
-	url, err := h.urlService.GetURL(r.Context(), shortCode)
+	// A custom domain nobody proved control of must not serve anyone's links
+	if h.domains != nil && !h.domains.Serves(r.Host) {
+		h.respondLinkError(w, r, http.StatusMisdirectedRequest, "This domain isn't set up yet", "Domain is not verified")
+		return
+	}
+
+	// Get URL from service (timed as a redirect lookup, see WithRedirectLookup)
+	url, err := h.urlService.GetURL(repository.WithRedirectLookup(r.Context()), shortCode)
 	if err != nil {
+		// Expired and used-up links existed once - 410 Gone tells clients not to retry
+		if isGone(err) {
+			h.respondLinkError(w, r, http.StatusGone, "This link is no longer available", err.Error())
+			return
+		}
+		// The database is shedding load and the link isn't cached - ask the
+		// client to come back instead of claiming the link doesn't exist
+		var openErr *resilience.OpenError
+		if errors.As(err, &openErr) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(openErr.RetryAfter.Seconds()))))
+			respondError(w, http.StatusServiceUnavailable, "Service temporarily unavailable, please retry")
+			return
+		}
+		// A master key the key manager no longer has is our fault, not the visitor's
+		if errors.Is(err, domain.ErrSealedDestination) {
+			h.logger.Error("Failed to decrypt destination", "short_code", shortCode, "error", err)
+			h.respondLinkError(w, r, http.StatusInternalServerError, "This link can't be opened right now", "Failed to decrypt destination")
+			return
+		}
 		h.logger.Warn("URL not found", "short_code", shortCode, "error", err)
-		respondError(w, http.StatusNotFound, "URL not found")
+		h.respondLinkError(w, r, http.StatusNotFound, "Link not found", "URL not found")
 		return
 	}
 
-	// Record the click asynchronously (don't block the redirect)
-	// This is a common pattern: analytics shouldn't slow down the user experience
-	go func() {
-		// Extract analytics data from request
-		ipAddress := r.RemoteAddr
-		userAgent := r.UserAgent()
-		referer := r.Referer()
+	// Signed links only open with their ?sig= - checked before anything
+	// (preview card, click) reveals or counts the link
+	if err := url.VerifySignature(r.URL.Query(), h.now()); err != nil {
+		h.respondLinkError(w, r, http.StatusForbidden, "This link can't be opened", err.Error())
+		return
+	}
 
-		if err := h.urlService.RecordClick(r.Context(), shortCode, ipAddress, userAgent, referer); err != nil {
-			h.logger.Error("Failed to record click", "error", err)
+	// Burn-after-reading links never redirect: they show their secret once
+	// Before the preview card, which would point bots at an empty destination
+	if url.BurnAfterReading {
+		h.serveSecret(w, r, url)
+		return
+	}
+
+	// File links stream their file instead of redirecting
+	if url.File != nil {
+		h.serveFile(w, r, url)
+		return
+	}
+
+	// Paste links show their snippet
+	if url.Paste != nil {
+		h.servePaste(w, r, url)
+		return
+	}
+
+	// Preview bots get the owner's card instead of the redirect
+	// They aren't people following the link, so no click is recorded
+	if h.servePreview(w, r, url) {
+		return
+	}
+
+	// A single-use link is claimed before the redirect: of two visitors
+	// arriving together, only one gets through
+	if url.SingleUse {
+		if err := h.urlService.UseOnce(r.Context(), url); err != nil {
+			if isGone(err) {
+				h.respondLinkError(w, r, http.StatusGone, "This link is no longer available", err.Error())
+				return
+			}
+			h.logger.Error("Failed to use single-use link", "short_code", shortCode, "error", err)
+			respondError(w, http.StatusServiceUnavailable, "Service temporarily unavailable, please retry")
+			return
 		}
-	}()
+	}
+
+	// Record the click asynchronously (don't block the redirect)
+	// This is a common pattern: analytics shouldn't slow down the user experience
+	h.recordClick(r, shortCode)
 
 	// Record business metric
 	metrics.RecordRedirect()
@@ -173,7 +387,58 @@ func (h *Handler) RedirectURL(w http.ResponseWriter, r *http.Request) {
 	// http.StatusFound (302) is a temporary redirect
 	// http.StatusMovedPermanently (301) is a permanent redirect
 	// We use 302 because URLs might expire or change
-	http.Redirect(w, r, url.OriginalURL, http.StatusFound)
+	h.setEdgeCacheHeaders(w, url)
+	http.Redirect(w, r, h.destinationFor(w, r, url), http.StatusFound)
+}
+
+// recordClick records a click on the link in the background
+func (h *Handler) recordClick(r *http.Request, shortCode string) {
+	// Extract analytics data BEFORE starting the goroutine - the request must not
+	// be touched after the handler returns
+	click := domain.ClickContext{
+		ShortCode: shortCode,
+		IPAddress: clientIP(r), // Without the port: stored as INET
+		UserAgent: r.UserAgent(),
+		Referer:   r.Referer(),
+		Consent:   consentSignal(r),
+	}
+
+	// The request context is canceled as soon as the response is sent,
+	// so detach from it while keeping its values (request ID, etc.)
+	clickCtx := context.WithoutCancel(r.Context())
+	h.clicks.Go(func() {
+		if err := h.urlService.RecordClick(clickCtx, click); err != nil {
+			h.logger.Error("Failed to record click", "error", err)
+		}
+	})
+}
+
+// DrainClicks waits for the clicks still being recorded, until ctx is done
+// Call it at shutdown, once the server stopped taking requests
+func (h *Handler) DrainClicks(ctx context.Context) startup.DrainReport {
+	return h.clicks.Drain(ctx)
+}
+
+// consentSignal reads the visitor's tracking opt-out from the request
+// GPC wins when both are sent: it is the one privacy laws recognize
+func consentSignal(r *http.Request) domain.ConsentSignal {
+	switch {
+	case r.Header.Get("Sec-GPC") == "1":
+		return domain.ConsentGPC
+	case r.Header.Get("DNT") == "1":
+		return domain.ConsentDoNotTrack
+	default:
+		return ""
+	}
+}
+
+// isGone reports whether a redirect failed because the link has run its
+// course (expired, click limit reached, single use used up, secret revealed)
+func isGone(err error) bool {
+	return errors.Is(err, domain.ErrURLExpired) ||
+		errors.Is(err, domain.ErrClickLimitReached) ||
+		errors.Is(err, domain.ErrLinkUsed) ||
+		errors.Is(err, domain.ErrLinkBurned)
 }
 
 // GetURLStats handles GET /api/v1/urls/{shortCode}/stats
@@ -188,6 +453,10 @@ func (h *Handler) GetURLStats(w http.ResponseWriter, r *http.Request) {
 
 	// Get stats from service
 	url, clicks, err := h.urlService.GetURLStats(r.Context(), shortCode)
+	if errors.Is(err, domain.ErrForbidden) {
+		respondError(w, http.StatusForbidden, "You don't have access to this URL")
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to get stats", "error", err)
 		respondError(w, http.StatusNotFound, "URL not found")
@@ -195,32 +464,391 @@ func (h *Handler) GetURLStats(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Build response
-	recentClicks := make([]ClickInfo, 0, len(clicks))
+	recentClicks := make([]v1.ClickInfo, 0, len(clicks))
 	for _, click := range clicks {
-		recentClicks = append(recentClicks, ClickInfo{
+		recentClicks = append(recentClicks, v1.ClickInfo{
 			ClickedAt:   click.ClickedAt,
 			CountryCode: click.CountryCode,
 			City:        click.City,
+			Channel:     string(click.Channel),
+			Browser:     click.Browser,
+			OS:          click.OS,
+			DeviceType:  click.DeviceType,
+			Region:      click.Region,
 		})
 	}
 
-	response := URLStatsResponse{
-		ID:           url.ID,
-		ShortCode:    url.ShortCode,
-		OriginalURL:  url.OriginalURL,
-		Clicks:       url.Clicks,
-		CreatedAt:    url.CreatedAt,
-		ExpiresAt:    url.ExpiresAt,
-		RecentClicks: recentClicks,
+	response := v1.URLStatsResponse{
+		ID:                   url.ID,
+		ShortCode:            url.ShortCode,
+		OriginalURL:          url.OriginalURL,
+		ResolvedURL:          url.ResolvedURL,
+		Clicks:               url.Clicks,
+		CreatedAt:            url.CreatedAt,
+		ExpiresAt:            url.ExpiresAt,
+		MaxClicks:            url.MaxClicks,
+		Metadata:             linkMetadata(url.Metadata),
+		Preview:              previewCard(url.Preview),
+		LanguageTargets:      url.LanguageTargets,
+		Schedule:             scheduleV1(url.Schedule),
+		SingleUse:            url.SingleUse,
+		UsedAt:               url.UsedAt,
+		Description:          url.Description,
+		CustomMetadata:       url.CustomMetadata,
+		Approval:             string(url.Approval),
+		SubmittedBy:          url.SubmittedBy,
+		Visibility:           string(url.Visibility),
+		DestinationEncrypted: url.DestinationSealed(),
+		Analytics:            h.analyticsStatus(w, url),
+		RecentClicks:         recentClicks,
+	}
+
+	w.Header().Set("ETag", url.ETag()) // Send it back in If-Match with PUT /api/v1/urls/{alias}
+	respondSuccess(w, http.StatusOK, response, "")
+}
+
+// GetURLSummary handles GET /api/v1/urls/{code}/summary?tz=Europe/Berlin
+// Click totals broken down by channel, device, browser, OS and region, plus
+// clicks per day of the last month and this week vs last week, for
+// dashboards that don't need every click
+func (h *Handler) GetURLSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.urlService.GetClickSummary(r.Context(), r.PathValue("code"), r.URL.Query().Get("tz"))
+	if err != nil {
+		h.respondAnalyticsError(w, err, "Failed to get click summary")
+		return
 	}
 
+	response := v1.URLSummaryResponse{
+		ID:               summary.URL.ID,
+		ShortCode:        summary.URL.ShortCode,
+		Clicks:           summary.URL.Clicks,
+		Channels:         summary.Channels,
+		Devices:          summary.Devices,
+		Browsers:         summary.Browsers,
+		OperatingSystems: summary.OperatingSystems,
+		Regions:          summary.Regions,
+	}
+	if summary.Daily != nil {
+		response.Timezone = summary.Daily.Location.String()
+		response.Daily = clickBuckets(summary.Daily.Buckets)
+	}
+	response.Comparison = clickComparison(summary.Comparison)
 	respondSuccess(w, http.StatusOK, response, "")
 }
 
+// linkMetadata converts the destination metadata (nil stays nil)
+func linkMetadata(meta *domain.LinkMetadata) *v1.LinkMetadata {
+	if meta == nil {
+		return nil
+	}
+	return &v1.LinkMetadata{
+		Title:       meta.Title,
+		Description: meta.Description,
+		FaviconURL:  meta.FaviconURL,
+		FetchedAt:   meta.FetchedAt,
+	}
+}
+
+// UpsertURL handles PUT /api/v1/urls/{alias}
+//
+// Declarative provisioning: the body is the desired state, and the response
+// says whether it was created (201), updated or already in place (200).
+// The response carries the ETag; send it back in If-Match to update only
+// what you last read (412 if someone changed it meanwhile), or send
+// If-None-Match: * to create without ever overwriting.
+func (h *Handler) UpsertURL(w http.ResponseWriter, r *http.Request) {
+	var req v1.UpsertURLRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	cond := domain.Precondition{
+		IfMatch:     r.Header.Get("If-Match"),
+		IfNoneMatch: r.Header.Get("If-None-Match"),
+	}
+	// Always passed, even when empty: PUT replaces the whole desired state
+	url, outcome, err := h.urlService.UpsertURL(r.Context(), r.PathValue("alias"), req.URL, cond,
+		domain.WithLanguageTargets(req.LanguageTargets),
+		domain.WithSchedule(scheduleFromV1(req.Schedule)),
+		domain.WithDescription(req.Description),
+		domain.WithCustomMetadata(req.CustomMetadata),
+	)
+	if outcome == domain.UpsertCreated || errors.Is(err, domain.ErrQuotaExceeded) {
+		h.writeQuotaHeaders(w, r)
+	}
+	if err != nil {
+		status := createErrorStatus(err)
+		switch {
+		case errors.Is(err, domain.ErrPreconditionFailed), errors.Is(err, domain.ErrVersionConflict):
+			status = http.StatusPreconditionFailed
+		case errors.Is(err, domain.ErrForbidden):
+			status = http.StatusForbidden
+		}
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to upsert URL", "error", err)
+		}
+		respondError(w, status, err.Error())
+		return
+	}
+
+	status := http.StatusOK
+	if outcome == domain.UpsertCreated {
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("ETag", url.ETag())
+	respondSuccess(w, status, v1.UpsertURLResponse{
+		CreateURLResponse: createURLResponse(h.baseURL, url),
+		Result:            string(outcome),
+	}, "")
+}
+
+// DeleteURL handles DELETE /api/v1/urls/{id}
+// Soft delete by default; ?permanent=true removes the URL and its analytics for good
+func (h *Handler) DeleteURL(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if r.URL.Query().Get("permanent") == "true" {
+		clicks, err := h.urlService.PurgeURL(r.Context(), id)
+		if err != nil {
+			h.respondLookupError(w, "Failed to purge URL", err)
+			return
+		}
+
+		h.logger.Info("URL purged", "id", id, "clicks_deleted", clicks)
+		respondSuccess(w, http.StatusOK, map[string]interface{}{
+			"id":             id,
+			"clicks_deleted": clicks,
+		}, "URL permanently deleted")
+		return
+	}
+
+	if err := h.urlService.DeleteURL(r.Context(), id); err != nil {
+		h.respondLookupError(w, "Failed to delete URL", err)
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, map[string]string{"id": id}, "URL deleted")
+}
+
+// SignURL handles POST /api/v1/urls/{id}/sign
+// Returns a URL that opens a signed link until expires_in_hours from now
+// (0 = forever), e.g. one per email recipient
+func (h *Handler) SignURL(w http.ResponseWriter, r *http.Request) {
+	var req v1.SignURLRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	signed, err := h.urlService.SignURL(r.Context(), r.PathValue("id"), time.Duration(req.ExpiresInHours)*time.Hour)
+	if errors.Is(err, domain.ErrLinkNotSigned) {
+		respondError(w, http.StatusBadRequest, "Only links created with \"signed\": true can be signed")
+		return
+	}
+	if err != nil {
+		h.respondLookupError(w, "Failed to sign URL", err)
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, v1.SignedURLResponse{
+		SignedURL: buildShortURL(h.baseURL, signed.URL) + "?" + signed.Query.Encode(),
+		ExpiresAt: signed.ExpiresAt,
+	}, "")
+}
+
+// RestoreURL handles POST /api/v1/urls/{id}/restore
+func (h *Handler) RestoreURL(w http.ResponseWriter, r *http.Request) {
+	url, err := h.urlService.RestoreURL(r.Context(), r.PathValue("id"))
+	if errors.Is(err, domain.ErrDestinationBlocked) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, domain.ErrAwaitingApproval) {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		h.respondLookupError(w, "Failed to restore URL", err)
+		return
+	}
+
+	response := v1.CreateURLResponse{
+		ID:          url.ID,
+		ShortCode:   url.ShortCode,
+		ShortURL:    h.shortURL(url),
+		OriginalURL: url.OriginalURL,
+		ResolvedURL: url.ResolvedURL,
+		CreatedAt:   url.CreatedAt,
+		ExpiresAt:   url.ExpiresAt,
+		MaxClicks:   url.MaxClicks,
+	}
+
+	respondSuccess(w, http.StatusOK, response, "URL restored")
+}
+
+// SuggestAliases handles GET /api/v1/aliases/suggest?url=...&keyword=...&limit=...
+// Returns custom aliases that are free RIGHT NOW (someone may still take them first)
+func (h *Handler) SuggestAliases(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	destination := query.Get("url")
+	keyword := query.Get("keyword")
+	if destination == "" && keyword == "" {
+		respondError(w, http.StatusBadRequest, "Provide a url or keyword parameter")
+		return
+	}
+
+	limit := 0 // 0 lets the service pick its default
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+		limit = parsed
+	}
+
+	suggestions, err := h.urlService.SuggestAliases(r.Context(), destination, keyword, limit)
+	if err != nil {
+		h.logger.Error("Failed to suggest aliases", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to suggest aliases")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, v1.AliasSuggestionsResponse{Suggestions: suggestions}, "")
+}
+
+// CheckAliasAvailability handles GET /api/v1/aliases/{alias}/availability
+// Lets the UI validate an alias while the user types instead of failing on submit
+func (h *Handler) CheckAliasAvailability(w http.ResponseWriter, r *http.Request) {
+	alias := r.PathValue("alias")
+
+	status, err := h.urlService.CheckAliasAvailability(r.Context(), alias)
+	if err != nil {
+		if errors.Is(err, domain.ErrCustomAliasInvalid) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.Error("Failed to check alias availability", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to check alias availability")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, v1.AliasAvailabilityResponse{
+		Alias:     alias,
+		Available: status == domain.AliasFree,
+		Status:    string(status),
+	}, "")
+}
+
+// shortURL builds the public short link for a URL
+func (h *Handler) shortURL(url *domain.URL) string {
+	return buildShortURL(h.baseURL, url)
+}
+
+// createURLResponse converts a newly created URL to its API representation
+func createURLResponse(baseURL string, url *domain.URL) v1.CreateURLResponse {
+	response := v1.CreateURLResponse{
+		ID:          url.ID,
+		ShortCode:   url.ShortCode,
+		ShortURL:    buildShortURL(baseURL, url),
+		OriginalURL: url.OriginalURL,
+		ResolvedURL: url.ResolvedURL,
+		CreatedAt:   url.CreatedAt,
+		ExpiresAt:   url.ExpiresAt,
+		MaxClicks:   url.MaxClicks,
+
+		LanguageTargets: url.LanguageTargets,
+		Schedule:        scheduleV1(url.Schedule),
+		SingleUse:       url.SingleUse,
+		Analytics:       analyticsOptOut(url.AnalyticsDisabled),
+
+		Description:    url.Description,
+		CustomMetadata: url.CustomMetadata,
+
+		Approval:    string(url.Approval),
+		SubmittedBy: url.SubmittedBy,
+
+		Visibility:           string(url.Visibility),
+		DestinationEncrypted: url.DestinationSealed(),
+	}
+	if url.RequiresSignature() {
+		// Only the owner sees these responses; stats never show the secret
+		response.Signed = true
+		response.SigningSecret = url.SigningSecret
+		response.SignedURL = buildShortURL(baseURL, url) + "?" + url.SignedQuery(time.Time{}).Encode()
+	}
+	return response
+}
+
+// buildShortURL joins baseURL and the short code
+// Links on a custom domain keep the scheme of the base URL
+func buildShortURL(baseURL string, url *domain.URL) string {
+	if url.Domain != "" {
+		scheme, _, _ := strings.Cut(baseURL, "://")
+		return fmt.Sprintf("%s://%s/%s", scheme, url.Domain, url.ShortCode)
+	}
+	return fmt.Sprintf("%s/%s", baseURL, url.ShortCode)
+}
+
+// respondLookupError answers 404 for unknown URLs, 403 for other people's
+// URLs and 500 for everything else
+func (h *Handler) respondLookupError(w http.ResponseWriter, message string, err error) {
+	if errors.Is(err, domain.ErrURLNotFound) {
+		respondError(w, http.StatusNotFound, "URL not found")
+		return
+	}
+	if errors.Is(err, domain.ErrForbidden) {
+		respondError(w, http.StatusForbidden, "You don't have access to this URL")
+		return
+	}
+	h.logger.Error(message, "error", err)
+	respondError(w, http.StatusInternalServerError, message)
+}
+
+// createErrorStatus maps errors from URL creation to HTTP status codes
+// Validation problems are the client's fault (400), a taken alias (or one
+// imitating another) is a conflict (409), a used-up plan is 429, a
+// workspace viewer trying to create is 403, everything else is ours (500)
+func createErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrCustomAliasTaken),
+		errors.Is(err, domain.ErrConfusableAlias):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, domain.ErrFileTooLarge),
+		errors.Is(err, domain.ErrPasteTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrEmptyURL),
+		errors.Is(err, domain.ErrInvalidURL),
+		errors.Is(err, domain.ErrShortCodeTooShort),
+		errors.Is(err, domain.ErrCustomAliasInvalid),
+		errors.Is(err, domain.ErrCustomAliasReserved),
+		errors.Is(err, domain.ErrRedirectorURL),
+		errors.Is(err, domain.ErrInvalidClickLimit),
+		errors.Is(err, domain.ErrInvalidDomain),
+		errors.Is(err, domain.ErrInvalidLanguageTargets),
+		errors.Is(err, domain.ErrInvalidSchedule),
+		errors.Is(err, domain.ErrInvalidSecret),
+		errors.Is(err, domain.ErrInvalidFile),
+		errors.Is(err, domain.ErrInvalidPaste),
+		errors.Is(err, domain.ErrInvalidDescription),
+		errors.Is(err, domain.ErrInvalidCustomMetadata),
+		errors.Is(err, domain.ErrDestinationBlocked),
+		errors.Is(err, domain.ErrCustomDomainUnverified),
+		errors.Is(err, domain.ErrSealedDestinationTargets):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // HealthCheck handles GET /health/live
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	respondJSON(w, http.StatusOK, map[string]string{
+	respondSuccess(w, http.StatusOK, map[string]string{
 		"status": "ok",
 		"time":   time.Now().Format(time.RFC3339),
-	})
+	}, "")
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -11,7 +12,11 @@ import (
 	"testing"
 	"time"
 
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/auth"
 	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/resilience"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -25,7 +30,7 @@ type MockURLService struct {
 	mock.Mock
 }
 
-func (m *MockURLService) CreateShortURL(ctx context.Context, originalURL, customAlias, createdBy string, expiresIn time.Duration) (*domain.URL, error) {
+func (m *MockURLService) CreateShortURL(ctx context.Context, originalURL, customAlias, createdBy string, expiresIn time.Duration, opts ...domain.URLOption) (*domain.URL, error) {
 	args := m.Called(ctx, originalURL, customAlias, createdBy, expiresIn)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -33,6 +38,14 @@ func (m *MockURLService) CreateShortURL(ctx context.Context, originalURL, custom
 	return args.Get(0).(*domain.URL), args.Error(1)
 }
 
+func (m *MockURLService) ValidateShortURL(ctx context.Context, originalURL, customAlias, createdBy string, expiresIn time.Duration, opts ...domain.URLOption) (*domain.CreationPreview, error) {
+	args := m.Called(ctx, originalURL, customAlias, createdBy, expiresIn)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CreationPreview), args.Error(1)
+}
+
 func (m *MockURLService) GetURL(ctx context.Context, shortCode string) (*domain.URL, error) {
 	args := m.Called(ctx, shortCode)
 	if args.Get(0) == nil {
@@ -41,11 +54,16 @@ func (m *MockURLService) GetURL(ctx context.Context, shortCode string) (*domain.
 	return args.Get(0).(*domain.URL), args.Error(1)
 }
 
-func (m *MockURLService) RecordClick(ctx context.Context, shortCode, ipAddress, userAgent, referer string) error {
-	args := m.Called(ctx, shortCode, ipAddress, userAgent, referer)
+func (m *MockURLService) RecordClick(ctx context.Context, click domain.ClickContext) error {
+	args := m.Called(ctx, click)
 	return args.Error(0)
 }
 
+// clickOn matches a recorded click on shortCode
+func clickOn(shortCode string) interface{} {
+	return mock.MatchedBy(func(click domain.ClickContext) bool { return click.ShortCode == shortCode })
+}
+
 func (m *MockURLService) GetURLStats(ctx context.Context, shortCode string) (*domain.URL, []*domain.URLClick, error) {
 	args := m.Called(ctx, shortCode)
 	if args.Get(0) == nil {
@@ -54,11 +72,122 @@ func (m *MockURLService) GetURLStats(ctx context.Context, shortCode string) (*do
 	return args.Get(0).(*domain.URL), args.Get(1).([]*domain.URLClick), args.Error(2)
 }
 
+func (m *MockURLService) TraceRedirects(ctx context.Context, shortCode string) (*domain.RedirectTrace, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RedirectTrace), args.Error(1)
+}
+
+func (m *MockURLService) GetClickSummary(ctx context.Context, shortCode, timezone string) (*domain.ClickSummary, error) {
+	args := m.Called(ctx, shortCode, timezone)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ClickSummary), args.Error(1)
+}
+
+func (m *MockURLService) GetClickHeatmap(ctx context.Context, shortCode string, query domain.TimeseriesQuery) (*domain.ClickHeatmap, error) {
+	args := m.Called(ctx, shortCode, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ClickHeatmap), args.Error(1)
+}
+
+func (m *MockURLService) GetClickTimeseries(ctx context.Context, shortCode string, query domain.TimeseriesQuery) (*domain.ClickTimeseries, error) {
+	args := m.Called(ctx, shortCode, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ClickTimeseries), args.Error(1)
+}
+
 func (m *MockURLService) DeleteURL(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockURLService) RestoreURL(ctx context.Context, id string) (*domain.URL, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockURLService) PurgeURL(ctx context.Context, id string) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockURLService) SuggestAliases(ctx context.Context, destination, keyword string, limit int) ([]string, error) {
+	args := m.Called(ctx, destination, keyword, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockURLService) CheckAliasAvailability(ctx context.Context, alias string) (domain.AliasStatus, error) {
+	args := m.Called(ctx, alias)
+	return args.Get(0).(domain.AliasStatus), args.Error(1)
+}
+
+func (m *MockURLService) SetPreview(ctx context.Context, id string, card *domain.PreviewCard) (*domain.URL, error) {
+	args := m.Called(ctx, id, card)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockURLService) SetNotes(ctx context.Context, id, description string, metadata map[string]string) (*domain.URL, error) {
+	args := m.Called(ctx, id, description, metadata)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockURLService) SetVisibility(ctx context.Context, id string, visibility domain.LinkVisibility) (*domain.URL, error) {
+	args := m.Called(ctx, id, visibility)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockURLService) UpsertURL(ctx context.Context, alias, originalURL string, cond domain.Precondition, opts ...domain.URLOption) (*domain.URL, domain.UpsertOutcome, error) {
+	args := m.Called(ctx, alias, originalURL, cond)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*domain.URL), args.Get(1).(domain.UpsertOutcome), args.Error(2)
+}
+
+func (m *MockURLService) CloneURL(ctx context.Context, id, originalURL, customAlias string) (*domain.URL, error) {
+	args := m.Called(ctx, id, originalURL, customAlias)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockURLService) SignURL(ctx context.Context, id string, expiresIn time.Duration) (*domain.SignedLink, error) {
+	args := m.Called(ctx, id, expiresIn)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SignedLink), args.Error(1)
+}
+
+func (m *MockURLService) UseOnce(ctx context.Context, url *domain.URL) error {
+	args := m.Called(ctx, url)
+	return args.Error(0)
+}
+
 // ==================== HELPER FUNCTIONS ====================
 
 func setupTestHandler() (*Handler, *MockURLService) {
@@ -146,6 +275,150 @@ func TestCreateURL_WithCustomAlias(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestCreateURL_CustomAliasTaken(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+
+	mockService.On("CreateShortURL", mock.Anything, "https://example.com", "taken", "anonymous", time.Duration(0)).
+		Return(nil, fmt.Errorf("%w: taken", domain.ErrCustomAliasTaken))
+
+	body := `{"url": "https://example.com", "custom_alias": "taken"}`
+	req := httptest.NewRequest("POST", "/api/v1/urls", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.CreateURL(w, req)
+
+	// Assert - a conflict, not a server error
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "custom alias already exists")
+	mockService.AssertExpectations(t)
+}
+
+func TestValidateURL(t *testing.T) {
+	remaining := int64(58)
+
+	tests := []struct {
+		name       string
+		body       string
+		alias      string
+		preview    *domain.CreationPreview
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:  "custom alias",
+			body:  `{"url": "https://example.com", "custom_alias": "spring"}`,
+			alias: "spring",
+			preview: &domain.CreationPreview{
+				URL:   &domain.URL{ShortCode: "spring", OriginalURL: "https://example.com"},
+				Usage: &domain.Usage{Plan: domain.Plan{MonthlyLinks: 100}, Used: 100 - remaining},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"short_url":"http://localhost:8080/spring"`,
+		},
+		{
+			name:       "generated code",
+			body:       `{"url": "https://example.com"}`,
+			preview:    &domain.CreationPreview{URL: &domain.URL{OriginalURL: "https://example.com"}},
+			wantStatus: http.StatusOK,
+			wantBody:   `"valid":true`,
+		},
+		{
+			name:       "confusable alias is flagged",
+			body:       `{"url": "https://example.com", "custom_alias": "paypa1"}`,
+			alias:      "paypa1",
+			preview:    &domain.CreationPreview{URL: &domain.URL{ShortCode: "paypa1", OriginalURL: "https://example.com"}, Imitates: "paypal"},
+			wantStatus: http.StatusOK,
+			wantBody:   `"confusable_with":"paypal"`,
+		},
+		{
+			name:       "alias taken",
+			body:       `{"url": "https://example.com", "custom_alias": "taken"}`,
+			alias:      "taken",
+			err:        fmt.Errorf("%w: taken", domain.ErrCustomAliasTaken),
+			wantStatus: http.StatusConflict,
+			wantBody:   "custom alias already exists",
+		},
+		{
+			name:       "quota used up",
+			body:       `{"url": "https://example.com"}`,
+			err:        domain.ErrQuotaExceeded,
+			wantStatus: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			mockService.On("ValidateShortURL", mock.Anything, "https://example.com", tt.alias, "anonymous", time.Duration(0)).
+				Return(tt.preview, tt.err)
+
+			req := httptest.NewRequest("POST", "/api/v1/urls/validate", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ValidateURL(w, req)
+
+			// Assert
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			if tt.preview != nil && tt.preview.Usage != nil {
+				assert.Contains(t, w.Body.String(), `"quota_remaining":58`)
+			}
+			if tt.alias == "" {
+				assert.NotContains(t, w.Body.String(), "short_url")
+			}
+			mockService.AssertNotCalled(t, "CreateShortURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCreateURL_ConfusableAlias(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+
+	mockService.On("CreateShortURL", mock.Anything, "https://example.com", "paypa1", "anonymous", time.Duration(0)).
+		Return(nil, fmt.Errorf("%w: paypa1 looks like paypal", domain.ErrConfusableAlias))
+
+	body := `{"url": "https://example.com", "custom_alias": "paypa1"}`
+	req := httptest.NewRequest("POST", "/api/v1/urls", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.CreateURL(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "paypa1 looks like paypal")
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateURL_Viewer(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+
+	mockService.On("CreateShortURL", mock.Anything, "https://example.com", "", "anonymous", time.Duration(0)).
+		Return(nil, domain.ErrForbidden)
+
+	body := `{"url": "https://example.com"}`
+	req := httptest.NewRequest("POST", "/api/v1/urls", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.CreateURL(w, req)
+
+	// Assert - viewers are read-only, which is not a server error
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertExpectations(t)
+}
+
 func TestCreateURL_WithExpiration(t *testing.T) {
 	// Arrange
 	handler, mockService := setupTestHandler()
@@ -217,6 +490,31 @@ func TestCreateURL_MissingURL(t *testing.T) {
 	assert.Contains(t, response["error"], "URL is required")
 }
 
+func TestCreateURL_ValidationDetails(t *testing.T) {
+	// Arrange: several invalid fields at once
+	handler, mockService := setupTestHandler()
+
+	body := `{"url": "ftp://example.com", "max_clicks": -5, "language_targets": {"fr": "not a url"}}`
+	req := httptest.NewRequest("POST", "/api/v1/urls", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.CreateURL(w, req)
+
+	// Assert: every problem is reported, keyed by field, and the service is never called
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response v1.Envelope
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "validation_failed", response.Code)
+	assert.Equal(t, map[string]string{
+		"url":                 "URL must be an absolute http(s) URL",
+		"max_clicks":          "max_clicks must be at least 1",
+		"language_targets.fr": "language_targets.fr must be an absolute http(s) URL",
+	}, response.Details)
+	mockService.AssertNotCalled(t, "CreateShortURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 // ==================== REDIRECT URL TESTS ====================
 
 func TestRedirectURL_Success(t *testing.T) {
@@ -230,10 +528,18 @@ func TestRedirectURL_Success(t *testing.T) {
 		IsActive:    true,
 	}
 
-	mockService.On("GetURL", mock.Anything, "abc123").Return(url, nil)
-	mockService.On("RecordClick", mock.Anything, "abc123", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	// RecordClick runs in a goroutine, so hand the click over when it has been called
+	clickRecorded := make(chan domain.ClickContext, 1)
+	// The lookup is timed as a redirect (redirect_lookup_duration_seconds)
+	mockService.On("GetURL", mock.MatchedBy(repository.IsRedirectLookup), "abc123").Return(url, nil)
+	mockService.On("RecordClick", mock.Anything, clickOn("abc123")).
+		Run(func(args mock.Arguments) { clickRecorded <- args.Get(1).(domain.ClickContext) }).
+		Return(nil)
 
 	req := httptest.NewRequest("GET", "/abc123", nil)
+	req.RemoteAddr = "203.0.113.7:54321"
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	req.Header.Set("Referer", "https://news.example.com/")
 	w := httptest.NewRecorder()
 
 	// Act
@@ -243,9 +549,66 @@ func TestRedirectURL_Success(t *testing.T) {
 	assert.Equal(t, http.StatusFound, w.Code)
 	assert.Equal(t, "https://example.com", w.Header().Get("Location"))
 
+	select {
+	case click := <-clickRecorded:
+		assert.Equal(t, domain.ClickContext{
+			ShortCode: "abc123",
+			IPAddress: "203.0.113.7", // No port: the column is an INET
+			UserAgent: "Mozilla/5.0",
+			Referer:   "https://news.example.com/",
+		}, click)
+	case <-time.After(time.Second):
+		t.Fatal("click was not recorded")
+	}
 	mockService.AssertExpectations(t)
 }
 
+func TestDrainClicks(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	url := &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com", IsActive: true}
+	mockService.On("GetURL", mock.Anything, "abc123").Return(url, nil)
+	mockService.On("RecordClick", mock.Anything, clickOn("abc123")).
+		Run(func(args mock.Arguments) { time.Sleep(20 * time.Millisecond) }). // A slow click write
+		Return(nil)
+	handler.RedirectURL(httptest.NewRecorder(), httptest.NewRequest("GET", "/abc123", nil))
+
+	// Act
+	report := handler.DrainClicks(context.Background())
+
+	// Assert
+	assert.Equal(t, 1, report.Flushed)
+	assert.Zero(t, report.Dropped)
+	mockService.AssertCalled(t, "RecordClick", mock.Anything, clickOn("abc123"))
+}
+
+func TestConsentSignal(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected domain.ConsentSignal
+	}{
+		{name: "no signal", expected: ""},
+		{name: "do not track", headers: map[string]string{"DNT": "1"}, expected: domain.ConsentDoNotTrack},
+		{name: "global privacy control", headers: map[string]string{"Sec-GPC": "1"}, expected: domain.ConsentGPC},
+		{name: "both - GPC wins", headers: map[string]string{"DNT": "1", "Sec-GPC": "1"}, expected: domain.ConsentGPC},
+		{name: "tracking allowed", headers: map[string]string{"DNT": "0"}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			// Act & Assert
+			assert.Equal(t, tt.expected, consentSignal(req))
+		})
+	}
+}
+
 func TestRedirectURL_NotFound(t *testing.T) {
 	// Arrange
 	handler, mockService := setupTestHandler()
@@ -268,6 +631,43 @@ func TestRedirectURL_NotFound(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestRedirectURL_CircuitOpen(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+
+	openErr := &resilience.OpenError{Name: "postgres_redirect", RetryAfter: 2500 * time.Millisecond}
+	mockService.On("GetURL", mock.Anything, "abc123").Return(nil, openErr)
+
+	req := httptest.NewRequest("GET", "/abc123", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.RedirectURL(w, req)
+
+	// Assert: temporary failure, not "not found"
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "3", w.Header().Get("Retry-After"))
+	mockService.AssertExpectations(t)
+}
+
+func TestRedirectURL_SealedDestinationUnreadable(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+
+	mockService.On("GetURL", mock.Anything, "abc123").Return(nil, fmt.Errorf("%w: %v", domain.ErrSealedDestination, assert.AnError))
+
+	req := httptest.NewRequest("GET", "/abc123", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.RedirectURL(w, req)
+
+	// Assert: a missing key is our problem, the link does exist
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("Location"))
+	mockService.AssertExpectations(t)
+}
+
 // ==================== GET URL STATS TESTS ====================
 
 func TestGetURLStats_Success(t *testing.T) {
@@ -280,6 +680,9 @@ func TestGetURLStats_Success(t *testing.T) {
 		OriginalURL: "https://example.com",
 		Clicks:      42,
 		IsActive:    true,
+
+		Description:    "Spring sale banner",
+		CustomMetadata: map[string]string{"ticket": "MKT-142"},
 	}
 
 	clicks := []*domain.URLClick{
@@ -308,10 +711,345 @@ func TestGetURLStats_Success(t *testing.T) {
 	data := response["data"].(map[string]interface{})
 	assert.Equal(t, "abc123", data["short_code"])
 	assert.Equal(t, float64(42), data["clicks"]) // JSON numbers are float64
+	assert.Equal(t, "Spring sale banner", data["description"])
+	assert.Equal(t, map[string]interface{}{"ticket": "MKT-142"}, data["custom_metadata"])
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetURLStats_NotOwner(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+
+	mockService.On("GetURLStats", mock.Anything, "abc123").Return(nil, nil, domain.ErrForbidden)
+
+	req := httptest.NewRequest("GET", "/api/v1/urls/abc123/stats", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.GetURLStats(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetURLSummary(t *testing.T) {
+	tests := []struct {
+		name           string
+		summary        *domain.ClickSummary
+		serviceErr     error
+		expectedStatus int
+	}{
+		{
+			name: "channel breakdown",
+			summary: &domain.ClickSummary{
+				URL:              &domain.URL{ID: "123", ShortCode: "abc123", Clicks: 7},
+				Channels:         map[string]int64{"social": 4, "direct": 2, "unknown": 1},
+				Devices:          map[string]int64{"mobile": 5, "desktop": 2},
+				Browsers:         map[string]int64{"Safari": 4, "Chrome": 3},
+				OperatingSystems: map[string]int64{"iOS": 4, "Windows": 3},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{name: "not the owner", serviceErr: domain.ErrForbidden, expectedStatus: http.StatusForbidden},
+		{name: "unknown link", serviceErr: fmt.Errorf("URL not found: %w", domain.ErrURLNotFound), expectedStatus: http.StatusNotFound},
+		{name: "database down", serviceErr: assert.AnError, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			if tt.serviceErr != nil {
+				mockService.On("GetClickSummary", mock.Anything, "abc123", "").Return(nil, tt.serviceErr)
+			} else {
+				mockService.On("GetClickSummary", mock.Anything, "abc123", "").Return(tt.summary, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc123/summary", nil)
+			req.SetPathValue("code", "abc123")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.GetURLSummary(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"clicks":7`)
+				assert.Contains(t, w.Body.String(), `"channels":{"direct":2,"social":4,"unknown":1}`)
+				assert.Contains(t, w.Body.String(), `"devices":{"desktop":2,"mobile":5}`)
+				assert.Contains(t, w.Body.String(), `"browsers":{"Chrome":3,"Safari":4}`)
+				assert.Contains(t, w.Body.String(), `"operating_systems":{"Windows":3,"iOS":4}`)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// ==================== DELETE / RESTORE TESTS ====================
+
+func TestDeleteURL_RequiresAdmin(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /api/v1/urls/{id}", RequireAdmin(handler.DeleteURL))
+	protected := AuthMiddleware(auth.NewStaticKeyAuthenticator("secret"))(mux)
+
+	tests := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "No credentials", authorization: "", expectedStatus: http.StatusUnauthorized},
+		{name: "Wrong key", authorization: "Bearer nope", expectedStatus: http.StatusUnauthorized},
+		{name: "Admin key", authorization: "Bearer secret", expectedStatus: http.StatusOK},
+	}
+
+	mockService.On("PurgeURL", mock.Anything, "123").Return(int64(3), nil)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("DELETE", "/api/v1/urls/123?permanent=true", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			protected.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	mockService.AssertNumberOfCalls(t, "PurgeURL", 1)
+}
+
+func TestRestoreURL_NotFound(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+
+	mockService.On("RestoreURL", mock.Anything, "missing").Return(nil, domain.ErrURLNotFound)
+
+	req := httptest.NewRequest("POST", "/api/v1/urls/missing/restore", nil)
+	req.SetPathValue("id", "missing")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.RestoreURL(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteURL_NotOwner(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+
+	mockService.On("DeleteURL", mock.Anything, "123").Return(domain.ErrForbidden)
+
+	req := httptest.NewRequest("DELETE", "/api/v1/urls/123", nil)
+	req.SetPathValue("id", "123")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.DeleteURL(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+// ==================== API V2 TESTS ====================
+
+func TestSuggestAliases(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		callsService   bool
+	}{
+		{name: "keyword", query: "?keyword=launch&limit=2", expectedStatus: http.StatusOK, callsService: true},
+		{name: "missing input", query: "", expectedStatus: http.StatusBadRequest},
+		{name: "bad limit", query: "?keyword=launch&limit=abc", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			mockService.On("SuggestAliases", mock.Anything, "", "launch", 2).
+				Return([]string{"launch", "launch-go"}, nil)
+
+			req := httptest.NewRequest("GET", "/api/v1/aliases/suggest"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.SuggestAliases(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.callsService {
+				assert.Contains(t, w.Body.String(), `"suggestions":["launch","launch-go"]`)
+			} else {
+				mockService.AssertNotCalled(t, "SuggestAliases", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestCheckAliasAvailability(t *testing.T) {
+	tests := []struct {
+		name           string
+		alias          string
+		status         domain.AliasStatus
+		err            error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "free", alias: "launch", status: domain.AliasFree, expectedStatus: http.StatusOK, expectedBody: `"available":true,"status":"free"`},
+		{name: "taken", alias: "promo", status: domain.AliasTaken, expectedStatus: http.StatusOK, expectedBody: `"available":false,"status":"taken"`},
+		{name: "reserved", alias: "admin", status: domain.AliasReserved, expectedStatus: http.StatusOK, expectedBody: `"status":"reserved"`},
+		{name: "invalid", alias: "a!", status: "", err: domain.ErrCustomAliasInvalid, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			mockService.On("CheckAliasAvailability", mock.Anything, tt.alias).Return(tt.status, tt.err)
+
+			req := httptest.NewRequest("GET", "/api/v1/aliases/x/availability", nil)
+			req.SetPathValue("alias", tt.alias)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.CheckAliasAvailability(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestCreateURLV2_Success(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+
+	expectedURL := &domain.URL{
+		ID:          "123",
+		ShortCode:   "abc123",
+		OriginalURL: "https://example.com",
+		CreatedAt:   time.Now(),
+		IsActive:    true,
+	}
+
+	mockService.On("CreateShortURL", mock.Anything, "https://example.com", "", "anonymous", 90*time.Minute).
+		Return(expectedURL, nil)
+
+	body := `{"url": "https://example.com", "expires_in": "90m"}`
+	req := httptest.NewRequest("POST", "/api/v2/urls", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.CreateURLV2(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, "abc123", data["short_code"])
+	assert.Equal(t, "http://localhost:8080/abc123", data["short_url"])
+	assert.NotContains(t, response, "error")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateURLV2_ErrorEnvelope(t *testing.T) {
+	// Arrange
+	handler, _ := setupTestHandler()
+
+	req := httptest.NewRequest("POST", "/api/v2/urls", bytes.NewBufferString(`{"url": ""}`))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.CreateURLV2(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	apiErr := response["error"].(map[string]interface{})
+	assert.Equal(t, "validation_failed", apiErr["code"])
+	assert.Contains(t, apiErr["message"], "URL is required")
+	assert.Equal(t, map[string]interface{}{"url": "URL is required"}, apiErr["details"])
+	assert.NotContains(t, response, "data")
+}
+
+func TestGetURLStatsV2_NestsLink(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+
+	url := &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com", Clicks: 7}
+	mockService.On("GetURLStats", mock.Anything, "abc123").Return(url, []*domain.URLClick{}, nil)
+
+	mux := http.NewServeMux()
+	NewAPIVersion(mux, "v2", VersionLifecycle{}).HandleFunc("GET /urls/{code}/stats", handler.GetURLStatsV2)
+
+	req := httptest.NewRequest("GET", "/api/v2/urls/abc123/stats", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	mux.ServeHTTP(w, req)
 
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	link := response["data"].(map[string]interface{})["link"].(map[string]interface{})
+	assert.Equal(t, "abc123", link["short_code"])
+	assert.Equal(t, float64(7), link["clicks"])
 	mockService.AssertExpectations(t)
 }
 
+func TestAPIVersion_DeprecationHeaders(t *testing.T) {
+	// Arrange
+	deprecatedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	mux := http.NewServeMux()
+	NewAPIVersion(mux, "v1", VersionLifecycle{
+		DeprecatedAt: deprecatedAt,
+		Sunset:       sunset,
+		Successor:    "/api/v2",
+	}).HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {})
+	NewAPIVersion(mux, "v2", VersionLifecycle{}).HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {})
+
+	// Act
+	v1 := httptest.NewRecorder()
+	mux.ServeHTTP(v1, httptest.NewRequest("GET", "/api/v1/ping", nil))
+	v2 := httptest.NewRecorder()
+	mux.ServeHTTP(v2, httptest.NewRequest("GET", "/api/v2/ping", nil))
+
+	// Assert
+	assert.Equal(t, "@1767225600", v1.Header().Get("Deprecation"))
+	assert.Equal(t, "Thu, 31 Dec 2026 00:00:00 GMT", v1.Header().Get("Sunset"))
+	assert.Contains(t, v1.Header().Get("Link"), `</api/v2>; rel="successor-version"`)
+	assert.Empty(t, v2.Header().Get("Deprecation"))
+	assert.Empty(t, v2.Header().Get("Sunset"))
+}
+
 // ==================== HEALTH CHECK TESTS ====================
 
 func TestHealthCheck(t *testing.T) {
@@ -327,10 +1065,12 @@ func TestHealthCheck(t *testing.T) {
 	// Assert
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var response map[string]interface{}
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
 	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Equal(t, "ok", response["status"])
-	assert.NotEmpty(t, response["time"])
+	assert.Equal(t, "ok", response.Data["status"])
+	assert.NotEmpty(t, response.Data["time"])
 }
 
 // ==================== TABLE-DRIVEN TESTS ====================
@@ -375,6 +1115,30 @@ func TestCreateURL_TableDriven(t *testing.T) {
 				assert.Contains(t, resp["error"], "required")
 			},
 		},
+		{
+			name:        "Sensitive link",
+			requestBody: `{"url": "https://example.com", "visibility": "sensitive"}`,
+			mockSetup: func(m *MockURLService) {
+				url := &domain.URL{ShortCode: "abc123", OriginalURL: "https://example.com", Visibility: domain.VisibilitySensitive}
+				m.On("CreateShortURL", mock.Anything, "https://example.com", "", "anonymous", time.Duration(0)).
+					Return(url, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			checkResponse: func(t *testing.T, resp map[string]interface{}) {
+				data := resp["data"].(map[string]interface{})
+				assert.Equal(t, "sensitive", data["visibility"])
+			},
+		},
+		{
+			name:           "Unknown visibility",
+			requestBody:    `{"url": "https://example.com", "visibility": "hidden"}`,
+			mockSetup:      func(m *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, resp map[string]interface{}) {
+				details := resp["details"].(map[string]interface{})
+				assert.Equal(t, "visibility must be one of: standard, sensitive", details["visibility"])
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -400,8 +1164,115 @@ func TestCreateURL_TableDriven(t *testing.T) {
 	}
 }
 
+// ==================== UPSERT TESTS ====================
+
+func TestUpsertURL(t *testing.T) {
+	url := &domain.URL{ID: "1", ShortCode: "promo", OriginalURL: "https://example.com", Version: 2}
+
+	tests := []struct {
+		name           string
+		ifMatch        string
+		outcome        domain.UpsertOutcome
+		err            error
+		expectedStatus int
+	}{
+		{name: "created", outcome: domain.UpsertCreated, expectedStatus: http.StatusCreated},
+		{name: "updated", ifMatch: `"abc"`, outcome: domain.UpsertUpdated, expectedStatus: http.StatusOK},
+		{name: "unchanged", outcome: domain.UpsertUnchanged, expectedStatus: http.StatusOK},
+		{name: "stale ETag", ifMatch: `"old"`, err: domain.ErrPreconditionFailed, expectedStatus: http.StatusPreconditionFailed},
+		{name: "lost a concurrent update", err: domain.ErrVersionConflict, expectedStatus: http.StatusPreconditionFailed},
+		{name: "not the owner", err: domain.ErrForbidden, expectedStatus: http.StatusForbidden},
+		{name: "invalid destination", err: fmt.Errorf("validation failed: %w", domain.ErrInvalidURL), expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			cond := domain.Precondition{IfMatch: tt.ifMatch}
+			if tt.err != nil {
+				mockService.On("UpsertURL", mock.Anything, "promo", "https://example.com", cond).Return(nil, domain.UpsertOutcome(""), tt.err)
+			} else {
+				mockService.On("UpsertURL", mock.Anything, "promo", "https://example.com", cond).Return(url, tt.outcome, nil)
+			}
+
+			req := httptest.NewRequest("PUT", "/api/v1/urls/promo", bytes.NewBufferString(`{"url": "https://example.com"}`))
+			req.SetPathValue("alias", "promo")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.UpsertURL(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.err != nil {
+				assert.Empty(t, w.Header().Get("ETag"))
+				return
+			}
+			assert.Equal(t, url.ETag(), w.Header().Get("ETag"))
+			assert.Contains(t, w.Body.String(), `"result":"`+string(tt.outcome)+`"`)
+		})
+	}
+}
+
 // ==================== HELPER FUNCTIONS ====================
 
 func stringPtr(s string) *string {
 	return &s
 }
+
+// ==================== CLONE URL TESTS ====================
+
+func TestCloneURL(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+	}{
+		{name: "cloned", body: `{"url":"https://example.com/summer"}`, expectCall: true, expectedStatus: http.StatusCreated},
+		{name: "missing URL", body: `{}`, expectedStatus: http.StatusBadRequest},
+		{name: "invalid JSON", body: `{`, expectedStatus: http.StatusBadRequest},
+		{name: "unknown source", body: `{"url":"https://example.com/summer"}`, serviceErr: domain.ErrURLNotFound, expectCall: true, expectedStatus: http.StatusNotFound},
+		{name: "someone else's link", body: `{"url":"https://example.com/summer"}`, serviceErr: domain.ErrForbidden, expectCall: true, expectedStatus: http.StatusForbidden},
+		{name: "invalid destination", body: `{"url":"https://example.com/summer"}`, serviceErr: domain.ErrInvalidURL, expectCall: true, expectedStatus: http.StatusBadRequest},
+		{name: "database down", body: `{"url":"https://example.com/summer"}`, serviceErr: assert.AnError, expectCall: true, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			if tt.expectCall {
+				var url *domain.URL
+				if tt.serviceErr == nil {
+					url = &domain.URL{
+						ID:              "456",
+						ShortCode:       "xyz789",
+						OriginalURL:     "https://example.com/summer",
+						LanguageTargets: map[string]string{"fr": "https://example.com/fr/summer"},
+					}
+				}
+				mockService.On("CloneURL", mock.Anything, "123", "https://example.com/summer", "").Return(url, tt.serviceErr)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/urls/123/clone", bytes.NewBufferString(tt.body))
+			req.SetPathValue("id", "123")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.CloneURL(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				assert.Contains(t, w.Body.String(), `"short_url":"http://localhost:8080/xyz789"`)
+				assert.Contains(t, w.Body.String(), `"language_targets":{"fr":"https://example.com/fr/summer"}`)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	v2 "url-shortener/internal/api/v2"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/ctxutil"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/validation"
+)
+
+// API v2 handlers
+// They reuse the same service layer as v1 - only the HTTP contract differs
+
+// CreateURLV2 handles POST /api/v2/urls
+func (h *Handler) CreateURLV2(w http.ResponseWriter, r *http.Request) {
+	var req v2.CreateLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorV2(w, r, http.StatusBadRequest, v2.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	if errs := validation.Struct(&req); errs != nil {
+		respondJSON(w, http.StatusBadRequest, v2.Envelope{
+			Error: &v2.Error{Code: v2.CodeValidation, Message: errs.Error(), Details: errs.Map()},
+			Meta:  metaV2(r),
+		})
+		return
+	}
+
+	if status, message, ok := h.checkCaptcha(r, req.CaptchaToken); !ok {
+		code := v2.CodeCaptchaRequired
+		if status == http.StatusServiceUnavailable {
+			code = v2.CodeUnavailable
+		}
+		respondErrorV2(w, r, status, code, message)
+		return
+	}
+
+	var expiresIn time.Duration
+	if req.ExpiresIn != "" {
+		expiresIn, _ = time.ParseDuration(req.ExpiresIn) // Checked by the duration rule
+	}
+
+	var opts []domain.URLOption
+	if req.MaxClicks != 0 {
+		opts = append(opts, domain.WithClickLimit(req.MaxClicks))
+	}
+	if req.Domain != "" {
+		opts = append(opts, domain.WithDomain(req.Domain))
+	}
+	if len(req.LanguageTargets) > 0 {
+		opts = append(opts, domain.WithLanguageTargets(req.LanguageTargets))
+	}
+	if req.Schedule != nil {
+		opts = append(opts, domain.WithSchedule(scheduleFromV2(req.Schedule)))
+	}
+	if req.Signed {
+		opts = append(opts, domain.WithSigning())
+	}
+	if req.SingleUse {
+		opts = append(opts, domain.WithSingleUse())
+	}
+	if withoutAnalytics(req.Analytics) {
+		opts = append(opts, domain.WithoutAnalytics())
+	}
+	if req.Description != "" {
+		opts = append(opts, domain.WithDescription(req.Description))
+	}
+	if len(req.CustomMetadata) > 0 {
+		opts = append(opts, domain.WithCustomMetadata(req.CustomMetadata))
+	}
+	if req.Visibility != "" {
+		visibility, _ := domain.ParseLinkVisibility(req.Visibility) // Validated above
+		opts = append(opts, domain.WithVisibility(visibility))
+	}
+
+	url, err := h.urlService.CreateShortURL(r.Context(), req.URL, req.CustomAlias, auth.FromContext(r.Context()).ID, expiresIn, opts...)
+	h.writeQuotaHeaders(w, r)
+	if err != nil {
+		status := createErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to create URL", "error", err)
+			respondErrorV2(w, r, status, v2.CodeInternal, "Failed to create URL")
+			return
+		}
+		respondErrorV2(w, r, status, errorCodeV2(status), err.Error())
+		return
+	}
+
+	link := h.linkV2(url)
+	link.SigningSecret = url.SigningSecret // The creator's only chance to see it
+	respondV2(w, r, http.StatusCreated, link)
+}
+
+// GetURLStatsV2 handles GET /api/v2/urls/{code}/stats
+func (h *Handler) GetURLStatsV2(w http.ResponseWriter, r *http.Request) {
+	shortCode := r.PathValue("code")
+
+	url, clicks, err := h.urlService.GetURLStats(r.Context(), shortCode)
+	if errors.Is(err, domain.ErrForbidden) {
+		respondErrorV2(w, r, http.StatusForbidden, v2.CodeForbidden, "You don't have access to this URL")
+		return
+	}
+	if err != nil {
+		respondErrorV2(w, r, http.StatusNotFound, v2.CodeNotFound, "URL not found")
+		return
+	}
+
+	recentClicks := make([]v2.Click, 0, len(clicks))
+	for _, click := range clicks {
+		recentClicks = append(recentClicks, v2.Click{
+			ClickedAt:   click.ClickedAt,
+			CountryCode: click.CountryCode,
+			City:        click.City,
+			Channel:     string(click.Channel),
+			Browser:     click.Browser,
+			OS:          click.OS,
+			DeviceType:  click.DeviceType,
+			Region:      click.Region,
+		})
+	}
+
+	respondV2(w, r, http.StatusOK, v2.LinkStats{
+		Link:         h.linkV2(url),
+		Analytics:    h.analyticsStatusV2(w, url),
+		RecentClicks: recentClicks,
+	})
+}
+
+// linkV2 converts a domain URL into the v2 Link representation
+func (h *Handler) linkV2(url *domain.URL) v2.Link {
+	return v2.Link{
+		ID:          url.ID,
+		ShortCode:   url.ShortCode,
+		ShortURL:    h.shortURL(url),
+		OriginalURL: url.OriginalURL,
+		ResolvedURL: url.ResolvedURL,
+		Clicks:      url.Clicks,
+		MaxClicks:   url.MaxClicks,
+		CreatedAt:   url.CreatedAt,
+		ExpiresAt:   url.ExpiresAt,
+		Metadata:    linkMetadataV2(url.Metadata),
+
+		LanguageTargets: url.LanguageTargets,
+		Schedule:        scheduleV2(url.Schedule),
+		Signed:          url.RequiresSignature(),
+		SingleUse:       url.SingleUse,
+		UsedAt:          url.UsedAt,
+		Analytics:       analyticsOptOut(url.AnalyticsDisabled),
+
+		Description:    url.Description,
+		CustomMetadata: url.CustomMetadata,
+
+		Visibility:           string(url.Visibility),
+		DestinationEncrypted: url.DestinationSealed(),
+	}
+}
+
+// linkMetadataV2 converts the destination metadata (nil stays nil)
+func linkMetadataV2(meta *domain.LinkMetadata) *v2.LinkMetadata {
+	if meta == nil {
+		return nil
+	}
+	return &v2.LinkMetadata{
+		Title:       meta.Title,
+		Description: meta.Description,
+		FaviconURL:  meta.FaviconURL,
+		FetchedAt:   meta.FetchedAt,
+	}
+}
+
+// respondV2 sends a successful v2 envelope
+func respondV2(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}) {
+	respondJSON(w, statusCode, v2.Envelope{
+		Data: data,
+		Meta: metaV2(r),
+	})
+}
+
+// respondErrorV2 sends a v2 error envelope
+// Only the message is translated: clients branch on the code, which never is
+func respondErrorV2(w http.ResponseWriter, r *http.Request, statusCode int, code, message string) {
+	respondJSON(w, statusCode, v2.Envelope{
+		Error: &v2.Error{Code: code, Message: translate(w, message)},
+		Meta:  metaV2(r),
+	})
+}
+
+// metaV2 builds response metadata from the request
+func metaV2(r *http.Request) *v2.Meta {
+	requestID := ctxutil.RequestID(r.Context())
+	if requestID == "" {
+		return nil
+	}
+	return &v2.Meta{RequestID: requestID}
+}
+
+// errorCodeV2 maps an HTTP status to the matching v2 error code
+func errorCodeV2(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return v2.CodeValidation
+	case http.StatusForbidden:
+		return v2.CodeForbidden
+	case http.StatusNotFound:
+		return v2.CodeNotFound
+	case http.StatusGone:
+		return v2.CodeGone
+	case http.StatusConflict:
+		return v2.CodeConflict
+	case http.StatusTooManyRequests:
+		return v2.CodeQuotaExceeded
+	default:
+		return v2.CodeInternal
+	}
+}
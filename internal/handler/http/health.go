@@ -0,0 +1,67 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DependencyCheck is one dependency the readiness probe verifies
+type DependencyCheck struct {
+	Name  string
+	Check func(ctx context.Context) error // e.g. db.Ping, redisClient.Ping(ctx).Err
+}
+
+// ReadinessCheck handles GET /health/ready
+//
+// LIVENESS vs READINESS:
+//   - /health/live answers "is the process alive?" - failing it restarts the pod
+//   - /health/ready answers "can it serve traffic?" - failing it only takes the
+//     pod out of the load balancer until Postgres/Redis are reachable again
+//
+// Checks run in parallel, each bounded by timeout
+func ReadinessCheck(timeout time.Duration, checks ...DependencyCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		results := make(map[string]string, len(checks))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		ready := true
+
+		for _, check := range checks {
+			wg.Add(1)
+			go func(check DependencyCheck) {
+				defer wg.Done()
+				status := "ok"
+				if err := check.Check(ctx); err != nil {
+					status = err.Error()
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				results[check.Name] = status
+				if status != "ok" {
+					ready = false
+				}
+			}(check)
+		}
+		wg.Wait()
+
+		statusCode := http.StatusOK
+		status := "ready"
+		if !ready {
+			statusCode = http.StatusServiceUnavailable
+			status = "not_ready"
+		}
+
+		// Still a data body at 503: the checks map IS the answer, not an error
+		respondSuccess(w, statusCode, map[string]interface{}{
+			"status": status,
+			"checks": results,
+			"time":   time.Now().Format(time.RFC3339),
+		}, "")
+	}
+}
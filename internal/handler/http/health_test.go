@@ -0,0 +1,42 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessCheck(t *testing.T) {
+	healthy := DependencyCheck{Name: "postgres", Check: func(context.Context) error { return nil }}
+	broken := DependencyCheck{Name: "redis", Check: func(context.Context) error { return errors.New("connection refused") }}
+
+	tests := []struct {
+		name           string
+		checks         []DependencyCheck
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "all dependencies up", checks: []DependencyCheck{healthy}, expectedStatus: http.StatusOK, expectedBody: `"status":"ready"`},
+		{name: "redis down", checks: []DependencyCheck{healthy, broken}, expectedStatus: http.StatusServiceUnavailable, expectedBody: `"redis":"connection refused"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := ReadinessCheck(time.Second, tt.checks...)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler(w, httptest.NewRequest("GET", "/health/ready", nil))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
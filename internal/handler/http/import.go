@@ -0,0 +1,156 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/importer"
+)
+
+const (
+	maxImportBytes = 10 << 20 // 10 MB upload limit
+	maxImportRows  = 50000    // Rows per file
+	syncImportRows = 500      // Larger files always run as a background job
+)
+
+// Importer is the service the import endpoints need
+// Implemented by service.ImportService
+type Importer interface {
+	Import(ctx context.Context, rows []domain.ImportRow, createdBy string, dryRun bool) *domain.ImportJob
+	StartImport(ctx context.Context, rows []domain.ImportRow, createdBy string, dryRun bool) (*domain.ImportJob, error)
+	GetJob(ctx context.Context, id string) (*domain.ImportJob, error) // domain.ErrImportJobNotFound if unknown
+}
+
+// ImportHandler serves the bulk import endpoints
+type ImportHandler struct {
+	importer Importer
+	logger   *slog.Logger
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(importer Importer, logger *slog.Logger) *ImportHandler {
+	return &ImportHandler{
+		importer: importer,
+		logger:   logger,
+	}
+}
+
+// Import handles POST /api/v1/import?dry_run=true&async=true
+//
+// The body is the CSV file itself (Content-Type: text/csv) or a multipart
+// form with the file in the "file" field. Small files are imported right away
+// (200 with per-row results); large files or async=true return 202 and a job
+// to poll at GET /api/v1/import/{id}
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	file, err := importFile(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	rows, err := importer.ParseCSV(file, maxImportRows)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, "Import file is too large")
+			return
+		}
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	dryRun := query.Get("dry_run") == "true"
+	createdBy := auth.FromContext(r.Context()).ID
+
+	if query.Get("async") == "true" || len(rows) > syncImportRows {
+		job, err := h.importer.StartImport(r.Context(), rows, createdBy, dryRun)
+		if err != nil {
+			h.logger.Error("Failed to start import", "error", err)
+			respondError(w, http.StatusInternalServerError, "Failed to start import")
+			return
+		}
+		h.logger.Info("Import started", "job_id", job.ID, "rows", len(rows), "dry_run", dryRun)
+
+		w.Header().Set("Location", "/api/v1/import/"+job.ID)
+		respondSuccess(w, http.StatusAccepted, importJobResponse(job), "Import started")
+		return
+	}
+
+	job := h.importer.Import(r.Context(), rows, createdBy, dryRun)
+	respondSuccess(w, http.StatusOK, importJobResponse(job), "")
+}
+
+// GetImportJob handles GET /api/v1/import/{id}
+// Only the caller who started the import (or an admin) can see it
+func (h *ImportHandler) GetImportJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.importer.GetJob(r.Context(), r.PathValue("id"))
+	if err != nil && !errors.Is(err, domain.ErrImportJobNotFound) {
+		h.logger.Error("Failed to get import job", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to get import job")
+		return
+	}
+	principal := auth.FromContext(r.Context())
+	if err != nil || (!principal.Admin && job.CreatedBy != principal.ID) {
+		respondError(w, http.StatusNotFound, "Import job not found")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, importJobResponse(job), "")
+}
+
+// importFile returns the uploaded CSV from a multipart form or the raw body
+func importFile(r *http.Request) (io.ReadCloser, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("multipart upload must contain a \"file\" field")
+	}
+	return file, nil
+}
+
+// importJobResponse converts a job to its API representation
+func importJobResponse(job *domain.ImportJob) v1.ImportJobResponse {
+	results := make([]v1.ImportRowResult, len(job.Results))
+	for i, result := range job.Results {
+		results[i] = v1.ImportRowResult{
+			Line:           result.Line,
+			Alias:          result.Alias,
+			URL:            result.URL,
+			ShortCode:      result.ShortCode,
+			AliasPreserved: result.AliasPreserved,
+			Status:         string(result.Status),
+			Error:          result.Error,
+		}
+	}
+
+	return v1.ImportJobResponse{
+		ID:         job.ID,
+		State:      string(job.State),
+		DryRun:     job.DryRun,
+		Total:      job.Total,
+		Processed:  job.Processed,
+		Created:    job.Created,
+		Failed:     job.Failed,
+		Progress:   job.Progress(),
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+		Results:    results,
+		Error:      job.Error,
+	}
+}
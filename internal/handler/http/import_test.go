@@ -0,0 +1,148 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockImporter is a mock implementation of Importer
+type MockImporter struct {
+	mock.Mock
+}
+
+func (m *MockImporter) Import(ctx context.Context, rows []domain.ImportRow, createdBy string, dryRun bool) *domain.ImportJob {
+	args := m.Called(ctx, rows, createdBy, dryRun)
+	return args.Get(0).(*domain.ImportJob)
+}
+
+func (m *MockImporter) StartImport(ctx context.Context, rows []domain.ImportRow, createdBy string, dryRun bool) (*domain.ImportJob, error) {
+	args := m.Called(ctx, rows, createdBy, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ImportJob), args.Error(1)
+}
+
+func (m *MockImporter) GetJob(ctx context.Context, id string) (*domain.ImportJob, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ImportJob), args.Error(1)
+}
+
+func setupImportHandler() (*ImportHandler, *MockImporter) {
+	mockImporter := new(MockImporter)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewImportHandler(mockImporter, logger), mockImporter
+}
+
+func TestImport_BitlyCSV(t *testing.T) {
+	// Arrange
+	handler, mockImporter := setupImportHandler()
+
+	csv := "Title,Long URL,Bitlink,Created\n" +
+		"Sale,https://example.com/sale,bit.ly/summer-sale,2024-06-01\n" +
+		"Docs,https://example.com/docs,https://bit.ly/3xYz,2024-06-02\n"
+	expectedRows := []domain.ImportRow{
+		{Line: 2, Alias: "summer-sale", URL: "https://example.com/sale"},
+		{Line: 3, Alias: "3xYz", URL: "https://example.com/docs"},
+	}
+
+	job := domain.NewImportJob("anonymous", 2, true)
+	job.State = domain.ImportCompleted
+	mockImporter.On("Import", mock.Anything, expectedRows, "anonymous", true).Return(job)
+
+	req := httptest.NewRequest("POST", "/api/v1/import?dry_run=true", strings.NewReader(csv))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.Import(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"state":"completed"`)
+	mockImporter.AssertExpectations(t)
+}
+
+func TestImport_LargeFileRunsAsJob(t *testing.T) {
+	// Arrange
+	handler, mockImporter := setupImportHandler()
+
+	var csv strings.Builder
+	for i := 0; i < syncImportRows+1; i++ {
+		fmt.Fprintf(&csv, "alias%d,https://example.com/%d\n", i, i)
+	}
+
+	job := domain.NewImportJob("anonymous", syncImportRows+1, false)
+	mockImporter.On("StartImport", mock.Anything, mock.Anything, "anonymous", false).Return(job, nil)
+
+	req := httptest.NewRequest("POST", "/api/v1/import", strings.NewReader(csv.String()))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.Import(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "/api/v1/import/"+job.ID, w.Header().Get("Location"))
+	mockImporter.AssertNotCalled(t, "Import", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestImport_RejectsFileWithoutURLs(t *testing.T) {
+	// Arrange
+	handler, _ := setupImportHandler()
+
+	req := httptest.NewRequest("POST", "/api/v1/import", strings.NewReader("name,notes\nfoo,bar\n"))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.Import(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetImportJob_OnlyOwnerCanSee(t *testing.T) {
+	// Arrange
+	handler, mockImporter := setupImportHandler()
+
+	job := domain.NewImportJob("user1", 10, false)
+	mockImporter.On("GetJob", mock.Anything, job.ID).Return(job, nil)
+
+	tests := []struct {
+		name           string
+		principal      *auth.Principal
+		expectedStatus int
+	}{
+		{name: "owner", principal: &auth.Principal{ID: "user1"}, expectedStatus: http.StatusOK},
+		{name: "admin", principal: &auth.Principal{ID: "admin", Admin: true}, expectedStatus: http.StatusOK},
+		{name: "someone else", principal: &auth.Principal{ID: "user2"}, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/import/"+job.ID, nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			req.SetPathValue("id", job.ID)
+			w := httptest.NewRecorder()
+
+			handler.GetImportJob(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/jobs"
+)
+
+// JobReader is what the job status endpoint needs
+// Implemented by jobs.Queue
+type JobReader interface {
+	Get(ctx context.Context, id string) (*jobs.Job, error)
+}
+
+// JobHandler serves the status of background jobs
+type JobHandler struct {
+	jobs   JobReader
+	logger *slog.Logger
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(jobs JobReader, logger *slog.Logger) *JobHandler {
+	return &JobHandler{
+		jobs:   jobs,
+		logger: logger,
+	}
+}
+
+// GetJob handles GET /api/v1/jobs/{id} (authenticated)
+// Only whoever enqueued the job (and admins) can see it; to everyone else
+// it doesn't exist, so job IDs can't be probed
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, jobs.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "Job not found")
+			return
+		}
+		h.logger.Error("Failed to get job", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to get job")
+		return
+	}
+
+	principal := auth.FromContext(r.Context())
+	if !principal.Admin && job.CreatedBy != principal.ID {
+		respondError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, jobResponse(job), "")
+}
+
+// jobResponse converts a job to its API representation
+func jobResponse(job *jobs.Job) v1.JobResponse {
+	response := v1.JobResponse{
+		ID:          job.ID,
+		Kind:        job.Kind,
+		Status:      string(job.Status),
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		Error:       job.Error,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.FinishedAt,
+	}
+
+	if job.Status == jobs.StatusSucceeded {
+		response.Result = job.Result
+	}
+	// A pending job that already ran is waiting for its retry
+	if job.Status == jobs.StatusPending && job.Attempts > 0 {
+		retryAt := job.RunAt
+		response.RetryAt = &retryAt
+	}
+
+	return response
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/jobs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockJobReader is a mock implementation of JobReader
+type MockJobReader struct {
+	mock.Mock
+}
+
+func (m *MockJobReader) Get(ctx context.Context, id string) (*jobs.Job, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*jobs.Job), args.Error(1)
+}
+
+func setupJobHandler() (*JobHandler, *MockJobReader) {
+	mockReader := new(MockJobReader)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewJobHandler(mockReader, logger), mockReader
+}
+
+func getJobRequest(id string, principal *auth.Principal) *http.Request {
+	req := httptest.NewRequest("GET", "/api/v1/jobs/"+id, nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	req.SetPathValue("id", id)
+	return req
+}
+
+func TestGetJob_Access(t *testing.T) {
+	tests := []struct {
+		name           string
+		principal      *auth.Principal
+		getErr         error
+		expectedStatus int
+	}{
+		{name: "creator", principal: &auth.Principal{ID: "user1"}, expectedStatus: http.StatusOK},
+		{name: "admin", principal: &auth.Principal{ID: "admin", Admin: true}, expectedStatus: http.StatusOK},
+		{name: "someone else", principal: &auth.Principal{ID: "user2"}, expectedStatus: http.StatusNotFound},
+		{name: "unknown job", principal: &auth.Principal{ID: "user1"}, getErr: jobs.ErrNotFound, expectedStatus: http.StatusNotFound},
+		{name: "store error", principal: &auth.Principal{ID: "user1"}, getErr: errors.New("db down"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockReader := setupJobHandler()
+			job := jobs.NewJob("links.recheck", nil, "user1", 3)
+			if tt.getErr != nil {
+				mockReader.On("Get", mock.Anything, job.ID).Return(nil, tt.getErr)
+			} else {
+				mockReader.On("Get", mock.Anything, job.ID).Return(job, nil)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.GetJob(w, getJobRequest(job.ID, tt.principal))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"id":"`+job.ID+`"`)
+				assert.Contains(t, w.Body.String(), `"status":"pending"`)
+			}
+		})
+	}
+}
+
+func TestGetJob_Response(t *testing.T) {
+	finishedAt := time.Now()
+
+	tests := []struct {
+		name        string
+		change      func(job *jobs.Job)
+		wantResult  bool
+		wantRetryAt bool
+	}{
+		{
+			name:   "waiting for the first run",
+			change: func(job *jobs.Job) {},
+		},
+		{
+			name: "waiting for a retry",
+			change: func(job *jobs.Job) {
+				job.Attempts = 1
+				job.Error = "upstream down"
+				job.RunAt = time.Now().Add(time.Minute)
+			},
+			wantRetryAt: true,
+		},
+		{
+			name: "succeeded",
+			change: func(job *jobs.Job) {
+				job.Status = jobs.StatusSucceeded
+				job.Attempts = 1
+				job.Result = json.RawMessage(`{"checked":12}`)
+				job.FinishedAt = &finishedAt
+			},
+			wantResult: true,
+		},
+		{
+			name: "failed",
+			change: func(job *jobs.Job) {
+				job.Status = jobs.StatusFailed
+				job.Attempts = 3
+				job.Error = "upstream down"
+				job.FinishedAt = &finishedAt
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockReader := setupJobHandler()
+			job := jobs.NewJob("links.recheck", nil, "user1", 3)
+			tt.change(job)
+			mockReader.On("Get", mock.Anything, job.ID).Return(job, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.GetJob(w, getJobRequest(job.ID, &auth.Principal{ID: "user1"}))
+
+			// Assert
+			assert.Equal(t, http.StatusOK, w.Code)
+			body := w.Body.String()
+			assert.Equal(t, tt.wantResult, strings.Contains(body, `"result":{"checked":12}`))
+			assert.Equal(t, tt.wantRetryAt, strings.Contains(body, `"retry_at"`))
+			if job.Error != "" {
+				assert.Contains(t, body, `"error":"upstream down"`)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+// APIKeyManager is the service the API key endpoints need
+// Implemented by service.APIKeyService
+type APIKeyManager interface {
+	CreateKey(ctx context.Context, key *domain.APIKey) (string, error)
+	ListKeys(ctx context.Context) ([]*domain.APIKey, error)
+	RotateKey(ctx context.Context, id string, rotation domain.APIKeyRotation) (string, *domain.APIKey, error)
+	RevokeKey(ctx context.Context, id string) error
+}
+
+// APIKeyHandler serves /api/v1/keys to workspace owners
+type APIKeyHandler struct {
+	keys   APIKeyManager
+	logger *slog.Logger
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(keys APIKeyManager, logger *slog.Logger) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys, logger: logger}
+}
+
+// CreateKey handles POST /api/v1/keys (workspace owners)
+// The secret is in the response once and never again
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var req v1.APIKeyRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	key := &domain.APIKey{Workspace: req.Workspace, Name: req.Name, Scopes: req.Scopes, ExpiresAt: req.ExpiresAt}
+	token, err := h.keys.CreateKey(r.Context(), key)
+	if err != nil {
+		h.respondKeyError(w, err, "Failed to create API key")
+		return
+	}
+
+	h.logger.Info("API key created", "id", key.ID, "workspace", key.Workspace, "scopes", key.Scopes)
+	response := apiKeyResponse(key)
+	response.Token = token
+	respondSuccess(w, http.StatusCreated, response, "Store this key now: it is not shown again")
+}
+
+// ListKeys handles GET /api/v1/keys (workspace owners)
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	window, err := parsePageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	keys, err := h.keys.ListKeys(r.Context())
+	if err != nil {
+		h.respondKeyError(w, err, "Failed to list API keys")
+		return
+	}
+
+	response := make([]v1.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, apiKeyResponse(key))
+	}
+	items, pagination := paginate(response, window)
+	respondList(w, items, pagination)
+}
+
+// RotateKey handles POST /api/v1/keys/{id}/rotate (workspace owners)
+// The key keeps its name and scopes but gets a new secret; the old one
+// keeps working for a grace period. The body is optional.
+func (h *APIKeyHandler) RotateKey(w http.ResponseWriter, r *http.Request) {
+	var req v1.RotateAPIKeyRequest
+	if r.ContentLength != 0 && !decodeRequest(w, r, &req) {
+		return
+	}
+
+	rotation := domain.APIKeyRotation{ExpiresAt: req.ExpiresAt}
+	if req.GracePeriodHours != nil {
+		grace := time.Duration(*req.GracePeriodHours) * time.Hour
+		rotation.Grace = &grace
+	}
+	token, key, err := h.keys.RotateKey(r.Context(), r.PathValue("id"), rotation)
+	if err != nil {
+		h.respondKeyError(w, err, "Failed to rotate API key")
+		return
+	}
+
+	h.logger.Info("API key rotated", "id", key.ID, "workspace", key.Workspace, "previous_secret_expires_at", key.PreviousExpiresAt)
+	response := apiKeyResponse(key)
+	response.Token = token
+	respondSuccess(w, http.StatusOK, response, "Store this key now: it is not shown again")
+}
+
+// RevokeKey handles DELETE /api/v1/keys/{id} (workspace owners)
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.keys.RevokeKey(r.Context(), id); err != nil {
+		h.respondKeyError(w, err, "Failed to revoke API key")
+		return
+	}
+
+	h.logger.Info("API key revoked", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondKeyError maps API key errors to HTTP statuses
+func (h *APIKeyHandler) respondKeyError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		respondError(w, http.StatusForbidden, "Only workspace owners can manage API keys, with a key that has the admin scope")
+	case errors.Is(err, domain.ErrAPIKeyNotFound):
+		respondError(w, http.StatusNotFound, "API key not found")
+	case errors.Is(err, domain.ErrInvalidAPIKey), errors.Is(err, domain.ErrAPIKeyExpiry):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Error(message, "error", err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+// apiKeyResponse converts a key for the API
+func apiKeyResponse(key *domain.APIKey) v1.APIKeyResponse {
+	return v1.APIKeyResponse{
+		ID:         key.ID,
+		Workspace:  key.Workspace,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RotatedAt:  key.RotatedAt,
+		ExpiresAt:  key.ExpiresAt,
+
+		PreviousSecretExpiresAt: key.PreviousExpiresAt,
+	}
+}
@@ -0,0 +1,218 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAPIKeyManager is a mock implementation of APIKeyManager
+type MockAPIKeyManager struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyManager) CreateKey(ctx context.Context, key *domain.APIKey) (string, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAPIKeyManager) ListKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyManager) RotateKey(ctx context.Context, id string, rotation domain.APIKeyRotation) (string, *domain.APIKey, error) {
+	args := m.Called(ctx, id, rotation)
+	if args.Get(1) == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+	return args.String(0), args.Get(1).(*domain.APIKey), args.Error(2)
+}
+
+func (m *MockAPIKeyManager) RevokeKey(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func newTestAPIKeyHandler() (*APIKeyHandler, *MockAPIKeyManager) {
+	keys := new(MockAPIKeyManager)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewAPIKeyHandler(keys, logger), keys
+}
+
+func TestCreateKey(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		createErr      error
+		expectCreate   bool
+		expectedStatus int
+		expectedWS     string
+	}{
+		{name: "created", body: `{"name": "CI", "scopes": ["urls:write"]}`, expectCreate: true, expectedStatus: http.StatusCreated},
+		{name: "for a named workspace", body: `{"name": "CI", "scopes": ["urls:write"], "workspace": "acme"}`, expectCreate: true, expectedStatus: http.StatusCreated, expectedWS: "acme"},
+		{name: "no scopes", body: `{"name": "CI", "scopes": []}`, expectedStatus: http.StatusBadRequest},
+		{name: "unknown scope", body: `{"name": "CI", "scopes": ["urls:delete"]}`, expectedStatus: http.StatusBadRequest},
+		{name: "no name", body: `{"scopes": ["urls:read"]}`, expectedStatus: http.StatusBadRequest},
+		{name: "expiry in the past", body: `{"name": "CI", "scopes": ["urls:read"], "expires_at": "2020-01-01T00:00:00Z"}`, createErr: domain.ErrAPIKeyExpiry, expectCreate: true, expectedStatus: http.StatusBadRequest},
+		{name: "not an owner", body: `{"name": "CI", "scopes": ["urls:read"]}`, createErr: domain.ErrForbidden, expectCreate: true, expectedStatus: http.StatusForbidden},
+		{name: "database down", body: `{"name": "CI", "scopes": ["urls:read"]}`, createErr: assert.AnError, expectCreate: true, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, keys := newTestAPIKeyHandler()
+			keys.On("CreateKey", mock.Anything, mock.AnythingOfType("*domain.APIKey")).
+				Run(func(args mock.Arguments) {
+					key := args.Get(1).(*domain.APIKey)
+					key.ID, key.Prefix, key.CreatedAt = "k1", "sk_abcdefgh", time.Now()
+				}).
+				Return("sk_abcdefghsecret", tt.createErr).Maybe()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/keys", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.CreateKey(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if !tt.expectCreate {
+				keys.AssertNotCalled(t, "CreateKey", mock.Anything, mock.Anything)
+			}
+			if tt.expectedStatus == http.StatusCreated {
+				var body struct {
+					Data map[string]interface{} `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, "sk_abcdefghsecret", body.Data["token"])
+				assert.Equal(t, "sk_abcdefgh", body.Data["prefix"])
+				assert.Equal(t, []interface{}{"urls:write"}, body.Data["scopes"])
+				assert.Equal(t, tt.expectedWS, body.Data["workspace"])
+			}
+		})
+	}
+}
+
+func TestListKeys(t *testing.T) {
+	// Arrange
+	handler, keys := newTestAPIKeyHandler()
+	used := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	keys.On("ListKeys", mock.Anything).Return([]*domain.APIKey{
+		{ID: "k1", Name: "CI", Prefix: "sk_abcdefgh", Scopes: []string{"urls:write"}, LastUsedAt: &used},
+		{ID: "k2", Name: "Dashboard", Prefix: "sk_ijklmnop", Scopes: []string{"stats:read"}},
+	}, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/keys", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ListKeys(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"last_used_at":"2026-03-01T12:00:00Z"`)
+	assert.Contains(t, w.Body.String(), `"name":"Dashboard"`)
+	assert.NotContains(t, w.Body.String(), `"token"`, "secrets are never listed")
+}
+
+func TestRotateKey(t *testing.T) {
+	twoHours := 2 * time.Hour
+	noGrace := time.Duration(0)
+	nextYear := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	previousUntil := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		body             string
+		expectedRotation domain.APIKeyRotation
+		rotateErr        error
+		expectRotate     bool
+		expectedStatus   int
+	}{
+		{name: "rotated", expectRotate: true, expectedStatus: http.StatusOK},
+		{name: "grace period", body: `{"grace_period_hours": 2}`, expectedRotation: domain.APIKeyRotation{Grace: &twoHours}, expectRotate: true, expectedStatus: http.StatusOK},
+		{name: "no grace period", body: `{"grace_period_hours": 0}`, expectedRotation: domain.APIKeyRotation{Grace: &noGrace}, expectRotate: true, expectedStatus: http.StatusOK},
+		{name: "new expiry", body: `{"expires_at": "2027-01-01T00:00:00Z"}`, expectedRotation: domain.APIKeyRotation{ExpiresAt: &nextYear}, expectRotate: true, expectedStatus: http.StatusOK},
+		{name: "negative grace period", body: `{"grace_period_hours": -1}`, expectedStatus: http.StatusBadRequest},
+		{name: "expiry in the past", rotateErr: domain.ErrAPIKeyExpiry, expectRotate: true, expectedStatus: http.StatusBadRequest},
+		{name: "unknown key", rotateErr: domain.ErrAPIKeyNotFound, expectRotate: true, expectedStatus: http.StatusNotFound},
+		{name: "not an owner", rotateErr: domain.ErrForbidden, expectRotate: true, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, keys := newTestAPIKeyHandler()
+			if tt.rotateErr != nil {
+				keys.On("RotateKey", mock.Anything, "k1", tt.expectedRotation).Return("", nil, tt.rotateErr)
+			} else {
+				keys.On("RotateKey", mock.Anything, "k1", tt.expectedRotation).
+					Return("sk_newsecret", &domain.APIKey{ID: "k1", Name: "CI", PreviousExpiresAt: &previousUntil}, nil)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/keys/k1/rotate", strings.NewReader(tt.body))
+			req.SetPathValue("id", "k1")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.RotateKey(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectRotate {
+				keys.AssertExpectations(t)
+			} else {
+				keys.AssertNotCalled(t, "RotateKey", mock.Anything, mock.Anything, mock.Anything)
+			}
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"token":"sk_newsecret"`)
+				assert.Contains(t, w.Body.String(), `"previous_secret_expires_at":"2026-03-02T12:00:00Z"`)
+			}
+		})
+	}
+}
+
+func TestRevokeKey(t *testing.T) {
+	tests := []struct {
+		name           string
+		revokeErr      error
+		expectedStatus int
+	}{
+		{name: "revoked", expectedStatus: http.StatusNoContent},
+		{name: "unknown key", revokeErr: domain.ErrAPIKeyNotFound, expectedStatus: http.StatusNotFound},
+		{name: "database down", revokeErr: fmt.Errorf("wrapped: %w", assert.AnError), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, keys := newTestAPIKeyHandler()
+			keys.On("RevokeKey", mock.Anything, "k1").Return(tt.revokeErr)
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/keys/k1", nil)
+			req.SetPathValue("id", "k1")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.RevokeKey(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			keys.AssertExpectations(t)
+		})
+	}
+}
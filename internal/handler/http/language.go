@@ -0,0 +1,68 @@
+package http
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// parseAcceptLanguage returns the languages of an Accept-Language header,
+// most preferred first
+//
+// HOW THE HEADER LOOKS:
+//
+//	Accept-Language: fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5
+//
+// Each language has a quality value q between 0 and 1 (default 1). Higher
+// means preferred; q=0 means "NOT this one". Entries with the same q keep
+// the order the browser sent them in.
+//
+// "*" (any language) is dropped: the fallback is the link's original URL
+// anyway. Malformed entries are skipped instead of failing the redirect.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	var languages []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+			quality = q
+		}
+		if quality == 0 {
+			continue
+		}
+
+		languages = append(languages, weighted{tag: tag, quality: quality})
+	}
+
+	// Stable sort: equal weights stay in header order
+	slices.SortStableFunc(languages, func(a, b weighted) int {
+		switch {
+		case a.quality > b.quality:
+			return -1
+		case a.quality < b.quality:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	tags := make([]string, 0, len(languages))
+	for _, language := range languages {
+		tags = append(tags, language.tag)
+	}
+	return tags
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected []string
+	}{
+		{name: "empty", header: "", expected: []string{}},
+		{name: "single", header: "fr", expected: []string{"fr"}},
+		{name: "ordered by quality", header: "en;q=0.5, fr-CH, de;q=0.8", expected: []string{"fr-ch", "de", "en"}},
+		{name: "equal quality keeps header order", header: "es, pt;q=0.9, it;q=0.9", expected: []string{"es", "pt", "it"}},
+		{name: "q=0 means not this one", header: "fr;q=0, en", expected: []string{"en"}},
+		{name: "wildcard is dropped", header: "de, *;q=0.5", expected: []string{"de"}},
+		{name: "malformed quality is skipped", header: "fr;q=abc, en;q=2, de", expected: []string{"de"}},
+		{name: "whitespace tolerated", header: " fr ; q=0.7 ,en ", expected: []string{"en", "fr"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseAcceptLanguage(tt.header))
+		})
+	}
+}
+
+func TestRedirectURL_LanguageTargets(t *testing.T) {
+	languageURL := func() *domain.URL {
+		return &domain.URL{
+			ID:          "123",
+			ShortCode:   "abc123",
+			OriginalURL: "https://example.com",
+			IsActive:    true,
+			LanguageTargets: map[string]string{
+				"fr":    "https://example.com/fr",
+				"pt-br": "https://example.com/br",
+			},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		acceptLanguage   string
+		expectedLocation string
+	}{
+		{name: "exact tag", acceptLanguage: "pt-BR,pt;q=0.9", expectedLocation: "https://example.com/br"},
+		{name: "primary language of a regional tag", acceptLanguage: "fr-CA", expectedLocation: "https://example.com/fr"},
+		{name: "first matching preference wins", acceptLanguage: "de, fr;q=0.8, pt-br;q=0.5", expectedLocation: "https://example.com/fr"},
+		{name: "refused language is skipped", acceptLanguage: "fr;q=0, en", expectedLocation: "https://example.com"},
+		{name: "no match falls back", acceptLanguage: "ja", expectedLocation: "https://example.com"},
+		{name: "no header falls back", acceptLanguage: "", expectedLocation: "https://example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			mockService.On("GetURL", mock.Anything, "abc123").Return(languageURL(), nil)
+			mockService.On("RecordClick", mock.Anything, clickOn("abc123")).Return(nil).Maybe()
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.RedirectURL(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusFound, w.Code)
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestRedirectURL_NoLanguageTargetsNoVary(t *testing.T) {
+	// Arrange: links without targets stay cacheable for everyone
+	handler, mockService := setupTestHandler()
+	url := &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com", IsActive: true}
+	mockService.On("GetURL", mock.Anything, "abc123").Return(url, nil)
+	mockService.On("RecordClick", mock.Anything, clickOn("abc123")).Return(nil).Maybe()
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Header.Set("Accept-Language", "fr")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.RedirectURL(w, req)
+
+	// Assert
+	assert.Equal(t, "https://example.com", w.Header().Get("Location"))
+	assert.Empty(t, w.Header().Get("Vary"))
+}
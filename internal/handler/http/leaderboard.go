@@ -0,0 +1,82 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+// TopLinksProvider is the service the leaderboard endpoint needs
+// Implemented by service.LeaderboardService
+type TopLinksProvider interface {
+	TopLinks(ctx context.Context, period domain.LeaderboardPeriod, limit int) (*domain.Leaderboard, error)
+}
+
+// LeaderboardHandler serves the caller's top links
+type LeaderboardHandler struct {
+	leaderboard TopLinksProvider
+	baseURL     string
+	logger      *slog.Logger
+}
+
+// NewLeaderboardHandler creates a new leaderboard handler
+func NewLeaderboardHandler(leaderboard TopLinksProvider, baseURL string, logger *slog.Logger) *LeaderboardHandler {
+	return &LeaderboardHandler{leaderboard: leaderboard, baseURL: baseURL, logger: logger}
+}
+
+// TopLinks handles GET /api/v1/stats/top (authenticated)
+//
+//	?period=24h|7d|30d   (default: 7d)
+//	&limit=10            (1-100, default: 10)
+//
+// Powers the dashboard home page: the caller's links ranked by clicks
+func (h *LeaderboardHandler) TopLinks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 0
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > domain.MaxLeaderboardLimit {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+		limit = parsed
+	}
+
+	board, err := h.leaderboard.TopLinks(r.Context(), domain.LeaderboardPeriod(query.Get("period")), limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrForbidden):
+			respondError(w, http.StatusForbidden, "Authentication required")
+		case errors.Is(err, domain.ErrInvalidPeriod):
+			respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			h.logger.Error("Failed to rank links", "error", err)
+			respondError(w, http.StatusInternalServerError, "Failed to rank links")
+		}
+		return
+	}
+
+	response := v1.TopLinksResponse{
+		Period: string(board.Period),
+		From:   board.From,
+		To:     board.To,
+		Links:  make([]v1.TopLinkResponse, 0, len(board.Links)),
+	}
+	for i, link := range board.Links {
+		response.Links = append(response.Links, v1.TopLinkResponse{
+			Rank:           i + 1,
+			ID:             link.URLID,
+			ShortCode:      link.ShortCode,
+			ShortURL:       buildShortURL(h.baseURL, &domain.URL{ShortCode: link.ShortCode, Domain: link.Domain}),
+			OriginalURL:    link.OriginalURL,
+			Clicks:         link.Clicks,
+			UniqueVisitors: link.UniqueVisitors,
+		})
+	}
+	respondSuccess(w, http.StatusOK, response, "")
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTopLinksProvider is a mock implementation of TopLinksProvider
+type MockTopLinksProvider struct {
+	mock.Mock
+}
+
+func (m *MockTopLinksProvider) TopLinks(ctx context.Context, period domain.LeaderboardPeriod, limit int) (*domain.Leaderboard, error) {
+	args := m.Called(ctx, period, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Leaderboard), args.Error(1)
+}
+
+func TestTopLinks(t *testing.T) {
+	board := &domain.Leaderboard{
+		Period: domain.Period24Hours,
+		From:   time.Date(2026, 3, 29, 10, 0, 0, 0, time.UTC),
+		To:     time.Date(2026, 3, 30, 10, 42, 0, 0, time.UTC),
+		Links: []domain.TopLink{
+			{URLID: "1", ShortCode: "abc123", OriginalURL: "https://example.com", Clicks: 12, UniqueVisitors: 7},
+			{URLID: "2", ShortCode: "sale", Domain: "go.example.com", OriginalURL: "https://example.com/sale", Clicks: 3, UniqueVisitors: 3},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedPeriod domain.LeaderboardPeriod
+		expectedLimit  int
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:           "ranked links",
+			query:          "?period=24h&limit=2",
+			expectedPeriod: domain.Period24Hours,
+			expectedLimit:  2,
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				`"period":"24h"`,
+				`{"rank":1,"id":"1","short_code":"abc123","short_url":"http://localhost:8080/abc123","original_url":"https://example.com","clicks":12,"unique_visitors":7}`,
+				`"rank":2,"id":"2","short_code":"sale","short_url":"http://go.example.com/sale"`,
+			},
+		},
+		{name: "defaults left to the service", expectCall: true, expectedStatus: http.StatusOK},
+		{name: "limit too large", query: "?limit=101", expectedStatus: http.StatusBadRequest},
+		{name: "limit not a number", query: "?limit=ten", expectedStatus: http.StatusBadRequest},
+		{name: "unknown period", query: "?period=1y", expectedPeriod: "1y", serviceErr: domain.ErrInvalidPeriod, expectCall: true, expectedStatus: http.StatusBadRequest},
+		{name: "anonymous", serviceErr: domain.ErrForbidden, expectCall: true, expectedStatus: http.StatusForbidden},
+		{name: "database down", serviceErr: assert.AnError, expectCall: true, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			provider := new(MockTopLinksProvider)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+			handler := NewLeaderboardHandler(provider, "http://localhost:8080", logger)
+			if tt.expectCall && tt.serviceErr != nil {
+				provider.On("TopLinks", mock.Anything, tt.expectedPeriod, tt.expectedLimit).Return(nil, tt.serviceErr)
+			} else if tt.expectCall {
+				provider.On("TopLinks", mock.Anything, tt.expectedPeriod, tt.expectedLimit).Return(board, nil)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/stats/top"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.TopLinks(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			for _, expected := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), expected)
+			}
+			provider.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"net/http"
+
+	"url-shortener/internal/i18n"
+)
+
+// Localize picks the visitor's language from the Accept-Language header
+// (see internal/i18n): error messages, error pages and the hosted UI are
+// then answered in it, falling back to English
+//
+// Like ContentNegotiation, it wraps the ResponseWriter so respondError -
+// which never sees the request - can find the language via localizerFor.
+func Localize(catalog *i18n.Catalog) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			language := catalog.Match(parseAcceptLanguage(r.Header.Get("Accept-Language")))
+			next.ServeHTTP(&localizedWriter{ResponseWriter: w, localizer: catalog.Localizer(language)}, r)
+		})
+	}
+}
+
+// localizedWriter carries the localizer chosen for the request
+type localizedWriter struct {
+	http.ResponseWriter
+	localizer i18n.Localizer
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *localizedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// localizerFor returns the localizer chosen for w
+// Requests that didn't go through Localize get the zero Localizer (English)
+func localizerFor(w http.ResponseWriter) (i18n.Localizer, bool) {
+	for w != nil {
+		if lw, ok := w.(*localizedWriter); ok {
+			return lw.localizer, true
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = unwrapper.Unwrap()
+	}
+	return i18n.Localizer{}, false
+}
+
+// translate returns message in the request's language and marks the
+// response as language dependent
+//
+// WHY ONLY HERE AND NOT IN THE MIDDLEWARE?
+// Vary: Accept-Language splits caches by language. Redirects and API data
+// are the same in every language, so only responses that actually contain
+// translated text get the header - edge-cached redirects stay one entry.
+func translate(w http.ResponseWriter, message string) string {
+	localizer, ok := localizerFor(w)
+	if !ok {
+		return message
+	}
+	setLanguageHeaders(w, localizer)
+	return localizer.Translate(message)
+}
+
+// setLanguageHeaders announces the language of a translated response
+func setLanguageHeaders(w http.ResponseWriter, localizer i18n.Localizer) {
+	w.Header().Set("Content-Language", localizer.Language())
+	w.Header().Add("Vary", "Accept-Language")
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/i18n"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCatalog(t *testing.T) *i18n.Catalog {
+	catalog, err := i18n.Load()
+	require.NoError(t, err)
+	return catalog
+}
+
+func TestLocalize_ErrorMessages(t *testing.T) {
+	tests := []struct {
+		name             string
+		acceptLanguage   string
+		expectedBody     string
+		expectedLanguage string
+	}{
+		{name: "french", acceptLanguage: "fr-CH, fr;q=0.9, en;q=0.8", expectedBody: `"error":"URL introuvable"`, expectedLanguage: "fr"},
+		{name: "german", acceptLanguage: "de", expectedBody: `"error":"URL nicht gefunden"`, expectedLanguage: "de"},
+		{name: "unsupported falls back to english", acceptLanguage: "ja", expectedBody: `"error":"URL not found"`, expectedLanguage: "en"},
+		{name: "no header", acceptLanguage: "", expectedBody: `"error":"URL not found"`, expectedLanguage: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			mockService.On("GetURL", mock.Anything, "missing").Return(nil, domain.ErrURLNotFound)
+
+			req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			w := httptest.NewRecorder()
+
+			// Act
+			Localize(newTestCatalog(t))(http.HandlerFunc(handler.RedirectURL)).ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.Equal(t, tt.expectedLanguage, w.Header().Get("Content-Language"))
+			assert.Contains(t, w.Header().Values("Vary"), "Accept-Language")
+		})
+	}
+}
+
+func TestLocalize_RedirectsStayLanguageNeutral(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	mockService.On("GetURL", mock.Anything, "abc123").Return(&domain.URL{ShortCode: "abc123", OriginalURL: "https://example.com"}, nil)
+	mockService.On("RecordClick", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Header.Set("Accept-Language", "fr")
+	w := httptest.NewRecorder()
+
+	// Act
+	Localize(newTestCatalog(t))(http.HandlerFunc(handler.RedirectURL)).ServeHTTP(w, req)
+
+	// Assert: no Vary: Accept-Language, so the edge keeps one cached redirect
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Language"))
+	assert.NotContains(t, w.Header().Values("Vary"), "Accept-Language")
+}
+
+func TestLocalize_ErrorPage(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	handler.WithErrorPages(newTestErrorPages(t))
+	mockService.On("GetURL", mock.Anything, "missing").Return(nil, domain.ErrURLNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.Header.Set("Accept", browserAccept)
+	req.Header.Set("Accept-Language", "es-MX,es;q=0.9")
+	w := httptest.NewRecorder()
+
+	// Act
+	Localize(newTestCatalog(t))(http.HandlerFunc(handler.RedirectURL)).ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "es", w.Header().Get("Content-Language"))
+	assert.Contains(t, w.Body.String(), `<html lang="es">`)
+	assert.Contains(t, w.Body.String(), "Enlace no encontrado")
+	assert.Contains(t, w.Body.String(), "Ir a la página de inicio")
+}
+
+func TestServeUI_Localized(t *testing.T) {
+	// Arrange
+	tmpl, err := template.ParseFiles(filepath.Join("..", "..", "..", "web", "templates", "index.html"))
+	require.NoError(t, err)
+	handler, _ := setupTestHandler()
+	handler.WithUI(tmpl)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "tr")
+	w := httptest.NewRecorder()
+
+	// Act
+	Localize(newTestCatalog(t))(http.HandlerFunc(handler.ServeUI)).ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "tr", w.Header().Get("Content-Language"))
+	assert.Contains(t, w.Body.String(), `<html lang="tr">`)
+	assert.Contains(t, w.Body.String(), "Kısa Bağlantı Oluştur")
+}
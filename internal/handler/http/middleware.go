@@ -4,10 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
 	"strconv"
 	"strings"
 	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/ctxutil"
+	"url-shortener/internal/errreport"
 	"url-shortener/internal/metrics"
 
 	"github.com/google/uuid"
@@ -34,6 +40,8 @@ func LoggingMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 			next.ServeHTTP(wrapped, r)
 
 			// Log after the request is processed
+			// The request ID is read from the response: this middleware runs
+			// outside RequestIDMiddleware, so the ID isn't in r's context
 			duration := time.Since(start)
 			logger.Info("HTTP request",
 				"method", r.Method,
@@ -42,6 +50,7 @@ func LoggingMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 				"duration_ms", duration.Milliseconds(),
 				"remote_addr", r.RemoteAddr,
 				"user_agent", r.UserAgent(),
+				"request_id", wrapped.Header().Get("X-Request-ID"),
 			)
 		})
 	}
@@ -58,8 +67,16 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the real writer (Flush, deadlines)
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // RequestIDMiddleware adds a unique request ID to each request
 // This is crucial for DISTRIBUTED TRACING and debugging
+//
+// It also puts the rest of the request metadata on the context (client IP,
+// trace ID), so code that only gets a ctx can read it with package ctxutil
 func RequestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Generate a unique request ID
@@ -69,16 +86,41 @@ func RequestIDMiddleware(next http.Handler) http.Handler {
 		w.Header().Set("X-Request-ID", requestID)
 
 		// Add to context so handlers can access it
-		ctx := context.WithValue(r.Context(), "request_id", requestID)
+		ctx := ctxutil.WithRequestID(r.Context(), requestID)
+		ctx = ctxutil.WithClientIP(ctx, clientIP(r))
+		if traceID := traceIDFrom(r); traceID != "" {
+			ctx = ctxutil.WithTraceID(ctx, traceID)
+		}
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// traceIDFrom reads the trace ID of a W3C traceparent header
+// ("00-<32 hex trace ID>-<16 hex parent ID>-<flags>"), "" if there is none
+// Tracing proxies and SDKs send it, so our logs can be joined with theirs
+func traceIDFrom(r *http.Request) string {
+	parts := strings.Split(r.Header.Get("traceparent"), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 {
+		return ""
+	}
+	traceID := strings.ToLower(parts[1])
+	if strings.Trim(traceID, "0123456789abcdef") != "" || strings.Trim(traceID, "0") == "" {
+		return "" // Not hex, or the all-zero ID the spec forbids
+	}
+	return traceID
+}
+
 // RecoveryMiddleware recovers from panics and returns a 500 error
 // This prevents the entire server from crashing due to a panic in a handler
-func RecoveryMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+//
+// Panics and 5xx responses also go to the error tracker (see package
+// errreport), with the request they happened in: a crash in production
+// should page someone, not wait in the logs until someone looks.
+// Pass errreport.Nop{} to only log.
+func RecoveryMiddleware(logger *slog.Logger, reporter errreport.Reporter) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 			defer func() {
 				if err := recover(); err != nil {
 					logger.Error("Panic recovered",
@@ -86,14 +128,57 @@ func RecoveryMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 						"path", r.URL.Path,
 						"method", r.Method,
 					)
+					event := errreport.Panic(err)
+					reporter.Report(withRequest(event, wrapped, r, http.StatusInternalServerError))
 					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					return
+				}
+				if wrapped.statusCode >= http.StatusInternalServerError {
+					reporter.Report(withRequest(serverError(r, wrapped.statusCode), wrapped, r, wrapped.statusCode))
 				}
 			}()
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(wrapped, r)
 		})
 	}
 }
 
+// serverError describes a 5xx response
+// The handler logged the cause; the event says where and how often
+func serverError(r *http.Request, status int) errreport.Event {
+	return errreport.Event{
+		Level:       errreport.LevelError,
+		Type:        fmt.Sprintf("HTTP %d", status),
+		Message:     fmt.Sprintf("%s %s returned %d %s", r.Method, r.URL.Path, status, http.StatusText(status)),
+		Tags:        map[string]string{},
+		Time:        time.Now(),
+		Fingerprint: []string{"http", r.Method, strconv.Itoa(status)},
+	}
+}
+
+// withRequest adds the request an event happened in
+// This middleware runs outside RequestIDMiddleware: the request ID is read
+// from the response headers and the trace ID from the request's
+func withRequest(event errreport.Event, w http.ResponseWriter, r *http.Request, status int) errreport.Event {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	event.Request = &errreport.Request{
+		Method:    r.Method,
+		URL:       scheme + "://" + r.Host + r.URL.Path,
+		Status:    status,
+		UserAgent: r.UserAgent(),
+	}
+	event.Tags["component"] = "http"
+	if requestID := w.Header().Get("X-Request-ID"); requestID != "" {
+		event.Tags["request_id"] = requestID
+	}
+	if traceID := traceIDFrom(r); traceID != "" {
+		event.Tags["trace_id"] = traceID
+	}
+	return event
+}
+
 // CORSMiddleware adds CORS headers
 // CORS (Cross-Origin Resource Sharing) allows web apps from different domains to access your API
 func CORSMiddleware(next http.Handler) http.Handler {
@@ -113,31 +198,85 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// TimeoutMiddleware adds a timeout to requests
-// This prevents slow clients or handlers from tying up server resources
-func TimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+// AuthMiddleware authenticates the caller from the "Authorization: Bearer <token>" header
+// Requests without a token continue as auth.Anonymous; an INVALID token is rejected
+// with 401 instead of silently downgrading to anonymous
+func AuthMiddleware(authenticator auth.Authenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Create a context with timeout
-			ctx, cancel := context.WithTimeout(r.Context(), timeout)
-			defer cancel()
+			token := bearerToken(r)
+			if token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-			// Create a channel to signal when the handler completes
-			done := make(chan struct{})
+			principal, err := authenticator.Authenticate(r.Context(), token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="url-shortener"`)
+				respondError(w, http.StatusUnauthorized, "Invalid credentials")
+				return
+			}
 
-			go func() {
-				next.ServeHTTP(w, r.WithContext(ctx))
-				close(done)
-			}()
+			ctx := auth.WithPrincipal(r.Context(), principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireAuth rejects anonymous requests
+// Must run after AuthMiddleware
+func RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if auth.FromContext(r.Context()) == auth.Anonymous {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="url-shortener"`)
+			respondError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		next(w, r)
+	}
+}
 
-			select {
-			case <-done:
-				// Handler completed successfully
+// RequireAdmin only lets admin principals through
+// Must run after AuthMiddleware
+func RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := auth.FromContext(r.Context())
+		if principal == auth.Anonymous {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="url-shortener"`)
+			respondError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		if !principal.Admin {
+			respondError(w, http.StatusForbidden, "Admin access required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if len(header) > len(prefix) && strings.EqualFold(header[:len(prefix)], prefix) {
+		return strings.TrimSpace(header[len(prefix):])
+	}
+	return ""
+}
+
+// ExceptPrefix applies a middleware to every request except those under prefix
+// WHY? Some routes bring their own credentials: SCIM tokens would be
+// rejected by AuthMiddleware as invalid API keys before the SCIM routes
+// could check them
+func ExceptPrefix(prefix string, middleware func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := middleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
 				return
-			case <-ctx.Done():
-				// Timeout occurred
-				http.Error(w, "Request timeout", http.StatusRequestTimeout)
 			}
+			wrapped.ServeHTTP(w, r)
 		})
 	}
 }
@@ -161,7 +300,7 @@ func RateLimitMiddleware(limiter RateLimiter) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Extract identifier (IP address)
 			// In production, you might use API keys instead
-			ip := extractIP(r)
+			ip := clientIP(r)
 
 			// Check rate limit
 			allowed, remaining, resetTime, err := limiter.Allow(r.Context(), ip)
@@ -204,33 +343,107 @@ type RateLimiter interface {
 	MaxRequests() int
 }
 
-// extractIP extracts the client IP address from the request
-// Handles X-Forwarded-For header for proxies/load balancers
-func extractIP(r *http.Request) string {
-	// Check X-Forwarded-For header (set by proxies)
-	forwarded := r.Header.Get("X-Forwarded-For")
-	if forwarded != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
-		ips := strings.Split(forwarded, ",")
-		if len(ips) > 0 {
-			return strings.TrimSpace(ips[0])
+// ClientIPMiddleware works out the client's IP once and stores it on the
+// context (ctxutil.ClientIP). The rate limiter, the abuse guard, CAPTCHA
+// checks, audit entries and click records all read it from there (clientIP)
+//
+// WHY NOT JUST READ X-Forwarded-For?
+// Anyone can send that header. Trusting its first value lets a client pick
+// its own IP: a new value per request dodges every ban and rate limit, and
+// a victim's value gets the victim banned. Only proxies we run may speak
+// for the client, so:
+//   - a connection from outside trustedProxies IS the client
+//   - behind a trusted proxy, X-Forwarded-For is read from the RIGHT (each
+//     proxy appends the address it saw), skipping our own proxies; the first
+//     other address is the client. Whatever is left of it came from the client
+//     and is ignored.
+//
+// In a multi-region deployment, list the secondary regions too: they
+// forward writes to the primary with the caller's X-Forwarded-For
+func ClientIPMiddleware(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := ctxutil.WithClientIP(r.Context(), resolveClientIP(r, trustedProxies))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// resolveClientIP returns the client's IP (see ClientIPMiddleware)
+func resolveClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	remote, ok := remoteAddr(r)
+	if !ok {
+		return remoteIP(r) // Not an IP (e.g. a Unix socket): nothing to follow
+	}
+
+	trusted := func(addr netip.Addr) bool {
+		for _, prefix := range trustedProxies {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+	if !trusted(remote) {
+		return remote.String()
+	}
+
+	forwarded := r.Header.Values("X-Forwarded-For")
+	if len(forwarded) == 0 {
+		// Some proxies only set X-Real-IP
+		if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+			return addr.Unmap().String()
+		}
+		return remote.String()
+	}
+
+	// Several headers count as one list, in order
+	hops := strings.Split(strings.Join(forwarded, ","), ",")
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break // Garbage: the closest address we could read is the best we know
+		}
+		client = addr.Unmap()
+		if !trusted(client) {
+			break
 		}
 	}
+	return client.String()
+}
 
-	// Check X-Real-IP header (set by some proxies)
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
-		return realIP
+// clientIP returns the client's IP, without port
+// Read from the context (ClientIPMiddleware); the connection's address when
+// the middleware didn't run (tests, the admin listener)
+func clientIP(r *http.Request) string {
+	if ip := ctxutil.ClientIP(r.Context()); ip != "" {
+		return ip
 	}
+	return remoteIP(r)
+}
 
-	// Fall back to RemoteAddr
-	// Remove port if present (e.g., "127.0.0.1:12345" -> "127.0.0.1")
-	ip := r.RemoteAddr
-	if colonIndex := strings.LastIndex(ip, ":"); colonIndex != -1 {
-		ip = ip[:colonIndex]
+// remoteIP returns the connection's address without port
+// (e.g. "127.0.0.1:12345" -> "127.0.0.1", "[::1]:12345" -> "::1")
+func remoteIP(r *http.Request) string {
+	if addr, ok := remoteAddr(r); ok {
+		return addr.String()
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
 	}
+	return r.RemoteAddr
+}
 
-	return ip
+// remoteAddr parses the connection's address, with or without port
+func remoteAddr(r *http.Request) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	if addr, err := netip.ParseAddr(r.RemoteAddr); err == nil {
+		return addr.Unmap(), true
+	}
+	return netip.Addr{}, false
 }
 
 // MetricsMiddleware records Prometheus metrics for HTTP requests
@@ -283,6 +496,11 @@ func (m *metricsResponseWriter) WriteHeader(code int) {
 	m.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the real writer (Flush, deadlines)
+func (m *metricsResponseWriter) Unwrap() http.ResponseWriter {
+	return m.ResponseWriter
+}
+
 // simplifyEndpoint reduces cardinality by grouping similar endpoints
 func simplifyEndpoint(path string) string {
 	// Root path
@@ -302,9 +520,52 @@ func simplifyEndpoint(path string) string {
 		return "/api/v1/urls"
 	}
 
-	// Health check
-	if path == "/health/live" {
-		return "/health/live"
+	if path == "/api/v1/aliases/suggest" {
+		return "/api/v1/aliases/suggest"
+	}
+
+	if strings.HasPrefix(path, "/api/v1/aliases/") && strings.HasSuffix(path, "/availability") {
+		return "/api/v1/aliases/:alias/availability"
+	}
+
+	if path == "/api/v1/me" {
+		return "/api/v1/me"
+	}
+
+	if strings.HasPrefix(path, "/api/v1/users/") {
+		return "/api/v1/users/:owner"
+	}
+
+	if strings.HasPrefix(path, "/api/v1/erasures/") {
+		return "/api/v1/erasures/:id"
+	}
+
+	if path == "/api/v1/export" {
+		return "/api/v1/export"
+	}
+
+	if path == "/api/v1/import" {
+		return "/api/v1/import"
+	}
+
+	if strings.HasPrefix(path, "/api/v1/import/") {
+		return "/api/v1/import/:id"
+	}
+
+	if strings.HasPrefix(path, "/api/v2/urls/") {
+		if strings.HasSuffix(path, "/stats") {
+			return "/api/v2/urls/:id/stats"
+		}
+		return "/api/v2/urls/:id"
+	}
+
+	if path == "/api/v2/urls" {
+		return "/api/v2/urls"
+	}
+
+	// Health checks
+	if path == "/health/live" || path == "/health/ready" {
+		return path
 	}
 
 	// Metrics endpoint
@@ -0,0 +1,230 @@
+package http
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"url-shortener/internal/ctxutil"
+	"url-shortener/internal/errreport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDMiddleware_StoresRequestMetadata(t *testing.T) {
+	// Arrange
+	var meta ctxutil.Metadata
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta = ctxutil.MetadataFrom(r.Context())
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.RemoteAddr = "203.0.113.7:54321"
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, w.Header().Get("X-Request-ID"), meta.RequestID)
+	assert.NotEmpty(t, meta.RequestID)
+	assert.Equal(t, "203.0.113.7", meta.ClientIP)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", meta.TraceID)
+}
+
+func TestTraceIDFrom(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		want        string
+	}{
+		{name: "valid", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{name: "upper case is normalized", traceparent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", want: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{name: "missing", traceparent: ""},
+		{name: "too few parts", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736"},
+		{name: "short trace ID", traceparent: "00-4bf92f35-00f067aa0ba902b7-01"},
+		{name: "not hex", traceparent: "00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "all zeros", traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+
+			assert.Equal(t, tt.want, traceIDFrom(req))
+		})
+	}
+}
+
+func TestResolveClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string // X-Forwarded-For headers
+		realIP     string
+		want       string
+	}{
+		{name: "direct connection", remoteAddr: "203.0.113.7:54321", want: "203.0.113.7"},
+		{name: "IPv6 direct connection", remoteAddr: "[2001:db8::1]:54321", want: "2001:db8::1"},
+		{name: "untrusted peer can't pick its IP", remoteAddr: "203.0.113.7:54321", forwarded: []string{"198.51.100.9"}, want: "203.0.113.7"},
+		{name: "untrusted peer can't use X-Real-IP either", remoteAddr: "203.0.113.7:54321", realIP: "198.51.100.9", want: "203.0.113.7"},
+		{name: "behind our proxy", remoteAddr: "10.0.0.2:80", forwarded: []string{"203.0.113.7"}, want: "203.0.113.7"},
+		{name: "spoofed values left of the client are ignored", remoteAddr: "10.0.0.2:80", forwarded: []string{"198.51.100.9, 203.0.113.7"}, want: "203.0.113.7"},
+		{name: "several proxies of ours", remoteAddr: "10.0.0.2:80", forwarded: []string{"1.1.1.1, 203.0.113.7, 10.0.0.9, fd00::5"}, want: "203.0.113.7"},
+		{name: "several headers are one list", remoteAddr: "10.0.0.2:80", forwarded: []string{"1.1.1.1", "203.0.113.7"}, want: "203.0.113.7"},
+		{name: "garbage stops the walk", remoteAddr: "10.0.0.2:80", forwarded: []string{"203.0.113.7, not-an-ip, 10.0.0.9"}, want: "10.0.0.9"},
+		{name: "only our proxies", remoteAddr: "10.0.0.2:80", forwarded: []string{"10.0.0.9"}, want: "10.0.0.9"},
+		{name: "X-Real-IP from our proxy", remoteAddr: "10.0.0.2:80", realIP: "203.0.113.7", want: "203.0.113.7"},
+		{name: "no header from our proxy", remoteAddr: "10.0.0.2:80", want: "10.0.0.2"},
+		{name: "IPv4-mapped addresses", remoteAddr: "[::ffff:10.0.0.2]:80", forwarded: []string{"::ffff:203.0.113.7"}, want: "203.0.113.7"},
+		{name: "not an IP", remoteAddr: "@", want: "@"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			// Act & Assert
+			assert.Equal(t, tt.want, resolveClientIP(req, trusted))
+		})
+	}
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	// Arrange: no trusted proxies, the default
+	var got string
+	handler := ClientIPMiddleware(nil)(RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = clientIP(r)
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.RemoteAddr = "203.0.113.7:54321"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+
+	// Act
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert
+	assert.Equal(t, "203.0.113.7", got)
+}
+
+func TestExceptPrefix(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{name: "other routes get the middleware", path: "/api/v1/urls", expectedStatus: http.StatusUnauthorized},
+		{name: "prefix is skipped", path: "/scim/v2/Users", expectedStatus: http.StatusOK},
+		{name: "prefix needs the trailing slash", path: "/scim/v2", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: a middleware that rejects everything
+			reject := func(http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusUnauthorized)
+				})
+			}
+			handler := ExceptPrefix(SCIMPrefix, reject)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+// recordingReporter keeps the reported events
+type recordingReporter struct {
+	events []errreport.Event
+}
+
+func (r *recordingReporter) Report(event errreport.Event) {
+	r.events = append(r.events, event)
+}
+
+func (r *recordingReporter) Flush(timeout time.Duration) bool {
+	return true
+}
+
+func TestRecoveryMiddleware_ReportsErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		handler       http.HandlerFunc
+		expectedType  string
+		expectedLevel string
+	}{
+		{
+			name:          "panic",
+			handler:       func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			expectedType:  "string",
+			expectedLevel: errreport.LevelFatal,
+		},
+		{
+			name:          "server error",
+			handler:       func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) },
+			expectedType:  "HTTP 503",
+			expectedLevel: errreport.LevelError,
+		},
+		{
+			name:    "client error",
+			handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) },
+		},
+		{
+			name:    "success",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			reporter := &recordingReporter{}
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			handler := RecoveryMiddleware(logger, reporter)(RequestIDMiddleware(tt.handler))
+			req := httptest.NewRequest(http.MethodGet, "https://sho.rt/abc123?token=secret", nil)
+			req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ServeHTTP(w, req)
+
+			// Assert
+			if tt.expectedType == "" {
+				assert.Empty(t, reporter.events)
+				return
+			}
+			require.Len(t, reporter.events, 1)
+			event := reporter.events[0]
+			assert.Equal(t, tt.expectedType, event.Type)
+			assert.Equal(t, tt.expectedLevel, event.Level)
+			assert.Equal(t, "https://sho.rt/abc123", event.Request.URL, "without the query string")
+			assert.Equal(t, w.Header().Get("X-Request-ID"), event.Tags["request_id"])
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", event.Tags["trace_id"])
+		})
+	}
+}
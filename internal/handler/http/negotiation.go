@@ -0,0 +1,52 @@
+package http
+
+import (
+	"net/http"
+
+	"url-shortener/internal/encoders"
+)
+
+// ContentNegotiation picks the response format from the Accept header
+// (see internal/encoders): JSON by default, XML or MessagePack on request
+//
+// HOW DOES respondJSON KNOW?
+// The response helpers only get the ResponseWriter, not the request. So the
+// middleware wraps the writer with the chosen encoder, and respondJSON looks
+// for it - through any other wrappers, via Unwrap.
+func ContentNegotiation(registry *encoders.Registry) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoder := registry.Negotiate(r.Header.Get("Accept"))
+			next.ServeHTTP(&negotiatedWriter{ResponseWriter: w, encoder: encoder}, r)
+		})
+	}
+}
+
+// negotiatedWriter carries the encoder chosen for the request
+type negotiatedWriter struct {
+	http.ResponseWriter
+	encoder encoders.Encoder
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *negotiatedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// defaultEncoder answers requests that didn't go through ContentNegotiation
+var defaultEncoder encoders.Encoder = encoders.JSON{}
+
+// encoderFor returns the encoder negotiated for w (JSON if none was)
+func encoderFor(w http.ResponseWriter) encoders.Encoder {
+	for w != nil {
+		if nw, ok := w.(*negotiatedWriter); ok {
+			return nw.encoder
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = unwrapper.Unwrap()
+	}
+	return defaultEncoder
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/internal/encoders"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentNegotiation(t *testing.T) {
+	tests := []struct {
+		name         string
+		accept       string
+		expectedType string
+		expectedBody string
+	}{
+		{
+			name:         "default JSON",
+			expectedType: "application/json",
+			expectedBody: `{"error":"URL is required"}` + "\n",
+		},
+		{
+			name:         "XML",
+			accept:       "application/xml",
+			expectedType: "application/xml; charset=utf-8",
+			expectedBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<response><error>URL is required</error></response>` + "\n",
+		},
+		{
+			name:         "MessagePack",
+			accept:       "application/msgpack",
+			expectedType: "application/msgpack",
+			expectedBody: "\x81\xa5error\xafURL is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: negotiation outside another writer wrapper, like in main
+			handler := Chain(
+				ContentNegotiation(encoders.Default()),
+				MetricsMiddleware,
+			)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				respondError(w, http.StatusBadRequest, "URL is required")
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/urls", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept", w.Header().Get("Vary"))
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+// SetNotes handles PUT /api/v1/urls/{id}/notes (owner or admin only)
+// Replaces the description and custom metadata; the redirect stays as it is
+func (h *Handler) SetNotes(w http.ResponseWriter, r *http.Request) {
+	var req v1.URLNotes
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	url, err := h.urlService.SetNotes(r.Context(), r.PathValue("id"), req.Description, req.CustomMetadata)
+	switch {
+	case errors.Is(err, domain.ErrInvalidDescription), errors.Is(err, domain.ErrInvalidCustomMetadata):
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, domain.ErrVersionConflict):
+		respondError(w, http.StatusConflict, "URL was modified by another request, please retry")
+		return
+	case err != nil:
+		h.respondLookupError(w, "Failed to save notes", err)
+		return
+	}
+
+	w.Header().Set("ETag", url.ETag())
+	respondSuccess(w, http.StatusOK, v1.URLNotes{
+		Description:    url.Description,
+		CustomMetadata: url.CustomMetadata,
+	}, "Notes saved")
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"url-shortener/internal/domain"
+)
+
+func TestSetNotes(t *testing.T) {
+	ticket := map[string]string{"ticket": "MKT-142"}
+
+	tests := []struct {
+		name           string
+		body           string
+		metadata       map[string]string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{name: "saved", body: `{"description":"Spring sale","custom_metadata":{"ticket":"MKT-142"}}`, metadata: ticket, expectedStatus: http.StatusOK},
+		{name: "invalid metadata", body: `{"description":"Spring sale","custom_metadata":{"ticket":"MKT-142"}}`, metadata: ticket, serviceErr: domain.ErrInvalidCustomMetadata, expectedStatus: http.StatusBadRequest},
+		{name: "description too long", body: `{"description":"Spring sale"}`, serviceErr: domain.ErrInvalidDescription, expectedStatus: http.StatusBadRequest},
+		{name: "not the owner", body: `{"description":"Spring sale"}`, serviceErr: domain.ErrForbidden, expectedStatus: http.StatusForbidden},
+		{name: "unknown link", body: `{"description":"Spring sale"}`, serviceErr: domain.ErrURLNotFound, expectedStatus: http.StatusNotFound},
+		{name: "concurrent update", body: `{"description":"Spring sale"}`, serviceErr: domain.ErrVersionConflict, expectedStatus: http.StatusConflict},
+		{name: "malformed body", body: `{`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			if tt.serviceErr != nil {
+				mockService.On("SetNotes", mock.Anything, "url-1", "Spring sale", tt.metadata).Return(nil, tt.serviceErr)
+			} else {
+				saved := &domain.URL{ID: "url-1", Version: 2, Description: "Spring sale", CustomMetadata: ticket}
+				mockService.On("SetNotes", mock.Anything, "url-1", "Spring sale", tt.metadata).Return(saved, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/urls/url-1/notes", strings.NewReader(tt.body))
+			req.SetPathValue("id", "url-1")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.SetNotes(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"description":"Spring sale"`)
+				assert.Contains(t, w.Body.String(), `"custom_metadata":{"ticket":"MKT-142"}`)
+				assert.NotEmpty(t, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+// PageManager is the service the page endpoints need
+// Implemented by service.PageService
+type PageManager interface {
+	CreatePage(ctx context.Context, page *domain.Page) error
+	GetPage(ctx context.Context, id string) (*domain.Page, error)
+	ListPages(ctx context.Context) ([]*domain.Page, error)
+	UpdatePage(ctx context.Context, page *domain.Page) error
+	DeletePage(ctx context.Context, id string) error
+	PublicPage(ctx context.Context, handle string) (*domain.Page, error)
+	ClickBlock(ctx context.Context, handle, blockID string) (string, error)
+}
+
+// PageHandler serves link-in-bio pages: the management API and the public
+// pages at /@{handle}
+type PageHandler struct {
+	pages   PageManager
+	tmpl    *template.Template
+	logger  *slog.Logger
+	baseURL string
+}
+
+// NewPageHandler creates a new page handler
+// tmpl renders the public page (web/templates/page.html)
+func NewPageHandler(pages PageManager, tmpl *template.Template, logger *slog.Logger, baseURL string) *PageHandler {
+	return &PageHandler{
+		pages:   pages,
+		tmpl:    tmpl,
+		logger:  logger,
+		baseURL: baseURL,
+	}
+}
+
+// CreatePage handles POST /api/v1/pages
+func (h *PageHandler) CreatePage(w http.ResponseWriter, r *http.Request) {
+	page, ok := decodePageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.pages.CreatePage(r.Context(), page); err != nil {
+		h.respondPageError(w, err, "Failed to create page")
+		return
+	}
+
+	respondSuccess(w, http.StatusCreated, h.toPageResponse(page), "Page created successfully")
+}
+
+// ListPages handles GET /api/v1/pages?limit=&offset=
+func (h *PageHandler) ListPages(w http.ResponseWriter, r *http.Request) {
+	window, err := parsePageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pages, err := h.pages.ListPages(r.Context())
+	if err != nil {
+		h.respondPageError(w, err, "Failed to list pages")
+		return
+	}
+
+	resp := make([]v1.PageResponse, 0, len(pages))
+	for _, page := range pages {
+		resp = append(resp, h.toPageResponse(page))
+	}
+	items, pagination := paginate(resp, window)
+	respondList(w, items, pagination)
+}
+
+// GetPage handles GET /api/v1/pages/{id}
+// The response includes the click count of every block
+func (h *PageHandler) GetPage(w http.ResponseWriter, r *http.Request) {
+	page, err := h.pages.GetPage(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.respondPageError(w, err, "Failed to get page")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, h.toPageResponse(page), "")
+}
+
+// UpdatePage handles PUT /api/v1/pages/{id}
+// The request replaces the whole page, blocks included
+func (h *PageHandler) UpdatePage(w http.ResponseWriter, r *http.Request) {
+	page, ok := decodePageRequest(w, r)
+	if !ok {
+		return
+	}
+	page.ID = r.PathValue("id")
+
+	if err := h.pages.UpdatePage(r.Context(), page); err != nil {
+		h.respondPageError(w, err, "Failed to update page")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, h.toPageResponse(page), "Page updated successfully")
+}
+
+// DeletePage handles DELETE /api/v1/pages/{id}
+func (h *PageHandler) DeletePage(w http.ResponseWriter, r *http.Request) {
+	if err := h.pages.DeletePage(r.Context(), r.PathValue("id")); err != nil {
+		h.respondPageError(w, err, "Failed to delete page")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Public serves /@{handle} and /@{handle}/{block} and passes every other
+// path on to next
+//
+// WHY NOT A MUX PATTERN?
+// ServeMux wildcards must cover a whole path segment, so "/@{handle}" is
+// not a valid pattern. Instead this wraps the catch-all "/" handler and
+// picks out paths that start with "/@" - short codes never contain "@".
+func (h *PageHandler) Public(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/@")
+		if !ok || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			next(w, r)
+			return
+		}
+
+		handle, blockID, hasBlock := strings.Cut(rest, "/")
+		handle = strings.ToLower(handle)
+		switch {
+		case !hasBlock:
+			h.renderPage(w, r, handle)
+		case blockID != "" && !strings.Contains(blockID, "/"):
+			h.clickBlock(w, r, handle, blockID)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+// renderPage renders the public page of handle
+func (h *PageHandler) renderPage(w http.ResponseWriter, r *http.Request, handle string) {
+	page, err := h.pages.PublicPage(r.Context(), handle)
+	if err != nil {
+		if errors.Is(err, domain.ErrPageNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		h.logger.Error("Failed to load page", "handle", handle, "error", err)
+		http.Error(w, "Failed to load page", http.StatusInternalServerError)
+		return
+	}
+
+	// Render into a buffer first: a template error must not leave a half
+	// written page behind a 200 status
+	var buf bytes.Buffer
+	if err := h.tmpl.Execute(&buf, page); err != nil {
+		h.logger.Error("Failed to render page", "handle", handle, "error", err)
+		http.Error(w, "Failed to render page", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// clickBlock counts a click-through and redirects to the block's URL
+// 302, not 301: browsers cache permanent redirects and would skip the count
+func (h *PageHandler) clickBlock(w http.ResponseWriter, r *http.Request, handle, blockID string) {
+	destination, err := h.pages.ClickBlock(r.Context(), handle, blockID)
+	if err != nil {
+		if errors.Is(err, domain.ErrPageNotFound) || errors.Is(err, domain.ErrBlockNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		h.logger.Error("Failed to follow page block", "handle", handle, "block", blockID, "error", err)
+		http.Error(w, "Failed to follow link", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, destination, http.StatusFound)
+}
+
+// respondPageError maps page errors to status codes
+func (h *PageHandler) respondPageError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrPageNotFound):
+		respondError(w, http.StatusNotFound, "Page not found")
+	case errors.Is(err, domain.ErrForbidden):
+		respondError(w, http.StatusForbidden, "Not allowed to manage this page")
+	case errors.Is(err, domain.ErrHandleTaken):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInvalidHandle),
+		errors.Is(err, domain.ErrInvalidTheme),
+		errors.Is(err, domain.ErrInvalidBlock),
+		errors.Is(err, domain.ErrTooManyBlocks),
+		errors.Is(err, domain.ErrPageTooLong):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Error(message, "error", err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+// decodePageRequest reads a v1.PageRequest into a domain.Page
+// Writes a 400 response and returns false on invalid JSON or fields
+func decodePageRequest(w http.ResponseWriter, r *http.Request) (*domain.Page, bool) {
+	var req v1.PageRequest
+	if !decodeRequest(w, r, &req) {
+		return nil, false
+	}
+
+	page := &domain.Page{
+		Handle: req.Handle,
+		Title:  req.Title,
+		Bio:    req.Bio,
+		Blocks: make([]domain.PageBlock, 0, len(req.Blocks)),
+	}
+	if req.Theme != nil {
+		page.Theme = domain.PageTheme{
+			Background:  req.Theme.Background,
+			Text:        req.Theme.Text,
+			Button:      req.Theme.Button,
+			ButtonText:  req.Theme.ButtonText,
+			ButtonShape: req.Theme.ButtonShape,
+		}
+	}
+	for _, block := range req.Blocks {
+		page.Blocks = append(page.Blocks, domain.PageBlock{
+			ID:    block.ID,
+			Title: block.Title,
+			URL:   block.URL,
+		})
+	}
+	return page, true
+}
+
+// toPageResponse converts a page to its API representation
+func (h *PageHandler) toPageResponse(page *domain.Page) v1.PageResponse {
+	resp := v1.PageResponse{
+		ID:      page.ID,
+		Handle:  page.Handle,
+		PageURL: h.baseURL + "/@" + page.Handle,
+		Title:   page.Title,
+		Bio:     page.Bio,
+		Theme: v1.PageThemeRequest{
+			Background:  page.Theme.Background,
+			Text:        page.Theme.Text,
+			Button:      page.Theme.Button,
+			ButtonText:  page.Theme.ButtonText,
+			ButtonShape: page.Theme.ButtonShape,
+		},
+		Blocks:    make([]v1.PageBlockResponse, 0, len(page.Blocks)),
+		CreatedAt: page.CreatedAt,
+		UpdatedAt: page.UpdatedAt,
+	}
+	for _, block := range page.Blocks {
+		resp.Blocks = append(resp.Blocks, v1.PageBlockResponse{
+			ID:       block.ID,
+			Title:    block.Title,
+			URL:      block.URL,
+			Position: block.Position,
+			Clicks:   block.Clicks,
+		})
+	}
+	return resp
+}
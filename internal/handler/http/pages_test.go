@@ -0,0 +1,203 @@
+package http
+
+import (
+	"context"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPageManager is a mock implementation of PageManager
+type MockPageManager struct {
+	mock.Mock
+}
+
+func (m *MockPageManager) CreatePage(ctx context.Context, page *domain.Page) error {
+	args := m.Called(ctx, page)
+	return args.Error(0)
+}
+
+func (m *MockPageManager) GetPage(ctx context.Context, id string) (*domain.Page, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Page), args.Error(1)
+}
+
+func (m *MockPageManager) ListPages(ctx context.Context) ([]*domain.Page, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Page), args.Error(1)
+}
+
+func (m *MockPageManager) UpdatePage(ctx context.Context, page *domain.Page) error {
+	args := m.Called(ctx, page)
+	return args.Error(0)
+}
+
+func (m *MockPageManager) DeletePage(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockPageManager) PublicPage(ctx context.Context, handle string) (*domain.Page, error) {
+	args := m.Called(ctx, handle)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Page), args.Error(1)
+}
+
+func (m *MockPageManager) ClickBlock(ctx context.Context, handle, blockID string) (string, error) {
+	args := m.Called(ctx, handle, blockID)
+	return args.String(0), args.Error(1)
+}
+
+func newTestPageHandler(t *testing.T) (*PageHandler, *MockPageManager) {
+	// The real template, so the tests also catch template errors
+	tmpl, err := template.ParseFiles(filepath.Join("..", "..", "..", "web", "templates", "page.html"))
+	require.NoError(t, err)
+
+	pages := new(MockPageManager)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewPageHandler(pages, tmpl, logger, "http://localhost:8080"), pages
+}
+
+func TestCreatePage(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+	}{
+		{name: "created", body: `{"handle":"alice","blocks":[{"title":"Blog","url":"https://alice.dev"}]}`, expectCall: true, expectedStatus: http.StatusCreated},
+		{name: "handle taken", body: `{"handle":"alice"}`, serviceErr: domain.ErrHandleTaken, expectCall: true, expectedStatus: http.StatusConflict},
+		{name: "invalid theme", body: `{"handle":"alice"}`, serviceErr: domain.ErrInvalidTheme, expectCall: true, expectedStatus: http.StatusBadRequest},
+		{name: "invalid JSON", body: `{`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, pages := newTestPageHandler(t)
+			if tt.expectCall {
+				pages.On("CreatePage", mock.Anything, mock.MatchedBy(func(p *domain.Page) bool {
+					return p.Handle == "alice"
+				})).Return(tt.serviceErr)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/pages", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.CreatePage(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				assert.Contains(t, w.Body.String(), `"page_url":"http://localhost:8080/@alice"`)
+			}
+			pages.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPublicPage(t *testing.T) {
+	page := &domain.Page{
+		ID:     "p1",
+		Handle: "alice",
+		Title:  "Alice <3",
+		Theme:  domain.DefaultPageTheme,
+		Blocks: []domain.PageBlock{{ID: "b1", Title: "Blog", URL: "https://alice.dev"}},
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		setup          func(pages *MockPageManager)
+		expectedStatus int
+		expectedBody   string
+		expectedTarget string
+		expectNext     bool
+	}{
+		{
+			name: "renders the page",
+			path: "/@Alice",
+			setup: func(pages *MockPageManager) {
+				pages.On("PublicPage", mock.Anything, "alice").Return(page, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `href="/@alice/b1"`,
+		},
+		{
+			name: "unknown handle",
+			path: "/@nobody",
+			setup: func(pages *MockPageManager) {
+				pages.On("PublicPage", mock.Anything, "nobody").Return(nil, domain.ErrPageNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "block click redirects",
+			path: "/@alice/b1",
+			setup: func(pages *MockPageManager) {
+				pages.On("ClickBlock", mock.Anything, "alice", "b1").Return("https://alice.dev", nil)
+			},
+			expectedStatus: http.StatusFound,
+			expectedTarget: "https://alice.dev",
+		},
+		{
+			name: "unknown block",
+			path: "/@alice/b9",
+			setup: func(pages *MockPageManager) {
+				pages.On("ClickBlock", mock.Anything, "alice", "b9").Return("", domain.ErrBlockNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{name: "short codes go to the next handler", path: "/abc123", setup: func(*MockPageManager) {}, expectNext: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, pages := newTestPageHandler(t)
+			tt.setup(pages)
+			nextCalled := false
+			next := func(w http.ResponseWriter, r *http.Request) { nextCalled = true }
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.Public(next)(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectNext, nextCalled)
+			if tt.expectNext {
+				return
+			}
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+				assert.Contains(t, w.Body.String(), "Alice &lt;3", "page text is HTML-escaped")
+			}
+			if tt.expectedTarget != "" {
+				assert.Equal(t, tt.expectedTarget, w.Header().Get("Location"))
+			}
+			pages.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "url-shortener/internal/api/v1"
+)
+
+// maxPageLimit caps ?limit= so one request can't ask for an unbounded page
+const maxPageLimit = 1000
+
+// pageRequest is the ?limit=&offset= window a client asked for
+type pageRequest struct {
+	Limit  int // 0 means "everything from Offset" (the pre-pagination behaviour)
+	Offset int
+}
+
+// parsePageRequest reads ?limit= and ?offset= from the query string
+//
+// WHY NO DEFAULT LIMIT?
+// The list endpoints returned every item before pagination existed.
+// Defaulting to e.g. 50 would silently truncate lists for existing clients,
+// so without ?limit= we still return everything (plus meta.pagination)
+func parsePageRequest(r *http.Request) (pageRequest, error) {
+	var page pageRequest
+	query := r.URL.Query()
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return page, errors.New("limit must be a number between 1 and " + strconv.Itoa(maxPageLimit))
+		}
+		page.Limit = limit
+	}
+
+	if raw := query.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return page, errors.New("offset must be zero or a positive number")
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
+
+// paginate cuts one page out of items and describes it for meta.pagination
+// An offset past the end returns an empty page, not an error
+func paginate[T any](items []T, page pageRequest) ([]T, *v1.Pagination) {
+	total := len(items)
+
+	start := min(page.Offset, total)
+	end := total
+	if page.Limit > 0 {
+		end = min(start+page.Limit, total)
+	}
+
+	return items[start:end], &v1.Pagination{
+		Limit:   page.Limit,
+		Offset:  page.Offset,
+		Total:   total,
+		HasMore: end < total,
+	}
+}
@@ -0,0 +1,204 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+)
+
+// Query parameters of a paste link
+const (
+	pasteRawParam      = "raw"      // ?raw=1 - the snippet as text/plain
+	pasteDownloadParam = "download" // ?download=1 - the same, saved as a file
+)
+
+// maxPasteRequestSize bounds the JSON body of POST /api/v1/pastes
+// JSON escaping (\n, \", ...) can make the content up to twice as long
+const maxPasteRequestSize = 2*domain.MaxPasteSize + 16<<10
+
+// pastePageData is what web/templates/paste.html renders
+type pastePageData struct {
+	Content     string
+	Language    string                              // "" = plain text
+	RawURL      string                              // ?raw=1, keeping ?sig= for signed links
+	DownloadURL string                              // ?download=1, likewise
+	Lang        string                              // <html lang>
+	T           func(string, ...interface{}) string // {{call .T "Raw"}}
+}
+
+// WithPastes renders paste links for browsers on a page from tmpl
+// Without it, pastes are still created and served - as JSON and raw text.
+func (h *Handler) WithPastes(tmpl *template.Template) *Handler {
+	h.pasteTmpl = tmpl
+	return h
+}
+
+// CreatePaste handles POST /api/v1/pastes (authenticated)
+func (h *Handler) CreatePaste(w http.ResponseWriter, r *http.Request) {
+	// Refuse oversized bodies up front instead of decoding them
+	if r.ContentLength > maxPasteRequestSize {
+		respondError(w, http.StatusRequestEntityTooLarge, pasteTooLargeMessage())
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxPasteRequestSize)
+
+	var req v1.CreatePasteRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var expiresIn time.Duration
+	if req.ExpiresInHours > 0 {
+		expiresIn = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+
+	opts := []domain.URLOption{domain.WithPaste(domain.Paste{
+		Content:  req.Content,
+		Language: strings.ToLower(req.Language),
+	})}
+	if req.MaxClicks != 0 {
+		opts = append(opts, domain.WithClickLimit(req.MaxClicks))
+	}
+	if req.Signed {
+		opts = append(opts, domain.WithSigning())
+	}
+
+	// A paste has no destination
+	url, err := h.urlService.CreateShortURL(r.Context(), "", req.CustomAlias, auth.FromContext(r.Context()).ID, expiresIn, opts...)
+	h.writeQuotaHeaders(w, r)
+	if err != nil {
+		status := createErrorStatus(err)
+		switch status {
+		case http.StatusRequestEntityTooLarge:
+			respondError(w, status, pasteTooLargeMessage())
+		case http.StatusInternalServerError:
+			h.logger.Error("Failed to create paste", "error", err)
+			respondError(w, status, "Failed to create paste")
+		default:
+			respondError(w, status, err.Error())
+		}
+		return
+	}
+
+	shortURL := h.shortURL(url)
+	response := v1.CreatePasteResponse{
+		ID:        url.ID,
+		ShortCode: url.ShortCode,
+		ShortURL:  shortURL,
+		RawURL:    shortURL + "?" + pasteRawParam + "=1",
+		Language:  url.Paste.Language,
+		Size:      len(url.Paste.Content),
+		CreatedAt: url.CreatedAt,
+		ExpiresAt: url.ExpiresAt,
+		MaxClicks: url.MaxClicks,
+	}
+	if url.RequiresSignature() {
+		// Only the owner sees this response; stats never show the secret
+		response.Signed = true
+		response.SigningSecret = url.SigningSecret
+		response.SignedURL = shortURL + "?" + url.SignedQuery(time.Time{}).Encode()
+	}
+	respondSuccess(w, http.StatusCreated, response, "Paste created successfully")
+}
+
+// servePaste answers the short link of a paste link
+//
+//   - ?raw=1 or ?download=1: the snippet as text/plain (for curl, or to save it)
+//   - browsers: a page showing it, with links to both
+//   - API clients: the snippet as JSON
+//
+// Every view is a click; HEAD requests are not views.
+func (h *Handler) servePaste(w http.ResponseWriter, r *http.Request, url *domain.URL) {
+	// Views are counted, and the paste may expire: nobody may keep a copy
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if r.Method != http.MethodHead {
+		h.recordClick(r, url.ShortCode)
+	}
+
+	query := r.URL.Query()
+	switch {
+	case query.Has(pasteRawParam) || query.Has(pasteDownloadParam):
+		h.writePasteRaw(w, r, url, query.Has(pasteDownloadParam))
+	case h.pasteTmpl != nil && prefersHTML(r):
+		h.renderPaste(w, r, url)
+	default:
+		respondSuccess(w, http.StatusOK, v1.PasteResponse{
+			ShortCode: url.ShortCode,
+			Language:  url.Paste.Language,
+			Content:   url.Paste.Content,
+		}, "")
+	}
+}
+
+// writePasteRaw writes the snippet as plain text
+func (h *Handler) writePasteRaw(w http.ResponseWriter, r *http.Request, url *domain.URL, download bool) {
+	header := w.Header()
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	header.Set("Content-Length", strconv.Itoa(len(url.Paste.Content)))
+	header.Set("Content-Security-Policy", "sandbox")
+	if download {
+		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", url.ShortCode+".txt"))
+	}
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write([]byte(url.Paste.Content))
+	}
+}
+
+// renderPaste writes the paste page
+func (h *Handler) renderPaste(w http.ResponseWriter, r *http.Request, url *domain.URL) {
+	localizer, localized := localizerFor(w)
+
+	// Render into a buffer first so a template error can't leave a half
+	// written page behind the status line
+	var buf bytes.Buffer
+	data := pastePageData{
+		Content:     url.Paste.Content,
+		Language:    url.Paste.Language,
+		RawURL:      pasteVariant(r, pasteRawParam),
+		DownloadURL: pasteVariant(r, pasteDownloadParam),
+		Lang:        localizer.Language(),
+		T:           localizer.Translate,
+	}
+	if err := h.pasteTmpl.Execute(&buf, data); err != nil {
+		h.logger.Error("Failed to render paste page", "short_code", url.ShortCode, "error", err)
+		h.writePasteRaw(w, r, url, false)
+		return
+	}
+
+	if localized {
+		setLanguageHeaders(w, localizer)
+	}
+	// Only the page's own inline styles: no scripts, no outside requests
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(buf.Bytes())
+	}
+}
+
+// pasteVariant returns the request's URL with one more query flag
+// The other parameters (?sig= and ?exp= of signed links) are kept, so the
+// raw and download links open wherever the page did
+func pasteVariant(r *http.Request, param string) string {
+	query := r.URL.Query()
+	query.Set(param, "1")
+	return (&url.URL{Path: r.URL.Path, RawQuery: query.Encode()}).String()
+}
+
+// pasteTooLargeMessage tells the client the paste limit
+func pasteTooLargeMessage() string {
+	return fmt.Sprintf("Paste is too large - the limit is %d KB", domain.MaxPasteSize>>10)
+}
@@ -0,0 +1,224 @@
+package http
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPasteHandler returns a handler with the real paste page
+func setupPasteHandler(t *testing.T) (*Handler, *MockURLService) {
+	t.Helper()
+	tmpl, err := template.ParseFiles(filepath.Join("..", "..", "..", "web", "templates", "paste.html"))
+	require.NoError(t, err)
+
+	handler, mockService := setupTestHandler()
+	handler.WithPastes(tmpl)
+	return handler, mockService
+}
+
+func TestCreatePaste(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "created",
+			body:           `{"content":"package main\n","language":"Go","expires_in_hours":24}`,
+			expectCall:     true,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"short_url":"http://localhost:8080/p4ste","raw_url":"http://localhost:8080/p4ste?raw=1","language":"go","size":13`,
+		},
+		{
+			name:           "no content",
+			body:           `{"language":"go"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"content"`,
+		},
+		{
+			name:           "too large",
+			body:           `{"content":"package main\n","language":"Go","expires_in_hours":24}`,
+			serviceErr:     domain.ErrPasteTooLarge,
+			expectCall:     true,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   "the limit is 128 KB",
+		},
+		{
+			name:           "bad language",
+			body:           `{"content":"package main\n","language":"Go","expires_in_hours":24}`,
+			serviceErr:     domain.ErrInvalidPaste,
+			expectCall:     true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "database down",
+			body:           `{"content":"package main\n","language":"Go","expires_in_hours":24}`,
+			serviceErr:     assert.AnError,
+			expectCall:     true,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Failed to create paste",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupPasteHandler(t)
+			if tt.expectCall {
+				var url *domain.URL
+				if tt.serviceErr == nil {
+					url = &domain.URL{ID: "1", ShortCode: "p4ste", Paste: &domain.Paste{Content: "package main\n", Language: "go"}}
+				}
+				// A paste has no destination
+				mockService.On("CreateShortURL", mock.Anything, "", "", "anonymous", 24*time.Hour).Return(url, tt.serviceErr)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/pastes", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.CreatePaste(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreatePaste_BodyTooLarge(t *testing.T) {
+	// Arrange
+	handler, mockService := setupPasteHandler(t)
+	body := `{"content":"` + strings.Repeat("a", maxPasteRequestSize) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pastes", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.CreatePaste(w, req)
+
+	// Assert: refused before decoding
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	mockService.AssertNotCalled(t, "CreateShortURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRedirectURL_Paste(t *testing.T) {
+	tests := []struct {
+		name                string
+		method              string
+		target              string
+		accept              string
+		expectedType        string
+		expectedBody        string
+		expectedDisposition string
+		expectClick         bool
+	}{
+		{
+			name:         "browser gets the page",
+			method:       http.MethodGet,
+			target:       "/p4ste",
+			accept:       "text/html",
+			expectedType: "text/html; charset=utf-8",
+			expectedBody: `<code class="language-go">if a &lt; b {}</code>`,
+			expectClick:  true,
+		},
+		{
+			name:         "signed page keeps the signature in its links",
+			method:       http.MethodGet,
+			target:       "/p4ste?sig=abc",
+			accept:       "text/html",
+			expectedType: "text/html; charset=utf-8",
+			expectedBody: `href="/p4ste?raw=1&amp;sig=abc"`,
+			expectClick:  true,
+		},
+		{
+			name:         "raw",
+			method:       http.MethodGet,
+			target:       "/p4ste?raw=1",
+			accept:       "text/html",
+			expectedType: "text/plain; charset=utf-8",
+			expectedBody: "if a < b {}",
+			expectClick:  true,
+		},
+		{
+			name:                "download",
+			method:              http.MethodGet,
+			target:              "/p4ste?download=1",
+			accept:              "text/html",
+			expectedType:        "text/plain; charset=utf-8",
+			expectedBody:        "if a < b {}",
+			expectedDisposition: `attachment; filename="p4ste.txt"`,
+			expectClick:         true,
+		},
+		{
+			name:         "API client",
+			method:       http.MethodGet,
+			target:       "/p4ste",
+			accept:       "application/json",
+			expectedType: "application/json",
+			expectedBody: `"language":"go","content":"if a \u003c b {}"`,
+			expectClick:  true,
+		},
+		{
+			name:         "HEAD is not a view",
+			method:       http.MethodHead,
+			target:       "/p4ste?raw=1",
+			expectedType: "text/plain; charset=utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupPasteHandler(t)
+			url := &domain.URL{ShortCode: "p4ste", IsActive: true, Paste: &domain.Paste{Content: "if a < b {}", Language: "go"}}
+			mockService.On("GetURL", mock.Anything, "p4ste").Return(url, nil)
+
+			// RecordClick runs in a goroutine, so signal when it has been called
+			clickRecorded := make(chan struct{})
+			mockService.On("RecordClick", mock.Anything, clickOn("p4ste")).
+				Run(func(args mock.Arguments) { close(clickRecorded) }).
+				Return(nil).Maybe()
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.RedirectURL(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Header().Get("Content-Type"), tt.expectedType)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.Equal(t, tt.expectedDisposition, w.Header().Get("Content-Disposition"))
+			assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			assert.Empty(t, w.Header().Get("Location"))
+
+			if tt.expectClick {
+				select {
+				case <-clickRecorded:
+				case <-time.After(time.Second):
+					t.Fatal("view was not counted")
+				}
+			} else {
+				assert.Empty(t, w.Body.String())
+				mockService.AssertNotCalled(t, "RecordClick", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
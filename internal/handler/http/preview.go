@@ -0,0 +1,167 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/featureflags"
+)
+
+// previewBots are User-Agent fragments (lowercase) of the bots that build
+// link previews in social networks and chat apps
+//
+// WHY A LIST AND NOT "ANY BOT"?
+// Only these bots render cards from og:/twitter: tags. Search engines and
+// monitoring tools should keep getting the plain redirect.
+var previewBots = []string{
+	"facebookexternalhit",
+	"facebot",
+	"twitterbot",
+	"slackbot",
+	"slack-imgproxy",
+	"linkedinbot",
+	"discordbot",
+	"telegrambot",
+	"whatsapp",
+	"pinterest",
+	"redditbot",
+	"skypeuripreview",
+	"mastodon",
+	"embedly",
+	"iframely",
+	"vkshare",
+}
+
+// isPreviewBot reports whether userAgent belongs to a link preview bot
+func isPreviewBot(userAgent string) bool {
+	userAgent = strings.ToLower(userAgent)
+	for _, bot := range previewBots {
+		if strings.Contains(userAgent, bot) {
+			return true
+		}
+	}
+	return false
+}
+
+// previewPageData is what web/templates/preview.html renders
+type previewPageData struct {
+	Title       string
+	Description string
+	ImageURL    string
+	ShortURL    string // og:url - the card links to the short link, not the destination
+	Destination string
+}
+
+// WithPreviewCards serves the owner's preview card to link preview bots
+func (h *Handler) WithPreviewCards(tmpl *template.Template) *Handler {
+	h.previewTmpl = tmpl
+	return h
+}
+
+// servePreview writes the preview card page when the link has a card and
+// the request comes from a preview bot
+// Returns false when the caller should redirect as usual
+func (h *Handler) servePreview(w http.ResponseWriter, r *http.Request, url *domain.URL) bool {
+	if h.previewTmpl == nil || url.Preview == nil || !h.featureEnabled(featureflags.PreviewPages, url.CreatedBy) {
+		return false
+	}
+
+	// The same URL answers differently per User-Agent - shared caches must
+	// not hand the bot page to people (or the redirect to bots)
+	w.Header().Add("Vary", "User-Agent")
+	if !isPreviewBot(r.UserAgent()) {
+		return false
+	}
+
+	// Fields the owner left empty fall back to the destination's own metadata
+	data := previewPageData{
+		Title:       url.Preview.Title,
+		Description: url.Preview.Description,
+		ImageURL:    url.Preview.ImageURL,
+		ShortURL:    h.shortURL(url),
+		Destination: url.OriginalURL,
+	}
+	if url.Metadata != nil {
+		if data.Title == "" {
+			data.Title = url.Metadata.Title
+		}
+		if data.Description == "" {
+			data.Description = url.Metadata.Description
+		}
+	}
+
+	// Render into a buffer first so a template error can't leave a half
+	// written page behind the status line
+	var buf bytes.Buffer
+	if err := h.previewTmpl.Execute(&buf, data); err != nil {
+		h.logger.Error("Failed to render preview card", "short_code", url.ShortCode, "error", err)
+		return false
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+	return true
+}
+
+// SetPreview handles PUT /api/v1/urls/{id}/preview (owner or admin only)
+func (h *Handler) SetPreview(w http.ResponseWriter, r *http.Request) {
+	var req v1.PreviewCard
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	card := &domain.PreviewCard{
+		Title:       req.Title,
+		Description: req.Description,
+		ImageURL:    req.ImageURL,
+	}
+	url, err := h.urlService.SetPreview(r.Context(), r.PathValue("id"), card)
+	if err != nil {
+		h.respondPreviewError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", url.ETag())
+	respondSuccess(w, http.StatusOK, previewCard(url.Preview), "Preview card saved")
+}
+
+// DeletePreview handles DELETE /api/v1/urls/{id}/preview (owner or admin only)
+// Bots follow the redirect again and read the destination's own tags
+func (h *Handler) DeletePreview(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.urlService.SetPreview(r.Context(), r.PathValue("id"), nil); err != nil {
+		h.respondPreviewError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondPreviewError maps preview card errors to HTTP responses
+func (h *Handler) respondPreviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidPreview):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrVersionConflict):
+		respondError(w, http.StatusConflict, "URL was modified by another request, please retry")
+	default:
+		h.respondLookupError(w, "Failed to save preview card", err)
+	}
+}
+
+// previewCard converts the preview card (nil stays nil)
+func previewCard(card *domain.PreviewCard) *v1.PreviewCard {
+	if card == nil {
+		return nil
+	}
+	return &v1.PreviewCard{
+		Title:       card.Title,
+		Description: card.Description,
+		ImageURL:    card.ImageURL,
+	}
+}
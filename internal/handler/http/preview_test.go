@@ -0,0 +1,210 @@
+package http
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const twitterbotUA = "Twitterbot/1.0"
+
+func newTestPreviewTemplate(t *testing.T) *template.Template {
+	tmpl, err := template.ParseFiles(filepath.Join("..", "..", "..", "web", "templates", "preview.html"))
+	require.NoError(t, err)
+	return tmpl
+}
+
+func TestIsPreviewBot(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		expected  bool
+	}{
+		{userAgent: "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", expected: true},
+		{userAgent: twitterbotUA, expected: true},
+		{userAgent: "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", expected: true},
+		{userAgent: "LinkedInBot/1.0 (compatible; Mozilla/5.0; Apache-HttpClient +http://www.linkedin.com)", expected: true},
+		{userAgent: "Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", expected: true},
+		{userAgent: "WhatsApp/2.23.20.0", expected: true},
+		{userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36", expected: false},
+		{userAgent: "Googlebot/2.1 (+http://www.google.com/bot.html)", expected: false},
+		{userAgent: "curl/8.4.0", expected: false},
+		{userAgent: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.userAgent, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isPreviewBot(tt.userAgent))
+		})
+	}
+}
+
+func TestRedirectURL_PreviewCardForBots(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	handler.WithPreviewCards(newTestPreviewTemplate(t))
+
+	url := &domain.URL{
+		ID:          "123",
+		ShortCode:   "abc123",
+		OriginalURL: "https://example.com/sale?a=1&b=2",
+		IsActive:    true,
+		Preview: &domain.PreviewCard{
+			Title:    "Spring <Sale>",
+			ImageURL: "https://cdn.example.com/sale.png",
+		},
+		Metadata: &domain.LinkMetadata{Title: "Example Shop", Description: "Everything 20% off"},
+	}
+	mockService.On("GetURL", mock.Anything, "abc123").Return(url, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Header.Set("User-Agent", twitterbotUA)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.RedirectURL(w, req)
+
+	// Assert: a page with the card instead of a redirect
+	body := w.Body.String()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "User-Agent", w.Header().Get("Vary"))
+	assert.Contains(t, body, `<meta property="og:title" content="Spring &lt;Sale&gt;">`)
+	assert.Contains(t, body, `<meta property="og:image" content="https://cdn.example.com/sale.png">`)
+	assert.Contains(t, body, `<meta name="twitter:card" content="summary_large_image">`)
+	assert.Contains(t, body, `<meta property="og:url" content="http://localhost:8080/abc123">`)
+
+	// The description wasn't overridden, so the destination's own is used
+	assert.Contains(t, body, `<meta property="og:description" content="Everything 20% off">`)
+
+	// Bots aren't people: no click is recorded
+	mockService.AssertNotCalled(t, "RecordClick", mock.Anything, mock.Anything)
+	mockService.AssertExpectations(t)
+}
+
+func TestRedirectURL_PreviewCardPeopleStillRedirect(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	handler.WithPreviewCards(newTestPreviewTemplate(t))
+
+	url := &domain.URL{
+		ID:          "123",
+		ShortCode:   "abc123",
+		OriginalURL: "https://example.com",
+		IsActive:    true,
+		Preview:     &domain.PreviewCard{Title: "Spring Sale"},
+	}
+	clickRecorded := make(chan struct{})
+	mockService.On("GetURL", mock.Anything, "abc123").Return(url, nil)
+	mockService.On("RecordClick", mock.Anything, clickOn("abc123")).
+		Run(func(args mock.Arguments) { close(clickRecorded) }).
+		Return(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) Safari/605.1.15")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.RedirectURL(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com", w.Header().Get("Location"))
+	assert.Equal(t, "User-Agent", w.Header().Get("Vary"))
+
+	select {
+	case <-clickRecorded:
+	case <-time.After(time.Second):
+		t.Fatal("click was not recorded")
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestRedirectURL_NoPreviewCardBotsRedirect(t *testing.T) {
+	// Arrange: without a card, bots read the destination's own tags
+	handler, mockService := setupTestHandler()
+	handler.WithPreviewCards(newTestPreviewTemplate(t))
+
+	url := &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com", IsActive: true}
+	mockService.On("GetURL", mock.Anything, "abc123").Return(url, nil)
+	mockService.On("RecordClick", mock.Anything, clickOn("abc123")).Return(nil).Maybe()
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Header.Set("User-Agent", twitterbotUA)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.RedirectURL(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Empty(t, w.Header().Get("Vary"))
+}
+
+func TestSetPreview(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{name: "saved", body: `{"title":"Spring Sale","image_url":"https://cdn.example.com/sale.png"}`, expectedStatus: http.StatusOK},
+		{name: "invalid card", body: `{"image_url":"javascript:alert(1)"}`, serviceErr: domain.ErrInvalidPreview, expectedStatus: http.StatusBadRequest},
+		{name: "not the owner", body: `{"title":"x"}`, serviceErr: domain.ErrForbidden, expectedStatus: http.StatusForbidden},
+		{name: "unknown link", body: `{"title":"x"}`, serviceErr: domain.ErrURLNotFound, expectedStatus: http.StatusNotFound},
+		{name: "malformed body", body: `{`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			if tt.serviceErr != nil {
+				mockService.On("SetPreview", mock.Anything, "url-1", mock.AnythingOfType("*domain.PreviewCard")).Return(nil, tt.serviceErr)
+			} else {
+				saved := &domain.URL{ID: "url-1", Version: 2, Preview: &domain.PreviewCard{Title: "Spring Sale"}}
+				mockService.On("SetPreview", mock.Anything, "url-1", mock.AnythingOfType("*domain.PreviewCard")).Return(saved, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/urls/url-1/preview", strings.NewReader(tt.body))
+			req.SetPathValue("id", "url-1")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.SetPreview(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"title":"Spring Sale"`)
+				assert.NotEmpty(t, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestDeletePreview(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	mockService.On("SetPreview", mock.Anything, "url-1", (*domain.PreviewCard)(nil)).Return(&domain.URL{ID: "url-1"}, nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/urls/url-1/preview", nil)
+	req.SetPathValue("id", "url-1")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.DeletePreview(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"url-shortener/internal/auth"
+)
+
+// maxQuickFormBytes caps the POST form of the quick-create endpoint
+const maxQuickFormBytes = 16 << 10
+
+// QuickKeyAuth lets the quick-create endpoint take the API key as a
+// "key" parameter
+//
+// WHY A PARAMETER?
+// Bookmarklets and simple browser extensions can open a URL but often
+// can't set an Authorization header. The key goes through the SAME
+// authenticator as the header, and needs the urls:write scope. A header
+// still wins when both are present.
+// Prefer the POST form: query strings end up in browser history and in
+// proxy logs.
+func QuickKeyAuth(authenticator auth.Authenticator, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if auth.FromContext(r.Context()) != auth.Anonymous {
+			next(w, r)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			r.Body = http.MaxBytesReader(w, r.Body, maxQuickFormBytes)
+		}
+		key := r.FormValue("key")
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		principal, err := authenticator.Authenticate(r.Context(), key)
+		if err != nil {
+			respondText(w, http.StatusUnauthorized, "Invalid credentials")
+			return
+		}
+		// The Scopes middleware never saw this key: it wasn't in a header
+		if !principal.HasScope(auth.ScopeURLsWrite) {
+			respondText(w, http.StatusForbidden, "This API key lacks the urls:write scope")
+			return
+		}
+		next(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	}
+}
+
+// QuickCreate handles GET and POST /api/v1/quick?url=...&alias=...
+// Answers with the bare short URL as text/plain, so a bookmarklet can show
+// it or copy it to the clipboard without parsing JSON. Errors are plain
+// text too, with the same status codes as POST /api/v1/urls.
+func (h *Handler) QuickCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, maxQuickFormBytes)
+	}
+	if err := r.ParseForm(); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondText(w, http.StatusRequestEntityTooLarge, "Form is too large")
+			return
+		}
+		respondText(w, http.StatusBadRequest, "Invalid form")
+		return
+	}
+
+	originalURL := r.Form.Get("url")
+	if originalURL == "" {
+		respondText(w, http.StatusBadRequest, "URL is required")
+		return
+	}
+
+	url, err := h.urlService.CreateShortURL(
+		r.Context(),
+		originalURL,
+		r.Form.Get("alias"),
+		auth.FromContext(r.Context()).ID,
+		0,
+	)
+	h.writeQuotaHeaders(w, r)
+	if err != nil {
+		status := createErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to create URL", "error", err)
+		}
+		respondText(w, status, err.Error())
+		return
+	}
+
+	respondText(w, http.StatusCreated, h.shortURL(url))
+}
+
+// respondText writes a one-line plain-text response
+// A GET that creates something must never be cached, hence no-store
+func respondText(w http.ResponseWriter, statusCode int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(statusCode)
+	fmt.Fprintln(w, text)
+}
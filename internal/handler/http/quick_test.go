@@ -0,0 +1,117 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestQuickCreate(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		form           string
+		createdBy      string
+		createErr      error
+		expectCreate   bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "GET anonymous",
+			method:         "GET",
+			target:         "/api/v1/quick?url=https://example.com",
+			createdBy:      "anonymous",
+			expectCreate:   true,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "http://localhost:8080/abc123\n",
+		},
+		{
+			name:           "GET with key",
+			method:         "GET",
+			target:         "/api/v1/quick?url=https://example.com&key=secret",
+			createdBy:      "admin",
+			expectCreate:   true,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "http://localhost:8080/abc123\n",
+		},
+		{
+			name:           "POST form with key",
+			method:         "POST",
+			target:         "/api/v1/quick",
+			form:           "url=https%3A%2F%2Fexample.com&key=secret",
+			createdBy:      "admin",
+			expectCreate:   true,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "http://localhost:8080/abc123\n",
+		},
+		{
+			name:           "invalid key",
+			method:         "GET",
+			target:         "/api/v1/quick?url=https://example.com&key=wrong",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "Invalid credentials\n",
+		},
+		{
+			name:           "missing url",
+			method:         "GET",
+			target:         "/api/v1/quick",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "URL is required\n",
+		},
+		{
+			name:           "validation error as text",
+			method:         "GET",
+			target:         "/api/v1/quick?url=https://example.com",
+			createdBy:      "anonymous",
+			createErr:      domain.ErrInvalidURL,
+			expectCreate:   true,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid URL format\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			endpoint := QuickKeyAuth(auth.NewStaticKeyAuthenticator("secret"), handler.QuickCreate)
+
+			if tt.expectCreate {
+				call := mockService.On("CreateShortURL", mock.Anything, "https://example.com", "", tt.createdBy, time.Duration(0))
+				if tt.createErr != nil {
+					call.Return(nil, tt.createErr)
+				} else {
+					call.Return(&domain.URL{ID: "1", ShortCode: "abc123", OriginalURL: "https://example.com"}, nil)
+				}
+			}
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.form))
+			if tt.form != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			endpoint(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			if !tt.expectCreate {
+				mockService.AssertNotCalled(t, "CreateShortURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+)
+
+// Region headers
+// X-Region tells clients (and whoever debugs a slow request) which region
+// actually did the work; X-Forwarded-Region tells the primary region which
+// region forwarded a write
+const (
+	regionHeader          = "X-Region"
+	forwardedRegionHeader = "X-Forwarded-Region"
+)
+
+// forwardedReads are GET endpoints that must run in the primary region too:
+// /quick creates links, and import jobs are run by the primary's workers
+// (without a job queue they only exist in the memory of one instance)
+var forwardedReads = []string{"/api/v1/quick", "/api/v1/import/"}
+
+// RegionMiddleware tags every response with the region that served it
+// (nothing to tag in a single-region deployment: name is empty)
+//
+// In a SECONDARY region (primary != nil) writes are forwarded to the primary
+// region instead of being handled here: POST, PUT, PATCH and DELETE requests
+// (plus forwardedReads) go through a reverse proxy to the primary's public
+// URL, with the caller's API key, body and client IP intact. Everything
+// else - redirects above all - is answered locally.
+//
+// The primary region authenticates, rate-limits and runs forwarded requests
+// like any other, so this instance doesn't need to know what they do.
+func RegionMiddleware(name string, primary *url.URL, logger *slog.Logger) func(http.Handler) http.Handler {
+	var proxy *httputil.ReverseProxy
+	if primary != nil {
+		proxy = &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.SetURL(primary)
+				// Keep the original client IP first, so the primary's rate
+				// limiter counts the caller and not this instance
+				pr.Out.Header["X-Forwarded-For"] = pr.In.Header["X-Forwarded-For"]
+				pr.SetXForwarded()
+				pr.Out.Header.Set(forwardedRegionHeader, name)
+			},
+			ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+				logger.Error("Failed to forward request to the primary region",
+					"error", err,
+					"method", r.Method,
+					"path", r.URL.Path,
+				)
+				respondError(w, http.StatusBadGateway, "Primary region unavailable, please retry")
+			},
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if proxy != nil && mustForward(r) {
+				proxy.ServeHTTP(w, r) // The primary sets X-Region itself
+				return
+			}
+
+			if name != "" {
+				w.Header().Set(regionHeader, name)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// mustForward reports whether a request writes and so belongs to the primary region
+func mustForward(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		for _, prefix := range forwardedReads {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				return true
+			}
+		}
+		return false
+	case http.MethodOptions:
+		return false // CORS preflight is answered locally
+	default:
+		return true
+	}
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionMiddleware(t *testing.T) {
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		expectForwarded bool
+	}{
+		{name: "redirect served locally", method: http.MethodGet, path: "/abc123", expectForwarded: false},
+		{name: "stats served locally", method: http.MethodGet, path: "/api/v1/urls/abc123", expectForwarded: false},
+		{name: "CORS preflight served locally", method: http.MethodOptions, path: "/api/v1/urls", expectForwarded: false},
+		{name: "create forwarded", method: http.MethodPost, path: "/api/v1/urls", expectForwarded: true},
+		{name: "delete forwarded", method: http.MethodDelete, path: "/api/v1/urls/42", expectForwarded: true},
+		{name: "quick create forwarded", method: http.MethodGet, path: "/api/v1/quick", expectForwarded: true},
+		{name: "import job forwarded", method: http.MethodGet, path: "/api/v1/import/job-1", expectForwarded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: the primary region echoes what it received
+			var forwarded *http.Request
+			var forwardedBody string
+			primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				forwarded = r
+				body, _ := io.ReadAll(r.Body)
+				forwardedBody = string(body)
+				w.Header().Set(regionHeader, "us-east")
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer primary.Close()
+			primaryURL, err := url.Parse(primary.URL)
+			require.NoError(t, err)
+
+			logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+			local := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+			handler := RegionMiddleware("eu-west", primaryURL, logger)(local)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"url":"https://example.com"}`))
+			req.Header.Set("Authorization", "Bearer key")
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ServeHTTP(w, req)
+
+			// Assert
+			if !tt.expectForwarded {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, "eu-west", w.Header().Get(regionHeader))
+				assert.Nil(t, forwarded)
+				return
+			}
+			require.NotNil(t, forwarded)
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Equal(t, []string{"us-east"}, w.Header().Values(regionHeader))
+			assert.Equal(t, tt.path, forwarded.URL.Path)
+			assert.Equal(t, "Bearer key", forwarded.Header.Get("Authorization"))
+			assert.Equal(t, "eu-west", forwarded.Header.Get(forwardedRegionHeader))
+			assert.True(t, strings.HasPrefix(forwarded.Header.Get("X-Forwarded-For"), "203.0.113.7"))
+			if tt.method == http.MethodPost {
+				assert.Equal(t, `{"url":"https://example.com"}`, forwardedBody)
+			}
+		})
+	}
+}
+
+func TestRegionMiddleware_PrimaryDown(t *testing.T) {
+	// Arrange: nothing listens on the primary's address
+	primary := httptest.NewServer(http.NotFoundHandler())
+	primaryURL, err := url.Parse(primary.URL)
+	require.NoError(t, err)
+	primary.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError + 4}))
+	handler := RegionMiddleware("eu-west", primaryURL, logger)(http.NotFoundHandler())
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/urls", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestRegionMiddleware_PrimaryRegion(t *testing.T) {
+	// Arrange: without a primary URL nothing is forwarded
+	handler := RegionMiddleware("us-east", nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/urls", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "us-east", w.Header().Get(regionHeader))
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+// ResolveURL handles GET /api/v1/urls/{code}/resolve
+//
+// Follows the link the way a browser would, from the server, and returns
+// every hop: the short link, its destination and whatever that redirects to.
+// Destinations that fail to load still get a 200 - the failure is part of
+// the answer (see the error field), so dashboards can show it.
+func (h *Handler) ResolveURL(w http.ResponseWriter, r *http.Request) {
+	trace, err := h.urlService.TraceRedirects(r.Context(), r.PathValue("code"))
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		respondError(w, http.StatusForbidden, "You don't have access to this URL")
+		return
+	case errors.Is(err, domain.ErrURLNotFound):
+		respondError(w, http.StatusNotFound, "URL not found")
+		return
+	case errors.Is(err, domain.ErrRedirectTraceUnavailable):
+		respondError(w, http.StatusServiceUnavailable, "Redirect tracing is not available")
+		return
+	case err != nil:
+		h.logger.Error("Failed to trace redirects", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to trace redirects")
+		return
+	}
+
+	// The first hop is our own redirect - or the error visitors get instead
+	chain := []v1.RedirectHop{{URL: h.shortURL(trace.URL), StatusCode: shortLinkStatus(trace.URL)}}
+	for _, hop := range trace.Hops {
+		chain = append(chain, v1.RedirectHop{URL: hop.URL, StatusCode: hop.StatusCode, ContentType: hop.ContentType})
+	}
+
+	response := v1.ResolveResponse{
+		ShortCode:    trace.URL.ShortCode,
+		Chain:        chain,
+		Truncated:    trace.Truncated,
+		ViaShortener: trace.ViaShortener,
+		Error:        trace.Error,
+	}
+	for _, hop := range trace.Hops {
+		if hop.StatusCode >= 300 && hop.StatusCode < 400 {
+			response.Redirects++
+		}
+	}
+	if final := trace.Final(); final != nil {
+		response.FinalURL = final.URL
+		response.FinalStatusCode = final.StatusCode
+		response.FinalContentType = final.ContentType
+	}
+
+	respondSuccess(w, http.StatusOK, response, "")
+}
+
+// shortLinkStatus is the status RedirectURL answers the short link with
+func shortLinkStatus(url *domain.URL) int {
+	switch err := url.CanBeAccessed(); {
+	case err == nil && !url.Redirects():
+		return http.StatusOK // The reveal page or the file
+	case err == nil:
+		return http.StatusFound
+	case isGone(err):
+		return http.StatusGone
+	default:
+		return http.StatusNotFound
+	}
+}
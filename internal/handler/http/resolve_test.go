@@ -0,0 +1,95 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestResolveURL(t *testing.T) {
+	link := func() *domain.URL {
+		return &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "http://example.com/old", IsActive: true}
+	}
+	expired := link()
+	past := time.Now().Add(-time.Hour)
+	expired.ExpiresAt = &past
+
+	tests := []struct {
+		name           string
+		trace          *domain.RedirectTrace
+		serviceErr     error
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name: "full chain",
+			trace: &domain.RedirectTrace{
+				URL: link(),
+				Hops: []domain.RedirectHop{
+					{URL: "http://example.com/old", StatusCode: 301},
+					{URL: "https://example.com/new", StatusCode: 200, ContentType: "text/html"},
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				`"chain":[{"url":"http://localhost:8080/abc123","status_code":302},{"url":"http://example.com/old","status_code":301},{"url":"https://example.com/new","status_code":200,"content_type":"text/html"}]`,
+				`"final_url":"https://example.com/new"`,
+				`"final_status_code":200`,
+				`"final_content_type":"text/html"`,
+				`"redirects":1`,
+			},
+		},
+		{
+			name: "broken destination",
+			trace: &domain.RedirectTrace{
+				URL:   link(),
+				Error: "failed to fetch http://example.com/old: no such host",
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"redirects":0`, `"error":"failed to fetch http://example.com/old: no such host"`},
+		},
+		{
+			name:           "expired link",
+			trace:          &domain.RedirectTrace{URL: expired},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`{"url":"http://localhost:8080/abc123","status_code":410}`},
+		},
+		{name: "not the owner", serviceErr: domain.ErrForbidden, expectedStatus: http.StatusForbidden},
+		{name: "unknown link", serviceErr: fmt.Errorf("URL not found: %w", domain.ErrURLNotFound), expectedStatus: http.StatusNotFound},
+		{name: "tracing off", serviceErr: domain.ErrRedirectTraceUnavailable, expectedStatus: http.StatusServiceUnavailable},
+		{name: "database down", serviceErr: assert.AnError, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			if tt.serviceErr != nil {
+				mockService.On("TraceRedirects", mock.Anything, "abc123").Return(nil, tt.serviceErr)
+			} else {
+				mockService.On("TraceRedirects", mock.Anything, "abc123").Return(tt.trace, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc123/resolve", nil)
+			req.SetPathValue("code", "abc123")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ResolveURL(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			for _, fragment := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), fragment)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -3,29 +3,30 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/validation"
 )
 
 // Response helpers for consistent API responses
+//
+// Every v1 handler answers with v1.Envelope:
+//
+//	{"data": {...}, "message": "...", "meta": {"request_id": "..."}}
+//	{"error": "...", "code": "...", "meta": {"request_id": "..."}}
+//
+// Use respondSuccess / respondList / respondError instead of respondJSON.
+// respondJSON is only for bodies a third party defines (e.g. Slack replies)
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error   string            `json:"error"`
-	Code    string            `json:"code,omitempty"`
-	Details map[string]string `json:"details,omitempty"`
-}
-
-// SuccessResponse represents a successful response
-type SuccessResponse struct {
-	Data    interface{} `json:"data"`
-	Message string      `json:"message,omitempty"`
-}
-
-// respondJSON sends a JSON response
+// respondJSON sends a response in the format the client negotiated
+// JSON unless ContentNegotiation picked XML or MessagePack for this request
 func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
+	encoder := encoderFor(w)
+	w.Header().Set("Content-Type", encoder.ContentType())
+	w.Header().Add("Vary", "Accept")
 	w.WriteHeader(statusCode)
 
-	if err := json.NewEncoder(w).Encode(data); err != nil {
+	if err := encoder.Encode(w, data); err != nil {
 		// If encoding fails, log it but don't try to send another response
 		// (headers are already sent)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -33,16 +34,77 @@ func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 }
 
 // respondError sends an error response
+// message is translated to the visitor's language when a catalog has it
 func respondError(w http.ResponseWriter, statusCode int, message string) {
-	respondJSON(w, statusCode, ErrorResponse{
-		Error: message,
+	respondJSON(w, statusCode, v1.Envelope{
+		Error: translate(w, message),
+		Meta:  responseMeta(w),
+	})
+}
+
+// decodeRequest reads a JSON body into dst and checks its validate tags
+// Writes the 400 response and returns false when the body can't be used
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return false
+	}
+	if errs := validation.Struct(dst); errs != nil {
+		respondValidationError(w, errs)
+		return false
+	}
+	return true
+}
+
+// respondValidationError sends a 400 listing every invalid field
+//
+//	{"error": "URL is required; max_clicks must be at least 1",
+//	 "code": "validation_failed",
+//	 "details": {"url": "URL is required", "max_clicks": "max_clicks must be at least 1"},
+//	 "meta": {"request_id": "..."}}
+func respondValidationError(w http.ResponseWriter, errs validation.Errors) {
+	respondJSON(w, http.StatusBadRequest, v1.Envelope{
+		Error:   errs.Error(),
+		Code:    "validation_failed",
+		Details: errs.Map(),
+		Meta:    responseMeta(w),
 	})
 }
 
 // respondSuccess sends a success response
 func respondSuccess(w http.ResponseWriter, statusCode int, data interface{}, message string) {
-	respondJSON(w, statusCode, SuccessResponse{
+	respondJSON(w, statusCode, v1.Envelope{
 		Data:    data,
 		Message: message,
+		Meta:    responseMeta(w),
+	})
+}
+
+// respondList sends one page of a list along with meta.pagination
+func respondList(w http.ResponseWriter, data interface{}, pagination *v1.Pagination) {
+	meta := responseMeta(w)
+	if meta == nil {
+		meta = &v1.Meta{}
+	}
+	meta.Pagination = pagination
+
+	respondJSON(w, http.StatusOK, v1.Envelope{
+		Data: data,
+		Meta: meta,
 	})
 }
+
+// responseMeta builds the meta block shared by every response
+//
+// WHY READ THE HEADER AND NOT THE CONTEXT?
+// RequestIDMiddleware sets X-Request-ID on the response before calling the
+// handler, so the header is always there, even for helpers that never see
+// the *http.Request. Putting it in the body too means a client that only
+// logs bodies can still quote it in a support ticket
+func responseMeta(w http.ResponseWriter) *v1.Meta {
+	requestID := w.Header().Get("X-Request-ID")
+	if requestID == "" {
+		return nil
+	}
+	return &v1.Meta{RequestID: requestID}
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "url-shortener/internal/api/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelope_IncludesRequestID(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(w http.ResponseWriter)
+		status  int
+	}{
+		{
+			name:    "success",
+			respond: func(w http.ResponseWriter) { respondSuccess(w, http.StatusOK, map[string]string{"a": "b"}, "") },
+			status:  http.StatusOK,
+		},
+		{
+			name:    "error",
+			respond: func(w http.ResponseWriter) { respondError(w, http.StatusNotFound, "Short URL not found") },
+			status:  http.StatusNotFound,
+		},
+		{
+			name:    "list",
+			respond: func(w http.ResponseWriter) { respondList(w, []string{}, &v1.Pagination{}) },
+			status:  http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.respond(w)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Request-ID", "req-123")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ServeHTTP(w, req)
+
+			// Assert: the body quotes the same ID as the header
+			assert.Equal(t, tt.status, w.Code)
+			var envelope v1.Envelope
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+			require.NotNil(t, envelope.Meta)
+			assert.Equal(t, w.Header().Get("X-Request-ID"), envelope.Meta.RequestID)
+			assert.NotEmpty(t, envelope.Meta.RequestID)
+		})
+	}
+}
+
+func TestEnvelope_NoRequestIDOmitsMeta(t *testing.T) {
+	// Arrange
+	w := httptest.NewRecorder()
+
+	// Act
+	respondError(w, http.StatusBadRequest, "Invalid request body")
+
+	// Assert
+	assert.JSONEq(t, `{"error":"Invalid request body"}`, w.Body.String())
+}
+
+func TestParsePageRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		expected  pageRequest
+		expectErr bool
+	}{
+		{name: "no params returns everything", query: "", expected: pageRequest{}},
+		{name: "limit and offset", query: "?limit=10&offset=20", expected: pageRequest{Limit: 10, Offset: 20}},
+		{name: "zero limit", query: "?limit=0", expectErr: true},
+		{name: "limit too large", query: "?limit=1001", expectErr: true},
+		{name: "negative offset", query: "?offset=-1", expectErr: true},
+		{name: "not a number", query: "?limit=ten", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/pages"+tt.query, nil)
+
+			// Act
+			got, err := parsePageRequest(req)
+
+			// Assert
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		name          string
+		window        pageRequest
+		expectedItems []int
+		expectedMeta  v1.Pagination
+	}{
+		{
+			name:          "no limit",
+			window:        pageRequest{},
+			expectedItems: []int{1, 2, 3, 4, 5},
+			expectedMeta:  v1.Pagination{Total: 5},
+		},
+		{
+			name:          "first page",
+			window:        pageRequest{Limit: 2},
+			expectedItems: []int{1, 2},
+			expectedMeta:  v1.Pagination{Limit: 2, Total: 5, HasMore: true},
+		},
+		{
+			name:          "last page",
+			window:        pageRequest{Limit: 2, Offset: 4},
+			expectedItems: []int{5},
+			expectedMeta:  v1.Pagination{Limit: 2, Offset: 4, Total: 5},
+		},
+		{
+			name:          "offset past the end",
+			window:        pageRequest{Limit: 2, Offset: 10},
+			expectedItems: []int{},
+			expectedMeta:  v1.Pagination{Limit: 2, Offset: 10, Total: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got, meta := paginate(items, tt.window)
+
+			// Assert
+			assert.Equal(t, tt.expectedItems, got)
+			assert.Equal(t, tt.expectedMeta, *meta)
+		})
+	}
+}
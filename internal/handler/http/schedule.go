@@ -0,0 +1,93 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+	v2 "url-shortener/internal/api/v2"
+	"url-shortener/internal/domain"
+)
+
+// WithScheduleTimezone sets the timezone of schedules that don't name their
+// own (default: the server's local timezone)
+func (h *Handler) WithScheduleTimezone(location *time.Location) *Handler {
+	h.scheduleTZ = location
+	return h
+}
+
+// destinationFor picks the redirect target for this visitor
+//
+// ORDER OF PRECEDENCE:
+//  1. A schedule rule covering the current time
+//  2. A language target matching Accept-Language
+//  3. The original URL
+//
+// The schedule wins because it usually means "this is the only place that
+// works right now" (e.g. the after-hours page while support is closed).
+func (h *Handler) destinationFor(w http.ResponseWriter, r *http.Request, url *domain.URL) string {
+	if url.Schedule != nil {
+		// The answer changes with the clock - nobody may cache it
+		w.Header().Set("Cache-Control", "no-store")
+		if destination, ok := url.Schedule.DestinationAt(h.now(), h.scheduleTZ); ok {
+			return destination
+		}
+	}
+
+	if len(url.LanguageTargets) == 0 {
+		return url.OriginalURL
+	}
+
+	// The same short URL redirects differently per language - shared caches
+	// must keep one answer per Accept-Language value
+	w.Header().Add("Vary", "Accept-Language")
+	return url.DestinationFor(parseAcceptLanguage(r.Header.Get("Accept-Language")))
+}
+
+// scheduleFromV1 converts a v1 schedule (nil stays nil)
+func scheduleFromV1(schedule *v1.Schedule) *domain.Schedule {
+	if schedule == nil {
+		return nil
+	}
+	rules := make([]domain.ScheduleRule, 0, len(schedule.Rules))
+	for _, rule := range schedule.Rules {
+		rules = append(rules, domain.ScheduleRule{Days: rule.Days, Start: rule.Start, End: rule.End, URL: rule.URL})
+	}
+	return &domain.Schedule{Timezone: schedule.Timezone, Rules: rules}
+}
+
+// scheduleV1 converts a schedule for v1 responses (nil stays nil)
+func scheduleV1(schedule *domain.Schedule) *v1.Schedule {
+	if schedule == nil {
+		return nil
+	}
+	rules := make([]v1.ScheduleRule, 0, len(schedule.Rules))
+	for _, rule := range schedule.Rules {
+		rules = append(rules, v1.ScheduleRule{Days: rule.Days, Start: rule.Start, End: rule.End, URL: rule.URL})
+	}
+	return &v1.Schedule{Timezone: schedule.Timezone, Rules: rules}
+}
+
+// scheduleFromV2 converts a v2 schedule (nil stays nil)
+func scheduleFromV2(schedule *v2.Schedule) *domain.Schedule {
+	if schedule == nil {
+		return nil
+	}
+	rules := make([]domain.ScheduleRule, 0, len(schedule.Rules))
+	for _, rule := range schedule.Rules {
+		rules = append(rules, domain.ScheduleRule{Days: rule.Days, Start: rule.Start, End: rule.End, URL: rule.URL})
+	}
+	return &domain.Schedule{Timezone: schedule.Timezone, Rules: rules}
+}
+
+// scheduleV2 converts a schedule for v2 responses (nil stays nil)
+func scheduleV2(schedule *domain.Schedule) *v2.Schedule {
+	if schedule == nil {
+		return nil
+	}
+	rules := make([]v2.ScheduleRule, 0, len(schedule.Rules))
+	for _, rule := range schedule.Rules {
+		rules = append(rules, v2.ScheduleRule{Days: rule.Days, Start: rule.Start, End: rule.End, URL: rule.URL})
+	}
+	return &v2.Schedule{Timezone: schedule.Timezone, Rules: rules}
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRedirectURL_Schedule(t *testing.T) {
+	businessHours := domain.ScheduleRule{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "17:00", URL: "https://example.com/chat"}
+	nightShift := domain.ScheduleRule{Days: []string{"fri"}, Start: "22:00", End: "06:00", URL: "https://example.com/night"}
+	weekend := domain.ScheduleRule{Days: []string{"sat", "sun"}, URL: "https://example.com/weekend"}
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone database not available")
+	}
+
+	tests := []struct {
+		name             string
+		schedule         *domain.Schedule
+		now              time.Time
+		acceptLanguage   string
+		expectedLocation string
+	}{
+		{
+			name:             "inside business hours",
+			schedule:         &domain.Schedule{Rules: []domain.ScheduleRule{businessHours}},
+			now:              time.Date(2025, 6, 4, 10, 30, 0, 0, time.UTC), // Wednesday
+			expectedLocation: "https://example.com/chat",
+		},
+		{
+			name:             "end time is exclusive",
+			schedule:         &domain.Schedule{Rules: []domain.ScheduleRule{businessHours}},
+			now:              time.Date(2025, 6, 4, 17, 0, 0, 0, time.UTC),
+			expectedLocation: "https://example.com",
+		},
+		{
+			name:             "wrong day",
+			schedule:         &domain.Schedule{Rules: []domain.ScheduleRule{businessHours}},
+			now:              time.Date(2025, 6, 7, 10, 30, 0, 0, time.UTC), // Saturday
+			expectedLocation: "https://example.com",
+		},
+		{
+			name:             "whole-day rule",
+			schedule:         &domain.Schedule{Rules: []domain.ScheduleRule{businessHours, weekend}},
+			now:              time.Date(2025, 6, 8, 23, 59, 0, 0, time.UTC), // Sunday
+			expectedLocation: "https://example.com/weekend",
+		},
+		{
+			name:             "overnight rule on its own day",
+			schedule:         &domain.Schedule{Rules: []domain.ScheduleRule{nightShift}},
+			now:              time.Date(2025, 6, 6, 23, 0, 0, 0, time.UTC), // Friday
+			expectedLocation: "https://example.com/night",
+		},
+		{
+			name:             "overnight rule after midnight",
+			schedule:         &domain.Schedule{Rules: []domain.ScheduleRule{nightShift}},
+			now:              time.Date(2025, 6, 7, 5, 59, 0, 0, time.UTC), // Saturday morning
+			expectedLocation: "https://example.com/night",
+		},
+		{
+			name:             "first matching rule wins",
+			schedule:         &domain.Schedule{Rules: []domain.ScheduleRule{nightShift, weekend}},
+			now:              time.Date(2025, 6, 7, 1, 0, 0, 0, time.UTC), // Saturday 01:00
+			expectedLocation: "https://example.com/night",
+		},
+		{
+			name:             "link timezone",
+			schedule:         &domain.Schedule{Timezone: "Europe/Berlin", Rules: []domain.ScheduleRule{businessHours}},
+			now:              time.Date(2025, 6, 4, 7, 30, 0, 0, time.UTC), // 09:30 in Berlin (CEST)
+			expectedLocation: "https://example.com/chat",
+		},
+		{
+			name:             "schedule wins over language targets",
+			schedule:         &domain.Schedule{Rules: []domain.ScheduleRule{businessHours}},
+			now:              time.Date(2025, 6, 4, 10, 30, 0, 0, time.UTC),
+			acceptLanguage:   "fr",
+			expectedLocation: "https://example.com/chat",
+		},
+		{
+			name:             "language targets when no rule matches",
+			schedule:         &domain.Schedule{Rules: []domain.ScheduleRule{businessHours}},
+			now:              time.Date(2025, 6, 4, 20, 0, 0, 0, time.UTC),
+			acceptLanguage:   "fr",
+			expectedLocation: "https://example.com/fr",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			handler.WithScheduleTimezone(time.UTC)
+			handler.now = func() time.Time { return tt.now }
+
+			url := &domain.URL{
+				ID:              "123",
+				ShortCode:       "abc123",
+				OriginalURL:     "https://example.com",
+				IsActive:        true,
+				Schedule:        tt.schedule,
+				LanguageTargets: map[string]string{"fr": "https://example.com/fr"},
+			}
+			mockService.On("GetURL", mock.Anything, "abc123").Return(url, nil)
+			mockService.On("RecordClick", mock.Anything, clickOn("abc123")).Return(nil).Maybe()
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.RedirectURL(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusFound, w.Code)
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		})
+	}
+
+	t.Run("server timezone", func(t *testing.T) {
+		// Arrange: 08:30 UTC is 10:30 in Berlin
+		handler, mockService := setupTestHandler()
+		handler.WithScheduleTimezone(berlin)
+		handler.now = func() time.Time { return time.Date(2025, 6, 4, 8, 30, 0, 0, time.UTC) }
+
+		url := &domain.URL{
+			ID:          "123",
+			ShortCode:   "abc123",
+			OriginalURL: "https://example.com",
+			IsActive:    true,
+			Schedule:    &domain.Schedule{Rules: []domain.ScheduleRule{businessHours}},
+		}
+		mockService.On("GetURL", mock.Anything, "abc123").Return(url, nil)
+		mockService.On("RecordClick", mock.Anything, clickOn("abc123")).Return(nil).Maybe()
+
+		req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.RedirectURL(w, req)
+
+		// Assert
+		assert.Equal(t, "https://example.com/chat", w.Header().Get("Location"))
+	})
+}
@@ -0,0 +1,571 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"url-shortener/internal/api/scim"
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+)
+
+// SCIMProvisioner is the service the SCIM endpoints need
+// Implemented by service.SCIMService
+type SCIMProvisioner interface {
+	IssueToken(ctx context.Context) (string, error)
+	RevokeToken(ctx context.Context) error
+	Authenticate(ctx context.Context, token string) (string, error)
+
+	ListMembers(ctx context.Context, workspace string, filter domain.MemberFilter, offset, limit int) ([]*domain.Member, int, error)
+	GetMember(ctx context.Context, workspace, id string) (*domain.Member, error)
+	CreateMember(ctx context.Context, workspace string, member *domain.Member) error
+	ReplaceMember(ctx context.Context, workspace string, member *domain.Member) (*domain.Member, error)
+	DeleteMember(ctx context.Context, workspace, id string) error
+
+	ListGroups(ctx context.Context, workspace string, filter domain.GroupFilter, offset, limit int) ([]*domain.Group, int, error)
+	GetGroup(ctx context.Context, workspace, id string) (*domain.Group, error)
+	CreateGroup(ctx context.Context, workspace string, group *domain.Group) error
+	ReplaceGroup(ctx context.Context, workspace string, group *domain.Group) error
+	DeleteGroup(ctx context.Context, workspace, id string) error
+}
+
+// SCIMPrefix is where the SCIM API lives
+// The API key middleware must skip it (see ExceptPrefix): SCIM tokens are
+// not API keys, and the SCIM routes check them themselves
+const SCIMPrefix = "/scim/v2/"
+
+const (
+	scimDefaultCount = 100 // Page size when the identity provider sends no count
+	scimMaxCount     = 200
+)
+
+// SCIMHandler serves /scim/v2 to identity providers, plus the endpoints
+// workspace owners use to issue the SCIM token
+type SCIMHandler struct {
+	scim    SCIMProvisioner
+	logger  *slog.Logger
+	baseURL string
+}
+
+// NewSCIMHandler creates a new SCIM handler
+func NewSCIMHandler(provisioner SCIMProvisioner, logger *slog.Logger, baseURL string) *SCIMHandler {
+	return &SCIMHandler{scim: provisioner, logger: logger, baseURL: baseURL}
+}
+
+// scimWorkspaceKey carries the workspace of the SCIM token in the context
+type scimWorkspaceKey struct{}
+
+// scimWorkspace returns the workspace Authenticate found
+func scimWorkspace(r *http.Request) string {
+	workspace, _ := r.Context().Value(scimWorkspaceKey{}).(string)
+	return workspace
+}
+
+// IssueToken handles POST /api/v1/scim/token (workspace owners)
+// The token is shown once; issuing a new one revokes the old one
+func (h *SCIMHandler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	token, err := h.scim.IssueToken(r.Context())
+	if errors.Is(err, domain.ErrForbidden) {
+		respondError(w, http.StatusForbidden, "Only workspace owners can manage provisioning")
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to issue SCIM token", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to issue SCIM token")
+		return
+	}
+
+	respondSuccess(w, http.StatusCreated, v1.SCIMTokenResponse{
+		Token:    token,
+		Endpoint: h.baseURL + "/scim/v2",
+	}, "Store this token now: it is not shown again")
+}
+
+// RevokeToken handles DELETE /api/v1/scim/token (workspace owners)
+func (h *SCIMHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	err := h.scim.RevokeToken(r.Context())
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		respondError(w, http.StatusForbidden, "Only workspace owners can manage provisioning")
+	case errors.Is(err, domain.ErrSCIMTokenNotFound):
+		respondError(w, http.StatusNotFound, "No SCIM token issued")
+	case err != nil:
+		h.logger.Error("Failed to revoke SCIM token", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to revoke SCIM token")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Authenticate checks the SCIM bearer token and passes its workspace on
+// API keys are refused here just like unknown tokens
+func (h *SCIMHandler) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+			respondSCIMError(w, http.StatusUnauthorized, "", "SCIM token required")
+			return
+		}
+
+		workspace, err := h.scim.Authenticate(r.Context(), token)
+		if err != nil {
+			if !errors.Is(err, auth.ErrInvalidCredentials) {
+				h.logger.Error("Failed to check SCIM token", "error", err)
+				respondSCIMError(w, http.StatusInternalServerError, "", "Failed to check SCIM token")
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+			respondSCIMError(w, http.StatusUnauthorized, "", "Invalid SCIM token")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), scimWorkspaceKey{}, workspace)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// ServiceProviderConfig handles GET /scim/v2/ServiceProviderConfig
+func (h *SCIMHandler) ServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	respondSCIM(w, http.StatusOK, scim.ServiceProviderConfig{
+		Schemas: []string{scim.SchemaServiceProviderConfig},
+		Patch:   scim.Supported{Supported: true},
+		Filter:  scim.FilterSupport{Supported: true, MaxResults: scimMaxCount},
+		AuthenticationSchemes: []scim.AuthenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "SCIM token",
+			Description: "Bearer token issued by the workspace owner with POST /api/v1/scim/token",
+			Primary:     true,
+		}},
+	})
+}
+
+// ==================== USERS ====================
+
+// ListUsers handles GET /scim/v2/Users?filter=&startIndex=&count=
+// Filters: userName eq "..." or externalId eq "..."
+func (h *SCIMHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	attribute, value, err := parseSCIMFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		respondSCIMError(w, http.StatusBadRequest, scim.ErrorInvalidFilter, err.Error())
+		return
+	}
+	var filter domain.MemberFilter
+	switch attribute {
+	case "":
+	case "username":
+		filter.UserName = value
+	case "externalid":
+		filter.ExternalID = value
+	default:
+		respondSCIMError(w, http.StatusBadRequest, scim.ErrorInvalidFilter, "Users can be filtered by userName or externalId")
+		return
+	}
+	startIndex, count, ok := parseSCIMPage(w, r)
+	if !ok {
+		return
+	}
+
+	members, total, err := h.scim.ListMembers(r.Context(), scimWorkspace(r), filter, startIndex-1, count)
+	if err != nil {
+		h.respondUserError(w, err)
+		return
+	}
+
+	resources := make([]scim.User, 0, len(members))
+	for _, member := range members {
+		resources = append(resources, h.toSCIMUser(member))
+	}
+	respondSCIM(w, http.StatusOK, scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GetUser handles GET /scim/v2/Users/{id}
+func (h *SCIMHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	member, err := h.scim.GetMember(r.Context(), scimWorkspace(r), r.PathValue("id"))
+	if err != nil {
+		h.respondUserError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, h.toSCIMUser(member))
+}
+
+// CreateUser handles POST /scim/v2/Users
+func (h *SCIMHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req scim.User
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+
+	member := memberFromSCIM(req)
+	if err := h.scim.CreateMember(r.Context(), scimWorkspace(r), member); err != nil {
+		h.respondUserError(w, err)
+		return
+	}
+	w.Header().Set("Location", h.location("Users", member.ID))
+	respondSCIM(w, http.StatusCreated, h.toSCIMUser(member))
+}
+
+// ReplaceUser handles PUT /scim/v2/Users/{id}
+// Attributes left out are cleared, as PUT replaces the whole resource
+func (h *SCIMHandler) ReplaceUser(w http.ResponseWriter, r *http.Request) {
+	var req scim.User
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+
+	member := memberFromSCIM(req)
+	member.ID = r.PathValue("id")
+	updated, err := h.scim.ReplaceMember(r.Context(), scimWorkspace(r), member)
+	if err != nil {
+		h.respondUserError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, h.toSCIMUser(updated))
+}
+
+// PatchUser handles PATCH /scim/v2/Users/{id}
+// This is how most identity providers deactivate people:
+// {"op": "replace", "path": "active", "value": false}
+func (h *SCIMHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	var req scim.PatchRequest
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+
+	workspace := scimWorkspace(r)
+	member, err := h.scim.GetMember(r.Context(), workspace, r.PathValue("id"))
+	if err != nil {
+		h.respondUserError(w, err)
+		return
+	}
+	for _, op := range req.Operations {
+		if err := applyUserPatch(member, op); err != nil {
+			respondSCIMError(w, http.StatusBadRequest, patchErrorType(err), err.Error())
+			return
+		}
+	}
+
+	updated, err := h.scim.ReplaceMember(r.Context(), workspace, member)
+	if err != nil {
+		h.respondUserError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, h.toSCIMUser(updated))
+}
+
+// DeleteUser handles DELETE /scim/v2/Users/{id}
+func (h *SCIMHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.scim.DeleteMember(r.Context(), scimWorkspace(r), r.PathValue("id")); err != nil {
+		h.respondUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondUserError maps member errors to SCIM errors
+func (h *SCIMHandler) respondUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidMember):
+		respondSCIMError(w, http.StatusBadRequest, scim.ErrorInvalidValue, err.Error())
+	case errors.Is(err, domain.ErrMemberNotFound):
+		respondSCIMError(w, http.StatusNotFound, "", "User not found")
+	case errors.Is(err, domain.ErrMemberExists):
+		respondSCIMError(w, http.StatusConflict, scim.ErrorUniqueness, err.Error())
+	default:
+		h.logger.Error("SCIM user request failed", "error", err)
+		respondSCIMError(w, http.StatusInternalServerError, "", "Failed to provision user")
+	}
+}
+
+// memberFromSCIM reads the attributes we store from a SCIM user
+func memberFromSCIM(user scim.User) *domain.Member {
+	member := &domain.Member{
+		UserName:    user.UserName,
+		ExternalID:  user.ExternalID,
+		DisplayName: user.DisplayName,
+		Active:      user.Active == nil || *user.Active,
+	}
+	if member.DisplayName == "" && user.Name != nil {
+		member.DisplayName = user.Name.Formatted
+		if member.DisplayName == "" {
+			member.DisplayName = joinName(user.Name.GivenName, user.Name.FamilyName)
+		}
+	}
+	member.Email = primaryEmail(user.Emails)
+	return member
+}
+
+// toSCIMUser converts a member to its SCIM representation
+func (h *SCIMHandler) toSCIMUser(member *domain.Member) scim.User {
+	active := member.Active
+	user := scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          member.ID,
+		ExternalID:  member.ExternalID,
+		UserName:    member.UserName,
+		DisplayName: member.DisplayName,
+		Active:      &active,
+		Meta:        h.meta("User", "Users", member.ID, member.CreatedAt, member.UpdatedAt),
+	}
+	if member.Email != "" {
+		user.Emails = []scim.Email{{Value: member.Email, Type: "work", Primary: true}}
+	}
+	for _, group := range member.Groups {
+		user.Groups = append(user.Groups, scim.Ref{
+			Value:   group.ID,
+			Ref:     h.location("Groups", group.ID),
+			Display: group.DisplayName,
+		})
+	}
+	return user
+}
+
+// ==================== GROUPS ====================
+
+// ListGroups handles GET /scim/v2/Groups?filter=&startIndex=&count=
+// Filters: displayName eq "..." or externalId eq "..."
+func (h *SCIMHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	attribute, value, err := parseSCIMFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		respondSCIMError(w, http.StatusBadRequest, scim.ErrorInvalidFilter, err.Error())
+		return
+	}
+	var filter domain.GroupFilter
+	switch attribute {
+	case "":
+	case "displayname":
+		filter.DisplayName = value
+	case "externalid":
+		filter.ExternalID = value
+	default:
+		respondSCIMError(w, http.StatusBadRequest, scim.ErrorInvalidFilter, "Groups can be filtered by displayName or externalId")
+		return
+	}
+	startIndex, count, ok := parseSCIMPage(w, r)
+	if !ok {
+		return
+	}
+
+	groups, total, err := h.scim.ListGroups(r.Context(), scimWorkspace(r), filter, startIndex-1, count)
+	if err != nil {
+		h.respondGroupError(w, err)
+		return
+	}
+
+	resources := make([]scim.Group, 0, len(groups))
+	for _, group := range groups {
+		resources = append(resources, h.toSCIMGroup(group))
+	}
+	respondSCIM(w, http.StatusOK, scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GetGroup handles GET /scim/v2/Groups/{id}
+func (h *SCIMHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	group, err := h.scim.GetGroup(r.Context(), scimWorkspace(r), r.PathValue("id"))
+	if err != nil {
+		h.respondGroupError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, h.toSCIMGroup(group))
+}
+
+// CreateGroup handles POST /scim/v2/Groups
+func (h *SCIMHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req scim.Group
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+
+	group := groupFromSCIM(req)
+	if err := h.scim.CreateGroup(r.Context(), scimWorkspace(r), group); err != nil {
+		h.respondGroupError(w, err)
+		return
+	}
+	w.Header().Set("Location", h.location("Groups", group.ID))
+	respondSCIM(w, http.StatusCreated, h.toSCIMGroup(group))
+}
+
+// ReplaceGroup handles PUT /scim/v2/Groups/{id}
+func (h *SCIMHandler) ReplaceGroup(w http.ResponseWriter, r *http.Request) {
+	var req scim.Group
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+
+	group := groupFromSCIM(req)
+	group.ID = r.PathValue("id")
+	if err := h.scim.ReplaceGroup(r.Context(), scimWorkspace(r), group); err != nil {
+		h.respondGroupError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, h.toSCIMGroup(group))
+}
+
+// PatchGroup handles PATCH /scim/v2/Groups/{id}
+// Identity providers add and remove members one operation at a time:
+// {"op": "remove", "path": "members[value eq \"<id>\"]"}
+func (h *SCIMHandler) PatchGroup(w http.ResponseWriter, r *http.Request) {
+	var req scim.PatchRequest
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+
+	workspace := scimWorkspace(r)
+	group, err := h.scim.GetGroup(r.Context(), workspace, r.PathValue("id"))
+	if err != nil {
+		h.respondGroupError(w, err)
+		return
+	}
+	for _, op := range req.Operations {
+		if err := applyGroupPatch(group, op); err != nil {
+			respondSCIMError(w, http.StatusBadRequest, patchErrorType(err), err.Error())
+			return
+		}
+	}
+
+	if err := h.scim.ReplaceGroup(r.Context(), workspace, group); err != nil {
+		h.respondGroupError(w, err)
+		return
+	}
+	respondSCIM(w, http.StatusOK, h.toSCIMGroup(group))
+}
+
+// DeleteGroup handles DELETE /scim/v2/Groups/{id}
+func (h *SCIMHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	if err := h.scim.DeleteGroup(r.Context(), scimWorkspace(r), r.PathValue("id")); err != nil {
+		h.respondGroupError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondGroupError maps group errors to SCIM errors
+// An unknown member is the request's fault (400), not a missing group
+func (h *SCIMHandler) respondGroupError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidGroup):
+		respondSCIMError(w, http.StatusBadRequest, scim.ErrorInvalidValue, err.Error())
+	case errors.Is(err, domain.ErrMemberNotFound):
+		respondSCIMError(w, http.StatusBadRequest, scim.ErrorInvalidValue, "Every member must be a user of this workspace")
+	case errors.Is(err, domain.ErrGroupNotFound):
+		respondSCIMError(w, http.StatusNotFound, "", "Group not found")
+	case errors.Is(err, domain.ErrGroupExists):
+		respondSCIMError(w, http.StatusConflict, scim.ErrorUniqueness, err.Error())
+	default:
+		h.logger.Error("SCIM group request failed", "error", err)
+		respondSCIMError(w, http.StatusInternalServerError, "", "Failed to provision group")
+	}
+}
+
+// groupFromSCIM reads a SCIM group
+func groupFromSCIM(group scim.Group) *domain.Group {
+	memberIDs := make([]string, 0, len(group.Members))
+	for _, member := range group.Members {
+		memberIDs = append(memberIDs, member.Value)
+	}
+	return &domain.Group{
+		DisplayName: group.DisplayName,
+		ExternalID:  group.ExternalID,
+		MemberIDs:   memberIDs,
+	}
+}
+
+// toSCIMGroup converts a group to its SCIM representation
+func (h *SCIMHandler) toSCIMGroup(group *domain.Group) scim.Group {
+	result := scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          group.ID,
+		ExternalID:  group.ExternalID,
+		DisplayName: group.DisplayName,
+		Meta:        h.meta("Group", "Groups", group.ID, group.CreatedAt, group.UpdatedAt),
+	}
+	for _, id := range group.MemberIDs {
+		result.Members = append(result.Members, scim.Ref{Value: id, Ref: h.location("Users", id)})
+	}
+	return result
+}
+
+// ==================== PROTOCOL HELPERS ====================
+
+// location is the URL of a resource
+func (h *SCIMHandler) location(endpoint, id string) string {
+	return h.baseURL + SCIMPrefix + endpoint + "/" + id
+}
+
+// meta describes a resource
+func (h *SCIMHandler) meta(resourceType, endpoint, id string, created, modified time.Time) *scim.Meta {
+	return &scim.Meta{
+		ResourceType: resourceType,
+		Created:      created,
+		LastModified: modified,
+		Location:     h.location(endpoint, id),
+	}
+}
+
+// parseSCIMPage reads startIndex (1-based, default 1) and count (default
+// 100, at most 200) and answers 400 for anything that is not a number
+func parseSCIMPage(w http.ResponseWriter, r *http.Request) (startIndex, count int, ok bool) {
+	startIndex, count = 1, scimDefaultCount
+	query := r.URL.Query()
+	if raw := query.Get("startIndex"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			respondSCIMError(w, http.StatusBadRequest, scim.ErrorInvalidValue, "startIndex must be a number")
+			return 0, 0, false
+		}
+		startIndex = max(n, 1) // The RFC says to treat anything below 1 as 1
+	}
+	if raw := query.Get("count"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			respondSCIMError(w, http.StatusBadRequest, scim.ErrorInvalidValue, "count must be a number")
+			return 0, 0, false
+		}
+		count = min(max(n, 0), scimMaxCount) // count=0 asks for totalResults only
+	}
+	return startIndex, count, true
+}
+
+// decodeSCIM reads a SCIM request body; answers 400 invalidSyntax if it isn't JSON
+func decodeSCIM(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		respondSCIMError(w, http.StatusBadRequest, scim.ErrorInvalidSyntax, "Invalid request body")
+		return false
+	}
+	return true
+}
+
+// respondSCIM sends a SCIM resource
+func respondSCIM(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", scim.ContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// respondSCIMError sends a SCIM error
+func respondSCIMError(w http.ResponseWriter, status int, scimType, detail string) {
+	respondSCIM(w, status, scim.Error{
+		Schemas:  []string{scim.SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
@@ -0,0 +1,296 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"url-shortener/internal/api/scim"
+	"url-shortener/internal/domain"
+)
+
+// SCIM FILTERS AND PATCH
+// The RFC defines a whole filter language and attribute path syntax. Identity
+// providers use a tiny part of it: they look a user or group up with
+// `userName eq "..."` before creating it, and PATCH one attribute (or one
+// group member) at a time. That part is implemented here; anything else is
+// a 400 the identity provider logs, instead of a guess.
+
+// scimFilterPattern matches `attribute eq "value"` (eq in any case)
+var scimFilterPattern = regexp.MustCompile(`(?i)^\s*([a-z][a-z0-9.]*)\s+eq\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+// parseSCIMFilter parses a filter; the attribute comes back lowercased,
+// since SCIM attribute names are case-insensitive
+// An empty filter is an empty attribute
+func parseSCIMFilter(filter string) (attribute, value string, err error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", "", nil
+	}
+	match := scimFilterPattern.FindStringSubmatch(filter)
+	if match == nil {
+		return "", "", errors.New(`only filters of the form 'attribute eq "value"' are supported`)
+	}
+	// The value is a JSON string: this also unescapes \" and \\
+	if err := json.Unmarshal([]byte(match[2]), &value); err != nil {
+		return "", "", errors.New("filter value is not a valid string")
+	}
+	return strings.ToLower(match[1]), value, nil
+}
+
+// scimPatchError is a PATCH operation we can't apply
+type scimPatchError struct {
+	scimType string
+	detail   string
+}
+
+func (e *scimPatchError) Error() string { return e.detail }
+
+// patchErrorType returns the scimType of a PATCH error
+func patchErrorType(err error) string {
+	var patchErr *scimPatchError
+	if errors.As(err, &patchErr) {
+		return patchErr.scimType
+	}
+	return scim.ErrorInvalidValue
+}
+
+func invalidPatchValue(attribute string) error {
+	return &scimPatchError{scimType: scim.ErrorInvalidValue, detail: fmt.Sprintf("invalid value for %s", attribute)}
+}
+
+// patchOp returns the lowercased op ("Replace" is common) or an error
+func patchOp(op scim.PatchOperation) (string, error) {
+	switch name := strings.ToLower(op.Op); name {
+	case "add", "replace", "remove":
+		return name, nil
+	default:
+		return "", &scimPatchError{scimType: scim.ErrorInvalidSyntax, detail: fmt.Sprintf("unknown operation %q", op.Op)}
+	}
+}
+
+// applyUserPatch applies one PATCH operation to a member
+// Attributes we don't store (name parts, phone numbers, enterprise
+// extensions) are ignored, so identity providers that send them still work
+func applyUserPatch(member *domain.Member, op scim.PatchOperation) error {
+	name, err := patchOp(op)
+	if err != nil {
+		return err
+	}
+
+	// Without a path, the value is an object of attributes to set
+	if op.Path == "" {
+		if name == "remove" {
+			return &scimPatchError{scimType: scim.ErrorInvalidPath, detail: "remove needs a path"}
+		}
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attributes); err != nil {
+			return invalidPatchValue("the operation")
+		}
+		for attribute, value := range attributes {
+			if err := setUserAttribute(member, strings.ToLower(attribute), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	attribute := strings.ToLower(op.Path)
+	if name == "remove" {
+		return removeUserAttribute(member, attribute)
+	}
+	return setUserAttribute(member, attribute, op.Value)
+}
+
+// setUserAttribute sets one attribute from its JSON value
+func setUserAttribute(member *domain.Member, attribute string, value json.RawMessage) error {
+	var target *string
+	switch {
+	case attribute == "username":
+		target = &member.UserName
+	case attribute == "displayname":
+		target = &member.DisplayName
+	case attribute == "externalid":
+		target = &member.ExternalID
+	case attribute == "active":
+		active, ok := parseSCIMBool(value)
+		if !ok {
+			return invalidPatchValue("active")
+		}
+		member.Active = active
+		return nil
+	case attribute == "emails":
+		var emails []scim.Email
+		if err := json.Unmarshal(value, &emails); err != nil {
+			return invalidPatchValue("emails")
+		}
+		member.Email = primaryEmail(emails)
+		return nil
+	case strings.HasPrefix(attribute, "emails[") && strings.HasSuffix(attribute, "].value"):
+		// e.g. emails[type eq "work"].value: we keep a single email anyway
+		target = &member.Email
+	default:
+		return nil
+	}
+
+	if err := json.Unmarshal(value, target); err != nil {
+		return invalidPatchValue(attribute)
+	}
+	return nil
+}
+
+// removeUserAttribute clears one attribute
+// userName and active can't be removed: a member always has both
+func removeUserAttribute(member *domain.Member, attribute string) error {
+	switch {
+	case attribute == "username", attribute == "active":
+		return &scimPatchError{scimType: scim.ErrorInvalidPath, detail: fmt.Sprintf("%s can't be removed", attribute)}
+	case attribute == "displayname":
+		member.DisplayName = ""
+	case attribute == "externalid":
+		member.ExternalID = ""
+	case strings.HasPrefix(attribute, "emails"):
+		member.Email = ""
+	}
+	return nil
+}
+
+// parseSCIMBool reads true/false, or the "True"/"False" strings some
+// identity providers send instead
+func parseSCIMBool(value json.RawMessage) (bool, bool) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, true
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		switch strings.ToLower(s) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// applyGroupPatch applies one PATCH operation to a group
+func applyGroupPatch(group *domain.Group, op scim.PatchOperation) error {
+	name, err := patchOp(op)
+	if err != nil {
+		return err
+	}
+
+	// Without a path, the value is an object of attributes to set
+	if op.Path == "" {
+		if name == "remove" {
+			return &scimPatchError{scimType: scim.ErrorInvalidPath, detail: "remove needs a path"}
+		}
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attributes); err != nil {
+			return invalidPatchValue("the operation")
+		}
+		for attribute, value := range attributes {
+			if err := patchGroupAttribute(group, name, strings.ToLower(attribute), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path := strings.ToLower(op.Path)
+
+	// members[value eq "<id>"] names one member
+	if strings.HasPrefix(path, "members[") && strings.HasSuffix(path, "]") {
+		if name != "remove" {
+			return &scimPatchError{scimType: scim.ErrorInvalidPath, detail: "only remove accepts a member filter"}
+		}
+		attribute, id, err := parseSCIMFilter(op.Path[len("members[") : len(op.Path)-1])
+		if err != nil || attribute != "value" {
+			return &scimPatchError{scimType: scim.ErrorInvalidPath, detail: `member filters must be value eq "<id>"`}
+		}
+		group.MemberIDs = slices.DeleteFunc(group.MemberIDs, func(memberID string) bool { return memberID == id })
+		return nil
+	}
+
+	return patchGroupAttribute(group, name, path, op.Value)
+}
+
+// patchGroupAttribute applies add, replace or remove to one attribute
+func patchGroupAttribute(group *domain.Group, op, attribute string, value json.RawMessage) error {
+	switch attribute {
+	case "members":
+		return patchGroupMembers(group, op, value)
+	case "displayname":
+		if op == "remove" {
+			return &scimPatchError{scimType: scim.ErrorInvalidPath, detail: "displayName can't be removed"}
+		}
+		if err := json.Unmarshal(value, &group.DisplayName); err != nil {
+			return invalidPatchValue("displayName")
+		}
+	case "externalid":
+		if op == "remove" {
+			group.ExternalID = ""
+			return nil
+		}
+		if err := json.Unmarshal(value, &group.ExternalID); err != nil {
+			return invalidPatchValue("externalId")
+		}
+	default:
+		return &scimPatchError{scimType: scim.ErrorInvalidPath, detail: fmt.Sprintf("unknown group attribute %q", attribute)}
+	}
+	return nil
+}
+
+// patchGroupMembers adds, replaces or removes members
+// remove without a value empties the group
+func patchGroupMembers(group *domain.Group, op string, value json.RawMessage) error {
+	var refs []scim.Ref
+	if len(value) > 0 {
+		if err := json.Unmarshal(value, &refs); err != nil {
+			return invalidPatchValue("members")
+		}
+	}
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, ref.Value)
+	}
+
+	switch op {
+	case "replace":
+		group.MemberIDs = ids
+	case "add":
+		for _, id := range ids {
+			if !slices.Contains(group.MemberIDs, id) {
+				group.MemberIDs = append(group.MemberIDs, id)
+			}
+		}
+	case "remove":
+		if len(ids) == 0 {
+			group.MemberIDs = nil
+			return nil
+		}
+		group.MemberIDs = slices.DeleteFunc(group.MemberIDs, func(id string) bool { return slices.Contains(ids, id) })
+	}
+	return nil
+}
+
+// primaryEmail picks the primary email, or the first one
+func primaryEmail(emails []scim.Email) string {
+	for _, email := range emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(emails) > 0 {
+		return emails[0].Value
+	}
+	return ""
+}
+
+// joinName builds a display name from name parts
+func joinName(given, family string) string {
+	return strings.TrimSpace(given + " " + family)
+}
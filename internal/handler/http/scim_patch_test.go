@@ -0,0 +1,151 @@
+package http
+
+import (
+	"encoding/json"
+	"testing"
+
+	"url-shortener/internal/api/scim"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSCIMFilter(t *testing.T) {
+	tests := []struct {
+		name              string
+		filter            string
+		expectedAttribute string
+		expectedValue     string
+		expectErr         bool
+	}{
+		{name: "empty"},
+		{name: "user name", filter: `userName eq "ada@example.com"`, expectedAttribute: "username", expectedValue: "ada@example.com"},
+		{name: "operator in capitals", filter: `externalId EQ "00u1"`, expectedAttribute: "externalid", expectedValue: "00u1"},
+		{name: "escaped quote", filter: `displayName eq "The \"A\" team"`, expectedAttribute: "displayname", expectedValue: `The "A" team`},
+		{name: "other operator", filter: `userName sw "ada"`, expectErr: true},
+		{name: "combined", filter: `userName eq "a" and active eq true`, expectErr: true},
+		{name: "unquoted", filter: `active eq true`, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			attribute, value, err := parseSCIMFilter(tt.filter)
+
+			// Assert
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedAttribute, attribute)
+			assert.Equal(t, tt.expectedValue, value)
+		})
+	}
+}
+
+func TestApplyUserPatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		op           scim.PatchOperation
+		expected     domain.Member
+		expectedType string // scimType of the error; "" means it applies
+	}{
+		{
+			name:     "deactivate",
+			op:       scim.PatchOperation{Op: "replace", Path: "active", Value: json.RawMessage(`false`)},
+			expected: domain.Member{UserName: "ada@example.com", DisplayName: "Ada", Email: "ada@example.com"},
+		},
+		{
+			name:     "no path (Okta)",
+			op:       scim.PatchOperation{Op: "replace", Value: json.RawMessage(`{"active":"False","displayName":"Ada L."}`)},
+			expected: domain.Member{UserName: "ada@example.com", DisplayName: "Ada L.", Email: "ada@example.com"},
+		},
+		{
+			name:     "email by filter (Entra ID)",
+			op:       scim.PatchOperation{Op: "Replace", Path: `emails[type eq "work"].value`, Value: json.RawMessage(`"ada@corp.example"`)},
+			expected: domain.Member{UserName: "ada@example.com", DisplayName: "Ada", Email: "ada@corp.example", Active: true},
+		},
+		{
+			name:     "remove display name",
+			op:       scim.PatchOperation{Op: "remove", Path: "displayName"},
+			expected: domain.Member{UserName: "ada@example.com", Email: "ada@example.com", Active: true},
+		},
+		{
+			name:     "attribute we don't store",
+			op:       scim.PatchOperation{Op: "add", Path: "phoneNumbers", Value: json.RawMessage(`[{"value":"555"}]`)},
+			expected: domain.Member{UserName: "ada@example.com", DisplayName: "Ada", Email: "ada@example.com", Active: true},
+		},
+		{name: "active not a boolean", op: scim.PatchOperation{Op: "replace", Path: "active", Value: json.RawMessage(`"maybe"`)}, expectedType: scim.ErrorInvalidValue},
+		{name: "remove user name", op: scim.PatchOperation{Op: "remove", Path: "userName"}, expectedType: scim.ErrorInvalidPath},
+		{name: "unknown op", op: scim.PatchOperation{Op: "move", Path: "userName"}, expectedType: scim.ErrorInvalidSyntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			member := &domain.Member{UserName: "ada@example.com", DisplayName: "Ada", Email: "ada@example.com", Active: true}
+
+			// Act
+			err := applyUserPatch(member, tt.op)
+
+			// Assert
+			if tt.expectedType != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedType, patchErrorType(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *member)
+		})
+	}
+}
+
+func TestApplyGroupPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       scim.PatchOperation
+		expected domain.Group
+	}{
+		{
+			name:     "rename",
+			op:       scim.PatchOperation{Op: "replace", Path: "displayName", Value: json.RawMessage(`"Growth"`)},
+			expected: domain.Group{DisplayName: "Growth", MemberIDs: []string{"m1", "m2"}},
+		},
+		{
+			name:     "replace members",
+			op:       scim.PatchOperation{Op: "replace", Path: "members", Value: json.RawMessage(`[{"value":"m3"}]`)},
+			expected: domain.Group{DisplayName: "Marketing", MemberIDs: []string{"m3"}},
+		},
+		{
+			name:     "remove members listed in the value",
+			op:       scim.PatchOperation{Op: "remove", Path: "members", Value: json.RawMessage(`[{"value":"m2"}]`)},
+			expected: domain.Group{DisplayName: "Marketing", MemberIDs: []string{"m1"}},
+		},
+		{
+			name:     "remove all members",
+			op:       scim.PatchOperation{Op: "remove", Path: "members"},
+			expected: domain.Group{DisplayName: "Marketing"},
+		},
+		{
+			name:     "no path",
+			op:       scim.PatchOperation{Op: "add", Value: json.RawMessage(`{"members":[{"value":"m3"}]}`)},
+			expected: domain.Group{DisplayName: "Marketing", MemberIDs: []string{"m1", "m2", "m3"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			group := &domain.Group{DisplayName: "Marketing", MemberIDs: []string{"m1", "m2"}}
+
+			// Act
+			err := applyGroupPatch(group, tt.op)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *group)
+		})
+	}
+}
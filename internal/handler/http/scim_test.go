@@ -0,0 +1,427 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/api/scim"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSCIMProvisioner is a mock implementation of SCIMProvisioner
+type MockSCIMProvisioner struct {
+	mock.Mock
+}
+
+func (m *MockSCIMProvisioner) IssueToken(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSCIMProvisioner) RevokeToken(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockSCIMProvisioner) Authenticate(ctx context.Context, token string) (string, error) {
+	args := m.Called(ctx, token)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSCIMProvisioner) ListMembers(ctx context.Context, workspace string, filter domain.MemberFilter, offset, limit int) ([]*domain.Member, int, error) {
+	args := m.Called(ctx, workspace, filter, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*domain.Member), args.Int(1), args.Error(2)
+}
+
+func (m *MockSCIMProvisioner) GetMember(ctx context.Context, workspace, id string) (*domain.Member, error) {
+	args := m.Called(ctx, workspace, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Member), args.Error(1)
+}
+
+func (m *MockSCIMProvisioner) CreateMember(ctx context.Context, workspace string, member *domain.Member) error {
+	args := m.Called(ctx, workspace, member)
+	return args.Error(0)
+}
+
+func (m *MockSCIMProvisioner) ReplaceMember(ctx context.Context, workspace string, member *domain.Member) (*domain.Member, error) {
+	args := m.Called(ctx, workspace, member)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Member), args.Error(1)
+}
+
+func (m *MockSCIMProvisioner) DeleteMember(ctx context.Context, workspace, id string) error {
+	args := m.Called(ctx, workspace, id)
+	return args.Error(0)
+}
+
+func (m *MockSCIMProvisioner) ListGroups(ctx context.Context, workspace string, filter domain.GroupFilter, offset, limit int) ([]*domain.Group, int, error) {
+	args := m.Called(ctx, workspace, filter, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*domain.Group), args.Int(1), args.Error(2)
+}
+
+func (m *MockSCIMProvisioner) GetGroup(ctx context.Context, workspace, id string) (*domain.Group, error) {
+	args := m.Called(ctx, workspace, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Group), args.Error(1)
+}
+
+func (m *MockSCIMProvisioner) CreateGroup(ctx context.Context, workspace string, group *domain.Group) error {
+	args := m.Called(ctx, workspace, group)
+	return args.Error(0)
+}
+
+func (m *MockSCIMProvisioner) ReplaceGroup(ctx context.Context, workspace string, group *domain.Group) error {
+	args := m.Called(ctx, workspace, group)
+	return args.Error(0)
+}
+
+func (m *MockSCIMProvisioner) DeleteGroup(ctx context.Context, workspace, id string) error {
+	args := m.Called(ctx, workspace, id)
+	return args.Error(0)
+}
+
+func newTestSCIMHandler() (*SCIMHandler, *MockSCIMProvisioner) {
+	provisioner := new(MockSCIMProvisioner)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewSCIMHandler(provisioner, logger, "https://sho.rt"), provisioner
+}
+
+// scimRequest builds a request that already went through Authenticate
+func scimRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	return req.WithContext(context.WithValue(req.Context(), scimWorkspaceKey{}, "team1"))
+}
+
+func TestSCIMAuthenticate(t *testing.T) {
+	tests := []struct {
+		name           string
+		authorization  string
+		workspace      string
+		authErr        error
+		expectedStatus int
+	}{
+		{name: "scim token", authorization: "Bearer scim_abc", workspace: "team1", expectedStatus: http.StatusOK},
+		{name: "no token", expectedStatus: http.StatusUnauthorized},
+		{name: "api key", authorization: "Bearer secret", authErr: auth.ErrInvalidCredentials, expectedStatus: http.StatusUnauthorized},
+		{name: "database down", authorization: "Bearer scim_abc", authErr: assert.AnError, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, provisioner := newTestSCIMHandler()
+			token := strings.TrimPrefix(tt.authorization, "Bearer ")
+			provisioner.On("Authenticate", mock.Anything, token).Return(tt.workspace, tt.authErr).Maybe()
+			var seen string
+			next := func(w http.ResponseWriter, r *http.Request) {
+				seen = scimWorkspace(r)
+				w.WriteHeader(http.StatusOK)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.Authenticate(next)(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.workspace, seen)
+			if tt.expectedStatus != http.StatusOK {
+				assert.Equal(t, scim.ContentType, w.Header().Get("Content-Type"))
+				assert.Contains(t, w.Body.String(), scim.SchemaError)
+			}
+		})
+	}
+}
+
+func TestSCIMListUsers(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ada := &domain.Member{ID: "m1", UserName: "ada@example.com", Active: true, CreatedAt: created, UpdatedAt: created}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectFilter   domain.MemberFilter
+		expectOffset   int
+		expectLimit    int
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "lookup by user name",
+			query:          `?filter=userName%20eq%20%22ada@example.com%22`,
+			expectFilter:   domain.MemberFilter{UserName: "ada@example.com"},
+			expectLimit:    100,
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"totalResults":1`,
+		},
+		{
+			name:           "second page",
+			query:          `?startIndex=11&count=10`,
+			expectOffset:   10,
+			expectLimit:    10,
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"startIndex":11`,
+		},
+		{
+			name:           "count is capped",
+			query:          `?count=5000`,
+			expectLimit:    200,
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+		},
+		{name: "unsupported attribute", query: `?filter=emails%20eq%20%22a%22`, expectedStatus: http.StatusBadRequest, expectedBody: `"scimType":"invalidFilter"`},
+		{name: "unsupported operator", query: `?filter=userName%20co%20%22ada%22`, expectedStatus: http.StatusBadRequest, expectedBody: `"scimType":"invalidFilter"`},
+		{name: "bad count", query: `?count=ten`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, provisioner := newTestSCIMHandler()
+			if tt.expectCall {
+				provisioner.On("ListMembers", mock.Anything, "team1", tt.expectFilter, tt.expectOffset, tt.expectLimit).
+					Return([]*domain.Member{ada}, 1, nil)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ListUsers(w, scimRequest(http.MethodGet, "/scim/v2/Users"+tt.query, ""))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			provisioner.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSCIMCreateUser(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "created",
+			body:           `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"ada@example.com","name":{"givenName":"Ada","familyName":"Lovelace"},"emails":[{"value":"home@example.com"},{"value":"ada@example.com","primary":true}]}`,
+			expectCall:     true,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"location":"https://sho.rt/scim/v2/Users/m1"`,
+		},
+		{name: "taken", body: `{"userName":"ada@example.com"}`, serviceErr: domain.ErrMemberExists, expectCall: true, expectedStatus: http.StatusConflict, expectedBody: `"scimType":"uniqueness"`},
+		{name: "no user name", body: `{}`, serviceErr: domain.ErrInvalidMember, expectCall: true, expectedStatus: http.StatusBadRequest, expectedBody: `"status":"400"`},
+		{name: "invalid JSON", body: `{`, expectedStatus: http.StatusBadRequest, expectedBody: `"scimType":"invalidSyntax"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, provisioner := newTestSCIMHandler()
+			var saved *domain.Member
+			if tt.expectCall {
+				provisioner.On("CreateMember", mock.Anything, "team1", mock.AnythingOfType("*domain.Member")).
+					Run(func(args mock.Arguments) {
+						saved = args.Get(2).(*domain.Member)
+						saved.ID = "m1"
+					}).
+					Return(tt.serviceErr)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.CreateUser(w, scimRequest(http.MethodPost, "/scim/v2/Users", tt.body))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, scim.ContentType, w.Header().Get("Content-Type"))
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			if tt.expectedStatus == http.StatusCreated {
+				assert.Equal(t, "Ada Lovelace", saved.DisplayName)
+				assert.Equal(t, "ada@example.com", saved.Email, "the primary email wins")
+				assert.True(t, saved.Active, "absent active means active")
+				assert.Equal(t, "https://sho.rt/scim/v2/Users/m1", w.Header().Get("Location"))
+			}
+			provisioner.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSCIMPatchUser_Deactivate(t *testing.T) {
+	// Arrange: Entra ID sends booleans as strings
+	handler, provisioner := newTestSCIMHandler()
+	stored := &domain.Member{ID: "m1", UserName: "ada@example.com", Active: true}
+	provisioner.On("GetMember", mock.Anything, "team1", "m1").Return(stored, nil)
+	provisioner.On("ReplaceMember", mock.Anything, "team1", mock.MatchedBy(func(m *domain.Member) bool {
+		return m.ID == "m1" && !m.Active
+	})).Return(&domain.Member{ID: "m1", UserName: "ada@example.com"}, nil)
+	body := `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],"Operations":[{"op":"Replace","path":"active","value":"False"}]}`
+	req := scimRequest(http.MethodPatch, "/scim/v2/Users/m1", body)
+	req.SetPathValue("id", "m1")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.PatchUser(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"active":false`)
+	provisioner.AssertExpectations(t)
+}
+
+func TestSCIMGetUser_NotFound(t *testing.T) {
+	// Arrange
+	handler, provisioner := newTestSCIMHandler()
+	provisioner.On("GetMember", mock.Anything, "team1", "m9").Return(nil, domain.ErrMemberNotFound)
+	req := scimRequest(http.MethodGet, "/scim/v2/Users/m9", "")
+	req.SetPathValue("id", "m9")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.GetUser(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var body scim.Error
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "404", body.Status)
+}
+
+func TestSCIMPatchGroup(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		serviceErr      error
+		expectCall      bool
+		expectedStatus  int
+		expectedMembers []string
+	}{
+		{
+			name:            "add member",
+			body:            `{"Operations":[{"op":"add","path":"members","value":[{"value":"m3"},{"value":"m1"}]}]}`,
+			expectCall:      true,
+			expectedStatus:  http.StatusOK,
+			expectedMembers: []string{"m1", "m2", "m3"},
+		},
+		{
+			name:            "remove member by filter",
+			body:            `{"Operations":[{"op":"remove","path":"members[value eq \"m1\"]"}]}`,
+			expectCall:      true,
+			expectedStatus:  http.StatusOK,
+			expectedMembers: []string{"m2"},
+		},
+		{
+			name:            "unknown member",
+			body:            `{"Operations":[{"op":"add","path":"members","value":[{"value":"nobody"}]}]}`,
+			serviceErr:      domain.ErrMemberNotFound,
+			expectCall:      true,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMembers: []string{"m1", "m2", "nobody"},
+		},
+		{name: "unknown attribute", body: `{"Operations":[{"op":"replace","path":"owner","value":"x"}]}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, provisioner := newTestSCIMHandler()
+			provisioner.On("GetGroup", mock.Anything, "team1", "g1").
+				Return(&domain.Group{ID: "g1", DisplayName: "Marketing", MemberIDs: []string{"m1", "m2"}}, nil)
+			if tt.expectCall {
+				provisioner.On("ReplaceGroup", mock.Anything, "team1", mock.MatchedBy(func(g *domain.Group) bool {
+					return assert.ObjectsAreEqual(tt.expectedMembers, g.MemberIDs)
+				})).Return(tt.serviceErr)
+			}
+			req := scimRequest(http.MethodPatch, "/scim/v2/Groups/g1", tt.body)
+			req.SetPathValue("id", "g1")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.PatchGroup(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			provisioner.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSCIMToken(t *testing.T) {
+	t.Run("issue", func(t *testing.T) {
+		// Arrange
+		handler, provisioner := newTestSCIMHandler()
+		provisioner.On("IssueToken", mock.Anything).Return("scim_abc", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.IssueToken(w, httptest.NewRequest(http.MethodPost, "/api/v1/scim/token", nil))
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"token":"scim_abc","endpoint":"https://sho.rt/scim/v2"`)
+	})
+
+	t.Run("issue as editor", func(t *testing.T) {
+		// Arrange
+		handler, provisioner := newTestSCIMHandler()
+		provisioner.On("IssueToken", mock.Anything).Return("", domain.ErrForbidden)
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.IssueToken(w, httptest.NewRequest(http.MethodPost, "/api/v1/scim/token", nil))
+
+		// Assert
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("revoke without a token", func(t *testing.T) {
+		// Arrange
+		handler, provisioner := newTestSCIMHandler()
+		provisioner.On("RevokeToken", mock.Anything).Return(domain.ErrSCIMTokenNotFound)
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.RevokeToken(w, httptest.NewRequest(http.MethodDelete, "/api/v1/scim/token", nil))
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
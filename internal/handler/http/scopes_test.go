@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/internal/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// keyAuthenticator authenticates one scoped API key
+type keyAuthenticator struct {
+	key    string
+	scopes []auth.Scope
+}
+
+func (a keyAuthenticator) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	if token != a.key {
+		return nil, auth.ErrInvalidCredentials
+	}
+	return &auth.Principal{ID: "team1", Scopes: a.scopes}, nil
+}
+
+func TestScopes(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/export", ok)
+	mux.HandleFunc("DELETE /api/v1/urls/{id}", ok)
+	mux.HandleFunc("GET /api/v1/urls/{code}/summary", ok)
+	mux.HandleFunc("PUT /api/v1/settings", ok)
+	handler := Scopes(mux, RouteScopes{
+		"GET /api/v1/urls/{code}/summary": auth.ScopeStatsRead,
+		"PUT /api/v1/settings":            auth.ScopeAdmin,
+	})(mux)
+
+	tests := []struct {
+		name           string
+		principal      *auth.Principal
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{name: "read key reads", principal: &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeURLsRead}}, method: http.MethodGet, path: "/api/v1/export", expectedStatus: http.StatusOK},
+		{name: "read key can't delete", principal: &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeURLsRead}}, method: http.MethodDelete, path: "/api/v1/urls/1", expectedStatus: http.StatusForbidden},
+		{name: "write key deletes", principal: &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeURLsWrite}}, method: http.MethodDelete, path: "/api/v1/urls/1", expectedStatus: http.StatusOK},
+		{name: "listed route", principal: &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeStatsRead}}, method: http.MethodGet, path: "/api/v1/urls/abc/summary", expectedStatus: http.StatusOK},
+		{name: "urls:read is not stats:read", principal: &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeURLsRead}}, method: http.MethodGet, path: "/api/v1/urls/abc/summary", expectedStatus: http.StatusForbidden},
+		{name: "write key can't change settings", principal: &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeURLsWrite}}, method: http.MethodPut, path: "/api/v1/settings", expectedStatus: http.StatusForbidden},
+		{name: "admin key does everything", principal: &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeAdmin}}, method: http.MethodPut, path: "/api/v1/settings", expectedStatus: http.StatusOK},
+		{name: "unscoped principal", principal: &auth.Principal{ID: "admin", Admin: true}, method: http.MethodPut, path: "/api/v1/settings", expectedStatus: http.StatusOK},
+		{name: "anonymous", method: http.MethodDelete, path: "/api/v1/urls/1", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestQuickKeyAuth_Scopes(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	authenticator := auth.NewChainAuthenticator(
+		keyAuthenticator{key: "sk_read", scopes: []auth.Scope{auth.ScopeURLsRead}},
+		keyAuthenticator{key: "sk_write", scopes: []auth.Scope{auth.ScopeURLsWrite}},
+	)
+	endpoint := QuickKeyAuth(authenticator, handler.QuickCreate)
+	mockService.On("CreateShortURL", mock.Anything, "https://example.com", "", "team1", mock.Anything).Return(nil, assert.AnError).Maybe()
+
+	// Act
+	readOnly := httptest.NewRecorder()
+	endpoint(readOnly, httptest.NewRequest(http.MethodGet, "/api/v1/quick?url=https://example.com&key=sk_read", nil))
+	writer := httptest.NewRecorder()
+	endpoint(writer, httptest.NewRequest(http.MethodGet, "/api/v1/quick?url=https://example.com&key=sk_write", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, readOnly.Code)
+	assert.Equal(t, "This API key lacks the urls:write scope\n", readOnly.Body.String())
+	assert.NotEqual(t, http.StatusForbidden, writer.Code)
+	mockService.AssertNumberOfCalls(t, "CreateShortURL", 1)
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/auth"
+)
+
+// retryableStatuses are the responses a client may retry with backoff
+// 429 and 503 come with Retry-After; 502 and 504 are a proxy giving up
+var retryableStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RateLimitPolicy is the request rate limit of the server (see RateLimitMiddleware)
+type RateLimitPolicy struct {
+	RequestsPerMinute int // 0 = not rate limited
+	Burst             int
+}
+
+// SDKHandler describes the API to client libraries and code generators
+//
+// WHY AN ENDPOINT?
+// The OpenAPI document says what the API looks like; it can't say what
+// THIS server and THIS key are allowed to do. A client library fetches its
+// configuration here once and knows where to send requests, how to
+// authenticate, and how fast it may go.
+type SDKHandler struct {
+	baseURL string
+	limits  RateLimitPolicy
+	quotas  UsageReporter // Optional: the key's plan and what is left of it
+	logger  *slog.Logger
+}
+
+// NewSDKHandler creates a new SDK handler
+func NewSDKHandler(baseURL string, limits RateLimitPolicy, logger *slog.Logger) *SDKHandler {
+	return &SDKHandler{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		limits:  limits,
+		logger:  logger,
+	}
+}
+
+// WithQuotas adds the caller's plan usage to the configuration
+func (h *SDKHandler) WithQuotas(quotas UsageReporter) *SDKHandler {
+	h.quotas = quotas
+	return h
+}
+
+// GetConfig handles GET /api/v1/sdk/config (authenticated)
+func (h *SDKHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	principal := auth.FromContext(r.Context())
+
+	response := v1.SDKConfigResponse{
+		BaseURL:    h.baseURL,
+		APIBaseURL: h.baseURL + "/api/v1",
+		OpenAPIURL: h.baseURL + "/api/openapi.json",
+		Auth: v1.SDKAuth{
+			Scheme:    "bearer",
+			Header:    "Authorization",
+			Prefix:    "Bearer ",
+			Principal: principal.ID,
+			Admin:     principal.Admin,
+			Plan:      principal.Plan,
+		},
+		Retry: v1.SDKRetryPolicy{
+			Statuses:         retryableStatuses,
+			RetryAfterHeader: "Retry-After",
+		},
+	}
+
+	if h.limits.RequestsPerMinute > 0 {
+		response.RateLimit = &v1.SDKRateLimit{
+			RequestsPerMinute: h.limits.RequestsPerMinute,
+			Burst:             h.limits.Burst,
+			Scope:             "ip",
+			LimitHeader:       "X-RateLimit-Limit",
+			RemainingHeader:   "X-RateLimit-Remaining",
+			ResetHeader:       "X-RateLimit-Reset",
+		}
+	}
+
+	if h.quotas != nil {
+		// The plan is a nice-to-have here: without it the configuration
+		// is still complete, so a failure only logs
+		usage, err := h.quotas.GetUsage(r.Context(), principal)
+		if err != nil {
+			h.logger.Warn("Failed to get quota usage", "error", err)
+		} else if usage != nil {
+			quota := usageResponse(usage)
+			response.Quota = &quota
+		}
+	}
+
+	respondSuccess(w, http.StatusOK, response, "")
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSDKHandler_GetConfig(t *testing.T) {
+	alice := &auth.Principal{ID: "alice", Plan: "free"}
+
+	tests := []struct {
+		name          string
+		limits        RateLimitPolicy
+		withQuotas    bool
+		quotaErr      error
+		wantRateLimit bool
+		wantQuota     bool
+	}{
+		{name: "rate limited with a plan", limits: RateLimitPolicy{RequestsPerMinute: 100, Burst: 120}, withQuotas: true, wantRateLimit: true, wantQuota: true},
+		{name: "no rate limit", withQuotas: true, wantQuota: true},
+		{name: "no quotas", limits: RateLimitPolicy{RequestsPerMinute: 100, Burst: 120}, wantRateLimit: true},
+		{name: "quota lookup fails", withQuotas: true, quotaErr: assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+			handler := NewSDKHandler("https://sho.rt/", tt.limits, logger)
+			if tt.withQuotas {
+				mockQuotas := new(MockUsageReporter)
+				var usage interface{}
+				if tt.quotaErr == nil {
+					usage = freeUsage("alice", 42)
+				}
+				mockQuotas.On("GetUsage", mock.Anything, alice).Return(usage, tt.quotaErr)
+				handler.WithQuotas(mockQuotas)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/sdk/config", nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), alice))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.GetConfig(w, req)
+
+			// Assert
+			require.Equal(t, http.StatusOK, w.Code)
+			var response struct {
+				Data v1.SDKConfigResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			config := response.Data
+
+			assert.Equal(t, "https://sho.rt", config.BaseURL)
+			assert.Equal(t, "https://sho.rt/api/v1", config.APIBaseURL)
+			assert.Equal(t, "https://sho.rt/api/openapi.json", config.OpenAPIURL)
+			assert.Equal(t, v1.SDKAuth{Scheme: "bearer", Header: "Authorization", Prefix: "Bearer ", Principal: "alice", Plan: "free"}, config.Auth)
+			assert.Contains(t, config.Retry.Statuses, http.StatusTooManyRequests)
+
+			if tt.wantRateLimit {
+				require.NotNil(t, config.RateLimit)
+				assert.Equal(t, 100, config.RateLimit.RequestsPerMinute)
+				assert.Equal(t, 120, config.RateLimit.Burst)
+				assert.Equal(t, "X-RateLimit-Remaining", config.RateLimit.RemainingHeader)
+			} else {
+				assert.Nil(t, config.RateLimit)
+			}
+			if tt.wantQuota {
+				require.NotNil(t, config.Quota)
+				assert.Equal(t, int64(42), config.Quota.Used)
+			} else {
+				assert.Nil(t, config.Quota)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+const (
+	defaultSearchLimit = 20  // Hits per page without ?limit=
+	maxSearchLimit     = 100 // Search pages are ranked, so deep pages are rarely useful
+)
+
+// Searcher is the service the search endpoint needs
+// Implemented by service.SearchService
+type Searcher interface {
+	Search(ctx context.Context, text string, q domain.SearchQuery) (*domain.SearchResult, error)
+}
+
+// SearchHandler serves full-text search over links
+type SearchHandler struct {
+	searcher Searcher
+	logger   *slog.Logger
+	baseURL  string
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(searcher Searcher, logger *slog.Logger, baseURL string) *SearchHandler {
+	return &SearchHandler{searcher: searcher, logger: logger, baseURL: baseURL}
+}
+
+// Search handles GET /api/v1/search?q=&domain=&active=&owner=&limit=&offset=
+// (authenticated)
+//
+// Every word of q must match the start of a word in the link's alias,
+// title, destination or description. Filters:
+//   - domain: only links on this host
+//   - active: true or false, only active or deactivated links
+//   - owner:  admins only, one owner's links (default: everyone's)
+//
+// Unlike the list endpoints, a page is 20 hits unless ?limit= says otherwise.
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if page.Limit == 0 {
+		page.Limit = defaultSearchLimit
+	}
+	if page.Limit > maxSearchLimit {
+		respondError(w, http.StatusBadRequest, "limit must be a number between 1 and "+strconv.Itoa(maxSearchLimit))
+		return
+	}
+
+	filters := domain.SearchQuery{
+		Owner:  query.Get("owner"),
+		Domain: strings.ToLower(strings.TrimSpace(query.Get("domain"))),
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+	if raw := query.Get("active"); raw != "" {
+		active, err := strconv.ParseBool(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "active must be true or false")
+			return
+		}
+		filters.Active = &active
+	}
+
+	result, err := h.searcher.Search(r.Context(), query.Get("q"), filters)
+	if err != nil {
+		h.respondSearchError(w, err)
+		return
+	}
+
+	terms, _ := domain.ParseSearchTerms(query.Get("q"))
+	hits := make([]v1.SearchHit, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		hits = append(hits, searchHitResponse(h.baseURL, hit, terms))
+	}
+	respondList(w, hits, &v1.Pagination{
+		Limit:   page.Limit,
+		Offset:  page.Offset,
+		Total:   result.Total,
+		HasMore: page.Offset+len(hits) < result.Total,
+	})
+}
+
+// respondSearchError answers 400 for searches without words, 403 for
+// other owners' links and 500 for everything else
+func (h *SearchHandler) respondSearchError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidSearch):
+		respondError(w, http.StatusBadRequest, "Search needs at least one word")
+	case errors.Is(err, domain.ErrForbidden):
+		respondError(w, http.StatusForbidden, "Only admins can search other owners' links")
+	default:
+		h.logger.Error("Failed to search URLs", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to search URLs")
+	}
+}
+
+// searchHitResponse converts a hit to its API representation, with the
+// matching fields highlighted
+func searchHitResponse(baseURL string, hit *domain.SearchHit, terms []string) v1.SearchHit {
+	url := hit.URL
+	alias := url.ShortCode
+	if url.CustomAlias != nil {
+		alias = *url.CustomAlias
+	}
+
+	// The owner's preview card wins over what the destination page says
+	var title, description string
+	if url.Metadata != nil {
+		title, description = url.Metadata.Title, url.Metadata.Description
+	}
+	if url.Preview != nil && url.Preview.Title != "" {
+		title = url.Preview.Title
+	}
+	if url.Preview != nil && url.Preview.Description != "" {
+		description = url.Preview.Description
+	}
+
+	highlights := make(map[string]string)
+	for field, text := range map[string]string{
+		"alias":        alias,
+		"title":        title,
+		"original_url": url.OriginalURL,
+		"description":  description,
+	} {
+		if highlighted := domain.Highlight(text, terms); highlighted != "" {
+			highlights[field] = highlighted
+		}
+	}
+
+	return v1.SearchHit{
+		ID:          url.ID,
+		ShortCode:   url.ShortCode,
+		ShortURL:    buildShortURL(baseURL, url),
+		OriginalURL: url.OriginalURL,
+		CustomAlias: url.CustomAlias,
+		Domain:      url.Domain,
+		Title:       title,
+		IsActive:    url.IsActive,
+		CreatedAt:   url.CreatedAt,
+		Clicks:      url.Clicks,
+		Score:       hit.Rank,
+		Highlights:  highlights,
+	}
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSearcher is a mock implementation of Searcher
+type MockSearcher struct {
+	mock.Mock
+}
+
+func (m *MockSearcher) Search(ctx context.Context, text string, q domain.SearchQuery) (*domain.SearchResult, error) {
+	args := m.Called(ctx, text, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SearchResult), args.Error(1)
+}
+
+func newTestSearchHandler() (*SearchHandler, *MockSearcher) {
+	searcher := new(MockSearcher)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewSearchHandler(searcher, logger, "http://localhost:8080"), searcher
+}
+
+func TestSearch(t *testing.T) {
+	active := true
+	hit := &domain.SearchHit{Rank: 0.6, URL: &domain.URL{
+		ID:          "url-1",
+		ShortCode:   "sale26",
+		OriginalURL: "https://shop.example.com/summer-sale",
+		CreatedAt:   time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		IsActive:    true,
+		Metadata:    &domain.LinkMetadata{Title: "Big Summer Sale"},
+	}}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedQuery  *domain.SearchQuery
+		serviceErr     error
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:           "hits",
+			query:          "?q=summer+sale&limit=1",
+			expectedQuery:  &domain.SearchQuery{Limit: 1},
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				`"short_url":"http://localhost:8080/sale26"`,
+				`"total":3,"has_more":true`,
+			},
+		},
+		{
+			name:           "filters",
+			query:          "?q=sale&domain=Go.Example.com&active=true",
+			expectedQuery:  &domain.SearchQuery{Domain: "go.example.com", Active: &active, Limit: 20},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid active filter",
+			query:          "?q=sale&active=maybe",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "page too large",
+			query:          "?q=sale&limit=500",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "no words",
+			query:          "?q=--",
+			expectedQuery:  &domain.SearchQuery{Limit: 20},
+			serviceErr:     domain.ErrInvalidSearch,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   []string{"at least one word"},
+		},
+		{
+			name:           "other owner",
+			query:          "?q=sale&owner=user2",
+			expectedQuery:  &domain.SearchQuery{Owner: "user2", Limit: 20},
+			serviceErr:     domain.ErrForbidden,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, searcher := newTestSearchHandler()
+			if tt.expectedQuery != nil {
+				if tt.serviceErr != nil {
+					searcher.On("Search", mock.Anything, mock.Anything, *tt.expectedQuery).Return(nil, tt.serviceErr)
+				} else {
+					searcher.On("Search", mock.Anything, mock.Anything, *tt.expectedQuery).
+						Return(&domain.SearchResult{Hits: []*domain.SearchHit{hit}, Total: 3}, nil)
+				}
+			}
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/search"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.Search(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			for _, expected := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), expected)
+			}
+			searcher.AssertExpectations(t)
+			if tt.expectedQuery == nil {
+				searcher.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestSearch_Highlights(t *testing.T) {
+	// Arrange
+	handler, searcher := newTestSearchHandler()
+	hit := &domain.SearchHit{Rank: 0.6, URL: &domain.URL{
+		ID:          "url-1",
+		ShortCode:   "sale26",
+		OriginalURL: "https://shop.example.com/summer-sale",
+		Metadata:    &domain.LinkMetadata{Title: "Big <Summer> Sale", Description: "Everything must go"},
+	}}
+	searcher.On("Search", mock.Anything, "summer sale", mock.Anything).
+		Return(&domain.SearchResult{Hits: []*domain.SearchHit{hit}, Total: 1}, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/search?q=summer+sale", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.Search(w, req)
+
+	// Assert
+	var response struct {
+		Data []v1.SearchHit `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, map[string]string{
+		"alias":        "<mark>sale26</mark>",
+		"title":        "Big &lt;<mark>Summer</mark>&gt; <mark>Sale</mark>",
+		"original_url": "https://shop.example.com/<mark>summer</mark>-<mark>sale</mark>",
+	}, response.Data[0].Highlights, "fields without a match aren't highlighted")
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"net/http"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+// SecretManager is what burn-after-reading links need
+// Implemented by service.SecretService
+type SecretManager interface {
+	CreateSecret(ctx context.Context, secret domain.Secret, expiresIn time.Duration) (*domain.URL, error)
+	Reveal(ctx context.Context, url *domain.URL, ipAddress, userAgent string) (*domain.Secret, error)
+	ListAudit(ctx context.Context, id string) ([]*domain.AuditEntry, error)
+}
+
+// secretPageData is what web/templates/secret.html renders
+type secretPageData struct {
+	Action   string // Where the reveal form posts: the link itself (with ?sig= for signed links)
+	Revealed bool
+	Text     string
+	URL      string
+	Lang     string                              // <html lang>
+	T        func(string, ...interface{}) string // {{call .T "Reveal secret"}}
+}
+
+// WithSecrets enables burn-after-reading links, revealed on a page rendered
+// from tmpl
+func (h *Handler) WithSecrets(secrets SecretManager, tmpl *template.Template) *Handler {
+	h.secrets = secrets
+	h.secretTmpl = tmpl
+	return h
+}
+
+// CreateSecret handles POST /api/v1/secrets (authenticated)
+func (h *Handler) CreateSecret(w http.ResponseWriter, r *http.Request) {
+	var req v1.CreateSecretRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var expiresIn time.Duration
+	if req.ExpiresInHours > 0 {
+		expiresIn = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+
+	url, err := h.secrets.CreateSecret(r.Context(), domain.Secret{Text: req.Text, URL: req.URL}, expiresIn)
+	if err != nil {
+		status := createErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to create secret", "error", err)
+			respondError(w, status, "Failed to create secret")
+			return
+		}
+		respondError(w, status, err.Error())
+		return
+	}
+
+	respondSuccess(w, http.StatusCreated, v1.CreateSecretResponse{
+		ID:        url.ID,
+		ShortCode: url.ShortCode,
+		ShortURL:  h.shortURL(url),
+		CreatedAt: url.CreatedAt,
+		ExpiresAt: url.ExpiresAt,
+	}, "Secret created - it can be revealed once")
+}
+
+// ListAudit handles GET /api/v1/urls/{id}/audit (owner or admin)
+func (h *Handler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	window, err := parsePageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := h.secrets.ListAudit(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.respondLookupError(w, "Failed to list audit log", err)
+		return
+	}
+
+	response := make([]v1.AuditEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, v1.AuditEntryResponse{
+			ID:         entry.ID,
+			Action:     string(entry.Action),
+			Actor:      entry.Actor,
+			IPAddress:  entry.IPAddress,
+			UserAgent:  entry.UserAgent,
+			OccurredAt: entry.OccurredAt,
+		})
+	}
+	items, pagination := paginate(response, window)
+	respondList(w, items, pagination)
+}
+
+// serveSecret answers the short link of a burn-after-reading link
+//
+// TWO STEPS:
+//   - GET shows a confirmation page with a "Reveal" button. Chat apps, mail
+//     scanners and preview bots open links on their own - if a GET revealed
+//     the secret, they would burn it before the recipient ever saw it.
+//   - POST (the button) reveals the secret and destroys it.
+//
+// API clients (no text/html in Accept) get the same steps as JSON.
+func (h *Handler) serveSecret(w http.ResponseWriter, r *http.Request, url *domain.URL) {
+	if h.secrets == nil {
+		h.respondLinkError(w, r, http.StatusServiceUnavailable, "This link can't be opened", domain.ErrSecretsUnavailable.Error())
+		return
+	}
+
+	// Neither the page nor the secret may be stored anywhere, and the
+	// revealed URL must not learn where it was shared from
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	if r.Method != http.MethodPost {
+		h.renderSecret(w, r, url, nil, "Reveal the secret with a POST to this URL - it can only be done once")
+		return
+	}
+
+	secret, err := h.secrets.Reveal(r.Context(), url, clientIP(r), r.UserAgent())
+	if err != nil {
+		if isGone(err) {
+			h.respondLinkError(w, r, http.StatusGone, "This link is no longer available", err.Error())
+			return
+		}
+		h.logger.Error("Failed to reveal secret", "short_code", url.ShortCode, "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to reveal secret")
+		return
+	}
+
+	h.recordClick(r, url.ShortCode)
+	h.renderSecret(w, r, url, secret, "Secret revealed - this link no longer works")
+}
+
+// renderSecret writes the reveal page (secret == nil) or the revealed
+// secret: HTML for browsers, JSON for everybody else
+func (h *Handler) renderSecret(w http.ResponseWriter, r *http.Request, url *domain.URL, secret *domain.Secret, message string) {
+	response := v1.SecretResponse{ShortCode: url.ShortCode}
+	if secret != nil {
+		response.Revealed = true
+		response.Text = secret.Text
+		response.URL = secret.URL
+	}
+
+	if h.secretTmpl == nil || !prefersHTML(r) {
+		respondSuccess(w, http.StatusOK, response, message)
+		return
+	}
+
+	localizer, localized := localizerFor(w)
+
+	// Render into a buffer first so a template error can't leave a half
+	// written page behind the status line
+	var buf bytes.Buffer
+	data := secretPageData{
+		Action:   r.URL.RequestURI(),
+		Revealed: response.Revealed,
+		Text:     response.Text,
+		URL:      response.URL,
+		Lang:     localizer.Language(),
+		T:        localizer.Translate,
+	}
+	if err := h.secretTmpl.Execute(&buf, data); err != nil {
+		h.logger.Error("Failed to render secret page", "short_code", url.ShortCode, "error", err)
+		respondSuccess(w, http.StatusOK, response, message)
+		return
+	}
+
+	if localized {
+		setLanguageHeaders(w, localizer)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,297 @@
+package http
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSecretManager is a mock implementation of SecretManager
+type MockSecretManager struct {
+	mock.Mock
+}
+
+func (m *MockSecretManager) CreateSecret(ctx context.Context, secret domain.Secret, expiresIn time.Duration) (*domain.URL, error) {
+	args := m.Called(ctx, secret, expiresIn)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockSecretManager) Reveal(ctx context.Context, url *domain.URL, ipAddress, userAgent string) (*domain.Secret, error) {
+	args := m.Called(ctx, url, ipAddress, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Secret), args.Error(1)
+}
+
+func (m *MockSecretManager) ListAudit(ctx context.Context, id string) ([]*domain.AuditEntry, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.AuditEntry), args.Error(1)
+}
+
+// setupSecretHandler returns a handler with burn-after-reading links and
+// the real reveal page
+func setupSecretHandler(t *testing.T) (*Handler, *MockURLService, *MockSecretManager) {
+	t.Helper()
+	tmpl, err := template.ParseFiles(filepath.Join("..", "..", "..", "web", "templates", "secret.html"))
+	require.NoError(t, err)
+
+	handler, mockService := setupTestHandler()
+	secrets := new(MockSecretManager)
+	handler.WithSecrets(secrets, tmpl)
+	return handler, mockService, secrets
+}
+
+func TestRedirectURL_SecretConfirmationPage(t *testing.T) {
+	tests := []struct {
+		name         string
+		accept       string
+		expectedBody string
+	}{
+		{name: "browser", accept: "text/html", expectedBody: `<form method="POST" action="/s3cret?sig=abc">`},
+		{name: "API client", accept: "application/json", expectedBody: `"revealed":false`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService, secrets := setupSecretHandler(t)
+			sealed := "v1.c2VhbGVk"
+			url := &domain.URL{ShortCode: "s3cret", IsActive: true, BurnAfterReading: true, Payload: &sealed}
+			mockService.On("GetURL", mock.Anything, "s3cret").Return(url, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/s3cret?sig=abc", nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.RedirectURL(w, req)
+
+			// Assert: a GET (e.g. a chat app's link scanner) never reveals
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NotContains(t, w.Body.String(), sealed)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+			assert.Empty(t, w.Header().Get("Location"))
+			secrets.AssertNotCalled(t, "Reveal", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			mockService.AssertNotCalled(t, "RecordClick", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRedirectURL_RevealSecret(t *testing.T) {
+	tests := []struct {
+		name           string
+		accept         string
+		secret         *domain.Secret
+		revealErr      error
+		expectedStatus int
+		expectedBody   string
+		expectClick    bool
+	}{
+		{
+			name:           "text in a browser",
+			accept:         "text/html",
+			secret:         &domain.Secret{Text: "wifi: <hunter2>"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `<div class="secret">wifi: &lt;hunter2&gt;</div>`,
+			expectClick:    true,
+		},
+		{
+			name:           "url for an API client",
+			accept:         "application/json",
+			secret:         &domain.Secret{URL: "https://example.com/invite"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"revealed":true,"url":"https://example.com/invite"`,
+			expectClick:    true,
+		},
+		{
+			name:           "revealed by someone else",
+			accept:         "application/json",
+			revealErr:      domain.ErrLinkBurned,
+			expectedStatus: http.StatusGone,
+			expectedBody:   "link has already been revealed",
+		},
+		{
+			name:           "database down",
+			accept:         "application/json",
+			revealErr:      assert.AnError,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService, secrets := setupSecretHandler(t)
+			url := &domain.URL{ShortCode: "s3cret", IsActive: true, BurnAfterReading: true}
+			mockService.On("GetURL", mock.Anything, "s3cret").Return(url, nil)
+			mockService.On("RecordClick", mock.Anything, clickOn("s3cret")).Return(nil).Maybe()
+			// The audit entry gets the client's IP, not our proxy's address and port
+			secrets.On("Reveal", mock.Anything, url, "203.0.113.7", "Mozilla/5.0").Return(tt.secret, tt.revealErr)
+
+			req := httptest.NewRequest(http.MethodPost, "/s3cret", nil)
+			req.RemoteAddr = "10.0.0.2:1234"
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			req.Header.Set("User-Agent", "Mozilla/5.0")
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			proxied := ClientIPMiddleware([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+
+			// Act
+			proxied(http.HandlerFunc(handler.RedirectURL)).ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.Empty(t, w.Header().Get("Location"), "a secret URL is shown, never redirected to")
+			if !tt.expectClick {
+				mockService.AssertNotCalled(t, "RecordClick", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRedirectURL_SecretsNotConfigured(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	mockService.On("GetURL", mock.Anything, "s3cret").Return(&domain.URL{ShortCode: "s3cret", IsActive: true, BurnAfterReading: true}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/s3cret", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.RedirectURL(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Empty(t, w.Header().Get("Location"))
+}
+
+func TestCreateSecret(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "created",
+			body:           `{"text":"wifi: hunter2","expires_in_hours":24}`,
+			expectCall:     true,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"short_url":"http://localhost:8080/s3cret"`,
+		},
+		{
+			name:           "not a web URL",
+			body:           `{"url":"ftp://example.com"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"url"`,
+		},
+		{
+			name:           "text and url",
+			body:           `{"text":"wifi: hunter2","expires_in_hours":24}`,
+			serviceErr:     domain.ErrInvalidSecret,
+			expectCall:     true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "database down",
+			body:           `{"text":"wifi: hunter2","expires_in_hours":24}`,
+			serviceErr:     assert.AnError,
+			expectCall:     true,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, _, secrets := setupSecretHandler(t)
+			if tt.expectCall {
+				var url *domain.URL
+				if tt.serviceErr == nil {
+					url = &domain.URL{ID: "1", ShortCode: "s3cret", BurnAfterReading: true}
+				}
+				secrets.On("CreateSecret", mock.Anything, domain.Secret{Text: "wifi: hunter2"}, 24*time.Hour).Return(url, tt.serviceErr)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/secrets", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.CreateSecret(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NotContains(t, w.Body.String(), "hunter2", "the secret is never echoed back")
+			secrets.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListAudit(t *testing.T) {
+	tests := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "owner",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"action":"link.revealed","actor":"anonymous","ip_address":"203.0.113.7"`,
+		},
+		{name: "someone else's link", serviceErr: domain.ErrForbidden, expectedStatus: http.StatusForbidden},
+		{name: "unknown link", serviceErr: domain.ErrURLNotFound, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, _, secrets := setupSecretHandler(t)
+			var entries []*domain.AuditEntry
+			if tt.serviceErr == nil {
+				entries = []*domain.AuditEntry{{
+					ID:         "a1",
+					Action:     domain.AuditLinkRevealed,
+					Actor:      "anonymous",
+					IPAddress:  "203.0.113.7",
+					OccurredAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+				}}
+			}
+			secrets.On("ListAudit", mock.Anything, "1").Return(entries, tt.serviceErr)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/1/audit", nil)
+			req.SetPathValue("id", "1")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ListAudit(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
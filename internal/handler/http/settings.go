@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+// WorkspaceSettingsManager is the service the settings endpoints need
+// Implemented by service.WorkspaceService
+type WorkspaceSettingsManager interface {
+	GetSettings(ctx context.Context) (*domain.WorkspaceSettings, error)
+	SaveSettings(ctx context.Context, settings *domain.WorkspaceSettings) error
+	SetPlan(ctx context.Context, workspace, plan string) error
+}
+
+// SettingsHandler serves the caller's workspace settings
+type SettingsHandler struct {
+	settings WorkspaceSettingsManager
+	logger   *slog.Logger
+}
+
+// NewSettingsHandler creates a new settings handler
+func NewSettingsHandler(settings WorkspaceSettingsManager, logger *slog.Logger) *SettingsHandler {
+	return &SettingsHandler{settings: settings, logger: logger}
+}
+
+// GetSettings handles GET /api/v1/settings (authenticated)
+func (h *SettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.settings.GetSettings(r.Context())
+	if err != nil {
+		h.respondSettingsError(w, err, "Failed to load settings")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, toSettingsResponse(settings), "")
+}
+
+// PutSettings handles PUT /api/v1/settings (authenticated)
+// The timezone applies to the analytics of every link the caller owns
+// Only the workspace's owners may save (403 for editors, contributors and viewers)
+func (h *SettingsHandler) PutSettings(w http.ResponseWriter, r *http.Request) {
+	var req v1.WorkspaceSettingsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	settings := &domain.WorkspaceSettings{
+		Timezone:        req.Timezone,
+		RequireApproval: req.RequireApproval,
+		GroupRoles:      req.GroupRoles,
+	}
+	if err := h.settings.SaveSettings(r.Context(), settings); err != nil {
+		h.respondSettingsError(w, err, "Failed to save settings")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, toSettingsResponse(settings), "Settings saved")
+}
+
+// PutPlan handles PUT /api/v1/workspaces/{workspace}/plan (admin only)
+// Puts a workspace on a quota plan without Stripe; its API keys, SSO
+// sessions and SCIM members carry the plan from their next request on
+func (h *SettingsHandler) PutPlan(w http.ResponseWriter, r *http.Request) {
+	var req v1.WorkspacePlanRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	workspace := r.PathValue("workspace")
+	if err := h.settings.SetPlan(r.Context(), workspace, req.Plan); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrForbidden):
+			respondError(w, http.StatusForbidden, "Only operators can change plans")
+		case errors.Is(err, domain.ErrUnknownPlan):
+			respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			h.logger.Error("Failed to set workspace plan", "error", err)
+			respondError(w, http.StatusInternalServerError, "Failed to set workspace plan")
+		}
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, v1.WorkspacePlanResponse{Workspace: workspace, Plan: req.Plan}, "Plan saved")
+}
+
+// respondSettingsError maps settings errors to HTTP statuses
+func (h *SettingsHandler) respondSettingsError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		respondError(w, http.StatusForbidden, "Only workspace owners can change settings")
+	case errors.Is(err, domain.ErrInvalidTimezone), errors.Is(err, domain.ErrInvalidGroupRoles):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Error(message, "error", err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+// toSettingsResponse converts workspace settings for the API
+func toSettingsResponse(settings *domain.WorkspaceSettings) v1.WorkspaceSettingsResponse {
+	response := v1.WorkspaceSettingsResponse{
+		Workspace:       settings.Workspace,
+		Timezone:        settings.Timezone,
+		RequireApproval: settings.RequireApproval,
+		GroupRoles:      settings.GroupRoles,
+		Plan:            settings.Plan,
+	}
+	if !settings.UpdatedAt.IsZero() {
+		response.UpdatedAt = &settings.UpdatedAt
+	}
+	return response
+}
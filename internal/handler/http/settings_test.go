@@ -0,0 +1,178 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWorkspaceSettingsManager is a mock implementation of WorkspaceSettingsManager
+type MockWorkspaceSettingsManager struct {
+	mock.Mock
+}
+
+func (m *MockWorkspaceSettingsManager) GetSettings(ctx context.Context) (*domain.WorkspaceSettings, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WorkspaceSettings), args.Error(1)
+}
+
+func (m *MockWorkspaceSettingsManager) SaveSettings(ctx context.Context, settings *domain.WorkspaceSettings) error {
+	args := m.Called(ctx, settings)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceSettingsManager) SetPlan(ctx context.Context, workspace, plan string) error {
+	args := m.Called(ctx, workspace, plan)
+	return args.Error(0)
+}
+
+func newTestSettingsHandler() (*SettingsHandler, *MockWorkspaceSettingsManager) {
+	settings := new(MockWorkspaceSettingsManager)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewSettingsHandler(settings, logger), settings
+}
+
+func TestGetSettings(t *testing.T) {
+	// Arrange
+	handler, settings := newTestSettingsHandler()
+	settings.On("GetSettings", mock.Anything).Return(&domain.WorkspaceSettings{Workspace: "user1"}, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/settings", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.GetSettings(w, req)
+
+	// Assert: never saved, so no updated_at
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"data":{"workspace":"user1","timezone":""}`)
+}
+
+func TestPutSettings(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "timezone saved",
+			body:           `{"timezone":"Europe/Berlin"}`,
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"timezone":"Europe/Berlin"`,
+		},
+		{
+			name:           "unknown timezone",
+			body:           `{"timezone":"Mars/Olympus"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"timezone":"timezone must be an IANA timezone such as \"Europe/Berlin\""`,
+		},
+		{
+			name:           "approval mode saved",
+			body:           `{"timezone":"UTC","require_approval":true}`,
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"require_approval":true`,
+		},
+		{
+			name:           "group roles saved",
+			body:           `{"timezone":"UTC","group_roles":{"Marketing":"editor","Everyone":"viewer"}}`,
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"group_roles":{"Everyone":"viewer","Marketing":"editor"}`,
+		},
+		{
+			name:           "unknown group role",
+			body:           `{"timezone":"UTC","group_roles":{"Marketing":"admin"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `must be one of: owner, editor, contributor, viewer`,
+		},
+		{name: "too many groups", body: `{"timezone":"UTC"}`, serviceErr: domain.ErrInvalidGroupRoles, expectCall: true, expectedStatus: http.StatusBadRequest},
+		{name: "not an owner", body: `{"timezone":"UTC"}`, serviceErr: domain.ErrForbidden, expectCall: true, expectedStatus: http.StatusForbidden},
+		{name: "invalid JSON", body: `{`, expectedStatus: http.StatusBadRequest},
+		{name: "database down", body: `{"timezone":"UTC"}`, serviceErr: assert.AnError, expectCall: true, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, settings := newTestSettingsHandler()
+			if tt.expectCall {
+				settings.On("SaveSettings", mock.Anything, mock.AnythingOfType("*domain.WorkspaceSettings")).
+					Run(func(args mock.Arguments) {
+						saved := args.Get(1).(*domain.WorkspaceSettings)
+						saved.Workspace = "user1"
+						saved.UpdatedAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+					}).
+					Return(tt.serviceErr)
+			}
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/settings", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.PutSettings(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			settings.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPutPlan(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "pro plan", body: `{"plan":"pro"}`, expectCall: true, expectedStatus: http.StatusOK, expectedBody: `"data":{"workspace":"team1","plan":"pro"}`},
+		{name: "back to the default plan", body: `{"plan":""}`, expectCall: true, expectedStatus: http.StatusOK, expectedBody: `"plan":""`},
+		{name: "unknown plan", body: `{"plan":"enterprise"}`, expectedStatus: http.StatusBadRequest},
+		{name: "not an operator", body: `{"plan":"pro"}`, serviceErr: domain.ErrForbidden, expectCall: true, expectedStatus: http.StatusForbidden},
+		{name: "database down", body: `{"plan":"pro"}`, serviceErr: assert.AnError, expectCall: true, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, settings := newTestSettingsHandler()
+			settings.On("SetPlan", mock.Anything, "team1", mock.Anything).Return(tt.serviceErr)
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/workspaces/team1/plan", strings.NewReader(tt.body))
+			req.SetPathValue("workspace", "team1")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.PutPlan(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			if tt.expectCall {
+				settings.AssertExpectations(t)
+			} else {
+				settings.AssertNotCalled(t, "SetPlan", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
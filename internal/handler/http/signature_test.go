@@ -0,0 +1,141 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectURL_SignedLink(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	secret := "s3cret"
+	signed := &domain.URL{ShortCode: "abc123", OriginalURL: "https://example.com", IsActive: true, SigningSecret: &secret}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedError  string
+	}{
+		{name: "valid signature", query: "?" + signed.SignedQuery(time.Time{}).Encode(), expectedStatus: http.StatusFound},
+		{name: "valid until next week", query: "?" + signed.SignedQuery(now.Add(7*24*time.Hour)).Encode(), expectedStatus: http.StatusFound},
+		{name: "no signature", expectedStatus: http.StatusForbidden, expectedError: "link requires a valid signature"},
+		{name: "tampered signature", query: "?sig=AAAAAAAAAAAAAAAAAAAAAA", expectedStatus: http.StatusForbidden, expectedError: "link requires a valid signature"},
+		{name: "expired signature", query: "?" + signed.SignedQuery(now.Add(-time.Minute)).Encode(), expectedStatus: http.StatusForbidden, expectedError: "link signature has expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			handler.WithEdgeCaching(time.Hour)
+			handler.now = func() time.Time { return now }
+			mockService.On("GetURL", mock.Anything, "abc123").Return(signed, nil)
+			mockService.On("RecordClick", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			req := httptest.NewRequest(http.MethodGet, "/abc123"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.RedirectURL(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Empty(t, w.Header().Get("Cache-Control"), "signed links must not be cached at the edge")
+			if tt.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedError)
+				mockService.AssertNotCalled(t, "RecordClick", mock.Anything, mock.Anything)
+			} else {
+				assert.Equal(t, "https://example.com", w.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestCreateURL_Signed(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	secret := "s3cret"
+	url := &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com", SigningSecret: &secret}
+	mockService.On("CreateShortURL", mock.Anything, "https://example.com", "", "anonymous", time.Duration(0)).Return(url, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/urls", bytes.NewBufferString(`{"url":"https://example.com","signed":true}`))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.CreateURL(w, req)
+
+	// Assert: the owner gets the secret and a link that opens
+	require.Equal(t, http.StatusCreated, w.Code)
+	var response struct {
+		Data v1.CreateURLResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Data.Signed)
+	assert.Equal(t, &secret, response.Data.SigningSecret)
+	assert.Equal(t, "http://localhost:8080/abc123?sig="+url.Signature(time.Time{}), response.Data.SignedURL)
+}
+
+func TestSignURL(t *testing.T) {
+	secret := "s3cret"
+	url := &domain.URL{ID: "123", ShortCode: "abc123", SigningSecret: &secret}
+	expiresAt := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "signed for a week",
+			body:           `{"expires_in_hours":168}`,
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"signed_url":"http://localhost:8080/abc123?exp=1772971200\u0026sig=` + url.Signature(expiresAt) + `","expires_at":"2026-03-08T12:00:00Z"`,
+		},
+		{name: "negative expiry", body: `{"expires_in_hours":-1}`, expectedStatus: http.StatusBadRequest},
+		{name: "not a signed link", body: `{}`, serviceErr: domain.ErrLinkNotSigned, expectCall: true, expectedStatus: http.StatusBadRequest},
+		{name: "someone else's link", body: `{}`, serviceErr: domain.ErrForbidden, expectCall: true, expectedStatus: http.StatusForbidden},
+		{name: "unknown link", body: `{}`, serviceErr: domain.ErrURLNotFound, expectCall: true, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			if tt.expectCall {
+				var signed *domain.SignedLink
+				if tt.serviceErr == nil {
+					signed = &domain.SignedLink{URL: url, Query: url.SignedQuery(expiresAt), ExpiresAt: &expiresAt}
+				}
+				mockService.On("SignURL", mock.Anything, "123", mock.AnythingOfType("time.Duration")).Return(signed, tt.serviceErr)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/urls/123/sign", bytes.NewBufferString(tt.body))
+			req.SetPathValue("id", "123")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.SignURL(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
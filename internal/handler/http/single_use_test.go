@@ -0,0 +1,89 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRedirectURL_SingleUse(t *testing.T) {
+	tests := []struct {
+		name           string
+		useErr         error
+		expectedStatus int
+		expectClick    bool
+	}{
+		{name: "first visitor", expectedStatus: http.StatusFound, expectClick: true},
+		{name: "lost the race", useErr: domain.ErrLinkUsed, expectedStatus: http.StatusGone},
+		{name: "database down", useErr: assert.AnError, expectedStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			handler.WithEdgeCaching(time.Hour)
+			url := &domain.URL{ShortCode: "reset1", OriginalURL: "https://example.com/reset", IsActive: true, SingleUse: true}
+			mockService.On("GetURL", mock.Anything, "reset1").Return(url, nil)
+			mockService.On("UseOnce", mock.Anything, url).Return(tt.useErr)
+			mockService.On("RecordClick", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			req := httptest.NewRequest(http.MethodGet, "/reset1", nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.RedirectURL(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Empty(t, w.Header().Get("Cache-Control"), "single-use links must not be cached at the edge")
+			if tt.expectClick {
+				assert.Equal(t, "https://example.com/reset", w.Header().Get("Location"))
+			} else {
+				assert.Empty(t, w.Header().Get("Location"))
+				mockService.AssertNotCalled(t, "RecordClick", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRedirectURL_SingleUseAlreadyUsed(t *testing.T) {
+	// Arrange: the lookup itself reports the link as used up
+	handler, mockService := setupTestHandler()
+	mockService.On("GetURL", mock.Anything, "reset1").Return(nil, domain.ErrLinkUsed)
+
+	req := httptest.NewRequest(http.MethodGet, "/reset1", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.RedirectURL(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Contains(t, w.Body.String(), "link has already been used")
+	mockService.AssertNotCalled(t, "UseOnce", mock.Anything, mock.Anything)
+}
+
+func TestCreateURL_SingleUse(t *testing.T) {
+	// Arrange
+	handler, mockService := setupTestHandler()
+	url := &domain.URL{ID: "123", ShortCode: "reset1", OriginalURL: "https://example.com/reset", SingleUse: true}
+	mockService.On("CreateShortURL", mock.Anything, "https://example.com/reset", "", "anonymous", time.Duration(0)).Return(url, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/urls", bytes.NewBufferString(`{"url":"https://example.com/reset","single_use":true}`))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.CreateURL(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"single_use":true`)
+}
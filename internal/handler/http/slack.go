@@ -0,0 +1,168 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/slack"
+)
+
+// maxSlackBodyBytes caps slash-command bodies (Slack sends well under 4 KB)
+const maxSlackBodyBytes = 16 << 10
+
+// SlackCommands is the service the Slack endpoints need
+// Implemented by service.SlackService
+type SlackCommands interface {
+	Workspace(ctx context.Context, teamID string) (*domain.SlackWorkspace, error)
+	SaveWorkspace(ctx context.Context, ws *domain.SlackWorkspace) error
+	DeleteWorkspace(ctx context.Context, teamID string) error
+	Shorten(ctx context.Context, ws *domain.SlackWorkspace, text string) (*domain.URL, error)
+}
+
+// SlackHandler serves the /shorten slash command and its admin settings
+type SlackHandler struct {
+	slack   SlackCommands
+	logger  *slog.Logger
+	baseURL string
+	now     func() time.Time
+}
+
+// NewSlackHandler creates a new Slack handler
+func NewSlackHandler(commands SlackCommands, logger *slog.Logger, baseURL string) *SlackHandler {
+	return &SlackHandler{
+		slack:   commands,
+		logger:  logger,
+		baseURL: baseURL,
+		now:     time.Now,
+	}
+}
+
+// Command handles POST /integrations/slack
+//
+// VERIFYING THE REQUEST:
+// The body names the team, but it's only a claim until the signature is
+// checked with THAT team's signing secret. So: read the raw body, look up
+// the team, verify, and only then act on the command.
+//
+// Slack shows non-200 answers as a generic "dispatch_failed" error, so
+// problems the user can fix (bad URL, taken alias) are 200 replies with
+// an explanation, visible only to them.
+func (h *SlackHandler) Command(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSlackBodyBytes))
+	if err != nil {
+		respondError(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid form body")
+		return
+	}
+	cmd := slack.ParseCommand(form)
+
+	ws, err := h.slack.Workspace(r.Context(), cmd.TeamID)
+	if err != nil {
+		if errors.Is(err, domain.ErrSlackWorkspaceNotFound) {
+			// Same answer as a bad signature: don't reveal which teams exist
+			respondError(w, http.StatusUnauthorized, "Invalid signature")
+			return
+		}
+		h.logger.Error("Failed to load slack workspace", "team_id", cmd.TeamID, "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to load workspace")
+		return
+	}
+
+	err = slack.VerifySignature(
+		body,
+		r.Header.Get("X-Slack-Request-Timestamp"),
+		r.Header.Get("X-Slack-Signature"),
+		ws.SigningSecret,
+		slack.DefaultTolerance,
+		h.now(),
+	)
+	if err != nil {
+		h.logger.Warn("Rejected slack command", "team_id", cmd.TeamID, "error", err)
+		respondError(w, http.StatusUnauthorized, "Invalid signature")
+		return
+	}
+
+	created, err := h.slack.Shorten(r.Context(), ws, cmd.Text)
+	if err != nil {
+		if errors.Is(err, domain.ErrSlackUsage) {
+			respondJSON(w, http.StatusOK, slack.Response{ResponseType: slack.Ephemeral, Text: err.Error()})
+			return
+		}
+		text := "Sorry, something went wrong. Please try again."
+		if status := createErrorStatus(err); status != http.StatusInternalServerError {
+			text = "Couldn't shorten that link: " + err.Error()
+		} else {
+			h.logger.Error("Failed to create URL from slack", "team_id", cmd.TeamID, "error", err)
+		}
+		respondJSON(w, http.StatusOK, slack.Response{ResponseType: slack.Ephemeral, Text: text})
+		return
+	}
+
+	responseType := slack.Ephemeral
+	if ws.InChannel {
+		responseType = slack.InChannel
+	}
+	h.logger.Info("URL created from slack", "team_id", cmd.TeamID, "user_id", cmd.UserID, "short_code", created.ShortCode)
+	respondJSON(w, http.StatusOK, slack.Response{ResponseType: responseType, Text: buildShortURL(h.baseURL, created)})
+}
+
+// PutWorkspace handles PUT /api/v1/integrations/slack/workspaces/{team} (admin only)
+func (h *SlackHandler) PutWorkspace(w http.ResponseWriter, r *http.Request) {
+	var req v1.SlackWorkspaceRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	ws := &domain.SlackWorkspace{
+		TeamID:        r.PathValue("team"),
+		TeamName:      req.TeamName,
+		SigningSecret: req.SigningSecret,
+		Owner:         req.Owner,
+		Domain:        req.Domain,
+		InChannel:     req.InChannel,
+	}
+	if err := h.slack.SaveWorkspace(r.Context(), ws); err != nil {
+		if errors.Is(err, domain.ErrInvalidSlackWorkspace) || errors.Is(err, domain.ErrInvalidDomain) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.Error("Failed to save slack workspace", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to save workspace")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, v1.SlackWorkspaceResponse{
+		TeamID:    ws.TeamID,
+		TeamName:  ws.TeamName,
+		Owner:     ws.Owner,
+		Domain:    ws.Domain,
+		InChannel: ws.InChannel,
+		UpdatedAt: ws.UpdatedAt,
+	}, "Workspace saved")
+}
+
+// DeleteWorkspace handles DELETE /api/v1/integrations/slack/workspaces/{team} (admin only)
+func (h *SlackHandler) DeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	if err := h.slack.DeleteWorkspace(r.Context(), r.PathValue("team")); err != nil {
+		if errors.Is(err, domain.ErrSlackWorkspaceNotFound) {
+			respondError(w, http.StatusNotFound, "Workspace not found")
+			return
+		}
+		h.logger.Error("Failed to delete slack workspace", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to delete workspace")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
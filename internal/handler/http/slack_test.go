@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/slack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSlackCommands is a mock implementation of SlackCommands
+type MockSlackCommands struct {
+	mock.Mock
+}
+
+func (m *MockSlackCommands) Workspace(ctx context.Context, teamID string) (*domain.SlackWorkspace, error) {
+	args := m.Called(ctx, teamID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SlackWorkspace), args.Error(1)
+}
+
+func (m *MockSlackCommands) SaveWorkspace(ctx context.Context, ws *domain.SlackWorkspace) error {
+	args := m.Called(ctx, ws)
+	return args.Error(0)
+}
+
+func (m *MockSlackCommands) DeleteWorkspace(ctx context.Context, teamID string) error {
+	args := m.Called(ctx, teamID)
+	return args.Error(0)
+}
+
+func (m *MockSlackCommands) Shorten(ctx context.Context, ws *domain.SlackWorkspace, text string) (*domain.URL, error) {
+	args := m.Called(ctx, ws, text)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func TestSlackCommand(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ws := &domain.SlackWorkspace{TeamID: "T1", SigningSecret: "shh", Owner: "slack:T1"}
+	body := url.Values{"team_id": {"T1"}, "user_id": {"U1"}, "command": {"/shorten"}, "text": {"https://example.com"}}.Encode()
+
+	tests := []struct {
+		name           string
+		team           *domain.SlackWorkspace
+		secret         string
+		shortenErr     error
+		expectShorten  bool
+		expectedStatus int
+		expectedText   string
+	}{
+		{name: "creates a link", team: ws, secret: "shh", expectShorten: true, expectedStatus: http.StatusOK, expectedText: "http://localhost:8080/abc123"},
+		{name: "bad signature", team: ws, secret: "wrong", expectedStatus: http.StatusUnauthorized},
+		{name: "unknown workspace", secret: "shh", expectedStatus: http.StatusUnauthorized},
+		{name: "user error is a friendly reply", team: ws, secret: "shh", shortenErr: domain.ErrInvalidURL, expectShorten: true, expectedStatus: http.StatusOK, expectedText: "Couldn't shorten that link: invalid URL format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			commands := new(MockSlackCommands)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+			handler := NewSlackHandler(commands, logger, "http://localhost:8080")
+			handler.now = func() time.Time { return now }
+
+			if tt.team != nil {
+				commands.On("Workspace", mock.Anything, "T1").Return(tt.team, nil)
+			} else {
+				commands.On("Workspace", mock.Anything, "T1").Return(nil, domain.ErrSlackWorkspaceNotFound)
+			}
+			if tt.shortenErr != nil {
+				commands.On("Shorten", mock.Anything, ws, "https://example.com").Return(nil, tt.shortenErr)
+			} else {
+				commands.On("Shorten", mock.Anything, ws, "https://example.com").Return(&domain.URL{ShortCode: "abc123"}, nil)
+			}
+
+			req := httptest.NewRequest("POST", "/integrations/slack", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("X-Slack-Request-Timestamp", strconv.FormatInt(now.Unix(), 10))
+			req.Header.Set("X-Slack-Signature", slack.Sign([]byte(body), tt.secret, now.Unix()))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.Command(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if !tt.expectShorten {
+				commands.AssertNotCalled(t, "Shorten", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			var reply slack.Response
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reply))
+			assert.Equal(t, slack.Ephemeral, reply.ResponseType)
+			assert.Equal(t, tt.expectedText, reply.Text)
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/saml"
+)
+
+// SSOProvider is the service the single sign-on endpoints need
+// Implemented by service.SSOService
+type SSOProvider interface {
+	ServiceProvider(workspace string) saml.ServiceProvider
+	Metadata(workspace string) []byte
+
+	GetConnection(ctx context.Context) (*domain.SAMLConnection, error)
+	SaveConnection(ctx context.Context, connection *domain.SAMLConnection) error
+	DeleteConnection(ctx context.Context) error
+
+	SignInURL(ctx context.Context, workspace, relayState string) (string, error)
+	SignIn(ctx context.Context, workspace, samlResponse string) (string, *domain.Session, error)
+	SignOut(ctx context.Context, token string) error
+}
+
+const (
+	maxSAMLResponseBytes = 256 << 10 // Signed responses are a few KB; generous for big group lists
+	maxRelayStateBytes   = 80        // The SAML bindings spec's limit
+)
+
+// SSOHandler serves SAML sign-in for workspace members, plus the endpoints
+// workspace owners use to connect their identity provider
+type SSOHandler struct {
+	sso    SSOProvider
+	logger *slog.Logger
+}
+
+// NewSSOHandler creates a new single sign-on handler
+func NewSSOHandler(sso SSOProvider, logger *slog.Logger) *SSOHandler {
+	return &SSOHandler{sso: sso, logger: logger}
+}
+
+// Metadata handles GET /saml/{workspace}/metadata (public)
+// Owners paste this URL into their IdP, which reads our entity ID and ACS URL from it
+func (h *SSOHandler) Metadata(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(h.sso.Metadata(r.PathValue("workspace")))
+}
+
+// Login handles GET /saml/{workspace}/login (public)
+// Sends the browser to the IdP with an AuthnRequest; ?RelayState= comes back with the response
+func (h *SSOHandler) Login(w http.ResponseWriter, r *http.Request) {
+	relayState := r.URL.Query().Get("RelayState")
+	if len(relayState) > maxRelayStateBytes {
+		respondError(w, http.StatusBadRequest, "RelayState must be at most 80 bytes")
+		return
+	}
+
+	location, err := h.sso.SignInURL(r.Context(), r.PathValue("workspace"), relayState)
+	if errors.Is(err, domain.ErrSAMLConnectionNotFound) {
+		respondError(w, http.StatusNotFound, "Single sign-on is not set up for this workspace")
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to start SAML sign-in", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to start sign-in")
+		return
+	}
+
+	http.Redirect(w, r, location, http.StatusFound)
+}
+
+// ACS handles POST /saml/{workspace}/acs (public), the assertion consumer service
+// The IdP's auto-submitting form posts SAMLResponse here; a verified sign-in
+// answers with a session token
+func (h *SSOHandler) ACS(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxSAMLResponseBytes)
+	if err := r.ParseForm(); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid form body")
+		return
+	}
+	samlResponse := r.PostForm.Get("SAMLResponse")
+	if samlResponse == "" {
+		respondError(w, http.StatusBadRequest, "SAMLResponse is required")
+		return
+	}
+
+	workspace := r.PathValue("workspace")
+	token, session, err := h.sso.SignIn(r.Context(), workspace, samlResponse)
+	switch {
+	case errors.Is(err, domain.ErrSAMLConnectionNotFound):
+		respondError(w, http.StatusNotFound, "Single sign-on is not set up for this workspace")
+		return
+	case isSAMLRejection(err):
+		// The reason helps whoever debugs the IdP setup, not the caller:
+		// it is logged, and the response says no more than "no"
+		h.logger.Warn("SAML sign-in rejected", "workspace", workspace, "error", err)
+		respondError(w, http.StatusUnauthorized, "Sign-in could not be verified")
+		return
+	case errors.Is(err, domain.ErrMemberInactive):
+		respondError(w, http.StatusForbidden, "Your account in this workspace is deactivated")
+		return
+	case errors.Is(err, domain.ErrNoWorkspaceRole):
+		respondError(w, http.StatusForbidden, "None of your groups has a role in this workspace")
+		return
+	case err != nil:
+		h.logger.Error("Failed to sign in", "workspace", workspace, "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to sign in")
+		return
+	}
+
+	respondSuccess(w, http.StatusCreated, v1.SessionResponse{
+		Token:      token,
+		TokenType:  "Bearer",
+		ExpiresAt:  session.ExpiresAt,
+		Workspace:  session.Workspace,
+		Member:     session.Member,
+		Role:       session.Role,
+		RelayState: r.PostForm.Get("RelayState"),
+	}, "Signed in")
+}
+
+// isSAMLRejection reports whether a sign-in failed because the response
+// itself is not acceptable (forged, expired, for someone else, replayed)
+func isSAMLRejection(err error) bool {
+	return errors.Is(err, saml.ErrMalformed) ||
+		errors.Is(err, saml.ErrInvalidSignature) ||
+		errors.Is(err, saml.ErrUnsupportedAlgorithm) ||
+		errors.Is(err, saml.ErrInvalidAssertion) ||
+		errors.Is(err, domain.ErrAssertionReplayed)
+}
+
+// SignOut handles DELETE /api/v1/sso/session (authenticated)
+// Ends the session whose token authenticated the request
+func (h *SSOHandler) SignOut(w http.ResponseWriter, r *http.Request) {
+	err := h.sso.SignOut(r.Context(), bearerToken(r))
+	switch {
+	case errors.Is(err, domain.ErrSessionNotFound):
+		respondError(w, http.StatusNotFound, "Not signed in with a single sign-on session")
+	case err != nil:
+		h.logger.Error("Failed to sign out", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to sign out")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetConnection handles GET /api/v1/sso/saml (workspace owners)
+func (h *SSOHandler) GetConnection(w http.ResponseWriter, r *http.Request) {
+	connection, err := h.sso.GetConnection(r.Context())
+	if err != nil {
+		h.respondConnectionError(w, err, "Failed to load SAML connection")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, h.toSAMLConnectionResponse(connection), "")
+}
+
+// PutConnection handles PUT /api/v1/sso/saml (workspace owners)
+// Creates or replaces the connection to the workspace's IdP
+func (h *SSOHandler) PutConnection(w http.ResponseWriter, r *http.Request) {
+	var req v1.SAMLConnectionRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	connection := &domain.SAMLConnection{
+		IdPEntityID:   req.IdPEntityID,
+		SSOURL:        req.SSOURL,
+		Certificate:   req.Certificate,
+		RoleAttribute: req.RoleAttribute,
+	}
+	if err := h.sso.SaveConnection(r.Context(), connection); err != nil {
+		h.respondConnectionError(w, err, "Failed to save SAML connection")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, h.toSAMLConnectionResponse(connection), "SAML connection saved")
+}
+
+// DeleteConnection handles DELETE /api/v1/sso/saml (workspace owners)
+func (h *SSOHandler) DeleteConnection(w http.ResponseWriter, r *http.Request) {
+	if err := h.sso.DeleteConnection(r.Context()); err != nil {
+		h.respondConnectionError(w, err, "Failed to delete SAML connection")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondConnectionError maps connection errors to HTTP statuses
+func (h *SSOHandler) respondConnectionError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		respondError(w, http.StatusForbidden, "Only workspace owners can manage single sign-on")
+	case errors.Is(err, domain.ErrSAMLConnectionNotFound):
+		respondError(w, http.StatusNotFound, "Single sign-on is not set up")
+	case errors.Is(err, domain.ErrInvalidSAMLConnection):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Error(message, "error", err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+// toSAMLConnectionResponse converts a connection for the API, with what
+// the owner needs to enter on the IdP side
+func (h *SSOHandler) toSAMLConnectionResponse(connection *domain.SAMLConnection) v1.SAMLConnectionResponse {
+	sp := h.sso.ServiceProvider(connection.Workspace)
+	response := v1.SAMLConnectionResponse{
+		IdPEntityID:   connection.IdPEntityID,
+		SSOURL:        connection.SSOURL,
+		RoleAttribute: connection.RoleAttribute,
+		SPEntityID:    sp.EntityID,
+		ACSURL:        sp.ACSURL,
+		MetadataURL:   sp.EntityID, // The entity ID is the metadata URL
+		LoginURL:      strings.TrimSuffix(sp.ACSURL, "/acs") + "/login",
+		UpdatedAt:     connection.UpdatedAt,
+	}
+	// IdPs rotate their certificates; the expiry tells owners when to update it
+	if cert, err := saml.ParseCertificate(connection.Certificate); err == nil {
+		expiresAt := cert.NotAfter
+		response.CertificateExpiresAt = &expiresAt
+	}
+	return response
+}
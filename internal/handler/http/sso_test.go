@@ -0,0 +1,304 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/saml"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSSOProvider is a mock implementation of SSOProvider
+type MockSSOProvider struct {
+	mock.Mock
+}
+
+func (m *MockSSOProvider) ServiceProvider(workspace string) saml.ServiceProvider {
+	base := "https://sho.rt/saml/" + workspace
+	return saml.ServiceProvider{EntityID: base + "/metadata", ACSURL: base + "/acs"}
+}
+
+func (m *MockSSOProvider) Metadata(workspace string) []byte {
+	args := m.Called(workspace)
+	return args.Get(0).([]byte)
+}
+
+func (m *MockSSOProvider) GetConnection(ctx context.Context) (*domain.SAMLConnection, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SAMLConnection), args.Error(1)
+}
+
+func (m *MockSSOProvider) SaveConnection(ctx context.Context, connection *domain.SAMLConnection) error {
+	args := m.Called(ctx, connection)
+	return args.Error(0)
+}
+
+func (m *MockSSOProvider) DeleteConnection(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockSSOProvider) SignInURL(ctx context.Context, workspace, relayState string) (string, error) {
+	args := m.Called(ctx, workspace, relayState)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSSOProvider) SignIn(ctx context.Context, workspace, samlResponse string) (string, *domain.Session, error) {
+	args := m.Called(ctx, workspace, samlResponse)
+	if args.Get(1) == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+	return args.String(0), args.Get(1).(*domain.Session), args.Error(2)
+}
+
+func (m *MockSSOProvider) SignOut(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func newTestSSOHandler() (*SSOHandler, *MockSSOProvider) {
+	provider := new(MockSSOProvider)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewSSOHandler(provider, logger), provider
+}
+
+func TestSSOMetadata(t *testing.T) {
+	// Arrange
+	handler, provider := newTestSSOHandler()
+	provider.On("Metadata", "team1").Return([]byte(`<md:EntityDescriptor/>`))
+	req := httptest.NewRequest(http.MethodGet, "/saml/team1/metadata", nil)
+	req.SetPathValue("workspace", "team1")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.Metadata(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/samlmetadata+xml", w.Header().Get("Content-Type"))
+	assert.Equal(t, `<md:EntityDescriptor/>`, w.Body.String())
+}
+
+func TestSSOLogin(t *testing.T) {
+	tests := []struct {
+		name             string
+		relayState       string
+		signInErr        error
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{name: "redirects to the IdP", relayState: "/dashboard", expectedStatus: http.StatusFound, expectedLocation: "https://idp.example.com/sso?SAMLRequest=abc"},
+		{name: "not set up", signInErr: domain.ErrSAMLConnectionNotFound, expectedStatus: http.StatusNotFound},
+		{name: "relay state too long", relayState: strings.Repeat("a", 81), expectedStatus: http.StatusBadRequest},
+		{name: "database down", signInErr: assert.AnError, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, provider := newTestSSOHandler()
+			provider.On("SignInURL", mock.Anything, "team1", tt.relayState).
+				Return("https://idp.example.com/sso?SAMLRequest=abc", tt.signInErr).Maybe()
+			req := httptest.NewRequest(http.MethodGet, "/saml/team1/login?RelayState="+url.QueryEscape(tt.relayState), nil)
+			req.SetPathValue("workspace", "team1")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.Login(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestSSOACS(t *testing.T) {
+	session := &domain.Session{Workspace: "team1", Member: "ada@example.com", Role: "editor", ExpiresAt: time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name           string
+		form           url.Values
+		signInErr      error
+		expectedStatus int
+	}{
+		{name: "signed in", form: url.Values{"SAMLResponse": {"PHNhbWw+"}, "RelayState": {"/dashboard"}}, expectedStatus: http.StatusCreated},
+		{name: "no response", form: url.Values{}, expectedStatus: http.StatusBadRequest},
+		{name: "forged", form: url.Values{"SAMLResponse": {"PHNhbWw+"}}, signInErr: fmt.Errorf("%w: digest mismatch", saml.ErrInvalidSignature), expectedStatus: http.StatusUnauthorized},
+		{name: "expired", form: url.Values{"SAMLResponse": {"PHNhbWw+"}}, signInErr: fmt.Errorf("%w: expired", saml.ErrInvalidAssertion), expectedStatus: http.StatusUnauthorized},
+		{name: "replayed", form: url.Values{"SAMLResponse": {"PHNhbWw+"}}, signInErr: domain.ErrAssertionReplayed, expectedStatus: http.StatusUnauthorized},
+		{name: "deactivated", form: url.Values{"SAMLResponse": {"PHNhbWw+"}}, signInErr: domain.ErrMemberInactive, expectedStatus: http.StatusForbidden},
+		{name: "no role", form: url.Values{"SAMLResponse": {"PHNhbWw+"}}, signInErr: domain.ErrNoWorkspaceRole, expectedStatus: http.StatusForbidden},
+		{name: "not set up", form: url.Values{"SAMLResponse": {"PHNhbWw+"}}, signInErr: domain.ErrSAMLConnectionNotFound, expectedStatus: http.StatusNotFound},
+		{name: "database down", form: url.Values{"SAMLResponse": {"PHNhbWw+"}}, signInErr: assert.AnError, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, provider := newTestSSOHandler()
+			if tt.signInErr != nil {
+				provider.On("SignIn", mock.Anything, "team1", "PHNhbWw+").Return("", nil, tt.signInErr).Maybe()
+			} else {
+				provider.On("SignIn", mock.Anything, "team1", "PHNhbWw+").Return("sso_token", session, nil).Maybe()
+			}
+			req := httptest.NewRequest(http.MethodPost, "/saml/team1/acs", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.SetPathValue("workspace", "team1")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ACS(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusCreated {
+				assert.NotContains(t, w.Body.String(), "digest", "the rejection reason is only logged")
+				return
+			}
+			var body struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, "sso_token", body.Data["token"])
+			assert.Equal(t, "Bearer", body.Data["token_type"])
+			assert.Equal(t, "editor", body.Data["role"])
+			assert.Equal(t, "/dashboard", body.Data["relay_state"])
+		})
+	}
+}
+
+func TestSSOSignOut(t *testing.T) {
+	tests := []struct {
+		name           string
+		signOutErr     error
+		expectedStatus int
+	}{
+		{name: "signed out", expectedStatus: http.StatusNoContent},
+		{name: "not a session", signOutErr: domain.ErrSessionNotFound, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, provider := newTestSSOHandler()
+			provider.On("SignOut", mock.Anything, "sso_token").Return(tt.signOutErr)
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/sso/session", nil)
+			req.Header.Set("Authorization", "Bearer sso_token")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.SignOut(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			provider.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSSOPutConnection(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		saveErr        error
+		expectedStatus int
+	}{
+		{
+			name:           "saved",
+			body:           `{"idp_entity_id": "http://www.okta.com/exk1", "sso_url": "https://acme.okta.com/app/sso/saml", "certificate": "MIIB", "role_attribute": "groups"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing certificate",
+			body:           `{"idp_entity_id": "http://www.okta.com/exk1", "sso_url": "https://acme.okta.com/app/sso/saml"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "sign-in URL is not http",
+			body:           `{"idp_entity_id": "http://www.okta.com/exk1", "sso_url": "ftp://acme.okta.com", "certificate": "MIIB"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "bad certificate",
+			body:           `{"idp_entity_id": "http://www.okta.com/exk1", "sso_url": "https://acme.okta.com/app/sso/saml", "certificate": "MIIB"}`,
+			saveErr:        fmt.Errorf("%w: %v", domain.ErrInvalidSAMLConnection, saml.ErrInvalidCertificate),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not an owner",
+			body:           `{"idp_entity_id": "http://www.okta.com/exk1", "sso_url": "https://acme.okta.com/app/sso/saml", "certificate": "MIIB"}`,
+			saveErr:        domain.ErrForbidden,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, provider := newTestSSOHandler()
+			provider.On("SaveConnection", mock.Anything, mock.AnythingOfType("*domain.SAMLConnection")).
+				Run(func(args mock.Arguments) { args.Get(1).(*domain.SAMLConnection).Workspace = "team1" }).
+				Return(tt.saveErr).Maybe()
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/sso/saml", strings.NewReader(tt.body))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{ID: "team1"}))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.PutConnection(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"acs_url":"https://sho.rt/saml/team1/acs"`)
+				assert.Contains(t, w.Body.String(), `"login_url":"https://sho.rt/saml/team1/login"`)
+			}
+		})
+	}
+}
+
+func TestSSOGetConnection_NotSetUp(t *testing.T) {
+	// Arrange
+	handler, provider := newTestSSOHandler()
+	provider.On("GetConnection", mock.Anything).Return(nil, domain.ErrSAMLConnectionNotFound)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/sso/saml", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.GetConnection(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSSODeleteConnection(t *testing.T) {
+	// Arrange
+	handler, provider := newTestSSOHandler()
+	provider.On("DeleteConnection", mock.Anything).Return(nil)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/sso/saml", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.DeleteConnection(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	provider.AssertExpectations(t)
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/internal/startup"
+)
+
+// StartupStatus is what the gate reports while the server is starting
+// (*startup.Tracker implements it)
+type StartupStatus interface {
+	Phase() startup.Phase
+	Waiting() map[string]string
+}
+
+// StartupGate answers requests while the dependencies are still connecting
+//
+// PARTIAL START:
+// The listener starts before PostgreSQL and Redis are reachable, with the
+// gate as its handler. Until Open is called:
+//   - /health/live answers 200: the process is alive, don't restart it
+//   - /health/ready answers 503 "starting" with the dependencies we wait for
+//   - everything else answers 503 with Retry-After
+//
+// Open swaps in the real handler; from then on the gate only forwards.
+type StartupGate struct {
+	status StartupStatus
+	next   atomic.Pointer[http.Handler]
+}
+
+// NewStartupGate creates a closed gate
+func NewStartupGate(status StartupStatus) *StartupGate {
+	return &StartupGate{status: status}
+}
+
+// Open starts forwarding every request to next
+func (g *StartupGate) Open(next http.Handler) {
+	g.next.Store(&next)
+}
+
+// ServeHTTP forwards to the real handler once open
+func (g *StartupGate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if next := g.next.Load(); next != nil {
+		(*next).ServeHTTP(w, r)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/health/live":
+		respondSuccess(w, http.StatusOK, map[string]string{
+			"status": "ok",
+			"time":   time.Now().Format(time.RFC3339),
+		}, "")
+	case "/health/ready":
+		// Same shape as ReadinessCheck, so dashboards read both alike
+		respondSuccess(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status":      string(g.status.Phase()),
+			"waiting_for": g.status.Waiting(),
+			"time":        time.Now().Format(time.RFC3339),
+		}, "")
+	default:
+		w.Header().Set("Retry-After", "5")
+		respondError(w, http.StatusServiceUnavailable, "Service is starting")
+	}
+}
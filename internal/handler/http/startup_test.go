@@ -0,0 +1,64 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/internal/startup"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStartupStatus reports a fixed startup state
+type fakeStartupStatus struct {
+	waiting map[string]string
+}
+
+func (s fakeStartupStatus) Phase() startup.Phase       { return startup.PhaseStarting }
+func (s fakeStartupStatus) Waiting() map[string]string { return s.waiting }
+
+func TestStartupGate_WhileStarting(t *testing.T) {
+	tests := []struct {
+		name               string
+		path               string
+		expectedStatus     int
+		expectedBody       string
+		expectedRetryAfter string
+	}{
+		{name: "alive", path: "/health/live", expectedStatus: http.StatusOK, expectedBody: `"status":"ok"`},
+		{name: "not ready", path: "/health/ready", expectedStatus: http.StatusServiceUnavailable, expectedBody: `"postgres":"connection refused"`},
+		{name: "traffic waits", path: "/api/v1/urls", expectedStatus: http.StatusServiceUnavailable, expectedBody: "Service is starting", expectedRetryAfter: "5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			gate := NewStartupGate(fakeStartupStatus{waiting: map[string]string{"postgres": "connection refused"}})
+			w := httptest.NewRecorder()
+
+			// Act
+			gate.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.Equal(t, tt.expectedRetryAfter, w.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestStartupGate_ForwardsOnceOpen(t *testing.T) {
+	// Arrange
+	gate := NewStartupGate(fakeStartupStatus{})
+	gate.Open(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	w := httptest.NewRecorder()
+
+	// Act
+	gate.ServeHTTP(w, httptest.NewRequest("GET", "/health/ready", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusTeapot, w.Code)
+}
@@ -1,21 +1,44 @@
 package http
 
 import (
+	"bytes"
+	"html/template"
 	"net/http"
 	"path/filepath"
+
+	"url-shortener/internal/captcha"
 )
 
+// uiPageData is what web/templates/index.html renders
+type uiPageData struct {
+	Lang string                              // <html lang>
+	T    func(string, ...interface{}) string // {{call .T "Shorten URL"}}
+
+	Captcha *captcha.Widget // Shown in the create form when CAPTCHAs are enabled
+}
+
+// WithUI renders the hosted UI from tmpl, translated with the request's
+// language (see Localize). Without it the page is served as a plain file.
+func (h *Handler) WithUI(tmpl *template.Template) *Handler {
+	h.uiTmpl = tmpl
+	return h
+}
+
 // ServeUI serves the web UI
 func (h *Handler) ServeUI(w http.ResponseWriter, r *http.Request) {
 	// Skip paths that should be handled by other handlers
-	if r.URL.Path == "/metrics" || r.URL.Path == "/metrics-raw" || r.URL.Path == "/api/docs" || r.URL.Path == "/api/openapi.json" || r.URL.Path == "/health/live" {
+	if r.URL.Path == "/metrics" || r.URL.Path == "/metrics-raw" || r.URL.Path == "/api/docs" || r.URL.Path == "/api/openapi.json" || r.URL.Path == "/health/live" || r.URL.Path == "/health/ready" {
 		http.NotFound(w, r)
 		return
 	}
 
 	// Serve index.html for root path
 	if r.URL.Path == "/" {
-		http.ServeFile(w, r, filepath.Join("web", "templates", "index.html"))
+		if h.uiTmpl == nil {
+			http.ServeFile(w, r, filepath.Join("web", "templates", "index.html"))
+			return
+		}
+		h.renderUI(w)
 		return
 	}
 
@@ -23,6 +46,29 @@ func (h *Handler) ServeUI(w http.ResponseWriter, r *http.Request) {
 	h.RedirectURL(w, r)
 }
 
+// renderUI writes the hosted UI in the visitor's language
+func (h *Handler) renderUI(w http.ResponseWriter) {
+	localizer, localized := localizerFor(w)
+
+	var buf bytes.Buffer
+	data := uiPageData{Lang: localizer.Language(), T: localizer.Translate}
+	if h.captcha != nil {
+		widget := h.captcha.Widget()
+		data.Captcha = &widget
+	}
+	if err := h.uiTmpl.Execute(&buf, data); err != nil {
+		h.logger.Error("Failed to render UI", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if localized {
+		setLanguageHeaders(w, localizer)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
 // SetupStaticFiles configures static file serving
 func SetupStaticFiles(mux *http.ServeMux) {
 	// Serve static files (CSS, JS)
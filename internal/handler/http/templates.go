@@ -0,0 +1,203 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+// TemplateManager is the service the template endpoints need
+// Implemented by service.TemplateService
+type TemplateManager interface {
+	CreateTemplate(ctx context.Context, template *domain.LinkTemplate) error
+	GetTemplate(ctx context.Context, id string) (*domain.LinkTemplate, error)
+	ListTemplates(ctx context.Context) ([]*domain.LinkTemplate, error)
+	UpdateTemplate(ctx context.Context, template *domain.LinkTemplate) error
+	DeleteTemplate(ctx context.Context, id string) error
+	CreateFromTemplate(ctx context.Context, id, originalURL, customAlias string) (*domain.URL, error)
+}
+
+// TemplateHandler serves the link template API
+type TemplateHandler struct {
+	templates TemplateManager
+	logger    *slog.Logger
+	baseURL   string
+}
+
+// NewTemplateHandler creates a new template handler
+func NewTemplateHandler(templates TemplateManager, logger *slog.Logger, baseURL string) *TemplateHandler {
+	return &TemplateHandler{
+		templates: templates,
+		logger:    logger,
+		baseURL:   baseURL,
+	}
+}
+
+// CreateTemplate handles POST /api/v1/templates
+func (h *TemplateHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	template, ok := decodeTemplateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.templates.CreateTemplate(r.Context(), template); err != nil {
+		h.respondTemplateError(w, err, "Failed to create template")
+		return
+	}
+
+	respondSuccess(w, http.StatusCreated, toTemplateResponse(template), "Template created successfully")
+}
+
+// ListTemplates handles GET /api/v1/templates?limit=&offset=
+func (h *TemplateHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	window, err := parsePageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	templates, err := h.templates.ListTemplates(r.Context())
+	if err != nil {
+		h.respondTemplateError(w, err, "Failed to list templates")
+		return
+	}
+
+	resp := make([]v1.TemplateResponse, 0, len(templates))
+	for _, template := range templates {
+		resp = append(resp, toTemplateResponse(template))
+	}
+	items, pagination := paginate(resp, window)
+	respondList(w, items, pagination)
+}
+
+// GetTemplate handles GET /api/v1/templates/{id}
+func (h *TemplateHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	template, err := h.templates.GetTemplate(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.respondTemplateError(w, err, "Failed to get template")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, toTemplateResponse(template), "")
+}
+
+// UpdateTemplate handles PUT /api/v1/templates/{id}
+// The request replaces the whole template; existing links are not touched
+func (h *TemplateHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	template, ok := decodeTemplateRequest(w, r)
+	if !ok {
+		return
+	}
+	template.ID = r.PathValue("id")
+
+	if err := h.templates.UpdateTemplate(r.Context(), template); err != nil {
+		h.respondTemplateError(w, err, "Failed to update template")
+		return
+	}
+
+	respondSuccess(w, http.StatusOK, toTemplateResponse(template), "Template updated successfully")
+}
+
+// DeleteTemplate handles DELETE /api/v1/templates/{id}
+func (h *TemplateHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := h.templates.DeleteTemplate(r.Context(), r.PathValue("id")); err != nil {
+		h.respondTemplateError(w, err, "Failed to delete template")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateURL handles POST /api/v1/templates/{id}/urls
+// Only the destination (and optionally an alias) comes from the body
+func (h *TemplateHandler) CreateURL(w http.ResponseWriter, r *http.Request) {
+	var req v1.CloneURLRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	url, err := h.templates.CreateFromTemplate(r.Context(), r.PathValue("id"), req.URL, req.CustomAlias)
+	if err != nil {
+		h.respondTemplateError(w, err, "Failed to create URL")
+		return
+	}
+
+	respondSuccess(w, http.StatusCreated, createURLResponse(h.baseURL, url), "URL created successfully")
+}
+
+// respondTemplateError maps template errors (and link creation errors) to
+// status codes
+func (h *TemplateHandler) respondTemplateError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrTemplateNotFound):
+		respondError(w, http.StatusNotFound, "Template not found")
+	case errors.Is(err, domain.ErrForbidden):
+		respondError(w, http.StatusForbidden, "Not allowed to use this template")
+	case errors.Is(err, domain.ErrTemplateNameTaken):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInvalidTemplate),
+		errors.Is(err, domain.ErrInvalidPreview):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		status := createErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			h.logger.Error(message, "error", err)
+			respondError(w, status, message)
+			return
+		}
+		respondError(w, status, err.Error())
+	}
+}
+
+// decodeTemplateRequest reads a v1.TemplateRequest into a domain.LinkTemplate
+// Writes a 400 response and returns false on invalid JSON or fields
+func decodeTemplateRequest(w http.ResponseWriter, r *http.Request) (*domain.LinkTemplate, bool) {
+	var req v1.TemplateRequest
+	if !decodeRequest(w, r, &req) {
+		return nil, false
+	}
+
+	settings := domain.LinkSettings{
+		ExpiresIn:       time.Duration(req.ExpiresInHours) * time.Hour,
+		MaxClicks:       req.MaxClicks,
+		Domain:          req.Domain,
+		LanguageTargets: req.LanguageTargets,
+		Schedule:        scheduleFromV1(req.Schedule),
+		Signed:          req.Signed,
+		SingleUse:       req.SingleUse,
+		NoAnalytics:     withoutAnalytics(req.Analytics),
+	}
+	if req.Preview != nil {
+		settings.Preview = &domain.PreviewCard{
+			Title:       req.Preview.Title,
+			Description: req.Preview.Description,
+			ImageURL:    req.Preview.ImageURL,
+		}
+	}
+	return &domain.LinkTemplate{Name: req.Name, Settings: settings}, true
+}
+
+// toTemplateResponse converts a template to its API representation
+func toTemplateResponse(template *domain.LinkTemplate) v1.TemplateResponse {
+	settings := template.Settings
+	return v1.TemplateResponse{
+		ID:              template.ID,
+		Name:            template.Name,
+		ExpiresInHours:  int(settings.ExpiresIn / time.Hour),
+		MaxClicks:       settings.MaxClicks,
+		Domain:          settings.Domain,
+		LanguageTargets: settings.LanguageTargets,
+		Schedule:        scheduleV1(settings.Schedule),
+		Preview:         previewCard(settings.Preview),
+		Signed:          settings.Signed,
+		SingleUse:       settings.SingleUse,
+		Analytics:       analyticsOptOut(settings.NoAnalytics),
+		CreatedAt:       template.CreatedAt,
+		UpdatedAt:       template.UpdatedAt,
+	}
+}
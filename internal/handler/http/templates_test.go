@@ -0,0 +1,252 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTemplateManager is a mock implementation of TemplateManager
+type MockTemplateManager struct {
+	mock.Mock
+}
+
+func (m *MockTemplateManager) CreateTemplate(ctx context.Context, template *domain.LinkTemplate) error {
+	args := m.Called(ctx, template)
+	return args.Error(0)
+}
+
+func (m *MockTemplateManager) GetTemplate(ctx context.Context, id string) (*domain.LinkTemplate, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LinkTemplate), args.Error(1)
+}
+
+func (m *MockTemplateManager) ListTemplates(ctx context.Context) ([]*domain.LinkTemplate, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.LinkTemplate), args.Error(1)
+}
+
+func (m *MockTemplateManager) UpdateTemplate(ctx context.Context, template *domain.LinkTemplate) error {
+	args := m.Called(ctx, template)
+	return args.Error(0)
+}
+
+func (m *MockTemplateManager) DeleteTemplate(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTemplateManager) CreateFromTemplate(ctx context.Context, id, originalURL, customAlias string) (*domain.URL, error) {
+	args := m.Called(ctx, id, originalURL, customAlias)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func newTestTemplateHandler() (*TemplateHandler, *MockTemplateManager) {
+	templates := new(MockTemplateManager)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewTemplateHandler(templates, logger, "http://localhost:8080"), templates
+}
+
+func TestCreateTemplate(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+	}{
+		{
+			name:           "created",
+			body:           `{"name":"spring","expires_in_hours":720,"max_clicks":500,"preview":{"title":"Spring Sale"}}`,
+			expectCall:     true,
+			expectedStatus: http.StatusCreated,
+		},
+		{name: "name taken", body: `{"name":"spring"}`, serviceErr: domain.ErrTemplateNameTaken, expectCall: true, expectedStatus: http.StatusConflict},
+		{name: "invalid template", body: `{"name":"spring"}`, serviceErr: domain.ErrInvalidTemplate, expectCall: true, expectedStatus: http.StatusBadRequest},
+		{name: "invalid schedule", body: `{"name":"spring"}`, serviceErr: domain.ErrInvalidSchedule, expectCall: true, expectedStatus: http.StatusBadRequest},
+		{name: "invalid JSON", body: `{`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, templates := newTestTemplateHandler()
+			if tt.expectCall {
+				templates.On("CreateTemplate", mock.Anything, mock.MatchedBy(func(tmpl *domain.LinkTemplate) bool {
+					return tmpl.Name == "spring"
+				})).Return(tt.serviceErr)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/templates", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.CreateTemplate(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				assert.Contains(t, w.Body.String(), `"expires_in_hours":720`)
+				assert.Contains(t, w.Body.String(), `"max_clicks":500`)
+				assert.Contains(t, w.Body.String(), `"title":"Spring Sale"`)
+			}
+			templates.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateTemplate_ConvertsSettings(t *testing.T) {
+	// Arrange
+	handler, templates := newTestTemplateHandler()
+	var got *domain.LinkTemplate
+	templates.On("CreateTemplate", mock.Anything, mock.AnythingOfType("*domain.LinkTemplate")).
+		Run(func(args mock.Arguments) { got = args.Get(1).(*domain.LinkTemplate) }).
+		Return(nil)
+
+	body := `{"name":"spring","expires_in_hours":24,"domain":"go.example.com","language_targets":{"fr":"https://example.com/fr"},` +
+		`"schedule":{"rules":[{"days":["sat"],"url":"https://example.com/weekend"}]}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/templates", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.CreateTemplate(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 24*time.Hour, got.Settings.ExpiresIn)
+	assert.Equal(t, "go.example.com", got.Settings.Domain)
+	assert.Equal(t, "https://example.com/fr", got.Settings.LanguageTargets["fr"])
+	assert.Equal(t, "https://example.com/weekend", got.Settings.Schedule.Rules[0].URL)
+}
+
+func TestTemplateLookupErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "not found", err: domain.ErrTemplateNotFound, expectedStatus: http.StatusNotFound},
+		{name: "someone else's", err: domain.ErrForbidden, expectedStatus: http.StatusForbidden},
+		{name: "database down", err: assert.AnError, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, templates := newTestTemplateHandler()
+			templates.On("GetTemplate", mock.Anything, "t1").Return(nil, tt.err)
+			templates.On("DeleteTemplate", mock.Anything, "t1").Return(tt.err)
+
+			getReq := httptest.NewRequest(http.MethodGet, "/api/v1/templates/t1", nil)
+			getReq.SetPathValue("id", "t1")
+			getW := httptest.NewRecorder()
+			deleteReq := httptest.NewRequest(http.MethodDelete, "/api/v1/templates/t1", nil)
+			deleteReq.SetPathValue("id", "t1")
+			deleteW := httptest.NewRecorder()
+
+			// Act
+			handler.GetTemplate(getW, getReq)
+			handler.DeleteTemplate(deleteW, deleteReq)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, getW.Code)
+			assert.Equal(t, tt.expectedStatus, deleteW.Code)
+		})
+	}
+}
+
+func TestTemplateCreateURL(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectCall     bool
+		expectedStatus int
+	}{
+		{name: "created", body: `{"url":"https://example.com/shoes","custom_alias":"shoes"}`, expectCall: true, expectedStatus: http.StatusCreated},
+		{name: "missing URL", body: `{}`, expectedStatus: http.StatusBadRequest},
+		{name: "alias taken", body: `{"url":"https://example.com/shoes","custom_alias":"shoes"}`, serviceErr: domain.ErrCustomAliasTaken, expectCall: true, expectedStatus: http.StatusConflict},
+		{name: "quota used up", body: `{"url":"https://example.com/shoes","custom_alias":"shoes"}`, serviceErr: domain.ErrQuotaExceeded, expectCall: true, expectedStatus: http.StatusTooManyRequests},
+		{name: "unknown template", body: `{"url":"https://example.com/shoes","custom_alias":"shoes"}`, serviceErr: domain.ErrTemplateNotFound, expectCall: true, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, templates := newTestTemplateHandler()
+			if tt.expectCall {
+				url := &domain.URL{ID: "9", ShortCode: "shoes", OriginalURL: "https://example.com/shoes", Domain: "go.example.com"}
+				if tt.serviceErr != nil {
+					url = nil
+				}
+				templates.On("CreateFromTemplate", mock.Anything, "t1", "https://example.com/shoes", "shoes").Return(url, tt.serviceErr)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/templates/t1/urls", strings.NewReader(tt.body))
+			req.SetPathValue("id", "t1")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.CreateURL(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				assert.Contains(t, w.Body.String(), `"short_url":"http://go.example.com/shoes"`)
+			}
+			templates.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListTemplates_Paginates(t *testing.T) {
+	// Arrange
+	handler, templates := newTestTemplateHandler()
+	templates.On("ListTemplates", mock.Anything).Return([]*domain.LinkTemplate{
+		{ID: "t1", Name: "one"},
+		{ID: "t2", Name: "two"},
+		{ID: "t3", Name: "three"},
+	}, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/templates?limit=2&offset=1", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ListTemplates(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"pagination":{"limit":2,"offset":1,"total":3,"has_more":false}`)
+	assert.Contains(t, w.Body.String(), `"name":"two"`)
+	assert.NotContains(t, w.Body.String(), `"name":"one"`)
+}
+
+func TestListTemplates_InvalidLimit(t *testing.T) {
+	// Arrange
+	handler, templates := newTestTemplateHandler()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/templates?limit=-5", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ListTemplates(w, req)
+
+	// Assert: rejected before the service is asked
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	templates.AssertNotCalled(t, "ListTemplates", mock.Anything)
+}
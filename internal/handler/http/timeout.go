@@ -0,0 +1,121 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/resilience"
+)
+
+// timeoutGrace is how long a request may keep writing after its deadline:
+// enough to send the error response, not enough to hang on a slow client
+const timeoutGrace = 2 * time.Second
+
+// RouteTimeouts sets how long requests may take, per route
+// Routes are mux patterns exactly as registered, e.g. "GET /api/v1/export"
+// or "/" (the catch-all: redirects, pages and the UI)
+type RouteTimeouts struct {
+	Default time.Duration            // Routes not listed (0 = no deadline)
+	Routes  map[string]time.Duration // Overrides (0 = no deadline)
+
+	// How long requests may wait on the cache and the database, within the
+	// deadline (see resilience.Budget). Routes not listed have no budget.
+	Budgets map[string]resilience.Budget
+}
+
+// Timeouts gives every request a deadline picked by its route in mux
+//
+// WHY A DEADLINE AND NOT A RACE?
+// The obvious timeout middleware runs the handler in a goroutine and writes
+// "timeout" when a timer fires first - while the handler may still be
+// writing to the same response. Instead, the deadline goes on the request
+// context: every repository, cache and outbound call below gets it and
+// gives up, and the handler returns on its own. Nothing else touches the
+// response, so it is written exactly once.
+//
+// WHAT THE CLIENT GETS:
+//   - a handler that fails because of the deadline answers 500; that becomes
+//     503 (like http.TimeoutHandler), since trying again later may work
+//   - a handler that returns without writing anything gets a 503
+//
+// A call that runs out of the route's budget fails like one that hits the
+// deadline: the cache is skipped, a database failure answers 503.
+//
+// The read and write deadlines of the connection move with the route, so
+// routes allowed more time than the server timeouts (exports, uploads) can
+// finish, and short ones (redirects) don't wait on slow clients for long.
+func Timeouts(mux *http.ServeMux, timeouts RouteTimeouts) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, pattern := mux.Handler(r)
+			timeout, listed := timeouts.Routes[pattern]
+			if !listed {
+				timeout = timeouts.Default
+			}
+			budget := timeouts.Budgets[pattern]
+			if timeout <= 0 && budget == (resilience.Budget{}) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+
+				// The server only resets the write deadline between requests
+				// when it has a WriteTimeout, so ours is cleared when we're done
+				rc := http.NewResponseController(w)
+				_ = rc.SetReadDeadline(time.Now().Add(timeout))
+				_ = rc.SetWriteDeadline(time.Now().Add(timeout + timeoutGrace))
+				defer func() { _ = rc.SetWriteDeadline(time.Time{}) }()
+			}
+			ctx = resilience.WithBudget(ctx, pattern, budget)
+
+			tw := &timeoutWriter{ResponseWriter: w, ctx: ctx}
+			next.ServeHTTP(tw, r.WithContext(ctx))
+
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return
+			}
+			metrics.RecordRequestTimeout(pattern)
+			if !tw.wroteHeader {
+				respondError(w, http.StatusServiceUnavailable, "Request timed out, please retry")
+			}
+		})
+	}
+}
+
+// timeoutWriter turns errors caused by the deadline (or the budget) into 503s
+type timeoutWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	wroteHeader bool
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	if tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	if code == http.StatusInternalServerError && (errors.Is(tw.ctx.Err(), context.DeadlineExceeded) || resilience.BudgetExhausted(tw.ctx)) {
+		code = http.StatusServiceUnavailable
+	}
+	tw.ResponseWriter.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	if !tw.wroteHeader {
+		tw.WriteHeader(http.StatusOK)
+	}
+	return tw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the real writer (Flush, deadlines)
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
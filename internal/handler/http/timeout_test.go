@@ -0,0 +1,137 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-shortener/internal/resilience"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeouts(t *testing.T) {
+	slowLookup := func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done() // A repository call waiting on its context
+		respondError(w, http.StatusInternalServerError, "Failed to get URL")
+	}
+
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "fast handler is untouched",
+			handler:        func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) },
+			path:           "/api/v1/urls",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "failure caused by the deadline becomes 503",
+			handler:        slowLookup,
+			path:           "/api/v1/urls",
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "handler that gives up silently gets 503",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			},
+			path:           "/abc123",
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "real errors keep their status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				respondError(w, http.StatusInternalServerError, "Failed to create short URL")
+			},
+			path:           "/api/v1/urls",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/v1/urls", tt.handler)
+			mux.HandleFunc("/", tt.handler)
+			handler := Timeouts(mux, RouteTimeouts{
+				Default: 20 * time.Millisecond,
+				Routes:  map[string]time.Duration{"/": 10 * time.Millisecond},
+			})(mux)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestTimeouts_PerRoute(t *testing.T) {
+	// Arrange
+	deadlines := make(map[string]time.Duration)
+	record := func(w http.ResponseWriter, r *http.Request) {
+		if deadline, ok := r.Context().Deadline(); ok {
+			deadlines[r.URL.Path] = time.Until(deadline).Round(time.Minute)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/export", record)
+	mux.HandleFunc("GET /api/v1/jobs/{id}", record)
+	mux.HandleFunc("/health/live", record)
+	handler := Timeouts(mux, RouteTimeouts{
+		Default: 10 * time.Minute,
+		Routes: map[string]time.Duration{
+			"GET /api/v1/export": 30 * time.Minute,
+			"/health/live":       0,
+		},
+	})(mux)
+
+	// Act
+	for _, path := range []string{"/api/v1/export", "/api/v1/jobs/42", "/health/live"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Assert
+	assert.Equal(t, 30*time.Minute, deadlines["/api/v1/export"])
+	assert.Equal(t, 10*time.Minute, deadlines["/api/v1/jobs/42"])
+	assert.NotContains(t, deadlines, "/health/live", "0 means no deadline")
+}
+
+func TestTimeouts_Budget(t *testing.T) {
+	// Arrange
+	database := resilience.Policy{Dependency: resilience.DependencyDatabase}
+	lookup := func(w http.ResponseWriter, r *http.Request) {
+		err := resilience.Do(r.Context(), database, "postgres.GetByShortCode", func(ctx context.Context) error {
+			<-ctx.Done() // A database that doesn't answer
+			return ctx.Err()
+		})
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get URL")
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", lookup)
+	handler := Timeouts(mux, RouteTimeouts{
+		Routes:  map[string]time.Duration{"/": time.Minute},
+		Budgets: map[string]resilience.Budget{"/": {Database: 10 * time.Millisecond}},
+	})(mux)
+	w := httptest.NewRecorder()
+
+	// Act
+	start := time.Now()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/abc123", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Less(t, time.Since(start), time.Second, "the budget ends the wait, not the deadline")
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+)
+
+// UsageReporter reports plan usage
+// Implemented by service.QuotaService; GetUsage returns nil for callers
+// without a quota (anonymous, admins)
+type UsageReporter interface {
+	GetUsage(ctx context.Context, principal *auth.Principal) (*domain.Usage, error)
+}
+
+// UsageHandler serves the quota usage endpoint
+type UsageHandler struct {
+	quotas UsageReporter
+	logger *slog.Logger
+}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler(quotas UsageReporter, logger *slog.Logger) *UsageHandler {
+	return &UsageHandler{
+		quotas: quotas,
+		logger: logger,
+	}
+}
+
+// GetUsage handles GET /api/v1/usage
+// Shows the caller's plan and how many links they created this month.
+// Admins can look at another owner with ?owner=... (on the default plan)
+func (h *UsageHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	principal := auth.FromContext(r.Context())
+	if owner := r.URL.Query().Get("owner"); owner != "" && owner != principal.ID {
+		if !principal.Admin {
+			respondError(w, http.StatusForbidden, "Only admins can view other owners")
+			return
+		}
+		principal = &auth.Principal{ID: owner}
+	}
+
+	usage, err := h.quotas.GetUsage(r.Context(), principal)
+	if err != nil {
+		h.logger.Error("Failed to get usage", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to get usage")
+		return
+	}
+	if usage == nil {
+		respondError(w, http.StatusNotFound, "No quota applies to this caller")
+		return
+	}
+
+	writeQuotaHeaders(w, usage)
+	respondSuccess(w, http.StatusOK, usageResponse(usage), "")
+}
+
+// usageResponse converts usage to the API response
+func usageResponse(usage *domain.Usage) v1.UsageResponse {
+	response := v1.UsageResponse{
+		Plan:        usage.Plan.Name,
+		PeriodStart: usage.PeriodStart,
+		PeriodEnd:   usage.PeriodEnd,
+		Used:        usage.Used,
+	}
+	if !usage.Plan.Unlimited() {
+		limit, remaining := usage.Plan.MonthlyLinks, usage.Remaining()
+		response.Limit = &limit
+		response.Remaining = &remaining
+	}
+	return response
+}
+
+// writeQuotaHeaders tells API clients how much of their plan is left
+//
+//	X-Quota-Limit:     links per month
+//	X-Quota-Remaining: links left this month
+//	X-Quota-Reset:     when the counter resets (Unix seconds)
+//
+// Unlimited plans get no headers
+func writeQuotaHeaders(w http.ResponseWriter, usage *domain.Usage) {
+	if usage == nil || usage.Plan.Unlimited() {
+		return
+	}
+	w.Header().Set("X-Quota-Limit", strconv.FormatInt(usage.Plan.MonthlyLinks, 10))
+	w.Header().Set("X-Quota-Remaining", strconv.FormatInt(usage.Remaining(), 10))
+	w.Header().Set("X-Quota-Reset", strconv.FormatInt(usage.PeriodEnd.Unix(), 10))
+}
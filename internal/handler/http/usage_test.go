@@ -0,0 +1,150 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockUsageReporter is a mock implementation of UsageReporter
+type MockUsageReporter struct {
+	mock.Mock
+}
+
+func (m *MockUsageReporter) GetUsage(ctx context.Context, principal *auth.Principal) (*domain.Usage, error) {
+	args := m.Called(ctx, principal)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Usage), args.Error(1)
+}
+
+func freeUsage(owner string, used int64) *domain.Usage {
+	start, end := domain.UsagePeriod(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC))
+	return &domain.Usage{
+		Owner:       owner,
+		Plan:        domain.Plan{Name: domain.PlanFree, MonthlyLinks: 100},
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Used:        used,
+	}
+}
+
+func TestGetUsage(t *testing.T) {
+	alice := &auth.Principal{ID: "alice"}
+
+	tests := []struct {
+		name           string
+		principal      *auth.Principal
+		query          string
+		usage          *domain.Usage
+		expectedStatus int
+	}{
+		{name: "own usage", principal: alice, usage: freeUsage("alice", 42), expectedStatus: http.StatusOK},
+		{name: "other owner needs admin", principal: alice, query: "?owner=bob", expectedStatus: http.StatusForbidden},
+		{name: "admin views other owner", principal: &auth.Principal{ID: "admin", Admin: true}, query: "?owner=bob", usage: freeUsage("bob", 7), expectedStatus: http.StatusOK},
+		{name: "no quota applies", principal: &auth.Principal{ID: "admin", Admin: true}, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockQuotas := new(MockUsageReporter)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+			handler := NewUsageHandler(mockQuotas, logger)
+
+			if tt.usage != nil {
+				mockQuotas.On("GetUsage", mock.Anything, mock.Anything).Return(tt.usage, nil)
+			} else {
+				mockQuotas.On("GetUsage", mock.Anything, mock.Anything).Return(nil, nil)
+			}
+
+			req := httptest.NewRequest("GET", "/api/v1/usage"+tt.query, nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.GetUsage(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data struct {
+					Plan      string `json:"plan"`
+					Used      int64  `json:"used"`
+					Limit     *int64 `json:"limit"`
+					Remaining *int64 `json:"remaining"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "free", response.Data.Plan)
+			assert.Equal(t, tt.usage.Used, response.Data.Used)
+			assert.Equal(t, int64(100), *response.Data.Limit)
+			assert.Equal(t, 100-tt.usage.Used, *response.Data.Remaining)
+			assert.Equal(t, tt.usage.Owner, mockQuotas.Calls[0].Arguments.Get(1).(*auth.Principal).ID)
+		})
+	}
+}
+
+func TestCreateURL_QuotaHeaders(t *testing.T) {
+	alice := &auth.Principal{ID: "alice"}
+
+	tests := []struct {
+		name              string
+		createErr         error
+		used              int64
+		expectedStatus    int
+		expectedRemaining string
+	}{
+		{name: "created", used: 43, expectedStatus: http.StatusCreated, expectedRemaining: "57"},
+		{name: "quota exceeded", createErr: domain.ErrQuotaExceeded, used: 100, expectedStatus: http.StatusTooManyRequests, expectedRemaining: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			mockQuotas := new(MockUsageReporter)
+			handler.WithQuotas(mockQuotas)
+
+			if tt.createErr != nil {
+				mockService.On("CreateShortURL", mock.Anything, "https://example.com", "", "alice", time.Duration(0)).
+					Return(nil, tt.createErr)
+			} else {
+				mockService.On("CreateShortURL", mock.Anything, "https://example.com", "", "alice", time.Duration(0)).
+					Return(&domain.URL{ID: "1", ShortCode: "abc123", OriginalURL: "https://example.com"}, nil)
+			}
+			mockQuotas.On("GetUsage", mock.Anything, alice).Return(freeUsage("alice", tt.used), nil)
+
+			req := httptest.NewRequest("POST", "/api/v1/urls", bytes.NewBufferString(`{"url": "https://example.com"}`))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), alice))
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.CreateURL(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, "100", w.Header().Get("X-Quota-Limit"))
+			assert.Equal(t, tt.expectedRemaining, w.Header().Get("X-Quota-Remaining"))
+			assert.NotEmpty(t, w.Header().Get("X-Quota-Reset"))
+		})
+	}
+}
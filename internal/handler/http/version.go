@@ -0,0 +1,92 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIVersion registers routes under a version prefix (e.g. "/api/v1")
+// and applies version-wide behavior such as deprecation headers
+//
+// WHY VERSION THE API?
+// Clients in the wild can't all upgrade at once. Keeping old versions frozen
+// while new ones evolve lets us fix mistakes without breaking anybody
+type APIVersion struct {
+	mux       *http.ServeMux
+	prefix    string
+	lifecycle VersionLifecycle
+}
+
+// VersionLifecycle describes when a version was deprecated and when it goes away
+// Zero values mean "not deprecated" / "no sunset date announced"
+type VersionLifecycle struct {
+	DeprecatedAt time.Time // Sent as the Deprecation header (RFC 9745)
+	Sunset       time.Time // Sent as the Sunset header (RFC 8594)
+	Successor    string    // Path of the replacement version, sent as a Link header
+}
+
+// NewAPIVersion creates a route registrar for the given version name (e.g. "v1")
+func NewAPIVersion(mux *http.ServeMux, name string, lifecycle VersionLifecycle) *APIVersion {
+	return &APIVersion{
+		mux:       mux,
+		prefix:    "/api/" + name,
+		lifecycle: lifecycle,
+	}
+}
+
+// Prefix returns the path prefix of this version (e.g. "/api/v1")
+func (v *APIVersion) Prefix() string {
+	return v.prefix
+}
+
+// HandleFunc registers a handler for a pattern relative to the version prefix
+// Patterns follow http.ServeMux syntax, including an optional method:
+//
+//	v.HandleFunc("POST /urls", h.CreateURL) // registers "POST /api/v1/urls"
+func (v *APIVersion) HandleFunc(pattern string, handler http.HandlerFunc) {
+	v.Handle(pattern, handler)
+}
+
+// Handle registers an http.Handler for a pattern relative to the version prefix
+func (v *APIVersion) Handle(pattern string, handler http.Handler) {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = "", pattern
+	}
+
+	full := v.prefix + path
+	if method != "" {
+		full = method + " " + full
+	}
+
+	v.mux.Handle(full, v.withLifecycleHeaders(handler))
+}
+
+// withLifecycleHeaders adds deprecation headers to every response of a deprecated version
+func (v *APIVersion) withLifecycleHeaders(next http.Handler) http.Handler {
+	lc := v.lifecycle
+	if lc.DeprecatedAt.IsZero() && lc.Sunset.IsZero() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !lc.DeprecatedAt.IsZero() {
+			// RFC 9745: Deprecation is a structured-field date ("@" + unix seconds)
+			w.Header().Set("Deprecation", "@"+formatUnix(lc.DeprecatedAt))
+		}
+		if !lc.Sunset.IsZero() {
+			// RFC 8594: Sunset is an HTTP-date
+			w.Header().Set("Sunset", lc.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if lc.Successor != "" {
+			w.Header().Add("Link", "<"+lc.Successor+">; rel=\"successor-version\"")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func formatUnix(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
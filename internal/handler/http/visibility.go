@@ -0,0 +1,41 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "url-shortener/internal/api/v1"
+	"url-shortener/internal/domain"
+)
+
+// SetVisibility handles PUT /api/v1/urls/{id}/visibility (owner or admin only)
+// "sensitive" hides the link's destinations from the workspace's viewers;
+// they keep seeing its stats
+func (h *Handler) SetVisibility(w http.ResponseWriter, r *http.Request) {
+	var req v1.URLVisibility
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	visibility, _ := domain.ParseLinkVisibility(req.Visibility) // Validated above
+
+	url, err := h.urlService.SetVisibility(r.Context(), r.PathValue("id"), visibility)
+	switch {
+	case errors.Is(err, domain.ErrVersionConflict):
+		respondError(w, http.StatusConflict, "URL was modified by another request, please retry")
+		return
+	case errors.Is(err, domain.ErrSealedDestinationTargets):
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		h.respondLookupError(w, "Failed to save visibility", err)
+		return
+	}
+
+	// Standard links store "" (see domain.VisibilityStandard); answer with the name
+	name := string(url.Visibility)
+	if url.Visibility == domain.VisibilityStandard {
+		name = "standard"
+	}
+	w.Header().Set("ETag", url.ETag())
+	respondSuccess(w, http.StatusOK, v1.URLVisibility{Visibility: name}, "Visibility saved")
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"url-shortener/internal/domain"
+)
+
+func TestSetVisibility(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		visibility     domain.LinkVisibility
+		serviceErr     error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "sensitive", body: `{"visibility":"sensitive"}`, visibility: domain.VisibilitySensitive, expectedStatus: http.StatusOK, expectedBody: `"visibility":"sensitive"`},
+		{name: "back to standard", body: `{"visibility":"standard"}`, visibility: domain.VisibilityStandard, expectedStatus: http.StatusOK, expectedBody: `"visibility":"standard"`},
+		{name: "unknown visibility", body: `{"visibility":"secret"}`, expectedStatus: http.StatusBadRequest},
+		{name: "missing visibility", body: `{}`, expectedStatus: http.StatusBadRequest},
+		{name: "viewer", body: `{"visibility":"sensitive"}`, visibility: domain.VisibilitySensitive, serviceErr: domain.ErrForbidden, expectedStatus: http.StatusForbidden},
+		{name: "unknown link", body: `{"visibility":"sensitive"}`, visibility: domain.VisibilitySensitive, serviceErr: domain.ErrURLNotFound, expectedStatus: http.StatusNotFound},
+		{name: "concurrent update", body: `{"visibility":"sensitive"}`, visibility: domain.VisibilitySensitive, serviceErr: domain.ErrVersionConflict, expectedStatus: http.StatusConflict},
+		{name: "can't be encrypted", body: `{"visibility":"sensitive"}`, visibility: domain.VisibilitySensitive, serviceErr: domain.ErrSealedDestinationTargets, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler, mockService := setupTestHandler()
+			if tt.serviceErr != nil {
+				mockService.On("SetVisibility", mock.Anything, "url-1", tt.visibility).Return(nil, tt.serviceErr)
+			} else {
+				saved := &domain.URL{ID: "url-1", Version: 2, Visibility: tt.visibility}
+				mockService.On("SetVisibility", mock.Anything, "url-1", tt.visibility).Return(saved, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/urls/url-1/visibility", strings.NewReader(tt.body))
+			req.SetPathValue("id", "url-1")
+			w := httptest.NewRecorder()
+
+			// Act
+			handler.SetVisibility(w, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			if tt.expectedStatus == http.StatusBadRequest && tt.serviceErr == nil {
+				mockService.AssertNotCalled(t, "SetVisibility", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
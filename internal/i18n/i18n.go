@@ -0,0 +1,182 @@
+// Package i18n translates user-facing text: API error messages, the
+// branded error pages and the hosted UI
+//
+// HOW MESSAGES ARE IDENTIFIED:
+// The English text IS the message ID (like gettext). Code keeps writing
+// respondError(w, 404, "URL not found"), and a catalog maps that string to
+// its translation:
+//
+//	locales/fr.json: {"URL not found": "URL introuvable"}
+//
+// English needs no catalog, and a message nobody translated yet still
+// shows up - in English - instead of as a cryptic key.
+//
+// FALLBACK CHAIN:
+// A visitor asking for "fr-CA" gets the fr-ca catalog, then fr, then the
+// English source text. Whatever is missing at one level falls through to
+// the next, so a partial catalog is never worse than no catalog.
+//
+// WHY EMBED THE FILES?
+// Catalogs are compiled into the binary (go:embed): the server can't start
+// with a missing or half-deployed locales directory, and tests use exactly
+// what production uses.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultLanguage is the language of the message IDs themselves
+const DefaultLanguage = "en"
+
+// ErrInvalidCatalog is returned when a locale file can't be used
+var ErrInvalidCatalog = errors.New("invalid translation catalog")
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Catalog holds every translation, by lowercase language tag
+type Catalog struct {
+	messages map[string]map[string]string // "fr" -> English text -> French text
+}
+
+// Load returns the catalogs compiled into the binary (locales/*.json)
+func Load() (*Catalog, error) {
+	sub, err := fs.Sub(locales, "locales")
+	if err != nil {
+		return nil, err
+	}
+	return New(sub)
+}
+
+// New reads every <language>.json file at the root of fsys
+// The file name is the language tag: fr.json, pt-br.json, ...
+func New(fsys fs.FS) (*Catalog, error) {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{messages: make(map[string]map[string]string)}
+	for _, file := range files {
+		language := strings.ToLower(strings.TrimSuffix(path.Base(file), ".json"))
+		if language == "" || language == DefaultLanguage {
+			return nil, fmt.Errorf("%w: %s: %s is the source language", ErrInvalidCatalog, file, DefaultLanguage)
+		}
+
+		raw, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidCatalog, file, err)
+		}
+		for id, text := range messages {
+			if strings.TrimSpace(text) == "" {
+				return nil, fmt.Errorf("%w: %s: empty translation for %q", ErrInvalidCatalog, file, id)
+			}
+		}
+		c.messages[language] = messages
+	}
+	return c, nil
+}
+
+// Languages returns the supported languages, DefaultLanguage first
+func (c *Catalog) Languages() []string {
+	languages := make([]string, 0, len(c.messages)+1)
+	for language := range c.messages {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return append([]string{DefaultLanguage}, languages...)
+}
+
+// Match picks the best supported language for a visitor
+// preferred is most-preferred first (see parseAcceptLanguage). For each
+// entry the exact tag wins over its base language ("fr-ch" then "fr").
+// Nothing supported means DefaultLanguage.
+func (c *Catalog) Match(preferred []string) string {
+	for _, tag := range preferred {
+		tag = strings.ToLower(tag)
+		for _, candidate := range fallbacks(tag) {
+			if candidate == DefaultLanguage {
+				return DefaultLanguage
+			}
+			if _, ok := c.messages[candidate]; ok {
+				return candidate
+			}
+		}
+	}
+	return DefaultLanguage
+}
+
+// Localizer returns the translator for one language (see Match)
+func (c *Catalog) Localizer(language string) Localizer {
+	language = strings.ToLower(language)
+	l := Localizer{language: language}
+	if c == nil {
+		return l
+	}
+	for _, candidate := range fallbacks(language) {
+		if messages, ok := c.messages[candidate]; ok {
+			l.chain = append(l.chain, messages)
+		}
+	}
+	return l
+}
+
+// Localizer translates messages into one language
+// The zero value is usable: it answers in English
+type Localizer struct {
+	language string
+	chain    []map[string]string // Most specific catalog first
+}
+
+// Language returns the language this localizer answers in
+// (what goes into Content-Language and <html lang>)
+func (l Localizer) Language() string {
+	if l.language == "" || len(l.chain) == 0 {
+		return DefaultLanguage
+	}
+	return l.language
+}
+
+// Translate returns message in the localizer's language
+// With args, the result is formatted like fmt.Sprintf. Translations can
+// reorder arguments with explicit indexes ("%[2]s ... %[1]d").
+func (l Localizer) Translate(message string, args ...interface{}) string {
+	text := message
+	for _, messages := range l.chain {
+		if translated, ok := messages[message]; ok {
+			text = translated
+			break
+		}
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// fallbacks lists the tags to try for a language, most specific first:
+// "zh-hant-tw" -> "zh-hant-tw", "zh-hant", "zh"
+func fallbacks(tag string) []string {
+	var chain []string
+	for tag != "" {
+		chain = append(chain, tag)
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	return chain
+}
@@ -0,0 +1,136 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCatalog(t *testing.T) *Catalog {
+	catalog, err := New(fstest.MapFS{
+		"fr.json":    {Data: []byte(`{"URL not found": "URL introuvable", "Copy": "Copier", "%d clicks": "%d clics"}`)},
+		"fr-ca.json": {Data: []byte(`{"Copy": "Copier (CA)"}`)},
+		"de.json":    {Data: []byte(`{"URL not found": "URL nicht gefunden"}`)},
+	})
+	require.NoError(t, err)
+	return catalog
+}
+
+func TestCatalog_Match(t *testing.T) {
+	tests := []struct {
+		name      string
+		preferred []string
+		expected  string
+	}{
+		{name: "exact", preferred: []string{"fr"}, expected: "fr"},
+		{name: "region with its own catalog", preferred: []string{"fr-ca"}, expected: "fr-ca"},
+		{name: "region falls back to base", preferred: []string{"fr-ch"}, expected: "fr"},
+		{name: "case insensitive", preferred: []string{"DE-AT"}, expected: "de"},
+		{name: "first supported wins", preferred: []string{"ja", "de", "fr"}, expected: "de"},
+		{name: "english beats a later translation", preferred: []string{"en-gb", "fr"}, expected: "en"},
+		{name: "nothing supported", preferred: []string{"ja", "ko"}, expected: "en"},
+		{name: "no header", preferred: nil, expected: "en"},
+	}
+
+	catalog := newTestCatalog(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, catalog.Match(tt.preferred))
+		})
+	}
+}
+
+func TestLocalizer_Translate(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		message  string
+		args     []interface{}
+		expected string
+	}{
+		{name: "translated", language: "fr", message: "URL not found", expected: "URL introuvable"},
+		{name: "region catalog first", language: "fr-ca", message: "Copy", expected: "Copier (CA)"},
+		{name: "region falls through to base", language: "fr-ca", message: "URL not found", expected: "URL introuvable"},
+		{name: "missing falls back to english", language: "de", message: "Copy", expected: "Copy"},
+		{name: "english", language: "en", message: "URL not found", expected: "URL not found"},
+		{name: "formatted", language: "fr", message: "%d clicks", args: []interface{}{3}, expected: "3 clics"},
+		{name: "formatted english", language: "en", message: "%d clicks", args: []interface{}{3}, expected: "3 clicks"},
+	}
+
+	catalog := newTestCatalog(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			localizer := catalog.Localizer(tt.language)
+			assert.Equal(t, tt.expected, localizer.Translate(tt.message, tt.args...))
+		})
+	}
+}
+
+func TestLocalizer_Language(t *testing.T) {
+	catalog := newTestCatalog(t)
+
+	assert.Equal(t, "fr", catalog.Localizer("fr").Language())
+	assert.Equal(t, "en", catalog.Localizer("ja").Language(), "no catalog means the answer is english")
+	assert.Equal(t, "en", Localizer{}.Language(), "the zero value answers in english")
+	assert.Equal(t, "Copy", Localizer{}.Translate("Copy"))
+}
+
+func TestNew_InvalidCatalogs(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{name: "not JSON", files: fstest.MapFS{"fr.json": {Data: []byte(`{`)}}},
+		{name: "not a string map", files: fstest.MapFS{"fr.json": {Data: []byte(`{"Copy": 1}`)}}},
+		{name: "empty translation", files: fstest.MapFS{"fr.json": {Data: []byte(`{"Copy": " "}`)}}},
+		{name: "english catalog", files: fstest.MapFS{"en.json": {Data: []byte(`{}`)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.files)
+			assert.ErrorIs(t, err, ErrInvalidCatalog)
+		})
+	}
+}
+
+// The shipped catalogs must all translate the same messages, so a new
+// string can't be added to one language and forgotten in the others
+func TestLoad_CatalogsAreComplete(t *testing.T) {
+	catalog, err := Load()
+	require.NoError(t, err)
+	require.Greater(t, len(catalog.Languages()), 1)
+
+	reference := catalog.messages[catalog.Languages()[1]]
+	for _, language := range catalog.Languages()[1:] {
+		for id := range reference {
+			assert.Contains(t, catalog.messages[language], id, "%s is missing %q", language, id)
+		}
+		assert.Len(t, catalog.messages[language], len(reference), "%s has messages the others don't", language)
+	}
+}
+
+// Every {{call .T "..."}} in the HTML templates needs a translation
+func TestLoad_TemplatesAreTranslated(t *testing.T) {
+	catalog, err := Load()
+	require.NoError(t, err)
+
+	pattern := regexp.MustCompile(`\{\{call \.T "([^"]+)"\}\}`)
+	files, err := filepath.Glob(filepath.Join("..", "..", "web", "templates", "*.html"))
+	require.NoError(t, err)
+
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		require.NoError(t, err)
+		for _, match := range pattern.FindAllStringSubmatch(string(raw), -1) {
+			for _, language := range catalog.Languages()[1:] {
+				assert.Contains(t, catalog.messages[language], match[1], "%s: %s is missing %q", filepath.Base(file), language, match[1])
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package importer reads link exports from other URL shorteners
+//
+// Supported formats:
+//   - Bitly export CSV (columns like "long_url" and "bitlink"/"custom_bitlinks")
+//   - TinyURL export CSV (columns like "alias" and "url")
+//   - Any CSV with a header naming an alias column and a URL column
+//   - Headerless two-column CSV: alias,url (or url,alias)
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"url-shortener/internal/domain"
+)
+
+var (
+	ErrEmptyFile     = errors.New("import file is empty")
+	ErrNoURLColumn   = errors.New("could not find a URL column in the import file")
+	ErrTooManyRows   = errors.New("import file has too many rows")
+	ErrMalformedFile = errors.New("import file is not valid CSV")
+)
+
+// Column names we recognize, normalized (lowercase, spaces/dashes -> underscores)
+// The first match wins, so more specific names come first
+var (
+	urlColumns   = []string{"long_url", "original_url", "destination", "destination_url", "target_url", "url"}
+	aliasColumns = []string{"custom_alias", "alias", "custom_bitlinks", "custom_bitlink", "back_half", "keyword", "bitlink", "short_url", "short_link", "link"}
+)
+
+// ParseCSV reads import rows from a CSV file
+// Rows without a URL are skipped; maxRows <= 0 means no limit
+func ParseCSV(r io.Reader, maxRows int) ([]domain.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Exports are not always rectangular
+	reader.TrimLeadingSpace = true
+
+	first, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrEmptyFile
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedFile, err)
+	}
+
+	urlIdx, aliasIdx, hasHeader := detectColumns(first)
+	if urlIdx < 0 {
+		return nil, ErrNoURLColumn
+	}
+
+	var rows []domain.ImportRow
+	line := 1
+	if !hasHeader {
+		if row, ok := toRow(first, line, urlIdx, aliasIdx); ok {
+			rows = append(rows, row)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrMalformedFile, line, err)
+		}
+
+		row, ok := toRow(record, line, urlIdx, aliasIdx)
+		if !ok {
+			continue
+		}
+		if maxRows > 0 && len(rows) >= maxRows {
+			return nil, fmt.Errorf("%w (max %d)", ErrTooManyRows, maxRows)
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrEmptyFile
+	}
+	return rows, nil
+}
+
+// detectColumns finds the URL and alias columns
+// Without a recognizable header the file is treated as alias,url (or url,alias)
+func detectColumns(first []string) (urlIdx, aliasIdx int, hasHeader bool) {
+	names := make([]string, len(first))
+	for i, name := range first {
+		names[i] = normalizeColumn(name)
+	}
+
+	urlIdx = findColumn(names, urlColumns)
+	if urlIdx >= 0 {
+		aliasIdx = findColumn(names, aliasColumns)
+		return urlIdx, aliasIdx, true
+	}
+
+	// Headerless file: whichever column holds a URL is the destination
+	switch {
+	case len(first) >= 2 && looksLikeURL(first[1]):
+		return 1, 0, false
+	case len(first) >= 2 && looksLikeURL(first[0]):
+		return 0, 1, false
+	case len(first) == 1 && looksLikeURL(first[0]):
+		return 0, -1, false
+	}
+	return -1, -1, false
+}
+
+// findColumn returns the index of the first candidate present in names, or -1
+func findColumn(names, candidates []string) int {
+	for _, candidate := range candidates {
+		for i, name := range names {
+			if name == candidate {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// toRow builds an ImportRow from a record; ok is false for rows without a URL
+func toRow(record []string, line, urlIdx, aliasIdx int) (domain.ImportRow, bool) {
+	if urlIdx >= len(record) || strings.TrimSpace(record[urlIdx]) == "" {
+		return domain.ImportRow{}, false
+	}
+
+	row := domain.ImportRow{Line: line, URL: strings.TrimSpace(record[urlIdx])}
+	if aliasIdx >= 0 && aliasIdx < len(record) {
+		row.Alias = aliasFromValue(record[aliasIdx])
+	}
+	return row, true
+}
+
+// aliasFromValue extracts the alias from values like "bit.ly/summer-sale",
+// "https://tinyurl.com/summer-sale" or plain "summer-sale"
+// Bitly lists several custom links separated by commas; the first one is used
+func aliasFromValue(value string) string {
+	value, _, _ = strings.Cut(strings.TrimSpace(value), ",")
+	value = strings.TrimSpace(value)
+	if i := strings.LastIndex(strings.TrimRight(value, "/"), "/"); i >= 0 {
+		value = strings.TrimRight(value, "/")[i+1:]
+	}
+	return value
+}
+
+// normalizeColumn lowercases a header and turns spaces and dashes into underscores
+// "Long URL" -> "long_url", "back-half" -> "back_half"
+func normalizeColumn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) // Excel adds a BOM
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+// looksLikeURL reports whether a value is an absolute http(s) URL
+func looksLikeURL(value string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(value))
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,137 @@
+// Package jobs runs long operations in the background
+//
+// WHY JOBS?
+// Some operations take longer than an HTTP request should: importing
+// thousands of links, exporting an account, re-checking every destination.
+// Instead of blocking, the handler ENQUEUES a job and answers
+// 202 Accepted with the job's ID; the client polls GET /api/v1/jobs/{id}
+// until the job has succeeded or failed.
+//
+// WHERE JOBS LIVE:
+// PostgreSQL (jobs table) is the queue. Jobs survive restarts, and every
+// instance can work on them: workers CLAIM jobs with FOR UPDATE SKIP LOCKED,
+// so two instances never run the same job at once.
+//
+// FAILURES:
+// A failed job is retried with exponential backoff until it has used up its
+// attempts. Errors wrapped with Permanent are not retried (bad input stays
+// bad). A worker that dies mid-job stops sending heartbeats; once the
+// heartbeat is stale, another worker picks the job up again. Handlers must
+// therefore be safe to run twice.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status is where a job is in its life cycle
+type Status string
+
+const (
+	StatusPending   Status = "pending"   // Waiting for a worker (or for its next retry)
+	StatusRunning   Status = "running"   // A worker is on it
+	StatusSucceeded Status = "succeeded" // Done; Result holds the outcome
+	StatusFailed    Status = "failed"    // Gave up; Error says why
+)
+
+// Finished reports whether the job will not change anymore
+func (s Status) Finished() bool {
+	return s == StatusSucceeded || s == StatusFailed
+}
+
+var (
+	ErrNotFound    = errors.New("job not found")
+	ErrUnknownKind = errors.New("no handler registered for this job kind")
+	ErrInvalidKind = errors.New("job kind must be 1-64 lowercase letters, digits, dots or dashes")
+)
+
+var validKind = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{0,63}$`)
+
+// Job is one unit of background work
+type Job struct {
+	ID          string
+	Kind        string          // Picks the Handler, e.g. "links.import"
+	Payload     json.RawMessage // Input of the handler
+	Status      Status
+	Attempts    int // Runs started so far (including the current one)
+	MaxAttempts int
+	Result      json.RawMessage // Output of the handler (succeeded jobs only)
+	Error       string          // Last failure (retried and failed jobs)
+	CreatedBy   string          // Principal that enqueued the job; only they (and admins) may read it
+	CreatedAt   time.Time
+	RunAt       time.Time  // Not claimed before this time (retries are scheduled here)
+	StartedAt   *time.Time // Start of the latest attempt
+	FinishedAt  *time.Time
+}
+
+// NewJob creates a pending job that can run right away
+func NewJob(kind string, payload json.RawMessage, createdBy string, maxAttempts int) *Job {
+	now := time.Now()
+	return &Job{
+		ID:          uuid.New().String(),
+		Kind:        kind,
+		Payload:     payload,
+		Status:      StatusPending,
+		MaxAttempts: maxAttempts,
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+		RunAt:       now,
+	}
+}
+
+// DecodePayload reads the job's payload into dst
+// A payload that doesn't decode fails the job for good (see Permanent)
+func (j *Job) DecodePayload(dst interface{}) error {
+	if err := json.Unmarshal(j.Payload, dst); err != nil {
+		return Permanent(fmt.Errorf("invalid payload for %s job: %w", j.Kind, err))
+	}
+	return nil
+}
+
+// Store persists jobs (PostgreSQL in production)
+type Store interface {
+	Create(ctx context.Context, job *Job) error
+	Get(ctx context.Context, id string) (*Job, error) // ErrNotFound if unknown
+
+	// ClaimNext atomically takes the oldest due job of one of kinds: a
+	// pending job whose RunAt has passed, or a running job whose heartbeat
+	// is older than staleAfter. It marks the job running, counts the
+	// attempt, and returns nil when there is nothing to do
+	ClaimNext(ctx context.Context, kinds []string, staleAfter time.Duration) (*Job, error)
+
+	// Heartbeat tells the other workers that the job is still being worked on
+	Heartbeat(ctx context.Context, id string) error
+
+	// Succeed, Retry and Fail end an attempt
+	Succeed(ctx context.Context, id string, result json.RawMessage) error
+	Retry(ctx context.Context, id, errMsg string, runAt time.Time) error // Back to pending until runAt
+	Fail(ctx context.Context, id, errMsg string) error
+
+	// DeleteFinishedBefore removes succeeded and failed jobs that finished
+	// before the given time and returns how many it removed
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// permanentError marks a failure that retrying can't fix
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job fails right away instead of being retried
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err (or an error it wraps) came from Permanent
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
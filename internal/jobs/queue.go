@@ -0,0 +1,249 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"url-shortener/internal/errreport"
+	"url-shortener/internal/metrics"
+)
+
+// Handler does the work of one job kind
+// The returned result is stored as JSON and shown by the status API
+// Handlers can run more than once for the same job (see the package doc)
+type Handler func(ctx context.Context, job *Job) (result interface{}, err error)
+
+// Queue enqueues jobs and runs them with a pool of workers
+type Queue struct {
+	store    Store
+	handlers map[string]Handler
+	reporter errreport.Reporter
+
+	maxAttempts int
+	baseDelay   time.Duration // Wait before the first retry; doubles per attempt
+	maxDelay    time.Duration
+	staleAfter  time.Duration // Running jobs without a heartbeat for this long are taken over
+	now         func() time.Time
+}
+
+// New creates a queue on top of store
+// Register every job kind before calling Run
+func New(store Store) *Queue {
+	return &Queue{
+		store:       store,
+		handlers:    make(map[string]Handler),
+		reporter:    errreport.Nop{},
+		maxAttempts: 3,
+		baseDelay:   30 * time.Second,
+		maxDelay:    30 * time.Minute,
+		staleAfter:  5 * time.Minute,
+		now:         time.Now,
+	}
+}
+
+// WithRetries sets how often a job runs at most (1 = never retried) and the
+// backoff between attempts: baseDelay doubled per attempt, up to maxDelay
+func (q *Queue) WithRetries(maxAttempts int, baseDelay, maxDelay time.Duration) *Queue {
+	q.maxAttempts = maxAttempts
+	q.baseDelay = baseDelay
+	q.maxDelay = maxDelay
+	return q
+}
+
+// WithErrorReporter sends panicking handlers to an error tracker
+func (q *Queue) WithErrorReporter(reporter errreport.Reporter) *Queue {
+	q.reporter = reporter
+	return q
+}
+
+// Register sets the handler of a job kind
+// Panics on an invalid kind: registration happens at startup, where a typo
+// should stop the server instead of leaving jobs that never run
+func (q *Queue) Register(kind string, handler Handler) *Queue {
+	if !validKind.MatchString(kind) {
+		panic(fmt.Sprintf("jobs: %v: %q", ErrInvalidKind, kind))
+	}
+	q.handlers[kind] = handler
+	return q
+}
+
+// Enqueue stores a new job; a worker picks it up on its next poll
+// payload is stored as JSON (nil = no input)
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload interface{}, createdBy string) (*Job, error) {
+	if _, ok := q.handlers[kind]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+
+	var raw json.RawMessage
+	if payload != nil {
+		var err error
+		if raw, err = json.Marshal(payload); err != nil {
+			return nil, fmt.Errorf("failed to encode job payload: %w", err)
+		}
+	}
+
+	job := NewJob(kind, raw, createdBy, q.maxAttempts)
+	job.CreatedAt = q.now()
+	job.RunAt = job.CreatedAt
+	if err := q.store.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	metrics.RecordJob(kind, "enqueued")
+	return job, nil
+}
+
+// Get returns a job by ID
+func (q *Queue) Get(ctx context.Context, id string) (*Job, error) {
+	return q.store.Get(ctx, id)
+}
+
+// Prune deletes the jobs that finished more than olderThan ago
+// Their status can't be polled anymore afterwards; pending and running
+// jobs are never touched
+func (q *Queue) Prune(ctx context.Context, olderThan time.Duration) (int64, error) {
+	return q.store.DeleteFinishedBefore(ctx, q.now().Add(-olderThan))
+}
+
+// Run works on jobs with the given number of workers until ctx is canceled
+// Idle workers poll the store every pollInterval
+func (q *Queue) Run(ctx context.Context, workers int, pollInterval time.Duration) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, pollInterval)
+		}()
+	}
+	wg.Wait()
+}
+
+// work is the loop of one worker
+func (q *Queue) work(ctx context.Context, pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before sleeping again
+		for {
+			processed, err := q.ProcessNext(ctx)
+			if err != nil && ctx.Err() == nil {
+				fmt.Printf("Warning: background job failed: %v\n", err)
+			}
+			if !processed || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessNext claims and runs one job
+// Returns false when there was nothing to do; the error is the job's (or
+// the store's) failure, already recorded on the job where possible
+func (q *Queue) ProcessNext(ctx context.Context) (bool, error) {
+	job, err := q.store.ClaimNext(ctx, slices.Sorted(maps.Keys(q.handlers)), q.staleAfter)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	result, runErr := q.runWithHeartbeat(ctx, job)
+
+	// Shutting down: leave the job running. Its heartbeat goes stale and
+	// another worker (or this one, after the restart) takes it over
+	if ctx.Err() != nil {
+		return true, ctx.Err()
+	}
+
+	if runErr == nil {
+		raw, err := marshalResult(result)
+		if err != nil {
+			runErr = Permanent(err)
+		} else {
+			metrics.RecordJob(job.Kind, "succeeded")
+			return true, q.store.Succeed(ctx, job.ID, raw)
+		}
+	}
+
+	if IsPermanent(runErr) || job.Attempts >= job.MaxAttempts {
+		metrics.RecordJob(job.Kind, "failed")
+		if err := q.store.Fail(ctx, job.ID, runErr.Error()); err != nil {
+			return true, fmt.Errorf("job %s: %w (and failed to record it: %v)", job.ID, runErr, err)
+		}
+		return true, fmt.Errorf("job %s (%s) failed: %w", job.ID, job.Kind, runErr)
+	}
+
+	metrics.RecordJob(job.Kind, "retried")
+	runAt := q.now().Add(q.backoff(job.Attempts))
+	if err := q.store.Retry(ctx, job.ID, runErr.Error(), runAt); err != nil {
+		return true, fmt.Errorf("job %s: %w (and failed to schedule the retry: %v)", job.ID, runErr, err)
+	}
+	return true, fmt.Errorf("job %s (%s) attempt %d failed, retrying at %s: %w",
+		job.ID, job.Kind, job.Attempts, runAt.Format(time.RFC3339), runErr)
+}
+
+// runWithHeartbeat runs the job's handler and keeps its heartbeat fresh
+func (q *Queue) runWithHeartbeat(ctx context.Context, job *Job) (result interface{}, err error) {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(q.staleAfter / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := q.store.Heartbeat(ctx, job.ID); err != nil && ctx.Err() == nil {
+					fmt.Printf("Warning: failed to record heartbeat of job %s: %v\n", job.ID, err)
+				}
+			}
+		}
+	}()
+
+	// A panicking handler fails its job instead of the whole worker
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			event := errreport.Panic(r)
+			event.Tags["component"] = "jobs"
+			event.Tags["job_kind"] = job.Kind
+			event.Tags["job_id"] = job.ID
+			q.reporter.Report(event)
+		}
+	}()
+
+	return q.handlers[job.Kind](ctx, job)
+}
+
+// backoff is the wait after the given (1-based) failed attempt
+func (q *Queue) backoff(attempt int) time.Duration {
+	delay := q.baseDelay
+	for i := 1; i < attempt && delay < q.maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, q.maxDelay)
+}
+
+// marshalResult stores a handler's result as JSON (nil stays empty)
+func marshalResult(result interface{}) (json.RawMessage, error) {
+	if result == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job result: %w", err)
+	}
+	return raw, nil
+}
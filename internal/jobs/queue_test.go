@@ -0,0 +1,405 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is an in-memory Store for tests
+// It claims jobs like the PostgreSQL store: oldest due job of a known kind
+type memoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+	now  func() time.Time
+}
+
+func newMemoryStore(now func() time.Time) *memoryStore {
+	return &memoryStore{jobs: make(map[string]*Job), now: now}
+}
+
+func (s *memoryStore) Create(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *job
+	s.jobs[job.ID] = &copied
+	return nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (s *memoryStore) ClaimNext(ctx context.Context, kinds []string, staleAfter time.Duration) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next *Job
+	for _, job := range s.jobs {
+		due := job.Status == StatusPending && !job.RunAt.After(s.now())
+		if !due || !slices.Contains(kinds, job.Kind) {
+			continue
+		}
+		if next == nil || job.RunAt.Before(next.RunAt) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	now := s.now()
+	next.Status = StatusRunning
+	next.Attempts++
+	next.StartedAt = &now
+	copied := *next
+	return &copied, nil
+}
+
+func (s *memoryStore) Heartbeat(ctx context.Context, id string) error { return nil }
+
+func (s *memoryStore) Succeed(ctx context.Context, id string, result json.RawMessage) error {
+	return s.update(id, func(job *Job) {
+		now := s.now()
+		job.Status, job.Result, job.Error, job.FinishedAt = StatusSucceeded, result, "", &now
+	})
+}
+
+func (s *memoryStore) Retry(ctx context.Context, id, errMsg string, runAt time.Time) error {
+	return s.update(id, func(job *Job) {
+		job.Status, job.Error, job.RunAt = StatusPending, errMsg, runAt
+	})
+}
+
+func (s *memoryStore) Fail(ctx context.Context, id, errMsg string) error {
+	return s.update(id, func(job *Job) {
+		now := s.now()
+		job.Status, job.Error, job.FinishedAt = StatusFailed, errMsg, &now
+	})
+}
+
+func (s *memoryStore) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for id, job := range s.jobs {
+		if job.Status.Finished() && job.FinishedAt.Before(before) {
+			delete(s.jobs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (s *memoryStore) update(id string, change func(job *Job)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	change(job)
+	return nil
+}
+
+// newTestQueue creates a queue with a memory store and a clock the test moves
+func newTestQueue(t *testing.T) (*Queue, *memoryStore, *time.Time) {
+	t.Helper()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	store := newMemoryStore(clock)
+	queue := New(store).WithRetries(3, 10*time.Second, time.Minute)
+	queue.now = clock
+	return queue, store, &now
+}
+
+func TestQueue_RunsJobAndStoresResult(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	queue, store, _ := newTestQueue(t)
+
+	type payload struct {
+		Name string `json:"name"`
+	}
+	queue.Register("greet", func(ctx context.Context, job *Job) (interface{}, error) {
+		var in payload
+		if err := job.DecodePayload(&in); err != nil {
+			return nil, err
+		}
+		return map[string]string{"greeting": "hello " + in.Name}, nil
+	})
+
+	job, err := queue.Enqueue(ctx, "greet", payload{Name: "ada"}, "user1")
+	require.NoError(t, err)
+
+	// Act
+	processed, err := queue.ProcessNext(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, processed)
+
+	stored, err := store.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSucceeded, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.JSONEq(t, `{"greeting": "hello ada"}`, string(stored.Result))
+	assert.Equal(t, "user1", stored.CreatedBy)
+	assert.NotNil(t, stored.FinishedAt)
+}
+
+func TestQueue_ProcessNext_NothingToDo(t *testing.T) {
+	// Arrange
+	queue, _, _ := newTestQueue(t)
+	queue.Register("noop", func(ctx context.Context, job *Job) (interface{}, error) { return nil, nil })
+
+	// Act
+	processed, err := queue.ProcessNext(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.False(t, processed)
+}
+
+func TestQueue_Failures(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      Handler
+		wantStatus   Status
+		wantAttempts int
+		wantError    string
+	}{
+		{
+			name:         "retried until the attempts are used up",
+			handler:      func(ctx context.Context, job *Job) (interface{}, error) { return nil, errors.New("upstream down") },
+			wantStatus:   StatusFailed,
+			wantAttempts: 3,
+			wantError:    "upstream down",
+		},
+		{
+			name: "permanent errors are not retried",
+			handler: func(ctx context.Context, job *Job) (interface{}, error) {
+				return nil, Permanent(errors.New("bad input"))
+			},
+			wantStatus:   StatusFailed,
+			wantAttempts: 1,
+			wantError:    "bad input",
+		},
+		{
+			name: "undecodable payload fails for good",
+			handler: func(ctx context.Context, job *Job) (interface{}, error) {
+				var n int
+				return nil, job.DecodePayload(&n)
+			},
+			wantStatus:   StatusFailed,
+			wantAttempts: 1,
+			wantError:    "invalid payload for test job",
+		},
+		{
+			name:         "panics fail the job, not the worker",
+			handler:      func(ctx context.Context, job *Job) (interface{}, error) { panic("boom") },
+			wantStatus:   StatusFailed,
+			wantAttempts: 3,
+			wantError:    "panic: boom",
+		},
+		{
+			name: "succeeds on a retry",
+			handler: func(ctx context.Context, job *Job) (interface{}, error) {
+				if job.Attempts < 2 {
+					return nil, errors.New("flaky")
+				}
+				return "ok", nil
+			},
+			wantStatus:   StatusSucceeded,
+			wantAttempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			queue, store, now := newTestQueue(t)
+			queue.Register("test", tt.handler)
+			job, err := queue.Enqueue(ctx, "test", "not a number", "user1")
+			require.NoError(t, err)
+
+			// Act - keep working, moving the clock past every retry delay
+			for i := 0; i < 5; i++ {
+				_, _ = queue.ProcessNext(ctx)
+				*now = now.Add(time.Hour)
+			}
+
+			// Assert
+			stored, err := store.Get(ctx, job.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, stored.Status)
+			assert.Equal(t, tt.wantAttempts, stored.Attempts)
+			assert.Contains(t, stored.Error, tt.wantError)
+		})
+	}
+}
+
+func TestQueue_RetryWaitsForBackoff(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	queue, store, now := newTestQueue(t)
+	queue.Register("flaky", func(ctx context.Context, job *Job) (interface{}, error) {
+		return nil, errors.New("try later")
+	})
+	job, err := queue.Enqueue(ctx, "flaky", nil, "user1")
+	require.NoError(t, err)
+
+	// Act
+	_, firstErr := queue.ProcessNext(ctx)
+	processedEarly, _ := queue.ProcessNext(ctx) // Before the backoff has passed
+	*now = now.Add(10 * time.Second)
+	processedLater, _ := queue.ProcessNext(ctx)
+
+	// Assert
+	assert.ErrorContains(t, firstErr, "retrying")
+	assert.False(t, processedEarly)
+	assert.True(t, processedLater)
+
+	stored, err := store.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Attempts)
+	assert.Equal(t, now.Add(20*time.Second), stored.RunAt, "the second retry waits twice as long")
+}
+
+func TestQueue_Backoff(t *testing.T) {
+	// Arrange
+	queue := New(newMemoryStore(time.Now)).WithRetries(10, time.Second, 5*time.Second)
+
+	// Act
+	var delays []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		delays = append(delays, queue.backoff(attempt))
+	}
+
+	// Assert
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	}, delays)
+}
+
+func TestQueue_Enqueue(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	queue, _, _ := newTestQueue(t)
+	queue.Register("known", func(ctx context.Context, job *Job) (interface{}, error) { return nil, nil })
+
+	// Act
+	job, knownErr := queue.Enqueue(ctx, "known", nil, "user1")
+	_, unknownErr := queue.Enqueue(ctx, "unknown", nil, "user1")
+
+	// Assert
+	require.NoError(t, knownErr)
+	assert.Equal(t, StatusPending, job.Status)
+	assert.Equal(t, 3, job.MaxAttempts)
+	assert.Nil(t, job.Payload)
+	assert.ErrorIs(t, unknownErr, ErrUnknownKind)
+}
+
+func TestQueue_RegisterRejectsInvalidKinds(t *testing.T) {
+	queue := New(newMemoryStore(time.Now))
+
+	for _, kind := range []string{"", "Links", "links recheck", "-links"} {
+		assert.Panics(t, func() {
+			queue.Register(kind, func(ctx context.Context, job *Job) (interface{}, error) { return nil, nil })
+		}, kind)
+	}
+}
+
+func TestQueue_RunStopsWithContext(t *testing.T) {
+	// Arrange
+	queue, store, _ := newTestQueue(t)
+	ran := make(chan string, 10)
+	queue.Register("work", func(ctx context.Context, job *Job) (interface{}, error) {
+		ran <- job.ID
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var ids []string
+	for i := 0; i < 3; i++ {
+		job, err := queue.Enqueue(ctx, "work", nil, "user1")
+		require.NoError(t, err)
+		ids = append(ids, job.ID)
+	}
+
+	// Act
+	done := make(chan struct{})
+	go func() {
+		queue.Run(ctx, 2, 10*time.Millisecond)
+		close(done)
+	}()
+
+	var got []string
+	for range ids {
+		select {
+		case id := <-ran:
+			got = append(got, id)
+		case <-time.After(5 * time.Second):
+			t.Fatal("jobs did not run")
+		}
+	}
+	cancel()
+
+	// Assert
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop")
+	}
+	assert.ElementsMatch(t, ids, got)
+	for _, id := range ids {
+		require.Eventually(t, func() bool {
+			job, err := store.Get(context.Background(), id)
+			return err == nil && job.Status == StatusSucceeded
+		}, time.Second, 10*time.Millisecond)
+	}
+}
+
+func TestQueue_PruneKeepsUnfinishedJobs(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	queue, store, now := newTestQueue(t)
+	queue.Register("work", func(ctx context.Context, job *Job) (interface{}, error) { return nil, nil })
+
+	done, err := queue.Enqueue(ctx, "work", nil, "user1")
+	require.NoError(t, err)
+	_, err = queue.ProcessNext(ctx)
+	require.NoError(t, err)
+	waiting, err := queue.Enqueue(ctx, "work", nil, "user1")
+	require.NoError(t, err)
+
+	*now = now.Add(8 * 24 * time.Hour)
+
+	// Act
+	deleted, err := queue.Prune(ctx, 7*24*time.Hour)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = store.Get(ctx, done.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Get(ctx, waiting.ID)
+	assert.NoError(t, err)
+}
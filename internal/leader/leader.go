@@ -0,0 +1,177 @@
+// Package leader picks one replica to run the singleton background work
+//
+// WHY?
+// Every replica runs the same binary. Some background workers must only run
+// once per deployment: two warehouse exporters would upload the same clicks
+// twice, two warning evaluators scan every link twice. Each replica runs an
+// Elector; the one holding the election's lock is the LEADER and starts the
+// workers, the others stand by.
+//
+// HOW:
+// Leadership is a lock (a PostgreSQL advisory lock in production, see
+// postgres.NewAdvisoryLocker) checked every interval. The lock belongs to
+// the leader's database session, so a leader that crashes or loses its
+// connection loses the lock too, and a standby takes over at its next check.
+// A leader that can't confirm its lock steps down and stops its workers:
+// for a short while there may be NO leader, but never two.
+//
+// Work queues that claim rows with FOR UPDATE SKIP LOCKED (background jobs,
+// erasure, archiving) don't need a leader: every replica can help.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/internal/errreport"
+	"url-shortener/internal/metrics"
+)
+
+// Locker holds named locks for this instance
+// postgres.NewAdvisoryLocker implements it (as does scheduler.Locker)
+type Locker interface {
+	// TryLock reports whether this instance holds the lock of name, taking
+	// it if it is free. A held lock is kept until Close
+	TryLock(ctx context.Context, name string) (bool, error)
+	// Close releases every held lock so another instance can take over
+	Close() error
+}
+
+// Worker is a background loop that runs until ctx is canceled
+type Worker func(ctx context.Context)
+
+// Elector runs its workers on one instance at a time
+type Elector struct {
+	locker   Locker
+	name     string
+	interval time.Duration
+	workers  []Worker
+	reporter errreport.Reporter
+	leading  atomic.Bool
+}
+
+// New creates an elector for the named election
+// Every instance taking part must use the same name
+func New(locker Locker, name string) *Elector {
+	return &Elector{
+		locker:   locker,
+		name:     name,
+		interval: 10 * time.Second,
+		reporter: errreport.Nop{},
+	}
+}
+
+// WithInterval sets how often leadership is checked
+// It bounds how long the deployment runs without a leader after a crash
+func (e *Elector) WithInterval(interval time.Duration) *Elector {
+	e.interval = interval
+	return e
+}
+
+// WithErrorReporter sends panicking workers to an error tracker
+func (e *Elector) WithErrorReporter(reporter errreport.Reporter) *Elector {
+	e.reporter = reporter
+	return e
+}
+
+// Go adds a worker that only runs while this instance is the leader
+// Workers must return when their ctx is canceled (leadership lost or shutdown)
+func (e *Elector) Go(worker Worker) *Elector {
+	e.workers = append(e.workers, worker)
+	return e
+}
+
+// IsLeader reports whether this instance currently leads
+func (e *Elector) IsLeader() bool {
+	return e.leading.Load()
+}
+
+// Run takes part in the election until ctx is canceled
+// It starts the workers when this instance becomes the leader and stops
+// them when it isn't anymore. On return, the lock is released.
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	var stopWorkers func()
+	defer func() {
+		if stopWorkers != nil {
+			stopWorkers()
+		}
+		e.setLeading(false)
+		if err := e.locker.Close(); err != nil {
+			fmt.Printf("Warning: failed to release leader lock %s: %v\n", e.name, err)
+		}
+	}()
+
+	for {
+		leading, err := e.locker.TryLock(ctx, e.name)
+		if err != nil && ctx.Err() == nil {
+			fmt.Printf("Warning: leader election %s: %v\n", e.name, err)
+		}
+
+		switch {
+		case leading && stopWorkers == nil:
+			fmt.Printf("Leader election %s: this instance is the leader\n", e.name)
+			stopWorkers = e.startWorkers(ctx)
+			e.setLeading(true)
+		case !leading && stopWorkers != nil:
+			fmt.Printf("Leader election %s: this instance is no longer the leader\n", e.name)
+			stopWorkers()
+			stopWorkers = nil
+			e.setLeading(false)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// startWorkers runs every worker and returns a function that stops them
+// and waits until they have returned
+func (e *Elector) startWorkers(ctx context.Context) func() {
+	workerCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for _, worker := range e.workers {
+		wg.Add(1)
+		go func(worker Worker) {
+			defer wg.Done()
+			defer e.reportPanic()
+			worker(workerCtx)
+		}(worker)
+	}
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// reportPanic sends a panicking worker to the error tracker, then lets the
+// panic go on: a worker that stopped halfway is not something to run on
+// without, the process crashes (and restarts) as before
+func (e *Elector) reportPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	event := errreport.Panic(r)
+	event.Tags["component"] = "leader"
+	event.Tags["election"] = e.name
+	e.reporter.Report(event)
+	// The process is about to exit: the report must leave before it does
+	e.reporter.Flush(5 * time.Second)
+	panic(r)
+}
+
+// setLeading records the leadership of this instance
+func (e *Elector) setLeading(leading bool) {
+	e.leading.Store(leading)
+	metrics.SetLeader(e.name, leading)
+}
@@ -0,0 +1,171 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"url-shortener/internal/errreport"
+	"url-shortener/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLocker hands out the lock according to the test
+type fakeLocker struct {
+	mu     sync.Mutex
+	locked bool
+	err    error
+	closed bool
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context, name string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.locked, l.err
+}
+
+func (l *fakeLocker) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	return nil
+}
+
+func (l *fakeLocker) set(locked bool, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.locked, l.err = locked, err
+}
+
+func (l *fakeLocker) isClosed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
+
+// workerProbe records when its worker starts and stops
+type workerProbe struct {
+	started chan struct{}
+	stopped chan struct{}
+}
+
+func newWorkerProbe() *workerProbe {
+	return &workerProbe{started: make(chan struct{}, 10), stopped: make(chan struct{}, 10)}
+}
+
+func (p *workerProbe) worker(ctx context.Context) {
+	p.started <- struct{}{}
+	<-ctx.Done()
+	p.stopped <- struct{}{}
+}
+
+func waitFor(t *testing.T, ch chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the worker to %s", what)
+	}
+}
+
+func TestElector_RunsWorkersOnlyWhileLeading(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locker := &fakeLocker{}
+	probe := newWorkerProbe()
+	elector := New(locker, "leader-test").WithInterval(5 * time.Millisecond).Go(probe.worker)
+
+	done := make(chan struct{})
+	go func() {
+		elector.Run(ctx)
+		close(done)
+	}()
+
+	// Act & Assert - a standby doesn't run the worker
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, elector.IsLeader())
+	assert.Empty(t, probe.started)
+
+	// Taking the lock makes this instance the leader
+	locker.set(true, nil)
+	waitFor(t, probe.started, "start")
+	require.Eventually(t, elector.IsLeader, time.Second, time.Millisecond)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.LeaderElected.WithLabelValues("leader-test")))
+
+	// A leader that can't confirm its lock steps down
+	locker.set(false, errors.New("connection reset"))
+	waitFor(t, probe.stopped, "stop")
+	require.Eventually(t, func() bool { return !elector.IsLeader() }, time.Second, time.Millisecond)
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.LeaderElected.WithLabelValues("leader-test")))
+
+	// And runs the workers again once it has the lock back
+	locker.set(true, nil)
+	waitFor(t, probe.started, "start again")
+
+	// Shutdown stops the workers and releases the lock
+	cancel()
+	waitFor(t, probe.stopped, "stop at shutdown")
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+	}
+	assert.False(t, elector.IsLeader())
+	assert.True(t, locker.isClosed())
+}
+
+func TestElector_StartsEveryWorker(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first, second := newWorkerProbe(), newWorkerProbe()
+	elector := New(&fakeLocker{locked: true}, "leader-test-many").
+		WithInterval(time.Hour).
+		Go(first.worker).
+		Go(second.worker)
+
+	// Act
+	go elector.Run(ctx)
+
+	// Assert
+	waitFor(t, first.started, "start")
+	waitFor(t, second.started, "start")
+}
+
+// recordingReporter keeps the reported events and the flushes
+type recordingReporter struct {
+	events  []errreport.Event
+	flushes int
+}
+
+func (r *recordingReporter) Report(event errreport.Event) {
+	r.events = append(r.events, event)
+}
+
+func (r *recordingReporter) Flush(timeout time.Duration) bool {
+	r.flushes++
+	return true
+}
+
+func TestElector_ReportsPanickingWorkers(t *testing.T) {
+	// Arrange
+	reporter := &recordingReporter{}
+	e := New(&fakeLocker{}, "background-workers").WithErrorReporter(reporter)
+
+	// Act & Assert: the panic goes on after the report
+	assert.PanicsWithValue(t, "boom", func() {
+		defer e.reportPanic()
+		panic("boom")
+	})
+	require.Len(t, reporter.events, 1)
+	assert.Equal(t, "background-workers", reporter.events[0].Tags["election"])
+	assert.Equal(t, 1, reporter.flushes, "sent before the process exits")
+}
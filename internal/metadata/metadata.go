@@ -0,0 +1,246 @@
+// Package metadata reads the title, description and favicon of a web page,
+// so links can be shown by name instead of by raw URL.
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/resolver"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// DefaultMaxBytes is how much of a page is read (the <head> is near the top)
+	DefaultMaxBytes = 512 << 10
+
+	maxRedirects      = 5
+	maxTitleLen       = 300
+	maxDescriptionLen = 1000
+)
+
+var (
+	ErrNotHTML     = errors.New("destination is not an HTML page")
+	ErrBadStatus   = errors.New("destination answered with an error status")
+	ErrTooManyHops = errors.New("destination redirects too many times")
+)
+
+// Fetcher downloads pages and extracts their metadata
+//
+// SAFETY (the URL comes from an untrusted user):
+//   - Uses resolver.NewSafeClient: private, loopback and link-local addresses
+//     are refused at dial time, for the first request AND every redirect
+//   - Redirects are followed manually and only to http(s), at most 5 hops
+//   - At most maxBytes of the body are read, and nothing but text/html
+//   - The client timeout bounds every request
+type Fetcher struct {
+	client   *http.Client
+	maxBytes int64
+	now      func() time.Time
+}
+
+// New creates a fetcher with an SSRF-safe client
+// maxBytes <= 0 uses DefaultMaxBytes
+func New(timeout time.Duration, maxBytes int64) *Fetcher {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	return &Fetcher{
+		client:   resolver.NewSafeClient(timeout),
+		maxBytes: maxBytes,
+		now:      time.Now,
+	}
+}
+
+// WithClient replaces the HTTP client
+// The client must not follow redirects itself (the fetcher does that)
+func (f *Fetcher) WithClient(client *http.Client) *Fetcher {
+	f.client = client
+	return f
+}
+
+// Fetch reads the metadata of the page at rawURL
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*domain.LinkMetadata, error) {
+	current, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	for hop := 0; hop <= maxRedirects; hop++ {
+		if current.Scheme != "http" && current.Scheme != "https" {
+			return nil, fmt.Errorf("%w: %s", ErrNotHTML, current.Scheme)
+		}
+
+		resp, err := f.get(ctx, current.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", current, err)
+		}
+
+		if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+			location := resp.Header.Get("Location")
+			resp.Body.Close()
+			next, err := current.Parse(location)
+			if err != nil || location == "" {
+				return nil, fmt.Errorf("invalid redirect location %q", location)
+			}
+			current = next
+			continue
+		}
+
+		meta, err := f.read(resp, current)
+		resp.Body.Close()
+		return meta, err
+	}
+
+	return nil, ErrTooManyHops
+}
+
+// get performs one request without following redirects
+func (f *Fetcher) get(ctx context.Context, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "url-shortener-metadata/1.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	return f.client.Do(req)
+}
+
+// read checks the final response and parses its head
+func (f *Fetcher) read(resp *http.Response, page *url.URL) (*domain.LinkMetadata, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d", ErrBadStatus, resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("%w: %q", ErrNotHTML, mediaType)
+	}
+
+	meta := Parse(io.LimitReader(resp.Body, f.maxBytes), page)
+	meta.FetchedAt = f.now()
+	return meta, nil
+}
+
+// Parse extracts the metadata from an HTML document
+// page is the document's URL, used to make the favicon link absolute
+//
+// WHICH VALUE WINS?
+// Open Graph tags are written for link previews, so they beat the generic
+// <title> and meta description. Only the <head> is read: parsing stops at
+// </head> or <body>.
+func Parse(r io.Reader, page *url.URL) *domain.LinkMetadata {
+	var title, ogTitle, description, ogDescription, icon string
+	inTitle := false
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			// io.EOF, the size limit, or broken markup: keep what was found
+			return buildMetadata(page, firstNonEmpty(ogTitle, title), firstNonEmpty(ogDescription, description), icon)
+
+		case html.TextToken:
+			if inTitle {
+				title += string(z.Text())
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return buildMetadata(page, firstNonEmpty(ogTitle, title), firstNonEmpty(ogDescription, description), icon)
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			attrs := map[string]string{}
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = z.TagAttr()
+				attrs[string(key)] = string(value)
+			}
+
+			switch string(name) {
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "body":
+				return buildMetadata(page, firstNonEmpty(ogTitle, title), firstNonEmpty(ogDescription, description), icon)
+			case "meta":
+				key := strings.ToLower(firstNonEmpty(attrs["property"], attrs["name"]))
+				switch key {
+				case "og:title":
+					ogTitle = attrs["content"]
+				case "og:description":
+					ogDescription = attrs["content"]
+				case "description":
+					description = attrs["content"]
+				}
+			case "link":
+				// "icon" and "shortcut icon" are favicons; apple-touch-icon only
+				// counts when nothing else is declared
+				for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+					if rel == "icon" || (rel == "apple-touch-icon" && icon == "") {
+						icon = attrs["href"]
+					}
+				}
+			}
+		}
+	}
+}
+
+// buildMetadata cleans up the extracted values
+func buildMetadata(page *url.URL, title, description, icon string) *domain.LinkMetadata {
+	return &domain.LinkMetadata{
+		Title:       clean(title, maxTitleLen),
+		Description: clean(description, maxDescriptionLen),
+		FaviconURL:  faviconURL(page, icon),
+	}
+}
+
+// faviconURL resolves href against the page, defaulting to /favicon.ico
+// Only http(s) URLs are kept (no data: or javascript: links in UIs)
+func faviconURL(page *url.URL, href string) string {
+	if page == nil {
+		return ""
+	}
+	if href == "" {
+		href = "/favicon.ico"
+	}
+	resolved, err := page.Parse(strings.TrimSpace(href))
+	if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+		return ""
+	}
+	return resolved.String()
+}
+
+// clean collapses whitespace and cuts s to at most limit characters
+func clean(s string, limit int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	page, _ := url.Parse("https://shop.example.com/sale/spring")
+
+	tests := []struct {
+		name                string
+		html                string
+		expectedTitle       string
+		expectedDescription string
+		expectedFavicon     string
+	}{
+		{
+			name:                "title and meta description",
+			html:                `<html><head><title> Spring  Sale </title><meta name="description" content="20% off"></head></html>`,
+			expectedTitle:       "Spring Sale",
+			expectedDescription: "20% off",
+			expectedFavicon:     "https://shop.example.com/favicon.ico",
+		},
+		{
+			name: "open graph wins",
+			html: `<head><title>Shop</title><meta property="og:title" content="Spring Sale">
+				<meta name="description" content="generic"><meta property="og:description" content="Everything 20% off">`,
+			expectedTitle:       "Spring Sale",
+			expectedDescription: "Everything 20% off",
+			expectedFavicon:     "https://shop.example.com/favicon.ico",
+		},
+		{
+			name:            "relative icon link",
+			html:            `<head><link rel="shortcut icon" href="../img/icon.png"><title>Shop</title></head>`,
+			expectedTitle:   "Shop",
+			expectedFavicon: "https://shop.example.com/img/icon.png",
+		},
+		{
+			name:            "data URLs are not kept",
+			html:            `<head><link rel="icon" href="data:image/png;base64,AAAA"></head>`,
+			expectedFavicon: "",
+		},
+		{
+			name:            "stops at the body",
+			html:            `<head></head><body><title>Not the title</title></body>`,
+			expectedFavicon: "https://shop.example.com/favicon.ico",
+		},
+		{
+			name:            "entities are decoded",
+			html:            `<title>Tom &amp; Jerry</title>`,
+			expectedTitle:   "Tom & Jerry",
+			expectedFavicon: "https://shop.example.com/favicon.ico",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := Parse(strings.NewReader(tt.html), page)
+
+			assert.Equal(t, tt.expectedTitle, meta.Title)
+			assert.Equal(t, tt.expectedDescription, meta.Description)
+			assert.Equal(t, tt.expectedFavicon, meta.FaviconURL)
+		})
+	}
+}
+
+func TestParse_TruncatesLongTitles(t *testing.T) {
+	meta := Parse(strings.NewReader("<title>"+strings.Repeat("ä", 400)+"</title>"), nil)
+
+	assert.Equal(t, maxTitleLen, len([]rune(meta.Title)))
+	assert.True(t, strings.HasSuffix(meta.Title, "…"))
+}
+
+// newTestFetcher returns a fetcher that may reach the local test server
+// (the default client refuses loopback addresses)
+func newTestFetcher(maxBytes int64) *Fetcher {
+	client := &http.Client{
+		Timeout: time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return New(time.Second, maxBytes).WithClient(client)
+}
+
+func TestFetcher_Fetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Landing</title></head><body>` + strings.Repeat("x", 4096) + `</body></html>`))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{0x89, 'P', 'N', 'G'})
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		path          string
+		expectedTitle string
+		expectedErr   error
+	}{
+		{name: "HTML page", path: "/page", expectedTitle: "Landing"},
+		{name: "follows redirects", path: "/moved", expectedTitle: "Landing"},
+		{name: "redirect loop", path: "/loop", expectedErr: ErrTooManyHops},
+		{name: "not HTML", path: "/image", expectedErr: ErrNotHTML},
+		{name: "error status", path: "/missing", expectedErr: ErrBadStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := newTestFetcher(1024).Fetch(context.Background(), server.URL+tt.path)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedTitle, meta.Title)
+			assert.Equal(t, server.URL+"/favicon.ico", meta.FaviconURL)
+			assert.False(t, meta.FetchedAt.IsZero())
+		})
+	}
+}
+
+func TestFetcher_RefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the request must not reach a loopback address")
+	}))
+	defer server.Close()
+
+	_, err := New(time.Second, 0).Fetch(context.Background(), server.URL)
+
+	assert.Error(t, err)
+}
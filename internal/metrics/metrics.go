@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -59,6 +61,15 @@ var (
 		},
 	)
 
+	// CacheStaleHitsTotal counts hits served past their soft TTL (stale-while-revalidate)
+	// Each one triggers a background refresh; they are also counted in cache_hits_total
+	CacheStaleHitsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "cache_stale_hits_total",
+			Help: "Total number of cache hits served stale while being refreshed",
+		},
+	)
+
 	// CacheOperationDuration tracks cache operation latency
 	CacheOperationDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -66,7 +77,20 @@ var (
 			Help:    "Duration of cache operations in seconds",
 			Buckets: []float64{.0001, .0005, .001, .0025, .005, .01, .025, .05},
 		},
-		[]string{"operation"}, // get, set, delete
+		[]string{"operation"}, // get, set, delete, exists, clear
+	)
+
+	// RedirectLookupDuration tracks how long the cached lookup of a redirect
+	// takes (contexts marked with repository.WithRedirectLookup), split by
+	// where the URL was found. Comparing the "redis" and "db" histograms shows
+	// what the cache actually saves - hit/miss counters alone can't
+	RedirectLookupDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "redirect_lookup_duration_seconds",
+			Help:    "Duration of short code lookups for redirects in seconds, by resolution source",
+			Buckets: []float64{.0001, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		},
+		[]string{"source"}, // Cache backend (redis, memcached) or db
 	)
 
 	// ==================== RATE LIMITING METRICS ====================
@@ -113,6 +137,90 @@ var (
 		},
 	)
 
+	// AbuseActionsTotal counts what the abuse guard did on the redirect path
+	// action: "blocked" (banned IP), "challenged" (CAPTCHA page shown),
+	// "solved" (CAPTCHA solved)
+	AbuseActionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "abuse_actions_total",
+			Help: "Total number of redirect requests blocked or challenged by the abuse guard",
+		},
+		[]string{"action"},
+	)
+
+	// ClicksDeduplicatedTotal counts clicks dropped as repeats within the dedup window
+	// (double-clicks, email link scanners, preview bots)
+	ClicksDeduplicatedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "clicks_deduplicated_total",
+			Help: "Total number of clicks not counted because they repeated within the dedup window",
+		},
+	)
+
+	// ClicksConsentSuppressedTotal counts clicks recorded without personal data
+	// because the visitor sent a DNT or GPC signal (signal: "dnt" or "gpc")
+	// For transparency reports: how often visitors opted out, and that it was honored
+	ClicksConsentSuppressedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "clicks_consent_suppressed_total",
+			Help: "Total number of clicks recorded without IP address and User-Agent because of a DNT or Sec-GPC header",
+		},
+		[]string{"signal"},
+	)
+
+	// ConfusableAliasesTotal counts new aliases that looked like a popular or
+	// reserved one (action: "rejected" or "flagged")
+	ConfusableAliasesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "confusable_aliases_total",
+			Help: "Total number of new custom aliases rejected or flagged for imitating another alias",
+		},
+		[]string{"action"},
+	)
+
+	// LinkApprovalsTotal counts the approval workflow of contributors' links
+	// (decision: "requested", "approved" or "rejected")
+	LinkApprovalsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "link_approvals_total",
+			Help: "Total number of links submitted for approval, approved and rejected",
+		},
+		[]string{"decision"},
+	)
+
+	// APIKeyExpiringAuthenticationsTotal counts requests made with API keys
+	// about to stop working (reason: "expiring" - the key expires soon,
+	// "previous_secret" - the secret was rotated out and is in its grace period)
+	// Should drop to zero before the keys do: alert on it
+	APIKeyExpiringAuthenticationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_key_expiring_authentications_total",
+			Help: "Total number of requests authenticated with an API key that expires soon or a rotated-out secret",
+		},
+		[]string{"reason"},
+	)
+
+	// KMSRequestsTotal counts calls to a cloud key management service
+	// Every decryption of a data key not in memory is one; errors mean
+	// encrypted destinations and secrets can't be read
+	KMSRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kms_requests_total",
+			Help: "Total number of requests to the key management service",
+		},
+		[]string{"provider", "operation", "result"}, // result: ok, error
+	)
+
+	// ErrorReportsTotal counts events for the error tracker (see errreport)
+	// failed or dropped reports mean crashes only reached the logs
+	ErrorReportsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "error_reports_total",
+			Help: "Total number of errors reported to the error tracker",
+		},
+		[]string{"level", "result"}, // result: sent, sampled_out, rate_limited, dropped, failed
+	)
+
 	// ActiveURLsGauge tracks number of active URLs
 	ActiveURLsGauge = promauto.NewGauge(
 		prometheus.GaugeOpts{
@@ -141,8 +249,181 @@ var (
 		},
 		[]string{"operation"},
 	)
+
+	// DependencyRetriesTotal counts retried PostgreSQL/Redis calls
+	// A rising rate is an early warning that a dependency is struggling
+	DependencyRetriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dependency_retries_total",
+			Help: "Total number of retried dependency calls",
+		},
+		[]string{"operation"}, // e.g. postgres.GetByShortCode, redis.GetURL
+	)
+
+	// RequestTimeoutsTotal counts requests that ran out of time
+	RequestTimeoutsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_request_timeouts_total",
+			Help: "Total number of HTTP requests that hit their route's deadline",
+		},
+		[]string{"route"}, // Mux pattern, e.g. "GET /api/v1/export"
+	)
+
+	// BudgetExhaustedTotal counts dependency calls cut short by their
+	// request's budget (see resilience.Budget)
+	BudgetExhaustedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dependency_budget_exhausted_total",
+			Help: "Total number of dependency calls that ran out of their request's budget",
+		},
+		[]string{"dependency", "route"}, // dependency: cache, database
+	)
+
+	// CircuitBreakerState is the current breaker state: 0 = closed, 1 = half-open, 2 = open
+	CircuitBreakerState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "Circuit breaker state (0 = closed, 1 = half-open, 2 = open)",
+		},
+		[]string{"name"},
+	)
+
+	// CircuitBreakerRejectionsTotal counts calls rejected by an open breaker
+	CircuitBreakerRejectionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "circuit_breaker_rejections_total",
+			Help: "Total number of calls rejected by an open circuit breaker",
+		},
+		[]string{"name"},
+	)
+
+	// ==================== FEATURE FLAG METRICS ====================
+
+	// FeatureFlagEnabled is the state of each flag for workspaces without an override
+	FeatureFlagEnabled = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "feature_flag_enabled",
+			Help: "Feature flag state for workspaces without an override (1 = on)",
+		},
+		[]string{"flag"},
+	)
+
+	// FeatureFlagOverrides counts the workspaces with their own state per flag
+	FeatureFlagOverrides = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "feature_flag_overrides",
+			Help: "Number of workspaces with a per-workspace override of the flag",
+		},
+		[]string{"flag"},
+	)
+
+	// FeatureFlagEvaluationsTotal counts flag checks by result
+	// Shows whether a flag is actually reached in the code paths it guards
+	FeatureFlagEvaluationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "feature_flag_evaluations_total",
+			Help: "Total number of feature flag evaluations",
+		},
+		[]string{"flag", "result"}, // result: on, off
+	)
+
+	// ==================== BACKGROUND JOB METRICS ====================
+
+	// JobsTotal counts background jobs by kind and what happened to them
+	// enqueued - retried - failed shows the backlog; alert on failed
+	JobsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jobs_total",
+			Help: "Total number of background job events",
+		},
+		[]string{"kind", "event"}, // event: enqueued, succeeded, retried, failed
+	)
+
+	// ==================== SCHEDULER METRICS ====================
+
+	// ScheduledRunsTotal counts scheduled task runs by result
+	// "skipped" = another replica holds the task's lock; "missed" = due
+	// times that passed while the previous run was still going
+	ScheduledRunsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "scheduled_runs_total",
+			Help: "Total number of scheduled task runs",
+		},
+		[]string{"task", "result"}, // result: ok, error, skipped, missed
+	)
+
+	// ScheduledRunDuration tracks how long scheduled tasks take
+	ScheduledRunDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "scheduled_run_duration_seconds",
+			Help:    "Duration of scheduled task runs",
+			Buckets: []float64{.01, .1, 1, 10, 60, 300, 1800},
+		},
+		[]string{"task"},
+	)
+
+	// ScheduledLastSuccess is when each task last succeeded (unix seconds)
+	// Alert on time() - scheduled_last_success_timestamp_seconds growing
+	ScheduledLastSuccess = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "scheduled_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful run of a scheduled task",
+		},
+		[]string{"task"},
+	)
+
+	// LeaderElected is 1 on the instance that currently leads the election
+	// Exactly one instance should report 1: sum by (election) (leader_elected)
+	LeaderElected = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "leader_elected",
+			Help: "Whether this instance is the leader of the election (1 = leader)",
+		},
+		[]string{"election"},
+	)
+
+	// ShutdownDrainedTotal counts background work waited for at shutdown
+	// dropped work was still running at the deadline and was lost
+	ShutdownDrainedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "shutdown_drained_total",
+			Help: "Total number of background tasks flushed or dropped at shutdown",
+		},
+		[]string{"queue", "result"}, // result: flushed, dropped
+	)
+
+	// AppPhase is 1 for the phase the server is in (starting, ready, stopping)
+	AppPhase = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "app_phase",
+			Help: "Life cycle phase of the server (1 = current phase)",
+		},
+		[]string{"phase"},
+	)
+
+	// DependencyUp is the result of the latest dependency probe (1 = up)
+	DependencyUp = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dependency_up",
+			Help: "Whether the dependency answered the latest probe (1 = up)",
+		},
+		[]string{"dependency"},
+	)
+)
+
+// Resolution sources for RedirectLookupDuration
+// Cache hits are labeled with the cache backend ("redis", "memcached"),
+// SourceCache when none was named
+const (
+	SourceCache = "cache"
+	SourceDB    = "db"
 )
 
+// ObserveRedirectLookup records how long a redirect lookup took and where it was resolved
+func ObserveRedirectLookup(source string, duration time.Duration) {
+	RedirectLookupDuration.WithLabelValues(source).Observe(duration.Seconds())
+}
+
 // RecordCacheHit increments cache hit counter
 func RecordCacheHit() {
 	CacheHitsTotal.Inc()
@@ -168,12 +449,142 @@ func RecordClickRecorded() {
 	ClicksRecordedTotal.Inc()
 }
 
+// RecordClickDeduplicated increments the deduplicated click counter
+func RecordClickDeduplicated() {
+	ClicksDeduplicatedTotal.Inc()
+}
+
+// RecordClickConsentSuppressed increments the consent-suppressed click counter
+func RecordClickConsentSuppressed(signal string) {
+	ClicksConsentSuppressedTotal.WithLabelValues(signal).Inc()
+}
+
+// RecordConfusableAlias increments the confusable alias counter for action
+func RecordConfusableAlias(action string) {
+	ConfusableAliasesTotal.WithLabelValues(action).Inc()
+}
+
+// RecordLinkApproval increments the link approval counter for decision
+func RecordLinkApproval(decision string) {
+	LinkApprovalsTotal.WithLabelValues(decision).Inc()
+}
+
+// RecordAPIKeyExpiringAuthentication increments the expiring API key counter for reason
+func RecordAPIKeyExpiringAuthentication(reason string) {
+	APIKeyExpiringAuthenticationsTotal.WithLabelValues(reason).Inc()
+}
+
+// RecordKMSRequest increments the key management request counter
+func RecordKMSRequest(provider, operation, result string) {
+	KMSRequestsTotal.WithLabelValues(provider, operation, result).Inc()
+}
+
+// RecordErrorReport increments the error report counter
+func RecordErrorReport(level, result string) {
+	ErrorReportsTotal.WithLabelValues(level, result).Inc()
+}
+
 // RecordRateLimited increments rate-limited requests counter
 func RecordRateLimited() {
 	RateLimitedRequestsTotal.Inc()
 }
 
+// RecordAbuseAction increments the abuse guard counter for action
+func RecordAbuseAction(action string) {
+	AbuseActionsTotal.WithLabelValues(action).Inc()
+}
+
 // RecordRateLimitAllowed increments allowed requests counter
 func RecordRateLimitAllowed() {
 	RateLimitAllowedRequestsTotal.Inc()
 }
+
+// RecordDependencyRetry increments the retry counter for an operation
+func RecordDependencyRetry(operation string) {
+	DependencyRetriesTotal.WithLabelValues(operation).Inc()
+}
+
+// RecordRequestTimeout counts a request that hit its deadline
+func RecordRequestTimeout(route string) {
+	RequestTimeoutsTotal.WithLabelValues(route).Inc()
+}
+
+// RecordBudgetExhausted counts a call that ran out of its request's budget
+func RecordBudgetExhausted(dependency, route string) {
+	BudgetExhaustedTotal.WithLabelValues(dependency, route).Inc()
+}
+
+// SetCircuitBreakerState records a breaker state change
+func SetCircuitBreakerState(name string, state int) {
+	CircuitBreakerState.WithLabelValues(name).Set(float64(state))
+}
+
+// RecordCircuitBreakerRejection increments the rejection counter for a breaker
+func RecordCircuitBreakerRejection(name string) {
+	CircuitBreakerRejectionsTotal.WithLabelValues(name).Inc()
+}
+
+// RecordCacheStaleHit increments the stale cache hit counter
+func RecordCacheStaleHit() {
+	CacheStaleHitsTotal.Inc()
+}
+
+// RecordFeatureFlagEvaluation increments the evaluation counter of a flag
+func RecordFeatureFlagEvaluation(flag string, enabled bool) {
+	result := "off"
+	if enabled {
+		result = "on"
+	}
+	FeatureFlagEvaluationsTotal.WithLabelValues(flag, result).Inc()
+}
+
+// RecordJob counts a background job event (enqueued, succeeded, retried, failed)
+func RecordJob(kind, event string) {
+	JobsTotal.WithLabelValues(kind, event).Inc()
+}
+
+// RecordScheduledRun records the result of a scheduled task run
+// duration is 0 for runs that didn't start, and then isn't observed
+func RecordScheduledRun(task, result string, duration time.Duration) {
+	ScheduledRunsTotal.WithLabelValues(task, result).Inc()
+	if duration > 0 {
+		ScheduledRunDuration.WithLabelValues(task).Observe(duration.Seconds())
+	}
+	if result == "ok" {
+		ScheduledLastSuccess.WithLabelValues(task).SetToCurrentTime()
+	}
+}
+
+// SetDependencyUp records the result of a dependency probe
+func SetDependencyUp(dependency string, up bool) {
+	value := 0.0
+	if up {
+		value = 1
+	}
+	DependencyUp.WithLabelValues(dependency).Set(value)
+}
+
+// SetLeader records whether this instance leads an election
+func SetLeader(election string, leading bool) {
+	value := 0.0
+	if leading {
+		value = 1
+	}
+	LeaderElected.WithLabelValues(election).Set(value)
+}
+
+// RecordDrained counts background work flushed or dropped at shutdown
+func RecordDrained(queue, result string, n int) {
+	ShutdownDrainedTotal.WithLabelValues(queue, result).Add(float64(n))
+}
+
+// SetAppPhase marks current as the phase the server is in
+func SetAppPhase(current string, phases []string) {
+	for _, phase := range phases {
+		value := 0.0
+		if phase == current {
+			value = 1
+		}
+		AppPhase.WithLabelValues(phase).Set(value)
+	}
+}
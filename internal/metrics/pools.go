@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// Connection pool collectors
+//
+// WHY A COLLECTOR INSTEAD OF GAUGES WE UPDATE?
+// Both pools already keep their own statistics. A prometheus.Collector reads
+// them at SCRAPE TIME, so the numbers are always fresh and we don't need a
+// background goroutine copying values into gauges.
+//
+// What to watch:
+// - acquired_conns close to max_conns -> the pool is saturated
+// - empty_acquire / acquire wait time growing -> requests queue for a connection
+// - redis timeouts > 0 -> callers gave up waiting for a Redis connection
+
+// pgxPoolCollector exports pgxpool.Stat
+type pgxPoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	emptyAcquireWaitTime *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	newConnsCount        *prometheus.Desc
+	maxLifetimeDestroys  *prometheus.Desc
+	maxIdleDestroys      *prometheus.Desc
+}
+
+// NewPgxPoolCollector creates a collector for a PostgreSQL connection pool
+// Register it once: prometheus.MustRegister(metrics.NewPgxPoolCollector(db))
+func NewPgxPoolCollector(pool *pgxpool.Pool) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("pgxpool_"+name, help, nil, nil)
+	}
+
+	return &pgxPoolCollector{
+		pool:                 pool,
+		acquiredConns:        desc("acquired_conns", "Connections currently checked out of the pool"),
+		idleConns:            desc("idle_conns", "Idle connections in the pool"),
+		constructingConns:    desc("constructing_conns", "Connections currently being established"),
+		totalConns:           desc("total_conns", "Total connections in the pool (acquired + idle + constructing)"),
+		maxConns:             desc("max_conns", "Maximum size of the pool"),
+		acquireCount:         desc("acquires_total", "Successful connection acquires"),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Total time spent acquiring connections"),
+		emptyAcquireCount:    desc("empty_acquires_total", "Acquires that had to wait because the pool had no idle connection"),
+		emptyAcquireWaitTime: desc("empty_acquire_wait_seconds_total", "Total time spent waiting in acquires that found the pool empty"),
+		canceledAcquireCount: desc("canceled_acquires_total", "Acquires canceled by their context (usually timeouts)"),
+		newConnsCount:        desc("new_conns_total", "Connections opened"),
+		maxLifetimeDestroys:  desc("max_lifetime_destroys_total", "Connections closed because they reached their max lifetime"),
+		maxIdleDestroys:      desc("max_idle_destroys_total", "Connections closed because they were idle too long"),
+	}
+}
+
+// Describe sends the descriptors of every metric
+func (c *pgxPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		c.acquiredConns, c.idleConns, c.constructingConns, c.totalConns, c.maxConns,
+		c.acquireCount, c.acquireDuration, c.emptyAcquireCount, c.emptyAcquireWaitTime,
+		c.canceledAcquireCount, c.newConnsCount, c.maxLifetimeDestroys, c.maxIdleDestroys,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect reads the pool statistics (called on every scrape)
+func (c *pgxPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+
+	gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+	gauge(c.idleConns, float64(stat.IdleConns()))
+	gauge(c.constructingConns, float64(stat.ConstructingConns()))
+	gauge(c.totalConns, float64(stat.TotalConns()))
+	gauge(c.maxConns, float64(stat.MaxConns()))
+	counter(c.acquireCount, float64(stat.AcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(c.emptyAcquireCount, float64(stat.EmptyAcquireCount()))
+	counter(c.emptyAcquireWaitTime, stat.EmptyAcquireWaitTime().Seconds())
+	counter(c.canceledAcquireCount, float64(stat.CanceledAcquireCount()))
+	counter(c.newConnsCount, float64(stat.NewConnsCount()))
+	counter(c.maxLifetimeDestroys, float64(stat.MaxLifetimeDestroyCount()))
+	counter(c.maxIdleDestroys, float64(stat.MaxIdleDestroyCount()))
+}
+
+// redisPoolCollector exports go-redis PoolStats
+type redisPoolCollector struct {
+	client *redis.Client
+
+	hits         *prometheus.Desc
+	misses       *prometheus.Desc
+	timeouts     *prometheus.Desc
+	waits        *prometheus.Desc
+	waitDuration *prometheus.Desc
+	totalConns   *prometheus.Desc
+	idleConns    *prometheus.Desc
+	staleConns   *prometheus.Desc
+}
+
+// NewRedisPoolCollector creates a collector for a Redis connection pool
+// Register it once: prometheus.MustRegister(metrics.NewRedisPoolCollector(client))
+func NewRedisPoolCollector(client *redis.Client) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("redis_pool_"+name, help, nil, nil)
+	}
+
+	return &redisPoolCollector{
+		client:       client,
+		hits:         desc("hits_total", "Times a free connection was found in the pool"),
+		misses:       desc("misses_total", "Times no free connection was found and a new one was needed"),
+		timeouts:     desc("timeouts_total", "Times waiting for a connection timed out"),
+		waits:        desc("waits_total", "Times a caller had to wait for a connection"),
+		waitDuration: desc("wait_duration_seconds_total", "Total time spent waiting for connections"),
+		totalConns:   desc("total_conns", "Total connections in the pool"),
+		idleConns:    desc("idle_conns", "Idle connections in the pool"),
+		staleConns:   desc("stale_conns_total", "Stale connections removed from the pool"),
+	}
+}
+
+// Describe sends the descriptors of every metric
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		c.hits, c.misses, c.timeouts, c.waits, c.waitDuration, c.totalConns, c.idleConns, c.staleConns,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect reads the pool statistics (called on every scrape)
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+
+	counter(c.hits, float64(stats.Hits))
+	counter(c.misses, float64(stats.Misses))
+	counter(c.timeouts, float64(stats.Timeouts))
+	counter(c.waits, float64(stats.WaitCount))
+	counter(c.waitDuration, float64(stats.WaitDurationNs)/1e9)
+	gauge(c.totalConns, float64(stats.TotalConns))
+	gauge(c.idleConns, float64(stats.IdleConns))
+	counter(c.staleConns, float64(stats.StaleConns))
+}
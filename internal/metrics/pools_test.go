@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Neither pool connects until it's used, so no server is needed
+func newTestPools(t *testing.T) (*pgxpool.Pool, *redis.Client) {
+	t.Helper()
+	pool, err := pgxpool.New(context.Background(), "postgres://test@127.0.0.1:1/test?pool_max_conns=7")
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	t.Cleanup(func() { client.Close() })
+	return pool, client
+}
+
+func TestPoolCollectors(t *testing.T) {
+	pool, client := newTestPools(t)
+
+	tests := []struct {
+		name      string
+		collector prometheus.Collector
+		expected  int
+	}{
+		{name: "pgxpool", collector: NewPgxPoolCollector(pool), expected: 13},
+		{name: "redis", collector: NewRedisPoolCollector(client), expected: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: the pedantic registry fails Gather when Collect sends
+			// a metric Describe didn't announce
+			registry := prometheus.NewPedanticRegistry()
+			require.NoError(t, registry.Register(tt.collector))
+
+			// Act
+			count := testutil.CollectAndCount(tt.collector)
+			problems, err := testutil.GatherAndLint(registry)
+
+			// Assert
+			assert.Equal(t, tt.expected, count)
+			require.NoError(t, err)
+			assert.Empty(t, problems)
+
+			described := make(chan *prometheus.Desc, tt.expected+1)
+			tt.collector.Describe(described)
+			close(described)
+			assert.Len(t, described, tt.expected, "Describe and Collect disagree")
+		})
+	}
+}
+
+func TestPgxPoolCollector_ReadsPoolStat(t *testing.T) {
+	// Arrange
+	pool, _ := newTestPools(t)
+
+	// Act & Assert
+	expected := `
+# HELP pgxpool_max_conns Maximum size of the pool
+# TYPE pgxpool_max_conns gauge
+pgxpool_max_conns 7
+`
+	assert.NoError(t, testutil.CollectAndCompare(NewPgxPoolCollector(pool), strings.NewReader(expected), "pgxpool_max_conns"))
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// WithRegion adds a region="<name>" label to every metric g gathers
+//
+// WHY AT SCRAPE TIME?
+// The metrics above are package variables created before the configuration
+// is loaded, so the region can't be one of their labels. Adding it while
+// gathering labels EVERY metric - ours, the pool collectors and the Go
+// runtime ones - without touching the code that records them. Dashboards
+// can then compare regions, or sum over the label for the global picture.
+//
+// Metrics that already carry a region label keep theirs.
+func WithRegion(g prometheus.Gatherer, name string) prometheus.Gatherer {
+	label, value := "region", name
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := g.Gather()
+		for _, family := range families {
+			for _, metric := range family.Metric {
+				if slices.ContainsFunc(metric.Label, func(l *dto.LabelPair) bool { return l.GetName() == label }) {
+					continue
+				}
+				metric.Label = append(metric.Label, &dto.LabelPair{Name: &label, Value: &value})
+				slices.SortFunc(metric.Label, func(a, b *dto.LabelPair) int { return cmp.Compare(a.GetName(), b.GetName()) })
+			}
+		}
+		return families, err
+	})
+}
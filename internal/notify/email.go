@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// EmailNotifier sends events as plain-text emails over SMTP
+type EmailNotifier struct {
+	addr string // SMTP server in host:port format
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewEmailNotifier creates an email notifier
+// username may be empty for SMTP servers that don't require authentication
+func NewEmailNotifier(host, port, username, password, from string, to []string) *EmailNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &EmailNotifier{
+		addr: host + ":" + port,
+		auth: auth,
+		from: from,
+		to:   to,
+	}
+}
+
+// Notify sends the event by email
+// net/smtp doesn't support contexts, so cancellation is only checked up front
+func (n *EmailNotifier) Notify(ctx context.Context, event Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := smtp.SendMail(n.addr, n.auth, n.from, n.to, n.message(event)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// message builds an RFC 822 email for the event
+func (n *EmailNotifier) message(event Event) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&b, "Subject: [URL Shortener] %s: %s\r\n", event.Type, event.ShortCode)
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	fmt.Fprintf(&b, "Event: %s\r\n", event.Type)
+	fmt.Fprintf(&b, "Short code: %s\r\n", event.ShortCode)
+	fmt.Fprintf(&b, "Owner: %s\r\n", event.Owner)
+	fmt.Fprintf(&b, "Time: %s\r\n", event.OccurredAt.Format("2006-01-02 15:04:05 MST"))
+	for key, value := range event.Data {
+		fmt.Fprintf(&b, "%s: %v\r\n", key, value)
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Event is a notification about something that happened to a URL
+// The same event is delivered to every configured channel (webhook, email, ...)
+type Event struct {
+	Type       string                 `json:"type"`        // e.g. "click_limit.near"
+	URLID      string                 `json:"url_id"`      // URL the event is about
+	ShortCode  string                 `json:"short_code"`  // Short code of the URL
+	Owner      string                 `json:"owner"`       // Who created the URL
+	OccurredAt time.Time              `json:"occurred_at"` // When the event happened
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// Notifier delivers events to the outside world
+// Implementations must be safe for concurrent use
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Multi fans an event out to several notifiers
+// Every notifier is tried even if an earlier one fails
+type Multi []Notifier
+
+// Notify delivers the event to all notifiers and joins their errors
+func (m Multi) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Nop discards every event
+// Used when no notification channel is configured
+type Nop struct{}
+
+// Notify does nothing
+func (Nop) Notify(ctx context.Context, event Event) error {
+	return nil
+}
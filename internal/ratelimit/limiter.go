@@ -25,6 +25,7 @@ type RateLimiter struct {
 	maxRequests int           // Maximum requests allowed
 	window      time.Duration // Time window (e.g., 1 minute)
 	burstSize   int           // Maximum burst size
+	namespace   string        // Separates limiters that share Redis (e.g. "alias-check")
 }
 
 // NewTokenBucketLimiter creates a new rate limiter
@@ -39,11 +40,27 @@ func NewTokenBucketLimiter(client *redis.Client, maxRequests int, window time.Du
 	}
 }
 
+// WithNamespace gives the limiter its own set of counters
+// WHY? Two limiters keyed by the same IP would otherwise share one bucket,
+// so a stricter per-endpoint limit would also eat into the global one
+func (rl *RateLimiter) WithNamespace(namespace string) *RateLimiter {
+	rl.namespace = namespace
+	return rl
+}
+
+// redisKey builds the Redis key for an identifier
+func (rl *RateLimiter) redisKey(key string) string {
+	if rl.namespace != "" {
+		return fmt.Sprintf("ratelimit:%s:%s", rl.namespace, key)
+	}
+	return fmt.Sprintf("ratelimit:%s", key)
+}
+
 // Allow checks if a request should be allowed
 // Returns (allowed bool, remaining int, resetTime time.Time, error)
 func (rl *RateLimiter) Allow(ctx context.Context, key string) (bool, int, time.Time, error) {
 	// Redis key for this identifier
-	redisKey := fmt.Sprintf("ratelimit:%s", key)
+	redisKey := rl.redisKey(key)
 
 	// Use Lua script for atomic operation
 	// This ensures no race conditions when multiple requests arrive simultaneously
@@ -109,13 +126,13 @@ func (rl *RateLimiter) Allow(ctx context.Context, key string) (bool, int, time.T
 // Reset clears the rate limit for a key
 // Useful for testing or manual overrides
 func (rl *RateLimiter) Reset(ctx context.Context, key string) error {
-	redisKey := fmt.Sprintf("ratelimit:%s", key)
+	redisKey := rl.redisKey(key)
 	return rl.client.Del(ctx, redisKey).Err()
 }
 
 // GetInfo returns current rate limit info for a key
 func (rl *RateLimiter) GetInfo(ctx context.Context, key string) (int, time.Duration, error) {
-	redisKey := fmt.Sprintf("ratelimit:%s", key)
+	redisKey := rl.redisKey(key)
 
 	// Get current count
 	count, err := rl.client.Get(ctx, redisKey).Int()
@@ -0,0 +1,75 @@
+// Package referrer classifies click referrers into marketing channels
+// (search, social, email, direct, internal or referral).
+package referrer
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"url-shortener/internal/domain"
+)
+
+// Classifier maps Referer headers to channels
+// Hosts of our own site count as internal; everything else goes through
+// the rules table (see rules.go)
+type Classifier struct {
+	internal map[string]bool
+}
+
+// NewClassifier creates a classifier that treats internalHosts as our own
+func NewClassifier(internalHosts ...string) *Classifier {
+	internal := make(map[string]bool, len(internalHosts))
+	for _, host := range internalHosts {
+		if host = strings.TrimSpace(host); host != "" {
+			internal[strings.ToLower(host)] = true
+		}
+	}
+	return &Classifier{internal: internal}
+}
+
+// Classify returns the channel of a click with the given Referer header
+//
+// An empty or unreadable referrer is "direct": browsers leave it out for
+// typed URLs, bookmarks, apps and strict referrer policies alike, so those
+// can't be told apart.
+func (c *Classifier) Classify(referer string) domain.Channel {
+	host := refererHost(referer)
+	if host == "" {
+		return domain.ChannelDirect
+	}
+	if c.internal[host] {
+		return domain.ChannelInternal
+	}
+
+	for _, r := range rules {
+		for _, pattern := range r.hosts {
+			if matchHost(host, pattern) {
+				return r.channel
+			}
+		}
+	}
+	return domain.ChannelReferral
+}
+
+// refererHost returns the lowercase host of a referrer, without port
+func refererHost(referer string) string {
+	parsed, err := url.Parse(strings.TrimSpace(referer))
+	if err != nil {
+		return ""
+	}
+	host := parsed.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// matchHost reports whether host matches a rules table pattern
+func matchHost(host, pattern string) bool {
+	if strings.HasSuffix(pattern, ".") {
+		// Brand pattern: "google." at the start of the host or after a dot
+		return strings.HasPrefix(host, pattern) || strings.Contains(host, "."+pattern)
+	}
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
@@ -0,0 +1,55 @@
+package referrer
+
+import (
+	"testing"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	classifier := NewClassifier("sho.rt", "Go.Acme.com")
+
+	tests := []struct {
+		referer  string
+		expected domain.Channel
+	}{
+		// Direct
+		{referer: "", expected: domain.ChannelDirect},
+		{referer: "not a url", expected: domain.ChannelDirect},
+
+		// Internal
+		{referer: "https://sho.rt/@jane", expected: domain.ChannelInternal},
+		{referer: "https://go.acme.com:443/promo", expected: domain.ChannelInternal},
+
+		// Search, including country domains of a brand
+		{referer: "https://www.google.com/", expected: domain.ChannelSearch},
+		{referer: "https://www.google.co.uk/search?q=shoes", expected: domain.ChannelSearch},
+		{referer: "https://duckduckgo.com/", expected: domain.ChannelSearch},
+		{referer: "android-app://com.google.android.googlequicksearchbox", expected: domain.ChannelSearch},
+
+		// Email wins over the search brand it lives on
+		{referer: "https://mail.google.com/mail/u/0/", expected: domain.ChannelEmail},
+		{referer: "https://mail.yahoo.com/", expected: domain.ChannelEmail},
+		{referer: "https://outlook.live.com/mail/", expected: domain.ChannelEmail},
+		{referer: "android-app://com.google.android.gm", expected: domain.ChannelEmail},
+
+		// Social
+		{referer: "https://t.co/AbCdEf", expected: domain.ChannelSocial},
+		{referer: "https://l.facebook.com/l.php?u=x", expected: domain.ChannelSocial},
+		{referer: "https://www.linkedin.com/feed/", expected: domain.ChannelSocial},
+		{referer: "https://old.reddit.com/r/golang", expected: domain.ChannelSocial},
+
+		// Everything else
+		{referer: "https://blog.example.com/post", expected: domain.ChannelReferral},
+		{referer: "https://notgoogle.com/", expected: domain.ChannelReferral},
+		{referer: "https://at.co/", expected: domain.ChannelReferral},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.referer, func(t *testing.T) {
+			assert.Equal(t, tt.expected, classifier.Classify(tt.referer))
+		})
+	}
+}
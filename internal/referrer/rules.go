@@ -0,0 +1,94 @@
+package referrer
+
+import "url-shortener/internal/domain"
+
+// rule assigns a channel to referrer hosts
+//
+// HOW HOSTS MATCH:
+//   - "bing.com"  matches bing.com and every subdomain (www.bing.com)
+//   - "google."   matches the brand under ANY suffix (google.de, www.google.co.uk)
+//
+// Rules are checked IN ORDER and the first match wins, so specific hosts
+// (mail.google.com) must come before their brand (google.).
+type rule struct {
+	channel domain.Channel
+	hosts   []string
+}
+
+// rules is the maintained table of known referrers
+// Keep it sorted by channel: email first (webmail lives on search and
+// portal domains), then social, then search.
+//
+// Android apps send "android-app://<package>" as the referrer, so some
+// entries are package names.
+var rules = []rule{
+	{
+		channel: domain.ChannelEmail,
+		hosts: []string{
+			"mail.google.com",
+			"com.google.android.gm",
+			"mail.yahoo.",
+			"outlook.live.com",
+			"outlook.office.com",
+			"outlook.office365.com",
+			"com.microsoft.office.outlook",
+			"mail.proton.me",
+			"mail.protonmail.com",
+			"mail.aol.com",
+			"mail.yandex.",
+			"mail.zoho.com",
+			"app.fastmail.com",
+			"webmail.",
+		},
+	},
+	{
+		channel: domain.ChannelSocial,
+		hosts: []string{
+			"facebook.com",
+			"fb.com",
+			"com.facebook.katana",
+			"instagram.com",
+			"t.co",
+			"twitter.com",
+			"x.com",
+			"com.twitter.android",
+			"linkedin.com",
+			"lnkd.in",
+			"com.linkedin.android",
+			"reddit.com",
+			"pinterest.",
+			"tiktok.com",
+			"youtube.com",
+			"threads.net",
+			"bsky.app",
+			"mastodon.social",
+			"news.ycombinator.com",
+			"slack.com",
+			"discord.com",
+			"t.me",
+			"whatsapp.com",
+			"vk.com",
+			"weibo.com",
+			"quora.com",
+			"tumblr.com",
+		},
+	},
+	{
+		channel: domain.ChannelSearch,
+		hosts: []string{
+			"google.",
+			"com.google.android.googlequicksearchbox",
+			"bing.com",
+			"duckduckgo.com",
+			"search.brave.com",
+			"yahoo.",
+			"yandex.",
+			"baidu.com",
+			"ecosia.org",
+			"startpage.com",
+			"qwant.com",
+			"naver.com",
+			"seznam.cz",
+		},
+	},
+}
@@ -0,0 +1,33 @@
+// Package region lets the service run in several regions at once
+//
+// HOW A MULTI-REGION DEPLOYMENT WORKS:
+// One region is the PRIMARY: it owns the writable PostgreSQL database. Every
+// other region runs the same binary next to a local READ REPLICA and its own
+// Redis, so redirects - the hot path - are answered without leaving the
+// region:
+//
+//	visitor -> eu-west: cache -> local replica        (redirect, fast)
+//	client  -> eu-west: POST /api/v1/urls -> us-east   (write, forwarded)
+//
+// API writes (creating, editing, deleting links...) are forwarded over HTTP
+// to the primary region, which runs them exactly like its own requests.
+// The few writes the redirect path needs (click counters and click events)
+// go straight to the primary database.
+//
+// REPLICATION LAG:
+// A link created a moment ago may not have reached the local replica yet.
+// Lookups that miss locally ask the primary database before giving up, so a
+// fresh link never answers 404 in another region.
+package region
+
+// Config describes where this instance runs
+type Config struct {
+	Name    string // This region (e.g. "eu-west"; "" = single-region deployment)
+	Primary string // The region that owns the writable database
+}
+
+// IsPrimary reports whether this instance runs in the primary region
+// A deployment without regions is its own primary
+func (c Config) IsPrimary() bool {
+	return c.Name == "" || c.Primary == "" || c.Name == c.Primary
+}
@@ -0,0 +1,132 @@
+package region
+
+import (
+	"context"
+	"testing"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockURLRepository is a mock implementation of URLRepository
+// Only the methods the tests route are mocked; the rest are never called
+type MockURLRepository struct {
+	mock.Mock
+	repository.URLRepository
+}
+
+func (m *MockURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*domain.URL, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) IncrementClicks(ctx context.Context, shortCode string) error {
+	args := m.Called(ctx, shortCode)
+	return args.Error(0)
+}
+
+func (m *MockURLRepository) ExistsCustomAlias(ctx context.Context, alias string) (bool, error) {
+	args := m.Called(ctx, alias)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestConfig_IsPrimary(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected bool
+	}{
+		{name: "single region", config: Config{}, expected: true},
+		{name: "primary region", config: Config{Name: "us-east", Primary: "us-east"}, expected: true},
+		{name: "no primary configured", config: Config{Name: "us-east"}, expected: true},
+		{name: "secondary region", config: Config{Name: "eu-west", Primary: "us-east"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.config.IsPrimary())
+		})
+	}
+}
+
+func TestURLRepository_ReadsLocalFirst(t *testing.T) {
+	tests := []struct {
+		name          string
+		localErr      error
+		expectPrimary bool
+	}{
+		{name: "found on the replica", expectPrimary: false},
+		{name: "not replicated yet", localErr: domain.ErrURLNotFound, expectPrimary: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			local, primary := new(MockURLRepository), new(MockURLRepository)
+			url := &domain.URL{ID: "1", ShortCode: "abc123"}
+			if tt.localErr != nil {
+				local.On("GetByShortCode", ctx, "abc123").Return(nil, tt.localErr)
+				primary.On("GetByShortCode", ctx, "abc123").Return(url, nil)
+			} else {
+				local.On("GetByShortCode", ctx, "abc123").Return(url, nil)
+			}
+			repo := NewURLRepository(local, primary)
+
+			// Act
+			got, err := repo.GetByShortCode(ctx, "abc123")
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, url, got)
+			local.AssertExpectations(t)
+			primary.AssertExpectations(t)
+			if !tt.expectPrimary {
+				primary.AssertNotCalled(t, "GetByShortCode", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestURLRepository_LocalFailureIsNotRetriedOnPrimary(t *testing.T) {
+	// Arrange: a broken replica is an outage to report, not a missing link
+	ctx := context.Background()
+	local, primary := new(MockURLRepository), new(MockURLRepository)
+	local.On("GetByShortCode", ctx, "abc123").Return(nil, assert.AnError)
+	repo := NewURLRepository(local, primary)
+
+	// Act
+	_, err := repo.GetByShortCode(ctx, "abc123")
+
+	// Assert
+	assert.ErrorIs(t, err, assert.AnError)
+	primary.AssertNotCalled(t, "GetByShortCode", mock.Anything, mock.Anything)
+}
+
+func TestURLRepository_WritesGoToPrimary(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	local, primary := new(MockURLRepository), new(MockURLRepository)
+	primary.On("IncrementClicks", ctx, "abc123").Return(nil)
+	primary.On("ExistsCustomAlias", ctx, "sale").Return(true, nil)
+	repo := NewURLRepository(local, primary)
+
+	// Act
+	incrementErr := repo.IncrementClicks(ctx, "abc123")
+	taken, existsErr := repo.ExistsCustomAlias(ctx, "sale")
+
+	// Assert
+	require.NoError(t, incrementErr)
+	require.NoError(t, existsErr)
+	assert.True(t, taken)
+	primary.AssertExpectations(t)
+	local.AssertNotCalled(t, "IncrementClicks", mock.Anything, mock.Anything)
+	local.AssertNotCalled(t, "ExistsCustomAlias", mock.Anything, mock.Anything)
+}
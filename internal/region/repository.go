@@ -0,0 +1,145 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// URLRepository reads from the local replica and writes to the primary database
+type URLRepository struct {
+	local   repository.URLRepository // Read replica in this region
+	primary repository.URLRepository // Writable database in the primary region
+}
+
+// NewURLRepository routes between the local replica and the primary database
+func NewURLRepository(local, primary repository.URLRepository) *URLRepository {
+	return &URLRepository{local: local, primary: primary}
+}
+
+// ==================== READS: local first ====================
+
+func (r *URLRepository) GetByShortCode(ctx context.Context, shortCode string) (*domain.URL, error) {
+	return readLocal(r.local.GetByShortCode, r.primary.GetByShortCode)(ctx, shortCode)
+}
+
+func (r *URLRepository) GetByID(ctx context.Context, id string) (*domain.URL, error) {
+	return readLocal(r.local.GetByID, r.primary.GetByID)(ctx, id)
+}
+
+func (r *URLRepository) GetByCustomAlias(ctx context.Context, alias string) (*domain.URL, error) {
+	return readLocal(r.local.GetByCustomAlias, r.primary.GetByCustomAlias)(ctx, alias)
+}
+
+// readLocal asks the replica, then the primary if the replica doesn't know
+// the link (yet) - a link created a moment ago may still be replicating
+// Archived links are rehydrated by the primary for the same reason: the
+// replica can't move rows
+func readLocal(local, primary func(context.Context, string) (*domain.URL, error)) func(context.Context, string) (*domain.URL, error) {
+	return func(ctx context.Context, key string) (*domain.URL, error) {
+		url, err := local(ctx, key)
+		if errors.Is(err, domain.ErrURLNotFound) {
+			return primary(ctx, key)
+		}
+		return url, err
+	}
+}
+
+// ==================== WRITES: primary only ====================
+
+func (r *URLRepository) Create(ctx context.Context, url *domain.URL) error {
+	return r.primary.Create(ctx, url)
+}
+
+func (r *URLRepository) Update(ctx context.Context, url *domain.URL) error {
+	return r.primary.Update(ctx, url)
+}
+
+func (r *URLRepository) SetMetadata(ctx context.Context, id string, meta *domain.LinkMetadata) error {
+	return r.primary.SetMetadata(ctx, id, meta)
+}
+
+func (r *URLRepository) Delete(ctx context.Context, id string) error {
+	return r.primary.Delete(ctx, id)
+}
+
+func (r *URLRepository) Restore(ctx context.Context, id string) error {
+	return r.primary.Restore(ctx, id)
+}
+
+func (r *URLRepository) Purge(ctx context.Context, id string) (int64, error) {
+	return r.primary.Purge(ctx, id)
+}
+
+func (r *URLRepository) IncrementClicks(ctx context.Context, shortCode string) error {
+	return r.primary.IncrementClicks(ctx, shortCode)
+}
+
+// MarkUsed must win or lose on the primary: a replica would let a link be
+// used once per region
+func (r *URLRepository) MarkUsed(ctx context.Context, shortCode string) (bool, error) {
+	return r.primary.MarkUsed(ctx, shortCode)
+}
+
+// Burn too: a secret must be revealed once, not once per region
+func (r *URLRepository) Burn(ctx context.Context, shortCode string) (*string, error) {
+	return r.primary.Burn(ctx, shortCode)
+}
+
+// Uniqueness checks come right before a write, so a lagging replica must not
+// answer them: a code it hasn't seen yet would look free
+
+func (r *URLRepository) ExistsShortCode(ctx context.Context, shortCode string) (bool, error) {
+	return r.primary.ExistsShortCode(ctx, shortCode)
+}
+
+func (r *URLRepository) ExistsCustomAlias(ctx context.Context, alias string) (bool, error) {
+	return r.primary.ExistsCustomAlias(ctx, alias)
+}
+
+func (r *URLRepository) FindTakenCodes(ctx context.Context, codes []string) (map[string]bool, error) {
+	return r.primary.FindTakenCodes(ctx, codes)
+}
+
+// ClickRepository records clicks in the primary database and reads
+// analytics from the local replica
+type ClickRepository struct {
+	local   repository.ClickRepository
+	primary repository.ClickRepository
+}
+
+// NewClickRepository routes between the local replica and the primary database
+func NewClickRepository(local, primary repository.ClickRepository) *ClickRepository {
+	return &ClickRepository{local: local, primary: primary}
+}
+
+func (r *ClickRepository) Create(ctx context.Context, click *domain.URLClick) error {
+	return r.primary.Create(ctx, click)
+}
+
+func (r *ClickRepository) GetByURLID(ctx context.Context, urlID string, limit, offset int) ([]*domain.URLClick, error) {
+	return r.local.GetByURLID(ctx, urlID, limit, offset)
+}
+
+func (r *ClickRepository) GetClickCount(ctx context.Context, urlID string) (int64, error) {
+	return r.local.GetClickCount(ctx, urlID)
+}
+
+func (r *ClickRepository) CountBy(ctx context.Context, urlID string, dimension domain.ClickDimension) (map[string]int64, error) {
+	return r.local.CountBy(ctx, urlID, dimension)
+}
+
+func (r *ClickRepository) CountByPeriod(ctx context.Context, urlID string, interval domain.Interval, location *time.Location, from, to time.Time) ([]domain.ClickBucket, error) {
+	return r.local.CountByPeriod(ctx, urlID, interval, location, from, to)
+}
+
+func (r *ClickRepository) CountByHourOfWeek(ctx context.Context, urlID string, location *time.Location, from, to time.Time) ([]domain.HourOfWeek, error) {
+	return r.local.CountByHourOfWeek(ctx, urlID, location, from, to)
+}
+
+func (r *ClickRepository) TopLinks(ctx context.Context, owner string, since time.Time, limit int) ([]domain.TopLink, error) {
+	return r.local.TopLinks(ctx, owner, since, limit)
+}
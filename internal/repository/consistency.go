@@ -0,0 +1,42 @@
+package repository
+
+import "context"
+
+// consistentReadKey marks a context whose reads must come from the database
+type consistentReadKey struct{}
+
+// WithConsistentRead asks repository decorators that serve reads from a copy
+// (the URL cache) to skip it and read the source of truth
+//
+// WHY?
+// A cached link is good enough for a redirect, but not for everything:
+// stats show the click counter (which the cached copy doesn't follow), and a
+// conditional update must compare against the version that is stored right
+// now. Callers that need the latest row say so on the context, and the
+// URLRepository interface stays the same for every implementation.
+func WithConsistentRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, consistentReadKey{}, true)
+}
+
+// IsConsistentRead reports whether ctx was marked with WithConsistentRead
+func IsConsistentRead(ctx context.Context) bool {
+	consistent, _ := ctx.Value(consistentReadKey{}).(bool)
+	return consistent
+}
+
+// redirectLookupKey marks a context whose lookups serve a redirect
+type redirectLookupKey struct{}
+
+// WithRedirectLookup marks the lookup of a redirect
+// The URL cache times these lookups (redirect_lookup_duration_seconds);
+// lookups for stats, updates or imports go through the same cache but
+// aren't redirects, so they aren't timed
+func WithRedirectLookup(ctx context.Context) context.Context {
+	return context.WithValue(ctx, redirectLookupKey{}, true)
+}
+
+// IsRedirectLookup reports whether ctx was marked with WithRedirectLookup
+func IsRedirectLookup(ctx context.Context) bool {
+	redirect, _ := ctx.Value(redirectLookupKey{}).(bool)
+	return redirect
+}
@@ -0,0 +1,254 @@
+// Package memcached is a Memcached implementation of the URL cache
+//
+// It stores exactly the same entries as the Redis cache (see internal/cache),
+// so switching CACHE_DRIVER only costs a cold cache, never a broken one.
+package memcached
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/domain"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// maxRelativeExpiration is the longest expiration Memcached accepts in seconds
+// Anything larger is read as an absolute Unix timestamp (see expiration)
+const maxRelativeExpiration = 30 * 24 * time.Hour
+
+// maxKeyLength is the longest key Memcached accepts, in bytes
+const maxKeyLength = 250
+
+// Client is the part of *memcache.Client the cache uses
+// Tests replace it with a fake: there is no in-process Memcached
+type Client interface {
+	Get(key string) (*memcache.Item, error)
+	Set(item *memcache.Item) error
+	Delete(key string) error
+	FlushAll() error
+	Ping() error
+}
+
+// Cache provides caching operations using Memcached
+// Same CACHE-ASIDE contract as the Redis cache: GetURL returns nil on a miss
+//
+// NOTE: the memcache client has no context support, so a canceled request
+// is only noticed before the call starts. The resilience.Cache decorator in
+// front of this cache still enforces REDIS_OP_TIMEOUT for the caller.
+type Cache struct {
+	client Client
+	ttl    time.Duration
+	codec  cache.Codec // Format used when writing entries (reads accept every format)
+
+	staleTTL    time.Duration      // Extra time an entry may be served while being refreshed (0 = disabled)
+	revalidator *cache.Revalidator // Refreshes stale entries (nil = disabled)
+}
+
+// Compile-time check: the backend implements the full cache contract
+var _ cache.Cache = (*Cache)(nil)
+
+// NewCache creates a new Memcached cache
+func NewCache(client Client, ttl time.Duration) *Cache {
+	return &Cache{
+		client: client,
+		ttl:    ttl,
+		codec:  cache.CodecJSON,
+	}
+}
+
+// WithCodec selects the format for new entries (see cache.Codec)
+func (c *Cache) WithCodec(codec cache.Codec) *Cache {
+	c.codec = codec
+	return c
+}
+
+// WithStaleWhileRevalidate keeps entries for staleTTL after they expire and
+// refreshes them in the background with refresh when they are read
+func (c *Cache) WithStaleWhileRevalidate(staleTTL time.Duration, refresh cache.RefreshFunc) *Cache {
+	c.staleTTL = staleTTL
+	c.revalidator = cache.NewRevalidator(refresh)
+	return c
+}
+
+// GetURL retrieves a URL from cache
+// Returns nil if not found (cache miss)
+func (c *Cache) GetURL(ctx context.Context, shortCode string) (*domain.URL, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	item, err := c.client.Get(key(shortCode))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		// Cache miss - not an error, just not found
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("memcached get error: %w", err)
+	}
+
+	entry, err := cache.Decode(item.Value)
+	if errors.Is(err, cache.ErrUnsupportedFormat) {
+		// Older or newer format - treat as a miss so it gets rewritten
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cached URL: %w", err)
+	}
+
+	// Stale: serve it anyway, refresh in the background
+	if c.revalidator != nil && time.Now().After(entry.FreshUntil) {
+		c.revalidator.Trigger(ctx, shortCode)
+	}
+
+	return entry.URL, nil
+}
+
+// SetURL stores a URL in cache
+func (c *Cache) SetURL(ctx context.Context, shortCode string, url *domain.URL) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := cache.Encode(c.codec, url, time.Now().Add(c.ttl))
+	if err != nil {
+		return fmt.Errorf("failed to encode URL: %w", err)
+	}
+
+	err = c.client.Set(&memcache.Item{
+		Key:        key(shortCode),
+		Value:      data,
+		Expiration: expiration(c.ttl + c.staleTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("memcached set error: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteURL removes a URL from cache
+// Used when URL is updated or deleted
+func (c *Cache) DeleteURL(ctx context.Context, shortCode string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := c.client.Delete(key(shortCode))
+	if err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+		return fmt.Errorf("memcached delete error: %w", err)
+	}
+
+	return nil
+}
+
+// Exists checks if a key exists in cache
+func (c *Cache) Exists(ctx context.Context, shortCode string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	_, err := c.client.Get(key(shortCode))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("memcached get error: %w", err)
+	}
+
+	return true, nil
+}
+
+// Clear removes all cached URLs
+//
+// WARNING: Memcached can't list keys, so this flushes EVERYTHING on the
+// configured servers - don't share them with other applications.
+func (c *Cache) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := c.client.FlushAll(); err != nil {
+		return fmt.Errorf("memcached flush error: %w", err)
+	}
+
+	return nil
+}
+
+// GetStats returns cache statistics
+// Memcached has no key count per prefix, so only reachability is reported;
+// hit and miss rates are on /metrics like for Redis
+func (c *Cache) GetStats(ctx context.Context) (map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := c.client.Ping(); err != nil {
+		return nil, fmt.Errorf("memcached ping error: %w", err)
+	}
+
+	return map[string]interface{}{
+		"driver":           "memcached",
+		"contract_version": cache.ContractVersion,
+	}, nil
+}
+
+// InitMemcached creates a new Memcached client
+func InitMemcached(servers []string) (*memcache.Client, error) {
+	client := memcache.New(servers...)
+	client.Timeout = 3 * time.Second // Timeout for socket reads and writes
+	client.MaxIdleConns = 10         // Idle connections kept per server
+
+	// Test connection
+	if err := client.Ping(); err != nil {
+		_ = client.Close() // Startup retries would leak idle connections per attempt
+		return nil, fmt.Errorf("failed to connect to Memcached: %w", err)
+	}
+
+	return client, nil
+}
+
+// key uses the same naming convention as Redis: "url:{shortCode}"
+//
+// Memcached keys are at most 250 bytes without spaces or control
+// characters. Redirects look up whatever path a visitor sends, so codes that
+// don't fit are hashed instead of failing the lookup ("url:sha256:{hex}").
+func key(shortCode string) string {
+	k := "url:" + shortCode
+	if len(k) <= maxKeyLength && legalKey(k) {
+		return k
+	}
+	sum := sha256.Sum256([]byte(shortCode))
+	return "url:sha256:" + hex.EncodeToString(sum[:])
+}
+
+// legalKey reports whether k has only the bytes Memcached allows in keys
+func legalKey(k string) bool {
+	for i := 0; i < len(k); i++ {
+		if k[i] <= ' ' || k[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// expiration converts a TTL to Memcached's expiration field
+//
+// GOTCHA: Memcached reads values up to 30 days as "seconds from now" and
+// anything larger as an absolute Unix timestamp. A 60-day TTL sent as
+// relative seconds would be a date in 1970, and the item would expire
+// immediately. Long TTLs are therefore sent as a timestamp.
+func expiration(ttl time.Duration) int32 {
+	if ttl <= 0 {
+		return 0 // Never expires
+	}
+	if ttl <= maxRelativeExpiration {
+		return int32((ttl + time.Second - 1) / time.Second) // Round up: 0 would mean "never"
+	}
+	return int32(time.Now().Add(ttl).Unix())
+}
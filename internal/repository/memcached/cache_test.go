@@ -0,0 +1,256 @@
+package memcached
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/domain"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient is an in-memory Client
+// Like the real client, it rejects keys Memcached would reject
+type fakeClient struct {
+	items map[string]*memcache.Item
+	err   error // Returned by every call when set (server down)
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{items: map[string]*memcache.Item{}}
+}
+
+func (f *fakeClient) Get(key string) (*memcache.Item, error) {
+	if err := f.check(key); err != nil {
+		return nil, err
+	}
+	item, ok := f.items[key]
+	if !ok {
+		return nil, memcache.ErrCacheMiss
+	}
+	return item, nil
+}
+
+func (f *fakeClient) Set(item *memcache.Item) error {
+	if err := f.check(item.Key); err != nil {
+		return err
+	}
+	f.items[item.Key] = item
+	return nil
+}
+
+func (f *fakeClient) Delete(key string) error {
+	if err := f.check(key); err != nil {
+		return err
+	}
+	if _, ok := f.items[key]; !ok {
+		return memcache.ErrCacheMiss
+	}
+	delete(f.items, key)
+	return nil
+}
+
+func (f *fakeClient) FlushAll() error {
+	if f.err != nil {
+		return f.err
+	}
+	f.items = map[string]*memcache.Item{}
+	return nil
+}
+
+func (f *fakeClient) Ping() error {
+	return f.err
+}
+
+func (f *fakeClient) check(key string) error {
+	if f.err != nil {
+		return f.err
+	}
+	if len(key) > maxKeyLength || !legalKey(key) {
+		return memcache.ErrMalformedKey
+	}
+	return nil
+}
+
+func TestExpiration(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		expected int32
+		absolute bool // A Unix timestamp around now + ttl
+	}{
+		{name: "zero never expires", ttl: 0, expected: 0},
+		{name: "negative never expires", ttl: -time.Minute, expected: 0},
+		{name: "seconds from now", ttl: time.Hour, expected: 3600},
+		{name: "sub-second rounds up, not to never", ttl: 300 * time.Millisecond, expected: 1},
+		{name: "exactly 30 days is still relative", ttl: 30 * 24 * time.Hour, expected: 30 * 24 * 3600},
+		{name: "just over 30 days is a timestamp", ttl: 30*24*time.Hour + time.Second, absolute: true},
+		{name: "60 days is a timestamp", ttl: 60 * 24 * time.Hour, absolute: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := expiration(tt.ttl)
+
+			// Assert
+			if !tt.absolute {
+				assert.Equal(t, tt.expected, got)
+				return
+			}
+			assert.InDelta(t, now.Add(tt.ttl).Unix(), int64(got), 5)
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	long := strings.Repeat("a", maxKeyLength)
+
+	tests := []struct {
+		name      string
+		shortCode string
+		expected  string // "" = hashed
+	}{
+		{name: "same convention as Redis", shortCode: "abc123", expected: "url:abc123"},
+		{name: "longest code that fits", shortCode: long[:maxKeyLength-len("url:")], expected: "url:" + long[:maxKeyLength-len("url:")]},
+		{name: "too long", shortCode: long},
+		{name: "space", shortCode: "a b"},
+		{name: "newline", shortCode: "abc\r\nflush_all"},
+		{name: "control character", shortCode: "abc\x7f"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := key(tt.shortCode)
+
+			// Assert
+			assert.LessOrEqual(t, len(got), maxKeyLength)
+			assert.True(t, legalKey(got), "illegal key %q", got)
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, got)
+			} else {
+				assert.True(t, strings.HasPrefix(got, "url:sha256:"), got)
+			}
+		})
+	}
+
+	// Hashing keeps different codes apart
+	assert.NotEqual(t, key(long), key(long+"b"))
+}
+
+func TestCache_SetAndGet(t *testing.T) {
+	for _, codec := range []cache.Codec{cache.CodecJSON, cache.CodecBinary} {
+		t.Run(string(codec), func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			client := newFakeClient()
+			c := NewCache(client, time.Hour).WithCodec(codec)
+			url := &domain.URL{ID: "1", ShortCode: "abc123", OriginalURL: "https://example.com", IsActive: true}
+
+			// Act
+			require.NoError(t, c.SetURL(ctx, "abc123", url))
+			got, err := c.GetURL(ctx, "abc123")
+
+			// Assert
+			require.NoError(t, err)
+			require.NotNil(t, got)
+			assert.Equal(t, "https://example.com", got.OriginalURL)
+			assert.Equal(t, int32(3600), client.items["url:abc123"].Expiration)
+		})
+	}
+}
+
+func TestCache_GetURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		shortCode string
+		stored    []byte // nil = nothing stored
+		clientErr error
+		wantErr   bool
+	}{
+		{name: "miss", shortCode: "abc123"},
+		{name: "unknown format is a miss", shortCode: "abc123", stored: []byte("\x00garbage")},
+		{name: "plain URL JSON from an older release is a miss", shortCode: "abc123", stored: []byte(`{"short_code":"abc123"}`)},
+		{name: "code that isn't a legal key is a miss, not an error", shortCode: strings.Repeat("x", 300)},
+		{name: "corrupt JSON is an error", shortCode: "abc123", stored: []byte(`{"url":`), wantErr: true},
+		{name: "server down is an error", shortCode: "abc123", clientErr: errors.New("connection refused"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			client := newFakeClient()
+			if tt.stored != nil {
+				client.items[key(tt.shortCode)] = &memcache.Item{Key: key(tt.shortCode), Value: tt.stored}
+			}
+			client.err = tt.clientErr
+			c := NewCache(client, time.Hour)
+
+			// Act
+			got, err := c.GetURL(context.Background(), tt.shortCode)
+
+			// Assert
+			assert.Nil(t, got)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCache_DeleteURL(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	client := newFakeClient()
+	c := NewCache(client, time.Hour)
+	require.NoError(t, c.SetURL(ctx, "abc123", &domain.URL{ShortCode: "abc123", OriginalURL: "https://example.com"}))
+
+	// Act
+	err := c.DeleteURL(ctx, "abc123")
+	missing := c.DeleteURL(ctx, "abc123") // Already gone: not an error
+
+	// Assert
+	require.NoError(t, err)
+	require.NoError(t, missing)
+	exists, err := c.Exists(ctx, "abc123")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestCache_StaleTTLExtendsExpiration(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	client := newFakeClient()
+	c := NewCache(client, time.Hour).
+		WithStaleWhileRevalidate(time.Hour, func(ctx context.Context, shortCode string) error { return nil })
+
+	// Act
+	require.NoError(t, c.SetURL(ctx, "abc123", &domain.URL{ShortCode: "abc123", OriginalURL: "https://example.com"}))
+
+	// Assert: kept for the stale window too
+	assert.Equal(t, int32(7200), client.items["url:abc123"].Expiration)
+}
+
+func TestCache_CanceledContext(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := NewCache(newFakeClient(), time.Hour)
+
+	// Act
+	_, err := c.GetURL(ctx, "abc123")
+
+	// Assert
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"url-shortener/internal/leader"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// advisoryLocker is the PostgreSQL implementation of leader.Locker, used for
+// leader election and by the scheduler
+//
+// ADVISORY LOCKS:
+// pg_try_advisory_lock takes a lock on a number instead of a row. It
+// belongs to the database SESSION, not a transaction: it is held until it is
+// unlocked or the connection closes. So the locker keeps one connection out
+// of the pool for as long as it holds a lock. If the server dies, its
+// connection closes and PostgreSQL releases the locks for the next replica.
+type advisoryLocker struct {
+	db *pgxpool.Pool
+
+	mu   sync.Mutex
+	conn *pgxpool.Conn   // Session holding the locks (nil while none is held)
+	held map[string]bool // Lock names held on conn
+}
+
+// NewAdvisoryLocker creates a locker on top of PostgreSQL advisory locks
+// Each locker holds its locks on its own connection
+func NewAdvisoryLocker(db *pgxpool.Pool) leader.Locker {
+	return &advisoryLocker{db: db, held: make(map[string]bool)}
+}
+
+// TryLock takes the lock of name unless another session holds it
+func (l *advisoryLocker) TryLock(ctx context.Context, name string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// A lost connection took its locks with it: start over
+	if l.conn != nil {
+		if err := l.conn.Ping(ctx); err != nil {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			l.dropConn()
+		}
+	}
+
+	if l.held[name] {
+		return true, nil
+	}
+
+	if l.conn == nil {
+		conn, err := l.db.Acquire(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to get a connection for advisory locks: %w", err)
+		}
+		l.conn = conn
+	}
+
+	var locked bool
+	if err := l.conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, advisoryKey(name)).Scan(&locked); err != nil {
+		l.releaseIfIdle()
+		return false, fmt.Errorf("failed to take advisory lock %s: %w", name, err)
+	}
+	if locked {
+		l.held[name] = true
+	}
+	l.releaseIfIdle()
+
+	return locked, nil
+}
+
+// Close releases every held lock and gives the connection back to the pool
+func (l *advisoryLocker) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+
+	_, err := l.conn.Exec(context.Background(), `SELECT pg_advisory_unlock_all()`)
+	if err != nil {
+		// Closing the session releases the locks too
+		l.dropConn()
+		return fmt.Errorf("failed to release advisory locks: %w", err)
+	}
+
+	l.held = make(map[string]bool)
+	l.conn.Release()
+	l.conn = nil
+	return nil
+}
+
+// releaseIfIdle gives the connection back when it holds no lock
+func (l *advisoryLocker) releaseIfIdle() {
+	if l.conn != nil && len(l.held) == 0 {
+		l.conn.Release()
+		l.conn = nil
+	}
+}
+
+// dropConn closes the session (and with it every lock) instead of giving it
+// back: a pooled connection must never carry someone's locks
+func (l *advisoryLocker) dropConn() {
+	_ = l.conn.Conn().Close(context.Background())
+	l.conn.Release()
+	l.conn = nil
+	l.held = make(map[string]bool)
+}
+
+// advisoryKey maps a lock name to the number advisory locks work with
+// The prefix keeps our numbers apart from other applications' on the same database
+func advisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("url-shortener:" + name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// anomalyRepository is the PostgreSQL implementation of repository.AnomalyRepository
+type anomalyRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewAnomalyRepository creates a new PostgreSQL anomaly repository
+func NewAnomalyRepository(db *pgxpool.Pool) repository.AnomalyRepository {
+	return &anomalyRepository{db: db}
+}
+
+// FindBusyURLs returns the active links with at least minClicks clicks in one hour
+// Only these can be spikes (see domain.SpikeDetector.MinClicks), so the
+// detector never looks at the long tail of quiet links
+func (r *anomalyRepository) FindBusyURLs(ctx context.Context, hour time.Time, minClicks int64, limit int) ([]*domain.HourlyClicks, error) {
+	query := `
+		SELECT ` + urlColumns + `, busy.hour_clicks
+		FROM (
+			SELECT url_id, SUM(clicks) AS hour_clicks
+			FROM url_click_rollups
+			WHERE hour = $1
+			GROUP BY url_id
+			HAVING SUM(clicks) >= $2
+		) busy
+		JOIN urls ON urls.id = busy.url_id
+		WHERE urls.is_active = true
+		ORDER BY busy.hour_clicks DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, hour, minClicks, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find busy URLs: %w", err)
+	}
+	defer rows.Close()
+
+	var busy []*domain.HourlyClicks
+	for rows.Next() {
+		var clicks int64
+		url, err := scanURL(rows, &clicks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan busy URL: %w", err)
+		}
+		busy = append(busy, &domain.HourlyClicks{URL: url, Clicks: clicks})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating busy URLs: %w", err)
+	}
+
+	return busy, nil
+}
+
+// HourlyClicks sums the rollups of the links per hour
+func (r *anomalyRepository) HourlyClicks(ctx context.Context, urlIDs []string, from, to time.Time) (map[string][]domain.ClickBucket, error) {
+	query := `
+		SELECT url_id, hour, SUM(clicks)
+		FROM url_click_rollups
+		WHERE url_id = ANY($1) AND hour >= $2 AND hour < $3
+		GROUP BY url_id, hour
+	`
+
+	rows, err := r.db.Query(ctx, query, urlIDs, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hourly clicks: %w", err)
+	}
+	defer rows.Close()
+
+	hourly := make(map[string][]domain.ClickBucket, len(urlIDs))
+	for rows.Next() {
+		var urlID string
+		var bucket domain.ClickBucket
+		if err := rows.Scan(&urlID, &bucket.Start, &bucket.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan hourly clicks: %w", err)
+		}
+		hourly[urlID] = append(hourly[urlID], bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating hourly clicks: %w", err)
+	}
+
+	return hourly, nil
+}
+
+// Record stores an anomaly
+// ON CONFLICT DO NOTHING makes this safe when several instances run the detector
+func (r *anomalyRepository) Record(ctx context.Context, anomaly *domain.ClickAnomaly) (bool, error) {
+	query := `
+		INSERT INTO url_anomalies (url_id, hour, kind, clicks, mean, stddev, score, detected_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (url_id, hour) DO NOTHING
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		anomaly.URL.ID,
+		anomaly.Hour,
+		string(anomaly.Kind),
+		anomaly.Clicks,
+		anomaly.Mean,
+		anomaly.StdDev,
+		anomaly.Score,
+		anomaly.DetectedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record anomaly: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
@@ -0,0 +1,203 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// apiKeyColumns are selected by every query that returns keys (see scanAPIKey)
+const apiKeyColumns = `id, workspace, name, prefix, scopes, admin, created_by, created_at, last_used_at, rotated_at, expires_at, previous_expires_at, hash_version`
+
+// apiKeyRepository is the PostgreSQL implementation of repository.APIKeyRepository
+//
+// IDs are compared as text (id::text = $2): a malformed ID in the URL is a
+// 404, not a SQL error.
+type apiKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewAPIKeyRepository creates a new PostgreSQL API key repository
+func NewAPIKeyRepository(db *pgxpool.Pool) repository.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create stores a new key
+func (r *apiKeyRepository) Create(ctx context.Context, key *domain.APIKey, tokenHash string) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO api_keys (workspace, name, prefix, token_hash, scopes, admin, created_by, expires_at, hash_version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`,
+		key.Workspace,
+		key.Name,
+		key.Prefix,
+		tokenHash,
+		key.Scopes,
+		key.Admin,
+		key.CreatedBy,
+		key.ExpiresAt,
+		key.HashVersion,
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// GetByHash returns the key of a current or previous secret, with the plan
+// of its workspace (the key authenticates every request: one query, not two)
+// One hash per pepper: both unique indexes serve = ANY of a few values
+func (r *apiKeyRepository) GetByHash(ctx context.Context, tokenHashes ...string) (*domain.APIKey, bool, error) {
+	var previous bool
+	var plan string
+	key, err := scanAPIKey(r.db.QueryRow(ctx, `
+		SELECT `+apiKeyColumns+`, NOT (token_hash = ANY($1)),
+		       COALESCE((SELECT plan FROM workspace_settings s WHERE s.workspace = api_keys.workspace), '')
+		FROM api_keys
+		WHERE token_hash = ANY($1) OR previous_token_hash = ANY($1)
+		LIMIT 1
+	`, tokenHashes), &previous, &plan)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get api key: %w", err)
+	}
+	key.Plan = plan
+	return key, previous, nil
+}
+
+// List returns a workspace's keys, oldest first
+func (r *apiKeyRepository) List(ctx context.Context, workspace string) ([]*domain.APIKey, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE workspace = $1
+		ORDER BY created_at, id
+	`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*domain.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Rotate replaces the secret of a key
+// The current secret becomes the previous one (the right-hand side of SET
+// sees the old row); a previous secret still in its grace period is dropped
+func (r *apiKeyRepository) Rotate(ctx context.Context, workspace, id, prefix, tokenHash, hashVersion string, previousExpiresAt time.Time, expiresAt *time.Time) (*domain.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(ctx, `
+		UPDATE api_keys
+		SET prefix = $3,
+		    token_hash = $4,
+		    hash_version = $5,
+		    previous_token_hash = token_hash,
+		    previous_expires_at = $6,
+		    expires_at = COALESCE($7, expires_at),
+		    rotated_at = CURRENT_TIMESTAMP
+		WHERE workspace = $1 AND id::text = $2
+		RETURNING `+apiKeyColumns,
+		workspace, id, prefix, tokenHash, hashVersion, previousExpiresAt, expiresAt,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate api key: %w", err)
+	}
+	return key, nil
+}
+
+// Rehash replaces the hash of a key's current secret
+func (r *apiKeyRepository) Rehash(ctx context.Context, id, prefix, tokenHash, hashVersion string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE api_keys
+		SET token_hash = $3, hash_version = $4
+		WHERE id = $1 AND prefix = $2
+	`, id, prefix, tokenHash, hashVersion)
+	if err != nil {
+		return fmt.Errorf("failed to rehash api key: %w", err)
+	}
+	return nil
+}
+
+// Delete revokes a key
+func (r *apiKeyRepository) Delete(ctx context.Context, workspace, id string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM api_keys WHERE workspace = $1 AND id::text = $2`, workspace, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Touch records that a key was used
+func (r *apiKeyRepository) Touch(ctx context.Context, id string, usedAt time.Time) error {
+	if _, err := r.db.Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, usedAt); err != nil {
+		return fmt.Errorf("failed to record api key use: %w", err)
+	}
+	return nil
+}
+
+// RevokeExpired deletes expired keys and forgets lapsed previous secrets
+func (r *apiKeyRepository) RevokeExpired(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM api_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired api keys: %w", err)
+	}
+	_, err = r.db.Exec(ctx, `
+		UPDATE api_keys
+		SET previous_token_hash = NULL, previous_expires_at = NULL
+		WHERE previous_expires_at <= $1
+	`, now)
+	if err != nil {
+		return tag.RowsAffected(), fmt.Errorf("failed to clear previous api key secrets: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// scanAPIKey reads the apiKeyColumns of one row
+func scanAPIKey(row pgx.Row, extra ...any) (*domain.APIKey, error) {
+	key := &domain.APIKey{}
+	dest := []any{
+		&key.ID,
+		&key.Workspace,
+		&key.Name,
+		&key.Prefix,
+		&key.Scopes,
+		&key.Admin,
+		&key.CreatedBy,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&key.RotatedAt,
+		&key.ExpiresAt,
+		&key.PreviousExpiresAt,
+		&key.HashVersion,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// approvalRepository is the PostgreSQL implementation of repository.ApprovalRepository
+type approvalRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewApprovalRepository creates a new PostgreSQL approval repository
+func NewApprovalRepository(db *pgxpool.Pool) repository.ApprovalRepository {
+	return &approvalRepository{db: db}
+}
+
+// ListPending returns a workspace's links waiting for approval, oldest first
+// Uses the partial index of migration 042. Archived links are left out: a
+// link nobody decided on for months is not worth a second query
+func (r *approvalRepository) ListPending(ctx context.Context, workspace string, limit int) ([]*domain.URL, error) {
+	query := `
+		SELECT ` + urlColumns + `
+		FROM urls
+		WHERE created_by = $1 AND approval_status = $2
+		ORDER BY created_at, id
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, workspace, string(domain.ApprovalPending), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending links: %w", err)
+	}
+	return collectURLs(rows)
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// archiveRepository is the PostgreSQL implementation of repository.ArchiveRepository
+type archiveRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewArchiveRepository creates a new PostgreSQL archive repository
+func NewArchiveRepository(db *pgxpool.Pool) repository.ArchiveRepository {
+	return &archiveRepository{db: db}
+}
+
+// ArchiveUnused moves one batch of cold links to urls_archive
+//
+// ONE STATEMENT, NO TRANSACTION NEEDED:
+// The CTE picks the links, DELETE ... RETURNING hands their rows to the
+// INSERT, and PostgreSQL runs it all atomically - a link is never in both
+// tables or in neither. SKIP LOCKED lets two instances archive side by side,
+// and skips links a request is updating right now.
+//
+// "Cold" = created before cutoff and no click since cutoff. The check uses
+// the url_clicks(url_id, clicked_at) index, one probe per candidate.
+func (r *archiveRepository) ArchiveUnused(ctx context.Context, cutoff time.Time, limit int) ([]*domain.URL, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH cold AS (
+			SELECT id FROM urls
+			WHERE created_at < $1
+			  AND NOT EXISTS (
+			      SELECT 1 FROM url_clicks
+			      WHERE url_clicks.url_id = urls.id AND url_clicks.clicked_at >= $1
+			  )
+			ORDER BY created_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		), moved AS (
+			DELETE FROM urls WHERE id IN (SELECT id FROM cold)
+			RETURNING `+urlColumns+`
+		)
+		INSERT INTO urls_archive (`+urlColumns+`)
+		SELECT `+urlColumns+` FROM moved
+		RETURNING `+urlColumns,
+		cutoff, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive URLs: %w", err)
+	}
+	return collectURLs(rows)
+}
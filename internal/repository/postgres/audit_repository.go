@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// auditRepository is the PostgreSQL implementation of repository.AuditRepository
+type auditRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewAuditRepository creates a new PostgreSQL audit repository
+func NewAuditRepository(db *pgxpool.Pool) repository.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// Record appends an entry and fills in its ID and time
+func (r *auditRepository) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO audit_log (action, workspace, url_id, short_code, actor, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, occurred_at
+	`, string(entry.Action), entry.Workspace, entry.URLID, entry.ShortCode, entry.Actor, entry.IPAddress, entry.UserAgent).
+		Scan(&entry.ID, &entry.OccurredAt)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListByURL returns the entries of a link, newest first
+func (r *auditRepository) ListByURL(ctx context.Context, urlID string) ([]*domain.AuditEntry, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, action, workspace, url_id, short_code, actor, ip_address, user_agent, occurred_at
+		FROM audit_log
+		WHERE url_id = $1
+		ORDER BY occurred_at DESC, id
+	`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.AuditEntry
+	for rows.Next() {
+		var entry domain.AuditEntry
+		var action string
+		if err := rows.Scan(
+			&entry.ID,
+			&action,
+			&entry.Workspace,
+			&entry.URLID,
+			&entry.ShortCode,
+			&entry.Actor,
+			&entry.IPAddress,
+			&entry.UserAgent,
+			&entry.OccurredAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entry.Action = domain.AuditAction(action)
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit entries: %w", err)
+	}
+	return entries, nil
+}
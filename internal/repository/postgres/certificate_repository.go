@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// certificateRepository is the PostgreSQL implementation of repository.CertificateRepository
+type certificateRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewCertificateRepository creates a new PostgreSQL certificate repository
+func NewCertificateRepository(db *pgxpool.Pool) repository.CertificateRepository {
+	return &certificateRepository{db: db}
+}
+
+// Get returns the data stored under name
+// A row that only records a failure has no data: it is a miss
+func (r *certificateRepository) Get(ctx context.Context, name string) ([]byte, error) {
+	var data []byte
+	err := r.db.QueryRow(ctx,
+		`SELECT data FROM tls_certificates WHERE name = $1 AND data IS NOT NULL`,
+		name,
+	).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrCertificateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get certificate: %w", err)
+	}
+	return data, nil
+}
+
+// Put stores data under name and clears a recorded failure
+func (r *certificateRepository) Put(ctx context.Context, name string, data []byte, notAfter *time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO tls_certificates (name, data, not_after, issued_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (name) DO UPDATE
+		SET data = EXCLUDED.data, not_after = EXCLUDED.not_after, issued_at = EXCLUDED.issued_at,
+		    last_error = '', failed_at = NULL
+	`, name, data, notAfter)
+	if err != nil {
+		return fmt.Errorf("failed to store certificate: %w", err)
+	}
+	return nil
+}
+
+// Delete removes name
+func (r *certificateRepository) Delete(ctx context.Context, name string) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM tls_certificates WHERE name = $1`, name); err != nil {
+		return fmt.Errorf("failed to delete certificate: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure remembers why a certificate for host couldn't be issued
+func (r *certificateRepository) RecordFailure(ctx context.Context, host, message string, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO tls_certificates (name, last_error, failed_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE
+		SET last_error = EXCLUDED.last_error, failed_at = EXCLUDED.failed_at
+	`, host, message, at)
+	if err != nil {
+		return fmt.Errorf("failed to record certificate failure: %w", err)
+	}
+	return nil
+}
+
+// Statuses returns what is known about the certificates of hosts
+func (r *certificateRepository) Statuses(ctx context.Context, hosts []string) (map[string]*domain.Certificate, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT name, issued_at, not_after, last_error, failed_at
+		FROM tls_certificates
+		WHERE name = ANY($1)
+	`, hosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get certificate statuses: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := make(map[string]*domain.Certificate, len(hosts))
+	for rows.Next() {
+		var c domain.Certificate
+		if err := rows.Scan(&c.Host, &c.IssuedAt, &c.NotAfter, &c.LastError, &c.FailedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan certificate status: %w", err)
+		}
+		statuses[c.Host] = &c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating certificate statuses: %w", err)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// clickBackfillRepository is the PostgreSQL implementation of repository.ClickBackfillRepository
+type clickBackfillRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewClickBackfillRepository creates a new PostgreSQL click backfill repository
+func NewClickBackfillRepository(db *pgxpool.Pool) repository.ClickBackfillRepository {
+	return &clickBackfillRepository{db: db}
+}
+
+// NextUnparsed returns the next batch of clicks without parsed User-Agent fields
+// Paging by ID (KEYSET pagination) instead of OFFSET keeps every batch
+// as fast as the first, even deep into a table with millions of rows
+func (r *clickBackfillRepository) NextUnparsed(ctx context.Context, afterID int64, limit int) ([]*domain.URLClick, error) {
+	query := `
+		SELECT id, COALESCE(user_agent, '')
+		FROM url_clicks
+		WHERE id > $1 AND device_type IS NULL
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load unparsed clicks: %w", err)
+	}
+	defer rows.Close()
+
+	var clicks []*domain.URLClick
+	for rows.Next() {
+		click := &domain.URLClick{}
+		if err := rows.Scan(&click.ID, &click.UserAgent); err != nil {
+			return nil, fmt.Errorf("failed to scan click: %w", err)
+		}
+		clicks = append(clicks, click)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating clicks: %w", err)
+	}
+
+	return clicks, nil
+}
+
+// SetDevices updates a whole batch with one UPDATE
+// unnest() turns the parallel arrays into rows to join against
+func (r *clickBackfillRepository) SetDevices(ctx context.Context, clicks []*domain.URLClick) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(clicks))
+	browsers := make([]string, len(clicks))
+	systems := make([]string, len(clicks))
+	devices := make([]string, len(clicks))
+	for i, click := range clicks {
+		ids[i] = click.ID
+		browsers[i] = click.Browser
+		systems[i] = click.OS
+		devices[i] = click.DeviceType
+	}
+
+	query := `
+		UPDATE url_clicks AS c
+		SET browser = v.browser, os = v.os, device_type = v.device_type
+		FROM unnest($1::bigint[], $2::text[], $3::text[], $4::text[]) AS v(id, browser, os, device_type)
+		WHERE c.id = v.id
+	`
+
+	if _, err := r.db.Exec(ctx, query, ids, browsers, systems, devices); err != nil {
+		return fmt.Errorf("failed to set click devices: %w", err)
+	}
+	return nil
+}
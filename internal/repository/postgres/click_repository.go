@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"url-shortener/internal/domain"
 	"url-shortener/internal/repository"
@@ -21,14 +22,28 @@ func NewClickRepository(db *pgxpool.Pool) repository.ClickRepository {
 }
 
 // Create inserts a new click event into the database
+// The same statement counts the click in its hourly rollup (migration 024),
+// so the leaderboard never disagrees with the clicks
+// An empty IP (the visitor opted out of tracking) is stored as NULL:
+// an empty string isn't a valid INET, and the whole click would be rejected
 func (r *clickRepository) Create(ctx context.Context, click *domain.URLClick) error {
 	query := `
-		INSERT INTO url_clicks (
-			url_id, clicked_at, ip_address, user_agent,
-			referer, country_code, city
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7
-		) RETURNING id
+		WITH click AS (
+			INSERT INTO url_clicks (
+				url_id, clicked_at, ip_address, user_agent,
+				referer, country_code, city, channel, browser, os,
+				device_type, region
+			) VALUES (
+				$1, $2, NULLIF($3, '')::inet, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, '')
+			) RETURNING id, url_id, clicked_at, ip_address
+		), rollup AS (
+			INSERT INTO url_click_rollups (url_id, hour, visitor, clicks)
+			SELECT url_id, date_trunc('hour', clicked_at, 'UTC'), COALESCE(host(ip_address), ''), 1
+			FROM click
+			ON CONFLICT (url_id, hour, visitor)
+			DO UPDATE SET clicks = url_click_rollups.clicks + 1
+		)
+		SELECT id FROM click
 	`
 
 	err := r.db.QueryRow(
@@ -41,6 +56,11 @@ func (r *clickRepository) Create(ctx context.Context, click *domain.URLClick) er
 		click.Referer,
 		click.CountryCode,
 		click.City,
+		click.Channel,
+		click.Browser,
+		click.OS,
+		click.DeviceType,
+		click.Region,
 	).Scan(&click.ID)
 
 	if err != nil {
@@ -53,8 +73,10 @@ func (r *clickRepository) Create(ctx context.Context, click *domain.URLClick) er
 // GetByURLID retrieves clicks for a specific URL with pagination
 func (r *clickRepository) GetByURLID(ctx context.Context, urlID string, limit, offset int) ([]*domain.URLClick, error) {
 	query := `
-		SELECT id, url_id, clicked_at, ip_address, user_agent,
-		       referer, country_code, city
+		SELECT id, url_id, clicked_at, COALESCE(host(ip_address), ''), user_agent,
+		       referer, country_code, city, COALESCE(channel, ''),
+		       COALESCE(browser, ''), COALESCE(os, ''), COALESCE(device_type, ''),
+		       COALESCE(region, '')
 		FROM url_clicks
 		WHERE url_id = $1
 		ORDER BY clicked_at DESC
@@ -81,6 +103,11 @@ func (r *clickRepository) GetByURLID(ctx context.Context, urlID string, limit, o
 			&click.Referer,
 			&click.CountryCode,
 			&click.City,
+			&click.Channel,
+			&click.Browser,
+			&click.OS,
+			&click.DeviceType,
+			&click.Region,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan click: %w", err)
@@ -108,3 +135,174 @@ func (r *clickRepository) GetClickCount(ctx context.Context, urlID string) (int6
 
 	return count, nil
 }
+
+// dimensionColumns maps analytics dimensions to their url_clicks column
+// Column names can't be query parameters, so ONLY names from this map are
+// ever put into the SQL text
+var dimensionColumns = map[domain.ClickDimension]string{
+	domain.DimensionChannel: "channel",
+	domain.DimensionBrowser: "browser",
+	domain.DimensionOS:      "os",
+	domain.DimensionDevice:  "device_type",
+	domain.DimensionRegion:  "region",
+}
+
+// CountBy counts the clicks of a URL per value of dimension
+func (r *clickRepository) CountBy(ctx context.Context, urlID string, dimension domain.ClickDimension) (map[string]int64, error) {
+	column, ok := dimensionColumns[dimension]
+	if !ok {
+		return nil, fmt.Errorf("unknown click dimension %q", dimension)
+	}
+
+	query := `
+		SELECT COALESCE(` + column + `, 'unknown'), COUNT(*)
+		FROM url_clicks
+		WHERE url_id = $1
+		GROUP BY 1
+	`
+
+	rows, err := r.db.Query(ctx, query, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clicks by %s: %w", dimension, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var value string
+		var count int64
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan click count: %w", err)
+		}
+		counts[value] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating click counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// CountByPeriod counts the clicks of a URL per interval, aligned to location
+//
+// HOW THE BUCKETING WORKS:
+//
+//	clicked_at AT TIME ZONE 'Europe/Berlin'  -> local wall clock (TIMESTAMP)
+//	date_trunc('day', <that>)                -> local midnight
+//
+// PostgreSQL knows DST rules too, so a Berlin day is 23 or 25 hours long
+// around the switch, exactly like the Go side expects (domain.Interval).
+func (r *clickRepository) CountByPeriod(ctx context.Context, urlID string, interval domain.Interval, location *time.Location, from, to time.Time) ([]domain.ClickBucket, error) {
+	if !interval.Valid() {
+		return nil, domain.ErrInvalidInterval
+	}
+
+	// The interval and timezone are parameters, so they can't inject SQL
+	query := `
+		SELECT date_trunc($2, clicked_at AT TIME ZONE $3) AS bucket, COUNT(*)
+		FROM url_clicks
+		WHERE url_id = $1 AND clicked_at >= $4 AND clicked_at < $5
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+
+	rows, err := r.db.Query(ctx, query, urlID, string(interval), location.String(), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clicks by %s: %w", interval, err)
+	}
+	defer rows.Close()
+
+	var buckets []domain.ClickBucket
+	for rows.Next() {
+		var local time.Time // Wall clock without timezone; pgx hands it back as UTC
+		var count int64
+		if err := rows.Scan(&local, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan click bucket: %w", err)
+		}
+		start := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, location)
+		buckets = append(buckets, domain.ClickBucket{Start: start, Clicks: count})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating click buckets: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// TopLinks ranks the links of owner by clicks since the start of since's hour
+//
+// WHY THE ROLLUPS:
+// url_clicks has one row per click; url_click_rollups one per link, hour
+// and visitor. Repeat clicks (the same people coming back to a popular
+// link) collapse into a counter, so there are fewer rows to read. Unique
+// visitors are the distinct known visitors over the whole period.
+func (r *clickRepository) TopLinks(ctx context.Context, owner string, since time.Time, limit int) ([]domain.TopLink, error) {
+	query := `
+		SELECT u.id, u.short_code, u.domain, u.original_url,
+		       SUM(r.clicks) AS clicks,
+		       COUNT(DISTINCT NULLIF(r.visitor, '')) AS unique_visitors,
+		       u.visibility = 'sensitive' AS sensitive
+		FROM urls u
+		JOIN url_click_rollups r ON r.url_id = u.id
+		WHERE u.created_by = $1 AND r.hour >= date_trunc('hour', $2::timestamptz, 'UTC')
+		GROUP BY u.id, u.short_code, u.domain, u.original_url, u.visibility
+		ORDER BY clicks DESC, unique_visitors DESC, u.short_code
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, owner, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []domain.TopLink
+	for rows.Next() {
+		var link domain.TopLink
+		if err := rows.Scan(&link.URLID, &link.ShortCode, &link.Domain, &link.OriginalURL, &link.Clicks, &link.UniqueVisitors, &link.Sensitive); err != nil {
+			return nil, fmt.Errorf("failed to scan top link: %w", err)
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating top links: %w", err)
+	}
+
+	return links, nil
+}
+
+// CountByHourOfWeek counts the clicks of a URL per weekday and local hour
+//
+// ISODOW numbers Monday 1 ... Sunday 7; minus one gives the row of the
+// heatmap. Like CountByPeriod, the wall clock comes from AT TIME ZONE, so
+// "9 o'clock" means 9 in the viewer's timezone all year, DST or not.
+func (r *clickRepository) CountByHourOfWeek(ctx context.Context, urlID string, location *time.Location, from, to time.Time) ([]domain.HourOfWeek, error) {
+	query := `
+		SELECT EXTRACT(ISODOW FROM clicked_at AT TIME ZONE $2)::int - 1 AS day,
+		       EXTRACT(HOUR FROM clicked_at AT TIME ZONE $2)::int AS hour,
+		       COUNT(*)
+		FROM url_clicks
+		WHERE url_id = $1 AND clicked_at >= $3 AND clicked_at < $4
+		GROUP BY day, hour
+	`
+
+	rows, err := r.db.Query(ctx, query, urlID, location.String(), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clicks by hour of week: %w", err)
+	}
+	defer rows.Close()
+
+	var cells []domain.HourOfWeek
+	for rows.Next() {
+		var cell domain.HourOfWeek
+		if err := rows.Scan(&cell.Day, &cell.Hour, &cell.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan heatmap cell: %w", err)
+		}
+		cells = append(cells, cell)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating heatmap cells: %w", err)
+	}
+
+	return cells, nil
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// clickStripeRepository is the PostgreSQL implementation of repository.ClickStripeRepository
+type clickStripeRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewClickStripeRepository creates a new PostgreSQL click stripe repository
+func NewClickStripeRepository(db *pgxpool.Pool) repository.ClickStripeRepository {
+	return &clickStripeRepository{db: db}
+}
+
+// FoldClickStripes moves a batch of stripes into urls.clicks
+//
+// ONE STATEMENT, NOTHING COUNTED TWICE OR LOST:
+// DELETE ... RETURNING hands the stripes to both UPDATEs, and Postgres runs
+// it all atomically. A reader sees a click either in a stripe or in
+// urls.clicks, never in both. Links archived meanwhile are updated in
+// urls_archive; stripes of purged links are just dropped.
+//
+// SKIP LOCKED passes over stripes a click is adding to right now: the fold
+// never makes a redirect wait, and picks them up next time.
+func (r *clickStripeRepository) FoldClickStripes(ctx context.Context, limit int) (int, error) {
+	var folded int
+	err := r.db.QueryRow(ctx, `
+		WITH batch AS (
+			SELECT url_id, stripe FROM url_click_stripes
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), removed AS (
+			DELETE FROM url_click_stripes s
+			USING batch
+			WHERE s.url_id = batch.url_id AND s.stripe = batch.stripe
+			RETURNING s.url_id, s.clicks
+		), totals AS (
+			SELECT url_id, SUM(clicks) AS clicks FROM removed GROUP BY url_id
+		), hot AS (
+			UPDATE urls SET clicks = urls.clicks + totals.clicks
+			FROM totals WHERE urls.id = totals.url_id
+		), archived AS (
+			UPDATE urls_archive SET clicks = urls_archive.clicks + totals.clicks
+			FROM totals WHERE urls_archive.id = totals.url_id
+		)
+		SELECT COUNT(*) FROM removed
+	`, limit).Scan(&folded)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fold click stripes: %w", err)
+	}
+	return folded, nil
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// codeCaseRepository is the PostgreSQL implementation of repository.CodeCaseRepository
+type codeCaseRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewCodeCaseRepository creates a new PostgreSQL code case repository
+func NewCodeCaseRepository(db *pgxpool.Pool) repository.CodeCaseRepository {
+	return &codeCaseRepository{db: db}
+}
+
+// NextMixedCase returns the next batch of links with capitals in their codes
+// Live and archived links alike: an archived link comes back on its next
+// click, and must come back under the lowercase code
+func (r *codeCaseRepository) NextMixedCase(ctx context.Context, afterID string, limit int) ([]*domain.URL, error) {
+	if afterID == "" {
+		afterID = "00000000-0000-0000-0000-000000000000"
+	}
+
+	query := `
+		SELECT id, short_code, custom_alias FROM (
+			SELECT id, short_code, custom_alias FROM urls
+			UNION ALL
+			SELECT id, short_code, custom_alias FROM urls_archive
+		) links
+		WHERE id > $1::uuid
+		  AND (short_code <> lower(short_code) OR custom_alias <> lower(custom_alias))
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load mixed-case links: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []*domain.URL
+	for rows.Next() {
+		url := &domain.URL{}
+		if err := rows.Scan(&url.ID, &url.ShortCode, &url.CustomAlias); err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+		urls = append(urls, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating links: %w", err)
+	}
+
+	return urls, nil
+}
+
+// LowercaseCodes lowercases the codes of one link, unless that makes them clash
+//
+// A CLASH is another link (live or archived) whose short code or alias equals
+// one of the lowercase forms - e.g. "ABC123" when "abc123" exists. Those two
+// links can't both keep working when case is ignored, so it is left to a
+// person to pick (rename one alias, then run the migration again).
+// The unique indexes still catch a link created with a clashing code meanwhile.
+func (r *codeCaseRepository) LowercaseCodes(ctx context.Context, id string, apply bool) (bool, error) {
+	var free bool
+	err := r.db.QueryRow(ctx, `
+		WITH target AS (
+			SELECT id, lower(short_code) AS code, lower(custom_alias) AS alias FROM urls WHERE id = $1
+			UNION ALL
+			SELECT id, lower(short_code), lower(custom_alias) FROM urls_archive WHERE id = $1
+		), clash AS (
+			SELECT 1 FROM target t
+			WHERE EXISTS (
+				SELECT 1 FROM urls u WHERE u.id <> t.id
+				  AND (u.short_code IN (t.code, t.alias) OR u.custom_alias IN (t.code, t.alias))
+			) OR EXISTS (
+				SELECT 1 FROM urls_archive a WHERE a.id <> t.id
+				  AND (a.short_code IN (t.code, t.alias) OR a.custom_alias IN (t.code, t.alias))
+			)
+		), hot AS (
+			UPDATE urls SET short_code = lower(short_code), custom_alias = lower(custom_alias)
+			WHERE id = $1 AND $2 AND NOT EXISTS (SELECT 1 FROM clash)
+		), archived AS (
+			UPDATE urls_archive SET short_code = lower(short_code), custom_alias = lower(custom_alias)
+			WHERE id = $1 AND $2 AND NOT EXISTS (SELECT 1 FROM clash)
+		)
+		SELECT NOT EXISTS (SELECT 1 FROM clash)
+	`, id, apply).Scan(&free)
+	if err != nil {
+		return false, fmt.Errorf("failed to lowercase codes: %w", err)
+	}
+	return free, nil
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// customDomainColumns is the column list scanCustomDomain reads
+const customDomainColumns = `id, host, owner, token, status, created_at, verified_at, checked_at, last_error`
+
+// customDomainRepository is the PostgreSQL implementation of repository.CustomDomainRepository
+type customDomainRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewCustomDomainRepository creates a new PostgreSQL custom domain repository
+func NewCustomDomainRepository(db *pgxpool.Pool) repository.CustomDomainRepository {
+	return &customDomainRepository{db: db}
+}
+
+// Create inserts a domain
+func (r *customDomainRepository) Create(ctx context.Context, d *domain.CustomDomain) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO custom_domains (host, owner, token, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, d.Host, d.Owner, d.Token, string(d.Status)).
+		Scan(&d.ID, &d.CreatedAt)
+	if isUniqueViolation(err) {
+		return domain.ErrCustomDomainTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create custom domain: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns one domain
+func (r *customDomainRepository) GetByID(ctx context.Context, id string) (*domain.CustomDomain, error) {
+	d, err := scanCustomDomain(r.db.QueryRow(ctx,
+		`SELECT `+customDomainColumns+` FROM custom_domains WHERE id = $1`,
+		id,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrCustomDomainNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom domain: %w", err)
+	}
+	return d, nil
+}
+
+// List returns every domain, sorted by host
+func (r *customDomainRepository) List(ctx context.Context) ([]*domain.CustomDomain, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+customDomainColumns+` FROM custom_domains ORDER BY host`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom domains: %w", err)
+	}
+	defer rows.Close()
+
+	var domains []*domain.CustomDomain
+	for rows.Next() {
+		d, err := scanCustomDomain(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan custom domain: %w", err)
+		}
+		domains = append(domains, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating custom domains: %w", err)
+	}
+	return domains, nil
+}
+
+// SaveCheck stores the outcome of a verification check
+func (r *customDomainRepository) SaveCheck(ctx context.Context, d *domain.CustomDomain) error {
+	result, err := r.db.Exec(ctx, `
+		UPDATE custom_domains
+		SET status = $2, verified_at = $3, checked_at = $4, last_error = $5
+		WHERE id = $1
+	`, d.ID, string(d.Status), d.VerifiedAt, d.CheckedAt, d.LastError)
+	if err != nil {
+		return fmt.Errorf("failed to save custom domain check: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrCustomDomainNotFound
+	}
+	return nil
+}
+
+// Delete removes a domain
+func (r *customDomainRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM custom_domains WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete custom domain: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrCustomDomainNotFound
+	}
+	return nil
+}
+
+// scanCustomDomain reads one row of customDomainColumns
+func scanCustomDomain(row pgx.Row) (*domain.CustomDomain, error) {
+	var d domain.CustomDomain
+	var status string
+	if err := row.Scan(
+		&d.ID,
+		&d.Host,
+		&d.Owner,
+		&d.Token,
+		&status,
+		&d.CreatedAt,
+		&d.VerifiedAt,
+		&d.CheckedAt,
+		&d.LastError,
+	); err != nil {
+		return nil, err
+	}
+	d.Status = domain.DomainStatus(status)
+	return &d, nil
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// domainRuleColumns lists the rule columns in the order scanDomainRule reads them
+const domainRuleColumns = `id, workspace, pattern, kind, action, reason, created_by, created_at`
+
+// domainRuleRepository is the PostgreSQL implementation of repository.DomainRuleRepository
+type domainRuleRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewDomainRuleRepository creates a new PostgreSQL domain rule repository
+func NewDomainRuleRepository(db *pgxpool.Pool) repository.DomainRuleRepository {
+	return &domainRuleRepository{db: db}
+}
+
+// List returns every rule, oldest first
+func (r *domainRuleRepository) List(ctx context.Context) ([]*domain.DomainRule, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+domainRuleColumns+` FROM domain_rules ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domain rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*domain.DomainRule
+	for rows.Next() {
+		rule, err := scanDomainRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan domain rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating domain rules: %w", err)
+	}
+	return rules, nil
+}
+
+// GetByID returns one rule
+func (r *domainRuleRepository) GetByID(ctx context.Context, id string) (*domain.DomainRule, error) {
+	rule, err := scanDomainRule(r.db.QueryRow(ctx,
+		`SELECT `+domainRuleColumns+` FROM domain_rules WHERE id = $1`,
+		id,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrDomainRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain rule: %w", err)
+	}
+	return rule, nil
+}
+
+// Create inserts a rule
+func (r *domainRuleRepository) Create(ctx context.Context, rule *domain.DomainRule) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO domain_rules (workspace, pattern, kind, action, reason, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, rule.Workspace, rule.Pattern, string(rule.Kind), string(rule.Action), rule.Reason, rule.CreatedBy).
+		Scan(&rule.ID, &rule.CreatedAt)
+	if isUniqueViolation(err) {
+		return domain.ErrDomainRuleDuplicate
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create domain rule: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a rule
+func (r *domainRuleRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM domain_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete domain rule: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrDomainRuleNotFound
+	}
+	return nil
+}
+
+// scanDomainRule reads one row of domainRuleColumns
+func scanDomainRule(row pgx.Row) (*domain.DomainRule, error) {
+	var rule domain.DomainRule
+	var kind, action string
+	if err := row.Scan(
+		&rule.ID,
+		&rule.Workspace,
+		&rule.Pattern,
+		&kind,
+		&action,
+		&rule.Reason,
+		&rule.CreatedBy,
+		&rule.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	rule.Kind = domain.PatternKind(kind)
+	rule.Action = domain.PolicyAction(action)
+	return &rule, nil
+}
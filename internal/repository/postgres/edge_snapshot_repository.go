@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// edgeSnapshotRepository is the PostgreSQL implementation of repository.EdgeSnapshotRepository
+type edgeSnapshotRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewEdgeSnapshotRepository creates a new PostgreSQL edge snapshot repository
+func NewEdgeSnapshotRepository(db *pgxpool.Pool) repository.EdgeSnapshotRepository {
+	return &edgeSnapshotRepository{db: db}
+}
+
+func (r *edgeSnapshotRepository) Version(ctx context.Context) (int64, int64, error) {
+	var current, prunedThrough int64
+	err := r.db.QueryRow(ctx, `SELECT version, pruned_through FROM url_change_counter`).Scan(&current, &prunedThrough)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read edge snapshot version: %w", err)
+	}
+	return current, prunedThrough, nil
+}
+
+// ListLinks pages through the active links by ID (the primary key index)
+// Links that changed after through are left out: they belong to the next
+// delta, which the client asks for with the version of this snapshot
+func (r *edgeSnapshotRepository) ListLinks(ctx context.Context, afterID string, through int64, limit int) ([]*domain.URL, error) {
+	// The first page starts before every possible ID
+	if afterID == "" {
+		afterID = "00000000-0000-0000-0000-000000000000"
+	}
+
+	query := `
+		SELECT ` + urlColumns + `
+		FROM urls
+		WHERE id > $1 AND change_seq <= $2 AND is_active = true
+		ORDER BY id
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, afterID, through, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links for edge snapshot: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []*domain.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan edge snapshot row: %w", err)
+		}
+		urls = append(urls, url)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating edge snapshot rows: %w", err)
+	}
+
+	return urls, nil
+}
+
+// ListChanges merges the changed links and the tombstones by number
+//
+// Each side reads at most limit rows through its own change_seq index, so
+// the first limit entries of the merge are exactly the next page
+func (r *edgeSnapshotRepository) ListChanges(ctx context.Context, after, through int64, limit int) ([]*domain.EdgeChange, error) {
+	changed, err := r.listChanged(ctx, after, through, limit)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := r.listRemoved(ctx, after, through, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]*domain.EdgeChange, 0, min(len(changed)+len(removed), limit))
+	for len(changes) < limit && (len(changed) > 0 || len(removed) > 0) {
+		if len(removed) == 0 || (len(changed) > 0 && changed[0].Seq < removed[0].Seq) {
+			changes = append(changes, changed[0])
+			changed = changed[1:]
+		} else {
+			changes = append(changes, removed[0])
+			removed = removed[1:]
+		}
+	}
+	return changes, nil
+}
+
+func (r *edgeSnapshotRepository) listChanged(ctx context.Context, after, through int64, limit int) ([]*domain.EdgeChange, error) {
+	query := `
+		SELECT ` + urlColumns + `, change_seq
+		FROM urls
+		WHERE change_seq > $1 AND change_seq <= $2
+		ORDER BY change_seq
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, after, through, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed links: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*domain.EdgeChange
+	for rows.Next() {
+		change := &domain.EdgeChange{}
+		url, err := scanURL(rows, &change.Seq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan changed link: %w", err)
+		}
+		change.URL = url
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating changed links: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (r *edgeSnapshotRepository) listRemoved(ctx context.Context, after, through int64, limit int) ([]*domain.EdgeChange, error) {
+	query := `
+		SELECT short_code, custom_alias, domain, change_seq
+		FROM url_tombstones
+		WHERE change_seq > $1 AND change_seq <= $2
+		ORDER BY change_seq
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, after, through, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list removed links: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*domain.EdgeChange
+	for rows.Next() {
+		change := &domain.EdgeChange{URL: &domain.URL{}, Removed: true}
+		if err := rows.Scan(&change.URL.ShortCode, &change.URL.CustomAlias, &change.URL.Domain, &change.Seq); err != nil {
+			return nil, fmt.Errorf("failed to scan removed link: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating removed links: %w", err)
+	}
+
+	return changes, nil
+}
+
+// PruneTombstones deletes old tombstones and moves pruned_through past them
+// in one statement, so no delta can start where tombstones are missing
+func (r *edgeSnapshotRepository) PruneTombstones(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `
+		WITH pruned AS (
+			DELETE FROM url_tombstones WHERE removed_at < $1
+			RETURNING change_seq
+		)
+		UPDATE url_change_counter
+		SET pruned_through = GREATEST(pruned_through, (SELECT MAX(change_seq) FROM pruned))
+		RETURNING (SELECT COUNT(*) FROM pruned)
+	`
+
+	var pruned int64
+	if err := r.db.QueryRow(ctx, query, cutoff).Scan(&pruned); err != nil {
+		return 0, fmt.Errorf("failed to prune edge tombstones: %w", err)
+	}
+	return pruned, nil
+}
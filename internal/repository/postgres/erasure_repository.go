@@ -0,0 +1,243 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// erasureRepository is the PostgreSQL implementation of repository.ErasureRepository
+type erasureRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewErasureRepository creates a new PostgreSQL erasure repository
+func NewErasureRepository(db *pgxpool.Pool) repository.ErasureRepository {
+	return &erasureRepository{db: db}
+}
+
+// erasureColumns is the column list shared by every query that loads a request
+const erasureColumns = `id, COALESCE(owner, ''), subject_hash, requested_by, status,
+		       urls_deleted, clicks_deleted, COALESCE(error, ''), requested_at, completed_at`
+
+// scanErasure scans a row selected with erasureColumns
+func scanErasure(row pgx.Row) (*domain.ErasureRequest, error) {
+	req := &domain.ErasureRequest{}
+	err := row.Scan(
+		&req.ID,
+		&req.Owner,
+		&req.SubjectHash,
+		&req.RequestedBy,
+		&req.Status,
+		&req.URLsDeleted,
+		&req.ClicksDeleted,
+		&req.Error,
+		&req.RequestedAt,
+		&req.CompletedAt,
+	)
+	return req, err
+}
+
+// Create stores a new pending request
+func (r *erasureRepository) Create(ctx context.Context, req *domain.ErasureRequest) error {
+	query := `
+		INSERT INTO erasure_requests (id, owner, subject_hash, requested_by, status, requested_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		req.ID, req.Owner, req.SubjectHash, req.RequestedBy, req.Status, req.RequestedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create erasure request: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns a request by its ID
+func (r *erasureRepository) GetByID(ctx context.Context, id string) (*domain.ErasureRequest, error) {
+	query := `SELECT ` + erasureColumns + ` FROM erasure_requests WHERE id = $1`
+
+	req, err := scanErasure(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrErasureNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get erasure request: %w", err)
+	}
+
+	return req, nil
+}
+
+// FindActiveByOwner returns the owner's unfinished request, if any
+func (r *erasureRepository) FindActiveByOwner(ctx context.Context, owner string) (*domain.ErasureRequest, error) {
+	query := `
+		SELECT ` + erasureColumns + `
+		FROM erasure_requests
+		WHERE owner = $1 AND status IN ('pending', 'running')
+	`
+
+	req, err := scanErasure(r.db.QueryRow(ctx, query, owner))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find active erasure request: %w", err)
+	}
+
+	return req, nil
+}
+
+// ClaimNext atomically takes the next request to work on
+// FOR UPDATE SKIP LOCKED lets several instances poll at once: each one skips
+// rows another instance is claiming instead of waiting for them
+func (r *erasureRepository) ClaimNext(ctx context.Context, staleAfter time.Duration) (*domain.ErasureRequest, error) {
+	query := `
+		UPDATE erasure_requests
+		SET status = 'running', heartbeat_at = NOW()
+		WHERE id = (
+			SELECT id FROM erasure_requests
+			WHERE status = 'pending'
+			   OR (status = 'running' AND heartbeat_at < $1)
+			ORDER BY requested_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + erasureColumns
+
+	req, err := scanErasure(r.db.QueryRow(ctx, query, time.Now().Add(-staleAfter)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim erasure request: %w", err)
+	}
+
+	return req, nil
+}
+
+// Update saves progress and the final outcome
+func (r *erasureRepository) Update(ctx context.Context, req *domain.ErasureRequest) error {
+	query := `
+		UPDATE erasure_requests
+		SET status = $2,
+		    urls_deleted = $3,
+		    clicks_deleted = $4,
+		    error = NULLIF($5, ''),
+		    completed_at = $6,
+		    heartbeat_at = NOW(),
+		    owner = CASE WHEN $2 = 'completed' THEN NULL ELSE owner END
+		WHERE id = $1
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		req.ID, req.Status, req.URLsDeleted, req.ClicksDeleted, req.Error, req.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update erasure request: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrErasureNotFound, req.ID)
+	}
+
+	return nil
+}
+
+// DeleteOwnerBatch deletes one batch of an owner's URLs and their clicks,
+// and the owner's audit log
+// Small batches keep each transaction (and its row locks) short, so redirects
+// for other users are never blocked behind a huge delete
+func (r *erasureRepository) DeleteOwnerBatch(ctx context.Context, owner string, limit int) ([]*domain.URL, int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op after a successful Commit
+	defer tx.Rollback(ctx)
+
+	// Bring a batch of archived links back first, so they are deleted like
+	// any other: the loop keeps going while the archive still has some
+	if _, err := tx.Exec(ctx, `
+		WITH moved AS (
+			DELETE FROM urls_archive
+			WHERE id IN (SELECT id FROM urls_archive WHERE created_by = $1 LIMIT $2)
+			RETURNING `+urlColumns+`
+		)
+		INSERT INTO urls (`+urlColumns+`)
+		SELECT `+urlColumns+` FROM moved
+	`, owner, limit); err != nil {
+		return nil, 0, fmt.Errorf("failed to rehydrate archived URLs: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT `+urlColumns+`
+		FROM urls
+		WHERE created_by = $1
+		LIMIT $2
+		FOR UPDATE
+	`, owner, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to select URLs for erasure: %w", err)
+	}
+	urls, err := collectURLs(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The audit log is keyed by owner, not by link: it also holds entries of
+	// links the owner deleted long ago. Deleted in every batch (the later
+	// ones find nothing) - and committed even when no links are left.
+	if _, err := tx.Exec(ctx, `DELETE FROM audit_log WHERE workspace = $1`, owner); err != nil {
+		return nil, 0, fmt.Errorf("failed to erase audit log: %w", err)
+	}
+	if len(urls) == 0 {
+		if err := tx.Commit(ctx); err != nil {
+			return nil, 0, fmt.Errorf("failed to commit erasure batch: %w", err)
+		}
+		return nil, 0, nil
+	}
+
+	ids := make([]string, len(urls))
+	for i, url := range urls {
+		ids[i] = url.ID
+	}
+
+	// url_warnings cascade; clicks don't (see migration 019) and are counted anyway
+	clicks, err := tx.Exec(ctx, `DELETE FROM url_clicks WHERE url_id = ANY($1)`, ids)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to erase clicks: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM url_click_rollups WHERE url_id = ANY($1)`, ids); err != nil {
+		return nil, 0, fmt.Errorf("failed to erase click rollups: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM urls WHERE id = ANY($1)`, ids); err != nil {
+		return nil, 0, fmt.Errorf("failed to erase URLs: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit erasure batch: %w", err)
+	}
+
+	return urls, clicks.RowsAffected(), nil
+}
+
+// CountOwnerURLs returns how many URLs created by owner remain
+func (r *erasureRepository) CountOwnerURLs(ctx context.Context, owner string) (int64, error) {
+	var count int64
+	err := r.db.QueryRow(ctx, `
+		SELECT (SELECT COUNT(*) FROM urls WHERE created_by = $1)
+		     + (SELECT COUNT(*) FROM urls_archive WHERE created_by = $1)
+	`, owner).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count URLs: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/featureflags"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// featureFlagRepository is the PostgreSQL implementation of featureflags.Store
+// The workspace overrides are JSONB: pgx encodes and decodes the map as JSON
+type featureFlagRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewFeatureFlagRepository creates a new PostgreSQL feature flag store
+func NewFeatureFlagRepository(db *pgxpool.Pool) featureflags.Store {
+	return &featureFlagRepository{db: db}
+}
+
+// List returns every stored flag
+func (r *featureFlagRepository) List(ctx context.Context) ([]featureflags.Flag, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT name, description, enabled, workspaces, updated_at
+		FROM feature_flags
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %w", err)
+	}
+	defer rows.Close()
+
+	var flags []featureflags.Flag
+	for rows.Next() {
+		var flag featureflags.Flag
+		if err := rows.Scan(&flag.Name, &flag.Description, &flag.Enabled, &flag.Workspaces, &flag.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag: %w", err)
+		}
+		flags = append(flags, flag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feature flags: %w", err)
+	}
+
+	return flags, nil
+}
+
+// Save creates or replaces a flag
+func (r *featureFlagRepository) Save(ctx context.Context, flag *featureflags.Flag) error {
+	workspaces := flag.Workspaces
+	if workspaces == nil {
+		workspaces = map[string]bool{} // NOT NULL column: store {} rather than null
+	}
+
+	query := `
+		INSERT INTO feature_flags (name, description, enabled, workspaces)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE
+		SET description = EXCLUDED.description,
+			enabled = EXCLUDED.enabled,
+			workspaces = EXCLUDED.workspaces,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+	if err := r.db.QueryRow(ctx, query, flag.Name, flag.Description, flag.Enabled, workspaces).Scan(&flag.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save feature flag: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a flag
+func (r *featureFlagRepository) Delete(ctx context.Context, name string) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM feature_flags WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return featureflags.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/jobs"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// jobRepository is the PostgreSQL implementation of jobs.Store
+// Payloads and results are JSONB: pgx passes json.RawMessage through as is
+type jobRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewJobRepository creates a new PostgreSQL job store
+func NewJobRepository(db *pgxpool.Pool) jobs.Store {
+	return &jobRepository{db: db}
+}
+
+// jobColumns is the column list shared by every query that loads a job
+const jobColumns = `id, kind, payload, status, attempts, max_attempts, result,
+		       COALESCE(error, ''), created_by, created_at, run_at, started_at, finished_at`
+
+// scanJob scans a row selected with jobColumns
+func scanJob(row pgx.Row) (*jobs.Job, error) {
+	job := &jobs.Job{}
+	err := row.Scan(
+		&job.ID,
+		&job.Kind,
+		&job.Payload,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.Result,
+		&job.Error,
+		&job.CreatedBy,
+		&job.CreatedAt,
+		&job.RunAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+	)
+	return job, err
+}
+
+// Create stores a new pending job
+func (r *jobRepository) Create(ctx context.Context, job *jobs.Job) error {
+	query := `
+		INSERT INTO jobs (id, kind, payload, status, max_attempts, created_by, created_at, run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		job.ID, job.Kind, job.Payload, job.Status, job.MaxAttempts, job.CreatedBy, job.CreatedAt, job.RunAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns a job by its ID
+func (r *jobRepository) Get(ctx context.Context, id string) (*jobs.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1`
+
+	job, err := scanJob(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", jobs.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ClaimNext atomically takes the next due job of one of kinds
+// FOR UPDATE SKIP LOCKED lets several workers poll at once: each one skips
+// rows another worker is claiming instead of waiting for them
+func (r *jobRepository) ClaimNext(ctx context.Context, kinds []string, staleAfter time.Duration) (*jobs.Job, error) {
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, started_at = NOW(), heartbeat_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE kind = ANY($1)
+			  AND ((status = 'pending' AND run_at <= NOW())
+			    OR (status = 'running' AND heartbeat_at < $2))
+			ORDER BY run_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + jobColumns
+
+	job, err := scanJob(r.db.QueryRow(ctx, query, kinds, time.Now().Add(-staleAfter)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Heartbeat refreshes the heartbeat of a running job
+func (r *jobRepository) Heartbeat(ctx context.Context, id string) error {
+	_, err := r.db.Exec(ctx, `UPDATE jobs SET heartbeat_at = NOW() WHERE id = $1 AND status = 'running'`, id)
+	if err != nil {
+		return fmt.Errorf("failed to record job heartbeat: %w", err)
+	}
+	return nil
+}
+
+// Succeed stores the result of a finished job
+func (r *jobRepository) Succeed(ctx context.Context, id string, result json.RawMessage) error {
+	query := `
+		UPDATE jobs
+		SET status = 'succeeded', result = $2, error = NULL, finished_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := r.db.Exec(ctx, query, id, result); err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return nil
+}
+
+// Retry puts a failed job back in the queue until runAt
+func (r *jobRepository) Retry(ctx context.Context, id, errMsg string, runAt time.Time) error {
+	query := `
+		UPDATE jobs
+		SET status = 'pending', error = $2, run_at = $3, heartbeat_at = NULL
+		WHERE id = $1
+	`
+
+	if _, err := r.db.Exec(ctx, query, id, errMsg, runAt); err != nil {
+		return fmt.Errorf("failed to schedule job retry: %w", err)
+	}
+	return nil
+}
+
+// DeleteFinishedBefore removes old succeeded and failed jobs
+func (r *jobRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM jobs WHERE status IN ('succeeded', 'failed') AND finished_at < $1`
+
+	result, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished jobs: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// Fail gives up on a job
+func (r *jobRepository) Fail(ctx context.Context, id, errMsg string) error {
+	query := `
+		UPDATE jobs
+		SET status = 'failed', error = $2, finished_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := r.db.Exec(ctx, query, id, errMsg); err != nil {
+		return fmt.Errorf("failed to record job failure: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,426 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// memberColumns lists the member columns in the order scanMember reads them
+const memberColumns = `id, workspace, user_name, external_id, display_name, email, active, created_at, updated_at`
+
+// groupColumns lists the group columns in the order scanGroup reads them
+const groupColumns = `id, workspace, display_name, external_id, created_at, updated_at`
+
+// memberRepository is the PostgreSQL implementation of repository.MemberRepository
+//
+// IDs are compared as text (id::text = $1): identity providers send back
+// whatever they were given, and a malformed ID is a 404, not a SQL error.
+type memberRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewMemberRepository creates a new PostgreSQL member repository
+func NewMemberRepository(db *pgxpool.Pool) repository.MemberRepository {
+	return &memberRepository{db: db}
+}
+
+// CreateMember inserts a member
+func (r *memberRepository) CreateMember(ctx context.Context, member *domain.Member) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO workspace_members (workspace, user_name, external_id, display_name, email, active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`, member.Workspace, member.UserName, member.ExternalID, member.DisplayName, member.Email, member.Active,
+	).Scan(&member.ID, &member.CreatedAt, &member.UpdatedAt)
+	if isUniqueViolation(err) {
+		return domain.ErrMemberExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create member: %w", err)
+	}
+	return nil
+}
+
+// GetMember returns one member with its groups
+func (r *memberRepository) GetMember(ctx context.Context, workspace, id string) (*domain.Member, error) {
+	return r.getMember(ctx, `workspace = $1 AND id::text = $2`, workspace, id)
+}
+
+// GetMemberByUserName returns one member with its groups
+func (r *memberRepository) GetMemberByUserName(ctx context.Context, workspace, userName string) (*domain.Member, error) {
+	return r.getMember(ctx, `workspace = $1 AND lower(user_name) = lower($2)`, workspace, userName)
+}
+
+func (r *memberRepository) getMember(ctx context.Context, where string, args ...any) (*domain.Member, error) {
+	member, err := scanMember(r.db.QueryRow(ctx,
+		`SELECT `+memberColumns+` FROM workspace_members WHERE `+where,
+		args...,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+
+	if err := r.loadGroups(ctx, []*domain.Member{member}); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// ListMembers returns a page of members, oldest first
+func (r *memberRepository) ListMembers(ctx context.Context, workspace string, filter domain.MemberFilter, offset, limit int) ([]*domain.Member, int, error) {
+	const where = `
+		WHERE workspace = $1
+		  AND ($2 = '' OR lower(user_name) = lower($2))
+		  AND ($3 = '' OR external_id = $3)
+	`
+
+	var total int
+	if err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM workspace_members`+where,
+		workspace, filter.UserName, filter.ExternalID,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count members: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx,
+		`SELECT `+memberColumns+` FROM workspace_members`+where+`ORDER BY created_at, id LIMIT $4 OFFSET $5`,
+		workspace, filter.UserName, filter.ExternalID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*domain.Member
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan member: %w", err)
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read members: %w", err)
+	}
+	rows.Close() // Give the connection back before loading the memberships
+
+	if err := r.loadGroups(ctx, members); err != nil {
+		return nil, 0, err
+	}
+	return members, total, nil
+}
+
+// loadGroups fills in the groups of members with one query
+func (r *memberRepository) loadGroups(ctx context.Context, members []*domain.Member) error {
+	if len(members) == 0 {
+		return nil
+	}
+	byID := make(map[string]*domain.Member, len(members))
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		byID[member.ID] = member
+		ids = append(ids, member.ID)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT gm.member_id::text, g.id::text, g.display_name
+		FROM workspace_group_members gm
+		JOIN workspace_groups g ON g.id = gm.group_id
+		WHERE gm.member_id::text = ANY($1)
+		ORDER BY g.display_name
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get member groups: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var memberID string
+		var group domain.GroupRef
+		if err := rows.Scan(&memberID, &group.ID, &group.DisplayName); err != nil {
+			return fmt.Errorf("failed to scan member group: %w", err)
+		}
+		byID[memberID].Groups = append(byID[memberID].Groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read member groups: %w", err)
+	}
+	return nil
+}
+
+// UpdateMember replaces a member's attributes
+func (r *memberRepository) UpdateMember(ctx context.Context, member *domain.Member) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE workspace_members
+		SET user_name = $3, external_id = $4, display_name = $5, email = $6, active = $7,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE workspace = $1 AND id::text = $2
+		RETURNING created_at, updated_at
+	`, member.Workspace, member.ID, member.UserName, member.ExternalID, member.DisplayName, member.Email, member.Active,
+	).Scan(&member.CreatedAt, &member.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrMemberNotFound
+	}
+	if isUniqueViolation(err) {
+		return domain.ErrMemberExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update member: %w", err)
+	}
+	return nil
+}
+
+// DeleteMember removes a member; the foreign key removes its memberships
+func (r *memberRepository) DeleteMember(ctx context.Context, workspace, id string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM workspace_members WHERE workspace = $1 AND id::text = $2`, workspace, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete member: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrMemberNotFound
+	}
+	return nil
+}
+
+// CreateGroup inserts a group and its members in one transaction
+func (r *memberRepository) CreateGroup(ctx context.Context, group *domain.Group) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op after Commit
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO workspace_groups (workspace, display_name, external_id)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`, group.Workspace, group.DisplayName, group.ExternalID).Scan(&group.ID, &group.CreatedAt, &group.UpdatedAt)
+	if isUniqueViolation(err) {
+		return domain.ErrGroupExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create group: %w", err)
+	}
+
+	if err := setGroupMembers(ctx, tx, group); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit group: %w", err)
+	}
+	return nil
+}
+
+// GetGroup returns one group with its member IDs
+func (r *memberRepository) GetGroup(ctx context.Context, workspace, id string) (*domain.Group, error) {
+	group, err := scanGroup(r.db.QueryRow(ctx,
+		`SELECT `+groupColumns+` FROM workspace_groups WHERE workspace = $1 AND id::text = $2`,
+		workspace, id,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+
+	if err := r.loadMembers(ctx, []*domain.Group{group}); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// ListGroups returns a page of groups, oldest first
+func (r *memberRepository) ListGroups(ctx context.Context, workspace string, filter domain.GroupFilter, offset, limit int) ([]*domain.Group, int, error) {
+	const where = `
+		WHERE workspace = $1
+		  AND ($2 = '' OR lower(display_name) = lower($2))
+		  AND ($3 = '' OR external_id = $3)
+	`
+
+	var total int
+	if err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM workspace_groups`+where,
+		workspace, filter.DisplayName, filter.ExternalID,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count groups: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx,
+		`SELECT `+groupColumns+` FROM workspace_groups`+where+`ORDER BY created_at, id LIMIT $4 OFFSET $5`,
+		workspace, filter.DisplayName, filter.ExternalID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []*domain.Group
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan group: %w", err)
+		}
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read groups: %w", err)
+	}
+	rows.Close() // Give the connection back before loading the memberships
+
+	if err := r.loadMembers(ctx, groups); err != nil {
+		return nil, 0, err
+	}
+	return groups, total, nil
+}
+
+// loadMembers fills in the member IDs of groups with one query
+func (r *memberRepository) loadMembers(ctx context.Context, groups []*domain.Group) error {
+	if len(groups) == 0 {
+		return nil
+	}
+	byID := make(map[string]*domain.Group, len(groups))
+	ids := make([]string, 0, len(groups))
+	for _, group := range groups {
+		byID[group.ID] = group
+		ids = append(ids, group.ID)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT group_id::text, member_id::text
+		FROM workspace_group_members
+		WHERE group_id::text = ANY($1)
+		ORDER BY member_id
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get group members: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var groupID, memberID string
+		if err := rows.Scan(&groupID, &memberID); err != nil {
+			return fmt.Errorf("failed to scan group member: %w", err)
+		}
+		byID[groupID].MemberIDs = append(byID[groupID].MemberIDs, memberID)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read group members: %w", err)
+	}
+	return nil
+}
+
+// UpdateGroup replaces a group's name and members in one transaction
+func (r *memberRepository) UpdateGroup(ctx context.Context, group *domain.Group) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		UPDATE workspace_groups
+		SET display_name = $3, external_id = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE workspace = $1 AND id::text = $2
+		RETURNING created_at, updated_at
+	`, group.Workspace, group.ID, group.DisplayName, group.ExternalID).Scan(&group.CreatedAt, &group.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrGroupNotFound
+	}
+	if isUniqueViolation(err) {
+		return domain.ErrGroupExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update group: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM workspace_group_members WHERE group_id = $1`, group.ID); err != nil {
+		return fmt.Errorf("failed to clear group members: %w", err)
+	}
+	if err := setGroupMembers(ctx, tx, group); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit group: %w", err)
+	}
+	return nil
+}
+
+// DeleteGroup removes a group; the foreign key removes its memberships
+func (r *memberRepository) DeleteGroup(ctx context.Context, workspace, id string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM workspace_groups WHERE workspace = $1 AND id::text = $2`, workspace, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrGroupNotFound
+	}
+	return nil
+}
+
+// setGroupMembers adds group.MemberIDs to the group
+// Only members of the group's own workspace are added; if any ID is not
+// one of them the whole change is refused
+func setGroupMembers(ctx context.Context, tx pgx.Tx, group *domain.Group) error {
+	ids := slices.Compact(slices.Sorted(slices.Values(group.MemberIDs)))
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO workspace_group_members (group_id, member_id)
+		SELECT $1, id FROM workspace_members
+		WHERE workspace = $2 AND id::text = ANY($3)
+	`, group.ID, group.Workspace, ids)
+	if err != nil {
+		return fmt.Errorf("failed to add group members: %w", err)
+	}
+	if int(tag.RowsAffected()) != len(ids) {
+		return fmt.Errorf("%w: a group member is not in the workspace", domain.ErrMemberNotFound)
+	}
+	group.MemberIDs = ids
+	return nil
+}
+
+// scanMember scans a row selected with memberColumns
+func scanMember(row pgx.Row) (*domain.Member, error) {
+	member := &domain.Member{}
+	err := row.Scan(
+		&member.ID,
+		&member.Workspace,
+		&member.UserName,
+		&member.ExternalID,
+		&member.DisplayName,
+		&member.Email,
+		&member.Active,
+		&member.CreatedAt,
+		&member.UpdatedAt,
+	)
+	return member, err
+}
+
+// scanGroup scans a row selected with groupColumns
+func scanGroup(row pgx.Row) (*domain.Group, error) {
+	group := &domain.Group{}
+	err := row.Scan(
+		&group.ID,
+		&group.Workspace,
+		&group.DisplayName,
+		&group.ExternalID,
+		&group.CreatedAt,
+		&group.UpdatedAt,
+	)
+	return group, err
+}
@@ -0,0 +1,261 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pageColumns lists the page columns in the order scanPage reads them
+// The theme is JSONB: pgx encodes and decodes domain.PageTheme as JSON
+const pageColumns = `id, handle, owner, title, bio, theme, created_at, updated_at`
+
+// pageRepository is the PostgreSQL implementation of repository.PageRepository
+type pageRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPageRepository creates a new PostgreSQL page repository
+func NewPageRepository(db *pgxpool.Pool) repository.PageRepository {
+	return &pageRepository{db: db}
+}
+
+// Create inserts a page and its blocks in one transaction
+func (r *pageRepository) Create(ctx context.Context, page *domain.Page) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op after Commit
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO pages (handle, owner, title, bio, theme)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`, page.Handle, page.Owner, page.Title, page.Bio, page.Theme).Scan(&page.ID, &page.CreatedAt, &page.UpdatedAt)
+	if isUniqueViolation(err) {
+		return domain.ErrHandleTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create page: %w", err)
+	}
+
+	for i := range page.Blocks {
+		if err := insertBlock(ctx, tx, page.ID, &page.Blocks[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit page: %w", err)
+	}
+	return nil
+}
+
+// Update replaces the page's fields and blocks in one transaction
+//
+// WHY NOT DELETE ALL BLOCKS AND INSERT THEM AGAIN?
+// Every block carries its click count. Updating kept blocks in place (by ID)
+// means reordering or renaming a link doesn't reset its analytics.
+func (r *pageRepository) Update(ctx context.Context, page *domain.Page) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		UPDATE pages
+		SET handle = $2, title = $3, bio = $4, theme = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at
+	`, page.ID, page.Handle, page.Title, page.Bio, page.Theme).Scan(&page.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrPageNotFound
+	}
+	if isUniqueViolation(err) {
+		return domain.ErrHandleTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update page: %w", err)
+	}
+
+	kept := make([]string, 0, len(page.Blocks))
+	for _, block := range page.Blocks {
+		if block.ID != "" {
+			kept = append(kept, block.ID)
+		}
+	}
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM page_blocks WHERE page_id = $1 AND NOT (id::text = ANY($2))`,
+		page.ID, kept,
+	); err != nil {
+		return fmt.Errorf("failed to remove page blocks: %w", err)
+	}
+
+	for i := range page.Blocks {
+		block := &page.Blocks[i]
+		if block.ID == "" {
+			if err := insertBlock(ctx, tx, page.ID, block); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := tx.QueryRow(ctx, `
+			UPDATE page_blocks
+			SET position = $3, title = $4, url = $5
+			WHERE id = $1 AND page_id = $2
+			RETURNING clicks
+		`, block.ID, page.ID, block.Position, block.Title, block.URL).Scan(&block.Clicks)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrBlockNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update page block: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit page: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns a page with its blocks
+func (r *pageRepository) GetByID(ctx context.Context, id string) (*domain.Page, error) {
+	return r.get(ctx, `SELECT `+pageColumns+` FROM pages WHERE id = $1`, id)
+}
+
+// GetByHandle returns a page with its blocks
+func (r *pageRepository) GetByHandle(ctx context.Context, handle string) (*domain.Page, error) {
+	return r.get(ctx, `SELECT `+pageColumns+` FROM pages WHERE handle = $1`, handle)
+}
+
+// get loads one page and then its blocks
+func (r *pageRepository) get(ctx context.Context, query string, arg string) (*domain.Page, error) {
+	page, err := scanPage(r.db.QueryRow(ctx, query, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrPageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, title, url, position, clicks
+		FROM page_blocks
+		WHERE page_id = $1
+		ORDER BY position
+	`, page.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page blocks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var block domain.PageBlock
+		if err := rows.Scan(&block.ID, &block.Title, &block.URL, &block.Position, &block.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan page block: %w", err)
+		}
+		page.Blocks = append(page.Blocks, block)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read page blocks: %w", err)
+	}
+
+	return page, nil
+}
+
+// ListByOwner returns the owner's pages without their blocks
+func (r *pageRepository) ListByOwner(ctx context.Context, owner string) ([]*domain.Page, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+pageColumns+` FROM pages WHERE owner = $1 ORDER BY created_at DESC`,
+		owner,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pages: %w", err)
+	}
+	defer rows.Close()
+
+	var pages []*domain.Page
+	for rows.Next() {
+		page, err := scanPage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan page: %w", err)
+		}
+		pages = append(pages, page)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pages: %w", err)
+	}
+
+	return pages, nil
+}
+
+// Delete removes a page; ON DELETE CASCADE removes its blocks
+func (r *pageRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM pages WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete page: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrPageNotFound
+	}
+	return nil
+}
+
+// IncrementBlockClicks counts a click-through atomically in the database
+func (r *pageRepository) IncrementBlockClicks(ctx context.Context, pageID, blockID string) error {
+	result, err := r.db.Exec(ctx,
+		`UPDATE page_blocks SET clicks = clicks + 1 WHERE id = $1 AND page_id = $2`,
+		blockID, pageID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to count block click: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrBlockNotFound
+	}
+	return nil
+}
+
+// insertBlock adds one block to a page and fills in its generated ID
+func insertBlock(ctx context.Context, tx pgx.Tx, pageID string, block *domain.PageBlock) error {
+	err := tx.QueryRow(ctx, `
+		INSERT INTO page_blocks (page_id, position, title, url)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, pageID, block.Position, block.Title, block.URL).Scan(&block.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create page block: %w", err)
+	}
+	block.Clicks = 0
+	return nil
+}
+
+// scanPage reads the columns listed in pageColumns
+func scanPage(row pgx.Row) (*domain.Page, error) {
+	var page domain.Page
+	err := row.Scan(
+		&page.ID,
+		&page.Handle,
+		&page.Owner,
+		&page.Title,
+		&page.Bio,
+		&page.Theme,
+		&page.CreatedAt,
+		&page.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
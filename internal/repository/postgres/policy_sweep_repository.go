@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// policySweepRepository is the PostgreSQL implementation of repository.PolicySweepRepository
+type policySweepRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPolicySweepRepository creates a new PostgreSQL policy sweep repository
+func NewPolicySweepRepository(db *pgxpool.Pool) repository.PolicySweepRepository {
+	return &policySweepRepository{db: db}
+}
+
+// ListActive returns the next page of active links, archived ones included
+// KEYSET PAGINATION ("id > last seen id") instead of OFFSET: every page is
+// an index range scan, so the 1000th page is as cheap as the first
+//
+// WHY THE ARCHIVE TOO?
+// Visiting an archived link moves it back into urls as it is. A link to a
+// banned domain must be off BEFORE that, not after the next rule change.
+func (r *policySweepRepository) ListActive(ctx context.Context, afterID string, limit int) ([]*domain.URL, error) {
+	query := `
+		SELECT ` + urlColumns + ` FROM (
+			(SELECT ` + urlColumns + ` FROM urls
+			 WHERE is_active = true AND ($1 = '' OR id > $1::uuid)
+			 ORDER BY id LIMIT $2)
+			UNION ALL
+			(SELECT ` + urlColumns + ` FROM urls_archive
+			 WHERE is_active = true AND ($1 = '' OR id > $1::uuid)
+			 ORDER BY id LIMIT $2)
+		) active
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active URLs: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []*domain.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan URL: %w", err)
+		}
+		urls = append(urls, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating active URLs: %w", err)
+	}
+	return urls, nil
+}
+
+// Deactivate turns an active link off, wherever it lives (urls or urls_archive)
+func (r *policySweepRepository) Deactivate(ctx context.Context, id string) (bool, error) {
+	var deactivated int
+	err := r.db.QueryRow(ctx, `
+		WITH hot AS (
+			UPDATE urls SET is_active = false WHERE id = $1 AND is_active = true RETURNING id
+		), archived AS (
+			UPDATE urls_archive SET is_active = false WHERE id = $1 AND is_active = true RETURNING id
+		)
+		SELECT (SELECT COUNT(*) FROM hot) + (SELECT COUNT(*) FROM archived)
+	`, id).Scan(&deactivated)
+	if err != nil {
+		return false, fmt.Errorf("failed to deactivate URL: %w", err)
+	}
+	return deactivated > 0, nil
+}
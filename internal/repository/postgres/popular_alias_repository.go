@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// popularAliasRepository is the PostgreSQL implementation of repository.PopularAliasRepository
+type popularAliasRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPopularAliasRepository creates a new PostgreSQL popular alias repository
+func NewPopularAliasRepository(db *pgxpool.Pool) repository.PopularAliasRepository {
+	return &popularAliasRepository{db: db}
+}
+
+// PopularAliases returns the most clicked aliases
+// Reads urls.clicks only: striped clicks not folded yet (see migration 038)
+// don't change which aliases are popular
+func (r *popularAliasRepository) PopularAliases(ctx context.Context, limit int) ([]domain.PopularAlias, error) {
+	query := `
+		SELECT custom_alias, clicks
+		FROM urls
+		WHERE custom_alias IS NOT NULL AND is_active = true
+		ORDER BY clicks DESC
+		LIMIT $1
+	`
+
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load popular aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var aliases []domain.PopularAlias
+	for rows.Next() {
+		var alias domain.PopularAlias
+		if err := rows.Scan(&alias.Alias, &alias.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan alias: %w", err)
+		}
+		aliases = append(aliases, alias)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating aliases: %w", err)
+	}
+
+	return aliases, nil
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// rollupRepository is the PostgreSQL implementation of repository.RollupRepository
+type rollupRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewRollupRepository creates a new PostgreSQL rollup repository
+func NewRollupRepository(db *pgxpool.Pool) repository.RollupRepository {
+	return &rollupRepository{db: db}
+}
+
+// DeleteBefore removes old rollups in batches
+// A single DELETE of a month of rows would hold its locks (and bloat the
+// WAL) for a long time; ctid picks the rows of one batch cheaply
+func (r *rollupRepository) DeleteBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM url_click_rollups
+		WHERE ctid IN (
+			SELECT ctid FROM url_click_rollups
+			WHERE hour < $1
+			LIMIT $2
+		)
+	`
+
+	result, err := r.db.Exec(ctx, query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old click rollups: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// OwnerDigests sums the rollups per link, then per owner
+// The top link is the first of the owner's links ordered by clicks. With
+// after = "" the > also leaves out links created without credentials
+func (r *rollupRepository) OwnerDigests(ctx context.Context, since time.Time, after string, limit int) ([]domain.ClickDigest, error) {
+	query := `
+		SELECT owner, COUNT(*), SUM(clicks),
+		       (ARRAY_AGG(short_code ORDER BY clicks DESC, short_code))[1],
+		       MAX(clicks)
+		FROM (
+			SELECT u.created_by AS owner, u.short_code, SUM(r.clicks) AS clicks
+			FROM url_click_rollups r
+			JOIN urls u ON u.id = r.url_id
+			WHERE r.hour >= $1 AND COALESCE(u.created_by, '') > $2
+			GROUP BY u.created_by, u.id, u.short_code
+		) per_link
+		GROUP BY owner
+		ORDER BY owner
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, since, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum clicks per owner: %w", err)
+	}
+	defer rows.Close()
+
+	var digests []domain.ClickDigest
+	for rows.Next() {
+		var digest domain.ClickDigest
+		if err := rows.Scan(&digest.Owner, &digest.Links, &digest.Clicks, &digest.TopLink, &digest.TopClicks); err != nil {
+			return nil, fmt.Errorf("failed to scan click digest: %w", err)
+		}
+		digests = append(digests, digest)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating click digests: %w", err)
+	}
+
+	return digests, nil
+}
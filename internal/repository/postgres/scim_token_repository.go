@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// scimTokenRepository is the PostgreSQL implementation of repository.SCIMTokenRepository
+type scimTokenRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewSCIMTokenRepository creates a new PostgreSQL SCIM token repository
+func NewSCIMTokenRepository(db *pgxpool.Pool) repository.SCIMTokenRepository {
+	return &scimTokenRepository{db: db}
+}
+
+// Save stores the workspace's token hash; the old token stops working
+func (r *scimTokenRepository) Save(ctx context.Context, workspace, tokenHash string) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO scim_tokens (workspace, token_hash)
+		VALUES ($1, $2)
+		ON CONFLICT (workspace) DO UPDATE
+		SET token_hash = EXCLUDED.token_hash, created_at = CURRENT_TIMESTAMP
+	`, workspace, tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to save scim token: %w", err)
+	}
+	return nil
+}
+
+// Workspace returns the workspace of a token hash
+func (r *scimTokenRepository) Workspace(ctx context.Context, tokenHash string) (string, error) {
+	var workspace string
+	err := r.db.QueryRow(ctx, `SELECT workspace FROM scim_tokens WHERE token_hash = $1`, tokenHash).Scan(&workspace)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", domain.ErrSCIMTokenNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get scim token: %w", err)
+	}
+	return workspace, nil
+}
+
+// Delete removes the workspace's token
+func (r *scimTokenRepository) Delete(ctx context.Context, workspace string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM scim_tokens WHERE workspace = $1`, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to delete scim token: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrSCIMTokenNotFound
+	}
+	return nil
+}
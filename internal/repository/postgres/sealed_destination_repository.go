@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// sealedDestinationRepository is the PostgreSQL implementation of repository.SealedDestinationRepository
+type sealedDestinationRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewSealedDestinationRepository creates a new PostgreSQL sealed destination repository
+func NewSealedDestinationRepository(db *pgxpool.Pool) repository.SealedDestinationRepository {
+	return &sealedDestinationRepository{db: db}
+}
+
+// sealable matches the links the re-encryption job looks at
+// Same condition as the partial indexes of migration 048
+const sealable = `(sealed_destination <> '' OR visibility = 'sensitive')`
+
+// NextSealable returns the next page of sensitive or encrypted links
+// Keyset pagination over urls and urls_archive, like ListActive of the
+// policy sweep: an archived link comes back into urls as it is, so it must
+// be encrypted (and under a current key) too
+func (r *sealedDestinationRepository) NextSealable(ctx context.Context, afterID string, limit int) ([]*domain.URL, error) {
+	query := `
+		SELECT ` + urlColumns + ` FROM (
+			(SELECT ` + urlColumns + ` FROM urls
+			 WHERE ` + sealable + ` AND ($1 = '' OR id > $1::uuid)
+			 ORDER BY id LIMIT $2)
+			UNION ALL
+			(SELECT ` + urlColumns + ` FROM urls_archive
+			 WHERE ` + sealable + ` AND ($1 = '' OR id > $1::uuid)
+			 ORDER BY id LIMIT $2)
+		) sealable
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sealable URLs: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []*domain.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan URL: %w", err)
+		}
+		urls = append(urls, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sealable URLs: %w", err)
+	}
+	return urls, nil
+}
+
+// Reseal swaps in a new encrypted destination, wherever the link lives
+// The WHERE clause compares with what the job read: an edit in between
+// (new destination, visibility switched back) wins over the job.
+// The version is not bumped - the link didn't change for its owner - but
+// the page metadata goes with the plain text destination.
+func (r *sealedDestinationRepository) Reseal(ctx context.Context, url *domain.URL, sealed string) (bool, error) {
+	var updated int
+	err := r.db.QueryRow(ctx, `
+		WITH hot AS (
+			UPDATE urls
+			SET sealed_destination = $4, original_url = '', resolved_url = NULL,
+			    meta_title = NULL, meta_description = NULL,
+			    meta_favicon_url = NULL, meta_fetched_at = NULL
+			WHERE id = $1 AND original_url = $2 AND sealed_destination = $3
+			RETURNING id
+		), archived AS (
+			UPDATE urls_archive
+			SET sealed_destination = $4, original_url = '', resolved_url = NULL,
+			    meta_title = NULL, meta_description = NULL,
+			    meta_favicon_url = NULL, meta_fetched_at = NULL
+			WHERE id = $1 AND original_url = $2 AND sealed_destination = $3
+			RETURNING id
+		)
+		SELECT (SELECT COUNT(*) FROM hot) + (SELECT COUNT(*) FROM archived)
+	`, url.ID, url.OriginalURL, url.SealedDestination, sealed).Scan(&updated)
+	if err != nil {
+		return false, fmt.Errorf("failed to reseal URL: %w", err)
+	}
+	return updated > 0, nil
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// searchRepository is the PostgreSQL implementation of repository.SearchRepository
+type searchRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewSearchRepository creates a new PostgreSQL search repository
+func NewSearchRepository(db *pgxpool.Pool) repository.SearchRepository {
+	return &searchRepository{db: db}
+}
+
+// Search runs a full-text search over urls and urls_archive
+//
+// HOW IT WORKS:
+// search_vector (migration 037) holds the words of every link, kept up to
+// date by Postgres itself. "@@" matches it against the tsquery using the
+// GIN index, so only matching links are read - no scan of the whole table.
+// ts_rank_cd scores each match by how many words hit and how heavily they
+// are weighted (alias > title > destination > description).
+//
+// COUNT(*) OVER () counts every match next to the page, in the same query.
+// (A page past the last hit has no rows to carry it, so its total is 0.)
+func (r *searchRepository) Search(ctx context.Context, q domain.SearchQuery) (*domain.SearchResult, error) {
+	filters := `search_vector @@ query
+		  AND ($2 = '' OR created_by = $2)
+		  AND ($3 = '' OR domain = $3)
+		  AND ($4::boolean IS NULL OR is_active = $4)
+		  AND NOT ($7 AND visibility = 'sensitive')`
+
+	query := `
+		WITH search AS (SELECT to_tsquery('simple', $1) AS query)
+		SELECT ` + urlColumns + `, rank, COUNT(*) OVER () AS total
+		FROM (
+			SELECT ` + urlColumns + `, ts_rank_cd(search_vector, query) AS rank
+			FROM urls, search WHERE ` + filters + `
+			UNION ALL
+			SELECT ` + urlColumns + `, ts_rank_cd(search_vector, query) AS rank
+			FROM urls_archive, search WHERE ` + filters + `
+		) hits
+		ORDER BY rank DESC, created_at DESC, id
+		LIMIT $5 OFFSET $6
+	`
+
+	rows, err := r.db.Query(ctx, query, q.TSQuery(), q.Owner, q.Domain, q.Active, q.Limit, q.Offset, q.HideSensitive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search URLs: %w", err)
+	}
+	defer rows.Close()
+
+	result := &domain.SearchResult{}
+	for rows.Next() {
+		hit := &domain.SearchHit{}
+		var rank float32
+		var total int64
+		url, err := scanURL(rows, &rank, &total)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search hit: %w", err)
+		}
+		hit.URL = url
+		hit.Rank = float64(rank)
+		result.Hits = append(result.Hits, hit)
+		result.Total = int(total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search hits: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// slackWorkspaceRepository is the PostgreSQL implementation of repository.SlackWorkspaceRepository
+type slackWorkspaceRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewSlackWorkspaceRepository creates a new PostgreSQL Slack workspace repository
+func NewSlackWorkspaceRepository(db *pgxpool.Pool) repository.SlackWorkspaceRepository {
+	return &slackWorkspaceRepository{db: db}
+}
+
+// Get retrieves the settings of one workspace
+func (r *slackWorkspaceRepository) Get(ctx context.Context, teamID string) (*domain.SlackWorkspace, error) {
+	query := `
+		SELECT team_id, team_name, signing_secret, owner, domain, in_channel, created_at, updated_at
+		FROM slack_workspaces
+		WHERE team_id = $1
+	`
+
+	var ws domain.SlackWorkspace
+	err := r.db.QueryRow(ctx, query, teamID).Scan(
+		&ws.TeamID,
+		&ws.TeamName,
+		&ws.SigningSecret,
+		&ws.Owner,
+		&ws.Domain,
+		&ws.InChannel,
+		&ws.CreatedAt,
+		&ws.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrSlackWorkspaceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get slack workspace: %w", err)
+	}
+
+	return &ws, nil
+}
+
+// Save creates or replaces the workspace settings
+func (r *slackWorkspaceRepository) Save(ctx context.Context, ws *domain.SlackWorkspace) error {
+	query := `
+		INSERT INTO slack_workspaces (team_id, team_name, signing_secret, owner, domain, in_channel)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (team_id) DO UPDATE
+		SET team_name = EXCLUDED.team_name,
+			signing_secret = EXCLUDED.signing_secret,
+			owner = EXCLUDED.owner,
+			domain = EXCLUDED.domain,
+			in_channel = EXCLUDED.in_channel,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		ws.TeamID,
+		ws.TeamName,
+		ws.SigningSecret,
+		ws.Owner,
+		ws.Domain,
+		ws.InChannel,
+	).Scan(&ws.CreatedAt, &ws.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save slack workspace: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the workspace
+func (r *slackWorkspaceRepository) Delete(ctx context.Context, teamID string) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM slack_workspaces WHERE team_id = $1`, teamID)
+	if err != nil {
+		return fmt.Errorf("failed to delete slack workspace: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrSlackWorkspaceNotFound
+	}
+	return nil
+}
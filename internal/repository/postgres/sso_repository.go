@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// samlConnectionRepository is the PostgreSQL implementation of repository.SAMLConnectionRepository
+type samlConnectionRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewSAMLConnectionRepository creates a new PostgreSQL SAML connection repository
+func NewSAMLConnectionRepository(db *pgxpool.Pool) repository.SAMLConnectionRepository {
+	return &samlConnectionRepository{db: db}
+}
+
+// Get returns the workspace's connection
+func (r *samlConnectionRepository) Get(ctx context.Context, workspace string) (*domain.SAMLConnection, error) {
+	connection := &domain.SAMLConnection{Workspace: workspace}
+	err := r.db.QueryRow(ctx, `
+		SELECT idp_entity_id, sso_url, certificate, role_attribute, created_at, updated_at
+		FROM saml_connections
+		WHERE workspace = $1
+	`, workspace).Scan(
+		&connection.IdPEntityID,
+		&connection.SSOURL,
+		&connection.Certificate,
+		&connection.RoleAttribute,
+		&connection.CreatedAt,
+		&connection.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrSAMLConnectionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saml connection: %w", err)
+	}
+	return connection, nil
+}
+
+// Save creates or replaces the workspace's connection
+func (r *samlConnectionRepository) Save(ctx context.Context, connection *domain.SAMLConnection) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO saml_connections (workspace, idp_entity_id, sso_url, certificate, role_attribute)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (workspace) DO UPDATE
+		SET idp_entity_id = EXCLUDED.idp_entity_id,
+		    sso_url = EXCLUDED.sso_url,
+		    certificate = EXCLUDED.certificate,
+		    role_attribute = EXCLUDED.role_attribute,
+		    updated_at = CURRENT_TIMESTAMP
+		RETURNING created_at, updated_at
+	`,
+		connection.Workspace,
+		connection.IdPEntityID,
+		connection.SSOURL,
+		connection.Certificate,
+		connection.RoleAttribute,
+	).Scan(&connection.CreatedAt, &connection.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save saml connection: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the connection
+// Sessions already issued run out on their own (they are short-lived)
+func (r *samlConnectionRepository) Delete(ctx context.Context, workspace string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM saml_connections WHERE workspace = $1`, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to delete saml connection: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrSAMLConnectionNotFound
+	}
+	return nil
+}
+
+// sessionRepository is the PostgreSQL implementation of repository.SessionRepository
+type sessionRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewSessionRepository creates a new PostgreSQL session repository
+func NewSessionRepository(db *pgxpool.Pool) repository.SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// CreateFromAssertion records the assertion and creates the session
+// Expired assertions and sessions are cleared on the way: sign-ins are rare
+// enough that this keeps both tables small without a cleanup job
+func (r *sessionRepository) CreateFromAssertion(ctx context.Context, assertionID string, assertionExpiresAt time.Time, tokenHash string, session *domain.Session) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM saml_assertions WHERE expires_at < CURRENT_TIMESTAMP`); err != nil {
+		return fmt.Errorf("failed to clear expired assertions: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM sso_sessions WHERE expires_at < CURRENT_TIMESTAMP`); err != nil {
+		return fmt.Errorf("failed to clear expired sessions: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO saml_assertions (workspace, id, expires_at)
+		VALUES ($1, $2, $3)
+	`, session.Workspace, assertionID, assertionExpiresAt)
+	if isUniqueViolation(err) {
+		return domain.ErrAssertionReplayed
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record assertion: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO sso_sessions (token_hash, workspace, member, role, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, tokenHash, session.Workspace, session.Member, session.Role, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit session: %w", err)
+	}
+	return nil
+}
+
+// Get returns an unexpired session, with the plan of its workspace
+func (r *sessionRepository) Get(ctx context.Context, tokenHash string) (*domain.Session, error) {
+	session := &domain.Session{}
+	err := r.db.QueryRow(ctx, `
+		SELECT workspace, member, role, expires_at,
+		       COALESCE((SELECT plan FROM workspace_settings s WHERE s.workspace = sso_sessions.workspace), '')
+		FROM sso_sessions
+		WHERE token_hash = $1 AND expires_at > CURRENT_TIMESTAMP
+	`, tokenHash).Scan(&session.Workspace, &session.Member, &session.Role, &session.ExpiresAt, &session.Plan)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return session, nil
+}
+
+// Delete ends a session
+func (r *sessionRepository) Delete(ctx context.Context, tokenHash string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM sso_sessions WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrSessionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// subscriptionColumns lists the columns scanSubscription reads, in order
+const subscriptionColumns = `owner, stripe_customer_id, stripe_subscription_id, plan, status, current_period_end, last_event_at`
+
+// subscriptionRepository is the PostgreSQL implementation of repository.SubscriptionRepository
+type subscriptionRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewSubscriptionRepository creates a new PostgreSQL subscription repository
+func NewSubscriptionRepository(db *pgxpool.Pool) repository.SubscriptionRepository {
+	return &subscriptionRepository{db: db}
+}
+
+// LinkCustomer creates the owner's row on first checkout
+// A later checkout for the same owner only updates the customer ID
+func (r *subscriptionRepository) LinkCustomer(ctx context.Context, owner, customerID string) error {
+	query := `
+		INSERT INTO subscriptions (owner, stripe_customer_id)
+		VALUES ($1, $2)
+		ON CONFLICT (owner) DO UPDATE
+		SET stripe_customer_id = EXCLUDED.stripe_customer_id, updated_at = CURRENT_TIMESTAMP
+	`
+
+	if _, err := r.db.Exec(ctx, query, owner, customerID); err != nil {
+		return fmt.Errorf("failed to link stripe customer: %w", err)
+	}
+	return nil
+}
+
+// Apply upserts the subscription, guarded by the event timestamp
+//
+// The WHERE on the conflict branch is what makes out-of-order delivery safe:
+// if the stored row came from a newer event, nothing is updated and
+// RETURNING yields no row.
+func (r *subscriptionRepository) Apply(ctx context.Context, sub *domain.Subscription) (bool, error) {
+	query := `
+		INSERT INTO subscriptions (owner, stripe_customer_id, stripe_subscription_id, plan, status, current_period_end, last_event_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (owner) DO UPDATE
+		SET stripe_customer_id = EXCLUDED.stripe_customer_id,
+			stripe_subscription_id = EXCLUDED.stripe_subscription_id,
+			plan = EXCLUDED.plan,
+			status = EXCLUDED.status,
+			current_period_end = EXCLUDED.current_period_end,
+			last_event_at = EXCLUDED.last_event_at,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE subscriptions.last_event_at <= EXCLUDED.last_event_at
+		RETURNING owner
+	`
+
+	var periodEnd *time.Time
+	if !sub.CurrentPeriodEnd.IsZero() {
+		periodEnd = &sub.CurrentPeriodEnd
+	}
+
+	var owner string
+	err := r.db.QueryRow(ctx, query,
+		sub.Owner,
+		sub.CustomerID,
+		sub.SubscriptionID,
+		sub.Plan,
+		sub.Status,
+		periodEnd,
+		sub.LastEventAt,
+	).Scan(&owner)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil // Stale event
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to apply subscription: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetByOwner retrieves the owner's subscription
+func (r *subscriptionRepository) GetByOwner(ctx context.Context, owner string) (*domain.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE owner = $1`
+	return scanSubscription(r.db.QueryRow(ctx, query, owner))
+}
+
+// GetByCustomer retrieves the subscription of a Stripe customer
+func (r *subscriptionRepository) GetByCustomer(ctx context.Context, customerID string) (*domain.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE stripe_customer_id = $1`
+	return scanSubscription(r.db.QueryRow(ctx, query, customerID))
+}
+
+// scanSubscription reads one row selected with subscriptionColumns
+func scanSubscription(row pgx.Row) (*domain.Subscription, error) {
+	var sub domain.Subscription
+	var periodEnd *time.Time
+
+	err := row.Scan(
+		&sub.Owner,
+		&sub.CustomerID,
+		&sub.SubscriptionID,
+		&sub.Plan,
+		&sub.Status,
+		&periodEnd,
+		&sub.LastEventAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	if periodEnd != nil {
+		sub.CurrentPeriodEnd = *periodEnd
+	}
+	return &sub, nil
+}
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// templateColumns lists the template columns in the order scanTemplate reads them
+// The settings are JSONB: pgx encodes and decodes domain.LinkSettings as JSON
+const templateColumns = `id, owner, name, settings, created_at, updated_at`
+
+// templateRepository is the PostgreSQL implementation of repository.TemplateRepository
+type templateRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewTemplateRepository creates a new PostgreSQL template repository
+func NewTemplateRepository(db *pgxpool.Pool) repository.TemplateRepository {
+	return &templateRepository{db: db}
+}
+
+// Create inserts a template
+func (r *templateRepository) Create(ctx context.Context, template *domain.LinkTemplate) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO link_templates (owner, name, settings)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`, template.Owner, template.Name, template.Settings).Scan(&template.ID, &template.CreatedAt, &template.UpdatedAt)
+	if isUniqueViolation(err) {
+		return domain.ErrTemplateNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create template: %w", err)
+	}
+	return nil
+}
+
+// Update replaces the template's name and settings
+func (r *templateRepository) Update(ctx context.Context, template *domain.LinkTemplate) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE link_templates
+		SET name = $2, settings = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at
+	`, template.ID, template.Name, template.Settings).Scan(&template.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrTemplateNotFound
+	}
+	if isUniqueViolation(err) {
+		return domain.ErrTemplateNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update template: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns one template
+func (r *templateRepository) GetByID(ctx context.Context, id string) (*domain.LinkTemplate, error) {
+	template, err := scanTemplate(r.db.QueryRow(ctx,
+		`SELECT `+templateColumns+` FROM link_templates WHERE id = $1`,
+		id,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	return template, nil
+}
+
+// ListByOwner returns the owner's templates sorted by name
+func (r *templateRepository) ListByOwner(ctx context.Context, owner string) ([]*domain.LinkTemplate, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+templateColumns+` FROM link_templates WHERE owner = $1 ORDER BY name`,
+		owner,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*domain.LinkTemplate
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// Delete removes a template
+func (r *templateRepository) Delete(ctx context.Context, id string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM link_templates WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrTemplateNotFound
+	}
+	return nil
+}
+
+// scanTemplate scans a row selected with templateColumns
+func scanTemplate(row pgx.Row) (*domain.LinkTemplate, error) {
+	template := &domain.LinkTemplate{}
+	err := row.Scan(
+		&template.ID,
+		&template.Owner,
+		&template.Name,
+		&template.Settings,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	)
+	return template, err
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand/v2"
 	"time"
 
 	"url-shortener/internal/domain"
 	"url-shortener/internal/repository"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,7 +19,8 @@ import (
 // The lowercase name means it's private to this package
 // We return it as the interface type (repository.URLRepository) for abstraction
 type urlRepository struct {
-	db *pgxpool.Pool // Connection pool for database connections
+	db      *pgxpool.Pool // Connection pool for database connections
+	stripes int           // Click counter rows per link (1 = the urls row itself)
 }
 
 // NewURLRepository creates a new PostgreSQL URL repository
@@ -27,7 +30,14 @@ type urlRepository struct {
 // Instead of opening a new connection for each query (slow!),
 // we maintain a pool of reusable connections. This dramatically improves performance.
 func NewURLRepository(db *pgxpool.Pool) repository.URLRepository {
-	return &urlRepository{db: db}
+	return &urlRepository{db: db, stripes: 1}
+}
+
+// NewStripedURLRepository creates a URL repository that counts the clicks
+// of each link in stripes rows of url_click_stripes (see IncrementClicks)
+// stripes <= 1 counts on the urls row, like NewURLRepository.
+func NewStripedURLRepository(db *pgxpool.Pool, stripes int) repository.URLRepository {
+	return &urlRepository{db: db, stripes: max(stripes, 1)}
 }
 
 // Create inserts a new URL into the database
@@ -37,12 +47,21 @@ func (r *urlRepository) Create(ctx context.Context, url *domain.URL) error {
 	query := `
 		INSERT INTO urls (
 			short_code, original_url, custom_alias, created_at,
-			expires_at, created_by, is_active, clicks
+			expires_at, created_by, is_active, clicks, resolved_url,
+			max_clicks, domain, preview_title, preview_description,
+			preview_image_url, language_targets, schedule, signing_secret,
+			single_use, burn_after_reading, payload, file, paste,
+			analytics_disabled, description, custom_metadata,
+			approval_status, submitted_by, visibility, sealed_destination
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8
-		) RETURNING id
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
+			$16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28,
+			$29
+		) RETURNING id, version
 	`
 
+	previewTitle, previewDescription, previewImage := previewValues(url.Preview)
+
 	// QueryRow executes the query and scans the result into url.ID
 	// ctx is used for timeouts and cancellation
 	err := r.db.QueryRow(
@@ -56,8 +75,34 @@ func (r *urlRepository) Create(ctx context.Context, url *domain.URL) error {
 		url.CreatedBy,
 		url.IsActive,
 		url.Clicks,
-	).Scan(&url.ID)
-
+		url.ResolvedURL, // Can be nil when resolution is disabled
+		url.MaxClicks,   // Can be nil (unlimited)
+		url.Domain,
+		previewTitle, // All three NULL when there is no preview card
+		previewDescription,
+		previewImage,
+		url.LanguageTargets, // pgx encodes the map as JSONB
+		url.Schedule,        // ... and the schedule (nil = NULL)
+		url.SigningSecret,   // nil for ordinary links
+		url.SingleUse,
+		url.BurnAfterReading,
+		url.Payload, // Sealed by the service - never plaintext
+		url.File,    // JSONB like the schedule (nil = NULL)
+		url.Paste,   // ... and the paste
+		url.AnalyticsDisabled,
+		url.Description,
+		url.CustomMetadata, // JSONB like the language targets (nil = NULL)
+		string(url.Approval),
+		url.SubmittedBy,
+		string(url.Visibility),
+		url.SealedDestination, // Sealed by the service - never plaintext
+	).Scan(&url.ID, &url.Version)
+
+	if isUniqueViolation(err) {
+		// Someone else took the code between our existence check and this INSERT
+		// The UNIQUE constraints are the real guarantee - the check is just a fast path
+		return domain.ErrShortCodeTaken
+	}
 	if err != nil {
 		// Wrap the error with context for better debugging
 		return fmt.Errorf("failed to create URL: %w", err)
@@ -69,31 +114,23 @@ func (r *urlRepository) Create(ctx context.Context, url *domain.URL) error {
 // GetByShortCode retrieves a URL by its short code
 func (r *urlRepository) GetByShortCode(ctx context.Context, shortCode string) (*domain.URL, error) {
 	query := `
-		SELECT id, short_code, original_url, custom_alias, created_at,
-		       expires_at, clicks, created_by, is_active
+		SELECT ` + urlColumns + `, ` + pendingClicks + `
 		FROM urls
 		WHERE short_code = $1 AND is_active = true
 	`
 
-	url := &domain.URL{}
-
-	// QueryRow returns a single row
-	err := r.db.QueryRow(ctx, query, shortCode).Scan(
-		&url.ID,
-		&url.ShortCode,
-		&url.OriginalURL,
-		&url.CustomAlias, // pgx handles NULL -> nil conversion automatically
-		&url.CreatedAt,
-		&url.ExpiresAt,
-		&url.Clicks,
-		&url.CreatedBy,
-		&url.IsActive,
-	)
-
+	url, err := scanCountedURL(r.db.QueryRow(ctx, query, shortCode))
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Not in the hot table - it may be archived
+		url, err = r.rehydrate(ctx, "short_code", shortCode)
+		if err == nil && !url.IsActive {
+			err = pgx.ErrNoRows
+		}
+	}
 	if err != nil {
 		// pgx.ErrNoRows is returned when no rows match the query
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("URL not found: %s", shortCode)
+			return nil, fmt.Errorf("%w: %s", domain.ErrURLNotFound, shortCode)
 		}
 		return nil, fmt.Errorf("failed to get URL: %w", err)
 	}
@@ -104,28 +141,18 @@ func (r *urlRepository) GetByShortCode(ctx context.Context, shortCode string) (*
 // GetByID retrieves a URL by its UUID
 func (r *urlRepository) GetByID(ctx context.Context, id string) (*domain.URL, error) {
 	query := `
-		SELECT id, short_code, original_url, custom_alias, created_at,
-		       expires_at, clicks, created_by, is_active
+		SELECT ` + urlColumns + `, ` + pendingClicks + `
 		FROM urls
 		WHERE id = $1
 	`
 
-	url := &domain.URL{}
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&url.ID,
-		&url.ShortCode,
-		&url.OriginalURL,
-		&url.CustomAlias,
-		&url.CreatedAt,
-		&url.ExpiresAt,
-		&url.Clicks,
-		&url.CreatedBy,
-		&url.IsActive,
-	)
-
+	url, err := scanCountedURL(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		url, err = r.rehydrate(ctx, "id", id)
+	}
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("URL not found: %s", id)
+			return nil, fmt.Errorf("%w: %s", domain.ErrURLNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get URL: %w", err)
 	}
@@ -136,28 +163,21 @@ func (r *urlRepository) GetByID(ctx context.Context, id string) (*domain.URL, er
 // GetByCustomAlias retrieves a URL by its custom alias
 func (r *urlRepository) GetByCustomAlias(ctx context.Context, alias string) (*domain.URL, error) {
 	query := `
-		SELECT id, short_code, original_url, custom_alias, created_at,
-		       expires_at, clicks, created_by, is_active
+		SELECT ` + urlColumns + `, ` + pendingClicks + `
 		FROM urls
 		WHERE custom_alias = $1 AND is_active = true
 	`
 
-	url := &domain.URL{}
-	err := r.db.QueryRow(ctx, query, alias).Scan(
-		&url.ID,
-		&url.ShortCode,
-		&url.OriginalURL,
-		&url.CustomAlias,
-		&url.CreatedAt,
-		&url.ExpiresAt,
-		&url.Clicks,
-		&url.CreatedBy,
-		&url.IsActive,
-	)
-
+	url, err := scanCountedURL(r.db.QueryRow(ctx, query, alias))
+	if errors.Is(err, pgx.ErrNoRows) {
+		url, err = r.rehydrate(ctx, "custom_alias", alias)
+		if err == nil && !url.IsActive {
+			err = pgx.ErrNoRows
+		}
+	}
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("URL not found: %s", alias)
+			return nil, fmt.Errorf("%w: %s", domain.ErrURLNotFound, alias)
 		}
 		return nil, fmt.Errorf("failed to get URL: %w", err)
 	}
@@ -165,34 +185,84 @@ func (r *urlRepository) GetByCustomAlias(ctx context.Context, alias string) (*do
 	return url, nil
 }
 
-// Update modifies an existing URL
+// Update modifies an existing URL if nobody changed it since it was read
 func (r *urlRepository) Update(ctx context.Context, url *domain.URL) error {
 	query := `
 		UPDATE urls
-		SET original_url = $1, custom_alias = $2, expires_at = $3, is_active = $4
-		WHERE id = $5
+		SET original_url = $1, custom_alias = $2, expires_at = $3, is_active = $4,
+		    resolved_url = $5, max_clicks = $6, version = version + 1,
+		    -- A new destination makes the old page metadata wrong
+		    meta_title = CASE WHEN original_url = $1 THEN meta_title END,
+		    meta_description = CASE WHEN original_url = $1 THEN meta_description END,
+		    meta_favicon_url = CASE WHEN original_url = $1 THEN meta_favicon_url END,
+		    meta_fetched_at = CASE WHEN original_url = $1 THEN meta_fetched_at END,
+		    preview_title = $9, preview_description = $10, preview_image_url = $11,
+		    language_targets = $12, schedule = $13,
+		    description = $14, custom_metadata = $15, approval_status = $16,
+		    visibility = $17, sealed_destination = $18
+		WHERE id = $7 AND version = $8
+		RETURNING version
 	`
 
-	// Exec executes a query that doesn't return rows
-	result, err := r.db.Exec(
+	previewTitle, previewDescription, previewImage := previewValues(url.Preview)
+	err := r.db.QueryRow(
 		ctx,
 		query,
 		url.OriginalURL,
 		url.CustomAlias,
 		url.ExpiresAt,
 		url.IsActive,
+		url.ResolvedURL,
+		url.MaxClicks,
 		url.ID,
-	)
-
+		url.Version,
+		previewTitle,
+		previewDescription,
+		previewImage,
+		url.LanguageTargets,
+		url.Schedule,
+		url.Description,
+		url.CustomMetadata,
+		string(url.Approval),
+		string(url.Visibility),
+		url.SealedDestination,
+	).Scan(&url.Version)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Either the URL is gone or someone else updated it first
+		var exists bool
+		if err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM urls WHERE id = $1)`, url.ID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to update URL: %w", err)
+		}
+		if exists {
+			return fmt.Errorf("%w: %s", domain.ErrVersionConflict, url.ID)
+		}
+		return fmt.Errorf("%w: %s", domain.ErrURLNotFound, url.ID)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update URL: %w", err)
 	}
 
-	// Check if any rows were affected
+	return nil
+}
+
+// SetMetadata stores the destination page metadata
+// The version is NOT bumped: metadata is derived from the destination, not
+// edited by the owner, so it must not invalidate ETags clients hold
+func (r *urlRepository) SetMetadata(ctx context.Context, id string, meta *domain.LinkMetadata) error {
+	query := `
+		UPDATE urls
+		SET meta_title = $2, meta_description = $3, meta_favicon_url = $4, meta_fetched_at = $5
+		WHERE id = $1
+	`
+
+	result, err := r.db.Exec(ctx, query, id, meta.Title, meta.Description, meta.FaviconURL, meta.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set URL metadata: %w", err)
+	}
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("URL not found: %s", url.ID)
+		return fmt.Errorf("%w: %s", domain.ErrURLNotFound, id)
 	}
-
 	return nil
 }
 
@@ -206,37 +276,166 @@ func (r *urlRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("URL not found: %s", id)
+		return fmt.Errorf("%w: %s", domain.ErrURLNotFound, id)
 	}
 
 	return nil
 }
 
+// Restore reactivates a soft-deleted URL
+func (r *urlRepository) Restore(ctx context.Context, id string) error {
+	query := `UPDATE urls SET is_active = true WHERE id = $1 AND is_active = false`
+
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore URL: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w or not deleted: %s", domain.ErrURLNotFound, id)
+	}
+
+	return nil
+}
+
+// Purge permanently deletes a URL and all of its analytics
+// Runs in a TRANSACTION: either the URL and its clicks are all gone, or nothing changes
+// Returns how many click events were removed
+func (r *urlRepository) Purge(ctx context.Context, id string) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op after a successful Commit
+	defer tx.Rollback(ctx)
+
+	// Clicks don't cascade (they outlive archiving, see migration 019)
+	clicks, err := tx.Exec(ctx, `DELETE FROM url_clicks WHERE url_id = $1`, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge clicks: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM url_click_rollups WHERE url_id = $1`, id); err != nil {
+		return 0, fmt.Errorf("failed to purge click rollups: %w", err)
+	}
+
+	result, err := tx.Exec(ctx, `DELETE FROM urls WHERE id = $1`, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge URL: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return 0, fmt.Errorf("%w: %s", domain.ErrURLNotFound, id)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit purge: %w", err)
+	}
+
+	return clicks.RowsAffected(), nil
+}
+
 // IncrementClicks atomically increases the click counter
 // ATOMIC OPERATION: This happens in a single database operation,
 // preventing race conditions when multiple requests access the same URL simultaneously
+//
+// STRIPED COUNTERS:
+// Atomic is not the same as fast. Every UPDATE of a row waits for the lock
+// held by the previous one, so a viral link counts its clicks one at a time.
+// With stripes > 1 a click adds 1 to one of the link's rows in
+// url_click_stripes, picked at random: N clicks can be counted side by
+// side. Reads add the stripes back (pendingClicks), and the click fold
+// worker moves them into urls.clicks (see FoldClickStripes).
 func (r *urlRepository) IncrementClicks(ctx context.Context, shortCode string) error {
 	query := `
 		UPDATE urls
 		SET clicks = clicks + 1
 		WHERE short_code = $1 AND is_active = true
 	`
+	args := []any{shortCode}
+	if r.stripes > 1 {
+		// INSERT ... SELECT adds nothing for unknown or inactive links, so
+		// "0 rows" still means "not found"
+		query = `
+			INSERT INTO url_click_stripes (url_id, stripe, clicks)
+			SELECT id, $2, 1 FROM urls
+			WHERE short_code = $1 AND is_active = true
+			ON CONFLICT (url_id, stripe)
+			DO UPDATE SET clicks = url_click_stripes.clicks + 1
+		`
+		args = append(args, rand.IntN(r.stripes))
+	}
 
-	result, err := r.db.Exec(ctx, query, shortCode)
+	result, err := r.db.Exec(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to increment clicks: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("URL not found or inactive: %s", shortCode)
+		return fmt.Errorf("%w or inactive: %s", domain.ErrURLNotFound, shortCode)
 	}
 
 	return nil
 }
 
+// MarkUsed uses up a single-use link
+// Returns false when the link was already used - or is not single-use,
+// inactive or unknown
+//
+// RACE-FREE: the WHERE clause is checked and the row updated in one
+// statement, and Postgres locks the row while doing it. Of two requests
+// arriving together, the second re-checks "used_at IS NULL" after the
+// first commits, matches nothing and gets 0 rows affected.
+func (r *urlRepository) MarkUsed(ctx context.Context, shortCode string) (bool, error) {
+	query := `
+		UPDATE urls
+		SET used_at = NOW()
+		WHERE short_code = $1 AND single_use AND used_at IS NULL AND is_active = true
+	`
+
+	result, err := r.db.Exec(ctx, query, shortCode)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark URL used: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// Burn reveals a burn-after-reading link and destroys its payload
+//
+// The CTE reads the payload and locks the row (FOR UPDATE); the UPDATE then
+// clears it. A second request waits for the lock, re-checks
+// "burned_at IS NULL" once the first commits, and finds nothing - so the
+// payload is handed out exactly once. RETURNING gives the OLD value from
+// the CTE, as the row itself now holds NULL.
+func (r *urlRepository) Burn(ctx context.Context, shortCode string) (*string, error) {
+	query := `
+		WITH target AS (
+			SELECT id, payload
+			FROM urls
+			WHERE short_code = $1 AND burn_after_reading AND burned_at IS NULL AND is_active = true
+			FOR UPDATE
+		)
+		UPDATE urls
+		SET payload = NULL, burned_at = NOW()
+		FROM target
+		WHERE urls.id = target.id
+		RETURNING target.payload
+	`
+
+	var payload *string
+	err := r.db.QueryRow(ctx, query, shortCode).Scan(&payload)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to burn URL: %w", err)
+	}
+	return payload, nil
+}
+
 // ExistsShortCode checks if a short code already exists
 func (r *urlRepository) ExistsShortCode(ctx context.Context, shortCode string) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM urls WHERE short_code = $1)`
+	// Archived links keep their code: it must not be handed out again
+	query := `SELECT EXISTS(SELECT 1 FROM urls WHERE short_code = $1)
+	             OR EXISTS(SELECT 1 FROM urls_archive WHERE short_code = $1)`
 
 	var exists bool
 	err := r.db.QueryRow(ctx, query, shortCode).Scan(&exists)
@@ -249,7 +448,8 @@ func (r *urlRepository) ExistsShortCode(ctx context.Context, shortCode string) (
 
 // ExistsCustomAlias checks if a custom alias is already taken
 func (r *urlRepository) ExistsCustomAlias(ctx context.Context, alias string) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM urls WHERE custom_alias = $1)`
+	query := `SELECT EXISTS(SELECT 1 FROM urls WHERE custom_alias = $1)
+	             OR EXISTS(SELECT 1 FROM urls_archive WHERE custom_alias = $1)`
 
 	var exists bool
 	err := r.db.QueryRow(ctx, query, alias).Scan(&exists)
@@ -260,6 +460,189 @@ func (r *urlRepository) ExistsCustomAlias(ctx context.Context, alias string) (bo
 	return exists, nil
 }
 
+// FindTakenCodes returns which of the given codes are already in use
+// = ANY($1) sends the whole list as a single array parameter, so checking
+// 20 candidates costs one round trip instead of 20
+func (r *urlRepository) FindTakenCodes(ctx context.Context, codes []string) (map[string]bool, error) {
+	query := `
+		SELECT short_code FROM urls WHERE short_code = ANY($1)
+		UNION
+		SELECT custom_alias FROM urls WHERE custom_alias = ANY($1)
+		UNION
+		SELECT short_code FROM urls_archive WHERE short_code = ANY($1)
+		UNION
+		SELECT custom_alias FROM urls_archive WHERE custom_alias = ANY($1)
+	`
+
+	rows, err := r.db.Query(ctx, query, codes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check codes: %w", err)
+	}
+	defer rows.Close()
+
+	taken := make(map[string]bool)
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, fmt.Errorf("failed to scan code: %w", err)
+		}
+		taken[code] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating codes: %w", err)
+	}
+
+	return taken, nil
+}
+
+// urlColumns is the column list shared by every query that loads a full URL
+// Keep it in sync with scanURL. It names EVERY column of urls: the archive
+// moves rows between urls and urls_archive with it. (search_vector is left
+// out: Postgres computes it, see migration 037.)
+const urlColumns = `id, short_code, original_url, custom_alias, created_at,
+		       expires_at, clicks, created_by, is_active, resolved_url, max_clicks,
+		       domain, version, meta_title, meta_description, meta_favicon_url,
+		       meta_fetched_at, preview_title, preview_description,
+		       preview_image_url, language_targets, schedule, signing_secret,
+		       single_use, used_at, burn_after_reading, payload, burned_at,
+		       file, paste, analytics_disabled, description, custom_metadata,
+		       approval_status, submitted_by, visibility, sealed_destination`
+
+// scanURL scans a row selected with urlColumns into a domain.URL
+// extra receives any columns selected after urlColumns
+func scanURL(row pgx.Row, extra ...any) (*domain.URL, error) {
+	url := &domain.URL{}
+	var metaTitle, metaDescription, metaFavicon *string
+	var metaFetchedAt *time.Time
+	var previewTitle, previewDescription, previewImage *string
+	var approval, visibility string
+	dest := []any{
+		&url.ID,
+		&url.ShortCode,
+		&url.OriginalURL,
+		&url.CustomAlias, // pgx handles NULL -> nil conversion automatically
+		&url.CreatedAt,
+		&url.ExpiresAt,
+		&url.Clicks,
+		&url.CreatedBy,
+		&url.IsActive,
+		&url.ResolvedURL,
+		&url.MaxClicks,
+		&url.Domain,
+		&url.Version,
+		&metaTitle,
+		&metaDescription,
+		&metaFavicon,
+		&metaFetchedAt,
+		&previewTitle,
+		&previewDescription,
+		&previewImage,
+		&url.LanguageTargets, // NULL -> nil map
+		&url.Schedule,        // NULL -> nil
+		&url.SigningSecret,   // NULL -> nil
+		&url.SingleUse,
+		&url.UsedAt, // NULL until a single-use link is used
+		&url.BurnAfterReading,
+		&url.Payload,  // NULL once revealed
+		&url.BurnedAt, // NULL until revealed
+		&url.File,     // NULL for links that redirect
+		&url.Paste,    // NULL for links that redirect
+		&url.AnalyticsDisabled,
+		&url.Description,
+		&url.CustomMetadata, // NULL -> nil map
+		&approval,
+		&url.SubmittedBy,
+		&visibility,
+		&url.SealedDestination,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return url, err
+	}
+	url.Approval = domain.ApprovalStatus(approval)
+	url.Visibility = domain.LinkVisibility(visibility)
+
+	// meta_fetched_at is set by every fetch, so it tells whether one ran
+	if metaFetchedAt != nil {
+		url.Metadata = &domain.LinkMetadata{
+			Title:       deref(metaTitle),
+			Description: deref(metaDescription),
+			FaviconURL:  deref(metaFavicon),
+			FetchedAt:   *metaFetchedAt,
+		}
+	}
+	// Saved cards write all three columns (empty fields as ''), so any
+	// non-NULL column means there is a card
+	if previewTitle != nil || previewDescription != nil || previewImage != nil {
+		url.Preview = &domain.PreviewCard{
+			Title:       deref(previewTitle),
+			Description: deref(previewDescription),
+			ImageURL:    deref(previewImage),
+		}
+	}
+	return url, nil
+}
+
+// pendingClicks selects the clicks of a urls row still in stripes
+// Only the lookups of this repository add them; lists, exports and stats
+// read urls.clicks, which is at most one fold interval behind
+const pendingClicks = `(SELECT COALESCE(SUM(s.clicks), 0)::BIGINT FROM url_click_stripes s WHERE s.url_id = urls.id)`
+
+// scanCountedURL scans a row selected with urlColumns and pendingClicks,
+// adding the pending clicks to the counter
+func scanCountedURL(row pgx.Row) (*domain.URL, error) {
+	var pending int64
+	url, err := scanURL(row, &pending)
+	if err != nil {
+		return url, err
+	}
+	url.Clicks += pending
+	return url, nil
+}
+
+// rehydrate moves an archived link back into urls and returns it
+// column is one of id, short_code or custom_alias (never user input)
+//
+// Same single-statement move as archiving, in the other direction. A code
+// taken again meanwhile makes the INSERT fail, so the link stays archived
+// and the lookup reports "not found".
+func (r *urlRepository) rehydrate(ctx context.Context, column, value string) (*domain.URL, error) {
+	url, err := scanURL(r.db.QueryRow(ctx, `
+		WITH moved AS (
+			DELETE FROM urls_archive WHERE `+column+` = $1
+			RETURNING `+urlColumns+`
+		)
+		INSERT INTO urls (`+urlColumns+`)
+		SELECT `+urlColumns+` FROM moved
+		RETURNING `+urlColumns,
+		value,
+	))
+	if isUniqueViolation(err) {
+		return nil, pgx.ErrNoRows
+	}
+	return url, err
+}
+
+// collectURLs scans every row selected with urlColumns
+func collectURLs(rows pgx.Rows) ([]*domain.URL, error) {
+	defer rows.Close()
+
+	var urls []*domain.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan URL: %w", err)
+		}
+		urls = append(urls, url)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating URLs: %w", err)
+	}
+
+	return urls, nil
+}
+
 // InitDB initializes the database connection pool
 // This is called once at application startup
 func InitDB(ctx context.Context, dsn string, maxConns, minConns int, maxLifetime time.Duration) (*pgxpool.Pool, error) {
@@ -283,9 +666,33 @@ func InitDB(ctx context.Context, dsn string, maxConns, minConns int, maxLifetime
 	}
 
 	// Test the connection
+	// Close the pool on failure: startup retries would leak one per attempt
 	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	return pool, nil
 }
+
+// previewValues returns the preview_* column values for card (NULLs for nil)
+func previewValues(card *domain.PreviewCard) (title, description, imageURL *string) {
+	if card == nil {
+		return nil, nil, nil
+	}
+	return &card.Title, &card.Description, &card.ImageURL
+}
+
+// deref returns the value of a nullable text column ("" for NULL)
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// isUniqueViolation reports whether err is a UNIQUE constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
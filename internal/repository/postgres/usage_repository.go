@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// usageRepository is the PostgreSQL implementation of repository.UsageRepository
+type usageRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewUsageRepository creates a new PostgreSQL usage repository
+func NewUsageRepository(db *pgxpool.Pool) repository.UsageRepository {
+	return &usageRepository{db: db}
+}
+
+// Increment counts one more link for the owner, unless the limit is reached
+//
+// UPSERT WITH A CONDITION: the first link of the month INSERTs the row, later
+// ones hit ON CONFLICT and UPDATE it - but only WHERE the counter is still
+// below the limit. When the WHERE fails, nothing is updated and RETURNING
+// yields no row. Postgres locks the row for the update, so two concurrent
+// requests for the last free link can't both succeed.
+func (r *usageRepository) Increment(ctx context.Context, owner string, period time.Time, limit int64) (int64, bool, error) {
+	query := `
+		INSERT INTO link_usage (owner, period_start, links)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (owner, period_start) DO UPDATE
+		SET links = link_usage.links + 1
+		WHERE $3 = 0 OR link_usage.links < $3
+		RETURNING links
+	`
+
+	var used int64
+	err := r.db.QueryRow(ctx, query, owner, period, limit).Scan(&used)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Limit reached - report the current value
+		used, err = r.Get(ctx, owner, period)
+		return used, false, err
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to increment link usage: %w", err)
+	}
+
+	return used, true, nil
+}
+
+// Decrement gives one link back, never going below zero
+func (r *usageRepository) Decrement(ctx context.Context, owner string, period time.Time) error {
+	query := `
+		UPDATE link_usage
+		SET links = GREATEST(links - 1, 0)
+		WHERE owner = $1 AND period_start = $2
+	`
+
+	if _, err := r.db.Exec(ctx, query, owner, period); err != nil {
+		return fmt.Errorf("failed to decrement link usage: %w", err)
+	}
+	return nil
+}
+
+// Get returns the owner's counter for the period (0 when there is no row yet)
+func (r *usageRepository) Get(ctx context.Context, owner string, period time.Time) (int64, error) {
+	query := `SELECT links FROM link_usage WHERE owner = $1 AND period_start = $2`
+
+	var used int64
+	err := r.db.QueryRow(ctx, query, owner, period).Scan(&used)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get link usage: %w", err)
+	}
+
+	return used, nil
+}
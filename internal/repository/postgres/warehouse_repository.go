@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// warehouseRepository is the PostgreSQL implementation of repository.WarehouseRepository
+type warehouseRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewWarehouseRepository creates a new PostgreSQL warehouse export repository
+func NewWarehouseRepository(db *pgxpool.Pool) repository.WarehouseRepository {
+	return &warehouseRepository{db: db}
+}
+
+// ListClicksAfter pages through url_clicks by ID (KEYSET pagination)
+// The short code comes from urls or, for archived links, urls_archive;
+// clicks of purged links keep an empty code
+func (r *warehouseRepository) ListClicksAfter(ctx context.Context, afterID int64, before time.Time, limit int) ([]*domain.URLClick, error) {
+	query := `
+		SELECT c.id, c.url_id, COALESCE(u.short_code, a.short_code, ''), c.clicked_at,
+		       COALESCE(c.referer, ''), COALESCE(c.country_code, ''), COALESCE(c.city, ''),
+		       COALESCE(c.channel, ''), COALESCE(c.browser, ''), COALESCE(c.os, ''),
+		       COALESCE(c.device_type, ''), COALESCE(c.region, '')
+		FROM url_clicks c
+		LEFT JOIN urls u ON u.id = c.url_id
+		LEFT JOIN urls_archive a ON a.id = c.url_id
+		WHERE c.id > $1 AND c.clicked_at < $2
+		ORDER BY c.id
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, afterID, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clicks for export: %w", err)
+	}
+	defer rows.Close()
+
+	var clicks []*domain.URLClick
+	for rows.Next() {
+		click := &domain.URLClick{}
+		err := rows.Scan(
+			&click.ID,
+			&click.URLID,
+			&click.ShortCode,
+			&click.ClickedAt,
+			&click.Referer,
+			&click.CountryCode,
+			&click.City,
+			&click.Channel,
+			&click.Browser,
+			&click.OS,
+			&click.DeviceType,
+			&click.Region,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan click: %w", err)
+		}
+		clicks = append(clicks, click)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating clicks: %w", err)
+	}
+
+	return clicks, nil
+}
+
+// GetCheckpoint returns 0 for sources that were never exported
+func (r *warehouseRepository) GetCheckpoint(ctx context.Context, name string) (int64, error) {
+	var lastID int64
+	err := r.db.QueryRow(ctx, `SELECT last_click_id FROM etl_checkpoints WHERE name = $1`, name).Scan(&lastID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get export checkpoint: %w", err)
+	}
+	return lastID, nil
+}
+
+// SaveCheckpoint upserts the checkpoint, keeping the higher ID
+func (r *warehouseRepository) SaveCheckpoint(ctx context.Context, name string, lastID int64) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO etl_checkpoints (name, last_click_id, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO UPDATE
+		SET last_click_id = GREATEST(etl_checkpoints.last_click_id, EXCLUDED.last_click_id),
+		    updated_at = NOW()
+	`, name, lastID)
+	if err != nil {
+		return fmt.Errorf("failed to save export checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// warningRepository is the PostgreSQL implementation of repository.WarningRepository
+type warningRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewWarningRepository creates a new PostgreSQL warning repository
+func NewWarningRepository(db *pgxpool.Pool) repository.WarningRepository {
+	return &warningRepository{db: db}
+}
+
+// FindNearClickLimit returns URLs that used at least ratio of their click limit
+// NOT EXISTS skips URLs that already received this warning
+func (r *warningRepository) FindNearClickLimit(ctx context.Context, ratio float64, kind domain.WarningKind, limit int) ([]*domain.URL, error) {
+	query := `
+		SELECT ` + urlColumns + `
+		FROM urls
+		WHERE is_active = true
+		  AND max_clicks IS NOT NULL
+		  AND clicks >= max_clicks * $1
+		  AND NOT EXISTS (
+		      SELECT 1 FROM url_warnings w WHERE w.url_id = urls.id AND w.kind = $2
+		  )
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, ratio, string(kind), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find URLs near click limit: %w", err)
+	}
+
+	return collectURLs(rows)
+}
+
+// FindExpiringBefore returns URLs that expire before the given time
+func (r *warningRepository) FindExpiringBefore(ctx context.Context, before time.Time, kind domain.WarningKind, limit int) ([]*domain.URL, error) {
+	query := `
+		SELECT ` + urlColumns + `
+		FROM urls
+		WHERE is_active = true
+		  AND expires_at IS NOT NULL
+		  AND expires_at > NOW()
+		  AND expires_at <= $1
+		  AND NOT EXISTS (
+		      SELECT 1 FROM url_warnings w WHERE w.url_id = urls.id AND w.kind = $2
+		  )
+		ORDER BY expires_at
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, before, string(kind), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expiring URLs: %w", err)
+	}
+
+	return collectURLs(rows)
+}
+
+// FindExpiredSince returns URLs whose expiration passed after since
+// The lower bound keeps links that expired long ago (before the first run)
+// from being announced all at once
+func (r *warningRepository) FindExpiredSince(ctx context.Context, since time.Time, kind domain.WarningKind, limit int) ([]*domain.URL, error) {
+	query := `
+		SELECT ` + urlColumns + `
+		FROM urls
+		WHERE is_active = true
+		  AND expires_at IS NOT NULL
+		  AND expires_at > $1
+		  AND expires_at <= NOW()
+		  AND NOT EXISTS (
+		      SELECT 1 FROM url_warnings w WHERE w.url_id = urls.id AND w.kind = $2
+		  )
+		ORDER BY expires_at
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, since, string(kind), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expired URLs: %w", err)
+	}
+
+	return collectURLs(rows)
+}
+
+// MarkSent records a sent warning
+// ON CONFLICT DO NOTHING makes this safe when several instances run the evaluator
+func (r *warningRepository) MarkSent(ctx context.Context, urlID string, kind domain.WarningKind) (bool, error) {
+	query := `
+		INSERT INTO url_warnings (url_id, kind, sent_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (url_id, kind) DO NOTHING
+	`
+
+	result, err := r.db.Exec(ctx, query, urlID, string(kind))
+	if err != nil {
+		return false, fmt.Errorf("failed to record warning: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
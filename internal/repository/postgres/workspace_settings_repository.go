@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// workspaceSettingsRepository is the PostgreSQL implementation of
+// repository.WorkspaceSettingsRepository
+type workspaceSettingsRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewWorkspaceSettingsRepository creates a new PostgreSQL workspace settings repository
+func NewWorkspaceSettingsRepository(db *pgxpool.Pool) repository.WorkspaceSettingsRepository {
+	return &workspaceSettingsRepository{db: db}
+}
+
+// Get returns a workspace's settings
+func (r *workspaceSettingsRepository) Get(ctx context.Context, workspace string) (*domain.WorkspaceSettings, error) {
+	query := `
+		SELECT workspace, timezone, require_approval, group_roles, plan, updated_at
+		FROM workspace_settings
+		WHERE workspace = $1
+	`
+
+	settings := &domain.WorkspaceSettings{}
+	err := r.db.QueryRow(ctx, query, workspace).Scan(&settings.Workspace, &settings.Timezone, &settings.RequireApproval, &settings.GroupRoles, &settings.Plan, &settings.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrWorkspaceSettingsNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// Save creates or replaces a workspace's settings
+func (r *workspaceSettingsRepository) Save(ctx context.Context, settings *domain.WorkspaceSettings) error {
+	query := `
+		INSERT INTO workspace_settings (workspace, timezone, require_approval, group_roles)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (workspace) DO UPDATE
+		SET timezone = EXCLUDED.timezone, require_approval = EXCLUDED.require_approval,
+		    group_roles = EXCLUDED.group_roles, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	// A nil map would be stored as JSON null; the column wants an object
+	groupRoles := settings.GroupRoles
+	if groupRoles == nil {
+		groupRoles = map[string]string{}
+	}
+	if err := r.db.QueryRow(ctx, query, settings.Workspace, settings.Timezone, settings.RequireApproval, groupRoles).Scan(&settings.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save workspace settings: %w", err)
+	}
+
+	return nil
+}
+
+// SetPlan puts a workspace on a quota plan
+func (r *workspaceSettingsRepository) SetPlan(ctx context.Context, workspace, plan string) error {
+	query := `
+		INSERT INTO workspace_settings (workspace, plan)
+		VALUES ($1, $2)
+		ON CONFLICT (workspace) DO UPDATE
+		SET plan = EXCLUDED.plan, updated_at = CURRENT_TIMESTAMP
+	`
+
+	if _, err := r.db.Exec(ctx, query, workspace, plan); err != nil {
+		return fmt.Errorf("failed to set workspace plan: %w", err)
+	}
+	return nil
+}
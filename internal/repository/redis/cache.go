@@ -2,12 +2,12 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"url-shortener/internal/cache"
 	"url-shortener/internal/domain"
-	"url-shortener/internal/metrics"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -17,35 +17,54 @@ import (
 // 1. Check cache first
 // 2. If miss, get from database
 // 3. Store in cache for next time
+//
+// Entries are encoded with internal/cache (binary or JSON) and can be served
+// stale while they are refreshed (see cache.Revalidator)
 type Cache struct {
 	client *redis.Client
 	ttl    time.Duration
+	codec  cache.Codec // Format used when writing entries (reads accept every format)
+
+	staleTTL    time.Duration      // Extra time an entry may be served while being refreshed (0 = disabled)
+	revalidator *cache.Revalidator // Refreshes stale entries (nil = disabled)
 }
 
+// Compile-time check: the backend implements the full cache contract
+var _ cache.Cache = (*Cache)(nil)
+
 // NewCache creates a new Redis cache
 func NewCache(client *redis.Client, ttl time.Duration) *Cache {
 	return &Cache{
 		client: client,
 		ttl:    ttl,
+		codec:  cache.CodecJSON,
 	}
 }
 
+// WithCodec selects the format for new entries (see cache.Codec)
+func (c *Cache) WithCodec(codec cache.Codec) *Cache {
+	c.codec = codec
+	return c
+}
+
+// WithStaleWhileRevalidate keeps entries for staleTTL after they expire and
+// refreshes them in the background with refresh when they are read
+func (c *Cache) WithStaleWhileRevalidate(staleTTL time.Duration, refresh cache.RefreshFunc) *Cache {
+	c.staleTTL = staleTTL
+	c.revalidator = cache.NewRevalidator(refresh)
+	return c
+}
+
 // GetURL retrieves a URL from cache
 // Returns nil if not found (cache miss)
 func (c *Cache) GetURL(ctx context.Context, shortCode string) (*domain.URL, error) {
-	start := time.Now()
-	defer func() {
-		metrics.CacheOperationDuration.WithLabelValues("get").Observe(time.Since(start).Seconds())
-	}()
-
 	// Key naming convention: "url:{shortCode}"
 	key := fmt.Sprintf("url:%s", shortCode)
 
 	// Get from Redis
-	data, err := c.client.Get(ctx, key).Result()
+	data, err := c.client.Get(ctx, key).Bytes()
 	if err == redis.Nil {
 		// Cache miss - not an error, just not found
-		metrics.RecordCacheMiss()
 		return nil, nil
 	}
 	if err != nil {
@@ -53,31 +72,38 @@ func (c *Cache) GetURL(ctx context.Context, shortCode string) (*domain.URL, erro
 		return nil, fmt.Errorf("redis get error: %w", err)
 	}
 
-	// Cache hit!
-	metrics.RecordCacheHit()
+	// Deserialize (JSON or binary, whichever wrote it)
+	entry, err := cache.Decode(data)
+	if errors.Is(err, cache.ErrUnsupportedFormat) {
+		// Older or newer format - treat as a miss so it gets rewritten
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cached URL: %w", err)
+	}
 
-	// Deserialize JSON to domain.URL
-	var url domain.URL
-	if err := json.Unmarshal([]byte(data), &url); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal cached URL: %w", err)
+	// Stale: serve it anyway, refresh in the background
+	if c.revalidator != nil && time.Now().After(entry.FreshUntil) {
+		c.revalidator.Trigger(ctx, shortCode)
 	}
 
-	return &url, nil
+	return entry.URL, nil
 }
 
 // SetURL stores a URL in cache
 func (c *Cache) SetURL(ctx context.Context, shortCode string, url *domain.URL) error {
 	key := fmt.Sprintf("url:%s", shortCode)
 
-	// Serialize URL to JSON
-	data, err := json.Marshal(url)
+	// Serialize with the configured codec
+	data, err := cache.Encode(c.codec, url, time.Now().Add(c.ttl))
 	if err != nil {
-		return fmt.Errorf("failed to marshal URL: %w", err)
+		return fmt.Errorf("failed to encode URL: %w", err)
 	}
 
 	// Store in Redis with TTL
 	// TTL ensures cache doesn't grow indefinitely and stale data is removed
-	err = c.client.Set(ctx, key, data, c.ttl).Err()
+	// With stale-while-revalidate, Redis keeps the entry for the stale window too
+	err = c.client.Set(ctx, key, data, c.ttl+c.staleTTL).Err()
 	if err != nil {
 		return fmt.Errorf("redis set error: %w", err)
 	}
@@ -150,8 +176,10 @@ func (c *Cache) GetStats(ctx context.Context) (map[string]interface{}, error) {
 	}
 
 	return map[string]interface{}{
-		"cached_urls": count,
-		"info":        info,
+		"driver":           "redis",
+		"contract_version": cache.ContractVersion,
+		"cached_urls":      count,
+		"info":             info,
 	}, nil
 }
 
@@ -176,6 +204,7 @@ func InitRedis(addr, password string, db int) (*redis.Client, error) {
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close() // Startup retries would leak one client per attempt
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
@@ -0,0 +1,34 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ClickDeduplicator remembers recent clicks so repeats can be skipped
+// Shared by every instance of the service, so a double-click that lands on
+// two different instances is still caught
+type ClickDeduplicator struct {
+	client *redis.Client
+}
+
+// NewClickDeduplicator creates a Redis-backed click deduplicator
+func NewClickDeduplicator(client *redis.Client) *ClickDeduplicator {
+	return &ClickDeduplicator{client: client}
+}
+
+// FirstSeen reports whether key was NOT seen within the last window
+// The first call for a key returns true; calls until the window ends return false
+//
+// SET key 1 NX PX window is ONE atomic command: two clicks arriving at the
+// same moment can't both see "new"
+func (d *ClickDeduplicator) FirstSeen(ctx context.Context, key string, window time.Duration) (bool, error) {
+	first, err := d.client.SetNX(ctx, fmt.Sprintf("click:dedup:%s", key), 1, window).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis dedup error: %w", err)
+	}
+	return first, nil
+}
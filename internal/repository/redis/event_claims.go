@@ -0,0 +1,42 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// EventClaims remembers ingested click event IDs (see service.ClickIngestService)
+// Shared by every instance, so a retry that lands elsewhere is still caught
+type EventClaims struct {
+	client *redis.Client
+}
+
+// NewEventClaims creates Redis-backed click event claims
+func NewEventClaims(client *redis.Client) *EventClaims {
+	return &EventClaims{client: client}
+}
+
+// Claim reports whether key was NOT claimed within the last ttl
+// SET NX is atomic: of two deliveries of the same event, only one wins
+func (c *EventClaims) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	first, err := c.client.SetNX(ctx, eventClaimKey(key), 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis event claim error: %w", err)
+	}
+	return first, nil
+}
+
+// Release forgets a claim, so the event can be delivered again
+func (c *EventClaims) Release(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, eventClaimKey(key)).Err(); err != nil {
+		return fmt.Errorf("redis event release error: %w", err)
+	}
+	return nil
+}
+
+func eventClaimKey(key string) string {
+	return fmt.Sprintf("click:event:%s", key)
+}
@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// flagChannel is the pub/sub channel announcing feature flag changes
+const flagChannel = "featureflags:changed"
+
+// FlagNotifier tells every instance that feature flags changed
+//
+// PUB/SUB carries no data here, only "something changed": each instance
+// then reloads the flags from PostgreSQL. A missed message (e.g. during a
+// reconnect) is harmless - the periodic refresh catches up.
+type FlagNotifier struct {
+	client *redis.Client
+}
+
+// NewFlagNotifier creates a Redis-backed change notifier
+func NewFlagNotifier(client *redis.Client) *FlagNotifier {
+	return &FlagNotifier{client: client}
+}
+
+// Publish announces a change
+func (n *FlagNotifier) Publish(ctx context.Context) error {
+	if err := n.client.Publish(ctx, flagChannel, "changed").Err(); err != nil {
+		return fmt.Errorf("redis publish error: %w", err)
+	}
+	return nil
+}
+
+// Subscribe calls onChange for every announced change until ctx is canceled
+// go-redis resubscribes by itself after a lost connection
+func (n *FlagNotifier) Subscribe(ctx context.Context, onChange func()) {
+	sub := n.client.Subscribe(ctx, flagChannel)
+	defer sub.Close()
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-messages:
+			if !ok {
+				return
+			}
+			onChange()
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LeaderboardCache caches top links leaderboards as JSON
+// Entries only expire (no invalidation on new clicks): a leaderboard is a
+// snapshot, and the TTL is how stale it may get
+type LeaderboardCache struct {
+	client *redis.Client
+}
+
+// NewLeaderboardCache creates a Redis-backed leaderboard cache
+func NewLeaderboardCache(client *redis.Client) *LeaderboardCache {
+	return &LeaderboardCache{client: client}
+}
+
+// Get returns the cached leaderboard, ok=false when nothing is cached
+func (c *LeaderboardCache) Get(ctx context.Context, owner string, period domain.LeaderboardPeriod, limit int) (*domain.Leaderboard, bool, error) {
+	data, err := c.client.Get(ctx, leaderboardKey(owner, period, limit)).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("redis get error: %w", err)
+	}
+
+	var board domain.Leaderboard
+	if err := json.Unmarshal(data, &board); err != nil {
+		return nil, false, fmt.Errorf("failed to decode leaderboard: %w", err)
+	}
+	return &board, true, nil
+}
+
+// Set caches the leaderboard for ttl
+func (c *LeaderboardCache) Set(ctx context.Context, owner string, period domain.LeaderboardPeriod, limit int, board *domain.Leaderboard, ttl time.Duration) error {
+	data, err := json.Marshal(board)
+	if err != nil {
+		return fmt.Errorf("failed to encode leaderboard: %w", err)
+	}
+	if err := c.client.Set(ctx, leaderboardKey(owner, period, limit), data, ttl).Err(); err != nil {
+		return fmt.Errorf("redis set error: %w", err)
+	}
+	return nil
+}
+
+// leaderboardKey names the entry: "top:v2:{owner}:{period}:{limit}"
+// v2 entries know which links are sensitive; v1 entries (from before) would
+// pass every link off as standard, so they are left to expire
+func leaderboardKey(owner string, period domain.LeaderboardPeriod, limit int) string {
+	return fmt.Sprintf("top:v2:%s:%s:%d", owner, period, limit)
+}
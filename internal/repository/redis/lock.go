@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// unlockScript deletes the lock only if we still own it
+// Without the token check, a request whose lock already expired could delete
+// the lock that ANOTHER request took in the meantime
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Locker takes short-lived locks shared by every instance of the service
+//
+// The locks are ADVISORY: they keep concurrent requests from racing through
+// a check-then-act sequence, but they expire after their TTL. Anything that
+// must never happen twice still needs a database constraint behind it.
+type Locker struct {
+	client *redis.Client
+}
+
+// NewLocker creates a Redis-backed locker
+func NewLocker(client *redis.Client) *Locker {
+	return &Locker{client: client}
+}
+
+// TryLock takes key for at most ttl without waiting
+// Returns ok=false when another holder has it. Call unlock when done.
+func (l *Locker) TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(), ok bool, err error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, false, err
+	}
+
+	key = fmt.Sprintf("lock:%s", key)
+
+	// SET key token NX PX ttl - only succeeds if nobody holds the lock
+	ok, err = l.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("redis lock error: %w", err)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+
+	unlock = func() {
+		// Release even if the request context is already canceled
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+		defer cancel()
+		if err := unlockScript.Run(ctx, l.client, []string{key}, token).Err(); err != nil {
+			fmt.Printf("Warning: failed to release lock %s: %v\n", key, err)
+		}
+	}
+	return unlock, true, nil
+}
+
+// newToken returns a random value identifying one lock holder
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// UsageCounter caches quota counters so usage checks don't need PostgreSQL
+// PostgreSQL stays the source of truth: a lost or stale Redis value only
+// costs one extra database query, never a wrong quota decision
+type UsageCounter struct {
+	client *redis.Client
+}
+
+// NewUsageCounter creates a Redis-backed usage counter cache
+func NewUsageCounter(client *redis.Client) *UsageCounter {
+	return &UsageCounter{client: client}
+}
+
+// Get returns the cached counter, ok=false when nothing is cached
+func (c *UsageCounter) Get(ctx context.Context, owner string, period time.Time) (used int64, ok bool, err error) {
+	used, err = c.client.Get(ctx, usageKey(owner, period)).Int64()
+	if err == redis.Nil {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("redis get error: %w", err)
+	}
+	return used, true, nil
+}
+
+// Set caches the counter until expiresAt (the end of the period)
+func (c *UsageCounter) Set(ctx context.Context, owner string, period time.Time, used int64, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	if err := c.client.Set(ctx, usageKey(owner, period), used, ttl).Err(); err != nil {
+		return fmt.Errorf("redis set error: %w", err)
+	}
+	return nil
+}
+
+// Delete drops the cached counter, so the next read goes to PostgreSQL
+func (c *UsageCounter) Delete(ctx context.Context, owner string, period time.Time) error {
+	if err := c.client.Del(ctx, usageKey(owner, period)).Err(); err != nil {
+		return fmt.Errorf("redis delete error: %w", err)
+	}
+	return nil
+}
+
+// usageKey names the counter: "usage:{owner}:{yyyy-mm}"
+func usageKey(owner string, period time.Time) string {
+	return fmt.Sprintf("usage:%s:%s", owner, period.Format("2006-01"))
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"time"
+
 	"url-shortener/internal/domain"
 )
 
@@ -30,22 +32,48 @@ type URLRepository interface {
 	// GetByCustomAlias retrieves a URL by its custom alias
 	GetByCustomAlias(ctx context.Context, alias string) (*domain.URL, error)
 
-	// Update modifies an existing URL
+	// Update modifies an existing URL and bumps its Version
+	// OPTIMISTIC LOCKING: the row is only written if its version still equals
+	// url.Version, otherwise domain.ErrVersionConflict is returned. On success
+	// url.Version holds the new version.
 	Update(ctx context.Context, url *domain.URL) error
 
+	// SetMetadata stores the destination page metadata (does not bump Version)
+	SetMetadata(ctx context.Context, id string, meta *domain.LinkMetadata) error
+
 	// Delete performs a soft delete (sets is_active = false)
 	Delete(ctx context.Context, id string) error
 
+	// Restore reactivates a soft-deleted URL
+	Restore(ctx context.Context, id string) error
+
+	// Purge permanently deletes a URL and its click events
+	// Returns the number of click events removed
+	Purge(ctx context.Context, id string) (int64, error)
+
 	// IncrementClicks increases the click counter for a URL
 	// This is done atomically in the database to avoid race conditions
 	IncrementClicks(ctx context.Context, shortCode string) error
 
+	// MarkUsed uses up a single-use link, atomically
+	// Returns false if another request already used it
+	MarkUsed(ctx context.Context, shortCode string) (bool, error)
+
+	// Burn reveals a burn-after-reading link: it returns the sealed payload
+	// and clears it in the same statement. Returns nil if the link was
+	// already revealed (by another request)
+	Burn(ctx context.Context, shortCode string) (*string, error)
+
 	// ExistsShortCode checks if a short code already exists
 	// Used to prevent collisions when generating short codes
 	ExistsShortCode(ctx context.Context, shortCode string) (bool, error)
 
 	// ExistsCustomAlias checks if a custom alias is already taken
 	ExistsCustomAlias(ctx context.Context, alias string) (bool, error)
+
+	// FindTakenCodes checks many candidates in ONE query
+	// Returns the subset already used as a short code or custom alias
+	FindTakenCodes(ctx context.Context, codes []string) (map[string]bool, error)
 }
 
 // ClickRepository defines the interface for analytics data access
@@ -59,7 +87,533 @@ type ClickRepository interface {
 	// GetClickCount returns the total number of clicks for a URL
 	GetClickCount(ctx context.Context, urlID string) (int64, error)
 
+	// CountBy counts the clicks of a URL per value of dimension
+	// Clicks without a value are counted as "unknown"
+	CountBy(ctx context.Context, urlID string, dimension domain.ClickDimension) (map[string]int64, error)
+
+	// CountByPeriod counts the clicks of a URL in [from, to) per interval,
+	// with buckets aligned to location's wall clock (midnight in Berlin, not
+	// in UTC). Intervals without clicks are left out.
+	CountByPeriod(ctx context.Context, urlID string, interval domain.Interval, location *time.Location, from, to time.Time) ([]domain.ClickBucket, error)
+
+	// CountByHourOfWeek counts the clicks of a URL in [from, to) per weekday
+	// and hour of location's wall clock. Cells without clicks are left out.
+	CountByHourOfWeek(ctx context.Context, urlID string, location *time.Location, from, to time.Time) ([]domain.HourOfWeek, error)
+
+	// TopLinks returns the links of owner with the most clicks since the
+	// start of since's hour, at most limit, from the hourly click rollups
+	TopLinks(ctx context.Context, owner string, since time.Time, limit int) ([]domain.TopLink, error)
+
 	// GetClickStats returns aggregated statistics (clicks per day, top countries, etc.)
 	// This would return a custom stats struct
 	// GetClickStats(ctx context.Context, urlID string) (*ClickStats, error)
 }
+
+// ClickBackfillRepository fills in parsed User-Agent fields of clicks
+// recorded before they existed (see cmd/backfill-useragents)
+type ClickBackfillRepository interface {
+	// NextUnparsed returns up to limit clicks with ID > afterID and no device
+	// type, in ID order (only ID and UserAgent are loaded)
+	NextUnparsed(ctx context.Context, afterID int64, limit int) ([]*domain.URLClick, error)
+
+	// SetDevices stores Browser, OS and DeviceType of every click in ONE statement
+	SetDevices(ctx context.Context, clicks []*domain.URLClick) error
+}
+
+// CodeCaseRepository lowercases the codes of links created while short codes
+// were case-sensitive (see cmd/lowercase-codes)
+type CodeCaseRepository interface {
+	// NextMixedCase returns up to limit links, live or archived, with ID >
+	// afterID ("" = from the start) and capitals in their short code or alias,
+	// in ID order (only ID, ShortCode and CustomAlias are loaded)
+	NextMixedCase(ctx context.Context, afterID string, limit int) ([]*domain.URL, error)
+
+	// LowercaseCodes lowercases the short code and alias of link id
+	// Returns false, changing nothing, when another link already uses one of
+	// the lowercase forms. With apply false nothing is changed either way.
+	LowercaseCodes(ctx context.Context, id string, apply bool) (bool, error)
+}
+
+// PopularAliasRepository reads the aliases worth protecting from imitation
+// (see domain.ConfusableIndex)
+type PopularAliasRepository interface {
+	// PopularAliases returns up to limit custom aliases of active links,
+	// most clicked first
+	PopularAliases(ctx context.Context, limit int) ([]domain.PopularAlias, error)
+}
+
+// ApprovalRepository finds links waiting for approval (see domain.WithPendingApproval)
+// Decisions are ordinary URL updates (URLRepository.Update)
+type ApprovalRepository interface {
+	// ListPending returns up to limit links of workspace that wait for
+	// approval, oldest first
+	ListPending(ctx context.Context, workspace string, limit int) ([]*domain.URL, error)
+}
+
+// WarehouseRepository reads click events for the data warehouse export
+// and remembers how far the export got
+type WarehouseRepository interface {
+	// ListClicksAfter returns up to limit clicks with ID > afterID recorded
+	// before before, in ID order, with the ShortCode of their link
+	ListClicksAfter(ctx context.Context, afterID int64, before time.Time, limit int) ([]*domain.URLClick, error)
+
+	// GetCheckpoint returns the last exported click ID of name (0 = nothing yet)
+	GetCheckpoint(ctx context.Context, name string) (int64, error)
+
+	// SaveCheckpoint stores the last exported click ID of name
+	// It never moves backwards, so a slower concurrent run can't undo progress
+	SaveCheckpoint(ctx context.Context, name string, lastID int64) error
+}
+
+// WarningRepository finds URLs that are about to stop working and remembers
+// which warnings were already sent, so owners are notified exactly once
+type WarningRepository interface {
+	// FindNearClickLimit returns active URLs that used at least ratio of their
+	// click limit and haven't been warned with kind yet
+	FindNearClickLimit(ctx context.Context, ratio float64, kind domain.WarningKind, limit int) ([]*domain.URL, error)
+
+	// FindExpiringBefore returns active URLs expiring before the given time
+	// that haven't been warned with kind yet
+	FindExpiringBefore(ctx context.Context, before time.Time, kind domain.WarningKind, limit int) ([]*domain.URL, error)
+
+	// FindExpiredSince returns active URLs that expired after since (and
+	// before now) and haven't been marked with kind yet
+	FindExpiredSince(ctx context.Context, since time.Time, kind domain.WarningKind, limit int) ([]*domain.URL, error)
+
+	// MarkSent records that a warning was sent
+	// Returns false if it had already been recorded (another instance won the race)
+	MarkSent(ctx context.Context, urlID string, kind domain.WarningKind) (bool, error)
+}
+
+// AnomalyRepository finds and records unusual click activity
+// Works on the hourly click rollups (migration 024) of one database
+type AnomalyRepository interface {
+	// FindBusyURLs returns the links with at least minClicks clicks in the
+	// hour starting at hour, busiest first, at most limit
+	FindBusyURLs(ctx context.Context, hour time.Time, minClicks int64, limit int) ([]*domain.HourlyClicks, error)
+
+	// HourlyClicks returns the clicks of each link per hour in [from, to),
+	// keyed by URL ID. Hours without clicks are left out.
+	HourlyClicks(ctx context.Context, urlIDs []string, from, to time.Time) (map[string][]domain.ClickBucket, error)
+
+	// Record stores an anomaly
+	// Returns false if the link's hour was already recorded (another run or instance won)
+	Record(ctx context.Context, anomaly *domain.ClickAnomaly) (bool, error)
+}
+
+// RollupRepository maintains and summarizes the hourly click rollups
+// (migration 024) of one database
+type RollupRepository interface {
+	// DeleteBefore removes up to limit rollup rows of hours before the given
+	// time and returns how many it removed
+	DeleteBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+
+	// OwnerDigests sums the clicks of each owner's links since the given
+	// time, for owners sorting after the given one, at most limit of them.
+	// Owners without clicks are left out.
+	OwnerDigests(ctx context.Context, since time.Time, after string, limit int) ([]domain.ClickDigest, error)
+}
+
+// DomainRuleRepository stores the destination domain rules (migration 026)
+type DomainRuleRepository interface {
+	// List returns every rule, global and per workspace, uncompiled
+	List(ctx context.Context) ([]*domain.DomainRule, error)
+
+	// GetByID returns one rule (domain.ErrDomainRuleNotFound if missing)
+	GetByID(ctx context.Context, id string) (*domain.DomainRule, error)
+
+	// Create inserts a rule and sets its ID and CreatedAt
+	// domain.ErrDomainRuleDuplicate if the workspace already has it
+	Create(ctx context.Context, rule *domain.DomainRule) error
+
+	// Delete removes a rule (domain.ErrDomainRuleNotFound if missing)
+	Delete(ctx context.Context, id string) error
+}
+
+// PolicySweepRepository walks the active links of one database so links
+// can be re-checked after the domain rules change
+type PolicySweepRepository interface {
+	// ListActive returns up to limit active links with an ID after afterID,
+	// in ID order ("" starts at the beginning)
+	ListActive(ctx context.Context, afterID string, limit int) ([]*domain.URL, error)
+
+	// Deactivate turns a link off (like a soft delete)
+	// Returns false if it was already inactive
+	Deactivate(ctx context.Context, id string) (bool, error)
+}
+
+// SealedDestinationRepository walks the sensitive and encrypted links of one
+// database so their destinations can be (re-)encrypted
+type SealedDestinationRepository interface {
+	// NextSealable returns up to limit links that are sensitive or have an
+	// encrypted destination, with an ID after afterID, in ID order ("" starts
+	// at the beginning). Archived links included.
+	NextSealable(ctx context.Context, afterID string, limit int) ([]*domain.URL, error)
+
+	// Reseal stores sealed as the link's encrypted destination and clears its
+	// plain text destination, unless the link changed since it was read
+	// (different OriginalURL or SealedDestination). Returns false then.
+	Reseal(ctx context.Context, url *domain.URL, sealed string) (bool, error)
+}
+
+// AuditRepository stores the audit log of links
+type AuditRepository interface {
+	// Record appends an entry and fills in its ID
+	Record(ctx context.Context, entry *domain.AuditEntry) error
+
+	// ListByURL returns the entries of a link, newest first
+	ListByURL(ctx context.Context, urlID string) ([]*domain.AuditEntry, error)
+}
+
+// ExportRepository reads everything a user owns, page by page, for data exports
+type ExportRepository interface {
+	// ListForExport returns up to limit URLs created by owner (including deleted
+	// ones) with their aggregate stats, ordered by (created_at, id)
+	// Pass the cursor of the last record to get the next page (nil = first page)
+	ListForExport(ctx context.Context, owner string, after *domain.ExportCursor, limit int) ([]*domain.ExportRecord, error)
+
+	// ListInventory returns up to limit links created by owner (active,
+	// deactivated and archived ones) without stats, in the same order
+	ListInventory(ctx context.Context, owner string, after *domain.ExportCursor, limit int) ([]*domain.URL, error)
+}
+
+// SearchRepository finds links by the words in them
+type SearchRepository interface {
+	// Search returns one page of the links matching every term of q (active,
+	// deactivated and archived ones), best match first, and the total
+	Search(ctx context.Context, q domain.SearchQuery) (*domain.SearchResult, error)
+}
+
+// ErasureRepository stores account deletion requests and deletes an owner's data
+type ErasureRepository interface {
+	// Create stores a new pending request
+	Create(ctx context.Context, req *domain.ErasureRequest) error
+
+	// GetByID returns a request, or domain.ErrErasureNotFound
+	GetByID(ctx context.Context, id string) (*domain.ErasureRequest, error)
+
+	// FindActiveByOwner returns the owner's pending or running request, or nil if there is none
+	FindActiveByOwner(ctx context.Context, owner string) (*domain.ErasureRequest, error)
+
+	// ClaimNext marks the oldest pending request (or a running one whose worker
+	// stopped sending heartbeats) as running and returns it; nil if there is none
+	ClaimNext(ctx context.Context, staleAfter time.Duration) (*domain.ErasureRequest, error)
+
+	// Update saves status, counts, and error, and refreshes the heartbeat
+	// Completing a request clears its owner column
+	Update(ctx context.Context, req *domain.ErasureRequest) error
+
+	// DeleteOwnerBatch permanently deletes up to limit URLs created by owner
+	// and their clicks; returns the deleted URLs (for cache invalidation)
+	DeleteOwnerBatch(ctx context.Context, owner string, limit int) ([]*domain.URL, int64, error)
+
+	// CountOwnerURLs returns how many URLs created by owner remain
+	CountOwnerURLs(ctx context.Context, owner string) (int64, error)
+}
+
+// UsageRepository counts links per owner and period for plan quotas
+type UsageRepository interface {
+	// Increment adds one link to the owner's counter for the period, unless
+	// that would go over limit (0 = no limit). Returns the counter value and
+	// false when the limit was already reached (nothing is changed then).
+	// The check and the increment are ONE statement, so concurrent creations
+	// can never overshoot the limit.
+	Increment(ctx context.Context, owner string, period time.Time, limit int64) (used int64, ok bool, err error)
+
+	// Decrement gives one link back (the creation failed after Increment)
+	Decrement(ctx context.Context, owner string, period time.Time) error
+
+	// Get returns the counter value (0 if the owner created nothing yet)
+	Get(ctx context.Context, owner string, period time.Time) (int64, error)
+}
+
+// SubscriptionRepository stores Stripe subscriptions per owner
+type SubscriptionRepository interface {
+	// LinkCustomer remembers which Stripe customer belongs to owner
+	// Called when a checkout finishes; keeps the existing row otherwise
+	LinkCustomer(ctx context.Context, owner, customerID string) error
+
+	// Apply stores sub unless a NEWER event was already applied
+	// Stripe does not guarantee delivery order, so an older
+	// "subscription.updated" must never overwrite a later "deleted".
+	// Returns false when the event was stale and nothing changed.
+	Apply(ctx context.Context, sub *domain.Subscription) (bool, error)
+
+	// GetByOwner returns domain.ErrSubscriptionNotFound if the owner never subscribed
+	GetByOwner(ctx context.Context, owner string) (*domain.Subscription, error)
+
+	// GetByCustomer returns domain.ErrSubscriptionNotFound for unknown customers
+	GetByCustomer(ctx context.Context, customerID string) (*domain.Subscription, error)
+}
+
+// SlackWorkspaceRepository stores per-workspace Slack app settings
+type SlackWorkspaceRepository interface {
+	// Get returns domain.ErrSlackWorkspaceNotFound for unknown teams
+	Get(ctx context.Context, teamID string) (*domain.SlackWorkspace, error)
+
+	// Save creates or replaces the workspace settings
+	Save(ctx context.Context, ws *domain.SlackWorkspace) error
+
+	// Delete removes the workspace (domain.ErrSlackWorkspaceNotFound if missing)
+	Delete(ctx context.Context, teamID string) error
+}
+
+// PageRepository stores link-in-bio pages and their blocks
+type PageRepository interface {
+	// Create inserts a page and its blocks (domain.ErrHandleTaken if the handle is used)
+	// Fills in the generated page and block IDs
+	Create(ctx context.Context, page *domain.Page) error
+
+	// Update replaces the page's fields and blocks
+	// Blocks with an ID that belongs to the page are kept (with their click
+	// counts), blocks without an ID are added, and all others are removed
+	Update(ctx context.Context, page *domain.Page) error
+
+	// GetByID returns a page with its blocks, or domain.ErrPageNotFound
+	GetByID(ctx context.Context, id string) (*domain.Page, error)
+
+	// GetByHandle returns a page with its blocks, or domain.ErrPageNotFound
+	GetByHandle(ctx context.Context, handle string) (*domain.Page, error)
+
+	// ListByOwner returns the owner's pages (without blocks), newest first
+	ListByOwner(ctx context.Context, owner string) ([]*domain.Page, error)
+
+	// Delete removes a page and its blocks (domain.ErrPageNotFound if missing)
+	Delete(ctx context.Context, id string) error
+
+	// IncrementBlockClicks counts one click-through on a block of the page
+	// Returns domain.ErrBlockNotFound if the block isn't on that page
+	IncrementBlockClicks(ctx context.Context, pageID, blockID string) error
+}
+
+// TemplateRepository stores link templates
+type TemplateRepository interface {
+	// Create inserts a template (domain.ErrTemplateNameTaken if the owner
+	// already has one with that name) and fills in its ID and timestamps
+	Create(ctx context.Context, template *domain.LinkTemplate) error
+
+	// Update replaces the name and settings (domain.ErrTemplateNotFound if missing)
+	Update(ctx context.Context, template *domain.LinkTemplate) error
+
+	// GetByID returns a template, or domain.ErrTemplateNotFound
+	GetByID(ctx context.Context, id string) (*domain.LinkTemplate, error)
+
+	// ListByOwner returns the owner's templates sorted by name
+	ListByOwner(ctx context.Context, owner string) ([]*domain.LinkTemplate, error)
+
+	// Delete removes a template (domain.ErrTemplateNotFound if missing)
+	Delete(ctx context.Context, id string) error
+}
+
+// ArchiveRepository moves cold links out of the hot urls table
+// Moving them back is transparent: URLRepository lookups rehydrate an
+// archived link the first time it is requested
+type ArchiveRepository interface {
+	// ArchiveUnused moves up to limit links created before cutoff and not
+	// clicked since then to the archive; returns the moved links
+	ArchiveUnused(ctx context.Context, cutoff time.Time, limit int) ([]*domain.URL, error)
+}
+
+// ClickStripeRepository folds striped click counters into the links
+// (see postgres.NewStripedURLRepository)
+type ClickStripeRepository interface {
+	// FoldClickStripes adds up to limit stripe rows to the counters of
+	// their links and deletes them; returns how many rows were folded
+	FoldClickStripes(ctx context.Context, limit int) (int, error)
+}
+
+// CustomDomainRepository stores customers' custom domains
+type CustomDomainRepository interface {
+	// Create inserts a domain (domain.ErrCustomDomainTaken if the host is
+	// registered already) and fills in its ID and CreatedAt
+	Create(ctx context.Context, d *domain.CustomDomain) error
+
+	// GetByID returns a domain, or domain.ErrCustomDomainNotFound
+	GetByID(ctx context.Context, id string) (*domain.CustomDomain, error)
+
+	// List returns every domain, sorted by host
+	List(ctx context.Context) ([]*domain.CustomDomain, error)
+
+	// SaveCheck stores the outcome of a verification check (status,
+	// verified_at, checked_at, last_error)
+	SaveCheck(ctx context.Context, d *domain.CustomDomain) error
+
+	// Delete removes a domain (domain.ErrCustomDomainNotFound if missing)
+	Delete(ctx context.Context, id string) error
+}
+
+// CertificateRepository stores automatic TLS state shared by every replica
+// Entries are keyed by autocert cache keys; certificates by their host
+type CertificateRepository interface {
+	// Get returns the data stored under name, or domain.ErrCertificateNotFound
+	Get(ctx context.Context, name string) ([]byte, error)
+
+	// Put stores data under name and clears a recorded failure
+	// notAfter is the expiry of the certificate in data (nil for other keys)
+	Put(ctx context.Context, name string, data []byte, notAfter *time.Time) error
+
+	// Delete removes name; deleting a missing key is not an error
+	Delete(ctx context.Context, name string) error
+
+	// RecordFailure remembers why a certificate for host couldn't be issued
+	// A certificate stored already is kept
+	RecordFailure(ctx context.Context, host, message string, at time.Time) error
+
+	// Statuses returns what is known about the certificates of hosts
+	// Hosts without any entry are left out
+	Statuses(ctx context.Context, hosts []string) (map[string]*domain.Certificate, error)
+}
+
+// EdgeSnapshotRepository reads the edge change log of one database
+// Every change that affects a redirect gets the next number of the log;
+// deleted links leave a tombstone with their own number (see migration 034)
+type EdgeSnapshotRepository interface {
+	// Version returns the number of the last change, and the number deltas
+	// must start from at least (the tombstones before it were pruned)
+	Version(ctx context.Context) (current, prunedThrough int64, err error)
+
+	// ListLinks returns up to limit active links last changed at or before
+	// through, ordered by ID
+	// Pass the ID of the last link to get the next page ("" = first page)
+	ListLinks(ctx context.Context, afterID string, through int64, limit int) ([]*domain.URL, error)
+
+	// ListChanges returns up to limit changes numbered after < seq <= through,
+	// in log order
+	ListChanges(ctx context.Context, after, through int64, limit int) ([]*domain.EdgeChange, error)
+
+	// PruneTombstones forgets links deleted before cutoff; returns how many
+	PruneTombstones(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// WorkspaceSettingsRepository stores the defaults of each workspace
+type WorkspaceSettingsRepository interface {
+	// Get returns a workspace's settings, or domain.ErrWorkspaceSettingsNotFound
+	Get(ctx context.Context, workspace string) (*domain.WorkspaceSettings, error)
+
+	// Save creates or replaces a workspace's settings and fills in UpdatedAt
+	// The plan is left alone: only SetPlan changes it
+	Save(ctx context.Context, settings *domain.WorkspaceSettings) error
+
+	// SetPlan puts a workspace on a quota plan, creating its settings if needed
+	SetPlan(ctx context.Context, workspace, plan string) error
+}
+
+// MemberRepository stores the members and groups identity providers
+// provision into workspaces (see SCIMService)
+// Every method is scoped to one workspace: IDs of another workspace's
+// members and groups are not found
+type MemberRepository interface {
+	// CreateMember inserts a member (domain.ErrMemberExists if the user
+	// name is taken) and fills in its ID and timestamps
+	CreateMember(ctx context.Context, member *domain.Member) error
+
+	// GetMember returns a member with its groups, or domain.ErrMemberNotFound
+	GetMember(ctx context.Context, workspace, id string) (*domain.Member, error)
+
+	// GetMemberByUserName returns a member with its groups, or domain.ErrMemberNotFound
+	GetMemberByUserName(ctx context.Context, workspace, userName string) (*domain.Member, error)
+
+	// ListMembers returns a page of the workspace's members with their
+	// groups, oldest first, and how many match the filter in all
+	ListMembers(ctx context.Context, workspace string, filter domain.MemberFilter, offset, limit int) ([]*domain.Member, int, error)
+
+	// UpdateMember replaces everything but the ID and groups
+	// (domain.ErrMemberNotFound, domain.ErrMemberExists)
+	UpdateMember(ctx context.Context, member *domain.Member) error
+
+	// DeleteMember removes a member and its group memberships
+	DeleteMember(ctx context.Context, workspace, id string) error
+
+	// CreateGroup inserts a group with its members (domain.ErrGroupExists,
+	// domain.ErrMemberNotFound for a member ID not in the workspace)
+	CreateGroup(ctx context.Context, group *domain.Group) error
+
+	// GetGroup returns a group with its member IDs, or domain.ErrGroupNotFound
+	GetGroup(ctx context.Context, workspace, id string) (*domain.Group, error)
+
+	// ListGroups returns a page of the workspace's groups, oldest first,
+	// and how many match the filter in all
+	ListGroups(ctx context.Context, workspace string, filter domain.GroupFilter, offset, limit int) ([]*domain.Group, int, error)
+
+	// UpdateGroup replaces the name and the members in one transaction
+	// (domain.ErrGroupNotFound, domain.ErrGroupExists, domain.ErrMemberNotFound)
+	UpdateGroup(ctx context.Context, group *domain.Group) error
+
+	// DeleteGroup removes a group; its members stay
+	DeleteGroup(ctx context.Context, workspace, id string) error
+}
+
+// SCIMTokenRepository stores one SCIM bearer token per workspace, hashed
+type SCIMTokenRepository interface {
+	// Save stores the token hash of a workspace, replacing its old one
+	Save(ctx context.Context, workspace, tokenHash string) error
+
+	// Workspace returns the workspace a token hash belongs to, or
+	// domain.ErrSCIMTokenNotFound
+	Workspace(ctx context.Context, tokenHash string) (string, error)
+
+	// Delete removes the workspace's token (domain.ErrSCIMTokenNotFound if none)
+	Delete(ctx context.Context, workspace string) error
+}
+
+// SAMLConnectionRepository stores each workspace's SAML identity provider
+type SAMLConnectionRepository interface {
+	// Get returns the workspace's connection, or domain.ErrSAMLConnectionNotFound
+	Get(ctx context.Context, workspace string) (*domain.SAMLConnection, error)
+
+	// Save creates or replaces the workspace's connection
+	Save(ctx context.Context, connection *domain.SAMLConnection) error
+
+	// Delete removes the connection (domain.ErrSAMLConnectionNotFound)
+	Delete(ctx context.Context, workspace string) error
+}
+
+// SessionRepository stores single sign-on sessions by token hash
+type SessionRepository interface {
+	// CreateFromAssertion records the assertion ID and creates the session
+	// in one transaction: an assertion used before is
+	// domain.ErrAssertionReplayed, and no session is created for it
+	CreateFromAssertion(ctx context.Context, assertionID string, assertionExpiresAt time.Time, tokenHash string, session *domain.Session) error
+
+	// Get returns an unexpired session, or domain.ErrSessionNotFound
+	Get(ctx context.Context, tokenHash string) (*domain.Session, error)
+
+	// Delete ends a session (domain.ErrSessionNotFound)
+	Delete(ctx context.Context, tokenHash string) error
+}
+
+// APIKeyRepository stores workspaces' API keys, by the hash of their secret
+type APIKeyRepository interface {
+	// Create stores a new key (ID and CreatedAt are filled in), with
+	// key.HashVersion next to the hash
+	Create(ctx context.Context, key *domain.APIKey, tokenHash string) error
+
+	// GetByHash returns the key of a secret, or domain.ErrAPIKeyNotFound
+	// tokenHashes are the secret hashed with every configured pepper. The
+	// secret may be the current one or the one replaced by the last
+	// rotation (previous = true): the caller checks expiry (APIKey.Expired,
+	// PreviousSecretValid)
+	GetByHash(ctx context.Context, tokenHashes ...string) (key *domain.APIKey, previous bool, err error)
+
+	// List returns a workspace's keys, oldest first
+	List(ctx context.Context, workspace string) ([]*domain.APIKey, error)
+
+	// Rotate replaces the secret of a key; the old one keeps working until
+	// previousExpiresAt, and expiresAt (if not nil) is the key's new expiry
+	// (domain.ErrAPIKeyNotFound if the workspace has no such key)
+	Rotate(ctx context.Context, workspace, id, prefix, tokenHash, hashVersion string, previousExpiresAt time.Time, expiresAt *time.Time) (*domain.APIKey, error)
+
+	// Rehash replaces the hash of a key's current secret, still the one
+	// starting with prefix (a key rotated in the meantime is left alone)
+	Rehash(ctx context.Context, id, prefix, tokenHash, hashVersion string) error
+
+	// Delete revokes a key (domain.ErrAPIKeyNotFound)
+	Delete(ctx context.Context, workspace, id string) error
+
+	// Touch records that a key was used at the given time
+	Touch(ctx context.Context, id string, usedAt time.Time) error
+
+	// RevokeExpired deletes the keys expired at now and forgets previous
+	// secrets whose grace period is over; returns how many keys were deleted
+	RevokeExpired(ctx context.Context, now time.Time) (int64, error)
+}
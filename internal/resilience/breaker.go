@@ -0,0 +1,164 @@
+package resilience
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"url-shortener/internal/metrics"
+)
+
+// ErrCircuitOpen is returned while a circuit breaker rejects calls
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// OpenError carries how long the caller should wait before trying again
+// errors.Is(err, ErrCircuitOpen) matches it
+type OpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s: %v (retry after %s)", e.Name, ErrCircuitOpen, e.RetryAfter.Round(time.Second))
+}
+
+func (e *OpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// Breaker states, also exported as the circuit_breaker_state gauge value
+const (
+	StateClosed   = 0 // Calls flow normally
+	StateHalfOpen = 1 // One trial call decides whether to close again
+	StateOpen     = 2 // Calls are rejected without touching the dependency
+)
+
+// CircuitBreaker stops calling a dependency that keeps failing
+//
+// WHY?
+// When PostgreSQL is overloaded, every extra query makes it slower. Without a
+// breaker, redirects pile up waiting for timeouts, hold connections, and turn
+// a slow database into a full outage. After `threshold` consecutive failures
+// the breaker OPENS: calls fail immediately for `openTimeout`, giving the
+// database room to recover. Then one trial call (HALF-OPEN) decides whether
+// to close the circuit again or stay open for another round.
+//
+// A nil *CircuitBreaker allows every call, so it is optional for callers.
+type CircuitBreaker struct {
+	name        string
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	state    int
+	failures int       // Consecutive failures while closed
+	openedAt time.Time // When the breaker last opened
+	probing  bool      // A half-open trial call is in flight
+}
+
+// NewCircuitBreaker creates a closed breaker
+// name labels the metrics, e.g. "postgres_redirect"
+func NewCircuitBreaker(name string, threshold int, openTimeout time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	b := &CircuitBreaker{name: name, threshold: threshold, openTimeout: openTimeout}
+	metrics.SetCircuitBreakerState(name, StateClosed)
+	return b
+}
+
+// Allow asks for permission to make a call
+// It returns an *OpenError while the breaker is open. Every allowed call
+// MUST be followed by exactly one Record.
+func (b *CircuitBreaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		elapsed := time.Since(b.openedAt)
+		if elapsed < b.openTimeout {
+			return b.reject(b.openTimeout - elapsed)
+		}
+		// Cool-down is over: let ONE trial call through
+		b.setState(StateHalfOpen)
+		b.probing = true
+		return nil
+
+	case StateHalfOpen:
+		if b.probing {
+			return b.reject(b.openTimeout)
+		}
+		b.probing = true
+		return nil
+
+	default:
+		return nil
+	}
+}
+
+// Record reports the outcome of an allowed call
+// Pass false only for failures of the dependency itself - "not found" is a
+// successful query
+func (b *CircuitBreaker) Record(success bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen {
+		b.probing = false
+		if success {
+			b.failures = 0
+			b.setState(StateClosed)
+		} else {
+			b.trip()
+		}
+		return
+	}
+
+	if success {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == StateClosed && b.failures >= b.threshold {
+		b.trip()
+	}
+}
+
+// State returns the current state (StateClosed, StateHalfOpen or StateOpen)
+func (b *CircuitBreaker) State() int {
+	if b == nil {
+		return StateClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// trip opens the breaker (caller holds the lock)
+func (b *CircuitBreaker) trip() {
+	b.openedAt = time.Now()
+	b.failures = 0
+	b.setState(StateOpen)
+}
+
+// reject counts a rejected call (caller holds the lock)
+func (b *CircuitBreaker) reject(retryAfter time.Duration) error {
+	metrics.RecordCircuitBreakerRejection(b.name)
+	return &OpenError{Name: b.name, RetryAfter: retryAfter}
+}
+
+// setState changes the state and updates the gauge (caller holds the lock)
+func (b *CircuitBreaker) setState(state int) {
+	b.state = state
+	metrics.SetCircuitBreakerState(b.name, state)
+}
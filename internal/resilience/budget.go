@@ -0,0 +1,118 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"url-shortener/internal/metrics"
+)
+
+// Dependency is what a Policy's calls are charged to in a request's Budget
+type Dependency string
+
+// Dependencies with a budget
+const (
+	DependencyCache    Dependency = "cache"
+	DependencyDatabase Dependency = "database"
+)
+
+// Budget is how long one request may wait on each dependency in total,
+// over all its calls and retries (0 = no limit besides the route deadline)
+//
+// WHY A BUDGET ON TOP OF THE ROUTE DEADLINE?
+// A redirect has 3 seconds before its deadline, and per-attempt timeouts
+// of 200ms (cache) and 2s (database). A cache that answers in 190ms, twice,
+// then a slow database, all fit - and the visitor waits seconds for a page
+// that normally takes 5ms. With a budget of 50ms for the cache, a slow cache
+// is given up on early and the database answers instead; with 200ms for the
+// database, the redirect fails fast (503) instead of hanging.
+type Budget struct {
+	Cache    time.Duration
+	Database time.Duration
+}
+
+// ErrBudgetExhausted is returned (wrapping the call's own error) by calls
+// that ran out of their request's budget for the dependency
+var ErrBudgetExhausted = errors.New("request budget exhausted")
+
+// budgetKey is the context key of a request's spending
+type budgetKey struct{}
+
+// spending is what is left of a request's budget
+type spending struct {
+	request context.Context // The budget ends with the request
+	route   string
+
+	mu        sync.Mutex
+	remaining map[Dependency]time.Duration
+	exhausted bool
+}
+
+// WithBudget returns a copy of ctx whose dependency calls share budget
+// route names the request in metrics (a mux pattern, e.g. "/")
+//
+// Only calls made while ctx is alive are charged: work the request leaves
+// running in the background (context.WithoutCancel, e.g. recording the
+// click) is not held to the redirect's budget once the response is sent.
+func WithBudget(ctx context.Context, route string, budget Budget) context.Context {
+	remaining := map[Dependency]time.Duration{}
+	if budget.Cache > 0 {
+		remaining[DependencyCache] = budget.Cache
+	}
+	if budget.Database > 0 {
+		remaining[DependencyDatabase] = budget.Database
+	}
+	if len(remaining) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, budgetKey{}, &spending{request: ctx, route: route, remaining: remaining})
+}
+
+// BudgetExhausted reports whether a call of the request behind ctx ran out
+// of its budget
+func BudgetExhausted(ctx context.Context) bool {
+	s, ok := ctx.Value(budgetKey{}).(*spending)
+	if !ok {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.exhausted
+}
+
+// charge bounds ctx by what is left of the budget for dependency
+// The returned settle function must be called with the call's error once it
+// returns: it takes the time waited off the budget and tells a call that
+// ran out of it from any other failure.
+func charge(ctx context.Context, dependency Dependency) (context.Context, func(err error) error) {
+	s, ok := ctx.Value(budgetKey{}).(*spending)
+	if !ok || dependency == "" || s.request.Err() != nil {
+		return ctx, func(err error) error { return err }
+	}
+	s.mu.Lock()
+	remaining, limited := s.remaining[dependency]
+	s.mu.Unlock()
+	if !limited {
+		return ctx, func(err error) error { return err }
+	}
+
+	start := time.Now()
+	bounded, cancel := context.WithTimeout(ctx, remaining)
+	return bounded, func(err error) error {
+		defer cancel()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.remaining[dependency] = max(s.remaining[dependency]-time.Since(start), 0)
+
+		// Out of budget, not the caller's own deadline or cancellation
+		if err == nil || ctx.Err() != nil || !errors.Is(bounded.Err(), context.DeadlineExceeded) {
+			return err
+		}
+		s.exhausted = true
+		metrics.RecordBudgetExhausted(string(dependency), s.route)
+		return fmt.Errorf("%w for %s: %w", ErrBudgetExhausted, dependency, err)
+	}
+}
@@ -0,0 +1,92 @@
+package resilience
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// waitForDeadline stands for a call to a dependency that doesn't answer
+func waitForDeadline(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestCall_Budget(t *testing.T) {
+	// Arrange
+	ctx := WithBudget(context.Background(), "/", Budget{Cache: 20 * time.Millisecond})
+	cache := Policy{Dependency: DependencyCache, MaxRetries: 5, Idempotent: true}
+	database := Policy{Dependency: DependencyDatabase}
+
+	// Act
+	start := time.Now()
+	cacheErr := Do(ctx, cache, "redis.GetURL", waitForDeadline)
+	spent := time.Since(start)
+	againErr := Do(ctx, cache, "redis.SetURL", func(ctx context.Context) error { return ctx.Err() })
+	databaseErr := Do(ctx, database, "postgres.GetByShortCode", func(ctx context.Context) error { return nil })
+
+	// Assert
+	assert.ErrorIs(t, cacheErr, ErrBudgetExhausted)
+	assert.ErrorIs(t, cacheErr, context.DeadlineExceeded)
+	assert.Less(t, spent, time.Second, "retries stop when the budget is used up")
+	assert.ErrorIs(t, againErr, ErrBudgetExhausted, "nothing is left for later calls")
+	assert.NoError(t, databaseErr, "dependencies without a budget are not limited")
+	assert.True(t, BudgetExhausted(ctx))
+}
+
+func TestCall_Budget_IsShared(t *testing.T) {
+	// Arrange
+	ctx := WithBudget(context.Background(), "/", Budget{Database: 50 * time.Millisecond})
+	database := Policy{Dependency: DependencyDatabase}
+	slowQuery := func(ctx context.Context) error {
+		select {
+		case <-time.After(30 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// Act
+	first := Do(ctx, database, "postgres.GetByShortCode", slowQuery)
+	second := Do(ctx, database, "postgres.GetByCustomAlias", slowQuery)
+
+	// Assert
+	assert.NoError(t, first)
+	assert.ErrorIs(t, second, ErrBudgetExhausted, "only 20ms were left")
+}
+
+func TestCall_Budget_EndsWithTheRequest(t *testing.T) {
+	// Arrange
+	request, cancel := context.WithCancel(context.Background())
+	ctx := WithBudget(request, "/", Budget{Database: time.Millisecond})
+	cancel()
+	background := context.WithoutCancel(ctx) // e.g. the click recorded after the response
+
+	// Act
+	err := Do(background, Policy{Dependency: DependencyDatabase}, "postgres.CreateClick", func(ctx context.Context) error {
+		time.Sleep(5 * time.Millisecond)
+		return ctx.Err()
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.False(t, BudgetExhausted(background))
+}
+
+func TestCall_Budget_CallerDeadlineIsNotTheBudget(t *testing.T) {
+	// Arrange
+	ctx := WithBudget(context.Background(), "/", Budget{Database: time.Second})
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	// Act
+	err := Do(ctx, Policy{Dependency: DependencyDatabase}, "postgres.GetByShortCode", waitForDeadline)
+
+	// Assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, ErrBudgetExhausted)
+	assert.False(t, BudgetExhausted(ctx))
+}
@@ -0,0 +1,43 @@
+package resilience
+
+import (
+	"context"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/domain"
+)
+
+// Cache wraps a cache.URLCache with timeouts and retries
+//
+// Keep the Redis timeout SHORT: the database is the fallback for every cache
+// failure, so a slow cache only adds latency. Every cache operation is
+// idempotent, so all of them may be retried.
+type Cache struct {
+	next   cache.URLCache
+	policy Policy
+}
+
+// NewCache wraps next
+func NewCache(next cache.URLCache, policy Policy) *Cache {
+	policy.Idempotent = true
+	policy.Dependency = DependencyCache
+	return &Cache{next: next, policy: policy}
+}
+
+func (c *Cache) GetURL(ctx context.Context, shortCode string) (*domain.URL, error) {
+	return Call(ctx, c.policy, "redis.GetURL", func(ctx context.Context) (*domain.URL, error) {
+		return c.next.GetURL(ctx, shortCode)
+	})
+}
+
+func (c *Cache) SetURL(ctx context.Context, shortCode string, url *domain.URL) error {
+	return Do(ctx, c.policy, "redis.SetURL", func(ctx context.Context) error {
+		return c.next.SetURL(ctx, shortCode, url)
+	})
+}
+
+func (c *Cache) DeleteURL(ctx context.Context, shortCode string) error {
+	return Do(ctx, c.policy, "redis.DeleteURL", func(ctx context.Context) error {
+		return c.next.DeleteURL(ctx, shortCode)
+	})
+}
@@ -0,0 +1,92 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// IsRetryable reports whether a failed call is worth another attempt
+//
+// Errors fall into three groups:
+//   - TRANSIENT: the call certainly had no effect and may succeed next time
+//     (serialization failure, deadlock, "too many connections", failed to
+//     send). Safe to retry anything.
+//   - AMBIGUOUS: the connection broke or timed out, so the server may or may
+//     not have executed it. Only idempotent calls are retried - repeating an
+//     IncrementClicks could count a click twice.
+//   - PERMANENT: everything else (not found, constraint violations, bad SQL,
+//     canceled by the caller). Retrying gives the same answer.
+func IsRetryable(err error, idempotent bool) bool {
+	switch {
+	case err == nil:
+		return false
+	case isTransient(err):
+		return true
+	case isAmbiguous(err):
+		return idempotent
+	default:
+		return false
+	}
+}
+
+// PostgreSQL error codes (https://www.postgresql.org/docs/current/errcodes-appendix.html)
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgTooManyConnections   = "53300"
+	pgCannotConnectNow     = "57P03" // Server starting up or in recovery
+	pgAdminShutdown        = "57P01"
+)
+
+func isTransient(err error) bool {
+	// The transaction was rolled back by the server - nothing happened
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgSerializationFailure, pgDeadlockDetected, pgTooManyConnections, pgCannotConnectNow:
+			return true
+		}
+		return false
+	}
+
+	// pgx guarantees nothing was sent to the server
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	// Errors that describe themselves as temporary (e.g. injected faults)
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) && temporary.Temporary() {
+		return true
+	}
+
+	return false
+}
+
+func isAmbiguous(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 = connection exception
+		return pgErr.Code == pgAdminShutdown || (len(pgErr.Code) == 5 && pgErr.Code[:2] == "08")
+	}
+
+	// The per-attempt timeout expired (Call already checked that the caller's
+	// own context is still alive)
+	if errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) {
+		return true
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, net.ErrClosed) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
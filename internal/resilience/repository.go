@@ -0,0 +1,183 @@
+package resilience
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// URLRepository wraps a URLRepository with timeouts and retries
+//
+// Reads use the read policy. Writes use the write policy, and writes that
+// are NOT safe to repeat (Create, IncrementClicks, Restore, Purge) are only
+// retried when the error proves the first attempt had no effect.
+type URLRepository struct {
+	next         repository.URLRepository
+	reads        Policy
+	writes       Policy
+	unsafeWrites Policy
+}
+
+// NewURLRepository wraps next
+func NewURLRepository(next repository.URLRepository, reads, writes Policy) *URLRepository {
+	reads.Idempotent = true
+	writes.Idempotent = true
+	reads.Dependency = DependencyDatabase
+	writes.Dependency = DependencyDatabase
+	unsafeWrites := writes
+	unsafeWrites.Idempotent = false
+
+	return &URLRepository{next: next, reads: reads, writes: writes, unsafeWrites: unsafeWrites}
+}
+
+func (r *URLRepository) Create(ctx context.Context, url *domain.URL) error {
+	return Do(ctx, r.unsafeWrites, "postgres.Create", func(ctx context.Context) error {
+		return r.next.Create(ctx, url)
+	})
+}
+
+func (r *URLRepository) GetByShortCode(ctx context.Context, shortCode string) (*domain.URL, error) {
+	return Call(ctx, r.reads, "postgres.GetByShortCode", func(ctx context.Context) (*domain.URL, error) {
+		return r.next.GetByShortCode(ctx, shortCode)
+	})
+}
+
+func (r *URLRepository) GetByID(ctx context.Context, id string) (*domain.URL, error) {
+	return Call(ctx, r.reads, "postgres.GetByID", func(ctx context.Context) (*domain.URL, error) {
+		return r.next.GetByID(ctx, id)
+	})
+}
+
+func (r *URLRepository) GetByCustomAlias(ctx context.Context, alias string) (*domain.URL, error) {
+	return Call(ctx, r.reads, "postgres.GetByCustomAlias", func(ctx context.Context) (*domain.URL, error) {
+		return r.next.GetByCustomAlias(ctx, alias)
+	})
+}
+
+func (r *URLRepository) Update(ctx context.Context, url *domain.URL) error {
+	return Do(ctx, r.writes, "postgres.Update", func(ctx context.Context) error {
+		return r.next.Update(ctx, url)
+	})
+}
+
+func (r *URLRepository) SetMetadata(ctx context.Context, id string, meta *domain.LinkMetadata) error {
+	return Do(ctx, r.writes, "postgres.SetMetadata", func(ctx context.Context) error {
+		return r.next.SetMetadata(ctx, id, meta)
+	})
+}
+
+func (r *URLRepository) Delete(ctx context.Context, id string) error {
+	return Do(ctx, r.writes, "postgres.Delete", func(ctx context.Context) error {
+		return r.next.Delete(ctx, id)
+	})
+}
+
+func (r *URLRepository) Restore(ctx context.Context, id string) error {
+	// Not idempotent: a second attempt fails with "not deleted"
+	return Do(ctx, r.unsafeWrites, "postgres.Restore", func(ctx context.Context) error {
+		return r.next.Restore(ctx, id)
+	})
+}
+
+func (r *URLRepository) Purge(ctx context.Context, id string) (int64, error) {
+	return Call(ctx, r.unsafeWrites, "postgres.Purge", func(ctx context.Context) (int64, error) {
+		return r.next.Purge(ctx, id)
+	})
+}
+
+func (r *URLRepository) IncrementClicks(ctx context.Context, shortCode string) error {
+	return Do(ctx, r.unsafeWrites, "postgres.IncrementClicks", func(ctx context.Context) error {
+		return r.next.IncrementClicks(ctx, shortCode)
+	})
+}
+
+func (r *URLRepository) MarkUsed(ctx context.Context, shortCode string) (bool, error) {
+	return Call(ctx, r.unsafeWrites, "postgres.MarkUsed", func(ctx context.Context) (bool, error) {
+		return r.next.MarkUsed(ctx, shortCode)
+	})
+}
+
+func (r *URLRepository) Burn(ctx context.Context, shortCode string) (*string, error) {
+	return Call(ctx, r.unsafeWrites, "postgres.Burn", func(ctx context.Context) (*string, error) {
+		return r.next.Burn(ctx, shortCode)
+	})
+}
+
+func (r *URLRepository) ExistsShortCode(ctx context.Context, shortCode string) (bool, error) {
+	return Call(ctx, r.reads, "postgres.ExistsShortCode", func(ctx context.Context) (bool, error) {
+		return r.next.ExistsShortCode(ctx, shortCode)
+	})
+}
+
+func (r *URLRepository) ExistsCustomAlias(ctx context.Context, alias string) (bool, error) {
+	return Call(ctx, r.reads, "postgres.ExistsCustomAlias", func(ctx context.Context) (bool, error) {
+		return r.next.ExistsCustomAlias(ctx, alias)
+	})
+}
+
+func (r *URLRepository) FindTakenCodes(ctx context.Context, codes []string) (map[string]bool, error) {
+	return Call(ctx, r.reads, "postgres.FindTakenCodes", func(ctx context.Context) (map[string]bool, error) {
+		return r.next.FindTakenCodes(ctx, codes)
+	})
+}
+
+// ClickRepository wraps a ClickRepository with timeouts and retries
+type ClickRepository struct {
+	next         repository.ClickRepository
+	reads        Policy
+	unsafeWrites Policy
+}
+
+// NewClickRepository wraps next
+func NewClickRepository(next repository.ClickRepository, reads, writes Policy) *ClickRepository {
+	reads.Idempotent = true
+	writes.Idempotent = false // Repeating Create would record the click twice
+	reads.Dependency = DependencyDatabase
+	writes.Dependency = DependencyDatabase
+
+	return &ClickRepository{next: next, reads: reads, unsafeWrites: writes}
+}
+
+func (r *ClickRepository) Create(ctx context.Context, click *domain.URLClick) error {
+	return Do(ctx, r.unsafeWrites, "postgres.CreateClick", func(ctx context.Context) error {
+		return r.next.Create(ctx, click)
+	})
+}
+
+func (r *ClickRepository) GetByURLID(ctx context.Context, urlID string, limit, offset int) ([]*domain.URLClick, error) {
+	return Call(ctx, r.reads, "postgres.GetClicks", func(ctx context.Context) ([]*domain.URLClick, error) {
+		return r.next.GetByURLID(ctx, urlID, limit, offset)
+	})
+}
+
+func (r *ClickRepository) GetClickCount(ctx context.Context, urlID string) (int64, error) {
+	return Call(ctx, r.reads, "postgres.GetClickCount", func(ctx context.Context) (int64, error) {
+		return r.next.GetClickCount(ctx, urlID)
+	})
+}
+
+func (r *ClickRepository) CountBy(ctx context.Context, urlID string, dimension domain.ClickDimension) (map[string]int64, error) {
+	return Call(ctx, r.reads, "postgres.CountClicksBy", func(ctx context.Context) (map[string]int64, error) {
+		return r.next.CountBy(ctx, urlID, dimension)
+	})
+}
+
+func (r *ClickRepository) CountByPeriod(ctx context.Context, urlID string, interval domain.Interval, location *time.Location, from, to time.Time) ([]domain.ClickBucket, error) {
+	return Call(ctx, r.reads, "postgres.CountClicksByPeriod", func(ctx context.Context) ([]domain.ClickBucket, error) {
+		return r.next.CountByPeriod(ctx, urlID, interval, location, from, to)
+	})
+}
+
+func (r *ClickRepository) CountByHourOfWeek(ctx context.Context, urlID string, location *time.Location, from, to time.Time) ([]domain.HourOfWeek, error) {
+	return Call(ctx, r.reads, "postgres.CountClicksByHourOfWeek", func(ctx context.Context) ([]domain.HourOfWeek, error) {
+		return r.next.CountByHourOfWeek(ctx, urlID, location, from, to)
+	})
+}
+
+func (r *ClickRepository) TopLinks(ctx context.Context, owner string, since time.Time, limit int) ([]domain.TopLink, error) {
+	return Call(ctx, r.reads, "postgres.TopLinks", func(ctx context.Context) ([]domain.TopLink, error) {
+		return r.next.TopLinks(ctx, owner, since, limit)
+	})
+}
@@ -0,0 +1,102 @@
+// Package resilience wraps dependency calls with timeouts and retries
+//
+// WHY NOT RETRY INSIDE THE SERVICE?
+// The service layer should read like business logic. Timeouts, retries and
+// backoff are the same for every call, so they live in DECORATORS around the
+// repositories and the cache (same interface, extra behavior), and main.go
+// decides whether to use them.
+package resilience
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"url-shortener/internal/metrics"
+)
+
+// Policy describes how one kind of call is protected
+type Policy struct {
+	Timeout    time.Duration // Per-attempt timeout (0 = rely on the caller's context)
+	MaxRetries int           // Retries after the first attempt (0 = never retry)
+	BaseDelay  time.Duration // First backoff delay, doubled on every retry
+	MaxDelay   time.Duration // Upper bound for a single backoff delay
+
+	// Idempotent operations can be repeated safely, so they are also retried
+	// when we can't tell whether the first attempt reached the server
+	// (connection reset, timeout). See IsRetryable.
+	Idempotent bool
+
+	// Dependency the calls are charged to when the request has a Budget
+	// ("" = never limited by one)
+	Dependency Dependency
+}
+
+// Call runs fn under the policy and returns its result
+// op names the operation in metrics, e.g. "postgres.GetByShortCode"
+// Every attempt and backoff counts against the request's Budget, if any
+func Call[T any](ctx context.Context, p Policy, op string, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, settle := charge(ctx, p.Dependency)
+	result, err := retry(ctx, p, op, fn)
+	return result, settle(err)
+}
+
+// retry runs fn until it succeeds or may not be retried anymore
+func retry[T any](ctx context.Context, p Policy, op string, fn func(ctx context.Context) (T, error)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		result, err := runAttempt(ctx, p.Timeout, fn)
+		if err == nil || attempt >= p.MaxRetries || ctx.Err() != nil || !IsRetryable(err, p.Idempotent) {
+			return result, err
+		}
+
+		metrics.RecordDependencyRetry(op)
+
+		// Wait before the next attempt, unless the caller gives up first
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+	}
+}
+
+// Do is Call for operations that only return an error
+func Do(ctx context.Context, p Policy, op string, fn func(ctx context.Context) error) error {
+	_, err := Call(ctx, p, op, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// runAttempt runs fn once with the per-attempt timeout
+func runAttempt[T any](ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+// backoff returns the delay before retry number attempt+1
+//
+// EXPONENTIAL BACKOFF WITH FULL JITTER:
+// The ceiling doubles on every retry (25ms, 50ms, 100ms...) up to MaxDelay,
+// and the actual delay is random between 0 and the ceiling. Without the
+// randomness, every instance that failed at the same moment would retry at
+// the same moment too, hitting the recovering database in synchronized waves.
+func (p Policy) backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	ceiling := p.BaseDelay << attempt
+	if p.MaxDelay > 0 && (ceiling <= 0 || ceiling > p.MaxDelay) {
+		ceiling = p.MaxDelay // ceiling <= 0 means the shift overflowed
+	}
+	if ceiling <= 0 {
+		ceiling = p.BaseDelay
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
@@ -0,0 +1,227 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Resolver follows the redirect chain of a destination URL without trusting it
+// This is used to UNWRAP links that point at other shorteners (link cloaking),
+// a common trick to hide the real destination from abuse filters
+//
+// SAFETY:
+// - Bounded number of hops so a redirect loop can't hold a request forever
+// - Per-request timeout on the whole resolution
+// - Connections to private/loopback addresses are refused (SSRF protection)
+type Resolver struct {
+	client  *http.Client
+	maxHops int
+}
+
+// Hop is a single step in a redirect chain
+type Hop struct {
+	URL         string `json:"url"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// Result describes where a destination URL ultimately leads
+type Result struct {
+	FinalURL     string // URL of the last hop (the real destination)
+	Hops         []Hop  // Every request made, in order
+	ViaShortener bool   // True if any hop went through a known URL shortener
+	Truncated    bool   // True if the chain was longer than maxHops
+}
+
+// Redirected reports whether the destination redirected somewhere else
+func (r *Result) Redirected() bool {
+	return len(r.Hops) > 1
+}
+
+var (
+	// ErrBlockedAddress is returned when a hop resolves to a private network address
+	ErrBlockedAddress = errors.New("destination resolves to a blocked address")
+)
+
+// knownShorteners lists hosts of public URL shorteners
+// A destination that redirects through one of these is most likely cloaking its target
+var knownShorteners = map[string]bool{
+	"bit.ly":      true,
+	"bitly.com":   true,
+	"buff.ly":     true,
+	"cutt.ly":     true,
+	"goo.gl":      true,
+	"is.gd":       true,
+	"ow.ly":       true,
+	"rb.gy":       true,
+	"rebrand.ly":  true,
+	"s.id":        true,
+	"shorturl.at": true,
+	"t.co":        true,
+	"t.ly":        true,
+	"tiny.cc":     true,
+	"tinyurl.com": true,
+	"v.gd":        true,
+}
+
+// New creates a resolver that follows at most maxHops redirects,
+// giving up after timeout for the whole chain
+func New(maxHops int, timeout time.Duration) *Resolver {
+	if maxHops <= 0 {
+		maxHops = 5
+	}
+
+	return &Resolver{
+		client:  NewSafeClient(timeout),
+		maxHops: maxHops,
+	}
+}
+
+// NewSafeClient returns an http.Client that:
+// 1. Does NOT follow redirects (callers inspect each hop themselves)
+// 2. Refuses to connect to loopback, private, and link-local addresses
+//
+// The address check runs at dial time (after DNS resolution), so it can't be
+// bypassed with a hostname that points at 127.0.0.1
+func NewSafeClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || isBlockedIP(ip) {
+				return ErrBlockedAddress
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Resolve follows redirects starting at rawURL and returns the chain
+// On error the result still holds the hops fetched before it, so callers
+// can show how far the chain got
+func (r *Resolver) Resolve(ctx context.Context, rawURL string) (*Result, error) {
+	result := &Result{}
+	current := rawURL
+
+	for i := 0; i <= r.maxHops; i++ {
+		currentURL, err := url.Parse(current)
+		if err != nil {
+			return result, fmt.Errorf("invalid URL in redirect chain: %w", err)
+		}
+		if currentURL.Scheme != "http" && currentURL.Scheme != "https" {
+			// Redirects to mailto:, javascript:, etc. end the chain
+			return result, nil
+		}
+
+		if IsKnownShortener(currentURL.Hostname()) {
+			result.ViaShortener = true
+		}
+
+		hop, location, err := r.fetch(ctx, current)
+		if err != nil {
+			return result, err
+		}
+		result.Hops = append(result.Hops, hop)
+		result.FinalURL = current
+
+		if location == "" {
+			return result, nil
+		}
+
+		// Location can be relative, resolve it against the current URL
+		next, err := currentURL.Parse(location)
+		if err != nil {
+			return result, fmt.Errorf("invalid redirect location %q: %w", location, err)
+		}
+		current = next.String()
+	}
+
+	result.Truncated = true
+	return result, nil
+}
+
+// fetch performs a single request and returns the hop plus the redirect target (if any)
+// HEAD is tried first because it's cheap; some servers reject it, so fall back to GET
+func (r *Resolver) fetch(ctx context.Context, target string) (Hop, string, error) {
+	resp, err := r.do(ctx, http.MethodHead, target)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = r.do(ctx, http.MethodGet, target)
+	}
+	if err != nil {
+		return Hop{}, "", fmt.Errorf("failed to fetch %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	hop := Hop{
+		URL:         target,
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		return hop, resp.Header.Get("Location"), nil
+	}
+
+	return hop, "", nil
+}
+
+func (r *Resolver) do(ctx context.Context, method, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "url-shortener-resolver/1.0")
+
+	return r.client.Do(req)
+}
+
+// IsKnownShortener reports whether host belongs to a public URL shortener
+func IsKnownShortener(host string) bool {
+	host = strings.ToLower(strings.TrimPrefix(host, "www."))
+	return knownShorteners[host]
+}
+
+// IsShortenerURL reports whether rawURL points at a public URL shortener
+// Unparseable URLs are not (validation reports them)
+func IsShortenerURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && IsKnownShortener(parsed.Hostname())
+}
+
+// isBlockedIP reports whether ip is in a range we must never connect to
+func isBlockedIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified()
+}
@@ -0,0 +1,220 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestResolver returns a resolver that may reach httptest servers
+// (the safe client refuses loopback, see TestNewSafeClient)
+func newTestResolver(maxHops int, transport http.RoundTripper) *Resolver {
+	return &Resolver{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxHops: maxHops,
+	}
+}
+
+// roundTripFunc lets a function act as a transport
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestResolve_FollowsChain(t *testing.T) {
+	// Arrange
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "b") // Relative to /a
+		w.WriteHeader(http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/c?ref=b")
+		w.WriteHeader(http.StatusFound)
+	})
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// Act
+	result, err := newTestResolver(5, nil).Resolve(context.Background(), server.URL+"/a")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/c?ref=b", result.FinalURL)
+	assert.True(t, result.Redirected())
+	assert.False(t, result.Truncated)
+	require.Len(t, result.Hops, 3)
+	assert.Equal(t, server.URL+"/b", result.Hops[1].URL)
+	assert.Equal(t, http.StatusFound, result.Hops[1].StatusCode)
+	assert.Equal(t, "text/html", result.Hops[2].ContentType)
+}
+
+func TestResolve_HopLimit(t *testing.T) {
+	// Arrange: every page redirects to the next one
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+	}))
+	defer server.Close()
+
+	// Act
+	result, err := newTestResolver(3, nil).Resolve(context.Background(), server.URL+"/")
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, result.Truncated)
+	assert.Len(t, result.Hops, 4) // The start plus 3 redirects
+	assert.Equal(t, server.URL+"/xxx", result.FinalURL)
+}
+
+func TestResolve_FallsBackToGET(t *testing.T) {
+	for _, status := range []int{http.StatusMethodNotAllowed, http.StatusNotImplemented} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			// Arrange
+			var methods []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				methods = append(methods, r.Method)
+				if r.Method == http.MethodHead {
+					w.WriteHeader(status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			// Act
+			result, err := newTestResolver(5, nil).Resolve(context.Background(), server.URL)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, []string{http.MethodHead, http.MethodGet}, methods)
+			require.Len(t, result.Hops, 1)
+			assert.Equal(t, http.StatusOK, result.Hops[0].StatusCode)
+		})
+	}
+}
+
+func TestResolve_NonHTTPSchemeEndsChain(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "mailto:sales@example.com")
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer server.Close()
+
+	// Act
+	result, err := newTestResolver(5, nil).Resolve(context.Background(), server.URL)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, result.Hops, 1)
+	assert.Equal(t, server.URL, result.FinalURL)
+	assert.False(t, result.Truncated)
+}
+
+func TestResolve_KnownShortenerWhenFetchFails(t *testing.T) {
+	// Arrange: the shortener times out
+	failing := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("i/o timeout")
+	})
+
+	// Act
+	result, err := newTestResolver(5, failing).Resolve(context.Background(), "https://bit.ly/abc")
+
+	// Assert
+	assert.Error(t, err)
+	require.NotNil(t, result)
+	assert.True(t, result.ViaShortener)
+	assert.Empty(t, result.Hops)
+}
+
+func TestIsShortenerURL(t *testing.T) {
+	tests := []struct {
+		rawURL   string
+		expected bool
+	}{
+		{"https://bit.ly/abc", true},
+		{"https://www.TinyURL.com/x", true},
+		{"https://example.com/bit.ly", false},
+		{"://not a url", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rawURL, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsShortenerURL(tt.rawURL))
+		})
+	}
+}
+
+func TestNewSafeClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{name: "loopback", target: server.URL},
+		{name: "localhost by name", target: "http://localhost:1/"},
+		{name: "private network", target: "http://10.0.0.1/"},
+		{name: "link-local (cloud metadata)", target: "http://169.254.169.254/latest/meta-data/"},
+		{name: "unspecified", target: "http://0.0.0.0:1/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			client := NewSafeClient(time.Second)
+
+			// Act
+			resp, err := client.Get(tt.target)
+
+			// Assert
+			if resp != nil {
+				resp.Body.Close()
+			}
+			assert.ErrorIs(t, err, ErrBlockedAddress)
+		})
+	}
+}
+
+func TestIsBlockedIP(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"fd00::1", true},
+		{"169.254.169.254", true},
+		{"224.0.0.1", true},
+		{"0.0.0.0", true},
+		{"93.184.216.34", false},
+		{"2606:2800:220:1::", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isBlockedIP(net.ParseIP(tt.ip)))
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+
+	_ "crypto/sha256" // Register the hashes crypto.Hash.New uses
+	_ "crypto/sha512"
+)
+
+// XML Signature namespaces and algorithms
+const (
+	namespaceDSig = "http://www.w3.org/2000/09/xmldsig#"
+
+	algExcC14N     = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnveloped   = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algSHA256      = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algSHA512      = "http://www.w3.org/2001/04/xmlenc#sha512"
+	algRSASHA256   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algRSASHA512   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	algECDSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+	algECDSASHA512 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha512"
+)
+
+// digestAlgorithms and signatureAlgorithms are what we accept
+// SHA-1 is left out on purpose: every IdP we target signs with SHA-256
+var (
+	digestAlgorithms = map[string]crypto.Hash{
+		algSHA256: crypto.SHA256,
+		algSHA512: crypto.SHA512,
+	}
+	signatureAlgorithms = map[string]crypto.Hash{
+		algRSASHA256:   crypto.SHA256,
+		algRSASHA512:   crypto.SHA512,
+		algECDSASHA256: crypto.SHA256,
+		algECDSASHA512: crypto.SHA512,
+	}
+)
+
+// verifySignature checks the enveloped signature of el against cert
+//
+// HOW AN ENVELOPED SIGNATURE WORKS:
+//  1. The Signature element sits INSIDE the element it signs, and its
+//     Reference points back at it by ID (URI="#_abc").
+//  2. DigestValue is the hash of that element, canonicalized, with the
+//     Signature itself left out (it can't contain its own hash).
+//  3. SignatureValue signs the canonical SignedInfo, which holds the
+//     digest - so changing one byte of the element breaks the chain.
+//
+// The reference must name el itself: a signature over some other element
+// proves nothing about el (that's the "signature wrapping" attack). The
+// certificate comes from the workspace's configuration, never from the
+// KeyInfo in the document, which anybody could have put there.
+func verifySignature(el *element, cert *x509.Certificate) error {
+	signatures := el.childElements(namespaceDSig, "Signature")
+	if len(signatures) != 1 {
+		return fmt.Errorf("%w: want one signature, found %d", ErrInvalidSignature, len(signatures))
+	}
+	signature := signatures[0]
+
+	signedInfo := signature.child(namespaceDSig, "SignedInfo")
+	if signedInfo == nil {
+		return fmt.Errorf("%w: no SignedInfo", ErrInvalidSignature)
+	}
+
+	c14nMethod := signedInfo.child(namespaceDSig, "CanonicalizationMethod")
+	if c14nMethod == nil || c14nMethod.attr("Algorithm") != algExcC14N {
+		return fmt.Errorf("%w: canonicalization must be exclusive C14N", ErrUnsupportedAlgorithm)
+	}
+	signatureMethod := signedInfo.child(namespaceDSig, "SignatureMethod")
+	if signatureMethod == nil {
+		return fmt.Errorf("%w: no SignatureMethod", ErrInvalidSignature)
+	}
+	signatureHash, ok := signatureAlgorithms[signatureMethod.attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("%w: signature method %q", ErrUnsupportedAlgorithm, signatureMethod.attr("Algorithm"))
+	}
+
+	// Exactly one reference, to el
+	references := signedInfo.childElements(namespaceDSig, "Reference")
+	if len(references) != 1 {
+		return fmt.Errorf("%w: want one reference, found %d", ErrInvalidSignature, len(references))
+	}
+	reference := references[0]
+	id := el.attr("ID")
+	if id == "" || reference.attr("URI") != "#"+id {
+		return fmt.Errorf("%w: signature does not reference the signed element", ErrInvalidSignature)
+	}
+
+	// Only the transforms SAML uses: enveloped signature, then exclusive C14N
+	var inclusive []string
+	var canonical bool
+	if transforms := reference.child(namespaceDSig, "Transforms"); transforms != nil {
+		for _, transform := range transforms.childElements(namespaceDSig, "Transform") {
+			switch transform.attr("Algorithm") {
+			case algEnveloped:
+			case algExcC14N:
+				canonical = true
+				inclusive = inclusivePrefixList(transform)
+			default:
+				return fmt.Errorf("%w: transform %q", ErrUnsupportedAlgorithm, transform.attr("Algorithm"))
+			}
+		}
+	}
+	if !canonical {
+		return fmt.Errorf("%w: reference must use exclusive C14N", ErrUnsupportedAlgorithm)
+	}
+
+	// Step 2: the digest of el without its signature
+	digestMethod := reference.child(namespaceDSig, "DigestMethod")
+	digestValue := reference.child(namespaceDSig, "DigestValue")
+	if digestMethod == nil || digestValue == nil {
+		return fmt.Errorf("%w: incomplete reference", ErrInvalidSignature)
+	}
+	digestHash, ok := digestAlgorithms[digestMethod.attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("%w: digest method %q", ErrUnsupportedAlgorithm, digestMethod.attr("Algorithm"))
+	}
+	expectedDigest, err := decodeBase64(digestValue.text())
+	if err != nil {
+		return fmt.Errorf("%w: digest is not base64", ErrInvalidSignature)
+	}
+	signed, err := canonicalize(el, signature, inclusive)
+	if err != nil {
+		return err
+	}
+	h := digestHash.New()
+	h.Write(signed)
+	if subtle.ConstantTimeCompare(h.Sum(nil), expectedDigest) != 1 {
+		return fmt.Errorf("%w: digest mismatch", ErrInvalidSignature)
+	}
+
+	// Step 3: the signature over SignedInfo
+	signatureValue := signature.child(namespaceDSig, "SignatureValue")
+	if signatureValue == nil {
+		return fmt.Errorf("%w: no SignatureValue", ErrInvalidSignature)
+	}
+	sig, err := decodeBase64(signatureValue.text())
+	if err != nil {
+		return fmt.Errorf("%w: signature is not base64", ErrInvalidSignature)
+	}
+	canonicalSignedInfo, err := canonicalize(signedInfo, nil, inclusivePrefixList(c14nMethod))
+	if err != nil {
+		return err
+	}
+	h = signatureHash.New()
+	h.Write(canonicalSignedInfo)
+	return verifyWithKey(cert, signatureMethod.attr("Algorithm"), signatureHash, h.Sum(nil), sig)
+}
+
+// verifyWithKey checks sig over hashed with the certificate's public key
+func verifyWithKey(cert *x509.Certificate, algorithm string, hash crypto.Hash, hashed, sig []byte) error {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if !strings.Contains(algorithm, "rsa-") {
+			return fmt.Errorf("%w: %s with an RSA key", ErrInvalidSignature, algorithm)
+		}
+		if err := rsa.VerifyPKCS1v15(key, hash, hashed, sig); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !strings.Contains(algorithm, "ecdsa-") {
+			return fmt.Errorf("%w: %s with an ECDSA key", ErrInvalidSignature, algorithm)
+		}
+		// XML Signature stores ECDSA signatures as r||s, not ASN.1
+		if len(sig) == 0 || len(sig)%2 != 0 {
+			return fmt.Errorf("%w: malformed ECDSA signature", ErrInvalidSignature)
+		}
+		r := new(big.Int).SetBytes(sig[:len(sig)/2])
+		s := new(big.Int).SetBytes(sig[len(sig)/2:])
+		if !ecdsa.Verify(key, hashed, r, s) {
+			return fmt.Errorf("%w: ECDSA verification failed", ErrInvalidSignature)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported key type %T", ErrUnsupportedAlgorithm, cert.PublicKey)
+	}
+}
+
+// inclusivePrefixList reads the InclusiveNamespaces PrefixList of a
+// canonicalization method or transform
+func inclusivePrefixList(method *element) []string {
+	if inclusive := method.child(algExcC14N, "InclusiveNamespaces"); inclusive != nil {
+		return strings.Fields(inclusive.attr("PrefixList"))
+	}
+	return nil
+}
+
+// decodeBase64 decodes base64 that may be wrapped over several lines
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
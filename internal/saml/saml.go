@@ -0,0 +1,337 @@
+// Package saml implements the service provider (SP) side of SAML 2.0 web
+// single sign-on: SP metadata, AuthnRequests (HTTP-Redirect binding) and
+// validation of the signed Responses identity providers POST to the
+// assertion consumer service (HTTP-POST binding).
+//
+// WHY NO SAML LIBRARY?
+// Like Stripe and Slack, we use a small slice of the protocol: signed
+// assertions from one IdP per workspace, no encryption, no single logout.
+// The parts that matter for security - canonicalization, the signature
+// check, and which element the data is read from - are short enough to
+// read in full here, and the binary keeps its small dependency tree.
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SAML namespaces and URNs
+const (
+	namespaceAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	namespaceProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	namespaceMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	statusSuccess      = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	confirmationBearer = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	bindingHTTPPost    = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	nameIDEmail        = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+)
+
+// ClockSkew is how far our clock and the IdP's may disagree
+const ClockSkew = 3 * time.Minute
+
+var (
+	ErrMalformed            = errors.New("malformed SAML message")
+	ErrInvalidSignature     = errors.New("invalid SAML signature")
+	ErrUnsupportedAlgorithm = errors.New("unsupported SAML algorithm")
+	ErrInvalidAssertion     = errors.New("SAML assertion rejected")
+	ErrInvalidCertificate   = errors.New("certificate must be a PEM or base64 X.509 certificate")
+)
+
+// ServiceProvider is us, as one workspace's IdP sees us
+type ServiceProvider struct {
+	EntityID string // Audience the assertions must be for
+	ACSURL   string // Where the IdP posts responses; also their Recipient
+}
+
+// IdentityProvider is the workspace's IdP
+type IdentityProvider struct {
+	EntityID    string            // Issuer of the assertions
+	Certificate *x509.Certificate // Signs them
+}
+
+// Assertion is what a verified response says about the user
+type Assertion struct {
+	ID           string              // Unique per assertion: replays are refused by ID
+	NameID       string              // The user, usually their email
+	Attributes   map[string][]string // Attribute name -> values
+	NotOnOrAfter time.Time           // When the assertion stops being usable
+}
+
+// ParseCertificate reads an IdP signing certificate
+// IdP consoles offer it as PEM or as the bare base64 of the metadata file
+func ParseCertificate(s string) (*x509.Certificate, error) {
+	s = strings.TrimSpace(s)
+	var der []byte
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		der = block.Bytes
+	} else {
+		decoded, err := decodeBase64(s)
+		if err != nil {
+			return nil, ErrInvalidCertificate
+		}
+		der = decoded
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, ErrInvalidCertificate
+	}
+	return cert, nil
+}
+
+// ParseResponse verifies a base64-encoded SAML Response (the SAMLResponse
+// form field) and returns its assertion
+//
+// WHAT IS CHECKED:
+//   - the assertion, or the response around it, is signed by the IdP
+//   - the assertion comes from the IdP (Issuer) and is for us (Audience)
+//   - it was sent to our ACS (Recipient, Destination) and is still valid
+//     (NotBefore / NotOnOrAfter, give or take ClockSkew)
+//
+// Everything returned is read from the verified element only. Replays of
+// a valid assertion are the caller's job: remember Assertion.ID until
+// Assertion.NotOnOrAfter.
+func ParseResponse(samlResponse string, sp ServiceProvider, idp IdentityProvider, now time.Time) (*Assertion, error) {
+	raw, err := decodeBase64(samlResponse)
+	if err != nil {
+		return nil, fmt.Errorf("%w: SAMLResponse is not base64", ErrMalformed)
+	}
+	root, err := parseXML(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !root.is(namespaceProtocol, "Response") {
+		return nil, fmt.Errorf("%w: not a SAML Response", ErrMalformed)
+	}
+	if err := checkUniqueIDs(root); err != nil {
+		return nil, err
+	}
+
+	if status := root.child(namespaceProtocol, "Status"); status != nil {
+		if code := status.child(namespaceProtocol, "StatusCode"); code == nil || code.attr("Value") != statusSuccess {
+			return nil, fmt.Errorf("%w: IdP answered %s", ErrInvalidAssertion, statusCode(status))
+		}
+	} else {
+		return nil, fmt.Errorf("%w: no Status", ErrMalformed)
+	}
+	if destination := root.attr("Destination"); destination != "" && destination != sp.ACSURL {
+		return nil, fmt.Errorf("%w: response is for %q", ErrInvalidAssertion, destination)
+	}
+
+	if len(root.childElements(namespaceAssertion, "EncryptedAssertion")) > 0 {
+		return nil, fmt.Errorf("%w: encrypted assertions", ErrUnsupportedAlgorithm)
+	}
+	assertions := root.childElements(namespaceAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, fmt.Errorf("%w: want one assertion, found %d", ErrMalformed, len(assertions))
+	}
+	assertion := assertions[0]
+
+	// A signed assertion is enough; otherwise the whole response must be
+	// signed (it contains the assertion). Any signature present must hold.
+	assertionSigned := assertion.child(namespaceDSig, "Signature") != nil
+	responseSigned := root.child(namespaceDSig, "Signature") != nil
+	if !assertionSigned && !responseSigned {
+		return nil, fmt.Errorf("%w: neither the assertion nor the response is signed", ErrInvalidSignature)
+	}
+	if responseSigned {
+		if err := verifySignature(root, idp.Certificate); err != nil {
+			return nil, err
+		}
+	}
+	if assertionSigned {
+		if err := verifySignature(assertion, idp.Certificate); err != nil {
+			return nil, err
+		}
+	}
+
+	return readAssertion(assertion, sp, idp, now)
+}
+
+// readAssertion checks the conditions of a verified assertion and reads it
+func readAssertion(assertion *element, sp ServiceProvider, idp IdentityProvider, now time.Time) (*Assertion, error) {
+	result := &Assertion{ID: assertion.attr("ID"), Attributes: map[string][]string{}}
+	if result.ID == "" {
+		return nil, fmt.Errorf("%w: assertion has no ID", ErrMalformed)
+	}
+
+	issuer := assertion.child(namespaceAssertion, "Issuer")
+	if issuer == nil || issuer.text() != idp.EntityID {
+		return nil, fmt.Errorf("%w: not issued by %q", ErrInvalidAssertion, idp.EntityID)
+	}
+
+	subject := assertion.child(namespaceAssertion, "Subject")
+	if subject == nil {
+		return nil, fmt.Errorf("%w: no Subject", ErrInvalidAssertion)
+	}
+	if nameID := subject.child(namespaceAssertion, "NameID"); nameID != nil {
+		result.NameID = nameID.text()
+	}
+	if result.NameID == "" {
+		return nil, fmt.Errorf("%w: no NameID", ErrInvalidAssertion)
+	}
+
+	// At least one bearer confirmation must be for our ACS and current
+	for _, confirmation := range subject.childElements(namespaceAssertion, "SubjectConfirmation") {
+		if confirmation.attr("Method") != confirmationBearer {
+			continue
+		}
+		data := confirmation.child(namespaceAssertion, "SubjectConfirmationData")
+		if data == nil || data.attr("Recipient") != sp.ACSURL {
+			continue
+		}
+		notOnOrAfter, err := parseTime(data.attr("NotOnOrAfter"))
+		if err != nil || !now.Before(notOnOrAfter.Add(ClockSkew)) {
+			continue
+		}
+		if notBefore := data.attr("NotBefore"); notBefore != "" {
+			t, err := parseTime(notBefore)
+			if err != nil || now.Add(ClockSkew).Before(t) {
+				continue
+			}
+		}
+		result.NotOnOrAfter = notOnOrAfter
+		break
+	}
+	if result.NotOnOrAfter.IsZero() {
+		return nil, fmt.Errorf("%w: no current bearer confirmation for %q", ErrInvalidAssertion, sp.ACSURL)
+	}
+
+	if conditions := assertion.child(namespaceAssertion, "Conditions"); conditions != nil {
+		if notBefore := conditions.attr("NotBefore"); notBefore != "" {
+			t, err := parseTime(notBefore)
+			if err != nil || now.Add(ClockSkew).Before(t) {
+				return nil, fmt.Errorf("%w: not valid yet", ErrInvalidAssertion)
+			}
+		}
+		if notOnOrAfter := conditions.attr("NotOnOrAfter"); notOnOrAfter != "" {
+			t, err := parseTime(notOnOrAfter)
+			if err != nil || !now.Before(t.Add(ClockSkew)) {
+				return nil, fmt.Errorf("%w: expired", ErrInvalidAssertion)
+			}
+			if t.Before(result.NotOnOrAfter) {
+				result.NotOnOrAfter = t
+			}
+		}
+		// Every AudienceRestriction must include us
+		for _, restriction := range conditions.childElements(namespaceAssertion, "AudienceRestriction") {
+			if !hasAudience(restriction, sp.EntityID) {
+				return nil, fmt.Errorf("%w: not for audience %q", ErrInvalidAssertion, sp.EntityID)
+			}
+		}
+	}
+
+	for _, statement := range assertion.childElements(namespaceAssertion, "AttributeStatement") {
+		for _, attribute := range statement.childElements(namespaceAssertion, "Attribute") {
+			name := attribute.attr("Name")
+			for _, value := range attribute.childElements(namespaceAssertion, "AttributeValue") {
+				result.Attributes[name] = append(result.Attributes[name], value.text())
+			}
+		}
+	}
+	return result, nil
+}
+
+// hasAudience reports whether an AudienceRestriction names the audience
+func hasAudience(restriction *element, audience string) bool {
+	for _, a := range restriction.childElements(namespaceAssertion, "Audience") {
+		if a.text() == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// statusCode describes a failed Status, e.g. "Responder/AuthnFailed"
+func statusCode(status *element) string {
+	var codes []string
+	for code := status.child(namespaceProtocol, "StatusCode"); code != nil; code = code.child(namespaceProtocol, "StatusCode") {
+		value := code.attr("Value")
+		codes = append(codes, value[strings.LastIndex(value, ":")+1:])
+	}
+	if len(codes) == 0 {
+		return "no status code"
+	}
+	return strings.Join(codes, "/")
+}
+
+// parseTime reads an xs:dateTime ("2026-03-01T12:00:00Z")
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// Metadata is the SP metadata document to upload to the IdP
+// It tells the IdP our entity ID, where to post responses, and that we
+// want signed assertions with the user's email as NameID
+func Metadata(sp ServiceProvider) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	buf.WriteString(`<md:EntityDescriptor xmlns:md="` + namespaceMetadata + `" entityID="`)
+	escapeAttr(&buf, sp.EntityID)
+	buf.WriteString(`">` + "\n")
+	buf.WriteString(`  <md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="` + namespaceProtocol + `">` + "\n")
+	buf.WriteString(`    <md:NameIDFormat>` + nameIDEmail + `</md:NameIDFormat>` + "\n")
+	buf.WriteString(`    <md:AssertionConsumerService Binding="` + bindingHTTPPost + `" Location="`)
+	escapeAttr(&buf, sp.ACSURL)
+	buf.WriteString(`" index="0" isDefault="true"/>` + "\n")
+	buf.WriteString(`  </md:SPSSODescriptor>` + "\n")
+	buf.WriteString(`</md:EntityDescriptor>` + "\n")
+	return buf.Bytes()
+}
+
+// AuthnRequestURL is where to send a browser to sign in at the IdP
+// (HTTP-Redirect binding: the request is deflated and base64-encoded into
+// the query). relayState comes back with the response.
+func AuthnRequestURL(sp ServiceProvider, ssoURL, relayState string, now time.Time) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate request ID: %w", err)
+	}
+
+	var request bytes.Buffer
+	request.WriteString(`<samlp:AuthnRequest xmlns:samlp="` + namespaceProtocol + `" xmlns:saml="` + namespaceAssertion + `"`)
+	request.WriteString(` ID="_` + hex.EncodeToString(id) + `" Version="2.0" IssueInstant="` + now.UTC().Format(time.RFC3339) + `"`)
+	request.WriteString(` Destination="`)
+	escapeAttr(&request, ssoURL)
+	request.WriteString(`" AssertionConsumerServiceURL="`)
+	escapeAttr(&request, sp.ACSURL)
+	request.WriteString(`" ProtocolBinding="` + bindingHTTPPost + `">`)
+	request.WriteString(`<saml:Issuer>`)
+	escapeText(&request, sp.EntityID)
+	request.WriteString(`</saml:Issuer>`)
+	request.WriteString(`<samlp:NameIDPolicy Format="` + nameIDEmail + `" AllowCreate="true"/>`)
+	request.WriteString(`</samlp:AuthnRequest>`)
+
+	var deflated bytes.Buffer
+	writer, err := flate.NewWriter(&deflated, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	writer.Write(request.Bytes())
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	target, err := url.Parse(ssoURL)
+	if err != nil {
+		return "", err
+	}
+	query := target.Query()
+	query.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	if relayState != "" {
+		query.Set("RelayState", relayState)
+	}
+	target.RawQuery = query.Encode()
+	return target.String(), nil
+}
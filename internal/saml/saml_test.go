@@ -0,0 +1,369 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testSP = ServiceProvider{
+		EntityID: "https://sho.rt/saml/team1/metadata",
+		ACSURL:   "https://sho.rt/saml/team1/acs",
+	}
+	testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+)
+
+// testIdP is an identity provider with its own key
+type testIdP struct {
+	key  crypto.Signer
+	cert *x509.Certificate
+}
+
+func newTestIdP(t *testing.T, key crypto.Signer) *testIdP {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    testNow.Add(-time.Hour),
+		NotAfter:     testNow.Add(365 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testIdP{key: key, cert: cert}
+}
+
+func newRSAIdP(t *testing.T) *testIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return newTestIdP(t, key)
+}
+
+func (idp *testIdP) provider() IdentityProvider {
+	return IdentityProvider{EntityID: "https://idp.example.com", Certificate: idp.cert}
+}
+
+// responseOptions change one thing of the test response
+type responseOptions struct {
+	issuer       string
+	audience     string
+	recipient    string
+	status       string
+	nameID       string
+	notOnOrAfter time.Time
+}
+
+// buildResponse writes a response; <!--SIG--> marks where the assertion's
+// signature goes and <!--RSIG--> the response's
+func buildResponse(opts responseOptions) string {
+	if opts.issuer == "" {
+		opts.issuer = "https://idp.example.com"
+	}
+	if opts.audience == "" {
+		opts.audience = testSP.EntityID
+	}
+	if opts.recipient == "" {
+		opts.recipient = testSP.ACSURL
+	}
+	if opts.status == "" {
+		opts.status = statusSuccess
+	}
+	if opts.nameID == "" {
+		opts.nameID = "ada@example.com"
+	}
+	if opts.notOnOrAfter.IsZero() {
+		opts.notOnOrAfter = testNow.Add(5 * time.Minute)
+	}
+	expires := opts.notOnOrAfter.Format(time.RFC3339)
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="_resp1" Version="2.0" IssueInstant="2026-03-01T12:00:00Z" Destination="%[3]s">
+  <saml:Issuer xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion">%[1]s</saml:Issuer><!--RSIG-->
+  <samlp:Status><samlp:StatusCode Value="%[4]s"/></samlp:Status>
+  <saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" xmlns:xs="http://www.w3.org/2001/XMLSchema" ID="_assert1" Version="2.0" IssueInstant="2026-03-01T12:00:00Z">
+    <saml:Issuer>%[1]s</saml:Issuer><!--SIG-->
+    <saml:Subject>
+      <saml:NameID Format="urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress">%[5]s</saml:NameID>
+      <saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">
+        <saml:SubjectConfirmationData NotOnOrAfter="%[6]s" Recipient="%[3]s"/>
+      </saml:SubjectConfirmation>
+    </saml:Subject>
+    <saml:Conditions NotBefore="2026-03-01T11:59:00Z" NotOnOrAfter="%[6]s">
+      <saml:AudienceRestriction><saml:Audience>%[2]s</saml:Audience></saml:AudienceRestriction>
+    </saml:Conditions>
+    <saml:AttributeStatement>
+      <saml:Attribute Name="groups">
+        <saml:AttributeValue xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="xs:string">Marketing</saml:AttributeValue>
+        <saml:AttributeValue>Everyone</saml:AttributeValue>
+      </saml:Attribute>
+    </saml:AttributeStatement>
+  </saml:Assertion>
+</samlp:Response>`, opts.issuer, opts.audience, opts.recipient, opts.status, opts.nameID, expires)
+}
+
+// sign puts an enveloped signature on the element with this ID, in place
+// of the marker
+func (idp *testIdP) sign(t *testing.T, document, id, marker string) string {
+	t.Helper()
+	root, err := parseXML([]byte(document))
+	require.NoError(t, err)
+	var target *element
+	root.walk(func(el *element) {
+		if el.attr("ID") == id {
+			target = el
+		}
+	})
+	require.NotNil(t, target)
+
+	// The marker is a comment, so it isn't part of the digest either
+	signed, err := canonicalize(target, nil, []string{"xs"})
+	require.NoError(t, err)
+	digest := crypto.SHA256.New()
+	digest.Write(signed)
+
+	signatureMethod := algRSASHA256
+	if _, ok := idp.key.(*ecdsa.PrivateKey); ok {
+		signatureMethod = algECDSASHA256
+	}
+	signedInfo := `<ds:SignedInfo>` +
+		`<ds:CanonicalizationMethod Algorithm="` + algExcC14N + `"/>` +
+		`<ds:SignatureMethod Algorithm="` + signatureMethod + `"/>` +
+		`<ds:Reference URI="#` + id + `"><ds:Transforms>` +
+		`<ds:Transform Algorithm="` + algEnveloped + `"/>` +
+		`<ds:Transform Algorithm="` + algExcC14N + `"><ec:InclusiveNamespaces xmlns:ec="` + algExcC14N + `" PrefixList="xs"/></ds:Transform>` +
+		`</ds:Transforms>` +
+		`<ds:DigestMethod Algorithm="` + algSHA256 + `"/>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(digest.Sum(nil)) + `</ds:DigestValue>` +
+		`</ds:Reference></ds:SignedInfo>`
+
+	// Canonicalize SignedInfo inside its Signature, as the verifier will
+	signatureDoc, err := parseXML([]byte(`<ds:Signature xmlns:ds="` + namespaceDSig + `">` + signedInfo + `</ds:Signature>`))
+	require.NoError(t, err)
+	canonicalSignedInfo, err := canonicalize(findChild(t, signatureDoc, "SignedInfo"), nil, nil)
+	require.NoError(t, err)
+	hashed := crypto.SHA256.New()
+	hashed.Write(canonicalSignedInfo)
+
+	var sig []byte
+	switch key := idp.key.(type) {
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed.Sum(nil))
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, hashed.Sum(nil))
+		require.NoError(t, err)
+		size := (key.Curve.Params().BitSize + 7) / 8
+		sig = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	}
+
+	signature := `<ds:Signature xmlns:ds="` + namespaceDSig + `">` + signedInfo +
+		`<ds:SignatureValue>` + base64.StdEncoding.EncodeToString(sig) + `</ds:SignatureValue>` +
+		`<ds:KeyInfo><ds:X509Data><ds:X509Certificate>` + base64.StdEncoding.EncodeToString(idp.cert.Raw) + `</ds:X509Certificate></ds:X509Data></ds:KeyInfo>` +
+		`</ds:Signature>`
+	return strings.Replace(document, marker, signature, 1)
+}
+
+func encode(document string) string {
+	return base64.StdEncoding.EncodeToString([]byte(document))
+}
+
+func TestParseResponse(t *testing.T) {
+	idp := newRSAIdP(t)
+	otherIdP := newRSAIdP(t)
+	signedAssertion := func(opts responseOptions) string {
+		return idp.sign(t, buildResponse(opts), "_assert1", "<!--SIG-->")
+	}
+
+	tests := []struct {
+		name      string
+		response  string // XML, base64-encoded before parsing unless raw
+		raw       bool
+		now       time.Time
+		expectErr error
+	}{
+		{name: "signed assertion", response: signedAssertion(responseOptions{})},
+		{name: "signed response", response: idp.sign(t, buildResponse(responseOptions{}), "_resp1", "<!--RSIG-->")},
+		{name: "within clock skew", response: signedAssertion(responseOptions{}), now: testNow.Add(6 * time.Minute)},
+		{name: "unsigned", response: buildResponse(responseOptions{}), expectErr: ErrInvalidSignature},
+		{name: "signed by another key", response: otherIdP.sign(t, buildResponse(responseOptions{}), "_assert1", "<!--SIG-->"), expectErr: ErrInvalidSignature},
+		{
+			name:      "tampered after signing",
+			response:  strings.Replace(signedAssertion(responseOptions{}), "ada@example.com", "eve@example.com", 1),
+			expectErr: ErrInvalidSignature,
+		},
+		{
+			name:      "attribute added after signing",
+			response:  strings.Replace(signedAssertion(responseOptions{}), ">Everyone<", ">Everyone</saml:AttributeValue><saml:AttributeValue>Admins<", 1),
+			expectErr: ErrInvalidSignature,
+		},
+		{
+			name: "second assertion smuggled in",
+			response: strings.Replace(signedAssertion(responseOptions{}), "<samlp:Status>",
+				`<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_evil"/><samlp:Status>`, 1),
+			expectErr: ErrMalformed,
+		},
+		{
+			name: "duplicate ID",
+			response: strings.Replace(signedAssertion(responseOptions{}), "<samlp:Status>",
+				`<samlp:Extensions ID="_assert1"/><samlp:Status>`, 1),
+			expectErr: ErrMalformed,
+		},
+		{name: "expired", response: signedAssertion(responseOptions{}), now: testNow.Add(10 * time.Minute), expectErr: ErrInvalidAssertion},
+		{name: "wrong audience", response: signedAssertion(responseOptions{audience: "https://other.example"}), expectErr: ErrInvalidAssertion},
+		{name: "wrong recipient", response: signedAssertion(responseOptions{recipient: "https://other.example/acs"}), expectErr: ErrInvalidAssertion},
+		{name: "wrong issuer", response: signedAssertion(responseOptions{issuer: "https://evil.example"}), expectErr: ErrInvalidAssertion},
+		{name: "IdP refused", response: signedAssertion(responseOptions{status: "urn:oasis:names:tc:SAML:2.0:status:Responder"}), expectErr: ErrInvalidAssertion},
+		{name: "not base64", response: "%%%", raw: true, expectErr: ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			now := testNow
+			if !tt.now.IsZero() {
+				now = tt.now
+			}
+			response := tt.response
+			if !tt.raw {
+				response = encode(response)
+			}
+
+			// Act
+			assertion, err := ParseResponse(response, testSP, idp.provider(), now)
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				assert.Nil(t, assertion)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "_assert1", assertion.ID)
+			assert.Equal(t, "ada@example.com", assertion.NameID)
+			assert.Equal(t, []string{"Marketing", "Everyone"}, assertion.Attributes["groups"])
+			assert.Equal(t, testNow.Add(5*time.Minute), assertion.NotOnOrAfter)
+		})
+	}
+}
+
+func TestParseResponse_ECDSA(t *testing.T) {
+	// Arrange
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	idp := newTestIdP(t, key)
+	response := idp.sign(t, buildResponse(responseOptions{}), "_assert1", "<!--SIG-->")
+
+	// Act
+	assertion, err := ParseResponse(encode(response), testSP, idp.provider(), testNow)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com", assertion.NameID)
+}
+
+func TestParseResponse_CommentInNameID(t *testing.T) {
+	// Arrange: comments aren't signed, so one can be added after signing -
+	// it must not cut the NameID short
+	idp := newRSAIdP(t)
+	response := idp.sign(t, buildResponse(responseOptions{nameID: "ada@example.com.evil.example"}), "_assert1", "<!--SIG-->")
+	response = strings.Replace(response, "ada@example.com.evil", "ada@example.com<!---->.evil", 1)
+
+	// Act
+	assertion, err := ParseResponse(encode(response), testSP, idp.provider(), testNow)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com.evil.example", assertion.NameID)
+}
+
+func TestParseCertificate(t *testing.T) {
+	idp := newRSAIdP(t)
+	pemCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: idp.cert.Raw}))
+	bare := base64.StdEncoding.EncodeToString(idp.cert.Raw)
+
+	tests := []struct {
+		name      string
+		input     string
+		expectErr bool
+	}{
+		{name: "PEM", input: pemCert},
+		{name: "bare base64 from metadata", input: "\n  " + bare[:40] + "\n  " + bare[40:] + "\n"},
+		{name: "garbage", input: "not a certificate", expectErr: true},
+		{name: "base64 of something else", input: base64.StdEncoding.EncodeToString([]byte("hello")), expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			cert, err := ParseCertificate(tt.input)
+
+			// Assert
+			if tt.expectErr {
+				assert.ErrorIs(t, err, ErrInvalidCertificate)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, idp.cert.Raw, cert.Raw)
+		})
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	// Act
+	metadata := Metadata(testSP)
+
+	// Assert: it parses, and has what the IdP reads
+	root, err := parseXML(metadata)
+	require.NoError(t, err)
+	assert.True(t, root.is(namespaceMetadata, "EntityDescriptor"))
+	assert.Equal(t, testSP.EntityID, root.attr("entityID"))
+	descriptor := root.child(namespaceMetadata, "SPSSODescriptor")
+	require.NotNil(t, descriptor)
+	assert.Equal(t, "true", descriptor.attr("WantAssertionsSigned"))
+	acs := descriptor.child(namespaceMetadata, "AssertionConsumerService")
+	require.NotNil(t, acs)
+	assert.Equal(t, testSP.ACSURL, acs.attr("Location"))
+	assert.Equal(t, bindingHTTPPost, acs.attr("Binding"))
+}
+
+func TestAuthnRequestURL(t *testing.T) {
+	// Act
+	target, err := AuthnRequestURL(testSP, "https://idp.example.com/sso?app=42", "/dashboard", testNow)
+
+	// Assert
+	require.NoError(t, err)
+	parsed, err := url.Parse(target)
+	require.NoError(t, err)
+	assert.Equal(t, "idp.example.com", parsed.Host)
+	assert.Equal(t, "42", parsed.Query().Get("app"), "the IdP's own parameters are kept")
+	assert.Equal(t, "/dashboard", parsed.Query().Get("RelayState"))
+
+	deflated, err := base64.StdEncoding.DecodeString(parsed.Query().Get("SAMLRequest"))
+	require.NoError(t, err)
+	request, err := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	require.NoError(t, err)
+	root, err := parseXML(request)
+	require.NoError(t, err)
+	assert.True(t, root.is(namespaceProtocol, "AuthnRequest"))
+	assert.Equal(t, testSP.ACSURL, root.attr("AssertionConsumerServiceURL"))
+	assert.Equal(t, testSP.EntityID, root.child(namespaceAssertion, "Issuer").text())
+}
@@ -0,0 +1,395 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// A TINY XML TREE
+// Signatures are computed over the exact bytes of a canonical form of the
+// document, so we need what encoding/xml's Unmarshal throws away: which
+// prefix each name used and where each namespace was declared. RawToken
+// gives us both; we build a small tree from it and canonicalize that.
+
+// xmlNamespace is bound to the "xml" prefix without a declaration
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// element is one XML element with its raw (unresolved) names
+type element struct {
+	prefix   string
+	local    string
+	attrs    []attribute // Ordinary attributes, in document order
+	nsDecls  []attribute // xmlns and xmlns:p declarations (local = prefix, "" for the default)
+	children []any       // *element, string (text) or xml.ProcInst
+	parent   *element
+}
+
+// attribute is an attribute or namespace declaration
+type attribute struct {
+	prefix string
+	local  string
+	value  string
+}
+
+// parseXML reads a document into a tree
+// DTDs are refused: they are how entity expansion and external entity
+// attacks get in, and SAML never needs one
+func parseXML(data []byte) (*element, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	var root, current *element
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if current == nil && root != nil {
+				return nil, fmt.Errorf("%w: more than one root element", ErrMalformed)
+			}
+			el := &element{prefix: t.Name.Space, local: t.Name.Local, parent: current}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					el.nsDecls = append(el.nsDecls, attribute{local: "", value: a.Value})
+				case a.Name.Space == "xmlns":
+					el.nsDecls = append(el.nsDecls, attribute{local: a.Name.Local, value: a.Value})
+				default:
+					el.attrs = append(el.attrs, attribute{prefix: a.Name.Space, local: a.Name.Local, value: a.Value})
+				}
+			}
+			if current == nil {
+				root = el
+			} else {
+				current.children = append(current.children, el)
+			}
+			current = el
+		case xml.EndElement:
+			// RawToken doesn't match end tags to start tags: we do
+			if current == nil || t.Name.Space != current.prefix || t.Name.Local != current.local {
+				return nil, fmt.Errorf("%w: unexpected end tag", ErrMalformed)
+			}
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.children = append(current.children, string(t))
+			} else if len(bytes.TrimSpace(t)) > 0 {
+				return nil, fmt.Errorf("%w: text outside the root element", ErrMalformed)
+			}
+		case xml.ProcInst:
+			if current != nil {
+				current.children = append(current.children, t.Copy())
+			}
+		case xml.Directive:
+			return nil, fmt.Errorf("%w: DTDs are not allowed", ErrMalformed)
+		case xml.Comment:
+			// Comments are not part of the canonical form we verify, and
+			// text() skips them: "alice@example.com<!---->.evil" is one value
+		}
+	}
+
+	if root == nil || current != nil {
+		return nil, fmt.Errorf("%w: incomplete document", ErrMalformed)
+	}
+	return root, nil
+}
+
+// lookupNamespace resolves a prefix ("" = default namespace) in el's scope
+func (el *element) lookupNamespace(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return xmlNamespace, true
+	}
+	for e := el; e != nil; e = e.parent {
+		for _, decl := range e.nsDecls {
+			if decl.local == prefix {
+				return decl.value, true
+			}
+		}
+	}
+	// No default namespace declared means "no namespace"
+	return "", prefix == ""
+}
+
+// namespace is the namespace of the element itself
+func (el *element) namespace() string {
+	ns, _ := el.lookupNamespace(el.prefix)
+	return ns
+}
+
+// is reports whether the element has this namespace and local name
+func (el *element) is(namespace, local string) bool {
+	return el.local == local && el.namespace() == namespace
+}
+
+// attr returns an unqualified attribute ("" if absent)
+func (el *element) attr(local string) string {
+	for _, a := range el.attrs {
+		if a.prefix == "" && a.local == local {
+			return a.value
+		}
+	}
+	return ""
+}
+
+// hasAttr reports whether an unqualified attribute is present
+func (el *element) hasAttr(local string) bool {
+	for _, a := range el.attrs {
+		if a.prefix == "" && a.local == local {
+			return true
+		}
+	}
+	return false
+}
+
+// childElements returns the children with this namespace and local name
+func (el *element) childElements(namespace, local string) []*element {
+	var matches []*element
+	for _, child := range el.children {
+		if c, ok := child.(*element); ok && c.is(namespace, local) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+// child returns the first child with this namespace and local name, or nil
+func (el *element) child(namespace, local string) *element {
+	if matches := el.childElements(namespace, local); len(matches) > 0 {
+		return matches[0]
+	}
+	return nil
+}
+
+// text returns the element's own text (not its descendants'), trimmed
+func (el *element) text() string {
+	var b strings.Builder
+	for _, child := range el.children {
+		if s, ok := child.(string); ok {
+			b.WriteString(s)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// walk calls fn for el and every element below it
+func (el *element) walk(fn func(*element)) {
+	fn(el)
+	for _, child := range el.children {
+		if c, ok := child.(*element); ok {
+			c.walk(fn)
+		}
+	}
+}
+
+// ==================== EXCLUSIVE CANONICALIZATION ====================
+
+// canonicalize writes the Exclusive XML Canonicalization 1.0 (without
+// comments) of the subtree rooted at el, leaving out the skip element
+// (the enveloped signature)
+//
+// WHY EXCLUSIVE?
+// Canonical XML makes "the same document" byte-identical however it was
+// formatted: attributes sorted, empty tags expanded, one way to escape. The
+// exclusive variant also ignores namespaces declared OUTSIDE the signed
+// element that it doesn't use, so an assertion keeps its signature when it
+// is moved into a different Response.
+//
+// inclusivePrefixes is the InclusiveNamespaces PrefixList: prefixes
+// ("#default" for the default namespace) rendered like inclusive C14N does,
+// whether used or not.
+func canonicalize(el *element, skip *element, inclusivePrefixes []string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, el, skip, map[string]string{}, inclusivePrefixes); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeCanonical writes one element; rendered holds the namespace
+// declarations already in effect in the OUTPUT (prefix -> URI)
+func writeCanonical(buf *bytes.Buffer, el *element, skip *element, rendered map[string]string, inclusivePrefixes []string) error {
+	// Namespaces "visibly utilized" by the element: its own prefix and
+	// those of its attributes, plus the inclusive ones
+	prefixes := map[string]bool{el.prefix: true}
+	for _, a := range el.attrs {
+		if a.prefix != "" {
+			prefixes[a.prefix] = true
+		}
+	}
+	for _, p := range inclusivePrefixes {
+		if p == "#default" {
+			p = ""
+		}
+		if _, inScope := el.lookupNamespace(p); inScope {
+			prefixes[p] = true
+		}
+	}
+	delete(prefixes, "xml") // Bound by definition, never declared
+
+	var decls []attribute
+	childRendered := rendered
+	for prefix := range prefixes {
+		uri, ok := el.lookupNamespace(prefix)
+		if !ok {
+			return fmt.Errorf("%w: undeclared prefix %q", ErrMalformed, prefix)
+		}
+		current, seen := rendered[prefix]
+		if prefix == "" && !seen {
+			current = "" // The output starts without a default namespace
+		}
+		if current == uri {
+			continue
+		}
+		if len(decls) == 0 {
+			childRendered = make(map[string]string, len(rendered)+1)
+			for k, v := range rendered {
+				childRendered[k] = v
+			}
+		}
+		childRendered[prefix] = uri
+		decls = append(decls, attribute{local: prefix, value: uri})
+	}
+	sort.Slice(decls, func(i, j int) bool { return decls[i].local < decls[j].local })
+
+	// Attributes are sorted by namespace URI, then local name
+	type resolved struct {
+		attribute
+		uri string
+	}
+	attrs := make([]resolved, 0, len(el.attrs))
+	for _, a := range el.attrs {
+		uri := ""
+		if a.prefix != "" {
+			uri, _ = el.lookupNamespace(a.prefix)
+		}
+		attrs = append(attrs, resolved{attribute: a, uri: uri})
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].uri != attrs[j].uri {
+			return attrs[i].uri < attrs[j].uri
+		}
+		return attrs[i].local < attrs[j].local
+	})
+
+	name := qualifiedName(el.prefix, el.local)
+	buf.WriteByte('<')
+	buf.WriteString(name)
+	for _, decl := range decls {
+		if decl.local == "" {
+			buf.WriteString(` xmlns="`)
+		} else {
+			buf.WriteString(` xmlns:` + decl.local + `="`)
+		}
+		escapeAttr(buf, decl.value)
+		buf.WriteByte('"')
+	}
+	for _, a := range attrs {
+		buf.WriteString(" " + qualifiedName(a.prefix, a.local) + `="`)
+		escapeAttr(buf, a.value)
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('>')
+
+	for _, child := range el.children {
+		switch c := child.(type) {
+		case *element:
+			if c == skip {
+				continue
+			}
+			if err := writeCanonical(buf, c, skip, childRendered, inclusivePrefixes); err != nil {
+				return err
+			}
+		case string:
+			escapeText(buf, c)
+		case xml.ProcInst:
+			buf.WriteString("<?" + c.Target)
+			if len(c.Inst) > 0 {
+				buf.WriteByte(' ')
+				buf.Write(c.Inst)
+			}
+			buf.WriteString("?>")
+		}
+	}
+
+	buf.WriteString("</" + name + ">")
+	return nil
+}
+
+func qualifiedName(prefix, local string) string {
+	if prefix == "" {
+		return local
+	}
+	return prefix + ":" + local
+}
+
+// escapeText escapes character data the canonical way
+func escapeText(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '>':
+			buf.WriteString("&gt;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// escapeAttr escapes an attribute value the canonical way
+func escapeAttr(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '"':
+			buf.WriteString("&quot;")
+		case '\t':
+			buf.WriteString("&#x9;")
+		case '\n':
+			buf.WriteString("&#xA;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// errDuplicateID is returned when two elements share an ID
+var errDuplicateID = errors.New("duplicate ID")
+
+// checkUniqueIDs refuses documents where two elements share an ID attribute
+// Signature wrapping attacks rely on a second element with the signed ID
+func checkUniqueIDs(root *element) error {
+	seen := map[string]bool{}
+	var err error
+	root.walk(func(el *element) {
+		if !el.hasAttr("ID") {
+			return
+		}
+		id := el.attr("ID")
+		if seen[id] {
+			err = fmt.Errorf("%w: %w %q", ErrMalformed, errDuplicateID, id)
+		}
+		seen[id] = true
+	})
+	return err
+}
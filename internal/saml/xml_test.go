@@ -0,0 +1,128 @@
+package saml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		document  string
+		path      []string // Local names from the root down to the element to canonicalize
+		inclusive []string
+		expected  string
+	}{
+		{
+			// The example of the Exclusive XML Canonicalization spec, section 2.2
+			name: "unused ancestor namespaces are dropped",
+			document: `<n0:local xmlns:n0="foo:bar" xmlns:n3="ftp://example.org">
+  <n1:elem2 xmlns:n1="http://example.net" xml:lang="en">
+    <n3:stuff xmlns:n3="ftp://example.org"/>
+  </n1:elem2>
+</n0:local>`,
+			path: []string{"elem2"},
+			expected: `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en">
+    <n3:stuff xmlns:n3="ftp://example.org"></n3:stuff>
+  </n1:elem2>`,
+		},
+		{
+			name:     "attributes sorted by namespace then name",
+			document: `<a xmlns:z="urn:z" xmlns:b="urn:b" z:x="1" b:y="2" c="3" a="4"/>`,
+			expected: `<a xmlns:b="urn:b" xmlns:z="urn:z" a="4" c="3" b:y="2" z:x="1"></a>`,
+		},
+		{
+			name:     "escaping",
+			document: `<a v="&quot;&lt;&amp;&gt;&#9;&#10;">x &lt; y &amp;&amp; y &gt; z "quoted"</a>`,
+			expected: `<a v="&quot;&lt;&amp;>&#x9;&#xA;">x &lt; y &amp;&amp; y &gt; z "quoted"</a>`,
+		},
+		{
+			name:     "comments and CDATA",
+			document: `<a><!-- gone --><![CDATA[<b>]]></a>`,
+			expected: `<a>&lt;b&gt;</a>`,
+		},
+		{
+			name:     "default namespace undeclared below a declared one",
+			document: `<a xmlns="urn:a"><b xmlns=""><c/></b></a>`,
+			expected: `<a xmlns="urn:a"><b xmlns=""><c></c></b></a>`,
+		},
+		{
+			name:     "redundant declarations are not repeated",
+			document: `<p:a xmlns:p="urn:p"><p:b xmlns:p="urn:p"/></p:a>`,
+			expected: `<p:a xmlns:p="urn:p"><p:b></p:b></p:a>`,
+		},
+		{
+			name:      "inclusive prefixes are kept",
+			document:  `<root xmlns:xs="urn:xs" xmlns:unused="urn:u"><a/></root>`,
+			path:      []string{"a"},
+			inclusive: []string{"xs"},
+			expected:  `<a xmlns:xs="urn:xs"></a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			root, err := parseXML([]byte(tt.document))
+			require.NoError(t, err)
+			el := root
+			for _, local := range tt.path {
+				el = findChild(t, el, local)
+			}
+
+			// Act
+			canonical, err := canonicalize(el, nil, tt.inclusive)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(canonical))
+		})
+	}
+}
+
+func TestParseXML_Rejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+	}{
+		{name: "DTD", document: `<!DOCTYPE a [<!ENTITY x "boom">]><a>&x;</a>`},
+		{name: "mismatched end tag", document: `<a><b></a></b>`},
+		{name: "two roots", document: `<a/><b/>`},
+		{name: "unclosed", document: `<a><b/>`},
+		{name: "empty", document: ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := parseXML([]byte(tt.document))
+
+			// Assert
+			assert.ErrorIs(t, err, ErrMalformed)
+		})
+	}
+}
+
+func TestElementText_IgnoresComments(t *testing.T) {
+	// Arrange: the comment splits the text node; a parser that kept only the
+	// first node would read "alice@example.com"
+	root, err := parseXML([]byte(`<NameID>alice@example.com<!---->.evil.example</NameID>`))
+	require.NoError(t, err)
+
+	// Act & Assert
+	assert.Equal(t, "alice@example.com.evil.example", root.text())
+}
+
+// findChild returns the first child element with this local name
+func findChild(t *testing.T, el *element, local string) *element {
+	t.Helper()
+	for _, child := range el.children {
+		if c, ok := child.(*element); ok && c.local == local {
+			return c
+		}
+	}
+	t.Fatalf("no <%s> in <%s>", local, el.local)
+	return nil
+}
@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSpec is returned for cron specs that don't parse
+var ErrInvalidSpec = errors.New("invalid cron spec")
+
+// Schedule says when a task is due, in UTC
+//
+// CRON SPECS:
+// Five fields, "minute hour day-of-month month day-of-week":
+//
+//	"*/15 * * * *"  every 15 minutes
+//	"5 * * * *"     every hour at :05
+//	"0 8 * * 1"     Mondays at 08:00
+//	"0 3 1 * *"     the first of every month at 03:00
+//
+// Each field takes *, a value, a range (1-5), a step (*/15, 0-30/10) or a
+// comma-separated list of those. Day-of-week counts from Sunday = 0 (7 is
+// Sunday too). Like classic cron, when both day fields are restricted a day
+// matches if EITHER does. The shortcuts @hourly, @daily, @weekly and
+// @monthly are accepted as well.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // Bit n set = value n matches
+	domAny, dowAny                bool   // The field was *
+}
+
+var shortcuts = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Parse reads a cron spec (see Schedule)
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if expanded, ok := shortcuts[spec]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("%w %q: want 5 fields, got %d", ErrInvalidSpec, spec, len(fields))
+	}
+
+	var s Schedule
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return Schedule{}, fmt.Errorf("%w %q: minute: %v", ErrInvalidSpec, spec, err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return Schedule{}, fmt.Errorf("%w %q: hour: %v", ErrInvalidSpec, spec, err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return Schedule{}, fmt.Errorf("%w %q: day of month: %v", ErrInvalidSpec, spec, err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12); err != nil {
+		return Schedule{}, fmt.Errorf("%w %q: month: %v", ErrInvalidSpec, spec, err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return Schedule{}, fmt.Errorf("%w %q: day of week: %v", ErrInvalidSpec, spec, err)
+	}
+
+	// 7 is another name for Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+
+	// "0 0 30 2 *" parses, but February 30th never comes
+	if s.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return Schedule{}, fmt.Errorf("%w %q: never due", ErrInvalidSpec, spec)
+	}
+
+	return s, nil
+}
+
+// parseField turns one field into a bit set of the values it matches
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangePart = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+		}
+
+		low, high := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			low, err1 = strconv.Atoi(bounds[0])
+			high, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil || low > high {
+				return 0, fmt.Errorf("bad range %q", rangePart)
+			}
+		default:
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", rangePart)
+			}
+			low, high = value, value
+			// "5/10" means from 5 on, every 10
+			if strings.Contains(part, "/") {
+				high = max
+			}
+		}
+
+		if low < min || high > max {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first due time strictly after t
+// Cron has minute precision: seconds are dropped
+func (s Schedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+
+	// Every spec matches at least once every few years (Feb 29 included);
+	// the limit only guards against a spec that can never match (Feb 30)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches applies cron's day-of-month / day-of-week rule
+func (s Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// Sunday 2026-03-01 10:30:20 UTC
+	from := time.Date(2026, 3, 1, 10, 30, 20, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{spec: "* * * * *", want: time.Date(2026, 3, 1, 10, 31, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", want: time.Date(2026, 3, 1, 10, 45, 0, 0, time.UTC)},
+		{spec: "5 * * * *", want: time.Date(2026, 3, 1, 11, 5, 0, 0, time.UTC)},
+		{spec: "30 10 * * *", want: time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)},
+		{spec: "0 8 * * 1", want: time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)},
+		{spec: "0 8 * * 1-5", want: time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", want: time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 3 1 * *", want: time.Date(2026, 4, 1, 3, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "10,40 9-11 * * *", want: time.Date(2026, 3, 1, 10, 40, 0, 0, time.UTC)},
+		{spec: "5/20 * * * *", want: time.Date(2026, 3, 1, 10, 45, 0, 0, time.UTC)},
+		{spec: "0-30/10 12 * * *", want: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: the 15th OR a Monday, whichever comes first
+		{spec: "0 0 15 * 1", want: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{spec: "@hourly", want: time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC)},
+		{spec: "@daily", want: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{spec: "@weekly", want: time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{spec: "@monthly", want: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			// Arrange
+			schedule, err := Parse(tt.spec)
+			require.NoError(t, err)
+
+			// Act
+			got := schedule.Next(from)
+
+			// Assert
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSchedule_NextIsStrictlyAfter(t *testing.T) {
+	schedule, err := Parse("0 * * * *")
+	require.NoError(t, err)
+
+	onTheHour := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, onTheHour.Add(time.Hour), schedule.Next(onTheHour))
+}
+
+func TestSchedule_NextUsesUTC(t *testing.T) {
+	schedule, err := Parse("0 8 * * *")
+	require.NoError(t, err)
+
+	berlin := time.FixedZone("CET", 3600)
+	from := time.Date(2026, 3, 1, 8, 30, 0, 0, berlin) // 07:30 UTC
+
+	assert.Equal(t, time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC), schedule.Next(from))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"a * * * *",
+		"0 0 30 2 *",
+		"@yearly",
+	} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := Parse(spec)
+			assert.ErrorIs(t, err, ErrInvalidSpec)
+		})
+	}
+}
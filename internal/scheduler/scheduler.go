@@ -0,0 +1,192 @@
+// Package scheduler runs recurring maintenance tasks on a cron schedule
+//
+// WHY A SCHEDULER?
+// Tickers ("every hour from whenever the server started") drift with every
+// deploy, and every replica runs them. Maintenance work - cleaning up old
+// rows, sending the weekly digest - should happen at a known time, once.
+//
+// ONE REPLICA PER TASK:
+// Before each run the scheduler asks its Locker whether this instance may
+// run the task. The PostgreSQL locker takes an advisory lock per task and
+// keeps it: the replica that got it first runs the task from then on, the
+// others skip it. When that replica stops (or loses its connection), the
+// lock is released and another replica takes over at the next due time.
+//
+// JITTER:
+// "0 * * * *" fires at the same second everywhere. Each run waits a random
+// extra delay (up to the task's jitter) so tasks that share a due time don't
+// hit the database all at once.
+//
+// OVERLAP:
+// A task never overlaps with itself: the next due time is only computed
+// after the run has finished. Due times that passed while a slow run was
+// still going are skipped (and counted as "missed"), not queued up.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"sync"
+	"time"
+
+	"url-shortener/internal/errreport"
+	"url-shortener/internal/leader"
+	"url-shortener/internal/metrics"
+)
+
+// Task is one run of a scheduled task
+type Task func(ctx context.Context) error
+
+// Locker decides which replica runs a task: the one holding the lock named
+// after it. The same primitive elects the leader (see package leader)
+type Locker = leader.Locker
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{0,63}$`)
+
+// entry is a registered task
+type entry struct {
+	name     string
+	schedule Schedule
+	jitter   time.Duration
+	task     Task
+}
+
+// Scheduler runs registered tasks when they are due
+type Scheduler struct {
+	locker   Locker
+	entries  []*entry
+	reporter errreport.Reporter
+
+	now    func() time.Time
+	after  func(d time.Duration) <-chan time.Time
+	jitter func(max time.Duration) time.Duration
+}
+
+// New creates a scheduler
+// locker may be nil: every task then runs on this instance
+func New(locker Locker) *Scheduler {
+	return &Scheduler{
+		locker:   locker,
+		reporter: errreport.Nop{},
+		now:      time.Now,
+		after:    time.After,
+		jitter:   randomJitter,
+	}
+}
+
+// WithErrorReporter sends panicking tasks to an error tracker
+func (s *Scheduler) WithErrorReporter(reporter errreport.Reporter) *Scheduler {
+	s.reporter = reporter
+	return s
+}
+
+// Register adds a task that runs whenever spec is due (see Schedule)
+// Panics on an invalid name or spec, or a name used twice: registration
+// happens at startup, where a typo should stop the server
+func (s *Scheduler) Register(name, spec string, jitter time.Duration, task Task) *Scheduler {
+	if !validName.MatchString(name) {
+		panic(fmt.Sprintf("scheduler: task name must be 1-64 lowercase letters, digits, dots or dashes: %q", name))
+	}
+	for _, e := range s.entries {
+		if e.name == name {
+			panic(fmt.Sprintf("scheduler: task %q registered twice", name))
+		}
+	}
+	schedule, err := Parse(spec)
+	if err != nil {
+		panic(fmt.Sprintf("scheduler: task %q: %v", name, err))
+	}
+
+	s.entries = append(s.entries, &entry{name: name, schedule: schedule, jitter: jitter, task: task})
+	return s
+}
+
+// Run runs the registered tasks until ctx is canceled, then releases the
+// locks this instance holds
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, e := range s.entries {
+		wg.Add(1)
+		go func(e *entry) {
+			defer wg.Done()
+			s.loop(ctx, e)
+		}(e)
+	}
+	wg.Wait()
+
+	if s.locker != nil {
+		if err := s.locker.Close(); err != nil {
+			fmt.Printf("Warning: failed to release scheduler locks: %v\n", err)
+		}
+	}
+}
+
+// loop waits for each due time of one task and runs it
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	next := e.schedule.Next(s.now())
+	for {
+		wait := next.Sub(s.now()) + s.jitter(e.jitter)
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.after(wait):
+		}
+
+		if err := s.runOnce(ctx, e); err != nil && ctx.Err() == nil {
+			fmt.Printf("Warning: scheduled task %s failed: %v\n", e.name, err)
+		}
+
+		// Only now look for the next due time: a slow run delays the task,
+		// it never runs twice at once
+		now := s.now()
+		next = e.schedule.Next(next)
+		for !next.After(now) {
+			metrics.RecordScheduledRun(e.name, "missed", 0)
+			next = e.schedule.Next(next)
+		}
+	}
+}
+
+// runOnce runs the task if this instance holds its lock
+func (s *Scheduler) runOnce(ctx context.Context, e *entry) (err error) {
+	if s.locker != nil {
+		locked, err := s.locker.TryLock(ctx, e.name)
+		if err != nil {
+			metrics.RecordScheduledRun(e.name, "error", 0)
+			return fmt.Errorf("failed to take the lock: %w", err)
+		}
+		if !locked {
+			metrics.RecordScheduledRun(e.name, "skipped", 0)
+			return nil
+		}
+	}
+
+	start := time.Now()
+	defer func() {
+		// A panicking task fails this run, not the scheduler
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			event := errreport.Panic(r)
+			event.Tags["component"] = "scheduler"
+			event.Tags["task"] = e.name
+			s.reporter.Report(event)
+		}
+		result := "ok"
+		if err != nil {
+			result = "error"
+		}
+		metrics.RecordScheduledRun(e.name, result, time.Since(start))
+	}()
+
+	return e.task(ctx)
+}
+
+// randomJitter returns a random delay in [0, max)
+func randomJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(max)))
+}
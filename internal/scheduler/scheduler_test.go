@@ -0,0 +1,257 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"url-shortener/internal/errreport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock moves forward by exactly the waited time, so loops run instantly
+// Once ctx is canceled it stops firing, like a timer that never comes
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	ctx   context.Context
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	if c.ctx.Err() != nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.waits = append(c.waits, d)
+	fired := make(chan time.Time, 1)
+	fired <- c.now
+	return fired
+}
+
+// fakeLocker grants the locks in granted
+type fakeLocker struct {
+	mu      sync.Mutex
+	granted map[string]bool
+	err     error
+	closed  bool
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context, name string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.granted[name], l.err
+}
+
+func (l *fakeLocker) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	return nil
+}
+
+// newTestScheduler creates a scheduler on a fake clock with a fixed 5s jitter
+func newTestScheduler(ctx context.Context, locker Locker, start time.Time) (*Scheduler, *fakeClock) {
+	clock := &fakeClock{now: start, ctx: ctx}
+	s := New(locker)
+	s.now = clock.Now
+	s.after = clock.After
+	s.jitter = func(max time.Duration) time.Duration { return min(max, 5*time.Second) }
+	return s, clock
+}
+
+func TestScheduler_RunsTaskWhenDue(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	locker := &fakeLocker{granted: map[string]bool{"janitor": true}}
+	s, clock := newTestScheduler(ctx, locker, time.Date(2026, 3, 1, 10, 0, 30, 0, time.UTC))
+
+	var ranAt []time.Time
+	s.Register("janitor", "0 * * * *", time.Minute, func(ctx context.Context) error {
+		ranAt = append(ranAt, clock.Now())
+		if len(ranAt) == 3 {
+			cancel()
+		}
+		return nil
+	})
+
+	// Act
+	s.Run(ctx)
+
+	// Assert - on the hour, plus the jitter
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 3, 1, 11, 0, 5, 0, time.UTC),
+		time.Date(2026, 3, 1, 12, 0, 5, 0, time.UTC),
+		time.Date(2026, 3, 1, 13, 0, 5, 0, time.UTC),
+	}, ranAt)
+	assert.True(t, locker.closed, "locks are released when the scheduler stops")
+}
+
+func TestScheduler_SlowRunSkipsMissedTimes(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, clock := newTestScheduler(ctx, nil, time.Date(2026, 3, 1, 10, 0, 30, 0, time.UTC))
+
+	runs := 0
+	s.Register("rollup", "0 * * * *", time.Minute, func(ctx context.Context) error {
+		runs++
+		if runs == 1 {
+			clock.Advance(2*time.Hour + 30*time.Minute) // Runs past the 12:00 and 13:00 due times
+		} else {
+			cancel()
+		}
+		return nil
+	})
+
+	// Act
+	s.Run(ctx)
+
+	// Assert - 11:00:05 + 2h30m = 13:30:05, next due 14:00, plus the jitter
+	assert.Equal(t, 2, runs)
+	assert.Equal(t, []time.Duration{59*time.Minute + 35*time.Second, 30 * time.Minute}, clock.waits)
+}
+
+func TestScheduler_RunOnce(t *testing.T) {
+	tests := []struct {
+		name     string
+		locker   Locker
+		task     Task
+		wantRun  bool
+		wantErr  string
+		errorsIs error
+	}{
+		{
+			name:    "no locker runs everything",
+			task:    func(ctx context.Context) error { return nil },
+			wantRun: true,
+		},
+		{
+			name:    "lock held here",
+			locker:  &fakeLocker{granted: map[string]bool{"digest": true}},
+			task:    func(ctx context.Context) error { return nil },
+			wantRun: true,
+		},
+		{
+			name:   "lock held by another replica",
+			locker: &fakeLocker{granted: map[string]bool{}},
+			task:   func(ctx context.Context) error { return nil },
+		},
+		{
+			name:    "lock error",
+			locker:  &fakeLocker{err: errors.New("connection refused")},
+			task:    func(ctx context.Context) error { return nil },
+			wantErr: "failed to take the lock",
+		},
+		{
+			name:     "task error",
+			task:     func(ctx context.Context) error { return context.DeadlineExceeded },
+			wantRun:  true,
+			errorsIs: context.DeadlineExceeded,
+		},
+		{
+			name:    "task panic",
+			task:    func(ctx context.Context) error { panic("boom") },
+			wantRun: true,
+			wantErr: "panic: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			s := New(tt.locker)
+			ran := false
+			s.Register("digest", "@weekly", 0, func(ctx context.Context) error {
+				ran = true
+				return tt.task(ctx)
+			})
+
+			// Act
+			err := s.runOnce(context.Background(), s.entries[0])
+
+			// Assert
+			assert.Equal(t, tt.wantRun, ran)
+			switch {
+			case tt.wantErr != "":
+				assert.ErrorContains(t, err, tt.wantErr)
+			case tt.errorsIs != nil:
+				assert.ErrorIs(t, err, tt.errorsIs)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestScheduler_RegisterRejectsMistakes(t *testing.T) {
+	task := func(ctx context.Context) error { return nil }
+
+	s := New(nil).Register("janitor", "@hourly", 0, task)
+
+	assert.Panics(t, func() { s.Register("janitor", "@daily", 0, task) }, "duplicate name")
+	assert.Panics(t, func() { s.Register("Bad Name", "@daily", 0, task) }, "invalid name")
+	assert.Panics(t, func() { s.Register("prober", "every minute", 0, task) }, "invalid spec")
+	require.Len(t, s.entries, 1)
+}
+
+func TestRandomJitter(t *testing.T) {
+	assert.Zero(t, randomJitter(0))
+	for i := 0; i < 100; i++ {
+		d := randomJitter(time.Second)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		assert.Less(t, d, time.Second)
+	}
+}
+
+// recordingReporter keeps the reported events
+type recordingReporter struct {
+	mu     sync.Mutex
+	events []errreport.Event
+}
+
+func (r *recordingReporter) Report(event errreport.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingReporter) Flush(timeout time.Duration) bool {
+	return true
+}
+
+func TestScheduler_RunOnce_ReportsPanics(t *testing.T) {
+	// Arrange
+	reporter := &recordingReporter{}
+	s := New(nil).
+		WithErrorReporter(reporter).
+		Register("digest", "@daily", 0, func(ctx context.Context) error { panic("boom") })
+
+	// Act
+	err := s.runOnce(context.Background(), s.entries[0])
+
+	// Assert
+	assert.ErrorContains(t, err, "panic: boom")
+	require.Len(t, reporter.events, 1)
+	assert.Equal(t, "panic: boom", reporter.events[0].Message)
+	assert.Equal(t, map[string]string{"component": "scheduler", "task": "digest"}, reporter.events[0].Tags)
+}
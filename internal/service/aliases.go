@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"url-shortener/internal/domain"
+)
+
+// suggestionWords are short, friendly words combined with the keyword
+// ("launch" -> "launch-now", "get-launch", ...)
+var suggestionWords = []string{
+	"go", "now", "get", "try", "hq", "app", "hub", "top", "pro", "live",
+	"info", "new", "link", "home", "team", "club",
+}
+
+// MaxAliasSuggestions caps how many suggestions a single request can ask for
+const MaxAliasSuggestions = 20
+
+// SuggestAliases returns up to limit available, human-friendly custom aliases
+//
+// The keyword is used when given; otherwise one is derived from the destination
+// (e.g. "https://github.com/golang/go" -> "github"). All candidates are checked
+// against the database in a SINGLE query, then the free ones are returned in
+// order of preference (plain keyword first)
+func (s *URLService) SuggestAliases(ctx context.Context, destination, keyword string, limit int) ([]string, error) {
+	if limit <= 0 || limit > MaxAliasSuggestions {
+		limit = 5
+	}
+
+	base := slugify(keyword)
+	if base == "" {
+		base = keywordFromURL(destination)
+	}
+	if base == "" {
+		return nil, fmt.Errorf("a keyword or a destination URL is required")
+	}
+
+	candidates := aliasCandidates(base, time.Now().Year())
+	if len(candidates) == 0 {
+		return []string{}, nil
+	}
+
+	taken, err := s.urlRepo.FindTakenCodes(ctx, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check alias availability: %w", err)
+	}
+
+	suggestions := make([]string, 0, limit)
+	for _, candidate := range candidates {
+		if taken[candidate] || domain.IsReservedAlias(candidate) {
+			continue
+		}
+		if _, err := s.checkConfusable(candidate); err != nil {
+			continue // Would be refused
+		}
+		suggestions = append(suggestions, candidate)
+		if len(suggestions) == limit {
+			break
+		}
+	}
+
+	return suggestions, nil
+}
+
+// CheckAliasAvailability reports whether a custom alias is free, reserved,
+// taken, or refused for looking like another one
+// Badly formatted aliases return domain.ErrCustomAliasInvalid
+func (s *URLService) CheckAliasAvailability(ctx context.Context, alias string) (domain.AliasStatus, error) {
+	alias = s.codeCase.Normalize(alias) // Checked the way it would be stored
+	if err := domain.ValidateAlias(alias); err != nil {
+		if errors.Is(err, domain.ErrCustomAliasReserved) {
+			return domain.AliasReserved, nil
+		}
+		return "", err
+	}
+
+	// An alias becomes the short code, so it must not collide with either column
+	taken, err := s.urlRepo.FindTakenCodes(ctx, []string{alias})
+	if err != nil {
+		return "", fmt.Errorf("failed to check alias availability: %w", err)
+	}
+	if taken[alias] {
+		return domain.AliasTaken, nil
+	}
+	if _, err := s.checkConfusable(alias); err != nil {
+		return domain.AliasConfusable, nil
+	}
+
+	return domain.AliasFree, nil
+}
+
+// aliasCandidates builds the ordered list of candidate aliases for a keyword
+// Only candidates that are valid aliases (3-20 characters) are kept
+func aliasCandidates(base string, year int) []string {
+	var candidates []string
+	seen := make(map[string]bool)
+	add := func(candidate string) {
+		if len(candidate) >= 3 && len(candidate) <= 20 && !seen[candidate] {
+			seen[candidate] = true
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	add(base)
+	for _, word := range suggestionWords {
+		add(base + "-" + word)
+		add(word + "-" + base)
+	}
+	add(fmt.Sprintf("%s-%d", base, year))
+	add(fmt.Sprintf("%s%d", base, year%100))
+	for i := 1; i <= 9; i++ {
+		add(fmt.Sprintf("%s%d", base, i))
+	}
+
+	return candidates
+}
+
+// keywordFromURL derives a keyword from the destination's host name
+// "https://www.github.com/..." -> "github", "https://blog.example.co.uk" -> "example"
+func keywordFromURL(destination string) string {
+	parsed, err := url.Parse(destination)
+	if err != nil || parsed.Hostname() == "" {
+		return ""
+	}
+
+	labels := strings.Split(strings.TrimPrefix(parsed.Hostname(), "www."), ".")
+	if len(labels) >= 2 {
+		// Take the label before the public suffix; skip short second-level suffixes like "co.uk"
+		candidate := labels[len(labels)-2]
+		if len(candidate) <= 3 && len(labels) >= 3 {
+			candidate = labels[len(labels)-3]
+		}
+		return slugify(candidate)
+	}
+	return slugify(labels[0])
+}
+
+// slugify lowercases text and keeps only alias-safe characters
+// Runs of other characters become a single hyphen; the result is trimmed to 16
+// characters so suffixes like "-now" still fit within the 20 character limit
+func slugify(text string) string {
+	var b strings.Builder
+	lastHyphen := true // Avoid a leading hyphen
+	for _, char := range strings.ToLower(text) {
+		if (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') {
+			b.WriteRune(char)
+			lastHyphen = false
+		} else if !lastHyphen {
+			b.WriteByte('-')
+			lastHyphen = true
+		}
+	}
+
+	slug := strings.Trim(b.String(), "-")
+	if len(slug) > 16 {
+		slug = strings.Trim(slug[:16], "-")
+	}
+	return slug
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/notify"
+	"url-shortener/internal/repository"
+)
+
+// AnomalyService watches click rates and alerts owners about spikes
+//
+// A sudden spike is either good news (the link went viral) or bad news (a
+// bot is hammering it, or it leaked somewhere it shouldn't be). Either way
+// the owner wants to know now, not at the end of the month.
+//
+// This is a BACKGROUND WORKER like WarningService: it reads the hourly
+// click rollups, never the redirect path. The CURRENT hour is checked, so a
+// spike is reported as soon as it crosses the threshold - a partial hour
+// only has fewer clicks than the full one, never more.
+type AnomalyService struct {
+	repo      repository.AnomalyRepository
+	notifier  notify.Notifier
+	detector  domain.SpikeDetector
+	batchSize int // Maximum links checked per run (busiest first)
+	now       func() time.Time
+}
+
+// NewAnomalyService creates a new anomaly service
+func NewAnomalyService(repo repository.AnomalyRepository, notifier notify.Notifier, detector domain.SpikeDetector) *AnomalyService {
+	return &AnomalyService{
+		repo:      repo,
+		notifier:  notifier,
+		detector:  detector,
+		batchSize: 500,
+		now:       time.Now,
+	}
+}
+
+// Run checks for anomalies every interval until ctx is canceled
+func (s *AnomalyService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Detect(ctx); err != nil {
+			fmt.Printf("Warning: anomaly detection failed: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Detect runs a single pass and returns the anomalies it recorded
+func (s *AnomalyService) Detect(ctx context.Context) ([]*domain.ClickAnomaly, error) {
+	now := s.now()
+	hour := now.UTC().Truncate(time.Hour)
+
+	busy, err := s.repo.FindBusyURLs(ctx, hour, s.detector.MinClicks, s.batchSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(busy) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(busy))
+	for i, candidate := range busy {
+		ids[i] = candidate.URL.ID
+	}
+	baselineFrom := hour.Add(-time.Duration(s.detector.BaselineHours) * time.Hour)
+	history, err := s.repo.HourlyClicks(ctx, ids, baselineFrom, hour)
+	if err != nil {
+		return nil, err
+	}
+
+	var recorded []*domain.ClickAnomaly
+	for _, candidate := range busy {
+		baseline, ok := s.baseline(candidate.URL, history[candidate.URL.ID], baselineFrom, hour)
+		if !ok {
+			continue
+		}
+		mean, stddev, score, spike := s.detector.Check(candidate.Clicks, baseline)
+		if !spike {
+			continue
+		}
+
+		anomaly := &domain.ClickAnomaly{
+			Kind:       domain.AnomalyClickSpike,
+			URL:        candidate.URL,
+			Hour:       hour,
+			Clicks:     candidate.Clicks,
+			Mean:       mean,
+			StdDev:     stddev,
+			Score:      score,
+			DetectedAt: now,
+		}
+		if s.report(ctx, anomaly) {
+			recorded = append(recorded, anomaly)
+		}
+	}
+	return recorded, nil
+}
+
+// baseline returns one count per hour the link existed in [from, to)
+// ok=false when the link is younger than the detector's MinHistory: its
+// first hours would all look like spikes compared with "nothing"
+func (s *AnomalyService) baseline(url *domain.URL, counted []domain.ClickBucket, from, to time.Time) ([]int64, bool) {
+	if created := url.CreatedAt.UTC().Truncate(time.Hour); created.After(from) {
+		from = created
+	}
+	hours := int(to.Sub(from) / time.Hour)
+	if hours < s.detector.MinHistory {
+		return nil, false
+	}
+
+	baseline := make([]int64, hours)
+	for _, bucket := range counted {
+		if i := int(bucket.Start.Sub(from) / time.Hour); i >= 0 && i < hours {
+			baseline[i] += bucket.Clicks
+		}
+	}
+	return baseline, true
+}
+
+// report records the anomaly and notifies the owner
+// Returns false when it was already recorded. Delivery is AT MOST ONCE,
+// like link warnings: a failed webhook is logged, not retried
+func (s *AnomalyService) report(ctx context.Context, anomaly *domain.ClickAnomaly) bool {
+	claimed, err := s.repo.Record(ctx, anomaly)
+	if err != nil {
+		fmt.Printf("Warning: failed to record click anomaly: %v\n", err)
+		return false
+	}
+	if !claimed {
+		return false
+	}
+
+	if err := s.notifier.Notify(ctx, anomalyEvent(anomaly)); err != nil {
+		fmt.Printf("Warning: failed to deliver click anomaly alert: %v\n", err)
+	}
+	return true
+}
+
+// anomalyEvent converts an anomaly into a notification event
+func anomalyEvent(anomaly *domain.ClickAnomaly) notify.Event {
+	return notify.Event{
+		Type:       string(anomaly.Kind),
+		URLID:      anomaly.URL.ID,
+		ShortCode:  anomaly.URL.ShortCode,
+		Owner:      anomaly.URL.CreatedBy,
+		OccurredAt: anomaly.DetectedAt,
+		Data: map[string]interface{}{
+			"hour":                 anomaly.Hour.Format(time.RFC3339),
+			"clicks":               anomaly.Clicks,
+			"mean_clicks_per_hour": roundTenth(anomaly.Mean),
+			"stddev":               roundTenth(anomaly.StdDev),
+			"score":                roundTenth(anomaly.Score),
+		},
+	}
+}
+
+// roundTenth rounds to one decimal, so alerts read "12.3" and not "12.345679"
+func roundTenth(x float64) float64 {
+	return math.Round(x*10) / 10
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAnomalyRepository is a mock implementation of AnomalyRepository
+type MockAnomalyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAnomalyRepository) FindBusyURLs(ctx context.Context, hour time.Time, minClicks int64, limit int) ([]*domain.HourlyClicks, error) {
+	args := m.Called(ctx, hour, minClicks, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.HourlyClicks), args.Error(1)
+}
+
+func (m *MockAnomalyRepository) HourlyClicks(ctx context.Context, urlIDs []string, from, to time.Time) (map[string][]domain.ClickBucket, error) {
+	args := m.Called(ctx, urlIDs, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string][]domain.ClickBucket), args.Error(1)
+}
+
+func (m *MockAnomalyRepository) Record(ctx context.Context, anomaly *domain.ClickAnomaly) (bool, error) {
+	args := m.Called(ctx, anomaly)
+	return args.Bool(0), args.Error(1)
+}
+
+// steadyHours returns clicks per hour for every hour in [from, to)
+func steadyHours(from, to time.Time, clicks int64) []domain.ClickBucket {
+	var buckets []domain.ClickBucket
+	for hour := from; hour.Before(to); hour = hour.Add(time.Hour) {
+		buckets = append(buckets, domain.ClickBucket{Start: hour, Clicks: clicks})
+	}
+	return buckets
+}
+
+func TestAnomalyService_Detect(t *testing.T) {
+	// Arrange: it's 14:20, so 14:00 is checked against the week before
+	ctx := context.Background()
+	now := time.Date(2026, 3, 30, 14, 20, 0, 0, time.UTC)
+	hour := time.Date(2026, 3, 30, 14, 0, 0, 0, time.UTC)
+	weekAgo := hour.Add(-7 * 24 * time.Hour)
+
+	viral := &domain.URL{ID: "1", ShortCode: "viral", CreatedBy: "alice", CreatedAt: weekAgo.AddDate(0, -1, 0)}
+	popular := &domain.URL{ID: "2", ShortCode: "popular", CreatedBy: "bob", CreatedAt: weekAgo.AddDate(0, -1, 0)}
+	brandNew := &domain.URL{ID: "3", ShortCode: "launch", CreatedBy: "carol", CreatedAt: hour.Add(-3 * time.Hour)}
+
+	repo := new(MockAnomalyRepository)
+	notifier := new(MockNotifier)
+	service := NewAnomalyService(repo, notifier, domain.DefaultSpikeDetector)
+	service.now = func() time.Time { return now }
+
+	repo.On("FindBusyURLs", ctx, hour, int64(50), 500).Return([]*domain.HourlyClicks{
+		{URL: viral, Clicks: 400},   // Usually ~10 an hour
+		{URL: popular, Clicks: 220}, // Usually ~200 an hour
+		{URL: brandNew, Clicks: 90}, // Only 3 hours old
+	}, nil)
+	repo.On("HourlyClicks", ctx, []string{"1", "2", "3"}, weekAgo, hour).Return(map[string][]domain.ClickBucket{
+		"1": steadyHours(weekAgo, hour, 10),
+		"2": steadyHours(weekAgo, hour, 200),
+		"3": steadyHours(hour.Add(-3*time.Hour), hour, 30),
+	}, nil)
+	repo.On("Record", ctx, mock.MatchedBy(func(a *domain.ClickAnomaly) bool { return a.URL == viral })).Return(true, nil)
+	notifier.On("Notify", ctx, mock.MatchedBy(func(e notify.Event) bool {
+		return e.Type == "click.spike" && e.ShortCode == "viral" && e.Owner == "alice" &&
+			e.Data["clicks"] == int64(400) && e.Data["mean_clicks_per_hour"] == 10.0
+	})).Return(nil)
+
+	// Act
+	anomalies, err := service.Detect(ctx)
+
+	// Assert: only the viral link; the popular one is busy as usual and the
+	// new one has no history to compare with
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, hour, anomalies[0].Hour)
+	assert.Equal(t, 10.0, anomalies[0].Mean)
+	assert.Zero(t, anomalies[0].StdDev)
+	assert.InDelta(t, (400.0-10)/3.1623, anomalies[0].Score, 0.01) // sqrt(mean) floor
+	notifier.AssertExpectations(t)
+}
+
+func TestAnomalyService_Detect_AlreadyRecorded(t *testing.T) {
+	// Arrange: an earlier run (or another instance) already reported this hour
+	ctx := context.Background()
+	hour := time.Date(2026, 3, 30, 14, 0, 0, 0, time.UTC)
+	url := &domain.URL{ID: "1", ShortCode: "viral"}
+
+	repo := new(MockAnomalyRepository)
+	notifier := new(MockNotifier)
+	service := NewAnomalyService(repo, notifier, domain.DefaultSpikeDetector)
+	service.now = func() time.Time { return hour.Add(50 * time.Minute) }
+
+	repo.On("FindBusyURLs", ctx, hour, int64(50), 500).Return([]*domain.HourlyClicks{{URL: url, Clicks: 900}}, nil)
+	repo.On("HourlyClicks", ctx, []string{"1"}, mock.Anything, hour).Return(map[string][]domain.ClickBucket{}, nil)
+	repo.On("Record", ctx, mock.Anything).Return(false, nil)
+
+	// Act
+	anomalies, err := service.Detect(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, anomalies)
+	notifier.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything)
+}
+
+func TestAnomalyService_Detect_NothingBusy(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockAnomalyRepository)
+	service := NewAnomalyService(repo, new(MockNotifier), domain.DefaultSpikeDetector)
+	repo.On("FindBusyURLs", ctx, mock.Anything, int64(50), 500).Return([]*domain.HourlyClicks{}, nil)
+
+	// Act
+	anomalies, err := service.Detect(ctx)
+
+	// Assert: no history query for nothing
+	require.NoError(t, err)
+	assert.Empty(t, anomalies)
+	repo.AssertNotCalled(t, "HourlyClicks", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSpikeDetector_Check(t *testing.T) {
+	detector := domain.SpikeDetector{Threshold: 4, MinClicks: 50}
+
+	tests := []struct {
+		name     string
+		clicks   int64
+		baseline []int64
+		expected bool
+	}{
+		{name: "ten times the usual", clicks: 500, baseline: []int64{40, 60, 50, 55, 45}, expected: true},
+		{name: "within the usual swing", clicks: 90, baseline: []int64{10, 90, 20, 80, 50}, expected: false},
+		{name: "unusual but quiet", clicks: 30, baseline: []int64{0, 0, 1, 0}, expected: false},
+		{name: "flat baseline", clicks: 60, baseline: []int64{50, 50, 50, 50}, expected: false}, // 10 above, sqrt(50) ~ 7
+		{name: "no baseline", clicks: 1000, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, _, _, spike := detector.Check(tt.clicks, tt.baseline)
+
+			// Assert
+			assert.Equal(t, tt.expected, spike)
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/repository"
+)
+
+// apiKeyPrefix starts every API key secret (see scimTokenPrefix)
+// Secret scanners (GitHub, GitLab) can be taught to look for it
+const apiKeyPrefix = "sk_"
+
+// apiKeyShownPrefix is how much of the secret is stored to tell keys apart
+// "sk_" and 8 random characters: recognizable, but useless to an attacker
+const apiKeyShownPrefix = len(apiKeyPrefix) + 8
+
+// lastUsedResolution is how stale last_used_at may get
+// Writing it on every request would turn every read into a write
+const lastUsedResolution = time.Minute
+
+// Defaults of WithRotationGrace and WithExpiryWarning
+const (
+	defaultRotationGrace = 24 * time.Hour
+	defaultExpiryWarning = 7 * 24 * time.Hour
+)
+
+// APIKeyService manages workspaces' API keys and authenticates them
+//
+// WHO MANAGES KEYS?
+// Workspace owners, with credentials that have the admin scope. A key acts
+// for its workspace with exactly its scopes (see auth.Scope), whoever
+// created it.
+//
+// HOW DO KEYS RUN OUT?
+// A key can have an expiry date; after it, it is refused, and the hourly
+// sweep (RevokeExpired) deletes it. Rotating a key gives it a new secret
+// while the old one keeps working for a grace period, so every client can
+// be switched over before it stops. Requests made with a key about to stop
+// working are counted (metrics) and logged, to find those clients in time.
+//
+// WHY A PEPPER?
+// Only hashes of secrets are stored, but a plain SHA-256 can be checked
+// offline by anyone with a copy of the table. With a pepper - a secret that
+// lives in the key manager, not the database - the hashes are HMACs, and a
+// database dump alone is useless. Each hash records its pepper's version
+// (APIKey.HashVersion): after a new pepper is put first, keys are re-hashed
+// the next time they are used.
+type APIKeyService struct {
+	keys          repository.APIKeyRepository
+	peppers       []apiKeyPepper // The first one hashes; none = plain SHA-256
+	rotationGrace time.Duration
+	expiryWarning time.Duration
+	now           func() time.Time
+}
+
+// apiKeyPepper is one secret API key secrets are hashed with
+type apiKeyPepper struct {
+	version string // Stored in api_keys.hash_version
+	key     []byte
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(keys repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{
+		keys:          keys,
+		rotationGrace: defaultRotationGrace,
+		expiryWarning: defaultExpiryWarning,
+		now:           time.Now,
+	}
+}
+
+// WithRotationGrace sets how long a rotated-out secret keeps working when
+// the rotation doesn't say (0 = it stops at once)
+func (s *APIKeyService) WithRotationGrace(grace time.Duration) *APIKeyService {
+	s.rotationGrace = grace
+	return s
+}
+
+// WithHashPeppers hashes secrets with HMAC-SHA256 and the first pepper
+// The others (and plain SHA-256) still find keys hashed before; those are
+// re-hashed with the first pepper when their current secret is used.
+// A pepper's version is derived from it, so the order is all that matters.
+func (s *APIKeyService) WithHashPeppers(peppers ...string) *APIKeyService {
+	s.peppers = nil
+	for _, pepper := range peppers {
+		fingerprint := sha256.Sum256([]byte("api-key-pepper:" + pepper))
+		s.peppers = append(s.peppers, apiKeyPepper{
+			version: "p" + hex.EncodeToString(fingerprint[:4]),
+			key:     []byte(pepper),
+		})
+	}
+	return s
+}
+
+// WithExpiryWarning sets how long before its expiry a key's use is reported
+func (s *APIKeyService) WithExpiryWarning(window time.Duration) *APIKeyService {
+	s.expiryWarning = window
+	return s
+}
+
+// CreateKey creates a key for the caller's workspace (owners only)
+// Returns the secret, which is shown once
+//
+// Operators may name another workspace in key.Workspace: that's how a new
+// workspace gets its first owner key (there is no other way to start one).
+// Such a key belongs to that workspace and has no operator powers.
+func (s *APIKeyService) CreateKey(ctx context.Context, key *domain.APIKey) (string, error) {
+	workspace, err := keyManagerWorkspace(ctx)
+	if err != nil {
+		return "", err
+	}
+	principal := auth.FromContext(ctx)
+	if requested := strings.TrimSpace(key.Workspace); requested != "" && requested != workspace {
+		if !principal.Admin {
+			return "", domain.ErrForbidden
+		}
+		workspace = requested
+	}
+	if err := key.Validate(); err != nil {
+		return "", err
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(s.now()) {
+		return "", domain.ErrAPIKeyExpiry
+	}
+
+	for _, scope := range key.Scopes {
+		if _, err := auth.ParseScope(scope); err != nil {
+			return "", fmt.Errorf("%w: %v", domain.ErrInvalidAPIKey, err)
+		}
+	}
+
+	token, err := newAPIKeySecret()
+	if err != nil {
+		return "", err
+	}
+	key.Workspace = workspace
+	key.Prefix = token[:apiKeyShownPrefix]
+	key.Admin = principal.Admin && workspace == principal.ID
+	key.CreatedBy = principal.Actor()
+	var hash string
+	key.HashVersion, hash = s.hashSecret(token)
+	if err := s.keys.Create(ctx, key, hash); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ListKeys returns the caller's keys (owners only)
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	workspace, err := keyManagerWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.keys.List(ctx, workspace)
+}
+
+// RotateKey gives a key a new secret (owners only)
+// Name, scopes and history stay; the old secret keeps working for the
+// rotation's grace period. Rotating again ends the grace period of the
+// secret before.
+func (s *APIKeyService) RotateKey(ctx context.Context, id string, rotation domain.APIKeyRotation) (string, *domain.APIKey, error) {
+	workspace, err := keyManagerWorkspace(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	now := s.now()
+	if rotation.ExpiresAt != nil && !rotation.ExpiresAt.After(now) {
+		return "", nil, domain.ErrAPIKeyExpiry
+	}
+	grace := s.rotationGrace
+	if rotation.Grace != nil {
+		grace = *rotation.Grace
+	}
+
+	token, err := newAPIKeySecret()
+	if err != nil {
+		return "", nil, err
+	}
+	version, hash := s.hashSecret(token)
+	key, err := s.keys.Rotate(ctx, workspace, id, token[:apiKeyShownPrefix], hash, version, now.Add(grace), rotation.ExpiresAt)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, key, nil
+}
+
+// RevokeKey deletes a key (owners only)
+func (s *APIKeyService) RevokeKey(ctx context.Context, id string) error {
+	workspace, err := keyManagerWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+	return s.keys.Delete(ctx, workspace, id)
+}
+
+// RevokeExpired deletes expired keys and forgets lapsed previous secrets
+// Run by the scheduler; returns how many keys were deleted
+func (s *APIKeyService) RevokeExpired(ctx context.Context) (int64, error) {
+	return s.keys.RevokeExpired(ctx, s.now())
+}
+
+// Authenticate turns an API key into its principal
+// It implements auth.Authenticator (see auth.ChainAuthenticator)
+func (s *APIKeyService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	if !strings.HasPrefix(token, apiKeyPrefix) {
+		return nil, auth.ErrInvalidCredentials
+	}
+	// previous: the token is the secret the last rotation replaced
+	key, previous, err := s.keys.GetByHash(ctx, s.candidateHashes(token)...)
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return nil, auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if key.Expired(now) || (previous && !key.PreviousSecretValid(now)) {
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	if !previous {
+		s.rehash(ctx, key, token)
+	}
+
+	warning := s.expiryWarningFor(key, previous, now)
+	if warning != "" {
+		metrics.RecordAPIKeyExpiringAuthentication(warning)
+	}
+
+	// A failed write must not lock a valid key out: the next request
+	// simply tries again. The warning is logged as often as the key is
+	// touched, not on every request.
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		_ = s.keys.Touch(ctx, key.ID, now)
+		switch warning {
+		case "previous_secret":
+			fmt.Printf("Warning: API key %s of %s used with its rotated-out secret, which stops working at %s\n",
+				key.Prefix, key.Workspace, key.PreviousExpiresAt.Format(time.RFC3339))
+		case "expiring":
+			fmt.Printf("Warning: API key %s of %s expires at %s\n",
+				key.Prefix, key.Workspace, key.ExpiresAt.Format(time.RFC3339))
+		}
+	}
+
+	scopes := make([]auth.Scope, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = auth.Scope(scope)
+	}
+	principal := &auth.Principal{ID: key.Workspace, Plan: key.Plan, Scopes: scopes}
+	// Operator powers need both an operator's key and the admin scope
+	principal.Admin = key.Admin && principal.HasScope(auth.ScopeAdmin)
+	return principal, nil
+}
+
+// HashVersion is the version new hashes get ("" = plain SHA-256)
+func (s *APIKeyService) HashVersion() string {
+	version, _ := s.hashSecret("")
+	return version
+}
+
+// hashSecret returns the hash of a secret to store, and its version
+func (s *APIKeyService) hashSecret(token string) (version, hash string) {
+	if len(s.peppers) == 0 {
+		return "", hashToken(token)
+	}
+	return s.peppers[0].version, s.peppers[0].hash(token)
+}
+
+// candidateHashes are the hashes a stored secret may have: one per pepper,
+// then plain SHA-256
+func (s *APIKeyService) candidateHashes(token string) []string {
+	hashes := make([]string, 0, len(s.peppers)+1)
+	for _, pepper := range s.peppers {
+		hashes = append(hashes, pepper.hash(token))
+	}
+	return append(hashes, hashToken(token))
+}
+
+// rehash moves a key's current secret to the first pepper
+// A failed write is retried on the next request, like Touch
+func (s *APIKeyService) rehash(ctx context.Context, key *domain.APIKey, token string) {
+	version, hash := s.hashSecret(token)
+	if key.HashVersion == version {
+		return
+	}
+	if err := s.keys.Rehash(ctx, key.ID, key.Prefix, hash, version); err != nil {
+		fmt.Printf("Warning: failed to rehash API key %s: %v\n", key.Prefix, err)
+	}
+}
+
+// hash is the HMAC-SHA256 of token with the pepper, as hex
+func (p apiKeyPepper) hash(token string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// expiryWarningFor is why a request's key is about to stop working, if it is
+// "previous_secret": the secret was rotated out; "expiring": the key expires
+// within the warning window; "" otherwise
+func (s *APIKeyService) expiryWarningFor(key *domain.APIKey, previous bool, now time.Time) string {
+	switch {
+	case previous:
+		return "previous_secret"
+	case key.ExpiresAt != nil && key.ExpiresAt.Sub(now) < s.expiryWarning:
+		return "expiring"
+	}
+	return ""
+}
+
+// keyManagerWorkspace is the workspace whose keys the caller may manage
+// Owners only, and a key needs the admin scope: otherwise a leaked
+// read-only key could mint itself a key that does everything
+func keyManagerWorkspace(ctx context.Context) (string, error) {
+	workspace, err := ownedWorkspace(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !auth.FromContext(ctx).HasScope(auth.ScopeAdmin) {
+		return "", domain.ErrForbidden
+	}
+	return workspace, nil
+}
+
+// newAPIKeySecret generates a new secret
+func newAPIKeySecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
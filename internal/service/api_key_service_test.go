@@ -0,0 +1,533 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAPIKeyRepository is a mock implementation of repository.APIKeyRepository
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey, tokenHash string) error {
+	args := m.Called(ctx, key, tokenHash)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetByHash(ctx context.Context, tokenHashes ...string) (*domain.APIKey, bool, error) {
+	args := m.Called(ctx, tokenHashes)
+	if args.Get(0) == nil {
+		return nil, false, args.Error(2)
+	}
+	return args.Get(0).(*domain.APIKey), args.Bool(1), args.Error(2)
+}
+
+func (m *MockAPIKeyRepository) List(ctx context.Context, workspace string) ([]*domain.APIKey, error) {
+	args := m.Called(ctx, workspace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Rotate(ctx context.Context, workspace, id, prefix, tokenHash, hashVersion string, previousExpiresAt time.Time, expiresAt *time.Time) (*domain.APIKey, error) {
+	args := m.Called(ctx, workspace, id, prefix, tokenHash, hashVersion, previousExpiresAt, expiresAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Rehash(ctx context.Context, id, prefix, tokenHash, hashVersion string) error {
+	args := m.Called(ctx, id, prefix, tokenHash, hashVersion)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Delete(ctx context.Context, workspace, id string) error {
+	args := m.Called(ctx, workspace, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Touch(ctx context.Context, id string, usedAt time.Time) error {
+	args := m.Called(ctx, id, usedAt)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) RevokeExpired(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestAPIKeyService_CreateKey(t *testing.T) {
+	yesterday := time.Now().Add(-24 * time.Hour)
+	nextYear := time.Now().AddDate(1, 0, 0)
+
+	tests := []struct {
+		name          string
+		principal     *auth.Principal
+		key           *domain.APIKey
+		expectedAdmin bool
+		expectedWS    string // "" = the caller's workspace
+		expectErr     error
+	}{
+		{
+			name:      "owner",
+			principal: &auth.Principal{ID: "team1"},
+			key:       &domain.APIKey{Name: " CI ", Scopes: []string{"urls:write", "urls:read", "urls:write"}},
+		},
+		{
+			name:          "operator",
+			principal:     &auth.Principal{ID: "admin", Admin: true},
+			key:           &domain.APIKey{Name: "edge sync", Scopes: []string{"admin"}},
+			expectedAdmin: true,
+		},
+		{
+			name:       "operator starts a workspace",
+			principal:  &auth.Principal{ID: "admin", Admin: true},
+			key:        &domain.APIKey{Workspace: "acme", Name: "owner", Scopes: []string{"admin"}},
+			expectedWS: "acme", // The workspace's key, without operator powers
+		},
+		{
+			name:          "operator naming its own workspace",
+			principal:     &auth.Principal{ID: "admin", Admin: true},
+			key:           &domain.APIKey{Workspace: "admin", Name: "edge sync", Scopes: []string{"admin"}},
+			expectedAdmin: true,
+		},
+		{
+			name:      "owner naming its own workspace",
+			principal: &auth.Principal{ID: "team1"},
+			key:       &domain.APIKey{Workspace: "team1", Name: "CI", Scopes: []string{"urls:write"}},
+		},
+		{
+			name:      "owner naming another workspace",
+			principal: &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeAdmin}},
+			key:       &domain.APIKey{Workspace: "team2", Name: "CI", Scopes: []string{"admin"}},
+			expectErr: domain.ErrForbidden,
+		},
+		{
+			name:      "admin-scoped key",
+			principal: &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeAdmin}},
+			key:       &domain.APIKey{Name: "CI", Scopes: []string{"urls:write", "urls:read"}},
+		},
+		{
+			name:      "key without the admin scope",
+			principal: &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeURLsWrite}},
+			key:       &domain.APIKey{Name: "CI", Scopes: []string{"urls:write"}},
+			expectErr: domain.ErrForbidden,
+		},
+		{
+			name:      "editor",
+			principal: teamEditor,
+			key:       &domain.APIKey{Name: "CI", Scopes: []string{"urls:write"}},
+			expectErr: domain.ErrForbidden,
+		},
+		{
+			name:      "unknown scope",
+			principal: &auth.Principal{ID: "team1"},
+			key:       &domain.APIKey{Name: "CI", Scopes: []string{"urls:delete"}},
+			expectErr: domain.ErrInvalidAPIKey,
+		},
+		{
+			name:      "no scopes",
+			principal: &auth.Principal{ID: "team1"},
+			key:       &domain.APIKey{Name: "CI"},
+			expectErr: domain.ErrInvalidAPIKey,
+		},
+		{
+			name:      "expiring key",
+			principal: &auth.Principal{ID: "team1"},
+			key:       &domain.APIKey{Name: "CI", Scopes: []string{"urls:write"}, ExpiresAt: &nextYear},
+		},
+		{
+			name:      "expiry in the past",
+			principal: &auth.Principal{ID: "team1"},
+			key:       &domain.APIKey{Name: "CI", Scopes: []string{"urls:write"}, ExpiresAt: &yesterday},
+			expectErr: domain.ErrAPIKeyExpiry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			keys := new(MockAPIKeyRepository)
+			var stored *domain.APIKey
+			var storedHash string
+			keys.On("Create", ctx, mock.AnythingOfType("*domain.APIKey"), mock.AnythingOfType("string")).
+				Run(func(args mock.Arguments) {
+					stored = args.Get(1).(*domain.APIKey)
+					storedHash = args.String(2)
+				}).
+				Return(nil).Maybe()
+			service := NewAPIKeyService(keys)
+
+			// Act
+			token, err := service.CreateKey(ctx, tt.key)
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				keys.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(token, "sk_"))
+			assert.Equal(t, hashToken(token), storedHash, "only the hash is stored")
+			expectedWS := tt.expectedWS
+			if expectedWS == "" {
+				expectedWS = tt.principal.ID
+			}
+			assert.Equal(t, expectedWS, stored.Workspace)
+			assert.Equal(t, token[:11], stored.Prefix)
+			assert.Equal(t, tt.expectedAdmin, stored.Admin)
+			assert.Equal(t, tt.principal.Actor(), stored.CreatedBy)
+		})
+	}
+}
+
+func TestAPIKeyService_CreateKey_NormalizesKey(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "team1"})
+	keys := new(MockAPIKeyRepository)
+	keys.On("Create", ctx, mock.Anything, mock.Anything).Return(nil)
+	key := &domain.APIKey{Name: " CI ", Scopes: []string{"urls:write", "urls:read", "urls:write"}}
+
+	// Act
+	_, err := NewAPIKeyService(keys).CreateKey(ctx, key)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "CI", key.Name)
+	assert.Equal(t, []string{"urls:write", "urls:read"}, key.Scopes)
+}
+
+func TestAPIKeyService_RotateKey(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	nextYear := now.AddDate(1, 0, 0)
+	noGrace := time.Duration(0)
+
+	tests := []struct {
+		name             string
+		rotation         domain.APIKeyRotation
+		expectedPrevious time.Time
+		expectedExpiry   *time.Time
+	}{
+		{name: "default grace period", expectedPrevious: now.Add(24 * time.Hour)},
+		{name: "old secret stops at once", rotation: domain.APIKeyRotation{Grace: &noGrace}, expectedPrevious: now},
+		{name: "new expiry", rotation: domain.APIKeyRotation{ExpiresAt: &nextYear}, expectedPrevious: now.Add(24 * time.Hour), expectedExpiry: &nextYear},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			owner := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "team1"})
+			keys := new(MockAPIKeyRepository)
+			var newHash string
+			keys.On("Rotate", owner, "team1", "k1", mock.AnythingOfType("string"), mock.AnythingOfType("string"), "", tt.expectedPrevious, tt.expectedExpiry).
+				Run(func(args mock.Arguments) { newHash = args.String(4) }).
+				Return(&domain.APIKey{ID: "k1", Workspace: "team1", Name: "CI"}, nil)
+			service := NewAPIKeyService(keys)
+			service.now = func() time.Time { return now }
+
+			// Act
+			token, key, err := service.RotateKey(owner, "k1", tt.rotation)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, "k1", key.ID)
+			assert.Equal(t, hashToken(token), newHash)
+			keys.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAPIKeyService_RotateKey_Rejected(t *testing.T) {
+	// Arrange
+	owner := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "team1"})
+	editor := auth.WithPrincipal(context.Background(), teamEditor)
+	lastWeek := time.Now().AddDate(0, 0, -7)
+	keys := new(MockAPIKeyRepository)
+	keys.On("Rotate", owner, "team1", "missing", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, domain.ErrAPIKeyNotFound)
+	service := NewAPIKeyService(keys)
+
+	// Act
+	_, _, errMissing := service.RotateKey(owner, "missing", domain.APIKeyRotation{})
+	_, _, errEditor := service.RotateKey(editor, "k1", domain.APIKeyRotation{})
+	_, _, errExpiry := service.RotateKey(owner, "k1", domain.APIKeyRotation{ExpiresAt: &lastWeek})
+
+	// Assert
+	assert.ErrorIs(t, errMissing, domain.ErrAPIKeyNotFound)
+	assert.ErrorIs(t, errEditor, domain.ErrForbidden)
+	assert.ErrorIs(t, errExpiry, domain.ErrAPIKeyExpiry)
+	keys.AssertNumberOfCalls(t, "Rotate", 1)
+}
+
+func TestAPIKeyService_WithRotationGrace(t *testing.T) {
+	// Arrange
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	owner := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "team1"})
+	keys := new(MockAPIKeyRepository)
+	keys.On("Rotate", owner, "team1", "k1", mock.Anything, mock.Anything, "", now.Add(time.Hour), (*time.Time)(nil)).
+		Return(&domain.APIKey{ID: "k1"}, nil)
+	service := NewAPIKeyService(keys).WithRotationGrace(time.Hour)
+	service.now = func() time.Time { return now }
+
+	// Act
+	_, _, err := service.RotateKey(owner, "k1", domain.APIKeyRotation{})
+
+	// Assert
+	require.NoError(t, err)
+	keys.AssertExpectations(t)
+}
+
+func TestAPIKeyService_RevokeExpired(t *testing.T) {
+	// Arrange
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	keys := new(MockAPIKeyRepository)
+	keys.On("RevokeExpired", mock.Anything, now).Return(int64(2), nil)
+	service := NewAPIKeyService(keys)
+	service.now = func() time.Time { return now }
+
+	// Act
+	revoked, err := service.RevokeExpired(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), revoked)
+}
+
+func TestAPIKeyService_RevokeKey(t *testing.T) {
+	// Arrange
+	owner := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "team1"})
+	readOnly := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeURLsRead}})
+	keys := new(MockAPIKeyRepository)
+	keys.On("Delete", owner, "team1", "k1").Return(nil)
+	service := NewAPIKeyService(keys)
+
+	// Act & Assert
+	assert.NoError(t, service.RevokeKey(owner, "k1"))
+	assert.ErrorIs(t, service.RevokeKey(readOnly, "k1"), domain.ErrForbidden)
+	keys.AssertExpectations(t)
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	const token = "sk_abcdefghijk"
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	recently := now.Add(-10 * time.Second)
+	earlier := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+	nextYear := now.AddDate(1, 0, 0)
+
+	tests := []struct {
+		name        string
+		token       string
+		found       *domain.APIKey
+		previous    bool // The token matched the secret the last rotation replaced
+		foundErr    error
+		expectTouch bool
+		expected    *auth.Principal
+		expectErr   error
+		warning     string // Counted in api_key_expiring_authentications_total
+	}{
+		{
+			name:        "scoped key",
+			token:       token,
+			found:       &domain.APIKey{ID: "k1", Workspace: "team1", Scopes: []string{"urls:read", "stats:read"}},
+			expectTouch: true,
+			expected:    &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeURLsRead, auth.ScopeStatsRead}},
+		},
+		{
+			name:        "workspace on the pro plan",
+			token:       token,
+			found:       &domain.APIKey{ID: "k1", Workspace: "team1", Plan: domain.PlanPro, Scopes: []string{"urls:write"}},
+			expectTouch: true,
+			expected:    &auth.Principal{ID: "team1", Plan: domain.PlanPro, Scopes: []auth.Scope{auth.ScopeURLsWrite}},
+		},
+		{
+			name:     "used a moment ago",
+			token:    token,
+			found:    &domain.APIKey{ID: "k1", Workspace: "team1", Scopes: []string{"urls:read"}, LastUsedAt: &recently},
+			expected: &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeURLsRead}},
+		},
+		{
+			name:        "operator key with the admin scope",
+			token:       token,
+			found:       &domain.APIKey{ID: "k1", Workspace: "admin", Admin: true, Scopes: []string{"admin"}, LastUsedAt: &earlier},
+			expectTouch: true,
+			expected:    &auth.Principal{ID: "admin", Admin: true, Scopes: []auth.Scope{auth.ScopeAdmin}},
+		},
+		{
+			name:        "operator key without the admin scope",
+			token:       token,
+			found:       &domain.APIKey{ID: "k1", Workspace: "admin", Admin: true, Scopes: []string{"urls:write"}},
+			expectTouch: true,
+			expected:    &auth.Principal{ID: "admin", Scopes: []auth.Scope{auth.ScopeURLsWrite}},
+		},
+		{
+			name:        "expires soon",
+			token:       token,
+			found:       &domain.APIKey{ID: "k1", Workspace: "team1", Prefix: "sk_abcdefgh", Scopes: []string{"urls:read"}, ExpiresAt: &later},
+			expectTouch: true,
+			expected:    &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeURLsRead}},
+			warning:     "expiring",
+		},
+		{
+			name:        "previous secret in its grace period",
+			token:       token,
+			found:       &domain.APIKey{ID: "k1", Workspace: "team1", Prefix: "sk_zyxwvuts", Scopes: []string{"urls:read"}, ExpiresAt: &nextYear, PreviousExpiresAt: &later},
+			previous:    true,
+			expectTouch: true,
+			expected:    &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeURLsRead}},
+			warning:     "previous_secret",
+		},
+		{
+			name:      "previous secret after its grace period",
+			token:     token,
+			found:     &domain.APIKey{ID: "k1", Workspace: "team1", Prefix: "sk_zyxwvuts", Scopes: []string{"urls:read"}, PreviousExpiresAt: &earlier},
+			previous:  true,
+			expectErr: auth.ErrInvalidCredentials,
+		},
+		{
+			// The hash that matched decides, not the displayed prefix
+			name:        "current secret after the previous one's grace period",
+			token:       token,
+			found:       &domain.APIKey{ID: "k1", Workspace: "team1", Prefix: "sk_zyxwvuts", Scopes: []string{"urls:read"}, PreviousExpiresAt: &earlier},
+			expectTouch: true,
+			expected:    &auth.Principal{ID: "team1", Scopes: []auth.Scope{auth.ScopeURLsRead}},
+		},
+		{
+			name:      "expired key",
+			token:     token,
+			found:     &domain.APIKey{ID: "k1", Workspace: "team1", Prefix: "sk_abcdefgh", Scopes: []string{"urls:read"}, ExpiresAt: &earlier},
+			expectErr: auth.ErrInvalidCredentials,
+		},
+		{name: "unknown key", token: token, foundErr: domain.ErrAPIKeyNotFound, expectErr: auth.ErrInvalidCredentials},
+		{name: "session token", token: "sso_abc", expectErr: auth.ErrInvalidCredentials},
+		{name: "database down", token: token, foundErr: assert.AnError, expectErr: assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			keys := new(MockAPIKeyRepository)
+			keys.On("GetByHash", ctx, []string{hashToken(tt.token)}).Return(tt.found, tt.previous, tt.foundErr).Maybe()
+			keys.On("Touch", ctx, "k1", now).Return(assert.AnError).Maybe()
+			service := NewAPIKeyService(keys)
+			service.now = func() time.Time { return now }
+			expiring := testutil.ToFloat64(metrics.APIKeyExpiringAuthenticationsTotal.WithLabelValues("expiring"))
+			previous := testutil.ToFloat64(metrics.APIKeyExpiringAuthenticationsTotal.WithLabelValues("previous_secret"))
+
+			// Act
+			principal, err := service.Authenticate(ctx, tt.token)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectErr)
+			warnings := map[string]float64{
+				"expiring":        testutil.ToFloat64(metrics.APIKeyExpiringAuthenticationsTotal.WithLabelValues("expiring")) - expiring,
+				"previous_secret": testutil.ToFloat64(metrics.APIKeyExpiringAuthenticationsTotal.WithLabelValues("previous_secret")) - previous,
+			}
+			for reason, count := range warnings {
+				if reason == tt.warning {
+					assert.Equal(t, 1.0, count, reason)
+				} else {
+					assert.Zero(t, count, reason)
+				}
+			}
+			assert.Equal(t, tt.expected, principal, "a failed Touch doesn't fail the request")
+			if tt.expectTouch {
+				keys.AssertCalled(t, "Touch", ctx, "k1", now)
+			} else {
+				keys.AssertNotCalled(t, "Touch", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_HashPeppers(t *testing.T) {
+	const (
+		token   = "sk_abcdefghijk"
+		current = "pepper-2026-10-0123456789abcdef0123456789"
+		older   = "pepper-2026-01-0123456789abcdef0123456789"
+	)
+	service := NewAPIKeyService(nil).WithHashPeppers(current, older)
+	version, hash := service.hashSecret(token)
+	olderVersion, olderHash := NewAPIKeyService(nil).WithHashPeppers(older).hashSecret(token)
+
+	tests := []struct {
+		name           string
+		found          *domain.APIKey
+		previous       bool
+		expectedRehash bool
+	}{
+		{name: "hashed with the current pepper", found: &domain.APIKey{ID: "k1", Workspace: "team1", Prefix: "sk_abcdefgh", HashVersion: version}},
+		{name: "hashed with an older pepper", found: &domain.APIKey{ID: "k1", Workspace: "team1", Prefix: "sk_abcdefgh", HashVersion: olderVersion}, expectedRehash: true},
+		{name: "hashed before peppers", found: &domain.APIKey{ID: "k1", Workspace: "team1", Prefix: "sk_abcdefgh"}, expectedRehash: true},
+		{
+			name: "rotated-out secret",
+			found: &domain.APIKey{ID: "k1", Workspace: "team1", Prefix: "sk_zyxwvuts", HashVersion: olderVersion,
+				PreviousExpiresAt: func() *time.Time { later := time.Now().Add(time.Hour); return &later }()},
+			previous: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			keys := new(MockAPIKeyRepository)
+			keys.On("GetByHash", ctx, []string{hash, olderHash, hashToken(token)}).Return(tt.found, tt.previous, nil)
+			keys.On("Touch", ctx, "k1", mock.Anything).Return(nil)
+			keys.On("Rehash", ctx, "k1", "sk_abcdefgh", hash, version).Return(nil).Maybe()
+			service := NewAPIKeyService(keys).WithHashPeppers(current, older)
+
+			// Act
+			principal, err := service.Authenticate(ctx, token)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, "team1", principal.ID)
+			if tt.expectedRehash {
+				keys.AssertCalled(t, "Rehash", ctx, "k1", "sk_abcdefgh", hash, version)
+			} else {
+				keys.AssertNotCalled(t, "Rehash", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_CreateKey_HashPepper(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "team1"})
+	keys := new(MockAPIKeyRepository)
+	var stored *domain.APIKey
+	var storedHash string
+	keys.On("Create", ctx, mock.AnythingOfType("*domain.APIKey"), mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*domain.APIKey)
+			storedHash = args.String(2)
+		}).
+		Return(nil)
+	service := NewAPIKeyService(keys).WithHashPeppers("pepper-2026-10-0123456789abcdef0123456789")
+
+	// Act
+	token, err := service.CreateKey(ctx, &domain.APIKey{Name: "CI", Scopes: []string{"urls:read"}})
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotEqual(t, hashToken(token), storedHash, "not a plain SHA-256")
+	assert.Len(t, storedHash, 64)
+	assert.Regexp(t, "^p[0-9a-f]{8}$", stored.HashVersion)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/events"
+	"url-shortener/internal/repository"
+)
+
+// maxPendingApprovals caps the approvals queue of one request
+// A workspace with more pending links than that has bigger problems than paging
+const maxPendingApprovals = 1000
+
+// ApprovalService runs the approval queue of a workspace
+//
+// Links land in the queue when a contributor creates them in a workspace
+// with RequireApproval (see URLService.CreateShortURL). Editors and owners
+// then approve them - the link goes live - or reject them. Every request
+// and decision is published as an event, which notifies the approvers (or
+// the contributor) and records the audit entry.
+type ApprovalService struct {
+	urlRepo   repository.URLRepository
+	approvals repository.ApprovalRepository
+	events    *events.Bus
+	now       func() time.Time
+}
+
+// NewApprovalService creates an approval service
+// bus should be the link event bus (URLService.Events), so the usual
+// subscribers see approval events
+func NewApprovalService(urlRepo repository.URLRepository, approvals repository.ApprovalRepository, bus *events.Bus) *ApprovalService {
+	return &ApprovalService{urlRepo: urlRepo, approvals: approvals, events: bus, now: time.Now}
+}
+
+// ListPending returns the links of the caller's workspace that wait for
+// approval, oldest first (editors, owners and admins only)
+func (s *ApprovalService) ListPending(ctx context.Context) ([]*domain.URL, error) {
+	principal := auth.FromContext(ctx)
+	if principal == auth.Anonymous || (!principal.Admin && !canReview(principal)) {
+		return nil, domain.ErrForbidden
+	}
+	return s.approvals.ListPending(ctx, principal.ID, maxPendingApprovals)
+}
+
+// Approve makes a pending link redirect
+func (s *ApprovalService) Approve(ctx context.Context, id, reason string) (*domain.URL, error) {
+	return s.decide(ctx, id, true, reason)
+}
+
+// Reject turns a pending link down; it never redirects
+// The contributor may delete it to free the alias
+func (s *ApprovalService) Reject(ctx context.Context, id, reason string) (*domain.URL, error) {
+	return s.decide(ctx, id, false, reason)
+}
+
+// decide records the decision on a pending link
+// The version check of Update makes two reviewers deciding at once safe:
+// the second gets domain.ErrVersionConflict instead of overwriting the first
+func (s *ApprovalService) decide(ctx context.Context, id string, approve bool, reason string) (*domain.URL, error) {
+	if utf8.RuneCountInString(reason) > domain.MaxApprovalReason {
+		return nil, domain.ErrInvalidApprovalReason
+	}
+
+	url, err := s.urlRepo.GetByID(repository.WithConsistentRead(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeApprove(ctx, url); err != nil {
+		return nil, err
+	}
+	if url.Approval != domain.ApprovalPending {
+		return nil, domain.ErrNotPendingApproval
+	}
+
+	url.Approval = domain.ApprovalRejected
+	if approve {
+		url.Approval = domain.ApprovalApproved
+		url.IsActive = true
+	}
+	if err := s.urlRepo.Update(ctx, url); err != nil {
+		return nil, err
+	}
+
+	s.events.Publish(ctx, events.ApprovalDecided{
+		URL:        url,
+		Actor:      auth.FromContext(ctx).Actor(),
+		Approved:   approve,
+		Reason:     reason,
+		OccurredAt: s.now(),
+	})
+	return url, nil
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockApprovalRepository is a mock implementation of repository.ApprovalRepository
+type MockApprovalRepository struct {
+	mock.Mock
+}
+
+func (m *MockApprovalRepository) ListPending(ctx context.Context, workspace string, limit int) ([]*domain.URL, error) {
+	args := m.Called(ctx, workspace, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URL), args.Error(1)
+}
+
+var (
+	teamOwner       = &auth.Principal{ID: "team1"}
+	teamEditor      = &auth.Principal{ID: "team1", Member: "erin@example.com", Role: auth.RoleEditor}
+	teamContributor = &auth.Principal{ID: "team1", Member: "dana@example.com", Role: auth.RoleContributor}
+	teamViewer      = &auth.Principal{ID: "team1", Member: "vic@example.com", Role: auth.RoleViewer}
+)
+
+func pendingURL() *domain.URL {
+	return &domain.URL{
+		ID:          "123",
+		ShortCode:   "launch",
+		OriginalURL: "https://example.com/launch",
+		CreatedBy:   "team1",
+		Approval:    domain.ApprovalPending,
+		SubmittedBy: "dana@example.com",
+		IsActive:    false,
+	}
+}
+
+func TestApprovalService_ListPending(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		expectErr error
+	}{
+		{name: "owner", principal: teamOwner},
+		{name: "editor", principal: teamEditor},
+		{name: "contributor", principal: teamContributor, expectErr: domain.ErrForbidden},
+		{name: "viewer", principal: teamViewer, expectErr: domain.ErrForbidden},
+		{name: "anonymous", principal: nil, expectErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+			approvals := new(MockApprovalRepository)
+			pending := []*domain.URL{pendingURL()}
+			if tt.expectErr == nil {
+				approvals.On("ListPending", ctx, "team1", maxPendingApprovals).Return(pending, nil)
+			}
+			service := NewApprovalService(new(MockURLRepository), approvals, events.NewBus())
+
+			// Act
+			urls, err := service.ListPending(ctx)
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				approvals.AssertNotCalled(t, "ListPending", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, pending, urls)
+		})
+	}
+}
+
+func TestApprovalService_Decide(t *testing.T) {
+	tests := []struct {
+		name         string
+		principal    *auth.Principal
+		approve      bool
+		reason       string
+		stored       *domain.URL
+		expectErr    error
+		wantApproval domain.ApprovalStatus
+		wantActive   bool
+	}{
+		{name: "editor approves", principal: teamEditor, approve: true, stored: pendingURL(), wantApproval: domain.ApprovalApproved, wantActive: true},
+		{name: "owner rejects", principal: teamOwner, reason: "Wrong campaign", stored: pendingURL(), wantApproval: domain.ApprovalRejected},
+		{name: "admin approves", principal: &auth.Principal{ID: "admin", Admin: true}, approve: true, stored: pendingURL(), wantApproval: domain.ApprovalApproved, wantActive: true},
+		{name: "contributor can't approve own link", principal: teamContributor, approve: true, stored: pendingURL(), expectErr: domain.ErrForbidden},
+		{name: "viewer can't reject", principal: teamViewer, stored: pendingURL(), expectErr: domain.ErrForbidden},
+		{name: "other workspace", principal: &auth.Principal{ID: "team2", Role: auth.RoleEditor}, approve: true, stored: pendingURL(), expectErr: domain.ErrForbidden},
+		{
+			name:      "already decided",
+			principal: teamEditor,
+			approve:   true,
+			stored:    &domain.URL{ID: "123", CreatedBy: "team1", Approval: domain.ApprovalRejected},
+			expectErr: domain.ErrNotPendingApproval,
+		},
+		{name: "never needed approval", principal: teamEditor, approve: true, stored: &domain.URL{ID: "123", CreatedBy: "team1", IsActive: true}, expectErr: domain.ErrNotPendingApproval},
+		{name: "reason too long", principal: teamEditor, reason: strings.Repeat("x", domain.MaxApprovalReason+1), expectErr: domain.ErrInvalidApprovalReason},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			mockURLRepo := new(MockURLRepository)
+			if tt.stored != nil {
+				mockURLRepo.On("GetByID", mock.Anything, "123").Return(tt.stored, nil)
+			}
+			mockURLRepo.On("Update", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
+			bus := events.NewBus()
+			var decided []events.ApprovalDecided
+			events.Subscribe(bus, func(ctx context.Context, e events.ApprovalDecided) {
+				decided = append(decided, e)
+			})
+			service := NewApprovalService(mockURLRepo, new(MockApprovalRepository), bus)
+
+			// Act
+			var url *domain.URL
+			var err error
+			if tt.approve {
+				url, err = service.Approve(ctx, "123", tt.reason)
+			} else {
+				url, err = service.Reject(ctx, "123", tt.reason)
+			}
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				mockURLRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				assert.Empty(t, decided)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantApproval, url.Approval)
+			assert.Equal(t, tt.wantActive, url.IsActive)
+			require.Len(t, decided, 1)
+			assert.Equal(t, tt.approve, decided[0].Approved)
+			assert.Equal(t, tt.reason, decided[0].Reason)
+			assert.Equal(t, tt.principal.Actor(), decided[0].Actor)
+		})
+	}
+}
+
+func TestApprovalService_DecideConflict(t *testing.T) {
+	// Arrange: another reviewer decided between our read and our write
+	ctx := auth.WithPrincipal(context.Background(), teamEditor)
+	mockURLRepo := new(MockURLRepository)
+	mockURLRepo.On("GetByID", mock.Anything, "123").Return(pendingURL(), nil)
+	mockURLRepo.On("Update", ctx, mock.AnythingOfType("*domain.URL")).Return(domain.ErrVersionConflict)
+	bus := events.NewBus()
+	var decided []events.ApprovalDecided
+	events.Subscribe(bus, func(ctx context.Context, e events.ApprovalDecided) {
+		decided = append(decided, e)
+	})
+
+	// Act
+	_, err := NewApprovalService(mockURLRepo, new(MockApprovalRepository), bus).Approve(ctx, "123", "")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrVersionConflict)
+	assert.Empty(t, decided)
+}
+
+func TestCreateShortURL_Approval(t *testing.T) {
+	tests := []struct {
+		name        string
+		principal   *auth.Principal
+		settings    *domain.WorkspaceSettings
+		settingsErr error
+		expectErr   bool
+		wantPending bool
+	}{
+		{name: "contributor in approval mode", principal: teamContributor, settings: &domain.WorkspaceSettings{Workspace: "team1", RequireApproval: true}, wantPending: true},
+		{name: "contributor without approval mode", principal: teamContributor, settings: &domain.WorkspaceSettings{Workspace: "team1"}},
+		{name: "settings never saved", principal: teamContributor, settingsErr: domain.ErrWorkspaceSettingsNotFound},
+		{name: "editor in approval mode", principal: teamEditor, settings: &domain.WorkspaceSettings{Workspace: "team1", RequireApproval: true}},
+		{name: "owner in approval mode", principal: teamOwner, settings: &domain.WorkspaceSettings{Workspace: "team1", RequireApproval: true}},
+		{name: "settings unreadable fail closed", principal: teamContributor, settingsErr: assert.AnError, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			mockURLRepo := new(MockURLRepository)
+			workspaces := new(MockWorkspaceSettingsRepository)
+			workspaces.On("Get", ctx, "team1").Return(tt.settings, tt.settingsErr)
+			mockURLRepo.On("ExistsCustomAlias", ctx, "launch").Return(false, nil)
+			mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
+			service := NewURLService(mockURLRepo, new(MockClickRepository)).WithWorkspaceSettings(workspaces)
+
+			var requested []events.ApprovalRequested
+			events.Subscribe(service.Events(), func(ctx context.Context, e events.ApprovalRequested) {
+				requested = append(requested, e)
+			})
+
+			// Act
+			url, err := service.CreateShortURL(ctx, "https://example.com/launch", "launch", "team1", 0)
+
+			// Assert
+			if tt.expectErr {
+				assert.ErrorIs(t, err, assert.AnError)
+				mockURLRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantPending {
+				assert.Equal(t, domain.ApprovalPending, url.Approval)
+				assert.Equal(t, "dana@example.com", url.SubmittedBy)
+				assert.False(t, url.IsActive, "a pending link must not redirect")
+				require.Len(t, requested, 1)
+				assert.Equal(t, "dana@example.com", requested[0].Actor)
+			} else {
+				assert.Equal(t, domain.ApprovalNone, url.Approval)
+				assert.True(t, url.IsActive)
+				assert.Empty(t, requested)
+			}
+		})
+	}
+}
+
+func TestAuthorizeManage_Roles(t *testing.T) {
+	live := &domain.URL{ID: "1", CreatedBy: "team1", IsActive: true}
+	ownPending := pendingURL()
+	othersPending := pendingURL()
+	othersPending.SubmittedBy = "frank@example.com"
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		url       *domain.URL
+		expectErr error
+	}{
+		{name: "owner manages live link", principal: teamOwner, url: live},
+		{name: "editor manages live link", principal: teamEditor, url: live},
+		{name: "contributor can't change live link", principal: teamContributor, url: live, expectErr: domain.ErrForbidden},
+		{name: "contributor manages own pending link", principal: teamContributor, url: ownPending},
+		{name: "contributor can't touch others' pending link", principal: teamContributor, url: othersPending, expectErr: domain.ErrForbidden},
+		{name: "viewer can't change live link", principal: teamViewer, url: live, expectErr: domain.ErrForbidden},
+		{name: "viewer can't touch pending link", principal: teamViewer, url: ownPending, expectErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+
+			// Act
+			err := authorizeManage(ctx, tt.url)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectErr)
+			assert.NoError(t, authorizeView(ctx, tt.url), "every member sees the workspace's stats")
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// ArchiveService moves links nobody uses anymore to the archive tier
+//
+// WHY ARCHIVE?
+// Most short links are clicked for a few weeks and then never again. They
+// still take up space in the urls table and its indexes, which every
+// redirect and every creation has to search. Moving them to urls_archive
+// keeps the hot table small.
+//
+// Archived links keep working: the first visit moves the link back
+// (rehydration, done by the URL repository) - it is only a bit slower.
+type ArchiveService struct {
+	repo      repository.ArchiveRepository
+	cache     Cache
+	after     time.Duration // Links unused for this long are archived
+	batchSize int
+	now       func() time.Time
+}
+
+// NewArchiveService creates a new archive service
+func NewArchiveService(repo repository.ArchiveRepository, cache Cache, after time.Duration) *ArchiveService {
+	return &ArchiveService{
+		repo:      repo,
+		cache:     cache,
+		after:     after,
+		batchSize: 500,
+		now:       time.Now,
+	}
+}
+
+// Run archives cold links every interval until ctx is canceled
+func (s *ArchiveService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ArchiveColdLinks(ctx); err != nil {
+			fmt.Printf("Warning: link archiving failed: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ArchiveColdLinks archives every link unused for the configured period
+// and returns how many were moved
+// Works in batches, so each statement (and its row locks) stays short
+func (s *ArchiveService) ArchiveColdLinks(ctx context.Context) (int, error) {
+	cutoff := s.now().Add(-s.after)
+
+	archived := 0
+	for ctx.Err() == nil {
+		urls, err := s.repo.ArchiveUnused(ctx, cutoff, s.batchSize)
+		if err != nil {
+			return archived, err
+		}
+		archived += len(urls)
+
+		// Out of the cache too - otherwise the cache would keep serving them
+		// and the hot tier would never actually shrink
+		for _, url := range urls {
+			s.invalidateCache(ctx, url)
+		}
+
+		if len(urls) < s.batchSize {
+			break
+		}
+	}
+	return archived, nil
+}
+
+// invalidateCache removes an archived URL from the cache
+func (s *ArchiveService) invalidateCache(ctx context.Context, url *domain.URL) {
+	keys := []string{url.ShortCode}
+	if url.CustomAlias != nil && *url.CustomAlias != url.ShortCode {
+		keys = append(keys, *url.CustomAlias)
+	}
+
+	for _, key := range keys {
+		if err := s.cache.DeleteURL(ctx, key); err != nil {
+			fmt.Printf("Warning: failed to invalidate cached URL: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockArchiveRepository is a mock implementation of ArchiveRepository
+type MockArchiveRepository struct {
+	mock.Mock
+}
+
+func (m *MockArchiveRepository) ArchiveUnused(ctx context.Context, cutoff time.Time, limit int) ([]*domain.URL, error) {
+	args := m.Called(ctx, cutoff, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URL), args.Error(1)
+}
+
+func TestArchiveColdLinks(t *testing.T) {
+	// Arrange: two batches, the second one not full
+	ctx := context.Background()
+	repo := new(MockArchiveRepository)
+	cache := new(MockCache)
+	svc := NewArchiveService(repo, cache, 180*24*time.Hour)
+	svc.batchSize = 2
+	now := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	cutoff := now.Add(-180 * 24 * time.Hour)
+
+	alias := "promo"
+	repo.On("ArchiveUnused", ctx, cutoff, 2).Return([]*domain.URL{
+		{ShortCode: "abc123"},
+		{ShortCode: "promo", CustomAlias: &alias},
+	}, nil).Once()
+	repo.On("ArchiveUnused", ctx, cutoff, 2).Return([]*domain.URL{{ShortCode: "xyz789"}}, nil).Once()
+	cache.On("DeleteURL", ctx, mock.Anything).Return(nil)
+
+	// Act
+	archived, err := svc.ArchiveColdLinks(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 3, archived)
+	repo.AssertExpectations(t)
+	cache.AssertNumberOfCalls(t, "DeleteURL", 3) // The alias equals the code: one key
+	cache.AssertCalled(t, "DeleteURL", ctx, "xyz789")
+}
+
+func TestArchiveColdLinks_StopsOnError(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockArchiveRepository)
+	svc := NewArchiveService(repo, new(MockCache), 30*24*time.Hour)
+	repo.On("ArchiveUnused", ctx, mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+
+	// Act
+	archived, err := svc.ArchiveColdLinks(ctx)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, 0, archived)
+	repo.AssertNumberOfCalls(t, "ArchiveUnused", 1)
+}
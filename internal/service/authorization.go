@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+)
+
+// OWNERSHIP RULES
+// The caller comes from the request context (set by the auth middleware), so
+// every entry point - HTTP, import jobs, future gRPC - gets the same checks.
+//
+//	           | view stats                 | delete / restore / purge
+//	admin      | every URL                  | every URL
+//	owner      | own URLs                   | own URLs
+//	anyone     | URLs created anonymously   | nothing
+//
+// URLs created without credentials have no real owner. Their stats stay
+// public (that's how the API always worked), but only admins can manage them.
+//
+// In a shared workspace the owner is the workspace, and members' roles
+// narrow what they may do with its links:
+//
+//	           | view stats | destinations      | create | manage                      | approve
+//	owner      | yes        | yes               | yes    | every link                  | yes
+//	editor     | yes        | yes               | yes    | every link                  | yes
+//	contributor| yes        | yes               | yes    | own links awaiting approval | no
+//	viewer     | yes        | not of sensitive  | no     | nothing                     | no
+//
+// Contributors can create links, but once one is live only editors and
+// owners change it - otherwise editing an approved link would skip approval.
+// Viewers are read-only, and links marked sensitive (domain.WithVisibility)
+// show them the stats without the destinations (see visibleTo).
+
+// authorizeView checks that the caller may see url's stats
+func authorizeView(ctx context.Context, url *domain.URL) error {
+	if url.CreatedBy == auth.Anonymous.ID {
+		return nil
+	}
+	principal := auth.FromContext(ctx)
+	if principal != auth.Anonymous && url.CreatedBy == principal.ID {
+		return nil // Every member of the workspace, whatever their role
+	}
+	return authorizeManage(ctx, url)
+}
+
+// authorizeManage checks that the caller may change or delete url
+func authorizeManage(ctx context.Context, url *domain.URL) error {
+	principal := auth.FromContext(ctx)
+	if principal.Admin {
+		return nil
+	}
+	if principal == auth.Anonymous || url.CreatedBy != principal.ID {
+		return domain.ErrForbidden
+	}
+	if err := authorizeWrite(ctx); err != nil {
+		return err
+	}
+	if principal.EffectiveRole() == auth.RoleContributor &&
+		(!url.AwaitsApproval() || url.SubmittedBy != principal.Member) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// authorizeApprove checks that the caller may approve or reject url
+// Admins, and the owners and editors of the link's workspace
+func authorizeApprove(ctx context.Context, url *domain.URL) error {
+	principal := auth.FromContext(ctx)
+	if principal.Admin {
+		return nil
+	}
+	if principal == auth.Anonymous || url.CreatedBy != principal.ID || !canReview(principal) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// canReview reports whether principal's role may approve links
+func canReview(principal *auth.Principal) bool {
+	role := principal.EffectiveRole()
+	return role == auth.RoleOwner || role == auth.RoleEditor
+}
+
+// authorizeWrite checks that the caller may create or change anything in
+// their workspace at all - everyone but viewers
+// Anonymous callers pass: what they may do is decided by the other checks
+func authorizeWrite(ctx context.Context) error {
+	if auth.FromContext(ctx).EffectiveRole() == auth.RoleViewer {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// canSeeDestinations reports whether the caller may see where url goes
+// Everyone who may view its stats, except viewers for sensitive links
+func canSeeDestinations(ctx context.Context, url *domain.URL) bool {
+	if url.Visibility != domain.VisibilitySensitive {
+		return true
+	}
+	principal := auth.FromContext(ctx)
+	return principal.Admin || principal.EffectiveRole() != auth.RoleViewer
+}
+
+// visibleTo returns url as the caller may see it: without its destinations
+// when they may not see them (a copy, url is left alone)
+// Call it on every link a read returns, after authorizeView
+func visibleTo(ctx context.Context, url *domain.URL) *domain.URL {
+	if canSeeDestinations(ctx, url) {
+		return url
+	}
+	return url.WithoutDestinations()
+}
+
+// authorizePage checks that the caller may change or delete page
+// Pages always have a real owner, so only that owner and admins qualify
+func authorizePage(ctx context.Context, page *domain.Page) error {
+	principal := auth.FromContext(ctx)
+	if principal.Admin {
+		return nil
+	}
+	if principal != auth.Anonymous && page.Owner == principal.ID {
+		return nil
+	}
+	return domain.ErrForbidden
+}
+
+// authorizeTemplate checks that the caller may use or change template
+// Templates are private: only their owner and admins qualify
+func authorizeTemplate(ctx context.Context, template *domain.LinkTemplate) error {
+	principal := auth.FromContext(ctx)
+	if principal.Admin {
+		return nil
+	}
+	if principal != auth.Anonymous && template.Owner == principal.ID {
+		return nil
+	}
+	return domain.ErrForbidden
+}
+
+// authorizeDomain checks that the caller may see or change a custom domain
+// Only the owner who registered it and admins qualify
+func authorizeDomain(ctx context.Context, d *domain.CustomDomain) error {
+	principal := auth.FromContext(ctx)
+	if principal.Admin {
+		return nil
+	}
+	if principal != auth.Anonymous && d.Owner == principal.ID {
+		return nil
+	}
+	return domain.ErrForbidden
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"url-shortener/internal/billing"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// ErrUnknownCustomer is returned for subscription events that can't be tied
+// to an owner yet. The webhook answers with an error so Stripe retries: the
+// checkout event that links the customer may simply not have arrived.
+var ErrUnknownCustomer = errors.New("stripe customer is not linked to an owner")
+
+// PortalSessions opens Stripe customer portal sessions
+// Implemented by billing.Client
+type PortalSessions interface {
+	CreatePortalSession(ctx context.Context, customerID, returnURL string) (string, error)
+}
+
+// BillingService keeps subscriptions in sync with Stripe
+//
+// HOW AN OWNER BECOMES "PRO":
+//  1. The pricing page starts a Stripe Checkout with client_reference_id set
+//     to the owner ID (or the owner in the subscription metadata)
+//  2. checkout.session.completed links the Stripe customer to the owner
+//  3. customer.subscription.* events store the plan and status
+//  4. QuotaService asks PlanFor on every link creation
+//
+// The service never calls Stripe to decide a plan - webhooks push every
+// change, so a Stripe outage can't block link creation.
+type BillingService struct {
+	subs         repository.SubscriptionRepository
+	portal       PortalSessions
+	productPlans map[string]string // Stripe product ID -> plan name
+	returnURL    string            // Where the portal sends customers back to
+}
+
+// NewBillingService creates a billing service
+// productPlans maps Stripe product IDs to plan names (see QuotaService)
+func NewBillingService(subs repository.SubscriptionRepository, portal PortalSessions, productPlans map[string]string, returnURL string) *BillingService {
+	return &BillingService{
+		subs:         subs,
+		portal:       portal,
+		productPlans: productPlans,
+		returnURL:    returnURL,
+	}
+}
+
+// HandleEvent applies a verified Stripe webhook event
+// Unknown event types are ignored, so new Stripe events never break the webhook
+func (s *BillingService) HandleEvent(ctx context.Context, event *billing.Event) error {
+	switch event.Type {
+	case billing.EventCheckoutCompleted:
+		var session billing.CheckoutSession
+		if err := event.DecodeObject(&session); err != nil {
+			return err
+		}
+		if session.ClientReferenceID == "" || session.Customer == "" {
+			fmt.Printf("Warning: checkout %s has no owner reference, ignoring\n", session.ID)
+			return nil
+		}
+		return s.subs.LinkCustomer(ctx, session.ClientReferenceID, session.Customer)
+
+	case billing.EventSubscriptionCreated, billing.EventSubscriptionUpdated, billing.EventSubscriptionDeleted:
+		var sub billing.Subscription
+		if err := event.DecodeObject(&sub); err != nil {
+			return err
+		}
+		return s.applySubscription(ctx, event, &sub)
+
+	default:
+		return nil
+	}
+}
+
+// applySubscription stores the plan and status of a Stripe subscription
+func (s *BillingService) applySubscription(ctx context.Context, event *billing.Event, sub *billing.Subscription) error {
+	owner := sub.Metadata["owner"]
+	if owner == "" {
+		existing, err := s.subs.GetByCustomer(ctx, sub.Customer)
+		if errors.Is(err, domain.ErrSubscriptionNotFound) {
+			return fmt.Errorf("%w: %s", ErrUnknownCustomer, sub.Customer)
+		}
+		if err != nil {
+			return err
+		}
+		owner = existing.Owner
+	}
+
+	status := sub.Status
+	if event.Type == billing.EventSubscriptionDeleted {
+		status = "canceled"
+	}
+
+	applied, err := s.subs.Apply(ctx, &domain.Subscription{
+		Owner:            owner,
+		CustomerID:       sub.Customer,
+		SubscriptionID:   sub.ID,
+		Plan:             s.planForProducts(sub.Products()),
+		Status:           status,
+		CurrentPeriodEnd: sub.PeriodEnd(),
+		LastEventAt:      event.CreatedAt(),
+	})
+	if err != nil {
+		return err
+	}
+	if !applied {
+		fmt.Printf("Warning: ignoring stale stripe event %s for %s\n", event.ID, owner)
+	}
+	return nil
+}
+
+// planForProducts returns the plan of the first mapped product
+// Unmapped products leave the owner on the default plan
+func (s *BillingService) planForProducts(products []string) string {
+	for _, product := range products {
+		if plan, ok := s.productPlans[product]; ok {
+			return plan
+		}
+	}
+	fmt.Printf("Warning: no plan configured for stripe products %v\n", products)
+	return ""
+}
+
+// PlanFor returns the plan the owner pays for ("" = no active subscription)
+// Implements PlanResolver for QuotaService
+func (s *BillingService) PlanFor(ctx context.Context, owner string) (string, error) {
+	sub, err := s.subs.GetByOwner(ctx, owner)
+	if errors.Is(err, domain.ErrSubscriptionNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !sub.Active() {
+		return "", nil
+	}
+	return sub.Plan, nil
+}
+
+// PortalURL opens a customer portal session for owner
+// Returns domain.ErrSubscriptionNotFound if the owner never checked out
+func (s *BillingService) PortalURL(ctx context.Context, owner string) (string, error) {
+	sub, err := s.subs.GetByOwner(ctx, owner)
+	if err != nil {
+		return "", err
+	}
+	return s.portal.CreatePortalSession(ctx, sub.CustomerID, s.returnURL)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"url-shortener/internal/billing"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSubscriptionRepository is a mock implementation of SubscriptionRepository
+type MockSubscriptionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSubscriptionRepository) LinkCustomer(ctx context.Context, owner, customerID string) error {
+	args := m.Called(ctx, owner, customerID)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) Apply(ctx context.Context, sub *domain.Subscription) (bool, error) {
+	args := m.Called(ctx, sub)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) GetByOwner(ctx context.Context, owner string) (*domain.Subscription, error) {
+	args := m.Called(ctx, owner)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Subscription), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) GetByCustomer(ctx context.Context, customerID string) (*domain.Subscription, error) {
+	args := m.Called(ctx, customerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Subscription), args.Error(1)
+}
+
+// stripeEvent builds a webhook event around object
+func stripeEvent(t *testing.T, eventType string, object any) *billing.Event {
+	data, err := json.Marshal(object)
+	require.NoError(t, err)
+
+	event := &billing.Event{ID: "evt_1", Type: eventType, Created: 1700000000}
+	event.Data.Object = data
+	return event
+}
+
+func newTestBillingService() (*BillingService, *MockSubscriptionRepository) {
+	subs := new(MockSubscriptionRepository)
+	return NewBillingService(subs, nil, map[string]string{"prod_pro": domain.PlanPro}, ""), subs
+}
+
+func TestBillingService_HandleEvent(t *testing.T) {
+	ctx := context.Background()
+	subscription := func(status string, metadata map[string]string) map[string]any {
+		return map[string]any{
+			"id":       "sub_1",
+			"customer": "cus_1",
+			"status":   status,
+			"metadata": metadata,
+			"items":    map[string]any{"data": []any{map[string]any{"price": map[string]any{"product": "prod_pro"}}}},
+		}
+	}
+
+	t.Run("checkout links the customer to the owner", func(t *testing.T) {
+		billingService, subs := newTestBillingService()
+		subs.On("LinkCustomer", ctx, "alice", "cus_1").Return(nil)
+
+		err := billingService.HandleEvent(ctx, stripeEvent(t, billing.EventCheckoutCompleted, map[string]any{
+			"customer":            "cus_1",
+			"client_reference_id": "alice",
+		}))
+
+		require.NoError(t, err)
+		subs.AssertExpectations(t)
+	})
+
+	t.Run("subscription maps the product to a plan", func(t *testing.T) {
+		billingService, subs := newTestBillingService()
+		subs.On("GetByCustomer", ctx, "cus_1").Return(&domain.Subscription{Owner: "alice", CustomerID: "cus_1"}, nil)
+		subs.On("Apply", ctx, mock.AnythingOfType("*domain.Subscription")).Return(true, nil)
+
+		err := billingService.HandleEvent(ctx, stripeEvent(t, billing.EventSubscriptionCreated, subscription("active", nil)))
+
+		require.NoError(t, err)
+		applied := subs.Calls[1].Arguments.Get(1).(*domain.Subscription)
+		assert.Equal(t, "alice", applied.Owner)
+		assert.Equal(t, domain.PlanPro, applied.Plan)
+		assert.Equal(t, "active", applied.Status)
+		assert.Equal(t, time.Unix(1700000000, 0).UTC(), applied.LastEventAt)
+	})
+
+	t.Run("owner from metadata skips the customer lookup", func(t *testing.T) {
+		billingService, subs := newTestBillingService()
+		subs.On("Apply", ctx, mock.MatchedBy(func(sub *domain.Subscription) bool {
+			return sub.Owner == "bob" && sub.Status == "canceled"
+		})).Return(true, nil)
+
+		err := billingService.HandleEvent(ctx, stripeEvent(t, billing.EventSubscriptionDeleted, subscription("active", map[string]string{"owner": "bob"})))
+
+		require.NoError(t, err)
+		subs.AssertNotCalled(t, "GetByCustomer", mock.Anything, mock.Anything)
+		subs.AssertExpectations(t)
+	})
+
+	t.Run("unknown customer asks Stripe to retry", func(t *testing.T) {
+		billingService, subs := newTestBillingService()
+		subs.On("GetByCustomer", ctx, "cus_1").Return(nil, domain.ErrSubscriptionNotFound)
+
+		err := billingService.HandleEvent(ctx, stripeEvent(t, billing.EventSubscriptionUpdated, subscription("active", nil)))
+
+		assert.ErrorIs(t, err, ErrUnknownCustomer)
+	})
+
+	t.Run("other events are ignored", func(t *testing.T) {
+		billingService, subs := newTestBillingService()
+
+		err := billingService.HandleEvent(ctx, stripeEvent(t, "invoice.paid", map[string]any{}))
+
+		require.NoError(t, err)
+		assert.Empty(t, subs.Calls)
+	})
+}
+
+func TestBillingService_PlanFor(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		sub      *domain.Subscription
+		expected string
+	}{
+		{name: "active", sub: &domain.Subscription{Plan: domain.PlanPro, Status: "active"}, expected: domain.PlanPro},
+		{name: "past due keeps the plan", sub: &domain.Subscription{Plan: domain.PlanPro, Status: "past_due"}, expected: domain.PlanPro},
+		{name: "canceled", sub: &domain.Subscription{Plan: domain.PlanPro, Status: "canceled"}, expected: ""},
+		{name: "never subscribed", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			billingService, subs := newTestBillingService()
+			if tt.sub != nil {
+				subs.On("GetByOwner", ctx, "alice").Return(tt.sub, nil)
+			} else {
+				subs.On("GetByOwner", ctx, "alice").Return(nil, domain.ErrSubscriptionNotFound)
+			}
+
+			plan, err := billingService.PlanFor(ctx, "alice")
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, plan)
+		})
+	}
+}
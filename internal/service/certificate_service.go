@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// certificateFailureTimeout bounds recording one failed issuance
+const certificateFailureTimeout = 5 * time.Second
+
+// CertificatePolicy decides which hosts may get a certificate
+// Implemented by CustomDomainService
+type CertificatePolicy interface {
+	AllowsCertificate(host string) bool
+}
+
+// CertificateService issues and renews TLS certificates on demand
+//
+// HOW AUTOMATIC TLS WORKS:
+// The first HTTPS request for a host asks an ACME CA (Let's Encrypt by
+// default) for a certificate. The CA checks that the host points at us:
+// it fetches a token over HTTP (HTTP-01, served by HTTPHandler on the
+// plain port) or over TLS (TLS-ALPN-01, answered by TLSConfig). The
+// certificate is then served until it is close to expiry and renewed.
+//
+// WHY ONLY VERIFIED DOMAINS?
+// Anyone can point a domain at us. If every TLS handshake could ask for a
+// certificate, strangers could burn our CA rate limits. CertificatePolicy
+// only lets our own hosts and verified custom domains through.
+//
+// WHY STORE CERTIFICATES IN POSTGRES?
+// Every replica must serve the same certificate, and an HTTP-01 token the
+// CA asks for may land on a replica that didn't request it. The service is
+// the autocert.Cache: keys, certificates and tokens are shared through
+// repository.CertificateRepository.
+type CertificateService struct {
+	repo    repository.CertificateRepository
+	policy  CertificatePolicy
+	manager *autocert.Manager
+	now     func() time.Time
+}
+
+// NewCertificateService creates a certificate service using Let's Encrypt
+func NewCertificateService(repo repository.CertificateRepository, policy CertificatePolicy) *CertificateService {
+	s := &CertificateService{
+		repo:   repo,
+		policy: policy,
+		now:    time.Now,
+	}
+	s.manager = &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      s,
+		HostPolicy: s.hostPolicy,
+	}
+	return s
+}
+
+// WithACME sets the CA directory ("" = Let's Encrypt) and the contact
+// address for expiry notices and account problems
+func (s *CertificateService) WithACME(directoryURL, email string) *CertificateService {
+	if directoryURL != "" {
+		s.manager.Client = &acme.Client{DirectoryURL: directoryURL}
+	}
+	s.manager.Email = email
+	return s
+}
+
+// WithRenewBefore sets how long before expiry certificates are renewed
+func (s *CertificateService) WithRenewBefore(d time.Duration) *CertificateService {
+	s.manager.RenewBefore = d
+	return s
+}
+
+// TLSConfig is the configuration of the HTTPS listener
+func (s *CertificateService) TLSConfig() *tls.Config {
+	config := s.manager.TLSConfig()
+	config.GetCertificate = s.GetCertificate
+	return config
+}
+
+// HTTPHandler answers HTTP-01 challenges and passes everything else on
+func (s *CertificateService) HTTPHandler(next http.Handler) http.Handler {
+	return s.manager.HTTPHandler(next)
+}
+
+// GetCertificate returns the certificate of a TLS handshake, issuing it
+// when needed
+// Failures for hosts we issue certificates for are stored, so the domains
+// API can tell the owner why HTTPS doesn't work yet.
+func (s *CertificateService) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert, err := s.manager.GetCertificate(hello)
+	if err == nil {
+		return cert, nil
+	}
+
+	host := normalizeHost(hello.ServerName)
+	if host == "" || slices.Contains(hello.SupportedProtos, acme.ALPNProto) || !s.policy.AllowsCertificate(host) {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), certificateFailureTimeout)
+	defer cancel()
+	if recordErr := s.repo.RecordFailure(ctx, host, err.Error(), s.now()); recordErr != nil {
+		fmt.Printf("Warning: failed to record certificate failure for %s: %v\n", host, recordErr)
+	}
+	return nil, err
+}
+
+// Statuses returns the certificate of every host; hosts without one get
+// an empty Certificate (status none)
+func (s *CertificateService) Statuses(ctx context.Context, hosts []string) (map[string]*domain.Certificate, error) {
+	statuses, err := s.repo.Statuses(ctx, hosts)
+	if err != nil {
+		return nil, err
+	}
+	for _, host := range hosts {
+		if statuses[host] == nil {
+			statuses[host] = &domain.Certificate{Host: host}
+		}
+	}
+	return statuses, nil
+}
+
+// Get implements autocert.Cache
+func (s *CertificateService) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.repo.Get(ctx, key)
+	if errors.Is(err, domain.ErrCertificateNotFound) {
+		return nil, autocert.ErrCacheMiss
+	}
+	return data, err
+}
+
+// Put implements autocert.Cache
+// Certificates are stored with their expiry for the domains API
+func (s *CertificateService) Put(ctx context.Context, key string, data []byte) error {
+	return s.repo.Put(ctx, key, data, certificateExpiry(data))
+}
+
+// Delete implements autocert.Cache
+func (s *CertificateService) Delete(ctx context.Context, key string) error {
+	return s.repo.Delete(ctx, key)
+}
+
+// hostPolicy is the autocert.HostPolicy: only allowed hosts get certificates
+func (s *CertificateService) hostPolicy(_ context.Context, host string) error {
+	if !s.policy.AllowsCertificate(host) {
+		return fmt.Errorf("no certificate for %s: not a trusted or verified domain", strings.ToLower(host))
+	}
+	return nil
+}
+
+// certificateExpiry returns the expiry of the leaf certificate in an
+// autocert cache entry (a private key, then the chain, in PEM), or nil if
+// the entry holds no certificate (the account key, an HTTP-01 token)
+func certificateExpiry(data []byte) *time.Time {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		leaf, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil
+		}
+		return &leaf.NotAfter
+	}
+}
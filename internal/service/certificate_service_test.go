@@ -0,0 +1,176 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// MockCertificateRepository is a mock implementation of CertificateRepository
+type MockCertificateRepository struct {
+	mock.Mock
+}
+
+func (m *MockCertificateRepository) Get(ctx context.Context, name string) ([]byte, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockCertificateRepository) Put(ctx context.Context, name string, data []byte, notAfter *time.Time) error {
+	args := m.Called(ctx, name, data, notAfter)
+	return args.Error(0)
+}
+
+func (m *MockCertificateRepository) Delete(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *MockCertificateRepository) RecordFailure(ctx context.Context, host, message string, at time.Time) error {
+	args := m.Called(ctx, host, message, at)
+	return args.Error(0)
+}
+
+func (m *MockCertificateRepository) Statuses(ctx context.Context, hosts []string) (map[string]*domain.Certificate, error) {
+	args := m.Called(ctx, hosts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*domain.Certificate), args.Error(1)
+}
+
+// allowHosts is a CertificatePolicy for a fixed list of hosts
+type allowHosts map[string]bool
+
+func (a allowHosts) AllowsCertificate(host string) bool { return a[host] }
+
+// autocertEntry builds a cache entry like autocert stores it: the private
+// key, then the certificate, in PEM
+func autocertEntry(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "go.example.com"},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, pem.Encode(&buf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	require.NoError(t, pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	return buf.Bytes()
+}
+
+func TestCertificateService_CacheStoresExpiry(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockCertificateRepository)
+	service := NewCertificateService(repo, allowHosts{})
+	notAfter := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	entry := autocertEntry(t, notAfter)
+
+	repo.On("Put", ctx, "go.example.com", entry, mock.MatchedBy(func(at *time.Time) bool {
+		return at != nil && at.Equal(notAfter)
+	})).Return(nil)
+	repo.On("Put", ctx, "acme_account+key", []byte("not a certificate"), (*time.Time)(nil)).Return(nil)
+	repo.On("Get", ctx, "unknown.example.com").Return(nil, domain.ErrCertificateNotFound)
+
+	// Act
+	certErr := service.Put(ctx, "go.example.com", entry)
+	keyErr := service.Put(ctx, "acme_account+key", []byte("not a certificate"))
+	_, getErr := service.Get(ctx, "unknown.example.com")
+
+	// Assert
+	assert.NoError(t, certErr)
+	assert.NoError(t, keyErr)
+	assert.ErrorIs(t, getErr, autocert.ErrCacheMiss, "autocert only issues a certificate on a cache miss")
+	repo.AssertExpectations(t)
+}
+
+func TestCertificateService_GetCertificate(t *testing.T) {
+	// A CA that refuses everything: issuance fails without leaving the test
+	ca := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer ca.Close()
+
+	tests := []struct {
+		name          string
+		host          string
+		expectFailure bool
+	}{
+		{name: "verified domain records the failure", host: "go.example.com", expectFailure: true},
+		{name: "unknown host is refused without asking the CA", host: "stranger.example.org"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := new(MockCertificateRepository)
+			service := NewCertificateService(repo, allowHosts{"go.example.com": true}).WithACME(ca.URL, "")
+			repo.On("Get", mock.Anything, mock.Anything).Return(nil, domain.ErrCertificateNotFound).Maybe()
+			repo.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+			repo.On("RecordFailure", mock.Anything, "go.example.com", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			// Act
+			cert, err := service.GetCertificate(&tls.ClientHelloInfo{ServerName: tt.host})
+
+			// Assert
+			require.Error(t, err)
+			assert.Nil(t, cert)
+			if tt.expectFailure {
+				repo.AssertCalled(t, "RecordFailure", mock.Anything, "go.example.com", err.Error(), mock.Anything)
+			} else {
+				repo.AssertNotCalled(t, "RecordFailure", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				repo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestCertificateService_Statuses(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockCertificateRepository)
+	service := NewCertificateService(repo, allowHosts{})
+	notAfter := time.Now().Add(60 * 24 * time.Hour)
+	hosts := []string{"go.example.com", "new.example.com"}
+	repo.On("Statuses", ctx, hosts).Return(map[string]*domain.Certificate{
+		"go.example.com": {Host: "go.example.com", NotAfter: &notAfter},
+	}, nil)
+
+	// Act
+	statuses, err := service.Statuses(ctx, hosts)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.CertificateIssued, statuses["go.example.com"].Status(time.Now()))
+	assert.Equal(t, domain.CertificateNone, statuses["new.example.com"].Status(time.Now()))
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/repository"
+)
+
+// ClickFoldService moves striped click counters into the links
+//
+// WHY FOLD?
+// Striped counters spread the clicks of a link over several rows (see
+// postgres.NewStripedURLRepository). Lookups add the rows up, but lists,
+// exports and rollups read urls.clicks alone. Folding every few seconds
+// keeps those close, and keeps url_click_stripes down to recent clicks.
+type ClickFoldService struct {
+	repo      repository.ClickStripeRepository
+	batchSize int
+}
+
+// NewClickFoldService creates a new click fold service
+func NewClickFoldService(repo repository.ClickStripeRepository) *ClickFoldService {
+	return &ClickFoldService{
+		repo:      repo,
+		batchSize: 1000,
+	}
+}
+
+// Run folds the stripes every interval until ctx is canceled
+func (s *ClickFoldService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Fold(ctx); err != nil {
+			fmt.Printf("Warning: folding click counters failed: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Fold folds every stripe and returns how many were folded
+// Works in batches, so each statement (and its row locks) stays short
+func (s *ClickFoldService) Fold(ctx context.Context) (int, error) {
+	folded := 0
+	for ctx.Err() == nil {
+		n, err := s.repo.FoldClickStripes(ctx, s.batchSize)
+		if err != nil {
+			return folded, err
+		}
+		folded += n
+
+		if n < s.batchSize {
+			break
+		}
+	}
+	return folded, nil
+}
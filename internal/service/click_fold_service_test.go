@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockClickStripeRepository is a mock implementation of ClickStripeRepository
+type MockClickStripeRepository struct {
+	mock.Mock
+}
+
+func (m *MockClickStripeRepository) FoldClickStripes(ctx context.Context, limit int) (int, error) {
+	args := m.Called(ctx, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func TestClickFoldService_Fold(t *testing.T) {
+	// Arrange: two full batches, then a short one
+	ctx := context.Background()
+	repo := new(MockClickStripeRepository)
+	svc := NewClickFoldService(repo)
+	svc.batchSize = 100
+	repo.On("FoldClickStripes", ctx, 100).Return(100, nil).Twice()
+	repo.On("FoldClickStripes", ctx, 100).Return(7, nil).Once()
+
+	// Act
+	folded, err := svc.Fold(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 207, folded)
+	repo.AssertExpectations(t)
+}
+
+func TestClickFoldService_StopsOnError(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockClickStripeRepository)
+	svc := NewClickFoldService(repo)
+	repo.On("FoldClickStripes", ctx, mock.Anything).Return(0, errors.New("connection refused"))
+
+	// Act
+	folded, err := svc.Fold(ctx)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, 0, folded)
+	repo.AssertNumberOfCalls(t, "FoldClickStripes", 1)
+}
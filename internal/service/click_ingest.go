@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+)
+
+// ClickRecorder records one click
+// Implemented by URLService
+type ClickRecorder interface {
+	RecordClick(ctx context.Context, click domain.ClickContext) error
+}
+
+// EventClaims remembers which click events were already ingested
+// Implemented by redis.EventClaims
+type EventClaims interface {
+	// Claim returns true for the first call with key within ttl
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Release forgets key, so the event can be sent again
+	Release(ctx context.Context, key string) error
+}
+
+// ClickIngestService records clicks reported by external edge redirectors
+//
+// WHY?
+// A redirector at the edge (e.g. a Cloudflare Worker) answers the 302 itself,
+// so the click never reaches us. It reports the clicks afterwards, in batches,
+// and the clicks go through the same RecordClick as our own redirects: same
+// counter, same analytics settings, same DNT handling.
+//
+// IDEMPOTENCY:
+// Edge networks retry - a batch that timed out may have been recorded. Every
+// event carries an ID; the first time an ID is seen it is claimed, and a
+// repeat within the TTL is reported as a duplicate instead of counted twice.
+// A click that fails to record releases its claim, so the retry goes through.
+type ClickIngestService struct {
+	recorder ClickRecorder
+	claims   EventClaims
+	ttl      time.Duration
+}
+
+// NewClickIngestService creates a click ingest service
+// ttl is how long event IDs are remembered; reporters must not retry later
+func NewClickIngestService(recorder ClickRecorder, claims EventClaims, ttl time.Duration) *ClickIngestService {
+	return &ClickIngestService{recorder: recorder, claims: claims, ttl: ttl}
+}
+
+// Ingest records a batch of click events, each on its own
+// One bad event doesn't fail the batch: every event gets its outcome, in order
+func (s *ClickIngestService) Ingest(ctx context.Context, clickEvents []domain.ClickEvent) []domain.IngestOutcome {
+	reporter := auth.FromContext(ctx).ID
+	outcomes := make([]domain.IngestOutcome, 0, len(clickEvents))
+	for _, event := range clickEvents {
+		outcomes = append(outcomes, s.ingest(ctx, reporter, event))
+	}
+	return outcomes
+}
+
+func (s *ClickIngestService) ingest(ctx context.Context, reporter string, event domain.ClickEvent) domain.IngestOutcome {
+	outcome := domain.IngestOutcome{ID: event.ID}
+	key := eventKey(reporter, event.ID)
+
+	first, err := s.claims.Claim(ctx, key, s.ttl)
+	if err != nil {
+		// Without the claim we can't tell a retry from a new click - don't guess
+		outcome.Status = domain.IngestFailed
+		outcome.Error = "idempotency check unavailable"
+		fmt.Printf("Warning: failed to claim click event: %v\n", err)
+		return outcome
+	}
+	if !first {
+		outcome.Status = domain.IngestDuplicate
+		return outcome
+	}
+
+	if err := s.recorder.RecordClick(ctx, event.Click); err != nil {
+		if releaseErr := s.claims.Release(ctx, key); releaseErr != nil {
+			fmt.Printf("Warning: failed to release click event: %v\n", releaseErr)
+		}
+		outcome.Status = domain.IngestFailed
+		outcome.Error = "failed to record click"
+		if errors.Is(err, domain.ErrURLNotFound) {
+			outcome.Status = domain.IngestRejected
+			outcome.Error = "unknown short code"
+		}
+		return outcome
+	}
+
+	outcome.Status = domain.IngestAccepted
+	return outcome
+}
+
+// eventKey scopes event IDs to the reporter: two edge deployments picking
+// the same ID don't swallow each other's clicks
+func eventKey(reporter, id string) string {
+	sum := sha256.Sum256([]byte(reporter + "\x00" + id))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockClickRecorder is a mock implementation of ClickRecorder
+type MockClickRecorder struct {
+	mock.Mock
+}
+
+func (m *MockClickRecorder) RecordClick(ctx context.Context, click domain.ClickContext) error {
+	args := m.Called(ctx, click)
+	return args.Error(0)
+}
+
+// memoryClaims is an in-memory EventClaims
+type memoryClaims struct {
+	mu      sync.Mutex
+	claimed map[string]bool
+	err     error // Returned by Claim when set
+}
+
+func newMemoryClaims() *memoryClaims {
+	return &memoryClaims{claimed: map[string]bool{}}
+}
+
+func (c *memoryClaims) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return false, c.err
+	}
+	if c.claimed[key] {
+		return false, nil
+	}
+	c.claimed[key] = true
+	return true, nil
+}
+
+func (c *memoryClaims) Release(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.claimed, key)
+	return nil
+}
+
+func TestClickIngest_Ingest(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "edge", Admin: true})
+	recorder := new(MockClickRecorder)
+	service := NewClickIngestService(recorder, newMemoryClaims(), time.Hour)
+
+	clickedAt := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+	good := domain.ClickContext{ShortCode: "abc123", ClickedAt: clickedAt, Region: "fra"}
+	unknown := domain.ClickContext{ShortCode: "gone", ClickedAt: clickedAt}
+	flaky := domain.ClickContext{ShortCode: "flaky1", ClickedAt: clickedAt}
+	recorder.On("RecordClick", ctx, good).Return(nil).Once()
+	recorder.On("RecordClick", ctx, unknown).Return(fmt.Errorf("URL not found: %w", domain.ErrURLNotFound))
+	recorder.On("RecordClick", ctx, flaky).Return(assert.AnError)
+
+	// Act
+	outcomes := service.Ingest(ctx, []domain.ClickEvent{
+		{ID: "e1", Click: good},
+		{ID: "e1", Click: good}, // Delivered twice in the same batch
+		{ID: "e2", Click: unknown},
+		{ID: "e3", Click: flaky},
+	})
+
+	// Assert
+	assert.Equal(t, []domain.IngestOutcome{
+		{ID: "e1", Status: domain.IngestAccepted},
+		{ID: "e1", Status: domain.IngestDuplicate},
+		{ID: "e2", Status: domain.IngestRejected, Error: "unknown short code"},
+		{ID: "e3", Status: domain.IngestFailed, Error: "failed to record click"},
+	}, outcomes)
+	recorder.AssertExpectations(t)
+}
+
+func TestClickIngest_RetryAfterFailure(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "edge", Admin: true})
+	recorder := new(MockClickRecorder)
+	service := NewClickIngestService(recorder, newMemoryClaims(), time.Hour)
+
+	click := domain.ClickContext{ShortCode: "abc123"}
+	recorder.On("RecordClick", ctx, click).Return(assert.AnError).Once()
+	recorder.On("RecordClick", ctx, click).Return(nil).Once()
+
+	// Act
+	first := service.Ingest(ctx, []domain.ClickEvent{{ID: "e1", Click: click}})
+	retry := service.Ingest(ctx, []domain.ClickEvent{{ID: "e1", Click: click}})
+	again := service.Ingest(ctx, []domain.ClickEvent{{ID: "e1", Click: click}})
+
+	// Assert - the failure released the ID, so the retry counted; the next repeat didn't
+	assert.Equal(t, domain.IngestFailed, first[0].Status)
+	assert.Equal(t, domain.IngestAccepted, retry[0].Status)
+	assert.Equal(t, domain.IngestDuplicate, again[0].Status)
+	recorder.AssertExpectations(t)
+}
+
+func TestClickIngest_IDsAreScopedToTheReporter(t *testing.T) {
+	// Arrange
+	recorder := new(MockClickRecorder)
+	service := NewClickIngestService(recorder, newMemoryClaims(), time.Hour)
+	recorder.On("RecordClick", mock.Anything, mock.Anything).Return(nil)
+
+	edgeA := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "edge-a", Admin: true})
+	edgeB := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "edge-b", Admin: true})
+	event := domain.ClickEvent{ID: "1", Click: domain.ClickContext{ShortCode: "abc123"}}
+
+	// Act
+	a := service.Ingest(edgeA, []domain.ClickEvent{event})
+	b := service.Ingest(edgeB, []domain.ClickEvent{event})
+
+	// Assert
+	assert.Equal(t, domain.IngestAccepted, a[0].Status)
+	assert.Equal(t, domain.IngestAccepted, b[0].Status)
+	recorder.AssertNumberOfCalls(t, "RecordClick", 2)
+}
+
+func TestClickIngest_ClaimsUnavailable(t *testing.T) {
+	// Arrange
+	recorder := new(MockClickRecorder)
+	claims := newMemoryClaims()
+	claims.err = assert.AnError
+	service := NewClickIngestService(recorder, claims, time.Hour)
+
+	// Act
+	outcomes := service.Ingest(context.Background(), []domain.ClickEvent{{ID: "e1", Click: domain.ClickContext{ShortCode: "abc123"}}})
+
+	// Assert - not recorded: it might be a retry of a click we already have
+	assert.Equal(t, domain.IngestFailed, outcomes[0].Status)
+	recorder.AssertNotCalled(t, "RecordClick", mock.Anything, mock.Anything)
+}
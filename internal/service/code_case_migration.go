@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// CodeCaseMigration lowercases the codes of links created while short codes
+// were case-sensitive
+//
+// It is the MIGRATION PATH to SHORT_CODE_CASE=insensitive: switch the setting
+// first (old links keep working - lookups try the exact code first), then
+// run this once. Afterwards "ABC123" and "abc123" are the same link.
+// It's safe to stop and rerun: lowercased links are not read again.
+//
+// Links it can't lowercase are reported, not changed:
+//   - conflicts: another link already uses the lowercase code
+//   - moves: with sharding, the lowercase code belongs on another database
+type CodeCaseMigration struct {
+	repo      repository.CodeCaseRepository
+	batchSize int
+	route     func(code string) int // Optional: shard of a code (see shard.Map.For)
+}
+
+// NewCodeCaseMigration creates a migration job
+func NewCodeCaseMigration(repo repository.CodeCaseRepository, batchSize int) *CodeCaseMigration {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	return &CodeCaseMigration{repo: repo, batchSize: batchSize}
+}
+
+// WithRouting skips links whose lowercase codes would route to another shard
+// Lowercasing "Kx9" to "kx9" changes its first character, and the first
+// character picks the shard: the row would be on the wrong database
+func (m *CodeCaseMigration) WithRouting(route func(code string) int) *CodeCaseMigration {
+	m.route = route
+	return m
+}
+
+// Run lowercases every mixed-case link (apply false = dry run: only report)
+// progress (optional) is called after each batch with the report so far
+func (m *CodeCaseMigration) Run(ctx context.Context, apply bool, progress func(*domain.CodeCaseReport)) (*domain.CodeCaseReport, error) {
+	report := &domain.CodeCaseReport{}
+	afterID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		urls, err := m.repo.NextMixedCase(ctx, afterID, m.batchSize)
+		if err != nil {
+			return report, err
+		}
+		if len(urls) == 0 {
+			return report, nil
+		}
+
+		for _, url := range urls {
+			report.Scanned++
+			if m.moves(url) {
+				report.Moved = append(report.Moved, url.ShortCode)
+				continue
+			}
+
+			free, err := m.repo.LowercaseCodes(ctx, url.ID, apply)
+			if err != nil {
+				return report, err
+			}
+			if !free {
+				report.Conflicts = append(report.Conflicts, url.ShortCode)
+				continue
+			}
+			report.Lowercased++
+		}
+
+		afterID = urls[len(urls)-1].ID
+		if progress != nil {
+			progress(report)
+		}
+	}
+}
+
+// moves reports whether lowercasing would put one of the link's codes on another shard
+func (m *CodeCaseMigration) moves(url *domain.URL) bool {
+	if m.route == nil {
+		return false
+	}
+	codes := []string{url.ShortCode}
+	if url.CustomAlias != nil {
+		codes = append(codes, *url.CustomAlias)
+	}
+	for _, code := range codes {
+		if m.route(code) != m.route(strings.ToLower(code)) {
+			return true
+		}
+	}
+	return false
+}
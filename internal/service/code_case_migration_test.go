@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockCodeCaseRepository is a mock implementation of CodeCaseRepository
+type MockCodeCaseRepository struct {
+	mock.Mock
+}
+
+func (m *MockCodeCaseRepository) NextMixedCase(ctx context.Context, afterID string, limit int) ([]*domain.URL, error) {
+	args := m.Called(ctx, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URL), args.Error(1)
+}
+
+func (m *MockCodeCaseRepository) LowercaseCodes(ctx context.Context, id string, apply bool) (bool, error) {
+	args := m.Called(ctx, id, apply)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestCodeCaseMigration_Run(t *testing.T) {
+	// Arrange: one batch of 2, then one link whose lowercase code is taken
+	ctx := context.Background()
+	repo := new(MockCodeCaseRepository)
+	migration := NewCodeCaseMigration(repo, 2)
+	alias := "Promo"
+
+	repo.On("NextMixedCase", ctx, "", 2).Return([]*domain.URL{
+		{ID: "1", ShortCode: "AbC123"},
+		{ID: "2", ShortCode: "Promo", CustomAlias: &alias},
+	}, nil)
+	repo.On("NextMixedCase", ctx, "2", 2).Return([]*domain.URL{{ID: "3", ShortCode: "XYZ"}}, nil)
+	repo.On("NextMixedCase", ctx, "3", 2).Return([]*domain.URL{}, nil)
+	repo.On("LowercaseCodes", ctx, "1", true).Return(true, nil)
+	repo.On("LowercaseCodes", ctx, "2", true).Return(true, nil)
+	repo.On("LowercaseCodes", ctx, "3", true).Return(false, nil)
+
+	var batches int
+
+	// Act
+	report, err := migration.Run(ctx, true, func(*domain.CodeCaseReport) { batches++ })
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Scanned)
+	assert.Equal(t, 2, report.Lowercased)
+	assert.Equal(t, []string{"XYZ"}, report.Conflicts)
+	assert.Equal(t, 2, batches)
+	repo.AssertExpectations(t)
+}
+
+func TestCodeCaseMigration_DryRun(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockCodeCaseRepository)
+	migration := NewCodeCaseMigration(repo, 10)
+	repo.On("NextMixedCase", ctx, "", 10).Return([]*domain.URL{{ID: "1", ShortCode: "AbC123"}}, nil)
+	repo.On("NextMixedCase", ctx, "1", 10).Return([]*domain.URL{}, nil)
+	repo.On("LowercaseCodes", ctx, "1", false).Return(true, nil)
+
+	// Act
+	report, err := migration.Run(ctx, false, nil)
+
+	// Assert: reported as lowercased, asked not to change anything
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Lowercased)
+	repo.AssertExpectations(t)
+}
+
+func TestCodeCaseMigration_SkipsShardMoves(t *testing.T) {
+	// Arrange: capitals live on shard 1, lowercase on shard 0
+	ctx := context.Background()
+	repo := new(MockCodeCaseRepository)
+	route := func(code string) int {
+		if code[0] >= 'A' && code[0] <= 'Z' {
+			return 1
+		}
+		return 0
+	}
+	migration := NewCodeCaseMigration(repo, 10).WithRouting(route)
+	repo.On("NextMixedCase", ctx, "", 10).Return([]*domain.URL{
+		{ID: "1", ShortCode: "Kx9"},
+		{ID: "2", ShortCode: "kX9"},
+	}, nil)
+	repo.On("NextMixedCase", ctx, "2", 10).Return([]*domain.URL{}, nil)
+	repo.On("LowercaseCodes", ctx, "2", true).Return(true, nil)
+
+	// Act
+	report, err := migration.Run(ctx, true, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Kx9"}, report.Moved)
+	assert.Equal(t, 1, report.Lowercased)
+	repo.AssertNotCalled(t, "LowercaseCodes", ctx, "1", true)
+}
+
+func TestCodeCaseMigration_StopsOnError(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockCodeCaseRepository)
+	migration := NewCodeCaseMigration(repo, 10)
+	repo.On("NextMixedCase", ctx, "", 10).Return(nil, errors.New("connection refused"))
+
+	// Act
+	_, err := migration.Run(ctx, true, nil)
+
+	// Assert
+	assert.Error(t, err)
+}
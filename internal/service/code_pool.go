@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// codePoolTTL is how long a checked code may wait in the pool
+// The check is a snapshot: the longer a code waits, the likelier someone
+// else (a custom alias, another instance) took it in the meantime
+const codePoolTTL = 10 * time.Second
+
+// codePool hands out generated short codes that were checked in batches
+//
+// WHY A POOL?
+// Without it every new link costs a query to check its code. Imports and
+// high-rate API clients create hundreds of links a second, so that is
+// hundreds of round trips that each check ONE code. The pool checks a
+// whole batch with one FindTakenCodes query and hands the free codes out
+// one by one.
+//
+// COALESCING: when the pool runs dry under load, the first caller refills
+// it while the others wait for the result, instead of every caller sending
+// its own query.
+//
+// A pooled code can still be taken before its INSERT; CreateShortURL
+// catches that through the UNIQUE constraint and draws another code.
+type codePool struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*pooledCodes // Code policies differ per domain
+}
+
+// pooledCodes are the checked codes of one domain
+type pooledCodes struct {
+	codes     []string
+	checkedAt time.Time
+}
+
+func newCodePool(size int, now func() time.Time) *codePool {
+	return &codePool{
+		size:  size,
+		ttl:   codePoolTTL,
+		now:   now,
+		hosts: make(map[string]*pooledCodes),
+	}
+}
+
+// take returns a checked code for host, refilling the pool when it is
+// empty or stale. refill checks n fresh candidates and returns the free ones
+func (p *codePool) take(ctx context.Context, host string, refill func(ctx context.Context, host string, n int) ([]string, error)) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pool := p.hosts[host]
+	if pool == nil || len(pool.codes) == 0 || p.now().Sub(pool.checkedAt) > p.ttl {
+		codes, err := refill(ctx, host, p.size)
+		if err != nil {
+			return "", err
+		}
+		if len(codes) == 0 {
+			return "", fmt.Errorf("all %d short code candidates were taken", p.size)
+		}
+		pool = &pooledCodes{codes: codes, checkedAt: p.now()}
+		p.hosts[host] = pool
+	}
+
+	code := pool.codes[0]
+	pool.codes = pool.codes[1:]
+	return code, nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRefill returns n numbered codes per call and counts the calls
+func countingRefill(calls *atomic.Int32) func(ctx context.Context, host string, n int) ([]string, error) {
+	return func(ctx context.Context, host string, n int) ([]string, error) {
+		call := calls.Add(1)
+		codes := make([]string, n)
+		for i := range codes {
+			codes[i] = fmt.Sprintf("%s-%d-%d", host, call, i)
+		}
+		return codes, nil
+	}
+}
+
+func TestCodePool_Take(t *testing.T) {
+	// Arrange
+	var calls atomic.Int32
+	pool := newCodePool(3, time.Now)
+	ctx := context.Background()
+
+	// Act
+	var codes []string
+	for i := 0; i < 4; i++ {
+		code, err := pool.take(ctx, "sho.rt", countingRefill(&calls))
+		require.NoError(t, err)
+		codes = append(codes, code)
+	}
+
+	// Assert - one refill per 3 codes
+	assert.Equal(t, []string{"sho.rt-1-0", "sho.rt-1-1", "sho.rt-1-2", "sho.rt-2-0"}, codes)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestCodePool_KeepsDomainsApart(t *testing.T) {
+	// Arrange
+	var calls atomic.Int32
+	pool := newCodePool(5, time.Now)
+	ctx := context.Background()
+
+	// Act
+	a, errA := pool.take(ctx, "a.example", countingRefill(&calls))
+	b, errB := pool.take(ctx, "b.example", countingRefill(&calls))
+
+	// Assert
+	require.NoError(t, errA)
+	require.NoError(t, errB)
+	assert.Equal(t, "a.example-1-0", a)
+	assert.Equal(t, "b.example-2-0", b)
+}
+
+func TestCodePool_DropsStaleCodes(t *testing.T) {
+	// Arrange
+	var calls atomic.Int32
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	pool := newCodePool(10, func() time.Time { return now })
+	ctx := context.Background()
+
+	_, err := pool.take(ctx, "", countingRefill(&calls))
+	require.NoError(t, err)
+
+	// Act
+	now = now.Add(codePoolTTL + time.Second)
+	code, err := pool.take(ctx, "", countingRefill(&calls))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "-2-0", code)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestCodePool_RefillFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		codes   []string
+		err     error
+		wantErr string
+	}{
+		{name: "query fails", err: errors.New("connection refused"), wantErr: "connection refused"},
+		{name: "every candidate taken", codes: []string{}, wantErr: "all 4 short code candidates were taken"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			pool := newCodePool(4, time.Now)
+			refill := func(ctx context.Context, host string, n int) ([]string, error) {
+				return tt.codes, tt.err
+			}
+
+			// Act
+			code, err := pool.take(context.Background(), "", refill)
+
+			// Assert
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Empty(t, code)
+		})
+	}
+}
+
+func TestCodePool_ConcurrentCallersShareRefills(t *testing.T) {
+	// Arrange
+	var calls atomic.Int32
+	pool := newCodePool(50, time.Now)
+	ctx := context.Background()
+
+	// Act - 100 goroutines hit an empty pool at once
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, err := pool.take(ctx, "", countingRefill(&calls))
+			assert.NoError(t, err)
+			mu.Lock()
+			seen[code] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// Assert - two queries instead of 100, and no code handed out twice
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Len(t, seen, 100)
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// ConfusableAliasService spots new aliases that imitate popular or reserved ones
+//
+// WHY?
+// A phishing link is most convincing on the instance it imitates:
+// short.example/paypa1 next to the real short.example/paypal. The most
+// clicked aliases are the ones worth imitating, so those are protected,
+// along with the reserved ones ("adrnin", "l0gin").
+//
+// Like the domain rules, the protected aliases live in memory (one map
+// lookup per new alias) and are reloaded every refresh interval. A link
+// that becomes popular is protected from the next reload on.
+type ConfusableAliasService struct {
+	repo  repository.PopularAliasRepository
+	limit int // How many of the most clicked aliases are protected
+	index atomic.Pointer[domain.ConfusableIndex]
+}
+
+// NewConfusableAliasService creates a service protecting only the reserved
+// aliases. Call Reload (or Run) to load the popular ones.
+func NewConfusableAliasService(repo repository.PopularAliasRepository, limit int) *ConfusableAliasService {
+	if limit <= 0 {
+		limit = 1000
+	}
+	s := &ConfusableAliasService{repo: repo, limit: limit}
+	s.index.Store(domain.NewConfusableIndex(nil))
+	return s
+}
+
+// Run reloads the popular aliases every interval until ctx is canceled
+func (s *ConfusableAliasService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Reload(ctx); err != nil {
+			fmt.Printf("Warning: failed to reload popular aliases: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reload replaces the protected aliases with the most clicked ones right now
+func (s *ConfusableAliasService) Reload(ctx context.Context) error {
+	popular, err := s.repo.PopularAliases(ctx, s.limit)
+	if err != nil {
+		return err
+	}
+
+	aliases := make([]string, len(popular))
+	for i, p := range popular {
+		aliases[i] = p.Alias
+	}
+	s.index.Store(domain.NewConfusableIndex(aliases))
+	return nil
+}
+
+// Imitated returns the protected alias that alias looks like, or "" if none
+func (s *ConfusableAliasService) Imitated(alias string) string {
+	return s.index.Load().Imitated(alias)
+}
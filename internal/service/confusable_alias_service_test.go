@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPopularAliasRepository is a mock implementation of PopularAliasRepository
+type MockPopularAliasRepository struct {
+	mock.Mock
+}
+
+func (m *MockPopularAliasRepository) PopularAliases(ctx context.Context, limit int) ([]domain.PopularAlias, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.PopularAlias), args.Error(1)
+}
+
+func TestConfusableAliasService_Reload(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockPopularAliasRepository)
+	svc := NewConfusableAliasService(repo, 100)
+	repo.On("PopularAliases", ctx, 100).Return([]domain.PopularAlias{{Alias: "paypal", Clicks: 900}}, nil).Once()
+	repo.On("PopularAliases", ctx, 100).Return(nil, errors.New("connection refused")).Once()
+
+	// Act & Assert: before the first reload only reserved aliases are protected
+	assert.Equal(t, "", svc.Imitated("paypa1"))
+	assert.Equal(t, "login", svc.Imitated("l0gin"))
+
+	require.NoError(t, svc.Reload(ctx))
+	assert.Equal(t, "paypal", svc.Imitated("paypa1"))
+
+	// A failed reload keeps the aliases loaded before
+	assert.Error(t, svc.Reload(ctx))
+	assert.Equal(t, "paypal", svc.Imitated("paypa1"))
+}
+
+// stubAliasGuard imitates one alias
+type stubAliasGuard map[string]string
+
+func (g stubAliasGuard) Imitated(alias string) string {
+	return g[alias]
+}
+
+func TestCreateShortURL_ConfusableAlias(t *testing.T) {
+	guard := stubAliasGuard{"paypa1": "paypal"}
+
+	tests := []struct {
+		name        string
+		policy      domain.ConfusablePolicy
+		alias       string
+		wantErr     error
+		wantFlagged bool
+	}{
+		{name: "rejected", policy: domain.ConfusableReject, alias: "paypa1", wantErr: domain.ErrConfusableAlias},
+		{name: "flagged", policy: domain.ConfusableFlag, alias: "paypa1", wantFlagged: true},
+		{name: "check off", policy: domain.ConfusableOff, alias: "paypa1"},
+		{name: "unrelated alias", policy: domain.ConfusableReject, alias: "spring"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			mockURLRepo := new(MockURLRepository)
+			service := NewURLService(mockURLRepo, new(MockClickRepository)).WithConfusableAliases(guard, tt.policy)
+			mockURLRepo.On("ExistsCustomAlias", ctx, tt.alias).Return(false, nil)
+			mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
+
+			var flagged []events.AliasFlagged
+			events.Subscribe(service.Events(), func(ctx context.Context, e events.AliasFlagged) {
+				flagged = append(flagged, e)
+			})
+
+			// Act
+			url, err := service.CreateShortURL(ctx, "https://example.com", tt.alias, "user1", 0)
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockURLRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.alias, url.ShortCode)
+			if tt.wantFlagged {
+				require.Len(t, flagged, 1)
+				assert.Equal(t, "paypal", flagged[0].Imitates)
+			} else {
+				assert.Empty(t, flagged)
+			}
+		})
+	}
+}
+
+func TestCheckAliasAvailability_Confusable(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	service := NewURLService(mockURLRepo, new(MockClickRepository)).
+		WithConfusableAliases(stubAliasGuard{"paypa1": "paypal"}, domain.ConfusableReject)
+	mockURLRepo.On("FindTakenCodes", ctx, []string{"paypa1"}).Return(map[string]bool{}, nil)
+
+	// Act
+	status, err := service.CheckAliasAvailability(ctx, "paypa1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.AliasConfusable, status)
+}
@@ -0,0 +1,409 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/jobs"
+	"url-shortener/internal/repository"
+)
+
+// DomainVerifyJob is the job kind that checks a domain's TXT record
+// Register CustomDomainService.VerifyJob for it on the job queue
+const DomainVerifyJob = "domains.verify"
+
+// domainLookupTimeout bounds one TXT lookup
+const domainLookupTimeout = 10 * time.Second
+
+// TXTResolver looks up DNS TXT records
+// Implemented by *net.Resolver
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// JobEnqueuer hands work to the background job queue
+// Implemented by jobs.Queue
+type JobEnqueuer interface {
+	Enqueue(ctx context.Context, kind string, payload interface{}, createdBy string) (*jobs.Job, error)
+}
+
+// CertificateStatuses reports the TLS certificates of hosts
+// Implemented by CertificateService
+type CertificateStatuses interface {
+	Statuses(ctx context.Context, hosts []string) (map[string]*domain.Certificate, error)
+}
+
+// domainVerifyPayload is the input of a DomainVerifyJob
+type domainVerifyPayload struct {
+	DomainID string `json:"domain_id"`
+}
+
+// CustomDomainService registers customers' domains and verifies that they
+// control them (see domain.CustomDomain for the workflow)
+//
+// WHY KEEP THE STATUS IN MEMORY?
+// Every redirect asks whether its host may be served. Domains are few and
+// change rarely, so like the domain rules each instance loads them all and
+// swaps in a new host table on Reload; a redirect never waits for the
+// database. Other instances pick up a change within one refresh interval.
+//
+// WHERE CHECKS RUN:
+//   - a new domain enqueues a DomainVerifyJob right away; the queue retries
+//     it with backoff while DNS propagates
+//   - GET /api/v1/domains/{id}/verify enqueues another one
+//   - RecheckDue (a scheduled task) checks pending domains on every run and
+//     verified ones once per recheck interval, so a removed record is noticed
+type CustomDomainService struct {
+	repo         repository.CustomDomainRepository
+	resolver     TXTResolver
+	jobs         JobEnqueuer         // Optional: without it, checks run inline
+	certificates CertificateStatuses // Optional: automatic TLS status of each domain
+	trusted      map[string]bool     // Our own hosts: always served, never verified
+	recheckAfter time.Duration       // How often verified domains are checked again
+	hosts        atomic.Pointer[map[string]*domain.CustomDomain]
+	now          func() time.Time
+}
+
+// NewCustomDomainService creates a domain service with no domains loaded
+// Call Reload (or Run) to load them
+func NewCustomDomainService(repo repository.CustomDomainRepository, resolver TXTResolver) *CustomDomainService {
+	s := &CustomDomainService{
+		repo:         repo,
+		resolver:     resolver,
+		trusted:      make(map[string]bool),
+		recheckAfter: 24 * time.Hour,
+		now:          time.Now,
+	}
+	s.hosts.Store(&map[string]*domain.CustomDomain{})
+	return s
+}
+
+// WithJobs runs verification checks on the background job queue
+func (s *CustomDomainService) WithJobs(q JobEnqueuer) *CustomDomainService {
+	s.jobs = q
+	return s
+}
+
+// WithCertificates adds the HTTPS certificate status to the domains returned
+func (s *CustomDomainService) WithCertificates(c CertificateStatuses) *CustomDomainService {
+	s.certificates = c
+	return s
+}
+
+// WithTrustedHosts lists the operator's own hosts (the default domain and
+// any other host run by us): links may use them without a verification
+func (s *CustomDomainService) WithTrustedHosts(hosts ...string) *CustomDomainService {
+	for _, host := range hosts {
+		s.trusted[normalizeHost(host)] = true
+	}
+	return s
+}
+
+// WithRecheckInterval sets how often verified domains are checked again
+func (s *CustomDomainService) WithRecheckInterval(interval time.Duration) *CustomDomainService {
+	s.recheckAfter = interval
+	return s
+}
+
+// Run reloads the domains every interval until ctx is canceled
+func (s *CustomDomainService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Reload(ctx); err != nil {
+			fmt.Printf("Warning: failed to reload custom domains: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reload replaces the host table with the domains currently in the database
+func (s *CustomDomainService) Reload(ctx context.Context) error {
+	domains, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	hosts := make(map[string]*domain.CustomDomain, len(domains))
+	for _, d := range domains {
+		hosts[d.Host] = d
+	}
+	s.hosts.Store(&hosts)
+	return nil
+}
+
+// Serves reports whether redirects may be answered on host (a Host header,
+// port allowed)
+// Registered domains are served once verified. Hosts nobody registered are
+// not ours to refuse - the default domain and its aliases end up there.
+func (s *CustomDomainService) Serves(host string) bool {
+	host = normalizeHost(host)
+	if s.trusted[host] {
+		return true
+	}
+	d, registered := (*s.hosts.Load())[host]
+	return !registered || d.IsVerified()
+}
+
+// AllowsCertificate reports whether a TLS certificate may be issued for
+// host: a trusted host or a verified domain. Unlike Serves, hosts nobody
+// registered are refused - we don't ask the CA on behalf of strangers.
+func (s *CustomDomainService) AllowsCertificate(host string) bool {
+	host = normalizeHost(host)
+	if s.trusted[host] {
+		return true
+	}
+	d, registered := (*s.hosts.Load())[host]
+	return registered && d.IsVerified()
+}
+
+// CheckDomain returns domain.ErrCustomDomainUnverified unless the caller
+// may create links on host: a trusted host, or a verified domain they own
+// (admins: any verified domain)
+func (s *CustomDomainService) CheckDomain(ctx context.Context, host string) error {
+	host = normalizeHost(host)
+	if host == "" || s.trusted[host] {
+		return nil
+	}
+
+	d, registered := (*s.hosts.Load())[host]
+	if !registered || !d.IsVerified() || authorizeDomain(ctx, d) != nil {
+		return fmt.Errorf("%w: %s", domain.ErrCustomDomainUnverified, host)
+	}
+	return nil
+}
+
+// AddDomain registers host for the caller and starts verifying it
+func (s *CustomDomainService) AddDomain(ctx context.Context, host string) (*domain.CustomDomain, error) {
+	principal := auth.FromContext(ctx)
+	if principal == auth.Anonymous || authorizeWrite(ctx) != nil {
+		return nil, domain.ErrForbidden
+	}
+
+	d, err := domain.NewCustomDomain(host, principal.ID)
+	if err != nil {
+		return nil, err
+	}
+	if s.trusted[d.Host] {
+		return nil, domain.ErrCustomDomainTaken
+	}
+	if err := s.repo.Create(ctx, d); err != nil {
+		return nil, err
+	}
+	s.reloadAfterChange(ctx)
+	s.attachCertificates(ctx, d)
+
+	// The customer usually adds the record right after this call; the
+	// job's retries give DNS time to catch up
+	if s.jobs != nil {
+		if _, err := s.jobs.Enqueue(ctx, DomainVerifyJob, domainVerifyPayload{DomainID: d.ID}, principal.ID); err != nil {
+			fmt.Printf("Warning: failed to enqueue verification of %s: %v\n", d.Host, err)
+		}
+	}
+	return d, nil
+}
+
+// ListDomains returns the caller's domains; admins see every domain
+func (s *CustomDomainService) ListDomains(ctx context.Context) ([]*domain.CustomDomain, error) {
+	domains, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]*domain.CustomDomain, 0, len(domains))
+	for _, d := range domains {
+		if authorizeDomain(ctx, d) == nil {
+			visible = append(visible, d)
+		}
+	}
+	s.attachCertificates(ctx, visible...)
+	return visible, nil
+}
+
+// GetDomain returns one of the caller's domains
+func (s *CustomDomainService) GetDomain(ctx context.Context, id string) (*domain.CustomDomain, error) {
+	d, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeDomain(ctx, d); err != nil {
+		return nil, err
+	}
+	s.attachCertificates(ctx, d)
+	return d, nil
+}
+
+// DeleteDomain removes one of the caller's domains; it stops serving at once
+// on this instance. Links created on it keep their domain, but are only
+// reachable on the default one.
+func (s *CustomDomainService) DeleteDomain(ctx context.Context, id string) error {
+	if err := authorizeWrite(ctx); err != nil {
+		return err
+	}
+	if _, err := s.GetDomain(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.reloadAfterChange(ctx)
+	return nil
+}
+
+// RequestVerification checks one of the caller's domains again
+// With a job queue the check runs in the background and the job is
+// returned to poll; without one it runs now and job is nil
+func (s *CustomDomainService) RequestVerification(ctx context.Context, id string) (*domain.CustomDomain, *jobs.Job, error) {
+	d, err := s.GetDomain(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if s.jobs == nil {
+		d, err = s.Verify(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.attachCertificates(ctx, d)
+		return d, nil, nil
+	}
+	job, err := s.jobs.Enqueue(ctx, DomainVerifyJob, domainVerifyPayload{DomainID: d.ID}, auth.FromContext(ctx).ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d, job, nil
+}
+
+// Verify looks up a domain's TXT record now and stores the outcome
+func (s *CustomDomainService) Verify(ctx context.Context, id string) (*domain.CustomDomain, error) {
+	d, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := d.Status
+	records, lookupErr := s.lookupChallenge(ctx, d)
+	d.RecordCheck(records, lookupErr, s.now())
+	if err := s.repo.SaveCheck(ctx, d); err != nil {
+		return nil, err
+	}
+
+	if d.Status != previous {
+		s.reloadAfterChange(ctx)
+		if d.Status == domain.DomainFailed {
+			fmt.Printf("Warning: custom domain %s lost its verification: %s\n", d.Host, d.LastError)
+		}
+	}
+	return d, nil
+}
+
+// VerifyJob is the jobs.Handler of DomainVerifyJob
+// A domain that isn't verified yet fails the attempt, so the queue tries
+// again later; a deleted domain fails the job for good
+func (s *CustomDomainService) VerifyJob(ctx context.Context, job *jobs.Job) (interface{}, error) {
+	var payload domainVerifyPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return nil, err
+	}
+
+	d, err := s.Verify(ctx, payload.DomainID)
+	if errors.Is(err, domain.ErrCustomDomainNotFound) {
+		return nil, jobs.Permanent(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !d.IsVerified() {
+		return nil, fmt.Errorf("%s: %s", d.Host, d.LastError)
+	}
+	return map[string]string{"host": d.Host, "status": string(d.Status)}, nil
+}
+
+// RecheckDue checks every domain that is due and returns how many it checked
+// Domains that aren't verified are due on every run; verified ones once
+// per recheck interval. One failing domain doesn't stop the others.
+func (s *CustomDomainService) RecheckDue(ctx context.Context) (int, error) {
+	domains, err := s.repo.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	checked := 0
+	var errs []error
+	for _, d := range domains {
+		if d.IsVerified() && d.CheckedAt != nil && s.now().Sub(*d.CheckedAt) < s.recheckAfter {
+			continue
+		}
+		if _, err := s.Verify(ctx, d.ID); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", d.Host, err))
+			continue
+		}
+		checked++
+	}
+	return checked, errors.Join(errs...)
+}
+
+// lookupChallenge returns the TXT records at the domain's challenge name
+// A name without TXT records is an answer (nil records), not an error
+func (s *CustomDomainService) lookupChallenge(ctx context.Context, d *domain.CustomDomain) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, domainLookupTimeout)
+	defer cancel()
+
+	records, err := s.resolver.LookupTXT(ctx, d.ChallengeName())
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil, nil
+	}
+	return records, err
+}
+
+// attachCertificates fills in the certificate of each domain
+// Without automatic TLS they stay nil; if the lookup fails the domains are
+// still returned, just without it
+func (s *CustomDomainService) attachCertificates(ctx context.Context, domains ...*domain.CustomDomain) {
+	if s.certificates == nil || len(domains) == 0 {
+		return
+	}
+
+	hosts := make([]string, len(domains))
+	for i, d := range domains {
+		hosts[i] = d.Host
+	}
+	statuses, err := s.certificates.Statuses(ctx, hosts)
+	if err != nil {
+		fmt.Printf("Warning: failed to load certificate statuses: %v\n", err)
+		return
+	}
+	for _, d := range domains {
+		d.Certificate = statuses[d.Host]
+	}
+}
+
+// reloadAfterChange applies a change on this instance right away
+// If the reload fails, the next periodic one picks the change up
+func (s *CustomDomainService) reloadAfterChange(ctx context.Context) {
+	if err := s.Reload(ctx); err != nil {
+		fmt.Printf("Warning: failed to reload custom domains: %v\n", err)
+	}
+}
+
+// normalizeHost lowercases a host and strips the port and trailing dot
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
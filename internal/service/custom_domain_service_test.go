@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/jobs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockCustomDomainRepository is a mock implementation of CustomDomainRepository
+type MockCustomDomainRepository struct {
+	mock.Mock
+}
+
+func (m *MockCustomDomainRepository) Create(ctx context.Context, d *domain.CustomDomain) error {
+	args := m.Called(ctx, d)
+	return args.Error(0)
+}
+
+func (m *MockCustomDomainRepository) GetByID(ctx context.Context, id string) (*domain.CustomDomain, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CustomDomain), args.Error(1)
+}
+
+func (m *MockCustomDomainRepository) List(ctx context.Context) ([]*domain.CustomDomain, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.CustomDomain), args.Error(1)
+}
+
+func (m *MockCustomDomainRepository) SaveCheck(ctx context.Context, d *domain.CustomDomain) error {
+	args := m.Called(ctx, d)
+	return args.Error(0)
+}
+
+func (m *MockCustomDomainRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockTXTResolver is a mock implementation of TXTResolver
+type MockTXTResolver struct {
+	mock.Mock
+}
+
+func (m *MockTXTResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// MockJobEnqueuer is a mock implementation of JobEnqueuer
+type MockJobEnqueuer struct {
+	mock.Mock
+}
+
+func (m *MockJobEnqueuer) Enqueue(ctx context.Context, kind string, payload interface{}, createdBy string) (*jobs.Job, error) {
+	args := m.Called(ctx, kind, payload, createdBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*jobs.Job), args.Error(1)
+}
+
+func customDomain(host string, status domain.DomainStatus) *domain.CustomDomain {
+	return &domain.CustomDomain{ID: "d-" + host, Host: host, Owner: "user1", Token: "abc", Status: status}
+}
+
+func TestCustomDomainService_Verify(t *testing.T) {
+	notFound := &net.DNSError{Err: "no such host", IsNotFound: true}
+	timeout := &net.DNSError{Err: "i/o timeout", IsTimeout: true}
+
+	tests := []struct {
+		name       string
+		status     domain.DomainStatus
+		records    []string
+		lookupErr  error
+		wantStatus domain.DomainStatus
+		wantError  string
+	}{
+		{name: "record published", status: domain.DomainPending, records: []string{"other", "shortener-verification=abc"}, wantStatus: domain.DomainVerified},
+		{name: "not published yet", status: domain.DomainPending, lookupErr: notFound, wantStatus: domain.DomainPending, wantError: domain.ErrDomainChallengeNotFound.Error()},
+		{name: "record removed", status: domain.DomainVerified, records: []string{"shortener-verification=old"}, wantStatus: domain.DomainFailed, wantError: domain.ErrDomainChallengeNotFound.Error()},
+		{name: "dns hiccup keeps the status", status: domain.DomainVerified, lookupErr: timeout, wantStatus: domain.DomainVerified, wantError: timeout.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			repo, resolver := new(MockCustomDomainRepository), new(MockTXTResolver)
+			service := NewCustomDomainService(repo, resolver)
+			d := customDomain("go.example.com", tt.status)
+
+			repo.On("GetByID", ctx, d.ID).Return(d, nil)
+			resolver.On("LookupTXT", mock.Anything, "_shortener-challenge.go.example.com").Return(tt.records, tt.lookupErr)
+			repo.On("SaveCheck", ctx, d).Return(nil)
+			repo.On("List", ctx).Return([]*domain.CustomDomain{d}, nil).Maybe() // Reloaded when the status changes
+
+			// Act
+			got, err := service.Verify(ctx, d.ID)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.Equal(t, tt.wantError, got.LastError)
+			assert.NotNil(t, got.CheckedAt)
+		})
+	}
+}
+
+func TestCustomDomainService_ServesAndCheckDomain(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockCustomDomainRepository)
+	repo.On("List", ctx).Return([]*domain.CustomDomain{
+		customDomain("go.example.com", domain.DomainVerified),
+		customDomain("new.example.com", domain.DomainPending),
+	}, nil)
+	service := NewCustomDomainService(repo, new(MockTXTResolver)).WithTrustedHosts("sho.rt")
+	require.NoError(t, service.Reload(ctx))
+
+	owner := auth.WithPrincipal(ctx, &auth.Principal{ID: "user1"})
+	stranger := auth.WithPrincipal(ctx, &auth.Principal{ID: "user2"})
+
+	// Act & Assert
+	assert.True(t, service.Serves("GO.example.com:443"))
+	assert.False(t, service.Serves("new.example.com"))
+	assert.True(t, service.Serves("unregistered.example.org"))
+
+	assert.NoError(t, service.CheckDomain(owner, "go.example.com"))
+	assert.NoError(t, service.CheckDomain(stranger, "sho.rt"))
+	assert.ErrorIs(t, service.CheckDomain(stranger, "go.example.com"), domain.ErrCustomDomainUnverified)
+	assert.ErrorIs(t, service.CheckDomain(owner, "new.example.com"), domain.ErrCustomDomainUnverified)
+	assert.ErrorIs(t, service.CheckDomain(owner, "unregistered.example.org"), domain.ErrCustomDomainUnverified)
+}
+
+func TestCustomDomainService_AddDomainEnqueuesVerification(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+	repo, queue := new(MockCustomDomainRepository), new(MockJobEnqueuer)
+	service := NewCustomDomainService(repo, new(MockTXTResolver)).WithJobs(queue)
+
+	repo.On("Create", ctx, mock.AnythingOfType("*domain.CustomDomain")).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.CustomDomain).ID = "d-1"
+	}).Return(nil)
+	repo.On("List", ctx).Return([]*domain.CustomDomain{}, nil)
+	queue.On("Enqueue", ctx, DomainVerifyJob, domainVerifyPayload{DomainID: "d-1"}, "user1").Return(&jobs.Job{ID: "job-1"}, nil)
+
+	// Act
+	d, err := service.AddDomain(ctx, " Go.Example.com. ")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "go.example.com", d.Host)
+	assert.Equal(t, domain.DomainPending, d.Status)
+	assert.Len(t, d.Token, 32)
+	queue.AssertExpectations(t)
+}
+
+func TestCustomDomainService_AddDomainRequiresCaller(t *testing.T) {
+	service := NewCustomDomainService(new(MockCustomDomainRepository), new(MockTXTResolver))
+
+	_, err := service.AddDomain(context.Background(), "go.example.com")
+
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+}
+
+func TestCustomDomainService_VerifyJob(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo, resolver := new(MockCustomDomainRepository), new(MockTXTResolver)
+	service := NewCustomDomainService(repo, resolver)
+
+	pending := customDomain("go.example.com", domain.DomainPending)
+	repo.On("GetByID", ctx, pending.ID).Return(pending, nil)
+	repo.On("GetByID", ctx, "gone").Return(nil, domain.ErrCustomDomainNotFound)
+	resolver.On("LookupTXT", mock.Anything, mock.Anything).Return([]string{}, nil)
+	repo.On("SaveCheck", ctx, pending).Return(nil)
+
+	// Act
+	_, stillPending := service.VerifyJob(ctx, &jobs.Job{Kind: DomainVerifyJob, Payload: []byte(`{"domain_id":"d-go.example.com"}`)})
+	_, deleted := service.VerifyJob(ctx, &jobs.Job{Kind: DomainVerifyJob, Payload: []byte(`{"domain_id":"gone"}`)})
+
+	// Assert
+	require.Error(t, stillPending)
+	assert.False(t, jobs.IsPermanent(stillPending), "a missing record is retried")
+	assert.True(t, jobs.IsPermanent(deleted))
+}
+
+func TestCustomDomainService_RecheckDue(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo, resolver := new(MockCustomDomainRepository), new(MockTXTResolver)
+	service := NewCustomDomainService(repo, resolver).WithRecheckInterval(24 * time.Hour)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	recent, stale := now.Add(-time.Hour), now.Add(-25*time.Hour)
+	fresh := customDomain("fresh.example.com", domain.DomainVerified)
+	fresh.CheckedAt = &recent
+	old := customDomain("old.example.com", domain.DomainVerified)
+	old.CheckedAt = &stale
+	pending := customDomain("new.example.com", domain.DomainPending)
+	pending.CheckedAt = &recent
+
+	repo.On("List", ctx).Return([]*domain.CustomDomain{fresh, old, pending}, nil)
+	repo.On("GetByID", ctx, old.ID).Return(old, nil)
+	repo.On("GetByID", ctx, pending.ID).Return(pending, errors.New("connection refused"))
+	resolver.On("LookupTXT", mock.Anything, old.ChallengeName()).Return([]string{old.ChallengeValue()}, nil)
+	repo.On("SaveCheck", ctx, old).Return(nil)
+
+	// Act
+	checked, err := service.RecheckDue(ctx)
+
+	// Assert
+	assert.Equal(t, 1, checked)
+	assert.ErrorContains(t, err, "new.example.com")
+	repo.AssertNotCalled(t, "GetByID", ctx, fresh.ID)
+}
+
+func TestCustomDomainService_AllowsCertificate(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockCustomDomainRepository)
+	repo.On("List", ctx).Return([]*domain.CustomDomain{
+		customDomain("go.example.com", domain.DomainVerified),
+		customDomain("new.example.com", domain.DomainPending),
+	}, nil)
+	service := NewCustomDomainService(repo, new(MockTXTResolver)).WithTrustedHosts("sho.rt")
+	require.NoError(t, service.Reload(ctx))
+
+	// Act & Assert
+	assert.True(t, service.AllowsCertificate("sho.rt"))
+	assert.True(t, service.AllowsCertificate("GO.example.com"))
+	assert.False(t, service.AllowsCertificate("new.example.com"), "not verified yet")
+	assert.False(t, service.AllowsCertificate("unregistered.example.org"), "served, but never certified")
+}
+
+func TestCustomDomainService_GetDomainWithCertificate(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+	repo, certs := new(MockCustomDomainRepository), new(MockCertificateRepository)
+	service := NewCustomDomainService(repo, new(MockTXTResolver)).
+		WithCertificates(NewCertificateService(certs, allowHosts{}))
+	d := customDomain("go.example.com", domain.DomainVerified)
+
+	repo.On("GetByID", ctx, d.ID).Return(d, nil)
+	certs.On("Statuses", ctx, []string{"go.example.com"}).Return(map[string]*domain.Certificate{
+		"go.example.com": {Host: "go.example.com", LastError: "429 rate limited"},
+	}, nil)
+
+	// Act
+	got, err := service.GetDomain(ctx, d.ID)
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, got.Certificate)
+	assert.Equal(t, domain.CertificateFailed, got.Certificate.Status(time.Now()))
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// DomainPolicyService manages the destination domain rules and checks links
+// against them (see domain.DomainPolicy for how rules combine)
+//
+// WHY KEEP THE RULES IN MEMORY?
+// Every creation and every sweep checks every destination. Rules are few
+// and change rarely, so each instance loads them all and swaps in a new
+// policy on Reload. Checking a link never touches the database.
+//
+// Other instances pick up a change on their next Reload (see Run), so a
+// new rule may take up to one refresh interval to apply everywhere.
+type DomainPolicyService struct {
+	rules  repository.DomainRuleRepository
+	policy atomic.Pointer[domain.DomainPolicy]
+}
+
+// NewDomainPolicyService creates a policy service with no rules
+// Call Reload (or Run) to load them
+func NewDomainPolicyService(rules repository.DomainRuleRepository) *DomainPolicyService {
+	s := &DomainPolicyService{rules: rules}
+	s.policy.Store(domain.NewDomainPolicy(nil))
+	return s
+}
+
+// Run reloads the rules every interval until ctx is canceled
+func (s *DomainPolicyService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Reload(ctx); err != nil {
+			fmt.Printf("Warning: failed to reload domain rules: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reload replaces the policy with the rules currently in the database
+// A rule that no longer compiles is skipped with a warning: one bad regex
+// must not switch off every other rule
+func (s *DomainPolicyService) Reload(ctx context.Context) error {
+	rules, err := s.rules.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	compiled := make([]*domain.DomainRule, 0, len(rules))
+	for _, rule := range rules {
+		if err := rule.Compile(); err != nil {
+			fmt.Printf("Warning: skipping domain rule %s (%q): %v\n", rule.ID, rule.Pattern, err)
+			continue
+		}
+		compiled = append(compiled, rule)
+	}
+	s.policy.Store(domain.NewDomainPolicy(compiled))
+	return nil
+}
+
+// Policy returns the policy currently in force
+func (s *DomainPolicyService) Policy() *domain.DomainPolicy {
+	return s.policy.Load()
+}
+
+// CheckURL returns a domain.ErrDestinationBlocked error if any destination
+// of the link is not allowed for its owner
+func (s *DomainPolicyService) CheckURL(url *domain.URL) error {
+	return s.Policy().CheckURL(url)
+}
+
+// ListRules returns the rules the caller is subject to: the global ones
+// and their own workspace's. Admins see every workspace's rules.
+func (s *DomainPolicyService) ListRules(ctx context.Context) ([]*domain.DomainRule, error) {
+	principal := auth.FromContext(ctx)
+	rules, err := s.rules.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if principal.Admin {
+		return rules, nil
+	}
+
+	visible := make([]*domain.DomainRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Workspace == domain.GlobalWorkspace || (principal != auth.Anonymous && rule.Workspace == principal.ID) {
+			visible = append(visible, rule)
+		}
+	}
+	return visible, nil
+}
+
+// CreateRule adds a rule to the caller's workspace, or a global rule
+// (admins only) when global is true
+// The rule applies on this instance right away
+func (s *DomainPolicyService) CreateRule(ctx context.Context, pattern string, action domain.PolicyAction, reason string, global bool) (*domain.DomainRule, error) {
+	principal := auth.FromContext(ctx)
+	if principal == auth.Anonymous || (global && !principal.Admin) {
+		return nil, domain.ErrForbidden
+	}
+
+	workspace := principal.ID
+	if global {
+		workspace = domain.GlobalWorkspace
+	}
+	rule, err := domain.NewDomainRule(workspace, pattern, action, reason, principal.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rules.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	s.reloadAfterChange(ctx)
+	return rule, nil
+}
+
+// DeleteRule removes a rule (its workspace's owner or an admin)
+// Links the rule deactivated stay off: their owners restore them
+func (s *DomainPolicyService) DeleteRule(ctx context.Context, id string) error {
+	rule, err := s.rules.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	principal := auth.FromContext(ctx)
+	if !principal.Admin && (principal == auth.Anonymous || rule.Workspace != principal.ID) {
+		return domain.ErrForbidden
+	}
+
+	if err := s.rules.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.reloadAfterChange(ctx)
+	return nil
+}
+
+// reloadAfterChange applies a rule change on this instance
+// The change is already saved, so a failed reload only delays it until Run
+func (s *DomainPolicyService) reloadAfterChange(ctx context.Context) {
+	if err := s.Reload(ctx); err != nil {
+		fmt.Printf("Warning: failed to reload domain rules: %v\n", err)
+	}
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/resolver"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockDomainRuleRepository is a mock implementation of repository.DomainRuleRepository
+type MockDomainRuleRepository struct {
+	mock.Mock
+}
+
+func (m *MockDomainRuleRepository) List(ctx context.Context) ([]*domain.DomainRule, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.DomainRule), args.Error(1)
+}
+
+func (m *MockDomainRuleRepository) GetByID(ctx context.Context, id string) (*domain.DomainRule, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.DomainRule), args.Error(1)
+}
+
+func (m *MockDomainRuleRepository) Create(ctx context.Context, rule *domain.DomainRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockDomainRuleRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// storedRule builds a rule the way the repository returns it: not compiled
+func storedRule(id, workspace, pattern string, kind domain.PatternKind, action domain.PolicyAction) *domain.DomainRule {
+	return &domain.DomainRule{ID: id, Workspace: workspace, Pattern: pattern, Kind: kind, Action: action}
+}
+
+// policyWith returns a policy service loaded with rules
+func policyWith(t *testing.T, rules ...*domain.DomainRule) *DomainPolicyService {
+	t.Helper()
+	repo := new(MockDomainRuleRepository)
+	repo.On("List", mock.Anything).Return(rules, nil)
+	policy := NewDomainPolicyService(repo)
+	require.NoError(t, policy.Reload(context.Background()))
+	return policy
+}
+
+// ==================== TESTS ====================
+
+func TestDomainPolicyService_Reload(t *testing.T) {
+	// Arrange
+	policy := policyWith(t,
+		storedRule("1", domain.GlobalWorkspace, "evil.com", domain.PatternDomain, domain.PolicyDeny),
+		storedRule("2", domain.GlobalWorkspace, "regex:([", domain.PatternRegex, domain.PolicyDeny), // Broken
+	)
+
+	// Act
+	blocked := policy.CheckURL(&domain.URL{OriginalURL: "https://login.evil.com/x", CreatedBy: "user1"})
+	allowed := policy.CheckURL(&domain.URL{OriginalURL: "https://example.com", CreatedBy: "user1"})
+
+	// Assert
+	assert.ErrorIs(t, blocked, domain.ErrDestinationBlocked)
+	assert.NoError(t, allowed, "a broken rule must not take the others down")
+	assert.Equal(t, "1", policy.Policy().Revision())
+}
+
+func TestDomainPolicyService_Reload_KeepsPolicyOnError(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockDomainRuleRepository)
+	repo.On("List", ctx).Return([]*domain.DomainRule{
+		storedRule("1", domain.GlobalWorkspace, ".tk", domain.PatternTLD, domain.PolicyDeny),
+	}, nil).Once()
+	repo.On("List", ctx).Return(nil, assert.AnError).Once()
+
+	policy := NewDomainPolicyService(repo)
+	require.NoError(t, policy.Reload(ctx))
+
+	// Act
+	err := policy.Reload(ctx)
+
+	// Assert
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.ErrorIs(t, policy.CheckURL(&domain.URL{OriginalURL: "http://free.tk"}), domain.ErrDestinationBlocked)
+}
+
+func TestDomainPolicyService_CreateRule(t *testing.T) {
+	tests := []struct {
+		name              string
+		principal         *auth.Principal
+		global            bool
+		expectErr         error
+		expectedWorkspace string
+	}{
+		{name: "workspace rule", principal: &auth.Principal{ID: "user1"}, expectedWorkspace: "user1"},
+		{name: "admin global rule", principal: &auth.Principal{ID: "admin", Admin: true}, global: true, expectedWorkspace: domain.GlobalWorkspace},
+		{name: "global needs admin", principal: &auth.Principal{ID: "user1"}, global: true, expectErr: domain.ErrForbidden},
+		{name: "anonymous", principal: auth.Anonymous, expectErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			repo := new(MockDomainRuleRepository)
+			repo.On("Create", ctx, mock.AnythingOfType("*domain.DomainRule")).Return(nil)
+			repo.On("List", ctx).Return([]*domain.DomainRule{}, nil)
+
+			// Act
+			rule, err := NewDomainPolicyService(repo).CreateRule(ctx, "*.Evil.com", domain.PolicyDeny, "phishing", tt.global)
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedWorkspace, rule.Workspace)
+			assert.Equal(t, "*.evil.com", rule.Pattern)
+			assert.Equal(t, domain.PatternWildcard, rule.Kind)
+			assert.Equal(t, tt.principal.ID, rule.CreatedBy)
+			repo.AssertCalled(t, "List", ctx) // Applied on this instance right away
+		})
+	}
+}
+
+func TestDomainPolicyService_CreateRule_InvalidPattern(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+	repo := new(MockDomainRuleRepository)
+
+	// Act
+	_, err := NewDomainPolicyService(repo).CreateRule(ctx, "regex:([", domain.PolicyDeny, "", false)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidDomainRule)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestDomainPolicyService_ListRules(t *testing.T) {
+	rules := []*domain.DomainRule{
+		storedRule("1", domain.GlobalWorkspace, ".tk", domain.PatternTLD, domain.PolicyDeny),
+		storedRule("2", "user1", "example.com", domain.PatternDomain, domain.PolicyAllow),
+		storedRule("3", "user2", "example.org", domain.PatternDomain, domain.PolicyAllow),
+	}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		expected  []string
+	}{
+		{name: "own and global rules", principal: &auth.Principal{ID: "user1"}, expected: []string{"1", "2"}},
+		{name: "admin sees everything", principal: &auth.Principal{ID: "admin", Admin: true}, expected: []string{"1", "2", "3"}},
+		{name: "anonymous sees global rules", principal: auth.Anonymous, expected: []string{"1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			repo := new(MockDomainRuleRepository)
+			repo.On("List", ctx).Return(rules, nil)
+
+			// Act
+			listed, err := NewDomainPolicyService(repo).ListRules(ctx)
+
+			// Assert
+			require.NoError(t, err)
+			ids := make([]string, len(listed))
+			for i, rule := range listed {
+				ids[i] = rule.ID
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestDomainPolicyService_DeleteRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		workspace string
+		expectErr error
+	}{
+		{name: "own rule", principal: &auth.Principal{ID: "user1"}, workspace: "user1"},
+		{name: "admin deletes any rule", principal: &auth.Principal{ID: "admin", Admin: true}, workspace: "user2"},
+		{name: "someone else's rule", principal: &auth.Principal{ID: "user1"}, workspace: "user2", expectErr: domain.ErrForbidden},
+		{name: "global rule needs admin", principal: &auth.Principal{ID: "user1"}, workspace: domain.GlobalWorkspace, expectErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			repo := new(MockDomainRuleRepository)
+			repo.On("GetByID", ctx, "r1").Return(storedRule("r1", tt.workspace, "example.com", domain.PatternDomain, domain.PolicyDeny), nil)
+			repo.On("Delete", ctx, "r1").Return(nil)
+			repo.On("List", ctx).Return([]*domain.DomainRule{}, nil)
+
+			// Act
+			err := NewDomainPolicyService(repo).DeleteRule(ctx, "r1")
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			repo.AssertCalled(t, "Delete", ctx, "r1")
+		})
+	}
+}
+
+func TestCreateShortURL_DestinationPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		resolvesTo  string
+		expectErr   error
+	}{
+		{name: "allowed", destination: "https://example.com", resolvesTo: "https://www.example.com/"},
+		{name: "banned domain", destination: "https://login.evil.com", expectErr: domain.ErrDestinationBlocked},
+		{name: "redirects to a banned domain", destination: "https://example.com/go", resolvesTo: "https://evil.com/", expectErr: domain.ErrDestinationBlocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			mockURLRepo := new(MockURLRepository)
+			mockResolver := new(MockResolver)
+
+			policy := policyWith(t, storedRule("1", domain.GlobalWorkspace, "evil.com", domain.PatternDomain, domain.PolicyDeny))
+			service := NewURLService(mockURLRepo, new(MockClickRepository)).
+				WithResolver(mockResolver, false).
+				WithDestinationPolicy(policy)
+
+			mockResolver.On("Resolve", ctx, tt.destination).Return(&resolver.Result{FinalURL: tt.resolvesTo}, nil)
+			mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
+			mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
+
+			// Act
+			url, err := service.CreateShortURL(ctx, tt.destination, "", "user1", 0)
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				assert.Nil(t, url)
+				mockURLRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, url)
+		})
+	}
+}
+
+func TestCreateShortURL_DestinationPolicy_BannedDomainIsNotFetched(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	mockResolver := new(MockResolver)
+
+	policy := policyWith(t, storedRule("1", domain.GlobalWorkspace, ".tk", domain.PatternTLD, domain.PolicyDeny))
+	service := NewURLService(mockURLRepo, new(MockClickRepository)).
+		WithResolver(mockResolver, false).
+		WithDestinationPolicy(policy)
+
+	mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
+
+	// Act
+	_, err := service.CreateShortURL(ctx, "http://free.tk", "", "user1", 0)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrDestinationBlocked)
+	mockResolver.AssertNotCalled(t, "Resolve", mock.Anything, mock.Anything)
+}
+
+func TestRestoreURL_DestinationBlocked(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+	mockURLRepo := new(MockURLRepository)
+
+	policy := policyWith(t, storedRule("1", domain.GlobalWorkspace, "evil.com", domain.PatternDomain, domain.PolicyDeny))
+	service := NewURLService(mockURLRepo, new(MockClickRepository)).
+		WithDestinationPolicy(policy)
+
+	mockURLRepo.On("GetByID", ctx, "123").Return(&domain.URL{
+		ID:          "123",
+		ShortCode:   "abc123",
+		OriginalURL: "https://evil.com/login",
+		CreatedBy:   "user1",
+	}, nil)
+
+	// Act
+	_, err := service.RestoreURL(ctx, "123")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrDestinationBlocked)
+	mockURLRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+}
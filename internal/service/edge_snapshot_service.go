@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// EdgeSnapshotService exports the links edge workers can serve on their own
+//
+// WHY AN EDGE SNAPSHOT?
+// A redirect answered by a CDN edge worker (or an nginx map) never reaches
+// us, which is as fast as a redirect gets. The edge needs a copy of the
+// links for that, and this service is where it comes from: a full snapshot
+// first, then only the changes since the version it has.
+//
+// VERSIONS:
+// Every shard numbers its changes (see migration 034). A snapshot version
+// is the position of every shard's change log, so "since=<version>" tells
+// each shard exactly where the client stopped reading.
+//
+// The database stays the source of truth: links the edge can't handle on
+// its own (see domain.URL.ServableAtEdge) are left out of a full snapshot,
+// and show up as removals in a delta - the edge passes them through to us.
+type EdgeSnapshotService struct {
+	shards   []repository.EdgeSnapshotRepository // Indexed like the shard map
+	pageSize int
+	now      func() time.Time
+}
+
+// NewEdgeSnapshotService creates an edge snapshot service over every shard
+func NewEdgeSnapshotService(shards ...repository.EdgeSnapshotRepository) *EdgeSnapshotService {
+	return &EdgeSnapshotService{
+		shards:   shards,
+		pageSize: 500,
+		now:      time.Now,
+	}
+}
+
+// Open pins the version a snapshot is read up to
+// since is the version the client already has ("" = full snapshot).
+// Returns domain.ErrInvalidSnapshotVersion for a version we never handed
+// out, and domain.ErrSnapshotExpired when the changes since it can't be
+// told any more (removals were pruned, or the shards changed).
+func (s *EdgeSnapshotService) Open(ctx context.Context, since string) (*domain.EdgeSnapshot, error) {
+	snapshot := &domain.EdgeSnapshot{Version: make(domain.SnapshotVersion, len(s.shards))}
+	if since != "" {
+		parsed, err := domain.ParseSnapshotVersion(since, len(s.shards))
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Since = parsed
+	}
+
+	for i, shard := range s.shards {
+		current, prunedThrough, err := shard.Version(ctx)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Version[i] = current
+
+		if snapshot.IsDelta() {
+			if snapshot.Since[i] > current {
+				return nil, domain.ErrInvalidSnapshotVersion
+			}
+			if snapshot.Since[i] < prunedThrough {
+				return nil, domain.ErrSnapshotExpired
+			}
+		}
+	}
+	return snapshot, nil
+}
+
+// Stream calls fn for every entry of snapshot, shard by shard
+// A full snapshot only has links to serve; a delta also has removals
+// (Removed), for deleted links and for links the edge must stop serving.
+// Stops at the first error returned by fn or a repository.
+func (s *EdgeSnapshotService) Stream(ctx context.Context, snapshot *domain.EdgeSnapshot, fn func(*domain.EdgeChange) error) error {
+	for i, shard := range s.shards {
+		var err error
+		if snapshot.IsDelta() {
+			err = s.streamChanges(ctx, shard, snapshot.Since[i], snapshot.Version[i], fn)
+		} else {
+			err = s.streamLinks(ctx, shard, snapshot.Version[i], fn)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *EdgeSnapshotService) streamLinks(ctx context.Context, shard repository.EdgeSnapshotRepository, through int64, fn func(*domain.EdgeChange) error) error {
+	after := ""
+	for {
+		urls, err := shard.ListLinks(ctx, after, through, s.pageSize)
+		if err != nil {
+			return fmt.Errorf("failed to read edge snapshot: %w", err)
+		}
+
+		for _, url := range urls {
+			if !s.servable(url) {
+				continue
+			}
+			if err := fn(&domain.EdgeChange{URL: url}); err != nil {
+				return err
+			}
+		}
+
+		// A short page means we reached the end
+		if len(urls) < s.pageSize {
+			return nil
+		}
+		after = urls[len(urls)-1].ID
+	}
+}
+
+func (s *EdgeSnapshotService) streamChanges(ctx context.Context, shard repository.EdgeSnapshotRepository, after, through int64, fn func(*domain.EdgeChange) error) error {
+	for {
+		changes, err := shard.ListChanges(ctx, after, through, s.pageSize)
+		if err != nil {
+			return fmt.Errorf("failed to read edge changes: %w", err)
+		}
+
+		for _, change := range changes {
+			// Deactivated, expired or no longer servable: the edge forgets it
+			if !change.Removed && !s.servable(change.URL) {
+				change.Removed = true
+			}
+			if err := fn(change); err != nil {
+				return err
+			}
+		}
+
+		if len(changes) < s.pageSize {
+			return nil
+		}
+		after = changes[len(changes)-1].Seq
+	}
+}
+
+// servable reports whether the edge should redirect url right now
+func (s *EdgeSnapshotService) servable(url *domain.URL) bool {
+	if url.ExpiresAt != nil && !s.now().Before(*url.ExpiresAt) {
+		return false
+	}
+	return url.IsActive && url.ServableAtEdge()
+}
+
+// PruneTombstones forgets links deleted more than retention ago, on every
+// shard (even if one fails), and returns how many were forgotten
+// Clients holding a version from before then must download a full snapshot.
+func (s *EdgeSnapshotService) PruneTombstones(ctx context.Context, retention time.Duration) (int64, error) {
+	cutoff := s.now().Add(-retention)
+
+	var pruned int64
+	var errs []error
+	for _, shard := range s.shards {
+		n, err := shard.PruneTombstones(ctx, cutoff)
+		pruned += n
+		errs = append(errs, err)
+	}
+	return pruned, errors.Join(errs...)
+}
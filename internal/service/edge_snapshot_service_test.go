@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockEdgeSnapshotRepository is a mock implementation of EdgeSnapshotRepository
+type MockEdgeSnapshotRepository struct {
+	mock.Mock
+}
+
+func (m *MockEdgeSnapshotRepository) Version(ctx context.Context) (int64, int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEdgeSnapshotRepository) ListLinks(ctx context.Context, afterID string, through int64, limit int) ([]*domain.URL, error) {
+	args := m.Called(ctx, afterID, through, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URL), args.Error(1)
+}
+
+func (m *MockEdgeSnapshotRepository) ListChanges(ctx context.Context, after, through int64, limit int) ([]*domain.EdgeChange, error) {
+	args := m.Called(ctx, after, through, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.EdgeChange), args.Error(1)
+}
+
+func (m *MockEdgeSnapshotRepository) PruneTombstones(ctx context.Context, cutoff time.Time) (int64, error) {
+	args := m.Called(ctx, cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestEdgeSnapshotService_Open(t *testing.T) {
+	tests := []struct {
+		name        string
+		since       string
+		wantVersion string
+		wantErr     error
+	}{
+		{name: "full snapshot", since: "", wantVersion: "40.7"},
+		{name: "delta", since: "30.5", wantVersion: "40.7"},
+		{name: "up to date", since: "40.7", wantVersion: "40.7"},
+		{name: "not a version", since: "abc", wantErr: domain.ErrInvalidSnapshotVersion},
+		{name: "from the future", since: "41.7", wantErr: domain.ErrInvalidSnapshotVersion},
+		{name: "removals pruned", since: "30.2", wantErr: domain.ErrSnapshotExpired},
+		{name: "shards changed", since: "30", wantErr: domain.ErrSnapshotExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			first, second := new(MockEdgeSnapshotRepository), new(MockEdgeSnapshotRepository)
+			first.On("Version", ctx).Return(int64(40), int64(10), nil).Maybe()
+			second.On("Version", ctx).Return(int64(7), int64(3), nil).Maybe()
+			service := NewEdgeSnapshotService(first, second)
+
+			// Act
+			snapshot, err := service.Open(ctx, tt.since)
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, snapshot.Version.String())
+			assert.Equal(t, tt.since != "", snapshot.IsDelta())
+		})
+	}
+}
+
+func TestEdgeSnapshotService_FullSnapshotSkipsUnservableLinks(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockEdgeSnapshotRepository)
+	service := NewEdgeSnapshotService(repo)
+	service.pageSize = 2
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	expired := now.Add(-time.Minute)
+	limit := int64(10)
+	firstPage := []*domain.URL{
+		{ID: "id-1", ShortCode: "plain", IsActive: true},
+		{ID: "id-2", ShortCode: "limited", IsActive: true, MaxClicks: &limit},
+	}
+	secondPage := []*domain.URL{
+		{ID: "id-3", ShortCode: "expired", IsActive: true, ExpiresAt: &expired},
+	}
+	repo.On("ListLinks", ctx, "", int64(40), 2).Return(firstPage, nil).Once()
+	repo.On("ListLinks", ctx, "id-2", int64(40), 2).Return(secondPage, nil).Once()
+
+	// Act
+	var seen []string
+	err := service.Stream(ctx, &domain.EdgeSnapshot{Version: domain.SnapshotVersion{40}}, func(change *domain.EdgeChange) error {
+		seen = append(seen, change.URL.ShortCode)
+		return nil
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"plain"}, seen)
+	repo.AssertExpectations(t)
+}
+
+func TestEdgeSnapshotService_DeltaRemovesUnservableLinks(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockEdgeSnapshotRepository)
+	service := NewEdgeSnapshotService(repo)
+	service.pageSize = 2
+
+	secret := "s3cret"
+	firstPage := []*domain.EdgeChange{
+		{Seq: 31, URL: &domain.URL{ShortCode: "edited", IsActive: true}},
+		{Seq: 33, URL: &domain.URL{ShortCode: "deleted"}, Removed: true},
+	}
+	secondPage := []*domain.EdgeChange{
+		{Seq: 36, URL: &domain.URL{ShortCode: "signed", IsActive: true, SigningSecret: &secret}},
+	}
+	repo.On("ListChanges", ctx, int64(30), int64(40), 2).Return(firstPage, nil).Once()
+	repo.On("ListChanges", ctx, int64(33), int64(40), 2).Return(secondPage, nil).Once()
+
+	// Act
+	removed := map[string]bool{}
+	err := service.Stream(ctx, &domain.EdgeSnapshot{Since: domain.SnapshotVersion{30}, Version: domain.SnapshotVersion{40}}, func(change *domain.EdgeChange) error {
+		removed[change.URL.ShortCode] = change.Removed
+		return nil
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"edited": false, "deleted": true, "signed": true}, removed)
+	repo.AssertExpectations(t)
+}
+
+func TestEdgeSnapshotService_PruneTombstonesOnEveryShard(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	first, second := new(MockEdgeSnapshotRepository), new(MockEdgeSnapshotRepository)
+	service := NewEdgeSnapshotService(first, second)
+	now := time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	cutoff := now.Add(-7 * 24 * time.Hour)
+	dbDown := errors.New("connection refused")
+	first.On("PruneTombstones", ctx, cutoff).Return(int64(0), dbDown)
+	second.On("PruneTombstones", ctx, cutoff).Return(int64(4), nil)
+
+	// Act
+	pruned, err := service.PruneTombstones(ctx, 7*24*time.Hour)
+
+	// Assert
+	assert.ErrorIs(t, err, dbDown)
+	assert.Equal(t, int64(4), pruned)
+	second.AssertExpectations(t)
+}
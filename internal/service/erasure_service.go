@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// ErasureService deletes everything an owner created (GDPR "right to erasure")
+//
+// Requests are stored first and processed by a BACKGROUND WORKER, because an
+// account can own millions of clicks - far too much to delete inside one HTTP
+// request. The worker deletes in batches, verifies nothing is left, and
+// records the counts for the deletion receipt.
+//
+// NOTE: only URLs, their clicks, their warnings, and the owner's audit log
+// exist in this service today. Any new per-owner data (API keys, ...) must be
+// added to the repository's batch delete and to verify.
+type ErasureService struct {
+	repo       repository.ErasureRepository
+	cache      Cache
+	batchSize  int
+	staleAfter time.Duration // Running requests without a heartbeat for this long are resumed
+	edge       EdgePurger    // Optional: purges deleted links from the CDN
+}
+
+// NewErasureService creates a new erasure service
+func NewErasureService(repo repository.ErasureRepository, cache Cache) *ErasureService {
+	return &ErasureService{
+		repo:       repo,
+		cache:      cache,
+		batchSize:  500,
+		staleAfter: 10 * time.Minute,
+	}
+}
+
+// WithEdgePurger purges deleted links from the CDN
+func (s *ErasureService) WithEdgePurger(p EdgePurger) *ErasureService {
+	s.edge = p
+	return s
+}
+
+// RequestErasure schedules the deletion of all of owner's data
+// Asking twice returns the request that is already pending or running
+func (s *ErasureService) RequestErasure(ctx context.Context, owner, requestedBy string) (*domain.ErasureRequest, error) {
+	active, err := s.repo.FindActiveByOwner(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return active, nil
+	}
+
+	req := domain.NewErasureRequest(owner, requestedBy)
+	if err := s.repo.Create(ctx, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// GetRequest returns an erasure request by ID
+func (s *ErasureService) GetRequest(ctx context.Context, id string) (*domain.ErasureRequest, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Run processes erasure requests every interval until ctx is canceled
+func (s *ErasureService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before sleeping again
+		for {
+			processed, err := s.ProcessNext(ctx)
+			if err != nil {
+				fmt.Printf("Warning: account erasure failed: %v\n", err)
+			}
+			if !processed || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessNext claims and processes one request
+// Returns false when there was nothing to do
+func (s *ErasureService) ProcessNext(ctx context.Context) (bool, error) {
+	req, err := s.repo.ClaimNext(ctx, s.staleAfter)
+	if err != nil || req == nil {
+		return false, err
+	}
+
+	if err := s.erase(ctx, req); err != nil {
+		req.Status = domain.ErasureFailed
+		req.Error = err.Error()
+		now := time.Now()
+		req.CompletedAt = &now
+		if updateErr := s.repo.Update(ctx, req); updateErr != nil {
+			return true, fmt.Errorf("%w (and failed to record it: %v)", err, updateErr)
+		}
+		return true, err
+	}
+
+	return true, nil
+}
+
+// erase deletes the owner's data batch by batch, then verifies and completes the request
+func (s *ErasureService) erase(ctx context.Context, req *domain.ErasureRequest) error {
+	for {
+		urls, clicks, err := s.repo.DeleteOwnerBatch(ctx, req.Owner, s.batchSize)
+		if err != nil {
+			return err
+		}
+
+		// Deleted links must stop redirecting NOW, not when the cache entry expires
+		for _, url := range urls {
+			s.invalidateCache(ctx, url)
+		}
+		purgeEdge(ctx, s.edge, urls...)
+
+		// Saving progress also refreshes the heartbeat, so no other
+		// instance takes over while we are still working
+		req.URLsDeleted += int64(len(urls))
+		req.ClicksDeleted += clicks
+		if err := s.repo.Update(ctx, req); err != nil {
+			return err
+		}
+
+		if len(urls) < s.batchSize {
+			break
+		}
+	}
+
+	// Verify: a link created while we were deleting would otherwise survive
+	remaining, err := s.repo.CountOwnerURLs(ctx, req.Owner)
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return fmt.Errorf("verification failed: %d URLs remain", remaining)
+	}
+
+	now := time.Now()
+	req.Status = domain.ErasureCompleted
+	req.CompletedAt = &now
+	req.Owner = "" // The repository clears the owner column on completion
+	return s.repo.Update(ctx, req)
+}
+
+// invalidateCache removes a deleted URL from the cache
+func (s *ErasureService) invalidateCache(ctx context.Context, url *domain.URL) {
+	keys := []string{url.ShortCode}
+	if url.CustomAlias != nil && *url.CustomAlias != url.ShortCode {
+		keys = append(keys, *url.CustomAlias)
+	}
+
+	for _, key := range keys {
+		if err := s.cache.DeleteURL(ctx, key); err != nil {
+			fmt.Printf("Warning: failed to invalidate cached URL: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockErasureRepository is a mock implementation of ErasureRepository
+type MockErasureRepository struct {
+	mock.Mock
+}
+
+func (m *MockErasureRepository) Create(ctx context.Context, req *domain.ErasureRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockErasureRepository) GetByID(ctx context.Context, id string) (*domain.ErasureRequest, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ErasureRequest), args.Error(1)
+}
+
+func (m *MockErasureRepository) FindActiveByOwner(ctx context.Context, owner string) (*domain.ErasureRequest, error) {
+	args := m.Called(ctx, owner)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ErasureRequest), args.Error(1)
+}
+
+func (m *MockErasureRepository) ClaimNext(ctx context.Context, staleAfter time.Duration) (*domain.ErasureRequest, error) {
+	args := m.Called(ctx, staleAfter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ErasureRequest), args.Error(1)
+}
+
+func (m *MockErasureRepository) Update(ctx context.Context, req *domain.ErasureRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockErasureRepository) DeleteOwnerBatch(ctx context.Context, owner string, limit int) ([]*domain.URL, int64, error) {
+	args := m.Called(ctx, owner, limit)
+	return args.Get(0).([]*domain.URL), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockErasureRepository) CountOwnerURLs(ctx context.Context, owner string) (int64, error) {
+	args := m.Called(ctx, owner)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestErasureService_ProcessNext_DeletesVerifiesAndCompletes(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockErasureRepository)
+	mockCache := new(MockCache)
+	edge := new(MockEdgePurger)
+	service := NewErasureService(repo, mockCache).WithEdgePurger(edge)
+	service.batchSize = 2
+
+	req := domain.NewErasureRequest("user1", "user1")
+	req.Status = domain.ErasureRunning
+	alias := "promo"
+	firstBatch := []*domain.URL{{ID: "1", ShortCode: "abc123"}, {ID: "2", ShortCode: "promo", CustomAlias: &alias}}
+	secondBatch := []*domain.URL{{ID: "3", ShortCode: "xyz789"}}
+
+	repo.On("ClaimNext", ctx, mock.Anything).Return(req, nil).Once()
+	repo.On("DeleteOwnerBatch", ctx, "user1", 2).Return(firstBatch, int64(10), nil).Once()
+	repo.On("DeleteOwnerBatch", ctx, "user1", 2).Return(secondBatch, int64(5), nil).Once()
+	repo.On("CountOwnerURLs", ctx, "user1").Return(int64(0), nil)
+	repo.On("Update", ctx, req).Return(nil)
+	mockCache.On("DeleteURL", ctx, mock.Anything).Return(nil)
+	edge.On("PurgeLinks", ctx, firstBatch).Return(nil).Once()
+	edge.On("PurgeLinks", ctx, secondBatch).Return(nil).Once()
+
+	// Act
+	processed, err := service.ProcessNext(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, domain.ErasureCompleted, req.Status)
+	assert.Equal(t, int64(3), req.URLsDeleted)
+	assert.Equal(t, int64(15), req.ClicksDeleted)
+	assert.Empty(t, req.Owner, "owner is forgotten once erased")
+	assert.Equal(t, domain.SubjectHash("user1"), req.SubjectHash)
+	mockCache.AssertNumberOfCalls(t, "DeleteURL", 3)
+	edge.AssertExpectations(t) // One purge request per batch
+}
+
+func TestErasureService_ProcessNext_FailsVerification(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockErasureRepository)
+	service := NewErasureService(repo, new(MockCache))
+
+	req := domain.NewErasureRequest("user1", "admin")
+	repo.On("ClaimNext", ctx, mock.Anything).Return(req, nil).Once()
+	repo.On("DeleteOwnerBatch", ctx, "user1", mock.Anything).Return([]*domain.URL{}, int64(0), nil)
+	repo.On("CountOwnerURLs", ctx, "user1").Return(int64(1), nil) // Created while we were deleting
+	repo.On("Update", ctx, req).Return(nil)
+
+	// Act
+	processed, err := service.ProcessNext(ctx)
+
+	// Assert
+	assert.True(t, processed)
+	assert.Error(t, err)
+	assert.Equal(t, domain.ErasureFailed, req.Status)
+	assert.Contains(t, req.Error, "verification failed")
+	assert.Equal(t, "user1", req.Owner, "failed requests keep the owner so they can be retried")
+}
+
+func TestErasureService_RequestErasure_ReturnsActiveRequest(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockErasureRepository)
+	service := NewErasureService(repo, new(MockCache))
+
+	active := domain.NewErasureRequest("user1", "user1")
+	repo.On("FindActiveByOwner", ctx, "user1").Return(active, nil)
+
+	// Act
+	req, err := service.RequestErasure(ctx, "user1", "user1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Same(t, active, req)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
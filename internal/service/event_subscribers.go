@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/ctxutil"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/events"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/notify"
+	"url-shortener/internal/repository"
+)
+
+// SUBSCRIBERS:
+// Side effects of link events, each independent of the others and of the
+// code that published the event. main subscribes the ones it needs; CDN
+// purges are subscribed by URLService itself (it owns the edge purger).
+
+// SubscribeMetrics counts created links and recorded clicks
+// Every way of creating a link (API, imports, Slack, templates) is counted,
+// because they all publish URLCreated
+func SubscribeMetrics(bus *events.Bus) {
+	events.Subscribe(bus, func(ctx context.Context, e events.URLCreated) {
+		metrics.RecordURLCreated()
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.ClickRecorded) {
+		metrics.RecordClickRecorded()
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.AliasFlagged) {
+		metrics.RecordConfusableAlias("flagged")
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.ApprovalRequested) {
+		metrics.RecordLinkApproval("requested")
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.ApprovalDecided) {
+		if e.Approved {
+			metrics.RecordLinkApproval("approved")
+		} else {
+			metrics.RecordLinkApproval("rejected")
+		}
+	})
+}
+
+// SubscribeNotifications sends link life cycle events (created, deleted,
+// expired), flagged aliases and the approval workflow to the notification
+// channels (webhook, email)
+//
+// approval.requested tells the approvers a link waits for them;
+// approval.decided tells the contributor (data.submitted_by) the outcome.
+//
+// Delivery runs in the background: a slow webhook must not hold up the
+// request that created the link. Like the link warnings, it is at most
+// once - a failed delivery is logged, not retried.
+func SubscribeNotifications(bus *events.Bus, notifier notify.Notifier) {
+	send := func(ctx context.Context, event notify.Event) {
+		ctx = context.WithoutCancel(ctx) // The request may be over before delivery
+		go func() {
+			if err := notifier.Notify(ctx, event); err != nil {
+				fmt.Printf("Warning: failed to deliver %s event: %v\n", event.Type, err)
+			}
+		}()
+	}
+
+	events.Subscribe(bus, func(ctx context.Context, e events.URLCreated) {
+		send(ctx, linkEvent(e.EventName(), e.URL, e.OccurredAt, map[string]interface{}{
+			"original_url": e.URL.OriginalURL,
+		}))
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.URLDeleted) {
+		send(ctx, linkEvent(e.EventName(), e.URL, e.OccurredAt, map[string]interface{}{
+			"permanent": e.Permanent,
+		}))
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.URLExpired) {
+		send(ctx, linkEvent(e.EventName(), e.URL, e.OccurredAt, nil))
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.AliasFlagged) {
+		send(ctx, linkEvent(e.EventName(), e.URL, e.OccurredAt, map[string]interface{}{
+			"imitates":     e.Imitates,
+			"original_url": e.URL.OriginalURL,
+		}))
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.ApprovalRequested) {
+		send(ctx, linkEvent(e.EventName(), e.URL, e.OccurredAt, map[string]interface{}{
+			"submitted_by": e.URL.SubmittedBy,
+			"original_url": e.URL.OriginalURL,
+		}))
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.ApprovalDecided) {
+		send(ctx, linkEvent(e.EventName(), e.URL, e.OccurredAt, map[string]interface{}{
+			"approved":     e.Approved,
+			"reason":       e.Reason,
+			"decided_by":   e.Actor,
+			"submitted_by": e.URL.SubmittedBy,
+		}))
+	})
+}
+
+// SubscribeAudit records link life cycle events in the audit log
+func SubscribeAudit(bus *events.Bus, audit repository.AuditRepository) {
+	record := func(ctx context.Context, action domain.AuditAction, url *domain.URL, actor string) {
+		entry := &domain.AuditEntry{
+			Action:    action,
+			Workspace: url.CreatedBy,
+			URLID:     url.ID,
+			ShortCode: url.ShortCode,
+			Actor:     actor,
+			IPAddress: ctxutil.ClientIP(ctx), // "" for background workers (expiry)
+		}
+		if err := audit.Record(ctx, entry); err != nil {
+			fmt.Printf("Warning: failed to audit %s of %s: %v\n", action, url.ShortCode, err)
+		}
+	}
+
+	events.Subscribe(bus, func(ctx context.Context, e events.URLCreated) {
+		record(ctx, domain.AuditLinkCreated, e.URL, e.Actor)
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.URLDeleted) {
+		action := domain.AuditLinkDeleted
+		if e.Permanent {
+			action = domain.AuditLinkPurged
+		}
+		record(ctx, action, e.URL, e.Actor)
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.URLExpired) {
+		record(ctx, domain.AuditLinkExpired, e.URL, domain.AuditActorSystem)
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.AliasFlagged) {
+		record(ctx, domain.AuditAliasFlagged, e.URL, e.Actor)
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.ApprovalRequested) {
+		record(ctx, domain.AuditApprovalRequested, e.URL, e.Actor)
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.ApprovalDecided) {
+		action := domain.AuditLinkRejected
+		if e.Approved {
+			action = domain.AuditLinkApproved
+		}
+		record(ctx, action, e.URL, e.Actor)
+	})
+}
+
+// linkEvent converts a link event into a notification
+func linkEvent(eventType string, url *domain.URL, occurredAt time.Time, data map[string]interface{}) notify.Event {
+	return notify.Event{
+		Type:       eventType,
+		URLID:      url.ID,
+		ShortCode:  url.ShortCode,
+		Owner:      url.CreatedBy,
+		OccurredAt: occurredAt,
+		Data:       data,
+	}
+}
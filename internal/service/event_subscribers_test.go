@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"url-shortener/internal/ctxutil"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/events"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/notify"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSubscribeMetrics(t *testing.T) {
+	// Arrange
+	bus := events.NewBus()
+	SubscribeMetrics(bus)
+	created := testutil.ToFloat64(metrics.URLsCreatedTotal)
+	clicks := testutil.ToFloat64(metrics.ClicksRecordedTotal)
+
+	// Act
+	bus.Publish(context.Background(), events.URLCreated{URL: &domain.URL{ShortCode: "abc"}})
+	bus.Publish(context.Background(), events.ClickRecorded{URL: &domain.URL{ShortCode: "abc"}})
+	bus.Publish(context.Background(), events.ClickRecorded{URL: &domain.URL{ShortCode: "abc"}})
+
+	// Assert
+	assert.Equal(t, created+1, testutil.ToFloat64(metrics.URLsCreatedTotal))
+	assert.Equal(t, clicks+2, testutil.ToFloat64(metrics.ClicksRecordedTotal))
+}
+
+func TestSubscribeAudit(t *testing.T) {
+	url := &domain.URL{ID: "123", ShortCode: "abc", CreatedBy: "alice"}
+
+	tests := []struct {
+		name       string
+		event      events.Event
+		wantAction domain.AuditAction
+		wantActor  string
+	}{
+		{name: "created", event: events.URLCreated{URL: url, Actor: "alice"}, wantAction: domain.AuditLinkCreated, wantActor: "alice"},
+		{name: "deleted", event: events.URLDeleted{URL: url, Actor: "admin"}, wantAction: domain.AuditLinkDeleted, wantActor: "admin"},
+		{name: "purged", event: events.URLDeleted{URL: url, Actor: "alice", Permanent: true}, wantAction: domain.AuditLinkPurged, wantActor: "alice"},
+		{name: "expired", event: events.URLExpired{URL: url}, wantAction: domain.AuditLinkExpired, wantActor: domain.AuditActorSystem},
+		{name: "approval requested", event: events.ApprovalRequested{URL: url, Actor: "dana@example.com"}, wantAction: domain.AuditApprovalRequested, wantActor: "dana@example.com"},
+		{name: "approved", event: events.ApprovalDecided{URL: url, Actor: "erin@example.com", Approved: true}, wantAction: domain.AuditLinkApproved, wantActor: "erin@example.com"},
+		{name: "rejected", event: events.ApprovalDecided{URL: url, Actor: "erin@example.com"}, wantAction: domain.AuditLinkRejected, wantActor: "erin@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := ctxutil.WithClientIP(context.Background(), "203.0.113.7")
+			bus := events.NewBus()
+			audit := new(MockAuditRepository)
+			SubscribeAudit(bus, audit)
+
+			audit.On("Record", ctx, mock.MatchedBy(func(e *domain.AuditEntry) bool {
+				return e.Action == tt.wantAction && e.Actor == tt.wantActor &&
+					e.URLID == "123" && e.Workspace == "alice" && e.IPAddress == "203.0.113.7"
+			})).Return(nil).Once()
+
+			// Act
+			bus.Publish(ctx, tt.event)
+
+			// Assert
+			audit.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSubscribeNotifications(t *testing.T) {
+	// Arrange
+	bus := events.NewBus()
+	notifier := new(MockNotifier)
+	SubscribeNotifications(bus, notifier)
+
+	delivered := make(chan notify.Event, 1)
+	notifier.On("Notify", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { delivered <- args.Get(1).(notify.Event) }).
+		Return(nil)
+
+	url := &domain.URL{ID: "123", ShortCode: "abc", CreatedBy: "alice"}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Act
+	bus.Publish(ctx, events.URLDeleted{URL: url, Permanent: true})
+	cancel() // The request is over; delivery goes on
+
+	// Assert
+	select {
+	case event := <-delivered:
+		assert.Equal(t, "url.deleted", event.Type)
+		assert.Equal(t, "alice", event.Owner)
+		assert.Equal(t, true, event.Data["permanent"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("the event was not delivered")
+	}
+	bus.Publish(ctx, events.ClickRecorded{URL: url}) // Clicks are never sent
+	notifier.AssertNumberOfCalls(t, "Notify", 1)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// ExportService streams everything a user owns, for data portability
+//
+// WHY A CALLBACK INSTEAD OF RETURNING A SLICE?
+// A user may own millions of links. Loading them all would use unbounded
+// memory; instead each page is handed to the caller (the HTTP response)
+// and dropped before the next page is read
+type ExportService struct {
+	repo     repository.ExportRepository
+	pageSize int
+}
+
+// NewExportService creates a new export service
+func NewExportService(repo repository.ExportRepository) *ExportService {
+	return &ExportService{
+		repo:     repo,
+		pageSize: 500,
+	}
+}
+
+// Export calls fn for every URL owned by owner, oldest first
+// Stops at the first error returned by fn or the repository
+// Viewers get sensitive links without their destinations (see visibleTo)
+func (s *ExportService) Export(ctx context.Context, owner string, fn func(*domain.ExportRecord) error) error {
+	var cursor *domain.ExportCursor
+	for {
+		records, err := s.repo.ListForExport(ctx, owner, cursor, s.pageSize)
+		if err != nil {
+			return fmt.Errorf("failed to export URLs: %w", err)
+		}
+
+		for _, record := range records {
+			if !canSeeDestinations(ctx, record.URL) {
+				redacted := *record
+				redacted.URL = record.URL.WithoutDestinations()
+				record = &redacted
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+
+		// A short page means we reached the end
+		if len(records) < s.pageSize {
+			return nil
+		}
+		cursor = records[len(records)-1].CursorAfter()
+	}
+}
+
+// StreamInventory calls fn for every link owned by owner after the cursor
+// (nil = from the start), oldest first, without click stats
+// Sync tools resume an interrupted stream from the last link they got.
+// Like Export, viewers get sensitive links without their destinations.
+func (s *ExportService) StreamInventory(ctx context.Context, owner string, after *domain.ExportCursor, fn func(*domain.URL) error) error {
+	cursor := after
+	for {
+		urls, err := s.repo.ListInventory(ctx, owner, cursor, s.pageSize)
+		if err != nil {
+			return fmt.Errorf("failed to list URLs: %w", err)
+		}
+
+		for _, url := range urls {
+			if err := fn(visibleTo(ctx, url)); err != nil {
+				return err
+			}
+		}
+
+		if len(urls) < s.pageSize {
+			return nil
+		}
+		cursor = domain.CursorAfterURL(urls[len(urls)-1])
+	}
+}
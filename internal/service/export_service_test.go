@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockExportRepository is a mock implementation of ExportRepository
+type MockExportRepository struct {
+	mock.Mock
+}
+
+func (m *MockExportRepository) ListForExport(ctx context.Context, owner string, after *domain.ExportCursor, limit int) ([]*domain.ExportRecord, error) {
+	args := m.Called(ctx, owner, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ExportRecord), args.Error(1)
+}
+
+func (m *MockExportRepository) ListInventory(ctx context.Context, owner string, after *domain.ExportCursor, limit int) ([]*domain.URL, error) {
+	args := m.Called(ctx, owner, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URL), args.Error(1)
+}
+
+func exportRecords(n int) []*domain.ExportRecord {
+	records := make([]*domain.ExportRecord, n)
+	for i := range records {
+		records[i] = &domain.ExportRecord{URL: &domain.URL{
+			ID:        fmt.Sprintf("id-%d", i),
+			CreatedAt: time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC),
+		}}
+	}
+	return records
+}
+
+func TestExportService_PagesWithCursor(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockExportRepository)
+	service := NewExportService(repo)
+	service.pageSize = 2
+
+	firstPage := exportRecords(2)
+	secondPage := exportRecords(1)
+	repo.On("ListForExport", ctx, "user1", (*domain.ExportCursor)(nil), 2).Return(firstPage, nil).Once()
+	repo.On("ListForExport", ctx, "user1", firstPage[1].CursorAfter(), 2).Return(secondPage, nil).Once()
+
+	// Act
+	var seen []string
+	err := service.Export(ctx, "user1", func(record *domain.ExportRecord) error {
+		seen = append(seen, record.URL.ID)
+		return nil
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id-0", "id-1", "id-0"}, seen)
+	repo.AssertExpectations(t)
+}
+
+func TestExportService_StopsOnCallbackError(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockExportRepository)
+	service := NewExportService(repo)
+	service.pageSize = 2
+
+	repo.On("ListForExport", ctx, "user1", (*domain.ExportCursor)(nil), 2).Return(exportRecords(2), nil).Once()
+	clientGone := errors.New("client disconnected")
+
+	// Act
+	err := service.Export(ctx, "user1", func(*domain.ExportRecord) error { return clientGone })
+
+	// Assert
+	assert.ErrorIs(t, err, clientGone)
+	repo.AssertNumberOfCalls(t, "ListForExport", 1)
+}
+
+func TestExportService_StreamInventoryResumes(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockExportRepository)
+	service := NewExportService(repo)
+	service.pageSize = 2
+
+	records := exportRecords(3)
+	resumeAt := records[0].CursorAfter()
+	repo.On("ListInventory", ctx, "user1", resumeAt, 2).Return([]*domain.URL{records[1].URL, records[2].URL}, nil).Once()
+	repo.On("ListInventory", ctx, "user1", records[2].CursorAfter(), 2).Return([]*domain.URL{}, nil).Once()
+
+	// Act
+	var seen []string
+	err := service.StreamInventory(ctx, "user1", resumeAt, func(url *domain.URL) error {
+		seen = append(seen, url.ID)
+		return nil
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id-1", "id-2"}, seen)
+	repo.AssertExpectations(t)
+}
+
+func TestExportService_HidesSensitiveDestinationsFromViewers(t *testing.T) {
+	records := func() []*domain.ExportRecord {
+		return []*domain.ExportRecord{
+			{URL: &domain.URL{ID: "a", OriginalURL: "https://example.com/public"}},
+			{URL: &domain.URL{ID: "b", OriginalURL: "https://example.com/unannounced", Visibility: domain.VisibilitySensitive}},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		expected  []string
+	}{
+		{name: "owner", principal: &auth.Principal{ID: "team1"}, expected: []string{"https://example.com/public", "https://example.com/unannounced"}},
+		{name: "viewer", principal: teamViewer, expected: []string{"https://example.com/public", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			repo := new(MockExportRepository)
+			stored := records()
+			repo.On("ListForExport", ctx, "team1", (*domain.ExportCursor)(nil), 500).Return(stored, nil)
+			repo.On("ListInventory", ctx, "team1", (*domain.ExportCursor)(nil), 500).Return([]*domain.URL{stored[0].URL, stored[1].URL}, nil)
+			service := NewExportService(repo)
+
+			// Act
+			var exported, streamed []string
+			errExport := service.Export(ctx, "team1", func(record *domain.ExportRecord) error {
+				exported = append(exported, record.URL.OriginalURL)
+				return nil
+			})
+			errStream := service.StreamInventory(ctx, "team1", nil, func(url *domain.URL) error {
+				streamed = append(streamed, url.OriginalURL)
+				return nil
+			})
+
+			// Assert
+			require.NoError(t, errExport)
+			require.NoError(t, errStream)
+			assert.Equal(t, tt.expected, exported)
+			assert.Equal(t, tt.expected, streamed)
+			assert.Equal(t, "https://example.com/unannounced", stored[1].URL.OriginalURL, "the stored link must not change")
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/storage/blob"
+)
+
+// defaultFileContentType is used when the upload doesn't say what it is
+const defaultFileContentType = "application/octet-stream"
+
+// FileService turns uploaded files into short links
+//
+// The file goes to blob storage, the link to the database. Everything
+// else - expiry, click counting, stats, ownership - is the same as for
+// any link (see domain.WithFile).
+type FileService struct {
+	links   LinkCreator
+	store   blob.Store
+	maxSize int64                  // Largest accepted file in bytes
+	newKey  func() (string, error) // Object key of a new upload (tests pin it)
+}
+
+// NewFileService creates a file service that accepts files up to maxSize bytes
+func NewFileService(links LinkCreator, store blob.Store, maxSize int64) *FileService {
+	return &FileService{
+		links:   links,
+		store:   store,
+		maxSize: maxSize,
+		newKey:  newFileKey,
+	}
+}
+
+// MaxSize returns the largest accepted file in bytes
+func (s *FileService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Upload stores size bytes of body and creates a link that serves them
+// The link belongs to the caller and expires after expiresIn (0 = never).
+func (s *FileService) Upload(ctx context.Context, name, contentType string, body io.Reader, size int64, expiresIn time.Duration) (*domain.URL, error) {
+	if size > s.maxSize {
+		return nil, fmt.Errorf("%w: the limit is %d bytes", domain.ErrFileTooLarge, s.maxSize)
+	}
+	if contentType == "" {
+		contentType = defaultFileContentType
+	}
+
+	key, err := s.newKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate file key: %w", err)
+	}
+	file := domain.FileAttachment{Key: key, Name: name, ContentType: contentType, Size: size}
+	if err := file.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err := s.store.Put(ctx, file.Key, body, file.Size, file.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	url, err := s.links.CreateShortURL(ctx, "", "", auth.FromContext(ctx).ID, expiresIn, domain.WithFile(file))
+	if err != nil {
+		// No link (e.g. quota exceeded) - don't keep a file nobody can reach
+		// Detached: the request may be canceled, the cleanup should still run
+		if deleteErr := s.store.Delete(context.WithoutCancel(ctx), file.Key); deleteErr != nil {
+			fmt.Printf("Warning: failed to delete orphaned file %s: %v\n", file.Key, deleteErr)
+		}
+		return nil, err
+	}
+	return url, nil
+}
+
+// Open returns the file of a file link for streaming
+// The caller must close the object's Body.
+func (s *FileService) Open(ctx context.Context, url *domain.URL) (*blob.Object, error) {
+	if url.File == nil {
+		return nil, domain.ErrURLNotFound
+	}
+
+	object, err := s.store.Get(ctx, url.File.Key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, fmt.Errorf("%w: file of %s is missing", domain.ErrURLNotFound, url.ShortCode)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return object, nil
+}
+
+// newFileKey returns a random object key, e.g. "files/3f2a9c..."
+// Random rather than the short code: codes can be reused after a purge,
+// keys never are
+func newFileKey() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "files/" + hex.EncodeToString(id), nil
+}
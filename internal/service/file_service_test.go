@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/storage/blob"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockBlobStore is a mock implementation of blob.Store
+type MockBlobStore struct {
+	mock.Mock
+}
+
+func (m *MockBlobStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	args := m.Called(ctx, key, body, size, contentType)
+	return args.Error(0)
+}
+
+func (m *MockBlobStore) Get(ctx context.Context, key string) (*blob.Object, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*blob.Object), args.Error(1)
+}
+
+func (m *MockBlobStore) Delete(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+// ==================== TESTS ====================
+
+func TestFileService_Upload(t *testing.T) {
+	tests := []struct {
+		name            string
+		fileName        string
+		contentType     string
+		size            int64
+		putErr          error
+		createErr       error
+		wantContentType string
+		wantErr         error
+		expectPut       bool
+		expectDelete    bool
+	}{
+		{
+			name:            "uploaded",
+			fileName:        "report.pdf",
+			contentType:     "application/pdf",
+			size:            8,
+			wantContentType: "application/pdf",
+			expectPut:       true,
+		},
+		{
+			name:            "no content type",
+			fileName:        "data.bin",
+			size:            8,
+			wantContentType: "application/octet-stream",
+			expectPut:       true,
+		},
+		{
+			name:     "too large",
+			fileName: "huge.iso",
+			size:     1025,
+			wantErr:  domain.ErrFileTooLarge,
+		},
+		{
+			name:     "empty",
+			fileName: "empty.txt",
+			size:     0,
+			wantErr:  domain.ErrInvalidFile,
+		},
+		{
+			name:     "no name",
+			fileName: "  ",
+			size:     8,
+			wantErr:  domain.ErrInvalidFile,
+		},
+		{
+			name:            "storage down",
+			fileName:        "report.pdf",
+			contentType:     "application/pdf",
+			size:            8,
+			putErr:          assert.AnError,
+			wantContentType: "application/pdf",
+			wantErr:         assert.AnError,
+			expectPut:       true,
+		},
+		{
+			name:            "quota exceeded removes the file",
+			fileName:        "report.pdf",
+			contentType:     "application/pdf",
+			size:            8,
+			createErr:       domain.ErrQuotaExceeded,
+			wantContentType: "application/pdf",
+			wantErr:         domain.ErrQuotaExceeded,
+			expectPut:       true,
+			expectDelete:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+			links := new(MockLinkCreator)
+			store := new(MockBlobStore)
+			body := strings.NewReader("%PDF-1.7")
+
+			svc := NewFileService(links, store, 1024)
+			svc.newKey = func() (string, error) { return "files/ab12", nil }
+
+			if tt.expectPut {
+				store.On("Put", ctx, "files/ab12", body, tt.size, tt.wantContentType).Return(tt.putErr)
+			}
+			if tt.expectPut && tt.putErr == nil {
+				var url *domain.URL
+				if tt.createErr == nil {
+					url = &domain.URL{ShortCode: "abc123"}
+				}
+				// A file link has no destination
+				links.On("CreateShortURL", ctx, "", "", "user1", "").Return(url, tt.createErr)
+			}
+			if tt.expectDelete {
+				store.On("Delete", mock.Anything, "files/ab12").Return(nil)
+			}
+
+			// Act
+			url, err := svc.Upload(ctx, tt.fileName, tt.contentType, body, tt.size, 0)
+
+			// Assert
+			store.AssertExpectations(t)
+			links.AssertExpectations(t)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				if !tt.expectPut {
+					store.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				}
+				if !tt.expectDelete {
+					store.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "abc123", url.ShortCode)
+		})
+	}
+}
+
+func TestFileService_Open(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     *domain.URL
+		getErr  error
+		wantErr error
+	}{
+		{
+			name: "found",
+			url:  &domain.URL{ShortCode: "abc123", File: &domain.FileAttachment{Key: "files/ab12"}},
+		},
+		{
+			name:    "file deleted from the bucket",
+			url:     &domain.URL{ShortCode: "abc123", File: &domain.FileAttachment{Key: "files/ab12"}},
+			getErr:  blob.ErrNotFound,
+			wantErr: domain.ErrURLNotFound,
+		},
+		{
+			name:    "storage down",
+			url:     &domain.URL{ShortCode: "abc123", File: &domain.FileAttachment{Key: "files/ab12"}},
+			getErr:  assert.AnError,
+			wantErr: assert.AnError,
+		},
+		{
+			name:    "not a file link",
+			url:     &domain.URL{ShortCode: "abc123", OriginalURL: "https://example.com"},
+			wantErr: domain.ErrURLNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			store := new(MockBlobStore)
+			object := &blob.Object{Body: io.NopCloser(strings.NewReader("hello")), Size: 5}
+			if tt.getErr != nil {
+				object = nil
+			}
+			store.On("Get", ctx, "files/ab12").Return(object, tt.getErr)
+			svc := NewFileService(nil, store, 1024)
+
+			// Act
+			got, err := svc.Open(ctx, tt.url)
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, object, got)
+		})
+	}
+}
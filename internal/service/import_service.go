@@ -0,0 +1,375 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/jobs"
+)
+
+// aliasCheckBatchSize is how many aliases are checked per FindTakenCodes query
+const aliasCheckBatchSize = 500
+
+// ImportJobKind is the job kind that runs a background import
+// Register ImportService.RunJob for it on the job queue
+const ImportJobKind = "links.import"
+
+// ImportJobQueue runs background imports and reports on them
+// Implemented by jobs.Queue
+type ImportJobQueue interface {
+	JobEnqueuer
+	Get(ctx context.Context, id string) (*jobs.Job, error)
+}
+
+// importPayload is the input of an ImportJobKind job
+// The caller's principal travels with the rows: the worker creates the
+// links as them, so quotas, workspace roles and approvals still apply
+type importPayload struct {
+	Principal auth.Principal     `json:"principal"`
+	DryRun    bool               `json:"dry_run"`
+	Total     int                `json:"total"`
+	Rows      []domain.ImportRow `json:"rows"`
+}
+
+// importResult is the stored result of a finished ImportJobKind job
+type importResult struct {
+	Created int                      `json:"created"`
+	Failed  int                      `json:"failed"`
+	Results []domain.ImportRowResult `json:"results"`
+}
+
+// ImportService creates links in bulk from files exported by other shorteners
+//
+// Small files are imported while the client waits (Import). Large files run
+// as a BACKGROUND JOB (StartImport) and the client polls GetJob for progress.
+//
+// WHERE BACKGROUND IMPORTS RUN:
+// With a job queue (WithJobs), the rows are stored as an ImportJobKind job:
+// the import survives restarts, is visible on every instance, and a worker
+// that dies mid-import hands it to another one. Progress is only known once
+// the job has finished. Without a queue, imports run in a goroutine of this
+// instance and live in memory for jobRetention (lost on restart).
+type ImportService struct {
+	urls         *URLService
+	jobs         ImportJobQueue // Optional: without it, background imports live in memory
+	jobRetention time.Duration
+
+	mu      sync.Mutex
+	running map[string]*domain.ImportJob
+}
+
+// NewImportService creates a new import service
+func NewImportService(urls *URLService) *ImportService {
+	return &ImportService{
+		urls:         urls,
+		jobRetention: 24 * time.Hour,
+		running:      make(map[string]*domain.ImportJob),
+	}
+}
+
+// WithJobs runs background imports on the job queue
+// Register RunJob for ImportJobKind on the same queue
+func (s *ImportService) WithJobs(queue ImportJobQueue) *ImportService {
+	s.jobs = queue
+	return s
+}
+
+// Import imports rows synchronously and returns the finished job
+func (s *ImportService) Import(ctx context.Context, rows []domain.ImportRow, createdBy string, dryRun bool) *domain.ImportJob {
+	job := s.newJob(rows, createdBy, dryRun)
+	s.run(ctx, job, rows, false)
+	return s.snapshot(job)
+}
+
+// StartImport imports rows in the background and returns the pending job
+// The import keeps running after the HTTP request that started it has finished
+func (s *ImportService) StartImport(ctx context.Context, rows []domain.ImportRow, createdBy string, dryRun bool) (*domain.ImportJob, error) {
+	if s.jobs == nil {
+		job := s.newJob(rows, createdBy, dryRun)
+		pending := s.snapshot(job)
+		go s.run(context.WithoutCancel(ctx), job, rows, false)
+		return pending, nil
+	}
+
+	payload := importPayload{
+		Principal: *auth.FromContext(ctx),
+		DryRun:    dryRun,
+		Total:     len(rows),
+		Rows:      rows,
+	}
+	queued, err := s.jobs.Enqueue(ctx, ImportJobKind, payload, createdBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start import: %w", err)
+	}
+	job := domain.NewImportJob(createdBy, len(rows), dryRun)
+	job.ID = queued.ID
+	job.CreatedAt = queued.CreatedAt
+	return job, nil
+}
+
+// GetJob returns a copy of a job
+// Returns domain.ErrImportJobNotFound if it is unknown (or already pruned)
+func (s *ImportService) GetJob(ctx context.Context, id string) (*domain.ImportJob, error) {
+	if s.jobs == nil {
+		s.mu.Lock()
+		job, ok := s.running[id]
+		s.mu.Unlock()
+		if !ok {
+			return nil, domain.ErrImportJobNotFound
+		}
+		return s.snapshot(job), nil
+	}
+
+	queued, err := s.jobs.Get(ctx, id)
+	if errors.Is(err, jobs.ErrNotFound) || (err == nil && queued.Kind != ImportJobKind) {
+		return nil, domain.ErrImportJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return importJobFromQueue(queued)
+}
+
+// RunJob is the jobs.Handler of ImportJobKind
+// Row failures are part of the result, so the job itself only fails when
+// its payload can't be read. A second attempt (the first worker died)
+// recognizes the aliased links the first one already created
+func (s *ImportService) RunJob(ctx context.Context, queued *jobs.Job) (interface{}, error) {
+	var payload importPayload
+	if err := queued.DecodePayload(&payload); err != nil {
+		return nil, err
+	}
+
+	ctx = auth.WithPrincipal(ctx, &payload.Principal)
+	job := domain.NewImportJob(queued.CreatedBy, len(payload.Rows), payload.DryRun)
+	s.run(ctx, job, payload.Rows, queued.Attempts > 1)
+
+	return importResult{Created: job.Created, Failed: job.Failed, Results: job.Results}, nil
+}
+
+// importJobFromQueue converts a queued ImportJobKind job to an import job
+func importJobFromQueue(queued *jobs.Job) (*domain.ImportJob, error) {
+	// Only the small fields are needed; the rows are skipped while decoding
+	var payload struct {
+		DryRun bool `json:"dry_run"`
+		Total  int  `json:"total"`
+	}
+	if err := queued.DecodePayload(&payload); err != nil {
+		return nil, err
+	}
+
+	job := &domain.ImportJob{
+		ID:         queued.ID,
+		CreatedBy:  queued.CreatedBy,
+		DryRun:     payload.DryRun,
+		State:      domain.ImportPending,
+		Total:      payload.Total,
+		Results:    []domain.ImportRowResult{},
+		CreatedAt:  queued.CreatedAt,
+		FinishedAt: queued.FinishedAt,
+	}
+
+	switch queued.Status {
+	case jobs.StatusRunning:
+		job.State = domain.ImportRunning
+	case jobs.StatusFailed:
+		job.State = domain.ImportFailed
+		job.Error = queued.Error
+	case jobs.StatusSucceeded:
+		var result importResult
+		if err := json.Unmarshal(queued.Result, &result); err != nil {
+			return nil, fmt.Errorf("invalid result of import job %s: %w", queued.ID, err)
+		}
+		job.State = domain.ImportCompleted
+		job.Processed = payload.Total
+		job.Created = result.Created
+		job.Failed = result.Failed
+		job.Results = result.Results
+	}
+	return job, nil
+}
+
+// newJob registers a job and prunes old finished ones
+func (s *ImportService) newJob(rows []domain.ImportRow, createdBy string, dryRun bool) *domain.ImportJob {
+	job := domain.NewImportJob(createdBy, len(rows), dryRun)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, old := range s.running {
+		if old.FinishedAt != nil && time.Since(*old.FinishedAt) > s.jobRetention {
+			delete(s.running, id)
+		}
+	}
+	s.running[job.ID] = job
+	return job
+}
+
+// snapshot copies a job under the lock so callers never race with the worker
+func (s *ImportService) snapshot(job *domain.ImportJob) *domain.ImportJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *job
+	copied.Results = append([]domain.ImportRowResult(nil), job.Results...)
+	return &copied
+}
+
+// run processes every row and records the results on the job
+// rerun is set when an earlier attempt of the same job may have created
+// some of the links already
+func (s *ImportService) run(ctx context.Context, job *domain.ImportJob, rows []domain.ImportRow, rerun bool) {
+	s.mu.Lock()
+	job.State = domain.ImportRunning
+	s.mu.Unlock()
+
+	// Check all aliases up front instead of one query per row
+	taken := s.takenAliases(ctx, rows)
+	claimed := make(map[string]bool) // Aliases used by earlier rows of the same file
+
+	for _, row := range rows {
+		wantAlias := row.Alias != "" && domain.ValidateAlias(row.Alias) == nil &&
+			!taken[row.Alias] && !claimed[row.Alias]
+
+		var result domain.ImportRowResult
+		if imported := s.importedBefore(ctx, row, job.CreatedBy, rerun && !job.DryRun && taken[row.Alias]); imported != nil {
+			result = *imported
+		} else if job.DryRun {
+			result = s.checkRow(row, job.CreatedBy, wantAlias)
+		} else {
+			result = s.createRow(ctx, row, job.CreatedBy, wantAlias)
+		}
+		if result.AliasPreserved {
+			claimed[row.Alias] = true
+		}
+
+		s.mu.Lock()
+		job.Results = append(job.Results, result)
+		job.Processed++
+		if result.Status == domain.ImportRowFailed {
+			job.Failed++
+		} else {
+			job.Created++
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	job.State = domain.ImportCompleted
+	job.FinishedAt = &now
+	s.mu.Unlock()
+}
+
+// takenAliases looks up which of the file's aliases already exist, in batches
+// If the lookup fails, every alias is treated as free and conflicts are
+// caught when the link is created
+func (s *ImportService) takenAliases(ctx context.Context, rows []domain.ImportRow) map[string]bool {
+	seen := make(map[string]bool)
+	var aliases []string
+	for _, row := range rows {
+		if row.Alias != "" && !seen[row.Alias] && domain.ValidateAlias(row.Alias) == nil {
+			seen[row.Alias] = true
+			aliases = append(aliases, row.Alias)
+		}
+	}
+
+	taken := make(map[string]bool)
+	for start := 0; start < len(aliases); start += aliasCheckBatchSize {
+		end := min(start+aliasCheckBatchSize, len(aliases))
+		batch, err := s.urls.urlRepo.FindTakenCodes(ctx, aliases[start:end])
+		if err != nil {
+			fmt.Printf("Warning: failed to check import aliases: %v\n", err)
+			continue
+		}
+		for alias := range batch {
+			taken[alias] = true
+		}
+	}
+	return taken
+}
+
+// importedBefore returns the result of a row that an earlier attempt of the
+// job already created: its alias is taken by a link of the same owner with
+// the same destination. check = false skips the lookup
+func (s *ImportService) importedBefore(ctx context.Context, row domain.ImportRow, createdBy string, check bool) *domain.ImportRowResult {
+	if !check {
+		return nil
+	}
+	url, err := s.urls.urlRepo.GetByCustomAlias(ctx, row.Alias)
+	if err != nil || url.CreatedBy != createdBy || url.OriginalURL != row.URL {
+		return nil
+	}
+
+	result := newRowResult(row)
+	result.Status = domain.ImportRowCreated
+	result.ShortCode = url.ShortCode
+	result.AliasPreserved = true
+	return &result
+}
+
+// checkRow validates a row without creating anything (dry run)
+func (s *ImportService) checkRow(row domain.ImportRow, createdBy string, useAlias bool) domain.ImportRowResult {
+	result := newRowResult(row)
+
+	// Generated codes are only known after creation; validate with a placeholder
+	url := domain.NewURL(row.URL, "dry-run", createdBy)
+	if useAlias {
+		url.ShortCode = row.Alias
+		url.WithCustomAlias(row.Alias)
+		result.ShortCode = row.Alias
+		result.AliasPreserved = true
+	}
+
+	if err := url.Validate(); err != nil {
+		result.Status = domain.ImportRowFailed
+		result.Error = err.Error()
+		result.ShortCode = ""
+		result.AliasPreserved = false
+		return result
+	}
+
+	result.Status = domain.ImportRowWouldCreate
+	return result
+}
+
+// createRow creates the link for a row
+// If the alias cannot be kept, the link gets a generated code instead
+func (s *ImportService) createRow(ctx context.Context, row domain.ImportRow, createdBy string, useAlias bool) domain.ImportRowResult {
+	result := newRowResult(row)
+
+	alias := ""
+	if useAlias {
+		alias = row.Alias
+	}
+
+	url, err := s.urls.CreateShortURL(ctx, row.URL, alias, createdBy, 0)
+	if err != nil && alias != "" {
+		// Someone may have claimed the alias since we checked; fall back to a generated code
+		url, err = s.urls.CreateShortURL(ctx, row.URL, "", createdBy, 0)
+		alias = ""
+	}
+	if err != nil {
+		result.Status = domain.ImportRowFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = domain.ImportRowCreated
+	result.ShortCode = url.ShortCode
+	result.AliasPreserved = alias != ""
+	return result
+}
+
+// newRowResult starts a result with the row's input values
+func newRowResult(row domain.ImportRow) domain.ImportRowResult {
+	return domain.ImportRowResult{
+		Line:  row.Line,
+		Alias: row.Alias,
+		URL:   row.URL,
+	}
+}
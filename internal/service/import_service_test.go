@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/jobs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockImportJobQueue is a mock implementation of ImportJobQueue
+type MockImportJobQueue struct {
+	MockJobEnqueuer
+}
+
+func (m *MockImportJobQueue) Get(ctx context.Context, id string) (*jobs.Job, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*jobs.Job), args.Error(1)
+}
+
+func TestImportService_PreservesFreeAliases(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	importer := NewImportService(NewURLService(mockURLRepo, new(MockClickRepository)))
+
+	rows := []domain.ImportRow{
+		{Line: 2, Alias: "summer", URL: "https://example.com/summer"},
+		{Line: 3, Alias: "taken", URL: "https://example.com/taken"},
+		{Line: 4, Alias: "summer", URL: "https://example.com/duplicate"},
+		{Line: 5, Alias: "", URL: "not-a-url"},
+	}
+
+	// All aliases are checked in one query
+	mockURLRepo.On("FindTakenCodes", ctx, []string{"summer", "taken"}).Return(map[string]bool{"taken": true}, nil).Once()
+	mockURLRepo.On("ExistsCustomAlias", ctx, "summer").Return(false, nil)
+	mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
+	mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
+
+	// Act
+	job := importer.Import(ctx, rows, "user1", false)
+
+	// Assert
+	assert.Equal(t, domain.ImportCompleted, job.State)
+	assert.Equal(t, 4, job.Processed)
+	assert.Equal(t, 3, job.Created)
+	assert.Equal(t, 1, job.Failed)
+	require.Len(t, job.Results, 4)
+
+	assert.Equal(t, "summer", job.Results[0].ShortCode)
+	assert.True(t, job.Results[0].AliasPreserved)
+	assert.False(t, job.Results[1].AliasPreserved, "taken alias gets a generated code")
+	assert.Equal(t, domain.ImportRowCreated, job.Results[1].Status)
+	assert.False(t, job.Results[2].AliasPreserved, "second use of an alias in the file gets a generated code")
+	assert.Equal(t, domain.ImportRowFailed, job.Results[3].Status)
+	assert.NotEmpty(t, job.Results[3].Error)
+
+	stored, err := importer.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, job.Created, stored.Created)
+}
+
+func TestImportService_DryRunCreatesNothing(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	importer := NewImportService(NewURLService(mockURLRepo, new(MockClickRepository)))
+
+	rows := []domain.ImportRow{
+		{Line: 1, Alias: "launch", URL: "https://example.com"},
+		{Line: 2, Alias: "bad alias!", URL: "https://example.com/other"},
+		{Line: 3, URL: "ftp://example.com"},
+	}
+	mockURLRepo.On("FindTakenCodes", ctx, []string{"launch"}).Return(map[string]bool{}, nil)
+
+	// Act
+	job := importer.Import(ctx, rows, "user1", true)
+
+	// Assert
+	assert.True(t, job.DryRun)
+	assert.Equal(t, 2, job.Created)
+	assert.Equal(t, 1, job.Failed)
+	assert.Equal(t, domain.ImportRowWouldCreate, job.Results[0].Status)
+	assert.True(t, job.Results[0].AliasPreserved)
+	assert.False(t, job.Results[1].AliasPreserved)
+	assert.Equal(t, domain.ImportRowFailed, job.Results[2].Status)
+	mockURLRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestImportService_StartImportQueuesJob(t *testing.T) {
+	// Arrange
+	principal := &auth.Principal{ID: "user1", Plan: "pro"}
+	ctx := auth.WithPrincipal(context.Background(), principal)
+	queue := new(MockImportJobQueue)
+	importer := NewImportService(NewURLService(new(MockURLRepository), new(MockClickRepository))).WithJobs(queue)
+
+	rows := []domain.ImportRow{{Line: 1, Alias: "launch", URL: "https://example.com"}}
+	expectedPayload := importPayload{Principal: *principal, Total: 1, Rows: rows}
+	queue.On("Enqueue", ctx, ImportJobKind, expectedPayload, "user1").Return(jobs.NewJob(ImportJobKind, nil, "user1", 3), nil)
+
+	// Act
+	job, err := importer.StartImport(ctx, rows, "user1", false)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportPending, job.State)
+	assert.Equal(t, 1, job.Total)
+	queue.AssertExpectations(t)
+}
+
+func TestImportService_RunJob(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	queue := new(MockImportJobQueue)
+	importer := NewImportService(NewURLService(mockURLRepo, new(MockClickRepository))).WithJobs(queue)
+
+	rows := []domain.ImportRow{
+		{Line: 1, Alias: "summer", URL: "https://example.com/summer"},
+		{Line: 2, Alias: "winter", URL: "https://example.com/winter"},
+	}
+	payload, err := json.Marshal(importPayload{Principal: auth.Principal{ID: "user1"}, Total: 2, Rows: rows})
+	require.NoError(t, err)
+	queued := jobs.NewJob(ImportJobKind, payload, "user1", 3)
+	queued.Attempts = 2 // The first worker died after creating "summer"
+
+	mockURLRepo.On("FindTakenCodes", mock.Anything, []string{"summer", "winter"}).Return(map[string]bool{"summer": true}, nil)
+	mockURLRepo.On("GetByCustomAlias", mock.Anything, "summer").
+		Return(&domain.URL{ShortCode: "summer", OriginalURL: "https://example.com/summer", CreatedBy: "user1"}, nil)
+	mockURLRepo.On("ExistsCustomAlias", mock.Anything, "winter").Return(false, nil)
+	mockURLRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.URL")).Return(nil).Once()
+
+	// Act
+	result, err := importer.RunJob(ctx, queued)
+
+	// Assert: the link of the first attempt is reported, not created again
+	require.NoError(t, err)
+	mockURLRepo.AssertExpectations(t)
+
+	// The stored result is what GET /api/v1/import/{id} shows
+	queued.Status = jobs.StatusSucceeded
+	queued.Result, err = json.Marshal(result)
+	require.NoError(t, err)
+	queue.On("Get", ctx, queued.ID).Return(queued, nil)
+
+	job, err := importer.GetJob(ctx, queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportCompleted, job.State)
+	assert.Equal(t, 2, job.Processed)
+	assert.Equal(t, 2, job.Created)
+	require.Len(t, job.Results, 2)
+	assert.Equal(t, "summer", job.Results[0].ShortCode)
+	assert.True(t, job.Results[0].AliasPreserved)
+	assert.Equal(t, "winter", job.Results[1].ShortCode)
+}
+
+func TestImportService_GetJobIgnoresOtherKinds(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	queue := new(MockImportJobQueue)
+	importer := NewImportService(NewURLService(new(MockURLRepository), new(MockClickRepository))).WithJobs(queue)
+
+	other := jobs.NewJob(DomainVerifyJob, nil, "user1", 3)
+	queue.On("Get", ctx, other.ID).Return(other, nil)
+	queue.On("Get", ctx, "missing").Return(nil, jobs.ErrNotFound)
+
+	// Act
+	_, otherErr := importer.GetJob(ctx, other.ID)
+	_, missingErr := importer.GetJob(ctx, "missing")
+
+	// Assert
+	assert.ErrorIs(t, otherErr, domain.ErrImportJobNotFound)
+	assert.ErrorIs(t, missingErr, domain.ErrImportJobNotFound)
+}
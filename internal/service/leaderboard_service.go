@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// LeaderboardCache caches computed leaderboards
+// Implemented by redis.LeaderboardCache; optional (nil = always ask PostgreSQL)
+type LeaderboardCache interface {
+	Get(ctx context.Context, owner string, period domain.LeaderboardPeriod, limit int) (*domain.Leaderboard, bool, error)
+	Set(ctx context.Context, owner string, period domain.LeaderboardPeriod, limit int, board *domain.Leaderboard, ttl time.Duration) error
+}
+
+// LeaderboardService ranks the caller's links by clicks
+//
+// WHY CACHE?
+// The dashboard home page shows the leaderboard on every visit, and the
+// ranking reads every rollup row of every link in the workspace. Being a
+// minute behind is fine for "what's hot", so results are cached per
+// workspace, period and limit for a short TTL.
+type LeaderboardService struct {
+	clicks repository.ClickRepository
+	cache  LeaderboardCache
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewLeaderboardService creates a leaderboard service
+func NewLeaderboardService(clicks repository.ClickRepository) *LeaderboardService {
+	return &LeaderboardService{clicks: clicks, now: time.Now}
+}
+
+// WithCache caches leaderboards for ttl (see LeaderboardCache)
+func (s *LeaderboardService) WithCache(cache LeaderboardCache, ttl time.Duration) *LeaderboardService {
+	if ttl > 0 {
+		s.cache = cache
+		s.ttl = ttl
+	}
+	return s
+}
+
+// TopLinks returns the caller's most clicked links in period
+// "" period means 7 days; limit <= 0 means domain.DefaultLeaderboardLimit,
+// larger than domain.MaxLeaderboardLimit is capped
+func (s *LeaderboardService) TopLinks(ctx context.Context, period domain.LeaderboardPeriod, limit int) (*domain.Leaderboard, error) {
+	principal := auth.FromContext(ctx)
+	if principal == auth.Anonymous {
+		return nil, domain.ErrForbidden
+	}
+
+	if period == "" {
+		period = domain.Period7Days
+	}
+	window, ok := period.Duration()
+	if !ok {
+		return nil, domain.ErrInvalidPeriod
+	}
+	switch {
+	case limit <= 0:
+		limit = domain.DefaultLeaderboardLimit
+	case limit > domain.MaxLeaderboardLimit:
+		limit = domain.MaxLeaderboardLimit
+	}
+
+	if board, found := s.cached(ctx, principal.ID, period, limit); found {
+		return visibleBoard(principal, board), nil
+	}
+
+	now := s.now()
+	from := now.Add(-window).Truncate(time.Hour)
+	links, err := s.clicks.TopLinks(ctx, principal.ID, from, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank links: %w", err)
+	}
+
+	board := &domain.Leaderboard{Period: period, From: from, To: now, Links: links}
+	if s.cache != nil {
+		if err := s.cache.Set(ctx, principal.ID, period, limit, board, s.ttl); err != nil {
+			fmt.Printf("Warning: failed to cache leaderboard: %v\n", err)
+		}
+	}
+	return visibleBoard(principal, board), nil
+}
+
+// visibleBoard returns board as principal may see it: for viewers, without
+// the destinations of sensitive links
+// The cache holds the full board for the whole workspace, so this runs on
+// every read, not before caching
+func visibleBoard(principal *auth.Principal, board *domain.Leaderboard) *domain.Leaderboard {
+	if principal.EffectiveRole() != auth.RoleViewer {
+		return board
+	}
+	redacted := *board
+	redacted.Links = make([]domain.TopLink, len(board.Links))
+	for i, link := range board.Links {
+		if link.Sensitive {
+			link.OriginalURL = ""
+		}
+		redacted.Links[i] = link
+	}
+	return &redacted
+}
+
+// cached reads a cached leaderboard; a broken cache is a miss, not an error
+func (s *LeaderboardService) cached(ctx context.Context, owner string, period domain.LeaderboardPeriod, limit int) (*domain.Leaderboard, bool) {
+	if s.cache == nil {
+		return nil, false
+	}
+	board, ok, err := s.cache.Get(ctx, owner, period, limit)
+	if err != nil {
+		fmt.Printf("Warning: failed to read cached leaderboard: %v\n", err)
+		return nil, false
+	}
+	return board, ok
+}
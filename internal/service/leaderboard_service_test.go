@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockLeaderboardCache is a mock implementation of LeaderboardCache
+type MockLeaderboardCache struct {
+	mock.Mock
+}
+
+func (m *MockLeaderboardCache) Get(ctx context.Context, owner string, period domain.LeaderboardPeriod, limit int) (*domain.Leaderboard, bool, error) {
+	args := m.Called(ctx, owner, period, limit)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(*domain.Leaderboard), args.Bool(1), args.Error(2)
+}
+
+func (m *MockLeaderboardCache) Set(ctx context.Context, owner string, period domain.LeaderboardPeriod, limit int, board *domain.Leaderboard, ttl time.Duration) error {
+	args := m.Called(ctx, owner, period, limit, board, ttl)
+	return args.Error(0)
+}
+
+func TestLeaderboardService_TopLinks(t *testing.T) {
+	now := time.Date(2026, 3, 30, 10, 42, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		period        domain.LeaderboardPeriod
+		limit         int
+		expectedSince time.Time
+		expectedLimit int
+		expectErr     error
+	}{
+		{name: "defaults to 7 days and 10 links", expectedSince: time.Date(2026, 3, 23, 10, 0, 0, 0, time.UTC), expectedLimit: 10},
+		{name: "24 hours", period: domain.Period24Hours, limit: 3, expectedSince: time.Date(2026, 3, 29, 10, 0, 0, 0, time.UTC), expectedLimit: 3},
+		{name: "30 days, limit capped", period: domain.Period30Days, limit: 500, expectedSince: time.Date(2026, 2, 28, 10, 0, 0, 0, time.UTC), expectedLimit: 100},
+		{name: "unknown period", period: "1y", expectErr: domain.ErrInvalidPeriod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+			clicks := new(MockClickRepository)
+			service := NewLeaderboardService(clicks)
+			service.now = func() time.Time { return now }
+
+			links := []domain.TopLink{{URLID: "1", ShortCode: "abc123", Clicks: 12, UniqueVisitors: 7}}
+			if tt.expectErr == nil {
+				clicks.On("TopLinks", ctx, "user1", tt.expectedSince, tt.expectedLimit).Return(links, nil)
+			}
+
+			// Act
+			board, err := service.TopLinks(ctx, tt.period, tt.limit)
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				clicks.AssertNotCalled(t, "TopLinks", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, links, board.Links)
+			assert.Equal(t, tt.expectedSince, board.From)
+			assert.Equal(t, now, board.To)
+			clicks.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLeaderboardService_TopLinks_Anonymous(t *testing.T) {
+	// Arrange
+	clicks := new(MockClickRepository)
+	service := NewLeaderboardService(clicks)
+
+	// Act
+	_, err := service.TopLinks(context.Background(), domain.Period7Days, 10)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	clicks.AssertNotCalled(t, "TopLinks", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLeaderboardService_TopLinks_Cache(t *testing.T) {
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+	cachedBoard := &domain.Leaderboard{Period: domain.Period7Days, Links: []domain.TopLink{{ShortCode: "cached"}}}
+
+	t.Run("hit skips the database", func(t *testing.T) {
+		// Arrange
+		clicks, cache := new(MockClickRepository), new(MockLeaderboardCache)
+		service := NewLeaderboardService(clicks).WithCache(cache, time.Minute)
+		cache.On("Get", ctx, "user1", domain.Period7Days, 10).Return(cachedBoard, true, nil)
+
+		// Act
+		board, err := service.TopLinks(ctx, "", 0)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Same(t, cachedBoard, board)
+		clicks.AssertNotCalled(t, "TopLinks", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("miss stores the result", func(t *testing.T) {
+		// Arrange
+		clicks, cache := new(MockClickRepository), new(MockLeaderboardCache)
+		service := NewLeaderboardService(clicks).WithCache(cache, time.Minute)
+		cache.On("Get", ctx, "user1", domain.Period24Hours, 5).Return(nil, false, nil)
+		clicks.On("TopLinks", ctx, "user1", mock.Anything, 5).Return([]domain.TopLink{{ShortCode: "fresh"}}, nil)
+		cache.On("Set", ctx, "user1", domain.Period24Hours, 5, mock.AnythingOfType("*domain.Leaderboard"), time.Minute).Return(nil)
+
+		// Act
+		board, err := service.TopLinks(ctx, domain.Period24Hours, 5)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "fresh", board.Links[0].ShortCode)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("broken cache falls back to the database", func(t *testing.T) {
+		// Arrange
+		clicks, cache := new(MockClickRepository), new(MockLeaderboardCache)
+		service := NewLeaderboardService(clicks).WithCache(cache, time.Minute)
+		cache.On("Get", ctx, "user1", domain.Period7Days, 10).Return(nil, false, assert.AnError)
+		clicks.On("TopLinks", ctx, "user1", mock.Anything, 10).Return([]domain.TopLink{{ShortCode: "fresh"}}, nil)
+		cache.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(assert.AnError)
+
+		// Act
+		board, err := service.TopLinks(ctx, "", 0)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "fresh", board.Links[0].ShortCode)
+	})
+}
+
+func TestLeaderboardService_TopLinks_Viewer(t *testing.T) {
+	// Arrange: the cached board is the whole workspace's, owners included
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1", Member: "vic@example.com", Role: auth.RoleViewer})
+	cachedBoard := &domain.Leaderboard{Period: domain.Period7Days, Links: []domain.TopLink{
+		{ShortCode: "sale", OriginalURL: "https://example.com/sale", Clicks: 40},
+		{ShortCode: "launch", OriginalURL: "https://example.com/unannounced", Clicks: 30, Sensitive: true},
+	}}
+	clicks, cache := new(MockClickRepository), new(MockLeaderboardCache)
+	service := NewLeaderboardService(clicks).WithCache(cache, time.Minute)
+	cache.On("Get", ctx, "user1", domain.Period7Days, 10).Return(cachedBoard, true, nil)
+
+	// Act
+	board, err := service.TopLinks(ctx, "", 0)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/sale", board.Links[0].OriginalURL)
+	assert.Empty(t, board.Links[1].OriginalURL)
+	assert.Equal(t, int64(30), board.Links[1].Clicks, "viewers still see the stats")
+	assert.Equal(t, "https://example.com/unannounced", cachedBoard.Links[1].OriginalURL, "the cached board must not change")
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// PageService manages link-in-bio pages
+//
+// A page is a small public profile at /@{handle}: a title, a bio, a theme
+// and an ordered list of links (blocks). Visitors reach a link through
+// /@{handle}/{block id}, which counts the click-through on that block
+// before redirecting.
+type PageService struct {
+	pages repository.PageRepository
+}
+
+// NewPageService creates a page service
+func NewPageService(pages repository.PageRepository) *PageService {
+	return &PageService{pages: pages}
+}
+
+// CreatePage creates a page owned by the caller
+func (s *PageService) CreatePage(ctx context.Context, page *domain.Page) error {
+	if err := authorizeWrite(ctx); err != nil {
+		return err
+	}
+	page.Owner = auth.FromContext(ctx).ID
+	page.Normalize()
+	if err := page.Validate(); err != nil {
+		return err
+	}
+
+	// New blocks get their IDs from the database
+	for i := range page.Blocks {
+		page.Blocks[i].ID = ""
+	}
+	return s.pages.Create(ctx, page)
+}
+
+// GetPage returns one of the caller's pages with its block analytics
+func (s *PageService) GetPage(ctx context.Context, id string) (*domain.Page, error) {
+	page, err := s.pages.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizePage(ctx, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// ListPages returns the caller's pages
+func (s *PageService) ListPages(ctx context.Context) ([]*domain.Page, error) {
+	return s.pages.ListByOwner(ctx, auth.FromContext(ctx).ID)
+}
+
+// UpdatePage replaces the content of page (matched by page.ID)
+//
+// KEEPING CLICK COUNTS:
+// Blocks sent with the ID of an existing block are updated in place, so
+// their click counts survive edits and reordering. Unknown IDs are treated
+// as new blocks - a client can't move another page's block onto its own.
+func (s *PageService) UpdatePage(ctx context.Context, page *domain.Page) error {
+	if err := authorizeWrite(ctx); err != nil {
+		return err
+	}
+	current, err := s.pages.GetByID(ctx, page.ID)
+	if err != nil {
+		return err
+	}
+	if err := authorizePage(ctx, current); err != nil {
+		return err
+	}
+
+	page.Owner = current.Owner
+	page.CreatedAt = current.CreatedAt
+	page.Normalize()
+	if err := page.Validate(); err != nil {
+		return err
+	}
+
+	for i := range page.Blocks {
+		if _, ok := current.Block(page.Blocks[i].ID); !ok {
+			page.Blocks[i].ID = ""
+		}
+	}
+	return s.pages.Update(ctx, page)
+}
+
+// DeletePage deletes one of the caller's pages
+func (s *PageService) DeletePage(ctx context.Context, id string) error {
+	if err := authorizeWrite(ctx); err != nil {
+		return err
+	}
+	page, err := s.pages.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := authorizePage(ctx, page); err != nil {
+		return err
+	}
+	return s.pages.Delete(ctx, id)
+}
+
+// PublicPage returns the page shown at /@{handle}
+func (s *PageService) PublicPage(ctx context.Context, handle string) (*domain.Page, error) {
+	return s.pages.GetByHandle(ctx, handle)
+}
+
+// ClickBlock records a click-through and returns where to send the visitor
+// A failed counter update is logged, not returned: analytics must never
+// break the link itself
+func (s *PageService) ClickBlock(ctx context.Context, handle, blockID string) (string, error) {
+	page, err := s.pages.GetByHandle(ctx, handle)
+	if err != nil {
+		return "", err
+	}
+	block, ok := page.Block(blockID)
+	if !ok {
+		return "", domain.ErrBlockNotFound
+	}
+
+	if err := s.pages.IncrementBlockClicks(ctx, page.ID, block.ID); err != nil {
+		fmt.Printf("Warning: failed to count click on page %s block %s: %v\n", page.Handle, block.ID, err)
+	}
+	return block.URL, nil
+}
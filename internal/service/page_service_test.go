@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPageRepository is a mock implementation of PageRepository
+type MockPageRepository struct {
+	mock.Mock
+}
+
+func (m *MockPageRepository) Create(ctx context.Context, page *domain.Page) error {
+	args := m.Called(ctx, page)
+	return args.Error(0)
+}
+
+func (m *MockPageRepository) Update(ctx context.Context, page *domain.Page) error {
+	args := m.Called(ctx, page)
+	return args.Error(0)
+}
+
+func (m *MockPageRepository) GetByID(ctx context.Context, id string) (*domain.Page, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Page), args.Error(1)
+}
+
+func (m *MockPageRepository) GetByHandle(ctx context.Context, handle string) (*domain.Page, error) {
+	args := m.Called(ctx, handle)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Page), args.Error(1)
+}
+
+func (m *MockPageRepository) ListByOwner(ctx context.Context, owner string) ([]*domain.Page, error) {
+	args := m.Called(ctx, owner)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Page), args.Error(1)
+}
+
+func (m *MockPageRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockPageRepository) IncrementBlockClicks(ctx context.Context, pageID, blockID string) error {
+	args := m.Called(ctx, pageID, blockID)
+	return args.Error(0)
+}
+
+func TestPageService_CreatePage(t *testing.T) {
+	tests := []struct {
+		name        string
+		page        *domain.Page
+		expectSave  bool
+		expectedErr error
+	}{
+		{
+			name: "valid page with default theme",
+			page: &domain.Page{
+				Handle: " Alice ",
+				Title:  "Alice",
+				Blocks: []domain.PageBlock{{ID: "client-id", Title: "Blog", URL: "https://alice.dev"}},
+			},
+			expectSave: true,
+		},
+		{name: "invalid handle", page: &domain.Page{Handle: "a!"}, expectedErr: domain.ErrInvalidHandle},
+		{name: "reserved handle", page: &domain.Page{Handle: "admin"}, expectedErr: domain.ErrInvalidHandle},
+		{
+			name:        "color outside the hex format",
+			page:        &domain.Page{Handle: "alice", Theme: domain.PageTheme{Background: "red;}"}},
+			expectedErr: domain.ErrInvalidTheme,
+		},
+		{
+			name:        "block with a javascript URL",
+			page:        &domain.Page{Handle: "alice", Blocks: []domain.PageBlock{{Title: "x", URL: "javascript:alert(1)"}}},
+			expectedErr: domain.ErrInvalidBlock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := new(MockPageRepository)
+			svc := NewPageService(repo)
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "alice"})
+			if tt.expectSave {
+				repo.On("Create", ctx, tt.page).Return(nil)
+			}
+
+			// Act
+			err := svc.CreatePage(ctx, tt.page)
+
+			// Assert
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "alice", tt.page.Owner)
+			assert.Equal(t, "alice", tt.page.Handle)
+			assert.Equal(t, domain.DefaultPageTheme, tt.page.Theme)
+			assert.Empty(t, tt.page.Blocks[0].ID, "block IDs come from the database")
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPageService_UpdatePage(t *testing.T) {
+	current := func() *domain.Page {
+		return &domain.Page{
+			ID:     "p1",
+			Handle: "alice",
+			Owner:  "alice",
+			Blocks: []domain.PageBlock{{ID: "b1", Title: "Blog", URL: "https://alice.dev", Clicks: 7}},
+		}
+	}
+
+	t.Run("keeps known block IDs and drops foreign ones", func(t *testing.T) {
+		// Arrange
+		repo := new(MockPageRepository)
+		svc := NewPageService(repo)
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "alice"})
+		page := &domain.Page{
+			ID:     "p1",
+			Handle: "alice",
+			Blocks: []domain.PageBlock{
+				{ID: "other-page-block", Title: "Shop", URL: "https://shop.example.com"},
+				{ID: "b1", Title: "Blog", URL: "https://alice.dev"},
+			},
+		}
+		repo.On("GetByID", ctx, "p1").Return(current(), nil)
+		repo.On("Update", ctx, page).Return(nil)
+
+		// Act
+		err := svc.UpdatePage(ctx, page)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, page.Blocks[0].ID)
+		assert.Equal(t, "b1", page.Blocks[1].ID)
+		assert.Equal(t, 1, page.Blocks[1].Position)
+		assert.Equal(t, "alice", page.Owner)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("other users can't edit the page", func(t *testing.T) {
+		// Arrange
+		repo := new(MockPageRepository)
+		svc := NewPageService(repo)
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "mallory"})
+		repo.On("GetByID", ctx, "p1").Return(current(), nil)
+
+		// Act
+		err := svc.UpdatePage(ctx, &domain.Page{ID: "p1", Handle: "mallory"})
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("admins can edit any page", func(t *testing.T) {
+		// Arrange
+		repo := new(MockPageRepository)
+		svc := NewPageService(repo)
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "root", Admin: true})
+		page := &domain.Page{ID: "p1", Handle: "alice"}
+		repo.On("GetByID", ctx, "p1").Return(current(), nil)
+		repo.On("Update", ctx, page).Return(nil)
+
+		// Act
+		err := svc.UpdatePage(ctx, page)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "alice", page.Owner, "the owner never changes")
+	})
+}
+
+func TestPageService_ClickBlock(t *testing.T) {
+	page := &domain.Page{
+		ID:     "p1",
+		Handle: "alice",
+		Blocks: []domain.PageBlock{{ID: "b1", Title: "Blog", URL: "https://alice.dev"}},
+	}
+
+	tests := []struct {
+		name            string
+		blockID         string
+		incrementErr    error
+		expectIncrement bool
+		expectedURL     string
+		expectedErr     error
+	}{
+		{name: "counts and returns the destination", blockID: "b1", expectIncrement: true, expectedURL: "https://alice.dev"},
+		{name: "counter failure still redirects", blockID: "b1", incrementErr: errors.New("db down"), expectIncrement: true, expectedURL: "https://alice.dev"},
+		{name: "unknown block", blockID: "b2", expectedErr: domain.ErrBlockNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := new(MockPageRepository)
+			svc := NewPageService(repo)
+			ctx := context.Background()
+			repo.On("GetByHandle", ctx, "alice").Return(page, nil)
+			if tt.expectIncrement {
+				repo.On("IncrementBlockClicks", ctx, "p1", tt.blockID).Return(tt.incrementErr)
+			}
+
+			// Act
+			destination, err := svc.ClickBlock(ctx, "alice", tt.blockID)
+
+			// Assert
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedURL, destination)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/notify"
+	"url-shortener/internal/repository"
+)
+
+// EventLinkBlocked is sent to a link's owner when the sweeper deactivates it
+const EventLinkBlocked = "link.blocked"
+
+// PolicySweeper deactivates existing links whose destination a NEW rule bans
+//
+// WHY A SWEEPER?
+// Creation only checks links against the rules of that moment. When a
+// domain turns out to host phishing next week, the links already pointing
+// there keep redirecting - until something looks at them again. The
+// sweeper walks every active link after each rule change and switches off
+// the ones that are no longer allowed.
+//
+// Deactivated links are soft-deleted like any other: their owners see them
+// in the trash and can restore them once the rule is lifted (restoring
+// checks the policy again).
+//
+// Like the other background workers it runs on the primary region only,
+// one sweeper per database.
+type PolicySweeper struct {
+	repo      repository.PolicySweepRepository
+	policy    *DomainPolicyService
+	cache     Cache
+	notifier  notify.Notifier
+	edge      EdgePurger // Optional: purges deactivated links from the CDN
+	batchSize int
+	swept     string // Revision of the policy the last complete sweep used
+	now       func() time.Time
+}
+
+// NewPolicySweeper creates a sweeper for the links in repo
+func NewPolicySweeper(repo repository.PolicySweepRepository, policy *DomainPolicyService, cache Cache, notifier notify.Notifier) *PolicySweeper {
+	return &PolicySweeper{
+		repo:      repo,
+		policy:    policy,
+		cache:     cache,
+		notifier:  notifier,
+		batchSize: 500,
+		now:       time.Now,
+	}
+}
+
+// WithEdgePurger also removes deactivated links from the CDN
+func (s *PolicySweeper) WithEdgePurger(p EdgePurger) *PolicySweeper {
+	s.edge = p
+	return s
+}
+
+// Run sweeps whenever the rules changed since the last sweep, checking
+// every interval until ctx is canceled
+func (s *PolicySweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		policy := s.policy.Policy()
+		if policy.Revision() != s.swept {
+			if _, err := s.Sweep(ctx, policy); err != nil {
+				fmt.Printf("Warning: domain policy sweep failed: %v\n", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep checks every active link against policy and deactivates the ones
+// it blocks, returning how many it deactivated
+// A sweep that fails halfway is repeated in full on the next run
+// (deactivated links are no longer active, so nothing is done twice)
+func (s *PolicySweeper) Sweep(ctx context.Context, policy *domain.DomainPolicy) (int, error) {
+	deactivated := 0
+	after := ""
+	for {
+		urls, err := s.repo.ListActive(ctx, after, s.batchSize)
+		if err != nil {
+			return deactivated, err
+		}
+
+		var blocked []*domain.URL
+		for _, url := range urls {
+			err := policy.CheckURL(url)
+			if err == nil {
+				continue
+			}
+			if s.deactivate(ctx, url, err) {
+				blocked = append(blocked, url)
+			}
+		}
+		deactivated += len(blocked)
+		purgeEdge(ctx, s.edge, blocked...)
+
+		if len(urls) < s.batchSize {
+			break
+		}
+		after = urls[len(urls)-1].ID
+	}
+
+	s.swept = policy.Revision()
+	return deactivated, nil
+}
+
+// deactivate switches off one blocked link and tells its owner
+// Returns false if it was already off (or couldn't be switched off)
+func (s *PolicySweeper) deactivate(ctx context.Context, url *domain.URL, reason error) bool {
+	changed, err := s.repo.Deactivate(ctx, url.ID)
+	if err != nil {
+		fmt.Printf("Warning: failed to deactivate blocked link %s: %v\n", url.ShortCode, err)
+		return false
+	}
+	if !changed {
+		return false
+	}
+
+	// The redirect path reads from the cache first: without this the link
+	// would keep working until its cache entry expires
+	s.invalidateCache(ctx, url)
+
+	event := notify.Event{
+		Type:       EventLinkBlocked,
+		URLID:      url.ID,
+		ShortCode:  url.ShortCode,
+		Owner:      url.CreatedBy,
+		OccurredAt: s.now(),
+		Data: map[string]interface{}{
+			"original_url": url.OriginalURL,
+			"reason":       blockedReason(reason),
+		},
+	}
+	if err := s.notifier.Notify(ctx, event); err != nil {
+		fmt.Printf("Warning: failed to deliver blocked link notice: %v\n", err)
+	}
+	return true
+}
+
+// invalidateCache removes a deactivated URL from the cache
+func (s *PolicySweeper) invalidateCache(ctx context.Context, url *domain.URL) {
+	keys := []string{url.ShortCode}
+	if url.CustomAlias != nil && *url.CustomAlias != url.ShortCode {
+		keys = append(keys, *url.CustomAlias)
+	}
+
+	for _, key := range keys {
+		if err := s.cache.DeleteURL(ctx, key); err != nil {
+			fmt.Printf("Warning: failed to invalidate cached URL: %v\n", err)
+		}
+	}
+}
+
+// blockedReason is the human part of a policy error ("evil.tk (phishing)")
+func blockedReason(err error) string {
+	return strings.TrimPrefix(err.Error(), domain.ErrDestinationBlocked.Error()+": ")
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPolicySweepRepository is a mock implementation of repository.PolicySweepRepository
+type MockPolicySweepRepository struct {
+	mock.Mock
+}
+
+func (m *MockPolicySweepRepository) ListActive(ctx context.Context, afterID string, limit int) ([]*domain.URL, error) {
+	args := m.Called(ctx, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URL), args.Error(1)
+}
+
+func (m *MockPolicySweepRepository) Deactivate(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// ==================== TESTS ====================
+
+func TestPolicySweeper_Sweep(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockPolicySweepRepository)
+	cache := new(MockCache)
+	notifier := new(MockNotifier)
+	edge := new(MockEdgePurger)
+
+	policy := policyWith(t, storedRule("1", domain.GlobalWorkspace, "evil.com", domain.PatternDomain, domain.PolicyDeny))
+	alias := "promo"
+	banned := &domain.URL{ID: "2", ShortCode: "promo", CustomAlias: &alias, OriginalURL: "https://evil.com/login", CreatedBy: "user1"}
+	gone := &domain.URL{ID: "3", ShortCode: "def456", OriginalURL: "https://www.evil.com", CreatedBy: "user2"}
+	fine := &domain.URL{ID: "4", ShortCode: "ghi789", OriginalURL: "https://example.com", CreatedBy: "user1"}
+
+	// Pages of two: the sweep continues after the last ID of a full page
+	repo.On("ListActive", ctx, "", 2).Return([]*domain.URL{{ID: "1", OriginalURL: "https://example.org"}, banned}, nil)
+	repo.On("ListActive", ctx, "2", 2).Return([]*domain.URL{gone, fine}, nil)
+	repo.On("ListActive", ctx, "4", 2).Return([]*domain.URL{}, nil)
+	repo.On("Deactivate", ctx, "2").Return(true, nil)
+	repo.On("Deactivate", ctx, "3").Return(false, nil) // Deleted by its owner meanwhile
+	cache.On("DeleteURL", ctx, "promo").Return(nil)
+	edge.On("PurgeLinks", ctx, []*domain.URL{banned}).Return(nil)
+	notifier.On("Notify", ctx, mock.AnythingOfType("notify.Event")).Return(nil)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	sweeper := NewPolicySweeper(repo, policy, cache, notifier).WithEdgePurger(edge)
+	sweeper.batchSize = 2
+	sweeper.now = func() time.Time { return now }
+
+	// Act
+	deactivated, err := sweeper.Sweep(ctx, policy.Policy())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, deactivated)
+	assert.Equal(t, "1", sweeper.swept)
+	repo.AssertNotCalled(t, "Deactivate", ctx, "4")
+	cache.AssertExpectations(t)
+	edge.AssertExpectations(t)
+	notifier.AssertNumberOfCalls(t, "Notify", 1)
+	notifier.AssertCalled(t, "Notify", ctx, notify.Event{
+		Type:       EventLinkBlocked,
+		URLID:      "2",
+		ShortCode:  "promo",
+		Owner:      "user1",
+		OccurredAt: now,
+		Data: map[string]interface{}{
+			"original_url": "https://evil.com/login",
+			"reason":       "evil.com",
+		},
+	})
+}
+
+func TestPolicySweeper_Sweep_FailureIsRetried(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockPolicySweepRepository)
+	policy := policyWith(t, storedRule("1", domain.GlobalWorkspace, ".tk", domain.PatternTLD, domain.PolicyDeny))
+	repo.On("ListActive", ctx, "", 500).Return(nil, fmt.Errorf("connection reset"))
+
+	sweeper := NewPolicySweeper(repo, policy, new(MockCache), new(MockNotifier))
+
+	// Act
+	_, err := sweeper.Sweep(ctx, policy.Policy())
+
+	// Assert
+	assert.Error(t, err)
+	assert.Empty(t, sweeper.swept, "an incomplete sweep must run again")
+}
+
+func TestPolicySweeper_Run_SweepsOnlyAfterRuleChanges(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	repo := new(MockPolicySweepRepository)
+	policy := policyWith(t, storedRule("1", domain.GlobalWorkspace, ".tk", domain.PatternTLD, domain.PolicyDeny))
+	repo.On("ListActive", mock.Anything, "", 500).Return([]*domain.URL{}, nil)
+
+	sweeper := NewPolicySweeper(repo, policy, new(MockCache), new(MockNotifier))
+
+	// Act: run a few ticks with the same rules
+	done := make(chan struct{})
+	go func() {
+		sweeper.Run(ctx, 5*time.Millisecond)
+		close(done)
+	}()
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	<-done
+
+	// Assert
+	repo.AssertNumberOfCalls(t, "ListActive", 1)
+}
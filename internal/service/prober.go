@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"url-shortener/internal/metrics"
+)
+
+// ProbeTarget is one dependency the prober checks
+type ProbeTarget struct {
+	Name  string
+	Check func(ctx context.Context) error // e.g. db.Ping, redisClient.Ping(ctx).Err
+}
+
+// Prober checks the dependencies on a schedule and records the results
+//
+// WHY, WITH A READINESS PROBE ALREADY THERE?
+// /health/ready is only asked by the load balancer, and its answer is
+// gone with the response. The prober asks the same questions from the
+// inside and keeps the answers as the dependency_up metric, so dashboards
+// and alerts show WHICH dependency was down, and since when.
+type Prober struct {
+	targets []ProbeTarget
+	timeout time.Duration // Per check
+}
+
+// NewProber creates a prober for the given dependencies
+func NewProber(timeout time.Duration, targets ...ProbeTarget) *Prober {
+	return &Prober{targets: targets, timeout: timeout}
+}
+
+// Probe checks every dependency in parallel
+// The error lists the dependencies that are down
+func (p *Prober) Probe(ctx context.Context) error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errs []error
+
+	for _, target := range p.targets {
+		wg.Add(1)
+		go func(target ProbeTarget) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, p.timeout)
+			defer cancel()
+
+			err := target.Check(checkCtx)
+			metrics.SetDependencyUp(target.Name, err == nil)
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, fmt.Errorf("%s: %w", target.Name, err))
+			}
+		}(target)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
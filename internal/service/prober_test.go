@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"url-shortener/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProber_Probe(t *testing.T) {
+	// Arrange
+	prober := NewProber(time.Second,
+		ProbeTarget{Name: "probe-test-postgres", Check: func(ctx context.Context) error { return nil }},
+		ProbeTarget{Name: "probe-test-redis", Check: func(ctx context.Context) error { return errors.New("connection refused") }},
+	)
+
+	// Act
+	err := prober.Probe(context.Background())
+
+	// Assert
+	assert.ErrorContains(t, err, "probe-test-redis: connection refused")
+	assert.NotContains(t, err.Error(), "probe-test-postgres")
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.DependencyUp.WithLabelValues("probe-test-postgres")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.DependencyUp.WithLabelValues("probe-test-redis")))
+}
+
+func TestProber_ChecksTimeOut(t *testing.T) {
+	// Arrange
+	prober := NewProber(10*time.Millisecond, ProbeTarget{Name: "probe-test-slow", Check: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	// Act
+	err := prober.Probe(context.Background())
+
+	// Assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// UsageCounter caches quota counters in front of the repository
+// Implemented by redis.UsageCounter; optional (nil = always ask PostgreSQL)
+type UsageCounter interface {
+	Get(ctx context.Context, owner string, period time.Time) (used int64, ok bool, err error)
+	Set(ctx context.Context, owner string, period time.Time, used int64, expiresAt time.Time) error
+	Delete(ctx context.Context, owner string, period time.Time) error
+}
+
+// PlanResolver looks up the plan an owner pays for
+// Implemented by BillingService; "" means no paid plan
+type PlanResolver interface {
+	PlanFor(ctx context.Context, owner string) (string, error)
+}
+
+// QuotaService enforces plan limits on link creation
+//
+// WHO IS COUNTED?
+// Authenticated, non-admin callers. Their plan comes from the plan resolver
+// (a paid subscription), then the principal (the workspace's plan, which
+// the authenticators read with the credentials), then the default plan.
+// Anonymous callers share one identity, so a quota would
+// let one visitor lock everybody out - they are limited by the per-IP rate
+// limiter instead. Admins are never limited.
+type QuotaService struct {
+	usage       repository.UsageRepository
+	counter     UsageCounter
+	resolver    PlanResolver
+	plans       map[string]domain.Plan
+	defaultPlan string
+	now         func() time.Time
+}
+
+// NewQuotaService creates a quota service
+// plans maps plan names to limits; defaultPlan applies to principals without a plan
+func NewQuotaService(usage repository.UsageRepository, plans map[string]domain.Plan, defaultPlan string) (*QuotaService, error) {
+	if _, ok := plans[defaultPlan]; !ok {
+		return nil, fmt.Errorf("unknown default plan %q", defaultPlan)
+	}
+	return &QuotaService{
+		usage:       usage,
+		plans:       plans,
+		defaultPlan: defaultPlan,
+		now:         time.Now,
+	}, nil
+}
+
+// WithCounter caches counters in Redis (see UsageCounter)
+func (s *QuotaService) WithCounter(c UsageCounter) *QuotaService {
+	s.counter = c
+	return s
+}
+
+// WithPlanResolver takes plans from paid subscriptions (see PlanResolver)
+func (s *QuotaService) WithPlanResolver(r PlanResolver) *QuotaService {
+	s.resolver = r
+	return s
+}
+
+// Reserve counts one new link for the caller in ctx
+// Returns domain.ErrQuotaExceeded when the plan's monthly limit is used up,
+// and nil usage for callers without a quota. Call Release if the link is
+// not created after all.
+func (s *QuotaService) Reserve(ctx context.Context) (*domain.Usage, error) {
+	usage, ok := s.start(ctx, auth.FromContext(ctx))
+	if !ok {
+		return nil, nil
+	}
+
+	// Fast path: a cached counter at the limit means no database round trip
+	if cached, found := s.cached(ctx, usage); found && !usage.Plan.Unlimited() && cached >= usage.Plan.MonthlyLinks {
+		usage.Used = cached
+		return usage, domain.ErrQuotaExceeded
+	}
+
+	used, ok, err := s.usage.Increment(ctx, usage.Owner, usage.PeriodStart, usage.Plan.MonthlyLinks)
+	if err != nil {
+		return nil, err
+	}
+	usage.Used = used
+	s.cache(ctx, usage)
+
+	if !ok {
+		return usage, domain.ErrQuotaExceeded
+	}
+	return usage, nil
+}
+
+// Release gives back a link reserved with Reserve
+func (s *QuotaService) Release(ctx context.Context, usage *domain.Usage) {
+	if usage == nil {
+		return
+	}
+	if err := s.usage.Decrement(ctx, usage.Owner, usage.PeriodStart); err != nil {
+		fmt.Printf("Warning: failed to release link quota: %v\n", err)
+	}
+	if s.counter != nil {
+		if err := s.counter.Delete(ctx, usage.Owner, usage.PeriodStart); err != nil {
+			fmt.Printf("Warning: failed to drop cached link usage: %v\n", err)
+		}
+	}
+}
+
+// Check tells whether the caller in ctx could create one more link, without
+// counting it: domain.ErrQuotaExceeded when Reserve would fail
+// Returns nil usage for callers without a quota
+func (s *QuotaService) Check(ctx context.Context) (*domain.Usage, error) {
+	usage, err := s.GetUsage(ctx, auth.FromContext(ctx))
+	if err != nil || usage == nil {
+		return nil, err
+	}
+	if usage.Remaining() == 0 {
+		return usage, domain.ErrQuotaExceeded
+	}
+	return usage, nil
+}
+
+// GetUsage returns the current period's usage for principal
+// Returns nil for callers without a quota (anonymous, admins)
+func (s *QuotaService) GetUsage(ctx context.Context, principal *auth.Principal) (*domain.Usage, error) {
+	usage, ok := s.start(ctx, principal)
+	if !ok {
+		return nil, nil
+	}
+
+	if cached, found := s.cached(ctx, usage); found {
+		usage.Used = cached
+		return usage, nil
+	}
+
+	used, err := s.usage.Get(ctx, usage.Owner, usage.PeriodStart)
+	if err != nil {
+		return nil, err
+	}
+	usage.Used = used
+	s.cache(ctx, usage)
+	return usage, nil
+}
+
+// start returns an empty Usage for the current period, or false when
+// principal has no quota
+func (s *QuotaService) start(ctx context.Context, principal *auth.Principal) (*domain.Usage, bool) {
+	if principal == auth.Anonymous || principal.Admin {
+		return nil, false
+	}
+
+	plan, ok := s.plans[s.resolvePlan(ctx, principal)]
+	if !ok {
+		plan = s.plans[s.defaultPlan]
+	}
+
+	start, end := domain.UsagePeriod(s.now())
+	return &domain.Usage{Owner: principal.ID, Plan: plan, PeriodStart: start, PeriodEnd: end}, true
+}
+
+// resolvePlan returns the principal's plan name
+// A paid subscription wins over the plan attached to the credentials.
+// Lookup failures fall back to the principal's plan - a billing database
+// hiccup shouldn't stop people from creating links.
+func (s *QuotaService) resolvePlan(ctx context.Context, principal *auth.Principal) string {
+	if s.resolver == nil {
+		return principal.Plan
+	}
+	plan, err := s.resolver.PlanFor(ctx, principal.ID)
+	if err != nil {
+		fmt.Printf("Warning: failed to look up subscription plan: %v\n", err)
+		return principal.Plan
+	}
+	if plan == "" {
+		return principal.Plan
+	}
+	return plan
+}
+
+// cached reads the counter from Redis; failures count as "not cached"
+func (s *QuotaService) cached(ctx context.Context, usage *domain.Usage) (int64, bool) {
+	if s.counter == nil {
+		return 0, false
+	}
+	used, ok, err := s.counter.Get(ctx, usage.Owner, usage.PeriodStart)
+	if err != nil {
+		fmt.Printf("Warning: failed to read cached link usage: %v\n", err)
+		return 0, false
+	}
+	return used, ok
+}
+
+// cache stores the counter in Redis until the period ends
+func (s *QuotaService) cache(ctx context.Context, usage *domain.Usage) {
+	if s.counter == nil {
+		return
+	}
+	if err := s.counter.Set(ctx, usage.Owner, usage.PeriodStart, usage.Used, usage.PeriodEnd); err != nil {
+		fmt.Printf("Warning: failed to cache link usage: %v\n", err)
+	}
+}
@@ -0,0 +1,391 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockUsageRepository is a mock implementation of UsageRepository
+type MockUsageRepository struct {
+	mock.Mock
+}
+
+func (m *MockUsageRepository) Increment(ctx context.Context, owner string, period time.Time, limit int64) (int64, bool, error) {
+	args := m.Called(ctx, owner, period, limit)
+	return args.Get(0).(int64), args.Bool(1), args.Error(2)
+}
+
+func (m *MockUsageRepository) Decrement(ctx context.Context, owner string, period time.Time) error {
+	args := m.Called(ctx, owner, period)
+	return args.Error(0)
+}
+
+func (m *MockUsageRepository) Get(ctx context.Context, owner string, period time.Time) (int64, error) {
+	args := m.Called(ctx, owner, period)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockUsageCounter is a mock implementation of UsageCounter
+type MockUsageCounter struct {
+	mock.Mock
+}
+
+func (m *MockUsageCounter) Get(ctx context.Context, owner string, period time.Time) (int64, bool, error) {
+	args := m.Called(ctx, owner, period)
+	return args.Get(0).(int64), args.Bool(1), args.Error(2)
+}
+
+func (m *MockUsageCounter) Set(ctx context.Context, owner string, period time.Time, used int64, expiresAt time.Time) error {
+	args := m.Called(ctx, owner, period, used, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockUsageCounter) Delete(ctx context.Context, owner string, period time.Time) error {
+	args := m.Called(ctx, owner, period)
+	return args.Error(0)
+}
+
+var (
+	testPlans = map[string]domain.Plan{
+		domain.PlanFree: {Name: domain.PlanFree, MonthlyLinks: 100},
+		domain.PlanPro:  {Name: domain.PlanPro},
+	}
+	testNow         = time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	testPeriodStart = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	testPeriodEnd   = time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func newTestQuotaService(t *testing.T) (*QuotaService, *MockUsageRepository, *MockUsageCounter) {
+	repo := new(MockUsageRepository)
+	counter := new(MockUsageCounter)
+
+	quotas, err := NewQuotaService(repo, testPlans, domain.PlanFree)
+	require.NoError(t, err)
+	quotas.WithCounter(counter)
+	quotas.now = func() time.Time { return testNow }
+
+	return quotas, repo, counter
+}
+
+func TestNewQuotaService_UnknownDefaultPlan(t *testing.T) {
+	_, err := NewQuotaService(new(MockUsageRepository), testPlans, "enterprise")
+	assert.Error(t, err)
+}
+
+func TestQuotaService_Reserve(t *testing.T) {
+	alice := &auth.Principal{ID: "alice"}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		cached    int64 // Cached counter (-1 = not cached)
+		limit     int64 // Limit passed to the repository
+		used      int64 // Counter after Increment
+		allowed   bool  // Increment result
+		wantErr   error
+		wantUsage bool
+		wantDB    bool
+	}{
+		{name: "under the limit", principal: alice, cached: -1, limit: 100, used: 42, allowed: true, wantUsage: true, wantDB: true},
+		{name: "limit reached at the database", principal: alice, cached: 99, limit: 100, used: 100, allowed: false, wantErr: domain.ErrQuotaExceeded, wantUsage: true, wantDB: true},
+		{name: "cached counter at the limit skips the database", principal: alice, cached: 100, wantErr: domain.ErrQuotaExceeded, wantUsage: true},
+		{name: "pro plan is counted but unlimited", principal: &auth.Principal{ID: "alice", Plan: domain.PlanPro}, cached: 5000, limit: 0, used: 5001, allowed: true, wantUsage: true, wantDB: true},
+		{name: "unknown plan falls back to the default", principal: &auth.Principal{ID: "alice", Plan: "gold"}, cached: -1, limit: 100, used: 1, allowed: true, wantUsage: true, wantDB: true},
+		{name: "anonymous callers have no quota", principal: auth.Anonymous},
+		{name: "admins have no quota", principal: &auth.Principal{ID: "admin", Admin: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			quotas, repo, counter := newTestQuotaService(t)
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+
+			counter.On("Get", ctx, tt.principal.ID, testPeriodStart).Return(tt.cached, tt.cached >= 0, nil)
+			repo.On("Increment", ctx, tt.principal.ID, testPeriodStart, tt.limit).Return(tt.used, tt.allowed, nil)
+			counter.On("Set", ctx, tt.principal.ID, testPeriodStart, tt.used, testPeriodEnd).Return(nil)
+
+			// Act
+			usage, err := quotas.Reserve(ctx)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.wantErr)
+			if !tt.wantUsage {
+				assert.Nil(t, usage)
+				repo.AssertNotCalled(t, "Increment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NotNil(t, usage)
+			assert.Equal(t, testPeriodStart, usage.PeriodStart)
+			assert.Equal(t, testPeriodEnd, usage.PeriodEnd)
+			if tt.wantDB {
+				repo.AssertCalled(t, "Increment", ctx, tt.principal.ID, testPeriodStart, tt.limit)
+				counter.AssertCalled(t, "Set", ctx, tt.principal.ID, testPeriodStart, tt.used, testPeriodEnd)
+			} else {
+				repo.AssertNotCalled(t, "Increment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestQuotaService_Release(t *testing.T) {
+	// Arrange
+	quotas, repo, counter := newTestQuotaService(t)
+	ctx := context.Background()
+
+	repo.On("Decrement", ctx, "alice", testPeriodStart).Return(nil)
+	counter.On("Delete", ctx, "alice", testPeriodStart).Return(nil)
+
+	// Act
+	quotas.Release(ctx, &domain.Usage{Owner: "alice", PeriodStart: testPeriodStart})
+	quotas.Release(ctx, nil) // Callers without a quota - nothing to give back
+
+	// Assert
+	repo.AssertNumberOfCalls(t, "Decrement", 1)
+	counter.AssertExpectations(t)
+}
+
+func TestQuotaService_GetUsage(t *testing.T) {
+	alice := &auth.Principal{ID: "alice"}
+
+	t.Run("from the cache", func(t *testing.T) {
+		quotas, repo, counter := newTestQuotaService(t)
+		ctx := context.Background()
+		counter.On("Get", ctx, "alice", testPeriodStart).Return(int64(30), true, nil)
+
+		usage, err := quotas.GetUsage(ctx, alice)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(30), usage.Used)
+		assert.Equal(t, int64(70), usage.Remaining())
+		repo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("cache miss reads the database and fills the cache", func(t *testing.T) {
+		quotas, repo, counter := newTestQuotaService(t)
+		ctx := context.Background()
+		counter.On("Get", ctx, "alice", testPeriodStart).Return(int64(0), false, nil)
+		repo.On("Get", ctx, "alice", testPeriodStart).Return(int64(12), nil)
+		counter.On("Set", ctx, "alice", testPeriodStart, int64(12), testPeriodEnd).Return(nil)
+
+		usage, err := quotas.GetUsage(ctx, alice)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(12), usage.Used)
+		counter.AssertExpectations(t)
+	})
+
+	t.Run("no quota for admins", func(t *testing.T) {
+		quotas, _, _ := newTestQuotaService(t)
+
+		usage, err := quotas.GetUsage(context.Background(), &auth.Principal{ID: "admin", Admin: true})
+
+		require.NoError(t, err)
+		assert.Nil(t, usage)
+	})
+}
+
+func TestQuotaService_Check(t *testing.T) {
+	alice := &auth.Principal{ID: "alice"}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		used      int64
+		wantErr   error
+		wantUsage bool
+	}{
+		{name: "links left", principal: alice, used: 99, wantUsage: true},
+		{name: "limit reached", principal: alice, used: 100, wantErr: domain.ErrQuotaExceeded, wantUsage: true},
+		{name: "unlimited plan", principal: &auth.Principal{ID: "alice", Plan: domain.PlanPro}, used: 5000, wantUsage: true},
+		{name: "anonymous callers have no quota", principal: auth.Anonymous},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			quotas, repo, counter := newTestQuotaService(t)
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			counter.On("Get", ctx, tt.principal.ID, testPeriodStart).Return(tt.used, true, nil)
+
+			// Act
+			usage, err := quotas.Check(ctx)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantUsage, usage != nil)
+			// Nothing is counted
+			repo.AssertNotCalled(t, "Increment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestQuotaService_WorkspacePlanOfAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		plan    string // Of the key's workspace
+		wantErr error
+	}{
+		{name: "pro workspace is not limited", plan: domain.PlanPro},
+		{name: "default plan is limited", plan: "", wantErr: domain.ErrQuotaExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: the authenticator puts the workspace's plan on the principal
+			keys := new(MockAPIKeyRepository)
+			keys.On("GetByHash", mock.Anything, mock.Anything).
+				Return(&domain.APIKey{ID: "k1", Workspace: "team1", Plan: tt.plan, Scopes: []string{"urls:write"}, LastUsedAt: &testNow}, false, nil)
+			keyService := NewAPIKeyService(keys)
+			keyService.now = func() time.Time { return testNow }
+			principal, err := keyService.Authenticate(context.Background(), "sk_abcdefghijk")
+			require.NoError(t, err)
+
+			quotas, _, counter := newTestQuotaService(t)
+			ctx := auth.WithPrincipal(context.Background(), principal)
+			counter.On("Get", ctx, "team1", testPeriodStart).Return(int64(100), true, nil)
+
+			// Act
+			usage, err := quotas.Check(ctx)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.wantErr)
+			require.NotNil(t, usage)
+			assert.Equal(t, tt.plan == domain.PlanPro, usage.Plan.Unlimited())
+		})
+	}
+}
+
+// MockQuotas is a mock implementation of Quotas
+type MockQuotas struct {
+	mock.Mock
+}
+
+func (m *MockQuotas) Reserve(ctx context.Context) (*domain.Usage, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Usage), args.Error(1)
+}
+
+func (m *MockQuotas) Release(ctx context.Context, usage *domain.Usage) {
+	m.Called(ctx, usage)
+}
+
+func (m *MockQuotas) Check(ctx context.Context) (*domain.Usage, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Usage), args.Error(1)
+}
+
+func TestCreateShortURL_Quotas(t *testing.T) {
+	t.Run("quota exceeded creates nothing", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		mockURLRepo := new(MockURLRepository)
+		mockQuotas := new(MockQuotas)
+		service := NewURLService(mockURLRepo, new(MockClickRepository)).WithQuotas(mockQuotas)
+
+		mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
+		mockQuotas.On("Reserve", ctx).Return(&domain.Usage{Owner: "alice"}, domain.ErrQuotaExceeded)
+
+		// Act
+		url, err := service.CreateShortURL(ctx, "https://example.com", "", "alice", 0)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrQuotaExceeded)
+		assert.Nil(t, url)
+		mockURLRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		mockQuotas.AssertNotCalled(t, "Release", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed insert gives the link back", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		mockURLRepo := new(MockURLRepository)
+		mockQuotas := new(MockQuotas)
+		service := NewURLService(mockURLRepo, new(MockClickRepository)).WithQuotas(mockQuotas)
+
+		usage := &domain.Usage{Owner: "alice", Used: 10}
+		mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
+		mockQuotas.On("Reserve", ctx).Return(usage, nil)
+		mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(assert.AnError)
+		mockQuotas.On("Release", ctx, usage).Return()
+
+		// Act
+		_, err := service.CreateShortURL(ctx, "https://example.com", "", "alice", 0)
+
+		// Assert
+		assert.Error(t, err)
+		mockQuotas.AssertExpectations(t)
+	})
+
+	t.Run("invalid URLs don't use up quota", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		mockURLRepo := new(MockURLRepository)
+		mockQuotas := new(MockQuotas)
+		service := NewURLService(mockURLRepo, new(MockClickRepository)).WithQuotas(mockQuotas)
+
+		mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
+
+		// Act
+		_, err := service.CreateShortURL(ctx, "not a url", "", "alice", 0)
+
+		// Assert
+		assert.Error(t, err)
+		mockQuotas.AssertNotCalled(t, "Reserve", mock.Anything)
+	})
+}
+
+// MockPlanResolver is a mock implementation of PlanResolver
+type MockPlanResolver struct {
+	mock.Mock
+}
+
+func (m *MockPlanResolver) PlanFor(ctx context.Context, owner string) (string, error) {
+	args := m.Called(ctx, owner)
+	return args.String(0), args.Error(1)
+}
+
+func TestQuotaService_PlanResolver(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		resolved  string
+		err       error
+		expected  string
+	}{
+		{name: "subscription wins over the credentials", principal: &auth.Principal{ID: "alice"}, resolved: domain.PlanPro, expected: domain.PlanPro},
+		{name: "no subscription keeps the principal's plan", principal: &auth.Principal{ID: "alice", Plan: domain.PlanPro}, expected: domain.PlanPro},
+		{name: "lookup failure falls back", principal: &auth.Principal{ID: "alice"}, err: assert.AnError, expected: domain.PlanFree},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quotas, _, counter := newTestQuotaService(t)
+			resolver := new(MockPlanResolver)
+			quotas.WithPlanResolver(resolver)
+			ctx := context.Background()
+
+			resolver.On("PlanFor", ctx, "alice").Return(tt.resolved, tt.err)
+			counter.On("Get", ctx, "alice", testPeriodStart).Return(int64(3), true, nil)
+
+			usage, err := quotas.GetUsage(ctx, tt.principal)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, usage.Plan.Name)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// ResealService keeps the encrypted destinations of one database current
+//
+// WHY A JOB?
+// Links are only encrypted when they are written. Two things happen to the
+// links already stored:
+//   - the master key is rotated (a new local key in front of the key file,
+//     a new KMS key or primary version): their data keys are still wrapped
+//     by the old one, which can only be removed once nothing uses it. The
+//     job re-wraps them (the destination itself isn't encrypted again, see
+//     package envelope).
+//   - encryption is switched on for the first time: sensitive links made
+//     before still have their destination in plain text. The job encrypts
+//     them.
+//
+// Sensitive links with language targets or a schedule are left alone and
+// counted as skipped: their other destinations can't be encrypted.
+type ResealService struct {
+	repo      repository.SealedDestinationRepository
+	sealer    DestinationSealer
+	cache     Cache
+	batchSize int
+}
+
+// NewResealService creates a re-encryption job for the links in repo
+func NewResealService(repo repository.SealedDestinationRepository, sealer DestinationSealer, cache Cache) *ResealService {
+	return &ResealService{
+		repo:      repo,
+		sealer:    sealer,
+		cache:     cache,
+		batchSize: 500,
+	}
+}
+
+// Reseal walks every sensitive or encrypted link once
+// A link that fails is logged and left for the next run; a link edited
+// while the job runs keeps the edit (see SealedDestinationRepository.Reseal)
+func (s *ResealService) Reseal(ctx context.Context) (*domain.ResealReport, error) {
+	report := &domain.ResealReport{}
+	after := ""
+	for {
+		urls, err := s.repo.NextSealable(ctx, after, s.batchSize)
+		if err != nil {
+			return report, err
+		}
+
+		for _, url := range urls {
+			report.Scanned++
+			s.reseal(ctx, url, report)
+		}
+
+		if len(urls) < s.batchSize {
+			break
+		}
+		after = urls[len(urls)-1].ID
+	}
+	return report, nil
+}
+
+// reseal re-wraps or encrypts one link and counts the outcome in report
+func (s *ResealService) reseal(ctx context.Context, url *domain.URL, report *domain.ResealReport) {
+	var sealed string
+	var err error
+	switch {
+	case url.DestinationSealed():
+		var changed bool
+		sealed, changed, err = s.sealer.Rewrap(ctx, url.SealedDestination)
+		if err == nil && !changed {
+			return
+		}
+	case !url.Redirects() || url.OriginalURL == "":
+		return
+	case url.LanguageTargets != nil || url.Schedule != nil:
+		report.Skipped++
+		return
+	default:
+		sealed, err = s.sealer.Seal(ctx, []byte(url.OriginalURL))
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to encrypt destination of %s: %v\n", url.ShortCode, err)
+		return
+	}
+
+	updated, err := s.repo.Reseal(ctx, url, sealed)
+	if err != nil {
+		fmt.Printf("Warning: failed to store encrypted destination of %s: %v\n", url.ShortCode, err)
+		return
+	}
+	if !updated {
+		return // Edited in the meantime - the next run looks at it again
+	}
+	if url.DestinationSealed() {
+		report.Rewrapped++
+	} else {
+		report.Sealed++
+	}
+
+	// Cached copies still have the old value: the plain text destination,
+	// or a data key wrapped by a key that is about to be removed
+	keys := []string{url.ShortCode}
+	if url.CustomAlias != nil && *url.CustomAlias != url.ShortCode {
+		keys = append(keys, *url.CustomAlias)
+	}
+	for _, key := range keys {
+		if err := s.cache.DeleteURL(ctx, key); err != nil {
+			fmt.Printf("Warning: failed to invalidate cached URL: %v\n", err)
+		}
+	}
+}
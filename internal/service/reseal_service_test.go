@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSealedDestinationRepository is a mock implementation of repository.SealedDestinationRepository
+type MockSealedDestinationRepository struct {
+	mock.Mock
+}
+
+func (m *MockSealedDestinationRepository) NextSealable(ctx context.Context, afterID string, limit int) ([]*domain.URL, error) {
+	args := m.Called(ctx, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URL), args.Error(1)
+}
+
+func (m *MockSealedDestinationRepository) Reseal(ctx context.Context, url *domain.URL, sealed string) (bool, error) {
+	args := m.Called(ctx, url, sealed)
+	return args.Bool(0), args.Error(1)
+}
+
+// ==================== TESTS ====================
+
+func TestResealService_Reseal(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockSealedDestinationRepository)
+	cache := new(MockCache)
+	before := testKeyRing(t, "k1")
+	ring := testKeyRing(t, "k2", "k1") // k2 is the new current key
+
+	alias := "launch-fr"
+	oldKey := &domain.URL{ID: "1", ShortCode: "abc123", CustomAlias: &alias, Visibility: domain.VisibilitySensitive,
+		SealedDestination: sealedWith(t, before, "https://example.com/launch")}
+	current := &domain.URL{ID: "2", ShortCode: "def456", Visibility: domain.VisibilitySensitive,
+		SealedDestination: sealedWith(t, ring, "https://example.com/current")}
+	plain := &domain.URL{ID: "3", ShortCode: "ghi789", Visibility: domain.VisibilitySensitive, OriginalURL: "https://example.com/plain"}
+	targets := &domain.URL{ID: "4", ShortCode: "jkl012", Visibility: domain.VisibilitySensitive, OriginalURL: "https://example.com/en",
+		LanguageTargets: map[string]string{"fr": "https://example.com/fr"}}
+	edited := &domain.URL{ID: "5", ShortCode: "mno345", Visibility: domain.VisibilitySensitive, OriginalURL: "https://example.com/edited"}
+	secret := &domain.URL{ID: "6", ShortCode: "pqr678", Visibility: domain.VisibilitySensitive, BurnAfterReading: true}
+
+	// Pages of three: the job continues after the last ID of a full page
+	repo.On("NextSealable", ctx, "", 3).Return([]*domain.URL{oldKey, current, plain}, nil)
+	repo.On("NextSealable", ctx, "3", 3).Return([]*domain.URL{targets, edited, secret}, nil)
+	repo.On("NextSealable", ctx, "6", 3).Return([]*domain.URL{}, nil)
+
+	var rewrapped, sealed string
+	repo.On("Reseal", ctx, oldKey, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { rewrapped = args.String(2) }).Return(true, nil)
+	repo.On("Reseal", ctx, plain, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { sealed = args.String(2) }).Return(true, nil)
+	repo.On("Reseal", ctx, edited, mock.AnythingOfType("string")).Return(false, nil) // Changed by its owner meanwhile
+	cache.On("DeleteURL", ctx, mock.AnythingOfType("string")).Return(nil)
+
+	service := NewResealService(repo, ring, cache)
+	service.batchSize = 3
+
+	// Act
+	report, err := service.Reseal(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &domain.ResealReport{Scanned: 6, Sealed: 1, Rewrapped: 1, Skipped: 1}, report)
+	assert.True(t, strings.HasPrefix(rewrapped, "env1:k2:"))
+	assert.True(t, strings.HasPrefix(sealed, "env1:k2:"))
+	opened, err := testKeyRing(t, "k2").Open(ctx, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/plain", string(opened))
+
+	// Cached copies of the changed links are dropped - under both codes
+	cache.AssertCalled(t, "DeleteURL", ctx, "abc123")
+	cache.AssertCalled(t, "DeleteURL", ctx, "launch-fr")
+	cache.AssertCalled(t, "DeleteURL", ctx, "ghi789")
+	cache.AssertNumberOfCalls(t, "DeleteURL", 3)
+	repo.AssertNotCalled(t, "Reseal", ctx, current, mock.Anything)
+	repo.AssertNotCalled(t, "Reseal", ctx, targets, mock.Anything)
+}
+
+func TestResealService_Reseal_ListFails(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockSealedDestinationRepository)
+	repo.On("NextSealable", ctx, "", 500).Return(nil, assert.AnError)
+	service := NewResealService(repo, testKeyRing(t, "k1"), new(MockCache))
+
+	// Act
+	report, err := service.Reseal(ctx)
+
+	// Assert
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.NotNil(t, report, "the caller logs the partial report")
+}
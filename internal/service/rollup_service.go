@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/notify"
+	"url-shortener/internal/repository"
+)
+
+// RollupService maintains the hourly click rollups and reports from them
+//
+// Two SCHEDULED TASKS use it (see internal/scheduler):
+//   - Prune ("rollup"): rollups are written with every click and only read
+//     for the last 30 days, so older hours are deleted
+//   - SendDigests ("digest"): once a week every owner whose links were
+//     clicked gets a summary: how many links, how many clicks, the top link
+//
+// Like the other workers, each database (shard) has its own service
+type RollupService struct {
+	repo      repository.RollupRepository
+	notifier  notify.Notifier
+	retention time.Duration
+	batchSize int // Rows deleted (or owners summed up) per query
+	now       func() time.Time
+}
+
+// NewRollupService creates a new rollup service
+func NewRollupService(repo repository.RollupRepository, notifier notify.Notifier) *RollupService {
+	return &RollupService{
+		repo:      repo,
+		notifier:  notifier,
+		retention: domain.RollupRetention,
+		batchSize: 5000,
+		now:       time.Now,
+	}
+}
+
+// Prune deletes the rollups older than the retention and returns how many
+// rows it deleted. It works in batches until none are left.
+func (s *RollupService) Prune(ctx context.Context) (int64, error) {
+	before := s.now().Add(-s.retention)
+
+	var deleted int64
+	for {
+		n, err := s.repo.DeleteBefore(ctx, before, s.batchSize)
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+		if n < int64(s.batchSize) {
+			return deleted, nil
+		}
+	}
+}
+
+// SendDigests notifies every owner whose links were clicked in the last
+// week and returns how many digests were delivered
+// Delivery is AT MOST ONCE like link warnings: a failed one is logged
+func (s *RollupService) SendDigests(ctx context.Context) (int, error) {
+	now := s.now()
+	since := now.Add(-domain.DigestPeriod)
+
+	sent := 0
+	after := ""
+	for {
+		digests, err := s.repo.OwnerDigests(ctx, since, after, s.batchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		for _, digest := range digests {
+			if err := s.notifier.Notify(ctx, digestEvent(digest, since, now)); err != nil {
+				fmt.Printf("Warning: failed to deliver click digest: %v\n", err)
+				continue
+			}
+			sent++
+		}
+
+		if len(digests) < s.batchSize {
+			return sent, nil
+		}
+		after = digests[len(digests)-1].Owner
+	}
+}
+
+// digestEvent converts a digest into a notification event
+func digestEvent(digest domain.ClickDigest, from, to time.Time) notify.Event {
+	return notify.Event{
+		Type:       domain.EventClickDigest,
+		ShortCode:  digest.TopLink,
+		Owner:      digest.Owner,
+		OccurredAt: to,
+		Data: map[string]interface{}{
+			"from":       from.Format(time.RFC3339),
+			"to":         to.Format(time.RFC3339),
+			"links":      digest.Links,
+			"clicks":     digest.Clicks,
+			"top_link":   digest.TopLink,
+			"top_clicks": digest.TopClicks,
+		},
+	}
+}
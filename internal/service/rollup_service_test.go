@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockRollupRepository is a mock implementation of RollupRepository
+type MockRollupRepository struct {
+	mock.Mock
+}
+
+func (m *MockRollupRepository) DeleteBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRollupRepository) OwnerDigests(ctx context.Context, since time.Time, after string, limit int) ([]domain.ClickDigest, error) {
+	args := m.Called(ctx, since, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ClickDigest), args.Error(1)
+}
+
+func newTestRollupService(repo *MockRollupRepository, notifier notify.Notifier, now time.Time) *RollupService {
+	s := NewRollupService(repo, notifier)
+	s.batchSize = 2
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestRollupService_Prune_DeletesInBatches(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	now := time.Date(2026, 3, 30, 12, 0, 0, 0, time.UTC)
+	before := now.Add(-domain.RollupRetention)
+
+	repo := new(MockRollupRepository)
+	repo.On("DeleteBefore", ctx, before, 2).Return(int64(2), nil).Twice()
+	repo.On("DeleteBefore", ctx, before, 2).Return(int64(1), nil).Once()
+	service := newTestRollupService(repo, new(MockNotifier), now)
+
+	// Act
+	deleted, err := service.Prune(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), deleted)
+	repo.AssertExpectations(t)
+}
+
+func TestRollupService_Prune_StopsOnError(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	now := time.Date(2026, 3, 30, 12, 0, 0, 0, time.UTC)
+
+	repo := new(MockRollupRepository)
+	repo.On("DeleteBefore", ctx, mock.Anything, 2).Return(int64(2), nil).Once()
+	repo.On("DeleteBefore", ctx, mock.Anything, 2).Return(int64(0), errors.New("db down")).Once()
+	service := newTestRollupService(repo, new(MockNotifier), now)
+
+	// Act
+	deleted, err := service.Prune(ctx)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, int64(2), deleted)
+}
+
+func TestRollupService_SendDigests_PagesThroughOwners(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	now := time.Date(2026, 3, 30, 8, 0, 0, 0, time.UTC)
+	since := now.Add(-domain.DigestPeriod)
+
+	repo := new(MockRollupRepository)
+	repo.On("OwnerDigests", ctx, since, "", 2).Return([]domain.ClickDigest{
+		{Owner: "alice", Links: 3, Clicks: 120, TopLink: "launch", TopClicks: 100},
+		{Owner: "bob", Links: 1, Clicks: 7, TopLink: "cv", TopClicks: 7},
+	}, nil)
+	repo.On("OwnerDigests", ctx, since, "bob", 2).Return([]domain.ClickDigest{
+		{Owner: "carol", Links: 2, Clicks: 9, TopLink: "menu", TopClicks: 5},
+	}, nil)
+
+	notifier := new(MockNotifier)
+	var events []notify.Event
+	notifier.On("Notify", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		events = append(events, args.Get(1).(notify.Event))
+	}).Return(nil)
+	service := newTestRollupService(repo, notifier, now)
+
+	// Act
+	sent, err := service.SendDigests(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 3, sent)
+	require.Len(t, events, 3)
+
+	alice := events[0]
+	assert.Equal(t, domain.EventClickDigest, alice.Type)
+	assert.Equal(t, "alice", alice.Owner)
+	assert.Equal(t, "launch", alice.ShortCode)
+	assert.Equal(t, int64(120), alice.Data["clicks"])
+	assert.Equal(t, int64(3), alice.Data["links"])
+	assert.Equal(t, int64(100), alice.Data["top_clicks"])
+	assert.Equal(t, since.Format(time.RFC3339), alice.Data["from"])
+	assert.Equal(t, "carol", events[2].Owner)
+}
+
+func TestRollupService_SendDigests_FailedDeliveryIsSkipped(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	now := time.Date(2026, 3, 30, 8, 0, 0, 0, time.UTC)
+
+	repo := new(MockRollupRepository)
+	repo.On("OwnerDigests", ctx, mock.Anything, "", 2).Return([]domain.ClickDigest{
+		{Owner: "alice", Links: 1, Clicks: 1, TopLink: "a", TopClicks: 1},
+	}, nil)
+
+	notifier := new(MockNotifier)
+	notifier.On("Notify", ctx, mock.Anything).Return(errors.New("smtp down"))
+	service := newTestRollupService(repo, notifier, now)
+
+	// Act
+	sent, err := service.SendDigests(ctx)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+}
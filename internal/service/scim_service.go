@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// scimTokenPrefix starts every SCIM token, so a leaked one is recognizable
+// (and never mistaken for an API key)
+const scimTokenPrefix = "scim_"
+
+// SCIMService provisions workspace members for identity providers
+//
+// WHY A SEPARATE TOKEN?
+// The identity provider needs to manage people, not links. An API key
+// would let it (and anyone who reads its configuration) create and delete
+// links too; a SCIM token works on /scim/v2 and nowhere else. The owner
+// issues it here, and only its SHA-256 is stored.
+//
+// Every method but the token ones gets the workspace from the token (the
+// handler authenticates it), so they take the workspace as an argument.
+type SCIMService struct {
+	members  repository.MemberRepository
+	tokens   repository.SCIMTokenRepository
+	settings repository.WorkspaceSettingsRepository
+}
+
+// NewSCIMService creates a SCIM service
+func NewSCIMService(members repository.MemberRepository, tokens repository.SCIMTokenRepository, settings repository.WorkspaceSettingsRepository) *SCIMService {
+	return &SCIMService{members: members, tokens: tokens, settings: settings}
+}
+
+// IssueToken creates the caller's SCIM token (owners only)
+// The old token stops working; the new one is only ever shown here
+func (s *SCIMService) IssueToken(ctx context.Context) (string, error) {
+	workspace, err := ownedWorkspace(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate scim token: %w", err)
+	}
+	token := scimTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	if err := s.tokens.Save(ctx, workspace, hashToken(token)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeToken removes the caller's SCIM token (owners only)
+// Provisioning stops; members already provisioned stay
+func (s *SCIMService) RevokeToken(ctx context.Context) error {
+	workspace, err := ownedWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+	return s.tokens.Delete(ctx, workspace)
+}
+
+// Authenticate returns the workspace of a SCIM token
+// Unknown tokens (including API keys) are auth.ErrInvalidCredentials
+func (s *SCIMService) Authenticate(ctx context.Context, token string) (string, error) {
+	if !strings.HasPrefix(token, scimTokenPrefix) {
+		return "", auth.ErrInvalidCredentials
+	}
+	workspace, err := s.tokens.Workspace(ctx, hashToken(token))
+	if errors.Is(err, domain.ErrSCIMTokenNotFound) {
+		return "", auth.ErrInvalidCredentials
+	}
+	return workspace, err
+}
+
+// ListMembers returns a page of the workspace's members and the total
+func (s *SCIMService) ListMembers(ctx context.Context, workspace string, filter domain.MemberFilter, offset, limit int) ([]*domain.Member, int, error) {
+	return s.members.ListMembers(ctx, workspace, filter, offset, limit)
+}
+
+// GetMember returns one member of the workspace
+func (s *SCIMService) GetMember(ctx context.Context, workspace, id string) (*domain.Member, error) {
+	return s.members.GetMember(ctx, workspace, id)
+}
+
+// CreateMember provisions a member into the workspace
+func (s *SCIMService) CreateMember(ctx context.Context, workspace string, member *domain.Member) error {
+	member.Workspace = workspace
+	if err := member.Validate(); err != nil {
+		return err
+	}
+	return s.members.CreateMember(ctx, member)
+}
+
+// ReplaceMember replaces a member's attributes and returns it with its groups
+// Setting Active to false deprovisions the member: they keep their groups
+// (so reactivating restores their access) but MemberPrincipal refuses them
+func (s *SCIMService) ReplaceMember(ctx context.Context, workspace string, member *domain.Member) (*domain.Member, error) {
+	member.Workspace = workspace
+	if err := member.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.members.UpdateMember(ctx, member); err != nil {
+		return nil, err
+	}
+	return s.members.GetMember(ctx, workspace, member.ID)
+}
+
+// DeleteMember removes a member from the workspace and all its groups
+func (s *SCIMService) DeleteMember(ctx context.Context, workspace, id string) error {
+	return s.members.DeleteMember(ctx, workspace, id)
+}
+
+// ListGroups returns a page of the workspace's groups and the total
+func (s *SCIMService) ListGroups(ctx context.Context, workspace string, filter domain.GroupFilter, offset, limit int) ([]*domain.Group, int, error) {
+	return s.members.ListGroups(ctx, workspace, filter, offset, limit)
+}
+
+// GetGroup returns one group of the workspace
+func (s *SCIMService) GetGroup(ctx context.Context, workspace, id string) (*domain.Group, error) {
+	return s.members.GetGroup(ctx, workspace, id)
+}
+
+// CreateGroup creates a group of the workspace's members
+func (s *SCIMService) CreateGroup(ctx context.Context, workspace string, group *domain.Group) error {
+	group.Workspace = workspace
+	if err := group.Validate(); err != nil {
+		return err
+	}
+	return s.members.CreateGroup(ctx, group)
+}
+
+// ReplaceGroup replaces a group's name and members
+// Renaming a group can change its members' role (see GroupRoles)
+func (s *SCIMService) ReplaceGroup(ctx context.Context, workspace string, group *domain.Group) error {
+	group.Workspace = workspace
+	if err := group.Validate(); err != nil {
+		return err
+	}
+	return s.members.UpdateGroup(ctx, group)
+}
+
+// DeleteGroup removes a group; its members lose the role it gave them
+func (s *SCIMService) DeleteGroup(ctx context.Context, workspace, id string) error {
+	return s.members.DeleteGroup(ctx, workspace, id)
+}
+
+// MemberPrincipal returns the principal a provisioned member acts as
+// (sign-in uses it): the workspace, the member, and the highest role the
+// workspace gives any of the member's groups
+//
+// Deactivated members are domain.ErrMemberInactive, and members in no
+// mapped group domain.ErrNoWorkspaceRole - never the owner, which an
+// empty Role would mean.
+func (s *SCIMService) MemberPrincipal(ctx context.Context, workspace, userName string) (*auth.Principal, error) {
+	member, err := s.members.GetMemberByUserName(ctx, workspace, userName)
+	if err != nil {
+		return nil, err
+	}
+	if !member.Active {
+		return nil, domain.ErrMemberInactive
+	}
+
+	settings, err := s.settings.Get(ctx, workspace)
+	if errors.Is(err, domain.ErrWorkspaceSettingsNotFound) {
+		return nil, domain.ErrNoWorkspaceRole
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	role := auth.HighestRole(mappedRoles(settings.GroupRoles, memberGroupNames(member)...)...)
+	if role == "" {
+		return nil, domain.ErrNoWorkspaceRole
+	}
+	return &auth.Principal{ID: workspace, Plan: settings.Plan, Member: member.UserName, Role: role}, nil
+}
+
+// mappedRoles returns the roles the mapping gives these groups; group
+// names are case-insensitive and unmapped groups give nothing
+func mappedRoles(groupRoles map[string]string, groups ...string) []auth.Role {
+	var roles []auth.Role
+	for _, group := range groups {
+		for name, role := range groupRoles {
+			if strings.EqualFold(name, group) {
+				roles = append(roles, auth.Role(role))
+				break
+			}
+		}
+	}
+	return roles
+}
+
+// memberGroupNames returns the names of the member's groups
+func memberGroupNames(member *domain.Member) []string {
+	names := make([]string, 0, len(member.Groups))
+	for _, group := range member.Groups {
+		names = append(names, group.DisplayName)
+	}
+	return names
+}
+
+// ownedWorkspace returns the caller's workspace if they are its owner
+func ownedWorkspace(ctx context.Context) (string, error) {
+	principal := auth.FromContext(ctx)
+	if principal == auth.Anonymous || principal.EffectiveRole() != auth.RoleOwner {
+		return "", domain.ErrForbidden
+	}
+	return principal.ID, nil
+}
+
+// hashToken is what the repositories store and look tokens up by
+// (SCIM tokens and SSO sessions)
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockMemberRepository is a mock implementation of repository.MemberRepository
+type MockMemberRepository struct {
+	mock.Mock
+}
+
+func (m *MockMemberRepository) CreateMember(ctx context.Context, member *domain.Member) error {
+	args := m.Called(ctx, member)
+	return args.Error(0)
+}
+
+func (m *MockMemberRepository) GetMember(ctx context.Context, workspace, id string) (*domain.Member, error) {
+	args := m.Called(ctx, workspace, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Member), args.Error(1)
+}
+
+func (m *MockMemberRepository) GetMemberByUserName(ctx context.Context, workspace, userName string) (*domain.Member, error) {
+	args := m.Called(ctx, workspace, userName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Member), args.Error(1)
+}
+
+func (m *MockMemberRepository) ListMembers(ctx context.Context, workspace string, filter domain.MemberFilter, offset, limit int) ([]*domain.Member, int, error) {
+	args := m.Called(ctx, workspace, filter, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*domain.Member), args.Int(1), args.Error(2)
+}
+
+func (m *MockMemberRepository) UpdateMember(ctx context.Context, member *domain.Member) error {
+	args := m.Called(ctx, member)
+	return args.Error(0)
+}
+
+func (m *MockMemberRepository) DeleteMember(ctx context.Context, workspace, id string) error {
+	args := m.Called(ctx, workspace, id)
+	return args.Error(0)
+}
+
+func (m *MockMemberRepository) CreateGroup(ctx context.Context, group *domain.Group) error {
+	args := m.Called(ctx, group)
+	return args.Error(0)
+}
+
+func (m *MockMemberRepository) GetGroup(ctx context.Context, workspace, id string) (*domain.Group, error) {
+	args := m.Called(ctx, workspace, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Group), args.Error(1)
+}
+
+func (m *MockMemberRepository) ListGroups(ctx context.Context, workspace string, filter domain.GroupFilter, offset, limit int) ([]*domain.Group, int, error) {
+	args := m.Called(ctx, workspace, filter, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*domain.Group), args.Int(1), args.Error(2)
+}
+
+func (m *MockMemberRepository) UpdateGroup(ctx context.Context, group *domain.Group) error {
+	args := m.Called(ctx, group)
+	return args.Error(0)
+}
+
+func (m *MockMemberRepository) DeleteGroup(ctx context.Context, workspace, id string) error {
+	args := m.Called(ctx, workspace, id)
+	return args.Error(0)
+}
+
+// MockSCIMTokenRepository is a mock implementation of repository.SCIMTokenRepository
+type MockSCIMTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockSCIMTokenRepository) Save(ctx context.Context, workspace, tokenHash string) error {
+	args := m.Called(ctx, workspace, tokenHash)
+	return args.Error(0)
+}
+
+func (m *MockSCIMTokenRepository) Workspace(ctx context.Context, tokenHash string) (string, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSCIMTokenRepository) Delete(ctx context.Context, workspace string) error {
+	args := m.Called(ctx, workspace)
+	return args.Error(0)
+}
+
+func TestSCIMService_IssueToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		expectErr error
+	}{
+		{name: "owner", principal: &auth.Principal{ID: "team1"}},
+		{name: "explicit owner", principal: &auth.Principal{ID: "team1", Member: "olivia@example.com", Role: auth.RoleOwner}},
+		{name: "editor", principal: teamEditor, expectErr: domain.ErrForbidden},
+		{name: "viewer", principal: teamViewer, expectErr: domain.ErrForbidden},
+		{name: "anonymous", expectErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+			tokens := new(MockSCIMTokenRepository)
+			var storedHash string
+			tokens.On("Save", ctx, "team1", mock.AnythingOfType("string")).
+				Run(func(args mock.Arguments) { storedHash = args.String(2) }).
+				Return(nil).Maybe()
+			service := NewSCIMService(new(MockMemberRepository), tokens, new(MockWorkspaceSettingsRepository))
+
+			// Act
+			token, err := service.IssueToken(ctx)
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				tokens.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(token, "scim_"))
+			assert.Equal(t, hashToken(token), storedHash, "only the hash is stored")
+			assert.NotContains(t, storedHash, token)
+		})
+	}
+}
+
+func TestSCIMService_RevokeToken(t *testing.T) {
+	// Arrange
+	owner := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "team1"})
+	editor := auth.WithPrincipal(context.Background(), teamEditor)
+	tokens := new(MockSCIMTokenRepository)
+	tokens.On("Delete", owner, "team1").Return(nil)
+	service := NewSCIMService(new(MockMemberRepository), tokens, new(MockWorkspaceSettingsRepository))
+
+	// Act & Assert
+	assert.NoError(t, service.RevokeToken(owner))
+	assert.ErrorIs(t, service.RevokeToken(editor), domain.ErrForbidden)
+	tokens.AssertExpectations(t)
+}
+
+func TestSCIMService_Authenticate(t *testing.T) {
+	const token = "scim_abcdef"
+	tests := []struct {
+		name      string
+		token     string
+		found     string
+		foundErr  error
+		expected  string
+		expectErr error
+	}{
+		{name: "known token", token: token, found: "team1", expected: "team1"},
+		{name: "unknown token", token: token, foundErr: domain.ErrSCIMTokenNotFound, expectErr: auth.ErrInvalidCredentials},
+		{name: "api key", token: "sk_live_123", expectErr: auth.ErrInvalidCredentials},
+		{name: "database down", token: token, foundErr: assert.AnError, expectErr: assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			tokens := new(MockSCIMTokenRepository)
+			tokens.On("Workspace", ctx, hashToken(tt.token)).Return(tt.found, tt.foundErr).Maybe()
+			service := NewSCIMService(new(MockMemberRepository), tokens, new(MockWorkspaceSettingsRepository))
+
+			// Act
+			workspace, err := service.Authenticate(ctx, tt.token)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectErr)
+			assert.Equal(t, tt.expected, workspace)
+		})
+	}
+}
+
+func TestSCIMService_CreateMember(t *testing.T) {
+	tests := []struct {
+		name      string
+		member    *domain.Member
+		expectErr error
+	}{
+		{name: "valid", member: &domain.Member{UserName: " ada@example.com ", Active: true}},
+		{name: "no user name", member: &domain.Member{UserName: "  "}, expectErr: domain.ErrInvalidMember},
+		{name: "user name too long", member: &domain.Member{UserName: strings.Repeat("a", 256)}, expectErr: domain.ErrInvalidMember},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			members := new(MockMemberRepository)
+			if tt.expectErr == nil {
+				members.On("CreateMember", ctx, mock.MatchedBy(func(m *domain.Member) bool {
+					return m.Workspace == "team1" && m.UserName == "ada@example.com"
+				})).Return(nil)
+			}
+			service := NewSCIMService(members, new(MockSCIMTokenRepository), new(MockWorkspaceSettingsRepository))
+
+			// Act
+			err := service.CreateMember(ctx, "team1", tt.member)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectErr)
+			members.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSCIMService_MemberPrincipal(t *testing.T) {
+	groupRoles := map[string]string{"Marketing": "editor", "everyone": "viewer", "Admins": "owner"}
+
+	tests := []struct {
+		name         string
+		member       *domain.Member
+		memberErr    error
+		settings     *domain.WorkspaceSettings
+		settingsErr  error
+		expectedRole auth.Role
+		expectErr    error
+	}{
+		{
+			name:         "highest role wins",
+			member:       &domain.Member{UserName: "ada@example.com", Active: true, Groups: []domain.GroupRef{{DisplayName: "Everyone"}, {DisplayName: "Marketing"}}},
+			settings:     &domain.WorkspaceSettings{GroupRoles: groupRoles},
+			expectedRole: auth.RoleEditor,
+		},
+		{
+			name:         "owner group",
+			member:       &domain.Member{UserName: "ada@example.com", Active: true, Groups: []domain.GroupRef{{DisplayName: "admins"}}},
+			settings:     &domain.WorkspaceSettings{GroupRoles: groupRoles, Plan: domain.PlanPro},
+			expectedRole: auth.RoleOwner,
+		},
+		{
+			name:      "deactivated",
+			member:    &domain.Member{UserName: "ada@example.com", Active: false, Groups: []domain.GroupRef{{DisplayName: "Marketing"}}},
+			settings:  &domain.WorkspaceSettings{GroupRoles: groupRoles},
+			expectErr: domain.ErrMemberInactive,
+		},
+		{
+			name:      "no mapped group",
+			member:    &domain.Member{UserName: "ada@example.com", Active: true, Groups: []domain.GroupRef{{DisplayName: "Sales"}}},
+			settings:  &domain.WorkspaceSettings{GroupRoles: groupRoles},
+			expectErr: domain.ErrNoWorkspaceRole,
+		},
+		{
+			name:        "no settings",
+			member:      &domain.Member{UserName: "ada@example.com", Active: true, Groups: []domain.GroupRef{{DisplayName: "Marketing"}}},
+			settingsErr: domain.ErrWorkspaceSettingsNotFound,
+			expectErr:   domain.ErrNoWorkspaceRole,
+		},
+		{name: "not provisioned", memberErr: domain.ErrMemberNotFound, expectErr: domain.ErrMemberNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			members := new(MockMemberRepository)
+			members.On("GetMemberByUserName", ctx, "team1", "ada@example.com").Return(tt.member, tt.memberErr)
+			settings := new(MockWorkspaceSettingsRepository)
+			settings.On("Get", ctx, "team1").Return(tt.settings, tt.settingsErr).Maybe()
+			service := NewSCIMService(members, new(MockSCIMTokenRepository), settings)
+
+			// Act
+			principal, err := service.MemberPrincipal(ctx, "team1", "ada@example.com")
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				assert.Nil(t, principal)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &auth.Principal{ID: "team1", Plan: tt.settings.Plan, Member: "ada@example.com", Role: tt.expectedRole}, principal)
+		})
+	}
+}
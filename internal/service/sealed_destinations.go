@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/domain"
+)
+
+// DestinationSealer encrypts the destinations of sensitive links
+// Implemented by envelope.Sealer (with any keys.Manager)
+type DestinationSealer interface {
+	Seal(ctx context.Context, plaintext []byte) (string, error)
+	Open(ctx context.Context, sealed string) ([]byte, error)
+
+	// Rewrap moves a sealed value to the current key version
+	// Returns false when it already uses it
+	Rewrap(ctx context.Context, sealed string) (string, bool, error)
+}
+
+// WithDestinationSealing stores the destinations of sensitive links
+// encrypted (see domain.URL.DestinationSealed)
+// Only GetURL - the redirect - decrypts them again
+func (s *URLService) WithDestinationSealing(sealer DestinationSealer) *URLService {
+	s.sealer = sealer
+	return s
+}
+
+// sealDestination encrypts or decrypts url's destination to match its
+// visibility, right before it is written
+//
+//   - a new plain text destination replaces an old encrypted one
+//   - sensitive links get their destination encrypted; the resolved URL and
+//     page metadata go too (they give the destination away)
+//   - links switched back to standard get their destination decrypted
+func (s *URLService) sealDestination(ctx context.Context, url *domain.URL) error {
+	if !url.Redirects() {
+		return nil
+	}
+	if url.OriginalURL != "" {
+		url.SealedDestination = ""
+	}
+
+	switch {
+	case url.Visibility == domain.VisibilitySensitive && s.sealer != nil && !url.DestinationSealed():
+		if url.LanguageTargets != nil || url.Schedule != nil {
+			return fmt.Errorf("validation failed: %w", domain.ErrSealedDestinationTargets)
+		}
+		sealed, err := s.sealer.Seal(ctx, []byte(url.OriginalURL))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt destination: %w", err)
+		}
+		url.SealedDestination = sealed
+		url.OriginalURL = ""
+		url.ResolvedURL = nil
+		url.Metadata = nil
+	case url.Visibility != domain.VisibilitySensitive && url.DestinationSealed():
+		destination, err := s.destinationOf(ctx, url)
+		if err != nil {
+			return err
+		}
+		url.OriginalURL = destination
+		url.SealedDestination = ""
+	}
+	return nil
+}
+
+// destinationOf returns the destination of url, decrypted if need be
+func (s *URLService) destinationOf(ctx context.Context, url *domain.URL) (string, error) {
+	if !url.DestinationSealed() {
+		return url.OriginalURL, nil
+	}
+	if s.sealer == nil {
+		return "", domain.ErrSealedDestination
+	}
+	plaintext, err := s.sealer.Open(ctx, url.SealedDestination)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrSealedDestination, err)
+	}
+	return string(plaintext), nil
+}
+
+// openDestination returns a copy of url with its destination decrypted
+// A copy: the cache hands the same link to other requests, and the
+// plain text must not end up back in it
+func (s *URLService) openDestination(ctx context.Context, url *domain.URL) (*domain.URL, error) {
+	if !url.DestinationSealed() {
+		return url, nil
+	}
+	destination, err := s.destinationOf(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	opened := *url
+	opened.OriginalURL = destination
+	return &opened, nil
+}
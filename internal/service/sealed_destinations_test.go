@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/crypto/envelope"
+	"url-shortener/internal/crypto/keys"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testKeyRing returns a sealer with a local key per ID (the first is current)
+// The key is derived from the ID, so "k1" is the same key in every ring
+func testKeyRing(t *testing.T, ids ...string) *envelope.Sealer {
+	t.Helper()
+	specs := make([]string, len(ids))
+	for i, id := range ids {
+		key := sha256.Sum256([]byte(id))
+		specs[i] = id + "=" + hex.EncodeToString(key[:])
+	}
+	local, err := keys.ParseLocal(strings.Join(specs, ","))
+	require.NoError(t, err)
+	return envelope.New(local)
+}
+
+// sealedWith seals destination with ring
+func sealedWith(t *testing.T, ring *envelope.Sealer, destination string) string {
+	t.Helper()
+	sealed, err := ring.Seal(context.Background(), []byte(destination))
+	require.NoError(t, err)
+	return sealed
+}
+
+func TestCreateShortURL_SealsSensitiveDestination(t *testing.T) {
+	tests := []struct {
+		name       string
+		sealing    bool
+		opts       []domain.URLOption
+		wantSealed bool
+		wantErr    error
+	}{
+		{name: "sensitive", sealing: true, opts: []domain.URLOption{domain.WithVisibility(domain.VisibilitySensitive)}, wantSealed: true},
+		{name: "standard", sealing: true},
+		{name: "sealing off", opts: []domain.URLOption{domain.WithVisibility(domain.VisibilitySensitive)}},
+		{
+			name:    "sensitive with language targets",
+			sealing: true,
+			opts: []domain.URLOption{
+				domain.WithVisibility(domain.VisibilitySensitive),
+				domain.WithLanguageTargets(map[string]string{"fr": "https://example.com/fr/launch"}),
+			},
+			wantErr: domain.ErrSealedDestinationTargets,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			mockURLRepo := new(MockURLRepository)
+			ring := testKeyRing(t, "k1")
+			service := NewURLService(mockURLRepo, new(MockClickRepository))
+			if tt.sealing {
+				service.WithDestinationSealing(ring)
+			}
+			mockURLRepo.On("ExistsCustomAlias", ctx, "launch").Return(false, nil)
+			mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil).Maybe()
+
+			// Act
+			url, err := service.CreateShortURL(ctx, "https://example.com/launch", "launch", "team1", 0, tt.opts...)
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockURLRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			if !tt.wantSealed {
+				assert.Equal(t, "https://example.com/launch", url.OriginalURL)
+				assert.False(t, url.DestinationSealed())
+				return
+			}
+			assert.Empty(t, url.OriginalURL, "the plain text never reaches the database")
+			opened, err := ring.Open(ctx, url.SealedDestination)
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com/launch", string(opened))
+		})
+	}
+}
+
+func TestGetURL_OpensSealedDestination(t *testing.T) {
+	tests := []struct {
+		name     string
+		ring     *envelope.Sealer
+		expected string
+		wantErr  error
+	}{
+		{name: "opened", ring: testKeyRing(t, "k1"), expected: "https://example.com/launch"},
+		{name: "key removed from the ring", ring: testKeyRing(t, "k2"), wantErr: domain.ErrSealedDestination},
+		{name: "sealing off", wantErr: domain.ErrSealedDestination},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			mockURLRepo := new(MockURLRepository)
+			service := NewURLService(mockURLRepo, new(MockClickRepository))
+			if tt.ring != nil {
+				service.WithDestinationSealing(tt.ring)
+			}
+			stored := &domain.URL{
+				ID: "1", ShortCode: "launch", IsActive: true,
+				Visibility:        domain.VisibilitySensitive,
+				SealedDestination: sealedWith(t, testKeyRing(t, "k1"), "https://example.com/launch"),
+			}
+			mockURLRepo.On("GetByShortCode", ctx, "launch").Return(stored, nil)
+
+			// Act
+			url, err := service.GetURL(ctx, "launch")
+
+			// Assert: the shared (cached) link keeps its destination sealed
+			assert.Empty(t, stored.OriginalURL)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, url.OriginalURL)
+			assert.False(t, url.ServableAtEdge(), "the redirect isn't cached at the CDN")
+		})
+	}
+}
+
+func TestSetVisibility_SealsDestination(t *testing.T) {
+	ring := testKeyRing(t, "k1")
+
+	tests := []struct {
+		name            string
+		current         *domain.URL
+		visibility      domain.LinkVisibility
+		wantDestination string
+		wantSealed      bool
+		wantErr         error
+	}{
+		{
+			name:       "made sensitive",
+			current:    &domain.URL{ID: "1", ShortCode: "launch", OriginalURL: "https://example.com/launch", CreatedBy: "team1", IsActive: true},
+			visibility: domain.VisibilitySensitive,
+			wantSealed: true,
+		},
+		{
+			name: "made standard",
+			current: &domain.URL{ID: "1", ShortCode: "launch", CreatedBy: "team1", IsActive: true,
+				Visibility: domain.VisibilitySensitive, SealedDestination: sealedWith(t, ring, "https://example.com/launch")},
+			visibility:      domain.VisibilityStandard,
+			wantDestination: "https://example.com/launch",
+		},
+		{
+			name: "sensitive with a schedule",
+			current: &domain.URL{ID: "1", ShortCode: "launch", OriginalURL: "https://example.com/launch", CreatedBy: "team1", IsActive: true,
+				Schedule: &domain.Schedule{Rules: []domain.ScheduleRule{{Start: "18:00", End: "09:00", URL: "https://example.com/closed"}}}},
+			visibility: domain.VisibilitySensitive,
+			wantErr:    domain.ErrSealedDestinationTargets,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), teamOwner)
+			mockURLRepo := new(MockURLRepository)
+			service := NewURLService(mockURLRepo, new(MockClickRepository)).WithDestinationSealing(ring)
+			mockURLRepo.On("GetByID", ctx, "1").Return(tt.current, nil)
+			mockURLRepo.On("Update", ctx, mock.AnythingOfType("*domain.URL")).Return(nil).Maybe()
+
+			// Act
+			updated, err := service.SetVisibility(ctx, "1", tt.visibility)
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockURLRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDestination, updated.OriginalURL)
+			assert.Equal(t, tt.wantSealed, updated.DestinationSealed())
+		})
+	}
+}
+
+func TestUpsertURL_SealedDestination(t *testing.T) {
+	ring := testKeyRing(t, "k1")
+	alice := &auth.Principal{ID: "alice"}
+	sealed := sealedWith(t, ring, "https://example.com/v1")
+
+	tests := []struct {
+		name        string
+		destination string
+		wantOutcome domain.UpsertOutcome
+	}{
+		{name: "same destination is a no-op", destination: "https://example.com/v1", wantOutcome: domain.UpsertUnchanged},
+		{name: "new destination is sealed", destination: "https://example.com/v2", wantOutcome: domain.UpsertUpdated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), alice)
+			mockURLRepo := new(MockURLRepository)
+			service := NewURLService(mockURLRepo, new(MockClickRepository)).WithDestinationSealing(ring)
+			current := &domain.URL{ID: "1", ShortCode: "promo", CreatedBy: "alice", IsActive: true, Version: 4,
+				Visibility: domain.VisibilitySensitive, SealedDestination: sealed}
+			mockURLRepo.On("GetByCustomAlias", mock.Anything, "promo").Return(current, nil)
+			mockURLRepo.On("Update", ctx, mock.AnythingOfType("*domain.URL")).Return(nil).Maybe()
+
+			// Act
+			url, outcome, err := service.UpsertURL(ctx, "promo", tt.destination, domain.Precondition{})
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOutcome, outcome)
+			assert.Empty(t, url.OriginalURL)
+			if tt.wantOutcome == domain.UpsertUnchanged {
+				mockURLRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NotEqual(t, sealed, url.SealedDestination)
+			opened, err := ring.Open(ctx, url.SealedDestination)
+			require.NoError(t, err)
+			assert.Equal(t, tt.destination, string(opened))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// SearchService finds links by their alias, title, destination or
+// description
+type SearchService struct {
+	repo repository.SearchRepository
+}
+
+// NewSearchService creates a new search service
+func NewSearchService(repo repository.SearchRepository) *SearchService {
+	return &SearchService{repo: repo}
+}
+
+// Search returns one page of the caller's links matching text
+// q carries the filters and the page; its Terms are parsed from text.
+//
+// Users only ever search their own links. Admins search everyone's, or one
+// owner's with q.Owner. Viewers don't find sensitive links at all: hiding
+// the destination of a hit isn't enough when the hit itself says which
+// words the destination contains.
+func (s *SearchService) Search(ctx context.Context, text string, q domain.SearchQuery) (*domain.SearchResult, error) {
+	terms, err := domain.ParseSearchTerms(text)
+	if err != nil {
+		return nil, err
+	}
+	q.Terms = terms
+
+	principal := auth.FromContext(ctx)
+	if !principal.Admin {
+		if principal == auth.Anonymous || (q.Owner != "" && q.Owner != principal.ID) {
+			return nil, domain.ErrForbidden
+		}
+		q.Owner = principal.ID
+		q.HideSensitive = principal.EffectiveRole() == auth.RoleViewer
+	}
+
+	result, err := s.repo.Search(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search URLs: %w", err)
+	}
+	return result, nil
+}
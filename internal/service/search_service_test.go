@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSearchRepository is a mock implementation of SearchRepository
+type MockSearchRepository struct {
+	mock.Mock
+}
+
+func (m *MockSearchRepository) Search(ctx context.Context, q domain.SearchQuery) (*domain.SearchResult, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SearchResult), args.Error(1)
+}
+
+func TestSearchService_Search(t *testing.T) {
+	user := &auth.Principal{ID: "user1"}
+	admin := &auth.Principal{ID: "root", Admin: true}
+	viewer := &auth.Principal{ID: "user1", Member: "vic@example.com", Role: auth.RoleViewer}
+
+	tests := []struct {
+		name          string
+		principal     *auth.Principal
+		text          string
+		owner         string
+		expectedOwner string
+		expectedHide  bool
+		expectedErr   error
+	}{
+		{name: "users search their own links", principal: user, text: "Summer-Sale", expectedOwner: "user1"},
+		{name: "users can't search other owners", principal: user, text: "sale", owner: "user2", expectedErr: domain.ErrForbidden},
+		{name: "anonymous callers can't search", principal: auth.Anonymous, text: "sale", expectedErr: domain.ErrForbidden},
+		{name: "admins search everyone", principal: admin, text: "summer sale"},
+		{name: "admins can pick an owner", principal: admin, text: "summer sale", owner: "user2", expectedOwner: "user2"},
+		{name: "no words", principal: user, text: " -- ", expectedErr: domain.ErrInvalidSearch},
+		{name: "viewers don't find sensitive links", principal: viewer, text: "summer sale", expectedOwner: "user1", expectedHide: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := new(MockSearchRepository)
+			service := NewSearchService(repo)
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			expected := domain.SearchQuery{Terms: []string{"summer", "sale"}, Owner: tt.expectedOwner, Limit: 20, HideSensitive: tt.expectedHide}
+			repo.On("Search", ctx, expected).Return(&domain.SearchResult{Total: 0}, nil).Maybe()
+
+			// Act
+			result, err := service.Search(ctx, tt.text, domain.SearchQuery{Owner: tt.owner, Limit: 20})
+
+			// Assert
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				repo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, result)
+			repo.AssertExpectations(t)
+		})
+	}
+}
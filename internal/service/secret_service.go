@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// PayloadCipher encrypts the payload of burn-after-reading links
+// Implemented by aesgcm.Cipher
+type PayloadCipher interface {
+	Seal(plaintext []byte) (string, error)
+	Open(sealed string) ([]byte, error)
+}
+
+// SecretService creates and reveals burn-after-reading links
+//
+// WHY NOT PUT THE SECRET IN THE URL?
+// A burn-after-reading link has no destination of its own. Its secret - a
+// text, or a URL to show instead of redirect to - is encrypted and stored
+// in the link's payload, so it never shows up in stats, exports, logs or
+// the cache. Revealing it destroys it (see domain.WithBurnAfterReading).
+type SecretService struct {
+	links   LinkCreator
+	urlRepo repository.URLRepository
+	audit   repository.AuditRepository
+	cipher  PayloadCipher
+	cache   Cache
+	edge    EdgePurger        // Optional: purges revealed links from the CDN
+	policy  DestinationPolicy // Optional: destination rules for secret URLs
+}
+
+// NewSecretService creates a secret service
+func NewSecretService(links LinkCreator, urlRepo repository.URLRepository, audit repository.AuditRepository, cipher PayloadCipher, cache Cache) *SecretService {
+	return &SecretService{
+		links:   links,
+		urlRepo: urlRepo,
+		audit:   audit,
+		cipher:  cipher,
+		cache:   cache,
+	}
+}
+
+// WithEdgePurger purges revealed links from the CDN
+func (s *SecretService) WithEdgePurger(p EdgePurger) *SecretService {
+	s.edge = p
+	return s
+}
+
+// WithDestinationPolicy applies the destination domain rules to secret URLs
+// The link itself has no destination, so URLService can't check them
+func (s *SecretService) WithDestinationPolicy(p DestinationPolicy) *SecretService {
+	s.policy = p
+	return s
+}
+
+// CreateSecret creates a link that reveals secret once
+// The link belongs to the caller, who can read its audit log
+func (s *SecretService) CreateSecret(ctx context.Context, secret domain.Secret, expiresIn time.Duration) (*domain.URL, error) {
+	if err := secret.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	owner := auth.FromContext(ctx).ID
+	if secret.URL != "" && s.policy != nil {
+		if err := s.policy.CheckURL(&domain.URL{OriginalURL: secret.URL, CreatedBy: owner}); err != nil {
+			return nil, err
+		}
+	}
+
+	plaintext, err := json.Marshal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode secret: %w", err)
+	}
+	sealed, err := s.cipher.Seal(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	return s.links.CreateShortURL(ctx, "", "", owner, expiresIn, domain.WithBurnAfterReading(sealed))
+}
+
+// Reveal destroys the link's payload and returns the secret it held
+// Returns domain.ErrLinkBurned when another visitor revealed it first.
+//
+// The reveal is recorded in the audit log with who asked and from where.
+// A failed audit write only logs: the payload is gone already, and failing
+// here would destroy the secret without anybody seeing it.
+func (s *SecretService) Reveal(ctx context.Context, url *domain.URL, ipAddress, userAgent string) (*domain.Secret, error) {
+	if !url.BurnAfterReading {
+		return nil, domain.ErrURLNotFound
+	}
+
+	payload, err := s.urlRepo.Burn(ctx, url.ShortCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reveal secret: %w", err)
+	}
+
+	// Revealed now, by us or someone else - caches must stop serving the payload
+	s.invalidateCache(ctx, url)
+	purgeEdge(ctx, s.edge, url)
+	if payload == nil {
+		return nil, domain.ErrLinkBurned
+	}
+
+	entry := &domain.AuditEntry{
+		Action:    domain.AuditLinkRevealed,
+		Workspace: url.CreatedBy,
+		URLID:     url.ID,
+		ShortCode: url.ShortCode,
+		Actor:     auth.FromContext(ctx).Actor(),
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	}
+	if err := s.audit.Record(ctx, entry); err != nil {
+		fmt.Printf("Warning: failed to audit reveal of %s: %v\n", url.ShortCode, err)
+	}
+
+	plaintext, err := s.cipher.Open(*payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	var secret domain.Secret
+	if err := json.Unmarshal(plaintext, &secret); err != nil {
+		return nil, fmt.Errorf("failed to decode secret: %w", err)
+	}
+	return &secret, nil
+}
+
+// ListAudit returns the audit log of a link, newest first (owner or admin only)
+func (s *SecretService) ListAudit(ctx context.Context, id string) ([]*domain.AuditEntry, error) {
+	url, err := s.urlRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeManage(ctx, url); err != nil {
+		return nil, err
+	}
+	return s.audit.ListByURL(ctx, url.ID)
+}
+
+// invalidateCache removes every cache entry that may hold the link
+func (s *SecretService) invalidateCache(ctx context.Context, url *domain.URL) {
+	keys := []string{url.ShortCode}
+	if url.CustomAlias != nil && *url.CustomAlias != url.ShortCode {
+		keys = append(keys, *url.CustomAlias)
+	}
+
+	for _, key := range keys {
+		if err := s.cache.DeleteURL(ctx, key); err != nil {
+			fmt.Printf("Warning: failed to invalidate cached URL: %v\n", err)
+		}
+	}
+}
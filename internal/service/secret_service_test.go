@@ -0,0 +1,210 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/crypto/aesgcm"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAuditRepository is a mock implementation of repository.AuditRepository
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) ListByURL(ctx context.Context, urlID string) ([]*domain.AuditEntry, error) {
+	args := m.Called(ctx, urlID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.AuditEntry), args.Error(1)
+}
+
+// testCipher returns a cipher with a fixed key
+func testCipher(t *testing.T) *aesgcm.Cipher {
+	t.Helper()
+	cipher, err := aesgcm.New(bytes.Repeat([]byte{7}, aesgcm.KeySize))
+	require.NoError(t, err)
+	return cipher
+}
+
+// ==================== TESTS ====================
+
+func TestSecretService_CreateSecret(t *testing.T) {
+	tests := []struct {
+		name       string
+		secret     domain.Secret
+		expectCall bool
+		wantErr    error
+	}{
+		{name: "text", secret: domain.Secret{Text: "the wifi password is hunter2"}, expectCall: true},
+		{name: "url", secret: domain.Secret{URL: "https://example.com/invite"}, expectCall: true},
+		{name: "text and url", secret: domain.Secret{Text: "x", URL: "https://example.com"}, wantErr: domain.ErrInvalidSecret},
+		{name: "nothing", secret: domain.Secret{Text: "   "}, wantErr: domain.ErrInvalidSecret},
+		{name: "not a web URL", secret: domain.Secret{URL: "javascript:alert(1)"}, wantErr: domain.ErrInvalidSecret},
+		{name: "blocked URL", secret: domain.Secret{URL: "https://evil.com/login"}, wantErr: domain.ErrDestinationBlocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+			links := new(MockLinkCreator)
+			policy := policyWith(t, storedRule("1", domain.GlobalWorkspace, "evil.com", domain.PatternDomain, domain.PolicyDeny))
+			svc := NewSecretService(links, nil, nil, testCipher(t), nil).WithDestinationPolicy(policy)
+			if tt.expectCall {
+				// The secret is never the link's destination
+				links.On("CreateShortURL", ctx, "", "", "user1", "").Return(&domain.URL{ShortCode: "abc123"}, nil)
+			}
+
+			// Act
+			url, err := svc.CreateSecret(ctx, tt.secret, 0)
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				links.AssertNotCalled(t, "CreateShortURL")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "abc123", url.ShortCode)
+			links.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSecretService_Reveal(t *testing.T) {
+	cipher := testCipher(t)
+	sealed, err := cipher.Seal([]byte(`{"text":"the wifi password is hunter2"}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		payload    *string
+		auditErr   error
+		wantSecret *domain.Secret
+		wantErr    error
+	}{
+		{
+			name:       "revealed",
+			payload:    &sealed,
+			wantSecret: &domain.Secret{Text: "the wifi password is hunter2"},
+		},
+		{
+			name:    "already revealed",
+			wantErr: domain.ErrLinkBurned,
+		},
+		{
+			name:       "audit log down",
+			payload:    &sealed,
+			auditErr:   assert.AnError,
+			wantSecret: &domain.Secret{Text: "the wifi password is hunter2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			repo := new(MockURLRepository)
+			audit := new(MockAuditRepository)
+			cache := new(MockCache)
+			edge := new(MockEdgePurger)
+			url := &domain.URL{ID: "1", ShortCode: "abc123", CreatedBy: "user1", BurnAfterReading: true, Payload: &sealed}
+
+			repo.On("Burn", ctx, "abc123").Return(tt.payload, nil)
+			cache.On("DeleteURL", ctx, "abc123").Return(nil)
+			edge.On("PurgeLinks", ctx, []*domain.URL{url}).Return(nil)
+			audit.On("Record", ctx, mock.AnythingOfType("*domain.AuditEntry")).Return(tt.auditErr)
+
+			svc := NewSecretService(nil, repo, audit, cipher, cache).WithEdgePurger(edge)
+
+			// Act
+			secret, err := svc.Reveal(ctx, url, "203.0.113.7", "Mozilla/5.0")
+
+			// Assert
+			cache.AssertExpectations(t)
+			edge.AssertExpectations(t)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				audit.AssertNotCalled(t, "Record", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSecret, secret)
+			audit.AssertCalled(t, "Record", ctx, &domain.AuditEntry{
+				Action:    domain.AuditLinkRevealed,
+				Workspace: "user1",
+				URLID:     "1",
+				ShortCode: "abc123",
+				Actor:     auth.Anonymous.ID,
+				IPAddress: "203.0.113.7",
+				UserAgent: "Mozilla/5.0",
+			})
+		})
+	}
+}
+
+func TestSecretService_Reveal_NotASecret(t *testing.T) {
+	// Arrange
+	repo := new(MockURLRepository)
+	svc := NewSecretService(nil, repo, nil, testCipher(t), nil)
+
+	// Act
+	_, err := svc.Reveal(context.Background(), &domain.URL{ShortCode: "abc123", OriginalURL: "https://example.com"}, "", "")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrURLNotFound)
+	repo.AssertNotCalled(t, "Burn", mock.Anything, mock.Anything)
+}
+
+func TestSecretService_ListAudit(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		wantErr   error
+	}{
+		{name: "owner", principal: &auth.Principal{ID: "user1"}},
+		{name: "admin", principal: &auth.Principal{ID: "root", Admin: true}},
+		{name: "someone else", principal: &auth.Principal{ID: "user2"}, wantErr: domain.ErrForbidden},
+		{name: "anonymous", principal: auth.Anonymous, wantErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			repo := new(MockURLRepository)
+			audit := new(MockAuditRepository)
+			entries := []*domain.AuditEntry{{ID: "a1", Action: domain.AuditLinkRevealed, URLID: "1"}}
+			repo.On("GetByID", ctx, "1").Return(&domain.URL{ID: "1", ShortCode: "abc123", CreatedBy: "user1"}, nil)
+			audit.On("ListByURL", ctx, "1").Return(entries, nil)
+
+			svc := NewSecretService(nil, repo, audit, testCipher(t), nil)
+
+			// Act
+			got, err := svc.ListAudit(ctx, "1")
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				audit.AssertNotCalled(t, "ListByURL", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, entries, got)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/crypto/keys"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// LinkCreator creates short links
+// Implemented by URLService
+type LinkCreator interface {
+	CreateShortURL(ctx context.Context, originalURL, customAlias, createdBy string, expiresIn time.Duration, opts ...domain.URLOption) (*domain.URL, error)
+}
+
+// SlackService backs the /shorten slash command
+//
+// Every workspace is configured separately: its own signing secret, the
+// principal its links belong to, and optionally a custom domain. Links are
+// created as that principal, so ownership and plan quotas work exactly
+// like for API callers.
+type SlackService struct {
+	workspaces repository.SlackWorkspaceRepository
+	links      LinkCreator
+	secrets    keys.Manager // nil = signing secrets are stored in plain text
+}
+
+// NewSlackService creates a Slack service
+func NewSlackService(workspaces repository.SlackWorkspaceRepository, links LinkCreator) *SlackService {
+	return &SlackService{
+		workspaces: workspaces,
+		links:      links,
+	}
+}
+
+// WithSecretEncryption stores signing secrets encrypted by m
+// Anyone with the signing secret can forge slash commands - as the
+// workspace owner. Secrets saved before stay readable and are encrypted
+// the next time their workspace is saved.
+func (s *SlackService) WithSecretEncryption(m keys.Manager) *SlackService {
+	s.secrets = m
+	return s
+}
+
+// Workspace returns the settings of a Slack team, signing secret decrypted
+func (s *SlackService) Workspace(ctx context.Context, teamID string) (*domain.SlackWorkspace, error) {
+	ws, err := s.workspaces.Get(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	ws.SigningSecret, err = keys.DecryptSecret(ctx, s.secrets, ws.SigningSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt signing secret of %s: %w", teamID, err)
+	}
+	return ws, nil
+}
+
+// SaveWorkspace creates or replaces a workspace's settings
+// The owner defaults to "slack:<team id>"
+func (s *SlackService) SaveWorkspace(ctx context.Context, ws *domain.SlackWorkspace) error {
+	if err := ws.Validate(); err != nil {
+		return err
+	}
+	if ws.Owner == "" {
+		ws.Owner = domain.DefaultSlackOwner(ws.TeamID)
+	}
+	ws.Domain = strings.ToLower(ws.Domain)
+	if s.secrets == nil {
+		return s.workspaces.Save(ctx, ws)
+	}
+
+	// The caller's copy keeps the plain text secret
+	stored := *ws
+	ciphertext, err := s.secrets.Encrypt(ctx, []byte(ws.SigningSecret))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt signing secret: %w", err)
+	}
+	stored.SigningSecret = ciphertext.String()
+	if err := s.workspaces.Save(ctx, &stored); err != nil {
+		return err
+	}
+	ws.CreatedAt, ws.UpdatedAt = stored.CreatedAt, stored.UpdatedAt
+	return nil
+}
+
+// DeleteWorkspace removes a workspace; its existing links stay
+func (s *SlackService) DeleteWorkspace(ctx context.Context, teamID string) error {
+	return s.workspaces.Delete(ctx, teamID)
+}
+
+// Shorten runs "/shorten <url> [alias]" for workspace ws
+func (s *SlackService) Shorten(ctx context.Context, ws *domain.SlackWorkspace, text string) (*domain.URL, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || len(fields) > 2 || fields[0] == "help" {
+		return nil, domain.ErrSlackUsage
+	}
+
+	// Slack wraps links it recognizes in angle brackets: <https://example.com>
+	// or <https://example.com|example.com>
+	originalURL := strings.TrimSuffix(strings.TrimPrefix(fields[0], "<"), ">")
+	originalURL, _, _ = strings.Cut(originalURL, "|")
+
+	var alias string
+	if len(fields) == 2 {
+		alias = fields[1]
+	}
+
+	var opts []domain.URLOption
+	if ws.Domain != "" {
+		opts = append(opts, domain.WithDomain(ws.Domain))
+	}
+
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{ID: ws.Owner})
+	return s.links.CreateShortURL(ctx, originalURL, alias, ws.Owner, 0, opts...)
+}
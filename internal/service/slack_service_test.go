@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/crypto/keys"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockLinkCreator is a mock implementation of LinkCreator
+type MockLinkCreator struct {
+	mock.Mock
+}
+
+func (m *MockLinkCreator) CreateShortURL(ctx context.Context, originalURL, customAlias, createdBy string, expiresIn time.Duration, opts ...domain.URLOption) (*domain.URL, error) {
+	url := &domain.URL{}
+	for _, opt := range opts {
+		opt(url)
+	}
+	args := m.Called(ctx, originalURL, customAlias, createdBy, url.Domain)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+// MockSlackWorkspaceRepository is a mock implementation of repository.SlackWorkspaceRepository
+type MockSlackWorkspaceRepository struct {
+	mock.Mock
+}
+
+func (m *MockSlackWorkspaceRepository) Get(ctx context.Context, teamID string) (*domain.SlackWorkspace, error) {
+	args := m.Called(ctx, teamID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SlackWorkspace), args.Error(1)
+}
+
+func (m *MockSlackWorkspaceRepository) Save(ctx context.Context, ws *domain.SlackWorkspace) error {
+	args := m.Called(ctx, ws)
+	return args.Error(0)
+}
+
+func (m *MockSlackWorkspaceRepository) Delete(ctx context.Context, teamID string) error {
+	args := m.Called(ctx, teamID)
+	return args.Error(0)
+}
+
+func TestSlackService_Shorten(t *testing.T) {
+	ws := &domain.SlackWorkspace{TeamID: "T1", Owner: "slack:T1", Domain: "go.example.com"}
+
+	tests := []struct {
+		name      string
+		text      string
+		wantURL   string
+		wantAlias string
+		wantErr   error
+	}{
+		{name: "plain URL", text: "https://example.com/docs", wantURL: "https://example.com/docs"},
+		{name: "URL with alias", text: "  https://example.com/docs   docs ", wantURL: "https://example.com/docs", wantAlias: "docs"},
+		{name: "link formatted by Slack", text: "<https://example.com/docs|example.com/docs>", wantURL: "https://example.com/docs"},
+		{name: "empty", text: "", wantErr: domain.ErrSlackUsage},
+		{name: "help", text: "help", wantErr: domain.ErrSlackUsage},
+		{name: "too many words", text: "https://example.com a b", wantErr: domain.ErrSlackUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			links := new(MockLinkCreator)
+			slackService := NewSlackService(nil, links)
+			links.On("CreateShortURL", mock.Anything, tt.wantURL, tt.wantAlias, "slack:T1", "go.example.com").
+				Return(&domain.URL{ShortCode: "abc123"}, nil)
+
+			// Act
+			url, err := slackService.Shorten(context.Background(), ws, tt.text)
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				links.AssertNotCalled(t, "CreateShortURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "abc123", url.ShortCode)
+
+			// Links are created AS the workspace owner (quotas, ownership)
+			ctx := links.Calls[0].Arguments.Get(0).(context.Context)
+			assert.Equal(t, "slack:T1", auth.FromContext(ctx).ID)
+		})
+	}
+}
+
+func TestSlackService_SecretEncryption(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	local, err := keys.ParseLocal("k1=" + strings.Repeat("ab", 32))
+	require.NoError(t, err)
+	repo := new(MockSlackWorkspaceRepository)
+	var stored domain.SlackWorkspace
+	repo.On("Save", ctx, mock.AnythingOfType("*domain.SlackWorkspace")).
+		Run(func(args mock.Arguments) { stored = *args.Get(1).(*domain.SlackWorkspace) }).
+		Return(nil)
+	repo.On("Get", ctx, "T0").Return(&domain.SlackWorkspace{TeamID: "T0", SigningSecret: "legacy"}, nil)
+	slackService := NewSlackService(repo, nil).WithSecretEncryption(local)
+	ws := &domain.SlackWorkspace{TeamID: "T1", SigningSecret: "8f742231b10e8888abcd99yyyzzz85a5"}
+
+	// Act
+	err = slackService.SaveWorkspace(ctx, ws)
+	require.NoError(t, err)
+	saved := stored
+	repo.On("Get", ctx, "T1").Return(&saved, nil)
+	loaded, err := slackService.Workspace(ctx, "T1")
+	require.NoError(t, err)
+	legacy, err := slackService.Workspace(ctx, "T0")
+	require.NoError(t, err)
+
+	// Assert
+	assert.True(t, strings.HasPrefix(stored.SigningSecret, "kv1:k1:"), "stored with its key version")
+	assert.Equal(t, "8f742231b10e8888abcd99yyyzzz85a5", ws.SigningSecret, "the caller's copy stays plain")
+	assert.Equal(t, "8f742231b10e8888abcd99yyyzzz85a5", loaded.SigningSecret)
+	assert.Equal(t, "legacy", legacy.SigningSecret, "secrets saved before still work")
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/saml"
+)
+
+// DefaultSessionTTL is how long a single sign-on session lasts
+// A workday: people sign in again in the morning, and someone deprovisioned
+// in the IdP loses access by the next day at the latest
+const DefaultSessionTTL = 8 * time.Hour
+
+// ssoSessionPrefix starts every session token (see scimTokenPrefix)
+const ssoSessionPrefix = "sso_"
+
+// SSOService signs workspace members in through their SAML identity provider
+//
+// HOW A SIGN-IN WORKS:
+//  1. The member opens /saml/{workspace}/login (or the app tile in their
+//     IdP) and signs in there.
+//  2. The IdP posts a signed assertion to /saml/{workspace}/acs.
+//  3. We verify it (internal/saml), work out the member's role, and hand out
+//     a session token that authenticates like an API key until it expires.
+//
+// The role is the highest one WorkspaceSettings.GroupRoles gives the
+// member's groups - those named in the assertion's role attribute and, if
+// the member was provisioned over SCIM, their SCIM groups. A member the
+// IdP deactivated over SCIM can't sign in even if the IdP still lets them.
+type SSOService struct {
+	connections repository.SAMLConnectionRepository
+	sessions    repository.SessionRepository
+	members     repository.MemberRepository
+	settings    repository.WorkspaceSettingsRepository
+	baseURL     string
+	sessionTTL  time.Duration
+	now         func() time.Time
+}
+
+// NewSSOService creates a single sign-on service
+// baseURL is the public URL the IdP reaches us at (entity ID and ACS URL)
+func NewSSOService(connections repository.SAMLConnectionRepository, sessions repository.SessionRepository, members repository.MemberRepository, settings repository.WorkspaceSettingsRepository, baseURL string) *SSOService {
+	return &SSOService{
+		connections: connections,
+		sessions:    sessions,
+		members:     members,
+		settings:    settings,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		sessionTTL:  DefaultSessionTTL,
+		now:         time.Now,
+	}
+}
+
+// WithSessionTTL sets how long sessions last (0 keeps the default)
+func (s *SSOService) WithSessionTTL(ttl time.Duration) *SSOService {
+	if ttl > 0 {
+		s.sessionTTL = ttl
+	}
+	return s
+}
+
+// ServiceProvider is how the workspace's IdP knows us
+// One entity per workspace: each workspace registers its own app in its IdP
+func (s *SSOService) ServiceProvider(workspace string) saml.ServiceProvider {
+	base := s.baseURL + "/saml/" + url.PathEscape(workspace)
+	return saml.ServiceProvider{EntityID: base + "/metadata", ACSURL: base + "/acs"}
+}
+
+// Metadata is the SP metadata the workspace's IdP imports
+// It needs no connection: owners set up the IdP side first
+func (s *SSOService) Metadata(workspace string) []byte {
+	return saml.Metadata(s.ServiceProvider(workspace))
+}
+
+// GetConnection returns the caller's SAML connection (owners only)
+func (s *SSOService) GetConnection(ctx context.Context) (*domain.SAMLConnection, error) {
+	workspace, err := ownedWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.connections.Get(ctx, workspace)
+}
+
+// SaveConnection connects the caller's workspace to its IdP (owners only)
+func (s *SSOService) SaveConnection(ctx context.Context, connection *domain.SAMLConnection) error {
+	workspace, err := ownedWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+	connection.Workspace = workspace
+	if err := connection.Validate(); err != nil {
+		return err
+	}
+	if _, err := saml.ParseCertificate(connection.Certificate); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidSAMLConnection, err)
+	}
+	return s.connections.Save(ctx, connection)
+}
+
+// DeleteConnection disconnects the caller's IdP (owners only)
+// Nobody can sign in afterwards; sessions already issued run out
+func (s *SSOService) DeleteConnection(ctx context.Context) error {
+	workspace, err := ownedWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+	return s.connections.Delete(ctx, workspace)
+}
+
+// SignInURL is where to send a member to sign in at the workspace's IdP
+func (s *SSOService) SignInURL(ctx context.Context, workspace, relayState string) (string, error) {
+	connection, err := s.connections.Get(ctx, workspace)
+	if err != nil {
+		return "", err
+	}
+	return saml.AuthnRequestURL(s.ServiceProvider(workspace), connection.SSOURL, relayState, s.now())
+}
+
+// SignIn verifies a SAMLResponse the IdP posted and starts a session
+// Returns the session token (shown once) and the session
+func (s *SSOService) SignIn(ctx context.Context, workspace, samlResponse string) (string, *domain.Session, error) {
+	connection, err := s.connections.Get(ctx, workspace)
+	if err != nil {
+		return "", nil, err
+	}
+	cert, err := saml.ParseCertificate(connection.Certificate)
+	if err != nil {
+		return "", nil, fmt.Errorf("stored certificate of %s: %w", workspace, err)
+	}
+
+	now := s.now()
+	assertion, err := saml.ParseResponse(samlResponse, s.ServiceProvider(workspace), saml.IdentityProvider{
+		EntityID:    connection.IdPEntityID,
+		Certificate: cert,
+	}, now)
+	if err != nil {
+		return "", nil, err
+	}
+
+	role, err := s.memberRole(ctx, workspace, connection, assertion)
+	if err != nil {
+		return "", nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+	token := ssoSessionPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	session := &domain.Session{
+		Workspace: workspace,
+		Member:    assertion.NameID,
+		Role:      string(role),
+		ExpiresAt: now.Add(s.sessionTTL),
+	}
+
+	// Keep the assertion ID at least until the assertion expires (plus the
+	// skew we allowed), so the same response can't start a second session
+	assertionExpiresAt := assertion.NotOnOrAfter.Add(saml.ClockSkew)
+	if err := s.sessions.CreateFromAssertion(ctx, assertion.ID, assertionExpiresAt, hashToken(token), session); err != nil {
+		return "", nil, err
+	}
+	return token, session, nil
+}
+
+// memberRole is the highest role the member's groups map to
+func (s *SSOService) memberRole(ctx context.Context, workspace string, connection *domain.SAMLConnection, assertion *saml.Assertion) (auth.Role, error) {
+	settings, err := s.settings.Get(ctx, workspace)
+	if errors.Is(err, domain.ErrWorkspaceSettingsNotFound) {
+		return "", domain.ErrNoWorkspaceRole
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var roles []auth.Role
+	if connection.RoleAttribute != "" {
+		roles = mappedRoles(settings.GroupRoles, assertion.Attributes[connection.RoleAttribute]...)
+	}
+
+	member, err := s.members.GetMemberByUserName(ctx, workspace, assertion.NameID)
+	switch {
+	case errors.Is(err, domain.ErrMemberNotFound):
+		// Not provisioned over SCIM: the assertion's groups decide alone
+	case err != nil:
+		return "", err
+	case !member.Active:
+		return "", domain.ErrMemberInactive
+	default:
+		roles = append(roles, mappedRoles(settings.GroupRoles, memberGroupNames(member)...)...)
+	}
+
+	role := auth.HighestRole(roles...)
+	if role == "" {
+		return "", domain.ErrNoWorkspaceRole
+	}
+	return role, nil
+}
+
+// Authenticate turns a session token into the member's principal
+// It implements auth.Authenticator, so sessions work wherever API keys do
+func (s *SSOService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	if !strings.HasPrefix(token, ssoSessionPrefix) {
+		return nil, auth.ErrInvalidCredentials
+	}
+	session, err := s.sessions.Get(ctx, hashToken(token))
+	if errors.Is(err, domain.ErrSessionNotFound) {
+		return nil, auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Principal{ID: session.Workspace, Plan: session.Plan, Member: session.Member, Role: auth.Role(session.Role)}, nil
+}
+
+// SignOut ends the session of a token
+func (s *SSOService) SignOut(ctx context.Context, token string) error {
+	if !strings.HasPrefix(token, ssoSessionPrefix) {
+		return domain.ErrSessionNotFound
+	}
+	return s.sessions.Delete(ctx, hashToken(token))
+}
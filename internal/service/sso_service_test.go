@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/saml"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSAMLConnectionRepository is a mock implementation of repository.SAMLConnectionRepository
+type MockSAMLConnectionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSAMLConnectionRepository) Get(ctx context.Context, workspace string) (*domain.SAMLConnection, error) {
+	args := m.Called(ctx, workspace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SAMLConnection), args.Error(1)
+}
+
+func (m *MockSAMLConnectionRepository) Save(ctx context.Context, connection *domain.SAMLConnection) error {
+	args := m.Called(ctx, connection)
+	return args.Error(0)
+}
+
+func (m *MockSAMLConnectionRepository) Delete(ctx context.Context, workspace string) error {
+	args := m.Called(ctx, workspace)
+	return args.Error(0)
+}
+
+// MockSessionRepository is a mock implementation of repository.SessionRepository
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) CreateFromAssertion(ctx context.Context, assertionID string, assertionExpiresAt time.Time, tokenHash string, session *domain.Session) error {
+	args := m.Called(ctx, assertionID, assertionExpiresAt, tokenHash, session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) Get(ctx context.Context, tokenHash string) (*domain.Session, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) Delete(ctx context.Context, tokenHash string) error {
+	args := m.Called(ctx, tokenHash)
+	return args.Error(0)
+}
+
+// testCertificatePEM creates a self-signed IdP certificate
+func testCertificatePEM(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func newTestSSOService(connections *MockSAMLConnectionRepository, sessions *MockSessionRepository, members *MockMemberRepository, settings *MockWorkspaceSettingsRepository) *SSOService {
+	return NewSSOService(connections, sessions, members, settings, "https://sho.rt/")
+}
+
+func TestSSOService_ServiceProvider(t *testing.T) {
+	// Arrange
+	service := newTestSSOService(nil, nil, nil, nil)
+
+	// Act
+	sp := service.ServiceProvider("team one")
+
+	// Assert
+	assert.Equal(t, "https://sho.rt/saml/team%20one/metadata", sp.EntityID)
+	assert.Equal(t, "https://sho.rt/saml/team%20one/acs", sp.ACSURL)
+	assert.Contains(t, string(service.Metadata("team one")), `entityID="https://sho.rt/saml/team%20one/metadata"`)
+}
+
+func TestSSOService_SaveConnection(t *testing.T) {
+	certificate := testCertificatePEM(t)
+
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		connection *domain.SAMLConnection
+		expectErr  error
+	}{
+		{
+			name:       "owner",
+			principal:  &auth.Principal{ID: "team1"},
+			connection: &domain.SAMLConnection{IdPEntityID: " http://www.okta.com/exk1 ", SSOURL: "https://acme.okta.com/app/sso/saml", Certificate: certificate},
+		},
+		{
+			name:       "editor",
+			principal:  teamEditor,
+			connection: &domain.SAMLConnection{IdPEntityID: "http://www.okta.com/exk1", SSOURL: "https://acme.okta.com/app/sso/saml", Certificate: certificate},
+			expectErr:  domain.ErrForbidden,
+		},
+		{
+			name:       "sign-in URL is not http",
+			principal:  &auth.Principal{ID: "team1"},
+			connection: &domain.SAMLConnection{IdPEntityID: "http://www.okta.com/exk1", SSOURL: "javascript:alert(1)", Certificate: certificate},
+			expectErr:  domain.ErrInvalidSAMLConnection,
+		},
+		{
+			name:       "certificate is not a certificate",
+			principal:  &auth.Principal{ID: "team1"},
+			connection: &domain.SAMLConnection{IdPEntityID: "http://www.okta.com/exk1", SSOURL: "https://acme.okta.com/app/sso/saml", Certificate: "-----BEGIN CERTIFICATE-----\nbm9wZQ==\n-----END CERTIFICATE-----"},
+			expectErr:  domain.ErrInvalidSAMLConnection,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			connections := new(MockSAMLConnectionRepository)
+			if tt.expectErr == nil {
+				connections.On("Save", ctx, mock.MatchedBy(func(c *domain.SAMLConnection) bool {
+					return c.Workspace == "team1" && c.IdPEntityID == "http://www.okta.com/exk1"
+				})).Return(nil)
+			}
+			service := newTestSSOService(connections, new(MockSessionRepository), new(MockMemberRepository), new(MockWorkspaceSettingsRepository))
+
+			// Act
+			err := service.SaveConnection(ctx, tt.connection)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectErr)
+			connections.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSSOService_SignIn_RejectsUnverifiedResponses(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	connections := new(MockSAMLConnectionRepository)
+	connections.On("Get", ctx, "team1").Return(&domain.SAMLConnection{
+		Workspace:   "team1",
+		IdPEntityID: "http://www.okta.com/exk1",
+		SSOURL:      "https://acme.okta.com/app/sso/saml",
+		Certificate: testCertificatePEM(t),
+	}, nil)
+	connections.On("Get", ctx, "team2").Return(nil, domain.ErrSAMLConnectionNotFound)
+	sessions := new(MockSessionRepository)
+	service := newTestSSOService(connections, sessions, new(MockMemberRepository), new(MockWorkspaceSettingsRepository))
+	unsigned := base64.StdEncoding.EncodeToString([]byte(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="r1" Destination="https://sho.rt/saml/team1/acs"><samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"/></samlp:Status><saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="a1"><saml:Subject><saml:NameID>ada@example.com</saml:NameID></saml:Subject></saml:Assertion></samlp:Response>`))
+
+	// Act
+	_, _, errUnsigned := service.SignIn(ctx, "team1", unsigned)
+	_, _, errNotSAML := service.SignIn(ctx, "team1", "not base64!")
+	_, _, errNoConnection := service.SignIn(ctx, "team2", unsigned)
+
+	// Assert
+	assert.ErrorIs(t, errUnsigned, saml.ErrInvalidSignature)
+	assert.ErrorIs(t, errNotSAML, saml.ErrMalformed)
+	assert.ErrorIs(t, errNoConnection, domain.ErrSAMLConnectionNotFound)
+	sessions.AssertNotCalled(t, "CreateFromAssertion", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSSOService_MemberRole(t *testing.T) {
+	groupRoles := map[string]string{"Marketing": "editor", "everyone": "viewer", "Admins": "owner"}
+
+	tests := []struct {
+		name          string
+		roleAttribute string
+		attributes    map[string][]string
+		member        *domain.Member
+		memberErr     error
+		settingsErr   error
+		expected      auth.Role
+		expectErr     error
+	}{
+		{
+			name:          "groups from the assertion",
+			roleAttribute: "groups",
+			attributes:    map[string][]string{"groups": {"Everyone", "Marketing"}},
+			memberErr:     domain.ErrMemberNotFound,
+			expected:      auth.RoleEditor,
+		},
+		{
+			name:          "SCIM groups count too",
+			roleAttribute: "groups",
+			attributes:    map[string][]string{"groups": {"everyone"}},
+			member:        &domain.Member{UserName: "ada@example.com", Active: true, Groups: []domain.GroupRef{{DisplayName: "Admins"}}},
+			expected:      auth.RoleOwner,
+		},
+		{
+			name:       "no role attribute configured",
+			attributes: map[string][]string{"groups": {"Admins"}},
+			member:     &domain.Member{UserName: "ada@example.com", Active: true, Groups: []domain.GroupRef{{DisplayName: "everyone"}}},
+			expected:   auth.RoleViewer,
+		},
+		{
+			name:          "deactivated over SCIM",
+			roleAttribute: "groups",
+			attributes:    map[string][]string{"groups": {"Admins"}},
+			member:        &domain.Member{UserName: "ada@example.com", Active: false},
+			expectErr:     domain.ErrMemberInactive,
+		},
+		{
+			name:          "no mapped group",
+			roleAttribute: "groups",
+			attributes:    map[string][]string{"groups": {"Sales"}},
+			memberErr:     domain.ErrMemberNotFound,
+			expectErr:     domain.ErrNoWorkspaceRole,
+		},
+		{
+			name:          "no settings",
+			roleAttribute: "groups",
+			attributes:    map[string][]string{"groups": {"Admins"}},
+			settingsErr:   domain.ErrWorkspaceSettingsNotFound,
+			expectErr:     domain.ErrNoWorkspaceRole,
+		},
+		{
+			name:          "database down",
+			roleAttribute: "groups",
+			attributes:    map[string][]string{"groups": {"Admins"}},
+			memberErr:     assert.AnError,
+			expectErr:     assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			members := new(MockMemberRepository)
+			members.On("GetMemberByUserName", ctx, "team1", "ada@example.com").Return(tt.member, tt.memberErr).Maybe()
+			settings := new(MockWorkspaceSettingsRepository)
+			var found *domain.WorkspaceSettings
+			if tt.settingsErr == nil {
+				found = &domain.WorkspaceSettings{Workspace: "team1", GroupRoles: groupRoles}
+			}
+			settings.On("Get", ctx, "team1").Return(found, tt.settingsErr)
+			service := newTestSSOService(new(MockSAMLConnectionRepository), new(MockSessionRepository), members, settings)
+			connection := &domain.SAMLConnection{Workspace: "team1", RoleAttribute: tt.roleAttribute}
+			assertion := &saml.Assertion{ID: "a1", NameID: "ada@example.com", Attributes: tt.attributes}
+
+			// Act
+			role, err := service.memberRole(ctx, "team1", connection, assertion)
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, role)
+		})
+	}
+}
+
+func TestSSOService_Authenticate(t *testing.T) {
+	const token = "sso_abcdef"
+	session := &domain.Session{Workspace: "team1", Member: "ada@example.com", Role: "editor", ExpiresAt: time.Now().Add(time.Hour)}
+
+	tests := []struct {
+		name      string
+		token     string
+		found     *domain.Session
+		foundErr  error
+		expected  *auth.Principal
+		expectErr error
+	}{
+		{name: "valid session", token: token, found: session, expected: &auth.Principal{ID: "team1", Member: "ada@example.com", Role: auth.RoleEditor}},
+		{
+			name:     "workspace on the pro plan",
+			token:    token,
+			found:    &domain.Session{Workspace: "team1", Member: "ada@example.com", Role: "editor", Plan: domain.PlanPro},
+			expected: &auth.Principal{ID: "team1", Plan: domain.PlanPro, Member: "ada@example.com", Role: auth.RoleEditor},
+		},
+		{name: "expired or unknown", token: token, foundErr: domain.ErrSessionNotFound, expectErr: auth.ErrInvalidCredentials},
+		{name: "scim token", token: "scim_abcdef", expectErr: auth.ErrInvalidCredentials},
+		{name: "database down", token: token, foundErr: assert.AnError, expectErr: assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			sessions := new(MockSessionRepository)
+			sessions.On("Get", ctx, hashToken(tt.token)).Return(tt.found, tt.foundErr).Maybe()
+			service := newTestSSOService(new(MockSAMLConnectionRepository), sessions, new(MockMemberRepository), new(MockWorkspaceSettingsRepository))
+
+			// Act
+			principal, err := service.Authenticate(ctx, tt.token)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectErr)
+			assert.Equal(t, tt.expected, principal)
+		})
+	}
+}
+
+func TestSSOService_SignOut(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	sessions := new(MockSessionRepository)
+	sessions.On("Delete", ctx, hashToken("sso_abcdef")).Return(nil)
+	service := newTestSSOService(new(MockSAMLConnectionRepository), sessions, new(MockMemberRepository), new(MockWorkspaceSettingsRepository))
+
+	// Act & Assert
+	assert.NoError(t, service.SignOut(ctx, "sso_abcdef"))
+	assert.ErrorIs(t, service.SignOut(ctx, "admin-key"), domain.ErrSessionNotFound)
+	sessions.AssertExpectations(t)
+}
+
+func TestSSOService_WithSessionTTL(t *testing.T) {
+	service := newTestSSOService(nil, nil, nil, nil)
+	assert.Equal(t, DefaultSessionTTL, service.sessionTTL)
+	assert.Equal(t, time.Hour, service.WithSessionTTL(time.Hour).sessionTTL)
+	assert.Equal(t, time.Hour, service.WithSessionTTL(0).sessionTTL, "0 keeps the current TTL")
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// TemplateService manages link templates and creates links from them
+//
+// A template is a named set of link settings owned by one caller. Creating
+// a link from it only needs a destination; everything else (lifetime,
+// click limit, domain, rules, preview card) comes from the template, so a
+// team's campaign links are always configured the same way.
+type TemplateService struct {
+	templates repository.TemplateRepository
+	links     LinkCreator
+}
+
+// NewTemplateService creates a template service
+func NewTemplateService(templates repository.TemplateRepository, links LinkCreator) *TemplateService {
+	return &TemplateService{templates: templates, links: links}
+}
+
+// CreateTemplate creates a template owned by the caller
+func (s *TemplateService) CreateTemplate(ctx context.Context, template *domain.LinkTemplate) error {
+	if err := authorizeWrite(ctx); err != nil {
+		return err
+	}
+	template.Owner = auth.FromContext(ctx).ID
+	template.Normalize()
+	if err := template.Validate(); err != nil {
+		return err
+	}
+	return s.templates.Create(ctx, template)
+}
+
+// GetTemplate returns one of the caller's templates
+func (s *TemplateService) GetTemplate(ctx context.Context, id string) (*domain.LinkTemplate, error) {
+	template, err := s.templates.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// ListTemplates returns the caller's templates
+func (s *TemplateService) ListTemplates(ctx context.Context) ([]*domain.LinkTemplate, error) {
+	return s.templates.ListByOwner(ctx, auth.FromContext(ctx).ID)
+}
+
+// UpdateTemplate replaces the name and settings of template (matched by ID)
+// Links already created from it keep their settings
+func (s *TemplateService) UpdateTemplate(ctx context.Context, template *domain.LinkTemplate) error {
+	if err := authorizeWrite(ctx); err != nil {
+		return err
+	}
+	current, err := s.GetTemplate(ctx, template.ID)
+	if err != nil {
+		return err
+	}
+
+	template.Owner = current.Owner
+	template.CreatedAt = current.CreatedAt
+	template.Normalize()
+	if err := template.Validate(); err != nil {
+		return err
+	}
+	return s.templates.Update(ctx, template)
+}
+
+// DeleteTemplate deletes one of the caller's templates
+func (s *TemplateService) DeleteTemplate(ctx context.Context, id string) error {
+	if err := authorizeWrite(ctx); err != nil {
+		return err
+	}
+	if _, err := s.GetTemplate(ctx, id); err != nil {
+		return err
+	}
+	return s.templates.Delete(ctx, id)
+}
+
+// CreateFromTemplate creates a link to originalURL with the template's settings
+// customAlias is optional, like at creation
+func (s *TemplateService) CreateFromTemplate(ctx context.Context, id, originalURL, customAlias string) (*domain.URL, error) {
+	template, err := s.GetTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := template.Settings
+	return s.links.CreateShortURL(ctx, originalURL, customAlias, auth.FromContext(ctx).ID, settings.ExpiresIn, settings.Options()...)
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockTemplateRepository is a mock implementation of TemplateRepository
+type MockTemplateRepository struct {
+	mock.Mock
+}
+
+func (m *MockTemplateRepository) Create(ctx context.Context, template *domain.LinkTemplate) error {
+	args := m.Called(ctx, template)
+	return args.Error(0)
+}
+
+func (m *MockTemplateRepository) Update(ctx context.Context, template *domain.LinkTemplate) error {
+	args := m.Called(ctx, template)
+	return args.Error(0)
+}
+
+func (m *MockTemplateRepository) GetByID(ctx context.Context, id string) (*domain.LinkTemplate, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LinkTemplate), args.Error(1)
+}
+
+func (m *MockTemplateRepository) ListByOwner(ctx context.Context, owner string) ([]*domain.LinkTemplate, error) {
+	args := m.Called(ctx, owner)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.LinkTemplate), args.Error(1)
+}
+
+func (m *MockTemplateRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestTemplateService_CreateTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    *domain.LinkTemplate
+		expectSave  bool
+		expectedErr error
+	}{
+		{
+			name: "valid template",
+			template: &domain.LinkTemplate{
+				Name: "  spring-campaign ",
+				Settings: domain.LinkSettings{
+					ExpiresIn:       30 * 24 * time.Hour,
+					Domain:          "Go.Example.com",
+					LanguageTargets: map[string]string{"FR": "https://example.com/fr"},
+				},
+			},
+			expectSave: true,
+		},
+		{name: "missing name", template: &domain.LinkTemplate{Name: "  "}, expectedErr: domain.ErrInvalidTemplate},
+		{
+			name:        "negative lifetime",
+			template:    &domain.LinkTemplate{Name: "x", Settings: domain.LinkSettings{ExpiresIn: -time.Hour}},
+			expectedErr: domain.ErrInvalidTemplate,
+		},
+		{
+			name:        "invalid language targets",
+			template:    &domain.LinkTemplate{Name: "x", Settings: domain.LinkSettings{LanguageTargets: map[string]string{"french": "https://example.com"}}},
+			expectedErr: domain.ErrInvalidLanguageTargets,
+		},
+		{
+			name:        "invalid preview card",
+			template:    &domain.LinkTemplate{Name: "x", Settings: domain.LinkSettings{Preview: &domain.PreviewCard{ImageURL: "javascript:alert(1)"}}},
+			expectedErr: domain.ErrInvalidPreview,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := new(MockTemplateRepository)
+			svc := NewTemplateService(repo, new(MockLinkCreator))
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "alice"})
+			if tt.expectSave {
+				repo.On("Create", ctx, tt.template).Return(nil)
+			}
+
+			// Act
+			err := svc.CreateTemplate(ctx, tt.template)
+
+			// Assert
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "alice", tt.template.Owner)
+			assert.Equal(t, "spring-campaign", tt.template.Name)
+			assert.Equal(t, "go.example.com", tt.template.Settings.Domain)
+			assert.Equal(t, map[string]string{"fr": "https://example.com/fr"}, tt.template.Settings.LanguageTargets)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTemplateService_UpdateTemplate(t *testing.T) {
+	// Arrange
+	repo := new(MockTemplateRepository)
+	svc := NewTemplateService(repo, new(MockLinkCreator))
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "alice"})
+
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo.On("GetByID", ctx, "t1").Return(&domain.LinkTemplate{ID: "t1", Owner: "alice", Name: "old", CreatedAt: createdAt}, nil)
+	repo.On("Update", ctx, mock.AnythingOfType("*domain.LinkTemplate")).Return(nil)
+
+	// The request cannot change the owner
+	update := &domain.LinkTemplate{ID: "t1", Owner: "mallory", Name: "new"}
+
+	// Act
+	err := svc.UpdateTemplate(ctx, update)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "alice", update.Owner)
+	assert.Equal(t, createdAt, update.CreatedAt)
+	repo.AssertExpectations(t)
+}
+
+func TestTemplateService_Rejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		caller   *auth.Principal
+		expected error
+	}{
+		{name: "someone else's template", caller: &auth.Principal{ID: "mallory"}, expected: domain.ErrForbidden},
+		{name: "anonymous caller", caller: auth.Anonymous, expected: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := new(MockTemplateRepository)
+			links := new(MockLinkCreator)
+			svc := NewTemplateService(repo, links)
+			ctx := auth.WithPrincipal(context.Background(), tt.caller)
+			repo.On("GetByID", ctx, "t1").Return(&domain.LinkTemplate{ID: "t1", Owner: "alice", Name: "campaign"}, nil)
+
+			// Act
+			_, getErr := svc.GetTemplate(ctx, "t1")
+			deleteErr := svc.DeleteTemplate(ctx, "t1")
+			_, createErr := svc.CreateFromTemplate(ctx, "t1", "https://example.com", "")
+
+			// Assert
+			assert.ErrorIs(t, getErr, tt.expected)
+			assert.ErrorIs(t, deleteErr, tt.expected)
+			assert.ErrorIs(t, createErr, tt.expected)
+			repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			links.AssertNotCalled(t, "CreateShortURL")
+		})
+	}
+}
+
+func TestTemplateService_CreateFromTemplate(t *testing.T) {
+	// Arrange: a real URLService so the stored link can be inspected
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "alice"})
+	repo := new(MockTemplateRepository)
+	mockURLRepo := new(MockURLRepository)
+	svc := NewTemplateService(repo, NewURLService(mockURLRepo, new(MockClickRepository)))
+
+	maxClicks := int64(500)
+	repo.On("GetByID", ctx, "t1").Return(&domain.LinkTemplate{
+		ID:    "t1",
+		Owner: "alice",
+		Name:  "spring-campaign",
+		Settings: domain.LinkSettings{
+			ExpiresIn: 30 * 24 * time.Hour,
+			MaxClicks: &maxClicks,
+			Schedule: &domain.Schedule{Rules: []domain.ScheduleRule{
+				{Days: []string{"sat", "sun"}, URL: "https://example.com/weekend"},
+			}},
+			Preview: &domain.PreviewCard{Title: "Spring Sale"},
+		},
+	}, nil)
+	mockURLRepo.On("ExistsCustomAlias", ctx, "spring-shoes").Return(false, nil)
+	mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
+
+	// Act
+	url, err := svc.CreateFromTemplate(ctx, "t1", "https://example.com/shoes", "spring-shoes")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/shoes", url.OriginalURL)
+	assert.Equal(t, "alice", url.CreatedBy)
+	assert.Equal(t, maxClicks, *url.MaxClicks)
+	require.NotNil(t, url.Schedule)
+	assert.Equal(t, "https://example.com/weekend", url.Schedule.Rules[0].URL)
+	assert.Equal(t, "Spring Sale", url.Preview.Title)
+	require.NotNil(t, url.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), *url.ExpiresAt, time.Minute)
+	mockURLRepo.AssertExpectations(t)
+}
@@ -2,23 +2,102 @@ package service
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"maps"
+	"net"
 	"time"
 
+	"url-shortener/internal/auth"
+	"url-shortener/internal/cache"
 	"url-shortener/internal/domain"
+	"url-shortener/internal/events"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/referrer"
 	"url-shortener/internal/repository"
+	"url-shortener/internal/resilience"
+	"url-shortener/internal/resolver"
+	"url-shortener/internal/shortcode"
+	"url-shortener/internal/useragent"
 )
 
 // Cache interface for URL caching
-// Using an interface allows for easy testing and swapping implementations
-type Cache interface {
-	GetURL(ctx context.Context, shortCode string) (*domain.URL, error)
-	SetURL(ctx context.Context, shortCode string, url *domain.URL) error
-	DeleteURL(ctx context.Context, shortCode string) error
+// The service only needs the narrow part of the cache contract, so mocks and
+// decorators stay small; backends implement the full cache.Cache
+type Cache = cache.URLCache
+
+// DestinationResolver follows the redirect chain of a destination URL
+// Implemented by resolver.Resolver; optional (nil disables resolution)
+type DestinationResolver interface {
+	Resolve(ctx context.Context, rawURL string) (*resolver.Result, error)
+}
+
+// MetadataFetcher reads the title, description and favicon of a page
+// Implemented by metadata.Fetcher; optional (nil disables fetching)
+type MetadataFetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*domain.LinkMetadata, error)
+}
+
+// Locker takes short-lived locks shared by every instance
+// Implemented by redis.Locker; optional (nil disables locking)
+type Locker interface {
+	// TryLock returns ok=false without waiting when someone else holds key
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(), ok bool, err error)
+}
+
+// ClickDeduplicator remembers which clicks were seen recently
+// Implemented by redis.ClickDeduplicator; optional (nil counts every click)
+type ClickDeduplicator interface {
+	// FirstSeen returns true for the first call with key in each window
+	FirstSeen(ctx context.Context, key string, window time.Duration) (bool, error)
+}
+
+// Quotas limits how many links a caller may create
+// Implemented by QuotaService; optional (nil disables quotas)
+type Quotas interface {
+	Reserve(ctx context.Context) (*domain.Usage, error)
+	Release(ctx context.Context, usage *domain.Usage)
+	// Check is Reserve without counting the link (dry runs)
+	Check(ctx context.Context) (*domain.Usage, error)
+}
+
+// EdgePurger removes links from the CDN in front of the redirects
+// Implemented by cdn.Cloudflare and cdn.Fastly; optional (nil = no CDN)
+type EdgePurger interface {
+	PurgeLinks(ctx context.Context, urls []*domain.URL) error
+}
+
+// DomainVerifier decides which custom domains links may be created on
+// Implemented by CustomDomainService; optional (nil allows every domain)
+type DomainVerifier interface {
+	CheckDomain(ctx context.Context, host string) error
+}
+
+// AliasGuard knows the aliases new ones must not imitate
+// Implemented by ConfusableAliasService; optional (nil allows every alias)
+type AliasGuard interface {
+	// Imitated returns the alias that alias looks like, or "" if none
+	Imitated(alias string) string
+}
+
+// DestinationPolicy decides which destinations links may point to
+// Implemented by DomainPolicyService; optional (nil allows everything)
+type DestinationPolicy interface {
+	CheckURL(url *domain.URL) error
 }
 
+// aliasLockTTL bounds how long a crashed request can block an alias
+const aliasLockTTL = 10 * time.Second
+
+// metadataFetchTimeout bounds one background metadata fetch, redirects included
+const metadataFetchTimeout = 20 * time.Second
+
+// redirectTraceTimeout bounds following a whole chain for TraceRedirects
+// (the resolver's own timeout applies to each hop)
+const redirectTraceTimeout = 15 * time.Second
+
 // URLService handles business logic for URL operations
 // This is the SERVICE LAYER - it sits between HTTP handlers and repositories
 //
@@ -30,16 +109,239 @@ type Cache interface {
 type URLService struct {
 	urlRepo   repository.URLRepository
 	clickRepo repository.ClickRepository
-	cache     Cache // Redis cache for performance
+	events    *events.Bus // Publishes what happens to links (see package events)
+	codes     *shortcode.Generator
+	codeCase  domain.CodeCase      // Whether codes ignore case (see WithCodeCase)
+	codePool  *codePool            // Optional: checks generated codes in batches
+	referrers *referrer.Classifier // Sorts clicks into channels (search, social, ...)
+
+	resolver          DestinationResolver                    // Optional: unwraps nested shorteners at creation
+	rejectRedirectors bool                                   // Reject destinations that go through other shorteners
+	tracer            DestinationResolver                    // Optional: follows destinations for TraceRedirects
+	aliasLocks        Locker                                 // Optional: serializes concurrent requests for the same custom alias
+	quotas            Quotas                                 // Optional: plan limits on link creation
+	metadata          MetadataFetcher                        // Optional: reads the destination's title etc. after creation
+	metadataSlots     chan struct{}                          // Limits how many fetches run at once
+	clickDedup        ClickDeduplicator                      // Optional: counts repeated clicks once
+	clickDedupWindow  time.Duration                          // How long a click counts as a repeat
+	region            string                                 // Optional: deployment region recorded on click events
+	edge              EdgePurger                             // Optional: purges changed links from the CDN
+	policy            DestinationPolicy                      // Optional: allow/deny rules for destination domains
+	aliasGuard        AliasGuard                             // Optional: spots aliases that imitate popular ones
+	confusable        domain.ConfusablePolicy                // What happens to such aliases
+	domains           DomainVerifier                         // Optional: links only go on verified custom domains
+	sealer            DestinationSealer                      // Optional: encrypts the destinations of sensitive links
+	workspaces        repository.WorkspaceSettingsRepository // Optional: per-workspace analytics timezone and approvals
+	analyticsTZ       *time.Location                         // Timezone of analytics without ?tz= or a workspace default
+	analyticsOff      bool                                   // ENABLE_ANALYTICS=false: count clicks, store no click events
+	honorConsent      bool                                   // Store clicks with DNT/GPC without personal data
+	now               func() time.Time                       // Clock for analytics ranges (tests pin it)
 }
 
 // NewURLService creates a new URL service
-func NewURLService(urlRepo repository.URLRepository, clickRepo repository.ClickRepository, cache Cache) *URLService {
-	return &URLService{
-		urlRepo:   urlRepo,
-		clickRepo: clickRepo,
-		cache:     cache,
+// Caching is not the service's business: main wraps urlRepo with
+// cache.CachedURLRepository, which serves lookups and forgets changed links
+func NewURLService(urlRepo repository.URLRepository, clickRepo repository.ClickRepository) *URLService {
+	s := &URLService{
+		urlRepo:     urlRepo,
+		clickRepo:   clickRepo,
+		events:      events.NewBus(),
+		codes:       shortcode.Default(),
+		referrers:   referrer.NewClassifier(),
+		analyticsTZ: time.UTC,
+		now:         time.Now,
 	}
+	s.subscribeEdgePurge()
+	return s
+}
+
+// Events returns the bus link events are published on
+// Subscribe to it for side effects (metrics, webhooks, audit log)
+func (s *URLService) Events() *events.Bus {
+	return s.events
+}
+
+// subscribeEdgePurge keeps the CDN in step with link events
+// Deleted and expired links must stop redirecting now, not when the edge TTL
+// runs out (our own cache forgets them in the repository decorator)
+func (s *URLService) subscribeEdgePurge() {
+	events.Subscribe(s.events, func(ctx context.Context, e events.URLDeleted) {
+		purgeEdge(ctx, s.edge, e.URL)
+	})
+	events.Subscribe(s.events, func(ctx context.Context, e events.URLExpired) {
+		purgeEdge(ctx, s.edge, e.URL)
+	})
+	// An approved link may have been served as "not found" while pending
+	events.Subscribe(s.events, func(ctx context.Context, e events.ApprovalDecided) {
+		if e.Approved {
+			purgeEdge(ctx, s.edge, e.URL)
+		}
+	})
+}
+
+// WithCodeGenerator replaces the default short code policy (6 alphanumeric characters)
+func (s *URLService) WithCodeGenerator(g *shortcode.Generator) *URLService {
+	s.codes = g
+	return s
+}
+
+// WithCodeCase sets the case policy of short codes (default: case-sensitive)
+//
+// With domain.CodeCaseInsensitive new aliases are stored lowercase, and a
+// code typed in the wrong case still finds its link: lookups try the code as
+// typed, then lowercased. Trying the exact code first keeps links created
+// before the switch working, mixed case and all, until cmd/lowercase-codes
+// has migrated them. The generator must only produce lowercase codes then
+// (see shortcode.FoldAlphabet).
+func (s *URLService) WithCodeCase(c domain.CodeCase) *URLService {
+	s.codeCase = c
+	return s
+}
+
+// WithCodePool checks generated short codes in batches of size (see codePool)
+// instead of one query per new link
+func (s *URLService) WithCodePool(size int) *URLService {
+	if size > 1 {
+		s.codePool = newCodePool(size, time.Now)
+	}
+	return s
+}
+
+// WithReferrerClassifier replaces the default classifier, which knows no
+// internal hosts (our own pages then count as "referral")
+func (s *URLService) WithReferrerClassifier(c *referrer.Classifier) *URLService {
+	s.referrers = c
+	return s
+}
+
+// WithRegion records the deployment region on every click event, so
+// analytics can tell which region served a link's visitors
+func (s *URLService) WithRegion(name string) *URLService {
+	s.region = name
+	return s
+}
+
+// WithEdgePurger purges edited and deleted links from the CDN, so
+// edge-cached redirects never point at an old destination
+func (s *URLService) WithEdgePurger(p EdgePurger) *URLService {
+	s.edge = p
+	return s
+}
+
+// WithAnalyticsTimezone sets the timezone analytics are bucketed in when
+// neither the request (?tz=) nor the link's workspace picks one
+func (s *URLService) WithAnalyticsTimezone(location *time.Location) *URLService {
+	s.analyticsTZ = location
+	return s
+}
+
+// WithAnalytics turns click events on or off for every link (ENABLE_ANALYTICS)
+// Off, clicks are still counted - the counter drives click limits - but
+// nothing about the visitor is stored. Links can also opt out one by one
+// (see domain.WithoutAnalytics).
+func (s *URLService) WithAnalytics(enabled bool) *URLService {
+	s.analyticsOff = !enabled
+	return s
+}
+
+// WithConsentSignals honors Do Not Track and Global Privacy Control
+// Clicks from visitors who send DNT: 1 or Sec-GPC: 1 still count, but their
+// click events are stored without IP address, User-Agent and referrer URL.
+// The repeat-click check still sees them: it keeps nothing but a short-lived hash.
+func (s *URLService) WithConsentSignals(honor bool) *URLService {
+	s.honorConsent = honor
+	return s
+}
+
+// WithWorkspaceSettings lets each workspace choose its analytics timezone,
+// and whether contributors' links need approval
+func (s *URLService) WithWorkspaceSettings(repo repository.WorkspaceSettingsRepository) *URLService {
+	s.workspaces = repo
+	return s
+}
+
+// WithResolver enables destination resolution at creation time
+// When rejectRedirectors is true, destinations that redirect through a known
+// URL shortener are rejected with domain.ErrRedirectorURL
+func (s *URLService) WithResolver(r DestinationResolver, rejectRedirectors bool) *URLService {
+	s.resolver = r
+	s.rejectRedirectors = rejectRedirectors
+	return s
+}
+
+// WithRedirectTracer enables TraceRedirects (the redirect chain preview)
+// It is separate from WithResolver: owners can debug their destinations
+// even when destinations aren't unwrapped at creation time
+func (s *URLService) WithRedirectTracer(t DestinationResolver) *URLService {
+	s.tracer = t
+	return s
+}
+
+// WithAliasLocks serializes concurrent creations of the same custom alias
+//
+// WHY?
+// "Is the alias free? Then insert it" is a CHECK-THEN-ACT race: two requests
+// can both pass the check. The UNIQUE constraint still stops the second
+// INSERT, but only after both requests did all the work (resolving the
+// destination, etc.). With the lock, the loser gets its 409 right away.
+func (s *URLService) WithAliasLocks(l Locker) *URLService {
+	s.aliasLocks = l
+	return s
+}
+
+// WithQuotas enforces plan limits on link creation
+// The caller is taken from ctx (see QuotaService)
+func (s *URLService) WithQuotas(q Quotas) *URLService {
+	s.quotas = q
+	return s
+}
+
+// WithMetadata fetches the destination's title, description and favicon
+// in the background after a link is created or its destination changes
+// At most concurrency fetches run at the same time; the others wait
+func (s *URLService) WithMetadata(f MetadataFetcher, concurrency int) *URLService {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	s.metadata = f
+	s.metadataSlots = make(chan struct{}, concurrency)
+	return s
+}
+
+// WithClickDedup counts a click only once when the same visitor (IP and
+// User-Agent) clicks the same link again within window
+//
+// WHY?
+// Double-clicks, email security scanners that open every link, and preview
+// bots all inflate the counters without being real visits.
+func (s *URLService) WithClickDedup(d ClickDeduplicator, window time.Duration) *URLService {
+	s.clickDedup = d
+	s.clickDedupWindow = window
+	return s
+}
+
+// WithDestinationPolicy rejects links to destinations the domain rules
+// don't allow, at creation, on updates and on restore
+func (s *URLService) WithDestinationPolicy(p DestinationPolicy) *URLService {
+	s.policy = p
+	return s
+}
+
+// WithConfusableAliases checks new custom aliases against g
+// An alias that looks like a popular or reserved one ("paypa1") is refused
+// (domain.ConfusableReject) or created and reported with an AliasFlagged
+// event (domain.ConfusableFlag)
+func (s *URLService) WithConfusableAliases(g AliasGuard, policy domain.ConfusablePolicy) *URLService {
+	s.aliasGuard = g
+	s.confusable = policy
+	return s
+}
+
+// WithDomainVerification only lets links be created on custom domains
+// their owner verified (see CustomDomainService)
+func (s *URLService) WithDomainVerification(v DomainVerifier) *URLService {
+	s.domains = v
+	return s
 }
 
 // CreateShortURL creates a new shortened URL
@@ -48,30 +350,194 @@ func NewURLService(urlRepo repository.URLRepository, clickRepo repository.ClickR
 // 2. Check for collisions
 // 3. Validate the URL
 // 4. Save to database
-func (s *URLService) CreateShortURL(ctx context.Context, originalURL, customAlias, createdBy string, expiresIn time.Duration) (*domain.URL, error) {
+//
+// Optional settings (click limit, ...) are passed as domain.URLOption values
+func (s *URLService) CreateShortURL(ctx context.Context, originalURL, customAlias, createdBy string, expiresIn time.Duration, opts ...domain.URLOption) (*domain.URL, error) {
+	// Viewers are read-only, whichever way they try to create a link
+	if err := authorizeWrite(ctx); err != nil {
+		return nil, err
+	}
+
+	// Build the link (short code is decided below)
+	customAlias = s.codeCase.Normalize(customAlias)
+	url, err := s.newURL(ctx, originalURL, customAlias, createdBy, expiresIn, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	// Determine the short code (custom alias or generated)
-	var shortCode string
 	if customAlias != "" {
+		// Hold the alias until the INSERT is done (see WithAliasLocks)
+		unlock, err := s.lockAlias(ctx, customAlias)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
 		// Check if custom alias is already taken
 		exists, err := s.urlRepo.ExistsCustomAlias(ctx, customAlias)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check custom alias: %w", err)
 		}
 		if exists {
-			return nil, fmt.Errorf("custom alias already exists: %s", customAlias)
+			return nil, fmt.Errorf("%w: %s", domain.ErrCustomAliasTaken, customAlias)
 		}
-		shortCode = customAlias
+		url.ShortCode = customAlias
 	} else {
 		// Generate a unique short code
-		var err error
-		shortCode, err = s.generateUniqueShortCode(ctx, 6)
+		shortCode, err := s.generateUniqueShortCode(ctx, url.Domain)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate short code: %w", err)
 		}
+		url.ShortCode = shortCode
+	}
+
+	// Validate the URL (business rules, destination and alias policies)
+	imitated, err := s.screenURL(ctx, url, customAlias)
+	if errors.Is(err, domain.ErrConfusableAlias) {
+		metrics.RecordConfusableAlias("rejected")
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	// Create the URL domain object
-	url := domain.NewURL(originalURL, shortCode, createdBy)
+	// Count the link against the caller's plan (only valid requests use up quota)
+	usage, err := s.reserveQuota(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unwrap the destination (only after validation - never fetch invalid URLs)
+	if err := s.resolveDestination(ctx, url); err != nil {
+		s.releaseQuota(ctx, usage)
+		return nil, err
+	}
+	// Check again: the destination may redirect to a blocked domain
+	if err := s.checkDestination(url); err != nil {
+		s.releaseQuota(ctx, usage)
+		return nil, err
+	}
+	// Encrypt the destination of a sensitive link - last, everything above needs it
+	if err := s.sealDestination(ctx, url); err != nil {
+		s.releaseQuota(ctx, usage)
+		return nil, err
+	}
+
+	// Save to database
+	// The UNIQUE constraints catch any race the checks above missed
+	err = s.urlRepo.Create(ctx, url)
+	for retry := 0; errors.Is(err, domain.ErrShortCodeTaken) && customAlias == "" && retry < 3; retry++ {
+		// The generated code was taken after it was checked: draw another
+		shortCode, genErr := s.generateUniqueShortCode(ctx, url.Domain)
+		if genErr != nil {
+			err = fmt.Errorf("failed to generate short code: %w", genErr)
+			break
+		}
+		url.ShortCode = shortCode
+		err = s.urlRepo.Create(ctx, url)
+	}
+	if err != nil {
+		s.releaseQuota(ctx, usage) // Nothing was created - give the link back
+	}
+	if errors.Is(err, domain.ErrShortCodeTaken) && customAlias != "" {
+		return nil, fmt.Errorf("%w: %s", domain.ErrCustomAliasTaken, customAlias)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create URL: %w", err)
+	}
+
+	s.events.Publish(ctx, events.URLCreated{URL: url, Actor: auth.FromContext(ctx).Actor(), OccurredAt: s.now()})
+	if url.Approval == domain.ApprovalPending {
+		s.events.Publish(ctx, events.ApprovalRequested{URL: url, Actor: auth.FromContext(ctx).Actor(), OccurredAt: s.now()})
+	}
+	if imitated != "" {
+		s.events.Publish(ctx, events.AliasFlagged{URL: url, Imitates: imitated, Actor: auth.FromContext(ctx).Actor(), OccurredAt: s.now()})
+	}
+	s.fetchMetadata(ctx, url)
+	return url, nil
+}
+
+// ValidateShortURL is a dry run of CreateShortURL: it runs the same checks
+// (validation, destination and domain policies, alias availability, quota)
+// and returns what would be created, or the error creating would fail with.
+// Nothing is stored, no quota is used and no event is published.
+//
+// WHY?
+// A UI can tell the user what is wrong while they fill in the form, and a
+// CI pipeline can check the campaign links it generates before launch day -
+// without creating links it would then have to delete.
+//
+// Generated short codes are not drawn: the real creation draws its own.
+// Destinations of anonymous callers are not followed (see below), so their
+// resolved URL is unknown until the link is created.
+func (s *URLService) ValidateShortURL(ctx context.Context, originalURL, customAlias, createdBy string, expiresIn time.Duration, opts ...domain.URLOption) (*domain.CreationPreview, error) {
+	if err := authorizeWrite(ctx); err != nil {
+		return nil, err
+	}
+
+	customAlias = s.codeCase.Normalize(customAlias)
+	url, err := s.newURL(ctx, originalURL, customAlias, createdBy, expiresIn, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if customAlias != "" {
+		// No lock: nothing will be inserted
+		exists, err := s.urlRepo.ExistsCustomAlias(ctx, customAlias)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check custom alias: %w", err)
+		}
+		if exists {
+			return nil, fmt.Errorf("%w: %s", domain.ErrCustomAliasTaken, customAlias)
+		}
+		url.ShortCode = customAlias
+	} else {
+		// A code from the link's policy, only to validate the rest of the link
+		// (not from the code pool: that would use the code up)
+		shortCode, err := s.codes.Generate(url.Domain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate short code: %w", err)
+		}
+		url.ShortCode = shortCode
+	}
+
+	imitated, err := s.screenURL(ctx, url, customAlias)
+	if err != nil {
+		return nil, err
+	}
+
+	var usage *domain.Usage
+	if s.quotas != nil {
+		if usage, err = s.quotas.Check(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Anonymous dry runs need no CAPTCHA and use no quota: they don't get to
+	// make us fetch URLs. Known shorteners are still recognized by their host.
+	if auth.FromContext(ctx) == auth.Anonymous {
+		if err := s.checkRedirector(url); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := s.resolveDestination(ctx, url); err != nil {
+			return nil, err
+		}
+		if err := s.checkDestination(url); err != nil {
+			return nil, err
+		}
+	}
+
+	if customAlias == "" {
+		url.ShortCode = ""
+	}
+	return &domain.CreationPreview{URL: url, Imitates: imitated, Usage: usage}, nil
+}
+
+// newURL builds the link CreateShortURL stores, without its short code
+// customAlias must already be normalized
+func (s *URLService) newURL(ctx context.Context, originalURL, customAlias, createdBy string, expiresIn time.Duration, opts []domain.URLOption) (*domain.URL, error) {
+	url := domain.NewURL(originalURL, "", createdBy)
 
 	// Set custom alias if provided
 	if customAlias != "" {
@@ -83,101 +549,354 @@ func (s *URLService) CreateShortURL(ctx context.Context, originalURL, customAlia
 		url.WithExpiration(expiresIn)
 	}
 
-	// Validate the URL (business rules)
+	// Apply optional settings
+	// Done before picking the short code because the code length can depend on them (domain)
+	for _, opt := range opts {
+		opt(url)
+	}
+
+	// Contributors' links may have to wait for approval
+	if member, err := s.approvalRequired(ctx, createdBy); err != nil {
+		return nil, err
+	} else if member != "" {
+		domain.WithPendingApproval(member)(url)
+	}
+	return url, nil
+}
+
+// screenURL runs the checks on a link with its short code that need no
+// storage: business rules, the custom domain, the destination policy and
+// confusable aliases. Returns the alias customAlias imitates, if any.
+func (s *URLService) screenURL(ctx context.Context, url *domain.URL, customAlias string) (string, error) {
 	if err := url.Validate(); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return "", fmt.Errorf("validation failed: %w", err)
+	}
+	if s.domains != nil && url.Domain != "" {
+		if err := s.domains.CheckDomain(ctx, url.Domain); err != nil {
+			return "", err
+		}
 	}
+	if err := s.checkDestination(url); err != nil {
+		return "", err
+	}
+	return s.checkConfusable(customAlias)
+}
 
-	// Save to database
-	if err := s.urlRepo.Create(ctx, url); err != nil {
-		return nil, fmt.Errorf("failed to create URL: %w", err)
+// UpsertURL makes the custom alias point at originalURL (PUT semantics)
+//
+// IDEMPOTENT: sending the same request twice has the same effect as once.
+//   - alias missing            -> created (like CreateShortURL)
+//   - destination differs      -> updated (owner or admin only)
+//   - destination already set  -> unchanged, nothing is written
+//
+// This is what declarative tools (Terraform, CI pipelines) need: they
+// describe the desired state and re-apply it on every run. cond carries
+// the If-Match / If-None-Match headers for optimistic concurrency.
+//
+// opts describe the rest of the desired state (language targets, schedule) and
+// are applied to the created or updated URL alike.
+func (s *URLService) UpsertURL(ctx context.Context, alias, originalURL string, cond domain.Precondition, opts ...domain.URLOption) (*domain.URL, domain.UpsertOutcome, error) {
+	// The preconditions compare against the stored version, not a cached one
+	alias = s.codeCase.Normalize(alias)
+	current, err := s.urlRepo.GetByCustomAlias(repository.WithConsistentRead(ctx), alias)
+	if errors.Is(err, domain.ErrURLNotFound) {
+		current = nil
+	} else if err != nil {
+		return nil, "", err
 	}
 
-	// Store in cache for fast access
-	// We don't fail if caching fails - it's not critical
-	if err := s.cache.SetURL(ctx, shortCode, url); err != nil {
-		fmt.Printf("Warning: failed to cache URL: %v\n", err)
+	if current != nil {
+		if err := authorizeManage(ctx, current); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := cond.Check(current); err != nil {
+		return nil, "", err
 	}
 
-	return url, nil
-}
+	if current == nil {
+		url, err := s.CreateShortURL(ctx, originalURL, alias, auth.FromContext(ctx).ID, 0, opts...)
+		if err != nil {
+			return nil, "", err
+		}
+		return url, domain.UpsertCreated, nil
+	}
 
-// GetURL retrieves a URL by its short code or custom alias
-// Implements CACHE-ASIDE PATTERN for performance
-func (s *URLService) GetURL(ctx context.Context, shortCode string) (*domain.URL, error) {
-	// STEP 1: Check cache first (cache-aside pattern)
-	cachedURL, err := s.cache.GetURL(ctx, shortCode)
-	if err == nil && cachedURL != nil {
-		// Cache hit! Return immediately
-		// This is ~50x faster than database lookup
-		if err = cachedURL.CanBeAccessed(); err != nil {
-			return nil, err
+	// An encrypted destination is compared in plain text
+	currentDestination, err := s.destinationOf(ctx, current)
+	if err != nil {
+		return nil, "", err
+	}
+	destinationChanged := currentDestination != originalURL
+
+	updated := *current
+	if destinationChanged {
+		updated.OriginalURL = originalURL
+		updated.SealedDestination = ""
+	}
+	for _, opt := range opts {
+		opt(&updated)
+	}
+
+	if !destinationChanged && maps.Equal(current.LanguageTargets, updated.LanguageTargets) &&
+		current.Schedule.Equal(updated.Schedule) && current.Description == updated.Description &&
+		maps.Equal(current.CustomMetadata, updated.CustomMetadata) {
+		return current, domain.UpsertUnchanged, nil
+	}
+
+	if destinationChanged {
+		updated.ResolvedURL = nil
+	}
+	if err := updated.Validate(); err != nil {
+		return nil, "", fmt.Errorf("validation failed: %w", err)
+	}
+	if destinationChanged {
+		if err := s.resolveDestination(ctx, &updated); err != nil {
+			return nil, "", err
 		}
-		return cachedURL, nil
 	}
+	if err := s.checkDestination(&updated); err != nil {
+		return nil, "", err
+	}
+	if err := s.sealDestination(ctx, &updated); err != nil {
+		return nil, "", err
+	}
+
+	// Update only succeeds if the version is still the one we read - a
+	// concurrent writer makes it fail with ErrVersionConflict
+	if err := s.urlRepo.Update(ctx, &updated); err != nil {
+		return nil, "", err
+	}
+
+	purgeEdge(ctx, s.edge, &updated)
+	if destinationChanged {
+		s.fetchMetadata(ctx, &updated)
+	}
+	return &updated, domain.UpsertUpdated, nil
+}
 
-	// STEP 2: Cache miss - get from database
-	url, err := s.urlRepo.GetByShortCode(ctx, shortCode)
+// CloneURL creates a new link to originalURL with the settings of link id
+// (owner or admin only)
+//
+// Copied: click limit, domain, language targets, schedule, preview card and
+// the expiration POLICY (see domain.URL.Settings). Not copied: the short
+// code, clicks and analytics - the clone is a new link that belongs to the
+// caller. customAlias is optional, like at creation.
+func (s *URLService) CloneURL(ctx context.Context, id, originalURL, customAlias string) (*domain.URL, error) {
+	source, err := s.urlRepo.GetByID(ctx, id)
 	if err != nil {
+		return nil, err
+	}
+	if err := authorizeManage(ctx, source); err != nil {
+		return nil, err
+	}
+
+	settings := source.Settings()
+	return s.CreateShortURL(ctx, originalURL, customAlias, auth.FromContext(ctx).ID, settings.ExpiresIn, settings.Options()...)
+}
+
+// reserveQuota counts a new link against the caller's plan (no-op without quotas)
+func (s *URLService) reserveQuota(ctx context.Context) (*domain.Usage, error) {
+	if s.quotas == nil {
+		return nil, nil
+	}
+	return s.quotas.Reserve(ctx)
+}
+
+// releaseQuota gives back a link reserved with reserveQuota
+func (s *URLService) releaseQuota(ctx context.Context, usage *domain.Usage) {
+	if s.quotas != nil {
+		s.quotas.Release(ctx, usage)
+	}
+}
+
+// lockAlias takes the creation lock for alias
+// If the lock backend is down we carry on without it - the database
+// constraint still guarantees uniqueness, we just lose the early 409
+func (s *URLService) lockAlias(ctx context.Context, alias string) (unlock func(), err error) {
+	noop := func() {}
+	if s.aliasLocks == nil {
+		return noop, nil
+	}
+
+	unlock, ok, err := s.aliasLocks.TryLock(ctx, "alias:"+alias, aliasLockTTL)
+	if err != nil {
+		fmt.Printf("Warning: failed to lock custom alias: %v\n", err)
+		return noop, nil
+	}
+	if !ok {
+		// Another request is creating this alias right now
+		return nil, fmt.Errorf("%w: %s", domain.ErrCustomAliasTaken, alias)
+	}
+	return unlock, nil
+}
+
+// GetURL retrieves a URL by its short code or custom alias
+// Whether the answer comes from the cache or the database is up to the
+// repository (see cache.CachedURLRepository)
+func (s *URLService) GetURL(ctx context.Context, shortCode string) (*domain.URL, error) {
+	url, err := s.lookupCode(ctx, shortCode, s.urlRepo.GetByShortCode)
+	if err != nil && !errors.Is(err, resilience.ErrCircuitOpen) {
 		// If not found, try custom alias
-		url, err = s.urlRepo.GetByCustomAlias(ctx, shortCode)
-		if err != nil {
-			return nil, fmt.Errorf("URL not found: %s", shortCode)
-		}
+		url, err = s.lookupCode(ctx, shortCode, s.urlRepo.GetByCustomAlias)
+	}
+	if errors.Is(err, resilience.ErrCircuitOpen) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("URL not found: %s", shortCode)
 	}
 
 	// Check if URL can be accessed (not expired, active)
 	if err := url.CanBeAccessed(); err != nil {
 		return nil, err
 	}
+	// The one place an encrypted destination is decrypted: the redirect
+	return s.openDestination(ctx, url)
+}
 
-	// STEP 3: Store in cache for next time
-	// Don't fail if caching fails - it's not critical
-	if err := s.cache.SetURL(ctx, shortCode, url); err != nil {
-		fmt.Printf("Warning: failed to cache URL: %v\n", err)
+// lookupCode finds a link by code with get (GetByShortCode or GetByCustomAlias)
+// When codes ignore case and the code as typed isn't found, it tries again lowercased
+func (s *URLService) lookupCode(ctx context.Context, code string, get func(context.Context, string) (*domain.URL, error)) (*domain.URL, error) {
+	url, err := get(ctx, code)
+	if errors.Is(err, domain.ErrURLNotFound) {
+		if folded := s.codeCase.Normalize(code); folded != code {
+			return get(ctx, folded)
+		}
 	}
-
-	return url, nil
+	return url, err
 }
 
 // RecordClick records a click event and increments the counter
 // This demonstrates a TRANSACTION-like operation across multiple tables
-func (s *URLService) RecordClick(ctx context.Context, shortCode, ipAddress, userAgent, referer string) error {
-	// Get the URL first to get its ID
-	url, err := s.urlRepo.GetByShortCode(ctx, shortCode)
+func (s *URLService) RecordClick(ctx context.Context, visit domain.ClickContext) error {
+	shortCode := visit.ShortCode
+	if s.isRepeatClick(ctx, s.codeCase.Normalize(shortCode), visit.IPAddress, visit.UserAgent) {
+		metrics.RecordClickDeduplicated()
+		return nil
+	}
+
+	// Get the URL first to get its ID (and the real counter for the event -
+	// a cached copy doesn't follow clicks)
+	url, err := s.lookupCode(repository.WithConsistentRead(ctx), shortCode, s.urlRepo.GetByShortCode)
 	if err != nil {
 		return fmt.Errorf("URL not found: %w", err)
 	}
 
 	// Increment the click counter atomically
-	if err := s.urlRepo.IncrementClicks(ctx, shortCode); err != nil {
+	// (by the stored code: the visitor may have typed it in another case)
+	if err := s.urlRepo.IncrementClicks(ctx, url.ShortCode); err != nil {
 		return fmt.Errorf("failed to increment clicks: %w", err)
 	}
 
+	url.IncrementClicks()
+
 	// Create click event for analytics
-	click := domain.NewURLClick(url.ID, ipAddress, userAgent, referer)
+	click := domain.NewURLClick(url.ID, visit.IPAddress, visit.UserAgent, visit.Referer)
+	click.ShortCode = url.ShortCode
+	click.Channel = s.referrers.Classify(visit.Referer)
+	click.Region = s.region
+	if visit.Region != "" {
+		click.Region = visit.Region
+	}
+	if !visit.ClickedAt.IsZero() {
+		click.ClickedAt = visit.ClickedAt
+	}
+	device := useragent.Parse(visit.UserAgent)
+	click.WithDevice(device.Browser, device.OS, device.Device)
 
 	// TODO: Add geolocation lookup here
 	// For now, we'll leave it empty
 	// In production, you'd use a service like MaxMind GeoIP2
 
-	if err := s.clickRepo.Create(ctx, click); err != nil {
-		// Log the error but don't fail the request
-		// Analytics is important but not critical for the redirect to work
-		// This is a design decision: availability > consistency for analytics
-		fmt.Printf("Warning: failed to record click event: %v\n", err)
+	// The visitor asked not to be tracked: keep the click, not the person
+	// Channel and device class stay - they describe traffic, not a visitor
+	if s.honorConsent && visit.Consent != "" {
+		click.IPAddress, click.UserAgent, click.Referer = "", "", ""
+		metrics.RecordClickConsentSuppressed(string(visit.Consent))
 	}
 
+	// With analytics off, the counter above is all we keep
+	if url.Analytics(!s.analyticsOff).Enabled {
+		if err := s.clickRepo.Create(ctx, click); err != nil {
+			// Log the error but don't fail the request
+			// Analytics is important but not critical for the redirect to work
+			// This is a design decision: availability > consistency for analytics
+			fmt.Printf("Warning: failed to record click event: %v\n", err)
+		}
+	}
+
+	s.events.Publish(ctx, events.ClickRecorded{URL: url, Click: click})
 	return nil
 }
 
+// UseOnce uses up a single-use link right before its redirect
+// Returns domain.ErrLinkUsed when another visitor got there first.
+// Other links pass untouched.
+//
+// It runs BEFORE the redirect, not in the background like RecordClick:
+// the claim decides whether this visitor may go through at all.
+func (s *URLService) UseOnce(ctx context.Context, url *domain.URL) error {
+	if !url.SingleUse {
+		return nil
+	}
+
+	won, err := s.urlRepo.MarkUsed(ctx, url.ShortCode)
+	if err != nil {
+		return fmt.Errorf("failed to use single-use link: %w", err)
+	}
+
+	// Winner or not, the link is used now - the CDN must stop serving it
+	purgeEdge(ctx, s.edge, url)
+	if !won {
+		return domain.ErrLinkUsed
+	}
+	return nil
+}
+
+// isRepeatClick reports whether the visitor clicked the link within the dedup window
+// Redis trouble counts the click: an inflated counter beats a lost one
+func (s *URLService) isRepeatClick(ctx context.Context, shortCode, ipAddress, userAgent string) bool {
+	if s.clickDedup == nil || s.clickDedupWindow <= 0 {
+		return false
+	}
+
+	first, err := s.clickDedup.FirstSeen(ctx, clickDedupKey(shortCode, ipAddress, userAgent), s.clickDedupWindow)
+	if err != nil {
+		fmt.Printf("Warning: click deduplication failed: %v\n", err)
+		return false
+	}
+	return !first
+}
+
+// clickDedupKey identifies a visitor's click on a link
+// The IP's port changes with every connection, so it is dropped. The parts
+// are hashed: the key stays short and Redis never holds raw IP addresses.
+func clickDedupKey(shortCode, ipAddress, userAgent string) string {
+	if host, _, err := net.SplitHostPort(ipAddress); err == nil {
+		ipAddress = host
+	}
+	sum := sha256.Sum256([]byte(shortCode + "\x00" + ipAddress + "\x00" + userAgent))
+	return hex.EncodeToString(sum[:])
+}
+
 // GetURLStats retrieves analytics for a URL
 func (s *URLService) GetURLStats(ctx context.Context, shortCode string) (*domain.URL, []*domain.URLClick, error) {
-	// Get the URL
-	url, err := s.urlRepo.GetByShortCode(ctx, shortCode)
+	// Get the URL - from the database, the cached copy has an old click count
+	url, err := s.lookupCode(repository.WithConsistentRead(ctx), shortCode, s.urlRepo.GetByShortCode)
 	if err != nil {
 		return nil, nil, fmt.Errorf("URL not found: %w", err)
 	}
+	if err := authorizeView(ctx, url); err != nil {
+		return nil, nil, err
+	}
+	url = visibleTo(ctx, url)
+
+	// No click events are recorded while analytics is off - and old ones
+	// from before it was turned off are not shown either
+	if !url.Analytics(!s.analyticsOff).Enabled {
+		return url, nil, nil
+	}
 
 	// Get recent clicks (last 100)
 	clicks, err := s.clickRepo.GetByURLID(ctx, url.ID, 100, 0)
@@ -188,18 +907,565 @@ func (s *URLService) GetURLStats(ctx context.Context, shortCode string) (*domain
 	return url, clicks, nil
 }
 
-// DeleteURL soft-deletes a URL
+// GetClickSummary breaks a URL's clicks down by channel, device, browser,
+// OS and serving region, plus clicks per day of the last month in timezone
+// ("" = the workspace default) (owner or admin only)
+func (s *URLService) GetClickSummary(ctx context.Context, shortCode, timezone string) (*domain.ClickSummary, error) {
+	url, err := s.lookupCode(ctx, shortCode, s.urlRepo.GetByShortCode)
+	if err != nil {
+		return nil, fmt.Errorf("URL not found: %w", err)
+	}
+	if err := authorizeView(ctx, url); err != nil {
+		return nil, err
+	}
+	url = visibleTo(ctx, url)
+
+	summary := &domain.ClickSummary{URL: url}
+	breakdowns := []struct {
+		dimension domain.ClickDimension
+		counts    *map[string]int64
+	}{
+		{domain.DimensionChannel, &summary.Channels},
+		{domain.DimensionDevice, &summary.Devices},
+		{domain.DimensionBrowser, &summary.Browsers},
+		{domain.DimensionOS, &summary.OperatingSystems},
+		{domain.DimensionRegion, &summary.Regions},
+	}
+	for _, b := range breakdowns {
+		counts, err := s.clickRepo.CountBy(ctx, url.ID, b.dimension)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count clicks by %s: %w", b.dimension, err)
+		}
+		*b.counts = counts
+	}
+
+	summary.Daily, err = s.clickTimeseries(ctx, url, domain.TimeseriesQuery{Interval: domain.IntervalDay, Timezone: timezone})
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Comparison, err = s.compareClicks(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// compareClicks compares the last 7 days with the 7 days before
+//
+// Rolling windows, not calendar weeks: on a Monday "this week" would be a
+// few hours against a full week, and every link would look like it's dying.
+// The windows end with the current hour, so both are 7 days long (the
+// current one is less than an hour short until the hour is over). One query
+// with hourly buckets counts both.
+func (s *URLService) compareClicks(ctx context.Context, url *domain.URL) (*domain.ClickComparison, error) {
+	to := s.now().Truncate(time.Hour).Add(time.Hour)
+	currentFrom := to.Add(-domain.ComparisonPeriod)
+	previousFrom := currentFrom.Add(-domain.ComparisonPeriod)
+
+	buckets, err := s.clickRepo.CountByPeriod(ctx, url.ID, domain.IntervalHour, time.UTC, previousFrom, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clicks by hour: %w", err)
+	}
+
+	var previous, current int64
+	for _, bucket := range buckets {
+		if bucket.Start.Before(currentFrom) {
+			previous += bucket.Clicks
+		} else {
+			current += bucket.Clicks
+		}
+	}
+	return domain.CompareClicks(previousFrom, currentFrom, to, previous, current), nil
+}
+
+// GetClickTimeseries counts a URL's clicks per hour, day, week or month
+// (owner or admin only)
+//
+// WHY DOES THE TIMEZONE MATTER?
+// "Clicks on Monday" means Monday where the viewer lives. A click at 23:30
+// in New York is already Tuesday in UTC, so with UTC buckets every evening
+// campaign would leak into the next day's bar.
+func (s *URLService) GetClickTimeseries(ctx context.Context, shortCode string, query domain.TimeseriesQuery) (*domain.ClickTimeseries, error) {
+	url, err := s.lookupCode(ctx, shortCode, s.urlRepo.GetByShortCode)
+	if err != nil {
+		return nil, fmt.Errorf("URL not found: %w", err)
+	}
+	if err := authorizeView(ctx, url); err != nil {
+		return nil, err
+	}
+	url = visibleTo(ctx, url)
+
+	return s.clickTimeseries(ctx, url, query)
+}
+
+// clickTimeseries builds the gap-free timeseries of url
+func (s *URLService) clickTimeseries(ctx context.Context, url *domain.URL, query domain.TimeseriesQuery) (*domain.ClickTimeseries, error) {
+	location, err := s.analyticsLocation(ctx, url, query.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := query.TimeRange(s.now(), location)
+	if err != nil {
+		return nil, err
+	}
+	interval := query.Interval
+	if interval == "" {
+		interval = domain.IntervalDay
+	}
+
+	counted, err := s.clickRepo.CountByPeriod(ctx, url.ID, interval, location, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clicks by %s: %w", interval, err)
+	}
+
+	return &domain.ClickTimeseries{
+		URL:      url,
+		Interval: interval,
+		Location: location,
+		From:     from,
+		To:       to,
+		Buckets:  domain.FillBuckets(interval, from, to, counted),
+	}, nil
+}
+
+// GetClickHeatmap returns the clicks of a link per weekday and hour of day
+// (same access rules as GetClickSummary)
+//
+// The range is rounded out to whole weeks (Monday to Monday in the timezone):
+// with a partial week some weekdays would be counted once more than others
+// and look busier than they are. Without From/To: the last 12 weeks.
+func (s *URLService) GetClickHeatmap(ctx context.Context, shortCode string, query domain.TimeseriesQuery) (*domain.ClickHeatmap, error) {
+	url, err := s.lookupCode(ctx, shortCode, s.urlRepo.GetByShortCode)
+	if err != nil {
+		return nil, fmt.Errorf("URL not found: %w", err)
+	}
+	if err := authorizeView(ctx, url); err != nil {
+		return nil, err
+	}
+	url = visibleTo(ctx, url)
+
+	location, err := s.analyticsLocation(ctx, url, query.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	query.Interval = domain.IntervalWeek
+	from, to, err := query.TimeRange(s.now(), location)
+	if err != nil {
+		return nil, err
+	}
+
+	cells, err := s.clickRepo.CountByHourOfWeek(ctx, url.ID, location, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clicks by hour of week: %w", err)
+	}
+
+	heatmap := &domain.ClickHeatmap{URL: url, Location: location, From: from, To: to}
+	heatmap.AddCells(cells)
+	return heatmap, nil
+}
+
+// analyticsLocation picks the timezone for url's analytics:
+// the requested one, else the link's workspace default, else the server's
+func (s *URLService) analyticsLocation(ctx context.Context, url *domain.URL, requested string) (*time.Location, error) {
+	if requested != "" {
+		return domain.LoadTimezone(requested)
+	}
+
+	if s.workspaces != nil && url.CreatedBy != "" {
+		settings, err := s.workspaces.Get(ctx, url.CreatedBy)
+		switch {
+		case err == nil && settings.Timezone != "":
+			// Validated on save; a zone dropped from the tz database later
+			// falls back to the default instead of breaking the dashboard
+			if location, err := domain.LoadTimezone(settings.Timezone); err == nil {
+				return location, nil
+			}
+		case err != nil && !errors.Is(err, domain.ErrWorkspaceSettingsNotFound):
+			return nil, fmt.Errorf("failed to get workspace settings: %w", err)
+		}
+	}
+
+	return s.analyticsTZ, nil
+}
+
+// TraceRedirects follows the destination of a short link server-side and
+// reports every hop (owner or admin; anonymous links are public)
+//
+// A destination that can't be fetched is NOT an error: the trace carries
+// the hops up to the failure plus the reason, which is exactly what someone
+// debugging a broken link wants to see.
+func (s *URLService) TraceRedirects(ctx context.Context, shortCode string) (*domain.RedirectTrace, error) {
+	url, err := s.lookupCode(ctx, shortCode, s.urlRepo.GetByShortCode)
+	if err != nil {
+		return nil, fmt.Errorf("URL not found: %w", err)
+	}
+	if err := authorizeView(ctx, url); err != nil {
+		return nil, err
+	}
+	if !canSeeDestinations(ctx, url) {
+		return nil, domain.ErrForbidden // Every hop gives the destination away
+	}
+	if s.tracer == nil {
+		return nil, domain.ErrRedirectTraceUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redirectTraceTimeout)
+	defer cancel()
+
+	trace := &domain.RedirectTrace{URL: url}
+	if !url.Redirects() {
+		return trace, nil // Answers with its reveal page or file - there is nothing to follow
+	}
+	if url.DestinationSealed() {
+		return nil, domain.ErrForbidden // Encrypted destinations are only decrypted to redirect
+	}
+	result, err := s.tracer.Resolve(ctx, url.OriginalURL)
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	if result != nil {
+		for _, hop := range result.Hops {
+			trace.Hops = append(trace.Hops, domain.RedirectHop{
+				URL:         hop.URL,
+				StatusCode:  hop.StatusCode,
+				ContentType: hop.ContentType,
+			})
+		}
+		trace.Truncated = result.Truncated
+		trace.ViaShortener = result.ViaShortener
+	}
+	return trace, nil
+}
+
+// DeleteURL soft-deletes a URL (owner or admin only)
 func (s *URLService) DeleteURL(ctx context.Context, id string) error {
-	return s.urlRepo.Delete(ctx, id)
+	url, err := s.urlRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := authorizeManage(ctx, url); err != nil {
+		return err
+	}
+
+	if err := s.urlRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.events.Publish(ctx, events.URLDeleted{URL: url, Actor: auth.FromContext(ctx).Actor(), OccurredAt: s.now()})
+	return nil
+}
+
+// RestoreURL reactivates a soft-deleted URL (owner or admin only)
+func (s *URLService) RestoreURL(ctx context.Context, id string) (*domain.URL, error) {
+	url, err := s.urlRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeManage(ctx, url); err != nil {
+		return nil, err
+	}
+	// A pending or rejected link is inactive too, but only approval brings it to life
+	if url.AwaitsApproval() {
+		return nil, domain.ErrAwaitingApproval
+	}
+	// The link may have been deactivated by the policy sweeper: it only
+	// comes back once its destination is allowed again
+	if err := s.checkDestination(url); err != nil {
+		return nil, err
+	}
+
+	if err := s.urlRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+
+	url, err = s.urlRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// A stale "inactive" copy may still be on the CDN
+	purgeEdge(ctx, s.edge, url)
+	return url, nil
+}
+
+// SignURL signs a signed link so it opens until expiresIn from now
+// (0 = the signature never expires; owner or admin only)
+// Returns domain.ErrLinkNotSigned for links that open without a signature
+func (s *URLService) SignURL(ctx context.Context, id string, expiresIn time.Duration) (*domain.SignedLink, error) {
+	url, err := s.urlRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeManage(ctx, url); err != nil {
+		return nil, err
+	}
+	if !url.RequiresSignature() {
+		return nil, domain.ErrLinkNotSigned
+	}
+
+	signed := &domain.SignedLink{URL: url}
+	var expires time.Time
+	if expiresIn > 0 {
+		// Whole seconds: that is all ?exp= can carry
+		expires = s.now().Add(expiresIn).Truncate(time.Second)
+		signed.ExpiresAt = &expires
+	}
+	signed.Query = url.SignedQuery(expires)
+	return signed, nil
+}
+
+// SetPreview sets the social preview card of a URL (owner or admin only)
+// A nil card removes it, so crawlers see the destination's own tags again
+func (s *URLService) SetPreview(ctx context.Context, id string, card *domain.PreviewCard) (*domain.URL, error) {
+	if card != nil {
+		card.Normalize()
+		if err := card.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	url, err := s.urlRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeManage(ctx, url); err != nil {
+		return nil, err
+	}
+
+	url.Preview = card
+	if err := s.urlRepo.Update(ctx, url); err != nil {
+		return nil, err
+	}
+
+	// Crawlers may read the card from the CDN
+	purgeEdge(ctx, s.edge, url)
+	return url, nil
+}
+
+// SetNotes replaces the description and custom metadata of a URL (owner or admin only)
+// Notes don't change the redirect, so unlike SetPreview nothing is purged
+// from the CDN
+func (s *URLService) SetNotes(ctx context.Context, id, description string, metadata map[string]string) (*domain.URL, error) {
+	url, err := s.urlRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeManage(ctx, url); err != nil {
+		return nil, err
+	}
+
+	domain.WithDescription(description)(url)
+	domain.WithCustomMetadata(metadata)(url)
+	if err := url.ValidateNotes(); err != nil {
+		return nil, err
+	}
+	if err := s.urlRepo.Update(ctx, url); err != nil {
+		return nil, err
+	}
+	return url, nil
+}
+
+// SetVisibility sets who in the workspace sees the destinations of a URL
+// (owner or admin only)
+// The redirect is the same for everyone, so nothing is purged from the CDN
+// With destination sealing the destination is encrypted or decrypted to match
+func (s *URLService) SetVisibility(ctx context.Context, id string, visibility domain.LinkVisibility) (*domain.URL, error) {
+	url, err := s.urlRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeManage(ctx, url); err != nil {
+		return nil, err
+	}
+
+	domain.WithVisibility(visibility)(url)
+	if err := s.sealDestination(ctx, url); err != nil {
+		return nil, err
+	}
+	if err := s.urlRepo.Update(ctx, url); err != nil {
+		return nil, err
+	}
+	return url, nil
+}
+
+// PurgeURL permanently deletes a URL and all of its analytics (owner or admin only)
+// Unlike DeleteURL this cannot be undone
+// Returns the number of click events removed
+func (s *URLService) PurgeURL(ctx context.Context, id string) (int64, error) {
+	url, err := s.urlRepo.GetByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if err := authorizeManage(ctx, url); err != nil {
+		return 0, err
+	}
+
+	clicks, err := s.urlRepo.Purge(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	s.events.Publish(ctx, events.URLDeleted{URL: url, Actor: auth.FromContext(ctx).Actor(), Permanent: true, OccurredAt: s.now()})
+	return clicks, nil
+}
+
+// purgeEdge removes links from the CDN (no-op without one)
+// Used whenever the redirect itself may change (edits, deletes, restores).
+// A failed purge only logs: the edge copy still expires after CDN_EDGE_TTL
+func purgeEdge(ctx context.Context, edge EdgePurger, urls ...*domain.URL) {
+	if edge == nil || len(urls) == 0 {
+		return
+	}
+	if err := edge.PurgeLinks(ctx, urls); err != nil {
+		fmt.Printf("Warning: failed to purge links from the CDN: %v\n", err)
+	}
+}
+
+// checkConfusable returns the alias that alias imitates ("" = none or no check)
+// With domain.ConfusableReject an imitation is an ErrConfusableAlias error
+func (s *URLService) checkConfusable(alias string) (string, error) {
+	if alias == "" || s.aliasGuard == nil || s.confusable == domain.ConfusableOff {
+		return "", nil
+	}
+	imitated := s.aliasGuard.Imitated(alias)
+	if imitated != "" && s.confusable == domain.ConfusableReject {
+		return "", fmt.Errorf("%w: %s looks like %s", domain.ErrConfusableAlias, alias, imitated)
+	}
+	return imitated, nil
+}
+
+// approvalRequired returns the contributor whose new link in workspace must
+// wait for approval ("" = it goes live right away)
+// Settings that can't be read fail the creation: guessing "no approval
+// needed" would publish links nobody reviewed
+func (s *URLService) approvalRequired(ctx context.Context, workspace string) (string, error) {
+	principal := auth.FromContext(ctx)
+	if s.workspaces == nil || principal.EffectiveRole() != auth.RoleContributor || principal.ID != workspace {
+		return "", nil
+	}
+	settings, err := s.workspaces.Get(ctx, workspace)
+	if errors.Is(err, domain.ErrWorkspaceSettingsNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get workspace settings: %w", err)
+	}
+	if !settings.RequireApproval {
+		return "", nil
+	}
+	return principal.Actor(), nil
+}
+
+// checkDestination applies the destination policy to every destination of url
+func (s *URLService) checkDestination(url *domain.URL) error {
+	if s.policy == nil {
+		return nil
+	}
+	return s.policy.CheckURL(url)
+}
+
+// resolveDestination follows the destination's redirects and stores the final URL
+// Resolution failures are NOT fatal: the destination may be temporarily down,
+// and we don't want to block link creation on a third-party server. Cloaking
+// is still rejected: a known shortener is recognized by its host, whether or
+// not it answers, and a chain too long to follow hides where it ends.
+func (s *URLService) resolveDestination(ctx context.Context, url *domain.URL) error {
+	// Secrets and files have no destination to follow
+	if s.resolver == nil || !url.Redirects() {
+		return nil
+	}
+	if err := s.checkRedirector(url); err != nil {
+		return err
+	}
+
+	result, err := s.resolver.Resolve(ctx, url.OriginalURL)
+	// The result holds the hops before a failure: a shortener that times out
+	// or fails TLS halfway down the chain is still a shortener
+	if result != nil && result.ViaShortener && s.rejectRedirectors {
+		return fmt.Errorf("validation failed: %w", domain.ErrRedirectorURL)
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to resolve destination: %v\n", err)
+		return nil
+	}
+
+	if result.Truncated {
+		if s.rejectRedirectors {
+			return fmt.Errorf("validation failed: %w: too many redirects", domain.ErrRedirectorURL)
+		}
+		// FinalURL is only where we stopped following, not the destination
+		return nil
+	}
+
+	url.WithResolvedURL(result.FinalURL)
+	return nil
+}
+
+// checkRedirector rejects destinations on a known URL shortener without
+// fetching them (REJECT_REDIRECTORS)
+func (s *URLService) checkRedirector(url *domain.URL) error {
+	if s.resolver == nil || !s.rejectRedirectors || !url.Redirects() {
+		return nil
+	}
+	if resolver.IsShortenerURL(url.OriginalURL) {
+		return fmt.Errorf("validation failed: %w", domain.ErrRedirectorURL)
+	}
+	return nil
+}
+
+// fetchMetadata reads the destination page's metadata in the background
+//
+// WHY ASYNC?
+// Fetching a third-party page can take seconds. The link works without its
+// title, so creation returns right away and the title shows up shortly
+// after. A failed fetch only logs a warning - UIs fall back to the raw URL.
+func (s *URLService) fetchMetadata(ctx context.Context, url *domain.URL) {
+	// Secrets and files have no page to read (and secrets must not leak one,
+	// nor encrypted destinations)
+	if s.metadata == nil || !url.Redirects() || url.DestinationSealed() {
+		return
+	}
+
+	// Copy what the goroutine needs: the caller keeps using url
+	snapshot := *url
+	// Detach from the request (it ends before the fetch) but keep its values
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		s.metadataSlots <- struct{}{}
+		defer func() { <-s.metadataSlots }()
+
+		fetchCtx, cancel := context.WithTimeout(ctx, metadataFetchTimeout)
+		defer cancel()
+
+		meta, err := s.metadata.Fetch(fetchCtx, snapshot.OriginalURL)
+		if err != nil {
+			fmt.Printf("Warning: failed to fetch metadata for %s: %v\n", snapshot.ShortCode, err)
+			return
+		}
+		if err := s.urlRepo.SetMetadata(ctx, snapshot.ID, meta); err != nil {
+			fmt.Printf("Warning: failed to store metadata for %s: %v\n", snapshot.ShortCode, err)
+		}
+	}()
 }
 
 // generateUniqueShortCode generates a cryptographically random short code
 // and ensures it doesn't collide with existing codes
-func (s *URLService) generateUniqueShortCode(ctx context.Context, length int) (string, error) {
+// The length and alphabet come from the code generator's policy for the domain
+func (s *URLService) generateUniqueShortCode(ctx context.Context, host string) (string, error) {
+	if s.codePool != nil {
+		return s.codePool.take(ctx, host, s.freeShortCodes)
+	}
+
 	// Try up to 10 times to generate a unique code
-	// Collisions are rare with 6 characters (62^6 = 56 billion possibilities)
+	// Collisions are rare with the default policy (62^6 = 56 billion possibilities)
 	for i := 0; i < 10; i++ {
-		code := generateShortCode(length)
+		code, err := s.codes.Generate(host)
+		if err != nil {
+			return "", err
+		}
 
 		// Check if it exists
 		exists, err := s.urlRepo.ExistsShortCode(ctx, code)
@@ -215,24 +1481,32 @@ func (s *URLService) generateUniqueShortCode(ctx context.Context, length int) (s
 	return "", fmt.Errorf("failed to generate unique short code after 10 attempts")
 }
 
-// generateShortCode generates a random alphanumeric string
-// Uses crypto/rand for cryptographically secure randomness
-func generateShortCode(length int) string {
-	// Base64 URL-safe encoding uses: A-Z, a-z, 0-9, -, _
-	// We'll use a custom charset for better readability (no confusing characters)
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-
-	// Generate random bytes
-	bytes := make([]byte, length)
-	if _, err := rand.Read(bytes); err != nil {
-		// Fallback to timestamp-based (less secure but works)
-		return base64.URLEncoding.EncodeToString([]byte(time.Now().String()))[:length]
+// freeShortCodes generates n candidate codes for host and returns the ones
+// not in use, checked with ONE query (refills the code pool)
+func (s *URLService) freeShortCodes(ctx context.Context, host string, n int) ([]string, error) {
+	candidates := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	for attempt := 0; len(candidates) < n && attempt < 4*n; attempt++ { // Tiny code spaces run out of distinct codes
+		code, err := s.codes.Generate(host)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[code] {
+			seen[code] = true
+			candidates = append(candidates, code)
+		}
 	}
 
-	// Map random bytes to charset
-	for i, b := range bytes {
-		bytes[i] = charset[b%byte(len(charset))]
+	taken, err := s.urlRepo.FindTakenCodes(ctx, candidates)
+	if err != nil {
+		return nil, err
 	}
 
-	return string(bytes)
+	free := candidates[:0]
+	for _, code := range candidates {
+		if !taken[code] {
+			free = append(free, code)
+		}
+	}
+	return free, nil
 }
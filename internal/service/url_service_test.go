@@ -2,11 +2,24 @@ package service
 
 import (
 	"context"
+	"errors"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
+	"url-shortener/internal/auth"
 	"url-shortener/internal/domain"
-
+	"url-shortener/internal/events"
+	"url-shortener/internal/faults"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/referrer"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/resilience"
+	"url-shortener/internal/resolver"
+	"url-shortener/internal/shortcode"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -50,16 +63,47 @@ func (m *MockURLRepository) ExistsCustomAlias(ctx context.Context, alias string)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockURLRepository) FindTakenCodes(ctx context.Context, codes []string) (map[string]bool, error) {
+	args := m.Called(ctx, codes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]bool), args.Error(1)
+}
+
 func (m *MockURLRepository) IncrementClicks(ctx context.Context, shortCode string) error {
 	args := m.Called(ctx, shortCode)
 	return args.Error(0)
 }
 
+func (m *MockURLRepository) MarkUsed(ctx context.Context, shortCode string) (bool, error) {
+	args := m.Called(ctx, shortCode)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockURLRepository) Burn(ctx context.Context, shortCode string) (*string, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*string), args.Error(1)
+}
+
 func (m *MockURLRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockURLRepository) Restore(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockURLRepository) Purge(ctx context.Context, id string) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockURLRepository) GetByID(ctx context.Context, id string) (*domain.URL, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -73,6 +117,11 @@ func (m *MockURLRepository) Update(ctx context.Context, url *domain.URL) error {
 	return args.Error(0)
 }
 
+func (m *MockURLRepository) SetMetadata(ctx context.Context, id string, meta *domain.LinkMetadata) error {
+	args := m.Called(ctx, id, meta)
+	return args.Error(0)
+}
+
 // MockClickRepository is a mock implementation of ClickRepository
 type MockClickRepository struct {
 	mock.Mock
@@ -96,6 +145,38 @@ func (m *MockClickRepository) GetClickCount(ctx context.Context, urlID string) (
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *MockClickRepository) CountBy(ctx context.Context, urlID string, dimension domain.ClickDimension) (map[string]int64, error) {
+	args := m.Called(ctx, urlID, dimension)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MockClickRepository) CountByPeriod(ctx context.Context, urlID string, interval domain.Interval, location *time.Location, from, to time.Time) ([]domain.ClickBucket, error) {
+	args := m.Called(ctx, urlID, interval, location, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ClickBucket), args.Error(1)
+}
+
+func (m *MockClickRepository) CountByHourOfWeek(ctx context.Context, urlID string, location *time.Location, from, to time.Time) ([]domain.HourOfWeek, error) {
+	args := m.Called(ctx, urlID, location, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.HourOfWeek), args.Error(1)
+}
+
+func (m *MockClickRepository) TopLinks(ctx context.Context, owner string, since time.Time, limit int) ([]domain.TopLink, error) {
+	args := m.Called(ctx, owner, since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.TopLink), args.Error(1)
+}
+
 // MockCache is a mock implementation of Cache
 type MockCache struct {
 	mock.Mock
@@ -119,6 +200,36 @@ func (m *MockCache) DeleteURL(ctx context.Context, shortCode string) error {
 	return args.Error(0)
 }
 
+// MockResolver is a mock implementation of DestinationResolver
+type MockResolver struct {
+	mock.Mock
+}
+
+func (m *MockResolver) Resolve(ctx context.Context, rawURL string) (*resolver.Result, error) {
+	args := m.Called(ctx, rawURL)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*resolver.Result), args.Error(1)
+}
+
+// MockLocker is a mock implementation of Locker
+type MockLocker struct {
+	mock.Mock
+}
+
+func (m *MockLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	args := m.Called(ctx, key, ttl)
+	if !args.Bool(1) {
+		return nil, false, args.Error(2)
+	}
+	return args.Get(0).(func()), true, args.Error(2)
+}
+
+// consistentRead matches contexts that must skip the cache
+// (see repository.WithConsistentRead)
+var consistentRead = mock.MatchedBy(repository.IsConsistentRead)
+
 // ==================== TESTS ====================
 
 func TestCreateShortURL_Success(t *testing.T) {
@@ -126,14 +237,12 @@ func TestCreateShortURL_Success(t *testing.T) {
 	ctx := context.Background()
 	mockURLRepo := new(MockURLRepository)
 	mockClickRepo := new(MockClickRepository)
-	mockCache := new(MockCache)
 
-	service := NewURLService(mockURLRepo, mockClickRepo, mockCache)
+	service := NewURLService(mockURLRepo, mockClickRepo)
 
 	// Mock expectations
 	mockURLRepo.On("ExistsCustomAlias", ctx, "mylink").Return(false, nil)
 	mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
-	mockCache.On("SetURL", ctx, "mylink", mock.AnythingOfType("*domain.URL")).Return(nil)
 
 	// Act
 	url, err := service.CreateShortURL(ctx, "https://example.com", "mylink", "user1", 0)
@@ -145,7 +254,6 @@ func TestCreateShortURL_Success(t *testing.T) {
 	assert.Equal(t, "https://example.com", url.OriginalURL)
 	assert.Equal(t, "user1", url.CreatedBy)
 	mockURLRepo.AssertExpectations(t)
-	mockCache.AssertExpectations(t)
 }
 
 func TestCreateShortURL_CustomAliasAlreadyExists(t *testing.T) {
@@ -153,9 +261,8 @@ func TestCreateShortURL_CustomAliasAlreadyExists(t *testing.T) {
 	ctx := context.Background()
 	mockURLRepo := new(MockURLRepository)
 	mockClickRepo := new(MockClickRepository)
-	mockCache := new(MockCache)
 
-	service := NewURLService(mockURLRepo, mockClickRepo, mockCache)
+	service := NewURLService(mockURLRepo, mockClickRepo)
 
 	// Mock: custom alias already exists
 	mockURLRepo.On("ExistsCustomAlias", ctx, "taken").Return(true, nil)
@@ -170,18 +277,65 @@ func TestCreateShortURL_CustomAliasAlreadyExists(t *testing.T) {
 	mockURLRepo.AssertExpectations(t)
 }
 
+func TestCreateShortURL_CustomAliasLocking(t *testing.T) {
+	tests := []struct {
+		name      string
+		locked    bool  // Another request holds the alias lock
+		lockErr   error // Lock backend failure
+		createErr error // Error from the INSERT
+		wantErr   error
+	}{
+		{name: "lock acquired", locked: false},
+		{name: "alias being created by another request", locked: true, wantErr: domain.ErrCustomAliasTaken},
+		{name: "lost the race at the database", createErr: domain.ErrShortCodeTaken, wantErr: domain.ErrCustomAliasTaken},
+		{name: "lock backend down falls back to the constraint", lockErr: errors.New("redis down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			mockURLRepo := new(MockURLRepository)
+			mockLocker := new(MockLocker)
+
+			service := NewURLService(mockURLRepo, new(MockClickRepository)).WithAliasLocks(mockLocker)
+
+			unlocked := false
+			unlock := func() { unlocked = true }
+			acquired := !tt.locked && tt.lockErr == nil
+			mockLocker.On("TryLock", ctx, "alias:mylink", aliasLockTTL).Return(unlock, acquired, tt.lockErr)
+			mockURLRepo.On("ExistsCustomAlias", ctx, "mylink").Return(false, nil)
+			mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(tt.createErr)
+
+			// Act
+			url, err := service.CreateShortURL(ctx, "https://example.com", "mylink", "user1", 0)
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, url)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "mylink", url.ShortCode)
+			}
+			if tt.locked {
+				mockURLRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			}
+			assert.Equal(t, acquired, unlocked, "the lock is released exactly when it was taken")
+		})
+	}
+}
+
 func TestCreateShortURL_WithExpiration(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockURLRepo := new(MockURLRepository)
 	mockClickRepo := new(MockClickRepository)
-	mockCache := new(MockCache)
 
-	service := NewURLService(mockURLRepo, mockClickRepo, mockCache)
+	service := NewURLService(mockURLRepo, mockClickRepo)
 
 	mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
 	mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
-	mockCache.On("SetURL", ctx, mock.Anything, mock.AnythingOfType("*domain.URL")).Return(nil)
 
 	// Act
 	url, err := service.CreateShortURL(ctx, "https://example.com", "", "user1", 24*time.Hour)
@@ -193,44 +347,84 @@ func TestCreateShortURL_WithExpiration(t *testing.T) {
 	assert.True(t, url.ExpiresAt.After(time.Now()))
 }
 
-func TestGetURL_CacheHit(t *testing.T) {
+func TestCreateShortURL_CodePoolChecksCodesInBatches(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockURLRepo := new(MockURLRepository)
-	mockClickRepo := new(MockClickRepository)
-	mockCache := new(MockCache)
-
-	service := NewURLService(mockURLRepo, mockClickRepo, mockCache)
+	service := NewURLService(mockURLRepo, new(MockClickRepository)).WithCodePool(4)
+
+	var candidates []string
+	taken := make(map[string]bool) // Filled once the candidates are known
+	mockURLRepo.On("FindTakenCodes", ctx, mock.MatchedBy(func(codes []string) bool {
+		return len(codes) == 4
+	})).Run(func(args mock.Arguments) {
+		candidates = append([]string(nil), args.Get(1).([]string)...)
+		taken[candidates[1]] = true // The second candidate is taken
+	}).Return(taken, nil).Once()
+	mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
 
-	cachedURL := &domain.URL{
-		ID:          "123",
-		ShortCode:   "abc123",
-		OriginalURL: "https://example.com",
-		IsActive:    true,
+	// Act
+	var codes []string
+	for i := 0; i < 3; i++ {
+		url, err := service.CreateShortURL(ctx, "https://example.com", "", "user1", 0)
+		require.NoError(t, err)
+		codes = append(codes, url.ShortCode)
 	}
 
-	// Mock: cache hit
-	mockCache.On("GetURL", ctx, "abc123").Return(cachedURL, nil)
+	// Assert - three links, one query, and the taken code was skipped
+	assert.Equal(t, []string{candidates[0], candidates[2], candidates[3]}, codes)
+	mockURLRepo.AssertNotCalled(t, "ExistsShortCode", mock.Anything, mock.Anything)
+	mockURLRepo.AssertExpectations(t)
+}
+
+func TestCreateShortURL_RetriesCodeTakenAfterTheCheck(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+	mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
+	// Someone took the first code between the check and the INSERT
+	mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(domain.ErrShortCodeTaken).Once()
+	mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil).Once()
 
 	// Act
-	url, err := service.GetURL(ctx, "abc123")
+	url, err := service.CreateShortURL(ctx, "https://example.com", "", "user1", 0)
 
 	// Assert
 	require.NoError(t, err)
-	assert.Equal(t, cachedURL, url)
-	mockCache.AssertExpectations(t)
-	// Database should NOT be called (cache hit)
+	assert.NotEmpty(t, url.ShortCode)
+	mockURLRepo.AssertNumberOfCalls(t, "ExistsShortCode", 2)
+	mockURLRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestGetURL_DatabaseUnavailable_ReturnsError(t *testing.T) {
+	// Arrange: every database call fails
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	mockClickRepo := new(MockClickRepository)
+	brokenRepo := faults.NewURLRepository(mockURLRepo, faults.NewInjector(1, 0))
+
+	service := NewURLService(brokenRepo, mockClickRepo)
+
+	// Act
+	url, err := service.GetURL(ctx, "abc123")
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, url)
 	mockURLRepo.AssertNotCalled(t, "GetByShortCode")
 }
 
-func TestGetURL_CacheMiss_DatabaseHit(t *testing.T) {
-	// Arrange
+func TestGetURL_RetriesTransientDatabaseErrors(t *testing.T) {
+	// Arrange: the first lookup hits a transient failure, the retry succeeds
 	ctx := context.Background()
 	mockURLRepo := new(MockURLRepository)
 	mockClickRepo := new(MockClickRepository)
-	mockCache := new(MockCache)
+	policy := resilience.Policy{MaxRetries: 2}
+	repo := resilience.NewURLRepository(mockURLRepo, policy, policy)
 
-	service := NewURLService(mockURLRepo, mockClickRepo, mockCache)
+	service := NewURLService(repo, mockClickRepo)
 
 	dbURL := &domain.URL{
 		ID:          "123",
@@ -238,11 +432,8 @@ func TestGetURL_CacheMiss_DatabaseHit(t *testing.T) {
 		OriginalURL: "https://example.com",
 		IsActive:    true,
 	}
-
-	// Mock: cache miss, database hit
-	mockCache.On("GetURL", ctx, "abc123").Return(nil, nil)
-	mockURLRepo.On("GetByShortCode", ctx, "abc123").Return(dbURL, nil)
-	mockCache.On("SetURL", ctx, "abc123", dbURL).Return(nil)
+	mockURLRepo.On("GetByShortCode", mock.Anything, "abc123").Return(nil, &faults.Error{Op: "test"}).Once()
+	mockURLRepo.On("GetByShortCode", mock.Anything, "abc123").Return(dbURL, nil).Once()
 
 	// Act
 	url, err := service.GetURL(ctx, "abc123")
@@ -250,8 +441,30 @@ func TestGetURL_CacheMiss_DatabaseHit(t *testing.T) {
 	// Assert
 	require.NoError(t, err)
 	assert.Equal(t, dbURL, url)
-	mockCache.AssertExpectations(t)
-	mockURLRepo.AssertExpectations(t)
+	mockURLRepo.AssertNumberOfCalls(t, "GetByShortCode", 2)
+}
+
+func TestRecordClick_DoesNotRetryAmbiguousWrites(t *testing.T) {
+	// Arrange: the connection drops during IncrementClicks - the click may
+	// already be counted, so retrying could count it twice
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	mockClickRepo := new(MockClickRepository)
+	policy := resilience.Policy{MaxRetries: 2}
+	repo := resilience.NewURLRepository(mockURLRepo, policy, policy)
+
+	service := NewURLService(repo, mockClickRepo)
+
+	url := &domain.URL{ID: "123", ShortCode: "abc123", IsActive: true}
+	mockURLRepo.On("GetByShortCode", mock.Anything, "abc123").Return(url, nil)
+	mockURLRepo.On("IncrementClicks", mock.Anything, "abc123").Return(io.ErrUnexpectedEOF)
+
+	// Act
+	err := service.RecordClick(ctx, domain.ClickContext{ShortCode: "abc123", IPAddress: "127.0.0.1", UserAgent: "test-agent"})
+
+	// Assert
+	assert.Error(t, err)
+	mockURLRepo.AssertNumberOfCalls(t, "IncrementClicks", 1)
 }
 
 func TestGetURL_ExpiredURL(t *testing.T) {
@@ -259,9 +472,8 @@ func TestGetURL_ExpiredURL(t *testing.T) {
 	ctx := context.Background()
 	mockURLRepo := new(MockURLRepository)
 	mockClickRepo := new(MockClickRepository)
-	mockCache := new(MockCache)
 
-	service := NewURLService(mockURLRepo, mockClickRepo, mockCache)
+	service := NewURLService(mockURLRepo, mockClickRepo)
 
 	expiredTime := time.Now().Add(-1 * time.Hour)
 	expiredURL := &domain.URL{
@@ -272,7 +484,7 @@ func TestGetURL_ExpiredURL(t *testing.T) {
 		ExpiresAt:   &expiredTime,
 	}
 
-	mockCache.On("GetURL", ctx, "abc123").Return(expiredURL, nil)
+	mockURLRepo.On("GetByShortCode", ctx, "abc123").Return(expiredURL, nil)
 
 	// Act
 	url, err := service.GetURL(ctx, "abc123")
@@ -283,14 +495,39 @@ func TestGetURL_ExpiredURL(t *testing.T) {
 	assert.Contains(t, err.Error(), "expired")
 }
 
+func TestGetURL_ClickLimitReached(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+
+	mockURLRepo := new(MockURLRepository)
+
+	service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+	usedUp := (&domain.URL{
+		ID:          "123",
+		ShortCode:   "abc123",
+		OriginalURL: "https://example.com",
+		IsActive:    true,
+		Clicks:      10,
+	}).WithMaxClicks(10)
+
+	mockURLRepo.On("GetByShortCode", ctx, "abc123").Return(usedUp, nil)
+
+	// Act
+	url, err := service.GetURL(ctx, "abc123")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrClickLimitReached)
+	assert.Nil(t, url)
+}
+
 func TestRecordClick_Success(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	mockURLRepo := new(MockURLRepository)
 	mockClickRepo := new(MockClickRepository)
-	mockCache := new(MockCache)
 
-	service := NewURLService(mockURLRepo, mockClickRepo, mockCache)
+	service := NewURLService(mockURLRepo, mockClickRepo)
 
 	url := &domain.URL{
 		ID:          "123",
@@ -298,12 +535,17 @@ func TestRecordClick_Success(t *testing.T) {
 		OriginalURL: "https://example.com",
 	}
 
-	mockURLRepo.On("GetByShortCode", ctx, "abc123").Return(url, nil)
+	mockURLRepo.On("GetByShortCode", consistentRead, "abc123").Return(url, nil)
 	mockURLRepo.On("IncrementClicks", ctx, "abc123").Return(nil)
 	mockClickRepo.On("Create", ctx, mock.AnythingOfType("*domain.URLClick")).Return(nil)
 
 	// Act
-	err := service.RecordClick(ctx, "abc123", "192.168.1.1", "Mozilla/5.0", "https://google.com")
+	err := service.RecordClick(ctx, domain.ClickContext{
+		ShortCode: "abc123",
+		IPAddress: "192.168.1.1",
+		UserAgent: "Mozilla/5.0",
+		Referer:   "https://google.com",
+	})
 
 	// Assert
 	require.NoError(t, err)
@@ -311,47 +553,47 @@ func TestRecordClick_Success(t *testing.T) {
 	mockClickRepo.AssertExpectations(t)
 }
 
-// ==================== TABLE-DRIVEN TESTS ====================
+func TestRecordClick_StoresChannelAndDevice(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	mockClickRepo := new(MockClickRepository)
 
-func TestCreateShortURL_TableDriven(t *testing.T) {
+	service := NewURLService(mockURLRepo, mockClickRepo).
+		WithReferrerClassifier(referrer.NewClassifier("sho.rt"))
+
+	url := &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com"}
+	mockURLRepo.On("GetByShortCode", consistentRead, "abc123").Return(url, nil)
+	mockURLRepo.On("IncrementClicks", ctx, "abc123").Return(nil)
+	mockClickRepo.On("Create", ctx, mock.MatchedBy(func(c *domain.URLClick) bool {
+		return c.Channel == domain.ChannelInternal &&
+			c.Browser == "Safari" && c.OS == "iOS" && c.DeviceType == "mobile"
+	})).Return(nil)
+
+	// Act
+	iphone := "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1"
+	err := service.RecordClick(ctx, domain.ClickContext{
+		ShortCode: "abc123",
+		IPAddress: "192.168.1.1",
+		UserAgent: iphone,
+		Referer:   "https://sho.rt/@jane",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	mockClickRepo.AssertExpectations(t)
+}
+
+func TestRecordClick_AnalyticsOff(t *testing.T) {
 	tests := []struct {
-		name          string
-		originalURL   string
-		customAlias   string
-		aliasExists   bool
-		expectError   bool
-		errorContains string
+		name            string
+		globallyEnabled bool
+		linkOptedOut    bool
+		wantClickEvent  bool
 	}{
-		{
-			name:        "Valid URL without custom alias",
-			originalURL: "https://example.com",
-			customAlias: "",
-			aliasExists: false,
-			expectError: false,
-		},
-		{
-			name:        "Valid URL with custom alias",
-			originalURL: "https://example.com",
-			customAlias: "mylink",
-			aliasExists: false,
-			expectError: false,
-		},
-		{
-			name:          "Custom alias already taken",
-			originalURL:   "https://example.com",
-			customAlias:   "taken",
-			aliasExists:   true,
-			expectError:   true,
-			errorContains: "already exists",
-		},
-		{
-			name:          "Invalid URL",
-			originalURL:   "not-a-valid-url",
-			customAlias:   "",
-			aliasExists:   false,
-			expectError:   true,
-			errorContains: "validation failed",
-		},
+		{name: "enabled", globallyEnabled: true, wantClickEvent: true},
+		{name: "disabled globally", globallyEnabled: false},
+		{name: "disabled for the link", globallyEnabled: true, linkOptedOut: true},
 	}
 
 	for _, tt := range tests {
@@ -360,35 +602,1795 @@ func TestCreateShortURL_TableDriven(t *testing.T) {
 			ctx := context.Background()
 			mockURLRepo := new(MockURLRepository)
 			mockClickRepo := new(MockClickRepository)
-			mockCache := new(MockCache)
 
-			service := NewURLService(mockURLRepo, mockClickRepo, mockCache)
+			service := NewURLService(mockURLRepo, mockClickRepo).WithAnalytics(tt.globallyEnabled)
 
-			if tt.customAlias != "" {
-				mockURLRepo.On("ExistsCustomAlias", ctx, tt.customAlias).Return(tt.aliasExists, nil)
-			} else {
-				mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
+			url := &domain.URL{ID: "123", ShortCode: "abc123", AnalyticsDisabled: tt.linkOptedOut}
+			mockURLRepo.On("GetByShortCode", consistentRead, "abc123").Return(url, nil)
+			mockURLRepo.On("IncrementClicks", ctx, "abc123").Return(nil)
+			if tt.wantClickEvent {
+				mockClickRepo.On("Create", ctx, mock.AnythingOfType("*domain.URLClick")).Return(nil)
 			}
 
-			if !tt.aliasExists && !tt.expectError {
-				mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
-				mockCache.On("SetURL", ctx, mock.Anything, mock.AnythingOfType("*domain.URL")).Return(nil)
+			// Act
+			err := service.RecordClick(ctx, domain.ClickContext{ShortCode: "abc123", IPAddress: "192.168.1.1"})
+
+			// Assert - the counter always counts, the click event depends on the setting
+			require.NoError(t, err)
+			mockURLRepo.AssertExpectations(t)
+			mockClickRepo.AssertExpectations(t)
+			if !tt.wantClickEvent {
+				mockClickRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
 			}
+		})
+	}
+}
 
-			// Act
-			url, err := service.CreateShortURL(ctx, tt.originalURL, tt.customAlias, "user1", 0)
+func TestRecordClick_ConsentSignals(t *testing.T) {
+	tests := []struct {
+		name           string
+		honor          bool
+		consent        domain.ConsentSignal
+		wantPersonal   bool
+		wantSuppressed float64
+	}{
+		{name: "no signal", honor: true, wantPersonal: true},
+		{name: "do not track", honor: true, consent: domain.ConsentDoNotTrack, wantSuppressed: 1},
+		{name: "global privacy control", honor: true, consent: domain.ConsentGPC, wantSuppressed: 1},
+		{name: "signals ignored by the deployment", honor: false, consent: domain.ConsentDoNotTrack, wantPersonal: true},
+	}
 
-			// Assert
-			if tt.expectError {
-				assert.Error(t, err)
-				if tt.errorContains != "" {
-					assert.Contains(t, err.Error(), tt.errorContains)
-				}
-				assert.Nil(t, url)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			mockURLRepo := new(MockURLRepository)
+			mockClickRepo := new(MockClickRepository)
+
+			service := NewURLService(mockURLRepo, mockClickRepo).WithConsentSignals(tt.honor)
+
+			url := &domain.URL{ID: "123", ShortCode: "abc123"}
+			mockURLRepo.On("GetByShortCode", consistentRead, "abc123").Return(url, nil)
+			mockURLRepo.On("IncrementClicks", ctx, "abc123").Return(nil)
+
+			var stored *domain.URLClick
+			mockClickRepo.On("Create", ctx, mock.AnythingOfType("*domain.URLClick")).
+				Run(func(args mock.Arguments) { stored = args.Get(1).(*domain.URLClick) }).
+				Return(nil)
+
+			suppressedBefore := consentSuppressed()
+
+			// Act
+			err := service.RecordClick(ctx, domain.ClickContext{
+				ShortCode: "abc123",
+				IPAddress: "192.168.1.1",
+				UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0",
+				Referer:   "https://www.google.com/search?q=jane",
+				Consent:   tt.consent,
+			})
+
+			// Assert - the click is always counted and stored, only the person is left out
+			require.NoError(t, err)
+			mockURLRepo.AssertExpectations(t)
+			require.NotNil(t, stored)
+			assert.Equal(t, domain.ChannelSearch, stored.Channel)
+			assert.Equal(t, "Chrome", stored.Browser)
+			if tt.wantPersonal {
+				assert.Equal(t, "192.168.1.1", stored.IPAddress)
+				assert.NotEmpty(t, stored.UserAgent)
+				assert.NotEmpty(t, stored.Referer)
 			} else {
-				assert.NoError(t, err)
-				assert.NotNil(t, url)
+				assert.Empty(t, stored.IPAddress)
+				assert.Empty(t, stored.UserAgent)
+				assert.Empty(t, stored.Referer)
+			}
+			assert.Equal(t, suppressedBefore+tt.wantSuppressed, consentSuppressed())
+		})
+	}
+}
+
+func TestRecordClick_ReportedByEdge(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	mockClickRepo := new(MockClickRepository)
+
+	service := NewURLService(mockURLRepo, mockClickRepo).WithRegion("eu-west")
+
+	clickedAt := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+	url := &domain.URL{ID: "123", ShortCode: "abc123"}
+	mockURLRepo.On("GetByShortCode", consistentRead, "abc123").Return(url, nil)
+	mockURLRepo.On("IncrementClicks", ctx, "abc123").Return(nil)
+	mockClickRepo.On("Create", ctx, mock.MatchedBy(func(c *domain.URLClick) bool {
+		return c.ClickedAt.Equal(clickedAt) && c.Region == "fra"
+	})).Return(nil)
+
+	// Act
+	err := service.RecordClick(ctx, domain.ClickContext{ShortCode: "abc123", ClickedAt: clickedAt, Region: "fra"})
+
+	// Assert - the edge's time and location, not ours
+	require.NoError(t, err)
+	mockClickRepo.AssertExpectations(t)
+}
+
+// consentSuppressed sums the consent-suppressed click counter over both signals
+func consentSuppressed() float64 {
+	return testutil.ToFloat64(metrics.ClicksConsentSuppressedTotal.WithLabelValues(string(domain.ConsentDoNotTrack))) +
+		testutil.ToFloat64(metrics.ClicksConsentSuppressedTotal.WithLabelValues(string(domain.ConsentGPC)))
+}
+
+func TestGetURLStats_AnalyticsOffSkipsClicks(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+	mockURLRepo := new(MockURLRepository)
+	mockClickRepo := new(MockClickRepository)
+
+	service := NewURLService(mockURLRepo, mockClickRepo)
+
+	url := &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "user1", Clicks: 7, AnalyticsDisabled: true}
+	mockURLRepo.On("GetByShortCode", consistentRead, "abc123").Return(url, nil)
+
+	// Act
+	got, clicks, err := service.GetURLStats(ctx, "abc123")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), got.Clicks)
+	assert.Empty(t, clicks)
+	mockClickRepo.AssertNotCalled(t, "GetByURLID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetClickSummary(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+	mockURLRepo := new(MockURLRepository)
+	mockClickRepo := new(MockClickRepository)
+
+	service := NewURLService(mockURLRepo, mockClickRepo)
+
+	url := &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "user1"}
+	channels := map[string]int64{"search": 3, "email": 1}
+	devices := map[string]int64{"mobile": 3, "desktop": 1}
+	browsers := map[string]int64{"Safari": 2, "Chrome": 2}
+	systems := map[string]int64{"iOS": 2, "Android": 1, "unknown": 1}
+	regions := map[string]int64{"eu-west": 3, "unknown": 1}
+	mockURLRepo.On("GetByShortCode", ctx, "abc123").Return(url, nil)
+	mockClickRepo.On("CountBy", ctx, "123", domain.DimensionChannel).Return(channels, nil)
+	mockClickRepo.On("CountBy", ctx, "123", domain.DimensionDevice).Return(devices, nil)
+	mockClickRepo.On("CountBy", ctx, "123", domain.DimensionBrowser).Return(browsers, nil)
+	mockClickRepo.On("CountBy", ctx, "123", domain.DimensionOS).Return(systems, nil)
+	mockClickRepo.On("CountBy", ctx, "123", domain.DimensionRegion).Return(regions, nil)
+
+	service.now = func() time.Time { return time.Date(2026, 3, 30, 12, 0, 0, 0, time.UTC) }
+	today := time.Date(2026, 3, 30, 0, 0, 0, 0, time.UTC)
+	mockClickRepo.On("CountByPeriod", ctx, "123", domain.IntervalDay, time.UTC, today.AddDate(0, 0, -29), today.AddDate(0, 0, 1)).
+		Return([]domain.ClickBucket{{Start: today, Clicks: 4}}, nil)
+	// Comparison: 7 days up to the end of the current hour vs the 7 before
+	end := time.Date(2026, 3, 30, 13, 0, 0, 0, time.UTC)
+	mockClickRepo.On("CountByPeriod", ctx, "123", domain.IntervalHour, time.UTC, end.AddDate(0, 0, -14), end).
+		Return([]domain.ClickBucket{
+			{Start: end.AddDate(0, 0, -10), Clicks: 20},
+			{Start: end.AddDate(0, 0, -7), Clicks: 25}, // First hour of the current week
+			{Start: end.Add(-time.Hour), Clicks: 5},
+		}, nil)
+
+	// Act
+	summary, err := service.GetClickSummary(ctx, "abc123", "")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, url, summary.URL)
+	assert.Equal(t, channels, summary.Channels)
+	assert.Equal(t, devices, summary.Devices)
+	assert.Equal(t, browsers, summary.Browsers)
+	assert.Equal(t, systems, summary.OperatingSystems)
+	assert.Equal(t, regions, summary.Regions)
+	require.Len(t, summary.Daily.Buckets, domain.SummaryDays)
+	assert.Equal(t, domain.ClickBucket{Start: today, Clicks: 4}, summary.Daily.Buckets[domain.SummaryDays-1])
+	assert.Zero(t, summary.Daily.Buckets[0].Clicks)
+	require.NotNil(t, summary.Comparison)
+	assert.Equal(t, int64(20), summary.Comparison.Previous)
+	assert.Equal(t, int64(30), summary.Comparison.Current)
+	assert.InDelta(t, 0.5, *summary.Comparison.Change, 1e-9)
+	assert.Equal(t, domain.TrendRising, summary.Comparison.Trend)
+}
+
+func TestCompareClicks(t *testing.T) {
+	tests := []struct {
+		name           string
+		previous       int64
+		current        int64
+		expectedChange *float64
+		expectedTrend  domain.Trend
+	}{
+		{name: "no clicks at all", expectedTrend: domain.TrendInactive},
+		{name: "first clicks", current: 3, expectedTrend: domain.TrendNew},
+		{name: "rising", previous: 100, current: 120, expectedChange: floatPtr(0.2), expectedTrend: domain.TrendRising},
+		{name: "falling", previous: 100, current: 50, expectedChange: floatPtr(-0.5), expectedTrend: domain.TrendFalling},
+		{name: "within 10 percent", previous: 100, current: 105, expectedChange: floatPtr(0.05), expectedTrend: domain.TrendSteady},
+		{name: "big percentage, few clicks", previous: 2, current: 4, expectedChange: floatPtr(1.0), expectedTrend: domain.TrendSteady},
+		{name: "stopped", previous: 40, current: 0, expectedChange: floatPtr(-1.0), expectedTrend: domain.TrendFalling},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			comparison := domain.CompareClicks(time.Time{}, time.Time{}, time.Time{}, tt.previous, tt.current)
+
+			// Assert
+			assert.Equal(t, tt.expectedTrend, comparison.Trend)
+			if tt.expectedChange == nil {
+				assert.Nil(t, comparison.Change)
+				return
+			}
+			require.NotNil(t, comparison.Change)
+			assert.InDelta(t, *tt.expectedChange, *comparison.Change, 1e-9)
+		})
+	}
+}
+
+func TestGetClickSummary_NotOwner(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "someone-else"})
+	mockURLRepo := new(MockURLRepository)
+	mockClickRepo := new(MockClickRepository)
+
+	service := NewURLService(mockURLRepo, mockClickRepo)
+
+	url := &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "user1"}
+	mockURLRepo.On("GetByShortCode", ctx, "abc123").Return(url, nil)
+
+	// Act
+	_, err := service.GetClickSummary(ctx, "abc123", "")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	mockClickRepo.AssertNotCalled(t, "CountBy", mock.Anything, mock.Anything, mock.Anything)
+	mockClickRepo.AssertNotCalled(t, "CountByPeriod", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTraceRedirects(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      *resolver.Result
+		resolveErr  error
+		expectHops  int
+		expectError bool
+	}{
+		{
+			name: "full chain",
+			result: &resolver.Result{
+				FinalURL: "https://example.com/new",
+				Hops: []resolver.Hop{
+					{URL: "https://example.com/old", StatusCode: 301},
+					{URL: "https://example.com/new", StatusCode: 200, ContentType: "text/html"},
+				},
+			},
+			expectHops: 2,
+		},
+		{
+			name:        "broken destination keeps the hops so far",
+			result:      &resolver.Result{Hops: []resolver.Hop{{URL: "https://example.com/old", StatusCode: 302}}},
+			resolveErr:  errors.New("failed to fetch https://gone.example.com: no such host"),
+			expectHops:  1,
+			expectError: true,
+		},
+		{
+			name:        "nothing answered",
+			resolveErr:  errors.New("failed to fetch https://example.com/old: timeout"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+			mockURLRepo := new(MockURLRepository)
+			mockResolver := new(MockResolver)
+			service := NewURLService(mockURLRepo, new(MockClickRepository)).
+				WithRedirectTracer(mockResolver)
+
+			url := &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com/old", CreatedBy: "user1"}
+			mockURLRepo.On("GetByShortCode", ctx, "abc123").Return(url, nil)
+			if tt.result != nil {
+				mockResolver.On("Resolve", mock.Anything, "https://example.com/old").Return(tt.result, tt.resolveErr)
+			} else {
+				mockResolver.On("Resolve", mock.Anything, "https://example.com/old").Return(nil, tt.resolveErr)
+			}
+
+			// Act
+			trace, err := service.TraceRedirects(ctx, "abc123")
+
+			// Assert: a broken destination is an answer, not an error
+			require.NoError(t, err)
+			assert.Equal(t, url, trace.URL)
+			assert.Len(t, trace.Hops, tt.expectHops)
+			assert.Equal(t, tt.expectError, trace.Error != "")
+		})
+	}
+}
+
+func TestTraceRedirects_Rejected(t *testing.T) {
+	url := &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com", CreatedBy: "user1"}
+
+	tests := []struct {
+		name      string
+		caller    string
+		tracer    bool
+		expectErr error
+	}{
+		{name: "not the owner", caller: "someone-else", tracer: true, expectErr: domain.ErrForbidden},
+		{name: "tracing not configured", caller: "user1", expectErr: domain.ErrRedirectTraceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: tt.caller})
+			mockURLRepo := new(MockURLRepository)
+			mockResolver := new(MockResolver)
+			service := NewURLService(mockURLRepo, new(MockClickRepository))
+			if tt.tracer {
+				service.WithRedirectTracer(mockResolver)
+			}
+			mockURLRepo.On("GetByShortCode", ctx, "abc123").Return(url, nil)
+
+			// Act
+			_, err := service.TraceRedirects(ctx, "abc123")
+
+			// Assert: no outbound request was made
+			assert.ErrorIs(t, err, tt.expectErr)
+			mockResolver.AssertNotCalled(t, "Resolve", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// MockClickDeduplicator is a mock implementation of ClickDeduplicator
+type MockClickDeduplicator struct {
+	mock.Mock
+}
+
+func (m *MockClickDeduplicator) FirstSeen(ctx context.Context, key string, window time.Duration) (bool, error) {
+	args := m.Called(ctx, key, window)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestRecordClick_Dedup(t *testing.T) {
+	tests := []struct {
+		name          string
+		firstSeen     bool
+		dedupErr      error
+		expectCounted bool
+	}{
+		{name: "first click is counted", firstSeen: true, expectCounted: true},
+		{name: "repeat within the window is skipped", firstSeen: false, expectCounted: false},
+		{name: "redis error counts the click", dedupErr: errors.New("connection refused"), expectCounted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			mockURLRepo := new(MockURLRepository)
+			mockClickRepo := new(MockClickRepository)
+			dedup := new(MockClickDeduplicator)
+
+			service := NewURLService(mockURLRepo, mockClickRepo).
+				WithClickDedup(dedup, 10*time.Second)
+
+			url := &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com"}
+			dedup.On("FirstSeen", ctx, clickDedupKey("abc123", "192.168.1.1", "Mozilla/5.0"), 10*time.Second).
+				Return(tt.firstSeen, tt.dedupErr)
+			mockURLRepo.On("GetByShortCode", consistentRead, "abc123").Return(url, nil).Maybe()
+			mockURLRepo.On("IncrementClicks", ctx, "abc123").Return(nil).Maybe()
+			mockClickRepo.On("Create", ctx, mock.AnythingOfType("*domain.URLClick")).Return(nil).Maybe()
+
+			// Act
+			err := service.RecordClick(ctx, domain.ClickContext{ShortCode: "abc123", IPAddress: "192.168.1.1:54321", UserAgent: "Mozilla/5.0"})
+
+			// Assert
+			require.NoError(t, err)
+			dedup.AssertExpectations(t)
+			if tt.expectCounted {
+				mockURLRepo.AssertCalled(t, "IncrementClicks", ctx, "abc123")
+				mockClickRepo.AssertCalled(t, "Create", ctx, mock.AnythingOfType("*domain.URLClick"))
+			} else {
+				mockURLRepo.AssertNotCalled(t, "IncrementClicks", mock.Anything, mock.Anything)
+				mockClickRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestClickDedupKey(t *testing.T) {
+	base := clickDedupKey("abc123", "192.168.1.1", "Mozilla/5.0")
+
+	// A new connection from the same visitor is still the same visitor
+	assert.Equal(t, base, clickDedupKey("abc123", "192.168.1.1:54321", "Mozilla/5.0"))
+	assert.Equal(t, clickDedupKey("abc123", "2001:db8::1", "Mozilla/5.0"), clickDedupKey("abc123", "[2001:db8::1]:443", "Mozilla/5.0"))
+
+	// Any other link, IP or browser is a different click
+	assert.NotEqual(t, base, clickDedupKey("xyz789", "192.168.1.1", "Mozilla/5.0"))
+	assert.NotEqual(t, base, clickDedupKey("abc123", "192.168.1.2", "Mozilla/5.0"))
+	assert.NotEqual(t, base, clickDedupKey("abc123", "192.168.1.1", "curl/8.4.0"))
+	assert.NotContains(t, base, "192.168.1.1")
+}
+
+func TestCreateShortURL_StoresResolvedURL(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	mockResolver := new(MockResolver)
+
+	service := NewURLService(mockURLRepo, new(MockClickRepository)).
+		WithResolver(mockResolver, true)
+
+	mockResolver.On("Resolve", ctx, "http://example.com").Return(&resolver.Result{
+		FinalURL: "https://www.example.com/",
+	}, nil)
+	mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
+	mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
+
+	// Act
+	url, err := service.CreateShortURL(ctx, "http://example.com", "", "user1", 0)
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, url.ResolvedURL)
+	assert.Equal(t, "https://www.example.com/", *url.ResolvedURL)
+}
+
+func TestCreateShortURL_RejectsNestedShortener(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	mockResolver := new(MockResolver)
+
+	service := NewURLService(mockURLRepo, new(MockClickRepository)).
+		WithResolver(mockResolver, true)
+
+	mockResolver.On("Resolve", ctx, "https://bit.ly/abc").Return(&resolver.Result{
+		FinalURL:     "https://phishing.example",
+		ViaShortener: true,
+	}, nil)
+	mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
+
+	// Act
+	url, err := service.CreateShortURL(ctx, "https://bit.ly/abc", "", "user1", 0)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrRedirectorURL)
+	assert.Nil(t, url)
+	mockURLRepo.AssertNotCalled(t, "Create")
+}
+
+func TestCreateShortURL_RedirectorChecks(t *testing.T) {
+	tests := []struct {
+		name         string
+		destination  string
+		result       *resolver.Result
+		resolveErr   error
+		reject       bool
+		wantErr      error
+		wantResolved *string
+		wantFetch    bool
+	}{
+		{
+			name:        "known shortener is rejected without fetching it",
+			destination: "https://bit.ly/abc",
+			reject:      true,
+			wantErr:     domain.ErrRedirectorURL,
+		},
+		{
+			name:        "shortener reached by a failing chain is rejected",
+			destination: "https://example.com/go",
+			result:      &resolver.Result{ViaShortener: true},
+			resolveErr:  errors.New("failed to fetch https://tinyurl.com/x: i/o timeout"),
+			reject:      true,
+			wantErr:     domain.ErrRedirectorURL,
+			wantFetch:   true,
+		},
+		{
+			name:        "truncated chain is rejected",
+			destination: "https://example.com/go",
+			result:      &resolver.Result{FinalURL: "https://hop5.example/", Truncated: true},
+			reject:      true,
+			wantErr:     domain.ErrRedirectorURL,
+			wantFetch:   true,
+		},
+		{
+			name:        "truncated chain stores no resolved URL when allowed",
+			destination: "https://example.com/go",
+			result:      &resolver.Result{FinalURL: "https://hop5.example/", Truncated: true},
+			wantFetch:   true,
+		},
+		{
+			name:        "unreachable destination is allowed",
+			destination: "https://example.com/go",
+			result:      &resolver.Result{},
+			resolveErr:  errors.New("connection refused"),
+			reject:      true,
+			wantFetch:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			mockURLRepo := new(MockURLRepository)
+			mockResolver := new(MockResolver)
+			service := NewURLService(mockURLRepo, new(MockClickRepository)).
+				WithResolver(mockResolver, tt.reject)
+
+			mockResolver.On("Resolve", ctx, tt.destination).Return(tt.result, tt.resolveErr)
+			mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
+			mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
+
+			// Act
+			url, err := service.CreateShortURL(ctx, tt.destination, "", "user1", 0)
+
+			// Assert
+			if tt.wantFetch {
+				mockResolver.AssertCalled(t, "Resolve", ctx, tt.destination)
+			} else {
+				mockResolver.AssertNotCalled(t, "Resolve", mock.Anything, mock.Anything)
+			}
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockURLRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantResolved, url.ResolvedURL)
+		})
+	}
+}
+
+// MockMetadataFetcher is a mock implementation of MetadataFetcher
+type MockMetadataFetcher struct {
+	mock.Mock
+}
+
+func (m *MockMetadataFetcher) Fetch(ctx context.Context, rawURL string) (*domain.LinkMetadata, error) {
+	args := m.Called(ctx, rawURL)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LinkMetadata), args.Error(1)
+}
+
+func TestCreateShortURL_FetchesMetadataInBackground(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	fetcher := new(MockMetadataFetcher)
+	service := NewURLService(mockURLRepo, new(MockClickRepository)).WithMetadata(fetcher, 2)
+
+	meta := &domain.LinkMetadata{Title: "Example Domain", FetchedAt: time.Now()}
+	stored := make(chan struct{})
+	mockURLRepo.On("ExistsCustomAlias", ctx, "mylink").Return(false, nil)
+	mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).
+		Run(func(args mock.Arguments) { args.Get(1).(*domain.URL).ID = "url-1" }).
+		Return(nil)
+	fetcher.On("Fetch", mock.Anything, "https://example.com").Return(meta, nil)
+	mockURLRepo.On("SetMetadata", mock.Anything, "url-1", meta).
+		Run(func(mock.Arguments) { close(stored) }).
+		Return(nil)
+
+	// Act
+	url, err := service.CreateShortURL(ctx, "https://example.com", "mylink", "user1", 0)
+
+	// Assert: creation doesn't wait for the fetch
+	require.NoError(t, err)
+	assert.Nil(t, url.Metadata)
+
+	select {
+	case <-stored:
+	case <-time.After(time.Second):
+		t.Fatal("metadata was not stored")
+	}
+	fetcher.AssertExpectations(t)
+	mockURLRepo.AssertExpectations(t)
+}
+
+func TestCreateShortURL_MetadataFailureIsIgnored(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	fetcher := new(MockMetadataFetcher)
+	service := NewURLService(mockURLRepo, new(MockClickRepository)).WithMetadata(fetcher, 1)
+
+	fetched := make(chan struct{})
+	mockURLRepo.On("ExistsCustomAlias", ctx, "mylink").Return(false, nil)
+	mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
+	fetcher.On("Fetch", mock.Anything, "https://example.com").
+		Run(func(mock.Arguments) { close(fetched) }).
+		Return(nil, errors.New("timeout"))
+
+	// Act
+	_, err := service.CreateShortURL(ctx, "https://example.com", "mylink", "user1", 0)
+
+	// Assert
+	require.NoError(t, err)
+	select {
+	case <-fetched:
+	case <-time.After(time.Second):
+		t.Fatal("metadata was not fetched")
+	}
+	mockURLRepo.AssertNotCalled(t, "SetMetadata", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// MockEdgePurger is a mock implementation of EdgePurger
+type MockEdgePurger struct {
+	mock.Mock
+}
+
+func (m *MockEdgePurger) PurgeLinks(ctx context.Context, urls []*domain.URL) error {
+	args := m.Called(ctx, urls)
+	return args.Error(0)
+}
+
+func TestDeleteURL_PurgesEdge(t *testing.T) {
+	tests := []struct {
+		name     string
+		purgeErr error
+	}{
+		{name: "purged"},
+		{name: "CDN API down - delete still succeeds", purgeErr: assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "admin", Admin: true})
+			mockURLRepo := new(MockURLRepository)
+			edge := new(MockEdgePurger)
+
+			service := NewURLService(mockURLRepo, new(MockClickRepository)).WithEdgePurger(edge)
+
+			url := &domain.URL{ID: "123", ShortCode: "abc123", IsActive: true}
+			mockURLRepo.On("GetByID", ctx, "123").Return(url, nil)
+			mockURLRepo.On("Delete", ctx, "123").Return(nil)
+			edge.On("PurgeLinks", ctx, []*domain.URL{url}).Return(tt.purgeErr)
+
+			// Act
+			err := service.DeleteURL(ctx, "123")
+
+			// Assert
+			require.NoError(t, err)
+			edge.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteURL_PublishesEvent(t *testing.T) {
+	tests := []struct {
+		name          string
+		purge         bool
+		wantPermanent bool
+	}{
+		{name: "soft delete"},
+		{name: "purge", purge: true, wantPermanent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "alice"})
+			mockURLRepo := new(MockURLRepository)
+
+			service := NewURLService(mockURLRepo, new(MockClickRepository))
+			var published []events.URLDeleted
+			events.Subscribe(service.Events(), func(ctx context.Context, e events.URLDeleted) {
+				published = append(published, e)
+			})
+
+			url := &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "alice", IsActive: true}
+			mockURLRepo.On("GetByID", ctx, "123").Return(url, nil)
+			mockURLRepo.On("Delete", ctx, "123").Return(nil)
+			mockURLRepo.On("Purge", ctx, "123").Return(int64(0), nil)
+
+			// Act
+			var err error
+			if tt.purge {
+				_, err = service.PurgeURL(ctx, "123")
+			} else {
+				err = service.DeleteURL(ctx, "123")
+			}
+
+			// Assert
+			require.NoError(t, err)
+			require.Len(t, published, 1)
+			assert.Equal(t, url, published[0].URL)
+			assert.Equal(t, "alice", published[0].Actor)
+			assert.Equal(t, tt.wantPermanent, published[0].Permanent)
+		})
+	}
+}
+
+func TestRestoreURL_Success(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "admin", Admin: true})
+	mockURLRepo := new(MockURLRepository)
+
+	service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+	restored := &domain.URL{ID: "123", ShortCode: "abc123", IsActive: true}
+	mockURLRepo.On("Restore", ctx, "123").Return(nil)
+	mockURLRepo.On("GetByID", ctx, "123").Return(restored, nil)
+
+	// Act
+	url, err := service.RestoreURL(ctx, "123")
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, url.IsActive)
+	mockURLRepo.AssertExpectations(t)
+}
+
+func TestSetPreview_SavesCard(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+	mockURLRepo := new(MockURLRepository)
+
+	service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+	url := &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "user1", IsActive: true}
+	mockURLRepo.On("GetByID", ctx, "123").Return(url, nil)
+	mockURLRepo.On("Update", ctx, mock.MatchedBy(func(u *domain.URL) bool {
+		return u.Preview != nil && u.Preview.Title == "Spring Sale"
+	})).Return(nil)
+
+	// Act
+	updated, err := service.SetPreview(ctx, "123", &domain.PreviewCard{Title: "  Spring Sale  "})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Spring Sale", updated.Preview.Title)
+	mockURLRepo.AssertExpectations(t)
+}
+
+func TestSetPreview_Rejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		card     *domain.PreviewCard
+		expected error
+	}{
+		{
+			name:     "empty card",
+			ctx:      auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"}),
+			card:     &domain.PreviewCard{Title: "   "},
+			expected: domain.ErrInvalidPreview,
+		},
+		{
+			name:     "image is not an http URL",
+			ctx:      auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"}),
+			card:     &domain.PreviewCard{ImageURL: "javascript:alert(1)"},
+			expected: domain.ErrInvalidPreview,
+		},
+		{
+			name:     "not the owner",
+			ctx:      auth.WithPrincipal(context.Background(), &auth.Principal{ID: "someone-else"}),
+			card:     &domain.PreviewCard{Title: "Spring Sale"},
+			expected: domain.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockURLRepo := new(MockURLRepository)
+			service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+			url := &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "user1", IsActive: true}
+			mockURLRepo.On("GetByID", mock.Anything, "123").Return(url, nil).Maybe()
+
+			// Act
+			_, err := service.SetPreview(tt.ctx, "123", tt.card)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expected)
+			mockURLRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSetNotes_SavesNotes(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+	mockURLRepo := new(MockURLRepository)
+	mockEdge := new(MockEdgePurger)
+
+	service := NewURLService(mockURLRepo, new(MockClickRepository)).WithEdgePurger(mockEdge)
+
+	url := &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "user1", IsActive: true}
+	mockURLRepo.On("GetByID", ctx, "123").Return(url, nil)
+	mockURLRepo.On("Update", ctx, mock.MatchedBy(func(u *domain.URL) bool {
+		return u.Description == "Spring sale" && u.CustomMetadata["ticket"] == "MKT-142"
+	})).Return(nil)
+
+	// Act
+	updated, err := service.SetNotes(ctx, "123", "  Spring sale ", map[string]string{"ticket": "MKT-142"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Spring sale", updated.Description)
+	mockURLRepo.AssertExpectations(t)
+	mockEdge.AssertNotCalled(t, "PurgeLinks", mock.Anything, mock.Anything)
+}
+
+func TestSetNotes_Rejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		caller      string
+		description string
+		metadata    map[string]string
+		expected    error
+	}{
+		{name: "description too long", caller: "user1", description: strings.Repeat("a", domain.MaxDescriptionLength+1), expected: domain.ErrInvalidDescription},
+		{name: "invalid metadata key", caller: "user1", metadata: map[string]string{"cost center": "42"}, expected: domain.ErrInvalidCustomMetadata},
+		{name: "not the owner", caller: "someone-else", description: "Spring sale", expected: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: tt.caller})
+			mockURLRepo := new(MockURLRepository)
+			service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+			url := &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "user1", IsActive: true}
+			mockURLRepo.On("GetByID", ctx, "123").Return(url, nil)
+
+			// Act
+			_, err := service.SetNotes(ctx, "123", tt.description, tt.metadata)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expected)
+			mockURLRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSetVisibility(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		expectErr error
+	}{
+		{name: "owner", principal: teamOwner},
+		{name: "editor", principal: teamEditor},
+		{name: "viewer", principal: teamViewer, expectErr: domain.ErrForbidden},
+		{name: "other workspace", principal: &auth.Principal{ID: "team2"}, expectErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			mockURLRepo := new(MockURLRepository)
+			mockEdge := new(MockEdgePurger)
+			service := NewURLService(mockURLRepo, new(MockClickRepository)).WithEdgePurger(mockEdge)
+
+			url := &domain.URL{ID: "123", ShortCode: "launch", OriginalURL: "https://example.com/launch", CreatedBy: "team1", IsActive: true}
+			mockURLRepo.On("GetByID", ctx, "123").Return(url, nil)
+			mockURLRepo.On("Update", ctx, mock.MatchedBy(func(u *domain.URL) bool {
+				return u.Visibility == domain.VisibilitySensitive
+			})).Return(nil)
+
+			// Act
+			updated, err := service.SetVisibility(ctx, "123", domain.VisibilitySensitive)
+
+			// Assert: the redirect doesn't change, so the CDN keeps its copy
+			mockEdge.AssertNotCalled(t, "PurgeLinks", mock.Anything, mock.Anything)
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				mockURLRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, domain.VisibilitySensitive, updated.Visibility)
+		})
+	}
+}
+
+func TestGetURLStats_SensitiveLink(t *testing.T) {
+	tests := []struct {
+		name            string
+		principal       *auth.Principal
+		visibility      domain.LinkVisibility
+		seesDestination bool
+	}{
+		{name: "owner", principal: teamOwner, visibility: domain.VisibilitySensitive, seesDestination: true},
+		{name: "editor", principal: teamEditor, visibility: domain.VisibilitySensitive, seesDestination: true},
+		{name: "contributor", principal: teamContributor, visibility: domain.VisibilitySensitive, seesDestination: true},
+		{name: "admin", principal: &auth.Principal{ID: "admin", Admin: true}, visibility: domain.VisibilitySensitive, seesDestination: true},
+		{name: "viewer", principal: teamViewer, visibility: domain.VisibilitySensitive},
+		{name: "viewer on standard link", principal: teamViewer, visibility: domain.VisibilityStandard, seesDestination: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			mockURLRepo := new(MockURLRepository)
+			service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+			stored := &domain.URL{
+				ID:                "123",
+				ShortCode:         "launch",
+				OriginalURL:       "https://example.com/launch",
+				LanguageTargets:   map[string]string{"fr": "https://example.com/fr/launch"},
+				CreatedBy:         "team1",
+				Clicks:            42,
+				IsActive:          true,
+				AnalyticsDisabled: true,
+				Visibility:        tt.visibility,
+			}
+			mockURLRepo.On("GetByShortCode", consistentRead, "launch").Return(stored, nil)
+
+			// Act
+			got, _, err := service.GetURLStats(ctx, "launch")
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, int64(42), got.Clicks, "everyone sees the stats")
+			if tt.seesDestination {
+				assert.Equal(t, "https://example.com/launch", got.OriginalURL)
+				assert.NotEmpty(t, got.LanguageTargets)
+			} else {
+				assert.Empty(t, got.OriginalURL)
+				assert.Empty(t, got.LanguageTargets)
+			}
+			assert.Equal(t, "https://example.com/launch", stored.OriginalURL, "the stored (maybe cached) link is never changed")
+		})
+	}
+}
+
+func TestTraceRedirects_SensitiveLink(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), teamViewer)
+	mockURLRepo := new(MockURLRepository)
+	mockResolver := new(MockResolver)
+	service := NewURLService(mockURLRepo, new(MockClickRepository)).WithRedirectTracer(mockResolver)
+
+	url := &domain.URL{ID: "123", ShortCode: "launch", OriginalURL: "https://example.com/launch", CreatedBy: "team1", Visibility: domain.VisibilitySensitive}
+	mockURLRepo.On("GetByShortCode", ctx, "launch").Return(url, nil)
+
+	// Act
+	_, err := service.TraceRedirects(ctx, "launch")
+
+	// Assert: every hop would give the destination away
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	mockResolver.AssertNotCalled(t, "Resolve", mock.Anything, mock.Anything)
+}
+
+func TestCreateShortURL_Viewer(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), teamViewer)
+	mockURLRepo := new(MockURLRepository)
+	service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+	// Act
+	_, err := service.CreateShortURL(ctx, "https://example.com/launch", "launch", "team1", 0)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	mockURLRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCloneURL(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+	mockURLRepo := new(MockURLRepository)
+
+	service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+	createdAt := time.Now().Add(-48 * time.Hour)
+	expiresAt := createdAt.Add(7 * 24 * time.Hour) // One week lifetime
+	maxClicks := int64(100)
+	source := &domain.URL{
+		ID:              "123",
+		ShortCode:       "spring",
+		OriginalURL:     "https://example.com/spring",
+		CreatedBy:       "user1",
+		CreatedAt:       createdAt,
+		ExpiresAt:       &expiresAt,
+		MaxClicks:       &maxClicks,
+		Clicks:          42,
+		IsActive:        true,
+		LanguageTargets: map[string]string{"fr": "https://example.com/fr/spring"},
+		Preview:         &domain.PreviewCard{Title: "Spring Sale"},
+	}
+	mockURLRepo.On("GetByID", ctx, "123").Return(source, nil)
+	mockURLRepo.On("ExistsCustomAlias", ctx, "summer").Return(false, nil)
+	var created *domain.URL
+	mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).
+		Run(func(args mock.Arguments) { created = args.Get(1).(*domain.URL) }).
+		Return(nil)
+
+	// Act
+	url, err := service.CloneURL(ctx, "123", "https://example.com/summer", "summer")
+
+	// Assert
+	require.NoError(t, err)
+	require.Same(t, created, url)
+	assert.Equal(t, "https://example.com/summer", url.OriginalURL)
+	assert.Equal(t, "summer", url.ShortCode)
+	assert.Equal(t, int64(0), url.Clicks, "clicks are not copied")
+	assert.Equal(t, maxClicks, *url.MaxClicks)
+	assert.Equal(t, source.LanguageTargets, url.LanguageTargets)
+	assert.Equal(t, "Spring Sale", url.Preview.Title)
+	// The lifetime is copied, not the date: a week from now
+	require.NotNil(t, url.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(7*24*time.Hour), *url.ExpiresAt, time.Minute)
+	mockURLRepo.AssertExpectations(t)
+}
+
+func TestCloneURL_NotOwner(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "someone-else"})
+	mockURLRepo := new(MockURLRepository)
+	service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+	source := &domain.URL{ID: "123", ShortCode: "spring", CreatedBy: "user1", IsActive: true}
+	mockURLRepo.On("GetByID", ctx, "123").Return(source, nil)
+
+	// Act
+	_, err := service.CloneURL(ctx, "123", "https://example.com/summer", "")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	mockURLRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestSignURL(t *testing.T) {
+	secret := "s3cret"
+	now := time.Date(2026, 3, 1, 12, 0, 0, 500, time.UTC)
+	nextWeek := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		url       *domain.URL
+		expiresIn time.Duration
+		expected  error
+		expires   *time.Time
+	}{
+		{name: "never expires", principal: &auth.Principal{ID: "user1"}, url: &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "user1", SigningSecret: &secret}},
+		{name: "expires in a week", principal: &auth.Principal{ID: "user1"}, url: &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "user1", SigningSecret: &secret}, expiresIn: 7 * 24 * time.Hour, expires: &nextWeek},
+		{name: "not a signed link", principal: &auth.Principal{ID: "user1"}, url: &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "user1"}, expected: domain.ErrLinkNotSigned},
+		{name: "someone else's link", principal: &auth.Principal{ID: "user2"}, url: &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "user1", SigningSecret: &secret}, expected: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			mockURLRepo := new(MockURLRepository)
+			service := NewURLService(mockURLRepo, new(MockClickRepository))
+			service.now = func() time.Time { return now }
+			mockURLRepo.On("GetByID", ctx, "123").Return(tt.url, nil)
+
+			// Act
+			signed, err := service.SignURL(ctx, "123", tt.expiresIn)
+
+			// Assert
+			if tt.expected != nil {
+				assert.ErrorIs(t, err, tt.expected)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expires, signed.ExpiresAt)
+			assert.NoError(t, tt.url.VerifySignature(signed.Query, now), "the signed query must open the link")
+		})
+	}
+}
+
+func TestUseOnce(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         *domain.URL
+		won         bool
+		repoErr     error
+		expected    error
+		expectClaim bool
+	}{
+		{name: "first visitor", url: &domain.URL{ShortCode: "reset1", SingleUse: true}, won: true, expectClaim: true},
+		{name: "someone got there first", url: &domain.URL{ShortCode: "reset1", SingleUse: true}, expected: domain.ErrLinkUsed, expectClaim: true},
+		{name: "database down", url: &domain.URL{ShortCode: "reset1", SingleUse: true}, repoErr: assert.AnError, expected: assert.AnError, expectClaim: true},
+		{name: "ordinary link", url: &domain.URL{ShortCode: "abc123"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			mockURLRepo := new(MockURLRepository)
+			service := NewURLService(mockURLRepo, new(MockClickRepository))
+			if tt.expectClaim {
+				mockURLRepo.On("MarkUsed", ctx, "reset1").Return(tt.won, tt.repoErr)
+			}
+
+			// Act
+			err := service.UseOnce(ctx, tt.url)
+
+			// Assert
+			if tt.expected != nil {
+				assert.ErrorIs(t, err, tt.expected)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockURLRepo.AssertExpectations(t)
+			if tt.expectClaim && tt.repoErr == nil {
+			} else {
+			}
+		})
+	}
+}
+
+func TestGetURL_UsedSingleUseLink(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+	usedAt := time.Now().Add(-time.Minute)
+	url := &domain.URL{ShortCode: "reset1", IsActive: true, SingleUse: true, UsedAt: &usedAt}
+	mockURLRepo.On("GetByShortCode", ctx, "reset1").Return(url, nil)
+
+	// Act
+	_, err := service.GetURL(ctx, "reset1")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrLinkUsed)
+}
+
+func TestPurgeURL_RemovesClicks(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "admin", Admin: true})
+	mockURLRepo := new(MockURLRepository)
+
+	service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+	url := (&domain.URL{ID: "123", ShortCode: "mylink"}).WithCustomAlias("mylink")
+	mockURLRepo.On("GetByID", ctx, "123").Return(url, nil)
+	mockURLRepo.On("Purge", ctx, "123").Return(int64(42), nil)
+
+	// Act
+	purged, err := service.PurgeURL(ctx, "123")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), purged)
+	mockURLRepo.AssertExpectations(t)
+}
+
+func TestOwnershipChecks(t *testing.T) {
+	alice := &auth.Principal{ID: "alice"}
+	bob := &auth.Principal{ID: "bob"}
+	admin := &auth.Principal{ID: "admin", Admin: true}
+
+	tests := []struct {
+		name       string
+		createdBy  string
+		caller     *auth.Principal
+		wantView   error
+		wantManage error
+	}{
+		{name: "owner", createdBy: "alice", caller: alice},
+		{name: "another user", createdBy: "alice", caller: bob, wantView: domain.ErrForbidden, wantManage: domain.ErrForbidden},
+		{name: "anonymous caller", createdBy: "alice", caller: auth.Anonymous, wantView: domain.ErrForbidden, wantManage: domain.ErrForbidden},
+		{name: "admin bypass", createdBy: "alice", caller: admin},
+		{name: "anonymous link stats stay public", createdBy: "anonymous", caller: bob, wantManage: domain.ErrForbidden},
+		{name: "anonymous link, anonymous caller", createdBy: "anonymous", caller: auth.Anonymous, wantManage: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.caller)
+			mockURLRepo := new(MockURLRepository)
+			mockClickRepo := new(MockClickRepository)
+
+			service := NewURLService(mockURLRepo, mockClickRepo)
+
+			url := &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: tt.createdBy, IsActive: true}
+			mockURLRepo.On("GetByShortCode", consistentRead, "abc123").Return(url, nil)
+			mockURLRepo.On("GetByID", ctx, "123").Return(url, nil)
+			mockURLRepo.On("Delete", ctx, "123").Return(nil)
+			mockClickRepo.On("GetByURLID", ctx, "123", 100, 0).Return([]*domain.URLClick{}, nil)
+
+			// Act
+			_, _, viewErr := service.GetURLStats(ctx, "abc123")
+			deleteErr := service.DeleteURL(ctx, "123")
+
+			// Assert
+			assert.ErrorIs(t, viewErr, tt.wantView)
+			assert.ErrorIs(t, deleteErr, tt.wantManage)
+			if tt.wantManage != nil {
+				mockURLRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestCreateShortURL_CodePolicyPerDomain(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+
+	generator, err := shortcode.NewGenerator(shortcode.AlphabetUnambiguous, 8, map[string]int{"go.example.com": 4})
+	require.NoError(t, err)
+
+	service := NewURLService(mockURLRepo, new(MockClickRepository)).
+		WithCodeGenerator(generator)
+
+	mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
+	mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
+
+	// Act
+	defaultURL, err := service.CreateShortURL(ctx, "https://example.com", "", "user1", 0)
+	require.NoError(t, err)
+	vanityURL, err := service.CreateShortURL(ctx, "https://example.com", "", "user1", 0, domain.WithDomain("go.example.com"))
+	require.NoError(t, err)
+
+	// Assert
+	assert.Len(t, defaultURL.ShortCode, 8)
+	assert.Len(t, vanityURL.ShortCode, 4)
+	assert.Equal(t, "go.example.com", vanityURL.Domain)
+	for _, code := range []string{defaultURL.ShortCode, vanityURL.ShortCode} {
+		assert.NotContains(t, code, "0")
+		assert.NotContains(t, code, "l")
+	}
+}
+
+func TestUpsertURL(t *testing.T) {
+	alice := &auth.Principal{ID: "alice"}
+	existing := func() *domain.URL {
+		return &domain.URL{ID: "1", ShortCode: "promo", OriginalURL: "https://example.com/v1", CreatedBy: "alice", IsActive: true, Version: 4}
+	}
+	currentETag := existing().ETag()
+	french := map[string]string{"fr": "https://example.com/fr"}
+	withFrench := func() *domain.URL {
+		url := existing()
+		url.LanguageTargets = french
+		return url
+	}
+	officeHours := func() *domain.Schedule {
+		return &domain.Schedule{Timezone: "Europe/Berlin", Rules: []domain.ScheduleRule{
+			{Days: []string{"mon", "fri"}, Start: "09:00", End: "17:00", URL: "https://example.com/chat"},
+		}}
+	}
+	withSchedule := func() *domain.URL {
+		url := existing()
+		url.Schedule = officeHours()
+		return url
+	}
+	ticket := map[string]string{"ticket": "MKT-142"}
+	withNotes := func() *domain.URL {
+		url := existing()
+		url.Description = "Spring sale"
+		url.CustomMetadata = ticket
+		return url
+	}
+	badSchedule := func(mutate func(*domain.Schedule)) *domain.Schedule {
+		schedule := officeHours()
+		mutate(schedule)
+		return schedule
+	}
+
+	tests := []struct {
+		name        string
+		caller      *auth.Principal
+		current     *domain.URL
+		destination string
+		languages   map[string]string
+		schedule    *domain.Schedule
+		description string
+		metadata    map[string]string
+		cond        domain.Precondition
+		updateErr   error
+		wantOutcome domain.UpsertOutcome
+		wantErr     error
+	}{
+		{name: "missing alias is created", caller: alice, destination: "https://example.com/v1", wantOutcome: domain.UpsertCreated},
+		{name: "same destination is a no-op", caller: alice, current: existing(), destination: "https://example.com/v1", wantOutcome: domain.UpsertUnchanged},
+		{name: "new destination is updated", caller: alice, current: existing(), destination: "https://example.com/v2", wantOutcome: domain.UpsertUpdated},
+		{name: "matching If-Match", caller: alice, current: existing(), destination: "https://example.com/v2", cond: domain.Precondition{IfMatch: currentETag}, wantOutcome: domain.UpsertUpdated},
+		{name: "stale If-Match", caller: alice, current: existing(), destination: "https://example.com/v2", cond: domain.Precondition{IfMatch: `"stale"`}, wantErr: domain.ErrPreconditionFailed},
+		{name: "weak If-Match never matches", caller: alice, current: existing(), destination: "https://example.com/v2", cond: domain.Precondition{IfMatch: "W/" + currentETag}, wantErr: domain.ErrPreconditionFailed},
+		{name: "If-Match on a missing alias", caller: alice, destination: "https://example.com/v1", cond: domain.Precondition{IfMatch: "*"}, wantErr: domain.ErrPreconditionFailed},
+		{name: "If-None-Match * never overwrites", caller: alice, current: existing(), destination: "https://example.com/v2", cond: domain.Precondition{IfNoneMatch: "*"}, wantErr: domain.ErrPreconditionFailed},
+		{name: "concurrent update loses", caller: alice, current: existing(), destination: "https://example.com/v2", updateErr: domain.ErrVersionConflict, wantErr: domain.ErrVersionConflict},
+		{name: "someone else's alias", caller: &auth.Principal{ID: "bob"}, current: existing(), destination: "https://example.com/v2", wantErr: domain.ErrForbidden},
+		{name: "invalid destination", caller: alice, current: existing(), destination: "not a url", wantErr: domain.ErrInvalidURL},
+		{name: "created with language targets", caller: alice, destination: "https://example.com/v1", languages: french, wantOutcome: domain.UpsertCreated},
+		{name: "new language targets are updated", caller: alice, current: existing(), destination: "https://example.com/v1", languages: french, wantOutcome: domain.UpsertUpdated},
+		{name: "same language targets are a no-op", caller: alice, current: withFrench(), destination: "https://example.com/v1", languages: map[string]string{"FR": "https://example.com/fr"}, wantOutcome: domain.UpsertUnchanged},
+		{name: "omitted language targets are removed", caller: alice, current: withFrench(), destination: "https://example.com/v1", wantOutcome: domain.UpsertUpdated},
+		{name: "invalid language tag", caller: alice, current: existing(), destination: "https://example.com/v1", languages: map[string]string{"french": "https://example.com/fr"}, wantErr: domain.ErrInvalidLanguageTargets},
+		{name: "invalid language destination", caller: alice, current: existing(), destination: "https://example.com/v1", languages: map[string]string{"fr": "ftp://example.com/fr"}, wantErr: domain.ErrInvalidLanguageTargets},
+		{name: "new schedule is updated", caller: alice, current: existing(), destination: "https://example.com/v1", schedule: officeHours(), wantOutcome: domain.UpsertUpdated},
+		{name: "same schedule is a no-op", caller: alice, current: withSchedule(), destination: "https://example.com/v1", schedule: badSchedule(func(s *domain.Schedule) { s.Rules[0].Days = []string{"MON", " fri"} }), wantOutcome: domain.UpsertUnchanged},
+		{name: "omitted schedule is removed", caller: alice, current: withSchedule(), destination: "https://example.com/v1", wantOutcome: domain.UpsertUpdated},
+		{name: "unknown timezone", caller: alice, current: existing(), destination: "https://example.com/v1", schedule: badSchedule(func(s *domain.Schedule) { s.Timezone = "Mars/Olympus" }), wantErr: domain.ErrInvalidSchedule},
+		{name: "unknown day", caller: alice, current: existing(), destination: "https://example.com/v1", schedule: badSchedule(func(s *domain.Schedule) { s.Rules[0].Days = []string{"monday"} }), wantErr: domain.ErrInvalidSchedule},
+		{name: "malformed time", caller: alice, current: existing(), destination: "https://example.com/v1", schedule: badSchedule(func(s *domain.Schedule) { s.Rules[0].End = "25:00" }), wantErr: domain.ErrInvalidSchedule},
+		{name: "start without end", caller: alice, current: existing(), destination: "https://example.com/v1", schedule: badSchedule(func(s *domain.Schedule) { s.Rules[0].End = "" }), wantErr: domain.ErrInvalidSchedule},
+		{name: "empty time range", caller: alice, current: existing(), destination: "https://example.com/v1", schedule: badSchedule(func(s *domain.Schedule) { s.Rules[0].End = "09:00" }), wantErr: domain.ErrInvalidSchedule},
+		{name: "schedule destination not http", caller: alice, current: existing(), destination: "https://example.com/v1", schedule: badSchedule(func(s *domain.Schedule) { s.Rules[0].URL = "javascript:alert(1)" }), wantErr: domain.ErrInvalidSchedule},
+		{name: "new notes are updated", caller: alice, current: existing(), destination: "https://example.com/v1", description: "Spring sale", metadata: ticket, wantOutcome: domain.UpsertUpdated},
+		{name: "same notes are a no-op", caller: alice, current: withNotes(), destination: "https://example.com/v1", description: " Spring sale ", metadata: map[string]string{"ticket": "MKT-142"}, wantOutcome: domain.UpsertUnchanged},
+		{name: "omitted notes are removed", caller: alice, current: withNotes(), destination: "https://example.com/v1", wantOutcome: domain.UpsertUpdated},
+		{name: "invalid metadata key", caller: alice, current: existing(), destination: "https://example.com/v1", metadata: map[string]string{"cost center": "42"}, wantErr: domain.ErrInvalidCustomMetadata},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.caller)
+			mockURLRepo := new(MockURLRepository)
+			service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+			if tt.current != nil {
+				mockURLRepo.On("GetByCustomAlias", consistentRead, "promo").Return(tt.current, nil)
+			} else {
+				mockURLRepo.On("GetByCustomAlias", consistentRead, "promo").Return(nil, domain.ErrURLNotFound)
+			}
+			mockURLRepo.On("ExistsCustomAlias", ctx, "promo").Return(false, nil)
+			mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
+			mockURLRepo.On("Update", ctx, mock.AnythingOfType("*domain.URL")).Return(tt.updateErr)
+
+			// Act
+			url, outcome, err := service.UpsertURL(ctx, "promo", tt.destination, tt.cond,
+				domain.WithLanguageTargets(tt.languages),
+				domain.WithSchedule(tt.schedule),
+				domain.WithDescription(tt.description),
+				domain.WithCustomMetadata(tt.metadata),
+			)
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				if tt.updateErr == nil {
+					mockURLRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				}
+				mockURLRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOutcome, outcome)
+			assert.Equal(t, tt.destination, url.OriginalURL)
+			assert.Len(t, url.LanguageTargets, len(tt.languages))
+			assert.Equal(t, tt.schedule != nil, url.Schedule != nil)
+			assert.Equal(t, tt.metadata, url.CustomMetadata)
+
+			switch tt.wantOutcome {
+			case domain.UpsertCreated:
+				mockURLRepo.AssertCalled(t, "Create", ctx, mock.Anything)
+			case domain.UpsertUpdated:
+				mockURLRepo.AssertCalled(t, "Update", ctx, mock.Anything)
+			case domain.UpsertUnchanged:
+				mockURLRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				mockURLRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestSuggestAliases_SkipsTakenCandidates(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+	// The plain keyword and the first combination are already taken
+	mockURLRepo.On("FindTakenCodes", ctx, mock.MatchedBy(func(codes []string) bool {
+		return len(codes) > 3 && codes[0] == "summer-sale"
+	})).Return(map[string]bool{"summer-sale": true, "summer-sale-go": true}, nil).Once()
+
+	// Act
+	suggestions, err := service.SuggestAliases(ctx, "", "Summer Sale!", 3)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go-summer-sale", "summer-sale-now", "now-summer-sale"}, suggestions)
+	mockURLRepo.AssertExpectations(t)
+}
+
+func TestSuggestAliases_KeywordFromURL(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+	mockURLRepo.On("FindTakenCodes", ctx, mock.Anything).Return(map[string]bool{}, nil)
+
+	// Act
+	suggestions, err := service.SuggestAliases(ctx, "https://www.bbc.co.uk/news", "", 0)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, suggestions, 5)
+	assert.Equal(t, "bbc", suggestions[0])
+}
+
+func TestCheckAliasAvailability(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockURLRepo := new(MockURLRepository)
+	service := NewURLService(mockURLRepo, new(MockClickRepository))
+
+	mockURLRepo.On("FindTakenCodes", ctx, []string{"promo"}).Return(map[string]bool{"promo": true}, nil)
+	mockURLRepo.On("FindTakenCodes", ctx, []string{"launch"}).Return(map[string]bool{}, nil)
+
+	// Act & Assert
+	status, err := service.CheckAliasAvailability(ctx, "promo")
+	require.NoError(t, err)
+	assert.Equal(t, domain.AliasTaken, status)
+
+	status, err = service.CheckAliasAvailability(ctx, "launch")
+	require.NoError(t, err)
+	assert.Equal(t, domain.AliasFree, status)
+
+	// Reserved aliases never hit the database
+	status, err = service.CheckAliasAvailability(ctx, "Admin")
+	require.NoError(t, err)
+	assert.Equal(t, domain.AliasReserved, status)
+
+	_, err = service.CheckAliasAvailability(ctx, "no spaces")
+	assert.ErrorIs(t, err, domain.ErrCustomAliasInvalid)
+	mockURLRepo.AssertNumberOfCalls(t, "FindTakenCodes", 2)
+}
+
+// ==================== TABLE-DRIVEN TESTS ====================
+
+func TestCreateShortURL_TableDriven(t *testing.T) {
+	tests := []struct {
+		name          string
+		originalURL   string
+		customAlias   string
+		aliasExists   bool
+		expectError   bool
+		errorContains string
+	}{
+		{
+			name:        "Valid URL without custom alias",
+			originalURL: "https://example.com",
+			customAlias: "",
+			aliasExists: false,
+			expectError: false,
+		},
+		{
+			name:        "Valid URL with custom alias",
+			originalURL: "https://example.com",
+			customAlias: "mylink",
+			aliasExists: false,
+			expectError: false,
+		},
+		{
+			name:          "Custom alias already taken",
+			originalURL:   "https://example.com",
+			customAlias:   "taken",
+			aliasExists:   true,
+			expectError:   true,
+			errorContains: "already exists",
+		},
+		{
+			name:          "Invalid URL",
+			originalURL:   "not-a-valid-url",
+			customAlias:   "",
+			aliasExists:   false,
+			expectError:   true,
+			errorContains: "validation failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			mockURLRepo := new(MockURLRepository)
+			mockClickRepo := new(MockClickRepository)
+
+			service := NewURLService(mockURLRepo, mockClickRepo)
+
+			if tt.customAlias != "" {
+				mockURLRepo.On("ExistsCustomAlias", ctx, tt.customAlias).Return(tt.aliasExists, nil)
+			} else {
+				mockURLRepo.On("ExistsShortCode", ctx, mock.Anything).Return(false, nil)
+			}
+
+			if !tt.aliasExists && !tt.expectError {
+				mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
+			}
+
+			// Act
+			url, err := service.CreateShortURL(ctx, tt.originalURL, tt.customAlias, "user1", 0)
+
+			// Assert
+			if tt.expectError {
+				assert.Error(t, err)
+				if tt.errorContains != "" {
+					assert.Contains(t, err.Error(), tt.errorContains)
+				}
+				assert.Nil(t, url)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, url)
+			}
+		})
+	}
+}
+
+func TestGetClickHeatmap(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// Monday 2026-03-30
+	now := time.Date(2026, 3, 30, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		query            domain.TimeseriesQuery
+		expectedLocation *time.Location
+		expectedFrom     time.Time
+		expectedTo       time.Time
+		expectErr        error
+	}{
+		{
+			name:             "last 12 weeks by default",
+			expectedLocation: time.UTC,
+			expectedFrom:     time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC),
+			expectedTo:       time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:             "range rounded out to whole weeks",
+			query:            domain.TimeseriesQuery{Timezone: "Europe/Berlin", From: "2026-03-04", To: "2026-03-18"},
+			expectedLocation: berlin,
+			expectedFrom:     time.Date(2026, 3, 2, 0, 0, 0, 0, berlin),
+			expectedTo:       time.Date(2026, 3, 23, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:      "interval is ignored",
+			query:     domain.TimeseriesQuery{Interval: "minute", Timezone: "Mars/Olympus"},
+			expectErr: domain.ErrInvalidTimezone,
+		},
+		{name: "bad range", query: domain.TimeseriesQuery{From: "2026-03-30", To: "2026-03-01"}, expectErr: domain.ErrInvalidTimeRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+			mockURLRepo := new(MockURLRepository)
+			mockClickRepo := new(MockClickRepository)
+			service := NewURLService(mockURLRepo, mockClickRepo)
+			service.now = func() time.Time { return now }
+
+			mockURLRepo.On("GetByShortCode", ctx, "abc123").Return(&domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "user1"}, nil)
+			if tt.expectErr == nil {
+				// Two shards report Tuesday 9:00; cells outside the matrix are dropped
+				mockClickRepo.On("CountByHourOfWeek", ctx, "123", tt.expectedLocation, tt.expectedFrom, tt.expectedTo).
+					Return([]domain.HourOfWeek{{Day: 1, Hour: 9, Clicks: 4}, {Day: 1, Hour: 9, Clicks: 2}, {Day: 6, Hour: 23, Clicks: 1}, {Day: 7, Hour: 0, Clicks: 9}}, nil)
+			}
+
+			// Act
+			heatmap, err := service.GetClickHeatmap(ctx, "abc123", tt.query)
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				mockClickRepo.AssertNotCalled(t, "CountByHourOfWeek", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedLocation, heatmap.Location)
+			assert.Equal(t, tt.expectedFrom, heatmap.From)
+			assert.Equal(t, int64(6), heatmap.Clicks[1][9])
+			assert.Equal(t, int64(1), heatmap.Clicks[6][23])
+			assert.Zero(t, heatmap.Clicks[0][0])
+			mockClickRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetClickHeatmap_NotOwner(t *testing.T) {
+	// Arrange
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user2"})
+	mockURLRepo := new(MockURLRepository)
+	mockClickRepo := new(MockClickRepository)
+	service := NewURLService(mockURLRepo, mockClickRepo)
+	mockURLRepo.On("GetByShortCode", ctx, "abc123").Return(&domain.URL{ID: "123", CreatedBy: "user1"}, nil)
+
+	// Act
+	_, err := service.GetClickHeatmap(ctx, "abc123", domain.TimeseriesQuery{})
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	mockClickRepo.AssertNotCalled(t, "CountByHourOfWeek", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestURLService_CodeCaseInsensitive(t *testing.T) {
+	t.Run("new aliases are stored lowercase", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		mockURLRepo := new(MockURLRepository)
+		service := NewURLService(mockURLRepo, new(MockClickRepository)).WithCodeCase(domain.CodeCaseInsensitive)
+		mockURLRepo.On("ExistsCustomAlias", ctx, "mylink").Return(false, nil)
+		mockURLRepo.On("Create", ctx, mock.AnythingOfType("*domain.URL")).Return(nil)
+
+		// Act
+		url, err := service.CreateShortURL(ctx, "https://example.com", "MyLink", "user1", 0)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "mylink", url.ShortCode)
+		assert.Equal(t, "mylink", *url.CustomAlias)
+	})
+
+	t.Run("a code typed in the wrong case finds its link", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		mockURLRepo := new(MockURLRepository)
+		service := NewURLService(mockURLRepo, new(MockClickRepository)).WithCodeCase(domain.CodeCaseInsensitive)
+		url := &domain.URL{ID: "123", ShortCode: "abc123", OriginalURL: "https://example.com", IsActive: true}
+		mockURLRepo.On("GetByShortCode", ctx, "ABC123").Return(nil, domain.ErrURLNotFound)
+		mockURLRepo.On("GetByShortCode", ctx, "abc123").Return(url, nil)
+
+		// Act
+		found, err := service.GetURL(ctx, "ABC123")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "abc123", found.ShortCode)
+	})
+
+	t.Run("links created before the switch keep their case", func(t *testing.T) {
+		// Arrange: the exact code is tried first
+		ctx := context.Background()
+		mockURLRepo := new(MockURLRepository)
+		service := NewURLService(mockURLRepo, new(MockClickRepository)).WithCodeCase(domain.CodeCaseInsensitive)
+		legacy := &domain.URL{ID: "123", ShortCode: "AbC123", OriginalURL: "https://example.com", IsActive: true}
+		mockURLRepo.On("GetByShortCode", ctx, "AbC123").Return(legacy, nil)
+
+		// Act
+		found, err := service.GetURL(ctx, "AbC123")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "AbC123", found.ShortCode)
+		mockURLRepo.AssertNotCalled(t, "GetByShortCode", ctx, "abc123")
+	})
+
+	t.Run("clicks are counted on the stored code", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		mockURLRepo := new(MockURLRepository)
+		mockClickRepo := new(MockClickRepository)
+		service := NewURLService(mockURLRepo, mockClickRepo).WithCodeCase(domain.CodeCaseInsensitive)
+		url := &domain.URL{ID: "123", ShortCode: "abc123", IsActive: true}
+		mockURLRepo.On("GetByShortCode", mock.Anything, "ABC123").Return(nil, domain.ErrURLNotFound)
+		mockURLRepo.On("GetByShortCode", mock.Anything, "abc123").Return(url, nil)
+		mockURLRepo.On("IncrementClicks", ctx, "abc123").Return(nil)
+		mockClickRepo.On("Create", ctx, mock.AnythingOfType("*domain.URLClick")).Return(nil)
+
+		// Act
+		err := service.RecordClick(ctx, domain.ClickContext{ShortCode: "ABC123", IPAddress: "127.0.0.1"})
+
+		// Assert
+		require.NoError(t, err)
+		mockURLRepo.AssertExpectations(t)
+	})
+
+	t.Run("case-sensitive deployments don't retry", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		mockURLRepo := new(MockURLRepository)
+		service := NewURLService(mockURLRepo, new(MockClickRepository))
+		mockURLRepo.On("GetByShortCode", ctx, "ABC123").Return(nil, domain.ErrURLNotFound)
+		mockURLRepo.On("GetByCustomAlias", ctx, "ABC123").Return(nil, domain.ErrURLNotFound)
+
+		// Act
+		_, err := service.GetURL(ctx, "ABC123")
+
+		// Assert
+		assert.Error(t, err)
+		mockURLRepo.AssertNumberOfCalls(t, "GetByShortCode", 1)
+	})
+}
+
+func TestValidateShortURL(t *testing.T) {
+	alice := &auth.Principal{ID: "alice"}
+
+	tests := []struct {
+		name        string
+		principal   *auth.Principal
+		originalURL string
+		alias       string
+		aliasTaken  bool
+		quotaErr    error
+		wantErr     error
+		wantCode    string
+	}{
+		{name: "custom alias", principal: alice, originalURL: "https://example.com", alias: "launch", wantCode: "launch"},
+		{name: "generated codes are not drawn", principal: alice, originalURL: "https://example.com"},
+		{name: "alias taken", principal: alice, originalURL: "https://example.com", alias: "launch", aliasTaken: true, wantErr: domain.ErrCustomAliasTaken},
+		{name: "invalid destination", principal: alice, originalURL: "ftp://example.com", wantErr: domain.ErrInvalidURL},
+		{name: "quota used up", principal: alice, originalURL: "https://example.com", quotaErr: domain.ErrQuotaExceeded, wantErr: domain.ErrQuotaExceeded},
+		{name: "viewers can't create links", principal: teamViewer, originalURL: "https://example.com", wantErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			mockURLRepo := new(MockURLRepository)
+			mockQuotas := new(MockQuotas)
+			service := NewURLService(mockURLRepo, new(MockClickRepository)).WithQuotas(mockQuotas)
+
+			usage := &domain.Usage{Owner: "alice", Plan: domain.Plan{MonthlyLinks: 100}, Used: 10}
+			mockURLRepo.On("ExistsCustomAlias", ctx, tt.alias).Return(tt.aliasTaken, nil)
+			mockQuotas.On("Check", ctx).Return(usage, tt.quotaErr)
+
+			// Act
+			preview, err := service.ValidateShortURL(ctx, tt.originalURL, tt.alias, tt.principal.ID, 0)
+
+			// Assert
+			// Nothing is stored or counted, whatever the outcome
+			mockURLRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			mockURLRepo.AssertNotCalled(t, "ExistsShortCode", mock.Anything, mock.Anything)
+			mockQuotas.AssertNotCalled(t, "Reserve", mock.Anything)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, preview)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCode, preview.URL.ShortCode)
+			assert.Equal(t, tt.originalURL, preview.URL.OriginalURL)
+			assert.Equal(t, int64(90), preview.Usage.Remaining())
+		})
+	}
+}
+
+func TestValidateShortURL_AnonymousCallersDontFetch(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		wantErr     error
+	}{
+		{name: "destination is not followed", destination: "https://example.com/launch"},
+		{name: "known shortener is still rejected", destination: "https://bit.ly/abc", wantErr: domain.ErrRedirectorURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), auth.Anonymous)
+			mockResolver := new(MockResolver)
+			service := NewURLService(new(MockURLRepository), new(MockClickRepository)).
+				WithResolver(mockResolver, true)
+
+			// Act
+			preview, err := service.ValidateShortURL(ctx, tt.destination, "", auth.Anonymous.ID, 0)
+
+			// Assert
+			mockResolver.AssertNotCalled(t, "Resolve", mock.Anything, mock.Anything)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
 			}
+			require.NoError(t, err)
+			assert.Nil(t, preview.URL.ResolvedURL)
 		})
 	}
 }
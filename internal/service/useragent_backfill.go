@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+
+	"url-shortener/internal/repository"
+	"url-shortener/internal/useragent"
+)
+
+// UserAgentBackfill parses the User-Agent of clicks recorded before clicks
+// had browser, OS and device columns
+//
+// It is a one-off MIGRATION JOB: run it once after migration 015 (it's safe
+// to stop and rerun - only rows still missing a device type are read).
+// Clicks without a User-Agent get device type "unknown", so no row is left
+// NULL and a rerun has nothing to do.
+type UserAgentBackfill struct {
+	repo      repository.ClickBackfillRepository
+	batchSize int
+}
+
+// NewUserAgentBackfill creates a backfill job
+func NewUserAgentBackfill(repo repository.ClickBackfillRepository, batchSize int) *UserAgentBackfill {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	return &UserAgentBackfill{repo: repo, batchSize: batchSize}
+}
+
+// Run backfills every click and returns how many were updated
+// progress (optional) is called after each batch with the running total
+func (b *UserAgentBackfill) Run(ctx context.Context, progress func(updated int64)) (int64, error) {
+	var updated, afterID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return updated, err
+		}
+
+		clicks, err := b.repo.NextUnparsed(ctx, afterID, b.batchSize)
+		if err != nil {
+			return updated, err
+		}
+		if len(clicks) == 0 {
+			return updated, nil
+		}
+
+		for _, click := range clicks {
+			device := useragent.Parse(click.UserAgent)
+			click.WithDevice(device.Browser, device.OS, device.Device)
+		}
+		if err := b.repo.SetDevices(ctx, clicks); err != nil {
+			return updated, err
+		}
+
+		updated += int64(len(clicks))
+		afterID = clicks[len(clicks)-1].ID
+		if progress != nil {
+			progress(updated)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockClickBackfillRepository is a mock implementation of ClickBackfillRepository
+type MockClickBackfillRepository struct {
+	mock.Mock
+}
+
+func (m *MockClickBackfillRepository) NextUnparsed(ctx context.Context, afterID int64, limit int) ([]*domain.URLClick, error) {
+	args := m.Called(ctx, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URLClick), args.Error(1)
+}
+
+func (m *MockClickBackfillRepository) SetDevices(ctx context.Context, clicks []*domain.URLClick) error {
+	args := m.Called(ctx, clicks)
+	return args.Error(0)
+}
+
+func TestUserAgentBackfill_Run(t *testing.T) {
+	// Arrange: two batches of 2, then nothing left
+	ctx := context.Background()
+	repo := new(MockClickBackfillRepository)
+	backfill := NewUserAgentBackfill(repo, 2)
+
+	first := []*domain.URLClick{
+		{ID: 1, UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1"},
+		{ID: 4, UserAgent: ""},
+	}
+	second := []*domain.URLClick{
+		{ID: 7, UserAgent: "curl/8.4.0"},
+	}
+	repo.On("NextUnparsed", ctx, int64(0), 2).Return(first, nil)
+	repo.On("NextUnparsed", ctx, int64(4), 2).Return(second, nil)
+	repo.On("NextUnparsed", ctx, int64(7), 2).Return([]*domain.URLClick{}, nil)
+	repo.On("SetDevices", ctx, mock.Anything).Return(nil)
+
+	var reported []int64
+
+	// Act
+	updated, err := backfill.Run(ctx, func(n int64) { reported = append(reported, n) })
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), updated)
+	assert.Equal(t, []int64{2, 3}, reported)
+
+	assert.Equal(t, "Safari", first[0].Browser)
+	assert.Equal(t, "iOS", first[0].OS)
+	assert.Equal(t, "mobile", first[0].DeviceType)
+	assert.Equal(t, "unknown", first[1].DeviceType) // Never NULL again, so a rerun skips it
+	assert.Equal(t, "bot", second[0].DeviceType)
+	repo.AssertExpectations(t)
+}
+
+func TestUserAgentBackfill_StopsOnError(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockClickBackfillRepository)
+	backfill := NewUserAgentBackfill(repo, 10)
+
+	dbDown := errors.New("connection refused")
+	repo.On("NextUnparsed", ctx, int64(0), 10).Return([]*domain.URLClick{{ID: 1, UserAgent: "curl/8.4.0"}}, nil)
+	repo.On("SetDevices", ctx, mock.Anything).Return(dbDown)
+
+	// Act
+	updated, err := backfill.Run(ctx, nil)
+
+	// Assert
+	assert.ErrorIs(t, err, dbDown)
+	assert.Equal(t, int64(0), updated)
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/warehouse"
+)
+
+// ObjectStore uploads files to object storage (S3, GCS, ...)
+// Defined here so the service doesn't depend on a specific client
+type ObjectStore interface {
+	Put(ctx context.Context, key string, body []byte, contentType, contentEncoding string) error
+}
+
+// WarehouseService copies new clicks to object storage for the data warehouse
+//
+// HOW IT KNOWS WHAT IS NEW:
+// Click IDs only grow, so the export remembers the last exported ID (the
+// CHECKPOINT, stored in etl_checkpoints) and next time asks for everything
+// after it. Each file is uploaded BEFORE the checkpoint moves: a crash in
+// between exports those clicks twice, never zero times.
+//
+// WHY THE LAG?
+// IDs are handed out when a click is inserted, but a slow transaction can
+// commit a lower ID after a higher one is already visible. Only exporting
+// clicks older than the lag gives those transactions time to finish, so
+// the checkpoint doesn't skip past them.
+type WarehouseService struct {
+	repo      repository.WarehouseRepository
+	store     ObjectStore
+	source    string // Name of the database, e.g. the shard name
+	prefix    string
+	format    warehouse.Format
+	batchSize int
+	lag       time.Duration
+	now       func() time.Time
+}
+
+// NewWarehouseService creates a new export service for one database
+func NewWarehouseService(repo repository.WarehouseRepository, store ObjectStore, source, prefix string, format warehouse.Format) *WarehouseService {
+	return &WarehouseService{
+		repo:      repo,
+		store:     store,
+		source:    source,
+		prefix:    prefix,
+		format:    format,
+		batchSize: 10000,
+		lag:       time.Minute,
+		now:       time.Now,
+	}
+}
+
+// WithBatchSize sets how many clicks are read per query
+func (s *WarehouseService) WithBatchSize(size int) *WarehouseService {
+	if size > 0 {
+		s.batchSize = size
+	}
+	return s
+}
+
+// WithLag sets how old a click must be before it is exported
+func (s *WarehouseService) WithLag(lag time.Duration) *WarehouseService {
+	s.lag = lag
+	return s
+}
+
+// Run exports new clicks every interval until ctx is canceled
+func (s *WarehouseService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ExportOnce(ctx); err != nil {
+			fmt.Printf("Warning: warehouse export of %s failed: %v\n", s.source, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExportOnce uploads every click recorded since the last checkpoint and
+// returns how many were exported
+func (s *WarehouseService) ExportOnce(ctx context.Context) (int, error) {
+	checkpoint, err := s.repo.GetCheckpoint(ctx, s.source)
+	if err != nil {
+		return 0, err
+	}
+	before := s.now().Add(-s.lag)
+
+	exported := 0
+	for ctx.Err() == nil {
+		clicks, err := s.repo.ListClicksAfter(ctx, checkpoint, before, s.batchSize)
+		if err != nil {
+			return exported, err
+		}
+
+		// One file per partition, so each file lands in the right dt=/hour= directory
+		for _, chunk := range splitByPartition(clicks) {
+			if err := s.upload(ctx, chunk); err != nil {
+				return exported, err
+			}
+			checkpoint = chunk[len(chunk)-1].ID
+			if err := s.repo.SaveCheckpoint(ctx, s.source, checkpoint); err != nil {
+				return exported, err
+			}
+			exported += len(chunk)
+		}
+
+		if len(clicks) < s.batchSize {
+			break
+		}
+	}
+	return exported, nil
+}
+
+// upload writes one file holding chunk
+func (s *WarehouseService) upload(ctx context.Context, chunk []*domain.URLClick) error {
+	body, err := s.format.Encode(s.source, chunk)
+	if err != nil {
+		return err
+	}
+
+	first, last := chunk[0], chunk[len(chunk)-1]
+	key := warehouse.ObjectKey(s.prefix, warehouse.Partition(first.ClickedAt), s.source, first.ID, last.ID, s.format)
+	if err := s.store.Put(ctx, key, body, "application/x-ndjson", s.format.ContentEncoding()); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return nil
+}
+
+// splitByPartition cuts ID-ordered clicks into runs of the same partition
+// Clicks are in ID order, not time order, so a partition can show up in
+// more than one run - that's fine, every run becomes its own file
+func splitByPartition(clicks []*domain.URLClick) [][]*domain.URLClick {
+	var chunks [][]*domain.URLClick
+	start := 0
+	for i := 1; i <= len(clicks); i++ {
+		if i == len(clicks) || warehouse.Partition(clicks[i].ClickedAt) != warehouse.Partition(clicks[start].ClickedAt) {
+			chunks = append(chunks, clicks[start:i])
+			start = i
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/warehouse"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockWarehouseRepository is a mock implementation of WarehouseRepository
+type MockWarehouseRepository struct {
+	mock.Mock
+}
+
+func (m *MockWarehouseRepository) ListClicksAfter(ctx context.Context, afterID int64, before time.Time, limit int) ([]*domain.URLClick, error) {
+	args := m.Called(ctx, afterID, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URLClick), args.Error(1)
+}
+
+func (m *MockWarehouseRepository) GetCheckpoint(ctx context.Context, name string) (int64, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockWarehouseRepository) SaveCheckpoint(ctx context.Context, name string, lastID int64) error {
+	args := m.Called(ctx, name, lastID)
+	return args.Error(0)
+}
+
+// MockObjectStore is a mock implementation of ObjectStore
+type MockObjectStore struct {
+	mock.Mock
+}
+
+func (m *MockObjectStore) Put(ctx context.Context, key string, body []byte, contentType, contentEncoding string) error {
+	args := m.Called(ctx, key, body, contentType, contentEncoding)
+	return args.Error(0)
+}
+
+func TestExportOnce(t *testing.T) {
+	// Arrange: a full batch crossing an hour boundary, then a short batch
+	ctx := context.Background()
+	repo := new(MockWarehouseRepository)
+	store := new(MockObjectStore)
+	svc := NewWarehouseService(repo, store, "primary", "clicks", warehouse.Format{Encoding: "ndjson", Compression: "gzip"}).
+		WithBatchSize(3)
+	now := time.Date(2025, 3, 1, 15, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	before := now.Add(-time.Minute)
+
+	at := func(hour, minute int) time.Time { return time.Date(2025, 3, 1, hour, minute, 0, 0, time.UTC) }
+	repo.On("GetCheckpoint", ctx, "primary").Return(int64(10), nil)
+	repo.On("ListClicksAfter", ctx, int64(10), before, 3).Return([]*domain.URLClick{
+		{ID: 11, ClickedAt: at(13, 58)},
+		{ID: 12, ClickedAt: at(13, 59)},
+		{ID: 13, ClickedAt: at(14, 1)},
+	}, nil)
+	repo.On("ListClicksAfter", ctx, int64(13), before, 3).Return([]*domain.URLClick{
+		{ID: 14, ClickedAt: at(14, 2)},
+	}, nil)
+	store.On("Put", ctx, mock.Anything, mock.Anything, "application/x-ndjson", "gzip").Return(nil)
+	repo.On("SaveCheckpoint", ctx, "primary", mock.Anything).Return(nil)
+
+	// Act
+	exported, err := svc.ExportOnce(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 4, exported)
+	store.AssertCalled(t, "Put", ctx, "clicks/dt=2025-03-01/hour=13/primary-11-12.ndjson.gz", mock.Anything, mock.Anything, mock.Anything)
+	store.AssertCalled(t, "Put", ctx, "clicks/dt=2025-03-01/hour=14/primary-13-13.ndjson.gz", mock.Anything, mock.Anything, mock.Anything)
+	store.AssertCalled(t, "Put", ctx, "clicks/dt=2025-03-01/hour=14/primary-14-14.ndjson.gz", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertCalled(t, "SaveCheckpoint", ctx, "primary", int64(12))
+	repo.AssertCalled(t, "SaveCheckpoint", ctx, "primary", int64(14))
+}
+
+func TestExportOnce_UploadFailureKeepsCheckpoint(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockWarehouseRepository)
+	store := new(MockObjectStore)
+	svc := NewWarehouseService(repo, store, "primary", "", warehouse.Format{Encoding: "ndjson", Compression: "none"})
+
+	repo.On("GetCheckpoint", ctx, "primary").Return(int64(0), nil)
+	repo.On("ListClicksAfter", ctx, int64(0), mock.Anything, mock.Anything).Return([]*domain.URLClick{
+		{ID: 1, ClickedAt: time.Now()},
+	}, nil)
+	store.On("Put", ctx, mock.Anything, mock.Anything, mock.Anything, "").Return(errors.New("access denied"))
+
+	// Act
+	exported, err := svc.ExportOnce(ctx)
+
+	// Assert: the clicks are retried next run
+	assert.Error(t, err)
+	assert.Equal(t, 0, exported)
+	repo.AssertNotCalled(t, "SaveCheckpoint", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExportOnce_NothingNew(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := new(MockWarehouseRepository)
+	store := new(MockObjectStore)
+	svc := NewWarehouseService(repo, store, "shard-1", "clicks", warehouse.Format{Encoding: "ndjson", Compression: "gzip"})
+
+	repo.On("GetCheckpoint", ctx, "shard-1").Return(int64(42), nil)
+	repo.On("ListClicksAfter", ctx, int64(42), mock.Anything, mock.Anything).Return([]*domain.URLClick{}, nil)
+
+	// Act
+	exported, err := svc.ExportOnce(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 0, exported)
+	store.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/events"
+	"url-shortener/internal/notify"
+	"url-shortener/internal/repository"
+)
+
+// WarningService periodically looks for URLs that are about to stop working
+// (click limit almost used up, or expiring soon) and notifies their owners
+// so they can renew the link before it dies
+//
+// This is a BACKGROUND WORKER: it runs on a ticker instead of per-request,
+// so the redirect path never pays for these checks
+type WarningService struct {
+	repo          repository.WarningRepository
+	notifier      notify.Notifier
+	expiryWarning time.Duration // How long before expiration owners are warned
+	batchSize     int           // Maximum URLs handled per kind per run
+	events        *events.Bus   // Optional: URLExpired is published here
+}
+
+// NewWarningService creates a new warning service
+func NewWarningService(repo repository.WarningRepository, notifier notify.Notifier, expiryWarning time.Duration) *WarningService {
+	return &WarningService{
+		repo:          repo,
+		notifier:      notifier,
+		expiryWarning: expiryWarning,
+		batchSize:     500,
+	}
+}
+
+// expiredLookback is how far back expired links are announced
+// Links that expired earlier (e.g. before the first run) are never announced
+const expiredLookback = 24 * time.Hour
+
+// WithEvents publishes events.URLExpired on bus, once per link, when a
+// link's expiration has passed
+func (s *WarningService) WithEvents(bus *events.Bus) *WarningService {
+	s.events = bus
+	return s
+}
+
+// Run evaluates warnings every interval until ctx is canceled
+func (s *WarningService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Evaluate(ctx); err != nil {
+			fmt.Printf("Warning: link warning evaluation failed: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Evaluate runs a single pass and returns how many warnings were sent
+func (s *WarningService) Evaluate(ctx context.Context) (int, error) {
+	sent := 0
+
+	// Check 100% before 80%: a URL that jumped straight past both thresholds
+	// gets the "reached" warning first
+	thresholds := []struct {
+		kind  domain.WarningKind
+		ratio float64
+	}{
+		{domain.WarningClickLimitReached, 1.0},
+		{domain.WarningClickLimitNear, domain.ClickLimitWarningRatio},
+	}
+
+	for _, threshold := range thresholds {
+		urls, err := s.repo.FindNearClickLimit(ctx, threshold.ratio, threshold.kind, s.batchSize)
+		if err != nil {
+			return sent, err
+		}
+		sent += s.warnAll(ctx, threshold.kind, urls)
+	}
+
+	urls, err := s.repo.FindExpiringBefore(ctx, time.Now().Add(s.expiryWarning), domain.WarningExpiringSoon, s.batchSize)
+	if err != nil {
+		return sent, err
+	}
+	sent += s.warnAll(ctx, domain.WarningExpiringSoon, urls)
+
+	if s.events != nil {
+		if err := s.announceExpired(ctx); err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// announceExpired publishes URLExpired for links that expired since the last runs
+// MarkSent makes sure only one instance announces each link
+func (s *WarningService) announceExpired(ctx context.Context) error {
+	now := time.Now()
+	urls, err := s.repo.FindExpiredSince(ctx, now.Add(-expiredLookback), domain.WarningExpired, s.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, url := range urls {
+		claimed, err := s.repo.MarkSent(ctx, url.ID, domain.WarningExpired)
+		if err != nil {
+			fmt.Printf("Warning: failed to record link expiration: %v\n", err)
+			continue
+		}
+		if claimed {
+			s.events.Publish(ctx, events.URLExpired{URL: url, OccurredAt: *url.ExpiresAt})
+		}
+	}
+	return nil
+}
+
+// warnAll sends one warning per URL and returns how many were delivered
+func (s *WarningService) warnAll(ctx context.Context, kind domain.WarningKind, urls []*domain.URL) int {
+	sent := 0
+	for _, url := range urls {
+		// Record first: if another instance already claimed this warning, skip it
+		// Delivery is AT MOST ONCE - a failed webhook is logged, not retried forever
+		claimed, err := s.repo.MarkSent(ctx, url.ID, kind)
+		if err != nil {
+			fmt.Printf("Warning: failed to record link warning: %v\n", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.notifier.Notify(ctx, warningEvent(domain.NewLinkWarning(kind, url))); err != nil {
+			fmt.Printf("Warning: failed to deliver link warning: %v\n", err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+// warningEvent converts a domain warning into a notification event
+func warningEvent(warning *domain.LinkWarning) notify.Event {
+	url := warning.URL
+	data := map[string]interface{}{
+		"clicks": url.Clicks,
+	}
+	if url.MaxClicks != nil {
+		data["max_clicks"] = *url.MaxClicks
+	}
+	if url.ExpiresAt != nil {
+		data["expires_at"] = url.ExpiresAt.Format(time.RFC3339)
+	}
+
+	return notify.Event{
+		Type:       string(warning.Kind),
+		URLID:      url.ID,
+		ShortCode:  url.ShortCode,
+		Owner:      url.CreatedBy,
+		OccurredAt: warning.TriggeredAt,
+		Data:       data,
+	}
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/events"
+	"url-shortener/internal/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ==================== MOCKS ====================
+
+// MockWarningRepository is a mock implementation of WarningRepository
+type MockWarningRepository struct {
+	mock.Mock
+}
+
+func (m *MockWarningRepository) FindNearClickLimit(ctx context.Context, ratio float64, kind domain.WarningKind, limit int) ([]*domain.URL, error) {
+	args := m.Called(ctx, ratio, kind, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URL), args.Error(1)
+}
+
+func (m *MockWarningRepository) FindExpiringBefore(ctx context.Context, before time.Time, kind domain.WarningKind, limit int) ([]*domain.URL, error) {
+	args := m.Called(ctx, before, kind, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URL), args.Error(1)
+}
+
+func (m *MockWarningRepository) FindExpiredSince(ctx context.Context, since time.Time, kind domain.WarningKind, limit int) ([]*domain.URL, error) {
+	args := m.Called(ctx, since, kind, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.URL), args.Error(1)
+}
+
+func (m *MockWarningRepository) MarkSent(ctx context.Context, urlID string, kind domain.WarningKind) (bool, error) {
+	args := m.Called(ctx, urlID, kind)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockNotifier is a mock implementation of notify.Notifier
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, event notify.Event) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+// ==================== TESTS ====================
+
+func TestWarningService_Evaluate_SendsEachWarningOnce(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := new(MockWarningRepository)
+	mockNotifier := new(MockNotifier)
+
+	service := NewWarningService(mockRepo, mockNotifier, 72*time.Hour)
+
+	nearLimit := (&domain.URL{ID: "1", ShortCode: "near", Clicks: 85}).WithMaxClicks(100)
+	alreadyWarned := (&domain.URL{ID: "2", ShortCode: "dup", Clicks: 90}).WithMaxClicks(100)
+	expiresAt := time.Now().Add(24 * time.Hour)
+	expiring := &domain.URL{ID: "3", ShortCode: "soon", ExpiresAt: &expiresAt}
+
+	mockRepo.On("FindNearClickLimit", ctx, 1.0, domain.WarningClickLimitReached, mock.Anything).Return([]*domain.URL{}, nil)
+	mockRepo.On("FindNearClickLimit", ctx, domain.ClickLimitWarningRatio, domain.WarningClickLimitNear, mock.Anything).
+		Return([]*domain.URL{nearLimit, alreadyWarned}, nil)
+	mockRepo.On("FindExpiringBefore", ctx, mock.AnythingOfType("time.Time"), domain.WarningExpiringSoon, mock.Anything).
+		Return([]*domain.URL{expiring}, nil)
+
+	mockRepo.On("MarkSent", ctx, "1", domain.WarningClickLimitNear).Return(true, nil)
+	mockRepo.On("MarkSent", ctx, "2", domain.WarningClickLimitNear).Return(false, nil) // another instance won
+	mockRepo.On("MarkSent", ctx, "3", domain.WarningExpiringSoon).Return(true, nil)
+
+	mockNotifier.On("Notify", ctx, mock.MatchedBy(func(e notify.Event) bool {
+		return e.Type == string(domain.WarningClickLimitNear) && e.ShortCode == "near"
+	})).Return(nil)
+	mockNotifier.On("Notify", ctx, mock.MatchedBy(func(e notify.Event) bool {
+		return e.Type == string(domain.WarningExpiringSoon) && e.ShortCode == "soon"
+	})).Return(nil)
+
+	// Act
+	sent, err := service.Evaluate(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	mockRepo.AssertExpectations(t)
+	mockNotifier.AssertExpectations(t)
+}
+
+func TestWarningService_Evaluate_AnnouncesExpiredLinksOnce(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := new(MockWarningRepository)
+	bus := events.NewBus()
+	var announced []string
+	events.Subscribe(bus, func(ctx context.Context, e events.URLExpired) {
+		announced = append(announced, e.URL.ShortCode)
+	})
+
+	service := NewWarningService(mockRepo, new(MockNotifier), 72*time.Hour).WithEvents(bus)
+
+	expiredAt := time.Now().Add(-time.Minute)
+	expired := &domain.URL{ID: "1", ShortCode: "gone", ExpiresAt: &expiredAt}
+	alreadyAnnounced := &domain.URL{ID: "2", ShortCode: "dup", ExpiresAt: &expiredAt}
+
+	mockRepo.On("FindNearClickLimit", ctx, mock.Anything, mock.Anything, mock.Anything).Return([]*domain.URL{}, nil)
+	mockRepo.On("FindExpiringBefore", ctx, mock.Anything, domain.WarningExpiringSoon, mock.Anything).Return([]*domain.URL{}, nil)
+	mockRepo.On("FindExpiredSince", ctx, mock.MatchedBy(func(since time.Time) bool {
+		return time.Since(since) > 23*time.Hour // Only recently expired links
+	}), domain.WarningExpired, mock.Anything).Return([]*domain.URL{expired, alreadyAnnounced}, nil)
+	mockRepo.On("MarkSent", ctx, "1", domain.WarningExpired).Return(true, nil)
+	mockRepo.On("MarkSent", ctx, "2", domain.WarningExpired).Return(false, nil) // another instance won
+
+	// Act
+	_, err := service.Evaluate(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gone"}, announced)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// WorkspaceService manages the caller's workspace settings
+// A workspace is the principal that owns links, so every caller manages
+// exactly one: their own (the routes require authentication). Only plans
+// are managed by operators, for any workspace (SetPlan)
+type WorkspaceService struct {
+	settings repository.WorkspaceSettingsRepository
+}
+
+// NewWorkspaceService creates a workspace service
+func NewWorkspaceService(settings repository.WorkspaceSettingsRepository) *WorkspaceService {
+	return &WorkspaceService{settings: settings}
+}
+
+// GetSettings returns the caller's settings
+// A workspace that never saved any gets the defaults, not an error
+func (s *WorkspaceService) GetSettings(ctx context.Context) (*domain.WorkspaceSettings, error) {
+	principal := auth.FromContext(ctx)
+	if principal == auth.Anonymous {
+		return nil, domain.ErrForbidden
+	}
+	workspace := principal.ID
+	settings, err := s.settings.Get(ctx, workspace)
+	if errors.Is(err, domain.ErrWorkspaceSettingsNotFound) {
+		return &domain.WorkspaceSettings{Workspace: workspace}, nil
+	}
+	return settings, err
+}
+
+// SaveSettings replaces the caller's settings
+// Owners only: a contributor must not be able to switch approvals off, or
+// map their own group to a better role
+func (s *WorkspaceService) SaveSettings(ctx context.Context, settings *domain.WorkspaceSettings) error {
+	principal := auth.FromContext(ctx)
+	if principal == auth.Anonymous || principal.EffectiveRole() != auth.RoleOwner {
+		return domain.ErrForbidden
+	}
+	settings.Workspace = principal.ID
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	for _, role := range settings.GroupRoles {
+		// "" would parse as owner: a mapping must name its role
+		if _, err := auth.ParseRole(role); err != nil || role == "" {
+			return domain.ErrInvalidGroupRoles
+		}
+	}
+	return s.settings.Save(ctx, settings)
+}
+
+// SetPlan puts any workspace on a quota plan ("" = the default plan)
+// Operators only: it's how a workspace gets a plan without Stripe. The
+// workspace's credentials carry the new plan from their next request on
+func (s *WorkspaceService) SetPlan(ctx context.Context, workspace, plan string) error {
+	if !auth.FromContext(ctx).Admin {
+		return domain.ErrForbidden
+	}
+	if !domain.ValidPlanName(plan) {
+		return domain.ErrUnknownPlan
+	}
+	return s.settings.SetPlan(ctx, workspace, plan)
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockWorkspaceSettingsRepository is a mock implementation of repository.WorkspaceSettingsRepository
+type MockWorkspaceSettingsRepository struct {
+	mock.Mock
+}
+
+func (m *MockWorkspaceSettingsRepository) Get(ctx context.Context, workspace string) (*domain.WorkspaceSettings, error) {
+	args := m.Called(ctx, workspace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WorkspaceSettings), args.Error(1)
+}
+
+func (m *MockWorkspaceSettingsRepository) Save(ctx context.Context, settings *domain.WorkspaceSettings) error {
+	args := m.Called(ctx, settings)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceSettingsRepository) SetPlan(ctx context.Context, workspace, plan string) error {
+	args := m.Called(ctx, workspace, plan)
+	return args.Error(0)
+}
+
+func TestWorkspaceService_GetSettings(t *testing.T) {
+	tests := []struct {
+		name      string
+		stored    *domain.WorkspaceSettings
+		storedErr error
+		expected  *domain.WorkspaceSettings
+		expectErr error
+	}{
+		{
+			name:     "saved settings",
+			stored:   &domain.WorkspaceSettings{Workspace: "user1", Timezone: "Europe/Berlin"},
+			expected: &domain.WorkspaceSettings{Workspace: "user1", Timezone: "Europe/Berlin"},
+		},
+		{
+			name:      "never saved gets the defaults",
+			storedErr: domain.ErrWorkspaceSettingsNotFound,
+			expected:  &domain.WorkspaceSettings{Workspace: "user1"},
+		},
+		{name: "database down", storedErr: assert.AnError, expectErr: assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+			repo := new(MockWorkspaceSettingsRepository)
+			repo.On("Get", ctx, "user1").Return(tt.stored, tt.storedErr)
+
+			// Act
+			settings, err := NewWorkspaceService(repo).GetSettings(ctx)
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, settings)
+		})
+	}
+}
+
+func TestWorkspaceService_SaveSettings(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		timezone  string
+		expectErr error
+	}{
+		{name: "timezone", principal: &auth.Principal{ID: "user1"}, timezone: "America/New_York"},
+		{name: "back to the default", principal: &auth.Principal{ID: "user1"}, timezone: ""},
+		{name: "unknown timezone", principal: &auth.Principal{ID: "user1"}, timezone: "Mars/Olympus", expectErr: domain.ErrInvalidTimezone},
+		{name: "server local time", principal: &auth.Principal{ID: "user1"}, timezone: "Local", expectErr: domain.ErrInvalidTimezone},
+		{name: "anonymous", principal: nil, timezone: "UTC", expectErr: domain.ErrForbidden},
+		{name: "explicit owner", principal: &auth.Principal{ID: "user1", Member: "olivia@example.com", Role: auth.RoleOwner}, timezone: "UTC"},
+		{name: "editor", principal: &auth.Principal{ID: "user1", Member: "erin@example.com", Role: auth.RoleEditor}, timezone: "UTC", expectErr: domain.ErrForbidden},
+		{name: "contributor", principal: &auth.Principal{ID: "user1", Member: "dana@example.com", Role: auth.RoleContributor}, timezone: "UTC", expectErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+			repo := new(MockWorkspaceSettingsRepository)
+			if tt.expectErr == nil {
+				repo.On("Save", ctx, mock.MatchedBy(func(s *domain.WorkspaceSettings) bool {
+					return s.Workspace == "user1" && s.Timezone == tt.timezone
+				})).Return(nil)
+			}
+
+			// Act
+			err := NewWorkspaceService(repo).SaveSettings(ctx, &domain.WorkspaceSettings{Workspace: "someone-else", Timezone: tt.timezone})
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectErr)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWorkspaceService_SaveSettings_GroupRoles(t *testing.T) {
+	tooMany := make(map[string]string, domain.MaxGroupRoles+1)
+	for i := range domain.MaxGroupRoles + 1 {
+		tooMany[fmt.Sprintf("group-%d", i)] = "viewer"
+	}
+
+	tests := []struct {
+		name       string
+		groupRoles map[string]string
+		expectErr  error
+	}{
+		{name: "roles", groupRoles: map[string]string{"Marketing": "editor", "Interns": "contributor", "Everyone": "viewer"}},
+		{name: "no mapping", groupRoles: nil},
+		{name: "unknown role", groupRoles: map[string]string{"Marketing": "admin"}, expectErr: domain.ErrInvalidGroupRoles},
+		{name: "empty role", groupRoles: map[string]string{"Marketing": ""}, expectErr: domain.ErrInvalidGroupRoles},
+		{name: "empty group name", groupRoles: map[string]string{" ": "viewer"}, expectErr: domain.ErrInvalidGroupRoles},
+		{name: "too many groups", groupRoles: tooMany, expectErr: domain.ErrInvalidGroupRoles},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+			repo := new(MockWorkspaceSettingsRepository)
+			if tt.expectErr == nil {
+				repo.On("Save", ctx, mock.Anything).Return(nil)
+			}
+
+			// Act
+			err := NewWorkspaceService(repo).SaveSettings(ctx, &domain.WorkspaceSettings{GroupRoles: tt.groupRoles})
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectErr)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWorkspaceService_SetPlan(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		plan      string
+		expectErr error
+	}{
+		{name: "operator sets pro", principal: &auth.Principal{ID: "admin", Admin: true}, plan: domain.PlanPro},
+		{name: "operator resets to the default", principal: &auth.Principal{ID: "admin", Admin: true}, plan: ""},
+		{name: "unknown plan", principal: &auth.Principal{ID: "admin", Admin: true}, plan: "enterprise", expectErr: domain.ErrUnknownPlan},
+		{name: "owners can't upgrade themselves", principal: &auth.Principal{ID: "team1", Role: auth.RoleOwner}, plan: domain.PlanPro, expectErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			repo := new(MockWorkspaceSettingsRepository)
+			repo.On("SetPlan", ctx, "team1", tt.plan).Return(nil)
+
+			// Act
+			err := NewWorkspaceService(repo).SetPlan(ctx, "team1", tt.plan)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.expectErr)
+			if tt.expectErr == nil {
+				repo.AssertExpectations(t)
+			} else {
+				repo.AssertNotCalled(t, "SetPlan", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestGetClickTimeseries(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// 2026-03-29 is the day Berlin switches to summer time (23 hours long)
+	now := time.Date(2026, 3, 30, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		query            domain.TimeseriesQuery
+		workspace        *domain.WorkspaceSettings
+		expectedLocation *time.Location
+		expectedFrom     time.Time
+		expectedTo       time.Time
+		expectedBuckets  int
+		expectErr        error
+	}{
+		{
+			name:             "requested timezone wins",
+			query:            domain.TimeseriesQuery{Timezone: "America/New_York", From: "2026-03-28", To: "2026-03-30"},
+			workspace:        &domain.WorkspaceSettings{Workspace: "user1", Timezone: "Europe/Berlin"},
+			expectedLocation: newYork,
+			expectedFrom:     time.Date(2026, 3, 28, 0, 0, 0, 0, newYork),
+			expectedTo:       time.Date(2026, 3, 31, 0, 0, 0, 0, newYork),
+			expectedBuckets:  3,
+		},
+		{
+			name:             "workspace default",
+			query:            domain.TimeseriesQuery{From: "2026-03-28", To: "2026-03-30"},
+			workspace:        &domain.WorkspaceSettings{Workspace: "user1", Timezone: "Europe/Berlin"},
+			expectedLocation: berlin,
+			expectedFrom:     time.Date(2026, 3, 28, 0, 0, 0, 0, berlin),
+			expectedTo:       time.Date(2026, 3, 31, 0, 0, 0, 0, berlin),
+			expectedBuckets:  3,
+		},
+		{
+			name:             "hours across the DST switch",
+			query:            domain.TimeseriesQuery{Interval: domain.IntervalHour, From: "2026-03-29", To: "2026-03-29T23:30:00+02:00"},
+			workspace:        &domain.WorkspaceSettings{Workspace: "user1", Timezone: "Europe/Berlin"},
+			expectedLocation: berlin,
+			expectedFrom:     time.Date(2026, 3, 29, 0, 0, 0, 0, berlin),
+			expectedTo:       time.Date(2026, 3, 30, 0, 0, 0, 0, berlin),
+			expectedBuckets:  23, // 02:00 doesn't exist that night
+		},
+		{
+			name:             "no settings uses the server default",
+			query:            domain.TimeseriesQuery{Interval: domain.IntervalWeek},
+			expectedLocation: time.UTC,
+			expectedFrom:     time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC), // 12 Mondays back
+			expectedTo:       time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC),
+			expectedBuckets:  12,
+		},
+		{name: "unknown timezone", query: domain.TimeseriesQuery{Timezone: "Mars/Olympus"}, expectErr: domain.ErrInvalidTimezone},
+		{name: "unknown interval", query: domain.TimeseriesQuery{Interval: "minute"}, expectErr: domain.ErrInvalidInterval},
+		{name: "from after to", query: domain.TimeseriesQuery{From: "2026-03-30", To: "2026-03-01"}, expectErr: domain.ErrInvalidTimeRange},
+		{name: "too many buckets", query: domain.TimeseriesQuery{Interval: domain.IntervalHour, From: "2025-01-01"}, expectErr: domain.ErrInvalidTimeRange},
+		{name: "bad date", query: domain.TimeseriesQuery{From: "last week"}, expectErr: domain.ErrInvalidTimeRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+			mockURLRepo := new(MockURLRepository)
+			mockClickRepo := new(MockClickRepository)
+			workspaces := new(MockWorkspaceSettingsRepository)
+			service := NewURLService(mockURLRepo, mockClickRepo).WithWorkspaceSettings(workspaces)
+			service.now = func() time.Time { return now }
+
+			url := &domain.URL{ID: "123", ShortCode: "abc123", CreatedBy: "user1"}
+			mockURLRepo.On("GetByShortCode", ctx, "abc123").Return(url, nil)
+			if tt.workspace != nil {
+				workspaces.On("Get", ctx, "user1").Return(tt.workspace, nil).Maybe()
+			} else {
+				workspaces.On("Get", ctx, "user1").Return(nil, domain.ErrWorkspaceSettingsNotFound).Maybe()
+			}
+			if tt.expectErr == nil {
+				mockClickRepo.On("CountByPeriod", ctx, "123", mock.Anything, tt.expectedLocation, tt.expectedFrom, tt.expectedTo).
+					Return([]domain.ClickBucket{{Start: tt.expectedFrom, Clicks: 5}}, nil)
+			}
+
+			// Act
+			series, err := service.GetClickTimeseries(ctx, "abc123", tt.query)
+
+			// Assert
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
+				mockClickRepo.AssertNotCalled(t, "CountByPeriod", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedLocation, series.Location)
+			require.Len(t, series.Buckets, tt.expectedBuckets)
+			assert.Equal(t, int64(5), series.Buckets[0].Clicks)
+			for _, bucket := range series.Buckets[1:] {
+				assert.Zero(t, bucket.Clicks, "gaps are filled with zeros")
+			}
+			mockClickRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetClickTimeseries_MergesShardBuckets(t *testing.T) {
+	// Arrange: the same day comes back twice (one row per shard)
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "user1"})
+	mockURLRepo := new(MockURLRepository)
+	mockClickRepo := new(MockClickRepository)
+	service := NewURLService(mockURLRepo, mockClickRepo)
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mockURLRepo.On("GetByShortCode", ctx, "abc123").Return(&domain.URL{ID: "123", CreatedBy: "user1"}, nil)
+	mockClickRepo.On("CountByPeriod", ctx, "123", domain.IntervalDay, time.UTC, day, day.AddDate(0, 0, 1)).
+		Return([]domain.ClickBucket{{Start: day, Clicks: 2}, {Start: day, Clicks: 3}}, nil)
+
+	// Act
+	series, err := service.GetClickTimeseries(ctx, "abc123", domain.TimeseriesQuery{From: "2026-03-01", To: "2026-03-01"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ClickBucket{{Start: day, Clicks: 5}}, series.Buckets)
+}
@@ -0,0 +1,435 @@
+package shard
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+// URLRepository routes every call to the shard that stores the link
+//
+// Calls that carry a short code (redirects, creation, alias checks) go
+// straight to one shard. Calls that only carry the link ID (management
+// endpoints, metadata) ask the shards in order until one knows the link -
+// IDs are UUIDs chosen by the database and say nothing about the shard.
+// Those calls are rare, so the extra queries stay off the hot path.
+type URLRepository struct {
+	shards []repository.URLRepository // Indexed like the Map
+	m      *Map
+}
+
+// NewURLRepository routes between shards (one repository per m.Names(), in order)
+func NewURLRepository(m *Map, shards []repository.URLRepository) *URLRepository {
+	return &URLRepository{shards: shards, m: m}
+}
+
+func (r *URLRepository) Create(ctx context.Context, url *domain.URL) error {
+	return r.shards[r.m.For(url.ShortCode)].Create(ctx, url)
+}
+
+func (r *URLRepository) GetByShortCode(ctx context.Context, shortCode string) (*domain.URL, error) {
+	return r.shards[r.m.For(shortCode)].GetByShortCode(ctx, shortCode)
+}
+
+func (r *URLRepository) GetByCustomAlias(ctx context.Context, alias string) (*domain.URL, error) {
+	return r.shards[r.m.For(alias)].GetByCustomAlias(ctx, alias)
+}
+
+func (r *URLRepository) Update(ctx context.Context, url *domain.URL) error {
+	return r.shards[r.m.For(url.ShortCode)].Update(ctx, url)
+}
+
+func (r *URLRepository) IncrementClicks(ctx context.Context, shortCode string) error {
+	return r.shards[r.m.For(shortCode)].IncrementClicks(ctx, shortCode)
+}
+
+func (r *URLRepository) MarkUsed(ctx context.Context, shortCode string) (bool, error) {
+	return r.shards[r.m.For(shortCode)].MarkUsed(ctx, shortCode)
+}
+
+func (r *URLRepository) Burn(ctx context.Context, shortCode string) (*string, error) {
+	return r.shards[r.m.For(shortCode)].Burn(ctx, shortCode)
+}
+
+func (r *URLRepository) ExistsShortCode(ctx context.Context, shortCode string) (bool, error) {
+	return r.shards[r.m.For(shortCode)].ExistsShortCode(ctx, shortCode)
+}
+
+func (r *URLRepository) ExistsCustomAlias(ctx context.Context, alias string) (bool, error) {
+	return r.shards[r.m.For(alias)].ExistsCustomAlias(ctx, alias)
+}
+
+// FindTakenCodes asks each shard about its own codes only
+func (r *URLRepository) FindTakenCodes(ctx context.Context, codes []string) (map[string]bool, error) {
+	byShard := make(map[int][]string)
+	for _, code := range codes {
+		i := r.m.For(code)
+		byShard[i] = append(byShard[i], code)
+	}
+
+	taken := make(map[string]bool)
+	for i, shardCodes := range byShard {
+		found, err := r.shards[i].FindTakenCodes(ctx, shardCodes)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(taken, found)
+	}
+	return taken, nil
+}
+
+func (r *URLRepository) GetByID(ctx context.Context, id string) (*domain.URL, error) {
+	var url *domain.URL
+	err := r.findByID(func(shard repository.URLRepository) error {
+		var err error
+		url, err = shard.GetByID(ctx, id)
+		return err
+	})
+	return url, err
+}
+
+func (r *URLRepository) SetMetadata(ctx context.Context, id string, meta *domain.LinkMetadata) error {
+	return r.findByID(func(shard repository.URLRepository) error {
+		return shard.SetMetadata(ctx, id, meta)
+	})
+}
+
+func (r *URLRepository) Delete(ctx context.Context, id string) error {
+	return r.findByID(func(shard repository.URLRepository) error {
+		return shard.Delete(ctx, id)
+	})
+}
+
+func (r *URLRepository) Restore(ctx context.Context, id string) error {
+	return r.findByID(func(shard repository.URLRepository) error {
+		return shard.Restore(ctx, id)
+	})
+}
+
+func (r *URLRepository) Purge(ctx context.Context, id string) (int64, error) {
+	var clicks int64
+	err := r.findByID(func(shard repository.URLRepository) error {
+		var err error
+		clicks, err = shard.Purge(ctx, id)
+		return err
+	})
+	return clicks, err
+}
+
+// findByID runs call on each shard until one doesn't answer "not found"
+// Returns the last "not found" if no shard has the link
+func (r *URLRepository) findByID(call func(repository.URLRepository) error) error {
+	var err error
+	for _, shard := range r.shards {
+		err = call(shard)
+		if !errors.Is(err, domain.ErrURLNotFound) {
+			return err
+		}
+	}
+	return err
+}
+
+// ClickRepository stores clicks on the shard of their link
+// Writes are routed by URLClick.ShortCode; reads only know the link ID and
+// combine the shards (only one of them has clicks for a given link)
+type ClickRepository struct {
+	shards []repository.ClickRepository
+	m      *Map
+}
+
+// NewClickRepository routes between shards (one repository per m.Names(), in order)
+func NewClickRepository(m *Map, shards []repository.ClickRepository) *ClickRepository {
+	return &ClickRepository{shards: shards, m: m}
+}
+
+func (r *ClickRepository) Create(ctx context.Context, click *domain.URLClick) error {
+	return r.shards[r.m.For(click.ShortCode)].Create(ctx, click)
+}
+
+// GetByURLID returns the first shard's non-empty page
+func (r *ClickRepository) GetByURLID(ctx context.Context, urlID string, limit, offset int) ([]*domain.URLClick, error) {
+	for _, shard := range r.shards {
+		clicks, err := shard.GetByURLID(ctx, urlID, limit, offset)
+		if err != nil || len(clicks) > 0 {
+			return clicks, err
+		}
+	}
+	return nil, nil
+}
+
+func (r *ClickRepository) GetClickCount(ctx context.Context, urlID string) (int64, error) {
+	var total int64
+	for _, shard := range r.shards {
+		count, err := shard.GetClickCount(ctx, urlID)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+func (r *ClickRepository) CountBy(ctx context.Context, urlID string, dimension domain.ClickDimension) (map[string]int64, error) {
+	totals := make(map[string]int64)
+	for _, shard := range r.shards {
+		counts, err := shard.CountBy(ctx, urlID, dimension)
+		if err != nil {
+			return nil, err
+		}
+		for value, count := range counts {
+			totals[value] += count
+		}
+	}
+	return totals, nil
+}
+
+// CountByPeriod concatenates the buckets of every shard
+// A bucket can come back from several shards; domain.FillBuckets adds them up
+func (r *ClickRepository) CountByPeriod(ctx context.Context, urlID string, interval domain.Interval, location *time.Location, from, to time.Time) ([]domain.ClickBucket, error) {
+	var buckets []domain.ClickBucket
+	for _, shard := range r.shards {
+		counted, err := shard.CountByPeriod(ctx, urlID, interval, location, from, to)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, counted...)
+	}
+	return buckets, nil
+}
+
+// CountByHourOfWeek concatenates the cells of every shard
+// A cell can come back from several shards; ClickHeatmap.AddCells adds them up
+func (r *ClickRepository) CountByHourOfWeek(ctx context.Context, urlID string, location *time.Location, from, to time.Time) ([]domain.HourOfWeek, error) {
+	var cells []domain.HourOfWeek
+	for _, shard := range r.shards {
+		counted, err := shard.CountByHourOfWeek(ctx, urlID, location, from, to)
+		if err != nil {
+			return nil, err
+		}
+		cells = append(cells, counted...)
+	}
+	return cells, nil
+}
+
+// TopLinks merges the top links of every shard
+// A link and its clicks live on one shard, so each shard's top limit
+// contains every link that can make the overall top limit
+func (r *ClickRepository) TopLinks(ctx context.Context, owner string, since time.Time, limit int) ([]domain.TopLink, error) {
+	var links []domain.TopLink
+	for _, shard := range r.shards {
+		top, err := shard.TopLinks(ctx, owner, since, limit)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, top...)
+	}
+	return domain.RankTopLinks(links, limit), nil
+}
+
+// ExportRepository merges the export pages of all shards
+type ExportRepository struct {
+	shards []repository.ExportRepository
+}
+
+// NewExportRepository merges exports of shards
+func NewExportRepository(shards []repository.ExportRepository) *ExportRepository {
+	return &ExportRepository{shards: shards}
+}
+
+// ListForExport reads one page per shard after the same cursor and keeps the
+// first limit records in (created_at, id) order - the next cursor then
+// continues correctly on every shard
+func (r *ExportRepository) ListForExport(ctx context.Context, owner string, after *domain.ExportCursor, limit int) ([]*domain.ExportRecord, error) {
+	var records []*domain.ExportRecord
+	for _, shard := range r.shards {
+		page, err := shard.ListForExport(ctx, owner, after, limit)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, page...)
+	}
+
+	slices.SortFunc(records, func(a, b *domain.ExportRecord) int {
+		if c := a.URL.CreatedAt.Compare(b.URL.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.URL.ID, b.URL.ID)
+	})
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// ListInventory merges one page per shard, like ListForExport
+func (r *ExportRepository) ListInventory(ctx context.Context, owner string, after *domain.ExportCursor, limit int) ([]*domain.URL, error) {
+	var urls []*domain.URL
+	for _, shard := range r.shards {
+		page, err := shard.ListInventory(ctx, owner, after, limit)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, page...)
+	}
+
+	slices.SortFunc(urls, func(a, b *domain.URL) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	if len(urls) > limit {
+		urls = urls[:limit]
+	}
+	return urls, nil
+}
+
+// SearchRepository merges the hits of all shards
+type SearchRepository struct {
+	shards []repository.SearchRepository
+}
+
+// NewSearchRepository searches every shard
+func NewSearchRepository(shards []repository.SearchRepository) *SearchRepository {
+	return &SearchRepository{shards: shards}
+}
+
+// Search asks every shard for everything up to the end of the page, then
+// ranks the hits together and cuts the page out
+// Ranks are comparable across shards: ts_rank_cd only looks at the link.
+func (r *SearchRepository) Search(ctx context.Context, q domain.SearchQuery) (*domain.SearchResult, error) {
+	perShard := q
+	perShard.Limit = q.Offset + q.Limit
+	perShard.Offset = 0
+
+	merged := &domain.SearchResult{}
+	for _, shard := range r.shards {
+		result, err := shard.Search(ctx, perShard)
+		if err != nil {
+			return nil, err
+		}
+		merged.Hits = append(merged.Hits, result.Hits...)
+		merged.Total += result.Total
+	}
+
+	slices.SortFunc(merged.Hits, func(a, b *domain.SearchHit) int {
+		if c := cmp.Compare(b.Rank, a.Rank); c != 0 {
+			return c
+		}
+		if c := b.URL.CreatedAt.Compare(a.URL.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.URL.ID, b.URL.ID)
+	})
+	if q.Offset >= len(merged.Hits) {
+		merged.Hits = nil
+		return merged, nil
+	}
+	merged.Hits = merged.Hits[q.Offset:min(q.Offset+q.Limit, len(merged.Hits))]
+	return merged, nil
+}
+
+// ErasureRepository keeps erasure requests on the primary and deletes the
+// owner's links on every shard
+type ErasureRepository struct {
+	repository.ErasureRepository                                // The primary: requests and its own links
+	shards                       []repository.ErasureRepository // Index 0 is the primary
+}
+
+// NewErasureRepository erases across shards (one repository per Map name, in order)
+func NewErasureRepository(shards []repository.ErasureRepository) *ErasureRepository {
+	return &ErasureRepository{ErasureRepository: shards[0], shards: shards}
+}
+
+// DeleteOwnerBatch fills one batch from the shards in order
+// The worker stops after a short batch, so a batch may only come back short
+// once EVERY shard is out of links
+func (r *ErasureRepository) DeleteOwnerBatch(ctx context.Context, owner string, limit int) ([]*domain.URL, int64, error) {
+	var urls []*domain.URL
+	var clicks int64
+	for _, shard := range r.shards {
+		if len(urls) >= limit {
+			break
+		}
+		deleted, deletedClicks, err := shard.DeleteOwnerBatch(ctx, owner, limit-len(urls))
+		if err != nil {
+			return urls, clicks, err
+		}
+		urls = append(urls, deleted...)
+		clicks += deletedClicks
+	}
+	return urls, clicks, nil
+}
+
+func (r *ErasureRepository) CountOwnerURLs(ctx context.Context, owner string) (int64, error) {
+	var total int64
+	for _, shard := range r.shards {
+		count, err := shard.CountOwnerURLs(ctx, owner)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// PopularAliasRepository merges the most clicked aliases of every shard
+type PopularAliasRepository struct {
+	shards []repository.PopularAliasRepository
+}
+
+// NewPopularAliasRepository reads the aliases of every shard
+func NewPopularAliasRepository(shards []repository.PopularAliasRepository) *PopularAliasRepository {
+	return &PopularAliasRepository{shards: shards}
+}
+
+// PopularAliases asks every shard for its top limit, then keeps the overall top limit
+func (r *PopularAliasRepository) PopularAliases(ctx context.Context, limit int) ([]domain.PopularAlias, error) {
+	var merged []domain.PopularAlias
+	for _, shard := range r.shards {
+		aliases, err := shard.PopularAliases(ctx, limit)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, aliases...)
+	}
+
+	slices.SortStableFunc(merged, func(a, b domain.PopularAlias) int {
+		return cmp.Compare(b.Clicks, a.Clicks)
+	})
+	return merged[:min(limit, len(merged))], nil
+}
+
+// ApprovalRepository merges the approval queues of every shard
+// A workspace's links are spread over all shards (the code picks the shard)
+type ApprovalRepository struct {
+	shards []repository.ApprovalRepository
+}
+
+// NewApprovalRepository reads the pending links of every shard
+func NewApprovalRepository(shards []repository.ApprovalRepository) *ApprovalRepository {
+	return &ApprovalRepository{shards: shards}
+}
+
+// ListPending asks every shard for its oldest limit, then keeps the overall oldest limit
+func (r *ApprovalRepository) ListPending(ctx context.Context, workspace string, limit int) ([]*domain.URL, error) {
+	var merged []*domain.URL
+	for _, shard := range r.shards {
+		urls, err := shard.ListPending(ctx, workspace, limit)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, urls...)
+	}
+
+	slices.SortStableFunc(merged, func(a, b *domain.URL) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return merged[:min(limit, len(merged))], nil
+}
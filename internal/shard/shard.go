@@ -0,0 +1,92 @@
+// Package shard spreads links over several PostgreSQL databases
+//
+// HOW A LINK FINDS ITS DATABASE:
+// The FIRST CHARACTER of a short code is its shard prefix. The shard map
+// assigns prefix characters to shards; every character nobody claims stays
+// on the primary database. With
+//
+//	SHARD_PREFIXES=eu=abcdefghijklm,us=nopqrstuvwxyz
+//
+// "kX9a2b" lives on shard eu, "pQ3z" on shard us and "7Hh2k" on the primary.
+// Generated codes pick their first character uniformly from the alphabet,
+// so each shard gets a share of new links proportional to the characters it
+// owns. Custom aliases follow the same rule.
+//
+// Routing is a pure function of the code: no lookup table, no extra query,
+// and the same code always lands on the same database - which is also what
+// keeps aliases unique across shards. The cache is keyed by code too, so it
+// needs no changes; clicks are stored next to their link.
+//
+// THE MAP IS PART OF THE DATA:
+// Moving a prefix to another shard without moving its rows makes those
+// links disappear. Plan the prefixes up front.
+package shard
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Primary is the name of the shard on the main database (DB_* settings)
+const Primary = "primary"
+
+var (
+	ErrUnknownShard    = errors.New("shard prefix refers to a shard without a database")
+	ErrDuplicatePrefix = errors.New("prefix character is assigned to more than one shard")
+)
+
+// Map assigns short codes to shards
+// Shard 0 is always the primary; the others follow sorted by name
+type Map struct {
+	names  []string
+	prefix map[byte]int // First character -> shard index
+}
+
+// NewMap builds a shard map
+// shards are the names of the extra databases; prefixes maps a shard name to
+// the characters it owns (each character may only be owned once)
+func NewMap(shards []string, prefixes map[string]string) (*Map, error) {
+	names := []string{Primary}
+	extra := slices.Clone(shards)
+	slices.Sort(extra)
+	names = append(names, extra...)
+
+	m := &Map{names: names, prefix: make(map[byte]int)}
+	for name, chars := range prefixes {
+		index := slices.Index(names, name)
+		if index < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownShard, name)
+		}
+		for i := 0; i < len(chars); i++ {
+			if owner, taken := m.prefix[chars[i]]; taken && owner != index {
+				return nil, fmt.Errorf("%w: %q", ErrDuplicatePrefix, chars[i])
+			}
+			m.prefix[chars[i]] = index
+		}
+	}
+	return m, nil
+}
+
+// Len returns the number of shards, primary included
+func (m *Map) Len() int {
+	return len(m.names)
+}
+
+// Name returns the name of shard i
+func (m *Map) Name(i int) string {
+	return m.names[i]
+}
+
+// Names returns the shard names in index order
+func (m *Map) Names() []string {
+	return slices.Clone(m.names)
+}
+
+// For returns the index of the shard that stores code
+func (m *Map) For(code string) int {
+	if code == "" {
+		return 0
+	}
+	return m.prefix[code[0]] // Unclaimed characters map to 0, the primary
+}
@@ -0,0 +1,296 @@
+package shard
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockURLRepository is a mock implementation of URLRepository
+// Only the methods the tests route are mocked; the rest are never called
+type MockURLRepository struct {
+	mock.Mock
+	repository.URLRepository
+}
+
+func (m *MockURLRepository) Create(ctx context.Context, url *domain.URL) error {
+	args := m.Called(ctx, url)
+	return args.Error(0)
+}
+
+func (m *MockURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*domain.URL, error) {
+	args := m.Called(ctx, shortCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) GetByID(ctx context.Context, id string) (*domain.URL, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) FindTakenCodes(ctx context.Context, codes []string) (map[string]bool, error) {
+	args := m.Called(ctx, codes)
+	return args.Get(0).(map[string]bool), args.Error(1)
+}
+
+// MockErasureRepository is a mock implementation of ErasureRepository
+type MockErasureRepository struct {
+	mock.Mock
+	repository.ErasureRepository
+}
+
+func (m *MockErasureRepository) DeleteOwnerBatch(ctx context.Context, owner string, limit int) ([]*domain.URL, int64, error) {
+	args := m.Called(ctx, owner, limit)
+	return args.Get(0).([]*domain.URL), args.Get(1).(int64), args.Error(2)
+}
+
+func testMap(t *testing.T) *Map {
+	t.Helper()
+	m, err := NewMap([]string{"us", "eu"}, map[string]string{"eu": "abc", "us": "xyz"})
+	require.NoError(t, err)
+	return m
+}
+
+func TestMap_For(t *testing.T) {
+	m := testMap(t)
+	assert.Equal(t, []string{"primary", "eu", "us"}, m.Names())
+
+	tests := []struct {
+		code     string
+		expected string
+	}{
+		{code: "aX9k2", expected: "eu"},
+		{code: "cab", expected: "eu"},
+		{code: "zzz", expected: "us"},
+		{code: "Abc12", expected: "primary"}, // Prefixes are case-sensitive
+		{code: "7Hh2k", expected: "primary"},
+		{code: "", expected: "primary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			assert.Equal(t, tt.expected, m.Name(m.For(tt.code)))
+		})
+	}
+}
+
+func TestNewMap_Rejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		shards   []string
+		prefixes map[string]string
+		expected error
+	}{
+		{name: "prefix for a shard without a database", shards: []string{"eu"}, prefixes: map[string]string{"us": "x"}, expected: ErrUnknownShard},
+		{name: "character owned twice", shards: []string{"eu", "us"}, prefixes: map[string]string{"eu": "ab", "us": "bc"}, expected: ErrDuplicatePrefix},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMap(tt.shards, tt.prefixes)
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestURLRepository_RoutesByCode(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	primary, eu, us := new(MockURLRepository), new(MockURLRepository), new(MockURLRepository)
+	repo := NewURLRepository(testMap(t), []repository.URLRepository{primary, eu, us})
+
+	url := &domain.URL{ShortCode: "aX9k2"}
+	eu.On("Create", ctx, url).Return(nil)
+	us.On("GetByShortCode", ctx, "xyz").Return(&domain.URL{ShortCode: "xyz"}, nil)
+	primary.On("FindTakenCodes", ctx, []string{"P1", "Q2"}).Return(map[string]bool{"Q2": true}, nil)
+	eu.On("FindTakenCodes", ctx, []string{"b7"}).Return(map[string]bool{"b7": true}, nil)
+
+	// Act
+	createErr := repo.Create(ctx, url)
+	found, getErr := repo.GetByShortCode(ctx, "xyz")
+	taken, takenErr := repo.FindTakenCodes(ctx, []string{"P1", "b7", "Q2"})
+
+	// Assert
+	require.NoError(t, createErr)
+	require.NoError(t, getErr)
+	require.NoError(t, takenErr)
+	assert.Equal(t, "xyz", found.ShortCode)
+	assert.Equal(t, map[string]bool{"Q2": true, "b7": true}, taken)
+	primary.AssertExpectations(t)
+	eu.AssertExpectations(t)
+	us.AssertExpectations(t)
+}
+
+func TestURLRepository_GetByIDAsksEveryShard(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	primary, eu, us := new(MockURLRepository), new(MockURLRepository), new(MockURLRepository)
+	repo := NewURLRepository(testMap(t), []repository.URLRepository{primary, eu, us})
+
+	notFound := fmt.Errorf("%w: 123", domain.ErrURLNotFound)
+	primary.On("GetByID", ctx, "123").Return(nil, notFound)
+	eu.On("GetByID", ctx, "123").Return(&domain.URL{ID: "123"}, nil)
+
+	// Act
+	url, err := repo.GetByID(ctx, "123")
+	_, missingErr := NewURLRepository(testMap(t), []repository.URLRepository{primary, primary, primary}).GetByID(ctx, "123")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "123", url.ID)
+	us.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	assert.ErrorIs(t, missingErr, domain.ErrURLNotFound)
+}
+
+func TestErasureRepository_FillsBatchesAcrossShards(t *testing.T) {
+	// Arrange: the primary has 1 link left, eu 2, us none
+	ctx := context.Background()
+	primary, eu, us := new(MockErasureRepository), new(MockErasureRepository), new(MockErasureRepository)
+	repo := NewErasureRepository([]repository.ErasureRepository{primary, eu, us})
+
+	primary.On("DeleteOwnerBatch", ctx, "alice", 2).Return([]*domain.URL{{ID: "1"}}, int64(5), nil)
+	eu.On("DeleteOwnerBatch", ctx, "alice", 1).Return([]*domain.URL{{ID: "2"}}, int64(3), nil)
+
+	// Act
+	urls, clicks, err := repo.DeleteOwnerBatch(ctx, "alice", 2)
+
+	// Assert: a full batch, so the worker asks again
+	require.NoError(t, err)
+	assert.Len(t, urls, 2)
+	assert.Equal(t, int64(8), clicks)
+	us.AssertNotCalled(t, "DeleteOwnerBatch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// MockClickRepository is a mock implementation of ClickRepository
+type MockClickRepository struct {
+	mock.Mock
+	repository.ClickRepository
+}
+
+func (m *MockClickRepository) TopLinks(ctx context.Context, owner string, since time.Time, limit int) ([]domain.TopLink, error) {
+	args := m.Called(ctx, owner, since, limit)
+	return args.Get(0).([]domain.TopLink), args.Error(1)
+}
+
+func TestClickRepository_TopLinksMergesShards(t *testing.T) {
+	// Arrange: each shard returns its own top 2
+	ctx := context.Background()
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	eu, us := new(MockClickRepository), new(MockClickRepository)
+	repo := NewClickRepository(testMap(t), []repository.ClickRepository{us, eu})
+
+	eu.On("TopLinks", ctx, "alice", since, 2).Return([]domain.TopLink{{ShortCode: "abc1", Clicks: 9}, {ShortCode: "abc2", Clicks: 4, UniqueVisitors: 1}}, nil)
+	us.On("TopLinks", ctx, "alice", since, 2).Return([]domain.TopLink{{ShortCode: "xyz1", Clicks: 4, UniqueVisitors: 3}}, nil)
+
+	// Act
+	top, err := repo.TopLinks(ctx, "alice", since, 2)
+
+	// Assert: ranked across shards, ties broken by unique visitors
+	require.NoError(t, err)
+	require.Len(t, top, 2)
+	assert.Equal(t, "abc1", top[0].ShortCode)
+	assert.Equal(t, "xyz1", top[1].ShortCode)
+}
+
+// MockSearchRepository is a mock implementation of SearchRepository
+type MockSearchRepository struct {
+	mock.Mock
+}
+
+func (m *MockSearchRepository) Search(ctx context.Context, q domain.SearchQuery) (*domain.SearchResult, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(*domain.SearchResult), args.Error(1)
+}
+
+func TestSearchRepository_RanksAcrossShards(t *testing.T) {
+	// Arrange: page 2 (one hit per page); each shard is asked for both pages
+	ctx := context.Background()
+	eu, us := new(MockSearchRepository), new(MockSearchRepository)
+	repo := NewSearchRepository([]repository.SearchRepository{eu, us})
+	perShard := domain.SearchQuery{Terms: []string{"sale"}, Owner: "alice", Limit: 2}
+
+	eu.On("Search", ctx, perShard).Return(&domain.SearchResult{Hits: []*domain.SearchHit{
+		{URL: &domain.URL{ID: "eu-1"}, Rank: 0.9},
+		{URL: &domain.URL{ID: "eu-2"}, Rank: 0.2},
+	}, Total: 3}, nil)
+	us.On("Search", ctx, perShard).Return(&domain.SearchResult{Hits: []*domain.SearchHit{
+		{URL: &domain.URL{ID: "us-1"}, Rank: 0.5},
+	}, Total: 1}, nil)
+
+	// Act
+	result, err := repo.Search(ctx, domain.SearchQuery{Terms: []string{"sale"}, Owner: "alice", Limit: 1, Offset: 1})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "us-1", result.Hits[0].URL.ID)
+	assert.Equal(t, 4, result.Total)
+}
+
+// MockPopularAliasRepository is a mock implementation of PopularAliasRepository
+type MockPopularAliasRepository struct {
+	mock.Mock
+}
+
+func (m *MockPopularAliasRepository) PopularAliases(ctx context.Context, limit int) ([]domain.PopularAlias, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]domain.PopularAlias), args.Error(1)
+}
+
+func TestPopularAliasRepository_KeepsOverallTop(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	eu, us := new(MockPopularAliasRepository), new(MockPopularAliasRepository)
+	repo := NewPopularAliasRepository([]repository.PopularAliasRepository{eu, us})
+	eu.On("PopularAliases", ctx, 2).Return([]domain.PopularAlias{{Alias: "launch", Clicks: 50}, {Alias: "docs", Clicks: 10}}, nil)
+	us.On("PopularAliases", ctx, 2).Return([]domain.PopularAlias{{Alias: "sale", Clicks: 30}}, nil)
+
+	// Act
+	aliases, err := repo.PopularAliases(ctx, 2)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []domain.PopularAlias{{Alias: "launch", Clicks: 50}, {Alias: "sale", Clicks: 30}}, aliases)
+}
+
+// MockApprovalRepository is a mock implementation of ApprovalRepository
+type MockApprovalRepository struct {
+	mock.Mock
+}
+
+func (m *MockApprovalRepository) ListPending(ctx context.Context, workspace string, limit int) ([]*domain.URL, error) {
+	args := m.Called(ctx, workspace, limit)
+	return args.Get(0).([]*domain.URL), args.Error(1)
+}
+
+func TestApprovalRepository_KeepsOldestFirst(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	eu, us := new(MockApprovalRepository), new(MockApprovalRepository)
+	repo := NewApprovalRepository([]repository.ApprovalRepository{eu, us})
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	first := &domain.URL{ID: "1", CreatedAt: monday}
+	second := &domain.URL{ID: "2", CreatedAt: monday.Add(time.Hour)}
+	third := &domain.URL{ID: "3", CreatedAt: monday.Add(2 * time.Hour)}
+	eu.On("ListPending", ctx, "acme", 2).Return([]*domain.URL{second, third}, nil)
+	us.On("ListPending", ctx, "acme", 2).Return([]*domain.URL{first}, nil)
+
+	// Act
+	urls, err := repo.ListPending(ctx, "acme", 2)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.URL{first, second}, urls)
+}
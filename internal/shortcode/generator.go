@@ -0,0 +1,141 @@
+package shortcode
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Predefined alphabets
+const (
+	// AlphabetAlphanumeric is the default: a-z, A-Z, 0-9 (62 characters)
+	AlphabetAlphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	// AlphabetUnambiguous drops characters that are easy to confuse when a link
+	// is read aloud or retyped: 0/O/o, 1/l/I (56 characters)
+	AlphabetUnambiguous = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// Length bounds for generated codes
+// The lower bound matches domain validation, the upper bound the short_code column
+const (
+	MinLength = 3
+	MaxLength = 20
+)
+
+var (
+	ErrInvalidLength   = fmt.Errorf("short code length must be between %d and %d", MinLength, MaxLength)
+	ErrInvalidAlphabet = errors.New("alphabet must contain at least 2 distinct URL-safe characters (a-z, A-Z, 0-9, -, _)")
+)
+
+// Generator creates random short codes
+//
+// COLLISION RISK vs LINK LENGTH:
+// The keyspace is len(alphabet)^length. 62^6 is ~56 billion codes, 56^6 ~30 billion.
+// Shorter codes are nicer to share but collide sooner, so the length can be
+// tuned per domain (e.g. 4 characters on a low-volume vanity domain)
+type Generator struct {
+	alphabet      string
+	length        int
+	domainLengths map[string]int // Per-domain override of length
+}
+
+// NewGenerator creates a generator, validating the alphabet and every length
+func NewGenerator(alphabet string, length int, domainLengths map[string]int) (*Generator, error) {
+	if !validAlphabet(alphabet) {
+		return nil, ErrInvalidAlphabet
+	}
+	if length < MinLength || length > MaxLength {
+		return nil, ErrInvalidLength
+	}
+
+	normalized := make(map[string]int, len(domainLengths))
+	for host, l := range domainLengths {
+		if l < MinLength || l > MaxLength {
+			return nil, fmt.Errorf("%w (domain %s)", ErrInvalidLength, host)
+		}
+		normalized[strings.ToLower(host)] = l
+	}
+
+	return &Generator{
+		alphabet:      alphabet,
+		length:        length,
+		domainLengths: normalized,
+	}, nil
+}
+
+// Default returns a generator with the historical settings: 6 alphanumeric characters
+func Default() *Generator {
+	g, _ := NewGenerator(AlphabetAlphanumeric, 6, nil)
+	return g
+}
+
+// LengthFor returns the code length to use for a domain ("" = default domain)
+func (g *Generator) LengthFor(domain string) int {
+	if l, ok := g.domainLengths[strings.ToLower(domain)]; ok {
+		return l
+	}
+	return g.length
+}
+
+// Alphabet returns the characters codes are built from
+func (g *Generator) Alphabet() string {
+	return g.alphabet
+}
+
+// Generate returns a random code for the given domain
+// Uses crypto/rand so codes can't be predicted from previous ones
+func (g *Generator) Generate(domain string) (string, error) {
+	return g.GenerateLength(g.LengthFor(domain))
+}
+
+// GenerateLength returns a random code of exactly length characters
+func (g *Generator) GenerateLength(length int) (string, error) {
+	// rand.Int returns a UNIFORM number in [0, max), unlike "byte % len(alphabet)",
+	// which favors the first characters whenever 256 isn't a multiple of the alphabet size
+	max := big.NewInt(int64(len(g.alphabet)))
+
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		code[i] = g.alphabet[n.Int64()]
+	}
+
+	return string(code), nil
+}
+
+// FoldAlphabet lowercases an alphabet and drops the repeats
+// Used when short codes ignore case: "aA1" becomes "a1", so no generated
+// code has a capital that would be lost on storage
+func FoldAlphabet(alphabet string) string {
+	var b strings.Builder
+	for _, char := range strings.ToLower(alphabet) {
+		if !strings.ContainsRune(b.String(), char) {
+			b.WriteRune(char)
+		}
+	}
+	return b.String()
+}
+
+// validAlphabet checks the alphabet only has URL-safe characters and at least two distinct ones
+func validAlphabet(alphabet string) bool {
+	seen := make(map[rune]bool)
+	for _, char := range alphabet {
+		if !((char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') ||
+			char == '-' || char == '_') {
+			return false
+		}
+		if seen[char] {
+			return false
+		}
+		seen[char] = true
+	}
+	return len(seen) >= 2
+}
@@ -0,0 +1,41 @@
+package slack
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifySignature(t *testing.T) {
+	const secret = "8f742231b10e8888abcd99yyyzzz85a5"
+	body := []byte("token=xyz&team_id=T1&command=%2Fshorten&text=https%3A%2F%2Fexample.com")
+	now := time.Unix(1700000000, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		wantErr   error
+	}{
+		{name: "valid", timestamp: timestamp, signature: Sign(body, secret, now.Unix())},
+		{name: "wrong secret", timestamp: timestamp, signature: Sign(body, "other", now.Unix()), wantErr: ErrInvalidSignature},
+		{name: "timestamp not covered by signature", timestamp: "1700000001", signature: Sign(body, secret, now.Unix()), wantErr: ErrInvalidSignature},
+		{name: "missing headers", wantErr: ErrInvalidSignature},
+		{
+			name:      "replayed old request",
+			timestamp: "1699999000",
+			signature: Sign(body, secret, 1699999000),
+			wantErr:   ErrSignatureExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(body, tt.timestamp, tt.signature, secret, DefaultTolerance, now)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
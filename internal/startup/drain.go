@@ -0,0 +1,91 @@
+package startup
+
+import (
+	"context"
+	"sync"
+
+	"url-shortener/internal/metrics"
+)
+
+// Inflight tracks background work a request leaves behind (recording its
+// click), so shutdown can wait for it instead of losing it
+//
+// WHY?
+// server.Shutdown waits for handlers, not for the goroutines they start.
+// A redirect answers right away and records its click in the background;
+// a process that exits right after the last response drops every click
+// that was still being written. Draining waits for them, within the
+// shutdown deadline, and says how many made it.
+//
+// Safe for concurrent use.
+type Inflight struct {
+	name string
+
+	mu      sync.Mutex
+	pending int
+	idle    chan struct{} // Closed when pending drops to 0
+}
+
+// DrainReport says what happened to the work pending when draining started
+type DrainReport struct {
+	Flushed int // Finished before the deadline
+	Dropped int // Still running at the deadline: lost when the process exits
+}
+
+// NewInflight creates a tracker; name labels it in metrics ("clicks")
+func NewInflight(name string) *Inflight {
+	idle := make(chan struct{})
+	close(idle)
+	return &Inflight{name: name, idle: idle}
+}
+
+// Go runs fn in the background and tracks it until it returns
+func (f *Inflight) Go(fn func()) {
+	f.mu.Lock()
+	if f.pending == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.pending++
+	f.mu.Unlock()
+
+	go func() {
+		defer f.done()
+		fn()
+	}()
+}
+
+// Pending returns how much work is running
+func (f *Inflight) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pending
+}
+
+// Drain waits until the work is done or ctx is, whichever comes first
+// Call it once nothing starts new work anymore (after server.Shutdown)
+func (f *Inflight) Drain(ctx context.Context) DrainReport {
+	f.mu.Lock()
+	pending, idle := f.pending, f.idle
+	f.mu.Unlock()
+
+	select {
+	case <-idle:
+	case <-ctx.Done():
+	}
+
+	dropped := min(f.Pending(), pending)
+	report := DrainReport{Flushed: pending - dropped, Dropped: dropped}
+	metrics.RecordDrained(f.name, "flushed", report.Flushed)
+	metrics.RecordDrained(f.name, "dropped", report.Dropped)
+	return report
+}
+
+// done marks one piece of work as finished
+func (f *Inflight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending--
+	if f.pending == 0 {
+		close(f.idle)
+	}
+}
@@ -0,0 +1,56 @@
+package startup
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"url-shortener/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInflight_Drain(t *testing.T) {
+	// Arrange
+	inflight := NewInflight("test-clicks")
+	var recorded atomic.Int32
+	for range 3 {
+		inflight.Go(func() {
+			time.Sleep(10 * time.Millisecond)
+			recorded.Add(1)
+		})
+	}
+
+	// Act
+	report := inflight.Drain(context.Background())
+
+	// Assert
+	assert.Equal(t, DrainReport{Flushed: 3}, report)
+	assert.Equal(t, int32(3), recorded.Load(), "every click was written before Drain returned")
+	assert.Zero(t, inflight.Pending())
+}
+
+func TestInflight_Drain_Deadline(t *testing.T) {
+	// Arrange
+	inflight := NewInflight("test-stuck-clicks")
+	stuck := make(chan struct{})
+	defer close(stuck)
+	inflight.Go(func() {})
+	inflight.Go(func() { <-stuck }) // A database that doesn't answer
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Act
+	report := inflight.Drain(ctx)
+
+	// Assert
+	assert.Equal(t, DrainReport{Dropped: 1}, report, "the finished click was no longer pending")
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.ShutdownDrainedTotal.WithLabelValues("test-stuck-clicks", "dropped")))
+}
+
+func TestInflight_Drain_NothingPending(t *testing.T) {
+	assert.Equal(t, DrainReport{}, NewInflight("test-idle").Drain(context.Background()))
+}
@@ -0,0 +1,178 @@
+// Package startup connects to the dependencies without giving up at the
+// first refused connection
+//
+// WHY?
+// In docker compose and on Kubernetes every container starts at once:
+// PostgreSQL may still be replaying its WAL when we first try to connect.
+// Exiting right away (log.Fatalf) turns that into a crash loop with growing
+// restart delays. Instead, each dependency is retried with backoff until a
+// deadline (STARTUP_TIMEOUT); only then does the server give up.
+//
+// PARTIAL START:
+// Meanwhile the listeners can already be up (STARTUP_PARTIAL): liveness says
+// "alive", readiness says "starting" and lists what we are waiting for, and
+// every other request gets 503 with Retry-After. Orchestrators see a process
+// that is making progress, and operators see WHY it isn't ready yet.
+//
+// PHASES:
+// starting -> ready -> stopping. Every transition is logged and exported as
+// the app_phase metric.
+package startup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"url-shortener/internal/metrics"
+)
+
+// Phase is where the server is in its life cycle
+type Phase string
+
+const (
+	PhaseStarting Phase = "starting" // Connecting to dependencies, not serving traffic yet
+	PhaseReady    Phase = "ready"    // Serving traffic
+	PhaseStopping Phase = "stopping" // Shutting down
+)
+
+// Phases lists every phase, in order
+var Phases = []Phase{PhaseStarting, PhaseReady, PhaseStopping}
+
+// Tracker follows the startup: the current phase and the dependencies that
+// aren't connected yet. Safe for concurrent use.
+type Tracker struct {
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	phase   Phase
+	since   time.Time
+	waiting map[string]string // Dependency -> last connection error
+
+	baseDelay      time.Duration // First wait between attempts, doubled each time
+	maxDelay       time.Duration
+	attemptTimeout time.Duration
+	sleep          func(ctx context.Context, d time.Duration) error
+}
+
+// NewTracker creates a tracker in the starting phase
+func NewTracker(logger *slog.Logger) *Tracker {
+	t := &Tracker{
+		logger:         logger,
+		phase:          PhaseStarting,
+		since:          time.Now(),
+		waiting:        make(map[string]string),
+		baseDelay:      500 * time.Millisecond,
+		maxDelay:       10 * time.Second,
+		attemptTimeout: 10 * time.Second,
+		sleep:          sleep,
+	}
+	metrics.SetAppPhase(string(PhaseStarting), phaseNames())
+	return t
+}
+
+// Phase returns the current phase
+func (t *Tracker) Phase() Phase {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.phase
+}
+
+// Waiting returns the dependencies still being connected, with their last error
+func (t *Tracker) Waiting() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return maps.Clone(t.waiting)
+}
+
+// SetPhase moves to the given phase and logs the transition
+func (t *Tracker) SetPhase(phase Phase) {
+	t.mu.Lock()
+	from, since := t.phase, t.since
+	t.phase, t.since = phase, time.Now()
+	t.mu.Unlock()
+
+	if from == phase {
+		return
+	}
+	metrics.SetAppPhase(string(phase), phaseNames())
+	t.logger.Info("Readiness changed", "from", from, "to", phase, "after", time.Since(since).Round(time.Millisecond))
+}
+
+// Connect calls connect until it succeeds or ctx is done
+//
+// The first attempt always runs, even when ctx has no time left, so a
+// STARTUP_TIMEOUT of 0 means "try once" (the old behavior). Every attempt
+// gets its own timeout: ctx bounds the retrying, not a connection that is
+// already being established.
+func Connect[T any](ctx context.Context, t *Tracker, dependency string, connect func(ctx context.Context) (T, error)) (T, error) {
+	delay := t.baseDelay
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), t.attemptTimeout)
+		result, err := connect(attemptCtx)
+		cancel()
+
+		if err == nil {
+			t.connected(dependency, attempt)
+			return result, nil
+		}
+		t.failed(dependency, err)
+
+		if ctx.Err() != nil {
+			return result, fmt.Errorf("gave up connecting to %s after %d attempts: %w", dependency, attempt, err)
+		}
+		t.logger.Warn("Dependency not reachable yet, retrying",
+			"dependency", dependency,
+			"attempt", attempt,
+			"retry_in", delay,
+			"error", err,
+		)
+		if t.sleep(ctx, delay) != nil {
+			return result, fmt.Errorf("gave up connecting to %s after %d attempts: %w", dependency, attempt, err)
+		}
+		delay = min(delay*2, t.maxDelay)
+	}
+}
+
+// failed records a failed attempt
+func (t *Tracker) failed(dependency string, err error) {
+	t.mu.Lock()
+	t.waiting[dependency] = err.Error()
+	t.mu.Unlock()
+	metrics.SetDependencyUp(dependency, false)
+}
+
+// connected records a dependency that is up
+func (t *Tracker) connected(dependency string, attempts int) {
+	t.mu.Lock()
+	delete(t.waiting, dependency)
+	t.mu.Unlock()
+	metrics.SetDependencyUp(dependency, true)
+	if attempts > 1 {
+		t.logger.Info("Dependency connected", "dependency", dependency, "attempts", attempts)
+	}
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// phaseNames returns the phases as metric label values
+func phaseNames() []string {
+	names := make([]string, len(Phases))
+	for i, phase := range Phases {
+		names[i] = string(phase)
+	}
+	return names
+}
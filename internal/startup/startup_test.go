@@ -0,0 +1,107 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"url-shortener/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTracker returns a tracker whose sleeps are recorded instead of waited
+func newTestTracker(slept *[]time.Duration) *Tracker {
+	t := NewTracker(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.sleep = func(ctx context.Context, d time.Duration) error {
+		*slept = append(*slept, d)
+		return ctx.Err()
+	}
+	return t
+}
+
+// failingTimes fails the first n calls, then succeeds
+func failingTimes(n int, calls *int) func(ctx context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		*calls++
+		if *calls <= n {
+			return "", errors.New("connection refused")
+		}
+		return "connected", nil
+	}
+}
+
+func TestConnect_RetriesWithBackoff(t *testing.T) {
+	// Arrange
+	var slept []time.Duration
+	tracker := newTestTracker(&slept)
+	calls := 0
+
+	// Act
+	result, err := Connect(context.Background(), tracker, "startup-test-db", failingTimes(6, &calls))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "connected", result)
+	assert.Equal(t, 7, calls)
+	assert.Equal(t, []time.Duration{
+		500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second,
+	}, slept, "the wait doubles up to the cap")
+	assert.Empty(t, tracker.Waiting())
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.DependencyUp.WithLabelValues("startup-test-db")))
+}
+
+func TestConnect_GivesUpAtDeadline(t *testing.T) {
+	// Arrange
+	var slept []time.Duration
+	tracker := newTestTracker(&slept)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // No time left: a STARTUP_TIMEOUT of 0
+	calls := 0
+
+	// Act
+	_, err := Connect(ctx, tracker, "startup-test-cache", failingTimes(1, &calls))
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gave up connecting to startup-test-cache after 1 attempts")
+	assert.Equal(t, 1, calls, "the first attempt still runs")
+	assert.Empty(t, slept)
+	assert.Equal(t, map[string]string{"startup-test-cache": "connection refused"}, tracker.Waiting())
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.DependencyUp.WithLabelValues("startup-test-cache")))
+}
+
+func TestConnect_AttemptOutlivesCanceledDeadline(t *testing.T) {
+	// Arrange
+	var slept []time.Duration
+	tracker := newTestTracker(&slept)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	_, err := Connect(ctx, tracker, "startup-test-attempt", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, ctx.Err()
+	})
+
+	// Assert
+	assert.NoError(t, err, "an attempt has its own timeout, not the retry deadline")
+}
+
+func TestTracker_SetPhase(t *testing.T) {
+	// Arrange
+	tracker := NewTracker(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.Equal(t, PhaseStarting, tracker.Phase())
+
+	// Act
+	tracker.SetPhase(PhaseReady)
+
+	// Assert
+	assert.Equal(t, PhaseReady, tracker.Phase())
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.AppPhase.WithLabelValues("ready")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.AppPhase.WithLabelValues("starting")))
+}
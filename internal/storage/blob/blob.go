@@ -0,0 +1,40 @@
+// Package blob stores files (uploads, ...) in object storage
+//
+// WHY NOT POSTGRES?
+// Files are big and read as a whole. Kept in the database they would bloat
+// backups and replication and compete with redirects for memory. Object
+// stores are built for exactly this: cheap, durable, and streamed.
+//
+// The application only sees Store; S3 is the implementation for every
+// S3-compatible service (Amazon S3, GCS with HMAC keys, MinIO, R2).
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when an object doesn't exist
+var ErrNotFound = errors.New("object not found")
+
+// Object is a stored file being read
+// The caller must close Body.
+type Object struct {
+	Body        io.ReadCloser
+	Size        int64  // Content-Length (-1 if unknown)
+	ContentType string // As stored with Put
+}
+
+// Store keeps files under keys
+type Store interface {
+	// Put stores size bytes of body under key (an existing object is replaced)
+	// body is streamed, never held in memory as a whole
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+
+	// Get opens the object under key, or returns ErrNotFound
+	Get(ctx context.Context, key string) (*Object, error)
+
+	// Delete removes the object under key (missing objects are not an error)
+	Delete(ctx context.Context, key string) error
+}
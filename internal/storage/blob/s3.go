@@ -0,0 +1,142 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3 is a Store in an S3-compatible bucket
+//
+// Objects are addressed path-style (<endpoint>/<bucket>/<key>), which every
+// S3-compatible service supports:
+//
+//	S3:  https://s3.eu-west-1.amazonaws.com   region eu-west-1
+//	GCS: https://storage.googleapis.com       region auto
+type S3 struct {
+	endpoint string
+	bucket   string
+	signer   Signer
+	timeout  time.Duration // Bounds uploads and deletes; downloads follow the caller's context
+	client   *http.Client
+	now      func() time.Time
+}
+
+// Compile-time check that S3 implements Store
+var _ Store = (*S3)(nil)
+
+// NewS3 creates a store in bucket
+func NewS3(endpoint, region, bucket, accessKey, secretKey string, timeout time.Duration) *S3 {
+	return &S3{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		bucket:   bucket,
+		signer:   Signer{Region: region, AccessKey: accessKey, SecretKey: secretKey},
+		timeout:  timeout,
+		// No client timeout: a download streams for as long as the visitor
+		// reads it. Every request carries a context instead.
+		client: &http.Client{},
+		now:    time.Now,
+	}
+}
+
+// Put uploads body under key
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	// S3 needs the length up front: without it Go would send the body
+	// chunked, which S3 rejects for plain PUTs
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	s.signer.Sign(req, UnsignedPayload, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload of %s failed: %w", key, err)
+	}
+	defer resp.Body.Close()
+	return checkStatus(resp, "upload", key)
+}
+
+// Get opens the object under key
+func (s *S3) Get(ctx context.Context, key string) (*Object, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.signer.Sign(req, PayloadHash(nil), s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download of %s failed: %w", key, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err := checkStatus(resp, "download", key); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return &Object{
+		Body:        resp.Body,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}, nil
+}
+
+// Delete removes the object under key
+func (s *S3) Delete(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	s.signer.Sign(req, PayloadHash(nil), s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("delete of %s failed: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil // Gone already - that's what we wanted
+	}
+	return checkStatus(resp, "delete", key)
+}
+
+// newRequest builds an unsigned request for the object under key
+func (s *S3) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	endpoint, err := url.Parse(s.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object store endpoint: %w", err)
+	}
+	endpoint.Path = "/" + s.bucket + "/" + key
+	endpoint.RawPath = EncodePath(endpoint.Path) // Sent exactly as signed
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", strings.ToLower(method), err)
+	}
+	return req, nil
+}
+
+// checkStatus turns an error response into an error carrying S3's message
+func checkStatus(resp *http.Response, operation, key string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s of %s returned status %d: %s", operation, key, resp.StatusCode, strings.TrimSpace(string(detail)))
+}
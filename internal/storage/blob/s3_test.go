@@ -0,0 +1,118 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3_Put(t *testing.T) {
+	// Arrange
+	var got *http.Request
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	store := NewS3(server.URL, "auto", "uploads", "key", "secret", time.Second)
+
+	// Act
+	err := store.Put(context.Background(), "files/ab12/report.pdf", strings.NewReader("%PDF-1.7"), 8, "application/pdf")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPut, got.Method)
+	assert.Equal(t, "/uploads/files/ab12/report.pdf", got.URL.Path)
+	assert.Equal(t, int64(8), got.ContentLength, "S3 rejects chunked uploads")
+	assert.Equal(t, "application/pdf", got.Header.Get("Content-Type"))
+	assert.Equal(t, UnsignedPayload, got.Header.Get("X-Amz-Content-Sha256"), "streamed bodies are not hashed")
+	assert.Contains(t, got.Header.Get("Authorization"), "Credential=key/")
+	assert.Equal(t, []byte("%PDF-1.7"), gotBody)
+}
+
+func TestS3_Get(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		wantErr  error
+		wantBody string
+	}{
+		{name: "found", status: http.StatusOK, wantBody: "hello"},
+		{name: "missing", status: http.StatusNotFound, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/uploads/files/ab12/hello.txt", r.URL.Path)
+				if tt.status != http.StatusOK {
+					http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", tt.status)
+					return
+				}
+				w.Header().Set("Content-Type", "text/plain")
+				w.Write([]byte("hello"))
+			}))
+			defer server.Close()
+			store := NewS3(server.URL, "auto", "uploads", "key", "secret", time.Second)
+
+			// Act
+			object, err := store.Get(context.Background(), "files/ab12/hello.txt")
+
+			// Assert
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer object.Body.Close()
+			body, _ := io.ReadAll(object.Body)
+			assert.Equal(t, tt.wantBody, string(body))
+			assert.Equal(t, int64(5), object.Size)
+			assert.Equal(t, "text/plain", object.ContentType)
+		})
+	}
+}
+
+func TestS3_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "deleted", status: http.StatusNoContent},
+		{name: "already gone", status: http.StatusNotFound},
+		{name: "access denied", status: http.StatusForbidden, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodDelete, r.Method)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+			store := NewS3(server.URL, "auto", "uploads", "key", "secret", time.Second)
+
+			// Act
+			err := store.Delete(context.Background(), "files/ab12/hello.txt")
+
+			// Assert
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Package useragent turns User-Agent headers into the dimensions analytics
+// reports on: browser family, operating system and device type.
+//
+// WHY NOT A FULL UA DATABASE?
+// Analytics only needs the big families ("Chrome on Android, mobile"), not
+// exact versions or phone models. A short ordered list of rules covers the
+// browsers people actually use and has no data files to keep updated.
+package useragent
+
+import "strings"
+
+// Device types
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown" // No User-Agent at all
+)
+
+// Other is the browser or OS of a User-Agent no rule recognizes
+const Other = "Other"
+
+// Info holds the parsed dimensions of one User-Agent
+type Info struct {
+	Browser string // Family, e.g. "Chrome", "Safari", "Firefox"
+	OS      string // Family, e.g. "Windows", "iOS", "Android"
+	Device  string // One of the Device* constants
+}
+
+// match is one "contains this fragment -> this name" rule
+type match struct {
+	fragment string // Lowercase
+	name     string
+}
+
+// Rules are checked IN ORDER and the first match wins. Order matters:
+// Edge and Opera also say "Chrome", Chrome also says "Safari", and iPhones
+// say "like Mac OS X".
+var (
+	bots = []match{
+		{"googlebot", "Googlebot"},
+		{"bingbot", "Bingbot"},
+		{"facebookexternalhit", "Facebook"},
+		{"twitterbot", "Twitterbot"},
+		{"slackbot", "Slackbot"},
+		{"curl/", "curl"},
+		{"wget/", "Wget"},
+		{"python-requests", "Python Requests"},
+		{"go-http-client", "Go HTTP Client"},
+		{"headlesschrome", "Headless Chrome"},
+		{"bot", "Bot"},
+		{"crawler", "Bot"},
+		{"spider", "Bot"},
+	}
+
+	browsers = []match{
+		{"edg/", "Edge"},
+		{"edga/", "Edge"},
+		{"edgios/", "Edge"},
+		{"edge/", "Edge"},
+		{"opr/", "Opera"},
+		{"opera", "Opera"},
+		{"samsungbrowser", "Samsung Internet"},
+		{"yabrowser", "Yandex Browser"},
+		{"vivaldi", "Vivaldi"},
+		{"firefox/", "Firefox"},
+		{"fxios/", "Firefox"},
+		{"crios/", "Chrome"},
+		{"chrome/", "Chrome"},
+		{"chromium/", "Chrome"},
+		{"msie ", "Internet Explorer"},
+		{"trident/", "Internet Explorer"},
+		{"safari/", "Safari"},
+	}
+
+	systems = []match{
+		{"windows phone", "Windows Phone"},
+		{"windows", "Windows"},
+		{"iphone", "iOS"},
+		{"ipod", "iOS"},
+		{"ipad", "iPadOS"},
+		{"android", "Android"},
+		{"cros ", "ChromeOS"}, // "X11; CrOS x86_64" (the space keeps "Microsoft" from matching)
+		{"mac os x", "macOS"},
+		{"macintosh", "macOS"},
+		{"linux", "Linux"},
+	}
+)
+
+// Parse reads the browser, OS and device type from a User-Agent header
+func Parse(userAgent string) Info {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return Info{Browser: Other, OS: Other, Device: DeviceUnknown}
+	}
+
+	// Bots pretend to be browsers ("Mozilla/5.0 (compatible; Googlebot/2.1)"),
+	// so they are recognized first
+	if bot, ok := find(bots, ua); ok {
+		return Info{Browser: bot, OS: Other, Device: DeviceBot}
+	}
+
+	browser, _ := find(browsers, ua)
+	os, _ := find(systems, ua)
+	return Info{Browser: browser, OS: os, Device: deviceType(ua)}
+}
+
+// find returns the name of the first rule whose fragment ua contains
+func find(rules []match, ua string) (string, bool) {
+	for _, rule := range rules {
+		if strings.Contains(ua, rule.fragment) {
+			return rule.name, true
+		}
+	}
+	return Other, false
+}
+
+// deviceType tells phones from tablets from desktops
+// Android tablets are Android WITHOUT "Mobile" in the User-Agent
+func deviceType(ua string) string {
+	switch {
+	case strings.Contains(ua, "ipad") || strings.Contains(ua, "tablet"):
+		return DeviceTablet
+	case strings.Contains(ua, "android") && !strings.Contains(ua, "mobile"):
+		return DeviceTablet
+	case strings.Contains(ua, "mobi") || strings.Contains(ua, "iphone") || strings.Contains(ua, "ipod") ||
+		strings.Contains(ua, "android") || strings.Contains(ua, "windows phone"):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
@@ -0,0 +1,82 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		expected  Info
+	}{
+		{
+			name:      "Chrome on Windows",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected:  Info{Browser: "Chrome", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name:      "Edge says Chrome too",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			expected:  Info{Browser: "Edge", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name:      "Safari on macOS",
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			expected:  Info{Browser: "Safari", OS: "macOS", Device: DeviceDesktop},
+		},
+		{
+			name:      "Safari on iPhone",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			expected:  Info{Browser: "Safari", OS: "iOS", Device: DeviceMobile},
+		},
+		{
+			name:      "Chrome on iPad",
+			userAgent: "Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			expected:  Info{Browser: "Chrome", OS: "iPadOS", Device: DeviceTablet},
+		},
+		{
+			name:      "Samsung Internet on Android phone",
+			userAgent: "Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			expected:  Info{Browser: "Samsung Internet", OS: "Android", Device: DeviceMobile},
+		},
+		{
+			name:      "Android tablet",
+			userAgent: "Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected:  Info{Browser: "Chrome", OS: "Android", Device: DeviceTablet},
+		},
+		{
+			name:      "Firefox on Linux",
+			userAgent: "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			expected:  Info{Browser: "Firefox", OS: "Linux", Device: DeviceDesktop},
+		},
+		{
+			name:      "Googlebot pretends to be a browser",
+			userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			expected:  Info{Browser: "Googlebot", OS: Other, Device: DeviceBot},
+		},
+		{
+			name:      "curl",
+			userAgent: "curl/8.4.0",
+			expected:  Info{Browser: "curl", OS: Other, Device: DeviceBot},
+		},
+		{
+			name:      "unknown client",
+			userAgent: "MyApp/1.0",
+			expected:  Info{Browser: Other, OS: Other, Device: DeviceDesktop},
+		},
+		{
+			name:      "no User-Agent",
+			userAgent: "",
+			expected:  Info{Browser: Other, OS: Other, Device: DeviceUnknown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Parse(tt.userAgent))
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"url-shortener/internal/domain"
+)
+
+// Built-in rules
+// The link-specific ones (httpurl, host, alias) call the domain's own
+// checks, so a rule means exactly the same thing here and in the domain
+func init() {
+	Register("required", required)
+	Register("min", minRule)
+	Register("max", maxRule)
+	Register("oneof", oneOf)
+	Register("httpurl", httpURL)
+	Register("host", host)
+	Register("alias", alias)
+	Register("duration", duration)
+	Register("timezone", timezone)
+	Register("ip", ip)
+}
+
+// required rejects empty strings (also whitespace-only), nil and zero values
+func required(v reflect.Value, _ string) string {
+	if isEmpty(v) {
+		return "is required"
+	}
+	return ""
+}
+
+// minRule checks the length of strings and collections, or the value of numbers
+func minRule(v reflect.Value, param string) string {
+	limit := intParam("min", param)
+	switch v.Kind() {
+	case reflect.String:
+		if int64(utf8.RuneCountInString(v.String())) < limit {
+			return fmt.Sprintf("must be at least %d characters", limit)
+		}
+	case reflect.Slice, reflect.Map:
+		if int64(v.Len()) < limit {
+			return fmt.Sprintf("must have at least %d items", limit)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < limit {
+			return fmt.Sprintf("must be at least %d", limit)
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return minRule(v.Elem(), param)
+		}
+	}
+	return ""
+}
+
+// maxRule is the upper-bound counterpart of minRule
+func maxRule(v reflect.Value, param string) string {
+	limit := intParam("max", param)
+	switch v.Kind() {
+	case reflect.String:
+		if int64(utf8.RuneCountInString(v.String())) > limit {
+			return fmt.Sprintf("must be at most %d characters", limit)
+		}
+	case reflect.Slice, reflect.Map:
+		if int64(v.Len()) > limit {
+			return fmt.Sprintf("must have at most %d items", limit)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() > limit {
+			return fmt.Sprintf("must be at most %d", limit)
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return maxRule(v.Elem(), param)
+		}
+	}
+	return ""
+}
+
+// oneOf accepts one of the space-separated values, e.g. oneof=rounded pill square
+func oneOf(v reflect.Value, param string) string {
+	allowed := strings.Fields(param)
+	for _, option := range allowed {
+		if v.String() == option {
+			return ""
+		}
+	}
+	return "must be one of: " + strings.Join(allowed, ", ")
+}
+
+// httpURL accepts absolute http(s) URLs
+func httpURL(v reflect.Value, _ string) string {
+	if !domain.IsHTTPURL(strings.TrimSpace(v.String())) {
+		return "must be an absolute http(s) URL"
+	}
+	return ""
+}
+
+// host accepts host names such as go.example.com
+func host(v reflect.Value, _ string) string {
+	if !domain.IsValidHost(strings.TrimSpace(v.String())) {
+		return "must be a host name such as go.example.com"
+	}
+	return ""
+}
+
+// alias accepts custom aliases that can be claimed
+func alias(v reflect.Value, _ string) string {
+	err := domain.ValidateAlias(v.String())
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, domain.ErrCustomAliasReserved):
+		return "is reserved"
+	default:
+		return "must be 3-20 letters, digits, '-' or '_'"
+	}
+}
+
+// duration accepts positive Go durations such as "24h" or "90m"
+func duration(v reflect.Value, _ string) string {
+	d, err := time.ParseDuration(v.String())
+	if err != nil || d <= 0 {
+		return `must be a positive duration such as "24h"`
+	}
+	return ""
+}
+
+// timezone accepts IANA timezone names such as "Europe/Berlin"
+func timezone(v reflect.Value, _ string) string {
+	if _, err := domain.LoadTimezone(v.String()); err != nil {
+		return `must be an IANA timezone such as "Europe/Berlin"`
+	}
+	return ""
+}
+
+// ip accepts IPv4 and IPv6 addresses without a zone or port, as
+// PostgreSQL's INET type stores them
+func ip(v reflect.Value, _ string) string {
+	addr, err := netip.ParseAddr(strings.TrimSpace(v.String()))
+	if err != nil || addr.Zone() != "" {
+		return "must be an IP address such as 203.0.113.7"
+	}
+	return ""
+}
@@ -0,0 +1,252 @@
+// Package validation checks request DTOs against rules declared in struct tags
+//
+// WHY TAGS?
+// Rules used to be if-statements spread over handlers ("URL is required")
+// and only reported the FIRST problem. With tags the rules sit next to the
+// fields they guard, and one pass reports every invalid field at once:
+//
+//	type CreateURLRequest struct {
+//		URL       string `json:"url" validate:"required,httpurl,max=2048" label:"URL"`
+//		MaxClicks int64  `json:"max_clicks" validate:"min=1"`
+//	}
+//
+// Errors are keyed by the JSON path of the field ("blocks[2].url"), so
+// clients can show them next to the right input.
+//
+// Rules other than "required" skip empty values: an optional field is only
+// checked when it is set. Nested structs (also in slices and pointers) are
+// validated too. "dive" applies the rules after it to every element of a
+// slice or every value of a map.
+//
+// The domain layer still validates everything it saves - these checks give
+// clients better errors, they are not the last line of defense.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FieldError is one invalid field
+type FieldError struct {
+	Field   string // JSON path, e.g. "schedule.rules[0].url"
+	Message string // e.g. "URL is required"
+}
+
+// Errors lists every invalid field, in the order the fields are declared
+type Errors []FieldError
+
+// Error joins the messages, so Errors can be returned as a plain error
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Map returns the messages keyed by field (the "details" of an API error)
+func (e Errors) Map() map[string]string {
+	m := make(map[string]string, len(e))
+	for _, fe := range e {
+		if _, ok := m[fe.Field]; !ok { // First problem of a field wins
+			m[fe.Field] = fe.Message
+		}
+	}
+	return m
+}
+
+// Rule checks one value against an optional parameter ("max=20" -> "20")
+// It returns "" when the value is valid, otherwise what is wrong with it
+// ("must be at most 20 characters"); the field label is prepended
+type Rule func(value reflect.Value, param string) string
+
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]Rule{}
+)
+
+// Register adds a rule usable in validate tags
+// Registering an existing name replaces it
+func Register(name string, rule Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rules[name] = rule
+}
+
+func lookup(name string) (Rule, bool) {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	rule, ok := rules[name]
+	return rule, ok
+}
+
+// Struct validates a struct (or pointer to one)
+// Returns nil when every field is valid
+// Panics on unknown rule names: a typo in a tag is a programming error
+func Struct(v interface{}) Errors {
+	var errs Errors
+	walkStruct(reflect.ValueOf(v), "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// walkStruct validates every field of a struct value
+func walkStruct(v reflect.Value, prefix string, errs *Errors) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		// Embedded structs share their parent's JSON object
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			walkStruct(v.Field(i), prefix, errs)
+			continue
+		}
+
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		path := join(prefix, name)
+		label := field.Tag.Get("label")
+		if label == "" {
+			label = name
+		}
+
+		checkValue(v.Field(i), path, label, splitRules(field.Tag.Get("validate")), errs)
+	}
+}
+
+// checkValue applies rules to one value and descends into nested structs
+func checkValue(v reflect.Value, path, label string, tagRules []string, errs *Errors) {
+	for i, spec := range tagRules {
+		name, param, _ := strings.Cut(spec, "=")
+
+		if name == "dive" {
+			diveInto(v, path, label, tagRules[i+1:], errs)
+			return // The remaining rules were for the elements
+		}
+
+		if name != "required" && isEmpty(v) {
+			continue
+		}
+		rule, ok := lookup(name)
+		if !ok {
+			panic(fmt.Sprintf("validation: unknown rule %q on %s", name, path))
+		}
+		if msg := rule(v, param); msg != "" {
+			*errs = append(*errs, FieldError{Field: path, Message: label + " " + msg})
+			return // One message per field is enough
+		}
+	}
+
+	descend(v, path, errs)
+}
+
+// diveInto applies rules to every element of a slice or value of a map
+func diveInto(v reflect.Value, path, label string, elemRules []string, errs *Errors) {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			elemPath := fmt.Sprintf("%s[%d]", path, i)
+			checkValue(v.Index(i), elemPath, elemPath, elemRules, errs)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		keys := make([]string, 0, v.Len())
+		values := map[string]reflect.Value{}
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			keys = append(keys, key)
+			values[key] = iter.Value()
+		}
+		slices.Sort(keys) // Map order is random; errors shouldn't be
+		for _, key := range keys {
+			elemPath := join(path, key)
+			checkValue(values[key], elemPath, elemPath, elemRules, errs)
+		}
+	}
+}
+
+// descend validates nested structs, also inside pointers and slices
+func descend(v reflect.Value, path string, errs *Errors) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			descend(v.Elem(), path, errs)
+		}
+	case reflect.Struct:
+		if v.Type().PkgPath() != "time" { // time.Time has nothing to validate
+			walkStruct(v, path, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			descend(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// isEmpty reports whether a value was left out of the request
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// jsonName returns the JSON key of a field
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func splitRules(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	return strings.Split(tag, ",")
+}
+
+// intParam parses the numeric parameter of a rule such as max=20
+func intParam(rule, param string) int64 {
+	n, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: %s needs a number, got %q", rule, param))
+	}
+	return n
+}
@@ -0,0 +1,141 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRule struct {
+	URL string `json:"url" validate:"required,httpurl" label:"URL"`
+}
+
+type testRequest struct {
+	URL         string            `json:"url" validate:"required,httpurl" label:"URL"`
+	CustomAlias string            `json:"custom_alias,omitempty" validate:"alias"`
+	MaxClicks   int64             `json:"max_clicks,omitempty" validate:"min=1"`
+	Limit       *int64            `json:"limit,omitempty" validate:"max=10"`
+	Domain      string            `json:"domain,omitempty" validate:"host"`
+	Shape       string            `json:"shape,omitempty" validate:"oneof=rounded pill"`
+	ExpiresIn   string            `json:"expires_in,omitempty" validate:"duration"`
+	Timezone    string            `json:"timezone,omitempty" validate:"timezone"`
+	IPAddress   string            `json:"ip_address,omitempty" validate:"ip"`
+	Targets     map[string]string `json:"targets,omitempty" validate:"max=2,dive,httpurl"`
+	Rules       []testRule        `json:"rules,omitempty" validate:"max=3"`
+	Nested      *testRule         `json:"nested,omitempty"`
+	Ignored     string            `json:"-" validate:"required"`
+	unexported  string            `validate:"required"`
+}
+
+func TestStruct(t *testing.T) {
+	tooMany := int64(11)
+
+	tests := []struct {
+		name     string
+		request  testRequest
+		expected map[string]string
+	}{
+		{
+			name:    "valid",
+			request: testRequest{URL: "https://example.com", CustomAlias: "spring-sale", MaxClicks: 5, Domain: "go.example.com", Shape: "pill", ExpiresIn: "24h", Timezone: "Europe/Berlin", IPAddress: "2001:db8::1"},
+		},
+		{
+			name:     "missing URL",
+			request:  testRequest{URL: "  "},
+			expected: map[string]string{"url": "URL is required"},
+		},
+		{
+			name: "every problem at once",
+			request: testRequest{
+				URL:         "ftp://example.com",
+				CustomAlias: "a!",
+				MaxClicks:   -1,
+				Limit:       &tooMany,
+				Domain:      "localhost",
+				Shape:       "circle",
+				ExpiresIn:   "-1h",
+				Timezone:    "Local",
+				IPAddress:   "203.0.113.7:443",
+			},
+			expected: map[string]string{
+				"url":          "URL must be an absolute http(s) URL",
+				"custom_alias": "custom_alias must be 3-20 letters, digits, '-' or '_'",
+				"max_clicks":   "max_clicks must be at least 1",
+				"limit":        "limit must be at most 10",
+				"domain":       "domain must be a host name such as go.example.com",
+				"shape":        "shape must be one of: rounded, pill",
+				"expires_in":   `expires_in must be a positive duration such as "24h"`,
+				"timezone":     `timezone must be an IANA timezone such as "Europe/Berlin"`,
+				"ip_address":   "ip_address must be an IP address such as 203.0.113.7",
+			},
+		},
+		{
+			name:     "reserved alias",
+			request:  testRequest{URL: "https://example.com", CustomAlias: "admin"},
+			expected: map[string]string{"custom_alias": "custom_alias is reserved"},
+		},
+		{
+			name:     "map values",
+			request:  testRequest{URL: "https://example.com", Targets: map[string]string{"fr": "https://example.com/fr", "de": "nope"}},
+			expected: map[string]string{"targets.de": "targets.de must be an absolute http(s) URL"},
+		},
+		{
+			name:     "too many map entries",
+			request:  testRequest{URL: "https://example.com", Targets: map[string]string{"a": "x", "b": "x", "c": "x"}},
+			expected: map[string]string{"targets": "targets must have at most 2 items"},
+		},
+		{
+			name:     "nested structs",
+			request:  testRequest{URL: "https://example.com", Rules: []testRule{{URL: "https://example.com/a"}, {}}, Nested: &testRule{URL: "mailto:x"}},
+			expected: map[string]string{"rules[1].url": "URL is required", "nested.url": "URL must be an absolute http(s) URL"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			errs := Struct(&tt.request)
+
+			// Assert
+			if tt.expected == nil {
+				assert.Nil(t, errs)
+				return
+			}
+			require.NotNil(t, errs)
+			assert.Equal(t, tt.expected, errs.Map())
+		})
+	}
+}
+
+func TestErrors_ErrorKeepsFieldOrder(t *testing.T) {
+	errs := Struct(testRequest{MaxClicks: -1, Domain: "localhost"})
+
+	assert.Equal(t, "URL is required; max_clicks must be at least 1; domain must be a host name such as go.example.com", errs.Error())
+}
+
+func TestRegister(t *testing.T) {
+	// Arrange
+	Register("even", func(v reflect.Value, _ string) string {
+		if v.Int()%2 != 0 {
+			return "must be even"
+		}
+		return ""
+	})
+	type request struct {
+		Count int `json:"count" validate:"even"`
+	}
+
+	// Act & Assert
+	assert.Nil(t, Struct(request{Count: 2}))
+	assert.Equal(t, "count must be even", Struct(request{Count: 3}).Error())
+}
+
+func TestStruct_UnknownRulePanics(t *testing.T) {
+	type request struct {
+		Name string `json:"name" validate:"requird"`
+	}
+
+	assert.Panics(t, func() { Struct(request{Name: "x"}) })
+}
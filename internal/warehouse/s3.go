@@ -0,0 +1,87 @@
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"url-shortener/internal/storage/blob"
+)
+
+// S3 uploads files to an S3-compatible object store
+//
+// ONE CLIENT, SEVERAL CLOUDS:
+// Amazon S3, Google Cloud Storage (XML API with HMAC keys), MinIO and R2
+// all accept the same PUT request signed with AWS Signature Version 4, so
+// the endpoint is all that changes:
+//
+//	S3:  https://s3.eu-west-1.amazonaws.com   region eu-west-1
+//	GCS: https://storage.googleapis.com       region auto
+//
+// Objects are addressed path-style (<endpoint>/<bucket>/<key>), which
+// every one of them supports.
+type S3 struct {
+	endpoint  string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3 creates an object store client
+func NewS3(endpoint, region, bucket, accessKey, secretKey string, timeout time.Duration) *S3 {
+	return &S3{
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: timeout},
+		now:       time.Now,
+	}
+}
+
+// Put uploads body under key (an existing object is replaced)
+func (s *S3) Put(ctx context.Context, key string, body []byte, contentType, contentEncoding string) error {
+	endpoint, err := url.Parse(s.endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid object store endpoint: %w", err)
+	}
+	endpoint.Path = "/" + s.bucket + "/" + key
+	endpoint.RawPath = blob.EncodePath(endpoint.Path) // "dt=..." is sent as "dt%3D...", exactly as signed
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+	s.sign(req, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload of %s failed: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("upload of %s returned status %d: %s", key, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header (see blob.Signer)
+func (s *S3) sign(req *http.Request, body []byte) {
+	signer := blob.Signer{Region: s.region, AccessKey: s.accessKey, SecretKey: s.secretKey}
+	signer.Sign(req, blob.PayloadHash(body), s.now())
+}
@@ -0,0 +1,145 @@
+// Package warehouse ships click events to object storage for analysts
+//
+// WHY NOT LET ANALYSTS QUERY POSTGRES?
+// Analytics queries scan millions of clicks. Run against the production
+// database they compete with redirects for CPU, memory and I/O. Instead, a
+// background exporter copies new clicks to S3 or GCS as files that
+// BigQuery, Snowflake, Athena & co. load directly.
+//
+// FILE LAYOUT (Hive-style partitions, understood by every warehouse):
+//
+//	<prefix>/dt=2025-03-01/hour=13/<source>-<first id>-<last id>.ndjson.gz
+//
+// One JSON object per line (see Record). Delivery is AT LEAST ONCE: after
+// a crash between an upload and its checkpoint the same clicks can appear
+// in two files - deduplicate on "id" (unique per source).
+package warehouse
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/domain"
+)
+
+var (
+	ErrUnknownFormat      = errors.New("unknown export format (use ndjson)")
+	ErrUnknownCompression = errors.New("unknown export compression (use gzip or none)")
+)
+
+// Record is one exported click
+// IP addresses and raw User-Agents are NOT exported: the warehouse gets
+// the parsed dimensions, not personal data
+type Record struct {
+	ID          int64     `json:"id"`
+	Source      string    `json:"source"` // Database the click came from (shard name)
+	URLID       string    `json:"url_id"`
+	ShortCode   string    `json:"short_code"`
+	ClickedAt   time.Time `json:"clicked_at"`
+	Referer     string    `json:"referer,omitempty"`
+	Channel     string    `json:"channel,omitempty"`
+	Browser     string    `json:"browser,omitempty"`
+	OS          string    `json:"os,omitempty"`
+	DeviceType  string    `json:"device_type,omitempty"`
+	CountryCode string    `json:"country_code,omitempty"`
+	City        string    `json:"city,omitempty"`
+	Region      string    `json:"region,omitempty"`
+}
+
+// Format describes how export files are written
+type Format struct {
+	Encoding    string // "ndjson"
+	Compression string // "gzip" or "none"
+}
+
+// Validate checks that the format is supported
+// Parquet is not supported yet: it needs a third-party encoder
+func (f Format) Validate() error {
+	if f.Encoding != "ndjson" {
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, f.Encoding)
+	}
+	if f.Compression != "gzip" && f.Compression != "none" {
+		return fmt.Errorf("%w: %q", ErrUnknownCompression, f.Compression)
+	}
+	return nil
+}
+
+// Extension returns the file name extension, e.g. ".ndjson.gz"
+func (f Format) Extension() string {
+	if f.Compression == "gzip" {
+		return ".ndjson.gz"
+	}
+	return ".ndjson"
+}
+
+// ContentEncoding returns the Content-Encoding of the files ("" = none)
+func (f Format) ContentEncoding() string {
+	if f.Compression == "gzip" {
+		return "gzip"
+	}
+	return ""
+}
+
+// Encode writes clicks as one file
+func (f Format) Encode(source string, clicks []*domain.URLClick) ([]byte, error) {
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	var encoder *json.Encoder
+	if f.Compression == "gzip" {
+		gz = gzip.NewWriter(&buf)
+		encoder = json.NewEncoder(gz) // Encode ends every object with a newline
+	} else {
+		encoder = json.NewEncoder(&buf)
+	}
+
+	for _, click := range clicks {
+		if err := encoder.Encode(newRecord(source, click)); err != nil {
+			return nil, fmt.Errorf("failed to encode click %d: %w", click.ID, err)
+		}
+	}
+
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress export file: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// newRecord converts a click for export
+func newRecord(source string, click *domain.URLClick) Record {
+	return Record{
+		ID:          click.ID,
+		Source:      source,
+		URLID:       click.URLID,
+		ShortCode:   click.ShortCode,
+		ClickedAt:   click.ClickedAt.UTC(),
+		Referer:     click.Referer,
+		Channel:     string(click.Channel),
+		Browser:     click.Browser,
+		OS:          click.OS,
+		DeviceType:  click.DeviceType,
+		CountryCode: click.CountryCode,
+		City:        click.City,
+		Region:      click.Region,
+	}
+}
+
+// Partition returns the partition directory of a click: dt=YYYY-MM-DD/hour=HH (UTC)
+func Partition(clickedAt time.Time) string {
+	t := clickedAt.UTC()
+	return fmt.Sprintf("dt=%s/hour=%02d", t.Format(time.DateOnly), t.Hour())
+}
+
+// ObjectKey returns the key of the file holding clicks firstID..lastID of source
+func ObjectKey(prefix, partition, source string, firstID, lastID int64, format Format) string {
+	key := fmt.Sprintf("%s/%s-%d-%d%s", partition, source, firstID, lastID, format.Extension())
+	if prefix != "" {
+		key = prefix + "/" + key
+	}
+	return key
+}
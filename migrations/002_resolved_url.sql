@@ -0,0 +1,5 @@
+-- Migration: Resolved destination URL
+-- Stores where a destination ultimately leads after following its redirects
+-- NULL means resolution was disabled or the destination didn't redirect
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS resolved_url TEXT;
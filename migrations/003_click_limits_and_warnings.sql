@@ -0,0 +1,16 @@
+-- Migration: Click limits and link warnings
+-- Lets URLs stop redirecting after N clicks, and remembers which warnings
+-- (80%/100% of click limit, expiring soon) were already sent to the owner
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS max_clicks BIGINT;
+
+-- One row per (URL, warning kind) - the primary key guarantees each warning is sent once
+CREATE TABLE IF NOT EXISTS url_warnings (
+    url_id UUID NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (url_id, kind)
+);
+
+-- Partial index so the evaluator only scans URLs that actually have a limit
+CREATE INDEX IF NOT EXISTS idx_max_clicks ON urls(max_clicks) WHERE max_clicks IS NOT NULL;
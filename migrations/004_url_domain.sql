@@ -0,0 +1,5 @@
+-- Migration: Short link domain
+-- Records which host a short link is served on, so code policies (length, etc.)
+-- can differ per domain. Empty string means the default domain.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS domain VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+-- Migration: Owner index for exports
+-- Exports page through an owner's URLs with a cursor on (created_at, id).
+-- This index lets each page start exactly where the last one ended instead of
+-- scanning (and skipping) every earlier row like OFFSET would.
+
+CREATE INDEX IF NOT EXISTS idx_urls_owner_created ON urls(created_by, created_at, id);
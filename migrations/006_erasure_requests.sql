@@ -0,0 +1,31 @@
+-- Migration: Account data deletion (GDPR right to erasure)
+-- One row per deletion request. The background worker claims pending rows,
+-- deletes the owner's data in batches, verifies nothing is left, and stores
+-- the counts for the deletion receipt.
+
+CREATE TABLE IF NOT EXISTS erasure_requests (
+    id UUID PRIMARY KEY,
+
+    -- Cleared (set to NULL) once the erasure completes; subject_hash remains
+    owner VARCHAR(255),
+    subject_hash CHAR(64) NOT NULL,
+    requested_by VARCHAR(255) NOT NULL,
+
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    urls_deleted BIGINT NOT NULL DEFAULT 0,
+    clicks_deleted BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+
+    requested_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    -- Heartbeat of the worker; a running request with an old heartbeat is
+    -- picked up again (deleting is idempotent, so resuming is safe)
+    heartbeat_at TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+-- The worker looks for pending requests, oldest first
+CREATE INDEX IF NOT EXISTS idx_erasure_pending ON erasure_requests(requested_at) WHERE status = 'pending';
+
+-- At most one unfinished request per owner
+CREATE UNIQUE INDEX IF NOT EXISTS idx_erasure_active_owner ON erasure_requests(owner)
+    WHERE status IN ('pending', 'running');
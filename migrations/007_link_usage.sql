@@ -0,0 +1,12 @@
+-- Migration: Plan quotas
+-- One row per owner and calendar month, counting the links they created.
+-- The row is the source of truth; Redis only caches the current value.
+
+CREATE TABLE IF NOT EXISTS link_usage (
+    owner VARCHAR(255) NOT NULL,
+    -- First day of the month (UTC) the counter belongs to
+    period_start DATE NOT NULL,
+    links BIGINT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (owner, period_start)
+);
@@ -0,0 +1,15 @@
+-- Migration: Stripe subscriptions
+-- One row per owner. Written by the billing webhook; the plan column is what
+-- the quota layer reads to pick the owner's monthly limit.
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    owner VARCHAR(255) PRIMARY KEY,
+    stripe_customer_id VARCHAR(255) NOT NULL UNIQUE,
+    stripe_subscription_id VARCHAR(255) NOT NULL DEFAULT '',
+    plan VARCHAR(50) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL DEFAULT 'incomplete',
+    current_period_end TIMESTAMP WITH TIME ZONE,
+    -- Creation time of the newest Stripe event applied (events can arrive out of order)
+    last_event_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT 'epoch',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
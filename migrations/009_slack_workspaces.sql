@@ -0,0 +1,18 @@
+-- Migration: Slack slash-command integration
+-- One row per Slack workspace (team) that installed the /shorten command.
+-- The signing secret has to be stored as-is: it is the HMAC key used to
+-- verify every request Slack sends for this workspace.
+
+CREATE TABLE IF NOT EXISTS slack_workspaces (
+    team_id VARCHAR(32) PRIMARY KEY,
+    team_name VARCHAR(255) NOT NULL DEFAULT '',
+    signing_secret VARCHAR(255) NOT NULL,
+    -- Principal that owns links created from this workspace (quotas, ownership)
+    owner VARCHAR(255) NOT NULL,
+    -- Optional custom domain for links created from Slack
+    domain VARCHAR(255) NOT NULL DEFAULT '',
+    -- Post replies to the channel instead of only to the caller
+    in_channel BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+-- Migration: URL versions
+-- Every update bumps the version; it backs the ETag of PUT /api/v1/urls/{alias}
+-- so concurrent updates can be detected (optimistic concurrency control).
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
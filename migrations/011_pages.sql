@@ -0,0 +1,29 @@
+-- Migration: link-in-bio pages
+-- A page is a public profile at /@{handle} with an ordered list of links
+-- (blocks). Blocks live in their own table so each one can count its
+-- click-throughs and keep that count when the page is edited or reordered.
+
+CREATE TABLE IF NOT EXISTS pages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    handle VARCHAR(30) NOT NULL UNIQUE,
+    owner VARCHAR(255) NOT NULL,
+    title VARCHAR(100) NOT NULL DEFAULT '',
+    bio VARCHAR(500) NOT NULL DEFAULT '',
+    -- Colors and button shape ({"background": "#fff", ...})
+    theme JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_pages_owner ON pages(owner);
+
+CREATE TABLE IF NOT EXISTS page_blocks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    page_id UUID NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    title VARCHAR(80) NOT NULL,
+    url TEXT NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_page_blocks_page ON page_blocks(page_id, position);
@@ -0,0 +1,9 @@
+-- Migration: destination page metadata
+-- Title, description and favicon of the page a link points to, fetched in
+-- the background after creation. All NULL until the fetch has run
+-- (meta_fetched_at is set even when the page had no usable metadata).
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS meta_title TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS meta_description TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS meta_favicon_url TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS meta_fetched_at TIMESTAMP WITH TIME ZONE;
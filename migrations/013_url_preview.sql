@@ -0,0 +1,7 @@
+-- Migration: social preview cards
+-- Owner-set og:/twitter: values served to link preview bots instead of the
+-- destination's own tags. All NULL when the link has no preview card.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS preview_title TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS preview_description TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS preview_image_url TEXT;
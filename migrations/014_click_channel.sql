@@ -0,0 +1,5 @@
+-- Migration: referrer channel of click events
+-- search, social, email, direct, internal or referral (see internal/referrer).
+-- NULL for clicks recorded before this column existed.
+
+ALTER TABLE url_clicks ADD COLUMN IF NOT EXISTS channel TEXT;
@@ -0,0 +1,8 @@
+-- Migration: parsed User-Agent of click events
+-- Browser family, OS family and device type (see internal/useragent).
+-- NULL for clicks recorded before these columns existed; fill them with
+--   go run ./cmd/backfill-useragents
+
+ALTER TABLE url_clicks ADD COLUMN IF NOT EXISTS browser TEXT;
+ALTER TABLE url_clicks ADD COLUMN IF NOT EXISTS os TEXT;
+ALTER TABLE url_clicks ADD COLUMN IF NOT EXISTS device_type TEXT;
@@ -0,0 +1,6 @@
+-- Migration: language-based destinations
+-- Maps lowercase language tags to destination overrides, e.g.
+-- {"fr": "https://example.com/fr"}. NULL when the link has none - every
+-- visitor then goes to original_url.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS language_targets JSONB;
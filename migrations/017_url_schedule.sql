@@ -0,0 +1,7 @@
+-- Migration: scheduled destinations
+-- Time-of-day / day-of-week routing rules as JSON, e.g.
+-- {"timezone": "Europe/Berlin", "rules": [{"days": ["mon"], "start": "09:00",
+-- "end": "17:00", "url": "https://example.com/chat"}]}
+-- NULL when the link has no schedule.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS schedule JSONB;
@@ -0,0 +1,14 @@
+-- Migration: link templates
+-- Named sets of link settings (lifetime, click limit, domain, language
+-- targets, schedule, preview card) so campaign links are created the same
+-- way every time. The settings are one JSON document, like a page theme.
+
+CREATE TABLE IF NOT EXISTS link_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner VARCHAR(255) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    settings JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (owner, name)
+);
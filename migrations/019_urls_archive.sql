@@ -0,0 +1,19 @@
+-- Migration: Archive tier for cold links
+-- Links without clicks for months move from urls to urls_archive, keeping the
+-- hot table (and its indexes) small. Visiting an archived link moves it back.
+
+-- Same columns as urls, plus when the link was archived
+-- NOTE: columns added to urls later must be added here too - rows are
+-- moved with the same column list in both directions
+CREATE TABLE IF NOT EXISTS urls_archive (LIKE urls INCLUDING DEFAULTS);
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- Only the indexes rehydration, uniqueness checks, exports and erasure need
+CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_archive_id ON urls_archive(id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_archive_short_code ON urls_archive(short_code);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_archive_custom_alias ON urls_archive(custom_alias) WHERE custom_alias IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_urls_archive_owner_created ON urls_archive(created_by, created_at, id);
+
+-- Clicks stay in url_clicks while their link is archived, so they can no
+-- longer cascade from urls. Purge and erasure delete clicks explicitly.
+ALTER TABLE url_clicks DROP CONSTRAINT IF EXISTS url_clicks_url_id_fkey;
@@ -0,0 +1,6 @@
+-- Migration: deployment region of click events
+-- In a multi-region setup every region serves redirects and records which
+-- region it is (REGION). NULL for clicks recorded before this column existed
+-- or by deployments without a region.
+
+ALTER TABLE url_clicks ADD COLUMN IF NOT EXISTS region TEXT;
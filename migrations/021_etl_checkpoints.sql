@@ -0,0 +1,9 @@
+-- Migration: checkpoints of the data warehouse export
+-- One row per exported source (a database shard); last_click_id is the
+-- highest url_clicks.id already shipped to object storage.
+
+CREATE TABLE IF NOT EXISTS etl_checkpoints (
+    name TEXT PRIMARY KEY,
+    last_click_id BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,14 @@
+-- Migration: Feature flags
+-- Flags flipped at runtime through the admin API (see internal/featureflags).
+-- Known flags that have no row yet use their built-in default.
+
+CREATE TABLE IF NOT EXISTS feature_flags (
+    name VARCHAR(64) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    -- State for every workspace without an override
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Per-workspace overrides: {"<workspace>": true|false}
+    workspaces JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
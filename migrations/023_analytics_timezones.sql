@@ -0,0 +1,23 @@
+-- Migration: Timezone-aware analytics
+-- Click timeseries are bucketed in the viewer's timezone with
+-- date_trunc(..., clicked_at AT TIME ZONE '<tz>'), which needs clicked_at to
+-- be an absolute point in time (TIMESTAMPTZ), not a wall clock reading.
+
+-- Existing values were written in the server's local time. The app and the
+-- database run in UTC in every environment we ship (Docker images default
+-- to UTC), so they are read as UTC. With the session in UTC, PostgreSQL 12+
+-- converts the column without rewriting the table.
+-- If your servers ran in another timezone, change 'UTC' below to it.
+SET timezone = 'UTC';
+ALTER TABLE url_clicks ALTER COLUMN clicked_at TYPE TIMESTAMP WITH TIME ZONE;
+RESET timezone;
+
+-- Per-workspace defaults. A workspace is the principal that owns links
+-- (urls.created_by); analytics use its timezone unless ?tz= overrides it.
+CREATE TABLE IF NOT EXISTS workspace_settings (
+    workspace VARCHAR(255) PRIMARY KEY,
+    -- IANA name, e.g. Europe/Berlin ('' = server default)
+    timezone VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,26 @@
+-- Migration: hourly click rollups for the top links leaderboard
+-- Ranking a workspace's links over 30 days from url_clicks means reading
+-- every click of every link. The rollup keeps one row per link, hour and
+-- visitor instead: repeat clicks collapse into a counter, and a visitor
+-- stays countable for unique visitors (the rows of an hour can't be summed
+-- into "uniques of the week" without knowing WHO they were).
+--
+-- Written in the same statement as the click (see clickRepository.Create).
+-- No foreign key, like url_clicks (see migration 019): purge and erasure
+-- delete rollups explicitly.
+
+CREATE TABLE IF NOT EXISTS url_click_rollups (
+    url_id UUID NOT NULL,
+    hour TIMESTAMP WITH TIME ZONE NOT NULL,
+    visitor TEXT NOT NULL,          -- IP address; '' when unknown (not counted as a visitor)
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (url_id, hour, visitor)
+);
+
+-- The leaderboard looks back at most 30 days: backfill those
+INSERT INTO url_click_rollups (url_id, hour, visitor, clicks)
+SELECT url_id, date_trunc('hour', clicked_at, 'UTC'), COALESCE(host(ip_address), ''), COUNT(*)
+FROM url_clicks
+WHERE clicked_at >= NOW() - INTERVAL '30 days'
+GROUP BY 1, 2, 3
+ON CONFLICT (url_id, hour, visitor) DO NOTHING;
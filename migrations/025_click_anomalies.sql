@@ -0,0 +1,20 @@
+-- Migration: click anomalies (spikes)
+-- The anomaly detector compares each link's clicks this hour with its
+-- hourly rollups of the week before (see service.AnomalyService). Every
+-- unusual hour is recorded once - the primary key makes sure owners get one
+-- alert per spike, even with several instances running the detector.
+
+CREATE TABLE IF NOT EXISTS url_anomalies (
+    url_id UUID NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    hour TIMESTAMP WITH TIME ZONE NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    clicks BIGINT NOT NULL,
+    mean DOUBLE PRECISION NOT NULL,     -- Average clicks per hour in the baseline
+    stddev DOUBLE PRECISION NOT NULL,
+    score DOUBLE PRECISION NOT NULL,    -- Standard deviations above the mean
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (url_id, hour)
+);
+
+-- The busiest links of an hour are found by hour, not by link
+CREATE INDEX IF NOT EXISTS idx_url_click_rollups_hour ON url_click_rollups(hour);
@@ -0,0 +1,20 @@
+-- Migration: destination domain rules
+-- Allow/deny rules for the hosts links may point to (see domain.DomainPolicy).
+-- workspace '' holds the global rules set by admins; any other value is the
+-- owner (created_by) the rule applies to. Rules are few and read as a whole,
+-- so every instance keeps them in memory.
+
+CREATE TABLE IF NOT EXISTS domain_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace VARCHAR(255) NOT NULL DEFAULT '',
+    pattern VARCHAR(500) NOT NULL,
+    kind VARCHAR(16) NOT NULL,          -- domain, wildcard, tld or regex
+    action VARCHAR(8) NOT NULL,         -- allow or deny
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (workspace, pattern, action)
+);
+
+-- The policy sweeper walks the active links in id order
+CREATE INDEX IF NOT EXISTS idx_urls_active_id ON urls(id) WHERE is_active = true;
@@ -0,0 +1,7 @@
+-- Migration: signed links
+-- Links with a signing secret only redirect with a valid ?sig= made with it
+-- (see domain.WithSigning). NULL = an ordinary link.
+-- Added to urls_archive too: the archive moves rows with the same column list.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS signing_secret TEXT;
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS signing_secret TEXT;
@@ -0,0 +1,10 @@
+-- Migration: single-use links
+-- A single-use link stops redirecting once used_at is set. The redirect
+-- sets it with a conditional UPDATE (... WHERE used_at IS NULL), so only one
+-- visitor ever gets through (see domain.WithSingleUse).
+-- Added to urls_archive too: the archive moves rows with the same column list.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS single_use BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS used_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS single_use BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS used_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,31 @@
+-- Migration: burn-after-reading links and the audit log
+-- A burn-after-reading link reveals its payload once: the reveal reads and
+-- clears payload in one statement and sets burned_at (see
+-- domain.WithBurnAfterReading). payload is ENCRYPTED by the application -
+-- this column never holds plaintext.
+-- Added to urls_archive too: the archive moves rows with the same column list.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS burn_after_reading BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS payload TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS burned_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS burn_after_reading BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS payload TEXT;
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS burned_at TIMESTAMP WITH TIME ZONE;
+
+-- Audit log: what happened to a link, for its owner to review
+-- No foreign key to urls: entries outlive deleted and archived links, and
+-- are removed with the owner's data (see service.ErasureService)
+CREATE TABLE IF NOT EXISTS audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    action VARCHAR(64) NOT NULL,        -- e.g. link.revealed
+    workspace VARCHAR(255) NOT NULL,    -- Owner of the link
+    url_id UUID NOT NULL,
+    short_code VARCHAR(255) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_url ON audit_log(url_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_workspace ON audit_log(workspace);
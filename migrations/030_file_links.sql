@@ -0,0 +1,10 @@
+-- Migration: file links
+-- A file link serves an uploaded file instead of redirecting. The file
+-- itself is in object storage (see internal/storage/blob); the link keeps
+-- where, e.g. {"key": "files/3f2a...", "name": "report.pdf",
+-- "content_type": "application/pdf", "size": 48213}.
+-- NULL for links that redirect.
+-- Added to urls_archive too: the archive moves rows with the same column list.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS file JSONB;
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS file JSONB;
@@ -0,0 +1,10 @@
+-- Migration: paste links
+-- A paste link shows a text or code snippet instead of redirecting, e.g.
+-- {"content": "package main\n...", "language": "go"}. Snippets are small
+-- (128 KiB at most), so they live in the row like a burn-after-reading
+-- payload; files go to object storage instead (see 030).
+-- NULL for links that redirect.
+-- Added to urls_archive too: the archive moves rows with the same column list.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS paste JSONB;
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS paste JSONB;
@@ -0,0 +1,32 @@
+-- Migration: Background jobs
+-- A generic queue for long operations (see internal/jobs). Workers claim
+-- due jobs with FOR UPDATE SKIP LOCKED, so any number of instances can
+-- share the queue without running a job twice.
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id UUID PRIMARY KEY,
+    kind VARCHAR(64) NOT NULL,
+    payload JSONB,
+
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 3,
+    result JSONB,
+    error TEXT,
+
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    -- Not claimed before this time: retries wait here with backoff
+    run_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    -- Heartbeat of the worker; a running job with an old heartbeat is taken
+    -- over by another worker (handlers are safe to run twice)
+    heartbeat_at TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+-- Workers look for due jobs, oldest first
+CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs(run_at) WHERE status = 'pending';
+
+-- ... and for running jobs whose worker died
+CREATE INDEX IF NOT EXISTS idx_jobs_running ON jobs(heartbeat_at) WHERE status = 'running';
@@ -0,0 +1,7 @@
+-- Migration: per-link analytics switch
+-- Links created with "analytics": false keep counting clicks, but no rows
+-- are written to clicks for them (see domain.WithoutAnalytics).
+-- Added to urls_archive too: the archive moves rows with the same column list.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS analytics_disabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS analytics_disabled BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,85 @@
+-- Migration: edge snapshot versions
+-- Edge workers keep a copy of every link and ask for the changes since the
+-- version they have (GET /api/v1/edge/snapshot?since=). Every change to a
+-- link that matters for redirecting takes the next number from a single
+-- counter row, and deleted rows leave a tombstone with their own number.
+--
+-- WHY A COUNTER ROW INSTEAD OF A SEQUENCE?
+-- Sequence numbers are handed out when a statement runs, not when it
+-- commits: number 11 can become visible before number 10, and a client that
+-- already moved past 11 would never see 10. The counter row stays locked
+-- until the writing transaction commits, so numbers become visible in order.
+-- Clicks don't touch it (the trigger ignores the clicks column).
+
+CREATE TABLE IF NOT EXISTS url_change_counter (
+    id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+    version BIGINT NOT NULL DEFAULT 0,
+    -- Tombstones up to this version were pruned: older deltas can't be served
+    pruned_through BIGINT NOT NULL DEFAULT 0
+);
+INSERT INTO url_change_counter (id) VALUES (true) ON CONFLICT DO NOTHING;
+
+-- Existing links start at 0, so they are part of every full snapshot
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS change_seq BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_urls_change_seq ON urls(change_seq);
+
+CREATE TABLE IF NOT EXISTS url_tombstones (
+    short_code VARCHAR(50) NOT NULL,
+    custom_alias VARCHAR(50),
+    domain VARCHAR(255) NOT NULL DEFAULT '',
+    change_seq BIGINT PRIMARY KEY,
+    removed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION next_url_change() RETURNS BIGINT AS $$
+    UPDATE url_change_counter SET version = version + 1 RETURNING version;
+$$ LANGUAGE sql;
+
+-- A link that moves to another alias or domain leaves its old codes behind:
+-- a tombstone for the old ones comes first, then the link under its new ones
+CREATE OR REPLACE FUNCTION stamp_url_change() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (OLD.custom_alias IS DISTINCT FROM NEW.custom_alias
+                          OR OLD.domain IS DISTINCT FROM NEW.domain) THEN
+        INSERT INTO url_tombstones (short_code, custom_alias, domain, change_seq)
+        VALUES (OLD.short_code, OLD.custom_alias, OLD.domain, next_url_change());
+    END IF;
+    NEW.change_seq := next_url_change();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_url_tombstone() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO url_tombstones (short_code, custom_alias, domain, change_seq)
+    VALUES (OLD.short_code, OLD.custom_alias, OLD.domain, next_url_change());
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS urls_change_insert ON urls;
+CREATE TRIGGER urls_change_insert BEFORE INSERT ON urls
+    FOR EACH ROW EXECUTE FUNCTION stamp_url_change();
+
+-- Only columns that change how (or whether) a link redirects
+DROP TRIGGER IF EXISTS urls_change_update ON urls;
+CREATE TRIGGER urls_change_update BEFORE UPDATE ON urls
+    FOR EACH ROW
+    WHEN (OLD.original_url IS DISTINCT FROM NEW.original_url
+       OR OLD.custom_alias IS DISTINCT FROM NEW.custom_alias
+       OR OLD.expires_at IS DISTINCT FROM NEW.expires_at
+       OR OLD.is_active IS DISTINCT FROM NEW.is_active
+       OR OLD.max_clicks IS DISTINCT FROM NEW.max_clicks
+       OR OLD.domain IS DISTINCT FROM NEW.domain
+       OR OLD.language_targets IS DISTINCT FROM NEW.language_targets
+       OR OLD.schedule IS DISTINCT FROM NEW.schedule
+       OR OLD.signing_secret IS DISTINCT FROM NEW.signing_secret
+       OR OLD.preview_title IS DISTINCT FROM NEW.preview_title
+       OR OLD.used_at IS DISTINCT FROM NEW.used_at
+       OR OLD.burned_at IS DISTINCT FROM NEW.burned_at)
+    EXECUTE FUNCTION stamp_url_change();
+
+-- Purged and archived links leave the table: the edge must forget them too
+DROP TRIGGER IF EXISTS urls_change_delete ON urls;
+CREATE TRIGGER urls_change_delete AFTER DELETE ON urls
+    FOR EACH ROW EXECUTE FUNCTION record_url_tombstone();
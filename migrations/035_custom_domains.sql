@@ -0,0 +1,19 @@
+-- Migration: custom domains
+-- Hosts customers serve their short links on. A domain is only served once
+-- its owner proved control of its DNS with a TXT record (see
+-- domain.CustomDomain). Domains are few and read as a whole, so every
+-- instance keeps their status in memory.
+
+CREATE TABLE IF NOT EXISTS custom_domains (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    host VARCHAR(255) UNIQUE NOT NULL,
+    owner VARCHAR(255) NOT NULL,
+    token VARCHAR(64) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending', -- pending, verified or failed
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    verified_at TIMESTAMP WITH TIME ZONE,
+    checked_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_custom_domains_owner ON custom_domains(owner, host);
@@ -0,0 +1,15 @@
+-- Migration: automatic TLS certificates
+-- State of the ACME client shared by every replica (see
+-- service.CertificateService): the account key, issued certificates and
+-- pending HTTP-01 tokens, stored under their autocert cache keys. A replica
+-- that didn't issue a certificate serves it from here instead of asking the
+-- CA again.
+
+CREATE TABLE IF NOT EXISTS tls_certificates (
+    name VARCHAR(255) PRIMARY KEY,          -- Cache key: a host for certificates
+    data BYTEA,                             -- NULL while only a failure is known
+    not_after TIMESTAMP WITH TIME ZONE,     -- Expiry of the certificate in data
+    issued_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT NOT NULL DEFAULT '',    -- Why the last issuance failed ("" after a success)
+    failed_at TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,27 @@
+-- Migration: full-text search over links
+-- search_vector is computed by Postgres on every insert and update, so the
+-- index can never drift from the link (see postgres.searchRepository).
+-- Weights rank matches on the alias above the title, the title above the
+-- destination, and the description last. The 'simple' configuration skips
+-- stemming: links are in every language, and aliases aren't words.
+-- Punctuation of the destination becomes spaces so "example.com/summer-sale"
+-- is found by "example", "summer" and "sale".
+-- Added to urls_archive too: archived links are searchable like the export.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple'::regconfig, coalesce(custom_alias, '') || ' ' || short_code), 'A') ||
+    setweight(to_tsvector('simple'::regconfig, coalesce(meta_title, '') || ' ' || coalesce(preview_title, '')), 'B') ||
+    setweight(to_tsvector('simple'::regconfig, regexp_replace(original_url, '[^[:alnum:]]+', ' ', 'g')), 'C') ||
+    setweight(to_tsvector('simple'::regconfig, coalesce(meta_description, '') || ' ' || coalesce(preview_description, '')), 'D')
+) STORED;
+
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple'::regconfig, coalesce(custom_alias, '') || ' ' || short_code), 'A') ||
+    setweight(to_tsvector('simple'::regconfig, coalesce(meta_title, '') || ' ' || coalesce(preview_title, '')), 'B') ||
+    setweight(to_tsvector('simple'::regconfig, regexp_replace(original_url, '[^[:alnum:]]+', ' ', 'g')), 'C') ||
+    setweight(to_tsvector('simple'::regconfig, coalesce(meta_description, '') || ' ' || coalesce(preview_description, '')), 'D')
+) STORED;
+
+-- GIN: one entry per word, pointing at every link containing it
+CREATE INDEX IF NOT EXISTS idx_urls_search ON urls USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_urls_archive_search ON urls_archive USING GIN (search_vector);
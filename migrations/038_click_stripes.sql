@@ -0,0 +1,20 @@
+-- Migration: striped click counters
+-- A viral link gets thousands of clicks per second. "UPDATE urls SET
+-- clicks = clicks + 1" makes every one of them wait for the lock on the
+-- same row. With CLICK_COUNTER_STRIPES > 1, a click adds 1 to one of N rows
+-- here instead (picked at random), so N clicks can be counted at once.
+--
+-- The real counter is urls.clicks + the link's stripes. The click fold
+-- worker moves the stripes into urls.clicks (and deletes them) every
+-- CLICK_FOLD_INTERVAL, so this table only holds recent clicks.
+--
+-- No foreign key: a link archived or purged before its stripes are folded
+-- must not take the rows with it (the fold updates urls_archive too, and
+-- drops the stripes of links that no longer exist).
+
+CREATE TABLE IF NOT EXISTS url_click_stripes (
+    url_id UUID NOT NULL,
+    stripe SMALLINT NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (url_id, stripe)
+);
@@ -0,0 +1,37 @@
+-- Migration: short codes that change case
+-- cmd/lowercase-codes lowercases the codes of links created before
+-- SHORT_CODE_CASE=insensitive. Until now a short code never changed, so the
+-- edge snapshot triggers (see 034) only watched the alias and domain: edge
+-- workers would keep serving the old code and never learn the new one.
+-- A changed short code now leaves a tombstone and stamps the link, like an alias.
+
+CREATE OR REPLACE FUNCTION stamp_url_change() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (OLD.short_code IS DISTINCT FROM NEW.short_code
+                          OR OLD.custom_alias IS DISTINCT FROM NEW.custom_alias
+                          OR OLD.domain IS DISTINCT FROM NEW.domain) THEN
+        INSERT INTO url_tombstones (short_code, custom_alias, domain, change_seq)
+        VALUES (OLD.short_code, OLD.custom_alias, OLD.domain, next_url_change());
+    END IF;
+    NEW.change_seq := next_url_change();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS urls_change_update ON urls;
+CREATE TRIGGER urls_change_update BEFORE UPDATE ON urls
+    FOR EACH ROW
+    WHEN (OLD.original_url IS DISTINCT FROM NEW.original_url
+       OR OLD.short_code IS DISTINCT FROM NEW.short_code
+       OR OLD.custom_alias IS DISTINCT FROM NEW.custom_alias
+       OR OLD.expires_at IS DISTINCT FROM NEW.expires_at
+       OR OLD.is_active IS DISTINCT FROM NEW.is_active
+       OR OLD.max_clicks IS DISTINCT FROM NEW.max_clicks
+       OR OLD.domain IS DISTINCT FROM NEW.domain
+       OR OLD.language_targets IS DISTINCT FROM NEW.language_targets
+       OR OLD.schedule IS DISTINCT FROM NEW.schedule
+       OR OLD.signing_secret IS DISTINCT FROM NEW.signing_secret
+       OR OLD.preview_title IS DISTINCT FROM NEW.preview_title
+       OR OLD.used_at IS DISTINCT FROM NEW.used_at
+       OR OLD.burned_at IS DISTINCT FROM NEW.burned_at)
+    EXECUTE FUNCTION stamp_url_change();
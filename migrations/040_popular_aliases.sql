@@ -0,0 +1,7 @@
+-- Migration: popular aliases
+-- Every instance reloads the most clicked aliases every few minutes, to
+-- reject new aliases that imitate them (see domain.ConfusableIndex). This
+-- index keeps that a short index scan instead of sorting every link.
+
+CREATE INDEX IF NOT EXISTS idx_urls_popular_aliases ON urls(clicks DESC)
+    WHERE custom_alias IS NOT NULL AND is_active = true;
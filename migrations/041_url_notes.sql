@@ -0,0 +1,11 @@
+-- Migration: notes on links
+-- A free-form description and a small key/value object ("custom metadata",
+-- e.g. {"ticket": "MKT-142"}) for owners and integrations. The application
+-- enforces the limits (see URL.ValidateNotes).
+-- Added to urls_archive too: the archive moves rows with the same column list.
+-- Not watched by the edge snapshot triggers: notes don't change redirects.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS custom_metadata JSONB;
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS custom_metadata JSONB;
@@ -0,0 +1,17 @@
+-- Migration: link approvals
+-- Workspaces can require that contributors' links are approved before they
+-- redirect. A pending link is stored inactive (is_active = false), so the
+-- redirect, the edge snapshot and the caches skip it without knowing about
+-- approvals; approving activates it, which the edge triggers (034) pick up.
+-- Added to urls_archive too: the archive moves rows with the same column list.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS approval_status TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS submitted_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS approval_status TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS submitted_by TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE workspace_settings ADD COLUMN IF NOT EXISTS require_approval BOOLEAN NOT NULL DEFAULT false;
+
+-- The approvals queue: a workspace's pending links, oldest first
+CREATE INDEX IF NOT EXISTS idx_urls_pending_approval ON urls(created_by, created_at)
+    WHERE approval_status = 'pending';
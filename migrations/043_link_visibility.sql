@@ -0,0 +1,7 @@
+-- Migration: link visibility
+-- 'sensitive' links show workspace viewers their stats but not where they
+-- point. '' is the standard visibility: every member sees everything.
+-- Added to urls_archive too: the archive moves rows with the same column list.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT '';
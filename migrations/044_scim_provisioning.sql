@@ -0,0 +1,54 @@
+-- Migration: SCIM user provisioning
+-- Identity providers (Okta, Entra ID, ...) create, update and remove the
+-- members of a workspace and their groups over SCIM 2.0. A member's role
+-- comes from their groups: workspace_settings.group_roles maps group names
+-- to roles, so renaming a group in the IdP never silently grants anything.
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace VARCHAR(255) NOT NULL,
+    -- The person's login, usually their email; what Principal.Member holds
+    user_name VARCHAR(255) NOT NULL,
+    external_id VARCHAR(255) NOT NULL DEFAULT '',
+    display_name VARCHAR(255) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    -- false = deprovisioned: kept (the IdP may reactivate them) but locked out
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- SCIM user names are case-insensitive
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspace_members_user_name
+    ON workspace_members(workspace, lower(user_name));
+
+CREATE TABLE IF NOT EXISTS workspace_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace VARCHAR(255) NOT NULL,
+    display_name VARCHAR(255) NOT NULL,
+    external_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspace_groups_display_name
+    ON workspace_groups(workspace, lower(display_name));
+
+CREATE TABLE IF NOT EXISTS workspace_group_members (
+    group_id UUID NOT NULL REFERENCES workspace_groups(id) ON DELETE CASCADE,
+    member_id UUID NOT NULL REFERENCES workspace_members(id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, member_id)
+);
+
+-- The groups of a member (the primary key covers the other direction)
+CREATE INDEX IF NOT EXISTS idx_workspace_group_members_member ON workspace_group_members(member_id);
+
+-- One SCIM token per workspace. Only its SHA-256 is stored: unlike the Slack
+-- signing secret we never need the token itself, only to recognize it.
+CREATE TABLE IF NOT EXISTS scim_tokens (
+    workspace VARCHAR(255) PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE workspace_settings ADD COLUMN IF NOT EXISTS group_roles JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,39 @@
+-- Migration: SAML single sign-on
+-- Each workspace can connect one SAML identity provider. People signing in
+-- through it get a short-lived session token that authenticates like an API
+-- key, with the role their IdP groups map to (workspace_settings.group_roles).
+
+CREATE TABLE IF NOT EXISTS saml_connections (
+    workspace VARCHAR(255) PRIMARY KEY,
+    idp_entity_id VARCHAR(1024) NOT NULL,
+    sso_url VARCHAR(2048) NOT NULL,
+    -- PEM; assertions must be signed by this certificate's key
+    certificate TEXT NOT NULL,
+    -- Assertion attribute whose values are looked up in group_roles ('' = none)
+    role_attribute VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Only the SHA-256 of a session token is stored, like SCIM tokens
+CREATE TABLE IF NOT EXISTS sso_sessions (
+    token_hash CHAR(64) PRIMARY KEY,
+    workspace VARCHAR(255) NOT NULL,
+    member VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sso_sessions_expires_at ON sso_sessions(expires_at);
+
+-- IDs of assertions already used, kept until they expire: a captured
+-- SAMLResponse can't be posted a second time
+CREATE TABLE IF NOT EXISTS saml_assertions (
+    workspace VARCHAR(255) NOT NULL,
+    id VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (workspace, id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_saml_assertions_expires_at ON saml_assertions(expires_at);
@@ -0,0 +1,28 @@
+-- Migration: scoped API keys
+-- Workspaces create keys for their scripts and integrations. Each key has
+-- scopes (urls:read, urls:write, stats:read, admin) that limit which routes
+-- it can call, so automation can run with least privilege.
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace VARCHAR(255) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    -- Start of the secret ("sk_Ab3dE9xY"), shown to tell keys apart
+    prefix VARCHAR(16) NOT NULL,
+    -- Only the SHA-256 of the secret is stored, like SCIM tokens
+    token_hash CHAR(64) NOT NULL,
+    scopes JSONB NOT NULL DEFAULT '[]',
+    -- Created by an operator (the admin key): admin-scoped keys may then
+    -- use operator endpoints too
+    admin BOOLEAN NOT NULL DEFAULT false,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Written at most once a minute per key, not on every request
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    rotated_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Every authenticated request looks its key up by hash
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_token_hash ON api_keys(token_hash);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_workspace ON api_keys(workspace, created_at);
@@ -0,0 +1,17 @@
+-- Migration: API key expiry and grace-period rotation
+-- Keys can have an expiry date. Rotating a key keeps its previous secret
+-- working until previous_expires_at, so clients can switch over without
+-- downtime. An hourly sweep deletes expired keys and forgets lapsed
+-- previous secrets.
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS previous_token_hash CHAR(64);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS previous_expires_at TIMESTAMP WITH TIME ZONE;
+
+-- Requests made with a previous secret look it up by hash too
+CREATE INDEX IF NOT EXISTS idx_api_keys_previous_token_hash ON api_keys(previous_token_hash)
+    WHERE previous_token_hash IS NOT NULL;
+
+-- For the sweep
+CREATE INDEX IF NOT EXISTS idx_api_keys_expires_at ON api_keys(expires_at)
+    WHERE expires_at IS NOT NULL;
@@ -0,0 +1,14 @@
+-- Migration: encrypted destinations
+-- With DESTINATION_ENCRYPTION_KEYS set, sensitive links keep their
+-- destination encrypted in sealed_destination and original_url is ''.
+-- Added to urls_archive too: the archive moves rows with the same column list.
+-- The partial index serves the re-encryption job, which only looks at
+-- sensitive and encrypted links.
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS sealed_destination TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls_archive ADD COLUMN IF NOT EXISTS sealed_destination TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_urls_sealable
+    ON urls (id) WHERE sealed_destination <> '' OR visibility = 'sensitive';
+CREATE INDEX IF NOT EXISTS idx_urls_archive_sealable
+    ON urls_archive (id) WHERE sealed_destination <> '' OR visibility = 'sensitive';
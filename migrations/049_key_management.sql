@@ -0,0 +1,12 @@
+-- Migration: key management for secret material
+-- API key secrets can be hashed with a pepper (HMAC-SHA256 with
+-- API_KEY_PEPPER). hash_version names the pepper of token_hash, so keys
+-- hashed before a pepper was added or rotated are found and re-hashed the
+-- next time they are used. '' = plain SHA-256, as before.
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS hash_version VARCHAR(16) NOT NULL DEFAULT '';
+
+-- Slack signing secrets may now be stored encrypted by the key manager,
+-- with the key version in front (kv1:<version>:<ciphertext>): longer than
+-- the secret itself
+ALTER TABLE slack_workspaces ALTER COLUMN signing_secret TYPE TEXT;
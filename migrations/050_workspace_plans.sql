@@ -0,0 +1,8 @@
+-- Migration: workspace plans
+-- Operators can put a workspace on a quota plan without Stripe
+-- (PUT /api/v1/workspaces/{workspace}/plan). '' = QUOTA_DEFAULT_PLAN.
+-- The plan is read together with the workspace's credentials (API keys,
+-- SSO sessions, SCIM members), so every request of the workspace carries it.
+-- A paid subscription still wins over it.
+
+ALTER TABLE workspace_settings ADD COLUMN IF NOT EXISTS plan TEXT NOT NULL DEFAULT '';
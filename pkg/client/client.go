@@ -0,0 +1,258 @@
+// Package client is a Go client for the URL shortener API
+//
+// Other services import it instead of building requests and parsing
+// response envelopes by hand:
+//
+//	c := client.New("https://sho.rt", os.Getenv("SHORTENER_API_KEY"))
+//	link, err := c.Create(ctx, client.CreateRequest{URL: "https://example.com"})
+//	if errors.Is(err, client.ErrConflict) {
+//		// The custom alias is taken
+//	}
+//
+// WHY NOT ONLY A GENERATED CLIENT?
+// A client generated from /api/openapi.json knows the shapes of requests
+// and responses, but not how to behave: it gives up on the first 503 and
+// keeps sending requests after the rate limit is used up. This package
+// retries with backoff and slows down BEFORE the server starts rejecting.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout    = 10 * time.Second
+	defaultMaxRetries = 3
+	defaultBaseDelay  = 200 * time.Millisecond
+	defaultMaxDelay   = 10 * time.Second
+	userAgent         = "url-shortener-go-client/1"
+)
+
+// Client talks to one URL shortener server with one API key
+// It is safe for concurrent use; share one per server
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+
+	limits *rateLimitState
+	sleep  func(ctx context.Context, d time.Duration) error // Swapped in tests
+}
+
+// New creates a client for the server at baseURL (e.g. "https://sho.rt")
+// apiKey may be empty: links are then created anonymously
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		maxRetries: defaultMaxRetries,
+		baseDelay:  defaultBaseDelay,
+		maxDelay:   defaultMaxDelay,
+		limits:     newRateLimitState(time.Now),
+		sleep:      sleepContext,
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client (10s timeout)
+func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	c.httpClient = httpClient
+	return c
+}
+
+// WithRetries sets how often a failed request is retried (0 = never) and
+// the backoff between attempts: baseDelay doubled per attempt, up to maxDelay
+func (c *Client) WithRetries(maxRetries int, baseDelay, maxDelay time.Duration) *Client {
+	c.maxRetries = maxRetries
+	c.baseDelay = baseDelay
+	c.maxDelay = maxDelay
+	return c
+}
+
+// envelope is the response body of both API versions
+// v1 sends error as a string (with code next to it), v2 as an object
+type envelope struct {
+	Data    json.RawMessage   `json:"data"`
+	Error   json.RawMessage   `json:"error"`
+	Code    string            `json:"code"`
+	Details map[string]string `json:"details"`
+	Meta    struct {
+		RequestID string `json:"request_id"`
+	} `json:"meta"`
+}
+
+// do sends a request and decodes the envelope's data into out (if not nil)
+//
+// HOW A REQUEST IS SENT:
+// 1. Wait if the rate limit is used up (see rateLimitState)
+// 2. Send; remember the X-RateLimit-* headers of the response
+// 3. On a retryable failure, back off and go to 1 (see retryable)
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.sleep(ctx, c.limits.reserve()); err != nil {
+			return err
+		}
+
+		resp, err := c.send(ctx, method, path, payload)
+		if err != nil {
+			// The context ending is the caller's decision, not a failure to retry
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if attempt < c.maxRetries && idempotent(method) {
+				if err := c.sleep(ctx, c.backoff(attempt)); err != nil {
+					return err
+				}
+				continue
+			}
+			return fmt.Errorf("%s %s: %w", method, path, err)
+		}
+
+		c.limits.update(resp.StatusCode, resp.Header)
+		env, readErr := readEnvelope(resp)
+
+		if resp.StatusCode < 300 {
+			if readErr != nil {
+				return fmt.Errorf("%s %s: decode response: %w", method, path, readErr)
+			}
+			if out != nil && len(env.Data) > 0 {
+				if err := json.Unmarshal(env.Data, out); err != nil {
+					return fmt.Errorf("%s %s: decode response: %w", method, path, err)
+				}
+			}
+			return nil
+		}
+
+		if attempt < c.maxRetries && retryable(method, resp.StatusCode) {
+			delay := c.backoff(attempt)
+			if wait := retryAfter(resp.Header); wait > delay {
+				delay = wait
+			}
+			if err := c.sleep(ctx, delay); err != nil {
+				return err
+			}
+			continue
+		}
+
+		return newAPIError(resp.StatusCode, env)
+	}
+}
+
+// send makes one HTTP request
+func (c *Client) send(ctx context.Context, method, path string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// readEnvelope reads and closes the response body
+// A body that isn't an envelope (e.g. a proxy's HTML error page) gives an
+// empty envelope and an error
+func readEnvelope(resp *http.Response) (envelope, error) {
+	defer resp.Body.Close()
+
+	var env envelope
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return env, err
+	}
+	if len(raw) == 0 {
+		return env, nil
+	}
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return envelope{}, err
+	}
+	return env, nil
+}
+
+// idempotent reports whether sending the request twice is harmless
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryable reports whether a failed request may be sent again
+//
+// WHY NOT RETRY EVERY 5XX?
+// A 502 or 504 may come AFTER the server created the link; sending the
+// POST again would create a second one. 429 is safe for every method: the
+// rate limiter rejects the request before any handler runs.
+func retryable(method string, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent(method)
+	}
+	return false
+}
+
+// backoff is the wait before retry number attempt+1: exponential with jitter
+// WHY JITTER? Clients that failed together would otherwise retry together
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.baseDelay << attempt
+	if delay <= 0 || delay > c.maxDelay {
+		delay = c.maxDelay // Also catches the shift overflowing
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// sleepContext waits for d, or until ctx ends
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,417 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSleep records the waits instead of waiting, and moves a fake clock
+// forward by them (the client's rate limit state reads that clock)
+type fakeSleep struct {
+	mu     sync.Mutex
+	now    time.Time
+	delays []time.Duration
+}
+
+func (f *fakeSleep) sleep(ctx context.Context, d time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if d > 0 {
+		f.delays = append(f.delays, d)
+		f.now = f.now.Add(d)
+	}
+	return ctx.Err()
+}
+
+func (f *fakeSleep) clock() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// newTestClient points a client at server and records its waits
+func newTestClient(server *httptest.Server, apiKey string) (*Client, *fakeSleep) {
+	sleeper := &fakeSleep{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	c := New(server.URL+"/", apiKey).WithHTTPClient(server.Client())
+	c.sleep = sleeper.sleep
+	c.limits = newRateLimitState(sleeper.clock)
+	return c, sleeper
+}
+
+// respond writes a JSON body with the given status
+func respond(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(body))
+}
+
+func TestClient_Create(t *testing.T) {
+	// Arrange
+	var gotMethod, gotPath, gotAuth string
+	var gotBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath, gotAuth = r.Method, r.URL.Path, r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		respond(w, http.StatusCreated, `{"data": {"id": "42", "short_code": "abc123", "short_url": "http://sho.rt/abc123",
+			"original_url": "https://example.com", "clicks": 0, "created_at": "2026-03-01T12:00:00Z",
+			"expires_at": "2026-03-02T12:00:00Z", "signed": true, "signing_secret": "s3cret"}}`)
+	}))
+	defer server.Close()
+	c, _ := newTestClient(server, "key-1")
+
+	// Act
+	link, err := c.Create(context.Background(), CreateRequest{
+		URL:         "https://example.com",
+		CustomAlias: "abc123",
+		ExpiresIn:   24 * time.Hour,
+		Signed:      true,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPost, gotMethod)
+	assert.Equal(t, "/api/v2/urls", gotPath)
+	assert.Equal(t, "Bearer key-1", gotAuth)
+	assert.Equal(t, map[string]interface{}{
+		"url":          "https://example.com",
+		"custom_alias": "abc123",
+		"expires_in":   "24h0m0s",
+		"signed":       true,
+	}, gotBody)
+
+	assert.Equal(t, "42", link.ID)
+	assert.Equal(t, "http://sho.rt/abc123", link.ShortURL)
+	assert.Equal(t, "s3cret", link.SigningSecret)
+	require.NotNil(t, link.ExpiresAt)
+	assert.Equal(t, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC), link.ExpiresAt.UTC())
+}
+
+func TestClient_Create_AnonymousSendsNoAuthorization(t *testing.T) {
+	// Arrange
+	var hasAuth bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasAuth = r.Header["Authorization"]
+		respond(w, http.StatusCreated, `{"data": {"id": "1", "short_code": "abc123"}}`)
+	}))
+	defer server.Close()
+	c, _ := newTestClient(server, "")
+
+	// Act
+	_, err := c.Create(context.Background(), CreateRequest{URL: "https://example.com"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, hasAuth)
+}
+
+func TestClient_GetAndStats(t *testing.T) {
+	// Arrange
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		respond(w, http.StatusOK, `{"data": {"link": {"id": "42", "short_code": "a/b", "clicks": 7},
+			"recent_clicks": [{"clicked_at": "2026-03-01T12:05:00Z", "country_code": "US", "channel": "social"}]}}`)
+	}))
+	defer server.Close()
+	c, _ := newTestClient(server, "key-1")
+
+	// Act
+	stats, statsErr := c.Stats(context.Background(), "a/b")
+	link, getErr := c.Get(context.Background(), "a/b")
+
+	// Assert
+	require.NoError(t, statsErr)
+	require.NoError(t, getErr)
+	assert.Equal(t, "/api/v2/urls/a%2Fb/stats", gotPath)
+	assert.Equal(t, int64(7), stats.Link.Clicks)
+	require.Len(t, stats.RecentClicks, 1)
+	assert.Equal(t, "US", stats.RecentClicks[0].CountryCode)
+	assert.Equal(t, "42", link.ID)
+}
+
+func TestClient_Delete(t *testing.T) {
+	// Arrange
+	var gotMethod, gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		respond(w, http.StatusOK, `{"data": {"id": "42"}, "message": "URL deleted"}`)
+	}))
+	defer server.Close()
+	c, _ := newTestClient(server, "key-1")
+
+	// Act
+	err := c.Delete(context.Background(), "42")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodDelete, gotMethod)
+	assert.Equal(t, "/api/v1/urls/42", gotPath)
+}
+
+func TestClient_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantIs      error
+		wantCode    string
+		wantMessage string
+		wantRequest string
+		wantDetails map[string]string
+	}{
+		{
+			name:        "v1 error",
+			status:      http.StatusBadRequest,
+			body:        `{"error": "URL is required", "code": "validation_failed", "details": {"url": "URL is required"}, "meta": {"request_id": "req-1"}}`,
+			wantIs:      ErrBadRequest,
+			wantCode:    "validation_failed",
+			wantMessage: "URL is required",
+			wantRequest: "req-1",
+			wantDetails: map[string]string{"url": "URL is required"},
+		},
+		{
+			name:        "v2 error",
+			status:      http.StatusNotFound,
+			body:        `{"error": {"code": "not_found", "message": "URL not found"}, "meta": {"request_id": "req-2"}}`,
+			wantIs:      ErrNotFound,
+			wantCode:    "not_found",
+			wantMessage: "URL not found",
+			wantRequest: "req-2",
+		},
+		{
+			name:        "not an envelope",
+			status:      http.StatusForbidden,
+			body:        `<html>Forbidden</html>`,
+			wantIs:      ErrForbidden,
+			wantMessage: "Forbidden",
+		},
+		{
+			name:        "conflict",
+			status:      http.StatusConflict,
+			body:        `{"error": {"code": "alias_taken", "message": "custom alias is already taken"}}`,
+			wantIs:      ErrConflict,
+			wantCode:    "alias_taken",
+			wantMessage: "custom alias is already taken",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				respond(w, tt.status, tt.body)
+			}))
+			defer server.Close()
+			c, _ := newTestClient(server, "key-1")
+
+			// Act
+			_, err := c.Create(context.Background(), CreateRequest{URL: "https://example.com"})
+
+			// Assert
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tt.wantIs)
+
+			var apiErr *APIError
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tt.status, apiErr.StatusCode)
+			assert.Equal(t, tt.wantCode, apiErr.Code)
+			assert.Equal(t, tt.wantMessage, apiErr.Message)
+			assert.Equal(t, tt.wantRequest, apiErr.RequestID)
+			assert.Equal(t, tt.wantDetails, apiErr.Details)
+		})
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int // One per attempt; the last one repeats
+		retryAfter   string
+		wantAttempts int
+		wantStatus   int           // Of the returned *APIError; 0 = success
+		wantMinDelay time.Duration // Of the first wait
+	}{
+		{
+			name:         "GET retries 503 until it succeeds",
+			method:       http.MethodGet,
+			statuses:     []int{503, 502, 200},
+			wantAttempts: 3,
+		},
+		{
+			name:         "GET gives up after the retries",
+			method:       http.MethodGet,
+			statuses:     []int{504},
+			wantAttempts: 3, // 1 + 2 retries
+			wantStatus:   http.StatusGatewayTimeout,
+		},
+		{
+			name:         "POST is not retried on 503",
+			method:       http.MethodPost,
+			statuses:     []int{503, 201},
+			wantAttempts: 1,
+			wantStatus:   http.StatusServiceUnavailable,
+		},
+		{
+			name:         "POST is retried on 429 after Retry-After",
+			method:       http.MethodPost,
+			statuses:     []int{429, 201},
+			retryAfter:   "3",
+			wantAttempts: 2,
+			wantMinDelay: 3 * time.Second,
+		},
+		{
+			name:         "4xx is not retried",
+			method:       http.MethodGet,
+			statuses:     []int{404},
+			wantAttempts: 1,
+			wantStatus:   http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var mu sync.Mutex
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				status := tt.statuses[min(attempts, len(tt.statuses)-1)]
+				attempts++
+				mu.Unlock()
+
+				if status == http.StatusTooManyRequests && tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				if status < 300 {
+					respond(w, status, `{"data": {"id": "1", "link": {"id": "1"}}}`)
+					return
+				}
+				respond(w, status, `{"error": "failed"}`)
+			}))
+			defer server.Close()
+			c, sleeper := newTestClient(server, "key-1")
+			c.WithRetries(2, 100*time.Millisecond, time.Second)
+
+			// Act
+			var err error
+			if tt.method == http.MethodPost {
+				_, err = c.Create(context.Background(), CreateRequest{URL: "https://example.com"})
+			} else {
+				_, err = c.Stats(context.Background(), "abc123")
+			}
+
+			// Assert
+			assert.Equal(t, tt.wantAttempts, attempts)
+			assert.Len(t, sleeper.delays, tt.wantAttempts-1)
+			if tt.wantStatus == 0 {
+				assert.NoError(t, err)
+			} else {
+				var apiErr *APIError
+				require.True(t, errors.As(err, &apiErr))
+				assert.Equal(t, tt.wantStatus, apiErr.StatusCode)
+			}
+			if tt.wantMinDelay > 0 {
+				assert.GreaterOrEqual(t, sleeper.delays[0], tt.wantMinDelay)
+			}
+		})
+	}
+}
+
+func TestClient_RetriesDroppedConnectionsOnlyWhenIdempotent(t *testing.T) {
+	// Arrange
+	var mu sync.Mutex
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		first := attempts == 1
+		mu.Unlock()
+
+		if first {
+			// Drop the connection without answering
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			_ = conn.Close()
+			return
+		}
+		respond(w, http.StatusOK, `{"data": {"link": {"id": "42"}}}`)
+	}))
+	defer server.Close()
+	c, _ := newTestClient(server, "key-1")
+
+	// Act
+	link, getErr := c.Get(context.Background(), "abc123")
+
+	mu.Lock()
+	attempts = 0
+	mu.Unlock()
+	_, createErr := c.Create(context.Background(), CreateRequest{URL: "https://example.com"})
+
+	// Assert
+	require.NoError(t, getErr)
+	assert.Equal(t, "42", link.ID)
+	assert.Error(t, createErr)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestClient_ThrottlesWhenTheLimitIsUsedUp(t *testing.T) {
+	// Arrange
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(30 * time.Second)
+	var mu sync.Mutex
+	remaining := 2
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		remaining--
+		left := max(remaining, 0)
+		mu.Unlock()
+
+		w.Header().Set("X-RateLimit-Limit", "2")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(left))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		respond(w, http.StatusOK, `{"data": {"link": {"id": "42"}}}`)
+	}))
+	defer server.Close()
+	c, sleeper := newTestClient(server, "key-1")
+
+	// Act - the first response says one request is left; the second uses it
+	for i := 0; i < 3; i++ {
+		_, err := c.Get(context.Background(), "abc123")
+		require.NoError(t, err)
+	}
+
+	// Assert - only the third request had to wait for the reset
+	assert.Equal(t, []time.Duration{30 * time.Second}, sleeper.delays)
+}
+
+func TestClient_ContextCancellationStopsRetries(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		respond(w, http.StatusTooManyRequests, `{"error": "slow down"}`)
+	}))
+	defer server.Close()
+	c := New(server.URL, "key-1").WithHTTPClient(server.Client())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Act
+	start := time.Now()
+	_, err := c.Get(ctx, "abc123")
+
+	// Assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
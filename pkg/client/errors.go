@@ -0,0 +1,87 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Errors to check with errors.Is; every *APIError matches one by status
+var (
+	ErrBadRequest   = errors.New("invalid request")
+	ErrUnauthorized = errors.New("missing or unknown API key")
+	ErrForbidden    = errors.New("not allowed")
+	ErrNotFound     = errors.New("link not found")
+	ErrConflict     = errors.New("custom alias already taken")
+	ErrGone         = errors.New("link is gone")
+	ErrRateLimited  = errors.New("rate limit or quota exceeded")
+)
+
+// APIError is an error response of the server
+type APIError struct {
+	StatusCode int
+	Code       string            // e.g. "validation_failed" (not every error has one)
+	Message    string            // Human-readable, may be translated
+	Details    map[string]string // Problem per field (validation_failed only)
+	RequestID  string            // Quote it when reporting a problem
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("url-shortener: %d %s", e.StatusCode, e.Message)
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// Is makes errors.Is(err, ErrNotFound) and friends work
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrGone:
+		return e.StatusCode == http.StatusGone
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// newAPIError builds the error from a response envelope
+func newAPIError(status int, env envelope) *APIError {
+	apiErr := &APIError{
+		StatusCode: status,
+		Code:       env.Code,
+		Details:    env.Details,
+		RequestID:  env.Meta.RequestID,
+	}
+
+	// v1: "error": "message"; v2: "error": {"code", "message", "details"}
+	var message string
+	var v2 struct {
+		Code    string            `json:"code"`
+		Message string            `json:"message"`
+		Details map[string]string `json:"details"`
+	}
+	if json.Unmarshal(env.Error, &message) == nil {
+		apiErr.Message = message
+	} else if json.Unmarshal(env.Error, &v2) == nil {
+		apiErr.Code = v2.Code
+		apiErr.Message = v2.Message
+		apiErr.Details = v2.Details
+	}
+
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(status)
+	}
+	return apiErr
+}
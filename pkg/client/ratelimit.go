@@ -0,0 +1,98 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimitState follows the server's rate limit through response headers
+//
+// HOW IT WORKS:
+// Every response says how many requests are left (X-RateLimit-Remaining)
+// and when the window resets (X-RateLimit-Reset, Unix seconds). Each
+// request takes one of the remaining requests; when none are left, the
+// next request waits for the reset instead of collecting a 429.
+// Until the first response, nothing is known and nothing waits.
+type rateLimitState struct {
+	mu        sync.Mutex
+	known     bool
+	remaining int
+	reset     time.Time
+	now       func() time.Time
+}
+
+func newRateLimitState(now func() time.Time) *rateLimitState {
+	return &rateLimitState{now: now}
+}
+
+// reserve takes one request from the limit and returns how long to wait
+// before sending it (0 = send now)
+func (s *rateLimitState) reserve() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.known {
+		return 0
+	}
+
+	now := s.now()
+	if !now.Before(s.reset) {
+		// A new window: the next response tells us its size
+		s.known = false
+		return 0
+	}
+	if s.remaining > 0 {
+		s.remaining--
+		return 0
+	}
+	return s.reset.Sub(now)
+}
+
+// update remembers the limit a response reported
+// A 429 also blocks requests for its Retry-After
+func (s *rateLimitState) update(status int, header http.Header) {
+	remaining, remainingErr := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	reset, resetErr := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if remainingErr == nil && resetErr == nil {
+		s.known = true
+		s.remaining = remaining
+		s.reset = time.Unix(reset, 0)
+	}
+
+	if status == http.StatusTooManyRequests {
+		if wait := retryAfter(header); wait > 0 {
+			until := s.now().Add(wait)
+			if !s.known || until.After(s.reset) {
+				s.reset = until
+			}
+			s.known = true
+			s.remaining = 0
+		}
+	}
+}
+
+// retryAfter reads the Retry-After header (seconds or an HTTP date)
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
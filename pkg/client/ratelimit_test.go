@@ -0,0 +1,112 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitState(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	resetIn := func(d time.Duration) string { return strconv.FormatInt(now.Add(d).Unix(), 10) }
+
+	tests := []struct {
+		name      string
+		status    int
+		header    map[string]string
+		wantWaits []time.Duration // Of consecutive reserve calls
+	}{
+		{
+			name:      "nothing known yet",
+			wantWaits: []time.Duration{0, 0, 0},
+		},
+		{
+			name:      "requests left",
+			status:    http.StatusOK,
+			header:    map[string]string{"X-RateLimit-Remaining": "2", "X-RateLimit-Reset": resetIn(time.Minute)},
+			wantWaits: []time.Duration{0, 0, time.Minute},
+		},
+		{
+			name:      "window already reset",
+			status:    http.StatusOK,
+			header:    map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": resetIn(-time.Second)},
+			wantWaits: []time.Duration{0, 0},
+		},
+		{
+			name:      "429 blocks for Retry-After",
+			status:    http.StatusTooManyRequests,
+			header:    map[string]string{"Retry-After": "5"},
+			wantWaits: []time.Duration{5 * time.Second},
+		},
+		{
+			name:   "429 keeps a later reset",
+			status: http.StatusTooManyRequests,
+			header: map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     resetIn(20 * time.Second),
+				"Retry-After":           "5",
+			},
+			wantWaits: []time.Duration{20 * time.Second},
+		},
+		{
+			name:      "unparsable headers are ignored",
+			status:    http.StatusOK,
+			header:    map[string]string{"X-RateLimit-Remaining": "lots", "X-RateLimit-Reset": resetIn(time.Minute)},
+			wantWaits: []time.Duration{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			state := newRateLimitState(func() time.Time { return now })
+			header := http.Header{}
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+
+			// Act
+			if tt.status != 0 {
+				state.update(tt.status, header)
+			}
+			var waits []time.Duration
+			for range tt.wantWaits {
+				waits = append(waits, state.reserve())
+			}
+
+			// Assert
+			assert.Equal(t, tt.wantWaits, waits)
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "missing", value: "", want: 0},
+		{name: "seconds", value: "30", want: 30 * time.Second},
+		{name: "negative", value: "-1", want: 0},
+		{name: "past date", value: "Sun, 01 Mar 2020 12:00:00 GMT", want: 0},
+		{name: "garbage", value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			header := http.Header{}
+			header.Set("Retry-After", tt.value)
+
+			// Act
+			got := retryAfter(header)
+
+			// Assert
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
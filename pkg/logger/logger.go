@@ -4,6 +4,8 @@ import (
 	"context"
 	"log/slog"
 	"os"
+
+	"url-shortener/internal/ctxutil"
 )
 
 // Logger wraps slog for structured logging
@@ -40,14 +42,14 @@ func New(level string) *Logger {
 	return &Logger{Logger: logger}
 }
 
-// WithContext adds context values to the logger
-// This is useful for adding request IDs, user IDs, etc.
+// WithContext adds the request metadata of ctx to the logger
+// (request ID, client IP, trace ID and principal, whichever are set)
 func (l *Logger) WithContext(ctx context.Context) *Logger {
-	// Extract request ID from context if available
-	if requestID, ok := ctx.Value("request_id").(string); ok {
-		return &Logger{Logger: l.With("request_id", requestID)}
+	attrs := ctxutil.MetadataFrom(ctx).LogAttrs()
+	if len(attrs) == 0 {
+		return l
 	}
-	return l
+	return &Logger{Logger: l.With(attrs...)}
 }
 
 // WithFields adds additional fields to the logger
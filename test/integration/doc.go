@@ -0,0 +1,8 @@
+// Package integration runs the whole stack (HTTP -> service -> PostgreSQL/Redis)
+// against real databases started with testcontainers-go.
+//
+// The tests are behind the "integration" build tag because they need Docker
+// and take much longer than the unit tests:
+//
+//	go test -tags integration ./test/integration/...
+package integration
@@ -0,0 +1,437 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"url-shortener/internal/auth"
+	urlcache "url-shortener/internal/cache"
+	"url-shortener/internal/domain"
+	httpHandler "url-shortener/internal/handler/http"
+	"url-shortener/internal/repository/postgres"
+	redisrepo "url-shortener/internal/repository/redis"
+	"url-shortener/internal/service"
+	"url-shortener/pkg/logger"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// adminKey is the stack's ADMIN_API_KEY
+const adminKey = "integration-admin-key"
+
+// stack is a running server backed by real PostgreSQL and Redis containers
+type stack struct {
+	server *httptest.Server
+	db     *pgxpool.Pool
+	cache  *redisrepo.Cache
+}
+
+// startStack starts both containers, applies the migrations and wires the
+// same layers as cmd/server. Containers are removed when the test ends.
+func startStack(t *testing.T) *stack {
+	t.Helper()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	pgContainer, err := tcpostgres.Run(ctx, "postgres:16-alpine",
+		tcpostgres.WithDatabase("urlshortener"),
+		tcpostgres.WithUsername("urlshortener"),
+		tcpostgres.WithPassword("test"),
+		// Postgres restarts once after initdb, so wait for the SECOND "ready" line
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(time.Minute),
+		),
+	)
+	testcontainers.CleanupContainer(t, pgContainer)
+	require.NoError(t, err)
+
+	redisContainer, err := tcredis.Run(ctx, "redis:7-alpine")
+	testcontainers.CleanupContainer(t, redisContainer)
+	require.NoError(t, err)
+
+	dsn, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+	db, err := postgres.InitDB(ctx, dsn, 10, 2, time.Minute)
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+	applyMigrations(t, db)
+
+	redisAddr, err := redisContainer.Endpoint(ctx, "")
+	require.NoError(t, err)
+	redisClient, err := redisrepo.InitRedis(redisAddr, "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { redisClient.Close() })
+
+	// Same layering as cmd/server/main.go
+	cache := redisrepo.NewCache(redisClient, time.Hour)
+	urlService := service.NewURLService(
+		urlcache.NewCachedURLRepository(postgres.NewURLRepository(db), cache),
+		postgres.NewClickRepository(db),
+	).WithAliasLocks(redisrepo.NewLocker(redisClient))
+
+	// Callers without a key are anonymous, like in cmd/server
+	apiKeyService := service.NewAPIKeyService(postgres.NewAPIKeyRepository(db))
+	authenticator := auth.NewChainAuthenticator(auth.NewStaticKeyAuthenticator(adminKey), apiKeyService)
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(httpHandler.AuthMiddleware(authenticator)(mux))
+	t.Cleanup(server.Close)
+
+	handler := httpHandler.NewHandler(urlService, logger.New("error").Logger, server.URL)
+	apiV1 := httpHandler.NewAPIVersion(mux, "v1", httpHandler.VersionLifecycle{})
+	apiV1.HandleFunc("/urls", handler.CreateURL)
+	apiV1.HandleFunc("/urls/", handler.GetURLStats)
+	apiKeyHandler := httpHandler.NewAPIKeyHandler(apiKeyService, logger.New("error").Logger)
+	apiV1.HandleFunc("POST /keys", httpHandler.RequireAuth(apiKeyHandler.CreateKey))
+	mux.HandleFunc("/", handler.ServeUI)
+
+	return &stack{server: server, db: db, cache: cache}
+}
+
+// applyMigrations runs migrations/*.sql in order, like `make migrate-up`
+func applyMigrations(t *testing.T, db *pgxpool.Pool) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join("..", "..", "migrations", "*.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files, "no migrations found")
+	sort.Strings(files)
+
+	for _, file := range files {
+		sql, err := os.ReadFile(file)
+		require.NoError(t, err)
+		// Exec without arguments uses the simple protocol, which allows
+		// several statements per call
+		_, err = db.Exec(context.Background(), string(sql))
+		require.NoError(t, err, "migration %s failed", filepath.Base(file))
+	}
+}
+
+// client does not follow redirects, so we can assert on the 302 itself
+var client = &http.Client{
+	Timeout: 10 * time.Second,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// createURL posts body to /api/v1/urls and returns the decoded "data" object
+func (s *stack) createURL(t *testing.T, body string) map[string]interface{} {
+	t.Helper()
+	return s.post(t, "", "/api/v1/urls", body)
+}
+
+// post sends body with the API key token ("" = anonymous), expects 201 and
+// returns the decoded "data" object
+func (s *stack) post(t *testing.T, token, path, body string) map[string]interface{} {
+	t.Helper()
+
+	resp := s.do(t, token, http.MethodPost, path, body)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode, readBody(t, resp))
+
+	var envelope struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&envelope))
+	return envelope.Data
+}
+
+// do sends a request with the API key token ("" = anonymous)
+func (s *stack) do(t *testing.T, token, method, path, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, s.server.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+// stats returns the "data" object of GET /api/v1/urls/{code}/stats
+func (s *stack) stats(t *testing.T, code string) map[string]interface{} {
+	t.Helper()
+
+	resp, err := client.Get(s.server.URL + "/api/v1/urls/" + code + "/stats")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, readBody(t, resp))
+
+	var envelope struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&envelope))
+	return envelope.Data
+}
+
+// redirect requests /{code} and returns the response (body already closed)
+func (s *stack) redirect(t *testing.T, code string) *http.Response {
+	t.Helper()
+
+	resp, err := client.Get(s.server.URL + "/" + code)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestFullStack(t *testing.T) {
+	s := startStack(t)
+
+	t.Run("create, redirect and count clicks", func(t *testing.T) {
+		created := s.createURL(t, `{"url": "https://example.com/integration"}`)
+		code := created["short_code"].(string)
+		require.NotEmpty(t, code)
+
+		// First redirect comes from the database, the second from Redis
+		for i := 0; i < 2; i++ {
+			resp := s.redirect(t, code)
+			require.Equal(t, http.StatusFound, resp.StatusCode)
+			require.Equal(t, "https://example.com/integration", resp.Header.Get("Location"))
+		}
+
+		// Clicks are recorded in a background goroutine
+		require.Eventually(t, func() bool {
+			return s.stats(t, code)["clicks"] == float64(2)
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+
+	t.Run("custom alias redirects", func(t *testing.T) {
+		s.createURL(t, `{"url": "https://example.com/alias", "custom_alias": "integration-alias"}`)
+
+		resp := s.redirect(t, "integration-alias")
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		require.Equal(t, "https://example.com/alias", resp.Header.Get("Location"))
+	})
+
+	t.Run("concurrent requests for one alias: one wins, the rest get 409", func(t *testing.T) {
+		const requests = 10
+		statuses := make(chan int, requests)
+
+		var wg sync.WaitGroup
+		for i := 0; i < requests; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				body := `{"url": "https://example.com/race", "custom_alias": "integration-race"}`
+				resp, err := client.Post(s.server.URL+"/api/v1/urls", "application/json", strings.NewReader(body))
+				if err != nil {
+					statuses <- 0
+					return
+				}
+				resp.Body.Close()
+				statuses <- resp.StatusCode
+			}()
+		}
+		wg.Wait()
+		close(statuses)
+
+		counts := map[int]int{}
+		for status := range statuses {
+			counts[status]++
+		}
+		require.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusConflict: requests - 1}, counts)
+	})
+
+	t.Run("unknown code returns 404", func(t *testing.T) {
+		resp := s.redirect(t, "doesnotexist")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("expired link returns 410", func(t *testing.T) {
+		created := s.createURL(t, `{"url": "https://example.com/expiring", "expires_in_hours": 1}`)
+		code := created["short_code"].(string)
+		require.Equal(t, http.StatusFound, s.redirect(t, code).StatusCode)
+
+		// Move the expiration into the past instead of waiting an hour,
+		// then drop the cached copy so the next lookup reads the database
+		ctx := context.Background()
+		_, err := s.db.Exec(ctx, `UPDATE urls SET expires_at = NOW() - INTERVAL '1 minute' WHERE short_code = $1`, code)
+		require.NoError(t, err)
+		require.NoError(t, s.cache.DeleteURL(ctx, code))
+
+		require.Equal(t, http.StatusGone, s.redirect(t, code).StatusCode)
+	})
+
+	t.Run("click limit returns 410 once used up", func(t *testing.T) {
+		created := s.createURL(t, `{"url": "https://example.com/once", "max_clicks": 1}`)
+		code := created["short_code"].(string)
+		require.Equal(t, http.StatusFound, s.redirect(t, code).StatusCode)
+
+		// The limit is enforced after the background click is recorded
+		require.Eventually(t, func() bool {
+			return s.redirect(t, code).StatusCode == http.StatusGone
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+
+	t.Run("striped click counters add up before and after folding", func(t *testing.T) {
+		created := s.createURL(t, `{"url": "https://example.com/viral"}`)
+		code := created["short_code"].(string)
+		ctx := context.Background()
+		repo := postgres.NewStripedURLRepository(s.db, 8)
+
+		// Concurrent clicks on one link, like a viral burst
+		const clicks = 200
+		var wg sync.WaitGroup
+		errs := make(chan error, clicks)
+		for i := 0; i < clicks; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- repo.IncrementClicks(ctx, code)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		// Lookups add the stripes to the counter...
+		url, err := repo.GetByShortCode(ctx, code)
+		require.NoError(t, err)
+		require.Equal(t, int64(clicks), url.Clicks)
+
+		// ...folding moves them into urls.clicks without changing the total
+		folded, err := service.NewClickFoldService(postgres.NewClickStripeRepository(s.db)).Fold(ctx)
+		require.NoError(t, err)
+		require.LessOrEqual(t, folded, 8)
+
+		var stored, pending int64
+		require.NoError(t, s.db.QueryRow(ctx, `
+			SELECT clicks, (SELECT COUNT(*) FROM url_click_stripes WHERE url_id = urls.id)
+			FROM urls WHERE short_code = $1`, code).Scan(&stored, &pending))
+		require.Equal(t, int64(clicks), stored)
+		require.Zero(t, pending)
+
+		url, err = repo.GetByShortCode(ctx, code)
+		require.NoError(t, err)
+		require.Equal(t, int64(clicks), url.Clicks)
+
+		// Unknown links are still reported, not counted
+		require.Error(t, repo.IncrementClicks(ctx, "doesnotexist"))
+	})
+	t.Run("workspaces only see their own links", func(t *testing.T) {
+		// The operator starts two workspaces, each with an owner key
+		acme := s.post(t, adminKey, "/api/v1/keys", `{"name": "owner", "scopes": ["admin"], "workspace": "acme"}`)
+		globex := s.post(t, adminKey, "/api/v1/keys", `{"name": "owner", "scopes": ["admin"], "workspace": "globex"}`)
+		require.Equal(t, "acme", acme["workspace"])
+		acmeKey, globexKey := acme["token"].(string), globex["token"].(string)
+
+		created := s.post(t, acmeKey, "/api/v1/urls", `{"url": "https://example.com/acme-only"}`)
+		code := created["short_code"].(string)
+		stats := "/api/v1/urls/" + code + "/stats"
+
+		for _, tt := range []struct {
+			token  string
+			status int
+		}{
+			{token: acmeKey, status: http.StatusOK},
+			{token: adminKey, status: http.StatusOK},
+			{token: globexKey, status: http.StatusForbidden},
+			{token: "", status: http.StatusForbidden},
+		} {
+			resp := s.do(t, tt.token, http.MethodGet, stats, "")
+			require.Equal(t, tt.status, resp.StatusCode, readBody(t, resp))
+			resp.Body.Close()
+		}
+
+		// A workspace owner can't hand itself a key for someone else's workspace
+		resp := s.do(t, acmeKey, http.MethodPost, "/api/v1/keys", `{"name": "sneaky", "scopes": ["admin"], "workspace": "globex"}`)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp.Body.Close()
+	})
+
+	t.Run("a pro workspace's keys are not limited", func(t *testing.T) {
+		ctx := context.Background()
+		created := s.post(t, adminKey, "/api/v1/keys", `{"name": "ci", "scopes": ["urls:write"], "workspace": "initech"}`)
+		token := created["token"].(string)
+
+		// The operator puts the workspace on pro; the key carries it from its next request
+		require.NoError(t, postgres.NewWorkspaceSettingsRepository(s.db).SetPlan(ctx, "initech", domain.PlanPro))
+		principal, err := service.NewAPIKeyService(postgres.NewAPIKeyRepository(s.db)).Authenticate(ctx, token)
+		require.NoError(t, err)
+		require.Equal(t, domain.PlanPro, principal.Plan)
+
+		plans := map[string]domain.Plan{
+			domain.PlanFree: {Name: domain.PlanFree, MonthlyLinks: 1},
+			domain.PlanPro:  {Name: domain.PlanPro},
+		}
+		quotas, err := service.NewQuotaService(postgres.NewUsageRepository(s.db), plans, domain.PlanFree)
+		require.NoError(t, err)
+		ctx = auth.WithPrincipal(ctx, principal)
+		for i := 0; i < 3; i++ {
+			_, err := quotas.Reserve(ctx)
+			require.NoError(t, err, "link %d", i+1)
+		}
+	})
+
+	t.Run("click events are stored with and without an IP", func(t *testing.T) {
+		created := s.createURL(t, `{"url": "https://example.com/clicks"}`)
+		code := created["short_code"].(string)
+		ctx := context.Background()
+		var urlID string
+		require.NoError(t, s.db.QueryRow(ctx, `SELECT id FROM urls WHERE short_code = $1`, code).Scan(&urlID))
+
+		// A redirect stores the client IP, without the connection's port
+		require.Equal(t, http.StatusFound, s.redirect(t, code).StatusCode)
+		require.Eventually(t, func() bool {
+			var events int
+			err := s.db.QueryRow(ctx, `
+				SELECT COUNT(*) FROM url_clicks
+				WHERE url_id = $1 AND host(ip_address) = '127.0.0.1'`, urlID).Scan(&events)
+			return err == nil && events == 1
+		}, 5*time.Second, 50*time.Millisecond)
+
+		// A visitor who opted out (DNT/GPC) has no IP: the event and its
+		// rollup are kept, with NULL and the anonymous visitor
+		repo := postgres.NewClickRepository(s.db)
+		click := domain.NewURLClick(urlID, "", "", "")
+		click.Channel = "direct"
+		click.WithDevice("Firefox", "Linux", "desktop")
+		require.NoError(t, repo.Create(ctx, click))
+		require.NotZero(t, click.ID)
+
+		var channel string
+		var rollup int
+		require.NoError(t, s.db.QueryRow(ctx, `
+			SELECT channel, (SELECT clicks FROM url_click_rollups WHERE url_id = $1 AND visitor = '')
+			FROM url_clicks WHERE id = $2 AND ip_address IS NULL`, urlID, click.ID).Scan(&channel, &rollup))
+		require.Equal(t, "direct", channel)
+		require.Equal(t, 1, rollup)
+
+		clicks, err := repo.GetByURLID(ctx, urlID, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, clicks, 2)
+		ips := []string{clicks[0].IPAddress, clicks[1].IPAddress}
+		require.ElementsMatch(t, []string{"", "127.0.0.1"}, ips)
+	})
+}
@@ -38,6 +38,14 @@ shortenForm.addEventListener('submit', async (e) => {
             requestBody.expires_in_hours = parseInt(expiresIn);
         }
 
+        // The CAPTCHA widget (when the server has one enabled) puts its
+        // token in a hidden field named by data-captcha-field
+        const captchaField = shortenForm.dataset.captchaField;
+        if (captchaField) {
+            const token = shortenForm.querySelector(`[name="${captchaField}"]`);
+            requestBody.captcha_token = token ? token.value : '';
+        }
+
         // Make API request
         const response = await fetch(`${API_BASE_URL}/api/v1/urls`, {
             method: 'POST',
@@ -62,9 +70,20 @@ shortenForm.addEventListener('submit', async (e) => {
         showToast(error.message || 'Failed to create short URL', 'error');
     } finally {
         hideLoading();
+        resetCaptcha();
     }
 });
 
+// Tokens are single-use: the next link needs a freshly solved CAPTCHA
+function resetCaptcha() {
+    if (window.hcaptcha) {
+        window.hcaptcha.reset();
+    }
+    if (window.turnstile) {
+        window.turnstile.reset();
+    }
+}
+
 // Display result
 function displayResult(data) {
     // Populate result fields